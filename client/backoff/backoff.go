@@ -21,6 +21,8 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -75,7 +77,9 @@ func (b *Backoff) Reset() {
 }
 
 // Retry calls a function until it succeeds or the context is done.
-// It will backoff if the function returns a retryable error.
+// It will backoff if the function returns a retryable error. If the error
+// carries a server-provided retry delay (see RetryDelay) that is longer than
+// the backoff pause, the server delay is used instead.
 // Once the context is done, retries will end and the most recent error will be returned.
 // Backoff is not reset by this function.
 func (b *Backoff) Retry(ctx context.Context, f func() error, retry ...codes.Code) error {
@@ -86,17 +90,40 @@ func (b *Backoff) Retry(ctx context.Context, f func() error, retry ...codes.Code
 
 	// Try calling f while the error is retryable and ctx is not done.
 	for {
-		if err := f(); !IsRetryable(err, retry...) {
+		err := f()
+		if !IsRetryable(err, retry...) {
 			return err
 		}
+		pause := b.Duration()
+		if delay, ok := RetryDelay(err); ok && delay > pause {
+			pause = delay
+		}
 		select {
-		case <-time.After(b.Duration()):
+		case <-time.After(pause):
 		case <-ctx.Done():
 			return ctx.Err()
 		}
 	}
 }
 
+// RetryDelay returns the retry delay suggested by the server in err, if any.
+// Servers suggest delays by attaching an errdetails.RetryInfo to the error
+// status, e.g. when a request is denied due to insufficient quota.
+func RetryDelay(err error) (time.Duration, bool) {
+	for _, d := range status.Convert(err).Details() {
+		info, ok := d.(*errdetails.RetryInfo)
+		if !ok {
+			continue
+		}
+		delay, err := ptypes.Duration(info.GetRetryDelay())
+		if err != nil || delay < 0 {
+			return 0, false
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
 // IsRetryable returns false unless the error is explicitly retriable per
 // https://godoc.org/google.golang.org/grpc/codes,
 // or if the error codes is in retry. codes.OK is not retryable.
@@ -20,10 +20,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/ptypes"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
 	_ "github.com/golang/glog"
+	durpb "github.com/golang/protobuf/ptypes/duration"
 )
 
 func TestBackoff(t *testing.T) {
@@ -195,3 +198,61 @@ func TestRetry(t *testing.T) {
 		}
 	}
 }
+
+func TestRetryDelay(t *testing.T) {
+	withRetryInfo := func(d *durpb.Duration) error {
+		st, err := status.New(codes.ResourceExhausted, "quota exhausted").WithDetails(&errdetails.RetryInfo{RetryDelay: d})
+		if err != nil {
+			t.Fatalf("WithDetails(): %v", err)
+		}
+		return st.Err()
+	}
+
+	for _, test := range []struct {
+		desc   string
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{desc: "nil", err: nil},
+		{desc: "plain error", err: errors.New("error")},
+		{desc: "no details", err: status.Errorf(codes.ResourceExhausted, "quota exhausted")},
+		{desc: "retry info", err: withRetryInfo(ptypes.DurationProto(3 * time.Second)), want: 3 * time.Second, wantOK: true},
+		{desc: "negative delay", err: withRetryInfo(ptypes.DurationProto(-time.Second))},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			got, ok := RetryDelay(test.err)
+			if got != test.want || ok != test.wantOK {
+				t.Errorf("RetryDelay() = (%v, %v), want (%v, %v)", got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryUsesServerDelay(t *testing.T) {
+	b := Backoff{
+		Min:    time.Millisecond,
+		Max:    time.Millisecond,
+		Factor: 2,
+	}
+	delay := 100 * time.Millisecond
+	st, err := status.New(codes.ResourceExhausted, "quota exhausted").WithDetails(&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(delay)})
+	if err != nil {
+		t.Fatalf("WithDetails(): %v", err)
+	}
+
+	var calls int
+	start := time.Now()
+	if err := b.Retry(context.Background(), func() error {
+		calls++
+		if calls == 1 {
+			return st.Err()
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("Retry() = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Retry() took %v, want at least %v", elapsed, delay)
+	}
+}
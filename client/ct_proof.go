@@ -0,0 +1,55 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/trillian"
+)
+
+// CTProofByHash is the JSON response of the get-proof-by-hash method of a
+// Certificate Transparency log, as defined in RFC 6962 section 4.5. Marshaling
+// it with encoding/json encodes the audit path as an array of base64 strings.
+type CTProofByHash struct {
+	// LeafIndex is the 0-based index of the leaf.
+	LeafIndex int64 `json:"leaf_index"`
+	// AuditPath is the audit path of the leaf, from the leaf to the root.
+	AuditPath [][]byte `json:"audit_path"`
+}
+
+// NewCTProofByHash converts the inclusion proof of a GetInclusionProof or
+// GetInclusionProofByHash response to the response of the CT get-proof-by-hash
+// method. Trillian proofs already list the audit path from the leaf up, so the
+// hashes are kept in order.
+func NewCTProofByHash(proof *trillian.Proof) (*CTProofByHash, error) {
+	if proof == nil {
+		return nil, errors.New("no proof")
+	}
+	if proof.LeafIndex < 0 {
+		return nil, fmt.Errorf("proof has negative leaf index %d", proof.LeafIndex)
+	}
+	// An empty audit path, e.g. of a tree with a single leaf, must be encoded
+	// as an empty array rather than null.
+	path := make([][]byte, 0, len(proof.Hashes))
+	for i, hash := range proof.Hashes {
+		if len(hash) == 0 {
+			return nil, fmt.Errorf("proof has empty hash at position %d", i)
+		}
+		path = append(path, hash)
+	}
+	return &CTProofByHash{LeafIndex: proof.LeafIndex, AuditPath: path}, nil
+}
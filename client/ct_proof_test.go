@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/testonly"
+
+	mto "github.com/google/trillian/merkle/testonly"
+)
+
+func TestNewCTProofByHash(t *testing.T) {
+	verifier := merkle.NewLogVerifier(rfc6962.DefaultHasher)
+	leaves := mto.LeafInputs()
+	roots := mto.RootHashes()
+	// The inclusion proofs of the RFC 6962 reference tree used by the tests of
+	// Certificate Transparency implementations.
+	for _, test := range []struct {
+		index    int64
+		treeSize int64
+		hashes   []string
+		want     string
+	}{
+		{
+			index:    0,
+			treeSize: 1,
+			want:     `{"leaf_index":0,"audit_path":[]}`,
+		},
+		{
+			index:    0,
+			treeSize: 8,
+			hashes: []string{
+				"96a296d224f285c67bee93c30f8a309157f0daa35dc5b87e410b78630a09cfc7",
+				"5f083f0a1a33ca076a95279832580db3e0ef4584bdff1f54c8a360f50de3031e",
+				"6b47aaf29ee3c2af9af889bc1fb9254dabd31177f16232dd6aab035ca39bf6e4",
+			},
+			want: `{"leaf_index":0,"audit_path":["lqKW0iTyhcZ77pPDD4owkVfw2qNdxbh+QQt4YwoJz8c=","Xwg/ChozygdqlSeYMlgNs+DvRYS9/x9UyKNg9Q3jAx4=","a0eq8p7jwq+a+Im8H7klTavTEXfxYjLdaqsDXKOb9uQ="]}`,
+		},
+		{
+			index:    5,
+			treeSize: 8,
+			hashes: []string{
+				"bc1a0643b12e4d2d7c77918f44e0f4f79a838b6cf9ec5b5c283e1f4d88599e6b",
+				"ca854ea128ed050b41b35ffc1b87b8eb2bde461e9e3b5596ece6b9d5975a0ae0",
+				"d37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+			},
+			want: `{"leaf_index":5,"audit_path":["vBoGQ7EuTS18d5GPROD095qDi2z57FtcKD4fTYhZnms=","yoVOoSjtBQtBs1/8G4e46yveRh6eO1WW7Oa51ZdaCuA=","037kGJdt2VdTwcc4Yrk5j6Kiz5tP8P3+izDNlSCWFLc="]}`,
+		},
+		{
+			index:    2,
+			treeSize: 3,
+			hashes: []string{
+				"fac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+			},
+			want: `{"leaf_index":2,"audit_path":["+sVCA+fMaWzw38tCySodnbr3CtnmIfS9jZhmLwDjwSU="]}`,
+		},
+	} {
+		proof := &trillian.Proof{LeafIndex: test.index}
+		for _, h := range test.hashes {
+			proof.Hashes = append(proof.Hashes, testonly.MustHexDecode(h))
+		}
+		ct, err := NewCTProofByHash(proof)
+		if err != nil {
+			t.Fatalf("NewCTProofByHash(%d, %d): %v", test.index, test.treeSize, err)
+		}
+		got, err := json.Marshal(ct)
+		if err != nil {
+			t.Fatalf("json.Marshal(): %v", err)
+		}
+		if string(got) != test.want {
+			t.Errorf("NewCTProofByHash(%d, %d) marshals to %s, want %s", test.index, test.treeSize, got, test.want)
+		}
+
+		// The decoded audit path must verify the leaf like a CT client would.
+		var decoded CTProofByHash
+		if err := json.Unmarshal(got, &decoded); err != nil {
+			t.Fatalf("json.Unmarshal(): %v", err)
+		}
+		leafHash := rfc6962.DefaultHasher.HashLeaf(leaves[test.index])
+		if err := verifier.VerifyInclusionProof(decoded.LeafIndex, test.treeSize, decoded.AuditPath, roots[test.treeSize], leafHash); err != nil {
+			t.Errorf("VerifyInclusionProof(%d, %d): %v", decoded.LeafIndex, test.treeSize, err)
+		}
+	}
+}
+
+func TestNewCTProofByHashErrors(t *testing.T) {
+	for _, proof := range []*trillian.Proof{
+		nil,
+		{LeafIndex: -1},
+		{LeafIndex: 1, Hashes: [][]byte{{1}, nil}},
+	} {
+		if _, err := NewCTProofByHash(proof); err == nil {
+			t.Errorf("NewCTProofByHash(%v) succeeded, want error", proof)
+		}
+	}
+}
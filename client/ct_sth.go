@@ -0,0 +1,155 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/certificate-transparency-go/tls"
+	tcrypto "github.com/google/trillian/crypto"
+	"github.com/google/trillian/types"
+)
+
+// CTSignedTreeHead is the JSON response of the get-sth method of a Certificate
+// Transparency log, as defined in RFC 6962 section 4.3. Marshaling it with
+// encoding/json encodes the root hash and signature as base64 strings.
+type CTSignedTreeHead struct {
+	// TreeSize is the number of leaves in the tree.
+	TreeSize uint64 `json:"tree_size"`
+	// Timestamp is the time of the tree head, in milliseconds since the epoch.
+	Timestamp uint64 `json:"timestamp"`
+	// SHA256RootHash is the root hash of the tree.
+	SHA256RootHash []byte `json:"sha256_root_hash"`
+	// TreeHeadSignature is the TLS encoding of the DigitallySigned struct of
+	// RFC 5246 section 4.7 which signs the tree head.
+	TreeHeadSignature []byte `json:"tree_head_signature"`
+}
+
+// ctTreeHeadSignature is the TreeHeadSignature struct of RFC 6962 section 3.5,
+// whose TLS encoding is the input to the signature of a CT tree head.
+type ctTreeHeadSignature struct {
+	Version        uint8
+	SignatureType  uint8
+	Timestamp      uint64
+	TreeSize       uint64
+	SHA256RootHash [sha256.Size]byte
+}
+
+const (
+	// ctV1 is the v1 Version of RFC 6962 section 3.2.
+	ctV1 = 0
+	// ctTreeHashSignatureType is the tree_hash SignatureType of RFC 6962
+	// section 3.2.
+	ctTreeHashSignatureType = 1
+)
+
+// NewCTSignedTreeHead converts a log root to the response of the CT get-sth
+// method. The signature of a Trillian log root covers its own encoding rather
+// than the CT TreeHeadSignature, so the tree head is signed afresh by signer,
+// which must hold the ECDSA or RSA key of the CT log. The timestamp is
+// truncated to milliseconds, and the log must use SHA-256 hashes.
+func NewCTSignedTreeHead(root *types.LogRootV1, signer crypto.Signer) (*CTSignedTreeHead, error) {
+	if root == nil {
+		return nil, errors.New("no log root")
+	}
+	sth := &CTSignedTreeHead{
+		TreeSize:       root.TreeSize,
+		Timestamp:      root.TimestampNanos / uint64(time.Millisecond),
+		SHA256RootHash: root.RootHash,
+	}
+	input, err := ctTreeHeadSignatureInput(sth)
+	if err != nil {
+		return nil, err
+	}
+	alg, err := ctSignatureAlgorithm(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	sig, err := tcrypto.NewSigner(0, signer, crypto.SHA256).Sign(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign tree head: %v", err)
+	}
+	sth.TreeHeadSignature, err = tls.Marshal(tls.DigitallySigned{
+		Algorithm: tls.SignatureAndHashAlgorithm{Hash: tls.SHA256, Signature: alg},
+		Signature: sig,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tree head signature: %v", err)
+	}
+	return sth, nil
+}
+
+// VerifyCTSignedTreeHead checks the signature of a CT tree head, e.g. one
+// returned by NewCTSignedTreeHead, against the public key of the CT log.
+func VerifyCTSignedTreeHead(pub crypto.PublicKey, sth *CTSignedTreeHead) error {
+	if sth == nil {
+		return errors.New("no tree head")
+	}
+	input, err := ctTreeHeadSignatureInput(sth)
+	if err != nil {
+		return err
+	}
+	var ds tls.DigitallySigned
+	if rest, err := tls.Unmarshal(sth.TreeHeadSignature, &ds); err != nil {
+		return fmt.Errorf("failed to decode tree head signature: %v", err)
+	} else if len(rest) > 0 {
+		return fmt.Errorf("tree head signature has %d bytes of trailing data", len(rest))
+	}
+	if ds.Algorithm.Hash != tls.SHA256 {
+		return fmt.Errorf("unsupported tree head signature hash algorithm %v", ds.Algorithm.Hash)
+	}
+	alg, err := ctSignatureAlgorithm(pub)
+	if err != nil {
+		return err
+	}
+	if ds.Algorithm.Signature != alg {
+		return fmt.Errorf("tree head signature algorithm %v doesn't match the %v key", ds.Algorithm.Signature, alg)
+	}
+	return tcrypto.Verify(pub, crypto.SHA256, input, ds.Signature)
+}
+
+// ctTreeHeadSignatureInput returns the TLS encoding of the TreeHeadSignature
+// of a CT tree head.
+func ctTreeHeadSignatureInput(sth *CTSignedTreeHead) ([]byte, error) {
+	if got, want := len(sth.SHA256RootHash), sha256.Size; got != want {
+		return nil, fmt.Errorf("root hash has %d bytes, want %d", got, want)
+	}
+	ths := ctTreeHeadSignature{
+		Version:       ctV1,
+		SignatureType: ctTreeHashSignatureType,
+		Timestamp:     sth.Timestamp,
+		TreeSize:      sth.TreeSize,
+	}
+	copy(ths.SHA256RootHash[:], sth.SHA256RootHash)
+	return tls.Marshal(ths)
+}
+
+// ctSignatureAlgorithm returns the TLS signature algorithm of a key, which RFC
+// 6962 section 2.1.4 restricts to ECDSA and RSA.
+func ctSignatureAlgorithm(pub crypto.PublicKey) (tls.SignatureAlgorithm, error) {
+	switch pub.(type) {
+	case *ecdsa.PublicKey:
+		return tls.ECDSA, nil
+	case *rsa.PublicKey:
+		return tls.RSA, nil
+	}
+	return tls.Anonymous, fmt.Errorf("unsupported key type %T for CT tree heads", pub)
+}
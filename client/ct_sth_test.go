@@ -0,0 +1,162 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/trillian/crypto/keys/pem"
+	"github.com/google/trillian/testonly"
+	"github.com/google/trillian/types"
+	"golang.org/x/crypto/ed25519"
+)
+
+// A tree head signed by a CT log, and its public key, from the signature tests
+// of github.com/google/certificate-transparency-go.
+const (
+	ctTestSTH = `{"tree_size":42,"timestamp":1348589667204,"sha256_root_hash":"GAQb1GZQgwAfuoxUEdLXSOirv9zf2SGMsCtop459TCM=","tree_head_signature":"BAMASDBGAiEAvv2AYFY3Y6Xkm6U+ZEPBP3Yk/WQDF4ETc24WASrKmD4CIQD1claNv+moZJDrkVxO4WrV7NcI/tNe1OXNGyw/CHtBMA=="}`
+
+	ctTestPublicKey = `-----BEGIN PUBLIC KEY-----
+MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAES0AfBkjr7b8b19p5Gk8plSAN16wW
+XZyhYsH6FMCEUK60t7pem/ckoPX8hupuaiJzJS0ZQ0SEoJGlFxkUFwft5g==
+-----END PUBLIC KEY-----`
+)
+
+func TestVerifyCTSignedTreeHead(t *testing.T) {
+	pub, err := pem.UnmarshalPublicKey(ctTestPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sth CTSignedTreeHead
+	if err := json.Unmarshal([]byte(ctTestSTH), &sth); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyCTSignedTreeHead(pub, &sth); err != nil {
+		t.Errorf("VerifyCTSignedTreeHead(): %v", err)
+	}
+	// The JSON encoding is stable, so a CT log can serve sth as it is.
+	if got, err := json.Marshal(sth); err != nil {
+		t.Errorf("json.Marshal(): %v", err)
+	} else if string(got) != ctTestSTH {
+		t.Errorf("json.Marshal()=%s, want %s", got, ctTestSTH)
+	}
+
+	for _, test := range []struct {
+		desc   string
+		modify func(sth *CTSignedTreeHead)
+	}{
+		{desc: "tree-size", modify: func(sth *CTSignedTreeHead) { sth.TreeSize++ }},
+		{desc: "timestamp", modify: func(sth *CTSignedTreeHead) { sth.Timestamp++ }},
+		{desc: "root-hash", modify: func(sth *CTSignedTreeHead) { sth.SHA256RootHash[0] ^= 1 }},
+		{desc: "short-root-hash", modify: func(sth *CTSignedTreeHead) { sth.SHA256RootHash = sth.SHA256RootHash[1:] }},
+		{desc: "signature", modify: func(sth *CTSignedTreeHead) { sth.TreeHeadSignature[10] ^= 1 }},
+		{desc: "trailing-data", modify: func(sth *CTSignedTreeHead) { sth.TreeHeadSignature = append(sth.TreeHeadSignature, 0) }},
+		{desc: "rsa-algorithm", modify: func(sth *CTSignedTreeHead) { sth.TreeHeadSignature[1] = 1 }},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			var sth CTSignedTreeHead
+			if err := json.Unmarshal([]byte(ctTestSTH), &sth); err != nil {
+				t.Fatal(err)
+			}
+			test.modify(&sth)
+			if err := VerifyCTSignedTreeHead(pub, &sth); err == nil {
+				t.Error("VerifyCTSignedTreeHead(): got nil error")
+			}
+		})
+	}
+}
+
+func TestNewCTSignedTreeHead(t *testing.T) {
+	ecKey, err := pem.UnmarshalPrivateKey(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := &types.LogRootV1{
+		TreeSize:       42,
+		RootHash:       testonly.MustHexDecode("18041bd4665083001fba8c5411d2d748e8abbfdcdfd9218cb02b68a78e7d4c23"),
+		TimestampNanos: 1348589667204999999,
+		Revision:       7,
+	}
+
+	for _, test := range []struct {
+		desc string
+		key  crypto.Signer
+		alg  byte
+	}{
+		{desc: "ecdsa", key: ecKey, alg: 3},
+		{desc: "rsa", key: rsaKey, alg: 1},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			sth, err := NewCTSignedTreeHead(root, test.key)
+			if err != nil {
+				t.Fatalf("NewCTSignedTreeHead(): %v", err)
+			}
+			if got, want := sth.TreeSize, root.TreeSize; got != want {
+				t.Errorf("TreeSize=%d, want %d", got, want)
+			}
+			if got, want := sth.Timestamp, uint64(1348589667204); got != want {
+				t.Errorf("Timestamp=%d, want %d", got, want)
+			}
+			if !bytes.Equal(sth.SHA256RootHash, root.RootHash) {
+				t.Errorf("SHA256RootHash=%x, want %x", sth.SHA256RootHash, root.RootHash)
+			}
+			// The DigitallySigned struct starts with the SHA-256 hash algorithm,
+			// then the signature algorithm.
+			if got, want := sth.TreeHeadSignature[:2], []byte{4, test.alg}; !bytes.Equal(got, want) {
+				t.Errorf("TreeHeadSignature algorithms=%x, want %x", got, want)
+			}
+			if err := VerifyCTSignedTreeHead(test.key.Public(), sth); err != nil {
+				t.Errorf("VerifyCTSignedTreeHead(): %v", err)
+			}
+		})
+	}
+}
+
+func TestNewCTSignedTreeHeadErrors(t *testing.T) {
+	ecKey, err := pem.UnmarshalPrivateKey(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := make([]byte, 32)
+
+	for _, test := range []struct {
+		desc string
+		root *types.LogRootV1
+		key  crypto.Signer
+	}{
+		{desc: "no-root", key: ecKey},
+		{desc: "short-root-hash", root: &types.LogRootV1{RootHash: hash[1:]}, key: ecKey},
+		{desc: "ed25519", root: &types.LogRootV1{RootHash: hash}, key: edKey},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if _, err := NewCTSignedTreeHead(test.root, test.key); err == nil {
+				t.Error("NewCTSignedTreeHead(): got nil error")
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RootStore persists the latest root trusted by a LogClient, so that Follow
+// can resume from it after a restart.
+type RootStore interface {
+	// LoadRoot returns the stored root, or nil if no root has been stored yet.
+	LoadRoot(ctx context.Context) (*types.LogRootV1, error)
+	// StoreRoot replaces the stored root.
+	StoreRoot(ctx context.Context, root *types.LogRootV1) error
+}
+
+// Checkpoint is a root verified by Follow, or the error which stopped it.
+type Checkpoint struct {
+	Root *types.LogRootV1
+	Err  error
+}
+
+// Follow fetches the latest root of the log every interval, which must be
+// positive, verifies that it is consistent with the trusted root, and sends
+// each new trusted root on the returned channel. The channel is closed when ctx is done, or after an error
+// has been sent on it, e.g. because the log served an inconsistent root.
+// Transient errors are retried at the next interval.
+//
+// If c.RootStore is set, Follow starts from the stored root if it is newer
+// than the trusted root of the client, and stores every new root before
+// sending it, so that a restarted follower resumes from the last root
+// verified.
+func (c *LogClient) Follow(ctx context.Context, interval time.Duration) (<-chan Checkpoint, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval must be positive, got %v", interval)
+	}
+	ch := make(chan Checkpoint)
+	go func() {
+		defer close(ch)
+		if err := c.follow(ctx, interval, ch); err != nil && ctx.Err() == nil {
+			select {
+			case ch <- Checkpoint{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return ch, nil
+}
+
+func (c *LogClient) follow(ctx context.Context, interval time.Duration, ch chan<- Checkpoint) error {
+	if c.RootStore != nil {
+		stored, err := c.RootStore.LoadRoot(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to load trusted root: %v", err)
+		}
+		if stored != nil {
+			if err := c.resume(stored); err != nil {
+				return err
+			}
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		root, err := c.UpdateRoot(ctx)
+		switch status.Code(err) {
+		case codes.OK:
+			if root == nil {
+				break
+			}
+			if c.RootStore != nil {
+				if err := c.RootStore.StoreRoot(ctx, root); err != nil {
+					return fmt.Errorf("failed to store trusted root: %v", err)
+				}
+			}
+			select {
+			case ch <- Checkpoint{Root: root}:
+			case <-ctx.Done():
+				return nil
+			}
+		case codes.Unavailable, codes.NotFound, codes.FailedPrecondition:
+			// Retry.
+		default:
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// resume makes root, which was verified in the past, the trusted root of the
+// client if it is newer than the current one.
+func (c *LogClient) resume(root *types.LogRootV1) error {
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+	c.rootLock.Lock()
+	defer c.rootLock.Unlock()
+
+	switch {
+	case root.TreeSize == c.root.TreeSize && c.root.TreeSize > 0 && !bytes.Equal(root.RootHash, c.root.RootHash):
+		return fmt.Errorf("stored root hash %x differs from trusted root hash %x at tree size %d", root.RootHash, c.root.RootHash, root.TreeSize)
+	case root.TreeSize > c.root.TreeSize, root.TreeSize == c.root.TreeSize && root.TimestampNanos > c.root.TimestampNanos:
+		c.root = *root
+	}
+	return nil
+}
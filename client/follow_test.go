@@ -0,0 +1,173 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/client"
+	"github.com/google/trillian/testonly/inmemory"
+	"github.com/google/trillian/types"
+)
+
+// memoryRootStore is a RootStore holding the root in memory.
+type memoryRootStore struct {
+	mu   sync.Mutex
+	root *types.LogRootV1
+}
+
+func (s *memoryRootStore) LoadRoot(ctx context.Context) (*types.LogRootV1, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.root, nil
+}
+
+func (s *memoryRootStore) StoreRoot(ctx context.Context, root *types.LogRootV1) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := *root
+	s.root = &r
+	return nil
+}
+
+// addLeaves queues count leaves and integrates them.
+func addLeaves(ctx context.Context, t *testing.T, env *inmemory.LogEnv, c *client.LogClient, prefix string, count int) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		if err := c.QueueLeaf(ctx, []byte(fmt.Sprintf("%s-%d", prefix, i))); err != nil {
+			t.Fatalf("QueueLeaf(): %v", err)
+		}
+	}
+	if _, err := env.Advance(ctx, time.Second); err != nil {
+		t.Fatalf("Advance(): %v", err)
+	}
+}
+
+// mustFollow calls Follow with a short interval.
+func mustFollow(ctx context.Context, t *testing.T, c *client.LogClient) <-chan client.Checkpoint {
+	t.Helper()
+	ch, err := c.Follow(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Follow(): %v", err)
+	}
+	return ch
+}
+
+// next returns the next checkpoint sent by Follow.
+func next(t *testing.T, ch <-chan client.Checkpoint) client.Checkpoint {
+	t.Helper()
+	select {
+	case cp, ok := <-ch:
+		if !ok {
+			t.Fatal("Follow() channel closed, want checkpoint")
+		}
+		return cp
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for checkpoint")
+	}
+	return client.Checkpoint{}
+}
+
+func newFollower(t *testing.T, env *inmemory.LogEnv, tree *trillian.Tree, store client.RootStore) *client.LogClient {
+	t.Helper()
+	c, err := client.NewFromTree(env.Log, tree, types.LogRootV1{})
+	if err != nil {
+		t.Fatalf("NewFromTree(): %v", err)
+	}
+	c.RootStore = store
+	return c
+}
+
+func TestFollow(t *testing.T) {
+	ctx := context.Background()
+	env, err := inmemory.NewLogEnv(ctx, 1)
+	if err != nil {
+		t.Fatalf("NewLogEnv(): %v", err)
+	}
+	defer env.Close()
+	tree, err := env.CreateLog(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateLog(): %v", err)
+	}
+	store := &memoryRootStore{}
+
+	fctx, cancel := context.WithCancel(ctx)
+	c := newFollower(t, env, tree, store)
+	ch := mustFollow(fctx, t, c)
+	if cp := next(t, ch); cp.Err != nil || cp.Root.TreeSize != 0 {
+		t.Fatalf("Follow() sent %+v, want empty root", cp)
+	}
+	addLeaves(ctx, t, env, c, "first", 3)
+	cp := next(t, ch)
+	if cp.Err != nil || cp.Root.TreeSize != 3 {
+		t.Fatalf("Follow() sent %+v, want root of size 3", cp)
+	}
+	if stored, _ := store.LoadRoot(ctx); stored.TreeSize != 3 {
+		t.Errorf("Follow() stored root of size %d, want 3", stored.TreeSize)
+	}
+	cancel()
+	for range ch {
+	}
+
+	// A new follower resumes from the stored root.
+	fctx, cancel = context.WithCancel(ctx)
+	defer cancel()
+	c = newFollower(t, env, tree, store)
+	ch = mustFollow(fctx, t, c)
+	addLeaves(ctx, t, env, c, "second", 2)
+	if cp := next(t, ch); cp.Err != nil || cp.Root.TreeSize != 5 {
+		t.Fatalf("Follow() after restart sent %+v, want root of size 5", cp)
+	}
+}
+
+func TestFollow_Inconsistent(t *testing.T) {
+	ctx := context.Background()
+	env, err := inmemory.NewLogEnv(ctx, 1)
+	if err != nil {
+		t.Fatalf("NewLogEnv(): %v", err)
+	}
+	defer env.Close()
+	tree, err := env.CreateLog(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateLog(): %v", err)
+	}
+	c := newFollower(t, env, tree, nil)
+	addLeaves(ctx, t, env, c, "leaf", 3)
+
+	// The stored root forks from the one served by the log.
+	store := &memoryRootStore{root: &types.LogRootV1{TreeSize: 3, RootHash: make([]byte, 32), TimestampNanos: 1}}
+	c = newFollower(t, env, tree, store)
+	ch := mustFollow(ctx, t, c)
+	if cp := next(t, ch); cp.Err == nil {
+		t.Fatalf("Follow() with inconsistent root sent %+v, want error", cp)
+	}
+	if cp, ok := <-ch; ok {
+		t.Errorf("Follow() sent %+v after error, want channel closed", cp)
+	}
+}
+
+func TestFollow_InvalidInterval(t *testing.T) {
+	c := client.New(0, nil, nil, types.LogRootV1{})
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if _, err := c.Follow(context.Background(), interval); err == nil {
+			t.Errorf("Follow(%v) returned err = nil, want non-nil", interval)
+		}
+	}
+}
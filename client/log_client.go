@@ -18,6 +18,8 @@ package client
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"sort"
 	"sync"
@@ -25,20 +27,56 @@ import (
 
 	"github.com/google/trillian"
 	"github.com/google/trillian/client/backoff"
+	"github.com/google/trillian/storage"
 	"github.com/google/trillian/types"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// DefaultListBatchSize is the number of leaves ListLeaves requests per call
+// unless LogClient.ListBatchSize is set.
+const DefaultListBatchSize = 1000
+
+// ErrPublicKeyMismatch is returned by NewFromTree if the public key of the tree
+// doesn't match the one pinned with WithPinnedPublicKey.
+var ErrPublicKeyMismatch = errors.New("client: tree public key doesn't match the pinned public key")
+
+// IsTreeNeedsInit returns whether err indicates that a tree exists but has no
+// root yet, e.g. because it hasn't been initialised with InitLog. This allows
+// callers to tell such trees apart from missing or deleted ones, for which
+// NotFound errors are returned.
+func IsTreeNeedsInit(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok || s.Code() != codes.FailedPrecondition {
+		return false
+	}
+	for _, d := range s.Details() {
+		if pf, ok := d.(*errdetails.PreconditionFailure); ok {
+			for _, v := range pf.GetViolations() {
+				if v.GetType() == storage.TreeNeedsInitViolation {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
 // LogClient represents a client for a given Trillian log instance.
 type LogClient struct {
 	*LogVerifier
 	LogID         int64
 	MinMergeDelay time.Duration
-	client        trillian.TrillianLogClient
-	root          types.LogRootV1
-	rootLock      sync.Mutex
-	updateLock    sync.Mutex
+	// ListBatchSize is the maximum number of leaves requested by each
+	// GetLeavesByRange call made by ListLeaves. Defaults to DefaultListBatchSize.
+	ListBatchSize int64
+	// RootStore, if set, persists the roots verified by Follow.
+	RootStore  RootStore
+	client     trillian.TrillianLogClient
+	root       types.LogRootV1
+	rootLock   sync.Mutex
+	updateLock sync.Mutex
 }
 
 // New returns a new LogClient.
@@ -51,8 +89,43 @@ func New(logID int64, client trillian.TrillianLogClient, verifier *LogVerifier,
 	}
 }
 
+// Option configures how NewFromTree creates a LogClient.
+type Option func(*options)
+
+type options struct {
+	pinnedKeyFingerprint []byte
+}
+
+// WithPinnedPublicKey makes NewFromTree check that the public key of the tree
+// config, e.g. as returned by GetTree, has the given fingerprint (see
+// PublicKeyFingerprint), and fail with ErrPublicKeyMismatch otherwise. This
+// protects against a server presenting a different key than expected, as
+// nothing signed by that key is trusted.
+func WithPinnedPublicKey(fingerprint []byte) Option {
+	return func(o *options) {
+		o.pinnedKeyFingerprint = fingerprint
+	}
+}
+
+// PublicKeyFingerprint returns the fingerprint of the DER-encoded public key
+// der: its SHA-256 hash.
+func PublicKeyFingerprint(der []byte) []byte {
+	h := sha256.Sum256(der)
+	return h[:]
+}
+
 // NewFromTree creates a new LogClient given a tree config.
-func NewFromTree(client trillian.TrillianLogClient, config *trillian.Tree, root types.LogRootV1) (*LogClient, error) {
+func NewFromTree(client trillian.TrillianLogClient, config *trillian.Tree, root types.LogRootV1, opts ...Option) (*LogClient, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.pinnedKeyFingerprint != nil {
+		if got := PublicKeyFingerprint(config.GetPublicKey().GetDer()); !bytes.Equal(got, o.pinnedKeyFingerprint) {
+			return nil, ErrPublicKeyMismatch
+		}
+	}
+
 	verifier, err := NewLogVerifierFromTree(config)
 	if err != nil {
 		return nil, err
@@ -125,6 +198,66 @@ func (c *LogClient) ListByIndex(ctx context.Context, start, count int64) ([]*tri
 	return resp.Leaves, nil
 }
 
+// ListLeaves calls fn for each leaf in [start, end), in index order.
+// Leaves are fetched with as many GetLeavesByRange calls as necessary, as the
+// server may return fewer leaves than requested. Retriable errors are retried
+// with backoff, resuming after the last leaf received, until ctx is done.
+// Iteration stops at the first error returned by fn, which is then returned.
+func (c *LogClient) ListLeaves(ctx context.Context, start, end int64, fn func(*trillian.LogLeaf) error) error {
+	if start < 0 || end < start {
+		return status.Errorf(codes.InvalidArgument, "invalid range [%d, %d)", start, end)
+	}
+	batchSize := c.ListBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultListBatchSize
+	}
+	b := &backoff.Backoff{
+		Min:    100 * time.Millisecond,
+		Max:    10 * time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+
+	for next := start; next < end; {
+		count := end - next
+		if count > batchSize {
+			count = batchSize
+		}
+		var leaves []*trillian.LogLeaf
+		if err := b.Retry(ctx, func() error {
+			resp, err := c.client.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{
+				LogId:      c.LogID,
+				StartIndex: next,
+				Count:      count,
+			})
+			if err != nil {
+				return err
+			}
+			leaves = resp.Leaves
+			return nil
+		}); err != nil {
+			return err
+		}
+		if len(leaves) == 0 {
+			return status.Errorf(codes.OutOfRange, "no leaves returned from index %d", next)
+		}
+		if int64(len(leaves)) > count {
+			return fmt.Errorf("len(Leaves)=%d, want at most %d", len(leaves), count)
+		}
+		for i, l := range leaves {
+			if want := next + int64(i); l.LeafIndex != want {
+				return fmt.Errorf("Leaves[%d].LeafIndex=%d, want %d", i, l.LeafIndex, want)
+			}
+			if err := fn(l); err != nil {
+				return err
+			}
+		}
+		next += int64(len(leaves))
+		b.Reset()
+	}
+	return nil
+}
+
 // WaitForRootUpdate repeatedly fetches the latest root until there is an
 // update, which it then applies, or until ctx times out.
 func (c *LogClient) WaitForRootUpdate(ctx context.Context) (*types.LogRootV1, error) {
@@ -258,8 +391,12 @@ func (c *LogClient) UpdateRoot(ctx context.Context) (*types.LogRootV1, error) {
 // It is best to call this method with a context that will timeout to avoid
 // waiting forever.
 func (c *LogClient) WaitForInclusion(ctx context.Context, data []byte) error {
-	leaf := c.BuildLeaf(data)
+	return c.WaitForInclusionByHash(ctx, c.BuildLeaf(data).MerkleLeafHash)
+}
 
+// WaitForInclusionByHash is like WaitForInclusion, for the leaf with the given
+// Merkle leaf hash, e.g. in a hash-only log.
+func (c *LogClient) WaitForInclusionByHash(ctx context.Context, leafHash []byte) error {
 	// If a minimum merge delay has been configured, wait at least that long before
 	// starting to poll
 	if c.MinMergeDelay > 0 {
@@ -276,7 +413,7 @@ func (c *LogClient) WaitForInclusion(ctx context.Context, data []byte) error {
 
 		// It is illegal to ask for an inclusion proof with TreeSize = 0.
 		if root.TreeSize >= 1 {
-			ok, err := c.getAndVerifyInclusionProof(ctx, leaf.MerkleLeafHash, root)
+			ok, err := c.getAndVerifyInclusionProof(ctx, leafHash, root)
 			if err != nil && status.Code(err) != codes.NotFound {
 				return err
 			} else if ok {
@@ -397,9 +534,49 @@ func (c *LogClient) AddSequencedLeaves(ctx context.Context, dataByIndex map[int6
 // AlreadyExists is considered a success case by this function.
 func (c *LogClient) QueueLeaf(ctx context.Context, data []byte) error {
 	leaf := c.BuildLeaf(data)
-	_, err := c.client.QueueLeaf(ctx, &trillian.QueueLeafRequest{
+	rsp, err := c.client.QueueLeaf(ctx, &trillian.QueueLeafRequest{
 		LogId: c.LogID,
 		Leaf:  leaf,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	return queuedLeafErr(rsp.QueuedLeaf)
+}
+
+// queuedLeafErr returns the error with the status of a queued leaf, if it
+// wasn't queued, e.g. because the leaf validator of the log rejected it.
+// AlreadyExists is not an error.
+func queuedLeafErr(leaf *trillian.QueuedLogLeaf) error {
+	st := leaf.GetStatus()
+	if st == nil {
+		return nil
+	}
+	switch codes.Code(st.Code) {
+	case codes.OK, codes.AlreadyExists:
+		return nil
+	}
+	return status.ErrorProto(st)
+}
+
+// QueueTombstone adds a tombstone deleting the leaf at index to a Trillian log
+// with leaf_tombstones without blocking. The leaf is still included in the log,
+// but is omitted by GetEffectiveLeaves once the tombstone is integrated.
+// AlreadyExists is considered a success case by this function.
+func (c *LogClient) QueueTombstone(ctx context.Context, index int64) error {
+	return c.QueueLeaf(ctx, types.NewTombstone(index))
+}
+
+// QueueLeafHash adds a leaf with the given Merkle leaf hash and extra data, but
+// no value, to a hash-only Trillian log without blocking.
+// AlreadyExists is considered a success case by this function.
+func (c *LogClient) QueueLeafHash(ctx context.Context, leafHash, extraData []byte) error {
+	rsp, err := c.client.QueueLeaf(ctx, &trillian.QueueLeafRequest{
+		LogId: c.LogID,
+		Leaf:  &trillian.LogLeaf{MerkleLeafHash: leafHash, ExtraData: extraData},
+	})
+	if err != nil {
+		return err
+	}
+	return queuedLeafErr(rsp.QueuedLeaf)
 }
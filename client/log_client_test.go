@@ -17,15 +17,19 @@ package client
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
 	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/storage"
 	"github.com/google/trillian/testonly/integration"
 	"github.com/google/trillian/types"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -129,6 +133,99 @@ func TestListByIndex(t *testing.T) {
 	}
 }
 
+// rangeLogClient serves GetLeavesByRange from a fixed set of leaves, returning
+// at most maxCount leaves per call and failing the first failures calls.
+type rangeLogClient struct {
+	trillian.TrillianLogClient
+	treeSize int64
+	maxCount int64
+	failures int
+	reqs     []*trillian.GetLeavesByRangeRequest
+}
+
+func (c *rangeLogClient) GetLeavesByRange(ctx context.Context, req *trillian.GetLeavesByRangeRequest, opts ...grpc.CallOption) (*trillian.GetLeavesByRangeResponse, error) {
+	c.reqs = append(c.reqs, req)
+	if c.failures > 0 {
+		c.failures--
+		return nil, status.Error(codes.Unavailable, "try again")
+	}
+	count := req.Count
+	if count > c.maxCount {
+		count = c.maxCount
+	}
+	resp := &trillian.GetLeavesByRangeResponse{}
+	for i := req.StartIndex; i < req.StartIndex+count && i < c.treeSize; i++ {
+		resp.Leaves = append(resp.Leaves, &trillian.LogLeaf{LeafIndex: i, LeafValue: []byte(fmt.Sprint(i))})
+	}
+	return resp, nil
+}
+
+func TestListLeaves(t *testing.T) {
+	for _, tc := range []struct {
+		desc          string
+		start, end    int64
+		batchSize     int64
+		maxCount      int64
+		failures      int
+		wantReqCounts []int64
+		wantErr       codes.Code
+	}{
+		{desc: "empty", start: 3, end: 3},
+		{desc: "single batch", start: 0, end: 5, maxCount: 10, wantReqCounts: []int64{5}},
+		{desc: "client batches", start: 2, end: 9, batchSize: 3, maxCount: 10, wantReqCounts: []int64{3, 3, 1}},
+		{desc: "server truncates", start: 0, end: 7, batchSize: 5, maxCount: 2, wantReqCounts: []int64{5, 5, 3, 1}},
+		{desc: "retries", start: 0, end: 4, maxCount: 10, failures: 2, wantReqCounts: []int64{4, 4, 4}},
+		{desc: "beyond tree size", start: 8, end: 12, maxCount: 10, wantReqCounts: []int64{4, 2}, wantErr: codes.OutOfRange},
+		{desc: "invalid range", start: 5, end: 4, wantErr: codes.InvalidArgument},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			fake := &rangeLogClient{treeSize: 10, maxCount: tc.maxCount, failures: tc.failures}
+			client := New(1, fake, nil, types.LogRootV1{})
+			client.ListBatchSize = tc.batchSize
+
+			next := tc.start
+			err := client.ListLeaves(context.Background(), tc.start, tc.end, func(l *trillian.LogLeaf) error {
+				if l.LeafIndex != next {
+					t.Errorf("ListLeaves() yielded leaf %d, want %d", l.LeafIndex, next)
+				}
+				next++
+				return nil
+			})
+			if got := status.Code(err); got != tc.wantErr {
+				t.Fatalf("ListLeaves() = %v, want code %v", err, tc.wantErr)
+			}
+			if tc.wantErr == codes.OK && next != tc.end {
+				t.Errorf("ListLeaves() stopped at %d, want %d", next, tc.end)
+			}
+
+			var counts []int64
+			for _, req := range fake.reqs {
+				counts = append(counts, req.Count)
+			}
+			if !reflect.DeepEqual(counts, tc.wantReqCounts) {
+				t.Errorf("GetLeavesByRange() called with counts %v, want %v", counts, tc.wantReqCounts)
+			}
+		})
+	}
+}
+
+func TestListLeavesCallbackError(t *testing.T) {
+	fake := &rangeLogClient{treeSize: 10, maxCount: 10}
+	client := New(1, fake, nil, types.LogRootV1{})
+	wantErr := errors.New("stop")
+	var calls int
+	err := client.ListLeaves(context.Background(), 0, 10, func(l *trillian.LogLeaf) error {
+		calls++
+		if l.LeafIndex == 2 {
+			return wantErr
+		}
+		return nil
+	})
+	if err != wantErr || calls != 3 {
+		t.Errorf("ListLeaves() = %v after %d calls, want %v after 3 calls", err, calls, wantErr)
+	}
+}
+
 func TestVerifyInclusion(t *testing.T) {
 	ctx := context.Background()
 	env, client := clientEnvForTest(ctx, t, stestonly.PreorderedLogTree)
@@ -317,3 +414,94 @@ func TestAddSequencedLeaves(t *testing.T) {
 		})
 	}
 }
+
+// queueLogClient serves QueueLeaf, returning a queued leaf with the given
+// status.
+type queueLogClient struct {
+	trillian.TrillianLogClient
+	status *status.Status
+}
+
+func (c *queueLogClient) QueueLeaf(ctx context.Context, req *trillian.QueueLeafRequest, opts ...grpc.CallOption) (*trillian.QueueLeafResponse, error) {
+	return &trillian.QueueLeafResponse{QueuedLeaf: &trillian.QueuedLogLeaf{Leaf: req.Leaf, Status: c.status.Proto()}}, nil
+}
+
+func TestQueueLeafStatus(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc     string
+		status   *status.Status
+		wantCode codes.Code
+	}{
+		{desc: "unset"},
+		{desc: "ok", status: status.New(codes.OK, "")},
+		{desc: "alreadyExists", status: status.New(codes.AlreadyExists, "duplicate")},
+		{desc: "rejected", status: status.New(codes.InvalidArgument, "bad leaf"), wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			c := New(1, &queueLogClient{status: test.status}, &LogVerifier{Hasher: rfc6962.DefaultHasher}, types.LogRootV1{})
+			if err := c.QueueLeaf(ctx, []byte("leaf")); status.Code(err) != test.wantCode {
+				t.Errorf("QueueLeaf() = %v, want code %v", err, test.wantCode)
+			}
+			if err := c.QueueLeafHash(ctx, make([]byte, 32), nil); status.Code(err) != test.wantCode {
+				t.Errorf("QueueLeafHash() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestAddLeafRejected(t *testing.T) {
+	// AddLeaf fails straight away, rather than waiting for the leaf to be
+	// included until its deadline. The fake client fails any other call.
+	c := New(1, &queueLogClient{status: status.New(codes.InvalidArgument, "bad leaf")}, &LogVerifier{Hasher: rfc6962.DefaultHasher}, types.LogRootV1{})
+	if err := c.AddLeaf(context.Background(), []byte("leaf")); err == nil {
+		t.Error("AddLeaf() of a rejected leaf succeeded")
+	}
+}
+
+func TestIsTreeNeedsInit(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		err  error
+		want bool
+	}{
+		{desc: "nil"},
+		{desc: "plain", err: errors.New("tree needs initialising")},
+		{desc: "notFound", err: status.Error(codes.NotFound, "tree 1 not found")},
+		{desc: "otherPrecondition", err: status.Error(codes.FailedPrecondition, "tree needs initialising")},
+		{desc: "needsInit", err: storage.ErrTreeNeedsInit, want: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := IsTreeNeedsInit(test.err); got != test.want {
+				t.Errorf("IsTreeNeedsInit(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewFromTreePinnedPublicKey(t *testing.T) {
+	pinned := PublicKeyFingerprint(stestonly.LogTree.PublicKey.Der)
+	// A server substituting the key of the log presents the tree with a
+	// different one.
+	substituted := proto.Clone(stestonly.LogTree).(*trillian.Tree)
+	substituted.PublicKey = stestonly.MapTree.PublicKey
+
+	for _, test := range []struct {
+		desc    string
+		tree    *trillian.Tree
+		opts    []Option
+		wantErr error
+	}{
+		{desc: "unpinned", tree: substituted},
+		{desc: "match", tree: stestonly.LogTree, opts: []Option{WithPinnedPublicKey(pinned)}},
+		{desc: "substituted", tree: substituted, opts: []Option{WithPinnedPublicKey(pinned)}, wantErr: ErrPublicKeyMismatch},
+		{desc: "noKey", tree: &trillian.Tree{TreeType: trillian.TreeType_LOG}, opts: []Option{WithPinnedPublicKey(pinned)}, wantErr: ErrPublicKeyMismatch},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := NewFromTree(nil, test.tree, types.LogRootV1{}, test.opts...)
+			if err != test.wantErr {
+				t.Errorf("NewFromTree() = _, %v, want _, %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@
 package client
 
 import (
+	"bytes"
 	"crypto"
 	"errors"
 	"fmt"
@@ -39,7 +40,14 @@ type LogVerifier struct {
 	PubKey crypto.PublicKey
 	// SigHash computes the digest of LogRoot for signing.
 	SigHash crypto.Hash
-	v       merkle.LogVerifier
+	// HashExtraData is whether leaf hashes commit to leaf extra data, matching
+	// the hash_extra_data setting of the tree.
+	HashExtraData bool
+	// LeafIndexOffset is the index of the first leaf of the log, matching the
+	// leaf_index_offset setting of the tree. It's subtracted from leaf indices
+	// before inclusion proofs are verified.
+	LeafIndexOffset int64
+	v               merkle.LogVerifier
 }
 
 // NewLogVerifier returns an object that can verify output from Trillian Logs.
@@ -63,9 +71,9 @@ func NewLogVerifierFromTree(config *trillian.Tree) (*LogVerifier, error) {
 		return nil, fmt.Errorf("client: NewLogVerifierFromTree(): TreeType: %v, want %v or %v", got, log, pLog)
 	}
 
-	logHasher, err := hashers.NewLogHasher(config.HashStrategy)
+	logHasher, err := trees.LogHasher(config)
 	if err != nil {
-		return nil, fmt.Errorf("client: NewLogVerifierFromTree(): NewLogHasher(): %v", err)
+		return nil, fmt.Errorf("client: NewLogVerifierFromTree(): LogHasher(): %v", err)
 	}
 
 	logPubKey, err := der.UnmarshalPublicKey(config.PublicKey.GetDer())
@@ -78,7 +86,10 @@ func NewLogVerifierFromTree(config *trillian.Tree) (*LogVerifier, error) {
 		return nil, fmt.Errorf("client: NewLogVerifierFromTree(): Failed parsing Log signature hash: %v", err)
 	}
 
-	return NewLogVerifier(logHasher, logPubKey, sigHash), nil
+	v := NewLogVerifier(logHasher, logPubKey, sigHash)
+	v.HashExtraData = config.HashExtraData
+	v.LeafIndexOffset = config.LeafIndexOffset
+	return v, nil
 }
 
 // VerifyRoot verifies that newRoot is a valid append-only operation from
@@ -98,6 +109,12 @@ func (c *LogVerifier) VerifyRoot(trusted *types.LogRootV1, newRoot *trillian.Sig
 		return nil, err
 	}
 
+	// The root of an empty tree must be the empty root of the hasher, which
+	// may be overridden by the tree.
+	if r.TreeSize == 0 && !bytes.Equal(r.RootHash, c.Hasher.EmptyRoot()) {
+		return nil, fmt.Errorf("VerifyRoot() error: root hash %x of empty tree, want %x", r.RootHash, c.Hasher.EmptyRoot())
+	}
+
 	// Implicitly trust the first root we get.
 	if trusted.TreeSize != 0 {
 		// Verify consistency proof.
@@ -108,6 +125,45 @@ func (c *LogVerifier) VerifyRoot(trusted *types.LogRootV1, newRoot *trillian.Sig
 	return r, nil
 }
 
+// ConsistencyChainError is returned by VerifyConsistencyChain when a pair of
+// adjacent checkpoints isn't consistent.
+type ConsistencyChainError struct {
+	// Index is the index of the proof that failed, i.e. the consistency of
+	// checkpoints Index and Index+1 couldn't be verified.
+	Index int
+	// Err is the verification error.
+	Err error
+}
+
+func (e *ConsistencyChainError) Error() string {
+	return fmt.Sprintf("checkpoints %d and %d are not consistent: %v", e.Index, e.Index+1, e.Err)
+}
+
+// VerifyConsistencyChain verifies that each checkpoint is consistent with the
+// one that precedes it, using proofs[i] to verify checkpoints[i] against
+// checkpoints[i+1]. Checkpoints must therefore be in non-decreasing tree size
+// order, and there must be exactly one proof fewer than checkpoints.
+// Verification stops at the first inconsistency, which is returned as a
+// *ConsistencyChainError. Checkpoint signatures are not verified.
+func (c *LogVerifier) VerifyConsistencyChain(checkpoints []types.LogRootV1, proofs [][][]byte) error {
+	if len(checkpoints) == 0 {
+		return errors.New("VerifyConsistencyChain() error: no checkpoints")
+	}
+	if got, want := len(proofs), len(checkpoints)-1; got != want {
+		return fmt.Errorf("VerifyConsistencyChain() error: got %d proofs for %d checkpoints, want %d", got, len(checkpoints), want)
+	}
+	for i, proof := range proofs {
+		from, to := checkpoints[i], checkpoints[i+1]
+		if err := c.v.VerifyConsistencyProof(int64(from.TreeSize), int64(to.TreeSize), from.RootHash, to.RootHash, proof); err != nil {
+			return &ConsistencyChainError{
+				Index: i,
+				Err:   fmt.Errorf("failed to verify consistency proof from %d->%d %x->%x: %v", from.TreeSize, to.TreeSize, from.RootHash, to.RootHash, err),
+			}
+		}
+	}
+	return nil
+}
+
 // VerifyInclusionAtIndex verifies that the inclusion proof for data at leafIndex
 // matches the given trusted root.
 func (c *LogVerifier) VerifyInclusionAtIndex(trusted *types.LogRootV1, data []byte, leafIndex int64, proof [][]byte) error {
@@ -115,7 +171,7 @@ func (c *LogVerifier) VerifyInclusionAtIndex(trusted *types.LogRootV1, data []by
 		return fmt.Errorf("VerifyInclusionAtIndex() error: trusted == nil")
 	}
 	leaf := c.BuildLeaf(data)
-	return c.v.VerifyInclusionProof(leafIndex, int64(trusted.TreeSize),
+	return c.v.VerifyInclusionProof(leafIndex-c.LeafIndexOffset, int64(trusted.TreeSize),
 		proof, trusted.RootHash, leaf.MerkleLeafHash)
 }
 
@@ -129,7 +185,7 @@ func (c *LogVerifier) VerifyInclusionByHash(trusted *types.LogRootV1, leafHash [
 		return fmt.Errorf("VerifyInclusionByHash() error: proof == nil")
 	}
 
-	return c.v.VerifyInclusionProof(proof.LeafIndex, int64(trusted.TreeSize), proof.Hashes,
+	return c.v.VerifyInclusionProof(proof.LeafIndex-c.LeafIndexOffset, int64(trusted.TreeSize), proof.Hashes,
 		trusted.RootHash, leafHash)
 }
 
@@ -137,9 +193,16 @@ func (c *LogVerifier) VerifyInclusionByHash(trusted *types.LogRootV1, leafHash [
 // TODO(pavelkalinnikov): This can be misleading as it creates a partially
 // filled LogLeaf. Consider returning a pair instead, or leafHash only.
 func (c *LogVerifier) BuildLeaf(data []byte) *trillian.LogLeaf {
-	leafHash := c.Hasher.HashLeaf(data)
+	return c.BuildLeafWithExtraData(data, nil)
+}
+
+// BuildLeafWithExtraData builds a leaf with the given value and extra data,
+// hashing the extra data too if c.HashExtraData is set.
+func (c *LogVerifier) BuildLeafWithExtraData(data, extraData []byte) *trillian.LogLeaf {
+	leafHash := hashers.HashLogLeaf(c.Hasher, data, extraData, c.HashExtraData)
 	return &trillian.LogLeaf{
 		LeafValue:      data,
+		ExtraData:      extraData,
 		MerkleLeafHash: leafHash,
 	}
 }
@@ -15,11 +15,14 @@
 package client
 
 import (
+	"bytes"
 	"crypto"
 	"testing"
 
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys/pem"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/merkle/rfc6962"
 	"github.com/google/trillian/testonly"
 	"github.com/google/trillian/types"
@@ -63,6 +66,41 @@ func TestVerifyRootErrors(t *testing.T) {
 	}
 }
 
+func TestVerifyRootEmptyRoot(t *testing.T) {
+	key, err := pem.UnmarshalPrivateKey(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("Failed to open test key, err=%v", err)
+	}
+	signer := tcrypto.NewSigner(0, key, crypto.SHA256)
+	pk, err := pem.UnmarshalPublicKey(testonly.DemoPublicKey)
+	if err != nil {
+		t.Fatalf("Failed to load public key, err=%v", err)
+	}
+	emptyRoot := bytes.Repeat([]byte{1}, 32)
+
+	for _, test := range []struct {
+		desc     string
+		hasher   hashers.LogHasher
+		rootHash []byte
+		wantErr  bool
+	}{
+		{desc: "default", hasher: rfc6962.DefaultHasher, rootHash: rfc6962.DefaultHasher.EmptyRoot()},
+		{desc: "defaultMismatch", hasher: rfc6962.DefaultHasher, rootHash: emptyRoot, wantErr: true},
+		{desc: "override", hasher: hashers.WithEmptyRoot(rfc6962.DefaultHasher, emptyRoot), rootHash: emptyRoot},
+		{desc: "overrideMismatch", hasher: hashers.WithEmptyRoot(rfc6962.DefaultHasher, emptyRoot), rootHash: rfc6962.DefaultHasher.EmptyRoot(), wantErr: true},
+	} {
+		signedRoot, err := signer.SignLogRoot(&types.LogRootV1{RootHash: test.rootHash})
+		if err != nil {
+			t.Fatalf("%v: SignLogRoot(): %v", test.desc, err)
+		}
+		logVerifier := NewLogVerifier(test.hasher, pk, crypto.SHA256)
+		_, err = logVerifier.VerifyRoot(&types.LogRootV1{}, signedRoot, nil)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: VerifyRoot() = %v, want err? %v", test.desc, err, test.wantErr)
+		}
+	}
+}
+
 func TestVerifyInclusionAtIndexErrors(t *testing.T) {
 	logVerifier := NewLogVerifier(nil, nil, crypto.SHA256)
 	// An error is expected because the first parameter (trusted) is nil
@@ -90,3 +128,126 @@ func TestVerifyInclusionByHashErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestVerifyInclusionLeafIndexOffset(t *testing.T) {
+	const offset = 1000
+	mt := merkle.NewInMemoryMerkleTree(rfc6962.DefaultHasher)
+	for i := 0; i < 7; i++ {
+		mt.AddLeaf([]byte{byte(i)})
+	}
+	root := &types.LogRootV1{TreeSize: 7, RootHash: mt.CurrentRoot().Hash()}
+	// The leaf with 0-based index 2 in the Merkle tree.
+	var hashes [][]byte
+	for _, d := range mt.PathToCurrentRoot(3) {
+		hashes = append(hashes, d.Value.Hash())
+	}
+
+	v := NewLogVerifier(rfc6962.DefaultHasher, nil, crypto.SHA256)
+	v.LeafIndexOffset = offset
+	leafHash := v.BuildLeaf([]byte{2}).MerkleLeafHash
+	if err := v.VerifyInclusionByHash(root, leafHash, &trillian.Proof{LeafIndex: offset + 2, Hashes: hashes}); err != nil {
+		t.Errorf("VerifyInclusionByHash(): %v", err)
+	}
+	if err := v.VerifyInclusionAtIndex(root, []byte{2}, offset+2, hashes); err != nil {
+		t.Errorf("VerifyInclusionAtIndex(): %v", err)
+	}
+	for _, index := range []int64{2, offset + 3} {
+		if err := v.VerifyInclusionByHash(root, leafHash, &trillian.Proof{LeafIndex: index, Hashes: hashes}); err == nil {
+			t.Errorf("VerifyInclusionByHash() with leaf index %d: got nil error", index)
+		}
+	}
+}
+
+func TestBuildLeafWithExtraData(t *testing.T) {
+	value, extra := []byte("value"), []byte("extra")
+	for _, test := range []struct {
+		desc          string
+		hashExtraData bool
+		extra         []byte
+		want          []byte
+	}{
+		{desc: "value only", extra: extra, want: rfc6962.DefaultHasher.HashLeaf(value)},
+		{desc: "extra data", hashExtraData: true, extra: extra, want: rfc6962.DefaultHasher.HashLeaf(hashers.ExtraDataLeafInput(value, extra))},
+		{desc: "no extra data", hashExtraData: true, want: rfc6962.DefaultHasher.HashLeaf(hashers.ExtraDataLeafInput(value, nil))},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			v := NewLogVerifier(rfc6962.DefaultHasher, nil, crypto.SHA256)
+			v.HashExtraData = test.hashExtraData
+			leaf := v.BuildLeafWithExtraData(value, test.extra)
+			if !bytes.Equal(leaf.MerkleLeafHash, test.want) {
+				t.Errorf("BuildLeafWithExtraData().MerkleLeafHash = %x, want %x", leaf.MerkleLeafHash, test.want)
+			}
+			if !bytes.Equal(leaf.LeafValue, value) || !bytes.Equal(leaf.ExtraData, test.extra) {
+				t.Errorf("BuildLeafWithExtraData() = %+v, want value %q and extra data %q", leaf, value, test.extra)
+			}
+		})
+	}
+}
+
+func TestVerifyConsistencyChain(t *testing.T) {
+	mt := merkle.NewInMemoryMerkleTree(rfc6962.DefaultHasher)
+	for i := 0; i < 20; i++ {
+		mt.AddLeaf([]byte{byte(i)})
+	}
+	checkpoint := func(size int64) types.LogRootV1 {
+		return types.LogRootV1{TreeSize: uint64(size), RootHash: mt.RootAtSnapshot(size).Hash()}
+	}
+	proof := func(size1, size2 int64) [][]byte {
+		var hashes [][]byte
+		for _, d := range mt.SnapshotConsistency(size1, size2) {
+			hashes = append(hashes, d.Value.Hash())
+		}
+		return hashes
+	}
+
+	sizes := []int64{0, 3, 7, 7, 12, 20}
+	var checkpoints []types.LogRootV1
+	var proofs [][][]byte
+	for i, size := range sizes {
+		checkpoints = append(checkpoints, checkpoint(size))
+		if i > 0 {
+			proofs = append(proofs, proof(sizes[i-1], size))
+		}
+	}
+	corrupt := func(i int) [][][]byte {
+		c := make([][][]byte, len(proofs))
+		copy(c, proofs)
+		c[i] = [][]byte{[]byte("not a hash")}
+		return c
+	}
+
+	v := NewLogVerifier(rfc6962.DefaultHasher, nil, crypto.SHA256)
+	for _, test := range []struct {
+		desc        string
+		checkpoints []types.LogRootV1
+		proofs      [][][]byte
+		wantErr     bool
+		wantIndex   int // Only checked if >= 0.
+	}{
+		{desc: "valid", checkpoints: checkpoints, proofs: proofs},
+		{desc: "single checkpoint", checkpoints: checkpoints[4:5]},
+		{desc: "no checkpoints", wantErr: true, wantIndex: -1},
+		{desc: "too few proofs", checkpoints: checkpoints, proofs: proofs[1:], wantErr: true, wantIndex: -1},
+		{desc: "bad proof", checkpoints: checkpoints, proofs: corrupt(3), wantErr: true, wantIndex: 3},
+		{desc: "first bad proof", checkpoints: checkpoints, proofs: append(corrupt(1)[:4], corrupt(4)[4]), wantErr: true, wantIndex: 1},
+		{desc: "shrinking", checkpoints: []types.LogRootV1{checkpoint(7), checkpoint(3)}, proofs: [][][]byte{nil}, wantErr: true, wantIndex: 0},
+		{desc: "fork", checkpoints: []types.LogRootV1{checkpoint(7), {TreeSize: 7, RootHash: []byte("other")}}, proofs: [][][]byte{nil}, wantErr: true, wantIndex: 0},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			err := v.VerifyConsistencyChain(test.checkpoints, test.proofs)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("VerifyConsistencyChain() = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr || test.wantIndex < 0 {
+				return
+			}
+			cerr, ok := err.(*ConsistencyChainError)
+			if !ok {
+				t.Fatalf("VerifyConsistencyChain() = %v, want *ConsistencyChainError", err)
+			}
+			if cerr.Index != test.wantIndex {
+				t.Errorf("VerifyConsistencyChain() failed at index %d, want %d", cerr.Index, test.wantIndex)
+			}
+		})
+	}
+}
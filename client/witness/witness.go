@@ -0,0 +1,150 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package witness is a minimal log client which only follows the roots of a
+// log, verifying that they are consistent with the root it trusts, e.g. for
+// witnesses running on constrained devices. Unlike package client, it doesn't
+// fetch leaves, proofs of inclusion or tree configurations, so it has fewer
+// dependencies.
+package witness
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"fmt"
+	"sync"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc"
+
+	tcrypto "github.com/google/trillian/crypto"
+)
+
+// LogClient is the part of trillian.TrillianLogClient used by Client.
+type LogClient interface {
+	GetLatestSignedLogRoot(ctx context.Context, in *trillian.GetLatestSignedLogRootRequest, opts ...grpc.CallOption) (*trillian.GetLatestSignedLogRootResponse, error)
+}
+
+// RootStore persists the root trusted by a Client, so that it resumes from it
+// after a restart. Implementations of client.RootStore satisfy it.
+type RootStore interface {
+	// LoadRoot returns the stored root, or nil if no root has been stored yet.
+	LoadRoot(ctx context.Context) (*types.LogRootV1, error)
+	// StoreRoot replaces the stored root.
+	StoreRoot(ctx context.Context, root *types.LogRootV1) error
+}
+
+// Client follows the roots of a log. It is safe for concurrent use.
+type Client struct {
+	logID    int64
+	client   LogClient
+	verifier merkle.LogVerifier
+	pubKey   crypto.PublicKey
+	sigHash  crypto.Hash
+	store    RootStore
+
+	mu      sync.Mutex
+	root    types.LogRootV1
+	trusted bool // Whether root is trusted, i.e. loaded or verified.
+	loaded  bool // Whether the stored root has been loaded.
+}
+
+// New returns a Client following the log logID with the given hash strategy
+// and signature verification key, e.g. as returned by
+// client.NewLogVerifierFromTree. If store isn't nil, the trusted root is
+// loaded from it by the first Update, and stored whenever it changes.
+// Otherwise, the first root fetched is trusted.
+func New(logID int64, client LogClient, hasher hashers.LogHasher, pubKey crypto.PublicKey, sigHash crypto.Hash, store RootStore) *Client {
+	return &Client{
+		logID:    logID,
+		client:   client,
+		verifier: merkle.NewLogVerifier(hasher),
+		pubKey:   pubKey,
+		sigHash:  sigHash,
+		store:    store,
+	}
+}
+
+// Root returns the trusted root, which is empty until the first Update.
+func (c *Client) Root() types.LogRootV1 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.root
+}
+
+// Update fetches the latest root of the log, verifies its signature and that
+// it is consistent with the trusted root, and returns it. It becomes the
+// trusted root, and is stored, if it is newer. If the log serves an older
+// root, e.g. from a lagging replica, the trusted root is returned instead.
+// An error is returned if the root can't be fetched or verified, in which
+// case the log may have forked if it's a verification error, and the trusted
+// root is unchanged.
+func (c *Client) Update(ctx context.Context) (types.LogRootV1, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded && c.store != nil {
+		stored, err := c.store.LoadRoot(ctx)
+		if err != nil {
+			return types.LogRootV1{}, fmt.Errorf("failed to load trusted root: %v", err)
+		}
+		if stored != nil {
+			c.root, c.trusted = *stored, true
+		}
+	}
+	c.loaded = true
+	trusted := c.root
+
+	rsp, err := c.client.GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{
+		LogId:         c.logID,
+		FirstTreeSize: int64(trusted.TreeSize),
+	})
+	if err != nil {
+		return types.LogRootV1{}, err
+	}
+	root, err := tcrypto.VerifySignedLogRoot(c.pubKey, c.sigHash, rsp.GetSignedLogRoot())
+	if err != nil {
+		return types.LogRootV1{}, fmt.Errorf("failed to verify signed log root: %v", err)
+	}
+
+	switch {
+	case trusted.TreeSize == 0:
+		// Any root is consistent with an empty tree.
+	case root.TreeSize < trusted.TreeSize:
+		return trusted, nil
+	case root.TreeSize == trusted.TreeSize:
+		if !bytes.Equal(root.RootHash, trusted.RootHash) {
+			return types.LogRootV1{}, fmt.Errorf("root hash of tree size %d is %x, want trusted %x", root.TreeSize, root.RootHash, trusted.RootHash)
+		}
+	default:
+		if err := c.verifier.VerifyConsistencyProof(int64(trusted.TreeSize), int64(root.TreeSize), trusted.RootHash, root.RootHash, rsp.GetProof().GetHashes()); err != nil {
+			return types.LogRootV1{}, fmt.Errorf("failed to verify consistency proof from %d->%d %x->%x: %v", trusted.TreeSize, root.TreeSize, trusted.RootHash, root.RootHash, err)
+		}
+	}
+
+	if c.trusted && root.TreeSize == trusted.TreeSize && root.TimestampNanos <= trusted.TimestampNanos {
+		return trusted, nil
+	}
+	if c.store != nil {
+		if err := c.store.StoreRoot(ctx, root); err != nil {
+			return types.LogRootV1{}, fmt.Errorf("failed to store trusted root: %v", err)
+		}
+	}
+	c.root, c.trusted = *root, true
+	return *root, nil
+}
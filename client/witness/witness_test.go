@@ -0,0 +1,181 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package witness_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian"
+	"github.com/google/trillian/client"
+	"github.com/google/trillian/client/witness"
+	"github.com/google/trillian/testonly/inmemory"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc"
+)
+
+// memoryRootStore is a RootStore holding the root in memory.
+type memoryRootStore struct {
+	root *types.LogRootV1
+}
+
+func (s *memoryRootStore) LoadRoot(ctx context.Context) (*types.LogRootV1, error) {
+	return s.root, nil
+}
+
+func (s *memoryRootStore) StoreRoot(ctx context.Context, root *types.LogRootV1) error {
+	r := *root
+	s.root = &r
+	return nil
+}
+
+// tamperingLogClient corrupts the signatures of the roots served by a log.
+type tamperingLogClient struct {
+	witness.LogClient
+}
+
+func (c tamperingLogClient) GetLatestSignedLogRoot(ctx context.Context, in *trillian.GetLatestSignedLogRootRequest, opts ...grpc.CallOption) (*trillian.GetLatestSignedLogRootResponse, error) {
+	rsp, err := c.LogClient.GetLatestSignedLogRoot(ctx, in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	rsp = proto.Clone(rsp).(*trillian.GetLatestSignedLogRootResponse)
+	rsp.SignedLogRoot.LogRootSignature[0] ^= 1
+	return rsp, nil
+}
+
+// newEnv returns an in-memory log server with a new log.
+func newEnv(ctx context.Context, t *testing.T) (*inmemory.LogEnv, *trillian.Tree) {
+	t.Helper()
+	env, err := inmemory.NewLogEnv(ctx, 1)
+	if err != nil {
+		t.Fatalf("NewLogEnv(): %v", err)
+	}
+	tree, err := env.CreateLog(ctx, nil)
+	if err != nil {
+		env.Close()
+		t.Fatalf("CreateLog(): %v", err)
+	}
+	return env, tree
+}
+
+func newWitness(t *testing.T, lc witness.LogClient, tree *trillian.Tree, store witness.RootStore) *witness.Client {
+	t.Helper()
+	v, err := client.NewLogVerifierFromTree(tree)
+	if err != nil {
+		t.Fatalf("NewLogVerifierFromTree(): %v", err)
+	}
+	return witness.New(tree.TreeId, lc, v.Hasher, v.PubKey, v.SigHash, store)
+}
+
+// addLeaves queues count leaves and integrates them.
+func addLeaves(ctx context.Context, t *testing.T, env *inmemory.LogEnv, tree *trillian.Tree, prefix string, count int) {
+	t.Helper()
+	v, err := client.NewLogVerifierFromTree(tree)
+	if err != nil {
+		t.Fatalf("NewLogVerifierFromTree(): %v", err)
+	}
+	for i := 0; i < count; i++ {
+		leaf := v.BuildLeaf([]byte(fmt.Sprintf("%s-%d", prefix, i)))
+		if _, err := env.Log.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: tree.TreeId, Leaf: leaf}); err != nil {
+			t.Fatalf("QueueLeaf(): %v", err)
+		}
+	}
+	if _, err := env.Advance(ctx, time.Second); err != nil {
+		t.Fatalf("Advance(): %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	ctx := context.Background()
+	env, tree := newEnv(ctx, t)
+	defer env.Close()
+	store := &memoryRootStore{}
+
+	w := newWitness(t, env.Log, tree, store)
+	if root, err := w.Update(ctx); err != nil || root.TreeSize != 0 {
+		t.Fatalf("Update() = %+v, %v; want empty root", root, err)
+	}
+	addLeaves(ctx, t, env, tree, "first", 3)
+	root, err := w.Update(ctx)
+	if err != nil || root.TreeSize != 3 {
+		t.Fatalf("Update() = %+v, %v; want root of size 3", root, err)
+	}
+	if store.root == nil || store.root.TreeSize != 3 {
+		t.Errorf("Update() stored %+v, want root of size 3", store.root)
+	}
+	// An unchanged log keeps the trusted root.
+	if again, err := w.Update(ctx); err != nil || again.TreeSize != 3 || again.TimestampNanos != root.TimestampNanos {
+		t.Errorf("Update() of unchanged log = %+v, %v; want %+v", again, err, root)
+	}
+
+	// A new witness resumes from the stored root, and verifies consistency
+	// with it.
+	addLeaves(ctx, t, env, tree, "second", 2)
+	w = newWitness(t, env.Log, tree, store)
+	if root, err := w.Update(ctx); err != nil || root.TreeSize != 5 {
+		t.Fatalf("Update() after restart = %+v, %v; want root of size 5", root, err)
+	}
+	if got := w.Root(); got.TreeSize != 5 {
+		t.Errorf("Root() = %+v, want root of size 5", got)
+	}
+}
+
+func TestUpdate_Errors(t *testing.T) {
+	ctx := context.Background()
+	env, tree := newEnv(ctx, t)
+	defer env.Close()
+	addLeaves(ctx, t, env, tree, "leaf", 4)
+
+	for _, test := range []struct {
+		desc  string
+		lc    witness.LogClient
+		store *memoryRootStore
+	}{
+		{
+			desc:  "badSignature",
+			lc:    tamperingLogClient{env.Log},
+			store: &memoryRootStore{},
+		},
+		{
+			desc:  "forkedSameSize",
+			lc:    env.Log,
+			store: &memoryRootStore{root: &types.LogRootV1{TreeSize: 4, RootHash: make([]byte, 32), TimestampNanos: 1}},
+		},
+		{
+			desc:  "forkedSmaller",
+			lc:    env.Log,
+			store: &memoryRootStore{root: &types.LogRootV1{TreeSize: 3, RootHash: make([]byte, 32), TimestampNanos: 1}},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			var want types.LogRootV1
+			if test.store.root != nil {
+				want = *test.store.root
+			}
+			w := newWitness(t, test.lc, tree, test.store)
+			if root, err := w.Update(ctx); err == nil {
+				t.Fatalf("Update() = %+v, want error", root)
+			}
+			if diff := cmp.Diff(want, w.Root()); diff != "" {
+				t.Errorf("Root() after error diff (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -26,16 +26,24 @@
 // assume reasonable defaults. Multiple types of private keys may be supported;
 // one has only to set the appropriate --private_key_format value and supply the
 // corresponding flags for the chosen key type.
+//
+// Trees may also be created from a tree template stored by the Admin server,
+// e.g. --template=ct, in which case the template's settings take precedence
+// over flag defaults, and flags explicitly set take precedence over the
+// template.
 package main
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/client"
@@ -51,15 +59,37 @@ var (
 	adminServerAddr = flag.String("admin_server", "", "Address of the gRPC Trillian Admin Server (host:port)")
 	rpcDeadline     = flag.Duration("rpc_deadline", time.Second*10, "Deadline for RPC requests")
 
-	treeState          = flag.String("tree_state", trillian.TreeState_ACTIVE.String(), "State of the new tree")
-	treeType           = flag.String("tree_type", trillian.TreeType_LOG.String(), "Type of the new tree")
-	hashStrategy       = flag.String("hash_strategy", trillian.HashStrategy_RFC6962_SHA256.String(), "Hash strategy (aka preimage protection) of the new tree")
-	hashAlgorithm      = flag.String("hash_algorithm", sigpb.DigitallySigned_SHA256.String(), "Hash algorithm of the new tree")
-	signatureAlgorithm = flag.String("signature_algorithm", sigpb.DigitallySigned_ECDSA.String(), "Signature algorithm of the new tree")
-	displayName        = flag.String("display_name", "", "Display name of the new tree")
-	description        = flag.String("description", "", "Description of the new tree")
-	maxRootDuration    = flag.Duration("max_root_duration", 0, "Interval after which a new signed root is produced despite no submissions; zero means never")
-	privateKeyFormat   = flag.String("private_key_format", "", "Type of protobuf message to send the key as (PrivateKey, PEMKeyFile, or PKCS11ConfigFile). If empty, a key will be generated for you by Trillian.")
+	treeID               = flag.Int64("tree_id", 0, "ID of the new tree; zero means a random ID is assigned")
+	treeState            = flag.String("tree_state", trillian.TreeState_ACTIVE.String(), "State of the new tree")
+	treeType             = flag.String("tree_type", trillian.TreeType_LOG.String(), "Type of the new tree")
+	hashStrategy         = flag.String("hash_strategy", trillian.HashStrategy_RFC6962_SHA256.String(), "Hash strategy (aka preimage protection) of the new tree")
+	hashAlgorithm        = flag.String("hash_algorithm", sigpb.DigitallySigned_SHA256.String(), "Hash algorithm of the new tree")
+	signatureAlgorithm   = flag.String("signature_algorithm", sigpb.DigitallySigned_ECDSA.String(), "Signature algorithm of the new tree")
+	displayName          = flag.String("display_name", "", "Display name of the new tree")
+	description          = flag.String("description", "", "Description of the new tree")
+	maxRootDuration      = flag.Duration("max_root_duration", 0, "Interval after which a new signed root is produced despite no submissions; zero means never")
+	signingInterval      = flag.Duration("signing_interval", 0, "Minimum time between the signed roots of the new log, whose integrations are kept unsigned until then; zero means every integration is signed")
+	maxQueueAge          = flag.Duration("max_queue_age", 0, "Maximum time leaves of the new log spend in the queue before being published: older leaves bypass the guard window and batch size of the sequencer, and flush integrations before the --signing_interval has passed; zero means no maximum")
+	orderedTimestamps    = flag.Bool("ordered_leaf_timestamps", false, "Whether leaves added to the new PREORDERED_LOG tree must have non-decreasing integrate timestamps")
+	callerIdentityHash   = flag.Bool("caller_leaf_identity_hash", false, "If true, leaves with a leaf identity hash which isn't the size of the hasher output are rejected. Only the size is checked: supplied leaf identity hashes are used whether or not this is set")
+	hashExtraData        = flag.Bool("hash_extra_data", false, "Whether the Merkle leaf hashes of the new log commit to leaf extra data as well as leaf values")
+	hashOnly             = flag.Bool("hash_only", false, "Whether clients submit the Merkle leaf hashes of the leaves of the new log instead of their values")
+	logRootEncoding      = flag.String("log_root_encoding", trillian.LogRootEncoding_TLS.String(), "Serialization of the signed log roots of the new log (TLS or CBOR)")
+	timestampGranularity = flag.String("timestamp_granularity", trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_NANOSECOND.String(), "Resolution of the timestamps of the signed log roots of the new log")
+	leafCompression      = flag.String("leaf_compression", trillian.LeafCompression_LEAF_COMPRESSION_NONE.String(), "Compression of the leaf values and extra data of the new log in storage")
+	leafCompressionDict  = flag.String("leaf_compression_dictionary", "", "If set, the file holding the first zstd dictionary, e.g. written by zstd --train, the leaf data of the new LEAF_COMPRESSION_ZSTD_DICTIONARY log is compressed with")
+	maxTreeSize          = flag.Int64("max_tree_size", 0, "Maximum number of leaves of the new log, after which it accepts no more; zero means no maximum")
+	leafIndexOffset      = flag.Int64("leaf_index_offset", 0, "Index of the first leaf of the new log, e.g. the max_tree_size of the log it succeeds; see the Tree proto")
+	queueWriteAhead      = flag.Bool("queue_write_ahead", false, "If true, log servers with a write-ahead log acknowledge leaves of the new log while its storage is unavailable, and queue them later; weakens durability, see the Tree proto")
+	leafEncryption       = flag.Bool("leaf_encryption", false, "If true, log servers encrypt the leaf values and extra data of the new log in storage, with a data key generated for it")
+	sortByQueueTime      = flag.Bool("sort_by_queue_timestamp", false, "If true, the signer assigns indices to each batch of leaves of the new log in queue timestamp order")
+	leafKeySource        = flag.String("leaf_ordering_key_source", "", "If set, the leaf field (LEAF_VALUE or EXTRA_DATA) the ordering key of each leaf of the new PREORDERED_LOG tree is extracted from; see the Tree proto for its storage cost")
+	leafKeyOffset        = flag.Int("leaf_ordering_key_offset", 0, "Offset of the leaf ordering keys in the field named by --leaf_ordering_key_source")
+	leafKeyLength        = flag.Int("leaf_ordering_key_length", 0, "Length of the leaf ordering keys; zero means they extend to the end of the field")
+	leafTombstones       = flag.Bool("leaf_tombstones", false, "If true, leaves of the new log whose value is a tombstone delete an earlier leaf from the results of GetEffectiveLeaves; see the Tree proto")
+	emptyRootHash        = flag.String("empty_root_hash", "", "If set, the hex-encoded root hash of the new log while it has no leaves, instead of the hasher's own")
+	templateName         = flag.String("template", "", "Name of the tree template to create the new tree from; flags explicitly set override the template")
+	privateKeyFormat     = flag.String("private_key_format", "", "Type of protobuf message to send the key as (PrivateKey, PEMKeyFile, or PKCS11ConfigFile). If empty, a key will be generated for you by Trillian.")
 
 	configFile = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags")
 
@@ -72,11 +102,6 @@ func createTree(ctx context.Context) (*trillian.Tree, error) {
 		return nil, errAdminAddrNotSet
 	}
 
-	req, err := newRequest()
-	if err != nil {
-		return nil, err
-	}
-
 	dialOpts, err := rpcflags.NewClientDialOptionsFromFlags()
 	if err != nil {
 		return nil, fmt.Errorf("failed to determine dial options: %v", err)
@@ -92,10 +117,65 @@ func createTree(ctx context.Context) (*trillian.Tree, error) {
 	mapClient := trillian.NewTrillianMapClient(conn)
 	logClient := trillian.NewTrillianLogClient(conn)
 
+	var tmpl *trillian.TreeTemplate
+	if *templateName != "" {
+		tmpl, err = adminClient.GetTreeTemplate(ctx, &trillian.GetTreeTemplateRequest{Name: *templateName})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tree template %q: %v", *templateName, err)
+		}
+	}
+
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	req, err := newRequest(tmpl, set)
+	if err != nil {
+		return nil, err
+	}
+
 	return client.CreateAndInitTree(ctx, req, adminClient, mapClient, logClient)
 }
 
-func newRequest() (*trillian.CreateTreeRequest, error) {
+// treeFlags maps the flags describing the new tree to functions copying the
+// corresponding field of the tree, which are used to apply the flags set
+// explicitly on top of a template.
+var treeFlags = map[string]func(dst, src *trillian.Tree){
+	"tree_id":                   func(dst, src *trillian.Tree) { dst.TreeId = src.TreeId },
+	"tree_state":                func(dst, src *trillian.Tree) { dst.TreeState = src.TreeState },
+	"tree_type":                 func(dst, src *trillian.Tree) { dst.TreeType = src.TreeType },
+	"hash_strategy":             func(dst, src *trillian.Tree) { dst.HashStrategy = src.HashStrategy },
+	"hash_algorithm":            func(dst, src *trillian.Tree) { dst.HashAlgorithm = src.HashAlgorithm },
+	"signature_algorithm":       func(dst, src *trillian.Tree) { dst.SignatureAlgorithm = src.SignatureAlgorithm },
+	"display_name":              func(dst, src *trillian.Tree) { dst.DisplayName = src.DisplayName },
+	"description":               func(dst, src *trillian.Tree) { dst.Description = src.Description },
+	"max_root_duration":         func(dst, src *trillian.Tree) { dst.MaxRootDuration = src.MaxRootDuration },
+	"signing_interval":          func(dst, src *trillian.Tree) { dst.SigningInterval = src.SigningInterval },
+	"max_queue_age":             func(dst, src *trillian.Tree) { dst.MaxQueueAge = src.MaxQueueAge },
+	"ordered_leaf_timestamps":   func(dst, src *trillian.Tree) { dst.OrderedLeafTimestamps = src.OrderedLeafTimestamps },
+	"caller_leaf_identity_hash": func(dst, src *trillian.Tree) { dst.CallerLeafIdentityHash = src.CallerLeafIdentityHash },
+	"hash_extra_data":           func(dst, src *trillian.Tree) { dst.HashExtraData = src.HashExtraData },
+	"hash_only":                 func(dst, src *trillian.Tree) { dst.HashOnly = src.HashOnly },
+	"log_root_encoding":         func(dst, src *trillian.Tree) { dst.LogRootEncoding = src.LogRootEncoding },
+	"timestamp_granularity":     func(dst, src *trillian.Tree) { dst.TimestampGranularity = src.TimestampGranularity },
+	"leaf_compression":          func(dst, src *trillian.Tree) { dst.LeafCompression = src.LeafCompression },
+	"max_tree_size":             func(dst, src *trillian.Tree) { dst.MaxTreeSize = src.MaxTreeSize },
+	"queue_write_ahead":         func(dst, src *trillian.Tree) { dst.QueueWriteAhead = src.QueueWriteAhead },
+	"leaf_encryption":           func(dst, src *trillian.Tree) { dst.LeafEncryption = src.LeafEncryption },
+	"sort_by_queue_timestamp":   func(dst, src *trillian.Tree) { dst.SortByQueueTimestamp = src.SortByQueueTimestamp },
+	"leaf_ordering_key_source":  func(dst, src *trillian.Tree) { dst.LeafOrderingKey = src.LeafOrderingKey },
+	"leaf_ordering_key_offset":  func(dst, src *trillian.Tree) { dst.LeafOrderingKey = src.LeafOrderingKey },
+	"leaf_ordering_key_length":  func(dst, src *trillian.Tree) { dst.LeafOrderingKey = src.LeafOrderingKey },
+	"leaf_tombstones":           func(dst, src *trillian.Tree) { dst.LeafTombstones = src.LeafTombstones },
+	"empty_root_hash":           func(dst, src *trillian.Tree) { dst.EmptyRootHash = src.EmptyRootHash },
+	"leaf_index_offset":         func(dst, src *trillian.Tree) { dst.LeafIndexOffset = src.LeafIndexOffset },
+	"leaf_compression_dictionary": func(dst, src *trillian.Tree) {
+		dst.LeafCompressionDictionaries = src.LeafCompressionDictionaries
+	},
+}
+
+// newRequest returns the request to create the tree described by the flags.
+// If tmpl isn't nil, the tree is created from it, with the flags named in set
+// overriding its settings.
+func newRequest(tmpl *trillian.TreeTemplate, set map[string]bool) (*trillian.CreateTreeRequest, error) {
 	ts, ok := trillian.TreeState_value[*treeState]
 	if !ok {
 		return nil, fmt.Errorf("unknown TreeState: %v", *treeState)
@@ -121,28 +201,102 @@ func newRequest() (*trillian.CreateTreeRequest, error) {
 		return nil, fmt.Errorf("unknown SignatureAlgorithm: %v", *signatureAlgorithm)
 	}
 
+	le, ok := trillian.LogRootEncoding_value[*logRootEncoding]
+	if !ok {
+		return nil, fmt.Errorf("unknown LogRootEncoding: %v", *logRootEncoding)
+	}
+
+	tg, ok := trillian.TimestampGranularity_value[*timestampGranularity]
+	if !ok {
+		return nil, fmt.Errorf("unknown TimestampGranularity: %v", *timestampGranularity)
+	}
+
+	lc, ok := trillian.LeafCompression_value[*leafCompression]
+	if !ok {
+		return nil, fmt.Errorf("unknown LeafCompression: %v", *leafCompression)
+	}
+
+	erh, err := hex.DecodeString(*emptyRootHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --empty_root_hash: %v", err)
+	}
+
 	ctr := &trillian.CreateTreeRequest{Tree: &trillian.Tree{
-		TreeState:          trillian.TreeState(ts),
-		TreeType:           trillian.TreeType(tt),
-		HashStrategy:       trillian.HashStrategy(hs),
-		HashAlgorithm:      sigpb.DigitallySigned_HashAlgorithm(ha),
-		SignatureAlgorithm: sigpb.DigitallySigned_SignatureAlgorithm(sa),
-		DisplayName:        *displayName,
-		Description:        *description,
-		MaxRootDuration:    ptypes.DurationProto(*maxRootDuration),
+		TreeId:                 *treeID,
+		TreeState:              trillian.TreeState(ts),
+		TreeType:               trillian.TreeType(tt),
+		HashStrategy:           trillian.HashStrategy(hs),
+		HashAlgorithm:          sigpb.DigitallySigned_HashAlgorithm(ha),
+		SignatureAlgorithm:     sigpb.DigitallySigned_SignatureAlgorithm(sa),
+		DisplayName:            *displayName,
+		Description:            *description,
+		MaxRootDuration:        ptypes.DurationProto(*maxRootDuration),
+		OrderedLeafTimestamps:  *orderedTimestamps,
+		CallerLeafIdentityHash: *callerIdentityHash,
+		HashExtraData:          *hashExtraData,
+		HashOnly:               *hashOnly,
+		LogRootEncoding:        trillian.LogRootEncoding(le),
+		TimestampGranularity:   trillian.TimestampGranularity(tg),
+		LeafCompression:        trillian.LeafCompression(lc),
+		MaxTreeSize:            *maxTreeSize,
+		QueueWriteAhead:        *queueWriteAhead,
+		SortByQueueTimestamp:   *sortByQueueTime,
+		LeafTombstones:         *leafTombstones,
+		EmptyRootHash:          erh,
+		LeafIndexOffset:        *leafIndexOffset,
 	}}
+	if *leafCompressionDict != "" {
+		dict, err := ioutil.ReadFile(*leafCompressionDict)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --leaf_compression_dictionary: %v", err)
+		}
+		ctr.Tree.LeafCompressionDictionaries = []*trillian.LeafCompressionDictionary{{Version: 1, Dictionary: dict}}
+	}
+	if *leafEncryption {
+		ctr.Tree.LeafEncryption = &trillian.LeafEncryption{}
+	}
+	if *signingInterval != 0 {
+		ctr.Tree.SigningInterval = ptypes.DurationProto(*signingInterval)
+	}
+	if *maxQueueAge != 0 {
+		ctr.Tree.MaxQueueAge = ptypes.DurationProto(*maxQueueAge)
+	}
+	if *leafKeySource != "" {
+		src, ok := trillian.LeafOrderingKey_Source_value[*leafKeySource]
+		if !ok {
+			return nil, fmt.Errorf("unknown LeafOrderingKey source: %v", *leafKeySource)
+		}
+		ctr.Tree.LeafOrderingKey = &trillian.LeafOrderingKey{
+			Source: trillian.LeafOrderingKey_Source(src),
+			Offset: int32(*leafKeyOffset),
+			Length: int32(*leafKeyLength),
+		}
+	}
+	if tmpl != nil {
+		tree := proto.Clone(ctr.Tree).(*trillian.Tree)
+		proto.Merge(tree, tmpl.Tree)
+		for name := range set {
+			if copyField, ok := treeFlags[name]; ok {
+				copyField(tree, ctr.Tree)
+			}
+		}
+		ctr.Tree = tree
+	}
 	glog.Infof("Creating tree %+v", ctr.Tree)
 
-	if *privateKeyFormat != "" {
+	switch {
+	case *privateKeyFormat != "":
 		pk, err := keys.New(*privateKeyFormat)
 		if err != nil {
 			return nil, err
 		}
 		ctr.Tree.PrivateKey = pk
-	} else {
+	case tmpl.GetKeySpec() != nil && !set["signature_algorithm"]:
+		ctr.KeySpec = proto.Clone(tmpl.KeySpec).(*keyspb.Specification)
+	default:
 		ctr.KeySpec = &keyspb.Specification{}
 
-		switch sigpb.DigitallySigned_SignatureAlgorithm(sa) {
+		switch sa := ctr.Tree.SignatureAlgorithm; sa {
 		case sigpb.DigitallySigned_ECDSA:
 			ctr.KeySpec.Params = &keyspb.Specification_EcdsaParams{
 				EcdsaParams: &keyspb.Specification_ECDSA{},
@@ -27,6 +27,7 @@ import (
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/trillian"
+	"github.com/google/trillian/crypto/keyspb"
 	"github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/testonly"
 	"github.com/google/trillian/testonly/flagsaver"
@@ -48,11 +49,16 @@ var defaultTree = &trillian.Tree{
 type testCase struct {
 	desc        string
 	setFlags    func()
+	template    *trillian.TreeTemplate
+	templateErr error
 	validateErr error
 	createErr   error
 	initErr     error
 	wantErr     bool
 	wantTree    *trillian.Tree
+	// wantReq, if set, changes the Tree of the CreateTreeRequest sent with all
+	// flag defaults into the one the test expects to be sent.
+	wantReq func(*trillian.Tree)
 }
 
 func mustMarshalAny(p proto.Message) *any.Any {
@@ -76,6 +82,145 @@ func TestCreateTree(t *testing.T) {
 			// runTest sets mandatory options, so no need to provide a setFlags func.
 			wantTree: defaultTree,
 		},
+		{
+			desc:     "treeID",
+			setFlags: func() { *treeID = 12345 },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.TreeId = 12345 },
+		},
+		{
+			desc:     "orderedLeafTimestamps",
+			setFlags: func() { *orderedTimestamps = true },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.OrderedLeafTimestamps = true },
+		},
+		{
+			desc:     "callerLeafIdentityHash",
+			setFlags: func() { *callerIdentityHash = true },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.CallerLeafIdentityHash = true },
+		},
+		{
+			desc:     "hashExtraData",
+			setFlags: func() { *hashExtraData = true },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.HashExtraData = true },
+		},
+		{
+			desc:     "hashOnly",
+			setFlags: func() { *hashOnly = true },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.HashOnly = true },
+		},
+		{
+			desc:     "logRootEncoding",
+			setFlags: func() { *logRootEncoding = trillian.LogRootEncoding_CBOR.String() },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.LogRootEncoding = trillian.LogRootEncoding_CBOR },
+		},
+		{
+			desc:        "invalidLogRootEncoding",
+			setFlags:    func() { *logRootEncoding = "XML" },
+			validateErr: errors.New("unknown LogRootEncoding"),
+			wantErr:     true,
+		},
+		{
+			desc:     "timestampGranularity",
+			setFlags: func() { *timestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND.String() },
+			wantTree: defaultTree,
+			wantReq: func(tree *trillian.Tree) {
+				tree.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND
+			},
+		},
+		{
+			desc:        "invalidTimestampGranularity",
+			setFlags:    func() { *timestampGranularity = "MINUTE" },
+			validateErr: errors.New("unknown TimestampGranularity"),
+			wantErr:     true,
+		},
+		{
+			desc:     "leafCompression",
+			setFlags: func() { *leafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP.String() },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP },
+		},
+		{
+			desc:        "invalidLeafCompression",
+			setFlags:    func() { *leafCompression = "LZ4" },
+			validateErr: errors.New("unknown LeafCompression"),
+			wantErr:     true,
+		},
+		{
+			desc:     "maxTreeSize",
+			setFlags: func() { *maxTreeSize = 1000 },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.MaxTreeSize = 1000 },
+		},
+		{
+			desc:     "queueWriteAhead",
+			setFlags: func() { *queueWriteAhead = true },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.QueueWriteAhead = true },
+		},
+		{
+			desc:     "leafEncryption",
+			setFlags: func() { *leafEncryption = true },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.LeafEncryption = &trillian.LeafEncryption{} },
+		},
+		{
+			desc:     "sortByQueueTimestamp",
+			setFlags: func() { *sortByQueueTime = true },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.SortByQueueTimestamp = true },
+		},
+		{
+			desc: "leafOrderingKey",
+			setFlags: func() {
+				*leafKeySource = trillian.LeafOrderingKey_EXTRA_DATA.String()
+				*leafKeyOffset = 2
+			},
+			wantTree: defaultTree,
+			wantReq: func(tree *trillian.Tree) {
+				tree.LeafOrderingKey = &trillian.LeafOrderingKey{Source: trillian.LeafOrderingKey_EXTRA_DATA, Offset: 2}
+			},
+		},
+		{
+			desc:     "leafTombstones",
+			setFlags: func() { *leafTombstones = true },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.LeafTombstones = true },
+		},
+		{
+			desc:     "emptyRootHash",
+			setFlags: func() { *emptyRootHash = "00" },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.EmptyRootHash = []byte{0} },
+		},
+		{
+			desc:        "invalidEmptyRootHash",
+			setFlags:    func() { *emptyRootHash = "zz" },
+			validateErr: errors.New("invalid --empty_root_hash"),
+			wantErr:     true,
+		},
+		{
+			desc:     "leafIndexOffset",
+			setFlags: func() { *leafIndexOffset = 1000 },
+			wantTree: defaultTree,
+			wantReq:  func(tree *trillian.Tree) { tree.LeafIndexOffset = 1000 },
+		},
+		{
+			desc: "signingCadence",
+			setFlags: func() {
+				*signingInterval = time.Minute
+				*maxQueueAge = 10 * time.Second
+			},
+			wantTree: defaultTree,
+			wantReq: func(tree *trillian.Tree) {
+				tree.SigningInterval = ptypes.DurationProto(time.Minute)
+				tree.MaxQueueAge = ptypes.DurationProto(10 * time.Second)
+			},
+		},
 		{
 			desc: "nonDefaultOpts",
 			setFlags: func() {
@@ -105,12 +250,30 @@ func TestCreateTree(t *testing.T) {
 			validateErr: errors.New("unknown TreeType"),
 			wantErr:     true,
 		},
+		{
+			desc:        "invalidLeafOrderingKeySource",
+			setFlags:    func() { *leafKeySource = "LLAMA!" },
+			validateErr: errors.New("unknown LeafOrderingKey source"),
+			wantErr:     true,
+		},
 		{
 			desc:        "invalidKeyTypeOpts",
 			setFlags:    func() { *privateKeyFormat = "LLAMA!!" },
 			validateErr: errors.New("key protobuf must be one of"),
 			wantErr:     true,
 		},
+		{
+			desc:     "template",
+			setFlags: func() { *templateName = "ct" },
+			template: &trillian.TreeTemplate{Name: "ct", Tree: &trillian.Tree{TreeType: trillian.TreeType_LOG}},
+			wantTree: defaultTree,
+		},
+		{
+			desc:        "templateNotFound",
+			setFlags:    func() { *templateName = "missing" },
+			templateErr: status.Errorf(codes.NotFound, "tree template not found"),
+			wantErr:     true,
+		},
 		{
 			desc:      "createErr",
 			createErr: status.Errorf(codes.Unavailable, "create tree failed"),
@@ -139,6 +302,80 @@ func TestCreateTree(t *testing.T) {
 	})
 }
 
+func TestNewRequest_Template(t *testing.T) {
+	rsaSpec := &keyspb.Specification{Params: &keyspb.Specification_RsaParams{RsaParams: &keyspb.Specification_RSA{Bits: 4096}}}
+	tmpl := &trillian.TreeTemplate{
+		Name: "ct",
+		Tree: &trillian.Tree{
+			TreeType:           trillian.TreeType_PREORDERED_LOG,
+			SignatureAlgorithm: sigpb.DigitallySigned_RSA,
+			Description:        "CT log",
+			MaxRootDuration:    ptypes.DurationProto(24 * time.Hour),
+			LogRootEncoding:    trillian.LogRootEncoding_CBOR,
+		},
+		KeySpec: rsaSpec,
+	}
+
+	fromTemplate := proto.Clone(defaultTree).(*trillian.Tree)
+	fromTemplate.PrivateKey = nil
+	proto.Merge(fromTemplate, tmpl.Tree)
+
+	for _, test := range []struct {
+		desc        string
+		flags       map[string]string
+		wantTree    func(*trillian.Tree)
+		wantKeySpec *keyspb.Specification
+	}{
+		{
+			desc:        "templateOnly",
+			wantTree:    func(*trillian.Tree) {},
+			wantKeySpec: rsaSpec,
+		},
+		{
+			desc:  "flagsOverride",
+			flags: map[string]string{"description": "", "max_root_duration": "1h", "tree_type": "LOG"},
+			wantTree: func(tree *trillian.Tree) {
+				tree.Description = ""
+				tree.MaxRootDuration = ptypes.DurationProto(time.Hour)
+				tree.TreeType = trillian.TreeType_LOG
+			},
+			wantKeySpec: rsaSpec,
+		},
+		{
+			desc:  "signatureAlgorithmOverride",
+			flags: map[string]string{"signature_algorithm": "ECDSA"},
+			wantTree: func(tree *trillian.Tree) {
+				tree.SignatureAlgorithm = sigpb.DigitallySigned_ECDSA
+			},
+			wantKeySpec: &keyspb.Specification{Params: &keyspb.Specification_EcdsaParams{EcdsaParams: &keyspb.Specification_ECDSA{}}},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			defer flagsaver.Save().MustRestore()
+			set := make(map[string]bool)
+			for name, value := range test.flags {
+				if err := flag.Set(name, value); err != nil {
+					t.Fatalf("flag.Set(%q, %q): %v", name, value, err)
+				}
+				set[name] = true
+			}
+
+			req, err := newRequest(tmpl, set)
+			if err != nil {
+				t.Fatalf("newRequest(): %v", err)
+			}
+			wantTree := proto.Clone(fromTemplate).(*trillian.Tree)
+			test.wantTree(wantTree)
+			if !proto.Equal(req.Tree, wantTree) {
+				t.Errorf("newRequest().Tree = %v, want %v", req.Tree, wantTree)
+			}
+			if !proto.Equal(req.KeySpec, test.wantKeySpec) {
+				t.Errorf("newRequest().KeySpec = %v, want %v", req.KeySpec, test.wantKeySpec)
+			}
+		})
+	}
+}
+
 // runTest executes the createtree command against a fake TrillianAdminServer
 // for each of the provided tests, and checks that the tree in the request is
 // as expected, or an expected error occurs.
@@ -163,19 +400,27 @@ func runTest(t *testing.T, tests []*testCase) {
 				tc.setFlags()
 			}
 
-			call := s.Admin.EXPECT().CreateTree(gomock.Any(), gomock.Any()).Return(tc.wantTree, tc.createErr)
-			expectCalls(call, tc.createErr, tc.validateErr)
+			if *templateName != "" {
+				s.Admin.EXPECT().GetTreeTemplate(gomock.Any(), &trillian.GetTreeTemplateRequest{Name: *templateName}).Return(tc.template, tc.templateErr)
+			}
+			var gotReq *trillian.CreateTreeRequest
+			call := s.Admin.EXPECT().CreateTree(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, req *trillian.CreateTreeRequest) (*trillian.Tree, error) {
+					gotReq = req
+					return tc.wantTree, tc.createErr
+				})
+			expectCalls(call, tc.createErr, tc.validateErr, tc.templateErr)
 			switch *treeType {
 			case "LOG":
 				call := s.Log.EXPECT().InitLog(gomock.Any(), gomock.Any()).Return(&trillian.InitLogResponse{}, tc.initErr)
-				expectCalls(call, tc.initErr, tc.validateErr, tc.createErr)
+				expectCalls(call, tc.initErr, tc.validateErr, tc.templateErr, tc.createErr)
 				call = s.Log.EXPECT().GetLatestSignedLogRoot(gomock.Any(), gomock.Any()).Return(&trillian.GetLatestSignedLogRootResponse{}, nil)
-				expectCalls(call, nil, tc.validateErr, tc.createErr, tc.initErr)
+				expectCalls(call, nil, tc.validateErr, tc.templateErr, tc.createErr, tc.initErr)
 			case "MAP":
 				call := s.Map.EXPECT().InitMap(gomock.Any(), gomock.Any()).Return(&trillian.InitMapResponse{}, tc.initErr)
-				expectCalls(call, tc.initErr, tc.validateErr, tc.createErr)
+				expectCalls(call, tc.initErr, tc.validateErr, tc.templateErr, tc.createErr)
 				call = s.Map.EXPECT().GetSignedMapRootByRevision(gomock.Any(), gomock.Any()).Return(&trillian.GetSignedMapRootResponse{}, nil)
-				expectCalls(call, nil, tc.validateErr, tc.createErr, tc.initErr)
+				expectCalls(call, nil, tc.validateErr, tc.templateErr, tc.createErr, tc.initErr)
 			}
 
 			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -184,6 +429,14 @@ func runTest(t *testing.T, tests []*testCase) {
 			if hasErr := err != nil; hasErr != tc.wantErr {
 				t.Errorf("createTree() '%v', wantErr = %v", err, tc.wantErr)
 			}
+			if tc.wantReq != nil {
+				wantTree := proto.Clone(defaultTree).(*trillian.Tree)
+				wantTree.PrivateKey = nil
+				tc.wantReq(wantTree)
+				if !proto.Equal(gotReq.GetTree(), wantTree) {
+					t.Errorf("CreateTree() called with tree %v, want %v", gotReq.GetTree(), wantTree)
+				}
+			}
 		})
 	}
 }
@@ -29,6 +29,12 @@ import (
 var (
 	pemKeyPath = flag.String("pem_key_path", "", "Path to the private key PEM file")
 	pemKeyPass = flag.String("pem_key_password", "", "Password of the private key PEM file")
+
+	// Alternative to --pem_key_password. With PEMKeyFile, the Trillian servers
+	// obtain the password from the named source, defined by their
+	// --pem_password_sources flag, whenever they load the key, so it isn't
+	// stored in the tree.
+	pemKeyPassSource = flag.String("pem_key_password_source", "", "Name of the password source, configured on the Trillian servers, holding the password of the private key PEM file (PEMKeyFile only)")
 )
 
 func init() {
@@ -40,13 +46,19 @@ func pemKeyFileProtoFromFlags() (proto.Message, error) {
 	if *pemKeyPath == "" {
 		return nil, errors.New("empty pem_key_path")
 	}
-	if *pemKeyPass == "" {
+	// The password source is defined on the servers, so it can't be resolved
+	// here.
+	switch {
+	case *pemKeyPass != "" && *pemKeyPassSource != "":
+		return nil, errors.New("at most one of pem_key_password and pem_key_password_source may be set")
+	case *pemKeyPass == "" && *pemKeyPassSource == "":
 		return nil, fmt.Errorf("empty password for PEM key file %q", *pemKeyPath)
 	}
 
 	return &keyspb.PEMKeyFile{
-		Path:     *pemKeyPath,
-		Password: *pemKeyPass,
+		Path:           *pemKeyPath,
+		Password:       *pemKeyPass,
+		PasswordSource: *pemKeyPassSource,
 	}, nil
 }
 
@@ -54,6 +66,9 @@ func privateKeyProtoFromFlags() (proto.Message, error) {
 	if *pemKeyPath == "" {
 		return nil, errors.New("empty pem_key_path")
 	}
+	if *pemKeyPassSource != "" {
+		return nil, errors.New("pem_key_password_source is only supported with PEMKeyFile")
+	}
 
 	key, err := pem.ReadPrivateKeyFile(*pemKeyPath, *pemKeyPass)
 	if err != nil {
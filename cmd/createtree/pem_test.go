@@ -33,6 +33,11 @@ func TestWithPEMKeyFile(t *testing.T) {
 		Path:     pemPath,
 		Password: pemPassword,
 	})
+	wantSourceTree := proto.Clone(defaultTree).(*trillian.Tree)
+	wantSourceTree.PrivateKey = mustMarshalAny(&keyspb.PEMKeyFile{
+		Path:           pemPath,
+		PasswordSource: "log-key",
+	})
 
 	runTest(t, []*testCase{
 		{
@@ -64,6 +69,27 @@ func TestWithPEMKeyFile(t *testing.T) {
 			},
 			wantTree: wantTree,
 		},
+		{
+			desc: "pemKeyPass and pemKeyPassSource",
+			setFlags: func() {
+				*privateKeyFormat = "PEMKeyFile"
+				*pemKeyPath = pemPath
+				*pemKeyPass = pemPassword
+				*pemKeyPassSource = "log-key"
+			},
+			validateErr: errors.New("at most one of pem_key_password and pem_key_password_source may be set"),
+			wantErr:     true,
+		},
+		{
+			desc: "valid pemKeyPath and pemKeyPassSource",
+			setFlags: func() {
+				*privateKeyFormat = "PEMKeyFile"
+				*pemKeyPath = pemPath
+				*pemKeyPass = ""
+				*pemKeyPassSource = "log-key"
+			},
+			wantTree: wantSourceTree,
+		},
 	})
 }
 
@@ -115,5 +141,16 @@ func TestWithPrivateKey(t *testing.T) {
 			},
 			wantTree: wantTree,
 		},
+		{
+			desc: "pemKeyPassSource",
+			setFlags: func() {
+				*privateKeyFormat = "PrivateKey"
+				*pemKeyPath = pemPath
+				*pemKeyPass = ""
+				*pemKeyPassSource = "log-key"
+			},
+			validateErr: errors.New("pem_key_password_source is only supported with PEMKeyFile"),
+			wantErr:     true,
+		},
 	})
 }
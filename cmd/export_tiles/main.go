@@ -0,0 +1,82 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains the implementation and entry point for the
+// export_tiles command, which exports the Merkle tree of a log as static files
+// in the layout of package merkle/tiles, from which a stateless server, e.g.
+// one backed by a CDN, can serve inclusion and consistency proofs.
+//
+// The command talks to storage directly, and only reads from it. Running it
+// again for a larger tree only writes the tiles which are new.
+//
+// Example usage:
+// $ ./export_tiles --mysql_uri=... --tree_id=123 --output_dir=/srv/log123
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/merkle/tiles"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/trees"
+
+	// Register supported storage providers.
+	_ "github.com/google/trillian/storage/cloudspanner"
+	_ "github.com/google/trillian/storage/mysql"
+
+	// Load hashers
+	_ "github.com/google/trillian/merkle/rfc6962"
+)
+
+var (
+	treeID     = flag.Int64("tree_id", 0, "ID of the log to export")
+	treeSize   = flag.Int64("tree_size", 0, "Size of the tree to export, which must have a retained signed root, or 0 to export the tree of the latest root")
+	tileHeight = flag.Uint("tile_height", tiles.DefaultHeight, "Height of the exported tiles, which the server of the tiles must be configured with")
+	outputDir  = flag.String("output_dir", "", "Directory to export the tiles to, which may hold tiles of an earlier export of the same log at a smaller or equal size and the same height")
+)
+
+func main() {
+	flag.Parse()
+	defer glog.Flush()
+	ctx := context.Background()
+
+	if *treeID == 0 {
+		glog.Exit("--tree_id must be set")
+	}
+	if *outputDir == "" {
+		glog.Exit("--output_dir must be set")
+	}
+
+	sp, err := storage.NewProviderFromFlags(monitoring.InertMetricFactory{})
+	if err != nil {
+		glog.Exitf("Failed to get storage provider: %v", err)
+	}
+	defer sp.Close()
+
+	tree, err := trees.GetTree(ctx, sp.AdminStorage(), *treeID, trees.NewGetOpts(trees.Query, trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG))
+	if err != nil {
+		glog.Exitf("Failed to get log %d: %v", *treeID, err)
+	}
+	report, err := log.ExportTiles(ctx, sp.LogStorage(), tree, tiles.Dir(*outputDir), log.ExportOptions{TreeSize: *treeSize, TileHeight: *tileHeight})
+	if err != nil {
+		glog.Exitf("Export failed: %v", err)
+	}
+	fmt.Printf("Log %d exported at size %d: wrote %d tiles, %d already existed\n", *treeID, report.Root.TreeSize, report.TilesWritten, report.TilesSkipped)
+}
@@ -24,13 +24,9 @@ import (
 )
 
 func parseFlags(file string) error {
-	args, valid := shell.Split(file)
-	if !valid {
-		return errors.New("flag file contains unclosed quotations")
-	}
-	// Expand any environment variables in the args
-	for i := range args {
-		args[i] = os.ExpandEnv(args[i])
+	args, err := splitFlagFile(file)
+	if err != nil {
+		return err
 	}
 
 	if err := flag.CommandLine.Parse(args); err != nil {
@@ -43,6 +39,20 @@ func parseFlags(file string) error {
 	return nil
 }
 
+// splitFlagFile splits the contents of a flag file into arguments, expanding
+// any environment variables.
+func splitFlagFile(file string) ([]string, error) {
+	args, valid := shell.Split(file)
+	if !valid {
+		return nil, errors.New("flag file contains unclosed quotations")
+	}
+	// Expand any environment variables in the args
+	for i := range args {
+		args[i] = os.ExpandEnv(args[i])
+	}
+	return args, nil
+}
+
 // ParseFlagFile parses a set of flags from a file at the provided
 // path. Re-calls flag.Parse() after parsing the flags in the file
 // so that flags provided on the command line take precedence over
@@ -54,3 +64,45 @@ func ParseFlagFile(path string) error {
 	}
 	return parseFlags(string(file))
 }
+
+// ParseFlagFileInto parses the flags of fs from a file at the provided path,
+// and then from the command line, so that they take precedence, as
+// ParseFlagFile does for flag.CommandLine. The other flags of flag.CommandLine
+// are accepted but ignored, so that fs can re-read a subset of the flags of a
+// file without changing flag.CommandLine, e.g. while other goroutines read
+// it. fs should be created with flag.ContinueOnError, so that bad flags are
+// returned as errors.
+func ParseFlagFileInto(fs *flag.FlagSet, path string) error {
+	file, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return parseFlagsInto(fs, string(file), os.Args[1:])
+}
+
+func parseFlagsInto(fs *flag.FlagSet, file string, cmdLine []string) error {
+	args, err := splitFlagFile(file)
+	if err != nil {
+		return err
+	}
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		if fs.Lookup(f.Name) == nil {
+			b, ok := f.Value.(interface{ IsBoolFlag() bool })
+			fs.Var(ignoredValue{isBool: ok && b.IsBoolFlag()}, f.Name, f.Usage)
+		}
+	})
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return fs.Parse(cmdLine)
+}
+
+// ignoredValue is a flag.Value which discards what it is set to. It doesn't
+// refer to the flag.Value it stands in for, so that parsing doesn't read it.
+type ignoredValue struct {
+	isBool bool
+}
+
+func (ignoredValue) String() string     { return "" }
+func (ignoredValue) Set(string) error   { return nil }
+func (v ignoredValue) IsBoolFlag() bool { return v.isBool }
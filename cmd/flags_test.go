@@ -16,6 +16,7 @@ package cmd
 
 import (
 	"flag"
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -109,3 +110,63 @@ func TestParseFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestParseFlagsInto(t *testing.T) {
+	var other string
+	var verbose bool
+	flag.StringVar(&other, "other", "", "")
+	flag.BoolVar(&verbose, "verbose", false, "")
+
+	for _, tc := range []struct {
+		name        string
+		contents    string
+		cliArgs     []string
+		expectedErr string
+		expectedX   int64
+	}{
+		{
+			name:      "flag in file among others",
+			contents:  "-other one -verbose -x 2",
+			expectedX: 2,
+		},
+		{
+			name:      "flag overridden by command-line",
+			contents:  "-x 2",
+			cliArgs:   []string{"-other", "one", "-x", "3"},
+			expectedX: 3,
+		},
+		{
+			name:        "undefined flag",
+			contents:    "-x 2 -typo 3",
+			expectedErr: "flag provided but not defined: -typo",
+		},
+		{
+			name:        "bad value",
+			contents:    "-x two",
+			expectedErr: `invalid value "two" for flag -x: parse error`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var x int64
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(ioutil.Discard)
+			fs.Int64Var(&x, "x", 0, "")
+			err := parseFlagsInto(fs, tc.contents, tc.cliArgs)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Errorf("parseFlagsInto() = %v, want %q", err, tc.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFlagsInto() = %v", err)
+			}
+			if x != tc.expectedX {
+				t.Errorf("flag 'x' not properly set: got %v, want %v", x, tc.expectedX)
+			}
+			if other != "" || verbose {
+				t.Errorf("flag.CommandLine changed: other = %q, verbose = %v", other, verbose)
+			}
+		})
+	}
+}
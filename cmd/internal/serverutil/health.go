@@ -0,0 +1,78 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package serverutil
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"google.golang.org/grpc/health"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultHealthCheckInterval is how often the health of a server is checked
+// for the gRPC health service if Main.HealthCheckInterval is zero.
+const DefaultHealthCheckInterval = 5 * time.Second
+
+// healthMonitor reports the result of a health check through the standard
+// gRPC health service, for the server as a whole and for each of its
+// services.
+type healthMonitor struct {
+	srv      *health.Server
+	services []string
+	check    func(context.Context) error
+	deadline time.Duration
+}
+
+func newHealthMonitor(check func(context.Context) error, deadline time.Duration) *healthMonitor {
+	return &healthMonitor{srv: health.NewServer(), check: check, deadline: deadline}
+}
+
+// update runs the health check, and reports its result.
+func (h *healthMonitor) update(ctx context.Context) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if h.check != nil {
+		ctx, cancel := context.WithTimeout(ctx, h.deadline)
+		defer cancel()
+		if err := h.check(ctx); err != nil {
+			glog.V(1).Infof("Health check failed: %v", err)
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+	}
+	h.srv.SetServingStatus("", status)
+	for _, s := range h.services {
+		h.srv.SetServingStatus(s, status)
+	}
+}
+
+// run updates the health every interval until ctx is done.
+func (h *healthMonitor) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		h.update(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// shutdown reports NOT_SERVING from now on, regardless of the health check.
+func (h *healthMonitor) shutdown() {
+	h.srv.Shutdown()
+}
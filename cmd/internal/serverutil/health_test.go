@@ -0,0 +1,63 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package serverutil
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	ctx := context.Background()
+	var healthErr error
+	h := newHealthMonitor(func(context.Context) error { return healthErr }, time.Second)
+	h.services = []string{"trillian.TrillianLog"}
+
+	check := func(desc, service string, want healthpb.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		resp, err := h.srv.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("%v: Check(%q): %v", desc, service, err)
+		}
+		if got := resp.Status; got != want {
+			t.Errorf("%v: Check(%q) = %v, want %v", desc, service, got, want)
+		}
+	}
+
+	h.update(ctx)
+	check("healthy", "", healthpb.HealthCheckResponse_SERVING)
+	check("healthy", "trillian.TrillianLog", healthpb.HealthCheckResponse_SERVING)
+
+	healthErr = errors.New("database unavailable")
+	h.update(ctx)
+	check("unhealthy", "", healthpb.HealthCheckResponse_NOT_SERVING)
+	check("unhealthy", "trillian.TrillianLog", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	// Once shut down, the server stays NOT_SERVING even if it's healthy.
+	healthErr = nil
+	h.update(ctx)
+	check("recovered", "", healthpb.HealthCheckResponse_SERVING)
+	h.shutdown()
+	h.update(ctx)
+	check("shutdown", "", healthpb.HealthCheckResponse_NOT_SERVING)
+	check("shutdown", "trillian.TrillianLog", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	if _, err := h.srv.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown"}); err == nil {
+		t.Error("Check() of unknown service succeeded")
+	}
+}
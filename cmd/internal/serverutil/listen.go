@@ -0,0 +1,79 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverutil
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// unixScheme prefixes endpoints which are paths of Unix domain sockets.
+const unixScheme = "unix://"
+
+// IsUnixEndpoint returns whether endpoint is of the form unix:///path/to/socket,
+// rather than host:port.
+func IsUnixEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, unixScheme)
+}
+
+// Listen listens on endpoint, which is either host:port for TCP, or
+// unix:///path/to/socket for a Unix domain socket. A stale socket left at the
+// path, e.g. by a server which crashed, is removed first. If mode is non-zero,
+// the permissions of the socket are set to it, otherwise they are subject to
+// the umask. The socket is removed when the listener is closed.
+func Listen(endpoint string, mode os.FileMode) (net.Listener, error) {
+	if !IsUnixEndpoint(endpoint) {
+		return net.Listen("tcp", endpoint)
+	}
+	path := strings.TrimPrefix(endpoint, unixScheme)
+	if path == "" {
+		return nil, fmt.Errorf("endpoint %q has no socket path", endpoint)
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and isn't a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %v", err)
+		}
+	}
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			lis.Close()
+			return nil, fmt.Errorf("failed to set socket permissions: %v", err)
+		}
+	}
+	return lis, nil
+}
+
+// ParseSocketMode parses the permissions of Unix domain sockets in octal,
+// e.g. "0660". The empty string parses as zero, i.e. unset.
+func ParseSocketMode(s string) (os.FileMode, error) {
+	if s == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || m&^uint64(os.ModePerm) != 0 {
+		return 0, fmt.Errorf("invalid socket mode %q, want octal permissions such as 0660", s)
+	}
+	return os.FileMode(m), nil
+}
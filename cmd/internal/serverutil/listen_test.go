@@ -0,0 +1,123 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverutil
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listen")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rpc.sock")
+	endpoint := "unix://" + path
+
+	// A stale socket is replaced.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("Listen(): %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	lis, err := Listen(endpoint, 0600)
+	if err != nil {
+		t.Fatalf("Listen(%q): %v", endpoint, err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat(): %v", err)
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(0600); got != want {
+		t.Errorf("socket permissions = %v, want %v", got, want)
+	}
+
+	go func() {
+		if conn, err := lis.Accept(); err == nil {
+			conn.Close()
+		}
+	}()
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Dial(): %v", err)
+	}
+	conn.Close()
+
+	lis.Close()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Stat() after Close() = %v, want not exist", err)
+	}
+}
+
+func TestListenUnixErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "listen")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, nil, 0600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+
+	for _, endpoint := range []string{"unix://", "unix://" + file} {
+		if lis, err := Listen(endpoint, 0); err == nil {
+			lis.Close()
+			t.Errorf("Listen(%q) succeeded, want error", endpoint)
+		}
+	}
+}
+
+func TestListenTCP(t *testing.T) {
+	lis, err := Listen("localhost:0", 0)
+	if err != nil {
+		t.Fatalf("Listen(): %v", err)
+	}
+	defer lis.Close()
+	if got, want := lis.Addr().Network(), "tcp"; got != want {
+		t.Errorf("Listen().Addr().Network() = %v, want %v", got, want)
+	}
+}
+
+func TestParseSocketMode(t *testing.T) {
+	for _, test := range []struct {
+		in      string
+		want    os.FileMode
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "0660", want: 0660},
+		{in: "600", want: 0600},
+		{in: "0999", wantErr: true},
+		{in: "01777", wantErr: true},
+		{in: "rw", wantErr: true},
+	} {
+		got, err := ParseSocketMode(test.in)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("ParseSocketMode(%q) = %v, %v; want err? %v", test.in, got, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("ParseSocketMode(%q) = %v, want %v", test.in, got, test.want)
+		}
+	}
+}
@@ -17,8 +17,10 @@ package serverutil
 
 import (
 	"context"
-	"net"
+	"crypto/tls"
+	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
@@ -27,6 +29,8 @@ import (
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/server/admin"
+	"github.com/google/trillian/server/chaos"
+	"github.com/google/trillian/server/chaos/chaospb"
 	"github.com/google/trillian/server/interceptor"
 	"github.com/google/trillian/util"
 	"github.com/google/trillian/util/clock"
@@ -38,6 +42,7 @@ import (
 
 	etcdnaming "github.com/coreos/etcd/clientv3/naming"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 const (
@@ -54,12 +59,30 @@ const (
 
 // Main encapsulates the data and logic to start a Trillian server (Log or Map).
 type Main struct {
-	// Endpoints for RPC and HTTP servers.
+	// Endpoints for RPC and HTTP servers, either host:port or
+	// unix:///path/to/socket for Unix domain sockets.
 	// HTTP is optional, if empty it'll not be bound.
 	RPCEndpoint, HTTPEndpoint string
+	// SocketMode, if non-zero, sets the permissions of the Unix domain sockets
+	// which the endpoints listen on, e.g. to restrict which local users can
+	// connect to the server.
+	SocketMode os.FileMode
 
 	// TLS Certificate and Key files for the server.
 	TLSCertFile, TLSKeyFile string
+	// TLSClientCAFile, if set, makes the server require client certificates
+	// signed by one of the CAs in the file.
+	TLSClientCAFile string
+	// TLSMinVersion is the minimum TLS version accepted, TLS 1.2 if zero.
+	TLSMinVersion uint16
+	// TLSCipherSuites are the cipher suites accepted for TLS 1.2 and below,
+	// DefaultTLSCipherSuites if empty.
+	TLSCipherSuites []uint16
+	// The TLS certificate and key files are re-read when they change, so
+	// that they can be rotated without restarting the server, and also every
+	// TLSCertReloadInterval if it's positive, e.g. for file systems where
+	// changes aren't reported.
+	TLSCertReloadInterval time.Duration
 
 	DBClose func() error
 
@@ -67,6 +90,34 @@ type Main struct {
 
 	StatsPrefix string
 	QuotaDryRun bool
+	// QuotaKinds overrides the kind of quota which requests to some methods
+	// draw tokens from.
+	QuotaKinds interceptor.QuotaKinds
+
+	// DisableAdminServer skips registering the TrillianAdmin service, e.g. for
+	// binaries that only serve the data plane.
+	DisableAdminServer bool
+
+	// Namespace, if set, is used to determine the namespace claimed by each
+	// RPC, which is attached to the request context. RPCs without a claim are
+	// rejected. Registry storage should enforce the namespace, see package
+	// storage/namespace.
+	Namespace interceptor.NamespaceFunc
+
+	// CallerLabel, if set, labels the RPC metrics of the server with the
+	// caller of each RPC. Only the labels in AllowedCallerLabels are recorded,
+	// others are recorded as monitoring.OtherCaller.
+	CallerLabel         monitoring.CallerLabelFunc
+	AllowedCallerLabels []string
+
+	// EnableReflection registers the gRPC server reflection service on the RPC
+	// endpoint, allowing tools such as grpcurl to introspect the served APIs.
+	EnableReflection bool
+
+	// FaultInjector, if set, injects faults into the RPCs of the server, and
+	// is registered as the Chaos service which configures them. It's only for
+	// chaos testing, see package chaos.
+	FaultInjector *chaos.Injector
 
 	// RegisterServerFn is called to register RPC servers.
 	RegisterServerFn func(*grpc.Server, extension.Registry) error
@@ -78,17 +129,68 @@ type Main struct {
 	// HealthyDeadline is the maximum duration to wait wait for a successful
 	// IsHealthy() call.
 	HealthyDeadline time.Duration
+	// IsHealthy also drives the status reported by the standard gRPC health
+	// service (grpc.health.v1.Health) on the RPC endpoint, for the server as a
+	// whole and for each of its services. It's called every
+	// HealthCheckInterval, or DefaultHealthCheckInterval if zero.
+	HealthCheckInterval time.Duration
+	// DrainDuration is for how long the gRPC health service reports
+	// NOT_SERVING once the server is asked to shut down, before it stops, so
+	// that health checkers can stop sending it RPCs in the meantime.
+	DrainDuration time.Duration
 
 	// AllowedTreeTypes determines which types of trees may be created through the Admin Server
-	// bound by Main. nil means unrestricted.
+	// bound by Main. nil means unrestricted. The first type is the default for trees created
+	// without one.
 	AllowedTreeTypes []trillian.TreeType
+	// UniqueTreeDisplayNames makes the Admin Server reject trees whose display
+	// name is that of an existing non-deleted tree.
+	UniqueTreeDisplayNames bool
 
 	TreeGCEnabled         bool
 	TreeDeleteThreshold   time.Duration
 	TreeDeleteMinInterval time.Duration
 
+	// RootAgeInterval, if positive, is how often the ages of the latest signed
+	// roots of all logs are exported as metrics, along with the number of logs
+	// older than each of RootAgeThresholds. Requires Registry.LogStorage.
+	RootAgeInterval   time.Duration
+	RootAgeThresholds []time.Duration
+
+	// MessageSizeLimits, if set, limits the size of the requests to each RPC
+	// method. Requests over the limit are rejected with
+	// codes.ResourceExhausted.
+	MessageSizeLimits *interceptor.MessageSizeLimits
+
+	// MaxActiveStreams, if positive, limits the number of concurrent
+	// streaming RPCs, e.g. TailLeaves, across all methods. Further streams are
+	// rejected with codes.ResourceExhausted until others end. Active streams
+	// are exported by the active_streams metric, by method, whether limited
+	// or not.
+	MaxActiveStreams int
+
+	// DisablePanicRecovery lets panics in RPC handlers crash the server, e.g.
+	// for debugging, rather than failing the RPC with codes.Internal.
+	DisablePanicRecovery bool
+
+	// Interceptors and StreamInterceptors are chained with the built-in
+	// interceptors of the server, for cross-cutting concerns such as
+	// authorization. They run in order after those attaching the request ID,
+	// recording RPC metrics, wrapping errors, injecting faults and claiming
+	// namespaces, and before the one charging quota and checking trees, so
+	// they can use the request ID and namespace in the context, are covered by
+	// the metrics, and can reject RPCs before quota is charged. Panics in them
+	// are recovered and logged, and fail the RPC with codes.Internal.
+	Interceptors       []grpc.UnaryServerInterceptor
+	StreamInterceptors []grpc.StreamServerInterceptor
+
 	// These will be added to the GRPC server options.
 	ExtraOptions []grpc.ServerOption
+
+	// certs serves the TLS certificate to the RPC and HTTP servers.
+	certs *certReloader
+	// streams counts the active streams of the RPC server.
+	streams *interceptor.StreamLimit
 }
 
 func (m *Main) healthz(rw http.ResponseWriter, req *http.Request) {
@@ -98,10 +200,24 @@ func (m *Main) healthz(rw http.ResponseWriter, req *http.Request) {
 		if err := m.IsHealthy(ctx); err != nil {
 			rw.WriteHeader(http.StatusServiceUnavailable)
 			rw.Write([]byte(err.Error()))
+			m.healthzDetails(rw, req)
 			return
 		}
 	}
 	rw.Write([]byte("ok"))
+	m.healthzDetails(rw, req)
+}
+
+// healthzDetails adds the number of active streams and their limit to the
+// /healthz response if the "verbose" parameter is set, e.g. /healthz?verbose.
+// They're omitted otherwise, so that the response stays "ok" for checkers
+// which compare it.
+func (m *Main) healthzDetails(rw http.ResponseWriter, req *http.Request) {
+	if _, verbose := req.URL.Query()["verbose"]; !verbose || m.streams == nil {
+		return
+	}
+	active, max := m.streams.Active()
+	fmt.Fprintf(rw, "\nactive streams: %d\nmax active streams: %d", active, max)
 }
 
 // Run starts the configured server. Blocks until the server exits.
@@ -111,34 +227,66 @@ func (m *Main) Run(ctx context.Context) error {
 	if m.HealthyDeadline == 0 {
 		m.HealthyDeadline = 5 * time.Second
 	}
+	if m.HealthCheckInterval == 0 {
+		m.HealthCheckInterval = DefaultHealthCheckInterval
+	}
 
 	srv, err := m.newGRPCServer()
 	if err != nil {
 		glog.Exitf("Error creating gRPC server: %v", err)
 	}
 	defer srv.GracefulStop()
+	if m.certs != nil {
+		defer m.certs.Close()
+	}
 
 	defer m.DBClose()
 
 	if err := m.RegisterServerFn(srv, m.Registry); err != nil {
 		return err
 	}
-	trillian.RegisterTrillianAdminServer(srv, admin.New(m.Registry, m.AllowedTreeTypes))
-	reflection.Register(srv)
+	if !m.DisableAdminServer {
+		var deleteThreshold time.Duration
+		if m.TreeGCEnabled {
+			deleteThreshold = m.TreeDeleteThreshold
+		}
+		adminServer := admin.New(m.Registry, m.AllowedTreeTypes, deleteThreshold)
+		adminServer.UniqueDisplayNames = m.UniqueTreeDisplayNames
+		trillian.RegisterTrillianAdminServer(srv, adminServer)
+	}
+	if m.FaultInjector != nil {
+		chaospb.RegisterChaosServer(srv, m.FaultInjector)
+	}
+	health := newHealthMonitor(m.IsHealthy, m.HealthyDeadline)
+	for name := range srv.GetServiceInfo() {
+		health.services = append(health.services, name)
+	}
+	healthpb.RegisterHealthServer(srv, health.srv)
+	if m.EnableReflection {
+		reflection.Register(srv)
+	}
 
 	if endpoint := m.HTTPEndpoint; endpoint != "" {
 		http.Handle("/metrics", promhttp.Handler())
 		http.HandleFunc("/healthz", m.healthz)
 
+		var tlsConfig *tls.Config
+		if m.TLSCertFile != "" || m.TLSKeyFile != "" {
+			if tlsConfig, err = m.tlsConfig(false); err != nil {
+				return err
+			}
+		}
 		go func() {
 			glog.Infof("HTTP server starting on %v", endpoint)
 
-			var err error
-			// Let http.ListenAndServeTLS handle the error case when only one of the flags is set.
-			if m.TLSCertFile != "" || m.TLSKeyFile != "" {
-				err = http.ListenAndServeTLS(endpoint, m.TLSCertFile, m.TLSKeyFile, nil)
-			} else {
-				err = http.ListenAndServe(endpoint, nil)
+			lis, err := Listen(endpoint, m.SocketMode)
+			if err == nil {
+				httpSrv := &http.Server{TLSConfig: tlsConfig}
+				if tlsConfig != nil {
+					err = httpSrv.ServeTLS(lis, "", "")
+				} else {
+					err = httpSrv.Serve(lis)
+				}
 			}
 
 			if err != nil {
@@ -148,11 +296,19 @@ func (m *Main) Run(ctx context.Context) error {
 	}
 
 	glog.Infof("RPC server starting on %v", m.RPCEndpoint)
-	lis, err := net.Listen("tcp", m.RPCEndpoint)
+	lis, err := Listen(m.RPCEndpoint, m.SocketMode)
 	if err != nil {
 		return err
 	}
-	go util.AwaitSignal(ctx, srv.Stop)
+	go health.run(ctx, m.HealthCheckInterval)
+	go util.AwaitSignal(ctx, func() {
+		health.shutdown()
+		if m.DrainDuration > 0 {
+			glog.Infof("Draining for %v before stopping the RPC server", m.DrainDuration)
+			time.Sleep(m.DrainDuration)
+		}
+		srv.Stop()
+	})
 
 	if m.TreeGCEnabled {
 		go func() {
@@ -166,6 +322,18 @@ func (m *Main) Run(ctx context.Context) error {
 		}()
 	}
 
+	if m.RootAgeInterval > 0 && m.Registry.LogStorage != nil {
+		go func() {
+			glog.Info("Root age monitor started")
+			admin.NewRootAgeMonitor(
+				m.Registry.AdminStorage,
+				m.Registry.LogStorage,
+				m.RootAgeThresholds,
+				m.RootAgeInterval,
+				m.Registry.MetricFactory).Run(ctx)
+		}()
+	}
+
 	if err := srv.Serve(lis); err != nil {
 		glog.Errorf("RPC server terminated: %v", err)
 	}
@@ -182,24 +350,69 @@ func (m *Main) Run(ctx context.Context) error {
 // newGRPCServer starts a new Trillian gRPC server.
 func (m *Main) newGRPCServer() (*grpc.Server, error) {
 	stats := monitoring.NewRPCStatsInterceptor(clock.System, m.StatsPrefix, m.Registry.MetricFactory)
+	if m.CallerLabel != nil {
+		var err error
+		stats, err = monitoring.NewRPCStatsInterceptorWithCallers(clock.System, m.StatsPrefix, m.Registry.MetricFactory, m.CallerLabel, m.AllowedCallerLabels)
+		if err != nil {
+			return nil, err
+		}
+	}
 	ti := interceptor.New(m.Registry.AdminStorage, m.Registry.QuotaManager, m.QuotaDryRun, m.Registry.MetricFactory)
+	ti.SetQuotaKinds(m.QuotaKinds)
+
+	interceptors := []grpc.UnaryServerInterceptor{interceptor.RequestID}
+	// Streams over the limit are rejected before anything else is done for
+	// them.
+	m.streams = interceptor.NewStreamLimit(m.MaxActiveStreams, m.Registry.MetricFactory)
+	streamInterceptors := []grpc.StreamServerInterceptor{m.streams.StreamInterceptor}
+	if !m.DisablePanicRecovery {
+		// Panics are recovered from inside the request ID interceptor, so that
+		// they are logged with the ID, and are recorded by the RPC metrics.
+		pr := interceptor.NewPanicRecovery(m.Registry.MetricFactory)
+		interceptors = append(interceptors, pr.UnaryInterceptor)
+		streamInterceptors = append(streamInterceptors, pr.StreamInterceptor)
+	}
+	interceptors = append(interceptors, stats.Interceptor(), interceptor.ErrorWrapper)
+	if m.MessageSizeLimits != nil {
+		// Oversized requests are recorded by the RPC metrics.
+		interceptors = append(interceptors, m.MessageSizeLimits.UnaryInterceptor)
+	}
+	if m.FaultInjector != nil {
+		// Injected faults are recorded by the RPC metrics, like real ones.
+		interceptors = append(interceptors, m.FaultInjector.UnaryInterceptor)
+	}
+	if m.Namespace != nil {
+		interceptors = append(interceptors, interceptor.Namespace(m.Namespace))
+	}
+	for _, i := range m.Interceptors {
+		interceptors = append(interceptors, interceptor.RecoverUnary(i))
+	}
+	interceptors = append(interceptors, ti.UnaryInterceptor)
+
+	// Streaming RPCs aren't covered by RPC metrics or fault injection.
+	if m.MessageSizeLimits != nil {
+		streamInterceptors = append(streamInterceptors, m.MessageSizeLimits.StreamInterceptor)
+	}
+	if m.Namespace != nil {
+		streamInterceptors = append(streamInterceptors, interceptor.StreamNamespace(m.Namespace))
+	}
+	for _, i := range m.StreamInterceptors {
+		streamInterceptors = append(streamInterceptors, interceptor.RecoverStream(i))
+	}
+	streamInterceptors = append(streamInterceptors, ti.StreamInterceptor)
 
 	serverOpts := []grpc.ServerOption{
-		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(
-			stats.Interceptor(),
-			interceptor.ErrorWrapper,
-			ti.UnaryInterceptor,
-		)),
+		grpc.UnaryInterceptor(grpc_middleware.ChainUnaryServer(interceptors...)),
+		grpc.StreamInterceptor(grpc_middleware.ChainStreamServer(streamInterceptors...)),
 	}
 	serverOpts = append(serverOpts, m.ExtraOptions...)
 
-	// Let credentials.NewServerTLSFromFile handle the error case when only one of the flags is set.
-	if m.TLSCertFile != "" || m.TLSKeyFile != "" {
-		serverCreds, err := credentials.NewServerTLSFromFile(m.TLSCertFile, m.TLSKeyFile)
+	if m.TLSCertFile != "" || m.TLSKeyFile != "" || m.TLSClientCAFile != "" {
+		tlsConfig, err := m.tlsConfig(true)
 		if err != nil {
 			return nil, err
 		}
-		serverOpts = append(serverOpts, grpc.Creds(serverCreds))
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 
 	s := grpc.NewServer(serverOpts...)
@@ -209,11 +422,16 @@ func (m *Main) newGRPCServer() (*grpc.Server, error) {
 
 // AnnounceSelf announces this binary's presence to etcd.  Returns a function that
 // should be called on process exit.
-// AnnounceSelf does nothing if client is nil.
+// AnnounceSelf does nothing if client is nil, or if endpoint is a Unix domain
+// socket, which other hosts can't reach.
 func AnnounceSelf(ctx context.Context, client *clientv3.Client, etcdService, endpoint string) func() {
 	if client == nil {
 		return func() {}
 	}
+	if IsUnixEndpoint(endpoint) {
+		glog.Infof("Not announcing Unix domain socket %v in %v", endpoint, etcdService)
+		return func() {}
+	}
 
 	res := etcdnaming.GRPCResolver{Client: client}
 
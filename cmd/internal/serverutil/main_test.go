@@ -0,0 +1,68 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverutil
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/server/interceptor"
+)
+
+func TestHealthz(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		url      string
+		healthy  error
+		wantCode int
+		wantBody string
+	}{
+		{desc: "healthy", url: "/healthz", wantCode: http.StatusOK, wantBody: "ok"},
+		{
+			desc:     "verbose",
+			url:      "/healthz?verbose",
+			wantCode: http.StatusOK,
+			wantBody: "ok\nactive streams: 0\nmax active streams: 100",
+		},
+		{
+			desc:     "unhealthy",
+			url:      "/healthz?verbose",
+			healthy:  errors.New("storage unavailable"),
+			wantCode: http.StatusServiceUnavailable,
+			wantBody: "storage unavailable\nactive streams: 0\nmax active streams: 100",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			m := &Main{
+				IsHealthy:       func(context.Context) error { return test.healthy },
+				HealthyDeadline: time.Second,
+				streams:         interceptor.NewStreamLimit(100, monitoring.InertMetricFactory{}),
+			}
+			rec := httptest.NewRecorder()
+			m.healthz(rec, httptest.NewRequest("GET", test.url, nil))
+			if got, want := rec.Code, test.wantCode; got != want {
+				t.Errorf("healthz() code = %d, want %d", got, want)
+			}
+			if got, want := rec.Body.String(), test.wantBody; got != want {
+				t.Errorf("healthz() body = %q, want %q", got, want)
+			}
+		})
+	}
+}
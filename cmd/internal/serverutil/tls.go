@@ -0,0 +1,256 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverutil
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/golang/glog"
+)
+
+// DefaultTLSCipherSuites are the cipher suites accepted for TLS 1.2 by
+// default: those with forward secrecy and AEAD encryption. The suites of TLS
+// 1.3 aren't configurable.
+var DefaultTLSCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+var (
+	tlsVersions = map[string]uint16{
+		"1.0": tls.VersionTLS10,
+		"1.1": tls.VersionTLS11,
+		"1.2": tls.VersionTLS12,
+		"1.3": tls.VersionTLS13,
+	}
+
+	// tlsCipherSuites are the cipher suites which may be configured, by name.
+	// Suites which are broken, like those using RC4 or 3DES, are left out.
+	tlsCipherSuites = map[string]uint16{
+		"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+		"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+		"TLS_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+		"TLS_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+		"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+		"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+		"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+		"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+		"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+		"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+		"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+		"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305":  tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+		"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305":    tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	}
+)
+
+// ParseTLSVersion returns the TLS version named v, e.g. "1.2".
+func ParseTLSVersion(v string) (uint16, error) {
+	version, ok := tlsVersions[v]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q, want one of 1.0, 1.1, 1.2 or 1.3", v)
+	}
+	return version, nil
+}
+
+// ParseTLSCipherSuites returns the cipher suites named in the comma-separated
+// list s, e.g. "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". An empty list returns
+// nil, i.e. DefaultTLSCipherSuites.
+func ParseTLSCipherSuites(s string) ([]uint16, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var suites []uint16
+	for _, name := range strings.Split(s, ",") {
+		suite, ok := tlsCipherSuites[strings.TrimSpace(name)]
+		if !ok {
+			names := make([]string, 0, len(tlsCipherSuites))
+			for n := range tlsCipherSuites {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown TLS cipher suite %q, want one of %s", name, strings.Join(names, ", "))
+		}
+		suites = append(suites, suite)
+	}
+	return suites, nil
+}
+
+// certReloader serves a certificate and key read from files. They are cached
+// and re-read by the next handshake after either file changes, as reported by
+// fsnotify, or after interval has elapsed, so that they can be rotated without
+// restarting the server.
+type certReloader struct {
+	certFile, keyFile string
+	interval          time.Duration
+	watcher           *fsnotify.Watcher
+
+	mu              sync.Mutex
+	cert            *tls.Certificate
+	certPEM, keyPEM []byte
+	checked         time.Time
+	stale           bool
+}
+
+// newCertReloader returns a certReloader for certFile and keyFile, which
+// fails if they can't be loaded. A non-positive interval means they are only
+// re-read when they change. Close must be called to stop watching them.
+func newCertReloader(certFile, keyFile string, interval time.Duration) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	// The directories are watched rather than the files, as files are often
+	// rotated by renaming, or by swapping symlinks like Kubernetes secrets.
+	watcher, err := fsnotify.NewWatcher()
+	if err == nil {
+		for _, dir := range []string{filepath.Dir(certFile), filepath.Dir(keyFile)} {
+			if err = watcher.Add(dir); err != nil {
+				watcher.Close()
+				break
+			}
+		}
+	}
+	if err != nil {
+		glog.Warningf("Failed to watch TLS certificate files, relying on polling: %v", err)
+		return r, nil
+	}
+	r.watcher = watcher
+	go r.watch()
+	return r, nil
+}
+
+// watch marks the certificate stale whenever the watched directories change.
+func (r *certReloader) watch() {
+	for {
+		select {
+		case _, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+			r.mu.Lock()
+			r.stale = true
+			r.mu.Unlock()
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+			glog.Warningf("Error watching TLS certificate files: %v", err)
+		}
+	}
+}
+
+// Close stops watching the certificate and key files.
+func (r *certReloader) Close() error {
+	if r.watcher == nil {
+		return nil
+	}
+	return r.watcher.Close()
+}
+
+// reload reads the certificate and key files, and parses them if they have
+// changed. It must be called with mu held, or before r is shared.
+func (r *certReloader) reload() error {
+	r.checked = time.Now()
+	r.stale = false
+	certPEM, err := ioutil.ReadFile(r.certFile)
+	if err != nil {
+		return err
+	}
+	keyPEM, err := ioutil.ReadFile(r.keyFile)
+	if err != nil {
+		return err
+	}
+	if r.cert != nil && bytes.Equal(certPEM, r.certPEM) && bytes.Equal(keyPEM, r.keyPEM) {
+		return nil
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate from %q and %q: %v", r.certFile, r.keyFile, err)
+	}
+	if r.cert != nil {
+		glog.Infof("Reloaded TLS certificate from %q", r.certFile)
+	}
+	r.cert, r.certPEM, r.keyPEM = &cert, certPEM, keyPEM
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate. If reloading fails,
+// e.g. while only one of the files has been replaced so that they don't
+// match, the previous certificate is kept until the files change again.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stale || (r.interval > 0 && time.Since(r.checked) >= r.interval) {
+		if err := r.reload(); err != nil {
+			glog.Warningf("Keeping the previous TLS certificate: %v", err)
+		}
+	}
+	return r.cert, nil
+}
+
+// tlsConfig returns the TLS configuration of the servers, which requires
+// client certificates signed by the CAs in TLSClientCAFile if clientAuth is
+// set and it isn't empty.
+func (m *Main) tlsConfig(clientAuth bool) (*tls.Config, error) {
+	if m.TLSCertFile == "" || m.TLSKeyFile == "" {
+		return nil, fmt.Errorf("both a TLS certificate and key file are required, got %q and %q", m.TLSCertFile, m.TLSKeyFile)
+	}
+	if m.certs == nil {
+		certs, err := newCertReloader(m.TLSCertFile, m.TLSKeyFile, m.TLSCertReloadInterval)
+		if err != nil {
+			return nil, err
+		}
+		m.certs = certs
+	}
+	cfg := &tls.Config{
+		GetCertificate: m.certs.GetCertificate,
+		MinVersion:     m.TLSMinVersion,
+		CipherSuites:   m.TLSCipherSuites,
+	}
+	if cfg.MinVersion == 0 {
+		cfg.MinVersion = tls.VersionTLS12
+	}
+	if len(cfg.CipherSuites) == 0 {
+		cfg.CipherSuites = DefaultTLSCipherSuites
+	}
+	if clientAuth && m.TLSClientCAFile != "" {
+		pem, err := ioutil.ReadFile(m.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %q", m.TLSClientCAFile)
+		}
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = pool
+	}
+	return cfg, nil
+}
@@ -0,0 +1,258 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serverutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	if got, err := ParseTLSVersion("1.3"); err != nil || got != tls.VersionTLS13 {
+		t.Errorf("ParseTLSVersion(1.3) = %x, %v; want %x, nil", got, err, tls.VersionTLS13)
+	}
+	if _, err := ParseTLSVersion("SSLv3"); err == nil {
+		t.Error("ParseTLSVersion(SSLv3) succeeded, want error")
+	}
+}
+
+func TestParseTLSCipherSuites(t *testing.T) {
+	for _, test := range []struct {
+		s       string
+		want    []uint16
+		wantErr bool
+	}{
+		{s: ""},
+		{
+			s:    "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305",
+			want: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305},
+		},
+		{s: "TLS_RSA_WITH_RC4_128_SHA", wantErr: true},
+		{s: "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,", wantErr: true},
+	} {
+		got, err := ParseTLSCipherSuites(test.s)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("ParseTLSCipherSuites(%q) returned err = %v, want err: %v", test.s, err, test.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(test.want, got); diff != "" {
+			t.Errorf("ParseTLSCipherSuites(%q) diff (-want +got):\n%s", test.s, diff)
+		}
+	}
+}
+
+// newCert returns a new self-signed certificate with the given common name,
+// and its key, in PEM.
+func newCert(t *testing.T, name string) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate(): %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey(): %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+}
+
+// writeCert writes a new self-signed certificate with the given common name
+// and its key to certFile and keyFile.
+func writeCert(t *testing.T, certFile, keyFile, name string) {
+	t.Helper()
+	certPEM, keyPEM := newCert(t, name)
+	writeFile(t, certFile, certPEM)
+	writeFile(t, keyFile, keyPEM)
+}
+
+// commonName returns the common name of the leaf of cert.
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate(): %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, "old")
+
+	r, err := newCertReloader(certFile, keyFile, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("newCertReloader(): %v", err)
+	}
+	defer r.Close()
+	getName := func() string {
+		t.Helper()
+		cert, err := r.GetCertificate(nil)
+		if err != nil {
+			t.Fatalf("GetCertificate(): %v", err)
+		}
+		return commonName(t, cert)
+	}
+	if got, want := getName(), "old"; got != want {
+		t.Errorf("GetCertificate() returned %q, want %q", got, want)
+	}
+
+	writeCert(t, certFile, keyFile, "new")
+	time.Sleep(time.Millisecond)
+	if got, want := getName(), "new"; got != want {
+		t.Errorf("GetCertificate() after rotation returned %q, want %q", got, want)
+	}
+
+	// A broken file, e.g. half-way through rotation, keeps the last certificate.
+	writeFile(t, keyFile, []byte("garbage"))
+	time.Sleep(time.Millisecond)
+	if got, want := getName(), "new"; got != want {
+		t.Errorf("GetCertificate() with a broken key returned %q, want %q", got, want)
+	}
+
+	if _, err := newCertReloader(certFile, keyFile, 0); err == nil {
+		t.Error("newCertReloader() of a broken key succeeded, want error")
+	}
+}
+
+func TestCertReloader_Watch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, "old")
+
+	// Without polling, the files are only re-read when they change.
+	r, err := newCertReloader(certFile, keyFile, 0)
+	if err != nil {
+		t.Fatalf("newCertReloader(): %v", err)
+	}
+	defer r.Close()
+	if r.watcher == nil {
+		t.Skip("fsnotify is unavailable")
+	}
+	// awaitName waits for GetCertificate to return the certificate named want.
+	awaitName := func(want string) {
+		t.Helper()
+		var got string
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			cert, err := r.GetCertificate(nil)
+			if err != nil {
+				t.Fatalf("GetCertificate(): %v", err)
+			}
+			if got = commonName(t, cert); got == want {
+				return
+			}
+		}
+		t.Fatalf("GetCertificate() returned %q, want %q", got, want)
+	}
+
+	// While only the certificate has been replaced, it doesn't match the key,
+	// and the old certificate is kept.
+	certPEM, keyPEM := newCert(t, "new")
+	writeFile(t, certFile, certPEM)
+	time.Sleep(100 * time.Millisecond)
+	awaitName("old")
+	writeFile(t, keyFile, keyPEM)
+	awaitName("new")
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certs")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	writeCert(t, certFile, keyFile, "server")
+
+	m := &Main{TLSCertFile: certFile, TLSKeyFile: keyFile}
+	cfg, err := m.tlsConfig(true)
+	if err != nil {
+		t.Fatalf("tlsConfig(): %v", err)
+	}
+	if got, want := cfg.MinVersion, uint16(tls.VersionTLS12); got != want {
+		t.Errorf("MinVersion = %x, want %x", got, want)
+	}
+	if diff := cmp.Diff(DefaultTLSCipherSuites, cfg.CipherSuites); diff != "" {
+		t.Errorf("CipherSuites diff (-want +got):\n%s", diff)
+	}
+	if cfg.ClientAuth != tls.NoClientCert {
+		t.Errorf("ClientAuth = %v, want NoClientCert", cfg.ClientAuth)
+	}
+
+	m = &Main{
+		TLSCertFile:     certFile,
+		TLSKeyFile:      keyFile,
+		TLSClientCAFile: certFile,
+		TLSMinVersion:   tls.VersionTLS13,
+		TLSCipherSuites: []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+	}
+	for _, clientAuth := range []bool{true, false} {
+		cfg, err := m.tlsConfig(clientAuth)
+		if err != nil {
+			t.Fatalf("tlsConfig(%v): %v", clientAuth, err)
+		}
+		if got, want := cfg.MinVersion, uint16(tls.VersionTLS13); got != want {
+			t.Errorf("tlsConfig(%v): MinVersion = %x, want %x", clientAuth, got, want)
+		}
+		if diff := cmp.Diff(m.TLSCipherSuites, cfg.CipherSuites); diff != "" {
+			t.Errorf("tlsConfig(%v): CipherSuites diff (-want +got):\n%s", clientAuth, diff)
+		}
+		if got := cfg.ClientAuth == tls.RequireAndVerifyClientCert; got != clientAuth {
+			t.Errorf("tlsConfig(%v): ClientAuth = %v", clientAuth, cfg.ClientAuth)
+		}
+	}
+
+	if _, err := (&Main{TLSCertFile: certFile}).tlsConfig(true); err == nil {
+		t.Error("tlsConfig() without a key file succeeded, want error")
+	}
+}
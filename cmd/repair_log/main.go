@@ -0,0 +1,123 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package main contains the implementation and entry point for the repair_log
+// command, which rebuilds the Merkle tree of a log from a known-good tree size
+// and signs a new root over it, e.g. after a storage rollback left the
+// latest root of the log inconsistent with its Merkle nodes.
+//
+// The command talks to storage directly, and all signers must be stopped for
+// the log while it runs. It only reports what it would do unless --dry_run is
+// false and --confirm_tree_id repeats --tree_id.
+//
+// Example usage:
+// $ ./repair_log --mysql_uri=... --tree_id=123 --from_index=1000 --expected_root_hash=<hex>
+// $ ./repair_log --mysql_uri=... --tree_id=123 --from_index=1000 --expected_root_hash=<hex> --dry_run=false --confirm_tree_id=123
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util/clock"
+
+	// Register key ProtoHandlers
+	_ "github.com/google/trillian/crypto/keys/der/proto"
+	_ "github.com/google/trillian/crypto/keys/pem/proto"
+	_ "github.com/google/trillian/crypto/keys/pkcs11/proto"
+
+	// Register supported storage providers.
+	_ "github.com/google/trillian/storage/cloudspanner"
+	_ "github.com/google/trillian/storage/mysql"
+
+	// Load hashers
+	_ "github.com/google/trillian/merkle/rfc6962"
+)
+
+var (
+	treeID           = flag.Int64("tree_id", 0, "ID of the log to repair")
+	fromIndex        = flag.Int64("from_index", -1, "Tree size up to which the Merkle nodes of the log are known to be intact")
+	expectedRootHash = flag.String("expected_root_hash", "", "Hex-encoded root hash of the log at --from_index, e.g. from a root published before the incident. Strongly recommended")
+	dryRun           = flag.Bool("dry_run", true, "If true, only report the root the repair would produce, without changing the log")
+	allowTruncate    = flag.Bool("allow_truncate", false, "If true, leaves missing from storage truncate the repaired log to the leaves before them, instead of failing the repair")
+	confirmTreeID    = flag.Int64("confirm_tree_id", 0, "Must repeat --tree_id for --dry_run=false to take effect")
+)
+
+// options returns the repair options given by the flags.
+func options() (log.RepairOptions, error) {
+	if *treeID == 0 {
+		return log.RepairOptions{}, fmt.Errorf("--tree_id must be set")
+	}
+	if *fromIndex < 0 {
+		return log.RepairOptions{}, fmt.Errorf("--from_index must be set")
+	}
+	if !*dryRun && *confirmTreeID != *treeID {
+		return log.RepairOptions{}, fmt.Errorf("--confirm_tree_id=%d must match --tree_id=%d when --dry_run=false", *confirmTreeID, *treeID)
+	}
+	opts := log.RepairOptions{Index: uint64(*fromIndex), DryRun: *dryRun, AllowTruncate: *allowTruncate}
+	if *expectedRootHash != "" {
+		hash, err := hex.DecodeString(*expectedRootHash)
+		if err != nil {
+			return log.RepairOptions{}, fmt.Errorf("invalid --expected_root_hash: %v", err)
+		}
+		opts.RootHash = hash
+	}
+	return opts, nil
+}
+
+func main() {
+	flag.Parse()
+	defer glog.Flush()
+
+	opts, err := options()
+	if err != nil {
+		glog.Exit(err)
+	}
+	if opts.RootHash == nil {
+		glog.Warningf("No --expected_root_hash given, trusting the Merkle nodes of log %d up to tree size %d", *treeID, opts.Index)
+	}
+
+	sp, err := storage.NewProviderFromFlags(monitoring.InertMetricFactory{})
+	if err != nil {
+		glog.Exitf("Failed to get storage provider: %v", err)
+	}
+	defer sp.Close()
+
+	registry := extension.Registry{
+		AdminStorage: sp.AdminStorage(),
+		LogStorage:   sp.LogStorage(),
+		QuotaManager: quota.Noop(),
+	}
+	info := &log.OperationInfo{Registry: registry, TimeSource: clock.System}
+	if !opts.DryRun {
+		glog.Warningf("REPAIRING log %d from tree size %d", *treeID, opts.Index)
+	}
+	root, err := log.NewSequencerManager(registry, 0).RepairFrom(context.Background(), *treeID, info, opts)
+	if err != nil {
+		glog.Exitf("Repair failed: %v", err)
+	}
+	if opts.DryRun {
+		fmt.Printf("Dry run: repairing log %d from tree size %d would give tree size %d, root hash %x\n", *treeID, opts.Index, root.TreeSize, root.RootHash)
+		return
+	}
+	fmt.Printf("Repaired log %d from tree size %d: tree size %d, root hash %x, revision %d\n", *treeID, opts.Index, root.TreeSize, root.RootHash, root.Revision)
+}
@@ -19,9 +19,11 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	_ "net/http/pprof" // Register pprof HTTP handlers.
 	"os"
 	"runtime/pprof"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
@@ -36,11 +38,18 @@ import (
 	"github.com/google/trillian/monitoring/opencensus"
 	"github.com/google/trillian/monitoring/prometheus"
 	"github.com/google/trillian/quota"
+	"github.com/google/trillian/quota/adaptiveqm"
 	"github.com/google/trillian/quota/etcd"
 	"github.com/google/trillian/quota/etcd/quotaapi"
 	"github.com/google/trillian/quota/etcd/quotapb"
 	"github.com/google/trillian/server"
+	"github.com/google/trillian/server/chaos"
+	"github.com/google/trillian/server/interceptor"
+	"github.com/google/trillian/server/queuewal"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cacheadmin"
+	"github.com/google/trillian/storage/encryption"
+	"github.com/google/trillian/storage/namespace"
 	"github.com/google/trillian/util/clock"
 	etcdutil "github.com/google/trillian/util/etcd"
 	"google.golang.org/grpc"
@@ -61,27 +70,99 @@ import (
 	_ "github.com/google/trillian/quota/mysqlqm"
 )
 
+// Values accepted by --namespace_source, apart from empty.
+const (
+	namespaceTLS      = "tls"
+	namespaceMetadata = "metadata"
+)
+
+// Values accepted by --rpc_services.
+const (
+	servicesAll   = "all"
+	servicesLog   = "log"
+	servicesAdmin = "admin"
+)
+
 var (
-	rpcEndpoint     = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port)")
-	httpEndpoint    = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP metrics (host:port, empty means disabled)")
-	healthzTimeout  = flag.Duration("healthz_timeout", time.Second*5, "Timeout used during healthz checks")
-	tlsCertFile     = flag.String("tls_cert_file", "", "Path to the TLS server certificate. If unset, the server will use unsecured connections.")
-	tlsKeyFile      = flag.String("tls_key_file", "", "Path to the TLS server key. If unset, the server will use unsecured connections.")
-	etcdService     = flag.String("etcd_service", "trillian-logserver", "Service name to announce ourselves under")
-	etcdHTTPService = flag.String("etcd_http_service", "trillian-logserver-http", "Service name to announce our HTTP endpoint under")
+	rpcEndpoint           = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port or unix:///path/to/socket)")
+	httpEndpoint          = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP metrics (host:port or unix:///path/to/socket, empty means disabled)")
+	socketMode            = flag.String("socket_mode", "", "Permissions of the Unix domain sockets listened on by the RPC and HTTP endpoints, in octal, e.g. 0660 (empty means subject to the umask)")
+	healthzTimeout        = flag.Duration("healthz_timeout", time.Second*5, "Timeout used during healthz checks")
+	healthCheckInterval   = flag.Duration("health_check_interval", serverutil.DefaultHealthCheckInterval, "How often the health reported by the gRPC health service on the RPC endpoint is refreshed")
+	drainDuration         = flag.Duration("drain_duration", 0, "For how long the gRPC health service reports NOT_SERVING on shutdown before the RPC server stops, so that clients can stop sending requests")
+	tlsCertFile           = flag.String("tls_cert_file", "", "Path to the TLS server certificate. If unset, the server will use unsecured connections.")
+	tlsKeyFile            = flag.String("tls_key_file", "", "Path to the TLS server key. If unset, the server will use unsecured connections.")
+	tlsClientCAFile       = flag.String("tls_client_ca_file", "", "Path to the CA certificates used to verify client certificates. If set, clients must present a certificate signed by one of them.")
+	tlsMinVersion         = flag.String("tls_min_version", "1.2", "Minimum TLS version accepted by the RPC and HTTP endpoints: 1.0, 1.1, 1.2 or 1.3")
+	tlsCipherSuites       = flag.String("tls_cipher_suites", "", "Comma-separated names of the cipher suites accepted for TLS 1.2 and below, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Empty means ECDHE suites with AES-GCM or ChaCha20-Poly1305")
+	tlsCertReloadInterval = flag.Duration("tls_cert_reload_interval", time.Minute, "How often --tls_cert_file and --tls_key_file are re-read, besides whenever they change, so that they can be rotated without restarting. Zero means they're only re-read when they change")
+	etcdService           = flag.String("etcd_service", "trillian-logserver", "Service name to announce ourselves under")
+	etcdHTTPService       = flag.String("etcd_http_service", "trillian-logserver-http", "Service name to announce our HTTP endpoint under")
 
 	quotaDryRun = flag.Bool("quota_dry_run", false, "If true no requests are blocked due to lack of tokens")
+	quotaKinds  = flag.String("quota_kinds", "", "Comma-separated list of method=kind pairs, where kind is read or write, making requests to the given RPC methods (e.g. GetEntryAndProof) draw tokens from that kind of quota. By default, readonly methods draw from read quota and others from write quota")
+
+	adaptiveQuotaCurve   = flag.String("adaptive_quota_curve", "", "Comma-separated backlog:fraction points, e.g. 10000:1,100000:0.1,200000:0, of a curve scaling --adaptive_quota_rate by the number of unsequenced leaves of each log, interpolated linearly between the points. Empty disables adaptive quota")
+	adaptiveQuotaRate    = flag.Float64("adaptive_quota_rate", 1000, "Tree/Write quota tokens per second available to each log on this server at the start of --adaptive_quota_curve")
+	adaptiveQuotaBurst   = flag.Int("adaptive_quota_burst", 0, "Maximum number of adaptive quota tokens each log can accumulate. Zero means one second's worth of --adaptive_quota_rate")
+	adaptiveQuotaRefresh = flag.Duration("adaptive_quota_refresh", adaptiveqm.DefaultBacklogRefresh, "How often the number of unsequenced leaves of each log is read for adaptive quota")
+
+	rpcServices = flag.String("rpc_services", servicesAll, "Services to serve on the RPC endpoint: \"all\" (TrillianLog, TrillianAdmin and, if enabled, Quota), \"log\" (TrillianLog only) or \"admin\" (TrillianAdmin and Quota only)")
+
+	namespaceSource      = flag.String("namespace_source", "", "Where callers claim their namespace (i.e. tenant) from, if namespaces are enforced: \"tls\" (organization of the client certificate, see --tls_client_ca_file) or \"metadata\" (see --namespace_metadata_key). Empty means trees are not isolated by namespace")
+	namespaceMetadataKey = flag.String("namespace_metadata_key", "x-trillian-namespace", "gRPC metadata header that callers claim their namespace in if --namespace_source=metadata. Only use behind a proxy that authenticates callers and sets it")
+
+	metricsCallers = flag.String("rpc_metrics_callers", "", "Comma-separated namespaces that RPC metrics are broken down by, as the caller label. RPCs from other namespaces are labelled \"other\". Requires --namespace_source. Empty means RPC metrics have no caller label")
+
+	grpcReflection   = flag.Bool("grpc_reflection", false, "If true the gRPC server reflection service is registered on the RPC endpoint, e.g. for use with grpcurl")
+	recoverPanics    = flag.Bool("recover_panics", true, "If true, panics in RPC handlers are logged, counted by the panics_total metric and fail the RPC with INTERNAL. If false they crash the server, e.g. for debugging")
+	maxActiveStreams = flag.Int("max_active_streams", 0, "If positive, the maximum number of concurrent streaming RPCs, e.g. TailLeaves. Further streams fail with RESOURCE_EXHAUSTED until others end. The number of active streams and the limit are shown by /healthz?verbose")
+
+	allowedTreeTypes = flag.String("allowed_tree_types", "LOG,PREORDERED_LOG", "Comma-separated types of trees which may be created through the TrillianAdmin service, out of LOG and PREORDERED_LOG. The first one is the default for trees created without a type")
+
+	uniqueTreeDisplayNames = flag.Bool("unique_tree_display_names", false, "If true, CreateTree fails with ALREADY_EXISTS if the display name of the tree is that of an existing non-deleted tree. Trees without a display name are always allowed")
+
+	treeCacheTTL = flag.Duration("tree_cache_ttl", 0, "If positive, tree metadata read from admin storage is cached in memory for this long. Trees modified through this server are evicted immediately; changes made by other servers may take up to this long to be observed")
 
 	treeGCEnabled            = flag.Bool("tree_gc", true, "If true, tree garbage collection (hard-deletion) is periodically performed")
 	treeDeleteThreshold      = flag.Duration("tree_delete_threshold", serverutil.DefaultTreeDeleteThreshold, "Minimum period a tree has to remain deleted before being hard-deleted")
 	treeDeleteMinRunInterval = flag.Duration("tree_delete_min_run_interval", serverutil.DefaultTreeDeleteMinInterval, "Minimum interval between tree garbage collection sweeps. Actual runs happen randomly between [minInterval,2*minInterval).")
 
+	rootAgeInterval   = flag.Duration("root_age_interval", time.Minute, "How often the ages of the latest signed roots of all logs are exported as the tree_root_age_seconds and trees_root_older_than metrics. Zero disables them")
+	rootAgeThresholds = flag.String("root_age_thresholds", "1m,5m,1h", "Comma-separated durations that the trees_root_older_than metric counts the logs whose latest signed root is older than")
+
 	tracing          = flag.Bool("tracing", false, "If true opencensus Stackdriver tracing will be enabled. See https://opencensus.io/.")
 	tracingProjectID = flag.String("tracing_project_id", "", "project ID to pass to stackdriver. Can be empty for GCP, consult docs for other platforms.")
 	tracingPercent   = flag.Int("tracing_percent", 0, "Percent of requests to be traced. Zero is a special case to use the DefaultSampler")
 
+	proofReadConcurrency = flag.Int("proof_read_concurrency", 1, "Maximum number of parallel storage reads used to fetch the nodes of a single proof. Only set above 1 for storage which supports concurrent reads in a read-only transaction, e.g. CloudSpanner; MySQL and Postgres transactions read sequentially")
+	proofReadRetries     = flag.Int("proof_read_retries", 0, "Number of times a storage read of proof nodes which fails with a transient error, e.g. UNAVAILABLE, is retried at the same revision before the proof fails. Zero disables retries")
+	proofReadBackoff     = flag.Duration("proof_read_backoff", 10*time.Millisecond, "Pause before the first retry of a proof node read, doubling with each further retry")
+	maxProofTreeSize     = flag.Int64("max_proof_tree_size", 1<<48, "Largest tree size which inclusion and consistency proofs are served for. Requests for larger sizes, or to logs whose latest root is larger, e.g. because it is corrupted, fail with INVALID_ARGUMENT")
+	revisionProofs       = flag.Bool("revision_proofs", false, "If true, the diagnostic GetProofAtRevision RPC is served, which reads proofs at arbitrary storage revisions for troubleshooting")
+	revisionProofCallers = flag.String("revision_proof_callers", "", "Comma-separated namespaces which may call GetProofAtRevision if --revision_proofs is set. Others are denied with PERMISSION_DENIED. Requires --namespace_source. Empty means any caller may")
+	tailPollInterval     = flag.Duration("tail_leaves_poll_interval", time.Second, "How often TailLeaves streams check for newly integrated leaves once they have caught up with the log")
+	leafCacheSize        = flag.Int("leaf_cache_size", 0, "Number of the most recently integrated leaves of each log cached in memory, so that GetLeavesByRange reads of the tail of a log avoid storage. The cache is filled by reads, and only serves leaves below the size of the latest root read from storage. Zero disables it")
+	leafCacheLogs        = flag.Int("leaf_cache_logs", 100, "Maximum number of logs whose leaves are cached in memory by --leaf_cache_size; the least recently read ones are evicted")
+
+	queueWALDir           = flag.String("queue_wal_dir", "", "If set, the directory of a write-ahead log which accepts the leaves of trees with queue_write_ahead set while storage is unavailable, and drains them into storage once it recovers. Empty means disabled")
+	queueWALMaxLeaves     = flag.Int("queue_wal_max_leaves", 100000, "Maximum number of leaves held in the write-ahead log across trees, beyond which queueing fails while storage is unavailable")
+	queueWALMaxAttempts   = flag.Int("queue_wal_max_drain_attempts", queuewal.DefaultMaxDrainAttempts, "Number of drains in a row in which leaves in the write-ahead log may fail with errors other than storage being unavailable or rejecting them before they are moved to the rejected file of their tree, so that they don't block the leaves after them. Zero means no limit")
+	queueWALDrainInterval = flag.Duration("queue_wal_drain_interval", time.Second, "How often leaves in the write-ahead log are drained into storage")
+
+	leafEncryptionKEKs = flag.String("leaf_encryption_keks", "", "Comma-separated list of id=path pairs of files holding the raw 32-byte key encryption keys (KEKs) which wrap the data keys of trees with leaf_encryption. The first KEK wraps new data keys, the others only unwrap existing ones until the trees are rewrapped. Empty means trees with leaf_encryption can't be created or used")
+
+	shadowLogs = flag.String("shadow_logs", "", "Comma-separated list of log=shadow pairs of log IDs. Leaves queued to each log are also queued, unchanged, to its shadow log, e.g. to dual-write during a migration. The logs are sequenced independently")
+
+	metricsFlushInterval = flag.Duration("metrics_flush_interval", 0, "If positive, labelled counters and histograms buffer observations in memory and export them at least this often, reducing their cost on hot paths. Scrapes of the metrics endpoint always include all prior observations")
+
+	chaosFaultInjection = flag.Bool("chaos_fault_injection", false, "Testing only, never set in production: if true the Chaos service is served, which configures faults (errors and delays) injected into RPCs. Requires a binary built with the chaos build tag")
+
 	configFile            = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags")
 	maxReceiveMessageSize = flag.Int("max_receive_message_size", 0, "Set the maximum receive message size for the log server")
+	maxReadMessageSize    = flag.Int("max_read_message_size", 0, "Maximum size in bytes of requests to readonly RPC methods, e.g. ListTrees, rejected with RESOURCE_EXHAUSTED beyond it; zero means no limit but --max_receive_message_size")
+	maxWriteMessageSize   = flag.Int("max_write_message_size", 0, "Maximum size in bytes of requests to other RPC methods, e.g. QueueLeaves, rejected with RESOURCE_EXHAUSTED beyond it; zero means no limit but --max_receive_message_size")
+	methodMessageSizes    = flag.String("method_message_sizes", "", "Comma-separated list of method=bytes pairs overriding --max_read_message_size or --max_write_message_size for the given RPC methods; zero means no limit")
 
 	// Profiling related flags.
 	cpuProfile = flag.String("cpuprofile", "", "If set, write CPU profile to this file")
@@ -98,10 +179,58 @@ func main() {
 		}
 	}
 
+	switch *rpcServices {
+	case servicesAll, servicesLog, servicesAdmin:
+	default:
+		glog.Exitf("Invalid --rpc_services value %q, want one of %q, %q or %q", *rpcServices, servicesAll, servicesLog, servicesAdmin)
+	}
+	serveLog := *rpcServices != servicesAdmin
+
+	var namespaceFn interceptor.NamespaceFunc
+	switch *namespaceSource {
+	case "":
+	case namespaceTLS:
+		if *tlsClientCAFile == "" {
+			glog.Exitf("--namespace_source=%s requires --tls_client_ca_file", namespaceTLS)
+		}
+		namespaceFn = interceptor.NamespaceFromTLS
+	case namespaceMetadata:
+		namespaceFn = interceptor.NamespaceFromMetadata(*namespaceMetadataKey)
+	default:
+		glog.Exitf("Invalid --namespace_source value %q, want one of %q, %q or %q", *namespaceSource, "", namespaceTLS, namespaceMetadata)
+	}
+	var callerLabelFn monitoring.CallerLabelFunc
+	var allowedCallers []string
+	if *metricsCallers != "" {
+		if namespaceFn == nil {
+			glog.Exit("--rpc_metrics_callers requires --namespace_source")
+		}
+		callerLabelFn = interceptor.NamespaceCallerLabel(namespaceFn)
+		allowedCallers = strings.Split(*metricsCallers, ",")
+	}
+	var proofCallers []string
+	if *revisionProofCallers != "" {
+		if namespaceFn == nil {
+			glog.Exit("--revision_proof_callers requires --namespace_source")
+		}
+		proofCallers = strings.Split(*revisionProofCallers, ",")
+	} else if *revisionProofs {
+		glog.Warning("--revision_proofs is set without --revision_proof_callers: GetProofAtRevision is served to any caller")
+	}
+	serveAdmin := *rpcServices != servicesLog
+	treeTypes, err := parseTreeTypes(*allowedTreeTypes)
+	if err != nil {
+		glog.Exitf("Invalid --allowed_tree_types value %q: %v", *allowedTreeTypes, err)
+	}
+	thresholds, err := parseThresholds(*rootAgeThresholds)
+	if err != nil {
+		glog.Exitf("Invalid --root_age_thresholds value %q: %v", *rootAgeThresholds, err)
+	}
+
 	ctx := context.Background()
 
 	var options []grpc.ServerOption
-	mf := prometheus.MetricFactory{}
+	mf := prometheus.MetricFactory{FlushInterval: *metricsFlushInterval}
 	monitoring.SetStartSpan(opencensus.StartSpan)
 
 	if *tracing {
@@ -113,10 +242,21 @@ func main() {
 		options = append(options, opts...)
 	}
 
+	methodSizes, err := interceptor.ParseMethodMessageSizes(*methodMessageSizes)
+	if err != nil {
+		glog.Exitf("Invalid --method_message_sizes: %v", err)
+	}
+	sizeLimits := &interceptor.MessageSizeLimits{Read: *maxReadMessageSize, Write: *maxWriteMessageSize, Methods: methodSizes}
+
 	// increase max receive msg size to allow listing of thousands of trees
-	if *maxReceiveMessageSize != 0 {
-		options = append(options, grpc.MaxRecvMsgSize(*maxReceiveMessageSize))
-		glog.Infof("Received max msg size option: %d", *maxReceiveMessageSize)
+	maxRecvSize := *maxReceiveMessageSize
+	if maxRecvSize == 0 {
+		// Let gRPC receive the requests allowed by the per-method limits.
+		maxRecvSize = sizeLimits.Max()
+	}
+	if maxRecvSize != 0 {
+		options = append(options, grpc.MaxRecvMsgSize(maxRecvSize))
+		glog.Infof("Received max msg size option: %d", maxRecvSize)
 	}
 
 	sp, err := storage.NewProviderFromFlags(mf)
@@ -129,6 +269,7 @@ func main() {
 	if err != nil {
 		glog.Exitf("Failed to connect to etcd at %v: %v", *etcd.Servers, err)
 	}
+	client = etcdutil.WithPrefix(client, *etcd.Prefix)
 
 	// Announce our endpoints to etcd if so configured.
 	unannounce := serverutil.AnnounceSelf(ctx, client, *etcdService, *rpcEndpoint)
@@ -143,14 +284,56 @@ func main() {
 		glog.Exitf("Error creating quota manager: %v", err)
 	}
 
+	as := sp.AdminStorage()
+	if *treeCacheTTL > 0 {
+		as, err = cacheadmin.NewCachedAdminStorage(as, *treeCacheTTL, mf)
+		if err != nil {
+			glog.Exitf("Error creating tree cache: %v", err)
+		}
+	}
+
+	ls := sp.LogStorage()
+	if namespaceFn != nil {
+		as = namespace.NewAdminStorage(as)
+		ls = namespace.NewLogStorage(ls)
+	}
+	var leafKeyWrapper encryption.KeyWrapper
+	if *leafEncryptionKEKs != "" {
+		if leafKeyWrapper, err = encryption.LoadKeyring(*leafEncryptionKEKs); err != nil {
+			glog.Exitf("Error loading --leaf_encryption_keks: %v", err)
+		}
+	}
+	ls = encryption.NewLogStorage(ls, leafKeyWrapper)
+
+	if *adaptiveQuotaCurve != "" {
+		curve, err := adaptiveqm.ParseCurve(*adaptiveQuotaCurve)
+		if err != nil {
+			glog.Exitf("Invalid --adaptive_quota_curve: %v", err)
+		}
+		if !storage.CountsUnsequenced(sp) {
+			glog.Exit("--adaptive_quota_curve needs storage which counts unsequenced leaves, e.g. MySQL")
+		}
+		qm, err = adaptiveqm.New(qm, adaptiveqm.Options{
+			Rate:           *adaptiveQuotaRate,
+			Burst:          *adaptiveQuotaBurst,
+			Curve:          curve,
+			Backlog:        adaptiveqm.StorageBacklog(as, ls),
+			BacklogRefresh: *adaptiveQuotaRefresh,
+		}, mf)
+		if err != nil {
+			glog.Exitf("Error creating adaptive quota manager: %v", err)
+		}
+	}
+
 	registry := extension.Registry{
-		AdminStorage:  sp.AdminStorage(),
-		LogStorage:    sp.LogStorage(),
+		AdminStorage:  as,
+		LogStorage:    ls,
 		QuotaManager:  qm,
 		MetricFactory: mf,
 		NewKeyProto: func(ctx context.Context, spec *keyspb.Specification) (proto.Message, error) {
 			return der.NewProtoFromSpec(spec)
 		},
+		LeafKeyWrapper: leafKeyWrapper,
 	}
 
 	// Enable CPU profile if requested.
@@ -160,23 +343,88 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	var faultInjector *chaos.Injector
+	if *chaosFaultInjection {
+		if faultInjector, err = chaos.New(); err != nil {
+			glog.Exitf("Error enabling --chaos_fault_injection: %v", err)
+		}
+	}
+
+	var queueWAL *queuewal.WAL
+	if *queueWALDir != "" {
+		if queueWAL, err = queuewal.Open(*queueWALDir, *queueWALMaxLeaves, mf); err != nil {
+			glog.Exitf("Error opening --queue_wal_dir: %v", err)
+		}
+		queueWAL.MaxDrainAttempts = *queueWALMaxAttempts
+	}
+
+	kinds, err := interceptor.ParseQuotaKinds(*quotaKinds)
+	if err != nil {
+		glog.Exitf("Invalid --quota_kinds: %v", err)
+	}
+	shadows, err := server.ParseShadows(*shadowLogs)
+	if err != nil {
+		glog.Exitf("Invalid --shadow_logs: %v", err)
+	}
+
+	sockMode, err := serverutil.ParseSocketMode(*socketMode)
+	if err != nil {
+		glog.Exitf("Invalid --socket_mode: %v", err)
+	}
+	tlsVersion, err := serverutil.ParseTLSVersion(*tlsMinVersion)
+	if err != nil {
+		glog.Exitf("Invalid --tls_min_version: %v", err)
+	}
+	tlsSuites, err := serverutil.ParseTLSCipherSuites(*tlsCipherSuites)
+	if err != nil {
+		glog.Exitf("Invalid --tls_cipher_suites: %v", err)
+	}
+
 	m := serverutil.Main{
-		RPCEndpoint:  *rpcEndpoint,
-		HTTPEndpoint: *httpEndpoint,
-		TLSCertFile:  *tlsCertFile,
-		TLSKeyFile:   *tlsKeyFile,
-		StatsPrefix:  "log",
-		ExtraOptions: options,
-		QuotaDryRun:  *quotaDryRun,
-		DBClose:      sp.Close,
-		Registry:     registry,
+		RPCEndpoint:           *rpcEndpoint,
+		HTTPEndpoint:          *httpEndpoint,
+		SocketMode:            sockMode,
+		TLSCertFile:           *tlsCertFile,
+		TLSKeyFile:            *tlsKeyFile,
+		TLSClientCAFile:       *tlsClientCAFile,
+		TLSMinVersion:         tlsVersion,
+		TLSCipherSuites:       tlsSuites,
+		TLSCertReloadInterval: *tlsCertReloadInterval,
+		Namespace:             namespaceFn,
+		CallerLabel:           callerLabelFn,
+		AllowedCallerLabels:   allowedCallers,
+		StatsPrefix:           "log",
+		ExtraOptions:          options,
+		QuotaDryRun:           *quotaDryRun,
+		QuotaKinds:            kinds,
+		MessageSizeLimits:     sizeLimits,
+		EnableReflection:      *grpcReflection,
+		DisablePanicRecovery:  !*recoverPanics,
+		MaxActiveStreams:      *maxActiveStreams,
+		FaultInjector:         faultInjector,
+		DBClose:               sp.Close,
+		Registry:              registry,
+		DisableAdminServer:    !serveAdmin,
 		RegisterServerFn: func(s *grpc.Server, registry extension.Registry) error {
-			logServer := server.NewTrillianLogRPCServer(registry, clock.System)
-			if err := logServer.IsHealthy(); err != nil {
-				return err
+			if serveLog {
+				logServer := server.NewTrillianLogRPCServer(registry, clock.System)
+				logServer.ProofReadConcurrency = *proofReadConcurrency
+				logServer.ProofReadRetries = *proofReadRetries
+				logServer.ProofReadBackoff = *proofReadBackoff
+				logServer.MaxProofTreeSize = *maxProofTreeSize
+				logServer.RevisionProofs = *revisionProofs
+				logServer.RevisionProofCallers = proofCallers
+				logServer.TailPollInterval = *tailPollInterval
+				logServer.QueueWAL = queueWAL
+				logServer.Shadows = shadows
+				logServer.LeafCache = server.NewLeafCache(*leafCacheSize, *leafCacheLogs, registry.MetricFactory)
+				if err := logServer.IsHealthy(); err != nil {
+					return err
+				}
+				go logServer.DrainQueueWAL(ctx, *queueWALDrainInterval)
+				trillian.RegisterTrillianLogServer(s, logServer)
 			}
-			trillian.RegisterTrillianLogServer(s, logServer)
-			if *quota.System == etcd.QuotaManagerName {
+			if serveAdmin && *quota.System == etcd.QuotaManagerName {
 				quotapb.RegisterQuotaServer(s, quotaapi.NewServer(client))
 			}
 			return nil
@@ -185,11 +433,16 @@ func main() {
 			as := sp.AdminStorage()
 			return as.CheckDatabaseAccessible(ctx)
 		},
-		HealthyDeadline:       *healthzTimeout,
-		AllowedTreeTypes:      []trillian.TreeType{trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG},
-		TreeGCEnabled:         *treeGCEnabled,
-		TreeDeleteThreshold:   *treeDeleteThreshold,
-		TreeDeleteMinInterval: *treeDeleteMinRunInterval,
+		HealthyDeadline:        *healthzTimeout,
+		HealthCheckInterval:    *healthCheckInterval,
+		DrainDuration:          *drainDuration,
+		AllowedTreeTypes:       treeTypes,
+		UniqueTreeDisplayNames: *uniqueTreeDisplayNames,
+		TreeGCEnabled:          *treeGCEnabled,
+		TreeDeleteThreshold:    *treeDeleteThreshold,
+		TreeDeleteMinInterval:  *treeDeleteMinRunInterval,
+		RootAgeInterval:        *rootAgeInterval,
+		RootAgeThresholds:      thresholds,
 	}
 
 	if err := m.Run(ctx); err != nil {
@@ -202,6 +455,36 @@ func main() {
 	}
 }
 
+// parseTreeTypes parses the value of --allowed_tree_types.
+func parseTreeTypes(s string) ([]trillian.TreeType, error) {
+	var ret []trillian.TreeType
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		tt := trillian.TreeType(trillian.TreeType_value[name])
+		if tt != trillian.TreeType_LOG && tt != trillian.TreeType_PREORDERED_LOG {
+			return nil, fmt.Errorf("tree type %q not served by a log server", name)
+		}
+		ret = append(ret, tt)
+	}
+	return ret, nil
+}
+
+// parseThresholds parses the value of --root_age_thresholds.
+func parseThresholds(s string) ([]time.Duration, error) {
+	var ret []time.Duration
+	for _, v := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("threshold %v is not positive", d)
+		}
+		ret = append(ret, d)
+	}
+	return ret, nil
+}
+
 func mustCreate(fileName string) *os.File {
 	f, err := os.Create(fileName)
 	if err != nil {
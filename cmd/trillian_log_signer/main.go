@@ -19,9 +19,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	_ "net/http/pprof" // Register pprof HTTP handlers.
 	"os"
+	"os/signal"
+	"path"
 	"runtime/pprof"
+	"syscall"
 	"time"
 
 	"github.com/golang/glog"
@@ -34,7 +38,9 @@ import (
 	"github.com/google/trillian/monitoring/prometheus"
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/quota/etcd"
+	"github.com/google/trillian/server"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
 	"github.com/google/trillian/util"
 	"github.com/google/trillian/util/clock"
 	"github.com/google/trillian/util/election"
@@ -62,18 +68,36 @@ import (
 )
 
 var (
-	rpcEndpoint              = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port)")
-	httpEndpoint             = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP (host:port, empty means disabled)")
+	rpcEndpoint              = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port or unix:///path/to/socket)")
+	httpEndpoint             = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP (host:port or unix:///path/to/socket, empty means disabled)")
+	socketMode               = flag.String("socket_mode", "", "Permissions of the Unix domain sockets listened on by the RPC and HTTP endpoints, in octal, e.g. 0660 (empty means subject to the umask)")
 	tlsCertFile              = flag.String("tls_cert_file", "", "Path to the TLS server certificate. If unset, the server will use unsecured connections.")
 	tlsKeyFile               = flag.String("tls_key_file", "", "Path to the TLS server key. If unset, the server will use unsecured connections.")
+	tlsMinVersion            = flag.String("tls_min_version", "1.2", "Minimum TLS version accepted by the RPC and HTTP endpoints: 1.0, 1.1, 1.2 or 1.3")
+	tlsCipherSuites          = flag.String("tls_cipher_suites", "", "Comma-separated names of the cipher suites accepted for TLS 1.2 and below, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Empty means ECDHE suites with AES-GCM or ChaCha20-Poly1305")
+	tlsCertReloadInterval    = flag.Duration("tls_cert_reload_interval", time.Minute, "How often --tls_cert_file and --tls_key_file are re-read, besides whenever they change, so that they can be rotated without restarting. Zero means they're only re-read when they change")
 	sequencerIntervalFlag    = flag.Duration("sequencer_interval", 100*time.Millisecond, "Time between each sequencing pass through all logs")
 	batchSizeFlag            = flag.Int("batch_size", 1000, "Max number of leaves to process per batch")
-	numSeqFlag               = flag.Int("num_sequencers", 10, "Number of sequencer workers to run in parallel")
+	minBatchSizeFlag         = flag.Int("min_batch_size", 1, "Lower bound of the batch size of each log if --max_batch_size is set")
+	maxBatchSizeFlag         = flag.Int("max_batch_size", 0, "If positive, the batch size of each log adapts to its load between --min_batch_size and this, starting from --batch_size: it grows while batches are full and shrinks while runs are slow")
+	numSeqFlag               = flag.Int("num_sequencers", 10, "Number of sequencer workers to run in parallel, i.e. the maximum number of logs sequenced concurrently")
+	maxConcurrentSequencing  = flag.Int("max_concurrent_sequencing", 0, "If positive, the maximum number of logs sequenced concurrently by all signers sharing --lock_file_path, e.g. to protect a shared database. Requires --etcd_servers")
+	initLogs                 = flag.Bool("init_logs", false, "If true, the signer writes the initial, empty signed root of logs which have none on its first pass over them, so that InitLog doesn't need to be called")
 	sequencerGuardWindowFlag = flag.Duration("sequencer_guard_window", 0, "If set, the time elapsed before submitted leaves are eligible for sequencing")
+	subtreeCacheSize         = flag.Int("subtree_cache_size", 1024, "Max number of log subtrees cached in memory between sequencer runs, zero means disabled (only supported by MySQL storage)")
 	forceMaster              = flag.Bool("force_master", false, "If true, assume master for all logs")
+	shardIndex               = flag.Int64("shard_index", 0, "Index of the shard of logs sequenced by this signer if --shard_count is set, in [0, --shard_count)")
+	shardCount               = flag.Int64("shard_count", 0, "If positive, logs are statically partitioned among this many signers instead of electing masters: each sequences the logs whose ID modulo --shard_count is its --shard_index. Unless set on the command line, both are re-read from --config on SIGHUP, so that signers can be added or removed without restarting")
 	etcdHTTPService          = flag.String("etcd_http_service", "trillian-logsigner-http", "Service name to announce our HTTP endpoint under")
 	lockDir                  = flag.String("lock_file_path", "/test/multimaster", "etcd lock file directory path")
 	healthzTimeout           = flag.Duration("healthz_timeout", time.Second*5, "Timeout used during healthz checks")
+	healthCheckInterval      = flag.Duration("health_check_interval", serverutil.DefaultHealthCheckInterval, "How often the health reported by the gRPC health service on the RPC endpoint is refreshed")
+	drainDuration            = flag.Duration("drain_duration", 0, "For how long the gRPC health service reports NOT_SERVING on shutdown before the RPC server stops, so that clients can stop sending requests")
+	grpcReflection           = flag.Bool("grpc_reflection", false, "If true the gRPC server reflection service is registered on the RPC endpoint, e.g. for use with grpcurl")
+	allowReintegrate         = flag.Bool("allow_reintegrate_pending", false, "If true the ReintegratePending RPC is enabled, letting operators integrate leaves left in the queue of logs this signer is master for")
+	allowResignMastership    = flag.Bool("allow_resign_mastership", false, "If true the ResignMastership RPC is enabled, letting operators make this signer resign mastership of logs")
+	allowSequencerStatus     = flag.Bool("allow_sequencer_status", false, "If true the GetSequencerStatus RPC is enabled, letting operators inspect the internal sequencing state of logs on this signer")
+	allowSignLogRoot         = flag.Bool("allow_sign_log_root", false, "If true the SignLogRoot RPC is enabled, letting operators sign the pending state of logs with a signing_interval which this signer is master for")
 
 	quotaIncreaseFactor = flag.Float64("quota_increase_factor", log.QuotaIncreaseFactor,
 		"Increase factor for tokens replenished by sequencing-based quotas (1 means a 1:1 relationship between sequenced leaves and replenished tokens)."+
@@ -83,6 +107,8 @@ var (
 	masterHoldInterval = flag.Duration("master_hold_interval", 60*time.Second, "Minimum interval to hold mastership for")
 	masterHoldJitter   = flag.Duration("master_hold_jitter", 120*time.Second, "Maximal random addition to --master_hold_interval")
 
+	metricsFlushInterval = flag.Duration("metrics_flush_interval", 0, "If positive, labelled counters and histograms buffer observations in memory and export them at least this often, reducing their cost on hot paths. Scrapes of the metrics endpoint always include all prior observations")
+
 	configFile            = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags")
 	maxReceiveMessageSize = flag.Int("max_receive_message_size", 0, "Set the maximum receive message size for the log server")
 
@@ -104,7 +130,7 @@ func main() {
 	glog.CopyStandardLogTo("WARNING")
 	glog.Info("**** Log Signer Starting ****")
 
-	mf := prometheus.MetricFactory{}
+	mf := prometheus.MetricFactory{FlushInterval: *metricsFlushInterval}
 	monitoring.SetStartSpan(opencensus.StartSpan)
 
 	sp, err := storage.NewProviderFromFlags(mf)
@@ -128,7 +154,18 @@ func main() {
 	hostname, _ := os.Hostname()
 	instanceID := fmt.Sprintf("%s.%d", hostname, os.Getpid())
 	var electionFactory election2.Factory
+	var sharding *log.Sharding
 	switch {
+	case *shardCount > 0:
+		if *forceMaster {
+			glog.Exit("--shard_count and --force_master are mutually exclusive")
+		}
+		sharding, err = log.NewSharding(log.Shard{Index: *shardIndex, Count: *shardCount})
+		if err != nil {
+			glog.Exitf("Invalid --shard_index or --shard_count: %v", err)
+		}
+		glog.Infof("**** Acting as master for logs of shard %v ****", sharding.Get())
+		go reloadShardOnSignal(ctx, sharding)
 	case *forceMaster:
 		glog.Warning("**** Acting as master for all logs ****")
 		electionFactory = election2.NoopFactory{}
@@ -150,11 +187,14 @@ func main() {
 		QuotaManager:    qm,
 		MetricFactory:   mf,
 	}
+	if *subtreeCacheSize > 0 {
+		registry.SubtreeCache = cache.NewMemorySubtreeCache(*subtreeCacheSize)
+	}
 
 	// Start HTTP server (optional)
 	if *httpEndpoint != "" {
 		// Announce our endpoint to etcd if so configured.
-		unannounceHTTP := serverutil.AnnounceSelf(ctx, client, *etcdHTTPService, *httpEndpoint)
+		unannounceHTTP := serverutil.AnnounceSelf(ctx, etcdutil.WithPrefix(client, *etcd.Prefix), *etcdHTTPService, *httpEndpoint)
 		defer unannounceHTTP()
 	}
 
@@ -164,17 +204,35 @@ func main() {
 	log.QuotaIncreaseFactor = *quotaIncreaseFactor
 	sequencerManager := log.NewSequencerManager(registry, *sequencerGuardWindowFlag)
 	info := log.OperationInfo{
-		Registry:    registry,
-		BatchSize:   *batchSizeFlag,
-		NumWorkers:  *numSeqFlag,
-		RunInterval: *sequencerIntervalFlag,
-		TimeSource:  clock.System,
+		Registry:     registry,
+		BatchSize:    *batchSizeFlag,
+		MinBatchSize: *minBatchSizeFlag,
+		MaxBatchSize: *maxBatchSizeFlag,
+		NumWorkers:   *numSeqFlag,
+		RunInterval:  *sequencerIntervalFlag,
+		TimeSource:   clock.System,
+		InitLogs:     *initLogs,
 		ElectionConfig: election.RunnerConfig{
 			PreElectionPause:   *preElectionPause,
 			MasterHoldInterval: *masterHoldInterval,
 			MasterHoldJitter:   *masterHoldJitter,
 			TimeSource:         clock.System,
 		},
+		Sharding: sharding,
+	}
+	if *maxBatchSizeFlag > 0 && (*minBatchSizeFlag < 1 || *minBatchSizeFlag > *maxBatchSizeFlag) {
+		glog.Exitf("--min_batch_size must be between 1 and --max_batch_size %d, got %d", *maxBatchSizeFlag, *minBatchSizeFlag)
+	}
+	if *maxConcurrentSequencing > 0 {
+		if client == nil {
+			glog.Exit("--max_concurrent_sequencing requires --etcd_servers")
+		}
+		sem, err := etcdutil.NewSemaphore(client, path.Join(*lockDir, "sequencing"), *maxConcurrentSequencing, mf)
+		if err != nil {
+			glog.Exitf("Failed to create sequencing semaphore: %v", err)
+		}
+		defer sem.Close()
+		info.Semaphore = sem
 	}
 	sequencerTask := log.NewOperationManager(info, sequencerManager)
 	go sequencerTask.OperationLoop(ctx)
@@ -193,21 +251,47 @@ func main() {
 		glog.Infof("Received max msg size option: %d", *maxReceiveMessageSize)
 	}
 
+	sockMode, err := serverutil.ParseSocketMode(*socketMode)
+	if err != nil {
+		glog.Exitf("Invalid --socket_mode: %v", err)
+	}
+	tlsVersion, err := serverutil.ParseTLSVersion(*tlsMinVersion)
+	if err != nil {
+		glog.Exitf("Invalid --tls_min_version: %v", err)
+	}
+	tlsSuites, err := serverutil.ParseTLSCipherSuites(*tlsCipherSuites)
+	if err != nil {
+		glog.Exitf("Invalid --tls_cipher_suites: %v", err)
+	}
+
 	m := serverutil.Main{
-		RPCEndpoint:  *rpcEndpoint,
-		HTTPEndpoint: *httpEndpoint,
-		TLSCertFile:  *tlsCertFile,
-		TLSKeyFile:   *tlsKeyFile,
-		StatsPrefix:  "logsigner",
-		ExtraOptions: options,
-		DBClose:      sp.Close,
-		Registry:     registry,
+		RPCEndpoint:           *rpcEndpoint,
+		HTTPEndpoint:          *httpEndpoint,
+		SocketMode:            sockMode,
+		TLSCertFile:           *tlsCertFile,
+		TLSKeyFile:            *tlsKeyFile,
+		TLSMinVersion:         tlsVersion,
+		TLSCipherSuites:       tlsSuites,
+		TLSCertReloadInterval: *tlsCertReloadInterval,
+		StatsPrefix:           "logsigner",
+		ExtraOptions:          options,
+		DBClose:               sp.Close,
+		EnableReflection:      *grpcReflection,
+		Registry:              registry,
 		RegisterServerFn: func(s *grpc.Server, _ extension.Registry) error {
-			tpb.RegisterTrillianLogSequencerServer(s, &struct{}{})
+			seqServer := server.NewTrillianLogSequencerServer(sequencerManager, &info, *sequencerGuardWindowFlag, sequencerTask)
+			seqServer.AllowReintegratePending = *allowReintegrate
+			seqServer.AllowResignMastership = *allowResignMastership
+			seqServer.AllowSequencerStatus = *allowSequencerStatus
+			seqServer.AllowSignLogRoot = *allowSignLogRoot
+			seqServer.InstanceID = instanceID
+			tpb.RegisterTrillianLogSequencerServer(s, seqServer)
 			return nil
 		},
-		IsHealthy:       sp.AdminStorage().CheckDatabaseAccessible,
-		HealthyDeadline: *healthzTimeout,
+		IsHealthy:           sp.AdminStorage().CheckDatabaseAccessible,
+		HealthyDeadline:     *healthzTimeout,
+		HealthCheckInterval: *healthCheckInterval,
+		DrainDuration:       *drainDuration,
 	}
 
 	if err := m.Run(ctx); err != nil {
@@ -231,3 +315,46 @@ func mustCreate(fileName string) *os.File {
 	}
 	return f
 }
+
+// reloadShardOnSignal re-reads --shard_index and --shard_count from --config
+// whenever the process receives SIGHUP, and updates sharding, until ctx is
+// done. The shard is left unchanged if the file can't be parsed.
+func reloadShardOnSignal(ctx context.Context, sharding *log.Sharding) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+	for {
+		select {
+		case <-sigs:
+		case <-ctx.Done():
+			return
+		}
+		if *configFile == "" {
+			glog.Warning("SIGHUP received without --config, shard unchanged")
+			continue
+		}
+		shard, err := readShard(*configFile)
+		if err != nil {
+			glog.Errorf("Failed to reload flags from config file %q, shard unchanged: %v", *configFile, err)
+			continue
+		}
+		if err := sharding.Set(shard); err != nil {
+			glog.Errorf("Invalid --shard_index or --shard_count in %q, shard unchanged: %v", *configFile, err)
+		}
+	}
+}
+
+// readShard parses --shard_index and --shard_count from the config file at
+// path and the command line, into a dedicated flag set rather than the
+// global flags, which are read concurrently and exit the process on errors.
+func readShard(path string) (log.Shard, error) {
+	var shard log.Shard
+	fs := flag.NewFlagSet("shard", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Int64Var(&shard.Index, "shard_index", 0, "")
+	fs.Int64Var(&shard.Count, "shard_count", 0, "")
+	if err := cmd.ParseFlagFileInto(fs, path); err != nil {
+		return log.Shard{}, err
+	}
+	return shard, nil
+}
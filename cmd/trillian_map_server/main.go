@@ -38,6 +38,8 @@ import (
 	"github.com/google/trillian/quota/etcd/quotaapi"
 	"github.com/google/trillian/quota/etcd/quotapb"
 	"github.com/google/trillian/server"
+	"github.com/google/trillian/server/chaos"
+	"github.com/google/trillian/server/interceptor"
 	"github.com/google/trillian/storage"
 	etcdutil "github.com/google/trillian/util/etcd"
 	"google.golang.org/grpc"
@@ -60,13 +62,26 @@ import (
 )
 
 var (
-	rpcEndpoint    = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port)")
-	httpEndpoint   = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP metrics (host:port, empty means disabled)")
-	healthzTimeout = flag.Duration("healthz_timeout", time.Second*5, "Timeout used during healthz checks")
-	tlsCertFile    = flag.String("tls_cert_file", "", "Path to the TLS server certificate. If unset, the server will use unsecured connections.")
-	tlsKeyFile     = flag.String("tls_key_file", "", "Path to the TLS server key. If unset, the server will use unsecured connections.")
+	rpcEndpoint           = flag.String("rpc_endpoint", "localhost:8090", "Endpoint for RPC requests (host:port or unix:///path/to/socket)")
+	httpEndpoint          = flag.String("http_endpoint", "localhost:8091", "Endpoint for HTTP metrics (host:port or unix:///path/to/socket, empty means disabled)")
+	socketMode            = flag.String("socket_mode", "", "Permissions of the Unix domain sockets listened on by the RPC and HTTP endpoints, in octal, e.g. 0660 (empty means subject to the umask)")
+	healthzTimeout        = flag.Duration("healthz_timeout", time.Second*5, "Timeout used during healthz checks")
+	healthCheckInterval   = flag.Duration("health_check_interval", serverutil.DefaultHealthCheckInterval, "How often the health reported by the gRPC health service on the RPC endpoint is refreshed")
+	drainDuration         = flag.Duration("drain_duration", 0, "For how long the gRPC health service reports NOT_SERVING on shutdown before the RPC server stops, so that clients can stop sending requests")
+	tlsCertFile           = flag.String("tls_cert_file", "", "Path to the TLS server certificate. If unset, the server will use unsecured connections.")
+	tlsKeyFile            = flag.String("tls_key_file", "", "Path to the TLS server key. If unset, the server will use unsecured connections.")
+	tlsMinVersion         = flag.String("tls_min_version", "1.2", "Minimum TLS version accepted by the RPC and HTTP endpoints: 1.0, 1.1, 1.2 or 1.3")
+	tlsCipherSuites       = flag.String("tls_cipher_suites", "", "Comma-separated names of the cipher suites accepted for TLS 1.2 and below, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. Empty means ECDHE suites with AES-GCM or ChaCha20-Poly1305")
+	tlsCertReloadInterval = flag.Duration("tls_cert_reload_interval", time.Minute, "How often --tls_cert_file and --tls_key_file are re-read, besides whenever they change, so that they can be rotated without restarting. Zero means they're only re-read when they change")
 
 	quotaDryRun = flag.Bool("quota_dry_run", false, "If true no requests are blocked due to lack of tokens")
+	quotaKinds  = flag.String("quota_kinds", "", "Comma-separated list of method=kind pairs, where kind is read or write, making requests to the given RPC methods (e.g. GetLeaves) draw tokens from that kind of quota. By default, readonly methods draw from read quota and others from write quota")
+
+	grpcReflection   = flag.Bool("grpc_reflection", false, "If true the gRPC server reflection service is registered on the RPC endpoint, e.g. for use with grpcurl")
+	recoverPanics    = flag.Bool("recover_panics", true, "If true, panics in RPC handlers are logged, counted by the panics_total metric and fail the RPC with INTERNAL. If false they crash the server, e.g. for debugging")
+	maxActiveStreams = flag.Int("max_active_streams", 0, "If positive, the maximum number of concurrent streaming RPCs, e.g. Watch of the gRPC health service. Further streams fail with RESOURCE_EXHAUSTED until others end. The number of active streams and the limit are shown by /healthz?verbose")
+
+	uniqueTreeDisplayNames = flag.Bool("unique_tree_display_names", false, "If true, CreateTree fails with ALREADY_EXISTS if the display name of the tree is that of an existing non-deleted tree. Trees without a display name are always allowed")
 
 	treeGCEnabled            = flag.Bool("tree_gc", true, "If true, tree garbage collection (hard-deletion) is periodically performed")
 	treeDeleteThreshold      = flag.Duration("tree_delete_threshold", serverutil.DefaultTreeDeleteThreshold, "Minimum period a tree has to remain deleted before being hard-deleted")
@@ -76,8 +91,15 @@ var (
 	tracingProjectID = flag.String("tracing_project_id", "", "project ID to pass to Stackdriver client. Can be empty for GCP, consult docs for other platforms.")
 	tracingPercent   = flag.Int("tracing_percent", 0, "Percent of requests to be traced. Zero is a special case to use the DefaultSampler")
 
+	metricsFlushInterval = flag.Duration("metrics_flush_interval", 0, "If positive, labelled counters and histograms buffer observations in memory and export them at least this often, reducing their cost on hot paths. Scrapes of the metrics endpoint always include all prior observations")
+
+	chaosFaultInjection = flag.Bool("chaos_fault_injection", false, "Testing only, never set in production: if true the Chaos service is served, which configures faults (errors and delays) injected into RPCs. Requires a binary built with the chaos build tag")
+
 	configFile            = flag.String("config", "", "Config file containing flags, file contents can be overridden by command line flags")
 	maxReceiveMessageSize = flag.Int("max_receive_message_size", 0, "Set the maximum receive message size for the log server")
+	maxReadMessageSize    = flag.Int("max_read_message_size", 0, "Maximum size in bytes of requests to readonly RPC methods, e.g. ListTrees, rejected with RESOURCE_EXHAUSTED beyond it; zero means no limit but --max_receive_message_size")
+	maxWriteMessageSize   = flag.Int("max_write_message_size", 0, "Maximum size in bytes of requests to other RPC methods, e.g. SetLeaves, rejected with RESOURCE_EXHAUSTED beyond it; zero means no limit but --max_receive_message_size")
+	methodMessageSizes    = flag.String("method_message_sizes", "", "Comma-separated list of method=bytes pairs overriding --max_read_message_size or --max_write_message_size for the given RPC methods; zero means no limit")
 
 	useSingleTransaction = flag.Bool("single_transaction", false, "Experimental: use a single transaction when updating the map")
 	largePreload         = flag.Bool("large_preload_fix", true, "Experimental: work-around locking performance issues when using useSingleTransaction mode")
@@ -97,7 +119,7 @@ func main() {
 	}
 
 	var options []grpc.ServerOption
-	mf := prometheus.MetricFactory{}
+	mf := prometheus.MetricFactory{FlushInterval: *metricsFlushInterval}
 	monitoring.SetStartSpan(opencensus.StartSpan)
 
 	if *tracing {
@@ -109,10 +131,21 @@ func main() {
 		options = append(options, opts...)
 	}
 
+	methodSizes, err := interceptor.ParseMethodMessageSizes(*methodMessageSizes)
+	if err != nil {
+		glog.Exitf("Invalid --method_message_sizes: %v", err)
+	}
+	sizeLimits := &interceptor.MessageSizeLimits{Read: *maxReadMessageSize, Write: *maxWriteMessageSize, Methods: methodSizes}
+
 	// increase max receive msg size to allow listing of thousands of trees
-	if *maxReceiveMessageSize != 0 {
-		options = append(options, grpc.MaxRecvMsgSize(*maxReceiveMessageSize))
-		glog.Infof("Received max msg size option: %d", *maxReceiveMessageSize)
+	maxRecvSize := *maxReceiveMessageSize
+	if maxRecvSize == 0 {
+		// Let gRPC receive the requests allowed by the per-method limits.
+		maxRecvSize = sizeLimits.Max()
+	}
+	if maxRecvSize != 0 {
+		options = append(options, grpc.MaxRecvMsgSize(maxRecvSize))
+		glog.Infof("Received max msg size option: %d", maxRecvSize)
 	}
 
 	sp, err := storage.NewProviderFromFlags(mf)
@@ -125,6 +158,7 @@ func main() {
 	if err != nil {
 		glog.Exitf("Failed to connect to etcd at %v: %v", etcd.Servers, err)
 	}
+	client = etcdutil.WithPrefix(client, *etcd.Prefix)
 
 	qm, err := quota.NewManagerFromFlags()
 	if err != nil {
@@ -150,16 +184,51 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
+	var faultInjector *chaos.Injector
+	if *chaosFaultInjection {
+		if faultInjector, err = chaos.New(); err != nil {
+			glog.Exitf("Error enabling --chaos_fault_injection: %v", err)
+		}
+	}
+
+	kinds, err := interceptor.ParseQuotaKinds(*quotaKinds)
+	if err != nil {
+		glog.Exitf("Invalid --quota_kinds: %v", err)
+	}
+
+	sockMode, err := serverutil.ParseSocketMode(*socketMode)
+	if err != nil {
+		glog.Exitf("Invalid --socket_mode: %v", err)
+	}
+	tlsVersion, err := serverutil.ParseTLSVersion(*tlsMinVersion)
+	if err != nil {
+		glog.Exitf("Invalid --tls_min_version: %v", err)
+	}
+	tlsSuites, err := serverutil.ParseTLSCipherSuites(*tlsCipherSuites)
+	if err != nil {
+		glog.Exitf("Invalid --tls_cipher_suites: %v", err)
+	}
+
 	m := serverutil.Main{
-		RPCEndpoint:  *rpcEndpoint,
-		HTTPEndpoint: *httpEndpoint,
-		TLSCertFile:  *tlsCertFile,
-		TLSKeyFile:   *tlsKeyFile,
-		StatsPrefix:  "map",
-		ExtraOptions: options,
-		QuotaDryRun:  *quotaDryRun,
-		DBClose:      sp.Close,
-		Registry:     registry,
+		RPCEndpoint:           *rpcEndpoint,
+		HTTPEndpoint:          *httpEndpoint,
+		SocketMode:            sockMode,
+		TLSCertFile:           *tlsCertFile,
+		TLSKeyFile:            *tlsKeyFile,
+		TLSMinVersion:         tlsVersion,
+		TLSCipherSuites:       tlsSuites,
+		TLSCertReloadInterval: *tlsCertReloadInterval,
+		StatsPrefix:           "map",
+		ExtraOptions:          options,
+		QuotaDryRun:           *quotaDryRun,
+		QuotaKinds:            kinds,
+		MessageSizeLimits:     sizeLimits,
+		EnableReflection:      *grpcReflection,
+		DisablePanicRecovery:  !*recoverPanics,
+		MaxActiveStreams:      *maxActiveStreams,
+		FaultInjector:         faultInjector,
+		DBClose:               sp.Close,
+		Registry:              registry,
 		RegisterServerFn: func(s *grpc.Server, registry extension.Registry) error {
 			mapServer := server.NewTrillianMapServer(registry,
 				server.TrillianMapServerOptions{
@@ -189,11 +258,14 @@ func main() {
 			as := sp.AdminStorage()
 			return as.CheckDatabaseAccessible(ctx)
 		},
-		HealthyDeadline:       *healthzTimeout,
-		AllowedTreeTypes:      []trillian.TreeType{trillian.TreeType_MAP},
-		TreeGCEnabled:         *treeGCEnabled,
-		TreeDeleteThreshold:   *treeDeleteThreshold,
-		TreeDeleteMinInterval: *treeDeleteMinRunInterval,
+		HealthyDeadline:        *healthzTimeout,
+		HealthCheckInterval:    *healthCheckInterval,
+		DrainDuration:          *drainDuration,
+		AllowedTreeTypes:       []trillian.TreeType{trillian.TreeType_MAP},
+		UniqueTreeDisplayNames: *uniqueTreeDisplayNames,
+		TreeGCEnabled:          *treeGCEnabled,
+		TreeDeleteThreshold:    *treeDeleteThreshold,
+		TreeDeleteMinInterval:  *treeDeleteMinRunInterval,
 	}
 
 	ctx := context.Background()
@@ -0,0 +1,200 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pem
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/trillian/crypto/keyspb"
+)
+
+// passwordCommandTimeout bounds how long a CommandPassword may run.
+const passwordCommandTimeout = 30 * time.Second
+
+// passwords caches the passwords obtained from each PasswordSource, so that
+// they aren't obtained every time a key is loaded.
+var passwords = &passwordCache{passwords: make(map[string]string)}
+
+// PasswordSource obtains the password of a PEM-encoded private key from
+// outside of its configuration, so that it needn't be stored in the tree.
+type PasswordSource interface {
+	// Password returns the current password.
+	Password(ctx context.Context) (string, error)
+}
+
+// EnvPassword is a PasswordSource which reads the password from the
+// environment variable it names.
+type EnvPassword string
+
+// Password implements PasswordSource.Password.
+func (e EnvPassword) Password(ctx context.Context) (string, error) {
+	password, ok := os.LookupEnv(string(e))
+	if !ok {
+		return "", fmt.Errorf("pemfile: environment variable %s not set", string(e))
+	}
+	return password, nil
+}
+
+// FilePassword is a PasswordSource which reads the password from the file at
+// the path it holds. Trailing newlines are ignored.
+type FilePassword string
+
+// Password implements PasswordSource.Password.
+func (f FilePassword) Password(ctx context.Context) (string, error) {
+	b, err := ioutil.ReadFile(string(f))
+	if err != nil {
+		return "", fmt.Errorf("pemfile: error reading password file: %v", err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// CommandPassword is a PasswordSource which runs a command, given as the path
+// of the executable followed by its arguments, and returns its standard output
+// as the password. Trailing newlines are ignored.
+type CommandPassword []string
+
+// Password implements PasswordSource.Password.
+func (c CommandPassword) Password(ctx context.Context) (string, error) {
+	if len(c) == 0 {
+		return "", errors.New("pemfile: empty password command")
+	}
+	ctx, cancel := context.WithTimeout(ctx, passwordCommandTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c[0], c[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("pemfile: password command %q failed: %v: %s", c[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// passwordSources holds the named password sources which a PEMKeyFile may
+// refer to, as set by SetPasswordSources.
+var passwordSources = struct {
+	mu      sync.RWMutex
+	sources map[string]PasswordSource
+}{}
+
+// SetPasswordSources replaces the named password sources which the
+// password_source field of a PEMKeyFile may refer to. They are configured by
+// the operator of the server, e.g. with ParsePasswordSources, because they
+// read its environment and files or run commands on it.
+func SetPasswordSources(sources map[string]PasswordSource) {
+	passwordSources.mu.Lock()
+	defer passwordSources.mu.Unlock()
+	passwordSources.sources = sources
+}
+
+// ParsePasswordSources parses a comma-separated list of named password
+// sources, each as name=env:VARIABLE, name=file:PATH or name=command:COMMAND,
+// where COMMAND is the space-separated path of the executable followed by its
+// arguments.
+func ParsePasswordSources(spec string) (map[string]PasswordSource, error) {
+	sources := make(map[string]PasswordSource)
+	for _, s := range strings.Split(spec, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		parts := strings.SplitN(s, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("pemfile: password source %q is not name=kind:value", s)
+		}
+		name := strings.TrimSpace(parts[0])
+		if _, ok := sources[name]; ok {
+			return nil, fmt.Errorf("pemfile: password source %q defined more than once", name)
+		}
+		kv := strings.SplitN(parts[1], ":", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("pemfile: password source %q is not name=kind:value", s)
+		}
+		switch kind, value := kv[0], kv[1]; kind {
+		case "env":
+			sources[name] = EnvPassword(value)
+		case "file":
+			sources[name] = FilePassword(value)
+		case "command":
+			sources[name] = CommandPassword(strings.Fields(value))
+		default:
+			return nil, fmt.Errorf("pemfile: unknown kind %q of password source %q", kind, name)
+		}
+	}
+	return sources, nil
+}
+
+// PasswordSourceFromProto returns the PasswordSource which pb refers to, or
+// nil if the password is stored in pb itself.
+func PasswordSourceFromProto(pb *keyspb.PEMKeyFile) (PasswordSource, error) {
+	name := pb.GetPasswordSource()
+	switch {
+	case name == "":
+		return nil, nil
+	case pb.GetPassword() != "":
+		return nil, fmt.Errorf("pemfile: at most one of password and password_source may be set for file %q", pb.GetPath())
+	}
+	passwordSources.mu.RLock()
+	src, ok := passwordSources.sources[name]
+	passwordSources.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("pemfile: unknown password source %q for file %q", name, pb.GetPath())
+	}
+	return src, nil
+}
+
+// passwordCache holds the last passwords which successfully decrypted a key,
+// by PasswordSource.
+type passwordCache struct {
+	mu        sync.Mutex
+	passwords map[string]string
+}
+
+// readPrivateKeyFile reads a PEM-encoded private key from a file, decrypting
+// it with the cached password of src. If there is none, or it fails to decrypt
+// the key, the password is obtained from src again, as it may have been
+// rotated along with the key.
+func (c *passwordCache) readPrivateKeyFile(ctx context.Context, file string, src PasswordSource) (crypto.Signer, error) {
+	id := fmt.Sprintf("%T:%q", src, src)
+	c.mu.Lock()
+	password, ok := c.passwords[id]
+	c.mu.Unlock()
+	if ok {
+		if k, err := ReadPrivateKeyFile(file, password); err == nil {
+			return k, nil
+		}
+	}
+
+	password, err := src.Password(ctx)
+	if err != nil {
+		return nil, err
+	}
+	k, err := ReadPrivateKeyFile(file, password)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.passwords[id] = password
+	c.mu.Unlock()
+	return k, nil
+}
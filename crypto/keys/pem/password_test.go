@@ -0,0 +1,201 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pem_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/trillian/crypto/keys/der"
+	. "github.com/google/trillian/crypto/keys/pem"
+	ktestonly "github.com/google/trillian/crypto/keys/testonly"
+	"github.com/google/trillian/crypto/keyspb"
+)
+
+func TestPasswordSources(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "pem")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(path, []byte("towel\n"), 0600); err != nil {
+		t.Fatalf("WriteFile(): %v", err)
+	}
+	const env = "TRILLIAN_TEST_PEM_PASSWORD"
+	os.Setenv(env, "towel")
+	defer os.Unsetenv(env)
+
+	for _, test := range []struct {
+		desc    string
+		src     PasswordSource
+		wantErr bool
+	}{
+		{desc: "env", src: EnvPassword(env)},
+		{desc: "unsetEnv", src: EnvPassword(env + "_UNSET"), wantErr: true},
+		{desc: "file", src: FilePassword(path)},
+		{desc: "missingFile", src: FilePassword(path + ".missing"), wantErr: true},
+		{desc: "command", src: CommandPassword{"cat", path}},
+		{desc: "failingCommand", src: CommandPassword{"cat", path + ".missing"}, wantErr: true},
+		{desc: "emptyCommand", src: CommandPassword{}, wantErr: true},
+	} {
+		got, err := test.src.Password(ctx)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: Password() = %q, %v; want err? %v", test.desc, got, err, test.wantErr)
+			continue
+		}
+		if err == nil && got != "towel" {
+			t.Errorf("%v: Password() = %q, want %q", test.desc, got, "towel")
+		}
+	}
+}
+
+func TestParsePasswordSources(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		spec    string
+		want    map[string]PasswordSource
+		wantErr bool
+	}{
+		{desc: "empty", spec: "", want: map[string]PasswordSource{}},
+		{
+			desc: "all",
+			spec: "a=env:PW,b=file:/etc/pw,c=command:cat /etc/pw",
+			want: map[string]PasswordSource{
+				"a": EnvPassword("PW"),
+				"b": FilePassword("/etc/pw"),
+				"c": CommandPassword{"cat", "/etc/pw"},
+			},
+		},
+		{desc: "noName", spec: "=env:PW", wantErr: true},
+		{desc: "noKind", spec: "a=PW", wantErr: true},
+		{desc: "noValue", spec: "a=env:", wantErr: true},
+		{desc: "unknownKind", spec: "a=secret:PW", wantErr: true},
+		{desc: "duplicate", spec: "a=env:PW,a=file:pw", wantErr: true},
+	} {
+		got, err := ParsePasswordSources(test.spec)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: ParsePasswordSources() = %v, %v; want err? %v", test.desc, got, err, test.wantErr)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: ParsePasswordSources() = %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
+
+func TestPasswordSourceFromProto(t *testing.T) {
+	SetPasswordSources(map[string]PasswordSource{"pw": EnvPassword("PW")})
+	defer SetPasswordSources(nil)
+
+	for _, test := range []struct {
+		desc    string
+		pb      *keyspb.PEMKeyFile
+		want    PasswordSource
+		wantErr bool
+	}{
+		{desc: "password", pb: &keyspb.PEMKeyFile{Password: "towel"}},
+		{desc: "source", pb: &keyspb.PEMKeyFile{PasswordSource: "pw"}, want: EnvPassword("PW")},
+		{desc: "unknownSource", pb: &keyspb.PEMKeyFile{PasswordSource: "other"}, wantErr: true},
+		{desc: "passwordAndSource", pb: &keyspb.PEMKeyFile{Password: "towel", PasswordSource: "pw"}, wantErr: true},
+	} {
+		got, err := PasswordSourceFromProto(test.pb)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("%v: PasswordSourceFromProto() = %v, %v; want err? %v", test.desc, got, err, test.wantErr)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%v: PasswordSourceFromProto() = %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
+
+// encryptedKey returns a new PEM-encoded private key, encrypted with password.
+func encryptedKey(t *testing.T, password string) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey(): %v", err)
+	}
+	keyDER, err := der.MarshalPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey(): %v", err)
+	}
+	block, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", keyDER, []byte(password), x509.PEMCipherAES256)
+	if err != nil {
+		t.Fatalf("EncryptPEMBlock(): %v", err)
+	}
+	return string(pem.EncodeToMemory(block))
+}
+
+func TestFromProtoPasswordRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pem")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	keyPath, passPath := filepath.Join(dir, "key.pem"), filepath.Join(dir, "password")
+	write := func(path, contents string) {
+		t.Helper()
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatalf("WriteFile(): %v", err)
+		}
+	}
+	keyPEM, err := ioutil.ReadFile("../../../testdata/log-rpc-server.privkey.pem")
+	if err != nil {
+		t.Fatalf("ReadFile(): %v", err)
+	}
+	write(keyPath, string(keyPEM))
+	write(passPath, "towel")
+	SetPasswordSources(map[string]PasswordSource{"key": CommandPassword{"cat", passPath}})
+	defer SetPasswordSources(nil)
+	pb := &keyspb.PEMKeyFile{Path: keyPath, PasswordSource: "key"}
+
+	load := func(desc string) {
+		t.Helper()
+		signer, err := FromProto(pb)
+		if err != nil {
+			t.Fatalf("%v: FromProto(): %v", desc, err)
+		}
+		if err := ktestonly.SignAndVerify(signer, signer.Public()); err != nil {
+			t.Errorf("%v: SignAndVerify() = %q, want nil", desc, err)
+		}
+	}
+	load("initial")
+
+	// The password is cached while it decrypts the key.
+	write(passPath, "wrong-password")
+	load("cached")
+
+	// Once the key is rotated, the rotated password is obtained.
+	write(keyPath, encryptedKey(t, "new-password"))
+	write(passPath, "new-password")
+	load("rotated")
+
+	write(keyPath, string(keyPEM))
+	if _, err := FromProto(pb); err == nil {
+		t.Error("FromProto() with a stale password succeeded")
+	}
+}
@@ -15,6 +15,7 @@
 package pem
 
 import (
+	"context"
 	"crypto"
 	"crypto/x509"
 	"encoding/pem"
@@ -27,8 +28,17 @@ import (
 )
 
 // FromProto takes a PEMKeyFile protobuf message and loads the private key it specifies.
+// If the password is obtained from a PasswordSource, it's cached until it no
+// longer decrypts the key.
 func FromProto(pb *keyspb.PEMKeyFile) (crypto.Signer, error) {
-	return ReadPrivateKeyFile(pb.GetPath(), pb.GetPassword())
+	src, err := PasswordSourceFromProto(pb)
+	if err != nil {
+		return nil, err
+	}
+	if src == nil {
+		return ReadPrivateKeyFile(pb.GetPath(), pb.GetPassword())
+	}
+	return passwords.readPrivateKeyFile(context.Background(), pb.GetPath(), src)
 }
 
 // ReadPrivateKeyFile reads a PEM-encoded private key from a file.
@@ -19,7 +19,9 @@ package proto
 import (
 	"context"
 	"crypto"
+	"flag"
 	"fmt"
+	"sync"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian/crypto/keys"
@@ -27,9 +29,31 @@ import (
 	"github.com/google/trillian/crypto/keyspb"
 )
 
+var passwordSources = flag.String("pem_password_sources", "", "Comma-separated list of password sources which PEM key files may name in their password_source, each as name=env:VARIABLE, name=file:PATH or name=command:COMMAND")
+
+var (
+	sourcesOnce sync.Once
+	sourcesErr  error
+)
+
+// setPasswordSources sets the password sources from the
+// --pem_password_sources flag, the first time it's called.
+func setPasswordSources() error {
+	sourcesOnce.Do(func() {
+		var sources map[string]pem.PasswordSource
+		if sources, sourcesErr = pem.ParsePasswordSources(*passwordSources); sourcesErr == nil {
+			pem.SetPasswordSources(sources)
+		}
+	})
+	return sourcesErr
+}
+
 func init() {
 	keys.RegisterHandler(&keyspb.PEMKeyFile{}, func(ctx context.Context, pb proto.Message) (crypto.Signer, error) {
 		if pb, ok := pb.(*keyspb.PEMKeyFile); ok {
+			if err := setPasswordSources(); err != nil {
+				return nil, err
+			}
 			return pem.FromProto(pb)
 		}
 		return nil, fmt.Errorf("pemfile: got %T, want *keyspb.PEMKeyFile", pb)
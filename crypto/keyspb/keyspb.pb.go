@@ -150,7 +150,7 @@ func (*Specification) XXX_OneofWrappers() []interface{} {
 	}
 }
 
-/// ECDSA defines parameters for an ECDSA key.
+// / ECDSA defines parameters for an ECDSA key.
 type Specification_ECDSA struct {
 	// The elliptic curve to use.
 	// Optional. If not set, the default curve will be used.
@@ -273,7 +273,12 @@ type PEMKeyFile struct {
 	Path string `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
 	// Password for decrypting the private key.
 	// If empty, indicates that the private key is not encrypted.
-	Password             string   `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	Password string `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	// Name of the password source holding the password. At most one of password
+	// and password_source may be set. The password is obtained again whenever it
+	// fails to decrypt the private key, so the key and password can be rotated
+	// together.
+	PasswordSource       string   `protobuf:"bytes,6,opt,name=password_source,json=passwordSource,proto3" json:"password_source,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -318,6 +323,13 @@ func (m *PEMKeyFile) GetPassword() string {
 	return ""
 }
 
+func (m *PEMKeyFile) GetPasswordSource() string {
+	if m != nil {
+		return m.PasswordSource
+	}
+	return ""
+}
+
 // PrivateKey is a private key, used for generating signatures.
 type PrivateKey struct {
 	// The key in DER-encoded form.
@@ -475,32 +487,35 @@ func init() {
 func init() { proto.RegisterFile("crypto/keyspb/keyspb.proto", fileDescriptor_c8ca2ab097770992) }
 
 var fileDescriptor_c8ca2ab097770992 = []byte{
-	// 432 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xcf, 0x6f, 0xd3, 0x30,
-	0x14, 0xc7, 0xdb, 0x65, 0x2d, 0xcd, 0x6b, 0x3b, 0x05, 0x9f, 0x58, 0x50, 0xf9, 0x91, 0xd3, 0xc4,
-	0x21, 0x55, 0x33, 0x0a, 0x03, 0x71, 0xa0, 0xcb, 0x5a, 0x4d, 0xea, 0x90, 0x22, 0x97, 0x71, 0xe0,
-	0x52, 0x9c, 0xd4, 0xcb, 0xac, 0x66, 0xb1, 0xe5, 0xb8, 0x43, 0xe1, 0xc6, 0x7f, 0x8e, 0xf2, 0x92,
-	0x0d, 0x4d, 0x1a, 0x9c, 0xf2, 0x7d, 0xce, 0xf7, 0xe3, 0xf7, 0x7d, 0xb6, 0xc1, 0x4d, 0x74, 0xa9,
-	0x8c, 0x1c, 0x6f, 0x79, 0x59, 0xa8, 0xb8, 0xf9, 0xf8, 0x4a, 0x4b, 0x23, 0x49, 0xb7, 0xae, 0xbc,
-	0xdf, 0x16, 0x0c, 0x57, 0x8a, 0x27, 0xe2, 0x4a, 0x24, 0xcc, 0x08, 0x99, 0x93, 0xcf, 0x30, 0xe0,
-	0xc9, 0xa6, 0x60, 0x6b, 0xc5, 0x34, 0xbb, 0x29, 0x9e, 0xb5, 0x5f, 0xb5, 0x8f, 0xfa, 0xc1, 0x73,
-	0xbf, 0xc1, 0x1f, 0x98, 0xfd, 0x79, 0x78, 0xb6, 0x9a, 0x9d, 0xb7, 0x68, 0x1f, 0x91, 0x08, 0x09,
-	0xf2, 0x11, 0x40, 0xff, 0xe5, 0xf7, 0x90, 0x3f, 0x7c, 0x9c, 0xa7, 0x48, 0xdb, 0xfa, 0x9e, 0x5d,
-	0xc0, 0x01, 0xdf, 0x04, 0xd3, 0xe9, 0xe4, 0xc3, 0x1d, 0x6f, 0x21, 0x3f, 0xfa, 0x47, 0xff, 0xda,
-	0x7b, 0xde, 0xa2, 0xc3, 0x06, 0xab, 0xf7, 0x71, 0x7f, 0x41, 0x07, 0xb3, 0x91, 0xf7, 0xd0, 0x49,
-	0x76, 0xfa, 0x96, 0xe3, 0x1c, 0x07, 0xc1, 0xeb, 0xff, 0xcc, 0xe1, 0x87, 0x95, 0x91, 0xd6, 0x7e,
-	0xef, 0x04, 0x3a, 0x58, 0x93, 0xa7, 0x30, 0x3c, 0x9b, 0x2f, 0x66, 0x97, 0x17, 0x5f, 0xd7, 0xe1,
-	0x25, 0xfd, 0x36, 0x77, 0x5a, 0xa4, 0x07, 0xfb, 0x51, 0x30, 0x7d, 0xe7, 0xb4, 0x51, 0x1d, 0x9f,
-	0xbc, 0x75, 0xf6, 0x50, 0x4d, 0x83, 0x89, 0x63, 0xb9, 0x87, 0x60, 0xd1, 0xd5, 0x8c, 0x10, 0xd8,
-	0x8f, 0x85, 0xa9, 0x0f, 0xb0, 0x43, 0x51, 0xbb, 0x36, 0x3c, 0x69, 0x22, 0x9f, 0xf6, 0xa0, 0x5b,
-	0x4f, 0xe8, 0x7d, 0x02, 0x88, 0xe6, 0x5f, 0x96, 0xbc, 0x5c, 0x88, 0x8c, 0x57, 0x98, 0x62, 0xe6,
-	0x1a, 0x31, 0x9b, 0xa2, 0x26, 0x2e, 0xf4, 0x14, 0x2b, 0x8a, 0x9f, 0x52, 0x6f, 0xf0, 0x3c, 0x6d,
-	0x7a, 0x5f, 0x7b, 0x2f, 0x00, 0x22, 0x2d, 0x6e, 0x99, 0xe1, 0x4b, 0x5e, 0x12, 0x07, 0xac, 0x0d,
-	0xd7, 0x08, 0x0f, 0x68, 0x25, 0xbd, 0x11, 0xd8, 0xd1, 0x2e, 0xce, 0x44, 0xf2, 0xf8, 0xef, 0x1f,
-	0x30, 0x88, 0x96, 0xe1, 0x6a, 0x32, 0x09, 0x65, 0x7e, 0x25, 0x52, 0xf2, 0x12, 0xfa, 0x46, 0x6e,
-	0x79, 0xbe, 0xce, 0x58, 0xcc, 0xb3, 0x26, 0x05, 0xe0, 0xd2, 0x45, 0xb5, 0x52, 0x6d, 0xa1, 0x44,
-	0xde, 0xc4, 0xa8, 0x24, 0x19, 0x01, 0x28, 0xec, 0xb0, 0xde, 0xf2, 0x12, 0xef, 0xcb, 0xa6, 0xb6,
-	0xba, 0xeb, 0x79, 0xfa, 0xe6, 0xfb, 0x51, 0x2a, 0xcc, 0xf5, 0x2e, 0xf6, 0x13, 0x79, 0x33, 0x4e,
-	0xa5, 0x4c, 0x33, 0x3e, 0x36, 0x5a, 0x64, 0x99, 0x60, 0xf9, 0xf8, 0xc1, 0x1b, 0x8d, 0xbb, 0xf8,
-	0x3a, 0x8f, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0x3a, 0x60, 0x6c, 0x48, 0xbb, 0x02, 0x00, 0x00,
+	// 476 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x93, 0x4f, 0x6f, 0xda, 0x40,
+	0x10, 0xc5, 0x43, 0x0c, 0x14, 0x0f, 0x7f, 0xea, 0xee, 0x29, 0xa1, 0xa2, 0x7f, 0xb8, 0x34, 0xea,
+	0x01, 0x04, 0x29, 0x6d, 0xda, 0x53, 0x09, 0x01, 0x45, 0x25, 0x95, 0xac, 0x75, 0xd3, 0x43, 0x2f,
+	0xee, 0xda, 0x5e, 0xc8, 0x0a, 0xe3, 0x5d, 0xad, 0x0d, 0x95, 0x7b, 0xeb, 0xd7, 0xe8, 0xa7, 0xad,
+	0x3c, 0x36, 0xae, 0x22, 0xa5, 0x3d, 0xf1, 0x66, 0x78, 0xbf, 0x9d, 0x79, 0xab, 0x35, 0x74, 0x7d,
+	0x9d, 0xaa, 0x44, 0x0e, 0x37, 0x3c, 0x8d, 0x95, 0x57, 0xfc, 0x0c, 0x94, 0x96, 0x89, 0x24, 0xf5,
+	0xbc, 0xea, 0xff, 0x32, 0xa0, 0xed, 0x28, 0xee, 0x8b, 0x95, 0xf0, 0x59, 0x22, 0x64, 0x44, 0x3e,
+	0x42, 0x8b, 0xfb, 0x41, 0xcc, 0x5c, 0xc5, 0x34, 0xdb, 0xc6, 0x27, 0x95, 0x17, 0x95, 0xb3, 0xe6,
+	0xf8, 0xe9, 0xa0, 0xc0, 0xef, 0x99, 0x07, 0xf3, 0xd9, 0x95, 0x33, 0xbd, 0x3e, 0xa2, 0x4d, 0x44,
+	0x6c, 0x24, 0xc8, 0x07, 0x00, 0xfd, 0x97, 0x3f, 0x46, 0xfe, 0xf4, 0x61, 0x9e, 0x22, 0x6d, 0xea,
+	0x92, 0x5d, 0x40, 0x87, 0x07, 0xe3, 0xc9, 0x64, 0xf4, 0xfe, 0xc0, 0x1b, 0xc8, 0xf7, 0xfe, 0x31,
+	0x3f, 0xf7, 0x5e, 0x1f, 0xd1, 0x76, 0x81, 0xe5, 0xe7, 0x74, 0x7f, 0x42, 0x0d, 0x77, 0x23, 0xef,
+	0xa0, 0xe6, 0xef, 0xf4, 0x9e, 0x63, 0x8e, 0xce, 0xf8, 0xe5, 0x7f, 0x72, 0x0c, 0x66, 0x99, 0x91,
+	0xe6, 0xfe, 0xfe, 0x05, 0xd4, 0xb0, 0x26, 0x4f, 0xa0, 0x7d, 0x35, 0x5f, 0x4c, 0x6f, 0x6f, 0xbe,
+	0xb8, 0xb3, 0x5b, 0xfa, 0x75, 0x6e, 0x1d, 0x91, 0x06, 0x54, 0xed, 0xf1, 0xe4, 0xad, 0x55, 0x41,
+	0x75, 0x7e, 0xf1, 0xc6, 0x3a, 0x46, 0x35, 0x19, 0x8f, 0x2c, 0xa3, 0x7b, 0x0a, 0x06, 0x75, 0xa6,
+	0x84, 0x40, 0xd5, 0x13, 0x49, 0x7e, 0x81, 0x35, 0x8a, 0xba, 0x6b, 0xc2, 0xa3, 0x62, 0xe5, 0xcb,
+	0x06, 0xd4, 0xf3, 0x84, 0xfd, 0xdf, 0x15, 0x00, 0x7b, 0xfe, 0x79, 0xc9, 0xd3, 0x85, 0x08, 0x79,
+	0xc6, 0x29, 0x96, 0xdc, 0x21, 0x67, 0x52, 0xd4, 0xa4, 0x0b, 0x0d, 0xc5, 0xe2, 0xf8, 0x87, 0xd4,
+	0x01, 0x5e, 0xa8, 0x49, 0xcb, 0x9a, 0xbc, 0x82, 0xc7, 0x07, 0xed, 0xc6, 0x72, 0xa7, 0x7d, 0x7e,
+	0x52, 0x47, 0x4b, 0xe7, 0xd0, 0x76, 0xb0, 0xfb, 0xa9, 0xda, 0x30, 0xac, 0x3a, 0x6d, 0x95, 0x66,
+	0x1e, 0xed, 0x69, 0xbb, 0xac, 0x56, 0x22, 0xe4, 0xd4, 0x2a, 0x4b, 0x5f, 0x6e, 0xb7, 0x2c, 0x0a,
+	0xfa, 0xcf, 0x00, 0x6c, 0x2d, 0xf6, 0x2c, 0xe1, 0x4b, 0x9e, 0x12, 0x0b, 0x8c, 0x80, 0x6b, 0x5c,
+	0xad, 0x45, 0x33, 0xd9, 0xef, 0x81, 0x69, 0xef, 0xbc, 0x50, 0xf8, 0x0f, 0xff, 0xfd, 0x1d, 0x5a,
+	0xf6, 0x72, 0xe6, 0x8c, 0x46, 0x33, 0x19, 0xad, 0xc4, 0x9a, 0x3c, 0x87, 0x66, 0x22, 0x37, 0x3c,
+	0x72, 0x43, 0xe6, 0xf1, 0xb0, 0xc8, 0x08, 0xd8, 0xba, 0xc9, 0x3a, 0xd9, 0x11, 0x4a, 0x44, 0x45,
+	0xc8, 0x4c, 0x92, 0x1e, 0x80, 0xc2, 0x09, 0xee, 0x86, 0xa7, 0xf8, 0x1c, 0x4c, 0x6a, 0xaa, 0xc3,
+	0xcc, 0xcb, 0xd7, 0xdf, 0xce, 0xd6, 0x22, 0xb9, 0xdb, 0x79, 0x03, 0x5f, 0x6e, 0x87, 0x6b, 0x29,
+	0xd7, 0x21, 0x1f, 0x26, 0x5a, 0x84, 0xa1, 0x60, 0xd1, 0xf0, 0xde, 0x27, 0xe0, 0xd5, 0xf1, 0xf1,
+	0x9f, 0xff, 0x19, 0x00, 0x96, 0xae, 0x24, 0x71, 0x1a, 0x03, 0x00, 0x00,
 }
@@ -20,6 +20,7 @@ import (
 	"crypto/rand"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
 	"github.com/google/trillian/types"
 	"golang.org/x/crypto/ed25519"
@@ -34,6 +35,8 @@ type Signer struct {
 	// If Hash is noHash (zero), the signer expects to be given the full message not a hashed digest.
 	Hash   crypto.Hash
 	Signer crypto.Signer
+	// LogRootEncoding is the serialization of the log roots that are signed.
+	LogRootEncoding trillian.LogRootEncoding
 }
 
 // NewSigner returns a new signer. The signer will set the KeyHint field, when available, with KeyID.
@@ -80,7 +83,7 @@ func (s *Signer) Sign(data []byte) ([]byte, error) {
 
 // SignLogRoot returns a complete SignedLogRoot (including signature).
 func (s *Signer) SignLogRoot(r *types.LogRootV1) (*trillian.SignedLogRoot, error) {
-	logRoot, err := r.MarshalBinary()
+	logRoot, err := r.Marshal(s.LogRootEncoding)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +100,21 @@ func (s *Signer) SignLogRoot(r *types.LogRootV1) (*trillian.SignedLogRoot, error
 	}, nil
 }
 
+// SignTreeAttestation returns a TreeAttestation of the given tree settings,
+// including the signature.
+func (s *Signer) SignTreeAttestation(tree *trillian.Tree) (*trillian.TreeAttestation, error) {
+	settings, err := proto.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.Sign(settings)
+	if err != nil {
+		glog.Warningf("%v: signer failed to sign tree attestation: %v", s.KeyHint, err)
+		return nil, err
+	}
+	return &trillian.TreeAttestation{Tree: settings, Signature: signature}, nil
+}
+
 // SignMapRoot hashes and signs the supplied (to-be) SignedMapRoot and returns a signature.
 func (s *Signer) SignMapRoot(r *types.MapRootV1) (*trillian.SignedMapRoot, error) {
 	rootBytes, err := r.MarshalBinary()
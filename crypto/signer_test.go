@@ -20,6 +20,8 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys/pem"
 	"github.com/google/trillian/testonly"
 	"github.com/google/trillian/types"
@@ -91,13 +93,22 @@ func TestSignLogRoot(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to open test key, err=%v", err)
 	}
-	signer := NewSigner(0, key, crypto.SHA256)
 
 	for _, test := range []struct {
-		root *types.LogRootV1
+		root     *types.LogRootV1
+		encoding trillian.LogRootEncoding
+		// wantFirst is the first byte of the serialized root.
+		wantFirst byte
 	}{
 		{root: &types.LogRootV1{TimestampNanos: 2267709, RootHash: []byte("Islington"), TreeSize: 2}},
+		{
+			root:      &types.LogRootV1{TimestampNanos: 2267709, RootHash: []byte("Islington"), TreeSize: 2},
+			encoding:  trillian.LogRootEncoding_CBOR,
+			wantFirst: 0xa6,
+		},
 	} {
+		signer := NewSigner(0, key, crypto.SHA256)
+		signer.LogRootEncoding = test.encoding
 		slr, err := signer.SignLogRoot(test.root)
 		if err != nil {
 			t.Errorf("Failed to sign log root: %v", err)
@@ -106,6 +117,9 @@ func TestSignLogRoot(t *testing.T) {
 		if got := len(slr.LogRootSignature); got == 0 {
 			t.Errorf("len(sig): %v, want > 0", got)
 		}
+		if got := slr.LogRoot[0]; got != test.wantFirst {
+			t.Errorf("%v: LogRoot[0]: %#x, want %#x", test.encoding, got, test.wantFirst)
+		}
 		// Check that the signature is correct
 		if _, err := VerifySignedLogRoot(key.Public(), crypto.SHA256, slr); err != nil {
 			t.Errorf("Verify(%v) failed: %v", test.root, err)
@@ -137,3 +151,33 @@ func TestSignMapRoot(t *testing.T) {
 		}
 	}
 }
+
+func TestSignTreeAttestation(t *testing.T) {
+	key, err := pem.UnmarshalPrivateKey(testonly.DemoPrivateKey, testonly.DemoPrivateKeyPass)
+	if err != nil {
+		t.Fatalf("Failed to open test key, err=%v", err)
+	}
+	signer := NewSigner(0, key, crypto.SHA256)
+
+	tree := &trillian.Tree{TreeId: 12345, TreeType: trillian.TreeType_LOG, HashStrategy: trillian.HashStrategy_RFC6962_SHA256}
+	a, err := signer.SignTreeAttestation(tree)
+	if err != nil {
+		t.Fatalf("Failed to sign tree attestation: %v", err)
+	}
+	got, err := VerifyTreeAttestation(key.Public(), crypto.SHA256, a)
+	if err != nil {
+		t.Fatalf("VerifyTreeAttestation() failed: %v", err)
+	}
+	if !proto.Equal(got, tree) {
+		t.Errorf("VerifyTreeAttestation() = %v, want %v", got, tree)
+	}
+
+	// Changing the attested settings invalidates the signature.
+	tree.HashStrategy = trillian.HashStrategy_TEST_MAP_HASHER
+	if a.Tree, err = proto.Marshal(tree); err != nil {
+		t.Fatalf("proto.Marshal(): %v", err)
+	}
+	if _, err := VerifyTreeAttestation(key.Public(), crypto.SHA256, a); err == nil {
+		t.Error("VerifyTreeAttestation() of modified settings succeeded, want error")
+	}
+}
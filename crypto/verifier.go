@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
 	"github.com/google/trillian/types"
 	"golang.org/x/crypto/ed25519"
@@ -60,6 +61,22 @@ func VerifySignedMapRoot(pub crypto.PublicKey, hash crypto.Hash, smr *trillian.S
 	return &root, nil
 }
 
+// VerifyTreeAttestation verifies the signature on the TreeAttestation and
+// returns the tree settings it attests to.
+func VerifyTreeAttestation(pub crypto.PublicKey, hash crypto.Hash, a *trillian.TreeAttestation) (*trillian.Tree, error) {
+	if a == nil {
+		return nil, errors.New("TreeAttestation is nil")
+	}
+	if err := Verify(pub, hash, a.Tree, a.Signature); err != nil {
+		return nil, err
+	}
+	var tree trillian.Tree
+	if err := proto.Unmarshal(a.Tree, &tree); err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
 // Verify cryptographically verifies the output of Signer.
 func Verify(pub crypto.PublicKey, hasher crypto.Hash, data, sig []byte) error {
 	if sig == nil {
@@ -17,11 +17,15 @@
 package extension
 
 import (
+	"io"
+
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/encryption"
 	"github.com/google/trillian/util/election2"
+	"github.com/google/trillian/validation"
 )
 
 // Registry defines all extension points available in Trillian.
@@ -45,4 +49,16 @@ type Registry struct {
 	NewKeyProto keys.ProtoGenerator
 	// SetProcessStatus sets the current process status for diagnostic purposes.
 	SetProcessStatus func(string)
+	// SubtreeCache, if set, is shared by the sequencer's storage transactions
+	// to cache log subtrees between runs.
+	SubtreeCache storage.SubtreeCache
+	// Rand is the source of randomness used to generate tree IDs.
+	// If nil, crypto/rand.Reader is used.
+	Rand io.Reader
+	// LeafValidator checks leaves before they are queued to a log.
+	// If nil, all leaves are accepted.
+	LeafValidator validation.LeafValidator
+	// LeafKeyWrapper wraps the data keys of trees created with leaf
+	// encryption. If nil, such trees can't be created.
+	LeafKeyWrapper encryption.KeyWrapper
 }
@@ -621,7 +621,7 @@ func setupAdminServer(ctx context.Context, t *testing.T) (*testServer, error) {
 			ti.UnaryInterceptor,
 		)),
 	)
-	trillian.RegisterTrillianAdminServer(ts.server, sa.New(registry, nil /* allowedTreeTypes */))
+	trillian.RegisterTrillianAdminServer(ts.server, sa.New(registry, nil /* allowedTreeTypes */, 0 /* deleteThreshold */))
 	go func() {
 		if err := ts.server.Serve(ts.lis); err != nil {
 			glog.Errorf("server.Serve()=%v", err)
@@ -81,13 +81,13 @@ type consistencyProofParams struct {
 var inclusionProofTestIndices = []int64{5, 27, 31, 80, 91}
 
 // consistencyProofTestParams are the intervals
-// to test proofs at
-var consistencyProofTestParams = []consistencyProofParams{{1, 2}, {2, 3}, {1, 3}, {2, 4}}
+// to test proofs at, including the trivial ones from size zero.
+var consistencyProofTestParams = []consistencyProofParams{{0, 0}, {0, 2}, {1, 2}, {2, 3}, {1, 3}, {2, 4}}
 
 // consistencyProofBadTestParams are the intervals to probe for consistency proofs, none of
-// these should succeed. Zero is not a valid tree size, nor is -1. 10000000 is outside the
-// range we'll reasonably queue (multiple of batch size).
-var consistencyProofBadTestParams = []consistencyProofParams{{0, 0}, {-1, 0}, {10000000, 10000000}}
+// these should succeed. -1 is not a valid tree size, nor is a first size larger than the
+// second. 10000000 is outside the range we'll reasonably queue (multiple of batch size).
+var consistencyProofBadTestParams = []consistencyProofParams{{-1, 0}, {2, 1}, {10000000, 10000000}}
 
 // RunLogIntegration runs a log integration test using the given client and test
 // parameters.
@@ -235,7 +235,7 @@ func newTestServer(registry extension.Registry) (*testServer, error) {
 			ti.UnaryInterceptor,
 		)),
 	)
-	trillian.RegisterTrillianAdminServer(s.server, admin.New(registry, nil /* allowedTreeTypes */))
+	trillian.RegisterTrillianAdminServer(s.server, admin.New(registry, nil /* allowedTreeTypes */, 0 /* deleteThreshold */))
 	trillian.RegisterTrillianLogServer(s.server, server.NewTrillianLogRPCServer(registry, clock.System))
 
 	var err error
@@ -0,0 +1,90 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// batchTuner keeps the adaptive batch size of each log, see
+// OperationInfo.MaxBatchSize.
+type batchTuner struct {
+	mu    sync.Mutex
+	sizes map[int64]int
+}
+
+// size returns the batch size to use for the next run of the given log.
+func (t *batchTuner) size(logID int64, info *OperationInfo) int {
+	if info.MaxBatchSize <= 0 {
+		return info.BatchSize
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if size, ok := t.sizes[logID]; ok {
+		return size
+	}
+	return clampBatchSize(info.BatchSize, info)
+}
+
+// update adapts the batch size of the given log after a run which integrated
+// the given number of leaves with a batch of the given size. The run is slow if
+// it took more than half of budget, the time which was left before its
+// deadline when it started, or if it ran out of time.
+func (t *batchTuner) update(logID int64, info *OperationInfo, size, leaves int, elapsed, budget time.Duration, timedOut bool) {
+	if info.MaxBatchSize <= 0 {
+		return
+	}
+	slow := timedOut || (budget > 0 && elapsed > budget/2)
+	switch {
+	case slow:
+		size /= 2
+	case leaves >= size:
+		// The batch was full, so the queue is likely deeper.
+		size *= 2
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sizes == nil {
+		t.sizes = make(map[int64]int)
+	}
+	t.sizes[logID] = clampBatchSize(size, info)
+}
+
+// retain forgets the batch sizes of the logs not in keep.
+func (t *batchTuner) retain(keep map[int64]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id := range t.sizes {
+		if !keep[id] {
+			delete(t.sizes, id)
+		}
+	}
+}
+
+// clampBatchSize returns the given batch size within the bounds set by info.
+func clampBatchSize(size int, info *OperationInfo) int {
+	min := info.MinBatchSize
+	if min <= 0 {
+		min = 1
+	}
+	switch {
+	case size < min:
+		return min
+	case size > info.MaxBatchSize:
+		return info.MaxBatchSize
+	}
+	return size
+}
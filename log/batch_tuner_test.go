@@ -0,0 +1,69 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchTuner(t *testing.T) {
+	const logID = 1
+	info := &OperationInfo{BatchSize: 100, MinBatchSize: 30, MaxBatchSize: 300}
+	var tuner batchTuner
+	if got, want := tuner.size(logID, info), 100; got != want {
+		t.Fatalf("size() before any run = %d, want %d", got, want)
+	}
+
+	// Each run starts from the size left by the previous one, with 10s left
+	// before its deadline.
+	for _, test := range []struct {
+		desc     string
+		leaves   int
+		elapsed  time.Duration
+		timedOut bool
+		want     int
+	}{
+		{desc: "full", leaves: 100, elapsed: time.Second, want: 200},
+		{desc: "full-max", leaves: 200, elapsed: time.Second, want: 300},
+		{desc: "partial", leaves: 10, elapsed: time.Second, want: 300},
+		{desc: "slow", leaves: 300, elapsed: 6 * time.Second, want: 150},
+		{desc: "timed-out", elapsed: time.Second, timedOut: true, want: 75},
+		{desc: "slow-again", leaves: 75, elapsed: 10 * time.Second, want: 37},
+		{desc: "slow-min", leaves: 37, elapsed: 10 * time.Second, want: 30},
+	} {
+		size := tuner.size(logID, info)
+		tuner.update(logID, info, size, test.leaves, test.elapsed, 10*time.Second, test.timedOut)
+		if got := tuner.size(logID, info); got != test.want {
+			t.Errorf("%s: size() after run with batch %d = %d, want %d", test.desc, size, got, test.want)
+		}
+	}
+	if got, want := tuner.size(2, info), 100; got != want {
+		t.Errorf("size() of another log = %d, want %d", got, want)
+	}
+	tuner.retain(map[int64]bool{2: true})
+	if got, want := tuner.size(logID, info), 100; got != want {
+		t.Errorf("size() after retain() of another log = %d, want %d", got, want)
+	}
+}
+
+func TestBatchTunerDisabled(t *testing.T) {
+	info := &OperationInfo{BatchSize: 100}
+	var tuner batchTuner
+	tuner.update(1, info, 100, 100, time.Second, 10*time.Second, false)
+	if got, want := tuner.size(1, info), 100; got != want {
+		t.Errorf("size() after full run = %d, want %d", got, want)
+	}
+}
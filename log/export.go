@@ -0,0 +1,225 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/tiles"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/tree"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/types"
+)
+
+// ExportOptions configures ExportTiles.
+type ExportOptions struct {
+	// TreeSize is the size of the tree to export, which must have a retained
+	// signed root. Zero means the size of the latest signed root.
+	TreeSize int64
+	// TileHeight is the height of the exported tiles. Zero means
+	// tiles.DefaultHeight.
+	TileHeight uint
+}
+
+// ExportReport is the result of ExportTiles.
+type ExportReport struct {
+	// Root is the exported signed root.
+	Root *types.LogRootV1
+	// TilesWritten is the number of tiles which were written.
+	TilesWritten int
+	// TilesSkipped is the number of tiles which already existed.
+	TilesSkipped int
+}
+
+// ExportTiles exports the Merkle tree of a log at a retained signed root to
+// store, in the layout of package tiles, so that proofs for all sizes up to
+// that of the root can be served from it without Trillian. Tiles which
+// already exist in store, e.g. from an export of a smaller tree, are not
+// written again. The export fails if store holds a checkpoint of a larger
+// tree, or of another tree of the same size, or tiles of another height.
+//
+// The tiles are read from the Merkle nodes in storage, in a single snapshot.
+// The root hash computed from the exported tiles is checked against the
+// signed root, which is only written once all the tiles are, so readers of
+// store always see a complete tree.
+func ExportTiles(ctx context.Context, ls storage.LogStorage, tree *trillian.Tree, store tiles.Store, opts ExportOptions) (*ExportReport, error) {
+	if tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG {
+		return nil, fmt.Errorf("%v: export not supported for tree type %v", tree.TreeId, tree.TreeType)
+	}
+	height := opts.TileHeight
+	if height == 0 {
+		height = tiles.DefaultHeight
+	}
+	if height > tiles.MaxHeight {
+		return nil, fmt.Errorf("tile height %d > %d", height, tiles.MaxHeight)
+	}
+	hasher, err := trees.LogHasher(tree)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", tree.TreeId, err)
+	}
+	tx, err := ls.SnapshotForTree(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	latest, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%v: failed to get latest root: %v", tree.TreeId, err)
+	}
+	var latestRoot types.LogRootV1
+	if err := latestRoot.UnmarshalBinary(latest.LogRoot); err != nil {
+		return nil, fmt.Errorf("%v: failed to unmarshal latest root: %v", tree.TreeId, err)
+	}
+	slr := latest
+	if opts.TreeSize != 0 && uint64(opts.TreeSize) != latestRoot.TreeSize {
+		if slr, err = tx.SignedLogRootAtSize(ctx, opts.TreeSize); err != nil {
+			return nil, fmt.Errorf("%v: failed to get root of size %d: %v", tree.TreeId, opts.TreeSize, err)
+		} else if slr == nil {
+			return nil, fmt.Errorf("%v: no retained root of size %d", tree.TreeId, opts.TreeSize)
+		}
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return nil, fmt.Errorf("%v: failed to unmarshal root: %v", tree.TreeId, err)
+	}
+	if root.TreeSize > latestRoot.TreeSize {
+		return nil, fmt.Errorf("%v: root of size %d is beyond the latest root, of size %d", tree.TreeId, root.TreeSize, latestRoot.TreeSize)
+	}
+
+	if err := checkStore(ctx, store, height, &root); err != nil {
+		return nil, fmt.Errorf("%v: %v", tree.TreeId, err)
+	}
+
+	report := &ExportReport{Root: &root}
+	for _, t := range tiles.Tiles(height, root.TreeSize) {
+		path := tiles.Path(height, t.Level, t.Index, t.Width)
+		if ok, err := store.Exists(ctx, path); err != nil {
+			return nil, err
+		} else if ok {
+			report.TilesSkipped++
+			continue
+		}
+		// The subtrees of a tile are perfect in the tree of the latest root,
+		// so they are read at its revision.
+		data, err := readTile(ctx, tx, int64(latestRoot.Revision), height, t)
+		if err != nil {
+			return nil, fmt.Errorf("%v: failed to read %s: %v", tree.TreeId, path, err)
+		}
+		if err := store.Write(ctx, path, data); err != nil {
+			return nil, err
+		}
+		report.TilesWritten++
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	p := &tiles.Prover{Hasher: hasher, Height: height, Size: root.TreeSize, Reader: store}
+	hash, err := p.RootHash(ctx, int64(root.TreeSize))
+	if err != nil {
+		return nil, fmt.Errorf("%v: failed to compute root hash from tiles: %v", tree.TreeId, err)
+	}
+	if !bytes.Equal(hash, root.RootHash) {
+		return nil, fmt.Errorf("%v: root hash of exported tiles is %x, want %x", tree.TreeId, hash, root.RootHash)
+	}
+	data, err := proto.Marshal(slr)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Write(ctx, tiles.CheckpointPath, data); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// checkStore checks that the tree of root, with tiles of the given height, can
+// be exported to store, and records the height in store if it isn't yet.
+func checkStore(ctx context.Context, store tiles.Store, height uint, root *types.LogRootV1) error {
+	data, err := store.Read(ctx, tiles.CheckpointPath)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	default:
+		var slr trillian.SignedLogRoot
+		if err := proto.Unmarshal(data, &slr); err != nil {
+			return fmt.Errorf("failed to unmarshal checkpoint: %v", err)
+		}
+		var prev types.LogRootV1
+		if err := prev.UnmarshalBinary(slr.LogRoot); err != nil {
+			return fmt.Errorf("failed to unmarshal checkpoint root: %v", err)
+		}
+		if prev.TreeSize > root.TreeSize {
+			return fmt.Errorf("store has a checkpoint of size %d, larger than %d", prev.TreeSize, root.TreeSize)
+		}
+		if prev.TreeSize == root.TreeSize && !bytes.Equal(prev.RootHash, root.RootHash) {
+			return fmt.Errorf("store has a checkpoint of size %d with root hash %x, want %x", prev.TreeSize, prev.RootHash, root.RootHash)
+		}
+	}
+
+	data, err = store.Read(ctx, tiles.HeightPath)
+	switch {
+	case os.IsNotExist(err):
+		return store.Write(ctx, tiles.HeightPath, []byte(fmt.Sprintf("%d\n", height)))
+	case err != nil:
+		return err
+	}
+	prev, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 32)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %v", tiles.HeightPath, err)
+	}
+	if uint(prev) != height {
+		return fmt.Errorf("store has tiles of height %d, not %d", prev, height)
+	}
+	return nil
+}
+
+// readTile reads the hashes of the subtrees of a tile from storage.
+func readTile(ctx context.Context, r storage.NodeReader, revision int64, height uint, t tiles.Tile) ([]byte, error) {
+	level := int64(t.Level * height)
+	first := int64(t.Index << height)
+	ids := make([]tree.NodeID, t.Width)
+	for i := range ids {
+		id, err := tree.NewNodeIDForTreeCoords(level, first+int64(i), maxTreeDepth)
+		if err != nil {
+			return nil, err
+		}
+		ids[i] = id
+	}
+	nodes, err := r.GetMerkleNodes(ctx, revision, ids)
+	if err != nil {
+		return nil, err
+	}
+	if got, want := len(nodes), len(ids); got != want {
+		return nil, fmt.Errorf("got %d nodes at revision %d, want %d", got, revision, want)
+	}
+	var data []byte
+	for i, node := range nodes {
+		if !node.NodeID.Equivalent(ids[i]) {
+			return nil, fmt.Errorf("node ID mismatch at %d", i)
+		}
+		data = append(data, node.Hash...)
+	}
+	return data, nil
+}
@@ -0,0 +1,201 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/merkle/tiles"
+	"github.com/google/trillian/types"
+)
+
+// exportHeight is small, so that small trees have several levels of tiles.
+const exportHeight = 2
+
+// tempStore returns a tiles.Dir in a new temporary directory.
+func tempStore(t *testing.T) tiles.Dir {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return tiles.Dir(dir)
+}
+
+// checkExport checks that the proofs for all tree sizes up to that of the
+// checkpoint in store, served from its tiles, verify against the roots of a
+// tree of the given leaf hashes.
+func checkExport(ctx context.Context, t *testing.T, store tiles.Store, leafHashes [][]byte) {
+	t.Helper()
+	data, err := store.Read(ctx, tiles.CheckpointPath)
+	if err != nil {
+		t.Fatalf("Read(checkpoint): %v", err)
+	}
+	var slr trillian.SignedLogRoot
+	if err := proto.Unmarshal(data, &slr); err != nil {
+		t.Fatalf("Unmarshal(checkpoint): %v", err)
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		t.Fatalf("UnmarshalBinary(): %v", err)
+	}
+	size := int64(root.TreeSize)
+
+	hasher := rfc6962.DefaultHasher
+	fact := compact.RangeFactory{Hash: hasher.HashChildren}
+	cr := fact.NewEmptyRange(0)
+	roots := [][]byte{hasher.EmptyRoot()}
+	for _, hash := range leafHashes[:size] {
+		if err := cr.Append(hash, nil); err != nil {
+			t.Fatalf("Append(): %v", err)
+		}
+		r, err := cr.GetRootHash(nil)
+		if err != nil {
+			t.Fatalf("GetRootHash(): %v", err)
+		}
+		roots = append(roots, r)
+	}
+	if !bytes.Equal(root.RootHash, roots[size]) {
+		t.Fatalf("checkpoint root hash %x, want %x", root.RootHash, roots[size])
+	}
+
+	p := &tiles.Prover{Hasher: hasher, Height: exportHeight, Size: root.TreeSize, Reader: store}
+	v := merkle.NewLogVerifier(hasher)
+	for size2 := int64(1); size2 <= size; size2++ {
+		for index := int64(0); index < size2; index++ {
+			proof, err := p.InclusionProof(ctx, index, size2)
+			if err != nil {
+				t.Fatalf("InclusionProof(%d, %d): %v", index, size2, err)
+			}
+			if err := v.VerifyInclusionProof(index, size2, proof, roots[size2], leafHashes[index]); err != nil {
+				t.Errorf("VerifyInclusionProof(%d, %d): %v", index, size2, err)
+			}
+		}
+		for size1 := int64(0); size1 <= size2; size1++ {
+			proof, err := p.ConsistencyProof(ctx, size1, size2)
+			if err != nil {
+				t.Fatalf("ConsistencyProof(%d, %d): %v", size1, size2, err)
+			}
+			if err := v.VerifyConsistencyProof(size1, size2, roots[size1], roots[size2], proof); err != nil {
+				t.Errorf("VerifyConsistencyProof(%d, %d): %v", size1, size2, err)
+			}
+		}
+	}
+	if _, err := p.InclusionProof(ctx, 0, size+1); err == nil {
+		t.Errorf("InclusionProof(0, %d) beyond the exported size returned err = nil, want non-nil", size+1)
+	}
+}
+
+func TestExportTiles(t *testing.T) {
+	ctx := context.Background()
+	s, tree := newReplayTest(ctx, t)
+	var leafHashes [][]byte
+	for _, batch := range []struct {
+		prefix string
+		count  int
+	}{{"a", 13}, {"b", 8}} {
+		for i := 0; i < batch.count; i++ {
+			leafHashes = append(leafHashes, rfc6962.DefaultHasher.HashLeaf([]byte(fmt.Sprintf("%s-%d", batch.prefix, i))))
+		}
+	}
+	opts := ExportOptions{TileHeight: exportHeight}
+
+	// The empty tree has no tiles.
+	store := tempStore(t)
+	report, err := ExportTiles(ctx, s.logStorage, tree, store, opts)
+	if err != nil {
+		t.Fatalf("ExportTiles(empty): %v", err)
+	}
+	if report.Root.TreeSize != 0 || report.TilesWritten != 0 {
+		t.Errorf("ExportTiles(empty) = %+v, want no tiles", report)
+	}
+
+	integrate(ctx, t, s, tree, "a", 13)
+	report, err = ExportTiles(ctx, s.logStorage, tree, store, opts)
+	if err != nil {
+		t.Fatalf("ExportTiles(13): %v", err)
+	}
+	// Tiles 0-2 and 3 (partial) of the 13 leaves, and tile 0 (partial) of
+	// the 3 subtrees of 4 leaves.
+	if got, want := *report, (ExportReport{Root: report.Root, TilesWritten: 5}); got != want || report.Root.TreeSize != 13 {
+		t.Errorf("ExportTiles(13) = %+v, want %+v of size 13", got, want)
+	}
+	checkExport(ctx, t, store, leafHashes)
+
+	// Re-exporting a larger tree only writes the new tiles, and the partial
+	// tiles which grew.
+	integrate(ctx, t, s, tree, "b", 8)
+	report, err = ExportTiles(ctx, s.logStorage, tree, store, opts)
+	if err != nil {
+		t.Fatalf("ExportTiles(21): %v", err)
+	}
+	// Tiles 0-2 of the leaves already exist, while tiles 3 (now full), 4 and
+	// 5 of the leaves, tiles 0 (now full) and 1 of the subtrees of 4 leaves,
+	// and tile 0 of the subtrees of 16 leaves are new.
+	if got, want := *report, (ExportReport{Root: report.Root, TilesWritten: 6, TilesSkipped: 3}); got != want {
+		t.Errorf("ExportTiles(21) = %+v, want %+v", got, want)
+	}
+	checkExport(ctx, t, store, leafHashes)
+
+	// Retained roots of older sizes can be exported.
+	older := tempStore(t)
+	if _, err := ExportTiles(ctx, s.logStorage, tree, older, ExportOptions{TreeSize: 13, TileHeight: exportHeight}); err != nil {
+		t.Fatalf("ExportTiles(TreeSize: 13): %v", err)
+	}
+	checkExport(ctx, t, older, leafHashes)
+
+	// A store can't go back to an older root, nor change tile height.
+	for _, test := range []struct {
+		desc string
+		opts ExportOptions
+		want string
+	}{
+		{desc: "older", opts: ExportOptions{TreeSize: 13, TileHeight: exportHeight}, want: "larger than 13"},
+		{desc: "height", opts: ExportOptions{TileHeight: exportHeight + 1}, want: "tiles of height"},
+	} {
+		if _, err := ExportTiles(ctx, s.logStorage, tree, store, test.opts); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("ExportTiles(%s) returned err = %v, want %q", test.desc, err, test.want)
+		}
+	}
+	checkExport(ctx, t, store, leafHashes)
+	if _, err := ExportTiles(ctx, s.logStorage, tree, tempStore(t), ExportOptions{TreeSize: 12}); err == nil || !strings.Contains(err.Error(), "no retained root") {
+		t.Errorf("ExportTiles(TreeSize: 12) returned err = %v, want no retained root", err)
+	}
+
+	// Existing tiles which don't match the tree fail the export, without
+	// writing the checkpoint.
+	bad := tempStore(t)
+	if err := bad.Write(ctx, tiles.Path(exportHeight, 2, 0, 1), make([]byte, 32)); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	if _, err := ExportTiles(ctx, s.logStorage, tree, bad, opts); err == nil || !strings.Contains(err.Error(), "root hash") {
+		t.Errorf("ExportTiles(corrupt tile) returned err = %v, want root hash mismatch", err)
+	}
+	if ok, err := bad.Exists(ctx, tiles.CheckpointPath); err != nil || ok {
+		t.Errorf("Exists(checkpoint) after failed export = (%v, %v), want (false, nil)", ok, err)
+	}
+}
@@ -18,6 +18,7 @@ package log
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -41,11 +42,14 @@ var (
 	once              sync.Once
 	knownLogs         monitoring.Gauge
 	resignations      monitoring.Counter
+	resignRequests    monitoring.Counter
 	isMaster          monitoring.Gauge
 	signingRuns       monitoring.Counter
 	failedSigningRuns monitoring.Counter
 	entriesAdded      monitoring.Counter
 	batchesAdded      monitoring.Counter
+	inFlightRuns      monitoring.Gauge
+	queueWait         monitoring.Histogram
 )
 
 func createMetrics(mf monitoring.MetricFactory) {
@@ -54,6 +58,7 @@ func createMetrics(mf monitoring.MetricFactory) {
 	}
 	knownLogs = mf.NewGauge("known_logs", "Set to 1 for known logs (whether this instance is master or not)", logIDLabel)
 	resignations = mf.NewCounter("master_resignations", "Number of mastership resignations", logIDLabel)
+	resignRequests = mf.NewCounter("master_resignation_requests", "Number of mastership resignations requested through ResignMastership", logIDLabel)
 	isMaster = mf.NewGauge("is_master", "Whether this instance is master (0/1)", logIDLabel)
 	signingRuns = mf.NewCounter("signing_runs", "Number of times a signing run has succeeded", logIDLabel)
 	failedSigningRuns = mf.NewCounter("failed_signing_runs", "Number of times a signing run has failed", logIDLabel)
@@ -68,6 +73,10 @@ func createMetrics(mf monitoring.MetricFactory) {
 	// entriesAdded / batchesAdded is average batch size. These can be used for
 	// tuning sequencing or evaluating performance.
 	batchesAdded = mf.NewCounter("batches_added", "Number of times a non zero number of entries was added", logIDLabel)
+	inFlightRuns = mf.NewGauge("operation_runs_in_flight", "Number of log operation runs currently executing")
+	// queueWait is the time between the start of a pass and the start of the
+	// run for a log, i.e. how long the log waited for a free worker.
+	queueWait = mf.NewHistogramWithBuckets("operation_queue_wait_seconds", "Time a log waited for a worker before its operation run started", monitoring.LatencyBuckets(), logIDLabel)
 }
 
 // Operation defines a task that operates on a log. Examples are scheduling, signing,
@@ -78,6 +87,23 @@ type Operation interface {
 	ExecutePass(ctx context.Context, logID int64, info *OperationInfo) (int, error)
 }
 
+// LogRetainer is optionally implemented by Operations which keep state for
+// each log, so that the state of the logs which an OperationManager no longer
+// runs them for, e.g. deleted ones or those held by another signer, is
+// dropped.
+type LogRetainer interface {
+	// RetainLogs drops the state of the logs not in logIDs.
+	RetainLogs(logIDs []int64)
+}
+
+// Semaphore bounds the number of operation runs executing at once, possibly
+// across several processes.
+type Semaphore interface {
+	// Acquire blocks until a run may start, or ctx is done. If it returns no
+	// error, the returned function must be called once the run is over.
+	Acquire(ctx context.Context) (func(), error)
+}
+
 // OperationInfo bundles up information needed for running a set of Operations.
 type OperationInfo struct {
 	// Registry provides access to Trillian storage.
@@ -87,21 +113,46 @@ type OperationInfo struct {
 
 	// BatchSize is the processing batch size to be passed to tasks run by this manager
 	BatchSize int
+	// MinBatchSize and MaxBatchSize, if MaxBatchSize is positive, make the
+	// sequencer adapt the batch size of each log within these bounds, starting
+	// from BatchSize. The batch size doubles after a run fills its batch, i.e.
+	// when the queue is deeper than the batch, and halves after a run takes
+	// more than half of the time left before its deadline.
+	MinBatchSize int
+	MaxBatchSize int
 	// TimeSource should be used by the Operation to allow mocking for tests.
 	TimeSource clock.TimeSource
+	// InitLogs makes the sequencer write the initial, empty signed root of
+	// logs which have none on its first pass over them, as the InitLog RPC
+	// does, rather than skipping them until it's called.
+	InitLogs bool
 
 	// The following parameters govern the overall scheduling of Operations
 	// by a OperationManager.
 
 	// Election-related configuration.
 	ElectionConfig election.RunnerConfig
+	// Sharding, if set, statically partitions logs among signers instead of
+	// electing a master for each, and Registry.ElectionFactory is ignored.
+	// Each pass sequences the logs of the current shard, so logs move between
+	// signers as soon as their shards change. Until all signers have the new
+	// shards, a log may briefly be sequenced by two signers, which storage
+	// resolves as with a split election, or by none.
+	Sharding *Sharding
 
 	// RunInterval is the time between starting batches of processing.  If a
 	// batch takes longer than this interval to complete, the next batch
 	// will start immediately.
 	RunInterval time.Duration
-	// NumWorkers is the number of worker goroutines to run in parallel.
+	// NumWorkers is the number of worker goroutines to run in parallel, which
+	// bounds the number of logs processed concurrently. Logs are handed to
+	// workers in least recently run order, so that all logs make progress even
+	// if a pass times out before completing.
 	NumWorkers int
+	// Semaphore, if set, is acquired by workers for each operation run, in
+	// addition to the NumWorkers limit. This allows bounding the concurrency of
+	// runs across all processes sharing a database.
+	Semaphore Semaphore
 	// Timeout sets an optional timeout on each operation run.
 	// If unset, default to the value of DefaultTimeout.
 	Timeout time.Duration
@@ -115,6 +166,7 @@ type OperationManager struct {
 	logOperation Operation
 
 	// electionRunner tracks the goroutines that run per-log mastership elections
+	runnersMu           sync.Mutex
 	electionRunner      map[string]*election.Runner
 	pendingResignations chan election.Resignation
 	runnerWG            sync.WaitGroup
@@ -123,6 +175,10 @@ type OperationManager struct {
 	// Cache of logID => name; assumed not to change during runtime
 	logNamesMutex sync.Mutex
 	logNames      map[int64]string
+	// schedule orders logs for each pass.
+	schedule *schedule
+	// status tracks the outcome of the passes for each log.
+	status *statusTracker
 }
 
 // NewOperationManager creates a new OperationManager instance.
@@ -139,7 +195,53 @@ func NewOperationManager(info OperationInfo, logOperation Operation) *OperationM
 		electionRunner:      make(map[string]*election.Runner),
 		pendingResignations: make(chan election.Resignation, 100),
 		logNames:            make(map[int64]string),
+		schedule:            newSchedule(),
+		status:              newStatusTracker(),
+	}
+}
+
+// SequencingStatus returns the state of the operation for the given log, as
+// of the latest pass. It is cheap, and doesn't run the operation.
+func (o *OperationManager) SequencingStatus(logID int64) SequencingStatus {
+	return o.status.get(logID)
+}
+
+// ResignMastership makes this instance resign mastership for the given log,
+// or for all logs if logID is zero, so that they're re-elected, e.g. to
+// rebalance logs across instances. Resignations happen between passes, like
+// those due after holding mastership for the configured interval. It returns
+// the IDs of the logs that this instance was master for, which are resigning.
+func (o *OperationManager) ResignMastership(logID int64, reason string) ([]int64, error) {
+	if o.info.Registry.ElectionFactory == nil || o.info.Sharding != nil {
+		return nil, errors.New("mastership elections are not in use")
+	}
+	o.runnersMu.Lock()
+	defer o.runnersMu.Unlock()
+	var ids []string
+	if logID != 0 {
+		ids = append(ids, strconv.FormatInt(logID, 10))
+	} else {
+		for id := range o.electionRunner {
+			ids = append(ids, id)
+		}
+	}
+
+	var resigning []int64
+	for _, id := range ids {
+		r := o.electionRunner[id]
+		if r == nil || !r.RequestResignation(reason) {
+			continue
+		}
+		glog.Warningf("%s: resigning mastership on request: %s", id, reason)
+		resignRequests.Inc(id)
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse logID %v as int64", id)
+		}
+		resigning = append(resigning, n)
 	}
+	sort.Slice(resigning, func(i, j int) bool { return resigning[i] < resigning[j] })
+	return resigning, nil
 }
 
 // getActiveLogIDs returns IDs of all currently active logs, regardless of
@@ -203,6 +305,9 @@ func (o *OperationManager) heldInfo(ctx context.Context, logIDs []int64) string
 // master for. Note that the instance may hold mastership for logs that are not
 // listed in allIDs, but such logs are skipped.
 func (o *OperationManager) masterFor(ctx context.Context, allIDs []int64) ([]int64, error) {
+	if o.info.Sharding != nil {
+		return o.shardFor(allIDs), nil
+	}
 	if o.info.Registry.ElectionFactory == nil {
 		return allIDs, nil
 	}
@@ -223,6 +328,8 @@ func (o *OperationManager) masterFor(ctx context.Context, allIDs []int64) ([]int
 	}
 
 	// Synchronize the set of log IDs with those we are tracking mastership for.
+	o.runnersMu.Lock()
+	defer o.runnersMu.Unlock()
 	for _, logID := range allStringIDs {
 		knownLogs.Set(1, logID)
 		if o.electionRunner[logID] != nil {
@@ -261,6 +368,24 @@ func (o *OperationManager) masterFor(ctx context.Context, allIDs []int64) ([]int
 	return heldIDs, nil
 }
 
+// shardFor returns the list of log IDs among allIDs that belong to the shard
+// of this instance.
+func (o *OperationManager) shardFor(allIDs []int64) []int64 {
+	shard := o.info.Sharding.Get()
+	owned := make([]int64, 0, len(allIDs))
+	for _, id := range allIDs {
+		s := strconv.FormatInt(id, 10)
+		knownLogs.Set(1, s)
+		if !shard.Owns(id) {
+			isMaster.Set(0, s)
+			continue
+		}
+		isMaster.Set(1, s)
+		owned = append(owned, id)
+	}
+	return owned
+}
+
 // updateHeldIDs updates the process status with the number/list of logs that
 // the instance holds mastership for.
 func (o *OperationManager) updateHeldIDs(ctx context.Context, logIDs, activeIDs []int64) {
@@ -294,12 +419,19 @@ func (o *OperationManager) getLogsAndExecutePass(ctx context.Context) error {
 		return fmt.Errorf("failed to determine log IDs we're master for: %v", err)
 	}
 	o.updateHeldIDs(ctx, logIDs, activeIDs)
+	o.status.setHeld(logIDs)
+	if r, ok := o.logOperation.(LogRetainer); ok {
+		r.RetainLogs(logIDs)
+	}
 
 	// TODO(pavelkalinnikov): Run executor once instead of doing it on each pass.
 	// This will be also needed when factoring out per-log operation loop.
 	ex := newExecutor(o.logOperation, &o.info, len(logIDs))
-	// Put logIDs that need to be processed to the executor's channel.
-	for _, logID := range logIDs {
+	ex.schedule = o.schedule
+	ex.status = o.status
+	// Put logIDs that need to be processed to the executor's channel, starting
+	// with the ones that have waited the longest.
+	for _, logID := range o.schedule.order(logIDs) {
 		ex.jobs <- logID
 	}
 	close(ex.jobs) // Cause executor's run to terminate when it has drained the jobs.
@@ -368,6 +500,7 @@ loop:
 	}
 
 	// Terminate all the election runners
+	o.runnersMu.Lock()
 	for logID, runner := range o.electionRunner {
 		if runner == nil {
 			continue
@@ -375,6 +508,7 @@ loop:
 		glog.V(1).Infof("cancel election runner for %s", logID)
 		runner.Cancel()
 	}
+	o.runnersMu.Unlock()
 
 	// Drain any remaining resignations which might have triggered.
 	close(o.pendingResignations)
@@ -399,6 +533,11 @@ type logOperationExecutor struct {
 	// auto-cancelable when mastership is lost.
 	// TODO(pavelkalinnikov): Report job completion status back.
 	jobs chan int64
+
+	// schedule, if set, is notified when a job starts.
+	schedule *schedule
+	// status, if set, is notified when a job finishes.
+	status *statusTracker
 }
 
 func newExecutor(op Operation, info *OperationInfo, jobs int) *logOperationExecutor {
@@ -433,9 +572,37 @@ func (e *logOperationExecutor) run(ctx context.Context) {
 					return
 				}
 
+				if err := ctx.Err(); err != nil {
+					// Leave the log for the next pass, which will run it first.
+					glog.Warningf("%v: skipping ExecutePass: %v", logID, err)
+					continue
+				}
+
+				var release func()
+				if sem := e.info.Semaphore; sem != nil {
+					var err error
+					if release, err = sem.Acquire(ctx); err != nil {
+						// Leave the log for the next pass, which will run it first.
+						glog.Warningf("%v: skipping ExecutePass: failed to acquire semaphore: %v", logID, err)
+						continue
+					}
+				}
+
 				label := strconv.FormatInt(logID, 10)
 				start := e.info.TimeSource.Now()
+				queueWait.Observe(start.Sub(startBatch).Seconds(), label)
+				if e.schedule != nil {
+					e.schedule.started(logID, start)
+				}
+				inFlightRuns.Add(1)
 				count, err := e.op.ExecutePass(ctx, logID, e.info)
+				inFlightRuns.Add(-1)
+				if release != nil {
+					release()
+				}
+				if e.status != nil {
+					e.status.finished(logID, start, e.info.TimeSource.Now(), count, err)
+				}
 				if err != nil {
 					glog.Errorf("ExecutePass(%v) failed: %v", logID, err)
 					failedSigningRuns.Inc(label)
@@ -469,3 +636,133 @@ func (e *logOperationExecutor) run(ctx context.Context) {
 		glog.V(1).Infof("Group run completed in %.2f seconds: no items to process", d)
 	}
 }
+
+// schedule tracks when the operation was last started for each log, so that
+// logs can be processed in least recently run order.
+type schedule struct {
+	mu        sync.Mutex
+	lastStart map[int64]time.Time
+}
+
+func newSchedule() *schedule {
+	return &schedule{lastStart: make(map[int64]time.Time)}
+}
+
+// order returns a copy of logIDs sorted by the time their operation was last
+// started, oldest first. Logs that never ran come first; ties are broken by
+// ID. Logs not in logIDs are forgotten.
+func (s *schedule) order(logIDs []int64) []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int64, len(logIDs))
+	copy(ids, logIDs)
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := s.lastStart[ids[i]], s.lastStart[ids[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return ids[i] < ids[j]
+	})
+
+	known := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		known[id] = true
+	}
+	for id := range s.lastStart {
+		if !known[id] {
+			delete(s.lastStart, id)
+		}
+	}
+	return ids
+}
+
+// started records that the operation for logID was started at t.
+func (s *schedule) started(logID int64, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastStart[logID] = t
+}
+
+// SequencingStatus is the state of the operation for a log, e.g. sequencing,
+// as seen by an OperationManager.
+type SequencingStatus struct {
+	// Master is whether the manager held mastership for the log at its latest
+	// pass, or would have run the operation for it if mastership is disabled.
+	Master bool
+	// LastSuccess is when the latest successful run of the operation for the
+	// log finished, or zero if there was none.
+	LastSuccess time.Time
+	// LastErr is the error of the latest run of the operation for the log, if
+	// it failed.
+	LastErr error
+	// Runs are the latest runs of the operation for the log, newest first, up
+	// to MaxSequencingRuns of them.
+	Runs []SequencingRun
+}
+
+// MaxSequencingRuns is the number of the latest runs of the operation for each
+// log which are kept in its SequencingStatus.
+const MaxSequencingRuns = 10
+
+// SequencingRun is the outcome of a run of the operation for a log.
+type SequencingRun struct {
+	// Start is when the run started.
+	Start time.Time
+	// Duration is how long the run took.
+	Duration time.Duration
+	// Items is the number of items processed by the run, e.g. leaves
+	// integrated.
+	Items int
+	// Err is the error of the run, if it failed.
+	Err error
+}
+
+// statusTracker records the SequencingStatus of each log.
+type statusTracker struct {
+	mu     sync.Mutex
+	held   map[int64]bool
+	status map[int64]SequencingStatus
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{held: make(map[int64]bool), status: make(map[int64]SequencingStatus)}
+}
+
+// setHeld records that the manager holds mastership for exactly logIDs.
+func (s *statusTracker) setHeld(logIDs []int64) {
+	held := make(map[int64]bool, len(logIDs))
+	for _, id := range logIDs {
+		held[id] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.held = held
+}
+
+// finished records that the operation for logID, which started at start,
+// finished at end having processed items, with err.
+func (s *statusTracker) finished(logID int64, start, end time.Time, items int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.status[logID]
+	st.LastErr = err
+	if err == nil {
+		st.LastSuccess = end
+	}
+	run := SequencingRun{Start: start, Duration: end.Sub(start), Items: items, Err: err}
+	// The slice is copied rather than updated in place, as get returns it.
+	st.Runs = append([]SequencingRun{run}, st.Runs...)
+	if len(st.Runs) > MaxSequencingRuns {
+		st.Runs = st.Runs[:MaxSequencingRuns]
+	}
+	s.status[logID] = st
+}
+
+func (s *statusTracker) get(logID int64) SequencingStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.status[logID]
+	st.Master = s.held[logID]
+	return st
+}
@@ -473,6 +473,41 @@ func TestMasterFor(t *testing.T) {
 	}
 }
 
+func TestOperationManagerResignMastership(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	info := OperationInfo{
+		Registry:   extension.Registry{ElectionFactory: masterForEvenFactory{}},
+		TimeSource: clock.System,
+	}
+	lom := NewOperationManager(info, nil)
+	lom.masterFor(ctx, []int64{1, 2, 3, 4})
+	time.Sleep(100 * time.Millisecond)
+
+	for _, test := range []struct {
+		logID int64
+		want  []int64
+	}{
+		{logID: 3},
+		{logID: 5},
+		{logID: 2, want: []int64{2}},
+		{logID: 0, want: []int64{2, 4}},
+	} {
+		got, err := lom.ResignMastership(test.logID, "testing")
+		if err != nil {
+			t.Fatalf("ResignMastership(%d): %v", test.logID, err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ResignMastership(%d)=%v; want %v", test.logID, got, test.want)
+		}
+	}
+
+	noElections := NewOperationManager(OperationInfo{TimeSource: clock.System}, nil)
+	if _, err := noElections.ResignMastership(0, "testing"); err == nil {
+		t.Error("ResignMastership() without elections succeeded; want error")
+	}
+}
+
 type alwaysMasterFactory struct{}
 
 func (m alwaysMasterFactory) NewElection(ctx context.Context, treeID string) (election2.Election, error) {
@@ -498,3 +533,200 @@ type failureFactory struct{}
 func (ff failureFactory) NewElection(ctx context.Context, treeID string) (election2.Election, error) {
 	return nil, errors.New("injected failure")
 }
+
+func TestSchedule(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newSchedule()
+
+	if got, want := s.order([]int64{3, 1, 2}), []int64{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order() = %v, want %v", got, want)
+	}
+
+	// Logs 1 and 2 ran, 1 most recently, so 3 (never ran) goes first.
+	s.started(2, base)
+	s.started(1, base.Add(time.Second))
+	if got, want := s.order([]int64{1, 2, 3}), []int64{3, 2, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order() = %v, want %v", got, want)
+	}
+
+	// Logs which are no longer passed in are forgotten.
+	s.order([]int64{1, 3})
+	if _, ok := s.lastStart[2]; ok {
+		t.Errorf("order() didn't forget log 2")
+	}
+}
+
+func TestStatusTracker(t *testing.T) {
+	base := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newStatusTracker()
+	s.setHeld([]int64{1, 2})
+
+	start := base.Add(-time.Second)
+	s.finished(1, start, base, 5, nil)
+	s.finished(2, start, base, 3, nil)
+	s.finished(2, base, base.Add(2*time.Second), 0, errors.New("failed"))
+	run1 := SequencingRun{Start: start, Duration: time.Second, Items: 5}
+	run2 := SequencingRun{Start: start, Duration: time.Second, Items: 3}
+	failedRun := SequencingRun{Start: base, Duration: 2 * time.Second, Err: errors.New("failed")}
+	for _, test := range []struct {
+		logID int64
+		want  SequencingStatus
+	}{
+		{logID: 1, want: SequencingStatus{Master: true, LastSuccess: base, Runs: []SequencingRun{run1}}},
+		// A failed run keeps the time of the latest successful one.
+		{logID: 2, want: SequencingStatus{Master: true, LastSuccess: base, LastErr: errors.New("failed"), Runs: []SequencingRun{failedRun, run2}}},
+		{logID: 3, want: SequencingStatus{}},
+	} {
+		if got := s.get(test.logID); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("get(%d) = %+v, want %+v", test.logID, got, test.want)
+		}
+	}
+
+	// Mastership is replaced by each pass, but the run outcomes are kept.
+	s.setHeld([]int64{2})
+	if got, want := s.get(1), (SequencingStatus{LastSuccess: base, Runs: []SequencingRun{run1}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("get(1) = %+v, want %+v", got, want)
+	}
+
+	// Only the latest runs are kept.
+	got := s.get(2).Runs
+	for i := 0; i < MaxSequencingRuns; i++ {
+		s.finished(2, base, base, i, nil)
+	}
+	runs := s.get(2).Runs
+	if got, want := len(runs), MaxSequencingRuns; got != want {
+		t.Fatalf("get(2) has %d runs, want %d", got, want)
+	}
+	if got, want := runs[0].Items, MaxSequencingRuns-1; got != want {
+		t.Errorf("get(2).Runs[0].Items = %d, want %d", got, want)
+	}
+	// Runs returned earlier are unaffected.
+	if want := []SequencingRun{failedRun, run2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("earlier get(2).Runs = %+v, want %+v", got, want)
+	}
+}
+
+func TestOperationManagerPassesIDsInFairOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logID1 := int64(451)
+	logID2 := int64(145)
+	mockStorage, mockAdmin := setupLogIDs(ctrl, map[int64]string{logID1: "LogID1", logID2: "LogID2"})
+	registry := extension.Registry{
+		LogStorage:   mockStorage,
+		AdminStorage: mockAdmin,
+	}
+
+	ts := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	info := defaultOperationInfo(registry)
+	info.TimeSource = ts
+	op := &recordingOperation{ts: ts}
+	lom := NewOperationManager(info, op)
+
+	// Each pass ends after a single run, so a log that is skipped in one pass
+	// must be run first in the next.
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		op.cancel = cancel
+		lom.OperationSingle(ctx)
+		cancel()
+	}
+	if want := []int64{logID2, logID1, logID2}; !reflect.DeepEqual(op.ran, want) {
+		t.Errorf("ExecutePass() called for logs %v, want %v", op.ran, want)
+	}
+}
+
+// recordingOperation is an Operation which records the logs it's run for,
+// advances a fake clock on each run and then ends the pass.
+type recordingOperation struct {
+	ts     *clock.FakeTimeSource
+	ran    []int64
+	cancel context.CancelFunc
+}
+
+func (r *recordingOperation) ExecutePass(ctx context.Context, logID int64, info *OperationInfo) (int, error) {
+	r.ran = append(r.ran, logID)
+	r.ts.Set(r.ts.Now().Add(time.Second))
+	r.cancel()
+	return 1, nil
+}
+
+func TestOperationManagerSemaphore(t *testing.T) {
+	for _, test := range []struct {
+		desc       string
+		acquireErr error
+		wantRuns   int32
+	}{
+		{desc: "limited", wantRuns: 3},
+		{desc: "acquireFails", acquireErr: errors.New("etcd unavailable")},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage, mockAdmin := setupLogIDs(ctrl, map[int64]string{1: "LogID1", 2: "LogID2", 3: "LogID3"})
+			registry := extension.Registry{
+				LogStorage:   mockStorage,
+				AdminStorage: mockAdmin,
+			}
+
+			sem := &chanSemaphore{slots: make(chan struct{}, 1), err: test.acquireErr}
+			info := defaultOperationInfo(registry)
+			info.NumWorkers = 3
+			info.Semaphore = sem
+			op := &concurrencyOperation{}
+			lom := NewOperationManager(info, op)
+			lom.OperationSingle(context.Background())
+
+			if got := atomic.LoadInt32(&op.runs); got != test.wantRuns {
+				t.Errorf("ExecutePass() called %d times, want %d", got, test.wantRuns)
+			}
+			if got := atomic.LoadInt32(&op.maxRunning); got > 1 {
+				t.Errorf("ExecutePass() ran %d times concurrently, want at most 1", got)
+			}
+			if got := len(sem.slots); got != 0 {
+				t.Errorf("%d semaphore slots still held after pass", got)
+			}
+		})
+	}
+}
+
+// chanSemaphore is a Semaphore with cap(slots) slots, or which fails with err
+// if set.
+type chanSemaphore struct {
+	slots chan struct{}
+	err   error
+}
+
+func (s *chanSemaphore) Acquire(ctx context.Context) (func(), error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// concurrencyOperation is an Operation which records the maximum number of
+// concurrent runs.
+type concurrencyOperation struct {
+	runs, running, maxRunning int32
+}
+
+func (c *concurrencyOperation) ExecutePass(ctx context.Context, logID int64, info *OperationInfo) (int, error) {
+	atomic.AddInt32(&c.runs, 1)
+	running := atomic.AddInt32(&c.running, 1)
+	defer atomic.AddInt32(&c.running, -1)
+	for {
+		max := atomic.LoadInt32(&c.maxRunning)
+		if running <= max || atomic.CompareAndSwapInt32(&c.maxRunning, max, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return 1, nil
+}
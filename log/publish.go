@@ -0,0 +1,103 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/types"
+)
+
+// signingCadence returns the signing_interval and max_queue_age of tree, or
+// zero for those it doesn't have. Without a signing_interval, every
+// integration is signed.
+func signingCadence(tree *trillian.Tree) (interval, maxQueueAge time.Duration, err error) {
+	if tree.SigningInterval != nil {
+		if interval, err = ptypes.Duration(tree.SigningInterval); err != nil {
+			return 0, 0, fmt.Errorf("invalid signing_interval: %v", err)
+		}
+	}
+	if tree.MaxQueueAge != nil {
+		if maxQueueAge, err = ptypes.Duration(tree.MaxQueueAge); err != nil {
+			return 0, 0, fmt.Errorf("invalid max_queue_age: %v", err)
+		}
+	}
+	return interval, maxQueueAge, nil
+}
+
+// flushDue returns whether a leaf queued at the given time would be published
+// later than maxQueueAge after it was, if the pending integrated state were
+// signed once interval has passed since the current root.
+func flushDue(current *types.LogRootV1, interval, maxQueueAge time.Duration, queued time.Time) bool {
+	if maxQueueAge <= 0 {
+		return false
+	}
+	next := time.Unix(0, int64(current.TimestampNanos)).Add(interval)
+	return next.Sub(queued) > maxQueueAge
+}
+
+// SignRoot signs the pending integrated state of tree on demand, if it has a
+// signing_interval and leaves were integrated since its latest signed root.
+// It returns the latest signed root, and whether it was signed by this call.
+func (s Sequencer) SignRoot(ctx context.Context, tree *trillian.Tree) (*trillian.SignedLogRoot, bool, error) {
+	label := strconv.FormatInt(tree.TreeId, 10)
+	var slr *trillian.SignedLogRoot
+	var signed bool
+	err := s.logStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		signed = false
+		latest, err := tx.LatestSignedLogRoot(ctx)
+		if err != nil {
+			return err
+		}
+		slr = latest
+		irs, ok := tx.(storage.IntegratedRootStore)
+		if !ok {
+			return nil
+		}
+		var current types.LogRootV1
+		if err := current.UnmarshalBinary(latest.LogRoot); err != nil {
+			return fmt.Errorf("%v: failed to unmarshal latest root: %v", tree.TreeId, err)
+		}
+		pending, err := irs.LatestIntegratedRoot(ctx)
+		if err != nil {
+			return fmt.Errorf("%v: failed to get latest integrated root: %v", tree.TreeId, err)
+		}
+		if pending.Revision <= current.Revision {
+			return nil
+		}
+		root := &types.LogRootV1{
+			RootHash:       pending.RootHash,
+			TimestampNanos: trees.RootTimestamp(tree, s.timeSource.Now()),
+			TreeSize:       pending.TreeSize,
+			Revision:       pending.Revision,
+		}
+		if slr, err = s.signRoot(ctx, tx, tree, root, &current, label); err != nil {
+			return err
+		}
+		signed = true
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return slr, signed, nil
+}
@@ -0,0 +1,224 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/clock"
+)
+
+func TestSigningInterval(t *testing.T) {
+	ctx := context.Background()
+	ts := clock.NewFake(fakeTime)
+	s, tree := newReintegrateTest(ctx, t, ts)
+	tree.SigningInterval = ptypes.DurationProto(time.Minute)
+
+	var leafHashes [][]byte
+	integrate := func(prefix string, count int) {
+		t.Helper()
+		queueLeaves(ctx, t, s, tree, prefix, count, fakeTime.Add(-time.Hour))
+		for i := 0; i < count; i++ {
+			leafHashes = append(leafHashes, rfc6962.DefaultHasher.HashLeaf([]byte(fmt.Sprintf("%s-%d", prefix, i))))
+		}
+		if n, err := s.IntegrateBatch(ctx, tree, 10, 0, 0); err != nil || n != count {
+			t.Fatalf("IntegrateBatch() = (%d, %v), want (%d, nil)", n, err, count)
+		}
+	}
+	checkRoots := func(wantSigned, wantIntegrated uint64) {
+		t.Helper()
+		_, signed, err := s.latestRoot(ctx, tree)
+		if err != nil {
+			t.Fatalf("latestRoot(): %v", err)
+		}
+		tx, err := s.logStorage.SnapshotForTree(ctx, tree)
+		if err != nil {
+			t.Fatalf("SnapshotForTree(): %v", err)
+		}
+		defer tx.Close()
+		integrated, err := tx.(storage.IntegratedRootStore).LatestIntegratedRoot(ctx)
+		if err != nil {
+			t.Fatalf("LatestIntegratedRoot(): %v", err)
+		}
+		if signed.TreeSize != wantSigned || integrated.TreeSize != wantIntegrated {
+			t.Errorf("signed and integrated sizes = %d, %d, want %d, %d", signed.TreeSize, integrated.TreeSize, wantSigned, wantIntegrated)
+		}
+		fact := compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+		cr := fact.NewEmptyRange(0)
+		for _, hash := range leafHashes[:signed.TreeSize] {
+			if err := cr.Append(hash, nil); err != nil {
+				t.Fatalf("Append(): %v", err)
+			}
+		}
+		if hash, err := cr.GetRootHash(nil); err != nil || !bytes.Equal(hash, signed.RootHash) {
+			t.Errorf("signed root hash %x, want %x (err %v)", signed.RootHash, hash, err)
+		}
+	}
+
+	// The first integration is signed, as the latest root is old.
+	integrate("a", 3)
+	checkRoots(3, 3)
+
+	// Integrations within the signing interval are kept unsigned.
+	ts.Set(fakeTime.Add(10 * time.Second))
+	integrate("b", 2)
+	ts.Set(fakeTime.Add(20 * time.Second))
+	integrate("c", 1)
+	checkRoots(3, 6)
+
+	// Signing on demand publishes the integrated state, once.
+	ts.Set(fakeTime.Add(30 * time.Second))
+	for _, want := range []bool{true, false} {
+		slr, signed, err := s.SignRoot(ctx, tree)
+		if err != nil || signed != want {
+			t.Fatalf("SignRoot() = (_, %v, %v), want (_, %v, nil)", signed, err, want)
+		}
+		var root types.LogRootV1
+		if err := root.UnmarshalBinary(slr.LogRoot); err != nil || root.TreeSize != 6 {
+			t.Errorf("SignRoot() returned root of size %d (err %v), want 6", root.TreeSize, err)
+		}
+	}
+	checkRoots(6, 6)
+
+	// Pending integrations are signed by a later pass once the interval has
+	// passed, even without new leaves.
+	ts.Set(fakeTime.Add(40 * time.Second))
+	integrate("d", 4)
+	checkRoots(6, 10)
+	ts.Set(fakeTime.Add(50 * time.Second))
+	if n, err := s.IntegrateBatch(ctx, tree, 10, 0, 0); err != nil || n != 0 {
+		t.Fatalf("IntegrateBatch() = (%d, %v), want (0, nil)", n, err)
+	}
+	checkRoots(6, 10)
+	ts.Set(fakeTime.Add(2 * time.Minute))
+	if n, err := s.IntegrateBatch(ctx, tree, 10, 0, 0); err != nil || n != 0 {
+		t.Fatalf("IntegrateBatch() = (%d, %v), want (0, nil)", n, err)
+	}
+	checkRoots(10, 10)
+}
+
+func TestMaxQueueAge(t *testing.T) {
+	ctx := context.Background()
+	ts := clock.NewFake(fakeTime)
+	s, tree := newReintegrateTest(ctx, t, ts)
+	tree.SigningInterval = ptypes.DurationProto(time.Minute)
+	tree.MaxQueueAge = ptypes.DurationProto(30 * time.Second)
+
+	for _, step := range []struct {
+		desc       string
+		now        time.Duration
+		queued     time.Duration
+		wantSigned uint64
+	}{
+		{desc: "oldRoot", queued: -time.Hour, wantSigned: 1},
+		// The leaf would be published 45s after it was queued, at the end of
+		// the signing interval, so it's flushed.
+		{desc: "flushed", now: 20 * time.Second, queued: 15 * time.Second, wantSigned: 2},
+		// The leaf will be published 28s after it was queued, in the next
+		// signing interval.
+		{desc: "withinMaxAge", now: 55 * time.Second, queued: 52 * time.Second, wantSigned: 2},
+		{desc: "intervalPassed", now: 80 * time.Second, queued: 79 * time.Second, wantSigned: 4},
+	} {
+		ts.Set(fakeTime.Add(step.now))
+		queueLeaves(ctx, t, s, tree, step.desc, 1, fakeTime.Add(step.queued))
+		if n, err := s.IntegrateBatch(ctx, tree, 10, 0, 0); err != nil || n != 1 {
+			t.Fatalf("%s: IntegrateBatch() = (%d, %v), want (1, nil)", step.desc, n, err)
+		}
+		_, root, err := s.latestRoot(ctx, tree)
+		if err != nil {
+			t.Fatalf("%s: latestRoot(): %v", step.desc, err)
+		}
+		if root.TreeSize != step.wantSigned {
+			t.Errorf("%s: signed root of size %d, want %d", step.desc, root.TreeSize, step.wantSigned)
+		}
+	}
+}
+
+func TestMaxQueueAge_Overdue(t *testing.T) {
+	ctx := context.Background()
+	ts := clock.NewFake(fakeTime)
+	s, tree := newReintegrateTest(ctx, t, ts)
+	tree.MaxQueueAge = ptypes.DurationProto(30 * time.Second)
+
+	queueLeaves(ctx, t, s, tree, "overdue", 3, fakeTime.Add(-40*time.Second))
+	queueLeaves(ctx, t, s, tree, "recent", 2, fakeTime.Add(-10*time.Second))
+
+	for _, step := range []struct {
+		desc        string
+		wantLeaves  int
+		wantOverdue bool
+	}{
+		// The guard window would hold back all the leaves, but the overdue
+		// ones fill the batch, so more of them may be queued.
+		{desc: "fullBatch", wantLeaves: 2, wantOverdue: true},
+		{desc: "lastOverdue", wantLeaves: 1},
+		// Recent leaves are still held back by the guard window.
+		{desc: "recent", wantLeaves: 0},
+	} {
+		ts.Set(ts.Now().Add(time.Millisecond))
+		n, _, overdue, err := s.integrateBatch(ctx, tree, 2, time.Minute, 0)
+		if err != nil {
+			t.Fatalf("%s: integrateBatch(): %v", step.desc, err)
+		}
+		if n != step.wantLeaves || overdue != step.wantOverdue {
+			t.Errorf("%s: integrateBatch() = (%d, overdue: %v), want (%d, overdue: %v)", step.desc, n, overdue, step.wantLeaves, step.wantOverdue)
+		}
+	}
+}
+
+func TestSigningIntervalCleared(t *testing.T) {
+	for _, newLeaves := range []int{0, 1} {
+		t.Run(fmt.Sprintf("newLeaves%d", newLeaves), func(t *testing.T) {
+			ctx := context.Background()
+			ts := clock.NewFake(fakeTime)
+			s, tree := newReintegrateTest(ctx, t, ts)
+			tree.SigningInterval = ptypes.DurationProto(time.Minute)
+
+			integrate := func(prefix string, count int) {
+				t.Helper()
+				queueLeaves(ctx, t, s, tree, prefix, count, fakeTime.Add(-time.Hour))
+				if n, err := s.IntegrateBatch(ctx, tree, 10, 0, 0); err != nil || n != count {
+					t.Fatalf("IntegrateBatch() = (%d, %v), want (%d, nil)", n, err, count)
+				}
+			}
+			integrate("a", 3)
+			ts.Set(fakeTime.Add(10 * time.Second))
+			integrate("b", 2)
+
+			// Once the signing_interval is cleared, the next pass builds on the
+			// pending integration and signs it, rather than failing on its
+			// write revision.
+			tree.SigningInterval = nil
+			ts.Set(fakeTime.Add(20 * time.Second))
+			integrate("c", newLeaves)
+			_, root, err := s.latestRoot(ctx, tree)
+			if err != nil {
+				t.Fatalf("latestRoot(): %v", err)
+			}
+			if want := uint64(5 + newLeaves); root.TreeSize != want {
+				t.Errorf("signed root of size %d, want %d", root.TreeSize, want)
+			}
+		})
+	}
+}
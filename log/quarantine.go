@@ -0,0 +1,86 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+)
+
+// checkLeaf returns an error if a dequeued leaf can't be integrated into a
+// log with the given hash size.
+func checkLeaf(leaf *trillian.LogLeaf, hashSize int) error {
+	if got := len(leaf.LeafIdentityHash); got != hashSize {
+		return fmt.Errorf("leaf identity hash has %d bytes, want %d", got, hashSize)
+	}
+	if got := len(leaf.MerkleLeafHash); got != hashSize {
+		return fmt.Errorf("Merkle leaf hash has %d bytes, want %d", got, hashSize)
+	}
+	if _, ok, err := queueTime(leaf); ok && err != nil {
+		return fmt.Errorf("invalid queue timestamp: %v", err)
+	}
+	return nil
+}
+
+// quarantine takes the dequeued leaves which can't be integrated out of the
+// queue, so that they don't block the others, and returns the rest. Only the
+// problems found by checkLeaf are caught here; errors from storage while
+// dequeuing or updating the leaves fail the whole batch, as they can't be
+// attributed to a leaf.
+func (s *logSequencingTask) quarantine(ctx context.Context, leaves []*trillian.LogLeaf) ([]*trillian.LogLeaf, error) {
+	var bad []*trillian.QuarantinedLeaf
+	good := make([]*trillian.LogLeaf, 0, len(leaves))
+	for _, leaf := range leaves {
+		if err := checkLeaf(leaf, s.hashSize); err != nil {
+			bad = append(bad, &trillian.QuarantinedLeaf{Leaf: leaf, Error: err.Error()})
+			continue
+		}
+		good = append(good, leaf)
+	}
+	if len(bad) == 0 {
+		return leaves, nil
+	}
+	if err := s.quarantineLeaves(ctx, bad); err != nil {
+		return nil, err
+	}
+	return good, nil
+}
+
+// quarantineLeaves moves the given dequeued leaves out of the queue, stamped
+// with the current time.
+func (s *logSequencingTask) quarantineLeaves(ctx context.Context, bad []*trillian.QuarantinedLeaf) error {
+	if len(bad) == 0 {
+		return nil
+	}
+	now, err := ptypes.TimestampProto(s.timeSource.Now())
+	if err != nil {
+		return fmt.Errorf("got invalid quarantine timestamp: %v", err)
+	}
+	for _, ql := range bad {
+		ql.QuarantineTimestamp = now
+	}
+	if err := s.tx.QuarantineLeaves(ctx, bad); err != nil {
+		return fmt.Errorf("%v: Sequencer failed to quarantine %d leaves: %v", s.label, len(bad), err)
+	}
+	for _, ql := range bad {
+		glog.Warningf("%v: quarantined leaf %x: %s", s.label, ql.Leaf.LeafIdentityHash, ql.Error)
+	}
+	seqQuarantined.Add(float64(len(bad)), s.label)
+	return nil
+}
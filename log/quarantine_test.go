@@ -0,0 +1,156 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/util/clock"
+
+	tcrypto "github.com/google/trillian/crypto"
+	stestonly "github.com/google/trillian/storage/testonly"
+)
+
+func TestCheckLeaf(t *testing.T) {
+	hash := rfc6962.DefaultHasher.HashLeaf([]byte("leaf"))
+	for _, test := range []struct {
+		desc    string
+		leaf    *trillian.LogLeaf
+		wantErr bool
+	}{
+		{desc: "ok", leaf: &trillian.LogLeaf{LeafIdentityHash: hash, MerkleLeafHash: hash}},
+		{desc: "noIdentityHash", leaf: &trillian.LogLeaf{MerkleLeafHash: hash}, wantErr: true},
+		{desc: "shortMerkleHash", leaf: &trillian.LogLeaf{LeafIdentityHash: hash, MerkleLeafHash: hash[1:]}, wantErr: true},
+		{desc: "longMerkleHash", leaf: &trillian.LogLeaf{LeafIdentityHash: hash, MerkleLeafHash: append(append([]byte{}, hash...), 0)}, wantErr: true},
+		{
+			desc:    "badQueueTimestamp",
+			leaf:    &trillian.LogLeaf{LeafIdentityHash: hash, MerkleLeafHash: hash, QueueTimestamp: &timestamp.Timestamp{Seconds: 1, Nanos: -1}},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			err := checkLeaf(test.leaf, rfc6962.DefaultHasher.Size())
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("checkLeaf() = %v, want error: %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestIntegrateBatch_Quarantine(t *testing.T) {
+	cryptoSigner := newSignerWithFixedSig(testSignedRoot.LogRootSignature)
+	signer := tcrypto.NewSigner(0, cryptoSigner, crypto.SHA256)
+	ts := clock.NewFake(fakeTime)
+
+	badHash := func() *trillian.LogLeaf {
+		leaf := getLeaf42()
+		leaf.LeafIdentityHash = []byte("bad")
+		leaf.MerkleLeafHash = []byte("bad")
+		return leaf
+	}
+
+	any := gomock.Any()
+	ctx := context.Background()
+	for i, test := range []struct {
+		desc           string
+		leaves         []*trillian.LogLeaf
+		quarantineErr  error
+		wantErr        bool
+		wantIntegrated int
+		wantQuarantine int
+	}{
+		{desc: "allGood", leaves: []*trillian.LogLeaf{getLeaf42()}, wantIntegrated: 1},
+		{desc: "someBad", leaves: []*trillian.LogLeaf{badHash(), getLeaf42(), badHash()}, wantIntegrated: 1, wantQuarantine: 2},
+		{desc: "allBad", leaves: []*trillian.LogLeaf{badHash()}, wantQuarantine: 1},
+		{
+			desc:          "quarantineFails",
+			leaves:        []*trillian.LogLeaf{badHash(), getLeaf42()},
+			quarantineErr: errors.New("quarantine failed"),
+			wantErr:       true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			logTX := storage.NewMockLogTreeTX(ctrl)
+			logTX.EXPECT().DequeueLeaves(any, any, any).Return(test.leaves, nil)
+			logTX.EXPECT().LatestSignedLogRoot(any).Return(testSignedRoot16, nil)
+			if test.wantQuarantine > 0 || test.quarantineErr != nil {
+				logTX.EXPECT().QuarantineLeaves(any, any).DoAndReturn(func(_ context.Context, leaves []*trillian.QuarantinedLeaf) error {
+					if got, want := len(leaves), test.wantQuarantine; test.quarantineErr == nil && got != want {
+						t.Errorf("QuarantineLeaves() got %d leaves, want %d", got, want)
+					}
+					for _, ql := range leaves {
+						if ql.Error == "" || ql.QuarantineTimestamp == nil {
+							t.Errorf("QuarantineLeaves() got leaf %v without error or timestamp", ql)
+						}
+					}
+					return test.quarantineErr
+				})
+			}
+			if test.wantIntegrated > 0 {
+				logTX.EXPECT().GetMerkleNodes(any, any, any).Return(compactTree16, nil)
+				logTX.EXPECT().WriteRevision(any).Return(int64(testRoot16.Revision+1), nil)
+				logTX.EXPECT().UpdateSequencedLeaves(any, any).DoAndReturn(func(_ context.Context, leaves []*trillian.LogLeaf) error {
+					if got, want := len(leaves), test.wantIntegrated; got != want {
+						t.Errorf("UpdateSequencedLeaves() got %d leaves, want %d", got, want)
+					}
+					for i, leaf := range leaves {
+						if got, want := leaf.LeafIndex, int64(testRoot16.TreeSize)+int64(i); got != want {
+							t.Errorf("UpdateSequencedLeaves() got leaf index %d, want %d", got, want)
+						}
+					}
+					return nil
+				})
+				logTX.EXPECT().SetMerkleNodes(any, any).Return(nil)
+				logTX.EXPECT().StoreSignedLogRoot(any, any).Return(nil)
+			}
+			if !test.wantErr {
+				logTX.EXPECT().Commit(any).Return(nil)
+			}
+			logTX.EXPECT().Close().Return(nil)
+			logStorage := &stestonly.FakeLogStorage{TX: logTX}
+
+			// Use a different tree for each test, so that metrics don't add up.
+			tree := &trillian.Tree{TreeId: int64(6000 + i), TreeType: trillian.TreeType_LOG}
+			sequencer := NewSequencer(rfc6962.DefaultHasher, ts, logStorage, signer, nil /* mf */, quota.Noop())
+			n, err := sequencer.IntegrateBatch(ctx, tree, 1000 /* limit */, 0 /* guardWindow */, time.Hour /* maxRootDuration */)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("IntegrateBatch() returned err = %v, want error: %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if n != test.wantIntegrated {
+				t.Errorf("IntegrateBatch() = %d, want %d", n, test.wantIntegrated)
+			}
+			if got, want := seqQuarantined.Value(strconv.FormatInt(tree.TreeId, 10)), float64(test.wantQuarantine); got != want {
+				t.Errorf("sequencer_quarantined = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+// checkConditions orders the dequeued leaves for integration, if the storage
+// supports storage.QueueConditionReader. Each complete conditional batch is
+// placed at the position of its first leaf if the tree has the size it
+// expects by then, and quarantined otherwise. If the dequeued leaves may have
+// been truncated, an incomplete batch and the leaves after it are left in the
+// queue, as the rest of the batch is dequeued by a later run.
+func (s *logSequencingTask) checkConditions(ctx context.Context, leaves []*trillian.LogLeaf, truncated bool) ([]*trillian.LogLeaf, error) {
+	r, ok := s.tx.(storage.QueueConditionReader)
+	if !ok {
+		return leaves, nil
+	}
+	conds := make([]*storagepb.QueueCondition, len(leaves))
+	batches := make(map[string][]*trillian.LogLeaf)
+	for i, leaf := range leaves {
+		if c := r.QueueCondition(leaf.LeafIdentityHash); c != nil {
+			conds[i] = c
+			batches[string(c.BatchId)] = append(batches[string(c.BatchId)], leaf)
+		}
+	}
+	if len(batches) == 0 {
+		return leaves, nil
+	}
+
+	var bad []*trillian.QuarantinedLeaf
+	ret := make([]*trillian.LogLeaf, 0, len(leaves))
+	done := make(map[string]bool)
+	treeSize := s.treeSize
+loop:
+	for i, leaf := range leaves {
+		c := conds[i]
+		if c == nil {
+			ret = append(ret, leaf)
+			treeSize++
+			continue
+		}
+		id := string(c.BatchId)
+		if done[id] {
+			continue
+		}
+		done[id] = true
+
+		batch := batches[id]
+		var reason string
+		switch {
+		case int64(len(batch)) < c.BatchSize && truncated && i > 0:
+			break loop
+		case int64(len(batch)) != c.BatchSize:
+			reason = fmt.Sprintf("conditional batch has %d of its %d leaves", len(batch), c.BatchSize)
+		case uint64(c.TreeSize) != treeSize:
+			reason = fmt.Sprintf("conditional batch expected tree size %d, but it is %d", c.TreeSize, treeSize)
+		}
+		if reason != "" {
+			for _, leaf := range batch {
+				bad = append(bad, &trillian.QuarantinedLeaf{Leaf: leaf, Error: reason})
+			}
+			continue
+		}
+		ret = append(ret, batch...)
+		treeSize += uint64(len(batch))
+	}
+
+	if err := s.quarantineLeaves(ctx, bad); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
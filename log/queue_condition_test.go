@@ -0,0 +1,101 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/storagepb"
+	"github.com/google/trillian/util/clock"
+)
+
+// conditionTX is a LogTreeTX which implements storage.QueueConditionReader,
+// and records the leaves it quarantines.
+type conditionTX struct {
+	storage.LogTreeTX
+	conds       map[string]*storagepb.QueueCondition
+	quarantined []string
+}
+
+func (tx *conditionTX) QueueCondition(leafIdentityHash []byte) *storagepb.QueueCondition {
+	return tx.conds[string(leafIdentityHash)]
+}
+
+func (tx *conditionTX) QuarantineLeaves(ctx context.Context, leaves []*trillian.QuarantinedLeaf) error {
+	for _, ql := range leaves {
+		tx.quarantined = append(tx.quarantined, string(ql.Leaf.LeafIdentityHash))
+	}
+	return nil
+}
+
+func TestCheckConditions(t *testing.T) {
+	// Leaves are named after their batch, if any, e.g. "a1" and "a2" are in
+	// batch "a"; "u" leaves are unconditional.
+	var (
+		a = &storagepb.QueueCondition{BatchId: []byte("a"), TreeSize: 11, BatchSize: 2}
+		b = &storagepb.QueueCondition{BatchId: []byte("b"), TreeSize: 10, BatchSize: 2}
+		c = &storagepb.QueueCondition{BatchId: []byte("c"), TreeSize: 10, BatchSize: 1}
+	)
+	for _, test := range []struct {
+		desc           string
+		leaves         []string
+		truncated      bool
+		want           []string
+		wantQuarantine []string
+	}{
+		{desc: "unconditional", leaves: []string{"u1", "u2"}, want: []string{"u1", "u2"}},
+		{desc: "inOrder", leaves: []string{"u1", "a1", "a2"}, want: []string{"u1", "a1", "a2"}},
+		{desc: "interleaved", leaves: []string{"b1", "u1", "b2"}, want: []string{"b1", "b2", "u1"}},
+		{desc: "wrongSize", leaves: []string{"u1", "c1", "u2"}, want: []string{"u1", "u2"}, wantQuarantine: []string{"c1"}},
+		{desc: "lostRace", leaves: []string{"u1", "b1", "b2", "a1", "a2"}, want: []string{"u1", "a1", "a2"}, wantQuarantine: []string{"b1", "b2"}},
+		{desc: "incomplete", leaves: []string{"u1", "a1"}, want: []string{"u1"}, wantQuarantine: []string{"a1"}},
+		{desc: "truncated", leaves: []string{"u1", "a1", "u2"}, truncated: true, want: []string{"u1"}},
+		{desc: "truncatedFirst", leaves: []string{"b1", "u1"}, truncated: true, want: []string{"u1"}, wantQuarantine: []string{"b1"}},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			tx := &conditionTX{conds: make(map[string]*storagepb.QueueCondition)}
+			var leaves []*trillian.LogLeaf
+			for _, name := range test.leaves {
+				leaves = append(leaves, &trillian.LogLeaf{LeafIdentityHash: []byte(name)})
+				for _, cond := range []*storagepb.QueueCondition{a, b, c} {
+					if strings.HasPrefix(name, string(cond.BatchId)) {
+						tx.conds[name] = cond
+					}
+				}
+			}
+			s := &logSequencingTask{label: "test", treeSize: 10, timeSource: clock.NewFake(fakeTime), tx: tx}
+
+			got, err := s.checkConditions(context.Background(), leaves, test.truncated)
+			if err != nil {
+				t.Fatalf("checkConditions(): %v", err)
+			}
+			var names []string
+			for _, leaf := range got {
+				names = append(names, string(leaf.LeafIdentityHash))
+			}
+			if diff := cmp.Diff(names, test.want); diff != "" {
+				t.Errorf("checkConditions() diff (-got +want):\n%s", diff)
+			}
+			if diff := cmp.Diff(tx.quarantined, test.wantQuarantine); diff != "" {
+				t.Errorf("quarantined leaves diff (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/types"
+)
+
+// leafRangeReadSize is the largest number of leaves read at once to rebuild
+// the compact range of a log from its leaves.
+const leafRangeReadSize = 1000
+
+// ReintegratePending integrates all leaves of the tree which have been queued
+// for at least minAge, in batches of at most limit leaves, and returns the
+// number of leaves integrated and the latest signed root. It is a repair operation for
+// leaves which were queued but never integrated, e.g. due to a bug, rather than
+// part of normal sequencing.
+//
+// Each new root is checked, before it is stored or signed, against a root
+// rebuilt from the hashes of the leaves in storage and the newly integrated
+// leaves, independently of the Merkle nodes which the new root is computed
+// from. If a check fails, ReintegratePending stops without publishing the new
+// root, and returns an error along with the number of leaves integrated so
+// far. It also returns an error if leaves are queued but no new root can be
+// signed yet, e.g. because the tree has a coarse timestamp_granularity and a
+// root was already signed in the current unit of time.
+func (s Sequencer) ReintegratePending(ctx context.Context, tree *trillian.Tree, limit int, minAge time.Duration) (int, *trillian.SignedLogRoot, error) {
+	s.reintegration = &leafRange{}
+	total := 0
+	for {
+		n, err := s.IntegrateBatch(ctx, tree, limit, minAge, 0 /* maxRootDurationInterval */)
+		if err != nil {
+			return total, nil, err
+		}
+		if n == 0 {
+			slr, _, err := s.latestRoot(ctx, tree)
+			return total, slr, err
+		}
+		total += n
+		glog.Infof("%v: reintegrated %d leaves", tree.TreeId, n)
+	}
+}
+
+// leafRange is the compact range of a log rebuilt from the hashes of its
+// leaves in storage, which reintegration checks new roots against. It is
+// kept between batches, so that the leaves are only read once.
+type leafRange struct {
+	cr       *compact.Range
+	rootHash []byte
+}
+
+// latestRoot returns the latest signed log root of the tree, and its parsed
+// log root.
+func (s Sequencer) latestRoot(ctx context.Context, tree *trillian.Tree) (*trillian.SignedLogRoot, *types.LogRootV1, error) {
+	tx, err := s.logStorage.SnapshotForTree(ctx, tree)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Close()
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: failed to get latest root: %v", tree.TreeId, err)
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return nil, nil, fmt.Errorf("%v: failed to unmarshal latest root: %v", tree.TreeId, err)
+	}
+	return slr, &root, tx.Commit(ctx)
+}
+
+// checkConsistent checks that the root is an extension of the prev root by the
+// given leaves. The compact range of prev is rebuilt from the hashes of its
+// leaves read from tx, unless an earlier check already did, and checked against
+// prev. The leaves are appended to it, and the resulting root hash compared
+// with that of root.
+func (s Sequencer) checkConsistent(ctx context.Context, tree *trillian.Tree, tx storage.ReadOnlyLogTreeTX, prev, root *types.LogRootV1, leaves []*trillian.LogLeaf) error {
+	if got, want := root.TreeSize, prev.TreeSize+uint64(len(leaves)); got != want {
+		return fmt.Errorf("%v: tree size %d of new root is not %d of previous root plus %d leaves", tree.TreeId, got, prev.TreeSize, len(leaves))
+	}
+	lr := s.reintegration
+	if lr == nil {
+		lr = &leafRange{}
+	}
+	if lr.cr == nil || lr.cr.End() != prev.TreeSize || !bytes.Equal(lr.rootHash, prev.RootHash) {
+		cr, err := s.rebuildLeafRange(ctx, tx, prev.TreeSize)
+		if err != nil {
+			return fmt.Errorf("%v: failed to rebuild compact range of previous root from its leaves: %v", tree.TreeId, err)
+		}
+		hash, err := s.rootHash(cr)
+		if err != nil {
+			return fmt.Errorf("%v: failed to compute root hash: %v", tree.TreeId, err)
+		}
+		if !bytes.Equal(hash, prev.RootHash) {
+			return fmt.Errorf("%v: root at tree size %d is not consistent with its leaves: got root hash %x, want %x", tree.TreeId, prev.TreeSize, hash, prev.RootHash)
+		}
+		lr.cr, lr.rootHash = cr, prev.RootHash
+	}
+
+	fact := compact.RangeFactory{Hash: s.hasher.HashChildren}
+	cr, err := fact.NewRange(0, lr.cr.End(), append([][]byte(nil), lr.cr.Hashes()...))
+	if err != nil {
+		return fmt.Errorf("%v: failed to copy compact range: %v", tree.TreeId, err)
+	}
+	for _, leaf := range leaves {
+		if got, want := leaf.LeafIndex, int64(cr.End()); got != want {
+			return fmt.Errorf("%v: got leaf %d, want %d", tree.TreeId, got, want)
+		}
+		if err := cr.Append(leaf.MerkleLeafHash, nil); err != nil {
+			return fmt.Errorf("%v: failed to append leaf %d: %v", tree.TreeId, leaf.LeafIndex, err)
+		}
+	}
+	hash, err := s.rootHash(cr)
+	if err != nil {
+		return fmt.Errorf("%v: failed to compute root hash: %v", tree.TreeId, err)
+	}
+	if !bytes.Equal(hash, root.RootHash) {
+		return fmt.Errorf("%v: root at tree size %d is not consistent with root at tree size %d: got root hash %x, want %x", tree.TreeId, root.TreeSize, prev.TreeSize, hash, root.RootHash)
+	}
+	lr.cr, lr.rootHash = cr, root.RootHash
+	return nil
+}
+
+// rebuildLeafRange builds the compact range [0, size) of the tree from the
+// hashes of its leaves read from tx.
+func (s Sequencer) rebuildLeafRange(ctx context.Context, tx storage.ReadOnlyLogTreeTX, size uint64) (*compact.Range, error) {
+	fact := compact.RangeFactory{Hash: s.hasher.HashChildren}
+	cr := fact.NewEmptyRange(0)
+	for cr.End() < size {
+		count := size - cr.End()
+		if count > leafRangeReadSize {
+			count = leafRangeReadSize
+		}
+		leaves, err := tx.GetLeavesByRange(ctx, int64(cr.End()), int64(count))
+		if err != nil {
+			return nil, err
+		}
+		if len(leaves) == 0 {
+			return nil, fmt.Errorf("missing leaf %d", cr.End())
+		}
+		for _, leaf := range leaves {
+			if got, want := leaf.LeafIndex, int64(cr.End()); got != want {
+				return nil, fmt.Errorf("got leaf %d, want %d", got, want)
+			}
+			if err := cr.Append(leaf.MerkleLeafHash, nil); err != nil {
+				return nil, fmt.Errorf("failed to append leaf %d: %v", leaf.LeafIndex, err)
+			}
+		}
+	}
+	return cr, nil
+}
+
+// rootHash returns the root hash of the compact range cr, which starts at 0.
+func (s Sequencer) rootHash(cr *compact.Range) ([]byte, error) {
+	if cr.End() == 0 {
+		return s.hasher.EmptyRoot(), nil
+	}
+	return cr.GetRootHash(nil)
+}
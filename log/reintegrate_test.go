@@ -0,0 +1,208 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/clock"
+
+	stestonly "github.com/google/trillian/storage/testonly"
+)
+
+// tickingTimeSource is a fake time source which advances by a millisecond on
+// every call to Now, so that roots signed in quick succession have distinct
+// timestamps.
+type tickingTimeSource struct {
+	*clock.FakeTimeSource
+}
+
+func (t tickingTimeSource) Now() time.Time {
+	now := t.FakeTimeSource.Now()
+	t.Set(now.Add(time.Millisecond))
+	return now
+}
+
+// newReintegrateTest returns a Sequencer and an initialised log in fresh
+// in-memory storage.
+func newReintegrateTest(ctx context.Context, t *testing.T, ts clock.TimeSource) (*Sequencer, *trillian.Tree) {
+	t.Helper()
+	tstore := memory.NewTreeStorage()
+	ls := memory.NewLogStorage(tstore, nil)
+	tree, err := storage.CreateTree(ctx, memory.NewAdminStorage(tstore), proto.Clone(stestonly.LogTree).(*trillian.Tree))
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	slr, err := fixedSigner.SignLogRoot(&types.LogRootV1{
+		RootHash:       rfc6962.DefaultHasher.EmptyRoot(),
+		TimestampNanos: uint64(ts.Now().Add(-24 * time.Hour).UnixNano()),
+	})
+	if err != nil {
+		t.Fatalf("SignLogRoot(): %v", err)
+	}
+	if err := ls.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		return tx.StoreSignedLogRoot(ctx, slr)
+	}); err != nil {
+		t.Fatalf("StoreSignedLogRoot(): %v", err)
+	}
+	return NewSequencer(rfc6962.DefaultHasher, ts, ls, fixedSigner, nil, quota.Noop()), tree
+}
+
+func queueLeaves(ctx context.Context, t *testing.T, s *Sequencer, tree *trillian.Tree, prefix string, count int, queued time.Time) {
+	t.Helper()
+	leaves := make([]*trillian.LogLeaf, count)
+	for i := range leaves {
+		value := []byte(fmt.Sprintf("%s-%d", prefix, i))
+		hash := rfc6962.DefaultHasher.HashLeaf(value)
+		leaves[i] = &trillian.LogLeaf{LeafValue: value, LeafIdentityHash: hash, MerkleLeafHash: hash}
+	}
+	if _, err := s.logStorage.QueueLeaves(ctx, tree, leaves, queued); err != nil {
+		t.Fatalf("QueueLeaves(): %v", err)
+	}
+}
+
+func TestReintegratePending(t *testing.T) {
+	ctx := context.Background()
+	ts := tickingTimeSource{clock.NewFake(fakeTime)}
+	s, tree := newReintegrateTest(ctx, t, ts)
+
+	queueLeaves(ctx, t, s, tree, "old", 5, fakeTime.Add(-2*time.Hour))
+	queueLeaves(ctx, t, s, tree, "new", 2, fakeTime.Add(-time.Minute))
+
+	// A limit of 2 leaves needs several batches.
+	n, slr, err := s.ReintegratePending(ctx, tree, 2, time.Hour)
+	if err != nil {
+		t.Fatalf("ReintegratePending(): %v", err)
+	}
+	if got, want := n, 5; got != want {
+		t.Errorf("ReintegratePending() integrated %d leaves, want %d", got, want)
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		t.Fatalf("UnmarshalBinary(): %v", err)
+	}
+	if got, want := root.TreeSize, uint64(5); got != want {
+		t.Errorf("ReintegratePending() root has tree size %d, want %d", got, want)
+	}
+
+	// Nothing is left that is old enough.
+	n, slr, err = s.ReintegratePending(ctx, tree, 2, time.Hour)
+	if err != nil || n != 0 {
+		t.Fatalf("ReintegratePending() again = (%d, _, %v), want (0, _, nil)", n, err)
+	}
+	var again types.LogRootV1
+	if err := again.UnmarshalBinary(slr.LogRoot); err != nil {
+		t.Fatalf("UnmarshalBinary(): %v", err)
+	}
+	if again.Revision != root.Revision {
+		t.Errorf("ReintegratePending() with nothing to integrate signed revision %d, want %d", again.Revision, root.Revision)
+	}
+}
+
+func TestReintegratePending_TimestampTaken(t *testing.T) {
+	ctx := context.Background()
+	ts := clock.NewFake(fakeTime)
+	s, tree := newReintegrateTest(ctx, t, ts)
+	tree.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND
+
+	queueLeaves(ctx, t, s, tree, "old", 4, fakeTime.Add(-2*time.Hour))
+
+	// Only one root can be signed per second, so the second batch fails
+	// rather than leaving the remaining leaves queued.
+	n, _, err := s.ReintegratePending(ctx, tree, 2, time.Hour)
+	if err == nil || n != 2 {
+		t.Fatalf("ReintegratePending() = (%d, _, %v), want (2, _, error)", n, err)
+	}
+	// In the next second, the remaining leaves are integrated, and an empty
+	// queue isn't an error even though no further root can be signed.
+	ts.Set(fakeTime.Add(time.Second))
+	n, slr, err := s.ReintegratePending(ctx, tree, 2, time.Hour)
+	if err != nil || n != 2 {
+		t.Fatalf("ReintegratePending() = (%d, _, %v), want (2, _, nil)", n, err)
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		t.Fatalf("UnmarshalBinary(): %v", err)
+	}
+	if got, want := root.TreeSize, uint64(4); got != want {
+		t.Errorf("ReintegratePending() root has tree size %d, want %d", got, want)
+	}
+}
+
+func TestCheckConsistent(t *testing.T) {
+	ctx := context.Background()
+	ts := clock.NewFake(fakeTime)
+	s, tree := newReintegrateTest(ctx, t, ts)
+
+	_, prev, err := s.latestRoot(ctx, tree)
+	if err != nil {
+		t.Fatalf("latestRoot(): %v", err)
+	}
+	queueLeaves(ctx, t, s, tree, "leaf", 3, fakeTime.Add(-time.Hour))
+	ts.Set(fakeTime.Add(time.Second))
+	if _, err := s.IntegrateBatch(ctx, tree, 10, 0, 0); err != nil {
+		t.Fatalf("IntegrateBatch(): %v", err)
+	}
+	_, root, err := s.latestRoot(ctx, tree)
+	if err != nil {
+		t.Fatalf("latestRoot(): %v", err)
+	}
+
+	tx, err := s.logStorage.SnapshotForTree(ctx, tree)
+	if err != nil {
+		t.Fatalf("SnapshotForTree(): %v", err)
+	}
+	defer tx.Close()
+	leaves, err := tx.GetLeavesByRange(ctx, 0, 3)
+	if err != nil {
+		t.Fatalf("GetLeavesByRange(): %v", err)
+	}
+
+	if err := s.checkConsistent(ctx, tree, tx, prev, root, leaves); err != nil {
+		t.Errorf("checkConsistent() = %v, want nil", err)
+	}
+	bad := *root
+	bad.RootHash = rfc6962.DefaultHasher.HashLeaf([]byte("bad"))
+	if err := s.checkConsistent(ctx, tree, tx, prev, &bad, leaves); err == nil {
+		t.Error("checkConsistent() with wrong root hash = nil, want error")
+	}
+	if err := s.checkConsistent(ctx, tree, tx, prev, root, leaves[1:]); err == nil {
+		t.Error("checkConsistent() with missing leaf = nil, want error")
+	}
+	if err := s.checkConsistent(ctx, tree, tx, prev, root, []*trillian.LogLeaf{leaves[1], leaves[0], leaves[2]}); err == nil {
+		t.Error("checkConsistent() with leaves out of order = nil, want error")
+	}
+
+	// Previous roots are checked against the leaves in storage, so they
+	// needn't have been stored.
+	prev1 := &types.LogRootV1{TreeSize: 1, RootHash: leaves[0].MerkleLeafHash}
+	if err := s.checkConsistent(ctx, tree, tx, prev1, root, leaves[1:]); err != nil {
+		t.Errorf("checkConsistent() from tree size 1 = %v, want nil", err)
+	}
+	badPrev := &types.LogRootV1{TreeSize: 1, RootHash: leaves[1].MerkleLeafHash}
+	if err := s.checkConsistent(ctx, tree, tx, badPrev, root, leaves[1:]); err == nil {
+		t.Error("checkConsistent() with previous root inconsistent with its leaves = nil, want error")
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// repairBatchSize is the number of leaves read at once when rebuilding a tree.
+const repairBatchSize = 1000
+
+// RepairOptions configures Sequencer.RepairFrom.
+type RepairOptions struct {
+	// Index is the tree size up to which the Merkle nodes in storage are known
+	// to be intact.
+	Index uint64
+	// RootHash, if set, is the known root hash of the tree of size Index, which
+	// the Merkle nodes in storage are checked against before anything else.
+	RootHash []byte
+	// DryRun makes RepairFrom compute the new root without storing anything.
+	DryRun bool
+	// AllowTruncate lets RepairFrom produce a root smaller than the latest one
+	// if leaves are missing from storage, rather than failing.
+	AllowTruncate bool
+}
+
+// RepairFrom rebuilds the Merkle nodes of the tree for all leaves from
+// opts.Index onwards, and signs a new root over them. It is a break-glass
+// repair for logs whose latest root doesn't match their Merkle nodes, e.g.
+// after a storage rollback, which normal sequencing refuses to extend.
+//
+// The nodes for the leaves before opts.Index are taken as they are in storage,
+// so opts.RootHash should be set if possible. The new root covers all leaves
+// from opts.Index up to the size of the latest root. If some of them are
+// missing from storage, RepairFrom fails with FailedPrecondition, unless
+// opts.AllowTruncate is set, in which case the new root only covers the leaves
+// stored contiguously from opts.Index, and is smaller than the latest root. It
+// returns the new root, which is not signed or stored in a dry run.
+//
+// RepairFrom must not run concurrently with a signer sequencing the tree.
+func (s Sequencer) RepairFrom(ctx context.Context, tree *trillian.Tree, opts RepairOptions) (*types.LogRootV1, error) {
+	if tree.TreeType != trillian.TreeType_LOG {
+		return nil, fmt.Errorf("%v: repair not supported for tree type %v", tree.TreeId, tree.TreeType)
+	}
+	if opts.DryRun {
+		tx, err := s.logStorage.SnapshotForTree(ctx, tree)
+		if err != nil {
+			return nil, err
+		}
+		defer tx.Close()
+		current, err := currentRoot(ctx, tx)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", tree.TreeId, err)
+		}
+		_, root, err := s.rebuild(ctx, tree, tx, current, opts)
+		if err != nil {
+			return nil, err
+		}
+		return root, tx.Commit(ctx)
+	}
+
+	var newRoot *types.LogRootV1
+	err := s.logStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		current, err := currentRoot(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("%v: %v", tree.TreeId, err)
+		}
+		newVersion, err := tx.WriteRevision(ctx)
+		if err != nil {
+			return err
+		}
+		if got, want := newVersion, int64(current.Revision)+1; got != want {
+			return fmt.Errorf("%v: got writeRevision of %v, but expected %v", tree.TreeId, got, want)
+		}
+		nodeMap, root, err := s.rebuild(ctx, tree, tx, current, opts)
+		if err != nil {
+			return err
+		}
+		nodes, err := s.buildNodesFromNodeMap(nodeMap, newVersion)
+		if err != nil {
+			return fmt.Errorf("%v: failed to build target nodes: %v", tree.TreeId, err)
+		}
+		if err := tx.SetMerkleNodes(ctx, nodes); err != nil {
+			return fmt.Errorf("%v: failed to set Merkle nodes: %v", tree.TreeId, err)
+		}
+
+		root.Revision = uint64(newVersion)
+		root.TimestampNanos = trees.RootTimestamp(tree, s.timeSource.Now())
+		if root.TimestampNanos <= current.TimestampNanos {
+			return fmt.Errorf("%v: refusing to sign root with timestamp earlier than previous root (%d <= %d)", tree.TreeId, root.TimestampNanos, current.TimestampNanos)
+		}
+		slr, err := s.signer.SignLogRoot(root)
+		if err != nil {
+			return fmt.Errorf("%v: signer failed to sign root: %v", tree.TreeId, err)
+		}
+		if err := tx.StoreSignedLogRoot(ctx, slr); err != nil {
+			return fmt.Errorf("%v: failed to write repaired tree root: %v", tree.TreeId, err)
+		}
+		newRoot = root
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	glog.Warningf("%v: REPAIRED tree from index %d: new root has tree size %d, revision %d, root hash %x", tree.TreeId, opts.Index, newRoot.TreeSize, newRoot.Revision, newRoot.RootHash)
+	return newRoot, nil
+}
+
+// repairReader is the part of a transaction needed to rebuild a tree.
+type repairReader interface {
+	storage.NodeReader
+	GetLeavesByRange(ctx context.Context, start, count int64) ([]*trillian.LogLeaf, error)
+}
+
+// currentRoot returns the latest log root read by tx.
+func currentRoot(ctx context.Context, tx storage.ReadOnlyLogTreeTX) (*types.LogRootV1, error) {
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest root: %v", err)
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal latest root: %v", err)
+	}
+	return &root, nil
+}
+
+// rebuild returns the Merkle nodes for the leaves of the tree from opts.Index
+// up to the size of the current root, and the resulting root, without its
+// timestamp and revision.
+func (s Sequencer) rebuild(ctx context.Context, tree *trillian.Tree, tx repairReader, current *types.LogRootV1, opts RepairOptions) (map[compact.NodeID][]byte, *types.LogRootV1, error) {
+	if opts.Index > current.TreeSize {
+		return nil, nil, fmt.Errorf("%v: repair index %d is beyond the tree size %d", tree.TreeId, opts.Index, current.TreeSize)
+	}
+	cr, err := s.loadCompactRange(ctx, opts.Index, int64(current.Revision), tx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: failed to load compact range [0, %d): %v", tree.TreeId, opts.Index, err)
+	}
+	if opts.RootHash != nil {
+		hash, err := cr.GetRootHash(nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v: failed to compute root hash at %d: %v", tree.TreeId, opts.Index, err)
+		}
+		if opts.Index == 0 {
+			hash = s.hasher.EmptyRoot()
+		}
+		if !bytes.Equal(hash, opts.RootHash) {
+			return nil, nil, fmt.Errorf("%v: Merkle nodes at tree size %d have root hash %x, want %x", tree.TreeId, opts.Index, hash, opts.RootHash)
+		}
+	}
+
+	nodeMap := make(map[compact.NodeID][]byte)
+	store := func(id compact.NodeID, hash []byte) { nodeMap[id] = hash }
+	for cr.End() < current.TreeSize {
+		count := current.TreeSize - cr.End()
+		if count > repairBatchSize {
+			count = repairBatchSize
+		}
+		leaves, err := tx.GetLeavesByRange(ctx, int64(cr.End()), int64(count))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%v: failed to get leaves from %d: %v", tree.TreeId, cr.End(), err)
+		}
+		// Only leaves stored contiguously from the start are used.
+		contiguous := 0
+		for contiguous < len(leaves) && leaves[contiguous].LeafIndex == int64(cr.End())+int64(contiguous) {
+			contiguous++
+		}
+		for _, leaf := range leaves[:contiguous] {
+			store(compact.NewNodeID(0, uint64(leaf.LeafIndex)), leaf.MerkleLeafHash)
+			if err := cr.Append(leaf.MerkleLeafHash, store); err != nil {
+				return nil, nil, fmt.Errorf("%v: failed to rebuild tree: %v", tree.TreeId, err)
+			}
+		}
+		if uint64(contiguous) < count {
+			break
+		}
+	}
+	if cr.End() < current.TreeSize {
+		if !opts.AllowTruncate {
+			return nil, nil, status.Errorf(codes.FailedPrecondition, "%v: leaves from %d are missing, repairing would truncate the tree from size %d to %d", tree.TreeId, cr.End(), current.TreeSize, cr.End())
+		}
+		glog.Warningf("%v: leaves from %d are missing, the repaired tree has size %d instead of %d", tree.TreeId, cr.End(), cr.End(), current.TreeSize)
+	}
+	// Store ephemeral nodes on the right border of the tree as well.
+	rootHash, err := cr.GetRootHash(store)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: failed to compute root hash: %v", tree.TreeId, err)
+	}
+	if cr.End() == 0 {
+		rootHash = s.hasher.EmptyRoot()
+	}
+	return nodeMap, &types.LogRootV1{TreeSize: cr.End(), RootHash: rootHash}, nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/tree"
+	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// integrate queues count leaves and integrates them, returning the new root.
+func integrate(ctx context.Context, t *testing.T, s *Sequencer, lt *trillian.Tree, prefix string, count int) *types.LogRootV1 {
+	t.Helper()
+	queueLeaves(ctx, t, s, lt, prefix, count, fakeTime.Add(-time.Hour))
+	if _, err := s.IntegrateBatch(ctx, lt, count, 0, 0); err != nil {
+		t.Fatalf("IntegrateBatch(): %v", err)
+	}
+	_, root, err := s.latestRoot(ctx, lt)
+	if err != nil {
+		t.Fatalf("latestRoot(): %v", err)
+	}
+	return root
+}
+
+// rollBack overwrites the hashes of the leaves from index begin up to the size
+// of the latest root in the Merkle tree, and re-signs the latest root at the
+// new revision, as if the nodes had been lost in a storage rollback.
+func rollBack(ctx context.Context, t *testing.T, s *Sequencer, lt *trillian.Tree, begin uint64) {
+	t.Helper()
+	err := s.logStorage.ReadWriteTransaction(ctx, lt, func(ctx context.Context, tx storage.LogTreeTX) error {
+		root, err := currentRoot(ctx, tx)
+		if err != nil {
+			return err
+		}
+		rev, err := tx.WriteRevision(ctx)
+		if err != nil {
+			return err
+		}
+		var nodes []tree.Node
+		for i := begin; i < root.TreeSize; i++ {
+			id, err := tree.NewNodeIDForTreeCoords(0, int64(i), maxTreeDepth)
+			if err != nil {
+				return err
+			}
+			nodes = append(nodes, tree.Node{NodeID: id, Hash: rfc6962.DefaultHasher.HashLeaf([]byte("lost")), NodeRevision: rev})
+		}
+		if err := tx.SetMerkleNodes(ctx, nodes); err != nil {
+			return err
+		}
+		root.Revision = uint64(rev)
+		root.TimestampNanos++
+		slr, err := s.signer.SignLogRoot(root)
+		if err != nil {
+			return err
+		}
+		return tx.StoreSignedLogRoot(ctx, slr)
+	})
+	if err != nil {
+		t.Fatalf("rollBack(): %v", err)
+	}
+}
+
+func TestRepairFrom(t *testing.T) {
+	ctx := context.Background()
+	ts := tickingTimeSource{clock.NewFake(fakeTime)}
+	s, lt := newReintegrateTest(ctx, t, ts)
+
+	good := integrate(ctx, t, s, lt, "good", 4)
+	want := integrate(ctx, t, s, lt, "lost", 3)
+	rollBack(ctx, t, s, lt, good.TreeSize)
+
+	// The log can no longer be extended.
+	queueLeaves(ctx, t, s, lt, "new", 1, fakeTime.Add(-time.Hour))
+	if _, err := s.IntegrateBatch(ctx, lt, 1, 0, 0); err == nil {
+		t.Fatal("IntegrateBatch() after rollback = nil, want error")
+	}
+
+	for _, opts := range []RepairOptions{
+		{Index: want.TreeSize + 1},
+		{Index: good.TreeSize, RootHash: want.RootHash},
+	} {
+		if _, err := s.RepairFrom(ctx, lt, opts); err == nil {
+			t.Errorf("RepairFrom(%+v) = (_, nil), want error", opts)
+		}
+	}
+
+	// A dry run computes the repaired root, but doesn't store it.
+	_, before, err := s.latestRoot(ctx, lt)
+	if err != nil {
+		t.Fatalf("latestRoot(): %v", err)
+	}
+	opts := RepairOptions{Index: good.TreeSize, RootHash: good.RootHash, DryRun: true}
+	root, err := s.RepairFrom(ctx, lt, opts)
+	if err != nil {
+		t.Fatalf("RepairFrom(%+v): %v", opts, err)
+	}
+	if root.TreeSize != want.TreeSize || !bytes.Equal(root.RootHash, want.RootHash) {
+		t.Errorf("RepairFrom(%+v) = size %d, hash %x, want size %d, hash %x", opts, root.TreeSize, root.RootHash, want.TreeSize, want.RootHash)
+	}
+	_, after, err := s.latestRoot(ctx, lt)
+	if err != nil {
+		t.Fatalf("latestRoot(): %v", err)
+	}
+	if after.Revision != before.Revision {
+		t.Errorf("RepairFrom(%+v) stored revision %d, want %d", opts, after.Revision, before.Revision)
+	}
+
+	opts.DryRun = false
+	root, err = s.RepairFrom(ctx, lt, opts)
+	if err != nil {
+		t.Fatalf("RepairFrom(%+v): %v", opts, err)
+	}
+	if root.TreeSize != want.TreeSize || !bytes.Equal(root.RootHash, want.RootHash) {
+		t.Errorf("RepairFrom(%+v) = size %d, hash %x, want size %d, hash %x", opts, root.TreeSize, root.RootHash, want.TreeSize, want.RootHash)
+	}
+	if got, want := root.Revision, before.Revision+1; got != want {
+		t.Errorf("RepairFrom(%+v) stored revision %d, want %d", opts, got, want)
+	}
+
+	// Sequencing resumes on top of the repaired tree.
+	if n, err := s.IntegrateBatch(ctx, lt, 1, 0, 0); err != nil || n != 1 {
+		t.Fatalf("IntegrateBatch() after repair = (%d, %v), want (1, nil)", n, err)
+	}
+	_, root, err = s.latestRoot(ctx, lt)
+	if err != nil {
+		t.Fatalf("latestRoot(): %v", err)
+	}
+	tx, err := s.logStorage.SnapshotForTree(ctx, lt)
+	if err != nil {
+		t.Fatalf("SnapshotForTree(): %v", err)
+	}
+	defer tx.Close()
+	leaves, err := tx.GetLeavesByRange(ctx, int64(want.TreeSize), int64(root.TreeSize-want.TreeSize))
+	if err != nil {
+		t.Fatalf("GetLeavesByRange(): %v", err)
+	}
+	if err := s.checkConsistent(ctx, lt, tx, want, root, leaves); err != nil {
+		t.Errorf("checkConsistent() after repair: %v", err)
+	}
+}
+
+// lossyReader is a repairReader which has lost the leaves from index lost on.
+type lossyReader struct {
+	storage.ReadOnlyLogTreeTX
+	lost int64
+}
+
+func (r lossyReader) GetLeavesByRange(ctx context.Context, start, count int64) ([]*trillian.LogLeaf, error) {
+	leaves, err := r.ReadOnlyLogTreeTX.GetLeavesByRange(ctx, start, count)
+	if err != nil {
+		return nil, err
+	}
+	kept := leaves[:0]
+	for _, leaf := range leaves {
+		if leaf.LeafIndex < r.lost {
+			kept = append(kept, leaf)
+		}
+	}
+	return kept, nil
+}
+
+func TestRepairFrom_MissingLeaves(t *testing.T) {
+	ctx := context.Background()
+	ts := tickingTimeSource{clock.NewFake(fakeTime)}
+	s, lt := newReintegrateTest(ctx, t, ts)
+
+	good := integrate(ctx, t, s, lt, "good", 4)
+	kept := integrate(ctx, t, s, lt, "kept", 2)
+	integrate(ctx, t, s, lt, "lost", 3)
+
+	tx, err := s.logStorage.SnapshotForTree(ctx, lt)
+	if err != nil {
+		t.Fatalf("SnapshotForTree(): %v", err)
+	}
+	defer tx.Close()
+	current, err := currentRoot(ctx, tx)
+	if err != nil {
+		t.Fatalf("currentRoot(): %v", err)
+	}
+	r := lossyReader{ReadOnlyLogTreeTX: tx, lost: int64(kept.TreeSize)}
+
+	opts := RepairOptions{Index: good.TreeSize, RootHash: good.RootHash}
+	if _, _, err := s.rebuild(ctx, lt, r, current, opts); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("rebuild(%+v) = %v, want code %v", opts, err, codes.FailedPrecondition)
+	}
+
+	opts.AllowTruncate = true
+	_, root, err := s.rebuild(ctx, lt, r, current, opts)
+	if err != nil {
+		t.Fatalf("rebuild(%+v): %v", opts, err)
+	}
+	if root.TreeSize != kept.TreeSize || !bytes.Equal(root.RootHash, kept.RootHash) {
+		t.Errorf("rebuild(%+v) = size %d, hash %x, want size %d, hash %x", opts, root.TreeSize, root.RootHash, kept.TreeSize, kept.RootHash)
+	}
+}
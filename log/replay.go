@@ -0,0 +1,218 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/trillian"
+	tcrypto "github.com/google/trillian/crypto"
+	"github.com/google/trillian/crypto/keys/der"
+	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/types"
+)
+
+// replayBatchSize is the number of leaves read at once when replaying a tree.
+const replayBatchSize = 1000
+
+// ReplayOptions configures VerifyReplay.
+type ReplayOptions struct {
+	// MaxRoots is the number of the most recent retained roots to check, or 0
+	// to check all of them.
+	MaxRoots int
+}
+
+// ReplayReport is the result of VerifyReplay.
+type ReplayReport struct {
+	// LeavesReplayed is the number of leaves which were hashed into the
+	// replayed tree.
+	LeavesReplayed uint64
+	// RootsChecked is the number of retained roots which matched the replayed
+	// tree.
+	RootsChecked int
+	// Divergence is the first point at which the replayed tree diverged from
+	// storage, or nil if it didn't.
+	Divergence *Divergence
+}
+
+// Divergence describes a mismatch between a tree rebuilt from its leaves and
+// what is stored for it.
+type Divergence struct {
+	// TreeSize is the size of the replayed tree when the divergence was found.
+	TreeSize uint64
+	// Root is the retained root which didn't match, if any.
+	Root *types.LogRootV1
+	// Reason describes the divergence.
+	Reason string
+}
+
+func (d *Divergence) String() string {
+	return fmt.Sprintf("at tree size %d: %s", d.TreeSize, d.Reason)
+}
+
+// VerifyReplay rebuilds the Merkle tree of a log from its stored leaves, and
+// checks that each retained signed root is validly signed, and has the root
+// hash of the rebuilt tree of its size. It also checks that the leaves are
+// stored contiguously, and that their Merkle leaf hashes match their values,
+// and their extra data if the tree has hash_extra_data. Hash-only trees are
+// rebuilt from the stored Merkle leaf hashes, as they have no values.
+// It's an end-to-end integrity check after e.g. a migration or restore.
+//
+// Mismatches are reported in the ReplayReport, which describes only the first
+// one, while an error means the check couldn't be done. VerifyReplay doesn't
+// use the Merkle nodes in storage, and reads the tree in a single snapshot.
+func VerifyReplay(ctx context.Context, ls storage.LogStorage, tree *trillian.Tree, opts ReplayOptions) (*ReplayReport, error) {
+	if tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG {
+		return nil, fmt.Errorf("%v: replay not supported for tree type %v", tree.TreeId, tree.TreeType)
+	}
+	hasher, err := trees.LogHasher(tree)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", tree.TreeId, err)
+	}
+	tx, err := ls.SnapshotForTree(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+
+	limit := opts.MaxRoots
+	if limit <= 0 {
+		limit = math.MaxInt32
+	}
+	slrs, err := tx.SignedLogRootHistory(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%v: failed to get retained roots: %v", tree.TreeId, err)
+	}
+	roots, err := retainedRoots(tree, slrs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{}
+	fact := compact.RangeFactory{Hash: hasher.HashChildren}
+	cr := fact.NewEmptyRange(0)
+	// check compares the retained roots of the current size with the replayed
+	// tree, and returns the roots left to check.
+	check := func(roots []retainedRoot) ([]retainedRoot, *Divergence, error) {
+		for len(roots) > 0 && roots[0].TreeSize == cr.End() {
+			r := roots[0]
+			if r.sigErr != nil {
+				return nil, &Divergence{TreeSize: cr.End(), Root: r.LogRootV1, Reason: fmt.Sprintf("retained root of revision %d has an invalid signature: %v", r.Revision, r.sigErr)}, nil
+			}
+			hash := hasher.EmptyRoot()
+			if cr.End() > 0 {
+				var err error
+				if hash, err = cr.GetRootHash(nil); err != nil {
+					return nil, nil, fmt.Errorf("%v: failed to compute root hash at %d: %v", tree.TreeId, cr.End(), err)
+				}
+			}
+			if !bytes.Equal(hash, r.RootHash) {
+				return nil, &Divergence{TreeSize: cr.End(), Root: r.LogRootV1, Reason: fmt.Sprintf("retained root of revision %d has root hash %x, replayed tree has %x", r.Revision, r.RootHash, hash)}, nil
+			}
+			report.RootsChecked++
+			roots = roots[1:]
+		}
+		return roots, nil, nil
+	}
+
+	diverged := func(div *Divergence) (*ReplayReport, error) {
+		report.Divergence = div
+		return report, tx.Commit(ctx)
+	}
+
+	var div *Divergence
+	if roots, div, err = check(roots); err != nil {
+		return nil, err
+	} else if div != nil {
+		return diverged(div)
+	}
+	for len(roots) > 0 {
+		leaves, err := tx.GetLeavesByRange(ctx, int64(cr.End()), replayBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("%v: failed to get leaves from %d: %v", tree.TreeId, cr.End(), err)
+		}
+		if len(leaves) == 0 {
+			return diverged(&Divergence{TreeSize: cr.End(), Reason: fmt.Sprintf("leaf %d is missing, but retained roots go up to tree size %d", cr.End(), roots[len(roots)-1].TreeSize)})
+		}
+		for _, leaf := range leaves {
+			if len(roots) == 0 {
+				break
+			}
+			if leaf.LeafIndex != int64(cr.End()) {
+				return diverged(&Divergence{TreeSize: cr.End(), Reason: fmt.Sprintf("leaf %d is missing, got leaf %d instead", cr.End(), leaf.LeafIndex)})
+			}
+			hash := leaf.MerkleLeafHash
+			if tree.HashOnly {
+				// The values of the leaves of hash-only trees aren't stored, so
+				// their hashes can only be replayed as they are.
+				if got, want := len(hash), hasher.Size(); got != want {
+					return diverged(&Divergence{TreeSize: cr.End(), Reason: fmt.Sprintf("leaf %d has a Merkle leaf hash of size %d, want %d", leaf.LeafIndex, got, want)})
+				}
+			} else if hash = hashers.HashLogLeaf(hasher, leaf.LeafValue, leaf.ExtraData, tree.HashExtraData); !bytes.Equal(hash, leaf.MerkleLeafHash) {
+				return diverged(&Divergence{TreeSize: cr.End(), Reason: fmt.Sprintf("leaf %d has Merkle leaf hash %x, but its value hashes to %x", leaf.LeafIndex, leaf.MerkleLeafHash, hash)})
+			}
+			if err := cr.Append(hash, nil); err != nil {
+				return nil, fmt.Errorf("%v: failed to replay leaf %d: %v", tree.TreeId, leaf.LeafIndex, err)
+			}
+			report.LeavesReplayed++
+			if roots, div, err = check(roots); err != nil {
+				return nil, err
+			} else if div != nil {
+				return diverged(div)
+			}
+		}
+	}
+	return report, tx.Commit(ctx)
+}
+
+// retainedRoot is a retained root of a tree, with the error verifying its
+// signature, if any.
+type retainedRoot struct {
+	*types.LogRootV1
+	sigErr error
+}
+
+// retainedRoots parses the given roots of tree and verifies their signatures,
+// and returns them in order of increasing tree size.
+func retainedRoots(tree *trillian.Tree, slrs []*trillian.SignedLogRoot) ([]retainedRoot, error) {
+	hash, err := trees.Hash(tree)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", tree.TreeId, err)
+	}
+	pub, err := der.UnmarshalPublicKey(tree.GetPublicKey().GetDer())
+	if err != nil {
+		return nil, fmt.Errorf("%v: failed to parse public key: %v", tree.TreeId, err)
+	}
+	roots := make([]retainedRoot, 0, len(slrs))
+	// The history is newest first, so go backwards to keep roots of the same
+	// size in the order they were stored.
+	for i := len(slrs) - 1; i >= 0; i-- {
+		var root types.LogRootV1
+		if err := root.UnmarshalBinary(slrs[i].LogRoot); err != nil {
+			return nil, fmt.Errorf("%v: failed to unmarshal retained root: %v", tree.TreeId, err)
+		}
+		_, sigErr := tcrypto.VerifySignedLogRoot(pub, hash, slrs[i])
+		roots = append(roots, retainedRoot{LogRootV1: &root, sigErr: sigErr})
+	}
+	sort.SliceStable(roots, func(i, j int) bool { return roots[i].TreeSize < roots[j].TreeSize })
+	return roots, nil
+}
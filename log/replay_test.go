@@ -0,0 +1,256 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	stestonly "github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/clock"
+)
+
+// newReplayTest returns a Sequencer for a new log with an empty root, which
+// signs roots with the key of the log.
+func newReplayTest(ctx context.Context, t *testing.T) (*Sequencer, *trillian.Tree) {
+	t.Helper()
+	ts := tickingTimeSource{clock.NewFake(fakeTime)}
+	tstore := memory.NewTreeStorage()
+	ls := memory.NewLogStorage(tstore, nil)
+	tree, err := storage.CreateTree(ctx, memory.NewAdminStorage(tstore), proto.Clone(stestonly.LogTree).(*trillian.Tree))
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	signer, err := trees.Signer(ctx, tree)
+	if err != nil {
+		t.Fatalf("Signer(): %v", err)
+	}
+	slr, err := signer.SignLogRoot(&types.LogRootV1{
+		RootHash:       rfc6962.DefaultHasher.EmptyRoot(),
+		TimestampNanos: uint64(fakeTime.Add(-24 * time.Hour).UnixNano()),
+	})
+	if err != nil {
+		t.Fatalf("SignLogRoot(): %v", err)
+	}
+	if err := ls.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		return tx.StoreSignedLogRoot(ctx, slr)
+	}); err != nil {
+		t.Fatalf("StoreSignedLogRoot(): %v", err)
+	}
+	return NewSequencer(rfc6962.DefaultHasher, ts, ls, signer, nil, quota.Noop()), tree
+}
+
+// storeRoot signs root with the signer of s, and stores it for tree at the
+// next revision.
+func storeRoot(ctx context.Context, t *testing.T, s *Sequencer, tree *trillian.Tree, root *types.LogRootV1) {
+	t.Helper()
+	err := s.logStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		rev, err := tx.WriteRevision(ctx)
+		if err != nil {
+			return err
+		}
+		root.Revision = uint64(rev)
+		slr, err := s.signer.SignLogRoot(root)
+		if err != nil {
+			return err
+		}
+		return tx.StoreSignedLogRoot(ctx, slr)
+	})
+	if err != nil {
+		t.Fatalf("storeRoot(): %v", err)
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc       string
+		setup      func(*Sequencer, *trillian.Tree)
+		opts       ReplayOptions
+		wantReport ReplayReport
+		wantDiv    string
+	}{
+		{
+			desc:       "empty",
+			setup:      func(*Sequencer, *trillian.Tree) {},
+			wantReport: ReplayReport{RootsChecked: 1},
+		},
+		{
+			desc: "intact",
+			setup: func(s *Sequencer, lt *trillian.Tree) {
+				integrate(ctx, t, s, lt, "a", 4)
+				integrate(ctx, t, s, lt, "b", 3)
+			},
+			wantReport: ReplayReport{LeavesReplayed: 7, RootsChecked: 3},
+		},
+		{
+			desc: "maxRoots",
+			setup: func(s *Sequencer, lt *trillian.Tree) {
+				integrate(ctx, t, s, lt, "a", 4)
+				integrate(ctx, t, s, lt, "b", 3)
+			},
+			opts:       ReplayOptions{MaxRoots: 2},
+			wantReport: ReplayReport{LeavesReplayed: 7, RootsChecked: 2},
+		},
+		{
+			desc: "wrongRootHash",
+			setup: func(s *Sequencer, lt *trillian.Tree) {
+				root := integrate(ctx, t, s, lt, "a", 4)
+				integrate(ctx, t, s, lt, "b", 3)
+				root.RootHash = rfc6962.DefaultHasher.HashLeaf([]byte("bogus"))
+				root.TimestampNanos += uint64(time.Hour)
+				storeRoot(ctx, t, s, lt, root)
+			},
+			wantReport: ReplayReport{LeavesReplayed: 4, RootsChecked: 2},
+			wantDiv:    "at tree size 4: retained root of revision 3 has root hash",
+		},
+		{
+			desc: "invalidSignature",
+			setup: func(s *Sequencer, lt *trillian.Tree) {
+				root := integrate(ctx, t, s, lt, "a", 4)
+				root.TimestampNanos += uint64(time.Hour)
+				s.signer = fixedSigner
+				storeRoot(ctx, t, s, lt, root)
+			},
+			wantReport: ReplayReport{LeavesReplayed: 4, RootsChecked: 2},
+			wantDiv:    "at tree size 4: retained root of revision 2 has an invalid signature",
+		},
+		{
+			desc: "wrongLeafHash",
+			setup: func(s *Sequencer, lt *trillian.Tree) {
+				integrate(ctx, t, s, lt, "a", 2)
+				hash := rfc6962.DefaultHasher.HashLeaf([]byte("other"))
+				leaf := &trillian.LogLeaf{LeafValue: []byte("value"), LeafIdentityHash: hash, MerkleLeafHash: hash}
+				if _, err := s.logStorage.QueueLeaves(ctx, lt, []*trillian.LogLeaf{leaf}, fakeTime.Add(-time.Hour)); err != nil {
+					t.Fatalf("QueueLeaves(): %v", err)
+				}
+				if _, err := s.IntegrateBatch(ctx, lt, 1, 0, 0); err != nil {
+					t.Fatalf("IntegrateBatch(): %v", err)
+				}
+			},
+			wantReport: ReplayReport{LeavesReplayed: 2, RootsChecked: 2},
+			wantDiv:    "at tree size 2: leaf 2 has Merkle leaf hash",
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			s, lt := newReplayTest(ctx, t)
+			test.setup(s, lt)
+			report, err := VerifyReplay(ctx, s.logStorage, lt, test.opts)
+			if err != nil {
+				t.Fatalf("VerifyReplay(): %v", err)
+			}
+			if got, want := report.LeavesReplayed, test.wantReport.LeavesReplayed; got != want {
+				t.Errorf("VerifyReplay() replayed %d leaves, want %d", got, want)
+			}
+			if got, want := report.RootsChecked, test.wantReport.RootsChecked; got != want {
+				t.Errorf("VerifyReplay() checked %d roots, want %d", got, want)
+			}
+			switch div := report.Divergence; {
+			case div == nil && test.wantDiv != "":
+				t.Errorf("VerifyReplay() found no divergence, want %q", test.wantDiv)
+			case div != nil && (test.wantDiv == "" || !strings.HasPrefix(div.String(), test.wantDiv)):
+				t.Errorf("VerifyReplay() found divergence %q, want %q", div, test.wantDiv)
+			}
+		})
+	}
+}
+
+func TestVerifyReplay_MissingLeaves(t *testing.T) {
+	ctx := context.Background()
+	s, lt := newReplayTest(ctx, t)
+	root := integrate(ctx, t, s, lt, "a", 3)
+	// A root beyond the stored leaves, e.g. after an incomplete restore.
+	root.TreeSize = 5
+	root.TimestampNanos += uint64(time.Hour)
+	storeRoot(ctx, t, s, lt, root)
+
+	report, err := VerifyReplay(ctx, s.logStorage, lt, ReplayOptions{})
+	if err != nil {
+		t.Fatalf("VerifyReplay(): %v", err)
+	}
+	if want := "at tree size 3: leaf 3 is missing"; report.Divergence == nil || !strings.HasPrefix(report.Divergence.String(), want) {
+		t.Errorf("VerifyReplay() found divergence %v, want %q", report.Divergence, want)
+	}
+
+	lt.TreeType = trillian.TreeType_MAP
+	if _, err := VerifyReplay(ctx, s.logStorage, lt, ReplayOptions{}); err == nil {
+		t.Error("VerifyReplay() of a map succeeded")
+	}
+}
+
+func TestVerifyReplay_LeafHashes(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc  string
+		setup func(*trillian.Tree)
+		leaf  func(i int) *trillian.LogLeaf
+	}{
+		{
+			desc:  "hashExtraData",
+			setup: func(lt *trillian.Tree) { lt.HashExtraData = true },
+			leaf: func(i int) *trillian.LogLeaf {
+				value, extra := []byte(fmt.Sprintf("value-%d", i)), []byte(fmt.Sprintf("extra-%d", i))
+				hash := hashers.HashLogLeaf(rfc6962.DefaultHasher, value, extra, true)
+				return &trillian.LogLeaf{LeafValue: value, ExtraData: extra, LeafIdentityHash: hash, MerkleLeafHash: hash}
+			},
+		},
+		{
+			desc:  "hashOnly",
+			setup: func(lt *trillian.Tree) { lt.HashOnly = true },
+			leaf: func(i int) *trillian.LogLeaf {
+				hash := rfc6962.DefaultHasher.HashLeaf([]byte(fmt.Sprintf("remote-%d", i)))
+				return &trillian.LogLeaf{LeafValue: []byte{}, LeafIdentityHash: hash, MerkleLeafHash: hash}
+			},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			s, lt := newReplayTest(ctx, t)
+			test.setup(lt)
+			leaves := make([]*trillian.LogLeaf, 3)
+			for i := range leaves {
+				leaves[i] = test.leaf(i)
+			}
+			if _, err := s.logStorage.QueueLeaves(ctx, lt, leaves, fakeTime.Add(-time.Hour)); err != nil {
+				t.Fatalf("QueueLeaves(): %v", err)
+			}
+			if _, err := s.IntegrateBatch(ctx, lt, len(leaves), 0, 0); err != nil {
+				t.Fatalf("IntegrateBatch(): %v", err)
+			}
+
+			report, err := VerifyReplay(ctx, s.logStorage, lt, ReplayOptions{})
+			if err != nil {
+				t.Fatalf("VerifyReplay(): %v", err)
+			}
+			if report.Divergence != nil {
+				t.Errorf("VerifyReplay() found divergence %q, want none", report.Divergence)
+			}
+			if got, want := report.LeavesReplayed, uint64(len(leaves)); got != want {
+				t.Errorf("VerifyReplay() replayed %d leaves, want %d", got, want)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -31,6 +32,7 @@ import (
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/tree"
+	"github.com/google/trillian/trees"
 	"github.com/google/trillian/types"
 	"github.com/google/trillian/util/clock"
 
@@ -53,7 +55,12 @@ var (
 	seqStoreRootLatency    monitoring.Histogram
 	seqCounter             monitoring.Counter
 	seqMergeDelay          monitoring.Histogram
+	seqOldestPendingAge    monitoring.Gauge
+	seqQuarantined         monitoring.Counter
 	seqTimestamp           monitoring.Gauge
+	seqBatchSize           monitoring.Gauge
+	seqUnsignedRoots       monitoring.Counter
+	seqForcedFlushes       monitoring.Counter
 
 	// QuotaIncreaseFactor is the multiplier used for the number of tokens added back to
 	// sequencing-based quotas. The resulting PutTokens call is equivalent to
@@ -92,7 +99,12 @@ func createSequencerMetrics(mf monitoring.MetricFactory) {
 	seqSetNodesLatency = mf.NewHistogram("sequencer_latency_set_nodes", "Latency of set-nodes part of sequencer batch operation in seconds", logIDLabel)
 	seqStoreRootLatency = mf.NewHistogram("sequencer_latency_store_root", "Latency of store-root part of sequencer batch operation in seconds", logIDLabel)
 	seqCounter = mf.NewCounter("sequencer_sequenced", "Number of leaves sequenced", logIDLabel)
-	seqMergeDelay = mf.NewHistogram("sequencer_merge_delay", "Delay between queuing and integration of leaves", logIDLabel)
+	seqMergeDelay = mf.NewHistogram("sequencer_merge_delay", "Delay in seconds between queuing and integration of each integrated leaf", logIDLabel)
+	seqQuarantined = mf.NewCounter("sequencer_quarantined", "Number of dequeued leaves quarantined because they can't be integrated", logIDLabel)
+	seqBatchSize = mf.NewGauge("sequencer_batch_size", "Maximum number of leaves integrated by the last sequencer batch operation", logIDLabel)
+	seqUnsignedRoots = mf.NewCounter("sequencer_unsigned_roots", "Number of integrations kept unsigned until the signing_interval of the log passed", logIDLabel)
+	seqForcedFlushes = mf.NewCounter("sequencer_forced_flushes", "Number of integrations forced by the max_queue_age of the log: of leaves within the guard window, of batches beyond the first of a pass, or signed before the signing_interval passed", logIDLabel)
+	seqOldestPendingAge = mf.NewGauge("sequencer_oldest_pending_age", "Age in seconds of the oldest leaf pending integration, as of the start of the last sequencing pass, or zero if it dequeued none", logIDLabel)
 }
 
 // Sequencer instances are responsible for integrating new leaves into a single log.
@@ -105,6 +117,12 @@ type Sequencer struct {
 	logStorage storage.LogStorage
 	signer     *tcrypto.Signer
 	qm         quota.Manager
+
+	// reintegration is set by ReintegratePending. It makes integrateBatch
+	// check each new root against the leaves in storage before storing or
+	// signing it, and fail rather than leave queued leaves alone while no new
+	// root can be signed.
+	reintegration *leafRange
 }
 
 // maxTreeDepth sets an upper limit on the size of Log trees.
@@ -135,13 +153,34 @@ func NewSequencer(
 
 // initCompactRangeFromStorage builds a compact range that matches the latest
 // data in the database. Ensures that the root hash matches the passed in root.
-func (s Sequencer) initCompactRangeFromStorage(ctx context.Context, root *types.LogRootV1, tx storage.TreeTX) (*compact.Range, error) {
-	fact := compact.RangeFactory{Hash: s.hasher.HashChildren}
+func (s Sequencer) initCompactRangeFromStorage(ctx context.Context, root *types.LogRootV1, tx storage.NodeReader) (*compact.Range, error) {
 	if root.TreeSize == 0 {
+		return s.loadCompactRange(ctx, 0, 0, tx)
+	}
+	cr, err := s.loadCompactRange(ctx, root.TreeSize, int64(root.Revision), tx)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := cr.GetRootHash(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the root hash: %v", err)
+	}
+	// Note: Tree size != 0 at this point, so we don't consider the empty hash.
+	if want := root.RootHash; !bytes.Equal(hash, want) {
+		return nil, fmt.Errorf("root hash mismatch: got %x, want %x", hash, want)
+	}
+	return cr, nil
+}
+
+// loadCompactRange builds the compact range [0, size) of the tree from the
+// Merkle nodes in storage at the given revision.
+func (s Sequencer) loadCompactRange(ctx context.Context, size uint64, rev int64, tx storage.NodeReader) (*compact.Range, error) {
+	fact := compact.RangeFactory{Hash: s.hasher.HashChildren}
+	if size == 0 {
 		return fact.NewEmptyRange(0), nil
 	}
 
-	ids := compact.RangeNodes(0, root.TreeSize)
+	ids := compact.RangeNodes(0, size)
 	storIDs := make([]tree.NodeID, len(ids))
 	for i, id := range ids {
 		nodeID, err := tree.NewNodeIDForTreeCoords(int64(id.Level), int64(id.Index), maxTreeDepth)
@@ -151,12 +190,12 @@ func (s Sequencer) initCompactRangeFromStorage(ctx context.Context, root *types.
 		storIDs[i] = nodeID
 	}
 
-	nodes, err := tx.GetMerkleNodes(ctx, int64(root.Revision), storIDs)
+	nodes, err := tx.GetMerkleNodes(ctx, rev, storIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get Merkle nodes: %v", err)
 	}
 	if got, want := len(nodes), len(storIDs); got != want {
-		return nil, fmt.Errorf("failed to get %d nodes at rev %d, got %d", want, root.Revision, got)
+		return nil, fmt.Errorf("failed to get %d nodes at rev %d, got %d", want, rev, got)
 	}
 	for i, id := range storIDs {
 		if !nodes[i].NodeID.Equivalent(id) {
@@ -169,18 +208,10 @@ func (s Sequencer) initCompactRangeFromStorage(ctx context.Context, root *types.
 		hashes[i] = node.Hash
 	}
 
-	cr, err := fact.NewRange(0, root.TreeSize, hashes)
+	cr, err := fact.NewRange(0, size, hashes)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create compact.Range: %v", err)
 	}
-	hash, err := cr.GetRootHash(nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to compute the root hash: %v", err)
-	}
-	// Note: Tree size != 0 at this point, so we don't consider the empty hash.
-	if want := root.RootHash; !bytes.Equal(hash, want) {
-		return nil, fmt.Errorf("root hash mismatch: got %x, want %x", hash, want)
-	}
 	return cr, nil
 }
 
@@ -196,9 +227,8 @@ func (s Sequencer) buildNodesFromNodeMap(nodeMap map[compact.NodeID][]byte, newV
 	return nodes, nil
 }
 
-func (s Sequencer) prepareLeaves(leaves []*trillian.LogLeaf, begin uint64, label string) error {
-	now := s.timeSource.Now()
-	integrateAt, err := ptypes.TimestampProto(now)
+func (s Sequencer) prepareLeaves(leaves []*trillian.LogLeaf, begin uint64) error {
+	integrateAt, err := ptypes.TimestampProto(s.timeSource.Now())
 	if err != nil {
 		return fmt.Errorf("got invalid integrate timestamp: %v", err)
 	}
@@ -209,20 +239,89 @@ func (s Sequencer) prepareLeaves(leaves []*trillian.LogLeaf, begin uint64, label
 		}
 		leaf.IntegrateTimestamp = integrateAt
 
-		// Old leaves might not have a QueueTimestamp, only calculate the merge
-		// delay if this one does.
-		if leaf.QueueTimestamp != nil && leaf.QueueTimestamp.Seconds != 0 {
-			queueTS, err := ptypes.Timestamp(leaf.QueueTimestamp)
-			if err != nil {
-				return fmt.Errorf("got invalid queue timestamp: %v", queueTS)
-			}
-			mergeDelay := now.Sub(queueTS)
-			seqMergeDelay.Observe(mergeDelay.Seconds(), label)
+		if _, ok, err := queueTime(leaf); ok && err != nil {
+			return fmt.Errorf("got invalid queue timestamp: %v", err)
 		}
 	}
 	return nil
 }
 
+// queueTime returns the time at which leaf was queued, and whether it's known.
+// Old leaves might not have a QueueTimestamp.
+func queueTime(leaf *trillian.LogLeaf) (time.Time, bool, error) {
+	if leaf.QueueTimestamp == nil || leaf.QueueTimestamp.Seconds == 0 {
+		return time.Time{}, false, nil
+	}
+	ts, err := ptypes.Timestamp(leaf.QueueTimestamp)
+	return ts, true, err
+}
+
+// sortByQueueTimestamp sorts leaves by queue timestamp, breaking ties by
+// identity hash. Leaves without a queue timestamp come first.
+func sortByQueueTimestamp(leaves []*trillian.LogLeaf) {
+	sort.Slice(leaves, func(i, j int) bool {
+		ti, tj := leaves[i].QueueTimestamp, leaves[j].QueueTimestamp
+		switch {
+		case ti.GetSeconds() != tj.GetSeconds():
+			return ti.GetSeconds() < tj.GetSeconds()
+		case ti.GetNanos() != tj.GetNanos():
+			return ti.GetNanos() < tj.GetNanos()
+		}
+		return bytes.Compare(leaves[i].LeafIdentityHash, leaves[j].LeafIdentityHash) < 0
+	})
+}
+
+// oldestPendingAge returns the time elapsed by now since the oldest of leaves
+// was queued, or zero if none of them has a queue timestamp. As leaves are
+// dequeued oldest first, this is the age of the oldest pending leaf when
+// leaves were just dequeued, apart from those within the guard window.
+func oldestPendingAge(leaves []*trillian.LogLeaf, now time.Time) time.Duration {
+	var age time.Duration
+	for _, leaf := range leaves {
+		if ts, ok, err := queueTime(leaf); ok && err == nil && now.Sub(ts) > age {
+			age = now.Sub(ts)
+		}
+	}
+	return age
+}
+
+// queuedAfter returns whether any of leaves was queued after t.
+func queuedAfter(leaves []*trillian.LogLeaf, t time.Time) bool {
+	for _, leaf := range leaves {
+		if ts, ok, err := queueTime(leaf); ok && err == nil && ts.After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// allQueuedBy returns whether all of leaves have a queue timestamp no later
+// than t.
+func allQueuedBy(leaves []*trillian.LogLeaf, t time.Time) bool {
+	for _, leaf := range leaves {
+		if ts, ok, err := queueTime(leaf); !ok || err != nil || ts.After(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// observeMergeDelays records the time spent in the queue by each of the
+// integrated leaves.
+func observeMergeDelays(leaves []*trillian.LogLeaf, label string) {
+	for _, leaf := range leaves {
+		queueTS, ok, err := queueTime(leaf)
+		if !ok || err != nil {
+			continue
+		}
+		integrateTS, err := ptypes.Timestamp(leaf.IntegrateTimestamp)
+		if err != nil {
+			continue
+		}
+		seqMergeDelay.Observe(integrateTS.Sub(queueTS).Seconds(), label)
+	}
+}
+
 // updateCompactRange adds the passed in leaves to the compact range. Returns a
 // map of all updated tree nodes, and the new root hash.
 func (s Sequencer) updateCompactRange(cr *compact.Range, leaves []*trillian.LogLeaf, label string) (map[compact.NodeID][]byte, []byte, error) {
@@ -265,8 +364,11 @@ type sequencingTask interface {
 type sequencingTaskData struct {
 	label      string
 	treeSize   uint64
+	hashSize   int
 	timeSource clock.TimeSource
 	tx         storage.LogTreeTX
+	// sortLeaves is set for trees with sort_by_queue_timestamp.
+	sortLeaves bool
 }
 
 // logSequencingTask is a sequencingTask implementation for "normal" Log mode,
@@ -282,6 +384,18 @@ func (s *logSequencingTask) fetch(ctx context.Context, limit int, cutoff time.Ti
 		return nil, fmt.Errorf("%v: Sequencer failed to dequeue leaves: %v", s.label, err)
 	}
 	seqDequeueLatency.Observe(clock.SecondsSince(s.timeSource, start), s.label)
+	truncated := len(leaves) >= limit
+
+	if leaves, err = s.quarantine(ctx, leaves); err != nil {
+		return nil, err
+	}
+	// Only this batch is sorted, as earlier ones already have their indices.
+	if s.sortLeaves {
+		sortByQueueTimestamp(leaves)
+	}
+	if leaves, err = s.checkConditions(ctx, leaves, truncated); err != nil {
+		return nil, err
+	}
 
 	// Assign leaf sequence numbers.
 	for i, leaf := range leaves {
@@ -319,26 +433,64 @@ func (s *preorderedLogSequencingTask) fetch(ctx context.Context, limit int, cuto
 }
 
 func (s *preorderedLogSequencingTask) update(ctx context.Context, leaves []*trillian.LogLeaf) error {
-	// TODO(pavelkalinnikov): Update integration timestamps.
+	// TODO(pavelkalinnikov): Update integration timestamps, preserving those
+	// supplied to AddSequencedLeaves.
 	return nil
 }
 
+// capLimit returns the number of leaves up to limit which can be integrated
+// into the tree at the given size without exceeding its max_tree_size.
+func capLimit(tree *trillian.Tree, treeSize uint64, limit int) int {
+	if tree.MaxTreeSize <= 0 {
+		return limit
+	}
+	if max := uint64(tree.MaxTreeSize); treeSize >= max {
+		return 0
+	} else if left := int64(max - treeSize); left < int64(limit) {
+		return int(left)
+	}
+	return limit
+}
+
 // IntegrateBatch wraps up all the operations needed to take a batch of queued
 // or sequenced leaves and integrate them into the tree.
 func (s Sequencer) IntegrateBatch(ctx context.Context, tree *trillian.Tree, limit int, guardWindow, maxRootDurationInterval time.Duration) (int, error) {
+	n, _, _, err := s.integrateBatch(ctx, tree, limit, guardWindow, maxRootDurationInterval)
+	return n, err
+}
+
+// integrateBatch is IntegrateBatch, also returning the latest root of the
+// tree, i.e. the one it signed, or the one it read if it signed none, and
+// whether the batch was filled with leaves queued for longer than the
+// max_queue_age of the tree, so that more of them may be left in the queue.
+func (s Sequencer) integrateBatch(ctx context.Context, tree *trillian.Tree, limit int, guardWindow, maxRootDurationInterval time.Duration) (int, *types.LogRootV1, bool, error) {
 	start := s.timeSource.Now()
 	label := strconv.FormatInt(tree.TreeId, 10)
 
 	numLeaves := 0
+	var latestRoot *types.LogRootV1
 	var newLogRoot *types.LogRootV1
 	var newSLR *trillian.SignedLogRoot
+	var requests []leafRequest
+	var integrated []*trillian.LogLeaf
+	var flushed, overdue bool
 	err := s.logStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
 		stageStart := s.timeSource.Now()
 		defer seqBatches.Inc(label)
 		defer func() { seqLatency.Observe(clock.SecondsSince(s.timeSource, start), label) }()
+		latestRoot = nil
+		flushed, overdue = false, false
+		// The age is exported however the pass ends, so that it drops to zero
+		// on passes which find no leaves, including those returning early.
+		var oldestAge time.Duration
+		defer func() { seqOldestPendingAge.Set(oldestAge.Seconds(), label) }()
 
 		// Get the latest known root from storage
 		sth, err := tx.LatestSignedLogRoot(ctx)
+		if err == storage.ErrTreeNeedsInit {
+			glog.Warningf("%v: Fresh log - no previous TreeHeads exist.", tree.TreeId)
+			return err
+		}
 		if err != nil || sth == nil {
 			return fmt.Errorf("%v: Sequencer failed to get latest root: %v", tree.TreeId, err)
 		}
@@ -356,12 +508,50 @@ func (s Sequencer) IntegrateBatch(ctx context.Context, tree *trillian.Tree, limi
 			glog.Warningf("%v: Fresh log - no previous TreeHeads exist.", tree.TreeId)
 			return storage.ErrTreeNeedsInit
 		}
+		latestRoot = &currentRoot
+
+		// Integrate from the root of the latest integration, which may be
+		// ahead of the latest signed root if the log has, or had, a
+		// signing_interval. Any such pending state is signed once the interval
+		// has passed, or straight away if it has since been cleared.
+		interval, maxQueueAge, err := signingCadence(tree)
+		if err != nil {
+			return fmt.Errorf("%v: %v", tree.TreeId, err)
+		}
+		baseRoot := &currentRoot
+		irs, ok := tx.(storage.IntegratedRootStore)
+		if interval > 0 && !ok {
+			return fmt.Errorf("%v: signing_interval not supported by storage", tree.TreeId)
+		}
+		if ok {
+			if baseRoot, err = irs.LatestIntegratedRoot(ctx); err != nil {
+				return fmt.Errorf("%v: Sequencer failed to get latest integrated root: %v", tree.TreeId, err)
+			}
+		}
+
+		// With a coarse timestamp granularity, a new root can only be signed
+		// once the truncated time has moved past that of the current root, so
+		// leave the queue alone until then.
+		// Reintegration instead checks whether any leaves are queued, so that
+		// it doesn't mistake waiting for an empty queue.
+		timestampTaken := false
+		if tree.TimestampGranularity != trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_NANOSECOND {
+			if now := trees.RootTimestamp(tree, s.timeSource.Now()); now <= currentRoot.TimestampNanos {
+				if s.reintegration == nil {
+					glog.V(1).Infof("%v: Root already signed at timestamp %d, waiting for the next %v", tree.TreeId, now, tree.TimestampGranularity)
+					return nil
+				}
+				timestampTaken = true
+			}
+		}
 
 		taskData := &sequencingTaskData{
 			label:      label,
-			treeSize:   currentRoot.TreeSize,
+			treeSize:   baseRoot.TreeSize,
+			hashSize:   s.hasher.Size(),
 			timeSource: s.timeSource,
 			tx:         tx,
+			sortLeaves: tree.SortByQueueTimestamp,
 		}
 		var st sequencingTask
 		switch tree.TreeType {
@@ -373,27 +563,84 @@ func (s Sequencer) IntegrateBatch(ctx context.Context, tree *trillian.Tree, limi
 			return fmt.Errorf("IntegrateBatch not supported for TreeType %v", tree.TreeType)
 		}
 
-		sequencedLeaves, err := st.fetch(ctx, limit, start.Add(-guardWindow))
-		if err != nil {
-			return fmt.Errorf("%v: Sequencer failed to load sequenced batch: %v", tree.TreeId, err)
+		// Never grow the tree past its maximum size, if it has one. Checking
+		// it here, in the same transaction as the integration, makes the cap
+		// hold however many leaves were queued.
+		if capped := capLimit(tree, baseRoot.TreeSize, limit); capped < limit {
+			if capped == 0 {
+				glog.V(1).Infof("%v: Tree is full at size %d, integrating no more leaves", tree.TreeId, baseRoot.TreeSize)
+			}
+			limit = capped
+		}
+		// Leaves queued for longer than the max_queue_age of the log aren't
+		// held back by the guard window.
+		guardCutoff := start.Add(-guardWindow)
+		cutoff := guardCutoff
+		if maxQueueAge > 0 && maxQueueAge < guardWindow {
+			cutoff = start.Add(-maxQueueAge)
+		}
+		var sequencedLeaves []*trillian.LogLeaf
+		if limit > 0 {
+			if sequencedLeaves, err = st.fetch(ctx, limit, cutoff); err != nil {
+				return fmt.Errorf("%v: Sequencer failed to load sequenced batch: %v", tree.TreeId, err)
+			}
 		}
 		numLeaves = len(sequencedLeaves)
+		oldestAge = oldestPendingAge(sequencedLeaves, start)
+		if timestampTaken {
+			if numLeaves > 0 {
+				return fmt.Errorf("%v: can't reintegrate queued leaves until the next %v, a root was already signed at timestamp %d", tree.TreeId, tree.TimestampGranularity, currentRoot.TimestampNanos)
+			}
+			return nil
+		}
+		requests = leafRequests(tx, sequencedLeaves)
+		integrated = nil
+		flushed = cutoff.After(guardCutoff) && queuedAfter(sequencedLeaves, guardCutoff)
+		// As leaves are dequeued oldest first, a full batch of overdue leaves
+		// may leave more of them in the queue, which shouldn't wait for the
+		// next pass either.
+		overdue = maxQueueAge > 0 && limit > 0 && numLeaves >= limit && allQueuedBy(sequencedLeaves, start.Add(-maxQueueAge))
 
 		// We need to create a signed root if entries were added or the latest root
-		// is too old.
+		// is too old. Logs with a signing_interval only sign the integrated
+		// state once the interval has passed since the latest signed root.
+		sinceRoot := time.Duration(s.timeSource.Now().UnixNano() - int64(currentRoot.TimestampNanos))
+		forced := maxRootDurationInterval != 0 && sinceRoot >= maxRootDurationInterval
+		sign := interval == 0 || forced || sinceRoot >= interval
+		// Leaves which would wait for the signing_interval for longer than the
+		// max_queue_age of the log flush the integrated state early.
+		if !sign && numLeaves > 0 && flushDue(&currentRoot, interval, maxQueueAge, start.Add(-oldestAge)) {
+			glog.V(1).Infof("%v: Flushing integrated state with leaves queued %v ago", tree.TreeId, oldestAge)
+			sign = true
+			flushed = true
+		}
 		if numLeaves == 0 {
-			nowNanos := s.timeSource.Now().UnixNano()
-			interval := time.Duration(nowNanos - int64(currentRoot.TimestampNanos))
-			if maxRootDurationInterval == 0 || interval < maxRootDurationInterval {
+			if baseRoot.Revision > currentRoot.Revision && sign {
+				// Sign the pending integrated state, which needs no new revision.
+				stageStart = s.timeSource.Now()
+				newLogRoot = &types.LogRootV1{
+					RootHash:       baseRoot.RootHash,
+					TimestampNanos: trees.RootTimestamp(tree, s.timeSource.Now()),
+					TreeSize:       baseRoot.TreeSize,
+					Revision:       baseRoot.Revision,
+				}
+				if newSLR, err = s.signRoot(ctx, tx, tree, newLogRoot, &currentRoot, label); err != nil {
+					return err
+				}
+				seqStoreRootLatency.Observe(clock.SecondsSince(s.timeSource, stageStart), label)
+				latestRoot = newLogRoot
+				return nil
+			}
+			if !forced {
 				// We have nothing to integrate into the tree.
 				glog.V(1).Infof("%v: No leaves sequenced in this signing operation", tree.TreeId)
 				return nil
 			}
-			glog.Infof("%v: Force new root generation as %v since last root", tree.TreeId, interval)
+			glog.Infof("%v: Force new root generation as %v since last root", tree.TreeId, sinceRoot)
 		}
 
 		stageStart = s.timeSource.Now()
-		cr, err := s.initCompactRangeFromStorage(ctx, &currentRoot, tx)
+		cr, err := s.initCompactRangeFromStorage(ctx, baseRoot, tx)
 		if err != nil {
 			return fmt.Errorf("%v: compact range init failed: %v", tree.TreeId, err)
 		}
@@ -408,18 +655,23 @@ func (s Sequencer) IntegrateBatch(ctx context.Context, tree *trillian.Tree, limi
 		if err != nil {
 			return err
 		}
-		if got, want := newVersion, int64(currentRoot.Revision)+1; got != want {
+		if got, want := newVersion, int64(baseRoot.Revision)+1; got != want {
 			return fmt.Errorf("%v: got writeRevision of %v, but expected %v", tree.TreeId, got, want)
 		}
 
 		// Collate node updates.
-		if err := s.prepareLeaves(sequencedLeaves, cr.End(), label); err != nil {
+		if err := s.prepareLeaves(sequencedLeaves, cr.End()); err != nil {
 			return err
 		}
 		nodeMap, newRoot, err := s.updateCompactRange(cr, sequencedLeaves, label)
 		if err != nil {
 			return err
 		}
+		if s.reintegration != nil && numLeaves > 0 {
+			if err := s.checkConsistent(ctx, tree, tx, baseRoot, &types.LogRootV1{TreeSize: cr.End(), RootHash: newRoot}, sequencedLeaves); err != nil {
+				return err
+			}
+		}
 		seqWriteTreeLatency.Observe(clock.SecondsSince(s.timeSource, stageStart), label)
 
 		// Store the sequenced batch.
@@ -452,41 +704,125 @@ func (s Sequencer) IntegrateBatch(ctx context.Context, tree *trillian.Tree, limi
 		}
 		newLogRoot = &types.LogRootV1{
 			RootHash:       newRoot,
-			TimestampNanos: uint64(s.timeSource.Now().UnixNano()),
+			TimestampNanos: trees.RootTimestamp(tree, s.timeSource.Now()),
 			TreeSize:       cr.End(),
 			Revision:       uint64(newVersion),
 		}
-		seqTreeSize.Set(float64(newLogRoot.TreeSize), label)
-		seqTimestamp.Set(float64(time.Duration(newLogRoot.TimestampNanos)*time.Nanosecond/
-			time.Millisecond), label)
 
-		if newLogRoot.TimestampNanos <= currentRoot.TimestampNanos {
-			return fmt.Errorf("%v: refusing to sign root with timestamp earlier than previous root (%d <= %d)", tree.TreeId, newLogRoot.TimestampNanos, currentRoot.TimestampNanos)
-		}
-
-		newSLR, err = s.signer.SignLogRoot(newLogRoot)
-		if err != nil {
-			return fmt.Errorf("%v: signer failed to sign root: %v", tree.TreeId, err)
+		if !sign {
+			// Keep the integrated state without publishing it, until the
+			// signing_interval has passed.
+			if err := irs.StoreIntegratedRoot(ctx, newLogRoot); err != nil {
+				return fmt.Errorf("%v: failed to write integrated tree root: %v", tree.TreeId, err)
+			}
+			seqStoreRootLatency.Observe(clock.SecondsSince(s.timeSource, stageStart), label)
+			seqUnsignedRoots.Inc(label)
+			integrated = sequencedLeaves
+			return nil
 		}
-
-		if err := tx.StoreSignedLogRoot(ctx, newSLR); err != nil {
-			return fmt.Errorf("%v: failed to write updated tree root: %v", tree.TreeId, err)
+		if newSLR, err = s.signRoot(ctx, tx, tree, newLogRoot, &currentRoot, label); err != nil {
+			return err
 		}
 		seqStoreRootLatency.Observe(clock.SecondsSince(s.timeSource, stageStart), label)
+		integrated = sequencedLeaves
+		latestRoot = newLogRoot
 		return nil
 	})
 	if err != nil {
-		return 0, err
+		return 0, nil, false, err
 	}
+	// Only record merge delays once the leaves are committed, so that those of
+	// failed or retried transactions aren't counted.
+	observeMergeDelays(integrated, label)
 
 	// Let quota.Manager know about newly-sequenced entries.
 	s.replenishQuota(ctx, numLeaves, tree.TreeId)
 
 	seqCounter.Add(float64(numLeaves), label)
-	if newSLR != nil {
+	if flushed {
+		seqForcedFlushes.Inc(label)
+	}
+	if newSLR != nil || len(integrated) > 0 {
 		glog.Infof("%v: sequenced %v leaves, size %v, tree-revision %v", tree.TreeId, numLeaves, newLogRoot.TreeSize, newLogRoot.Revision)
+		for _, r := range requests {
+			glog.V(1).Infof("%v: integrated leaf %d queued by request %s", tree.TreeId, r.leafIndex, r.requestID)
+		}
+	}
+	return numLeaves, latestRoot, overdue, nil
+}
+
+// signRoot signs root and stores it as the latest signed root of the tree,
+// refusing to go back in time from the current one.
+func (s Sequencer) signRoot(ctx context.Context, tx storage.LogTreeTX, tree *trillian.Tree, root, current *types.LogRootV1, label string) (*trillian.SignedLogRoot, error) {
+	if root.TimestampNanos <= current.TimestampNanos {
+		return nil, fmt.Errorf("%v: refusing to sign root with timestamp earlier than previous root (%d <= %d)", tree.TreeId, root.TimestampNanos, current.TimestampNanos)
+	}
+	slr, err := s.signer.SignLogRoot(root)
+	if err != nil {
+		return nil, fmt.Errorf("%v: signer failed to sign root: %v", tree.TreeId, err)
+	}
+	if err := tx.StoreSignedLogRoot(ctx, slr); err != nil {
+		return nil, fmt.Errorf("%v: failed to write updated tree root: %v", tree.TreeId, err)
+	}
+	seqTreeSize.Set(float64(root.TreeSize), label)
+	seqTimestamp.Set(float64(time.Duration(root.TimestampNanos)*time.Nanosecond/time.Millisecond), label)
+	return slr, nil
+}
+
+// InitTree writes the initial signed root of tree, of size zero, if it has
+// none, like the InitLog RPC. It returns the new root, or nil if the tree was
+// already initialised, so it can be called on every pass over a tree. Storage
+// rejects roots of the same revision, so a concurrent initialisation, e.g. by
+// another signer during a split election, makes one of the two fail.
+func (s Sequencer) InitTree(ctx context.Context, tree *trillian.Tree) (*trillian.SignedLogRoot, error) {
+	var newSLR *trillian.SignedLogRoot
+	err := s.logStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		newSLR = nil
+		latest, err := tx.LatestSignedLogRoot(ctx)
+		if err != nil && err != storage.ErrTreeNeedsInit {
+			return fmt.Errorf("%v: failed to get latest root: %v", tree.TreeId, err)
+		}
+		if latest.GetLogRoot() != nil {
+			return nil
+		}
+		newSLR, err = s.signer.SignLogRoot(&types.LogRootV1{
+			RootHash:       s.hasher.EmptyRoot(),
+			TimestampNanos: trees.RootTimestamp(tree, s.timeSource.Now()),
+		})
+		if err != nil {
+			return fmt.Errorf("%v: signer failed to sign root: %v", tree.TreeId, err)
+		}
+		if err := tx.StoreSignedLogRoot(ctx, newSLR); err != nil {
+			return fmt.Errorf("%v: failed to write initial tree root: %v", tree.TreeId, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newSLR, nil
+}
+
+// leafRequest is the ID of the request that queued a leaf.
+type leafRequest struct {
+	leafIndex int64
+	requestID string
+}
+
+// leafRequests returns the IDs of the requests that queued the leaves, for
+// those leaves that have one, if tx supports storage.QueueRequestIDReader.
+func leafRequests(tx storage.LogTreeTX, leaves []*trillian.LogLeaf) []leafRequest {
+	r, ok := tx.(storage.QueueRequestIDReader)
+	if !ok {
+		return nil
+	}
+	var requests []leafRequest
+	for _, leaf := range leaves {
+		if id := r.QueueRequestID(leaf.LeafIdentityHash); id != "" {
+			requests = append(requests, leafRequest{leafIndex: leaf.LeafIndex, requestID: id})
+		}
 	}
-	return numLeaves, nil
+	return requests
 }
 
 // replenishQuota replenishes all quotas, such as {Tree/Global, Read/Write},
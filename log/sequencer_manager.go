@@ -17,6 +17,7 @@ package log
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
@@ -24,8 +25,11 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
-	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/storage"
 	"github.com/google/trillian/trees"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	tcrypto "github.com/google/trillian/crypto"
 )
@@ -36,9 +40,39 @@ type SequencerManager struct {
 	registry     extension.Registry
 	signers      map[int64]*tcrypto.Signer
 	signersMutex sync.Mutex
+	batchSizes   batchTuner
+	// runs holds the outcome of the latest successful ExecutePass of each log,
+	// until RetainLogs drops it.
+	runsMu sync.Mutex
+	runs   map[int64]passOutcome
 }
 
-var seqOpts = trees.NewGetOpts(trees.SequenceLog, trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG)
+// passOutcome is the outcome of a successful ExecutePass of a log.
+type passOutcome struct {
+	root      *types.LogRootV1
+	batchFull bool
+}
+
+// SequencerStatus is the state of the sequencing of a log by a
+// SequencerManager, see SequencerManager.SequencerStatus.
+type SequencerStatus struct {
+	// Root is the latest root of the log as of the latest successful
+	// ExecutePass, i.e. the root it signed or read, or nil if there was none.
+	Root *types.LogRootV1
+	// BatchFull is whether the latest successful ExecutePass integrated a full
+	// batch, in which case more leaves are likely to be pending.
+	BatchFull bool
+	// BatchSize is the batch size of the next ExecutePass, which may vary if
+	// OperationInfo.MaxBatchSize is set.
+	BatchSize int
+}
+
+var (
+	seqOpts     = trees.NewGetOpts(trees.SequenceLog, trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG)
+	listQOpts   = trees.NewGetOpts(trees.Query, trillian.TreeType_LOG)
+	countOpts   = trees.NewGetOpts(trees.Query, trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG)
+	requeueOpts = trees.NewGetOpts(trees.QueueLog, trillian.TreeType_LOG)
+)
 
 // NewSequencerManager creates a new SequencerManager instance based on the provided KeyManager instance
 // and guard window.
@@ -47,9 +81,37 @@ func NewSequencerManager(registry extension.Registry, gw time.Duration) *Sequenc
 		guardWindow: gw,
 		registry:    registry,
 		signers:     make(map[int64]*tcrypto.Signer),
+		runs:        make(map[int64]passOutcome),
 	}
 }
 
+// SequencerStatus returns the state of the sequencing of the specified Log,
+// as of the latest ExecutePass. It is cheap: it doesn't access storage, nor
+// run sequencing.
+func (s *SequencerManager) SequencerStatus(logID int64, info *OperationInfo) SequencerStatus {
+	s.runsMu.Lock()
+	run := s.runs[logID]
+	s.runsMu.Unlock()
+	return SequencerStatus{Root: run.root, BatchFull: run.batchFull, BatchSize: s.batchSizes.size(logID, info)}
+}
+
+// RetainLogs implements LogRetainer, dropping the outcome of the latest
+// ExecutePass and the batch size of the Logs not in logIDs.
+func (s *SequencerManager) RetainLogs(logIDs []int64) {
+	keep := make(map[int64]bool, len(logIDs))
+	for _, id := range logIDs {
+		keep[id] = true
+	}
+	s.runsMu.Lock()
+	for id := range s.runs {
+		if !keep[id] {
+			delete(s.runs, id)
+		}
+	}
+	s.runsMu.Unlock()
+	s.batchSizes.retain(keep)
+}
+
 // ExecutePass performs sequencing for the specified Log.
 func (s *SequencerManager) ExecutePass(ctx context.Context, logID int64, info *OperationInfo) (int, error) {
 	// TODO(Martin2112): Honor the sequencing enabled in log parameters, needs an API change
@@ -60,8 +122,11 @@ func (s *SequencerManager) ExecutePass(ctx context.Context, logID int64, info *O
 		return 0, fmt.Errorf("error retrieving log %v: %v", logID, err)
 	}
 	ctx = trees.NewContext(ctx, tree)
+	if s.registry.SubtreeCache != nil {
+		ctx = storage.NewSubtreeCacheContext(ctx, s.registry.SubtreeCache)
+	}
 
-	hasher, err := hashers.NewLogHasher(tree.HashStrategy)
+	hasher, err := trees.LogHasher(tree)
 	if err != nil {
 		return 0, fmt.Errorf("error getting hasher for log %v: %v", logID, err)
 	}
@@ -78,13 +143,206 @@ func (s *SequencerManager) ExecutePass(ctx context.Context, logID int64, info *O
 		glog.Warning("failed to parse tree.MaxRootDuration, using zero")
 		maxRootDuration = 0
 	}
-	leaves, err := sequencer.IntegrateBatch(ctx, tree, info.BatchSize, s.guardWindow, maxRootDuration)
+	batchSize := s.batchSizes.size(logID, info)
+	seqBatchSize.Set(float64(batchSize), strconv.FormatInt(logID, 10))
+	start := info.TimeSource.Now()
+	var budget time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		budget = deadline.Sub(start)
+	}
+	leaves, root, overdue, err := sequencer.integrateBatch(ctx, tree, batchSize, s.guardWindow, maxRootDuration)
+	s.batchSizes.update(logID, info, batchSize, leaves, info.TimeSource.Now().Sub(start), budget, ctx.Err() != nil)
+	if err == storage.ErrTreeNeedsInit && info.InitLogs {
+		slr, err := sequencer.InitTree(ctx, tree)
+		if err != nil {
+			return 0, fmt.Errorf("failed to initialise log %v: %v", logID, err)
+		}
+		if slr != nil {
+			glog.Infof("%v: initialised log with an empty root", logID)
+		}
+		return 0, nil
+	}
 	if err != nil {
 		return 0, fmt.Errorf("failed to integrate batch for %v: %v", logID, err)
 	}
+	// Leaves queued for longer than the max_queue_age of the log aren't held
+	// back by the batch size: they are integrated by further batches of this
+	// pass, as long as it has time left.
+	n := leaves
+	for overdue && n > 0 && ctx.Err() == nil {
+		var batchRoot *types.LogRootV1
+		if n, batchRoot, overdue, err = sequencer.integrateBatch(ctx, tree, batchSize, s.guardWindow, maxRootDuration); err != nil {
+			return leaves, fmt.Errorf("failed to integrate overdue batch for %v: %v", logID, err)
+		}
+		if n > 0 {
+			seqForcedFlushes.Inc(strconv.FormatInt(logID, 10))
+		}
+		leaves += n
+		if batchRoot != nil {
+			root = batchRoot
+		}
+	}
+	s.runsMu.Lock()
+	s.runs[logID] = passOutcome{root: root, batchFull: leaves >= batchSize}
+	s.runsMu.Unlock()
 	return leaves, nil
 }
 
+// ReintegratePending integrates all leaves of the specified Log which have
+// been queued for at least minAge, see Sequencer.ReintegratePending. It returns
+// the number of leaves integrated and the latest signed root.
+func (s *SequencerManager) ReintegratePending(ctx context.Context, logID int64, info *OperationInfo, minAge time.Duration) (int, *trillian.SignedLogRoot, error) {
+	tree, err := trees.GetTree(ctx, s.registry.AdminStorage, logID, seqOpts)
+	if err != nil {
+		return 0, nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	if s.registry.SubtreeCache != nil {
+		ctx = storage.NewSubtreeCacheContext(ctx, s.registry.SubtreeCache)
+	}
+
+	hasher, err := trees.LogHasher(tree)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error getting hasher for log %v: %v", logID, err)
+	}
+
+	signer, err := s.getSigner(ctx, tree)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error getting signer for log %v: %v", logID, err)
+	}
+
+	sequencer := NewSequencer(hasher, info.TimeSource, s.registry.LogStorage, signer, s.registry.MetricFactory, s.registry.QuotaManager)
+	leaves, slr, err := sequencer.ReintegratePending(ctx, tree, info.BatchSize, minAge)
+	if err != nil {
+		return leaves, nil, fmt.Errorf("failed to reintegrate pending leaves of %v after %d leaves: %v", logID, leaves, err)
+	}
+	return leaves, slr, nil
+}
+
+// RepairFrom rebuilds the Merkle tree of the given log from a known-good tree
+// size, see Sequencer.RepairFrom. It returns the repaired root.
+func (s *SequencerManager) RepairFrom(ctx context.Context, logID int64, info *OperationInfo, opts RepairOptions) (*types.LogRootV1, error) {
+	tree, err := trees.GetTree(ctx, s.registry.AdminStorage, logID, seqOpts)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	hasher, err := trees.LogHasher(tree)
+	if err != nil {
+		return nil, fmt.Errorf("error getting hasher for log %v: %v", logID, err)
+	}
+
+	signer, err := s.getSigner(ctx, tree)
+	if err != nil {
+		return nil, fmt.Errorf("error getting signer for log %v: %v", logID, err)
+	}
+
+	sequencer := NewSequencer(hasher, info.TimeSource, s.registry.LogStorage, signer, s.registry.MetricFactory, s.registry.QuotaManager)
+	root, err := sequencer.RepairFrom(ctx, tree, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to repair %v from %d: %v", logID, opts.Index, err)
+	}
+	return root, nil
+}
+
+// SignLogRoot signs the pending integrated state of the specified Log on
+// demand, see Sequencer.SignRoot. It returns the latest signed root, and
+// whether it was signed by this call.
+func (s *SequencerManager) SignLogRoot(ctx context.Context, logID int64, info *OperationInfo) (*trillian.SignedLogRoot, bool, error) {
+	tree, err := trees.GetTree(ctx, s.registry.AdminStorage, logID, seqOpts)
+	if err != nil {
+		return nil, false, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	hasher, err := trees.LogHasher(tree)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting hasher for log %v: %v", logID, err)
+	}
+
+	signer, err := s.getSigner(ctx, tree)
+	if err != nil {
+		return nil, false, fmt.Errorf("error getting signer for log %v: %v", logID, err)
+	}
+
+	sequencer := NewSequencer(hasher, info.TimeSource, s.registry.LogStorage, signer, s.registry.MetricFactory, s.registry.QuotaManager)
+	slr, signed, err := sequencer.SignRoot(ctx, tree)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to sign root of %v: %v", logID, err)
+	}
+	return slr, signed, nil
+}
+
+// ListQuarantinedLeaves returns up to limit leaves of the specified Log which
+// were quarantined because they couldn't be integrated, oldest first.
+func (s *SequencerManager) ListQuarantinedLeaves(ctx context.Context, logID int64, limit int) ([]*trillian.QuarantinedLeaf, error) {
+	tree, err := trees.GetTree(ctx, s.registry.AdminStorage, logID, listQOpts)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	tx, err := s.registry.LogStorage.SnapshotForTree(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Close()
+	leaves, err := tx.ListQuarantinedLeaves(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return leaves, tx.Commit(ctx)
+}
+
+// CountPending returns the number of leaves queued in the specified Log and
+// not yet integrated, up to limit if it's positive, or -1 if its storage can't
+// count them.
+func (s *SequencerManager) CountPending(ctx context.Context, logID int64, limit int64) (int64, error) {
+	tree, err := trees.GetTree(ctx, s.registry.AdminStorage, logID, countOpts)
+	if err != nil {
+		return 0, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	tx, err := s.registry.LogStorage.SnapshotForTree(ctx, tree)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Close()
+	c, ok := tx.(storage.UnsequencedCounter)
+	if !ok {
+		return -1, tx.Commit(ctx)
+	}
+	count, err := c.CountUnsequenced(ctx, limit)
+	if status.Code(err) == codes.Unimplemented {
+		count, err = -1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, tx.Commit(ctx)
+}
+
+// RequeueQuarantinedLeaves moves the quarantined leaves of the specified Log
+// with the given identity hashes back to the queue. It returns the number of
+// leaves requeued.
+func (s *SequencerManager) RequeueQuarantinedLeaves(ctx context.Context, logID int64, leafIdentityHashes [][]byte) (int, error) {
+	tree, err := trees.GetTree(ctx, s.registry.AdminStorage, logID, requeueOpts)
+	if err != nil {
+		return 0, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	var requeued int
+	err = s.registry.LogStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		var err error
+		requeued, err = tx.RequeueQuarantinedLeaves(ctx, leafIdentityHashes)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return requeued, nil
+}
+
 // getSigner returns a signer for the given tree.
 // Signers are cached, so only one will be created per tree.
 func (s *SequencerManager) getSigner(ctx context.Context, tree *trillian.Tree) (*tcrypto.Signer, error) {
@@ -15,6 +15,7 @@
 package log
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"errors"
@@ -48,13 +49,15 @@ var fakeTimeSource = clock.NewFake(fakeTime)
 var testLogID1 = int64(1)
 var leaf0Hash = rfc6962.DefaultHasher.HashLeaf([]byte{})
 var testLeaf0 = &trillian.LogLeaf{
-	MerkleLeafHash: leaf0Hash,
-	LeafValue:      nil,
-	ExtraData:      nil,
-	LeafIndex:      0,
+	LeafIdentityHash: leaf0Hash,
+	MerkleLeafHash:   leaf0Hash,
+	LeafValue:        nil,
+	ExtraData:        nil,
+	LeafIndex:        0,
 }
 
 var testLeaf0Updated = &trillian.LogLeaf{
+	LeafIdentityHash:   leaf0Hash,
 	MerkleLeafHash:     testonly.MustDecodeBase64("bjQLnP+zepicpUTmu3gKLHiQHT+zNzh2hRGjBhevoB0="),
 	LeafValue:          nil,
 	ExtraData:          nil,
@@ -256,7 +259,25 @@ func TestSequencerManagerSingleLogOneLeaf(t *testing.T) {
 	}
 
 	sm := NewSequencerManager(registry, zeroDuration)
-	sm.ExecutePass(ctx, logID, createTestInfo(registry))
+	info := createTestInfo(registry)
+	if got, want := sm.SequencerStatus(logID, info), (SequencerStatus{BatchSize: 50}); !cmp.Equal(got, want) {
+		t.Errorf("SequencerStatus() before ExecutePass = %+v, want %+v", got, want)
+	}
+	if _, err := sm.ExecutePass(ctx, logID, info); err != nil {
+		t.Fatalf("ExecutePass(): %v", err)
+	}
+	want := SequencerStatus{Root: updatedRoot, BatchSize: 50}
+	if got := sm.SequencerStatus(logID, info); !cmp.Equal(got, want) {
+		t.Errorf("SequencerStatus() = %+v, want %+v", got, want)
+	}
+	sm.RetainLogs([]int64{logID})
+	if got := sm.SequencerStatus(logID, info); !cmp.Equal(got, want) {
+		t.Errorf("SequencerStatus() after RetainLogs() of the log = %+v, want %+v", got, want)
+	}
+	sm.RetainLogs([]int64{logID + 1})
+	if got, want := sm.SequencerStatus(logID, info), (SequencerStatus{BatchSize: 50}); !cmp.Equal(got, want) {
+		t.Errorf("SequencerStatus() after RetainLogs() of another log = %+v, want %+v", got, want)
+	}
 }
 
 // cmpMatcher is a custom gomock.Matcher that uses cmp.Equal combined with a
@@ -310,6 +331,88 @@ func TestSequencerManagerGuardWindow(t *testing.T) {
 	sm.ExecutePass(ctx, logID, createTestInfo(registry))
 }
 
+func TestSequencerManagerInitLogs(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		initLogs bool
+		latest   *trillian.SignedLogRoot
+		wantInit bool
+		wantErr  bool
+	}{
+		{desc: "disabled", wantErr: true},
+		{desc: "uninitialised", initLogs: true, wantInit: true},
+		{desc: "initialisedConcurrently", initLogs: true, latest: testSignedRoot0},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			logID := stestonly.LogTree.GetTreeId()
+			mockAdminTx := storage.NewMockReadOnlyAdminTX(mockCtrl)
+			mockAdmin := &stestonly.FakeAdminStorage{ReadOnlyTX: []storage.ReadOnlyAdminTX{mockAdminTx}}
+			mockTx := storage.NewMockLogTreeTX(mockCtrl)
+			fakeStorage := &stestonly.FakeLogStorage{TX: mockTx}
+
+			var keyProto ptypes.DynamicAny
+			if err := ptypes.UnmarshalAny(stestonly.LogTree.PrivateKey, &keyProto); err != nil {
+				t.Fatalf("Failed to unmarshal stestonly.LogTree.PrivateKey: %v", err)
+			}
+			keys.RegisterHandler(fakeKeyProtoHandler(keyProto.Message, fixedGoSigner, nil))
+			defer keys.UnregisterHandler(keyProto.Message)
+
+			// The pass finds no root.
+			mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(nil, storage.ErrTreeNeedsInit)
+			mockTx.EXPECT().Close().Return(nil)
+			if test.initLogs {
+				// The initialisation checks again in its own transaction.
+				latestErr := storage.ErrTreeNeedsInit
+				if test.latest != nil {
+					latestErr = nil
+				}
+				mockTx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(test.latest, latestErr)
+				mockTx.EXPECT().Close().Return(nil)
+				mockTx.EXPECT().Commit(gomock.Any()).Return(nil)
+			}
+			if test.wantInit {
+				mockTx.EXPECT().StoreSignedLogRoot(gomock.Any(), gomock.Any()).Do(func(_ context.Context, slr *trillian.SignedLogRoot) {
+					var root types.LogRootV1
+					if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+						t.Fatalf("UnmarshalBinary(): %v", err)
+					}
+					if root.TreeSize != 0 || root.Revision != 0 {
+						t.Errorf("initial root has size %d and revision %d, want zero", root.TreeSize, root.Revision)
+					}
+					if got, want := root.RootHash, rfc6962.DefaultHasher.EmptyRoot(); !bytes.Equal(got, want) {
+						t.Errorf("initial root hash = %x, want %x", got, want)
+					}
+				}).Return(nil)
+			}
+
+			mockAdminTx.EXPECT().GetTree(gomock.Any(), logID).Return(stestonly.LogTree, nil)
+			mockAdminTx.EXPECT().Commit().Return(nil)
+			mockAdminTx.EXPECT().Close().Return(nil)
+
+			registry := extension.Registry{
+				AdminStorage: mockAdmin,
+				LogStorage:   fakeStorage,
+				QuotaManager: quota.Noop(),
+			}
+			info := createTestInfo(registry)
+			info.InitLogs = test.initLogs
+
+			sm := NewSequencerManager(registry, zeroDuration)
+			leaves, err := sm.ExecutePass(ctx, logID, info)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("ExecutePass() = %v, %v; want err? %v", leaves, err, test.wantErr)
+			}
+			if leaves != 0 {
+				t.Errorf("ExecutePass() = %v leaves, want 0", leaves)
+			}
+		})
+	}
+}
+
 func createTestInfo(registry extension.Registry) *OperationInfo {
 	// Set sign interval to 100 years so it won't trigger a root expiry signing unless overridden
 	return &OperationInfo{
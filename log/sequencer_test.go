@@ -19,11 +19,14 @@ import (
 	"crypto"
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys/pem"
 	"github.com/google/trillian/merkle/rfc6962"
@@ -45,6 +48,7 @@ var (
 	testLeaf16Data = []byte("testdataforleaf")
 	testLeaf16Hash = rfc6962.DefaultHasher.HashLeaf(testLeaf16Data)
 	testLeaf16     = &trillian.LogLeaf{
+		LeafIdentityHash:   testLeaf16Hash,
 		MerkleLeafHash:     testLeaf16Hash,
 		LeafValue:          testLeaf16Data,
 		ExtraData:          nil,
@@ -52,6 +56,7 @@ var (
 		IntegrateTimestamp: testonly.MustToTimestampProto(fakeTime),
 	}
 	testLeaf21 = &trillian.LogLeaf{
+		LeafIdentityHash:   testLeaf16Hash,
 		MerkleLeafHash:     testLeaf16Hash,
 		LeafValue:          testLeaf16Data,
 		ExtraData:          nil,
@@ -254,10 +259,11 @@ type testContext struct {
 // This gets modified so tests need their own copies
 func getLeaf42() *trillian.LogLeaf {
 	return &trillian.LogLeaf{
-		MerkleLeafHash: testLeaf16Hash,
-		LeafValue:      testLeaf16Data,
-		ExtraData:      nil,
-		LeafIndex:      42,
+		LeafIdentityHash: testLeaf16Hash,
+		MerkleLeafHash:   testLeaf16Hash,
+		LeafValue:        testLeaf16Data,
+		ExtraData:        nil,
+		LeafIndex:        42,
 	}
 }
 
@@ -716,8 +722,12 @@ func TestIntegrateBatch_PutTokens(t *testing.T) {
 
 	oneHundredLeaves := make([]*trillian.LogLeaf, 100)
 	for i := range oneHundredLeaves {
+		value := []byte(fmt.Sprintf("leaf-%v", i))
+		hash := rfc6962.DefaultHasher.HashLeaf(value)
 		oneHundredLeaves[i] = &trillian.LogLeaf{
-			LeafValue: []byte(fmt.Sprintf("leaf-%v", i)),
+			LeafIdentityHash: hash,
+			MerkleLeafHash:   hash,
+			LeafValue:        value,
 		}
 	}
 
@@ -802,3 +812,199 @@ func TestIntegrateBatch_PutTokens(t *testing.T) {
 		}()
 	}
 }
+
+func TestIntegrateBatch_MergeDelayMetrics(t *testing.T) {
+	cryptoSigner := newSignerWithFixedSig(testSignedRoot.LogRootSignature)
+	signer := tcrypto.NewSigner(0, cryptoSigner, crypto.SHA256)
+	ts := clock.NewFake(fakeTime)
+
+	queuedAt := func(ago time.Duration) *trillian.LogLeaf {
+		leaf := getLeaf42()
+		leaf.QueueTimestamp, _ = ptypes.TimestampProto(fakeTime.Add(-ago))
+		return leaf
+	}
+
+	any := gomock.Any()
+	ctx := context.Background()
+	for i, test := range []struct {
+		desc      string
+		leaves    []*trillian.LogLeaf
+		commitErr error
+		// waiting makes the pass wait for the next second, without reading
+		// the queue.
+		waiting       bool
+		wantOldestAge float64
+		wantCount     uint64
+		wantSum       float64
+	}{
+		{desc: "noLeaves"},
+		{desc: "waiting", waiting: true},
+		{
+			desc:          "leaves",
+			leaves:        []*trillian.LogLeaf{queuedAt(30 * time.Second), queuedAt(10 * time.Second)},
+			wantOldestAge: 30,
+			wantCount:     2,
+			wantSum:       40,
+		},
+		{
+			desc:          "noQueueTimestamp",
+			leaves:        []*trillian.LogLeaf{getLeaf42()},
+			wantOldestAge: 0,
+		},
+		{
+			desc:          "commitFails",
+			leaves:        []*trillian.LogLeaf{queuedAt(30 * time.Second)},
+			commitErr:     errors.New("commit failed"),
+			wantOldestAge: 30,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			logTX := storage.NewMockLogTreeTX(ctrl)
+			if !test.waiting {
+				logTX.EXPECT().DequeueLeaves(any, any, any).Return(test.leaves, nil)
+			}
+			logTX.EXPECT().LatestSignedLogRoot(any).Return(testSignedRoot16, nil)
+			if len(test.leaves) != 0 {
+				logTX.EXPECT().GetMerkleNodes(any, any, any).Return(compactTree16, nil)
+			}
+			logTX.EXPECT().WriteRevision(any).AnyTimes().Return(int64(testRoot16.Revision+1), nil)
+			logTX.EXPECT().UpdateSequencedLeaves(any, any).AnyTimes().Return(nil)
+			logTX.EXPECT().SetMerkleNodes(any, any).AnyTimes().Return(nil)
+			logTX.EXPECT().StoreSignedLogRoot(any, any).AnyTimes().Return(nil)
+			logTX.EXPECT().Commit(any).Return(test.commitErr)
+			logTX.EXPECT().Close().Return(nil)
+			logStorage := &stestonly.FakeLogStorage{TX: logTX}
+
+			// Use a different tree for each test, so that metrics don't add up.
+			tree := &trillian.Tree{TreeId: int64(5000 + i), TreeType: trillian.TreeType_LOG}
+			ts := ts
+			if test.waiting {
+				// The root was signed within the current second.
+				tree.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND
+				ts = clock.NewFake(fakeTime.Add(-5 * time.Millisecond))
+			}
+			label := strconv.FormatInt(tree.TreeId, 10)
+			sequencer := NewSequencer(rfc6962.DefaultHasher, ts, logStorage, signer, nil /* mf */, quota.Noop())
+			// The age of an earlier pass is replaced.
+			seqOldestPendingAge.Set(99, label)
+			_, err := sequencer.IntegrateBatch(ctx, tree, 1000 /* limit */, 0 /* guardWindow */, time.Hour /* maxRootDuration */)
+			if gotErr := err != nil; gotErr != (test.commitErr != nil) {
+				t.Fatalf("IntegrateBatch() returned err = %v, want error: %v", err, test.commitErr != nil)
+			}
+
+			if got := seqOldestPendingAge.Value(label); got != test.wantOldestAge {
+				t.Errorf("sequencer_oldest_pending_age = %v, want %v", got, test.wantOldestAge)
+			}
+			if count, sum := seqMergeDelay.Info(label); count != test.wantCount || sum != test.wantSum {
+				t.Errorf("sequencer_merge_delay has count %d and sum %v, want %d and %v", count, sum, test.wantCount, test.wantSum)
+			}
+		})
+	}
+}
+
+// requestIDTX is a LogTreeTX which knows the request IDs of some leaves.
+type requestIDTX struct {
+	storage.LogTreeTX
+	requestIDs map[string]string
+}
+
+func (tx requestIDTX) QueueRequestID(leafIdentityHash []byte) string {
+	return tx.requestIDs[string(leafIdentityHash)]
+}
+
+func TestLeafRequests(t *testing.T) {
+	leaves := []*trillian.LogLeaf{
+		{LeafIdentityHash: []byte("a"), LeafIndex: 10},
+		{LeafIdentityHash: []byte("b"), LeafIndex: 11},
+		{LeafIdentityHash: []byte("c"), LeafIndex: 12},
+	}
+	tx := requestIDTX{requestIDs: map[string]string{"a": "req-1", "c": "req-2"}}
+	got := leafRequests(tx, leaves)
+	want := []leafRequest{{leafIndex: 10, requestID: "req-1"}, {leafIndex: 12, requestID: "req-2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("leafRequests() = %+v, want %+v", got, want)
+	}
+
+	// Storage which doesn't keep request IDs.
+	if got := leafRequests(requestIDTX{}.LogTreeTX, leaves); got != nil {
+		t.Errorf("leafRequests() without request IDs = %+v, want nil", got)
+	}
+}
+
+func TestCapLimit(t *testing.T) {
+	for _, test := range []struct {
+		desc        string
+		maxTreeSize int64
+		treeSize    uint64
+		want        int
+	}{
+		{desc: "noMax", treeSize: 1 << 40, want: 100},
+		{desc: "farFromMax", maxTreeSize: 1000, treeSize: 10, want: 100},
+		{desc: "nearMax", maxTreeSize: 1000, treeSize: 990, want: 10},
+		{desc: "atMax", maxTreeSize: 1000, treeSize: 1000, want: 0},
+		{desc: "pastMax", maxTreeSize: 1000, treeSize: 1001, want: 0},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			tree := &trillian.Tree{TreeType: trillian.TreeType_LOG, MaxTreeSize: test.maxTreeSize}
+			if got := capLimit(tree, test.treeSize, 100); got != test.want {
+				t.Errorf("capLimit() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLogSequencingTask_SortLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Leaves are queued at the given second, and their identity hashes start
+	// with id to break ties. They are dequeued out of order.
+	newLeaf := func(secs int64, id string) *trillian.LogLeaf {
+		hash := rfc6962.DefaultHasher.HashLeaf([]byte(id))
+		hash[0] = id[0]
+		return &trillian.LogLeaf{
+			LeafIdentityHash: hash,
+			MerkleLeafHash:   hash,
+			QueueTimestamp:   testonly.MustToTimestampProto(fakeTime.Add(time.Duration(secs) * time.Second)),
+		}
+	}
+	dequeued := func() []*trillian.LogLeaf {
+		return []*trillian.LogLeaf{newLeaf(3, "a"), newLeaf(1, "b"), newLeaf(2, "b"), newLeaf(2, "a")}
+	}
+
+	for _, test := range []struct {
+		desc       string
+		sortLeaves bool
+		want       []*trillian.LogLeaf
+	}{
+		{desc: "dequeueOrder", want: dequeued()},
+		{desc: "sorted", sortLeaves: true, want: []*trillian.LogLeaf{newLeaf(1, "b"), newLeaf(2, "a"), newLeaf(2, "b"), newLeaf(3, "a")}},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			tx := storage.NewMockLogTreeTX(ctrl)
+			tx.EXPECT().DequeueLeaves(gomock.Any(), 10, gomock.Any()).Return(dequeued(), nil)
+			s := &logSequencingTask{
+				label:      "test",
+				treeSize:   5,
+				hashSize:   rfc6962.DefaultHasher.Size(),
+				timeSource: clock.NewFake(fakeTime),
+				tx:         tx,
+				sortLeaves: test.sortLeaves,
+			}
+
+			got, err := s.fetch(context.Background(), 10, fakeTime)
+			if err != nil {
+				t.Fatalf("fetch(): %v", err)
+			}
+			for i, leaf := range test.want {
+				leaf.LeafIndex = int64(5 + i)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("fetch() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
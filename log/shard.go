@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package log
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/golang/glog"
+)
+
+// Shard identifies the logs sequenced by one signer of a fleet which
+// statically partitions logs, rather than electing a master for each: the
+// signer with shard Index of Count sequences the logs whose ID modulo Count is
+// Index.
+type Shard struct {
+	Index int64
+	Count int64
+}
+
+// Validate checks that s is a shard of a positive number of shards.
+func (s Shard) Validate() error {
+	if s.Count <= 0 {
+		return fmt.Errorf("shard count must be positive, got %d", s.Count)
+	}
+	if s.Index < 0 || s.Index >= s.Count {
+		return fmt.Errorf("shard index must be in [0, %d), got %d", s.Count, s.Index)
+	}
+	return nil
+}
+
+// Owns returns whether the log with the given ID belongs to s.
+func (s Shard) Owns(logID int64) bool {
+	return logID%s.Count == s.Index
+}
+
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Sharding holds the Shard of a signer, which can be changed while it runs,
+// e.g. when the number of signers changes. See OperationInfo.Sharding.
+type Sharding struct {
+	mu    sync.RWMutex
+	shard Shard
+}
+
+// NewSharding returns a Sharding which is initially set to s.
+func NewSharding(s Shard) (*Sharding, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	return &Sharding{shard: s}, nil
+}
+
+// Get returns the current shard.
+func (s *Sharding) Get() Shard {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.shard
+}
+
+// Set changes the shard, which takes effect from the next pass of the
+// operation manager. The shard is left unchanged if shard is invalid.
+func (s *Sharding) Set(shard Shard) error {
+	if err := shard.Validate(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if shard != s.shard {
+		glog.Infof("Changing shard from %v to %v", s.shard, shard)
+		s.shard = shard
+	}
+	return nil
+}
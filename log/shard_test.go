@@ -0,0 +1,78 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package log
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestShardValidate(t *testing.T) {
+	for _, test := range []struct {
+		shard   Shard
+		wantErr bool
+	}{
+		{shard: Shard{Index: 0, Count: 1}},
+		{shard: Shard{Index: 2, Count: 3}},
+		{shard: Shard{Index: 0, Count: 0}, wantErr: true},
+		{shard: Shard{Index: 3, Count: 3}, wantErr: true},
+		{shard: Shard{Index: -1, Count: 3}, wantErr: true},
+	} {
+		if err := test.shard.Validate(); (err != nil) != test.wantErr {
+			t.Errorf("%v.Validate() = %v, wantErr %v", test.shard, err, test.wantErr)
+		}
+	}
+}
+
+func TestMasterForSharding(t *testing.T) {
+	ctx := context.Background()
+	sharding, err := NewSharding(Shard{Index: 1, Count: 2})
+	if err != nil {
+		t.Fatalf("NewSharding(): %v", err)
+	}
+	o := NewOperationManager(OperationInfo{Sharding: sharding}, nil)
+	allIDs := []int64{1, 2, 3, 4, 5, 6}
+
+	for _, test := range []struct {
+		shard Shard
+		want  []int64
+	}{
+		{shard: Shard{Index: 1, Count: 2}, want: []int64{1, 3, 5}},
+		// The logs are rebalanced when the number of shards changes.
+		{shard: Shard{Index: 1, Count: 3}, want: []int64{1, 4}},
+		{shard: Shard{Index: 0, Count: 1}, want: allIDs},
+	} {
+		if err := sharding.Set(test.shard); err != nil {
+			t.Fatalf("Set(%v): %v", test.shard, err)
+		}
+		got, err := o.masterFor(ctx, allIDs)
+		if err != nil {
+			t.Fatalf("masterFor(): %v", err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("masterFor() with shard %v = %v, want %v", test.shard, got, test.want)
+		}
+	}
+
+	if err := sharding.Set(Shard{Index: 2, Count: 2}); err == nil {
+		t.Error("Set() of invalid shard succeeded")
+	}
+	if got, want := sharding.Get(), (Shard{Index: 0, Count: 1}); got != want {
+		t.Errorf("Get() after invalid Set() = %v, want %v", got, want)
+	}
+	if _, err := o.ResignMastership(0, "test"); err == nil {
+		t.Error("ResignMastership() succeeded with sharding, want error")
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compact
+
+import (
+	"bytes"
+	"fmt"
+	"math/bits"
+)
+
+// InclusionProof returns the inclusion proof of the leaf with the specified
+// index against the root hash of this compact range, as returned by
+// GetRootHash. The proof hashes are ordered from the leaf up to the root, as
+// in RFC 6962, and can be checked with RangeFactory.VerifyInclusion. Requires
+// the range to start at index 0.
+//
+// A compact range stores only the roots of its perfect subtrees, so a proof can
+// only be built for a leaf which is itself one of them. This is the case for
+// the last leaf of a range of an odd size, e.g. a leaf that has just been
+// appended. For other leaves an error is returned, which identifies the
+// subtree covering the leaf.
+func (r *Range) InclusionProof(index uint64) ([][]byte, error) {
+	if r.begin != 0 {
+		return nil, fmt.Errorf("begin=%d, want 0", r.begin)
+	}
+	if index >= r.end {
+		return nil, fmt.Errorf("index %d out of range [0, %d)", index, r.end)
+	}
+	// The paths to leaves index and end diverge at the level of the perfect
+	// subtree which covers the leaf.
+	if level := uint(bits.Len64(index^r.end)) - 1; level != 0 {
+		id := NewNodeID(level, index>>level)
+		return nil, fmt.Errorf("leaf %d is covered by subtree %+v, whose inner nodes are not stored", index, id)
+	}
+	// The leaf is the lowest subtree, and all the others are its left siblings
+	// along the right border of the tree.
+	ln := len(r.hashes)
+	proof := make([][]byte, 0, ln-1)
+	for i := ln - 2; i >= 0; i-- {
+		proof = append(proof, r.hashes[i])
+	}
+	return proof, nil
+}
+
+// VerifyInclusion checks that the passed in proof proves the inclusion of the
+// leaf with the specified index and hash in the Merkle tree of the given size
+// with the given root hash. The proof is ordered from the leaf up to the root,
+// as returned by Range.InclusionProof, or by any RFC 6962 log.
+func (f *RangeFactory) VerifyInclusion(index, size uint64, leafHash []byte, proof [][]byte, root []byte) error {
+	if index >= size {
+		return fmt.Errorf("index %d out of range [0, %d)", index, size)
+	}
+	// The proof consists of the siblings below the point where the paths to
+	// leaves index and size-1 diverge, followed by the left siblings of the
+	// nodes along the right border of the tree above this point.
+	inner := uint(bits.Len64(index ^ (size - 1)))
+	border := bits.OnesCount64(index >> inner)
+	if got, want := len(proof), int(inner)+border; got != want {
+		return fmt.Errorf("wrong proof size %d, want %d", got, want)
+	}
+
+	hash := leafHash
+	for i, sibling := range proof[:inner] {
+		if index>>uint(i)&1 == 0 {
+			hash = f.Hash(hash, sibling)
+		} else {
+			hash = f.Hash(sibling, hash)
+		}
+	}
+	for _, sibling := range proof[inner:] {
+		hash = f.Hash(sibling, hash)
+	}
+	if !bytes.Equal(hash, root) {
+		return fmt.Errorf("root mismatch: calculated %x, want %x", hash, root)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compact_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/merkle/rfc6962"
+)
+
+var factory = &compact.RangeFactory{Hash: rfc6962.DefaultHasher.HashChildren}
+
+// fullProof returns the inclusion proof of the given leaf built from the full
+// tree. Note: InMemoryMerkleTree counts leaves from 1.
+func fullProof(tree *merkle.InMemoryMerkleTree, index uint64) [][]byte {
+	desc := tree.PathToCurrentRoot(int64(index) + 1)
+	proof := make([][]byte, len(desc))
+	for i, d := range desc {
+		proof[i] = d.Value.Hash()
+	}
+	return proof
+}
+
+func TestInclusionProof(t *testing.T) {
+	tree := merkle.NewInMemoryMerkleTree(rfc6962.DefaultHasher)
+	cr := factory.NewEmptyRange(0)
+	for size := uint64(1); size <= 130; size++ {
+		index := size - 1
+		data := []byte(fmt.Sprintf("data: %d", index))
+		tree.AddLeaf(data)
+		leafHash := rfc6962.DefaultHasher.HashLeaf(data)
+		if err := cr.Append(leafHash, nil); err != nil {
+			t.Fatalf("Append(): %v", err)
+		}
+		root, err := cr.GetRootHash(nil)
+		if err != nil {
+			t.Fatalf("GetRootHash(): %v", err)
+		}
+
+		proof, err := cr.InclusionProof(index)
+		if size%2 == 0 {
+			// The last leaf has been merged into a bigger subtree.
+			if err == nil {
+				t.Errorf("size %d: InclusionProof(%d) succeeded, want error", size, index)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("size %d: InclusionProof(%d): %v", size, index, err)
+		}
+		if want := fullProof(tree, index); !reflect.DeepEqual(proof, want) {
+			t.Errorf("size %d: InclusionProof(%d) = %x, want %x", size, index, proof, want)
+		}
+		if err := factory.VerifyInclusion(index, size, leafHash, proof, root); err != nil {
+			t.Errorf("size %d: VerifyInclusion(%d): %v", size, index, err)
+		}
+	}
+}
+
+func TestInclusionProofErrors(t *testing.T) {
+	hash := rfc6962.DefaultHasher.HashLeaf([]byte("data"))
+	cr, err := factory.NewRange(1, 2, [][]byte{hash})
+	if err != nil {
+		t.Fatalf("NewRange(): %v", err)
+	}
+	if _, err := cr.InclusionProof(1); err == nil {
+		t.Error("InclusionProof() for a range not starting at 0 succeeded")
+	}
+
+	cr = factory.NewEmptyRange(0)
+	for i := 0; i < 5; i++ {
+		if err := cr.Append(hash, nil); err != nil {
+			t.Fatalf("Append(): %v", err)
+		}
+	}
+	for _, index := range []uint64{0, 3, 5, 10} {
+		if _, err := cr.InclusionProof(index); err == nil {
+			t.Errorf("InclusionProof(%d) succeeded, want error", index)
+		}
+	}
+}
+
+func TestVerifyInclusion(t *testing.T) {
+	tree := merkle.NewInMemoryMerkleTree(rfc6962.DefaultHasher)
+	for size := uint64(1); size <= 70; size++ {
+		tree.AddLeaf([]byte(fmt.Sprintf("data: %d", size-1)))
+		root := tree.CurrentRoot().Hash()
+		for index := uint64(0); index < size; index++ {
+			leafHash := tree.LeafHash(int64(index) + 1)
+			proof := fullProof(tree, index)
+			if err := factory.VerifyInclusion(index, size, leafHash, proof, root); err != nil {
+				t.Errorf("VerifyInclusion(%d, %d): %v", index, size, err)
+			}
+
+			// A wrong index is rejected.
+			if size > 1 {
+				other := (index + 1) % size
+				if err := factory.VerifyInclusion(other, size, leafHash, proof, root); err == nil {
+					t.Errorf("VerifyInclusion(%d, %d) with a wrong index succeeded", other, size)
+				}
+			}
+			// Tampered proofs are rejected.
+			for i := range proof {
+				tampered := append([][]byte(nil), proof...)
+				tampered[i] = leafHash
+				if err := factory.VerifyInclusion(index, size, leafHash, tampered, root); err == nil {
+					t.Errorf("VerifyInclusion(%d, %d) with tampered proof[%d] succeeded", index, size, i)
+				}
+			}
+			if err := factory.VerifyInclusion(index, size, leafHash, append(proof, root), root); err == nil {
+				t.Errorf("VerifyInclusion(%d, %d) with a longer proof succeeded", index, size)
+			}
+		}
+	}
+	if err := factory.VerifyInclusion(1, 1, nil, nil, nil); err == nil {
+		t.Error("VerifyInclusion() with index beyond size succeeded")
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashers
+
+// WithEmptyRoot returns a LogHasher which is h, except that its EmptyRoot is
+// root. If root is empty, h is returned as is.
+func WithEmptyRoot(h LogHasher, root []byte) LogHasher {
+	if len(root) == 0 {
+		return h
+	}
+	return &emptyRootHasher{LogHasher: h, root: root}
+}
+
+type emptyRootHasher struct {
+	LogHasher
+	root []byte
+}
+
+// EmptyRoot implements LogHasher.EmptyRoot.
+func (h *emptyRootHasher) EmptyRoot() []byte {
+	return h.root
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashers
+
+import "encoding/binary"
+
+// HashLogLeaf returns the Merkle leaf hash of a log leaf. If hashExtraData is
+// false, only the leaf value is hashed. Otherwise, the extra data is hashed
+// too, as described in ExtraDataLeafInput.
+func HashLogLeaf(h LogHasher, value, extraData []byte, hashExtraData bool) []byte {
+	if !hashExtraData {
+		return h.HashLeaf(value)
+	}
+	return h.HashLeaf(ExtraDataLeafInput(value, extraData))
+}
+
+// ExtraDataLeafInput returns the data passed to LogHasher.HashLeaf for the
+// leaves of trees which commit to leaf extra data, i.e. have hash_extra_data
+// set. It's the concatenation of the leaf value and the extra data, each
+// prefixed with its length as a 4-byte big-endian integer:
+//
+//	+---+---+---+---+----....----+---+---+---+---+----....----+
+//	|  len(value)   |   value    | len(extraData)|  extraData |
+//	+---+---+---+---+----....----+---+---+---+---+----....----+
+//
+// Absent extra data is encoded the same as empty extra data.
+func ExtraDataLeafInput(value, extraData []byte) []byte {
+	b := make([]byte, 0, 8+len(value)+len(extraData))
+	b = appendLengthPrefixed(b, value)
+	return appendLengthPrefixed(b, extraData)
+}
+
+func appendLengthPrefixed(b, data []byte) []byte {
+	var l [4]byte
+	binary.BigEndian.PutUint32(l[:], uint32(len(data)))
+	return append(append(b, l[:]...), data...)
+}
@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hashers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestExtraDataLeafInput(t *testing.T) {
+	for _, test := range []struct {
+		desc         string
+		value, extra []byte
+		want         string
+	}{
+		{desc: "empty", want: "0000000000000000"},
+		{desc: "value", value: []byte("ab"), want: "00000002616200000000"},
+		{desc: "extra", extra: []byte("c"), want: "000000000000000163"},
+		{desc: "both", value: []byte("ab"), extra: []byte("c"), want: "0000000261620000000163"},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := hex.EncodeToString(ExtraDataLeafInput(test.value, test.extra)); got != test.want {
+				t.Errorf("ExtraDataLeafInput() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+// sha256Hasher hashes leaves with plain SHA-256.
+type sha256Hasher struct{ LogHasher }
+
+func (sha256Hasher) HashLeaf(leaf []byte) []byte {
+	h := sha256.Sum256(leaf)
+	return h[:]
+}
+
+func TestHashLogLeaf(t *testing.T) {
+	h := sha256Hasher{}
+	value, extra := []byte("value"), []byte("extra")
+
+	if got, want := HashLogLeaf(h, value, extra, false), h.HashLeaf(value); !bytes.Equal(got, want) {
+		t.Errorf("HashLogLeaf(value-only) = %x, want %x", got, want)
+	}
+	if got, want := HashLogLeaf(h, value, extra, true), h.HashLeaf(ExtraDataLeafInput(value, extra)); !bytes.Equal(got, want) {
+		t.Errorf("HashLogLeaf(extra data) = %x, want %x", got, want)
+	}
+	// Moving bytes between the value and extra data changes the hash.
+	if a, b := HashLogLeaf(h, []byte("ab"), []byte("c"), true), HashLogLeaf(h, []byte("a"), []byte("bc"), true); bytes.Equal(a, b) {
+		t.Errorf("HashLogLeaf() = %x for different splits of the same bytes", a)
+	}
+}
@@ -90,6 +90,52 @@ func CalcConsistencyProofNodeAddresses(snapshot1, snapshot2, treeSize int64) ([]
 	return snapshotConsistency(snapshot1, snapshot2, treeSize)
 }
 
+// InclusionProofNodes returns the IDs of the nodes whose hashes make up the
+// RFC 6962 inclusion proof of the leaf at index in a tree of treeSize leaves,
+// in proof order, i.e. from the leaf towards the root.
+//
+// The IDs are in the coordinates of the nodes of a log of treeSize leaves,
+// whose levels are populated from the bottom up: the node (level, index)
+// covers the leaves [index<<level, (index+1)<<level) which are in the tree,
+// and nodes at the right border of the tree which would only have a left
+// child are skipped (see the diagrams in the tests). Each of the returned
+// nodes has the RFC 6962 hash of the leaves it covers, so the proof consists
+// of their hashes, as stored for the tree at treeSize.
+//
+// The log server fetches these nodes for proofs at the tree size the nodes are
+// read at. For older tree sizes, it uses CalcInclusionProofNodeAddresses,
+// which adds the nodes needed to recompute the ones on the right border of the
+// tree as they were at that size.
+func InclusionProofNodes(index, treeSize int64) ([]compact.NodeID, error) {
+	fetches, err := CalcInclusionProofNodeAddresses(treeSize, index, treeSize)
+	if err != nil {
+		return nil, err
+	}
+	return fetchIDs(fetches), nil
+}
+
+// ConsistencyProofNodes returns the IDs of the nodes whose hashes make up the
+// RFC 6962 consistency proof between the trees of size1 and size2 leaves, in
+// proof order. The IDs are in the coordinates of the tree of size2 leaves, see
+// InclusionProofNodes.
+func ConsistencyProofNodes(size1, size2 int64) ([]compact.NodeID, error) {
+	fetches, err := CalcConsistencyProofNodeAddresses(size1, size2, size2)
+	if err != nil {
+		return nil, err
+	}
+	return fetchIDs(fetches), nil
+}
+
+// fetchIDs returns the IDs of fetches, none of which need rehashing for proofs
+// at the tree size the nodes are read at.
+func fetchIDs(fetches []NodeFetch) []compact.NodeID {
+	ids := make([]compact.NodeID, len(fetches))
+	for i, f := range fetches {
+		ids[i] = f.ID
+	}
+	return ids
+}
+
 // snapshotConsistency does the calculation of consistency proof node addresses between
 // two snapshots. Based on the C++ code used by CT but adjusted to fit our situation.
 func snapshotConsistency(snapshot1, snapshot2, treeSize int64) ([]NodeFetch, error) {
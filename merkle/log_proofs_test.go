@@ -15,10 +15,14 @@
 package merkle
 
 import (
+	"bytes"
 	"fmt"
+	"math/bits"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/merkle/rfc6962"
 )
 
 type auditPathTestData struct {
@@ -284,6 +288,173 @@ func TestCalcConsistencyProofNodeAddressesBadInputs(t *testing.T) {
 	}
 }
 
+// rfc6962Nodes are the nodes of the example tree of 7 leaves in RFC 6962,
+// section 2.1.3, named as in the RFC.
+var rfc6962Nodes = map[string]compact.NodeID{
+	"b": compact.NewNodeID(0, 1),
+	"c": compact.NewNodeID(0, 2),
+	"d": compact.NewNodeID(0, 3),
+	"f": compact.NewNodeID(0, 5),
+	"j": compact.NewNodeID(0, 6), // d6, whose parent has no right child.
+	"g": compact.NewNodeID(1, 0),
+	"h": compact.NewNodeID(1, 1),
+	"i": compact.NewNodeID(1, 2),
+	"k": compact.NewNodeID(2, 0),
+	"l": compact.NewNodeID(2, 1),
+}
+
+// rfc6962IDs returns the IDs of the named nodes of the RFC 6962 example tree.
+func rfc6962IDs(names ...string) []compact.NodeID {
+	ids := make([]compact.NodeID, len(names))
+	for i, name := range names {
+		ids[i] = rfc6962Nodes[name]
+	}
+	return ids
+}
+
+func TestInclusionProofNodes(t *testing.T) {
+	// The audit paths of the example tree in RFC 6962, section 2.1.3.
+	for _, test := range []struct {
+		index int64
+		want  []compact.NodeID
+	}{
+		{index: 0, want: rfc6962IDs("b", "h", "l")},
+		{index: 3, want: rfc6962IDs("c", "g", "l")},
+		{index: 4, want: rfc6962IDs("f", "j", "k")},
+		{index: 6, want: rfc6962IDs("i", "k")},
+	} {
+		ids, err := InclusionProofNodes(test.index, 7)
+		if err != nil {
+			t.Fatalf("InclusionProofNodes(%d, 7): %v", test.index, err)
+		}
+		if diff := cmp.Diff(ids, test.want); diff != "" {
+			t.Errorf("InclusionProofNodes(%d, 7) diff (-got +want):\n%v", test.index, diff)
+		}
+	}
+	for _, test := range pathTestBad {
+		if _, err := InclusionProofNodes(test.leafIndex, test.treeSize); err == nil {
+			t.Errorf("InclusionProofNodes(%d, %d) accepted bad params", test.leafIndex, test.treeSize)
+		}
+	}
+
+	// The hashes of the nodes are the RFC 6962 reference proofs.
+	for _, p := range inclusionProofs[1:] {
+		ids, err := InclusionProofNodes(p.leaf-1, p.snapshot)
+		if err != nil {
+			t.Fatalf("InclusionProofNodes(%d, %d): %v", p.leaf-1, p.snapshot, err)
+		}
+		if got := nodeHashes(leaves[:p.snapshot], ids); !equalProofs(got, p.proof) {
+			t.Errorf("InclusionProofNodes(%d, %d) = %v with hashes %x, want %x", p.leaf-1, p.snapshot, ids, got, p.proof)
+		}
+	}
+}
+
+func TestConsistencyProofNodes(t *testing.T) {
+	// The consistency proofs of the example tree in RFC 6962, section
+	// 2.1.4.1.
+	for _, test := range []struct {
+		size1 int64
+		want  []compact.NodeID
+	}{
+		{size1: 3, want: rfc6962IDs("c", "d", "g", "l")},
+		{size1: 4, want: rfc6962IDs("l")},
+		{size1: 6, want: rfc6962IDs("i", "j", "k")},
+		{size1: 7, want: []compact.NodeID{}},
+	} {
+		ids, err := ConsistencyProofNodes(test.size1, 7)
+		if err != nil {
+			t.Fatalf("ConsistencyProofNodes(%d, 7): %v", test.size1, err)
+		}
+		if diff := cmp.Diff(ids, test.want); diff != "" {
+			t.Errorf("ConsistencyProofNodes(%d, 7) diff (-got +want):\n%v", test.size1, diff)
+		}
+	}
+	for _, test := range consistencyTestsBad {
+		if _, err := ConsistencyProofNodes(test.priorTreeSize, test.treeSize); err == nil {
+			t.Errorf("ConsistencyProofNodes(%d, %d) accepted bad params", test.priorTreeSize, test.treeSize)
+		}
+	}
+
+	// The hashes of the nodes are the RFC 6962 reference proofs.
+	for _, p := range consistencyProofs {
+		ids, err := ConsistencyProofNodes(p.snapshot1, p.snapshot2)
+		if err != nil {
+			t.Fatalf("ConsistencyProofNodes(%d, %d): %v", p.snapshot1, p.snapshot2, err)
+		}
+		if got := nodeHashes(leaves[:p.snapshot2], ids); !equalProofs(got, p.proof) {
+			t.Errorf("ConsistencyProofNodes(%d, %d) = %v with hashes %x, want %x", p.snapshot1, p.snapshot2, ids, got, p.proof)
+		}
+	}
+}
+
+func TestProofNodesGenerated(t *testing.T) {
+	const size = 70
+	tree := NewInMemoryMerkleTree(rfc6962.DefaultHasher)
+	data := make([][]byte, 0, size)
+	for i := 0; i < size; i++ {
+		data = append(data, []byte(fmt.Sprintf("data:%d", i)))
+		tree.AddLeaf(data[i])
+	}
+	for size1 := int64(1); size1 <= size; size1++ {
+		for index := int64(0); index < size1; index++ {
+			ids, err := InclusionProofNodes(index, size1)
+			if err != nil {
+				t.Fatalf("InclusionProofNodes(%d, %d): %v", index, size1, err)
+			}
+			want := rawProof(tree.PathToRootAtSnapshot(index+1, size1))
+			if got := nodeHashes(data[:size1], ids); !equalProofs(got, want) {
+				t.Errorf("InclusionProofNodes(%d, %d) = %v: node hashes don't match the proof", index, size1, ids)
+			}
+		}
+		for size2 := size1; size2 <= size; size2++ {
+			ids, err := ConsistencyProofNodes(size1, size2)
+			if err != nil {
+				t.Fatalf("ConsistencyProofNodes(%d, %d): %v", size1, size2, err)
+			}
+			want := rawProof(tree.SnapshotConsistency(size1, size2))
+			if got := nodeHashes(data[:size2], ids); !equalProofs(got, want) {
+				t.Errorf("ConsistencyProofNodes(%d, %d) = %v: node hashes don't match the proof", size1, size2, ids)
+			}
+		}
+	}
+}
+
+// nodeHashes returns the RFC 6962 hashes of the nodes ids in the tree of the
+// given leaves, each of which covers the leaves under it which are in the
+// tree.
+func nodeHashes(leaves [][]byte, ids []compact.NodeID) [][]byte {
+	hashes := make([][]byte, len(ids))
+	for i, id := range ids {
+		begin, end := id.Index<<id.Level, (id.Index+1)<<id.Level
+		if size := uint64(len(leaves)); end > size {
+			end = size
+		}
+		hashes[i] = treeHash(leaves[begin:end])
+	}
+	return hashes
+}
+
+// treeHash returns the RFC 6962 Merkle Tree Hash of the given non-empty leaves.
+func treeHash(leaves [][]byte) []byte {
+	if len(leaves) == 1 {
+		return rfc6962.DefaultHasher.HashLeaf(leaves[0])
+	}
+	k := 1 << (bits.Len(uint(len(leaves)-1)) - 1)
+	return rfc6962.DefaultHasher.HashChildren(treeHash(leaves[:k]), treeHash(leaves[k:]))
+}
+
+func equalProofs(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func comparePaths(t *testing.T, desc string, got, expected []NodeFetch) {
 	if len(expected) != len(got) {
 		t.Fatalf("%s: expected %d nodes in path but got %d: %v", desc, len(expected), len(got), got)
@@ -0,0 +1,337 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tiles defines a layout of static files holding the Merkle tree of a
+// log, and a Prover which serves inclusion and consistency proofs from them
+// without access to Trillian, e.g. from a CDN-backed store.
+//
+// The layout is that of the hash tiles of golang.org/x/mod/sumdb/tlog. A tree
+// exported at size N with tiles of height H consists of:
+//
+//   - checkpoint: the serialized trillian.SignedLogRoot of size N.
+//   - height: H, in decimal, so that all the tiles of a store have one height.
+//   - tile/H/L/NNN: the hashes of the 2^H perfect subtrees of height L*H
+//     starting at the subtree 2^H*NNN, concatenated from left to right. NNN is
+//     written in groups of three decimal digits, all but the last one prefixed
+//     with "x", e.g. x001/x234/067 for tile 1234067.
+//   - tile/H/L/NNN.p/W: the first W < 2^H of those hashes, if the tree of size
+//     N doesn't have all of them.
+//
+// A tile's contents never change once written, as the tree is append-only, so
+// exporting a larger tree only adds tiles, and tiles can be cached forever.
+// The hashes of all the perfect subtrees of the tree, and hence the proofs for
+// all sizes up to N, can be computed from the tiles of the tree of size N.
+package tiles
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/merkle/hashers"
+)
+
+const (
+	// DefaultHeight is the suggested default tile height, which matches the
+	// height of the subtrees Trillian stores.
+	DefaultHeight = 8
+	// MaxHeight is the maximum supported tile height.
+	MaxHeight = 30
+
+	// CheckpointPath is the path of the signed root of an exported tree.
+	CheckpointPath = "checkpoint"
+	// HeightPath is the path of the height of the tiles of an exported tree.
+	HeightPath = "height"
+)
+
+// Path returns the path of the tile of the given height at the given level
+// and index, holding width hashes.
+func Path(height, level uint, index uint64, width int) string {
+	n := fmt.Sprintf("%03d", index%1000)
+	for index >= 1000 {
+		index /= 1000
+		n = fmt.Sprintf("x%03d/%s", index%1000, n)
+	}
+	p := fmt.Sprintf("tile/%d/%d/%s", height, level, n)
+	if width < 1<<height {
+		p = fmt.Sprintf("%s.p/%d", p, width)
+	}
+	return p
+}
+
+// Tile identifies a tile of a tree, and the number of hashes it holds.
+type Tile struct {
+	Level uint
+	Index uint64
+	Width int
+}
+
+// Tiles returns the tiles of the given height of a tree of size leaves, level
+// by level, from left to right.
+func Tiles(height uint, size uint64) []Tile {
+	var tiles []Tile
+	for level := uint(0); ; level++ {
+		if level*height >= 64 {
+			break
+		}
+		n := size >> (level * height)
+		if n == 0 {
+			break
+		}
+		for index := uint64(0); index<<height < n; index++ {
+			width := uint64(1) << height
+			if rest := n - index<<height; rest < width {
+				width = rest
+			}
+			tiles = append(tiles, Tile{Level: level, Index: index, Width: int(width)})
+		}
+	}
+	return tiles
+}
+
+// Reader reads exported files.
+type Reader interface {
+	// Read returns the contents of the file at path. The error for a missing
+	// file satisfies os.IsNotExist.
+	Read(ctx context.Context, path string) ([]byte, error)
+}
+
+// Store reads and writes exported files.
+type Store interface {
+	Reader
+	// Exists returns whether there is a file at path.
+	Exists(ctx context.Context, path string) (bool, error)
+	// Write atomically creates or replaces the file at path.
+	Write(ctx context.Context, path string, data []byte) error
+}
+
+// Dir is a Store in a local directory.
+type Dir string
+
+// Read implements Reader.
+func (d Dir) Read(ctx context.Context, path string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(string(d), filepath.FromSlash(path)))
+}
+
+// Exists implements Store.
+func (d Dir) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := os.Stat(filepath.Join(string(d), filepath.FromSlash(path)))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Write implements Store. It writes a temporary file which it renames to
+// path, so that readers never see a partial file.
+func (d Dir) Write(ctx context.Context, path string, data []byte) error {
+	name := filepath.Join(string(d), filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// Prover computes proofs for a tree exported at Size, from its tiles of the
+// given Height. It reads the tiles it needs for each proof, and doesn't cache
+// them.
+type Prover struct {
+	Hasher hashers.LogHasher
+	Height uint
+	// Size is the size of the exported tree, as given by its checkpoint.
+	Size   uint64
+	Reader Reader
+}
+
+// InclusionProof returns the inclusion proof of the leaf at index in the tree
+// of the given size, which must not be greater than Size.
+func (p *Prover) InclusionProof(ctx context.Context, index, size int64) ([][]byte, error) {
+	if err := p.checkSize(size); err != nil {
+		return nil, err
+	}
+	ids, err := merkle.InclusionProofNodes(index, size)
+	if err != nil {
+		return nil, err
+	}
+	return p.proof(ctx, ids, uint64(size))
+}
+
+// ConsistencyProof returns the consistency proof between the trees of size1
+// and size2, which must not be greater than Size.
+func (p *Prover) ConsistencyProof(ctx context.Context, size1, size2 int64) ([][]byte, error) {
+	if err := p.checkSize(size2); err != nil {
+		return nil, err
+	}
+	if size1 < 0 || size1 > size2 {
+		return nil, fmt.Errorf("invalid size1 %d for size2 %d", size1, size2)
+	}
+	if size1 == 0 || size1 == size2 {
+		// There's nothing to prove.
+		return nil, nil
+	}
+	ids, err := merkle.ConsistencyProofNodes(size1, size2)
+	if err != nil {
+		return nil, err
+	}
+	return p.proof(ctx, ids, uint64(size2))
+}
+
+// RootHash returns the root hash of the tree of the given size, which must not
+// be greater than Size.
+func (p *Prover) RootHash(ctx context.Context, size int64) ([]byte, error) {
+	if err := p.checkSize(size); err != nil {
+		return nil, err
+	}
+	if size == 0 {
+		return p.Hasher.EmptyRoot(), nil
+	}
+	return p.rangeHash(ctx, make(tileCache), 0, uint64(size))
+}
+
+func (p *Prover) checkSize(size int64) error {
+	if size < 0 || uint64(size) > p.Size {
+		return fmt.Errorf("tree size %d outside of the exported [0, %d]", size, p.Size)
+	}
+	return nil
+}
+
+// tileCache holds the tiles read for a single proof.
+type tileCache map[Tile][][]byte
+
+// proof returns the hashes of the given nodes of the tree of size leaves,
+// where nodes on its right border cover only the leaves which exist.
+func (p *Prover) proof(ctx context.Context, ids []compact.NodeID, size uint64) ([][]byte, error) {
+	cache := make(tileCache)
+	proof := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		begin := id.Index << id.Level
+		end := (id.Index + 1) << id.Level
+		if end > size {
+			end = size
+		}
+		hash, err := p.rangeHash(ctx, cache, begin, end)
+		if err != nil {
+			return nil, err
+		}
+		proof = append(proof, hash)
+	}
+	return proof, nil
+}
+
+// rangeHash returns the RFC 6962 hash of the leaves [begin, end), where begin
+// is the start of a perfect subtree containing the range.
+func (p *Prover) rangeHash(ctx context.Context, cache tileCache, begin, end uint64) ([]byte, error) {
+	ids := compact.RangeNodes(begin, end)
+	hashes := make([][]byte, len(ids))
+	for i, id := range ids {
+		hash, err := p.nodeHash(ctx, cache, id)
+		if err != nil {
+			return nil, err
+		}
+		hashes[i] = hash
+	}
+	// The subtrees are of decreasing sizes, so the hash of the range is that
+	// of their right-to-left fold, as for the right border of a tree.
+	hash := hashes[len(hashes)-1]
+	for i := len(hashes) - 2; i >= 0; i-- {
+		hash = p.Hasher.HashChildren(hashes[i], hash)
+	}
+	return hash, nil
+}
+
+// nodeHash returns the hash of a perfect subtree, computed from the hashes of
+// its subtrees in the tile row below it.
+func (p *Prover) nodeHash(ctx context.Context, cache tileCache, id compact.NodeID) ([]byte, error) {
+	level := id.Level / p.Height
+	rel := id.Level % p.Height
+	first := id.Index << rel
+	index := first >> p.Height
+	hashes, err := p.tile(ctx, cache, level, index)
+	if err != nil {
+		return nil, err
+	}
+	offset := first - index<<p.Height
+	count := uint64(1) << rel
+	if offset+count > uint64(len(hashes)) {
+		return nil, fmt.Errorf("node %+v is not in the tree exported at size %d", id, p.Size)
+	}
+	hashes = hashes[offset : offset+count]
+	for len(hashes) > 1 {
+		next := make([][]byte, len(hashes)/2)
+		for i := range next {
+			next[i] = p.Hasher.HashChildren(hashes[2*i], hashes[2*i+1])
+		}
+		hashes = next
+	}
+	return hashes[0], nil
+}
+
+// tile returns the hashes of the tile at level and index of the tree of Size.
+func (p *Prover) tile(ctx context.Context, cache tileCache, level uint, index uint64) ([][]byte, error) {
+	n := p.Size >> (level * p.Height)
+	if index<<p.Height >= n {
+		return nil, fmt.Errorf("tile %d/%d is not in the tree exported at size %d", level, index, p.Size)
+	}
+	width := uint64(1) << p.Height
+	if rest := n - index<<p.Height; rest < width {
+		width = rest
+	}
+	t := Tile{Level: level, Index: index, Width: int(width)}
+	if hashes, ok := cache[t]; ok {
+		return hashes, nil
+	}
+	path := Path(p.Height, level, index, t.Width)
+	data, err := p.Reader.Read(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := SplitHashes(data, p.Hasher.Size(), t.Width)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	cache[t] = hashes
+	return hashes, nil
+}
+
+// SplitHashes splits the contents of a tile into its width hashes of size
+// bytes each.
+func SplitHashes(data []byte, size, width int) ([][]byte, error) {
+	if len(data) != size*width {
+		return nil, fmt.Errorf("got %d bytes, want %d hashes of %d bytes", len(data), width, size)
+	}
+	hashes := make([][]byte, width)
+	for i := range hashes {
+		hashes[i] = data[i*size : (i+1)*size]
+	}
+	return hashes, nil
+}
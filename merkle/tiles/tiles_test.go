@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tiles
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	for _, test := range []struct {
+		height, level uint
+		index         uint64
+		width         int
+		want          string
+	}{
+		{height: 8, level: 0, index: 0, width: 256, want: "tile/8/0/000"},
+		{height: 8, level: 1, index: 999, width: 256, want: "tile/8/1/999"},
+		{height: 8, level: 0, index: 1000, width: 256, want: "tile/8/0/x001/000"},
+		{height: 8, level: 0, index: 1234067, width: 256, want: "tile/8/0/x001/x234/067"},
+		{height: 8, level: 2, index: 5, width: 3, want: "tile/8/2/005.p/3"},
+		{height: 2, level: 0, index: 1, width: 4, want: "tile/2/0/001"},
+	} {
+		if got := Path(test.height, test.level, test.index, test.width); got != test.want {
+			t.Errorf("Path(%d, %d, %d, %d) = %q, want %q", test.height, test.level, test.index, test.width, got, test.want)
+		}
+	}
+}
+
+func TestTiles(t *testing.T) {
+	for _, test := range []struct {
+		height uint
+		size   uint64
+		want   []Tile
+	}{
+		{height: 2, size: 0},
+		{height: 2, size: 3, want: []Tile{{0, 0, 3}}},
+		{height: 2, size: 4, want: []Tile{{0, 0, 4}, {1, 0, 1}}},
+		{height: 2, size: 21, want: []Tile{
+			{0, 0, 4}, {0, 1, 4}, {0, 2, 4}, {0, 3, 4}, {0, 4, 4}, {0, 5, 1},
+			{1, 0, 4}, {1, 1, 1},
+			{2, 0, 1},
+		}},
+		{height: 8, size: 1 << 16, want: func() []Tile {
+			var tiles []Tile
+			for i := uint64(0); i < 256; i++ {
+				tiles = append(tiles, Tile{0, i, 256})
+			}
+			return append(tiles, Tile{1, 0, 256}, Tile{2, 0, 1})
+		}()},
+	} {
+		if got := Tiles(test.height, test.size); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Tiles(%d, %d) = %v, want %v", test.height, test.size, got, test.want)
+		}
+	}
+}
+
+func TestSplitHashes(t *testing.T) {
+	got, err := SplitHashes([]byte("aabbcc"), 2, 3)
+	if err != nil {
+		t.Fatalf("SplitHashes(): %v", err)
+	}
+	if want := [][]byte{[]byte("aa"), []byte("bb"), []byte("cc")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitHashes() = %q, want %q", got, want)
+	}
+	if _, err := SplitHashes([]byte("aabbc"), 2, 3); err == nil {
+		t.Error("SplitHashes(truncated) returned err = nil, want non-nil")
+	}
+}
+
+func TestDir(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "tiles")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	d := Dir(dir)
+
+	path := Path(8, 0, 1234067, 3)
+	if ok, err := d.Exists(ctx, path); err != nil || ok {
+		t.Errorf("Exists() before Write = (%v, %v), want (false, nil)", ok, err)
+	}
+	if _, err := d.Read(ctx, path); !os.IsNotExist(err) {
+		t.Errorf("Read() before Write returned err = %v, want not exist", err)
+	}
+	for _, data := range []string{"old", "new"} {
+		if err := d.Write(ctx, path, []byte(data)); err != nil {
+			t.Fatalf("Write(): %v", err)
+		}
+		if got, err := d.Read(ctx, path); err != nil || string(got) != data {
+			t.Errorf("Read() = (%q, %v), want (%q, nil)", got, err, data)
+		}
+	}
+	if ok, err := d.Exists(ctx, path); err != nil || !ok {
+		t.Errorf("Exists() after Write = (%v, %v), want (true, nil)", ok, err)
+	}
+}
@@ -97,6 +97,18 @@ func (m *InertFloat) Set(val float64, labelVals ...string) {
 	m.vals[key] = val
 }
 
+// Delete implements GaugeDeleter.
+func (m *InertFloat) Delete(labelVals ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, err := keyForLabels(labelVals, m.labelCount)
+	if err != nil {
+		glog.Error(err.Error())
+		return
+	}
+	delete(m.vals, key)
+}
+
 // Value returns the current value.
 func (m *InertFloat) Value(labelVals ...string) float64 {
 	m.mu.Lock()
@@ -40,6 +40,14 @@ type Gauge interface {
 	Value(labelVals ...string) float64
 }
 
+// GaugeDeleter is optionally implemented by Gauges which can stop exporting
+// the value for a set of labels, e.g. those of a deleted tree.
+type GaugeDeleter interface {
+	// Delete removes the value for labelVals, or resets it to zero if the
+	// Gauge has no labels.
+	Delete(labelVals ...string)
+}
+
 // Histogram is a metric class that tracks the distribution of a collection
 // of observations.
 type Histogram interface {
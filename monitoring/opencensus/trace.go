@@ -25,6 +25,8 @@ import (
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/trace"
 	"google.golang.org/grpc"
+
+	"github.com/google/trillian/util/requestid"
 )
 
 // This is the same set of views that used to be the default before that
@@ -104,10 +106,14 @@ func applyConfig(percent int) error {
 	return nil
 }
 
-// StartSpan starts a new tracing span.
+// StartSpan starts a new tracing span, with the ID of the request in ctx as
+// its "request_id" attribute, if there is one.
 // The returned context should be used for all child calls within the span, and
 // the returned func should be called to close the span.
 func StartSpan(ctx context.Context, name string) (context.Context, func()) {
 	ctx, span := trace.StartSpan(ctx, name)
+	if id := requestid.FromContext(ctx); id != "" {
+		span.AddAttributes(trace.StringAttribute("request_id", id))
+	}
 	return ctx, span.End
 }
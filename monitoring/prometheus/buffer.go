@@ -0,0 +1,146 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// numShards is the number of independently locked parts of a buffer, to
+	// reduce contention between concurrent observations.
+	numShards = 16
+	// maxBufferedObservations is the number of histogram observations buffered
+	// per label set before they are flushed by the observing goroutine.
+	maxBufferedObservations = 1024
+)
+
+// pending holds the observations buffered for a single label set.
+type pending struct {
+	labelVals []string
+	// sum is the total added to a counter.
+	sum float64
+	// observations are the values observed by a histogram.
+	observations []float64
+}
+
+type shard struct {
+	mu      sync.Mutex
+	pending map[string]*pending
+}
+
+// buffer accumulates observations of a labelled metric, keyed by their label
+// values, and applies them to the underlying Prometheus metric on flush. This
+// keeps the label lookups of the Prometheus client off the observing
+// goroutine.
+type buffer struct {
+	shards [numShards]shard
+	// apply applies the pending observations to the underlying metric.
+	apply func(*pending)
+}
+
+// newBuffer returns a buffer that is flushed every interval, and whenever the
+// collector c is collected.
+func newBuffer(c prometheus.Collector, interval time.Duration, apply func(*pending)) (*buffer, prometheus.Collector) {
+	b := &buffer{apply: apply}
+	for i := range b.shards {
+		b.shards[i].pending = make(map[string]*pending)
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			b.flush()
+		}
+	}()
+	return b, &flushingCollector{Collector: c, flush: b.flush}
+}
+
+// get returns the pending observations for labelVals, with the shard holding
+// them locked.
+func (b *buffer) get(labelVals []string) (*shard, *pending) {
+	key := strings.Join(labelVals, "\xff")
+	s := &b.shards[fnv32a(key)%numShards]
+	s.mu.Lock()
+	p, ok := s.pending[key]
+	if !ok {
+		p = &pending{labelVals: append([]string(nil), labelVals...)}
+		s.pending[key] = p
+	}
+	return s, p
+}
+
+// add buffers the addition of val to a counter.
+func (b *buffer) add(val float64, labelVals []string) {
+	s, p := b.get(labelVals)
+	p.sum += val
+	s.mu.Unlock()
+}
+
+// observe buffers the observation of val by a histogram.
+func (b *buffer) observe(val float64, labelVals []string) {
+	s, p := b.get(labelVals)
+	p.observations = append(p.observations, val)
+	var full *pending
+	if len(p.observations) >= maxBufferedObservations {
+		full = &pending{labelVals: p.labelVals, observations: p.observations}
+		p.observations = nil
+	}
+	s.mu.Unlock()
+	if full != nil {
+		b.apply(full)
+	}
+}
+
+// flush applies all buffered observations to the underlying metric.
+func (b *buffer) flush() {
+	for i := range b.shards {
+		s := &b.shards[i]
+		s.mu.Lock()
+		flushed := s.pending
+		s.pending = make(map[string]*pending, len(flushed))
+		s.mu.Unlock()
+		for _, p := range flushed {
+			b.apply(p)
+		}
+	}
+}
+
+// flushingCollector flushes a buffer before its metrics are collected, so
+// scrapes observe all observations made before them.
+type flushingCollector struct {
+	prometheus.Collector
+	flush func()
+}
+
+// Collect implements prometheus.Collector.
+func (c *flushingCollector) Collect(ch chan<- prometheus.Metric) {
+	c.flush()
+	c.Collector.Collect(ch)
+}
+
+// fnv32a returns the 32-bit FNV-1a hash of s, without allocating.
+func fnv32a(s string) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619
+	}
+	return h
+}
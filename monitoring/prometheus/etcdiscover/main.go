@@ -34,17 +34,20 @@ import (
 	etcdnaming "github.com/coreos/etcd/clientv3/naming"
 	"github.com/golang/glog"
 	"github.com/google/trillian/util"
+	etcdutil "github.com/google/trillian/util/etcd"
 	"google.golang.org/grpc/naming"
 )
 
 var (
 	etcdServers  = flag.String("etcd_servers", "", "Comma-separated list of etcd servers")
 	etcdServices = flag.String("etcd_services", "", "Comma-separated list of service names to monitor for endpoints")
+	etcdPrefix   = flag.String("etcd_prefix", "", "Prefix of the etcd keys of service announcements, as passed to the announcing servers")
 	targetFile   = flag.String("target", "", "File to update with service endpoint locations")
 )
 
 type serviceInstanceInfo struct {
 	servers  []string
+	prefix   string
 	services []string
 	target   string
 
@@ -53,9 +56,10 @@ type serviceInstanceInfo struct {
 	instances map[string]map[string]bool
 }
 
-func newServiceInstanceInfo(etcdServers, etcdServices, target string) *serviceInstanceInfo {
+func newServiceInstanceInfo(etcdServers, etcdPrefix, etcdServices, target string) *serviceInstanceInfo {
 	s := serviceInstanceInfo{
 		servers:   strings.Split(etcdServers, ","),
+		prefix:    etcdPrefix,
 		services:  strings.Split(etcdServices, ","),
 		watcher:   make(map[string]naming.Watcher), // nolint: megacheck
 		target:    target,
@@ -156,7 +160,7 @@ func (s *serviceInstanceInfo) watchService(service string) {
 	if err != nil {
 		glog.Exitf("Failed to connect to etcd at %v: %v", s.servers, err)
 	}
-	res := &etcdnaming.GRPCResolver{Client: client}
+	res := &etcdnaming.GRPCResolver{Client: etcdutil.WithPrefix(client, s.prefix)}
 	watcher, err := res.Resolve(service)
 	if err != nil {
 		glog.Exitf("Failed to watch %s for updates: %v", service, err)
@@ -202,7 +206,7 @@ func main() {
 		glog.Exitf("No etcd services configured with --etcd_services")
 	}
 
-	state := newServiceInstanceInfo(*etcdServers, *etcdServices, *targetFile)
+	state := newServiceInstanceInfo(*etcdServers, *etcdPrefix, *etcdServices, *targetFile)
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	go util.AwaitSignal(ctx, func() {
@@ -18,6 +18,7 @@ package prometheus
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/google/trillian/monitoring"
@@ -28,6 +29,15 @@ import (
 // MetricFactory allows the creation of Prometheus-based metrics.
 type MetricFactory struct {
 	Prefix string
+
+	// FlushInterval, if positive, makes labelled counters and histograms
+	// buffer observations in memory, and apply them to the underlying
+	// Prometheus metrics at least this often. This reduces the cost of
+	// observations on hot paths, at the expense of their being delayed by up
+	// to FlushInterval. Buffers are also flushed whenever the metrics are
+	// collected or read via Value or Info, so scrapes and reads are exact.
+	// Metrics without labels and gauges are never buffered.
+	FlushInterval time.Duration
 }
 
 // NewCounter creates a new Counter object backed by Prometheus.
@@ -48,8 +58,19 @@ func (pmf MetricFactory) NewCounter(name, help string, labelNames ...string) mon
 			Help: help,
 		},
 		labelNames)
-	prometheus.MustRegister(vec)
-	return &Counter{labelNames: labelNames, vec: vec}
+	if pmf.FlushInterval <= 0 {
+		prometheus.MustRegister(vec)
+		return &Counter{labelNames: labelNames, vec: vec}
+	}
+	buf, collector := newBuffer(vec, pmf.FlushInterval, func(p *pending) {
+		if c, err := vec.GetMetricWithLabelValues(p.labelVals...); err != nil {
+			glog.Error(err.Error())
+		} else {
+			c.Add(p.sum)
+		}
+	})
+	prometheus.MustRegister(collector)
+	return &Counter{labelNames: labelNames, vec: vec, buf: buf}
 }
 
 // NewGauge creates a new Gauge object backed by Prometheus.
@@ -104,8 +125,22 @@ func (pmf MetricFactory) newHistogram(name, help string, buckets []float64, labe
 			Buckets: buckets,
 		},
 		labelNames)
-	prometheus.MustRegister(vec)
-	return &Histogram{labelNames: labelNames, vec: vec}
+	if pmf.FlushInterval <= 0 {
+		prometheus.MustRegister(vec)
+		return &Histogram{labelNames: labelNames, vec: vec}
+	}
+	buf, collector := newBuffer(vec, pmf.FlushInterval, func(p *pending) {
+		h, err := vec.GetMetricWithLabelValues(p.labelVals...)
+		if err != nil {
+			glog.Error(err.Error())
+			return
+		}
+		for _, val := range p.observations {
+			h.Observe(val)
+		}
+	})
+	prometheus.MustRegister(collector)
+	return &Histogram{labelNames: labelNames, vec: vec, buf: buf}
 }
 
 // Counter is a wrapper around a Prometheus Counter or CounterVec object.
@@ -113,10 +148,16 @@ type Counter struct {
 	labelNames []string
 	single     prometheus.Counter
 	vec        *prometheus.CounterVec
+	// buf, if set, buffers additions to vec.
+	buf *buffer
 }
 
 // Inc adds 1 to a counter.
 func (m *Counter) Inc(labelVals ...string) {
+	if m.buf != nil {
+		m.Add(1, labelVals...)
+		return
+	}
 	labels, err := labelsFor(m.labelNames, labelVals)
 	if err != nil {
 		glog.Error(err.Error())
@@ -131,6 +172,14 @@ func (m *Counter) Inc(labelVals ...string) {
 
 // Add adds the given amount to a counter.
 func (m *Counter) Add(val float64, labelVals ...string) {
+	if m.buf != nil {
+		if err := checkLabels(m.labelNames, labelVals); err != nil {
+			glog.Error(err.Error())
+			return
+		}
+		m.buf.add(val, labelVals)
+		return
+	}
 	labels, err := labelsFor(m.labelNames, labelVals)
 	if err != nil {
 		glog.Error(err.Error())
@@ -150,6 +199,9 @@ func (m *Counter) Value(labelVals ...string) float64 {
 		glog.Error(err.Error())
 		return 0.0
 	}
+	if m.buf != nil {
+		m.buf.flush()
+	}
 	var metric prometheus.Metric
 	if m.vec != nil {
 		metric = m.vec.With(labels)
@@ -231,6 +283,20 @@ func (m *Gauge) Set(val float64, labelVals ...string) {
 	}
 }
 
+// Delete implements monitoring.GaugeDeleter.
+func (m *Gauge) Delete(labelVals ...string) {
+	labels, err := labelsFor(m.labelNames, labelVals)
+	if err != nil {
+		glog.Error(err.Error())
+		return
+	}
+	if m.vec != nil {
+		m.vec.Delete(labels)
+	} else {
+		m.single.Set(0)
+	}
+}
+
 // Value returns the current amount of a gauge.
 func (m *Gauge) Value(labelVals ...string) float64 {
 	labels, err := labelsFor(m.labelNames, labelVals)
@@ -261,10 +327,20 @@ type Histogram struct {
 	labelNames []string
 	single     prometheus.Histogram
 	vec        *prometheus.HistogramVec
+	// buf, if set, buffers observations of vec.
+	buf *buffer
 }
 
 // Observe adds a single observation to the histogram.
 func (m *Histogram) Observe(val float64, labelVals ...string) {
+	if m.buf != nil {
+		if err := checkLabels(m.labelNames, labelVals); err != nil {
+			glog.Error(err.Error())
+			return
+		}
+		m.buf.observe(val, labelVals)
+		return
+	}
 	labels, err := labelsFor(m.labelNames, labelVals)
 	if err != nil {
 		glog.Error(err.Error())
@@ -284,6 +360,9 @@ func (m *Histogram) Info(labelVals ...string) (uint64, float64) {
 		glog.Error(err.Error())
 		return 0, 0.0
 	}
+	if m.buf != nil {
+		m.buf.flush()
+	}
 	var metric prometheus.Metric
 	if m.vec != nil {
 		metric = m.vec.With(labels).(prometheus.Metric)
@@ -303,9 +382,16 @@ func (m *Histogram) Info(labelVals ...string) (uint64, float64) {
 	return histVal.GetSampleCount(), histVal.GetSampleSum()
 }
 
-func labelsFor(names, values []string) (prometheus.Labels, error) {
+func checkLabels(names, values []string) error {
 	if len(names) != len(values) {
-		return nil, fmt.Errorf("got %d (%v) values for %d labels (%v)", len(values), values, len(names), names)
+		return fmt.Errorf("got %d (%v) values for %d labels (%v)", len(values), values, len(names), names)
+	}
+	return nil
+}
+
+func labelsFor(names, values []string) (prometheus.Labels, error) {
+	if err := checkLabels(names, values); err != nil {
+		return nil, err
 	}
 	if len(names) == 0 {
 		return nil, nil
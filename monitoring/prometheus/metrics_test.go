@@ -15,9 +15,14 @@
 package prometheus
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/trillian/monitoring/testonly"
+	"github.com/prometheus/client_golang/prometheus"
+
+	dto "github.com/prometheus/client_model/go"
 )
 
 func TestCounter(t *testing.T) {
@@ -29,3 +34,76 @@ func TestGauge(t *testing.T) {
 func TestHistogram(t *testing.T) {
 	testonly.TestHistogram(t, MetricFactory{Prefix: "TestHistogram"})
 }
+
+func TestBufferedCounter(t *testing.T) {
+	testonly.TestCounter(t, MetricFactory{Prefix: "TestBufferedCounter", FlushInterval: time.Hour})
+}
+
+func TestBufferedHistogram(t *testing.T) {
+	testonly.TestHistogram(t, MetricFactory{Prefix: "TestBufferedHistogram", FlushInterval: time.Hour})
+}
+
+func TestBufferedGather(t *testing.T) {
+	mf := MetricFactory{Prefix: "TestBufferedGather", FlushInterval: time.Hour}
+	counter := mf.NewCounter("counter", "help", "label")
+	histogram := mf.NewHistogram("histogram", "help", "label")
+	counter.Add(3, "a")
+	for i := 0; i < maxBufferedObservations+2; i++ {
+		histogram.Observe(1, "a")
+	}
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatalf("Gather(): %v", err)
+	}
+	got := make(map[string]*dto.Metric)
+	for _, f := range families {
+		if strings.HasPrefix(f.GetName(), mf.Prefix) && len(f.Metric) == 1 {
+			got[f.GetName()] = f.Metric[0]
+		}
+	}
+	if got, want := got["TestBufferedGathercounter"].GetCounter().GetValue(), 3.0; got != want {
+		t.Errorf("gathered counter = %v, want %v", got, want)
+	}
+	if got, want := got["TestBufferedGatherhistogram"].GetHistogram().GetSampleCount(), uint64(maxBufferedObservations+2); got != want {
+		t.Errorf("gathered histogram count = %v, want %v", got, want)
+	}
+}
+
+func BenchmarkCounterInc(b *testing.B) {
+	for _, test := range []struct {
+		desc string
+		mf   MetricFactory
+	}{
+		{desc: "sync", mf: MetricFactory{Prefix: "BenchmarkCounterIncSync"}},
+		{desc: "buffered", mf: MetricFactory{Prefix: "BenchmarkCounterIncBuffered", FlushInterval: time.Second}},
+	} {
+		counter := test.mf.NewCounter("counter", "help", "tree_id", "method")
+		b.Run(test.desc, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					counter.Inc("1234567890", "QueueLeaves")
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkHistogramObserve(b *testing.B) {
+	for _, test := range []struct {
+		desc string
+		mf   MetricFactory
+	}{
+		{desc: "sync", mf: MetricFactory{Prefix: "BenchmarkHistogramObserveSync"}},
+		{desc: "buffered", mf: MetricFactory{Prefix: "BenchmarkHistogramObserveBuffered", FlushInterval: time.Second}},
+	} {
+		histogram := test.mf.NewHistogram("histogram", "help", "tree_id", "method")
+		b.Run(test.desc, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					histogram.Observe(0.01, "1234567890", "QueueLeaves")
+				}
+			})
+		})
+	}
+}
@@ -17,6 +17,7 @@ package monitoring
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -26,10 +27,21 @@ import (
 
 const traceSpanRoot = "/trillian/mon/"
 
+// OtherCaller is the caller label recorded for RPCs whose caller label is not
+// in the allowlist of the RPCStatsInterceptor.
+const OtherCaller = "other"
+
+// CallerLabelFunc returns the label identifying the caller of an RPC, such as
+// its tenant, from the request context. It may return any string; labels not
+// in the allowlist configured with it are recorded as OtherCaller.
+type CallerLabelFunc func(ctx context.Context) string
+
 // RPCStatsInterceptor provides a gRPC interceptor that records statistics about the RPCs passing through it.
 type RPCStatsInterceptor struct {
 	prefix            string
 	timeSource        clock.TimeSource
+	callerLabel       CallerLabelFunc
+	allowedCallers    map[string]bool
 	ReqCount          Counter
 	ReqSuccessCount   Counter
 	ReqSuccessLatency Histogram
@@ -40,17 +52,46 @@ type RPCStatsInterceptor struct {
 // NewRPCStatsInterceptor creates a new RPCStatsInterceptor for the given application/component, with
 // a specified time source.
 func NewRPCStatsInterceptor(timeSource clock.TimeSource, prefix string, mf MetricFactory) *RPCStatsInterceptor {
+	return newRPCStatsInterceptor(timeSource, prefix, mf, "method")
+}
+
+// NewRPCStatsInterceptorWithCallers creates a new RPCStatsInterceptor like
+// NewRPCStatsInterceptor, whose metrics are also labelled by the caller of
+// each RPC, as returned by callerLabel. To bound the cardinality of the
+// metrics, only the labels in allowed are recorded, and all others are
+// recorded as OtherCaller.
+func NewRPCStatsInterceptorWithCallers(timeSource clock.TimeSource, prefix string, mf MetricFactory, callerLabel CallerLabelFunc, allowed []string) (*RPCStatsInterceptor, error) {
+	if callerLabel == nil {
+		return nil, errors.New("nil caller label function")
+	}
+	if len(allowed) == 0 {
+		return nil, errors.New("empty caller label allowlist")
+	}
+	allowedCallers := make(map[string]bool)
+	for _, label := range allowed {
+		if label == "" || label == OtherCaller {
+			return nil, fmt.Errorf("invalid caller label in allowlist: %q", label)
+		}
+		allowedCallers[label] = true
+	}
+	interceptor := newRPCStatsInterceptor(timeSource, prefix, mf, "method", "caller")
+	interceptor.callerLabel = callerLabel
+	interceptor.allowedCallers = allowedCallers
+	return interceptor, nil
+}
+
+func newRPCStatsInterceptor(timeSource clock.TimeSource, prefix string, mf MetricFactory, labelNames ...string) *RPCStatsInterceptor {
 	if mf == nil {
 		mf = InertMetricFactory{}
 	}
 	interceptor := RPCStatsInterceptor{
 		prefix:            prefix,
 		timeSource:        timeSource,
-		ReqCount:          mf.NewCounter(prefixedName(prefix, "rpc_requests"), "Number of requests", "method"),
-		ReqSuccessCount:   mf.NewCounter(prefixedName(prefix, "rpc_success"), "Number of successful requests", "method"),
-		ReqSuccessLatency: mf.NewHistogram(prefixedName(prefix, "rpc_success_latency"), "Latency of successful requests in seconds", "method"),
-		ReqErrorCount:     mf.NewCounter(prefixedName(prefix, "rpc_errors"), "Number of errored requests", "method"),
-		ReqErrorLatency:   mf.NewHistogram(prefixedName(prefix, "rpc_error_latency"), "Latency of errored requests in seconds", "method"),
+		ReqCount:          mf.NewCounter(prefixedName(prefix, "rpc_requests"), "Number of requests", labelNames...),
+		ReqSuccessCount:   mf.NewCounter(prefixedName(prefix, "rpc_success"), "Number of successful requests", labelNames...),
+		ReqSuccessLatency: mf.NewHistogram(prefixedName(prefix, "rpc_success_latency"), "Latency of successful requests in seconds", labelNames...),
+		ReqErrorCount:     mf.NewCounter(prefixedName(prefix, "rpc_errors"), "Number of errored requests", labelNames...),
+		ReqErrorLatency:   mf.NewHistogram(prefixedName(prefix, "rpc_error_latency"), "Latency of errored requests in seconds", labelNames...),
 	}
 	return &interceptor
 }
@@ -59,6 +100,18 @@ func prefixedName(prefix, name string) string {
 	return fmt.Sprintf("%s_%s", prefix, name)
 }
 
+// labels returns the metric label values of an RPC.
+func (r *RPCStatsInterceptor) labels(ctx context.Context, method string) []string {
+	if r.callerLabel == nil {
+		return []string{method}
+	}
+	caller := r.callerLabel(ctx)
+	if !r.allowedCallers[caller] {
+		caller = OtherCaller
+	}
+	return []string{method, caller}
+}
+
 func (r *RPCStatsInterceptor) recordFailureLatency(labels []string, startTime time.Time) {
 	latency := clock.SecondsSince(r.timeSource, startTime)
 	r.ReqErrorCount.Inc(labels...)
@@ -69,7 +122,7 @@ func (r *RPCStatsInterceptor) recordFailureLatency(labels []string, startTime ti
 // will record request counts / errors and latencies for that servers handlers
 func (r *RPCStatsInterceptor) Interceptor() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		labels := []string{info.FullMethod}
+		labels := r.labels(ctx, info.FullMethod)
 
 		// This interceptor wraps the request handler so we should track the
 		// additional latency it imposes.
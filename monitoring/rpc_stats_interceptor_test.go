@@ -187,3 +187,64 @@ func TestCanInitializeNilMetricFactory(t *testing.T) {
 	monitoring.NewRPCStatsInterceptor(&ts, "test_nil_metric_factory", nil)
 	// Should reach here without throwing an exception
 }
+
+type callerKey struct{}
+
+func TestCallerLabels(t *testing.T) {
+	ts := clock.PredefinedFake{
+		Base:   fakeTime,
+		Delays: []time.Duration{0, time.Millisecond, 0, time.Millisecond, 0, time.Millisecond, 0, time.Millisecond},
+	}
+	callerLabel := func(ctx context.Context) string {
+		caller, _ := ctx.Value(callerKey{}).(string)
+		return caller
+	}
+	stats, err := monitoring.NewRPCStatsInterceptorWithCallers(&ts, "test_callers", monitoring.InertMetricFactory{}, callerLabel, []string{"alice", "bob"})
+	if err != nil {
+		t.Fatalf("NewRPCStatsInterceptorWithCallers(): %v", err)
+	}
+	i := stats.Interceptor()
+
+	handler := recordingUnaryHandler{rsp: "OK"}
+	for _, caller := range []string{"alice", "alice", "mallory", ""} {
+		ctx := context.WithValue(context.Background(), callerKey{}, caller)
+		if _, err := i(ctx, "wibble", &grpc.UnaryServerInfo{FullMethod: "testmethod"}, handler.handler()); err != nil {
+			t.Fatalf("interceptor()=_,%v; want _,nil", err)
+		}
+	}
+
+	for _, test := range []struct {
+		caller string
+		want   float64
+	}{
+		{caller: "alice", want: 2},
+		{caller: "bob", want: 0},
+		{caller: monitoring.OtherCaller, want: 2},
+		{caller: "mallory", want: 0},
+	} {
+		if got := stats.ReqCount.Value("testmethod", test.caller); got != test.want {
+			t.Errorf("stats.ReqCount[%q]=%v; want %v", test.caller, got, test.want)
+		}
+		if got := stats.ReqSuccessCount.Value("testmethod", test.caller); got != test.want {
+			t.Errorf("stats.ReqSuccessCount[%q]=%v; want %v", test.caller, got, test.want)
+		}
+	}
+}
+
+func TestCallerLabels_InvalidConfig(t *testing.T) {
+	callerLabel := func(context.Context) string { return "alice" }
+	for _, test := range []struct {
+		desc        string
+		callerLabel monitoring.CallerLabelFunc
+		allowed     []string
+	}{
+		{desc: "nil func", allowed: []string{"alice"}},
+		{desc: "no allowlist", callerLabel: callerLabel},
+		{desc: "empty label", callerLabel: callerLabel, allowed: []string{"alice", ""}},
+		{desc: "other label", callerLabel: callerLabel, allowed: []string{monitoring.OtherCaller}},
+	} {
+		if _, err := monitoring.NewRPCStatsInterceptorWithCallers(clock.System, "test_invalid_callers", nil, test.callerLabel, test.allowed); err == nil {
+			t.Errorf("%s: NewRPCStatsInterceptorWithCallers()=_,nil; want error", test.desc)
+		}
+	}
+}
@@ -165,6 +165,12 @@ func TestGauge(t *testing.T, factory monitoring.MetricFactory) {
 					t.Errorf("Gauge[%v].Value()=%v; want %v", test.labelVals, got, want)
 				}
 			}
+			if d, ok := gauge.(monitoring.GaugeDeleter); ok {
+				d.Delete(test.labelVals...)
+				if got, want := gauge.Value(test.labelVals...), 0.0; got != want {
+					t.Errorf("Gauge[%v].Value() after Delete()=%v; want %v", test.labelVals, got, want)
+				}
+			}
 		})
 	}
 }
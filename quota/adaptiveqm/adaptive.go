@@ -0,0 +1,416 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adaptiveqm contains a quota.Manager implementation which slows down
+// writes to trees as their backlog of unsequenced leaves grows.
+package adaptiveqm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultBacklogRefresh is the suggested default for Options.BacklogRefresh.
+const DefaultBacklogRefresh = time.Second
+
+// backlogTimeout bounds how long a read of the backlog of a tree may take.
+const backlogTimeout = 30 * time.Second
+
+var (
+	// now is used in place of time.Now to allow tests to take control of time.
+	now = time.Now
+
+	metricsOnce   sync.Once
+	rateGauge     monitoring.Gauge
+	backlogGauge  monitoring.Gauge
+	throttledReqs monitoring.Counter
+)
+
+func initMetrics(mf monitoring.MetricFactory) {
+	metricsOnce.Do(func() {
+		if mf == nil {
+			mf = monitoring.InertMetricFactory{}
+		}
+		rateGauge = mf.NewGauge("quota_adaptive_rate", "Effective rate of adaptive quota tokens per second", "spec")
+		backlogGauge = mf.NewGauge("quota_adaptive_backlog", "Last known number of unsequenced leaves of trees governed by adaptive quota", "spec")
+		throttledReqs = mf.NewCounter("quota_adaptive_throttled", "Number of token requests denied by adaptive quota", "spec")
+	})
+}
+
+// Point is a point of a Curve.
+type Point struct {
+	// Backlog is a number of unsequenced leaves.
+	Backlog int64
+	// Fraction is the fraction of the full rate available at Backlog.
+	Fraction float64
+}
+
+// Curve maps the backlog of a tree to the fraction of the full rate of tokens
+// available to it. The fraction is interpolated linearly between the points,
+// which are sorted by increasing backlog, and is that of the first or last
+// point outside of them.
+type Curve []Point
+
+// ParseCurve parses a curve given as a comma-separated list of backlog:fraction
+// points, e.g. "10000:1,100000:0.1,200000:0".
+func ParseCurve(s string) (Curve, error) {
+	if s == "" {
+		return nil, errors.New("empty curve")
+	}
+	var c Curve
+	for _, p := range strings.Split(s, ",") {
+		parts := strings.Split(p, ":")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid point %q, want backlog:fraction", p)
+		}
+		backlog, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backlog in point %q: %v", p, err)
+		}
+		fraction, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fraction in point %q: %v", p, err)
+		}
+		c = append(c, Point{Backlog: backlog, Fraction: fraction})
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c Curve) validate() error {
+	if len(c) == 0 {
+		return errors.New("empty curve")
+	}
+	for i, p := range c {
+		switch {
+		case p.Backlog < 0:
+			return fmt.Errorf("point %d: negative backlog %d", i, p.Backlog)
+		case p.Fraction < 0 || p.Fraction > 1 || math.IsNaN(p.Fraction):
+			return fmt.Errorf("point %d: fraction %v outside of [0, 1]", i, p.Fraction)
+		case i > 0 && p.Backlog <= c[i-1].Backlog:
+			return fmt.Errorf("point %d: backlog %d not greater than that of the previous point", i, p.Backlog)
+		}
+	}
+	return nil
+}
+
+// Fraction returns the fraction of the full rate available at backlog.
+func (c Curve) Fraction(backlog int64) float64 {
+	if backlog <= c[0].Backlog {
+		return c[0].Fraction
+	}
+	for i := 1; i < len(c); i++ {
+		if backlog < c[i].Backlog {
+			prev, next := c[i-1], c[i]
+			pos := float64(backlog-prev.Backlog) / float64(next.Backlog-prev.Backlog)
+			return prev.Fraction + pos*(next.Fraction-prev.Fraction)
+		}
+	}
+	return c[len(c)-1].Fraction
+}
+
+// BacklogFunc returns the number of unsequenced leaves of a tree.
+type BacklogFunc func(ctx context.Context, treeID int64) (int64, error)
+
+// StorageBacklog returns a BacklogFunc which counts the unsequenced leaves of
+// trees in ls, reading the trees from as. It fails with Unimplemented if the
+// transactions of ls aren't a storage.UnsequencedCounter.
+func StorageBacklog(as storage.AdminStorage, ls storage.LogStorage) BacklogFunc {
+	return func(ctx context.Context, treeID int64) (int64, error) {
+		tree, err := storage.GetTree(ctx, as, treeID)
+		if err != nil {
+			return 0, err
+		}
+		tx, err := ls.SnapshotForTree(ctx, tree)
+		if err != nil {
+			return 0, err
+		}
+		defer tx.Close()
+		c, ok := tx.(storage.UnsequencedCounter)
+		if !ok {
+			return 0, status.Error(codes.Unimplemented, "storage doesn't count unsequenced leaves")
+		}
+		count, err := c.CountUnsequenced(ctx, 0 /* limit */)
+		if err != nil {
+			return 0, err
+		}
+		return count, tx.Commit(ctx)
+	}
+}
+
+// Options holds the parameters of a Manager.
+type Options struct {
+	// Rate is the number of Tree/Write tokens per second available to each
+	// tree while its backlog is at the start of Curve. It applies to each
+	// Manager separately, i.e. to each server.
+	Rate float64
+	// Burst is the maximum number of tokens a tree can accumulate. Zero means
+	// one second's worth of Rate.
+	Burst int
+	// Curve scales Rate according to the backlog of a tree.
+	Curve Curve
+	// Backlog returns the backlog of a tree. It must not be nil.
+	Backlog BacklogFunc
+	// BacklogRefresh is how long the backlog of a tree is reused for before
+	// being read again, in the background. Zero means DefaultBacklogRefresh.
+	BacklogRefresh time.Duration
+}
+
+// Manager is a quota.Manager which governs the Tree/Write tokens of each tree
+// with a token bucket, refilled at a rate which decreases as the backlog of
+// the tree grows, in front of a wrapped quota.Manager. Requests are thus
+// slowed down gradually, with ResourceExhausted errors carrying increasing
+// refill delays, rather than all failing once a hard limit is reached. Other
+// specs are left to the wrapped quota.Manager.
+type Manager struct {
+	qm   quota.Manager
+	opts Options
+
+	// mu guards buckets.
+	mu      sync.Mutex
+	buckets map[int64]*bucket
+
+	// refreshes tracks the background reads of backlogs.
+	refreshes sync.WaitGroup
+}
+
+var _ quota.Manager = &Manager{}
+
+type bucket struct {
+	// mu guards the fields below. It isn't held while the backlog is read.
+	mu          sync.Mutex
+	tokens      float64
+	rate        float64
+	lastFill    time.Time
+	backlogRead time.Time
+	// refreshing is set while the backlog is read, so that requests for the
+	// same tree don't all read it.
+	refreshing bool
+}
+
+// New returns a Manager wrapping qm, or an error if opts are invalid.
+func New(qm quota.Manager, opts Options, mf monitoring.MetricFactory) (*Manager, error) {
+	switch {
+	case opts.Rate <= 0:
+		return nil, fmt.Errorf("invalid rate: %v", opts.Rate)
+	case opts.Burst < 0:
+		return nil, fmt.Errorf("invalid burst: %v", opts.Burst)
+	case opts.Backlog == nil:
+		return nil, errors.New("nil Backlog")
+	case opts.BacklogRefresh < 0:
+		return nil, fmt.Errorf("invalid backlog refresh: %v", opts.BacklogRefresh)
+	}
+	if err := opts.Curve.validate(); err != nil {
+		return nil, fmt.Errorf("invalid curve: %v", err)
+	}
+	if opts.Burst == 0 {
+		opts.Burst = int(math.Max(1, math.Ceil(opts.Rate)))
+	}
+	if opts.BacklogRefresh == 0 {
+		opts.BacklogRefresh = DefaultBacklogRefresh
+	}
+	initMetrics(mf)
+	return &Manager{qm: qm, opts: opts, buckets: make(map[int64]*bucket)}, nil
+}
+
+// governed returns whether spec is governed by m.
+func governed(spec quota.Spec) bool {
+	return spec.Group == quota.Tree && spec.Kind == quota.Write
+}
+
+func (m *Manager) bucket(treeID int64) *bucket {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.buckets[treeID]
+	if !ok {
+		b = &bucket{tokens: float64(m.opts.Burst), rate: m.opts.Rate * m.opts.Curve.Fraction(0), lastFill: now()}
+		m.buckets[treeID] = b
+	}
+	return b
+}
+
+// refill adds the tokens accumulated in b since the last refill, and starts
+// reading the backlog of the tree of spec in the background if it's stale.
+// b.mu must be held. Requests don't wait for the backlog: the rate of b is
+// updated once it has been read.
+func (m *Manager) refill(b *bucket, spec quota.Spec) {
+	t := m.fill(b)
+	if b.refreshing || (!b.backlogRead.IsZero() && t.Sub(b.backlogRead) < m.opts.BacklogRefresh) {
+		return
+	}
+	b.backlogRead = t
+	b.refreshing = true
+	m.refreshes.Add(1)
+	go m.refreshRate(b, spec)
+}
+
+// fill adds the tokens accumulated in b since the last refill, at its current
+// rate, and returns the time of the refill. b.mu must be held.
+func (m *Manager) fill(b *bucket) time.Time {
+	t := now()
+	if elapsed := t.Sub(b.lastFill).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(m.opts.Burst), b.tokens+elapsed*b.rate)
+		b.lastFill = t
+	}
+	return t
+}
+
+// refreshRate reads the backlog of the tree of spec, and updates the rate of
+// b from it. If the backlog can't be read, the last known rate is kept.
+func (m *Manager) refreshRate(b *bucket, spec quota.Spec) {
+	defer m.refreshes.Done()
+	ctx, cancel := context.WithTimeout(context.Background(), backlogTimeout)
+	defer cancel()
+	backlog, err := m.opts.Backlog(ctx, spec.TreeID)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refreshing = false
+	if err != nil {
+		glog.Warningf("%v: failed to read backlog, keeping rate %v: %v", spec.TreeID, b.rate, err)
+		return
+	}
+	// Tokens accumulated so far are added at the previous rate.
+	m.fill(b)
+	b.rate = m.opts.Rate * m.opts.Curve.Fraction(backlog)
+	backlogGauge.Set(float64(backlog), spec.Name())
+	rateGauge.Set(b.rate, spec.Name())
+}
+
+// take acquires numTokens from the bucket of spec.
+func (m *Manager) take(numTokens int, spec quota.Spec) error {
+	b := m.bucket(spec.TreeID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m.refill(b, spec)
+	if b.tokens >= float64(numTokens) {
+		b.tokens -= float64(numTokens)
+		return nil
+	}
+	throttledReqs.Inc(spec.Name())
+	// The bucket is refilled at the current rate until the backlog is read
+	// again. If the rate is zero, that's the earliest tokens may be available.
+	var refill time.Duration
+	switch {
+	case numTokens > m.opts.Burst:
+		// Never available.
+	case b.rate > 0:
+		refill = time.Duration(math.Ceil((float64(numTokens) - b.tokens) / b.rate * float64(time.Second)))
+	default:
+		refill = b.backlogRead.Add(m.opts.BacklogRefresh).Sub(now())
+	}
+	return &quota.ExhaustedError{
+		Spec:        spec.Name(),
+		Available:   int64(b.tokens),
+		Requested:   int64(numTokens),
+		RefillDelay: refill,
+	}
+}
+
+// give returns numTokens to the bucket of spec.
+func (m *Manager) give(numTokens int, spec quota.Spec) {
+	b := m.bucket(spec.TreeID)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(float64(m.opts.Burst), b.tokens+float64(numTokens))
+}
+
+// GetTokens implements quota.Manager.GetTokens. Tree/Write tokens are taken
+// from the buckets of m before those of the wrapped quota.Manager, and
+// returned to them if the latter fails.
+func (m *Manager) GetTokens(ctx context.Context, numTokens int, specs []quota.Spec) error {
+	var taken []quota.Spec
+	defer func() {
+		for _, spec := range taken {
+			m.give(numTokens, spec)
+		}
+	}()
+	for _, spec := range specs {
+		if !governed(spec) {
+			continue
+		}
+		if err := m.take(numTokens, spec); err != nil {
+			return err
+		}
+		taken = append(taken, spec)
+	}
+	if err := m.qm.GetTokens(ctx, numTokens, specs); err != nil {
+		return err
+	}
+	taken = nil
+	return nil
+}
+
+// PeekTokens implements quota.Manager.PeekTokens. The tokens of Tree/Write
+// specs are capped by those in the buckets of m.
+func (m *Manager) PeekTokens(ctx context.Context, specs []quota.Spec) (map[quota.Spec]int, error) {
+	tokens, err := m.qm.PeekTokens(ctx, specs)
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range specs {
+		if !governed(spec) {
+			continue
+		}
+		b := m.bucket(spec.TreeID)
+		b.mu.Lock()
+		m.refill(b, spec)
+		if n := int(b.tokens); n < tokens[spec] {
+			tokens[spec] = n
+		}
+		b.mu.Unlock()
+	}
+	return tokens, nil
+}
+
+// PutTokens implements quota.Manager.PutTokens. Tokens are only put in the
+// wrapped quota.Manager, as the buckets of m are refilled over time.
+func (m *Manager) PutTokens(ctx context.Context, numTokens int, specs []quota.Spec) error {
+	return m.qm.PutTokens(ctx, numTokens, specs)
+}
+
+// ResetQuota implements quota.Manager.ResetQuota.
+func (m *Manager) ResetQuota(ctx context.Context, specs []quota.Spec) error {
+	m.mu.Lock()
+	for _, spec := range specs {
+		if governed(spec) {
+			delete(m.buckets, spec.TreeID)
+		}
+	}
+	m.mu.Unlock()
+	return m.qm.ResetQuota(ctx, specs)
+}
+
+// CleanUpTree implements quota.TreeCleaner.CleanUpTree.
+func (m *Manager) CleanUpTree(ctx context.Context, treeID int64) error {
+	m.mu.Lock()
+	delete(m.buckets, treeID)
+	m.mu.Unlock()
+	return quota.CleanUpTree(ctx, m.qm, treeID)
+}
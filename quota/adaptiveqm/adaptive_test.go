@@ -0,0 +1,398 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adaptiveqm
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/types"
+)
+
+const treeID = 12345
+
+var (
+	curve     = Curve{{Backlog: 100, Fraction: 1}, {Backlog: 300, Fraction: 0.5}, {Backlog: 400, Fraction: 0}}
+	writeSpec = quota.Spec{Group: quota.Tree, Kind: quota.Write, TreeID: treeID}
+	readSpec  = quota.Spec{Group: quota.Tree, Kind: quota.Read, TreeID: treeID}
+)
+
+func TestParseCurve(t *testing.T) {
+	for _, test := range []struct {
+		s       string
+		want    Curve
+		wantErr bool
+	}{
+		{s: "100:1,300:0.5,400:0", want: curve},
+		{s: "0:0.5", want: Curve{{Backlog: 0, Fraction: 0.5}}},
+		{s: "", wantErr: true},
+		{s: "100", wantErr: true},
+		{s: "x:1", wantErr: true},
+		{s: "100:x", wantErr: true},
+		{s: "-1:1", wantErr: true},
+		{s: "100:1.5", wantErr: true},
+		{s: "100:1,100:0", wantErr: true},
+		{s: "200:1,100:0", wantErr: true},
+	} {
+		got, err := ParseCurve(test.s)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("ParseCurve(%q) returned err = %v, wantErr %v", test.s, err, test.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseCurve(%q) = %v, want %v", test.s, got, test.want)
+		}
+	}
+}
+
+func TestCurveFraction(t *testing.T) {
+	for _, test := range []struct {
+		backlog int64
+		want    float64
+	}{
+		{backlog: 0, want: 1},
+		{backlog: 100, want: 1},
+		{backlog: 200, want: 0.75},
+		{backlog: 300, want: 0.5},
+		{backlog: 350, want: 0.25},
+		{backlog: 400, want: 0},
+		{backlog: 1000, want: 0},
+	} {
+		if got := curve.Fraction(test.backlog); got != test.want {
+			t.Errorf("Fraction(%d) = %v, want %v", test.backlog, got, test.want)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	backlog := func(context.Context, int64) (int64, error) { return 0, nil }
+	for _, test := range []struct {
+		desc string
+		opts Options
+	}{
+		{desc: "noRate", opts: Options{Curve: curve, Backlog: backlog}},
+		{desc: "negativeBurst", opts: Options{Rate: 1, Burst: -1, Curve: curve, Backlog: backlog}},
+		{desc: "noCurve", opts: Options{Rate: 1, Backlog: backlog}},
+		{desc: "noBacklog", opts: Options{Rate: 1, Curve: curve}},
+		{desc: "negativeRefresh", opts: Options{Rate: 1, Curve: curve, Backlog: backlog, BacklogRefresh: -time.Second}},
+	} {
+		if _, err := New(quota.Noop(), test.opts, nil); err == nil {
+			t.Errorf("%v: New() returned err = nil, want non-nil", test.desc)
+		}
+	}
+}
+
+// fakeTime replaces now with a clock which only advances when told to.
+func fakeTime(t *testing.T) func(time.Duration) {
+	t.Helper()
+	current := time.Unix(1000, 0)
+	now = func() time.Time { return current }
+	t.Cleanup(func() { now = time.Now })
+	return func(d time.Duration) { current = current.Add(d) }
+}
+
+// drain takes all the tokens available to writeSpec, and waits for the
+// backlog read it may have started.
+func drain(ctx context.Context, t *testing.T, m *Manager) {
+	t.Helper()
+	tokens, err := m.PeekTokens(ctx, []quota.Spec{writeSpec})
+	if err != nil {
+		t.Fatalf("PeekTokens(): %v", err)
+	}
+	if n := tokens[writeSpec]; n > 0 {
+		if err := m.GetTokens(ctx, n, []quota.Spec{writeSpec}); err != nil {
+			t.Fatalf("GetTokens(%d): %v", n, err)
+		}
+	}
+	m.refreshes.Wait()
+}
+
+// getTokens calls m.GetTokens, and waits for the backlog read it may have
+// started.
+func getTokens(ctx context.Context, m *Manager, numTokens int, specs []quota.Spec) error {
+	err := m.GetTokens(ctx, numTokens, specs)
+	m.refreshes.Wait()
+	return err
+}
+
+func TestManager_GetTokens(t *testing.T) {
+	ctx := context.Background()
+	advance := fakeTime(t)
+	var backlog int64
+	var backlogReads int
+	m, err := New(quota.Noop(), Options{
+		Rate:  100,
+		Curve: curve,
+		Backlog: func(_ context.Context, id int64) (int64, error) {
+			if id != treeID {
+				t.Errorf("Backlog(%v), want tree %v", id, treeID)
+			}
+			backlogReads++
+			return backlog, nil
+		},
+	}, monitoring.InertMetricFactory{})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+
+	// The full burst is available at first.
+	if err := getTokens(ctx, m, 100, []quota.Spec{writeSpec}); err != nil {
+		t.Fatalf("GetTokens(100) = %v", err)
+	}
+	// Read tokens aren't governed.
+	if err := getTokens(ctx, m, 1000, []quota.Spec{readSpec}); err != nil {
+		t.Fatalf("GetTokens(read) = %v", err)
+	}
+
+	// As the backlog grows, tokens are refilled more slowly, so clients are
+	// asked to wait longer for the same number of tokens.
+	var prevDelay time.Duration
+	for _, test := range []struct {
+		backlog   int64
+		wantRate  float64
+		wantDelay time.Duration
+	}{
+		{backlog: 0, wantRate: 100, wantDelay: 100 * time.Millisecond},
+		{backlog: 200, wantRate: 75, wantDelay: 133333334},
+		{backlog: 300, wantRate: 50, wantDelay: 200 * time.Millisecond},
+		{backlog: 350, wantRate: 25, wantDelay: 400 * time.Millisecond},
+	} {
+		t.Run(fmt.Sprint(test.backlog), func(t *testing.T) {
+			backlog = test.backlog
+			advance(DefaultBacklogRefresh)
+			// Draining the bucket reads the new backlog.
+			drain(ctx, t, m)
+			if got := rateGauge.Value(writeSpec.Name()); got != test.wantRate {
+				t.Errorf("rate = %v, want %v", got, test.wantRate)
+			}
+			err := getTokens(ctx, m, 10, []quota.Spec{writeSpec})
+			qe, ok := err.(*quota.ExhaustedError)
+			if !ok {
+				t.Fatalf("GetTokens() = %v, want ExhaustedError", err)
+			}
+			if diff := qe.RefillDelay - test.wantDelay; diff < -time.Microsecond || diff > time.Microsecond || qe.RefillDelay <= prevDelay {
+				t.Errorf("RefillDelay = %v, want %v", qe.RefillDelay, test.wantDelay)
+			}
+			prevDelay = qe.RefillDelay
+			advance(qe.RefillDelay)
+			if err := getTokens(ctx, m, 10, []quota.Spec{writeSpec}); err != nil {
+				t.Errorf("GetTokens() after RefillDelay = %v", err)
+			}
+		})
+	}
+
+	// No tokens are refilled once the curve reaches zero, until the backlog
+	// is read again.
+	backlog = 400
+	advance(DefaultBacklogRefresh)
+	drain(ctx, t, m)
+	advance(DefaultBacklogRefresh / 2)
+	err = getTokens(ctx, m, 1, []quota.Spec{writeSpec})
+	if qe, ok := err.(*quota.ExhaustedError); !ok || qe.RefillDelay != DefaultBacklogRefresh/2 {
+		t.Errorf("GetTokens() at zero rate = %v, want ExhaustedError with RefillDelay %v", err, DefaultBacklogRefresh/2)
+	}
+	backlog = 0
+	advance(DefaultBacklogRefresh / 2)
+	drain(ctx, t, m)
+	advance(10 * time.Millisecond)
+	if err := getTokens(ctx, m, 1, []quota.Spec{writeSpec}); err != nil {
+		t.Errorf("GetTokens() after backlog cleared = %v", err)
+	}
+
+	// More than the burst is never available.
+	err = getTokens(ctx, m, 101, []quota.Spec{writeSpec})
+	if qe, ok := err.(*quota.ExhaustedError); !ok || qe.RefillDelay != 0 {
+		t.Errorf("GetTokens(101) = %v, want ExhaustedError with no RefillDelay", err)
+	}
+
+	// The backlog is only read once per refresh.
+	reads := backlogReads
+	for i := 0; i < 10; i++ {
+		getTokens(ctx, m, 1, []quota.Spec{writeSpec})
+	}
+	m.refreshes.Wait()
+	if backlogReads != reads {
+		t.Errorf("Backlog read %d times within a refresh, want 0", backlogReads-reads)
+	}
+}
+
+func TestManager_BacklogError(t *testing.T) {
+	ctx := context.Background()
+	advance := fakeTime(t)
+	var backlogErr error
+	m, err := New(quota.Noop(), Options{
+		Rate:  100,
+		Curve: curve,
+		Backlog: func(context.Context, int64) (int64, error) {
+			return 400, backlogErr
+		},
+	}, monitoring.InertMetricFactory{})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	getTokens(ctx, m, 100, []quota.Spec{writeSpec})
+	m.refreshes.Wait()
+
+	// The last known rate, zero, is kept.
+	backlogErr = errors.New("storage down")
+	advance(DefaultBacklogRefresh)
+	getTokens(ctx, m, 1, []quota.Spec{writeSpec})
+	m.refreshes.Wait()
+	if err := getTokens(ctx, m, 1, []quota.Spec{writeSpec}); err == nil {
+		t.Error("GetTokens() with backlog error = nil, want ExhaustedError")
+	}
+}
+
+func TestManager_BacklogInBackground(t *testing.T) {
+	ctx := context.Background()
+	fakeTime(t)
+	read := make(chan struct{})
+	release := make(chan struct{})
+	m, err := New(quota.Noop(), Options{
+		Rate:  100,
+		Curve: curve,
+		Backlog: func(ctx context.Context, _ int64) (int64, error) {
+			if ctx.Err() != nil {
+				t.Errorf("Backlog() called with done context: %v", ctx.Err())
+			}
+			read <- struct{}{}
+			<-release
+			return 400, nil
+		},
+	}, monitoring.InertMetricFactory{})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+
+	// Requests don't wait for the backlog, nor start another read of it, and
+	// aren't affected by the cancellation of their context.
+	reqCtx, cancel := context.WithCancel(ctx)
+	if err := m.GetTokens(reqCtx, 10, []quota.Spec{writeSpec}); err != nil {
+		t.Fatalf("GetTokens() = %v", err)
+	}
+	cancel()
+	<-read
+	if err := m.GetTokens(ctx, 10, []quota.Spec{writeSpec}); err != nil {
+		t.Errorf("GetTokens() while reading backlog = %v", err)
+	}
+	close(release)
+	m.refreshes.Wait()
+	if got, want := rateGauge.Value(writeSpec.Name()), 0.0; got != want {
+		t.Errorf("rate = %v, want %v", got, want)
+	}
+}
+
+func TestManager_WrappedErrors(t *testing.T) {
+	ctx := context.Background()
+	fakeTime(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	qm := quota.NewMockManager(ctrl)
+	m, err := New(qm, Options{
+		Rate:    10,
+		Curve:   curve,
+		Backlog: func(context.Context, int64) (int64, error) { return 0, nil },
+	}, monitoring.InertMetricFactory{})
+	if err != nil {
+		t.Fatalf("New(): %v", err)
+	}
+	// Backlog reads may still run when the test ends, after fakeTime.
+	defer m.refreshes.Wait()
+	specs := []quota.Spec{{Group: quota.Global, Kind: quota.Write}, writeSpec}
+
+	// Tokens are returned to the bucket if the wrapped manager fails.
+	wantErr := errors.New("no tokens")
+	qm.EXPECT().GetTokens(ctx, 10, specs).Return(wantErr)
+	if err := m.GetTokens(ctx, 10, specs); err != wantErr {
+		t.Errorf("GetTokens() = %v, want %v", err, wantErr)
+	}
+	qm.EXPECT().PeekTokens(ctx, specs).Return(map[quota.Spec]int{specs[0]: 5, writeSpec: quota.MaxTokens}, nil)
+	got, err := m.PeekTokens(ctx, specs)
+	if err != nil {
+		t.Fatalf("PeekTokens(): %v", err)
+	}
+	if want := map[quota.Spec]int{specs[0]: 5, writeSpec: 10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PeekTokens() = %v, want %v", got, want)
+	}
+
+	// The wrapped manager isn't called if the bucket is exhausted.
+	qm.EXPECT().GetTokens(ctx, 10, specs).Return(nil)
+	if err := m.GetTokens(ctx, 10, specs); err != nil {
+		t.Errorf("GetTokens() = %v", err)
+	}
+	if err := m.GetTokens(ctx, 10, specs); err == nil {
+		t.Error("GetTokens() on exhausted bucket = nil, want ExhaustedError")
+	}
+
+	// Cleaning up the tree refills its bucket.
+	qm.EXPECT().ResetQuota(ctx, quota.TreeSpecs(treeID)).Return(nil)
+	if err := m.CleanUpTree(ctx, treeID); err != nil {
+		t.Fatalf("CleanUpTree(): %v", err)
+	}
+	qm.EXPECT().GetTokens(ctx, 10, specs).Return(nil)
+	if err := m.GetTokens(ctx, 10, specs); err != nil {
+		t.Errorf("GetTokens() after CleanUpTree = %v", err)
+	}
+}
+
+func TestStorageBacklog(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	as := memory.NewAdminStorage(ts)
+	ls := memory.NewLogStorage(ts, nil)
+	tree, err := storage.CreateTree(ctx, as, testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	var leaves []*trillian.LogLeaf
+	for i := 0; i < 3; i++ {
+		hash := sha256.Sum256([]byte(fmt.Sprintf("leaf%d", i)))
+		leaves = append(leaves, &trillian.LogLeaf{LeafIdentityHash: hash[:], MerkleLeafHash: hash[:]})
+	}
+	logRoot, err := (&types.LogRootV1{}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): %v", err)
+	}
+	if err := ls.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		if err := tx.StoreSignedLogRoot(ctx, &trillian.SignedLogRoot{LogRoot: logRoot}); err != nil {
+			return err
+		}
+		_, err := tx.QueueLeaves(ctx, leaves, time.Now())
+		return err
+	}); err != nil {
+		t.Fatalf("QueueLeaves(): %v", err)
+	}
+
+	backlog := StorageBacklog(as, ls)
+	if got, err := backlog(ctx, tree.TreeId); err != nil || got != 3 {
+		t.Errorf("backlog() = (%d, %v), want (3, nil)", got, err)
+	}
+	if _, err := backlog(ctx, tree.TreeId+1); err == nil {
+		t.Error("backlog(unknown tree) returned err = nil, want non-nil")
+	}
+}
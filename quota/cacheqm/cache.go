@@ -92,6 +92,19 @@ func (m *manager) ResetQuota(ctx context.Context, specs []quota.Spec) error {
 	return m.qm.ResetQuota(ctx, specs)
 }
 
+// CleanUpTree implements quota.TreeCleaner.CleanUpTree. Cached tokens of the
+// tree are discarded rather than replenished.
+func (m *manager) CleanUpTree(ctx context.Context, treeID int64) error {
+	m.mu.Lock()
+	for spec := range m.cache {
+		if spec.Group == quota.Tree && spec.TreeID == treeID {
+			delete(m.cache, spec)
+		}
+	}
+	m.mu.Unlock()
+	return quota.CleanUpTree(ctx, m.qm, treeID)
+}
+
 // GetTokens implements Manager.GetTokens.
 func (m *manager) GetTokens(ctx context.Context, numTokens int, specs []quota.Spec) error {
 	m.mu.Lock()
@@ -148,6 +148,41 @@ func TestCachedManager_GetTokens_CachesTokens(t *testing.T) {
 	}
 }
 
+func TestCachedManager_CleanUpTree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	treeSpecs := []quota.Spec{{Group: quota.Tree, Kind: quota.Write, TreeID: 10}}
+	mock := quota.NewMockManager(ctrl)
+	qm, err := NewCachedManager(mock, minBatchSize, maxEntries)
+	if err != nil {
+		t.Fatalf("NewCachedManager() returned err = %v", err)
+	}
+
+	// Cache tokens of the tree and of the global quotas.
+	mock.EXPECT().GetTokens(ctx, matchers.AtLeast(minBatchSize), treeSpecs).Return(nil)
+	mock.EXPECT().GetTokens(ctx, matchers.AtLeast(minBatchSize), specs).Return(nil)
+	for _, s := range [][]quota.Spec{treeSpecs, specs} {
+		if err := qm.GetTokens(ctx, 1, s); err != nil {
+			t.Fatalf("GetTokens() returned err = %v", err)
+		}
+	}
+
+	mock.EXPECT().ResetQuota(ctx, quota.TreeSpecs(10)).Return(nil)
+	if err := qm.(quota.TreeCleaner).CleanUpTree(ctx, 10); err != nil {
+		t.Fatalf("CleanUpTree() returned err = %v", err)
+	}
+
+	// The cached tokens of the tree are gone, but not the others.
+	mock.EXPECT().GetTokens(ctx, matchers.AtLeast(minBatchSize), treeSpecs).Return(nil)
+	for _, s := range [][]quota.Spec{treeSpecs, specs} {
+		if err := qm.GetTokens(ctx, 1, s); err != nil {
+			t.Fatalf("GetTokens() returned err = %v", err)
+		}
+	}
+}
+
 func TestCachedManager_GetTokens_EvictsCache(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
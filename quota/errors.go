@@ -0,0 +1,52 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"fmt"
+	"time"
+)
+
+// ExhaustedError is returned by Managers when tokens can't be acquired because
+// a quota doesn't have enough of them.
+// It allows callers to learn which quota was exhausted and when it may be
+// retried.
+type ExhaustedError struct {
+	// Spec is the name of the exhausted quota, as returned by Spec.Name().
+	Spec string
+	// Available is the number of tokens that were available in Spec.
+	Available int64
+	// Requested is the number of tokens that were requested.
+	Requested int64
+	// RefillDelay is the estimated time until Requested tokens are available.
+	// Zero means unknown.
+	RefillDelay time.Duration
+	// Err is an optional implementation-specific error, returned by Unwrap.
+	Err error
+}
+
+// Error returns a description of the exhausted quota.
+func (e *ExhaustedError) Error() string {
+	msg := fmt.Sprintf("insufficient tokens on %v (%v vs %v)", e.Spec, e.Available, e.Requested)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%v: %v", e.Err, msg)
+	}
+	return msg
+}
+
+// Unwrap returns the underlying implementation-specific error, if any.
+func (e *ExhaustedError) Unwrap() error {
+	return e.Err
+}
@@ -31,6 +31,9 @@ const QuotaManagerName = "etcd"
 var (
 	// Servers is a flag containing the address(es) of etcd servers
 	Servers = flag.String("etcd_servers", "", "A comma-separated list of etcd servers; no etcd registration if empty")
+	// Prefix is a flag containing the prefix of the etcd keys of service
+	// announcements and quotas.
+	Prefix = flag.String("etcd_prefix", "", "Prefix of the etcd keys of service announcements and quotas, e.g. to share an etcd cluster between independent Trillian deployments")
 	// TODO(Martin2112): suggested renaming these to etc_... to avoid clashes, but will it break existing deploys?
 	quotaMinBatchSize = flag.Int("quota_min_batch_size", cacheqm.DefaultMinBatchSize, "Minimum number of tokens to request from the quota system. "+
 		"Zero or lower means batching is disabled. Applicable for etcd quotas.")
@@ -53,7 +56,7 @@ func newEtcdQuotaManager() (quota.Manager, error) {
 		return nil, fmt.Errorf("failed to connect to etcd at %v: %v", *Servers, err)
 	}
 
-	qm := etcdqm.New(client)
+	qm := etcdqm.New(etcd.WithPrefix(client, *Prefix))
 	if *quotaMinBatchSize > 0 && *quotaMaxCacheEntries > 0 {
 		cachedQM, err := cacheqm.NewCachedManager(qm, *quotaMinBatchSize, *quotaMaxCacheEntries)
 		if err != nil {
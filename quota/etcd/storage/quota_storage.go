@@ -363,6 +363,31 @@ func getConfigs(s concurrency.STM) (*storagepb.Configs, error) {
 	return cfgs, nil
 }
 
+// specName returns the quota spec name corresponding to the config name, e.g.
+// "trees/10/write" for "quotas/trees/10/write/config".
+func specName(name string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(name, "quotas/"), "/config")
+}
+
+// refillDelay estimates how long it takes for bucket to have the requested number of tokens,
+// according to cfg. Zero is returned for quotas that aren't replenished over time or if the
+// request can never be satisfied.
+func refillDelay(cfg *storagepb.Config, bucket *storagepb.Bucket, now time.Time, requested int64) time.Duration {
+	tb := cfg.GetTimeBased()
+	if tb == nil || tb.TokensToReplenish <= 0 || requested > cfg.MaxTokens {
+		return 0
+	}
+	interval := time.Duration(tb.ReplenishIntervalSeconds) * time.Second
+	next := time.Unix(0, bucket.LastReplenishMillisSinceEpoch*1e6).Add(interval)
+	missing := -bucket.Tokens
+	replenishments := (missing + tb.TokensToReplenish - 1) / tb.TokensToReplenish
+	delay := next.Sub(now) + time.Duration(replenishments-1)*interval
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
 // modBucket adds "add" tokens to the specified quota. Add may be negative or zero.
 // Time-based quotas that are due replenishment will be replenished before the add operation. Quotas
 // that are above ceiling (eg, due to lowered max tokens) will also be constrained to the
@@ -392,9 +417,15 @@ func modBucket(s concurrency.STM, cfg *storagepb.Config, now time.Time, add int6
 		}
 	}
 
+	available := newBucket.Tokens
 	newBucket.Tokens += add
 	if newBucket.Tokens < 0 {
-		return 0, fmt.Errorf("insufficient tokens on %v (%v vs %v)", key, prevBucket.Tokens, -add)
+		return 0, &quota.ExhaustedError{
+			Spec:        specName(cfg.Name),
+			Available:   available,
+			Requested:   -add,
+			RefillDelay: refillDelay(cfg, newBucket, now, -add),
+		}
 	}
 	if newBucket.Tokens > cfg.MaxTokens {
 		newBucket.Tokens = cfg.MaxTokens
@@ -28,6 +28,7 @@ import (
 	"github.com/google/trillian/quota/etcd/storagepb"
 	"github.com/google/trillian/testonly/integration/etcd"
 	"github.com/google/trillian/util/clock"
+	etcdutil "github.com/google/trillian/util/etcd"
 	"github.com/kylelemons/godebug/pretty"
 )
 
@@ -459,6 +460,45 @@ func TestQuotaStorage_DeletedConfig(t *testing.T) {
 	}
 }
 
+func TestQuotaStorage_Prefix(t *testing.T) {
+	defer setupTimeSource(fixedTimeSource)()
+
+	ctx := context.Background()
+	qsA := &QuotaStorage{Client: etcdutil.WithPrefix(client, "a/")}
+	qsB := &QuotaStorage{Client: etcdutil.WithPrefix(client, "b/")}
+
+	cfgs := &storagepb.Configs{Configs: []*storagepb.Config{deepCopy(cfgs).Configs[1]}} // Only global/write
+	globalWrite := cfgs.Configs[0]
+	if _, err := qsA.UpdateConfigs(ctx, true /* reset */, updater(cfgs)); err != nil {
+		t.Fatalf("UpdateConfigs() returned err = %v", err)
+	}
+	defer func() {
+		if _, err := qsA.UpdateConfigs(ctx, true /* reset */, updater(&storagepb.Configs{})); err != nil {
+			t.Errorf("UpdateConfigs() returned err = %v", err)
+		}
+	}()
+
+	names := []string{globalWrite.Name}
+	if err := qsA.Get(ctx, names, 10); err != nil {
+		t.Fatalf("Get() returned err = %v", err)
+	}
+	if err := peekAndDiff(ctx, qsA, map[string]int64{globalWrite.Name: globalWrite.MaxTokens - 10}); err != nil {
+		t.Errorf("peekAndDiff returned err = %v", err)
+	}
+	// Quotas under other prefixes are unaffected, i.e. still infinite.
+	if err := peekAndDiff(ctx, qsB, map[string]int64{globalWrite.Name: quotaMaxTokens}); err != nil {
+		t.Errorf("peekAndDiff returned err = %v", err)
+	}
+
+	resp, err := client.Get(ctx, "a/"+configsKey)
+	if err != nil {
+		t.Fatalf("Get(%q) returned err = %v", "a/"+configsKey, err)
+	}
+	if len(resp.Kvs) != 1 {
+		t.Errorf("Get(%q) returned %d keys, want 1", "a/"+configsKey, len(resp.Kvs))
+	}
+}
+
 func TestQuotaStorage_DisabledConfig(t *testing.T) {
 	defer setupTimeSource(fixedTimeSource)()
 
@@ -981,3 +1021,78 @@ func updater(cfgs *storagepb.Configs) func(*storagepb.Configs) {
 		proto.Merge(c, cfgs)
 	}
 }
+
+func TestQuotaStorage_GetExhausted(t *testing.T) {
+	fakeTime := clock.NewFake(time.Now())
+	defer setupTimeSource(fakeTime)()
+
+	ctx := context.Background()
+	qs := &QuotaStorage{Client: client}
+	if _, err := qs.UpdateConfigs(ctx, true /* reset */, updater(cfgs)); err != nil {
+		t.Fatalf("UpdateConfigs() returned err = %v", err)
+	}
+	if err := setupTokens(ctx, qs, cfgs, map[string]int64{userRead.Name: 10}); err != nil {
+		t.Fatalf("setupTokens() returned err = %v", err)
+	}
+
+	err := qs.Get(ctx, []string{userRead.Name}, 600)
+	qe, ok := err.(*quota.ExhaustedError)
+	if !ok {
+		t.Fatalf("Get() returned err = %v, want *quota.ExhaustedError", err)
+	}
+	interval := time.Duration(userRead.GetTimeBased().ReplenishIntervalSeconds) * time.Second
+	want := &quota.ExhaustedError{
+		Spec:      "users/llama/read",
+		Available: 10,
+		Requested: 600,
+		// 590 tokens are missing, which takes two replenishments of 500.
+		RefillDelay: 2 * interval,
+	}
+	// Bucket timestamps are stored with millisecond precision.
+	if diff := qe.RefillDelay - want.RefillDelay; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Errorf("Get() returned RefillDelay = %v, want %v", qe.RefillDelay, want.RefillDelay)
+	}
+	qe.RefillDelay = want.RefillDelay
+	if diff := pretty.Compare(qe, want); diff != "" {
+		t.Errorf("Get() returned diff (-got +want):\n%v", diff)
+	}
+}
+
+func TestRefillDelay(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tb := &storagepb.Config_TimeBased{
+		TimeBased: &storagepb.TimeBasedStrategy{
+			ReplenishIntervalSeconds: 10,
+			TokensToReplenish:        5,
+		},
+	}
+	timeBased := &storagepb.Config{MaxTokens: 20, ReplenishmentStrategy: tb}
+	seqBased := &storagepb.Config{
+		MaxTokens:             20,
+		ReplenishmentStrategy: &storagepb.Config_SequencingBased{SequencingBased: &storagepb.SequencingBasedStrategy{}},
+	}
+	replenished := func(ago time.Duration, tokens int64) *storagepb.Bucket {
+		return &storagepb.Bucket{
+			Tokens:                        tokens,
+			LastReplenishMillisSinceEpoch: now.Add(-ago).UnixNano() / 1e6,
+		}
+	}
+
+	tests := []struct {
+		desc      string
+		cfg       *storagepb.Config
+		bucket    *storagepb.Bucket
+		requested int64
+		want      time.Duration
+	}{
+		{desc: "sequencingBased", cfg: seqBased, bucket: replenished(0, -1), requested: 1},
+		{desc: "overMax", cfg: timeBased, bucket: replenished(0, -21), requested: 21},
+		{desc: "nextReplenishment", cfg: timeBased, bucket: replenished(4*time.Second, -5), requested: 6, want: 6 * time.Second},
+		{desc: "severalReplenishments", cfg: timeBased, bucket: replenished(0, -11), requested: 15, want: 30 * time.Second},
+	}
+	for _, test := range tests {
+		if got := refillDelay(test.cfg, test.bucket, now, test.requested); got != test.want {
+			t.Errorf("%v: refillDelay() = %v, want %v", test.desc, got, test.want)
+		}
+	}
+}
@@ -37,6 +37,7 @@ type m struct {
 	AcquiredTokens    monitoring.Counter
 	ReturnedTokens    monitoring.Counter
 	ReplenishedTokens monitoring.Counter
+	TreeCleanups      monitoring.Counter
 }
 
 // IncAcquired increments the AcquiredTokens metric.
@@ -54,6 +55,15 @@ func (m *m) IncReplenished(tokens int, specs []Spec, success bool) {
 	m.add(m.ReplenishedTokens, tokens, specs, success)
 }
 
+// IncTreeCleanup increments the TreeCleanups metric for the given operation
+// on a tree, e.g. "delete".
+func (m *m) IncTreeCleanup(op string, success bool) {
+	if m.TreeCleanups == nil {
+		return
+	}
+	m.TreeCleanups.Inc(op, fmt.Sprint(success))
+}
+
 func (m *m) add(c monitoring.Counter, tokens int, specs []Spec, success bool) {
 	if c == nil {
 		return
@@ -74,5 +84,6 @@ func InitMetrics(mf monitoring.MetricFactory) {
 		Metrics.AcquiredTokens = mf.NewCounter("quota_acquired_tokens", "Number of acquired quota tokens", "spec", "success")
 		Metrics.ReturnedTokens = mf.NewCounter("quota_returned_tokens", "Number of quota tokens returned due to overcharging (bad requests, duplicates, etc)", "spec", "success")
 		Metrics.ReplenishedTokens = mf.NewCounter("quota_replenished_tokens", "Number of quota tokens replenished due to sequencer progress", "spec", "success")
+		Metrics.TreeCleanups = mf.NewCounter("quota_tree_cleanups", "Number of quota cleanups due to trees being deleted or undeleted", "operation", "success")
 	})
 }
@@ -38,8 +38,8 @@ const (
 )
 
 var (
-	// ErrTooManyUnsequencedRows is returned when tokens are requested but Unsequenced has grown
-	// beyond the configured limit.
+	// ErrTooManyUnsequencedRows is returned, wrapped in a quota.ExhaustedError, when tokens are
+	// requested but Unsequenced has grown beyond the configured limit.
 	ErrTooManyUnsequencedRows = errors.New("too many unsequenced rows")
 )
 
@@ -73,7 +73,12 @@ func (m *QuotaManager) GetTokens(ctx context.Context, numTokens int, specs []quo
 			return err
 		}
 		if count+numTokens > m.MaxUnsequencedRows {
-			return ErrTooManyUnsequencedRows
+			return &quota.ExhaustedError{
+				Spec:      spec.Name(),
+				Available: int64(m.MaxUnsequencedRows - count),
+				Requested: int64(numTokens),
+				Err:       ErrTooManyUnsequencedRows,
+			}
 		}
 	}
 	return nil
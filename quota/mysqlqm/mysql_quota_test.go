@@ -18,6 +18,7 @@ import (
 	"context"
 	"crypto"
 	"database/sql"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -112,7 +113,7 @@ func TestQuotaManager_GetTokens(t *testing.T) {
 		// See TestQuotaManager_GetTokens_InformationSchema for information schema tests.
 		qm := &mysqlqm.QuotaManager{DB: db, MaxUnsequencedRows: test.maxUnsequencedRows, UseSelectCount: true}
 		err := qm.GetTokens(ctx, test.numTokens, test.specs)
-		if hasErr := err == mysqlqm.ErrTooManyUnsequencedRows; hasErr != test.wantErr {
+		if hasErr := errors.Is(err, mysqlqm.ErrTooManyUnsequencedRows); hasErr != test.wantErr {
 			t.Errorf("%v: GetTokens() returned err = %q, wantErr = %v", test.desc, err, test.wantErr)
 		}
 	}
@@ -175,7 +176,7 @@ func TestQuotaManager_GetTokens_InformationSchema(t *testing.T) {
 					stop = true
 				default:
 					// An error means that GetTokens is working correctly
-					stop = qm.GetTokens(ctx, 1 /* numTokens */, globalWriteSpec) == mysqlqm.ErrTooManyUnsequencedRows
+					stop = errors.Is(qm.GetTokens(ctx, 1 /* numTokens */, globalWriteSpec), mysqlqm.ErrTooManyUnsequencedRows)
 				}
 			}
 		})
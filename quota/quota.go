@@ -118,3 +118,31 @@ type Manager interface {
 	// ResetQuota resets the quota for all specs.
 	ResetQuota(ctx context.Context, specs []Spec) error
 }
+
+// TreeCleaner is an optional interface of Managers which keep quota state for
+// individual trees beyond what ResetQuota restores, e.g. tokens acquired in
+// advance.
+type TreeCleaner interface {
+	// CleanUpTree removes the quota state of the tree, e.g. after the tree is
+	// deleted.
+	CleanUpTree(ctx context.Context, treeID int64) error
+}
+
+// TreeSpecs returns the specs of all kinds of tokens of the Tree group for
+// treeID.
+func TreeSpecs(treeID int64) []Spec {
+	return []Spec{
+		{Group: Tree, Kind: Read, TreeID: treeID},
+		{Group: Tree, Kind: Write, TreeID: treeID},
+	}
+}
+
+// CleanUpTree removes the quota state of treeID from qm, e.g. after the tree is
+// deleted. Managers which don't implement TreeCleaner have the quotas of the
+// tree reset instead.
+func CleanUpTree(ctx context.Context, qm Manager, treeID int64) error {
+	if tc, ok := qm.(TreeCleaner); ok {
+		return tc.CleanUpTree(ctx, treeID)
+	}
+	return qm.ResetQuota(ctx, TreeSpecs(treeID))
+}
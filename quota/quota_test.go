@@ -14,7 +14,14 @@
 
 package quota
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+)
 
 func TestSpec_Name(t *testing.T) {
 	tests := []struct {
@@ -34,3 +41,64 @@ func TestSpec_Name(t *testing.T) {
 		}
 	}
 }
+
+func TestExhaustedError(t *testing.T) {
+	inner := errors.New("too many rows")
+	for _, test := range []struct {
+		err       *ExhaustedError
+		wantMsg   string
+		wantInner error
+	}{
+		{
+			err:     &ExhaustedError{Spec: "global/write", Available: 1, Requested: 2},
+			wantMsg: "insufficient tokens on global/write (1 vs 2)",
+		},
+		{
+			err:       &ExhaustedError{Spec: "global/write", Available: 1, Requested: 2, Err: inner},
+			wantMsg:   "too many rows: insufficient tokens on global/write (1 vs 2)",
+			wantInner: inner,
+		},
+	} {
+		if got := test.err.Error(); got != test.wantMsg {
+			t.Errorf("Error() = %q, want %q", got, test.wantMsg)
+		}
+		if got := errors.Unwrap(test.err); got != test.wantInner {
+			t.Errorf("Unwrap() = %v, want %v", got, test.wantInner)
+		}
+	}
+}
+
+type fakeTreeCleaner struct {
+	Manager
+	cleaned []int64
+}
+
+func (f *fakeTreeCleaner) CleanUpTree(ctx context.Context, treeID int64) error {
+	f.cleaned = append(f.cleaned, treeID)
+	return nil
+}
+
+func TestCleanUpTree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	// Managers which aren't TreeCleaners have the tree quotas reset.
+	want := errors.New("reset failed")
+	qm := NewMockManager(ctrl)
+	qm.EXPECT().ResetQuota(ctx, []Spec{
+		{Group: Tree, Kind: Read, TreeID: 10},
+		{Group: Tree, Kind: Write, TreeID: 10},
+	}).Return(want)
+	if err := CleanUpTree(ctx, qm, 10); err != want {
+		t.Errorf("CleanUpTree() returned err = %v, want %v", err, want)
+	}
+
+	tc := &fakeTreeCleaner{Manager: NewMockManager(ctrl)}
+	if err := CleanUpTree(ctx, tc, 10); err != nil {
+		t.Errorf("CleanUpTree() returned err = %v", err)
+	}
+	if got, want := tc.cleaned, []int64{10}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CleanUpTree() cleaned trees %v, want %v", got, want)
+	}
+}
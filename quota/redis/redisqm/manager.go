@@ -17,7 +17,7 @@ package redisqm
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/quota/redis/redistb"
@@ -94,7 +94,16 @@ func (m *Manager) getTokensSingle(ctx context.Context, numTokens int, spec quota
 		return err
 	}
 	if !allowed {
-		return fmt.Errorf("insufficient tokens on %v (%v vs %v)", name, remaining, numTokens)
+		var refill time.Duration
+		if rate > 0 && numTokens <= capacity {
+			refill = time.Duration(float64(int64(numTokens)-remaining) / rate * float64(time.Second))
+		}
+		return &quota.ExhaustedError{
+			Spec:        spec.Name(),
+			Available:   remaining,
+			Requested:   int64(numTokens),
+			RefillDelay: refill,
+		}
 	}
 
 	return nil
@@ -17,37 +17,64 @@ package admin
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
+	"io"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/empty"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys/der"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/quota"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/encryption"
+	"github.com/google/trillian/storage/namespace"
 	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util/requestid"
 	"google.golang.org/genproto/protobuf/field_mask"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	tcrypto "github.com/google/trillian/crypto"
+
 	_ "github.com/google/trillian/merkle/rfc6962" // Make hashers available
 )
 
+// maxTreeIDAttempts is the number of random IDs CreateTree tries before giving
+// up, in case they collide with existing trees.
+const maxTreeIDAttempts = 5
+
 // Server is an implementation of trillian.TrillianAdminServer.
 type Server struct {
 	registry         extension.Registry
 	allowedTreeTypes []trillian.TreeType
+	deleteThreshold  time.Duration
+
+	// UniqueDisplayNames makes CreateTree, UpdateTree and BatchUpdateTrees
+	// fail with codes.AlreadyExists if the display name of the tree is that of
+	// another non-deleted tree. Trees without a display name are always
+	// allowed. It should be set before the server starts serving.
+	UniqueDisplayNames bool
 }
 
 // New returns a trillian.TrillianAdminServer implementation.
 // registry is the extension.Registry used by the Server.
 // allowedTreeTypes defines which tree types may be created through this server,
-// with nil meaning unrestricted.
-func New(registry extension.Registry, allowedTreeTypes []trillian.TreeType) *Server {
+// with nil meaning unrestricted. Its first type is the default for trees
+// created without one.
+// deleteThreshold is the time after which soft-deleted trees become eligible
+// for hard-deletion (see DeletedTreeGC) and can no longer be undeleted, with
+// zero meaning that trees are never hard-deleted.
+func New(registry extension.Registry, allowedTreeTypes []trillian.TreeType, deleteThreshold time.Duration) *Server {
 	return &Server{
 		registry:         registry,
 		allowedTreeTypes: allowedTreeTypes,
+		deleteThreshold:  deleteThreshold,
 	}
 }
 
@@ -81,10 +108,19 @@ func (s *Server) GetTree(ctx context.Context, req *trillian.GetTreeRequest) (*tr
 
 // CreateTree implements trillian.TrillianAdminServer.CreateTree.
 func (s *Server) CreateTree(ctx context.Context, req *trillian.CreateTreeRequest) (*trillian.Tree, error) {
-	tree := req.GetTree()
-	if tree == nil {
+	if req.GetTree() == nil {
 		return nil, status.Errorf(codes.InvalidArgument, "a tree is required")
 	}
+	// The tree is filled in below, which mustn't show through to the caller.
+	tree := proto.Clone(req.GetTree()).(*trillian.Tree)
+	// Storage rejects IDs which are taken, in any namespace, so callers
+	// restricted to a namespace could probe for the trees of others.
+	if _, ok := namespace.FromContext(ctx); ok && tree.TreeId != 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "tree.tree_id can't be chosen by callers claiming a namespace")
+	}
+	if tree.TreeType == trillian.TreeType_UNKNOWN_TREE_TYPE && len(s.allowedTreeTypes) > 0 {
+		tree.TreeType = s.allowedTreeTypes[0]
+	}
 	if err := s.validateAllowedTreeType(tree.TreeType); err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
@@ -128,6 +164,21 @@ func (s *Server) CreateTree(ctx context.Context, req *trillian.CreateTreeRequest
 		return nil, status.Errorf(codes.InvalidArgument, "tree.private_key or key_spec is required")
 	}
 
+	// If leaf encryption was requested, generate a data key for the tree.
+	if e := tree.LeafEncryption; e != nil {
+		if len(e.WrappedDataKey) != 0 || e.KekId != "" {
+			return nil, status.Errorf(codes.InvalidArgument, "tree.leaf_encryption must be empty, the data key is generated by the server")
+		}
+		if s.registry.LeafKeyWrapper == nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "leaf encryption is not enabled")
+		}
+		leafEncryption, err := s.newLeafEncryption(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tree.LeafEncryption = leafEncryption
+	}
+
 	// Check that the tree.PrivateKey is valid by trying to get a signer.
 	signer, err := trees.Signer(ctx, tree)
 	if err != nil {
@@ -151,18 +202,116 @@ func (s *Server) CreateTree(ctx context.Context, req *trillian.CreateTreeRequest
 		tree.PublicKey = publicKey
 	}
 
-	// Clear generated fields, storage must set those
-	tree.TreeId = 0
+	// Clear generated fields, storage must set those.
 	tree.CreateTime = nil
 	tree.UpdateTime = nil
 	tree.Deleted = false
 	tree.DeleteTime = nil
 
-	createdTree, err := storage.CreateTree(ctx, s.registry.AdminStorage, tree)
+	// tree.TreeId is kept as is if set, otherwise a random ID is assigned.
+	// Storage rejects IDs that are already taken, in which case another random
+	// ID is tried.
+	randomID := tree.TreeId == 0
+	for attempt := 1; ; attempt++ {
+		if randomID {
+			if tree.TreeId, err = storage.NewTreeIDFrom(s.rand()); err != nil {
+				return nil, status.Errorf(codes.Internal, "failed to generate tree ID: %v", err)
+			}
+		}
+		createdTree, nameTaken, err := s.createAttestedTree(ctx, tree, signer)
+		if randomID && !nameTaken && attempt < maxTreeIDAttempts && status.Code(err) == codes.AlreadyExists {
+			glog.Warningf("%sGenerated tree ID %v is taken, retrying", requestid.LogPrefix(ctx), tree.TreeId)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return redact(createdTree), nil
+	}
+}
+
+// newLeafEncryption returns a new data key for a tree, wrapped by the current
+// KEK.
+func (s *Server) newLeafEncryption(ctx context.Context) (*trillian.LeafEncryption, error) {
+	key, err := encryption.NewDataKey()
 	if err != nil {
-		return nil, err
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	wrapped, kekID, err := s.registry.LeafKeyWrapper.Wrap(ctx, key)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to wrap data key: %v", err)
+	}
+	return &trillian.LeafEncryption{WrappedDataKey: wrapped, KekId: kekID}, nil
+}
+
+// createAttestedTree creates the tree in storage, along with an attestation of
+// its settings signed by signer, in a single transaction. Trees are still
+// created if the storage doesn't support attestations, and rejected if their
+// leaf indices overlap those of another log. It also returns whether the tree
+// was rejected because its display name is taken, as per UniqueDisplayNames.
+func (s *Server) createAttestedTree(ctx context.Context, tree *trillian.Tree, signer *tcrypto.Signer) (*trillian.Tree, bool, error) {
+	var createdTree *trillian.Tree
+	var nameTaken bool
+	err := s.registry.AdminStorage.ReadWriteTransaction(ctx, func(ctx context.Context, tx storage.AdminTX) error {
+		if s.UniqueDisplayNames {
+			err := storage.CheckDisplayNameAvailable(ctx, tx, tree.DisplayName)
+			nameTaken = status.Code(err) == codes.AlreadyExists
+			if err != nil {
+				return err
+			}
+		}
+		if err := storage.CheckLeafIndexRangeAvailable(ctx, tx, tree); err != nil {
+			return err
+		}
+		var err error
+		if createdTree, err = tx.CreateTree(ctx, tree); err != nil {
+			return err
+		}
+		attestation, err := signer.SignTreeAttestation(attestedSettings(createdTree))
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to sign tree attestation: %v", err)
+		}
+		err = tx.CreateTreeAttestation(ctx, createdTree.TreeId, attestation)
+		if status.Code(err) == codes.Unimplemented {
+			glog.Warningf("%sTree %v created without attestation: %v", requestid.LogPrefix(ctx), createdTree.TreeId, err)
+			return nil
+		}
+		return err
+	})
+	return createdTree, nameTaken, err
+}
+
+// attestedSettings returns a copy of tree with only the settings which can't
+// change after creation, as covered by its attestation.
+func attestedSettings(tree *trillian.Tree) *trillian.Tree {
+	settings := proto.Clone(tree).(*trillian.Tree)
+	settings.TreeState = trillian.TreeState_UNKNOWN_TREE_STATE
+	settings.DisplayName = ""
+	settings.Description = ""
+	settings.PrivateKey = nil
+	settings.StorageSettings = nil
+	settings.MaxRootDuration = nil
+	settings.SigningInterval = nil
+	settings.MaxQueueAge = nil
+	settings.UpdateTime = nil
+	settings.Deleted = false
+	settings.DeleteTime = nil
+	// Dictionaries can be appended after creation.
+	settings.LeafCompressionDictionaries = nil
+	if settings.LeafEncryption != nil {
+		// Whether leaves are encrypted can't change, but the wrapped data key
+		// does when rewrapped.
+		settings.LeafEncryption = &trillian.LeafEncryption{}
 	}
-	return redact(createdTree), nil
+	return settings
+}
+
+// rand returns the source of randomness for tree IDs.
+func (s *Server) rand() io.Reader {
+	if s.registry.Rand != nil {
+		return s.registry.Rand
+	}
+	return rand.Reader
 }
 
 func (s *Server) validateAllowedTreeType(tt trillian.TreeType) error {
@@ -189,11 +338,19 @@ func (s *Server) UpdateTree(ctx context.Context, req *trillian.UpdateTreeRequest
 		return nil, err
 	}
 
-	updatedTree, err := storage.UpdateTree(ctx, s.registry.AdminStorage, tree.TreeId, func(other *trillian.Tree) {
-		if err := applyUpdateMask(tree, other, mask); err != nil {
-			// Should never happen (famous last words).
-			glog.Errorf("Error applying mask on tree update: %v", err)
+	var updatedTree *trillian.Tree
+	err := s.registry.AdminStorage.ReadWriteTransaction(ctx, func(ctx context.Context, tx storage.AdminTX) error {
+		if err := s.checkDisplayNameUpdate(ctx, tx, tree, mask); err != nil {
+			return err
 		}
+		var err error
+		updatedTree, err = tx.UpdateTree(ctx, tree.TreeId, func(other *trillian.Tree) {
+			if err := applyUpdateMask(tree, other, mask); err != nil {
+				// Should never happen (famous last words).
+				glog.Errorf("%sError applying mask on tree update: %v", requestid.LogPrefix(ctx), err)
+			}
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -201,6 +358,21 @@ func (s *Server) UpdateTree(ctx context.Context, req *trillian.UpdateTreeRequest
 	return redact(updatedTree), nil
 }
 
+// checkDisplayNameUpdate returns an AlreadyExists error if UniqueDisplayNames
+// is set, and the update of tree with mask renames it to the display name of
+// another tree in tx.
+func (s *Server) checkDisplayNameUpdate(ctx context.Context, tx storage.AdminReader, tree *trillian.Tree, mask *field_mask.FieldMask) error {
+	if !s.UniqueDisplayNames {
+		return nil
+	}
+	for _, path := range mask.GetPaths() {
+		if path == "display_name" {
+			return storage.CheckDisplayNameAvailableFor(ctx, tx, tree.TreeId, tree.DisplayName)
+		}
+	}
+	return nil
+}
+
 func applyUpdateMask(from, to *trillian.Tree, mask *field_mask.FieldMask) error {
 	if mask == nil || len(mask.Paths) == 0 {
 		return status.Errorf(codes.InvalidArgument, "an update_mask is required")
@@ -219,33 +391,334 @@ func applyUpdateMask(from, to *trillian.Tree, mask *field_mask.FieldMask) error
 			to.StorageSettings = from.StorageSettings
 		case "max_root_duration":
 			to.MaxRootDuration = from.MaxRootDuration
+		case "signing_interval":
+			to.SigningInterval = from.SigningInterval
+		case "max_queue_age":
+			to.MaxQueueAge = from.MaxQueueAge
 		case "private_key":
 			to.PrivateKey = from.PrivateKey
+		case "leaf_compression_dictionaries":
+			to.LeafCompressionDictionaries = from.LeafCompressionDictionaries
 		default:
+			if readonlyTreeFields[path] {
+				return status.Errorf(codes.InvalidArgument, "readonly field can't be updated: %q", path)
+			}
 			return status.Errorf(codes.InvalidArgument, "invalid update_mask path: %q", path)
 		}
 	}
 	return nil
 }
 
+// readonlyTreeFields are the fields of trees which are set when they're
+// created and never change, so update_masks containing them are rejected with
+// a clearer error than unknown paths. The only exception is tree_type, which
+// UpdateTree can change from PREORDERED_LOG to LOG once the tree is frozen,
+// and BatchUpdateTrees rejects as readonly.
+var readonlyTreeFields = map[string]bool{
+	"tree_id":                   true,
+	"tree_type":                 true,
+	"hash_strategy":             true,
+	"hash_algorithm":            true,
+	"signature_algorithm":       true,
+	"public_key":                true,
+	"create_time":               true,
+	"update_time":               true,
+	"deleted":                   true,
+	"delete_time":               true,
+	"ordered_leaf_timestamps":   true,
+	"caller_leaf_identity_hash": true,
+	"hash_extra_data":           true,
+	"namespace":                 true,
+	"log_root_encoding":         true,
+	"timestamp_granularity":     true,
+	"hash_only":                 true,
+	"leaf_compression":          true,
+	"max_tree_size":             true,
+	"queue_write_ahead":         true,
+	"leaf_encryption":           true,
+	"sort_by_queue_timestamp":   true,
+	"leaf_ordering_key":         true,
+	"leaf_tombstones":           true,
+	"empty_root_hash":           true,
+	"leaf_index_offset":         true,
+}
+
+// batchUpdateFields are the fields of trees which BatchUpdateTrees may update:
+// their mutable metadata, which doesn't affect how they're served.
+var batchUpdateFields = map[string]bool{
+	"display_name":      true,
+	"description":       true,
+	"max_root_duration": true,
+}
+
+// BatchUpdateTrees implements trillian.TrillianAdminServer.BatchUpdateTrees.
+func (s *Server) BatchUpdateTrees(ctx context.Context, req *trillian.BatchUpdateTreesRequest) (*trillian.BatchUpdateTreesResponse, error) {
+	reqs := req.GetRequests()
+	seen := make(map[int64]bool)
+	for i, r := range reqs {
+		tree := r.GetTree()
+		if tree == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "requests[%d]: a tree is required", i)
+		}
+		if seen[tree.TreeId] {
+			return nil, status.Errorf(codes.InvalidArgument, "requests[%d]: tree %v is updated more than once", i, tree.TreeId)
+		}
+		seen[tree.TreeId] = true
+		if err := applyUpdateMask(&trillian.Tree{}, &trillian.Tree{}, r.GetUpdateMask()); err != nil {
+			return nil, err
+		}
+		for _, path := range r.GetUpdateMask().GetPaths() {
+			if readonlyTreeFields[path] {
+				return nil, status.Errorf(codes.InvalidArgument, "requests[%d]: readonly field can't be updated: %q", i, path)
+			}
+			if !batchUpdateFields[path] {
+				return nil, status.Errorf(codes.InvalidArgument, "requests[%d]: field %q can't be updated in a batch, use UpdateTree", i, path)
+			}
+		}
+	}
+
+	// All trees are updated in one transaction, so that a failed update
+	// leaves none of them changed.
+	var trees []*trillian.Tree
+	err := s.registry.AdminStorage.ReadWriteTransaction(ctx, func(ctx context.Context, tx storage.AdminTX) error {
+		trees = make([]*trillian.Tree, 0, len(reqs))
+		for _, r := range reqs {
+			// Trees renamed earlier in the batch are seen by the check.
+			if err := s.checkDisplayNameUpdate(ctx, tx, r.Tree, r.UpdateMask); err != nil {
+				return err
+			}
+			updated, err := tx.UpdateTree(ctx, r.Tree.TreeId, func(other *trillian.Tree) {
+				if err := applyUpdateMask(r.Tree, other, r.UpdateMask); err != nil {
+					// Checked above.
+					glog.Errorf("%sError applying mask on tree update: %v", requestid.LogPrefix(ctx), err)
+				}
+			})
+			if err != nil {
+				return err
+			}
+			trees = append(trees, redact(updated))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.BatchUpdateTreesResponse{Trees: trees}, nil
+}
+
 // DeleteTree implements trillian.TrillianAdminServer.DeleteTree.
 func (s *Server) DeleteTree(ctx context.Context, req *trillian.DeleteTreeRequest) (*trillian.Tree, error) {
 	tree, err := storage.SoftDeleteTree(ctx, s.registry.AdminStorage, req.GetTreeId())
 	if err != nil {
 		return nil, err
 	}
+	s.cleanUpQuota(ctx, "delete", tree.TreeId, quota.CleanUpTree)
 	return redact(tree), nil
 }
 
+// cleanUpQuota applies the quota cleanup fn of the operation op to the deleted
+// or undeleted tree. This is best-effort: failures are logged and counted, but
+// don't fail the operation, as the tree has already been updated.
+func (s *Server) cleanUpQuota(ctx context.Context, op string, treeID int64, fn func(context.Context, quota.Manager, int64) error) {
+	qm := s.registry.QuotaManager
+	if qm == nil {
+		return
+	}
+	err := fn(ctx, qm, treeID)
+	if err != nil {
+		glog.Warningf("%v: %sfailed to clean up quota after tree %v: %v", treeID, requestid.LogPrefix(ctx), op, err)
+	}
+	quota.Metrics.IncTreeCleanup(op, err == nil)
+}
+
+// resetTreeQuota restores the default quotas of treeID.
+func resetTreeQuota(ctx context.Context, qm quota.Manager, treeID int64) error {
+	return qm.ResetQuota(ctx, quota.TreeSpecs(treeID))
+}
+
 // UndeleteTree implements trillian.TrillianAdminServer.UndeleteTree.
 func (s *Server) UndeleteTree(ctx context.Context, req *trillian.UndeleteTreeRequest) (*trillian.Tree, error) {
-	tree, err := storage.UndeleteTree(ctx, s.registry.AdminStorage, req.GetTreeId())
+	if s.deleteThreshold == 0 {
+		tree, err := storage.UndeleteTree(ctx, s.registry.AdminStorage, req.GetTreeId())
+		if err != nil {
+			return nil, err
+		}
+		s.cleanUpQuota(ctx, "undelete", tree.TreeId, resetTreeQuota)
+		return redact(tree), nil
+	}
+
+	// Check the deletion time in the same transaction as the undelete, so that
+	// trees can't be undeleted after they become eligible for hard-deletion.
+	var tree *trillian.Tree
+	err := s.registry.AdminStorage.ReadWriteTransaction(ctx, func(ctx context.Context, tx storage.AdminTX) error {
+		stored, err := tx.GetTree(ctx, req.GetTreeId())
+		if err != nil {
+			return err
+		}
+		if stored.Deleted {
+			remaining, err := s.timeUntilHardDelete(stored)
+			if err != nil {
+				return status.Errorf(codes.Internal, "invalid delete_time of tree %v: %v", stored.TreeId, err)
+			}
+			if remaining < 0 {
+				return status.Errorf(codes.FailedPrecondition, "tree %v was deleted more than %v ago and can't be undeleted", stored.TreeId, s.deleteThreshold)
+			}
+		}
+		tree, err = tx.UndeleteTree(ctx, req.GetTreeId())
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	s.cleanUpQuota(ctx, "undelete", tree.TreeId, resetTreeQuota)
 	return redact(tree), nil
 }
 
+// ListSoftDeletedTrees implements trillian.TrillianAdminServer.ListSoftDeletedTrees.
+func (s *Server) ListSoftDeletedTrees(ctx context.Context, req *trillian.ListSoftDeletedTreesRequest) (*trillian.ListSoftDeletedTreesResponse, error) {
+	// TODO(codingllama): This needs access control
+	trees, err := storage.ListTrees(ctx, s.registry.AdminStorage, true /* includeDeleted */)
+	if err != nil {
+		return nil, err
+	}
+	resp := &trillian.ListSoftDeletedTreesResponse{}
+	for _, tree := range trees {
+		if !tree.Deleted {
+			continue
+		}
+		deleted := &trillian.SoftDeletedTree{Tree: redact(tree)}
+		if s.deleteThreshold > 0 {
+			remaining, err := s.timeUntilHardDelete(tree)
+			if err != nil {
+				return nil, status.Errorf(codes.Internal, "invalid delete_time of tree %v: %v", tree.TreeId, err)
+			}
+			if remaining < 0 {
+				remaining = 0
+			}
+			deleted.TimeUntilHardDelete = ptypes.DurationProto(remaining)
+		}
+		resp.Trees = append(resp.Trees, deleted)
+	}
+	return resp, nil
+}
+
+// GetTreeAttestation implements trillian.TrillianAdminServer.GetTreeAttestation.
+func (s *Server) GetTreeAttestation(ctx context.Context, req *trillian.GetTreeAttestationRequest) (*trillian.TreeAttestation, error) {
+	return storage.GetTreeAttestation(ctx, s.registry.AdminStorage, req.GetTreeId())
+}
+
+// RewrapLeafDataKey implements trillian.TrillianAdminServer.RewrapLeafDataKey.
+func (s *Server) RewrapLeafDataKey(ctx context.Context, req *trillian.RewrapLeafDataKeyRequest) (*trillian.Tree, error) {
+	w := s.registry.LeafKeyWrapper
+	if w == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "leaf encryption is not enabled")
+	}
+	tree, err := storage.GetTree(ctx, s.registry.AdminStorage, req.GetTreeId())
+	if err != nil {
+		return nil, err
+	}
+	e := tree.LeafEncryption
+	if e == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "tree %v doesn't have leaf_encryption", tree.TreeId)
+	}
+	key, err := w.Unwrap(ctx, e.WrappedDataKey, e.KekId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unwrap data key: %v", err)
+	}
+	wrapped, kekID, err := w.Wrap(ctx, key)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to wrap data key: %v", err)
+	}
+
+	// The data key of a tree never changes, so it doesn't matter if the tree
+	// is concurrently rewrapped.
+	updated, err := storage.UpdateTree(ctx, s.registry.AdminStorage, tree.TreeId, func(tree *trillian.Tree) {
+		tree.LeafEncryption = &trillian.LeafEncryption{WrappedDataKey: wrapped, KekId: kekID}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return redact(updated), nil
+}
+
+// CompactTreeStorage implements trillian.TrillianAdminServer.CompactTreeStorage.
+func (s *Server) CompactTreeStorage(ctx context.Context, req *trillian.CompactTreeStorageRequest) (*trillian.CompactTreeStorageResponse, error) {
+	tree, err := storage.GetTree(ctx, s.registry.AdminStorage, req.GetTreeId())
+	if err != nil {
+		return nil, err
+	}
+	var ts interface{}
+	switch tree.TreeType {
+	case trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG:
+		ts = s.registry.LogStorage
+	case trillian.TreeType_MAP:
+		ts = s.registry.MapStorage
+	}
+	c, ok := ts.(storage.TreeStorageCompactor)
+	if !ok {
+		return nil, status.Errorf(codes.Unimplemented, "storage of tree %v doesn't support compaction", tree.TreeId)
+	}
+	reclaimed, err := c.CompactTreeStorage(ctx, tree, req.GetOptimize())
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.CompactTreeStorageResponse{BytesReclaimed: reclaimed}, nil
+}
+
+// CreateTreeTemplate implements trillian.TrillianAdminServer.CreateTreeTemplate.
+func (s *Server) CreateTreeTemplate(ctx context.Context, req *trillian.CreateTreeTemplateRequest) (*trillian.TreeTemplate, error) {
+	if err := storage.ValidateTreeTemplate(req.GetTemplate()); err != nil {
+		return nil, err
+	}
+	if err := storage.CreateTreeTemplate(ctx, s.registry.AdminStorage, req.GetTemplate()); err != nil {
+		return nil, err
+	}
+	return req.GetTemplate(), nil
+}
+
+// GetTreeTemplate implements trillian.TrillianAdminServer.GetTreeTemplate.
+func (s *Server) GetTreeTemplate(ctx context.Context, req *trillian.GetTreeTemplateRequest) (*trillian.TreeTemplate, error) {
+	return storage.GetTreeTemplate(ctx, s.registry.AdminStorage, req.GetName())
+}
+
+// ListTreeTemplates implements trillian.TrillianAdminServer.ListTreeTemplates.
+func (s *Server) ListTreeTemplates(ctx context.Context, req *trillian.ListTreeTemplatesRequest) (*trillian.ListTreeTemplatesResponse, error) {
+	tmpls, err := storage.ListTreeTemplates(ctx, s.registry.AdminStorage)
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.ListTreeTemplatesResponse{Templates: tmpls}, nil
+}
+
+// UpdateTreeTemplate implements trillian.TrillianAdminServer.UpdateTreeTemplate.
+func (s *Server) UpdateTreeTemplate(ctx context.Context, req *trillian.UpdateTreeTemplateRequest) (*trillian.TreeTemplate, error) {
+	if err := storage.ValidateTreeTemplate(req.GetTemplate()); err != nil {
+		return nil, err
+	}
+	if err := storage.UpdateTreeTemplate(ctx, s.registry.AdminStorage, req.GetTemplate()); err != nil {
+		return nil, err
+	}
+	return req.GetTemplate(), nil
+}
+
+// DeleteTreeTemplate implements trillian.TrillianAdminServer.DeleteTreeTemplate.
+func (s *Server) DeleteTreeTemplate(ctx context.Context, req *trillian.DeleteTreeTemplateRequest) (*empty.Empty, error) {
+	if err := storage.DeleteTreeTemplate(ctx, s.registry.AdminStorage, req.GetName()); err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
+
+// timeUntilHardDelete returns the time left until the soft-deleted tree
+// becomes eligible for hard-deletion, which is negative if it already is.
+func (s *Server) timeUntilHardDelete(tree *trillian.Tree) (time.Duration, error) {
+	deleteTime, err := ptypes.Timestamp(tree.DeleteTime)
+	if err != nil {
+		return 0, err
+	}
+	return s.deleteThreshold - timeNow().Sub(deleteTime), nil
+}
+
 // redact removes sensitive information from t. Returns t for convenience.
 func redact(t *trillian.Tree) *trillian.Tree {
 	t.PrivateKey = nil
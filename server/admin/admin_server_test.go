@@ -15,12 +15,14 @@
 package admin
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"strings"
@@ -34,12 +36,18 @@ import (
 	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/trillian"
+	tcrypto "github.com/google/trillian/crypto"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/keys/der"
 	"github.com/google/trillian/crypto/keyspb"
 	"github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/quota"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/encryption"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/storage/namespace"
 	"github.com/google/trillian/storage/testonly"
 	"github.com/kylelemons/godebug/pretty"
 	"google.golang.org/genproto/protobuf/field_mask"
@@ -502,6 +510,7 @@ func TestServer_CreateTree(t *testing.T) {
 				newTree.UpdateTime = nowPB
 				newTree.PublicKey, err = der.ToPublicProto(privateKey.Public())
 				tx.EXPECT().CreateTree(gomock.Any(), gomock.Any()).MaxTimes(1).Return(newTree, test.createErr)
+				tx.EXPECT().CreateTreeAttestation(gomock.Any(), newTree.TreeId, gomock.Any()).MaxTimes(1).Return(nil)
 			}
 
 			// Copy test.req so that any changes CreateTree makes don't affect the original, which may be shared between tests.
@@ -532,16 +541,246 @@ func TestServer_CreateTree(t *testing.T) {
 	}
 }
 
+func TestServer_CreateTree_TreeID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// randBytes returns the randomness from which NewTreeIDFrom derives id.
+	randBytes := func(ids ...int64) []byte {
+		var b []byte
+		for _, id := range ids {
+			b = append(b, make([]byte, 8)...)
+			binary.BigEndian.PutUint64(b[len(b)-8:], uint64(id-1))
+		}
+		return b
+	}
+	exists := status.Error(codes.AlreadyExists, "tree already exists")
+
+	tests := []struct {
+		desc       string
+		treeID     int64
+		namespace  string
+		rand       []byte
+		createErrs []error // One per expected storage CreateTree call.
+		wantIDs    []int64
+		wantCode   codes.Code
+	}{
+		{desc: "randomID", rand: randBytes(42), createErrs: []error{nil}, wantIDs: []int64{42}},
+		{desc: "requestedID", treeID: 12345, createErrs: []error{nil}, wantIDs: []int64{12345}},
+		{
+			desc:       "duplicateID",
+			treeID:     12345,
+			rand:       randBytes(42),
+			createErrs: []error{exists},
+			wantIDs:    []int64{12345},
+			wantCode:   codes.AlreadyExists,
+		},
+		{
+			desc:       "randomIDCollision",
+			rand:       randBytes(42, 42, 100),
+			createErrs: []error{exists, exists, nil},
+			wantIDs:    []int64{42, 42, 100},
+		},
+		{
+			desc:       "randomIDExhausted",
+			rand:       randBytes(1, 2, 3, 4, 5, 6),
+			createErrs: []error{exists, exists, exists, exists, exists},
+			wantIDs:    []int64{1, 2, 3, 4, 5},
+			wantCode:   codes.AlreadyExists,
+		},
+		{desc: "randErr", wantCode: codes.Internal},
+		{
+			desc:       "namespaceRandomID",
+			namespace:  "a",
+			rand:       randBytes(42),
+			createErrs: []error{nil},
+			wantIDs:    []int64{42},
+		},
+		{desc: "namespaceRequestedID", treeID: 12345, namespace: "a", wantCode: codes.InvalidArgument},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			as := &testonly.FakeAdminStorage{}
+			var gotIDs []int64
+			for _, createErr := range test.createErrs {
+				tx := storage.NewMockAdminTX(ctrl)
+				tx.EXPECT().Close().Return(nil)
+				if createErr == nil {
+					tx.EXPECT().Commit().Return(nil)
+				}
+				tx.EXPECT().CreateTree(gomock.Any(), gomock.Any()).Do(func(_ context.Context, tree *trillian.Tree) {
+					gotIDs = append(gotIDs, tree.TreeId)
+				}).Return(&trillian.Tree{}, createErr)
+				if createErr == nil {
+					tx.EXPECT().CreateTreeAttestation(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				}
+				as.TX = append(as.TX, tx)
+			}
+			s := New(extension.Registry{AdminStorage: as, Rand: bytes.NewReader(test.rand)}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+
+			tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+			tree.TreeId = test.treeID
+			ctx := ctx
+			if test.namespace != "" {
+				ctx = namespace.NewContext(ctx, test.namespace)
+			}
+			_, err := s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: tree})
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("CreateTree() returned err = %v, wantCode = %s", err, test.wantCode)
+			}
+			if diff := cmp.Diff(gotIDs, test.wantIDs); diff != "" {
+				t.Errorf("storage CreateTree() got TreeIds diff (-got +want):\n%v", diff)
+			}
+		})
+	}
+}
+
+func TestServer_CreateTree_UniqueDisplayNames(t *testing.T) {
+	ctx := context.Background()
+	s := New(extension.Registry{AdminStorage: memory.NewAdminStorage(memory.NewTreeStorage())}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+	create := func(displayName string) (*trillian.Tree, error) {
+		tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+		tree.DisplayName = displayName
+		return s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: tree})
+	}
+
+	first, err := create("dup")
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	// Duplicates are allowed unless UniqueDisplayNames is set.
+	second, err := create("dup")
+	if err != nil {
+		t.Fatalf("CreateTree() of duplicate display name returned err = %v", err)
+	}
+	s.UniqueDisplayNames = true
+	_, err = create("dup")
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateTree() of duplicate display name returned err = %v, want %s", err, codes.AlreadyExists)
+	} else if msg := err.Error(); !strings.Contains(msg, fmt.Sprint(first.TreeId)) && !strings.Contains(msg, fmt.Sprint(second.TreeId)) {
+		t.Errorf("CreateTree() of duplicate display name returned err = %v, want it to name tree %v or %v", err, first.TreeId, second.TreeId)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := create(""); err != nil {
+			t.Errorf("CreateTree() without display name returned err = %v", err)
+		}
+	}
+	if _, err := create("unique"); err != nil {
+		t.Errorf("CreateTree() of unique display name returned err = %v", err)
+	}
+}
+
+func TestServer_UpdateTree_UniqueDisplayNames(t *testing.T) {
+	ctx := context.Background()
+	// newServer returns a server with trees named a, b and c, and their IDs.
+	newServer := func(t *testing.T) (*Server, map[string]int64) {
+		t.Helper()
+		s := New(extension.Registry{AdminStorage: memory.NewAdminStorage(memory.NewTreeStorage())}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+		s.UniqueDisplayNames = true
+		ids := make(map[string]int64)
+		for _, name := range []string{"a", "b", "c"} {
+			tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+			tree.DisplayName = name
+			created, err := s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: tree})
+			if err != nil {
+				t.Fatalf("CreateTree(%q) returned err = %v", name, err)
+			}
+			ids[name] = created.TreeId
+		}
+		return s, ids
+	}
+	rename := func(id int64, to string) *trillian.UpdateTreeRequest {
+		return &trillian.UpdateTreeRequest{
+			Tree:       &trillian.Tree{TreeId: id, DisplayName: to},
+			UpdateMask: &field_mask.FieldMask{Paths: []string{"display_name"}},
+		}
+	}
+
+	s, ids := newServer(t)
+	if _, err := s.UpdateTree(ctx, rename(ids["c"], "a")); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("UpdateTree() to taken display name returned err = %v, want %s", err, codes.AlreadyExists)
+	}
+	if _, err := s.UpdateTree(ctx, rename(ids["a"], "a")); err != nil {
+		t.Errorf("UpdateTree() to own display name returned err = %v", err)
+	}
+
+	// Each tree of a batch is checked, including against those renamed
+	// earlier in the batch.
+	for _, test := range []struct {
+		desc     string
+		renames  [][2]string // From, to.
+		wantCode codes.Code
+	}{
+		{desc: "taken", renames: [][2]string{{"b", "x"}, {"c", "a"}}, wantCode: codes.AlreadyExists},
+		{desc: "takenInBatch", renames: [][2]string{{"b", "x"}, {"c", "x"}}, wantCode: codes.AlreadyExists},
+		{desc: "freedInBatch", renames: [][2]string{{"b", "y"}, {"c", "b"}}},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			s, ids := newServer(t)
+			var reqs []*trillian.UpdateTreeRequest
+			for _, r := range test.renames {
+				reqs = append(reqs, rename(ids[r[0]], r[1]))
+			}
+			_, err := s.BatchUpdateTrees(ctx, &trillian.BatchUpdateTreesRequest{Requests: reqs})
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("BatchUpdateTrees() returned err = %v, wantCode = %s", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestServer_CreateTree_LeafIndexOffset(t *testing.T) {
+	ctx := context.Background()
+	s := New(extension.Registry{AdminStorage: memory.NewAdminStorage(memory.NewTreeStorage())}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+	create := func(offset, maxTreeSize int64) (*trillian.Tree, error) {
+		tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+		tree.LeafIndexOffset = offset
+		tree.MaxTreeSize = maxTreeSize
+		return s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: tree})
+	}
+
+	predecessor, err := create(0, 1000)
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	if _, err := create(500, 0); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateTree() of overlapping offset returned err = %v, want %s", err, codes.AlreadyExists)
+	} else if msg := err.Error(); !strings.Contains(msg, fmt.Sprint(predecessor.TreeId)) {
+		t.Errorf("CreateTree() of overlapping offset returned err = %v, want it to name tree %v", err, predecessor.TreeId)
+	}
+	successor, err := create(1000, 0)
+	if err != nil {
+		t.Fatalf("CreateTree() of successor returned err = %v", err)
+	}
+	if got, want := successor.LeafIndexOffset, int64(1000); got != want {
+		t.Errorf("CreateTree() returned leaf_index_offset %v, want %v", got, want)
+	}
+	// The successor is unbounded, so no later offset is available.
+	if _, err := create(1000000, 0); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateTree() of offset after unbounded log returned err = %v, want %s", err, codes.AlreadyExists)
+	}
+	// Logs without an offset don't conflict with each other.
+	if _, err := create(0, 1000); err != nil {
+		t.Errorf("CreateTree() without offset returned err = %v", err)
+	}
+}
+
 func TestServer_CreateTree_AllowedTreeTypes(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	untypedTree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	untypedTree.TreeType = trillian.TreeType_UNKNOWN_TREE_TYPE
+
 	tests := []struct {
 		desc      string
 		treeTypes []trillian.TreeType
 		req       *trillian.CreateTreeRequest
 		wantCode  codes.Code
 		wantMsg   string
+		wantType  trillian.TreeType
 	}{
 		{
 			desc:      "mapOnLogServer",
@@ -589,6 +828,13 @@ func TestServer_CreateTree_AllowedTreeTypes(t *testing.T) {
 			req:       &trillian.CreateTreeRequest{Tree: testonly.MapTree},
 			wantCode:  codes.OK,
 		},
+		{
+			desc:      "defaultType",
+			treeTypes: []trillian.TreeType{trillian.TreeType_PREORDERED_LOG, trillian.TreeType_LOG},
+			req:       &trillian.CreateTreeRequest{Tree: untypedTree},
+			wantCode:  codes.OK,
+			wantType:  trillian.TreeType_PREORDERED_LOG,
+		},
 		// treeTypes = nil is exercised by all other tests.
 	}
 
@@ -606,14 +852,25 @@ func TestServer_CreateTree_AllowedTreeTypes(t *testing.T) {
 
 		// Storage interactions aren't the focus of this test, so mocks are configured in a rather
 		// permissive way.
-		tx.EXPECT().CreateTree(gomock.Any(), gomock.Any()).AnyTimes().Return(&trillian.Tree{}, nil)
+		var gotType trillian.TreeType
+		tx.EXPECT().CreateTree(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(func(_ context.Context, tree *trillian.Tree) (*trillian.Tree, error) {
+			gotType = tree.TreeType
+			return &trillian.Tree{}, nil
+		})
+		tx.EXPECT().CreateTreeAttestation(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().Return(nil)
 
-		_, err := s.CreateTree(ctx, test.req)
+		req := proto.Clone(test.req).(*trillian.CreateTreeRequest)
+		_, err := s.CreateTree(ctx, req)
+		if !proto.Equal(req, test.req) {
+			t.Errorf("%v: CreateTree() modified the request to %v", test.desc, req)
+		}
 		switch s, ok := status.FromError(err); {
 		case !ok || s.Code() != test.wantCode:
 			t.Errorf("%v: CreateTree() returned err = %v, wantCode = %s", test.desc, err, test.wantCode)
 		case err != nil && !strings.Contains(err.Error(), test.wantMsg):
 			t.Errorf("%v: CreateTree() returned err = %q, wantMsg = %q", test.desc, err, test.wantMsg)
+		case test.wantType != trillian.TreeType_UNKNOWN_TREE_TYPE && gotType != test.wantType:
+			t.Errorf("%v: CreateTree() created tree of type %s, want %s", test.desc, gotType, test.wantType)
 		}
 	}
 }
@@ -639,10 +896,12 @@ func TestServer_UpdateTree(t *testing.T) {
 		Description:     "Brand New Tree Desc",
 		StorageSettings: settings,
 		MaxRootDuration: ptypes.DurationProto(2 * time.Nanosecond),
+		SigningInterval: ptypes.DurationProto(time.Minute),
+		MaxQueueAge:     ptypes.DurationProto(10 * time.Second),
 		PrivateKey:      ttestonly.MustMarshalAny(t, &empty.Empty{}),
 	}
 	successMask := &field_mask.FieldMask{
-		Paths: []string{"tree_state", "display_name", "description", "storage_settings", "max_root_duration", "private_key"},
+		Paths: []string{"tree_state", "display_name", "description", "storage_settings", "max_root_duration", "signing_interval", "max_queue_age", "private_key"},
 	}
 
 	successWant := proto.Clone(existingTree).(*trillian.Tree)
@@ -652,6 +911,8 @@ func TestServer_UpdateTree(t *testing.T) {
 	successWant.StorageSettings = successTree.StorageSettings
 	successWant.PrivateKey = nil // redacted on responses
 	successWant.MaxRootDuration = successTree.MaxRootDuration
+	successWant.SigningInterval = successTree.SigningInterval
+	successWant.MaxQueueAge = successTree.MaxQueueAge
 
 	tests := []struct {
 		desc                           string
@@ -744,6 +1005,132 @@ func TestServer_UpdateTree(t *testing.T) {
 	}
 }
 
+func TestServer_BatchUpdateTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nowPB := ptypes.TimestampNow()
+	existing := make(map[int64]*trillian.Tree)
+	for _, id := range []int64{10, 11} {
+		tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+		tree.TreeId = id
+		tree.CreateTime = nowPB
+		tree.UpdateTime = nowPB
+		existing[id] = tree
+	}
+	update := func(id int64, name string, paths ...string) *trillian.UpdateTreeRequest {
+		return &trillian.UpdateTreeRequest{
+			Tree:       &trillian.Tree{TreeId: id, DisplayName: name, Description: "desc " + name, TreeType: trillian.TreeType_MAP},
+			UpdateMask: &field_mask.FieldMask{Paths: paths},
+		}
+	}
+
+	tests := []struct {
+		desc                 string
+		reqs                 []*trillian.UpdateTreeRequest
+		updateErr            error
+		wantErr, wantCommit  bool
+		wantNames, wantDescs []string
+	}{
+		{
+			desc:       "success",
+			reqs:       []*trillian.UpdateTreeRequest{update(10, "a", "display_name"), update(11, "b", "display_name", "description")},
+			wantCommit: true,
+			wantNames:  []string{"a", "b"},
+			wantDescs:  []string{testonly.LogTree.Description, "desc b"},
+		},
+		{
+			desc:       "empty",
+			wantCommit: true,
+		},
+		{
+			desc:    "nilTree",
+			reqs:    []*trillian.UpdateTreeRequest{update(10, "a", "display_name"), {UpdateMask: &field_mask.FieldMask{Paths: []string{"display_name"}}}},
+			wantErr: true,
+		},
+		{
+			desc:    "emptyUpdateMask",
+			reqs:    []*trillian.UpdateTreeRequest{update(10, "a")},
+			wantErr: true,
+		},
+		{
+			desc:    "duplicateTree",
+			reqs:    []*trillian.UpdateTreeRequest{update(10, "a", "display_name"), update(10, "b", "description")},
+			wantErr: true,
+		},
+		{
+			desc:    "treeType",
+			reqs:    []*trillian.UpdateTreeRequest{update(10, "a", "display_name"), update(11, "b", "tree_type")},
+			wantErr: true,
+		},
+		{
+			desc:    "readonlyField",
+			reqs:    []*trillian.UpdateTreeRequest{update(10, "a", "hash_strategy")},
+			wantErr: true,
+		},
+		{
+			desc:      "updateErr",
+			reqs:      []*trillian.UpdateTreeRequest{update(10, "a", "display_name"), update(11, "b", "display_name")},
+			updateErr: errors.New("error updating tree"),
+			wantErr:   true,
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			setup := setupAdminServer(
+				ctrl,
+				nil,   /* keygen */
+				false, /* snapshot */
+				test.wantCommit,
+				false /* commitErr */)
+
+			for i, req := range test.reqs {
+				if req.Tree == nil || test.wantErr && test.updateErr == nil {
+					continue
+				}
+				var err error
+				if i == len(test.reqs)-1 {
+					err = test.updateErr
+				}
+				current := proto.Clone(existing[req.Tree.TreeId]).(*trillian.Tree)
+				setup.tx.EXPECT().UpdateTree(gomock.Any(), req.Tree.TreeId, gomock.Any()).Do(func(ctx context.Context, treeID int64, updateFn func(*trillian.Tree)) {
+					updateFn(current)
+				}).Return(current, err)
+			}
+
+			resp, err := setup.server.BatchUpdateTrees(ctx, &trillian.BatchUpdateTreesRequest{Requests: test.reqs})
+			if hasErr := err != nil; hasErr != test.wantErr {
+				t.Fatalf("BatchUpdateTrees() returned err = %v, wantErr = %v", err, test.wantErr)
+			} else if hasErr {
+				if got, want := status.Code(err), codes.InvalidArgument; test.updateErr == nil && got != want {
+					t.Errorf("BatchUpdateTrees() returned code %v, want %v", got, want)
+				}
+				return
+			}
+
+			if got, want := len(resp.Trees), len(test.wantNames); got != want {
+				t.Fatalf("BatchUpdateTrees() returned %v trees, want %v", got, want)
+			}
+			for i, tree := range resp.Trees {
+				if got, want := tree.DisplayName, test.wantNames[i]; got != want {
+					t.Errorf("Trees[%v].DisplayName = %q, want %q", i, got, want)
+				}
+				if got, want := tree.Description, test.wantDescs[i]; got != want {
+					t.Errorf("Trees[%v].Description = %q, want %q", i, got, want)
+				}
+				if got, want := tree.TreeType, trillian.TreeType_LOG; got != want {
+					t.Errorf("Trees[%v].TreeType = %v, want %v", i, got, want)
+				}
+				if tree.PrivateKey != nil {
+					t.Errorf("Trees[%v].PrivateKey = %v, want redacted", i, tree.PrivateKey)
+				}
+			}
+		})
+	}
+}
+
 func TestServer_DeleteTree(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -880,6 +1267,48 @@ func TestServer_UndeleteTree(t *testing.T) {
 	}
 }
 
+func TestServer_DeleteUndeleteTreeQuota(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	quota.InitMetrics(monitoring.InertMetricFactory{})
+
+	ctx := context.Background()
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.TreeId = 10
+	for _, resetErr := range []error{nil, errors.New("quota unavailable")} {
+		success := fmt.Sprint(resetErr == nil)
+		for _, op := range []string{"delete", "undelete"} {
+			setup := setupAdminServer(
+				ctrl,
+				nil,   /* keygen */
+				false, /* snapshot */
+				true,  /* shouldCommit */
+				false)
+			qm := quota.NewMockManager(ctrl)
+			qm.EXPECT().ResetQuota(gomock.Any(), quota.TreeSpecs(tree.TreeId)).Return(resetErr)
+			s := setup.server
+			s.registry.QuotaManager = qm
+
+			before := quota.Metrics.TreeCleanups.Value(op, success)
+			// Quota cleanup failures don't fail the operation.
+			var err error
+			if op == "delete" {
+				setup.tx.EXPECT().SoftDeleteTree(gomock.Any(), tree.TreeId).Return(tree, nil)
+				_, err = s.DeleteTree(ctx, &trillian.DeleteTreeRequest{TreeId: tree.TreeId})
+			} else {
+				setup.tx.EXPECT().UndeleteTree(gomock.Any(), tree.TreeId).Return(tree, nil)
+				_, err = s.UndeleteTree(ctx, &trillian.UndeleteTreeRequest{TreeId: tree.TreeId})
+			}
+			if err != nil {
+				t.Errorf("%v (resetErr = %v) returned err = %v", op, resetErr, err)
+			}
+			if got, want := quota.Metrics.TreeCleanups.Value(op, success)-before, 1.0; got != want {
+				t.Errorf("%v (resetErr = %v) counted %v cleanups with success = %v, want %v", op, resetErr, got, success, want)
+			}
+		}
+	}
+}
+
 func TestServer_UndeleteTreeErrors(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -913,6 +1342,438 @@ func TestServer_UndeleteTreeErrors(t *testing.T) {
 	}
 }
 
+func TestServer_UndeleteTreeThreshold(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1000000, 0)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	deletedAt := func(d time.Duration) *trillian.Tree {
+		tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+		tree.TreeId = 10
+		tree.Deleted = true
+		tree.DeleteTime, _ = ptypes.TimestampProto(now.Add(-d))
+		return tree
+	}
+	notDeleted := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	notDeleted.TreeId = 10
+
+	tests := []struct {
+		desc         string
+		tree         *trillian.Tree
+		wantUndelete bool
+		wantCode     codes.Code
+	}{
+		{desc: "withinThreshold", tree: deletedAt(time.Hour), wantUndelete: true},
+		{desc: "atThreshold", tree: deletedAt(24 * time.Hour), wantUndelete: true},
+		{desc: "pastThreshold", tree: deletedAt(25 * time.Hour), wantCode: codes.FailedPrecondition},
+		{desc: "notDeleted", tree: notDeleted, wantUndelete: true, wantCode: codes.FailedPrecondition},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			setup := setupAdminServer(
+				ctrl,
+				nil,   /* keygen */
+				false, /* snapshot */
+				test.wantCode == codes.OK,
+				false /* commitErr */)
+			req := &trillian.UndeleteTreeRequest{TreeId: test.tree.TreeId}
+
+			tx := setup.tx
+			tx.EXPECT().GetTree(gomock.Any(), req.TreeId).Return(test.tree, nil)
+			if test.wantUndelete {
+				var err error
+				if test.wantCode != codes.OK {
+					err = status.Error(test.wantCode, "tree not deleted")
+				}
+				tx.EXPECT().UndeleteTree(gomock.Any(), req.TreeId).Return(test.tree, err)
+			}
+
+			s := setup.server
+			s.deleteThreshold = 24 * time.Hour
+			_, err := s.UndeleteTree(ctx, req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("UndeleteTree() returned err = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestServer_ListSoftDeletedTrees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Unix(1000000, 0)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	activeLog := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	recentLog := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	oldMap := proto.Clone(testonly.MapTree).(*trillian.Tree)
+	for i, tree := range []*trillian.Tree{activeLog, recentLog, oldMap} {
+		tree.TreeId = int64(i) + 10
+	}
+	recentLog.Deleted = true
+	recentLog.DeleteTime, _ = ptypes.TimestampProto(now.Add(-time.Hour))
+	oldMap.Deleted = true
+	oldMap.DeleteTime, _ = ptypes.TimestampProto(now.Add(-48 * time.Hour))
+
+	redacted := func(tree *trillian.Tree) *trillian.Tree {
+		tree = proto.Clone(tree).(*trillian.Tree)
+		tree.PrivateKey = nil
+		return tree
+	}
+
+	tests := []struct {
+		desc            string
+		deleteThreshold time.Duration
+		want            []*trillian.SoftDeletedTree
+	}{
+		{
+			desc: "noThreshold",
+			want: []*trillian.SoftDeletedTree{
+				{Tree: redacted(recentLog)},
+				{Tree: redacted(oldMap)},
+			},
+		},
+		{
+			desc:            "threshold",
+			deleteThreshold: 24 * time.Hour,
+			want: []*trillian.SoftDeletedTree{
+				{Tree: redacted(recentLog), TimeUntilHardDelete: ptypes.DurationProto(23 * time.Hour)},
+				{Tree: redacted(oldMap), TimeUntilHardDelete: ptypes.DurationProto(0)},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			setup := setupAdminServer(
+				ctrl,
+				nil,  /* keygen */
+				true, /* snapshot */
+				true, /* shouldCommit */
+				false /* commitErr */)
+
+			tx := setup.snapshotTX
+			trees := []*trillian.Tree{activeLog, recentLog, oldMap}
+			for i, tree := range trees {
+				trees[i] = proto.Clone(tree).(*trillian.Tree)
+			}
+			tx.EXPECT().ListTrees(gomock.Any(), true).Return(trees, nil)
+
+			s := setup.server
+			s.deleteThreshold = test.deleteThreshold
+			resp, err := s.ListSoftDeletedTrees(ctx, &trillian.ListSoftDeletedTreesRequest{})
+			if err != nil {
+				t.Fatalf("ListSoftDeletedTrees() returned err = %v", err)
+			}
+			want := &trillian.ListSoftDeletedTreesResponse{Trees: test.want}
+			if !proto.Equal(resp, want) {
+				t.Errorf("post-ListSoftDeletedTrees() diff (-got +want):\n%v", pretty.Compare(resp, want))
+			}
+		})
+	}
+}
+
+func TestServer_GetTreeAttestation(t *testing.T) {
+	ctx := context.Background()
+	s := New(extension.Registry{AdminStorage: memory.NewAdminStorage(memory.NewTreeStorage())}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+
+	tree, err := s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: proto.Clone(testonly.LogTree).(*trillian.Tree)})
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	// Updates must not affect the attestation.
+	update := &trillian.Tree{TreeId: tree.TreeId, DisplayName: "renamed", TreeState: trillian.TreeState_FROZEN}
+	mask := &field_mask.FieldMask{Paths: []string{"display_name", "tree_state"}}
+	if _, err := s.UpdateTree(ctx, &trillian.UpdateTreeRequest{Tree: update, UpdateMask: mask}); err != nil {
+		t.Fatalf("UpdateTree() returned err = %v", err)
+	}
+
+	attestation, err := s.GetTreeAttestation(ctx, &trillian.GetTreeAttestationRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("GetTreeAttestation() returned err = %v", err)
+	}
+	pub, err := der.UnmarshalPublicKey(tree.PublicKey.GetDer())
+	if err != nil {
+		t.Fatalf("UnmarshalPublicKey() returned err = %v", err)
+	}
+	got, err := tcrypto.VerifyTreeAttestation(pub, crypto.SHA256, attestation)
+	if err != nil {
+		t.Fatalf("VerifyTreeAttestation() returned err = %v", err)
+	}
+	if want := attestedSettings(tree); !proto.Equal(got, want) {
+		t.Errorf("VerifyTreeAttestation() diff (-got +want):\n%v", pretty.Compare(got, want))
+	}
+	if got.DisplayName != "" || got.TreeState != trillian.TreeState_UNKNOWN_TREE_STATE || got.PrivateKey != nil {
+		t.Errorf("VerifyTreeAttestation() = %v, want no mutable fields", got)
+	}
+	dictTree := &trillian.Tree{LeafCompressionDictionaries: []*trillian.LeafCompressionDictionary{{Version: 1, Dictionary: []byte("dict")}}}
+	if got := attestedSettings(dictTree).LeafCompressionDictionaries; got != nil {
+		t.Errorf("attestedSettings() has leaf_compression_dictionaries = %v, want none", got)
+	}
+
+	if _, err := s.GetTreeAttestation(ctx, &trillian.GetTreeAttestationRequest{TreeId: tree.TreeId + 1}); status.Code(err) != codes.NotFound {
+		t.Errorf("GetTreeAttestation() of unknown tree returned err = %v, wantCode = %s", err, codes.NotFound)
+	}
+}
+
+func TestServer_LeafEncryption(t *testing.T) {
+	ctx := context.Background()
+	kek1, kek2 := bytes.Repeat([]byte{1}, encryption.DataKeySize), bytes.Repeat([]byte{2}, encryption.DataKeySize)
+	keyring, err := encryption.NewKeyring("k1", map[string][]byte{"k1": kek1})
+	if err != nil {
+		t.Fatalf("NewKeyring() returned err = %v", err)
+	}
+	as := memory.NewAdminStorage(memory.NewTreeStorage())
+	s := New(extension.Registry{AdminStorage: as, LeafKeyWrapper: keyring}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+
+	plainTree := func() *trillian.Tree {
+		tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+		tree.TreeId = 0
+		return tree
+	}
+	newTree := func() *trillian.Tree {
+		tree := plainTree()
+		tree.LeafEncryption = &trillian.LeafEncryption{}
+		return tree
+	}
+	tree, err := s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: newTree()})
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	e := tree.LeafEncryption
+	if len(e.GetWrappedDataKey()) == 0 || e.GetKekId() != "k1" {
+		t.Fatalf("CreateTree() returned leaf_encryption = %v, want a data key wrapped by k1", e)
+	}
+	key, err := keyring.Unwrap(ctx, e.WrappedDataKey, e.KekId)
+	if err != nil {
+		t.Fatalf("Unwrap() returned err = %v", err)
+	}
+
+	// After the KEK is rotated, the same data key is rewrapped by the new one.
+	rotated, err := encryption.NewKeyring("k2", map[string][]byte{"k1": kek1, "k2": kek2})
+	if err != nil {
+		t.Fatalf("NewKeyring() returned err = %v", err)
+	}
+	s = New(extension.Registry{AdminStorage: as, LeafKeyWrapper: rotated}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+	rewrapped, err := s.RewrapLeafDataKey(ctx, &trillian.RewrapLeafDataKeyRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("RewrapLeafDataKey() returned err = %v", err)
+	}
+	if got, want := rewrapped.LeafEncryption.GetKekId(), "k2"; got != want {
+		t.Errorf("RewrapLeafDataKey() returned kek_id = %q, want %q", got, want)
+	}
+	if got, err := rotated.Unwrap(ctx, rewrapped.LeafEncryption.WrappedDataKey, rewrapped.LeafEncryption.KekId); err != nil || !bytes.Equal(got, key) {
+		t.Errorf("Unwrap() of rewrapped key = %x, %v; want %x, nil", got, err, key)
+	}
+	stored, err := s.GetTree(ctx, &trillian.GetTreeRequest{TreeId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("GetTree() returned err = %v", err)
+	}
+	if !proto.Equal(stored.LeafEncryption, rewrapped.LeafEncryption) {
+		t.Errorf("GetTree() returned leaf_encryption = %v, want %v", stored.LeafEncryption, rewrapped.LeafEncryption)
+	}
+
+	// The attestation covers that leaves are encrypted, but not the key.
+	if _, err := s.GetTreeAttestation(ctx, &trillian.GetTreeAttestationRequest{TreeId: tree.TreeId}); err != nil {
+		t.Errorf("GetTreeAttestation() returned err = %v", err)
+	}
+	if got := attestedSettings(rewrapped).LeafEncryption; !proto.Equal(got, attestedSettings(tree).LeafEncryption) || len(got.GetWrappedDataKey()) != 0 {
+		t.Errorf("attestedSettings() has leaf_encryption = %v, want empty", got)
+	}
+
+	plain, err := s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: plainTree()})
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	withKey := newTree()
+	withKey.LeafEncryption = e
+	noWrapper := New(extension.Registry{AdminStorage: as}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+	for _, test := range []struct {
+		desc     string
+		fn       func() error
+		wantCode codes.Code
+	}{
+		{
+			desc: "createWithKey",
+			fn: func() error {
+				_, err := s.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: withKey})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "createWithoutWrapper",
+			fn: func() error {
+				_, err := noWrapper.CreateTree(ctx, &trillian.CreateTreeRequest{Tree: newTree()})
+				return err
+			},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			desc: "rewrapWithoutWrapper",
+			fn: func() error {
+				_, err := noWrapper.RewrapLeafDataKey(ctx, &trillian.RewrapLeafDataKeyRequest{TreeId: tree.TreeId})
+				return err
+			},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			desc: "rewrapUnencrypted",
+			fn: func() error {
+				_, err := s.RewrapLeafDataKey(ctx, &trillian.RewrapLeafDataKeyRequest{TreeId: plain.TreeId})
+				return err
+			},
+			wantCode: codes.FailedPrecondition,
+		},
+	} {
+		if err := test.fn(); status.Code(err) != test.wantCode {
+			t.Errorf("%v: returned err = %v, wantCode = %s", test.desc, err, test.wantCode)
+		}
+	}
+}
+
+// fakeCompactor is a LogStorage which records compactions.
+type fakeCompactor struct {
+	storage.LogStorage
+	tree     *trillian.Tree
+	optimize bool
+}
+
+func (c *fakeCompactor) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	c.tree, c.optimize = tree, optimize
+	return 42, nil
+}
+
+func TestServer_CompactTreeStorage(t *testing.T) {
+	ctx := context.Background()
+	as := memory.NewAdminStorage(memory.NewTreeStorage())
+	logTree, err := storage.CreateTree(ctx, as, testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	mapTree, err := storage.CreateTree(ctx, as, testonly.MapTree)
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+
+	c := &fakeCompactor{}
+	s := New(extension.Registry{AdminStorage: as, LogStorage: c}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+	resp, err := s.CompactTreeStorage(ctx, &trillian.CompactTreeStorageRequest{TreeId: logTree.TreeId, Optimize: true})
+	if err != nil {
+		t.Fatalf("CompactTreeStorage() returned err = %v", err)
+	}
+	if got, want := resp.BytesReclaimed, int64(42); got != want {
+		t.Errorf("CompactTreeStorage() returned bytes_reclaimed = %v, want %v", got, want)
+	}
+	if c.tree.GetTreeId() != logTree.TreeId || !c.optimize {
+		t.Errorf("CompactTreeStorage() compacted tree %v with optimize = %v, want tree %v with optimize", c.tree.GetTreeId(), c.optimize, logTree.TreeId)
+	}
+
+	if _, err := s.CompactTreeStorage(ctx, &trillian.CompactTreeStorageRequest{TreeId: mapTree.TreeId}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("CompactTreeStorage() of map without compactor returned err = %v, wantCode = %s", err, codes.Unimplemented)
+	}
+}
+
+func TestServer_TreeTemplates(t *testing.T) {
+	ctx := context.Background()
+	s := New(extension.Registry{AdminStorage: memory.NewAdminStorage(memory.NewTreeStorage())}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+
+	ct := &trillian.TreeTemplate{
+		Name: "ct",
+		Tree: &trillian.Tree{
+			TreeType:     trillian.TreeType_LOG,
+			HashStrategy: trillian.HashStrategy_RFC6962_SHA256,
+		},
+	}
+	if _, err := s.CreateTreeTemplate(ctx, &trillian.CreateTreeTemplateRequest{Template: ct}); err != nil {
+		t.Fatalf("CreateTreeTemplate() returned err = %v", err)
+	}
+
+	invalid := proto.Clone(ct).(*trillian.TreeTemplate)
+	invalid.Tree.TreeId = 12345
+	for _, test := range []struct {
+		desc     string
+		call     func() error
+		wantCode codes.Code
+	}{
+		{
+			desc: "createExisting",
+			call: func() error {
+				_, err := s.CreateTreeTemplate(ctx, &trillian.CreateTreeTemplateRequest{Template: ct})
+				return err
+			},
+			wantCode: codes.AlreadyExists,
+		},
+		{
+			desc: "createInvalid",
+			call: func() error {
+				_, err := s.CreateTreeTemplate(ctx, &trillian.CreateTreeTemplateRequest{Template: invalid})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "updateInvalid",
+			call: func() error {
+				_, err := s.UpdateTreeTemplate(ctx, &trillian.UpdateTreeTemplateRequest{Template: invalid})
+				return err
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "getMissing",
+			call: func() error {
+				_, err := s.GetTreeTemplate(ctx, &trillian.GetTreeTemplateRequest{Name: "missing"})
+				return err
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			desc: "deleteMissing",
+			call: func() error {
+				_, err := s.DeleteTreeTemplate(ctx, &trillian.DeleteTreeTemplateRequest{Name: "missing"})
+				return err
+			},
+			wantCode: codes.NotFound,
+		},
+	} {
+		if err := test.call(); status.Code(err) != test.wantCode {
+			t.Errorf("%v: got err = %v, wantCode = %s", test.desc, err, test.wantCode)
+		}
+	}
+
+	updated := proto.Clone(ct).(*trillian.TreeTemplate)
+	updated.Tree.MaxRootDuration = ptypes.DurationProto(24 * time.Hour)
+	if _, err := s.UpdateTreeTemplate(ctx, &trillian.UpdateTreeTemplateRequest{Template: updated}); err != nil {
+		t.Fatalf("UpdateTreeTemplate() returned err = %v", err)
+	}
+	got, err := s.GetTreeTemplate(ctx, &trillian.GetTreeTemplateRequest{Name: ct.Name})
+	if err != nil {
+		t.Fatalf("GetTreeTemplate() returned err = %v", err)
+	}
+	if !proto.Equal(got, updated) {
+		t.Errorf("GetTreeTemplate() diff (-got +want):\n%v", pretty.Compare(got, updated))
+	}
+	resp, err := s.ListTreeTemplates(ctx, &trillian.ListTreeTemplatesRequest{})
+	if err != nil {
+		t.Fatalf("ListTreeTemplates() returned err = %v", err)
+	}
+	if want := (&trillian.ListTreeTemplatesResponse{Templates: []*trillian.TreeTemplate{updated}}); !proto.Equal(resp, want) {
+		t.Errorf("ListTreeTemplates() diff (-got +want):\n%v", pretty.Compare(resp, want))
+	}
+	if _, err := s.DeleteTreeTemplate(ctx, &trillian.DeleteTreeTemplateRequest{Name: ct.Name}); err != nil {
+		t.Fatalf("DeleteTreeTemplate() returned err = %v", err)
+	}
+}
+
 // adminTestSetup contains an operational Server and required dependencies.
 // It's created via setupAdminServer.
 type adminTestSetup struct {
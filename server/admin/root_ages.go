@@ -0,0 +1,239 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	treeRootAge       monitoring.Gauge
+	treesOlderThan    monitoring.Gauge
+	rootAgeMetricOnce sync.Once
+)
+
+// logRootAge is the age of the latest signed root of a log.
+type logRootAge struct {
+	treeID int64
+	// hasRoot is false if the log doesn't have a signed root yet.
+	hasRoot bool
+	age     time.Duration
+}
+
+// GetRootAges implements trillian.TrillianAdminServer.GetRootAges.
+func (s *Server) GetRootAges(ctx context.Context, req *trillian.GetRootAgesRequest) (*trillian.GetRootAgesResponse, error) {
+	if s.registry.LogStorage == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "server doesn't serve logs")
+	}
+	thresholds := make([]time.Duration, 0, len(req.GetThresholds()))
+	for _, pb := range req.GetThresholds() {
+		d, err := ptypes.Duration(pb)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid threshold: %v", err)
+		}
+		if d <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "threshold must be positive, got %v", d)
+		}
+		thresholds = append(thresholds, d)
+	}
+
+	ages, err := rootAges(ctx, s.registry.AdminStorage, s.registry.LogStorage, timeNow())
+	if err != nil {
+		return nil, err
+	}
+	resp := &trillian.GetRootAgesResponse{}
+	for _, a := range ages {
+		age := &trillian.TreeRootAge{TreeId: a.treeID}
+		if a.hasRoot {
+			age.Age = ptypes.DurationProto(a.age)
+		}
+		resp.Trees = append(resp.Trees, age)
+	}
+	for i, count := range countOlderThan(ages, thresholds) {
+		resp.OlderThan = append(resp.OlderThan, &trillian.RootAgeCount{
+			Threshold: req.Thresholds[i],
+			TreeCount: count,
+		})
+	}
+	return resp, nil
+}
+
+// rootAges returns the ages at now of the latest signed roots of all active
+// and draining logs, ordered by tree ID. Frozen logs are skipped, as their roots
+// aren't refreshed, and so are logs whose root can't be read, which are logged.
+func rootAges(ctx context.Context, admin storage.AdminStorage, logs storage.LogStorage, now time.Time) ([]logRootAge, error) {
+	trees, err := storage.ListTrees(ctx, admin, false /* includeDeleted */)
+	if err != nil {
+		return nil, err
+	}
+	var ages []logRootAge
+	for _, tree := range trees {
+		switch tree.TreeType {
+		case trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG:
+		default:
+			continue
+		}
+		switch tree.TreeState {
+		case trillian.TreeState_ACTIVE, trillian.TreeState_DRAINING:
+		default:
+			continue
+		}
+		age, err := rootAge(ctx, logs, tree, now)
+		if err != nil {
+			glog.Warningf("%v: failed to read root for root age: %v", tree.TreeId, err)
+			continue
+		}
+		ages = append(ages, age)
+	}
+	sort.Slice(ages, func(i, j int) bool { return ages[i].treeID < ages[j].treeID })
+	return ages, nil
+}
+
+// rootAge returns the age at now of the latest signed root of tree.
+func rootAge(ctx context.Context, logs storage.LogStorage, tree *trillian.Tree, now time.Time) (logRootAge, error) {
+	ret := logRootAge{treeID: tree.TreeId}
+	tx, err := logs.SnapshotForTree(ctx, tree)
+	if tx != nil {
+		defer tx.Close()
+	}
+	if err == storage.ErrTreeNeedsInit {
+		return ret, nil
+	} else if err != nil {
+		return ret, err
+	}
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err == storage.ErrTreeNeedsInit {
+		return ret, nil
+	} else if err != nil {
+		return ret, err
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return ret, fmt.Errorf("could not read log root: %v", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return ret, err
+	}
+	ret.hasRoot = true
+	// Don't report negative ages if the signer's clock is ahead of ours.
+	if age := now.Sub(time.Unix(0, int64(root.TimestampNanos))); age > 0 {
+		ret.age = age
+	}
+	return ret, nil
+}
+
+// countOlderThan returns the number of ages older than each threshold,
+// counting logs without a root as older than all of them.
+func countOlderThan(ages []logRootAge, thresholds []time.Duration) []int64 {
+	counts := make([]int64, len(thresholds))
+	for _, a := range ages {
+		for i, threshold := range thresholds {
+			if !a.hasRoot || a.age > threshold {
+				counts[i]++
+			}
+		}
+	}
+	return counts
+}
+
+// RootAgeMonitor periodically exports the ages of the latest signed roots of
+// all logs as metrics, so that stale logs can be alerted on from one place:
+// tree_root_age_seconds holds the age of each log which has a root, and
+// trees_root_older_than holds the number of logs older than each threshold.
+type RootAgeMonitor struct {
+	admin      storage.AdminStorage
+	logs       storage.LogStorage
+	thresholds []time.Duration
+	interval   time.Duration
+	// exported holds the IDs of the logs whose age was exported by the
+	// latest RunOnce, so that those of logs which are gone can be deleted.
+	exported map[int64]bool
+}
+
+// NewRootAgeMonitor returns a new RootAgeMonitor, which exports the ages of
+// logs every interval.
+func NewRootAgeMonitor(admin storage.AdminStorage, logs storage.LogStorage, thresholds []time.Duration, interval time.Duration, mf monitoring.MetricFactory) *RootAgeMonitor {
+	rootAgeMetricOnce.Do(func() {
+		if mf == nil {
+			mf = monitoring.InertMetricFactory{}
+		}
+		treeRootAge = mf.NewGauge("tree_root_age_seconds", "Age of the latest signed root of the log", monitoring.TreeIDLabel)
+		treesOlderThan = mf.NewGauge("trees_root_older_than", "Number of active and draining logs whose latest signed root is older than the threshold, or which have no root", "threshold")
+	})
+	return &RootAgeMonitor{
+		admin:      admin,
+		logs:       logs,
+		thresholds: thresholds,
+		interval:   interval,
+	}
+}
+
+// Run exports the ages of logs every interval until ctx is cancelled.
+func (m *RootAgeMonitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		if err := m.RunOnce(ctx); err != nil {
+			glog.Errorf("RootAgeMonitor.Run: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce exports the current ages of logs.
+func (m *RootAgeMonitor) RunOnce(ctx context.Context) error {
+	ages, err := rootAges(ctx, m.admin, m.logs, timeNow())
+	if err != nil {
+		return err
+	}
+	exported := make(map[int64]bool, len(ages))
+	for _, a := range ages {
+		if a.hasRoot {
+			treeRootAge.Set(a.age.Seconds(), fmt.Sprint(a.treeID))
+			exported[a.treeID] = true
+		}
+	}
+	// Stop exporting the ages of logs which were deleted, frozen or became
+	// unreadable, rather than leaving their last age.
+	if d, ok := treeRootAge.(monitoring.GaugeDeleter); ok {
+		for id := range m.exported {
+			if !exported[id] {
+				d.Delete(fmt.Sprint(id))
+			}
+		}
+	}
+	m.exported = exported
+	for i, count := range countOlderThan(ages, m.thresholds) {
+		treesOlderThan.Set(float64(count), m.thresholds[i].String())
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rootAgeFixture holds logs whose latest roots have different ages.
+type rootAgeFixture struct {
+	as storage.AdminStorage
+	ls storage.LogStorage
+	// fresh has a root 10s old, stale one 10m old, and uninit has none.
+	fresh, stale, uninit int64
+}
+
+func newRootAgeFixture(ctx context.Context, t *testing.T, now time.Time) *rootAgeFixture {
+	t.Helper()
+	ts := memory.NewTreeStorage()
+	f := &rootAgeFixture{as: memory.NewAdminStorage(ts), ls: memory.NewLogStorage(ts, nil)}
+
+	newLog := func(age time.Duration) *trillian.Tree {
+		tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+		tree.TreeId = 0
+		tree, err := storage.CreateTree(ctx, f.as, tree)
+		if err != nil {
+			t.Fatalf("CreateTree(): %v", err)
+		}
+		if age == 0 {
+			return tree
+		}
+		logRoot, err := (&types.LogRootV1{
+			RootHash:       rfc6962.DefaultHasher.EmptyRoot(),
+			TimestampNanos: uint64(now.Add(-age).UnixNano()),
+		}).MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(): %v", err)
+		}
+		if err := f.ls.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+			return tx.StoreSignedLogRoot(ctx, &trillian.SignedLogRoot{LogRoot: logRoot})
+		}); err != nil {
+			t.Fatalf("StoreSignedLogRoot(): %v", err)
+		}
+		return tree
+	}
+	f.fresh = newLog(10 * time.Second).TreeId
+	f.stale = newLog(10 * time.Minute).TreeId
+	f.uninit = newLog(0).TreeId
+
+	// Logs whose root can't be read are skipped.
+	f.ls = &failingLogStorage{LogStorage: f.ls, treeID: newLog(time.Hour).TreeId}
+
+	// Frozen logs are skipped, however old their roots are.
+	frozen := newLog(time.Hour)
+	if _, err := storage.UpdateTree(ctx, f.as, frozen.TreeId, func(tree *trillian.Tree) {
+		tree.TreeState = trillian.TreeState_FROZEN
+	}); err != nil {
+		t.Fatalf("UpdateTree(): %v", err)
+	}
+	return f
+}
+
+// failingLogStorage fails to read the tree with treeID.
+type failingLogStorage struct {
+	storage.LogStorage
+	treeID int64
+}
+
+func (s *failingLogStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (storage.ReadOnlyLogTreeTX, error) {
+	if tree.TreeId == s.treeID {
+		return nil, errors.New("unreadable tree")
+	}
+	return s.LogStorage.SnapshotForTree(ctx, tree)
+}
+
+func TestServer_GetRootAges(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1600000000, 0)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	f := newRootAgeFixture(ctx, t, now)
+	s := New(extension.Registry{AdminStorage: f.as, LogStorage: f.ls}, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+
+	thresholds := []*duration.Duration{ptypes.DurationProto(time.Minute), ptypes.DurationProto(time.Hour)}
+	resp, err := s.GetRootAges(ctx, &trillian.GetRootAgesRequest{Thresholds: thresholds})
+	if err != nil {
+		t.Fatalf("GetRootAges() returned err = %v", err)
+	}
+	want := &trillian.GetRootAgesResponse{
+		Trees: []*trillian.TreeRootAge{
+			{TreeId: f.fresh, Age: ptypes.DurationProto(10 * time.Second)},
+			{TreeId: f.stale, Age: ptypes.DurationProto(10 * time.Minute)},
+			{TreeId: f.uninit},
+		},
+		OlderThan: []*trillian.RootAgeCount{
+			{Threshold: thresholds[0], TreeCount: 2},
+			{Threshold: thresholds[1], TreeCount: 1},
+		},
+	}
+	sort.Slice(want.Trees, func(i, j int) bool { return want.Trees[i].TreeId < want.Trees[j].TreeId })
+	if !proto.Equal(resp, want) {
+		t.Errorf("GetRootAges() returned %v, want %v", resp, want)
+	}
+
+	for _, test := range []struct {
+		desc     string
+		registry extension.Registry
+		req      *trillian.GetRootAgesRequest
+		wantCode codes.Code
+	}{
+		{
+			desc:     "negativeThreshold",
+			registry: extension.Registry{AdminStorage: f.as, LogStorage: f.ls},
+			req:      &trillian.GetRootAgesRequest{Thresholds: []*duration.Duration{ptypes.DurationProto(-time.Minute)}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "noLogStorage",
+			registry: extension.Registry{AdminStorage: f.as},
+			req:      &trillian.GetRootAgesRequest{},
+			wantCode: codes.FailedPrecondition,
+		},
+	} {
+		s := New(test.registry, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
+		if _, err := s.GetRootAges(ctx, test.req); status.Code(err) != test.wantCode {
+			t.Errorf("%v: GetRootAges() returned err = %v, want code %v", test.desc, err, test.wantCode)
+		}
+	}
+}
+
+func TestRootAgeMonitor_RunOnce(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1600000000, 0)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	f := newRootAgeFixture(ctx, t, now)
+	m := NewRootAgeMonitor(f.as, f.ls, []time.Duration{time.Minute, time.Hour}, time.Minute, monitoring.InertMetricFactory{})
+	if err := m.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce() returned err = %v", err)
+	}
+
+	for _, test := range []struct {
+		gauge  monitoring.Gauge
+		labels []string
+		want   float64
+	}{
+		{gauge: treeRootAge, labels: []string{fmt.Sprint(f.fresh)}, want: 10},
+		{gauge: treeRootAge, labels: []string{fmt.Sprint(f.stale)}, want: 600},
+		{gauge: treesOlderThan, labels: []string{"1m0s"}, want: 2},
+		{gauge: treesOlderThan, labels: []string{"1h0m0s"}, want: 1},
+	} {
+		if got := test.gauge.Value(test.labels...); got != test.want {
+			t.Errorf("gauge%v = %v, want %v", test.labels, got, test.want)
+		}
+	}
+
+	// The ages of logs which are no longer monitored, e.g. frozen or deleted
+	// ones, stop being exported.
+	if _, err := storage.UpdateTree(ctx, f.as, f.stale, func(tree *trillian.Tree) {
+		tree.TreeState = trillian.TreeState_FROZEN
+	}); err != nil {
+		t.Fatalf("UpdateTree(): %v", err)
+	}
+	if err := m.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce() returned err = %v", err)
+	}
+	if got := treeRootAge.Value(fmt.Sprint(f.stale)); got != 0 {
+		t.Errorf("gauge[%v] after freezing = %v, want 0", f.stale, got)
+	}
+	if got, want := treeRootAge.Value(fmt.Sprint(f.fresh)), 10.0; got != want {
+		t.Errorf("gauge[%v] = %v, want %v", f.fresh, got, want)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaos injects faults into the RPCs of a server, so that the retry
+// and alerting paths of its clients can be exercised during chaos testing.
+//
+// It must never be used in production. To make it impossible to enable by
+// accident, New fails unless the binary is built with the "chaos" build tag,
+// servers only call it if started with --chaos_fault_injection, and no faults
+// are injected until they are configured through the Chaos service.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian/server/chaos/chaospb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// servicePrefix is the prefix of the methods of the Chaos service, which
+// faults can't be injected into so that they can always be turned off.
+const servicePrefix = "/chaospb.Chaos/"
+
+// fault is a validated chaospb.Fault.
+type fault struct {
+	percent float64
+	delay   time.Duration
+	code    codes.Code
+}
+
+// Injector injects the configured faults into RPCs through its
+// UnaryInterceptor, and implements chaospb.ChaosServer to configure them.
+type Injector struct {
+	// percent returns a random percentage in [0, 100).
+	percent func() float64
+
+	mu     sync.RWMutex
+	config *chaospb.Config
+	faults map[string]fault // By method.
+}
+
+// New returns an Injector which doesn't inject any faults until they are set
+// through SetConfig. It returns an error if the binary isn't built with the
+// chaos build tag.
+func New() (*Injector, error) {
+	if !Enabled {
+		return nil, errors.New("chaos: fault injection requires a binary built with the chaos build tag")
+	}
+	glog.Warning("Chaos: fault injection is available, this server must not be used in production")
+	return newInjector(), nil
+}
+
+func newInjector() *Injector {
+	return &Injector{
+		percent: func() float64 { return rand.Float64() * 100 },
+		config:  &chaospb.Config{},
+		faults:  make(map[string]fault),
+	}
+}
+
+// UnaryInterceptor injects the configured fault of the called method, if any,
+// into the percentage of calls it applies to.
+func (i *Injector) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	i.mu.RLock()
+	f, ok := i.faults[info.FullMethod]
+	i.mu.RUnlock()
+	if !ok || i.percent() >= f.percent {
+		return handler(ctx, req)
+	}
+
+	if f.delay > 0 {
+		t := time.NewTimer(f.delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+	}
+	if f.code != codes.OK {
+		return nil, status.Errorf(f.code, "chaos: injected fault in %v", info.FullMethod)
+	}
+	return handler(ctx, req)
+}
+
+// SetConfig implements chaospb.ChaosServer.SetConfig.
+func (i *Injector) SetConfig(ctx context.Context, config *chaospb.Config) (*chaospb.Config, error) {
+	faults := make(map[string]fault)
+	for _, f := range config.GetFaults() {
+		if _, ok := faults[f.Method]; ok {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate fault for method %q", f.Method)
+		}
+		v, err := validate(f)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid fault for method %q: %v", f.Method, err)
+		}
+		faults[f.Method] = v
+	}
+
+	config = proto.Clone(config).(*chaospb.Config)
+	i.mu.Lock()
+	i.config, i.faults = config, faults
+	i.mu.Unlock()
+
+	if len(faults) == 0 {
+		glog.Warning("Chaos: fault injection turned off")
+	} else {
+		glog.Warningf("Chaos: injecting faults: %v", config)
+	}
+	return config, nil
+}
+
+// GetConfig implements chaospb.ChaosServer.GetConfig.
+func (i *Injector) GetConfig(ctx context.Context, req *chaospb.GetConfigRequest) (*chaospb.Config, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return proto.Clone(i.config).(*chaospb.Config), nil
+}
+
+func validate(f *chaospb.Fault) (fault, error) {
+	var v fault
+	switch {
+	case !strings.HasPrefix(f.Method, "/") || strings.Count(f.Method, "/") != 2:
+		return v, errors.New("method must be a full method name, e.g. /trillian.TrillianLog/QueueLeaves")
+	case strings.HasPrefix(f.Method, servicePrefix):
+		return v, errors.New("faults can't be injected into the Chaos service")
+	case f.Percent <= 0 || f.Percent > 100:
+		return v, fmt.Errorf("percent %v must be in (0, 100]", f.Percent)
+	case f.Code < 0 || codes.Code(f.Code) > codes.Unauthenticated:
+		return v, fmt.Errorf("unknown code %d", f.Code)
+	}
+	v.percent = f.Percent
+	v.code = codes.Code(f.Code)
+	if f.Delay != nil {
+		var err error
+		if v.delay, err = ptypes.Duration(f.Delay); err != nil {
+			return v, fmt.Errorf("invalid delay: %v", err)
+		}
+		if v.delay < 0 {
+			return v, fmt.Errorf("delay %v must not be negative", v.delay)
+		}
+	}
+	if v.delay == 0 && v.code == codes.OK {
+		return v, errors.New("a delay or code is required")
+	}
+	return v, nil
+}
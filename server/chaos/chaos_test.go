@@ -0,0 +1,149 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian/server/chaos/chaospb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const queueLeaves = "/trillian.TrillianLog/QueueLeaves"
+
+func TestNew(t *testing.T) {
+	i, err := New()
+	if got := err == nil; got != Enabled {
+		t.Errorf("New() = %v, %v; want success: %v", i, err, Enabled)
+	}
+}
+
+func TestSetConfig_Invalid(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc  string
+		fault *chaospb.Fault
+	}{
+		{desc: "noMethod", fault: &chaospb.Fault{Percent: 50, Code: int32(codes.Unavailable)}},
+		{desc: "shortMethod", fault: &chaospb.Fault{Method: "QueueLeaves", Percent: 50, Code: int32(codes.Unavailable)}},
+		{desc: "chaosMethod", fault: &chaospb.Fault{Method: "/chaospb.Chaos/SetConfig", Percent: 50, Code: int32(codes.Unavailable)}},
+		{desc: "zeroPercent", fault: &chaospb.Fault{Method: queueLeaves, Code: int32(codes.Unavailable)}},
+		{desc: "overPercent", fault: &chaospb.Fault{Method: queueLeaves, Percent: 101, Code: int32(codes.Unavailable)}},
+		{desc: "unknownCode", fault: &chaospb.Fault{Method: queueLeaves, Percent: 50, Code: 100}},
+		{desc: "negativeDelay", fault: &chaospb.Fault{Method: queueLeaves, Percent: 50, Delay: ptypes.DurationProto(-time.Second)}},
+		{desc: "noEffect", fault: &chaospb.Fault{Method: queueLeaves, Percent: 50}},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			i := newInjector()
+			_, err := i.SetConfig(ctx, &chaospb.Config{Faults: []*chaospb.Fault{test.fault}})
+			if got, want := status.Code(err), codes.InvalidArgument; got != want {
+				t.Errorf("SetConfig() returned err = %v, want code %s", err, want)
+			}
+		})
+	}
+
+	i := newInjector()
+	f := &chaospb.Fault{Method: queueLeaves, Percent: 50, Code: int32(codes.Unavailable)}
+	if _, err := i.SetConfig(ctx, &chaospb.Config{Faults: []*chaospb.Fault{f, f}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SetConfig() with duplicate faults returned err = %v, want code %s", err, codes.InvalidArgument)
+	}
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	ctx := context.Background()
+	i := newInjector()
+	config := &chaospb.Config{Faults: []*chaospb.Fault{
+		{Method: queueLeaves, Percent: 25, Code: int32(codes.Unavailable)},
+		{Method: "/trillian.TrillianLog/GetLatestSignedLogRoot", Percent: 100, Delay: ptypes.DurationProto(10 * time.Millisecond)},
+	}}
+	if _, err := i.SetConfig(ctx, config); err != nil {
+		t.Fatalf("SetConfig(): %v", err)
+	}
+	got, err := i.GetConfig(ctx, &chaospb.GetConfigRequest{})
+	if err != nil {
+		t.Fatalf("GetConfig(): %v", err)
+	}
+	if !proto.Equal(got, config) {
+		t.Errorf("GetConfig() = %v, want %v", got, config)
+	}
+
+	for _, test := range []struct {
+		desc        string
+		method      string
+		percent     float64
+		wantCode    codes.Code
+		wantHandled bool
+		wantDelay   time.Duration
+	}{
+		{desc: "failed", method: queueLeaves, percent: 24.9, wantCode: codes.Unavailable},
+		{desc: "notAffected", method: queueLeaves, percent: 25, wantHandled: true},
+		{desc: "delayed", method: "/trillian.TrillianLog/GetLatestSignedLogRoot", percent: 99.9, wantHandled: true, wantDelay: 10 * time.Millisecond},
+		{desc: "noFault", method: "/trillian.TrillianLog/GetLeavesByIndex", wantHandled: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			i.percent = func() float64 { return test.percent }
+			handled := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				handled = true
+				return "resp", nil
+			}
+			start := time.Now()
+			_, err := i.UnaryInterceptor(ctx, "req", &grpc.UnaryServerInfo{FullMethod: test.method}, handler)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("UnaryInterceptor() returned err = %v, want code %s", err, test.wantCode)
+			}
+			if handled != test.wantHandled {
+				t.Errorf("UnaryInterceptor() called handler: %v, want %v", handled, test.wantHandled)
+			}
+			if elapsed := time.Since(start); elapsed < test.wantDelay {
+				t.Errorf("UnaryInterceptor() took %v, want at least %v", elapsed, test.wantDelay)
+			}
+		})
+	}
+
+	// Turning fault injection off.
+	if _, err := i.SetConfig(ctx, &chaospb.Config{}); err != nil {
+		t.Fatalf("SetConfig(): %v", err)
+	}
+	i.percent = func() float64 { return 0 }
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return "resp", nil }
+	if _, err := i.UnaryInterceptor(ctx, "req", &grpc.UnaryServerInfo{FullMethod: queueLeaves}, handler); err != nil {
+		t.Errorf("UnaryInterceptor() after turning faults off returned err = %v", err)
+	}
+}
+
+func TestUnaryInterceptor_DelayCancelled(t *testing.T) {
+	i := newInjector()
+	config := &chaospb.Config{Faults: []*chaospb.Fault{{Method: queueLeaves, Percent: 100, Delay: ptypes.DurationProto(time.Hour)}}}
+	if _, err := i.SetConfig(context.Background(), config); err != nil {
+		t.Fatalf("SetConfig(): %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Error("handler called after cancelled delay")
+		return nil, nil
+	}
+	_, err := i.UnaryInterceptor(ctx, "req", &grpc.UnaryServerInfo{FullMethod: queueLeaves}, handler)
+	if got, want := status.Code(err), codes.DeadlineExceeded; got != want {
+		t.Errorf("UnaryInterceptor() returned err = %v, want code %s", err, want)
+	}
+}
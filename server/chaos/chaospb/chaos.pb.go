@@ -0,0 +1,320 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: server/chaos/chaospb/chaos.proto
+
+package chaospb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+// A fault injected into the calls of an RPC method.
+type Fault struct {
+	// Full name of the RPC method, e.g. "/trillian.TrillianLog/QueueLeaves".
+	Method string `protobuf:"bytes,1,opt,name=method,proto3" json:"method,omitempty"`
+	// Percentage of the calls of the method which the fault is injected into,
+	// in (0, 100].
+	Percent float64 `protobuf:"fixed64,2,opt,name=percent,proto3" json:"percent,omitempty"`
+	// If set, affected calls are delayed by this long before being handled, or
+	// failing if code is set.
+	Delay *duration.Duration `protobuf:"bytes,3,opt,name=delay,proto3" json:"delay,omitempty"`
+	// If not zero (OK), affected calls fail with this gRPC status code instead
+	// of being handled.
+	Code                 int32    `protobuf:"varint,4,opt,name=code,proto3" json:"code,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Fault) Reset()         { *m = Fault{} }
+func (m *Fault) String() string { return proto.CompactTextString(m) }
+func (*Fault) ProtoMessage()    {}
+func (*Fault) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8784146dbbe4cdc0, []int{0}
+}
+
+func (m *Fault) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Fault.Unmarshal(m, b)
+}
+func (m *Fault) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Fault.Marshal(b, m, deterministic)
+}
+func (m *Fault) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Fault.Merge(m, src)
+}
+func (m *Fault) XXX_Size() int {
+	return xxx_messageInfo_Fault.Size(m)
+}
+func (m *Fault) XXX_DiscardUnknown() {
+	xxx_messageInfo_Fault.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Fault proto.InternalMessageInfo
+
+func (m *Fault) GetMethod() string {
+	if m != nil {
+		return m.Method
+	}
+	return ""
+}
+
+func (m *Fault) GetPercent() float64 {
+	if m != nil {
+		return m.Percent
+	}
+	return 0
+}
+
+func (m *Fault) GetDelay() *duration.Duration {
+	if m != nil {
+		return m.Delay
+	}
+	return nil
+}
+
+func (m *Fault) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+// The faults injected by a server.
+type Config struct {
+	// The faults, at most one per method.
+	Faults               []*Fault `protobuf:"bytes,1,rep,name=faults,proto3" json:"faults,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Config) Reset()         { *m = Config{} }
+func (m *Config) String() string { return proto.CompactTextString(m) }
+func (*Config) ProtoMessage()    {}
+func (*Config) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8784146dbbe4cdc0, []int{1}
+}
+
+func (m *Config) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Config.Unmarshal(m, b)
+}
+func (m *Config) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Config.Marshal(b, m, deterministic)
+}
+func (m *Config) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Config.Merge(m, src)
+}
+func (m *Config) XXX_Size() int {
+	return xxx_messageInfo_Config.Size(m)
+}
+func (m *Config) XXX_DiscardUnknown() {
+	xxx_messageInfo_Config.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Config proto.InternalMessageInfo
+
+func (m *Config) GetFaults() []*Fault {
+	if m != nil {
+		return m.Faults
+	}
+	return nil
+}
+
+// GetConfig request.
+type GetConfigRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetConfigRequest) Reset()         { *m = GetConfigRequest{} }
+func (m *GetConfigRequest) String() string { return proto.CompactTextString(m) }
+func (*GetConfigRequest) ProtoMessage()    {}
+func (*GetConfigRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8784146dbbe4cdc0, []int{2}
+}
+
+func (m *GetConfigRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetConfigRequest.Unmarshal(m, b)
+}
+func (m *GetConfigRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetConfigRequest.Marshal(b, m, deterministic)
+}
+func (m *GetConfigRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetConfigRequest.Merge(m, src)
+}
+func (m *GetConfigRequest) XXX_Size() int {
+	return xxx_messageInfo_GetConfigRequest.Size(m)
+}
+func (m *GetConfigRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetConfigRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetConfigRequest proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*Fault)(nil), "chaospb.Fault")
+	proto.RegisterType((*Config)(nil), "chaospb.Config")
+	proto.RegisterType((*GetConfigRequest)(nil), "chaospb.GetConfigRequest")
+}
+
+func init() { proto.RegisterFile("server/chaos/chaospb/chaos.proto", fileDescriptor_8784146dbbe4cdc0) }
+
+var fileDescriptor_8784146dbbe4cdc0 = []byte{
+	// 254 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x4f, 0x3d, 0x4f, 0xc3, 0x30,
+	0x10, 0xc5, 0xb4, 0x49, 0x95, 0xab, 0x04, 0xe8, 0x06, 0xe4, 0x76, 0x40, 0x56, 0x06, 0x94, 0xc9,
+	0x41, 0x61, 0x62, 0x2e, 0x82, 0xdd, 0xfc, 0x82, 0x7c, 0x5c, 0xd2, 0x4a, 0x21, 0x0e, 0xb1, 0x83,
+	0xc4, 0xc2, 0x6f, 0x47, 0x8d, 0x9d, 0x0c, 0x15, 0x8b, 0x7d, 0xf7, 0xde, 0xbb, 0x7b, 0xf7, 0x40,
+	0x18, 0x1a, 0xbe, 0x69, 0x48, 0xcb, 0x63, 0xae, 0x8d, 0x7b, 0xfb, 0xc2, 0xfd, 0xb2, 0x1f, 0xb4,
+	0xd5, 0xb8, 0xf1, 0xe0, 0xfe, 0xa1, 0xd1, 0xba, 0x69, 0x29, 0x9d, 0xe0, 0x62, 0xac, 0xd3, 0x6a,
+	0x1c, 0x72, 0x7b, 0xd2, 0x9d, 0x13, 0xc6, 0xbf, 0x10, 0xbc, 0xe5, 0x63, 0x6b, 0xf1, 0x1e, 0xc2,
+	0x4f, 0xb2, 0x47, 0x5d, 0x71, 0x26, 0x58, 0x12, 0x29, 0xdf, 0x21, 0x87, 0x4d, 0x4f, 0x43, 0x49,
+	0x9d, 0xe5, 0xd7, 0x82, 0x25, 0x4c, 0xcd, 0x2d, 0xa6, 0x10, 0x54, 0xd4, 0xe6, 0x3f, 0x7c, 0x25,
+	0x58, 0xb2, 0xcd, 0x76, 0xd2, 0x59, 0xc9, 0xd9, 0x4a, 0xbe, 0x7a, 0x2b, 0xe5, 0x74, 0x88, 0xb0,
+	0x2e, 0x75, 0x45, 0x7c, 0x2d, 0x58, 0x12, 0xa8, 0xa9, 0x8e, 0x9f, 0x20, 0x3c, 0xe8, 0xae, 0x3e,
+	0x35, 0xf8, 0x08, 0x61, 0x7d, 0xbe, 0xc4, 0x70, 0x26, 0x56, 0xc9, 0x36, 0xbb, 0x91, 0x3e, 0x83,
+	0x9c, 0x0e, 0x54, 0x9e, 0x8d, 0x11, 0xee, 0xde, 0xc9, 0xba, 0x21, 0x45, 0x5f, 0x23, 0x19, 0x9b,
+	0x19, 0x08, 0x0e, 0x67, 0x31, 0xa6, 0x10, 0x7d, 0xcc, 0x24, 0xde, 0x2e, 0x1b, 0x1c, 0xb0, 0xbf,
+	0x04, 0xe2, 0x2b, 0x7c, 0x81, 0x68, 0xd9, 0x86, 0xbb, 0x85, 0xbf, 0x74, 0xf8, 0x67, 0xb4, 0x08,
+	0xa7, 0xa0, 0xcf, 0x7f, 0x03, 0x00, 0x18, 0x38, 0xc7, 0x19, 0x8e, 0x01, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ChaosClient is the client API for Chaos service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ChaosClient interface {
+	// Replaces the faults injected by the server and returns the new config.
+	// An empty config turns fault injection off.
+	SetConfig(ctx context.Context, in *Config, opts ...grpc.CallOption) (*Config, error)
+	// Returns the faults currently injected by the server.
+	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*Config, error)
+}
+
+type chaosClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewChaosClient(cc *grpc.ClientConn) ChaosClient {
+	return &chaosClient{cc}
+}
+
+func (c *chaosClient) SetConfig(ctx context.Context, in *Config, opts ...grpc.CallOption) (*Config, error) {
+	out := new(Config)
+	err := c.cc.Invoke(ctx, "/chaospb.Chaos/SetConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chaosClient) GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*Config, error) {
+	out := new(Config)
+	err := c.cc.Invoke(ctx, "/chaospb.Chaos/GetConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChaosServer is the server API for Chaos service.
+type ChaosServer interface {
+	// Replaces the faults injected by the server and returns the new config.
+	// An empty config turns fault injection off.
+	SetConfig(context.Context, *Config) (*Config, error)
+	// Returns the faults currently injected by the server.
+	GetConfig(context.Context, *GetConfigRequest) (*Config, error)
+}
+
+// UnimplementedChaosServer can be embedded to have forward compatible implementations.
+type UnimplementedChaosServer struct {
+}
+
+func (*UnimplementedChaosServer) SetConfig(ctx context.Context, req *Config) (*Config, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConfig not implemented")
+}
+func (*UnimplementedChaosServer) GetConfig(ctx context.Context, req *GetConfigRequest) (*Config, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
+
+func RegisterChaosServer(s *grpc.Server, srv ChaosServer) {
+	s.RegisterService(&_Chaos_serviceDesc, srv)
+}
+
+func _Chaos_SetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Config)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChaosServer).SetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chaospb.Chaos/SetConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChaosServer).SetConfig(ctx, req.(*Config))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Chaos_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChaosServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/chaospb.Chaos/GetConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChaosServer).GetConfig(ctx, req.(*GetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Chaos_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "chaospb.Chaos",
+	HandlerType: (*ChaosServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SetConfig",
+			Handler:    _Chaos_SetConfig_Handler,
+		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _Chaos_GetConfig_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "server/chaos/chaospb/chaos.proto",
+}
@@ -17,19 +17,25 @@ package interceptor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 	"sync"
 	"time"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/quota/etcd/quotapb"
-	"github.com/google/trillian/server/errors"
+	"github.com/google/trillian/server/chaos/chaospb"
+	serrors "github.com/google/trillian/server/errors"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util/requestid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -92,6 +98,9 @@ type TrillianInterceptor struct {
 	// quotaDryRun controls whether lack of tokens actually blocks requests (if set to true, no
 	// requests are blocked by lack of tokens).
 	quotaDryRun bool
+
+	// quotaKinds overrides the kind of quota charged for some methods.
+	quotaKinds QuotaKinds
 }
 
 // New returns a new TrillianInterceptor instance.
@@ -144,6 +153,20 @@ func (i *TrillianInterceptor) UnaryInterceptor(ctx context.Context, req interfac
 	return resp, err
 }
 
+// StreamInterceptor executes the TrillianInterceptor logic for server-streaming
+// RPCs, once their request is received. Their tokens are charged once per
+// stream, and never refunded.
+func (i *TrillianInterceptor) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	rp := i.NewProcessor()
+	return handler(srv, &interceptedStream{
+		ServerStream: ss,
+		ctx:          ss.Context(),
+		onRecv: func(ctx context.Context, req interface{}) (context.Context, error) {
+			return rp.Before(ctx, req, info.FullMethod)
+		},
+	})
+}
+
 // NewProcessor returns a RequestProcessor for the TrillianInterceptor logic.
 func (i *TrillianInterceptor) NewProcessor() RequestProcessor {
 	return &trillianProcessor{parent: i}
@@ -163,9 +186,9 @@ func (tp *trillianProcessor) Before(ctx context.Context, req interface{}, method
 	// Don't want the Before to contain the action, so don't overwrite the ctx.
 	innerCtx, spanEnd := spanFor(ctx, "Before")
 	defer spanEnd()
-	info, err := newRPCInfo(req)
+	info, err := newRPCInfo(req, tp.parent.quotaKinds, method)
 	if err != nil {
-		glog.Warningf("Failed to read tree info: %v", err)
+		glog.Warningf("%sFailed to read tree info: %v", requestid.LogPrefix(ctx), err)
 		incRequestDeniedCounter(badInfoReason, 0, "")
 		return ctx, err
 	}
@@ -193,9 +216,9 @@ func (tp *trillianProcessor) Before(ctx context.Context, req interface{}, method
 		if err != nil {
 			if !tp.parent.quotaDryRun {
 				incRequestDeniedCounter(insufficientTokensReason, info.treeID, info.quotaUsers)
-				return ctx, status.Errorf(codes.ResourceExhausted, "quota exhausted: %v", err)
+				return ctx, quotaExhaustedError(ctx, err)
 			}
-			glog.Warningf("(quotaDryRun) Request %+v not denied due to dry run mode: %v", req, err)
+			glog.Warningf("%s(quotaDryRun) Request %+v not denied due to dry run mode: %v", requestid.LogPrefix(ctx), req, err)
 		}
 		quota.Metrics.IncAcquired(info.tokens, info.specs, err == nil)
 		if err = innerCtx.Err(); err != nil {
@@ -207,6 +230,29 @@ func (tp *trillianProcessor) Before(ctx context.Context, req interface{}, method
 	return ctx, nil
 }
 
+// quotaExhaustedError returns a ResourceExhausted error for a failed GetTokens call.
+// If err is, or wraps, a quota.ExhaustedError, its details are attached to the returned status as a
+// trillian.QuotaExhaustedDetails and, if a refill estimate is available, an errdetails.RetryInfo.
+func quotaExhaustedError(ctx context.Context, err error) error {
+	st := status.Newf(codes.ResourceExhausted, "quota exhausted: %v", err)
+	var qe *quota.ExhaustedError
+	if !errors.As(err, &qe) {
+		return st.Err()
+	}
+	qd := &trillian.QuotaExhaustedDetails{Spec: qe.Spec, TokensAvailable: qe.Available}
+	details := []proto.Message{qd}
+	if qe.RefillDelay > 0 {
+		qd.RefillDelay = ptypes.DurationProto(qe.RefillDelay)
+		details = append(details, &errdetails.RetryInfo{RetryDelay: qd.RefillDelay})
+	}
+	withDetails, detailsErr := st.WithDetails(details...)
+	if detailsErr != nil {
+		glog.Warningf("%sFailed to attach quota details to status: %v", requestid.LogPrefix(ctx), detailsErr)
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
 func (tp *trillianProcessor) After(ctx context.Context, resp interface{}, method string, handlerErr error) {
 	if !enabledServices[serviceName(method)] {
 		return
@@ -215,7 +261,7 @@ func (tp *trillianProcessor) After(ctx context.Context, resp interface{}, method
 	defer spanEnd()
 	switch {
 	case tp.info == nil:
-		glog.Warningf("After called with nil rpcInfo, resp = [%+v], handlerErr = [%v]", resp, handlerErr)
+		glog.Warningf("%sAfter called with nil rpcInfo, resp = [%+v], handlerErr = [%v]", requestid.LogPrefix(ctx), resp, handlerErr)
 		return
 	case tp.info.tokens == 0:
 		// After() currently only does quota processing
@@ -255,6 +301,12 @@ func (tp *trillianProcessor) After(ctx context.Context, resp interface{}, method
 					tokens++
 				}
 			}
+		case *trillian.BulkAddSequencedLeavesResponse:
+			for _, leaf := range resp.GetResults() {
+				if !isLeafOK(leaf) {
+					tokens++
+				}
+			}
 		case *trillian.QueueLeavesResponse:
 			for _, leaf := range resp.GetQueuedLeaves() {
 				if !isLeafOK(leaf) {
@@ -266,8 +318,10 @@ func (tp *trillianProcessor) After(ctx context.Context, resp interface{}, method
 	if tokens > 0 {
 		// Run PutTokens in a separate goroutine and with a separate context.
 		// It shouldn't block RPC completion, nor should it share the RPC's context deadline.
+		// Keep the request ID for logging and tracing, though.
+		bgCtx := requestid.NewContext(context.Background(), requestid.FromContext(ctx))
 		go func() {
-			ctx, spanEnd := spanFor(context.Background(), "After.PutTokens")
+			ctx, spanEnd := spanFor(bgCtx, "After.PutTokens")
 			defer spanEnd()
 			ctx, cancel := context.WithTimeout(ctx, PutTokensTimeout)
 			defer cancel()
@@ -278,7 +332,7 @@ func (tp *trillianProcessor) After(ctx context.Context, resp interface{}, method
 			// in its impl).
 			err := tp.parent.qm.PutTokens(ctx, tokens, refunds)
 			if err != nil {
-				glog.Warningf("Failed to replenish %v tokens: %v", tokens, err)
+				glog.Warningf("%sFailed to replenish %v tokens: %v", requestid.LogPrefix(ctx), tokens, err)
 			}
 			quota.Metrics.IncReturned(tokens, refunds, err == nil)
 		}()
@@ -358,36 +412,75 @@ func newRPCInfoForRequest(req interface{}) (*rpcInfo, error) {
 		*quotapb.DeleteConfigRequest,
 		*quotapb.GetConfigRequest,
 		*quotapb.ListConfigsRequest,
-		*quotapb.UpdateConfigRequest:
+		*quotapb.UpdateConfigRequest,
+		// Chaos testing requests
+		*chaospb.GetConfigRequest,
+		*chaospb.Config:
 		info.getTree = false
 		info.readonly = false // Doesn't really matter as all interceptors are turned off
 
+	// Tree templates aren't tied to a tree
+	case *trillian.GetTreeTemplateRequest,
+		*trillian.ListTreeTemplatesRequest:
+		info.getTree = false
+	case *trillian.CreateTreeTemplateRequest,
+		*trillian.DeleteTreeTemplateRequest,
+		*trillian.UpdateTreeTemplateRequest:
+		info.getTree = false
+		info.readonly = false
+
 	// Admin create
 	case *trillian.CreateTreeRequest:
 		info.getTree = false // Tree doesn't exist
 		info.readonly = false
 
 	// Admin list
-	case *trillian.ListTreesRequest:
+	case *trillian.ListTreesRequest,
+		*trillian.ListSoftDeletedTreesRequest,
+		*trillian.GetRootAgesRequest:
 		info.getTree = false // Zero to many trees
 
 	// Admin / readonly
-	case *trillian.GetTreeRequest:
+	case *trillian.GetTreeRequest,
+		*trillian.GetTreeAttestationRequest:
 		info.getTree = false // Read done within RPC handler
 
 	// Admin / readwrite
-	case *trillian.DeleteTreeRequest,
+	case *trillian.BatchUpdateTreesRequest,
+		*trillian.CompactTreeStorageRequest,
+		*trillian.DeleteTreeRequest,
+		*trillian.RewrapLeafDataKeyRequest,
 		*trillian.UndeleteTreeRequest,
 		*trillian.UpdateTreeRequest:
 		info.getTree = false // Read-modify-write done within RPC handler
 		info.readonly = false
 
+	// Log sequencer / readonly
+	case *trillian.ListQuarantinedLeavesRequest,
+		*trillian.GetSequencerStatusRequest,
+		*trillian.GetSequencingStatusRequest:
+		info.getTree = false // Read done by the signer
+
+	// Log sequencer / readwrite
+	case *trillian.ReintegratePendingRequest,
+		*trillian.RequeueQuarantinedLeavesRequest,
+		*trillian.ResignMastershipRequest,
+		*trillian.SignLogRootRequest:
+		info.getTree = false // Read done by the signer
+		info.readonly = false
+
 	// (Log + Pre-ordered Log) / readonly
 	case *trillian.GetConsistencyProofRequest,
 		*trillian.GetEntryAndProofRequest,
+		*trillian.GetHistoricalInclusionProofRequest,
 		*trillian.GetInclusionProofByHashRequest,
 		*trillian.GetInclusionProofRequest,
-		*trillian.GetLatestSignedLogRootRequest:
+		*trillian.GetLatestSignedLogRootRequest,
+		*trillian.GetProofAtRevisionRequest,
+		*trillian.GetSignedLogRootHistoryRequest,
+		*trillian.PredictRootRequest,
+		*trillian.TailLeavesRequest,
+		*trillian.VerifyInclusionRequest:
 		info.treeTypes = []trillian.TreeType{trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG}
 		info.tokens = 1
 	case *trillian.GetLeavesByHashRequest:
@@ -402,6 +495,18 @@ func newRPCInfoForRequest(req interface{}) (*rpcInfo, error) {
 		if c := req.GetCount(); c > 1 {
 			info.tokens = int(c)
 		}
+	case *trillian.GetLeavesByKeyRangeRequest:
+		info.treeTypes = []trillian.TreeType{trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG}
+		info.tokens = 1
+		if c := req.GetCount(); c > 1 {
+			info.tokens = int(c)
+		}
+	case *trillian.GetEffectiveLeavesRequest:
+		info.treeTypes = []trillian.TreeType{trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG}
+		info.tokens = 1
+		if c := req.GetCount(); c > 1 {
+			info.tokens = int(c)
+		}
 	case *trillian.GetSequencedLeafCountRequest:
 		info.treeTypes = []trillian.TreeType{trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG}
 
@@ -424,6 +529,10 @@ func newRPCInfoForRequest(req interface{}) (*rpcInfo, error) {
 		info.readonly = false
 		info.treeTypes = []trillian.TreeType{trillian.TreeType_PREORDERED_LOG}
 		info.tokens = len(req.GetLeaves())
+	case *trillian.BulkAddSequencedLeavesRequest:
+		info.readonly = false
+		info.treeTypes = []trillian.TreeType{trillian.TreeType_PREORDERED_LOG}
+		info.tokens = len(req.GetLeaves())
 
 	// (Log + Pre-ordered Log) / readwrite
 	case *trillian.InitLogRequest:
@@ -470,7 +579,7 @@ func newRPCInfoForRequest(req interface{}) (*rpcInfo, error) {
 	return info, nil
 }
 
-func newRPCInfo(req interface{}) (*rpcInfo, error) {
+func newRPCInfo(req interface{}, kinds QuotaKinds, method string) (*rpcInfo, error) {
 	info, err := newRPCInfoForRequest(req)
 	if err != nil {
 		return nil, err
@@ -496,6 +605,9 @@ func newRPCInfo(req interface{}) (*rpcInfo, error) {
 		if info.readonly {
 			kind = quota.Read
 		}
+		if k, ok := kinds[methodName(method)]; ok {
+			kind = k
+		}
 
 		for _, user := range chargedUsers(req) {
 			info.specs = append(info.specs, quota.Spec{Group: quota.User, Kind: kind, User: user})
@@ -534,7 +646,7 @@ func ErrorWrapper(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo,
 	ctx, spanEnd := spanFor(ctx, "ErrorWrapper")
 	defer spanEnd()
 	rsp, err := handler(ctx, req)
-	return rsp, errors.WrapError(err)
+	return rsp, serrors.WrapError(err)
 }
 
 func spanFor(ctx context.Context, name string) (context.Context, func()) {
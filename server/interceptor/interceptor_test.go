@@ -17,6 +17,7 @@ package interceptor
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -26,10 +27,12 @@ import (
 	"github.com/google/trillian"
 	"github.com/google/trillian/quota"
 	"github.com/google/trillian/quota/etcd/quotapb"
+	"github.com/google/trillian/server/chaos/chaospb"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/testonly"
 	"github.com/google/trillian/trees"
 	"github.com/kylelemons/godebug/pretty"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -95,6 +98,16 @@ func TestTrillianInterceptor_TreeInterception(t *testing.T) {
 			method: "/trillian.TrillianAdmin/UpdateTree",
 			req:    &trillian.UpdateTreeRequest{Tree: &trillian.Tree{TreeId: logTree.TreeId}},
 		},
+		{
+			desc:   "adminRewrapByID",
+			method: "/trillian.TrillianAdmin/RewrapLeafDataKey",
+			req:    &trillian.RewrapLeafDataKeyRequest{TreeId: logTree.TreeId},
+		},
+		{
+			desc:   "adminCompactByID",
+			method: "/trillian.TrillianAdmin/CompactTreeStorage",
+			req:    &trillian.CompactTreeStorageRequest{TreeId: logTree.TreeId},
+		},
 		{
 			desc:     "logRPC",
 			method:   "/trillian.TrillianLog/GetLatestSignedLogRoot",
@@ -190,6 +203,66 @@ func TestTrillianInterceptor_TreeInterception(t *testing.T) {
 	}
 }
 
+func TestTrillianInterceptor_StreamInterceptor(t *testing.T) {
+	logTree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	logTree.TreeId = 10
+	mapTree := proto.Clone(testonly.MapTree).(*trillian.Tree)
+	mapTree.TreeId = 11
+
+	for _, test := range []struct {
+		desc    string
+		treeID  int64
+		wantErr bool
+	}{
+		{desc: "log", treeID: logTree.TreeId},
+		{desc: "wrongTreeType", treeID: mapTree.TreeId, wantErr: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			admin := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			admin.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logTree.TreeId).AnyTimes().Return(logTree, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), mapTree.TreeId).AnyTimes().Return(mapTree, nil)
+			adminTX.EXPECT().Close().Return(nil)
+			adminTX.EXPECT().Commit().Return(nil)
+
+			intercept := New(admin, quota.Noop(), false /* quotaDryRun */, nil /* mf */)
+			ss := &fakeServerStream{ctx: context.Background(), req: &trillian.TailLeavesRequest{LogId: test.treeID}}
+			handler := func(srv interface{}, stream grpc.ServerStream) error {
+				if err := stream.RecvMsg(&trillian.TailLeavesRequest{}); err != nil {
+					return err
+				}
+				if tree, ok := trees.FromContext(stream.Context()); !ok || !proto.Equal(tree, logTree) {
+					t.Errorf("trees.FromContext() = (%v, %v), want (%v, true)", tree, ok, logTree)
+				}
+				return nil
+			}
+			err := intercept.StreamInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/trillian.TrillianLog/TailLeaves"}, handler)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("StreamInterceptor() returned err = %v, wantErr = %v", err, test.wantErr)
+			}
+		})
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream which receives req.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+	req proto.Message
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeServerStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.req)
+	return nil
+}
+
 func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 
 	logTree := proto.Clone(testonly.LogTree).(*trillian.Tree)
@@ -207,6 +280,7 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 	tests := []struct {
 		desc         string
 		dryRun       bool
+		kinds        QuotaKinds
 		method       string
 		req          interface{}
 		specs        []quota.Spec
@@ -298,6 +372,30 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 			},
 			wantTokens: 1,
 		},
+		{
+			desc:   "logRead with write kind",
+			kinds:  QuotaKinds{"GetLatestSignedLogRoot": quota.Write, "QueueLeaf": quota.Read},
+			method: "/trillian.TrillianLog/GetLatestSignedLogRoot",
+			req:    &trillian.GetLatestSignedLogRootRequest{LogId: logTree.TreeId, ChargeTo: charges},
+			specs: []quota.Spec{
+				{Group: quota.User, Kind: quota.Write, User: charge1},
+				{Group: quota.User, Kind: quota.Write, User: charge2},
+				{Group: quota.Tree, Kind: quota.Write, TreeID: logTree.TreeId},
+				{Group: quota.Global, Kind: quota.Write, Refundable: true},
+			},
+			wantTokens: 1,
+		},
+		{
+			desc:   "logRead with other method kinds",
+			kinds:  QuotaKinds{"QueueLeaf": quota.Read},
+			method: "/trillian.TrillianLog/GetLatestSignedLogRoot",
+			req:    &trillian.GetLatestSignedLogRootRequest{LogId: logTree.TreeId},
+			specs: []quota.Spec{
+				{Group: quota.Tree, Kind: quota.Read, TreeID: logTree.TreeId},
+				{Group: quota.Global, Kind: quota.Read, Refundable: true},
+			},
+			wantTokens: 1,
+		},
 		{
 			desc:   "mapRead",
 			method: "/trillian.TrillianMap/GetLeaves",
@@ -342,6 +440,19 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 			},
 			wantTokens: 3,
 		},
+		{
+			desc:   "bulkSequencedLogLeavesRequest",
+			method: "/trillian.TrillianLog/BulkAddSequencedLeaves",
+			req: &trillian.BulkAddSequencedLeavesRequest{
+				LogId:  preorderedTree.TreeId,
+				Leaves: []*trillian.LogLeaf{{}, {}, {}, {}},
+			},
+			specs: []quota.Spec{
+				{Group: quota.Tree, Kind: quota.Write, TreeID: preorderedTree.TreeId},
+				{Group: quota.Global, Kind: quota.Write, Refundable: true},
+			},
+			wantTokens: 4,
+		},
 		{
 			desc:   "batchLogLeavesRequest with charges",
 			method: "/trillian.TrillianLog/QueueLeaves",
@@ -431,6 +542,7 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 
 			handler := &fakeHandler{resp: "ok"}
 			intercept := New(admin, qm, test.dryRun, nil /* mf */)
+			intercept.SetQuotaKinds(test.kinds)
 
 			// resp and handler assertions are done by TestTrillianInterceptor_TreeInterception,
 			// we're only concerned with the quota logic here.
@@ -444,6 +556,61 @@ func TestTrillianInterceptor_QuotaInterception(t *testing.T) {
 	}
 }
 
+func TestQuotaExhaustedError(t *testing.T) {
+	for _, test := range []struct {
+		desc        string
+		err         error
+		wantDetails []proto.Message
+	}{
+		{
+			desc: "otherError",
+			err:  errors.New("not enough tokens"),
+		},
+		{
+			desc: "noRefillDelay",
+			err:  &quota.ExhaustedError{Spec: "global/write", Available: 2, Requested: 5},
+			wantDetails: []proto.Message{
+				&trillian.QuotaExhaustedDetails{Spec: "global/write", TokensAvailable: 2},
+			},
+		},
+		{
+			desc: "wrapped",
+			err:  fmt.Errorf("quota manager: %w", &quota.ExhaustedError{Spec: "global/write", Available: 2, Requested: 5}),
+			wantDetails: []proto.Message{
+				&trillian.QuotaExhaustedDetails{Spec: "global/write", TokensAvailable: 2},
+			},
+		},
+		{
+			desc: "refillDelay",
+			err:  &quota.ExhaustedError{Spec: "trees/10/write", Available: 1, Requested: 3, RefillDelay: 1500 * time.Millisecond},
+			wantDetails: []proto.Message{
+				&trillian.QuotaExhaustedDetails{
+					Spec:            "trees/10/write",
+					TokensAvailable: 1,
+					RefillDelay:     ptypes.DurationProto(1500 * time.Millisecond),
+				},
+				&errdetails.RetryInfo{RetryDelay: ptypes.DurationProto(1500 * time.Millisecond)},
+			},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			st := status.Convert(quotaExhaustedError(context.Background(), test.err))
+			if got, want := st.Code(), codes.ResourceExhausted; got != want {
+				t.Errorf("quotaExhaustedError() returned code %v, want %v", got, want)
+			}
+			details := st.Details()
+			if got, want := len(details), len(test.wantDetails); got != want {
+				t.Fatalf("quotaExhaustedError() returned %d details, want %d", got, want)
+			}
+			for i, d := range details {
+				if !proto.Equal(d.(proto.Message), test.wantDetails[i]) {
+					t.Errorf("quotaExhaustedError() details[%d] = %v, want %v", i, d, test.wantDetails[i])
+				}
+			}
+		})
+	}
+}
+
 func TestTrillianInterceptor_QuotaInterception_ReturnsTokens(t *testing.T) {
 	logTree := proto.Clone(testonly.LogTree).(*trillian.Tree)
 	logTree.TreeId = 10
@@ -622,12 +789,30 @@ func TestTrillianInterceptor_NotIntercepted(t *testing.T) {
 		// Admin
 		{method: "/trillian.TrillianAdmin/CreateTree", req: &trillian.CreateTreeRequest{}},
 		{method: "/trillian.TrillianAdmin/ListTrees", req: &trillian.ListTreesRequest{}},
+		{method: "/trillian.TrillianAdmin/GetRootAges", req: &trillian.GetRootAgesRequest{}},
+		{method: "/trillian.TrillianAdmin/BatchUpdateTrees", req: &trillian.BatchUpdateTreesRequest{}},
+		{method: "/trillian.TrillianAdmin/CreateTreeTemplate", req: &trillian.CreateTreeTemplateRequest{}},
+		{method: "/trillian.TrillianAdmin/GetTreeTemplate", req: &trillian.GetTreeTemplateRequest{}},
+		{method: "/trillian.TrillianAdmin/ListTreeTemplates", req: &trillian.ListTreeTemplatesRequest{}},
+		{method: "/trillian.TrillianAdmin/UpdateTreeTemplate", req: &trillian.UpdateTreeTemplateRequest{}},
+		{method: "/trillian.TrillianAdmin/DeleteTreeTemplate", req: &trillian.DeleteTreeTemplateRequest{}},
+		// Log sequencer
+		{method: "/trillian.TrillianLogSequencer/ReintegratePending", req: &trillian.ReintegratePendingRequest{LogId: 10}},
+		{method: "/trillian.TrillianLogSequencer/ListQuarantinedLeaves", req: &trillian.ListQuarantinedLeavesRequest{LogId: 10}},
+		{method: "/trillian.TrillianLogSequencer/RequeueQuarantinedLeaves", req: &trillian.RequeueQuarantinedLeavesRequest{LogId: 10}},
+		{method: "/trillian.TrillianLogSequencer/GetSequencingStatus", req: &trillian.GetSequencingStatusRequest{LogId: 10}},
+		{method: "/trillian.TrillianLogSequencer/GetSequencerStatus", req: &trillian.GetSequencerStatusRequest{LogId: 10}},
+		{method: "/trillian.TrillianLogSequencer/ResignMastership", req: &trillian.ResignMastershipRequest{}},
+		{method: "/trillian.TrillianLogSequencer/SignLogRoot", req: &trillian.SignLogRootRequest{LogId: 10}},
 		// Quota
 		{method: "/quotapb.Quota/CreateConfig", req: &quotapb.CreateConfigRequest{}},
 		{method: "/quotapb.Quota/DeleteConfig", req: &quotapb.DeleteConfigRequest{}},
 		{method: "/quotapb.Quota/GetConfig", req: &quotapb.GetConfigRequest{}},
 		{method: "/quotapb.Quota/ListConfigs", req: &quotapb.ListConfigsRequest{}},
 		{method: "/quotapb.Quota/UpdateConfig", req: &quotapb.UpdateConfigRequest{}},
+		// Chaos
+		{method: "/chaospb.Chaos/GetConfig", req: &chaospb.GetConfigRequest{}},
+		{method: "/chaospb.Chaos/SetConfig", req: &chaospb.Config{}},
 	}
 
 	ctx := context.Background()
@@ -0,0 +1,136 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MessageSizeLimits limits the size of the requests to each RPC method, which
+// the grpc.MaxRecvMsgSize server option can only do for all methods at once.
+// Sizes are in bytes, and zero means no limit. The limits can't exceed that of
+// the gRPC server, which rejects larger requests before they are intercepted.
+type MessageSizeLimits struct {
+	// Read and Write limit the requests to readonly methods, e.g. ListTrees
+	// or GetLeavesByRange, and to other methods, e.g. QueueLeaves,
+	// respectively. Requests to methods unknown to the TrillianInterceptor
+	// are limited by Write.
+	Read, Write int
+	// Methods overrides the limit for the methods with the given names,
+	// without their service (e.g. "QueueLeaves").
+	Methods map[string]int
+}
+
+// ParseMethodMessageSizes parses a comma-separated list of method=bytes pairs
+// into a map suitable for MessageSizeLimits.Methods.
+func ParseMethodMessageSizes(s string) (map[string]int, error) {
+	sizes := make(map[string]int)
+	if s == "" {
+		return sizes, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid message size %q, want method=bytes", pair)
+		}
+		method := parts[0]
+		if _, ok := sizes[method]; ok {
+			return nil, fmt.Errorf("duplicate message size for method %s", method)
+		}
+		size, err := strconv.Atoi(parts[1])
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("invalid message size %q for method %s, want bytes >= 0", parts[1], method)
+		}
+		sizes[method] = size
+	}
+	return sizes, nil
+}
+
+// Max returns the largest of the limits, or zero if any method is unlimited.
+// The gRPC server must accept requests of this size for all the limits to be
+// reachable.
+func (l *MessageSizeLimits) Max() int {
+	if l.Read == 0 || l.Write == 0 {
+		return 0
+	}
+	max := l.Read
+	if l.Write > max {
+		max = l.Write
+	}
+	for _, size := range l.Methods {
+		if size == 0 {
+			return 0
+		}
+		if size > max {
+			max = size
+		}
+	}
+	return max
+}
+
+// limit returns the limit applicable to req, a request to fullMethod.
+func (l *MessageSizeLimits) limit(fullMethod string, req interface{}) int {
+	if size, ok := l.Methods[methodName(fullMethod)]; ok {
+		return size
+	}
+	if info, err := newRPCInfoForRequest(req); err == nil && info.readonly {
+		return l.Read
+	}
+	return l.Write
+}
+
+// check returns RESOURCE_EXHAUSTED if req is larger than its limit.
+func (l *MessageSizeLimits) check(fullMethod string, req interface{}) error {
+	limit := l.limit(fullMethod, req)
+	if limit == 0 {
+		return nil
+	}
+	m, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+	if size := proto.Size(m); size > limit {
+		return status.Errorf(codes.ResourceExhausted, "request of %d bytes exceeds the limit of %d bytes for %s", size, limit, methodName(fullMethod))
+	}
+	return nil
+}
+
+// UnaryInterceptor rejects requests larger than their limit.
+func (l *MessageSizeLimits) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := l.check(info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamInterceptor rejects the requests of server-streaming RPCs which are
+// larger than their limit.
+func (l *MessageSizeLimits) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &interceptedStream{
+		ServerStream: ss,
+		ctx:          ss.Context(),
+		onRecv: func(ctx context.Context, req interface{}) (context.Context, error) {
+			return ctx, l.check(info.FullMethod, req)
+		},
+	})
+}
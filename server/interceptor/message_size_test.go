@@ -0,0 +1,133 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseMethodMessageSizes(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		s       string
+		want    map[string]int
+		wantErr bool
+	}{
+		{desc: "empty", want: map[string]int{}},
+		{desc: "sizes", s: "ListTrees=0,QueueLeaves=1024", want: map[string]int{"ListTrees": 0, "QueueLeaves": 1024}},
+		{desc: "noSize", s: "QueueLeaves", wantErr: true},
+		{desc: "noMethod", s: "=1024", wantErr: true},
+		{desc: "badSize", s: "QueueLeaves=1k", wantErr: true},
+		{desc: "negative", s: "QueueLeaves=-1", wantErr: true},
+		{desc: "duplicate", s: "QueueLeaves=1,QueueLeaves=2", wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ParseMethodMessageSizes(tc.s)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ParseMethodMessageSizes(%q): %v, wantErr %v", tc.s, err, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("ParseMethodMessageSizes(%q) diff (-got +want):\n%s", tc.s, diff)
+			}
+		})
+	}
+}
+
+func TestMessageSizeLimits_Max(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		limits MessageSizeLimits
+		want   int
+	}{
+		{desc: "unlimited"},
+		{desc: "readUnlimited", limits: MessageSizeLimits{Write: 100}},
+		{desc: "read", limits: MessageSizeLimits{Read: 1000, Write: 100}, want: 1000},
+		{desc: "method", limits: MessageSizeLimits{Read: 1000, Write: 100, Methods: map[string]int{"ListTrees": 5000}}, want: 5000},
+		{desc: "methodUnlimited", limits: MessageSizeLimits{Read: 1000, Write: 100, Methods: map[string]int{"ListTrees": 0}}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := tc.limits.Max(); got != tc.want {
+				t.Errorf("Max() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMessageSizeLimits(t *testing.T) {
+	value := bytes.Repeat([]byte("v"), 200)
+	queueLeaves := &trillian.QueueLeavesRequest{LogId: 1, Leaves: []*trillian.LogLeaf{{LeafValue: value}}}
+	getLeaves := &trillian.GetLeavesByHashRequest{LogId: 1, LeafHash: [][]byte{value}}
+	limits := &MessageSizeLimits{Read: 1000, Write: 100, Methods: map[string]int{"GetEntryAndProof": 5}}
+
+	for _, tc := range []struct {
+		desc     string
+		method   string
+		req      proto.Message
+		wantCode codes.Code
+	}{
+		{desc: "write", method: "/trillian.TrillianLog/QueueLeaves", req: queueLeaves, wantCode: codes.ResourceExhausted},
+		{desc: "smallWrite", method: "/trillian.TrillianLog/QueueLeaves", req: &trillian.QueueLeavesRequest{LogId: 1}},
+		{desc: "read", method: "/trillian.TrillianLog/GetLeavesByHash", req: getLeaves},
+		{desc: "method", method: "/trillian.TrillianLog/GetEntryAndProof", req: &trillian.GetEntryAndProofRequest{LogId: 1, LeafIndex: 1, TreeSize: 100}, wantCode: codes.ResourceExhausted},
+		{desc: "unknown", method: "/trillian.TrillianLog/Unknown", req: &trillian.LogLeaf{LeafValue: value}, wantCode: codes.ResourceExhausted},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			}
+			_, err := limits.UnaryInterceptor(context.Background(), tc.req, &grpc.UnaryServerInfo{FullMethod: tc.method}, handler)
+			if code := status.Code(err); code != tc.wantCode {
+				t.Errorf("UnaryInterceptor() = %v, want code %v", err, tc.wantCode)
+			}
+			if want := tc.wantCode == codes.OK; called != want {
+				t.Errorf("handler called = %v, want %v", called, want)
+			}
+		})
+	}
+}
+
+func TestMessageSizeLimits_Stream(t *testing.T) {
+	limits := &MessageSizeLimits{Read: 5, Write: 5}
+	for _, tc := range []struct {
+		desc     string
+		req      *trillian.TailLeavesRequest
+		wantCode codes.Code
+	}{
+		{desc: "small", req: &trillian.TailLeavesRequest{LogId: 1}},
+		{desc: "large", req: &trillian.TailLeavesRequest{LogId: 1 << 40, StartIndex: 1 << 40}, wantCode: codes.ResourceExhausted},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ss := &fakeServerStream{ctx: context.Background(), req: tc.req}
+			handler := func(srv interface{}, stream grpc.ServerStream) error {
+				return stream.RecvMsg(&trillian.TailLeavesRequest{})
+			}
+			err := limits.StreamInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/trillian.TrillianLog/TailLeaves"}, handler)
+			if code := status.Code(err); code != tc.wantCode {
+				t.Errorf("StreamInterceptor() = %v, want code %v", err, tc.wantCode)
+			}
+		})
+	}
+}
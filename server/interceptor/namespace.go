@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage/namespace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// NamespaceFunc returns the namespace claimed by the caller of an RPC.
+type NamespaceFunc func(ctx context.Context) (string, error)
+
+// NamespaceFromMetadata returns a NamespaceFunc that reads the namespace from
+// the gRPC metadata header key, which must have exactly one value.
+// Any client can set arbitrary headers, so this is only safe if requests
+// come through a proxy that authenticates callers and sets the header itself.
+func NamespaceFromMetadata(key string) NamespaceFunc {
+	return func(ctx context.Context) (string, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get(key)
+		if len(values) != 1 {
+			return "", status.Errorf(codes.Unauthenticated, "want exactly one %q header, got %d", key, len(values))
+		}
+		return values[0], nil
+	}
+}
+
+// NamespaceFromTLS is a NamespaceFunc that reads the namespace from the
+// subject organization of the verified client certificate, which must have
+// exactly one organization.
+func NamespaceFromTLS(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Error(codes.Unauthenticated, "no peer information")
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return "", status.Error(codes.Unauthenticated, "no verified client certificate")
+	}
+	orgs := info.State.VerifiedChains[0][0].Subject.Organization
+	if len(orgs) != 1 {
+		return "", status.Errorf(codes.Unauthenticated, "want exactly one organization in client certificate, got %d", len(orgs))
+	}
+	return orgs[0], nil
+}
+
+// NamespaceCallerLabel returns a monitoring.CallerLabelFunc that labels RPCs
+// with the namespace claimed by their caller, e.g. to break down RPC metrics
+// by tenant. RPCs without a claim get an empty label.
+func NamespaceCallerLabel(claim NamespaceFunc) monitoring.CallerLabelFunc {
+	return func(ctx context.Context) string {
+		ns, err := claim(ctx)
+		if err != nil {
+			return ""
+		}
+		return ns
+	}
+}
+
+// Namespace returns a grpc.UnaryServerInterceptor that requires every request
+// to claim a non-empty namespace, as returned by claim, and attaches it to the
+// request context (see namespace.NewContext). Requests without a claim fail
+// with Unauthenticated.
+//
+// The namespace is enforced by the storage implementations returned by
+// namespace.NewAdminStorage and namespace.NewLogStorage.
+func Namespace(claim NamespaceFunc) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ns, err := claim(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ns == "" {
+			return nil, status.Error(codes.Unauthenticated, "empty namespace")
+		}
+		return handler(namespace.NewContext(ctx, ns), req)
+	}
+}
+
+// StreamNamespace returns the grpc.StreamServerInterceptor equivalent of
+// Namespace.
+func StreamNamespace(claim NamespaceFunc) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ns, err := claim(ss.Context())
+		if err != nil {
+			return err
+		}
+		if ns == "" {
+			return status.Error(codes.Unauthenticated, "empty namespace")
+		}
+		return handler(srv, &interceptedStream{ServerStream: ss, ctx: namespace.NewContext(ss.Context(), ns)})
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/google/trillian/storage/namespace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const namespaceKey = "x-trillian-namespace"
+
+func TestNamespaceFromMetadata(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		md       metadata.MD
+		want     string
+		wantCode codes.Code
+	}{
+		{desc: "noMetadata", wantCode: codes.Unauthenticated},
+		{desc: "noHeader", md: metadata.Pairs("other", "a"), wantCode: codes.Unauthenticated},
+		{desc: "header", md: metadata.Pairs(namespaceKey, "a"), want: "a"},
+		{desc: "multipleHeaders", md: metadata.Pairs(namespaceKey, "a", namespaceKey, "b"), wantCode: codes.Unauthenticated},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			if test.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.md)
+			}
+			got, err := NamespaceFromMetadata(namespaceKey)(ctx)
+			if code := status.Code(err); code != test.wantCode {
+				t.Fatalf("NamespaceFromMetadata() = (_, %v), want code %v", err, test.wantCode)
+			}
+			if got != test.want {
+				t.Errorf("NamespaceFromMetadata() = (%q, _), want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceFromTLS(t *testing.T) {
+	withCert := func(orgs ...string) context.Context {
+		cert := &x509.Certificate{Subject: pkix.Name{Organization: orgs}}
+		info := credentials.TLSInfo{State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}}
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: info})
+	}
+	for _, test := range []struct {
+		desc     string
+		ctx      context.Context
+		want     string
+		wantCode codes.Code
+	}{
+		{desc: "noPeer", ctx: context.Background(), wantCode: codes.Unauthenticated},
+		{desc: "noTLS", ctx: peer.NewContext(context.Background(), &peer.Peer{}), wantCode: codes.Unauthenticated},
+		{
+			desc:     "unverified",
+			ctx:      peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{}}),
+			wantCode: codes.Unauthenticated,
+		},
+		{desc: "noOrganization", ctx: withCert(), wantCode: codes.Unauthenticated},
+		{desc: "organization", ctx: withCert("a"), want: "a"},
+		{desc: "multipleOrganizations", ctx: withCert("a", "b"), wantCode: codes.Unauthenticated},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := NamespaceFromTLS(test.ctx)
+			if code := status.Code(err); code != test.wantCode {
+				t.Fatalf("NamespaceFromTLS() = (_, %v), want code %v", err, test.wantCode)
+			}
+			if got != test.want {
+				t.Errorf("NamespaceFromTLS() = (%q, _), want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNamespaceCallerLabel(t *testing.T) {
+	label := NamespaceCallerLabel(NamespaceFromMetadata("ns"))
+	if got, want := label(metadata.NewIncomingContext(context.Background(), metadata.Pairs("ns", "a"))), "a"; got != want {
+		t.Errorf("NamespaceCallerLabel() with claim = %q, want %q", got, want)
+	}
+	if got := label(context.Background()); got != "" {
+		t.Errorf("NamespaceCallerLabel() without claim = %q, want empty", got)
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		md       metadata.MD
+		want     string
+		wantCode codes.Code
+	}{
+		{desc: "noClaim", wantCode: codes.Unauthenticated},
+		{desc: "emptyClaim", md: metadata.Pairs(namespaceKey, ""), wantCode: codes.Unauthenticated},
+		{desc: "claim", md: metadata.Pairs(namespaceKey, "a"), want: "a"},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), test.md)
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				if got, ok := namespace.FromContext(ctx); !ok || got != test.want {
+					t.Errorf("namespace.FromContext() = (%q, %v), want (%q, true)", got, ok, test.want)
+				}
+				return "resp", nil
+			}
+			intercept := Namespace(NamespaceFromMetadata(namespaceKey))
+			_, err := intercept(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/trillian.TrillianLog/QueueLeaf"}, handler)
+			if code := status.Code(err); code != test.wantCode {
+				t.Errorf("Namespace() = (_, %v), want code %v", err, test.wantCode)
+			}
+			if want := test.wantCode == codes.OK; called != want {
+				t.Errorf("handler called = %v, want %v", called, want)
+			}
+		})
+	}
+}
+
+func TestStreamNamespace(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		md       metadata.MD
+		want     string
+		wantCode codes.Code
+	}{
+		{desc: "noClaim", wantCode: codes.Unauthenticated},
+		{desc: "claim", md: metadata.Pairs(namespaceKey, "a"), want: "a"},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ss := &fakeServerStream{ctx: metadata.NewIncomingContext(context.Background(), test.md)}
+			called := false
+			handler := func(srv interface{}, stream grpc.ServerStream) error {
+				called = true
+				if got, ok := namespace.FromContext(stream.Context()); !ok || got != test.want {
+					t.Errorf("namespace.FromContext() = (%q, %v), want (%q, true)", got, ok, test.want)
+				}
+				return nil
+			}
+			intercept := StreamNamespace(NamespaceFromMetadata(namespaceKey))
+			err := intercept(nil, ss, &grpc.StreamServerInfo{FullMethod: "/trillian.TrillianLog/TailLeaves"}, handler)
+			if code := status.Code(err); code != test.wantCode {
+				t.Errorf("StreamNamespace() = %v, want code %v", err, test.wantCode)
+			}
+			if want := test.wantCode == codes.OK; called != want {
+				t.Errorf("handler called = %v, want %v", called, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/trillian/quota"
+)
+
+// QuotaKinds maps RPC method names, without their service (e.g.
+// "GetInclusionProof"), to the kind of quota which requests to them draw
+// tokens from. Methods not in the map draw from quota.Read if they're
+// readonly, and from quota.Write otherwise.
+type QuotaKinds map[string]quota.Kind
+
+// ParseQuotaKinds parses a comma-separated list of method=kind pairs, where
+// kind is "read" or "write", into QuotaKinds.
+func ParseQuotaKinds(s string) (QuotaKinds, error) {
+	kinds := make(QuotaKinds)
+	if s == "" {
+		return kinds, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid quota kind %q, want method=kind", pair)
+		}
+		method := parts[0]
+		if _, ok := kinds[method]; ok {
+			return nil, fmt.Errorf("duplicate quota kind for method %s", method)
+		}
+		switch strings.ToLower(parts[1]) {
+		case "read":
+			kinds[method] = quota.Read
+		case "write":
+			kinds[method] = quota.Write
+		default:
+			return nil, fmt.Errorf("invalid quota kind %q for method %s, want read or write", parts[1], method)
+		}
+	}
+	return kinds, nil
+}
+
+// SetQuotaKinds makes requests to the methods in kinds draw tokens from the
+// given kind of quota, e.g. so that expensive reads are limited by the
+// stricter write quota. It must be called before the interceptor is used.
+func (i *TrillianInterceptor) SetQuotaKinds(kinds QuotaKinds) {
+	i.quotaKinds = kinds
+}
+
+// methodName returns the method name "method" for "/some.package.service/method"
+// and "/service.method".
+func methodName(fullMethod string) string {
+	if matches := fullyQualifiedRE.FindStringSubmatch(fullMethod); len(matches) == 3 {
+		return matches[2]
+	}
+	if matches := unqualifiedRE.FindStringSubmatch(fullMethod); len(matches) == 3 {
+		return matches[2]
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian/quota"
+)
+
+func TestParseQuotaKinds(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		s       string
+		want    QuotaKinds
+		wantErr bool
+	}{
+		{desc: "empty", want: QuotaKinds{}},
+		{
+			desc: "kinds",
+			s:    "GetEntryAndProof=write,QueueLeaf=Read",
+			want: QuotaKinds{"GetEntryAndProof": quota.Write, "QueueLeaf": quota.Read},
+		},
+		{desc: "noKind", s: "GetEntryAndProof", wantErr: true},
+		{desc: "noMethod", s: "=write", wantErr: true},
+		{desc: "badKind", s: "GetEntryAndProof=expensive", wantErr: true},
+		{desc: "duplicate", s: "QueueLeaf=read,QueueLeaf=write", wantErr: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			got, err := ParseQuotaKinds(tc.s)
+			if gotErr := err != nil; gotErr != tc.wantErr {
+				t.Fatalf("ParseQuotaKinds(%q): %v, wantErr %v", tc.s, err, tc.wantErr)
+			}
+			if diff := cmp.Diff(got, tc.want); diff != "" {
+				t.Errorf("ParseQuotaKinds(%q) diff (-got +want):\n%s", tc.s, diff)
+			}
+		})
+	}
+}
+
+func TestMethodName(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		method string
+		want   string
+	}{
+		{desc: "trillian", method: "/trillian.TrillianLog/QueueLeaf", want: "QueueLeaf"},
+		{desc: "unqualified", method: "/service.method", want: "method"},
+		{desc: "malformed", method: "/package.service.method"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got, want := methodName(tc.method), tc.want; got != want {
+				t.Errorf("methodName(%v): %v, want %v", tc.method, got, want)
+			}
+		})
+	}
+}
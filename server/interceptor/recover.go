@@ -0,0 +1,126 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"runtime/debug"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/util/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RecoverUnary returns a grpc.UnaryServerInterceptor running i, which recovers
+// from panics in i and fails the RPC with codes.Internal instead of crashing
+// the server. Panics in the handler called by i are propagated unchanged, so
+// that they are treated like those of handlers without i.
+func RecoverUnary(i grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rsp interface{}, err error) {
+		inHandler := false
+		defer func() {
+			if rec := recover(); rec != nil {
+				if inHandler {
+					panic(rec)
+				}
+				glog.Errorf("%s: interceptor panicked: %v\n%s", info.FullMethod, rec, debug.Stack())
+				rsp, err = nil, status.Error(codes.Internal, "interceptor panicked")
+			}
+		}()
+		return i(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			inHandler = true
+			rsp, err := handler(ctx, req)
+			inHandler = false
+			return rsp, err
+		})
+	}
+}
+
+// RecoverStream is the grpc.StreamServerInterceptor equivalent of
+// RecoverUnary.
+func RecoverStream(i grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		inHandler := false
+		defer func() {
+			if rec := recover(); rec != nil {
+				if inHandler {
+					panic(rec)
+				}
+				glog.Errorf("%s: interceptor panicked: %v\n%s", info.FullMethod, rec, debug.Stack())
+				err = status.Error(codes.Internal, "interceptor panicked")
+			}
+		}()
+		return i(srv, ss, info, func(srv interface{}, ss grpc.ServerStream) error {
+			inHandler = true
+			err := handler(srv, ss)
+			inHandler = false
+			return err
+		})
+	}
+}
+
+var (
+	panicCounter     monitoring.Counter
+	panicMetricsOnce sync.Once
+)
+
+// PanicRecovery recovers from panics in RPC handlers, so that they fail the
+// RPC with codes.Internal rather than crashing the server. Panics are logged
+// with their stack, and counted by the panics_total metric.
+type PanicRecovery struct{}
+
+// NewPanicRecovery returns a PanicRecovery counting panics with mf.
+func NewPanicRecovery(mf monitoring.MetricFactory) *PanicRecovery {
+	panicMetricsOnce.Do(func() {
+		if mf == nil {
+			mf = monitoring.InertMetricFactory{}
+		}
+		panicCounter = mf.NewCounter("panics_total", "Number of panics recovered from in RPC handlers, by method", "method")
+	})
+	return &PanicRecovery{}
+}
+
+// UnaryInterceptor recovers from panics in the handlers of unary RPCs.
+func (p *PanicRecovery) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (rsp interface{}, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			rsp, err = nil, p.recovered(ctx, info.FullMethod, rec)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// StreamInterceptor recovers from panics in the handlers of streaming RPCs.
+func (p *PanicRecovery) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = p.recovered(ss.Context(), info.FullMethod, rec)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+// recovered logs and counts the panic rec of an RPC to method, and returns the
+// error to fail it with. It must be called from the deferred function which
+// recovered, so that the stack logged is that of the panic.
+func (p *PanicRecovery) recovered(ctx context.Context, method string, rec interface{}) error {
+	panicCounter.Inc(method)
+	glog.Errorf("request %s: %s panicked: %v\n%s", requestid.FromContext(ctx), method, rec, debug.Stack())
+	return status.Error(codes.Internal, "internal error")
+}
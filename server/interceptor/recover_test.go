@@ -0,0 +1,165 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRecoverUnary(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	handlerErr := errors.New("handler failed")
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "rsp", handlerErr
+	}
+	for _, test := range []struct {
+		desc     string
+		i        grpc.UnaryServerInterceptor
+		wantRsp  interface{}
+		wantErr  error
+		wantCode codes.Code
+	}{
+		{
+			desc: "passThrough",
+			i: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				return handler(ctx, req)
+			},
+			wantRsp: "rsp",
+			wantErr: handlerErr,
+		},
+		{
+			desc: "panicBeforeHandler",
+			i: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				panic("boom")
+			},
+			wantCode: codes.Internal,
+		},
+		{
+			desc: "panicAfterHandler",
+			i: func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				handler(ctx, req)
+				panic("boom")
+			},
+			wantCode: codes.Internal,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			rsp, err := RecoverUnary(test.i)(context.Background(), "req", info, handler)
+			if rsp != test.wantRsp {
+				t.Errorf("RecoverUnary() returned rsp = %v, want %v", rsp, test.wantRsp)
+			}
+			if test.wantErr != nil {
+				if err != test.wantErr {
+					t.Errorf("RecoverUnary() returned err = %v, want %v", err, test.wantErr)
+				}
+			} else if got := status.Code(err); got != test.wantCode {
+				t.Errorf("RecoverUnary() returned err = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestRecoverUnary_HandlerPanic(t *testing.T) {
+	defer func() {
+		if rec := recover(); rec != "handler boom" {
+			t.Errorf("recover() = %v, want handler panic", rec)
+		}
+	}()
+	i := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("handler boom")
+	}
+	RecoverUnary(i)(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler)
+	t.Error("RecoverUnary() returned, want handler panic propagated")
+}
+
+func TestRecoverStream(t *testing.T) {
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+	called := false
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		return nil
+	}
+	i := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return err
+		}
+		panic("boom")
+	}
+	err := RecoverStream(i)(nil, nil, info, handler)
+	if got, want := status.Code(err), codes.Internal; got != want {
+		t.Errorf("RecoverStream() returned err = %v, want code %v", err, want)
+	}
+	if !called {
+		t.Error("RecoverStream() didn't call the handler")
+	}
+}
+
+func TestPanicRecovery(t *testing.T) {
+	p := NewPanicRecovery(nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}
+	for _, test := range []struct {
+		desc     string
+		handler  grpc.UnaryHandler
+		wantRsp  interface{}
+		wantCode codes.Code
+	}{
+		{
+			desc:    "ok",
+			handler: func(ctx context.Context, req interface{}) (interface{}, error) { return "rsp", nil },
+			wantRsp: "rsp",
+		},
+		{
+			desc: "error",
+			handler: func(ctx context.Context, req interface{}) (interface{}, error) {
+				return nil, status.Error(codes.NotFound, "not found")
+			},
+			wantCode: codes.NotFound,
+		},
+		{
+			desc:     "panic",
+			handler:  func(ctx context.Context, req interface{}) (interface{}, error) { panic("boom") },
+			wantCode: codes.Internal,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			rsp, err := p.UnaryInterceptor(context.Background(), "req", info, test.handler)
+			if rsp != test.wantRsp {
+				t.Errorf("UnaryInterceptor() returned rsp = %v, want %v", rsp, test.wantRsp)
+			}
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("UnaryInterceptor() returned err = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestPanicRecovery_Stream(t *testing.T) {
+	p := NewPanicRecovery(nil)
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Stream"}
+	handler := func(srv interface{}, ss grpc.ServerStream) error { panic("boom") }
+	err := p.StreamInterceptor(nil, &interceptedStream{ctx: context.Background()}, info, handler)
+	if got, want := status.Code(err), codes.Internal; got != want {
+		t.Errorf("StreamInterceptor() returned err = %v, want code %v", err, want)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian/util/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestID is a grpc.UnaryServerInterceptor that attaches a request ID to
+// the request context (see requestid.NewContext), and sends it back to the
+// caller in the response header. The ID is taken from the x-request-id
+// metadata header of the request if it has exactly one valid value, and is
+// generated otherwise.
+func RequestID(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var id string
+	if values := md.Get(requestid.MetadataKey); len(values) == 1 && requestid.Valid(values[0]) {
+		id = values[0]
+	} else {
+		id = requestid.New()
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(requestid.MetadataKey, id)); err != nil {
+		glog.V(1).Infof("request %s: failed to set response header: %v", id, err)
+	}
+	ctx = requestid.NewContext(ctx, id)
+	rsp, err := handler(ctx, req)
+	if err != nil {
+		glog.V(1).Infof("request %s: %s failed: %v", id, info.FullMethod, err)
+	}
+	return rsp, err
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/trillian/util/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeStream records the headers set by a handler.
+type fakeStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *fakeStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestRequestID(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		md   metadata.MD
+		// want is the expected request ID, or empty if it should be generated.
+		want string
+	}{
+		{desc: "no header"},
+		{desc: "supplied", md: metadata.Pairs(requestid.MetadataKey, "req-1"), want: "req-1"},
+		{desc: "multiple values", md: metadata.Pairs(requestid.MetadataKey, "req-1", requestid.MetadataKey, "req-2")},
+		{desc: "invalid", md: metadata.Pairs(requestid.MetadataKey, "req 1")},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			stream := &fakeStream{}
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			ctx = metadata.NewIncomingContext(ctx, test.md)
+
+			var got string
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				got = requestid.FromContext(ctx)
+				return nil, nil
+			}
+			if _, err := RequestID(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Method"}, handler); err != nil {
+				t.Fatalf("RequestID() returned err = %v", err)
+			}
+			if test.want != "" && got != test.want {
+				t.Errorf("request ID = %q, want %q", got, test.want)
+			}
+			if !requestid.Valid(got) {
+				t.Errorf("request ID = %q, want valid ID", got)
+			}
+			if echoed := stream.header.Get(requestid.MetadataKey); len(echoed) != 1 || echoed[0] != got {
+				t.Errorf("response header %v = %q, want [%q]", requestid.MetadataKey, echoed, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// interceptedStream is a grpc.ServerStream with the context ctx. If onRecv is
+// set, it's called with the first message received, and the context it
+// returns replaces ctx, so that interceptors can act on the request of
+// server-streaming RPCs.
+type interceptedStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	onRecv   func(ctx context.Context, req interface{}) (context.Context, error)
+	received bool
+}
+
+func (s *interceptedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *interceptedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if s.onRecv == nil || s.received {
+		return nil
+	}
+	s.received = true
+	ctx, err := s.onRecv(s.ctx, m)
+	if err != nil {
+		return err
+	}
+	s.ctx = ctx
+	return nil
+}
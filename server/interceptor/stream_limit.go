@@ -0,0 +1,96 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"sync"
+
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	activeStreamsGauge monitoring.Gauge
+	rejectedStreams    monitoring.Counter
+	streamMetricsOnce  sync.Once
+)
+
+// StreamLimit counts the active streaming RPCs of a server, and rejects new
+// ones with codes.ResourceExhausted once the limit is reached, so that many
+// long-lived streams, e.g. those of slow consumers, can't exhaust the
+// resources of the server. The active streams are exported by the
+// active_streams metric, by method.
+type StreamLimit struct {
+	max int
+
+	mu     sync.Mutex
+	total  int
+	active map[string]int
+}
+
+// NewStreamLimit returns a StreamLimit allowing up to max active streams
+// across all methods, exporting metrics with mf. Zero means no limit, in which
+// case streams are only counted.
+func NewStreamLimit(max int, mf monitoring.MetricFactory) *StreamLimit {
+	streamMetricsOnce.Do(func() {
+		if mf == nil {
+			mf = monitoring.InertMetricFactory{}
+		}
+		activeStreamsGauge = mf.NewGauge("active_streams", "Number of active streaming RPCs, by method", "method")
+		rejectedStreams = mf.NewCounter("rejected_streams", "Number of streaming RPCs rejected for exceeding the limit of active streams, by method", "method")
+	})
+	return &StreamLimit{max: max, active: make(map[string]int)}
+}
+
+// StreamInterceptor counts the streaming RPCs while their handlers run, and
+// rejects those over the limit.
+func (l *StreamLimit) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	method := info.FullMethod
+	if !l.acquire(method) {
+		rejectedStreams.Inc(method)
+		return status.Errorf(codes.ResourceExhausted, "too many active streams, the limit is %d", l.max)
+	}
+	defer l.release(method)
+	return handler(srv, ss)
+}
+
+// Active returns the number of active streams, and the limit, zero if none.
+func (l *StreamLimit) Active() (active, max int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.total, l.max
+}
+
+func (l *StreamLimit) acquire(method string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.max > 0 && l.total >= l.max {
+		return false
+	}
+	l.total++
+	l.active[method]++
+	activeStreamsGauge.Set(float64(l.active[method]), method)
+	return true
+}
+
+func (l *StreamLimit) release(method string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.total--
+	l.active[method]--
+	activeStreamsGauge.Set(float64(l.active[method]), method)
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStreamLimit(t *testing.T) {
+	for _, test := range []struct {
+		desc         string
+		max, streams int
+		wantRejected int
+	}{
+		{desc: "unlimited", max: 0, streams: 5},
+		{desc: "underLimit", max: 5, streams: 4},
+		{desc: "atLimit", max: 5, streams: 5},
+		{desc: "overLimit", max: 2, streams: 5, wantRejected: 3},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			l := NewStreamLimit(test.max, monitoring.InertMetricFactory{})
+			info := &grpc.StreamServerInfo{FullMethod: "/trillian.TrillianLog/TailLeaves"}
+			ss := &fakeServerStream{ctx: context.Background()}
+
+			// Streams block until released, so that they're all active at once.
+			release := make(chan struct{})
+			started := make(chan struct{})
+			errs := make(chan error, test.streams)
+			handler := func(srv interface{}, stream grpc.ServerStream) error {
+				started <- struct{}{}
+				<-release
+				return nil
+			}
+			wantActive := test.streams - test.wantRejected
+			for i := 0; i < test.streams; i++ {
+				go func() { errs <- l.StreamInterceptor(nil, ss, info, handler) }()
+			}
+			for i := 0; i < wantActive; i++ {
+				<-started
+			}
+			for i := 0; i < test.wantRejected; i++ {
+				if err := <-errs; status.Code(err) != codes.ResourceExhausted {
+					t.Errorf("StreamInterceptor() returned %v, want code %v", err, codes.ResourceExhausted)
+				}
+			}
+
+			if active, max := l.Active(); active != wantActive || max != test.max {
+				t.Errorf("Active() = %d, %d; want %d, %d", active, max, wantActive, test.max)
+			}
+
+			close(release)
+			for i := 0; i < wantActive; i++ {
+				if err := <-errs; err != nil {
+					t.Errorf("StreamInterceptor() returned %v, want nil", err)
+				}
+			}
+			if active, _ := l.Active(); active != 0 {
+				t.Errorf("Active() after streams ended = %d, want 0", active)
+			}
+		})
+	}
+}
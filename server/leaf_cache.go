@@ -0,0 +1,181 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+)
+
+// LeafCache holds the most recently integrated leaves of each log, so that
+// reads of the tail of a log are served without a storage read. It is filled
+// by GetLeavesByRange reads, and only serves leaves below the size of the log
+// root read in the same transaction. It holds the leaves of a fixed number of
+// logs, and evicts the least recently used ones, so that logs which are no
+// longer read, e.g. as they were deleted, don't stay cached. Cached leaves are
+// shared between responses and must not be modified.
+type LeafCache struct {
+	size    int64
+	maxLogs int
+	hits    monitoring.Counter
+	misses  monitoring.Counter
+
+	mu      sync.Mutex
+	lru     *list.List // Of *leafWindow, most recently used first.
+	windows map[int64]*list.Element
+}
+
+// leafWindow holds the contiguous leaves [start, end) of a log, in a ring
+// indexed by LeafIndex modulo its length.
+type leafWindow struct {
+	logID      int64
+	start, end int64
+	ring       []*trillian.LogLeaf
+}
+
+// NewLeafCache creates a LeafCache which holds up to size leaves for each of
+// up to maxLogs logs. It returns nil, which caches nothing, if size or maxLogs
+// isn't positive.
+func NewLeafCache(size, maxLogs int, mf monitoring.MetricFactory) *LeafCache {
+	if size <= 0 || maxLogs <= 0 {
+		return nil
+	}
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	return &LeafCache{
+		size:    int64(size),
+		maxLogs: maxLogs,
+		hits:    mf.NewCounter("leaf_cache_hits", "Number of GetLeavesByRange requests served from the leaf cache"),
+		misses:  mf.NewCounter("leaf_cache_misses", "Number of GetLeavesByRange requests not served from the leaf cache"),
+		lru:     list.New(),
+		windows: make(map[int64]*list.Element),
+	}
+}
+
+// get returns the leaves [start, start+count) of a log, truncated to
+// treeSize, and whether they are all cached. Cached leaves at or above
+// treeSize are dropped, as the log can't have integrated them.
+func (c *LeafCache) get(logID, start, count, treeSize int64) ([]*trillian.LogLeaf, bool) {
+	if c == nil {
+		return nil, false
+	}
+	end := start + count
+	if end > treeSize {
+		end = treeSize
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w, ok := c.window(logID)
+	if ok && w.end > treeSize {
+		w.end = treeSize
+		if w.start >= w.end {
+			c.lru.Remove(c.windows[logID])
+			delete(c.windows, logID)
+			ok = false
+		}
+	}
+	if !ok || start >= end || start < w.start || end > w.end {
+		c.misses.Inc()
+		return nil, false
+	}
+	c.hits.Inc()
+	leaves := make([]*trillian.LogLeaf, 0, end-start)
+	for i := start; i < end; i++ {
+		leaves = append(leaves, w.ring[i%c.size])
+	}
+	return leaves, true
+}
+
+// put adds the leaves of a log read from storage in a transaction which saw a
+// root of treeSize. Only the leaves among the last size ones below treeSize
+// are kept. The leaves must be contiguous and in index order; otherwise they
+// are ignored.
+func (c *LeafCache) put(logID int64, leaves []*trillian.LogLeaf, treeSize int64) {
+	if c == nil || len(leaves) == 0 {
+		return
+	}
+	start := leaves[0].LeafIndex
+	for i, leaf := range leaves {
+		if leaf.LeafIndex != start+int64(i) {
+			return
+		}
+	}
+	end := start + int64(len(leaves))
+	if start < 0 || end > treeSize {
+		return
+	}
+	if tail := treeSize - c.size; start < tail {
+		if end <= tail {
+			return
+		}
+		leaves = leaves[tail-start:]
+		start = tail
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w, ok := c.window(logID)
+	if !ok {
+		w = &leafWindow{logID: logID, ring: make([]*trillian.LogLeaf, c.size)}
+		c.windows[logID] = c.lru.PushFront(w)
+		for c.lru.Len() > c.maxLogs {
+			e := c.lru.Back()
+			c.lru.Remove(e)
+			delete(c.windows, e.Value.(*leafWindow).logID)
+		}
+	}
+	if !ok || end < w.start || start > w.end || w.end > treeSize {
+		// The leaves aren't adjacent to the cached ones, or the log shrank
+		// since they were cached, so start again from these leaves.
+		w.start, w.end = start, start
+	}
+	if end > w.end {
+		w.end = end
+	}
+	// Keep only the last size leaves, so that no two share a slot.
+	if lo := w.end - c.size; start < lo {
+		if end <= lo {
+			leaves = nil
+		} else {
+			leaves = leaves[lo-start:]
+		}
+		start = lo
+	}
+	for i, leaf := range leaves {
+		w.ring[(start+int64(i))%c.size] = leaf
+	}
+	if start < w.start {
+		w.start = start
+	}
+	if w.end-w.start > c.size {
+		w.start = w.end - c.size
+	}
+}
+
+// window returns the cached window of a log, if any, and marks it as the most
+// recently used. c.mu must be held.
+func (c *LeafCache) window(logID int64) (*leafWindow, bool) {
+	e, ok := c.windows[logID]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*leafWindow), true
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian"
+)
+
+// testLeaves returns leaves [start, end).
+func testLeaves(start, end int64) []*trillian.LogLeaf {
+	var leaves []*trillian.LogLeaf
+	for i := start; i < end; i++ {
+		leaves = append(leaves, &trillian.LogLeaf{LeafIndex: i})
+	}
+	return leaves
+}
+
+func leafIndices(leaves []*trillian.LogLeaf) []int64 {
+	var indices []int64
+	for _, leaf := range leaves {
+		indices = append(indices, leaf.LeafIndex)
+	}
+	return indices
+}
+
+func TestLeafCache(t *testing.T) {
+	type put struct {
+		start, end, treeSize int64
+	}
+	for _, test := range []struct {
+		desc                  string
+		puts                  []put
+		start, count, getSize int64
+		want                  []int64
+		wantHit               bool
+	}{
+		{desc: "empty", start: 0, count: 1, getSize: 10},
+		{desc: "hit", puts: []put{{2, 6, 6}}, start: 3, count: 2, getSize: 10, want: []int64{3, 4}, wantHit: true},
+		{desc: "clampedToTreeSize", puts: []put{{6, 10, 10}}, start: 8, count: 5, getSize: 10, want: []int64{8, 9}, wantHit: true},
+		{desc: "partial", puts: []put{{2, 6, 6}}, start: 5, count: 2, getSize: 10},
+		{desc: "onlyTail", puts: []put{{0, 10, 10}}, start: 5, count: 1, getSize: 10},
+		{desc: "tail", puts: []put{{0, 10, 10}}, start: 6, count: 4, getSize: 10, want: []int64{6, 7, 8, 9}, wantHit: true},
+		{desc: "beyondRoot", puts: []put{{2, 6, 5}}, start: 2, count: 1, getSize: 10},
+		{desc: "appended", puts: []put{{2, 5, 5}, {5, 7, 7}}, start: 3, count: 4, getSize: 10, want: []int64{3, 4, 5, 6}, wantHit: true},
+		{desc: "appendedEvicts", puts: []put{{2, 5, 5}, {5, 7, 7}}, start: 2, count: 1, getSize: 10},
+		{desc: "prepended", puts: []put{{5, 7, 7}, {3, 5, 7}}, start: 3, count: 4, getSize: 10, want: []int64{3, 4, 5, 6}, wantHit: true},
+		{desc: "prependedKeepsTail", puts: []put{{5, 8, 8}, {2, 5, 8}}, start: 4, count: 4, getSize: 10, want: []int64{4, 5, 6, 7}, wantHit: true},
+		{desc: "prependedDropsHead", puts: []put{{5, 8, 8}, {2, 5, 8}}, start: 3, count: 1, getSize: 10},
+		{desc: "disjoint", puts: []put{{2, 4, 4}, {6, 8, 8}}, start: 2, count: 1, getSize: 10},
+		{desc: "disjointKept", puts: []put{{2, 4, 4}, {6, 8, 8}}, start: 6, count: 2, getSize: 10, want: []int64{6, 7}, wantHit: true},
+		{desc: "shrunk", puts: []put{{2, 6, 6}}, start: 4, count: 1, getSize: 4},
+		{desc: "shrunkKeepsPrefix", puts: []put{{2, 6, 6}}, start: 2, count: 5, getSize: 4, want: []int64{2, 3}, wantHit: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			c := NewLeafCache(4, 2, nil)
+			for _, p := range test.puts {
+				c.put(1, testLeaves(p.start, p.end), p.treeSize)
+			}
+			leaves, hit := c.get(1, test.start, test.count, test.getSize)
+			if hit != test.wantHit {
+				t.Errorf("get(%d, %d, %d) hit=%v, want %v", test.start, test.count, test.getSize, hit, test.wantHit)
+			}
+			if got := leafIndices(leaves); !cmp.Equal(got, test.want) {
+				t.Errorf("get(%d, %d, %d)=%v, want %v", test.start, test.count, test.getSize, got, test.want)
+			}
+			if _, hit := c.get(2, test.start, test.count, test.getSize); hit {
+				t.Errorf("get() of another log hit")
+			}
+		})
+	}
+}
+
+func TestLeafCacheMetrics(t *testing.T) {
+	c := NewLeafCache(4, 2, nil)
+	c.put(1, testLeaves(0, 4), 4)
+	c.get(1, 0, 4, 4)
+	c.get(1, 2, 1, 4)
+	c.get(1, 4, 1, 8)
+	if got, want := c.hits.Value(), 2.0; got != want {
+		t.Errorf("hits=%v, want %v", got, want)
+	}
+	if got, want := c.misses.Value(), 1.0; got != want {
+		t.Errorf("misses=%v, want %v", got, want)
+	}
+}
+
+func TestLeafCacheEvictsLogs(t *testing.T) {
+	c := NewLeafCache(4, 2, nil)
+	c.put(1, testLeaves(0, 4), 4)
+	c.put(2, testLeaves(0, 4), 4)
+	// Reading log 1 makes log 2 the least recently used one.
+	c.get(1, 0, 1, 4)
+	c.put(3, testLeaves(0, 4), 4)
+	for _, test := range []struct {
+		logID   int64
+		wantHit bool
+	}{
+		{logID: 1, wantHit: true},
+		{logID: 2, wantHit: false},
+		{logID: 3, wantHit: true},
+	} {
+		if _, hit := c.get(test.logID, 0, 4, 4); hit != test.wantHit {
+			t.Errorf("get(log %d) hit=%v, want %v", test.logID, hit, test.wantHit)
+		}
+	}
+	if got, want := len(c.windows), 2; got != want {
+		t.Errorf("cached %d logs, want %d", got, want)
+	}
+}
+
+func TestLeafCacheDisabled(t *testing.T) {
+	for _, test := range []struct {
+		size, maxLogs int
+	}{
+		{size: 0, maxLogs: 2},
+		{size: 4, maxLogs: 0},
+	} {
+		c := NewLeafCache(test.size, test.maxLogs, nil)
+		if c != nil {
+			t.Fatalf("NewLeafCache(%d, %d)=%v, want nil", test.size, test.maxLogs, c)
+		}
+		c.put(1, testLeaves(0, 4), 4)
+		if _, hit := c.get(1, 0, 1, 4); hit {
+			t.Errorf("get() of a disabled cache hit")
+		}
+	}
+}
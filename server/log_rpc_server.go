@@ -15,19 +15,30 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
 	"github.com/google/trillian/extension"
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/server/queuewal"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/namespace"
+	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/trees"
 	"github.com/google/trillian/types"
 	"github.com/google/trillian/util/clock"
+	"github.com/google/trillian/util/requestid"
+	"github.com/google/trillian/validation"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -37,6 +48,52 @@ import (
 
 const traceSpanRoot = "/trillian"
 
+// maxProofsByHash is the maximum number of proofs returned by
+// GetInclusionProofByHash when all indices of a leaf hash are requested.
+const maxProofsByHash = 100
+
+// maxSignedLogRootHistory is the maximum number of roots returned by
+// GetSignedLogRootHistory.
+const maxSignedLogRootHistory = 1000
+
+// maxPredictRootLeaves is the maximum number of leaf hashes accepted by
+// PredictRoot.
+const maxPredictRootLeaves = 1000
+
+// tailLeavesBatch is the maximum number of leaves sent in a single
+// TailLeavesResponse.
+const tailLeavesBatch = 1000
+
+// defaultBulkChunkSize is the number of leaves BulkAddSequencedLeaves adds
+// in each transaction if the request doesn't set chunk_size.
+const defaultBulkChunkSize = 1000
+
+// defaultTailPollInterval is used if TrillianLogRPCServer.TailPollInterval
+// isn't set.
+const defaultTailPollInterval = time.Second
+
+// defaultProofReadBackoff is used if TrillianLogRPCServer.ProofReadBackoff
+// isn't set.
+const defaultProofReadBackoff = 10 * time.Millisecond
+
+// defaultMaxProofTreeSize is used if TrillianLogRPCServer.MaxProofTreeSize
+// isn't set. Proofs in trees of up to 2^48 leaves have at most 48 nodes.
+const defaultMaxProofTreeSize = 1 << 48
+
+// indexOffsets holds the index of the first leaf of a log in each supported
+// trillian.IndexBase.
+var indexOffsets = map[trillian.IndexBase]int64{
+	trillian.IndexBase_INDEX_BASE_ZERO: 0,
+	trillian.IndexBase_INDEX_BASE_ONE:  1,
+}
+
+// firstLeafIndex returns the index of the first leaf of tree in requests and
+// responses using base, i.e. the leaf_index_offset of the tree plus the
+// offset of base. Leaves are stored with 0-based indices.
+func firstLeafIndex(tree *trillian.Tree, base trillian.IndexBase) int64 {
+	return tree.LeafIndexOffset + indexOffsets[base]
+}
+
 var (
 	optsLogInit            = trees.NewGetOpts(trees.Admin, trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG)
 	optsLogRead            = trees.NewGetOpts(trees.Query, trillian.TreeType_LOG, trillian.TreeType_PREORDERED_LOG)
@@ -51,6 +108,78 @@ type TrillianLogRPCServer struct {
 	leafCounter           monitoring.Counter
 	proofIndexPercentiles monitoring.Histogram
 	fetchedLeaves         monitoring.Counter
+	proofNodes            monitoring.Histogram
+	proofNodeReads        monitoring.Histogram
+	proofBytes            monitoring.Histogram
+	proofReadRetries      monitoring.Counter
+	bulkAddedLeaves       monitoring.Counter
+	bulkExistingLeaves    monitoring.Counter
+	bulkRejectedLeaves    monitoring.Counter
+	bulkHighIndex         monitoring.Gauge
+
+	// ProofReadConcurrency is the maximum number of parallel storage reads
+	// used to fetch the nodes of a single proof. Values above 1 must only be
+	// used with storage that supports concurrent reads in a read-only
+	// transaction. It should be set before the server starts serving.
+	ProofReadConcurrency int
+
+	// ProofReadRetries is the number of times a storage read of proof nodes
+	// which fails with a transient error, e.g. codes.Unavailable, is retried
+	// before the proof fails. Retries read at the same revision within the
+	// same transaction, so the proof stays consistent. Other errors fail
+	// immediately. Zero disables retries. It should be set before the server
+	// starts serving.
+	ProofReadRetries int
+
+	// ProofReadBackoff is the pause before the first retry of a proof node
+	// read, which doubles with each further retry up to 32 times as long,
+	// with random jitter. Zero means
+	// defaultProofReadBackoff. It should be set before the server starts
+	// serving.
+	ProofReadBackoff time.Duration
+
+	// MaxProofTreeSize is the largest tree size which inclusion and
+	// consistency proofs are served for. Proofs requested for a larger size,
+	// or from a log whose latest root has a larger size, e.g. because it is
+	// corrupted, fail with codes.InvalidArgument. Zero means
+	// defaultMaxProofTreeSize. It should be set before the server starts
+	// serving.
+	MaxProofTreeSize int64
+
+	// RevisionProofs enables the GetProofAtRevision RPC, which reads proofs
+	// at arbitrary storage revisions for troubleshooting. It's disabled by
+	// default. It should be set before the server starts serving.
+	RevisionProofs bool
+
+	// RevisionProofCallers, if set, restricts GetProofAtRevision to callers
+	// whose namespace, as claimed to the namespace interceptor, is listed.
+	// Other callers, including those which claim no namespace, fail with
+	// codes.PermissionDenied. Empty means any caller may use it if
+	// RevisionProofs is set. It should be set before the server starts
+	// serving.
+	RevisionProofCallers []string
+
+	// TailPollInterval is how often TailLeaves checks whether new leaves
+	// have been integrated, once it has sent all those which were. Zero means
+	// defaultTailPollInterval. It should be set before the server starts
+	// serving.
+	TailPollInterval time.Duration
+
+	// QueueWAL, if set, accepts the leaves of trees with queue_write_ahead
+	// set while storage is unavailable. Its leaves are drained into storage by
+	// DrainQueueWAL, and before further leaves of the same tree are queued. It
+	// should be set before the server starts serving.
+	QueueWAL *queuewal.WAL
+
+	// Shadows holds the shadow logs which the leaves queued to each log are
+	// also queued to, by log ID. It should be set before the server starts
+	// serving.
+	Shadows map[int64]Shadow
+
+	// LeafCache, if set, serves GetLeavesByRange reads of the most recently
+	// integrated leaves of each log without a storage read. It should be set
+	// before the server starts serving.
+	LeafCache *LeafCache
 }
 
 // NewTrillianLogRPCServer creates a new RPC server backed by a LogStorageProvider.
@@ -76,6 +205,49 @@ func NewTrillianLogRPCServer(registry extension.Registry, timeSource clock.TimeS
 			"fetched_leaves",
 			"Count of individual leaves fetched through get-entries calls",
 		),
+		proofNodes: mf.NewHistogramWithBuckets(
+			"proof_nodes",
+			"Number of nodes in inclusion and consistency proofs served",
+			monitoring.ExpBuckets(1, 1.25, 24),
+			monitoring.TreeIDLabel,
+		),
+		proofNodeReads: mf.NewHistogramWithBuckets(
+			"proof_node_reads",
+			"Number of Merkle nodes read from storage to build inclusion and consistency proofs",
+			monitoring.ExpBuckets(1, 1.25, 24),
+			monitoring.TreeIDLabel,
+		),
+		proofBytes: mf.NewHistogramWithBuckets(
+			"proof_bytes",
+			"Total size in bytes of the hashes in inclusion and consistency proofs served",
+			monitoring.ExpBuckets(32, 1.25, 24),
+			monitoring.TreeIDLabel,
+		),
+		proofReadRetries: mf.NewCounter(
+			"proof_read_retries",
+			"Number of storage reads of proof nodes retried after transient errors",
+			monitoring.TreeIDLabel,
+		),
+		bulkAddedLeaves: mf.NewCounter(
+			"bulk_added_leaves",
+			"Number of leaves added by BulkAddSequencedLeaves",
+			monitoring.TreeIDLabel,
+		),
+		bulkExistingLeaves: mf.NewCounter(
+			"bulk_existing_leaves",
+			"Number of leaves submitted to BulkAddSequencedLeaves which were already stored at their index",
+			monitoring.TreeIDLabel,
+		),
+		bulkRejectedLeaves: mf.NewCounter(
+			"bulk_rejected_leaves",
+			"Number of leaves rejected by BulkAddSequencedLeaves",
+			monitoring.TreeIDLabel,
+		),
+		bulkHighIndex: mf.NewGauge(
+			"bulk_high_index",
+			"Highest leaf index added or found already stored by the latest chunk of BulkAddSequencedLeaves",
+			monitoring.TreeIDLabel,
+		),
 	}
 }
 
@@ -108,16 +280,64 @@ func (t *TrillianLogRPCServer) QueueLeaf(ctx context.Context, req *trillian.Queu
 	if len(queueRsp.QueuedLeaves) != 1 {
 		return nil, status.Errorf(codes.Internal, "unexpected count of leaves %d", len(queueRsp.QueuedLeaves))
 	}
-	return &trillian.QueueLeafResponse{QueuedLeaf: queueRsp.QueuedLeaves[0]}, nil
+	queued := queueRsp.QueuedLeaves[0]
+	// A leaf rejected by the leaf validator fails the request, so that callers
+	// don't mistake it for a queued leaf.
+	if c := codes.Code(queued.GetStatus().GetCode()); c != codes.OK && c != codes.AlreadyExists {
+		return nil, status.ErrorProto(queued.Status)
+	}
+	return &trillian.QueueLeafResponse{QueuedLeaf: queued, Shadow: queueRsp.Shadow}, nil
 }
 
-func hashLeaves(leaves []*trillian.LogLeaf, hasher hashers.LogHasher) {
-	for _, leaf := range leaves {
-		leaf.MerkleLeafHash = hasher.HashLeaf(leaf.LeafValue)
+// hashLeaves sets the Merkle leaf hash of each leaf, which covers its extra
+// data too if the tree requires it, and defaults the leaf identity hash to it.
+// Leaf identity hashes supplied by the caller are kept, and must be of the
+// hasher's size if the tree has caller_leaf_identity_hash.
+// Leaves of hash-only trees keep the Merkle leaf hash supplied by the caller
+// instead, and must have no value.
+func hashLeaves(tree *trillian.Tree, leaves []*trillian.LogLeaf, hasher hashers.LogHasher) error {
+	for i, leaf := range leaves {
+		switch {
+		case tree.HashOnly && len(leaf.LeafValue) != 0:
+			return status.Errorf(codes.InvalidArgument, "leaves[%d].LeafValue: must be empty for hash-only trees", i)
+		case tree.HashOnly:
+			if err := validateLeafHash(leaf.MerkleLeafHash, hasher); err != nil {
+				return status.Errorf(codes.InvalidArgument, "leaves[%d].MerkleLeafHash: %v", i, err)
+			}
+			// Storage may require values to be non-NULL.
+			leaf.LeafValue = []byte{}
+		case len(leaf.LeafValue) == 0:
+			return status.Errorf(codes.InvalidArgument, "leaves[%d].LeafValue: empty", i)
+		default:
+			leaf.MerkleLeafHash = hashers.HashLogLeaf(hasher, leaf.LeafValue, leaf.ExtraData, tree.HashExtraData)
+		}
 		if len(leaf.LeafIdentityHash) == 0 {
 			leaf.LeafIdentityHash = leaf.MerkleLeafHash
+		} else if got, want := len(leaf.LeafIdentityHash), hasher.Size(); tree.CallerLeafIdentityHash && got != want {
+			return status.Errorf(codes.InvalidArgument, "leaves[%d].LeafIdentityHash has size %d, want %d", i, got, want)
+		}
+	}
+	return nil
+}
+
+// checkTombstones returns INVALID_ARGUMENT if any of the leaves of a tree with
+// leaf_tombstones has a value starting with types.TombstonePrefix which isn't a
+// valid tombstone or, if the leaves are preordered, is a tombstone for a leaf
+// which doesn't precede it.
+func checkTombstones(tree *trillian.Tree, leaves []*trillian.LogLeaf, preordered bool) error {
+	if !tree.LeafTombstones {
+		return nil
+	}
+	for i, leaf := range leaves {
+		deleted, ok, err := types.ParseTombstone(leaf.LeafValue)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "leaves[%d].LeafValue: %v", i, err)
+		}
+		if ok && preordered && deleted >= leaf.LeafIndex {
+			return status.Errorf(codes.InvalidArgument, "leaves[%d] is a tombstone for leaf %d, want < LeafIndex %d", i, deleted, leaf.LeafIndex)
 		}
 	}
+	return nil
 }
 
 // QueueLeaves submits a batch of leaves to the log for later integration into the underlying tree.
@@ -133,24 +353,184 @@ func (t *TrillianLogRPCServer) QueueLeaves(ctx context.Context, req *trillian.Qu
 	if err != nil {
 		return nil, err
 	}
+	if err := checkTombstones(tree, req.Leaves, false); err != nil {
+		return nil, err
+	}
 
 	ctx = trees.NewContext(ctx, tree)
+	if err := t.checkTreeSize(ctx, tree, req.Condition); err != nil {
+		return nil, err
+	}
+
+	shadow, hasShadow := t.Shadows[logID]
+	var shadowLeaves []*trillian.LogLeaf
+	if hasShadow {
+		shadowLeaves = cloneLeaves(req.Leaves)
+	}
 
-	hashLeaves(req.Leaves, hasher)
+	if err := hashLeaves(tree, req.Leaves, hasher); err != nil {
+		return nil, err
+	}
 
-	ret, err := t.registry.LogStorage.QueueLeaves(ctx, tree, req.Leaves, t.timeSource.Now())
+	ret, err := t.queueValidLeaves(ctx, tree, req.Leaves, req.Condition)
 	if err != nil {
 		return nil, err
 	}
+	resp := &trillian.QueueLeavesResponse{QueuedLeaves: ret}
+	if hasShadow {
+		resp.Shadow = t.queueShadow(ctx, shadow, shadowLeaves, ret)
+	}
 
 	for _, l := range ret {
 		if l.Status == nil || l.Status.Code == int32(codes.OK) {
 			t.leafCounter.Inc("new")
 		} else if l.Status.Code == int32(codes.AlreadyExists) {
 			t.leafCounter.Inc("existing")
+		} else {
+			t.leafCounter.Inc("rejected")
+		}
+	}
+	return resp, nil
+}
+
+// checkTreeSize returns FAILED_PRECONDITION if the log has reached its
+// max_tree_size, or doesn't have the size expected by cond if it is set.
+// Leaves queued while the log fills up can still get past this check, but the
+// sequencer never integrates them; likewise, the sequencer checks cond again.
+func (t *TrillianLogRPCServer) checkTreeSize(ctx context.Context, tree *trillian.Tree, cond *trillian.QueueCondition) error {
+	if cond != nil && cond.ExpectedTreeSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "QueueLeavesRequest.Condition.ExpectedTreeSize: %d, want >= 0", cond.ExpectedTreeSize)
+	}
+	if tree.MaxTreeSize <= 0 && cond == nil {
+		return nil
+	}
+	tx, err := t.snapshotForTree(ctx, tree, "QueueLeaves")
+	if err != nil {
+		return err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "QueueLeaves")
+
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return err
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return status.Errorf(codes.Internal, "Could not read current log root: %v", err)
+	}
+	if err := t.commitAndLog(ctx, tree.TreeId, tx, "QueueLeaves"); err != nil {
+		return err
+	}
+	if tree.MaxTreeSize > 0 && root.TreeSize >= uint64(tree.MaxTreeSize) {
+		return status.Errorf(codes.FailedPrecondition, "log is full: tree size %d reached max_tree_size %d", root.TreeSize, tree.MaxTreeSize)
+	}
+	if cond != nil && root.TreeSize != uint64(cond.ExpectedTreeSize) {
+		return status.Errorf(codes.FailedPrecondition, "tree size is %d, want expected_tree_size %d", root.TreeSize, cond.ExpectedTreeSize)
+	}
+	return nil
+}
+
+// queueValidLeaves queues the leaves accepted by the registry's LeafValidator,
+// and returns the results for all leaves in order, with the status of those
+// which were rejected. If cond is set, the accepted leaves are queued as one
+// conditional batch.
+func (t *TrillianLogRPCServer) queueValidLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, cond *trillian.QueueCondition) ([]*trillian.QueuedLogLeaf, error) {
+	rejected, err := validation.ValidateLeaves(ctx, t.registry.LeafValidator, tree, leaves)
+	if err != nil {
+		return nil, err
+	}
+	valid := make([]*trillian.LogLeaf, 0, len(leaves))
+	for i, leaf := range leaves {
+		if rejected[i] == nil {
+			valid = append(valid, leaf)
+		}
+	}
+	if cond != nil {
+		ctx = storage.NewQueueConditionContext(ctx, &storagepb.QueueCondition{
+			BatchId:   newBatchID(),
+			TreeSize:  cond.ExpectedTreeSize,
+			BatchSize: int64(len(valid)),
+		})
+	}
+	if len(valid) == len(leaves) {
+		return t.queueLeaves(ctx, tree, leaves, cond != nil)
+	}
+
+	var queued []*trillian.QueuedLogLeaf
+	if len(valid) > 0 {
+		if queued, err = t.queueLeaves(ctx, tree, valid, cond != nil); err != nil {
+			return nil, err
+		}
+		if got, want := len(queued), len(valid); got != want {
+			return nil, status.Errorf(codes.Internal, "QueueLeaves returned %d leaves, want: %d", got, want)
+		}
+	}
+	ret := make([]*trillian.QueuedLogLeaf, len(leaves))
+	for i := range leaves {
+		if rejected[i] != nil {
+			ret[i] = &trillian.QueuedLogLeaf{Status: rejected[i].Proto()}
+			continue
+		}
+		ret[i], queued = queued[0], queued[1:]
+	}
+	return ret, nil
+}
+
+// queueLeaves queues leaves in storage or, if the tree has queue_write_ahead
+// set and storage is unavailable, in the QueueWAL. Conditional batches are
+// always queued in storage, which checks their condition.
+func (t *TrillianLogRPCServer) queueLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, conditional bool) ([]*trillian.QueuedLogLeaf, error) {
+	queue := func(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+		return t.registry.LogStorage.QueueLeaves(ctx, tree, leaves, queueTimestamp)
+	}
+	if t.QueueWAL == nil || !tree.QueueWriteAhead || conditional {
+		return queue(ctx, leaves, t.timeSource.Now())
+	}
+	return t.QueueWAL.Queue(ctx, tree.TreeId, leaves, t.timeSource.Now(), queue)
+}
+
+// DrainQueueWAL drains the leaves in the QueueWAL into storage every interval,
+// until ctx is done. Trees for which storage is still unavailable are retried
+// at the next interval.
+func (t *TrillianLogRPCServer) DrainQueueWAL(ctx context.Context, interval time.Duration) {
+	if t.QueueWAL == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, treeID := range t.QueueWAL.Pending() {
+			if err := t.drainQueueWAL(ctx, treeID); err != nil {
+				glog.V(1).Infof("%d: failed to drain the queue WAL: %v", treeID, err)
+			}
 		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *TrillianLogRPCServer) drainQueueWAL(ctx context.Context, treeID int64) error {
+	tree, err := trees.GetTree(ctx, t.registry.AdminStorage, treeID, optsLogWrite)
+	if err != nil {
+		return err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	return t.QueueWAL.Drain(ctx, treeID, func(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+		return t.registry.LogStorage.QueueLeaves(ctx, tree, leaves, queueTimestamp)
+	})
+}
+
+// newBatchID returns a random ID for a conditional batch of leaves.
+func newBatchID() []byte {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		// crypto/rand only fails if the OS has no source of randomness.
+		panic(err)
 	}
-	return &trillian.QueueLeavesResponse{QueuedLeaves: ret}, nil
+	return id
 }
 
 // AddSequencedLeaf submits one sequenced leaf to the storage.
@@ -191,19 +571,212 @@ func (t *TrillianLogRPCServer) AddSequencedLeaves(ctx context.Context, req *tril
 	if err != nil {
 		return nil, err
 	}
+	if err := prepareSequencedLeaves(tree, hasher, req.Leaves); err != nil {
+		return nil, err
+	}
 
-	hashLeaves(req.Leaves, hasher)
+	ctx = trees.NewContext(ctx, tree)
+	leaves, err := t.addSequencedLeaves(ctx, tree, req.Leaves)
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.AddSequencedLeavesResponse{Results: leaves}, nil
+}
+
+// BulkAddSequencedLeaves submits a large batch of sequenced leaves to a
+// pre-ordered log in chunks, treating leaves already stored at their index as
+// added.
+func (t *TrillianLogRPCServer) BulkAddSequencedLeaves(ctx context.Context, req *trillian.BulkAddSequencedLeavesRequest) (*trillian.BulkAddSequencedLeavesResponse, error) {
+	ctx, spanEnd := spanFor(ctx, "BulkAddSequencedLeaves")
+	defer spanEnd()
+	if err := validateBulkAddSequencedLeavesRequest(req); err != nil {
+		return nil, err
+	}
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, req.LogId, optsPreorderedLogWrite)
+	if err != nil {
+		return nil, err
+	}
+	// Check all of the leaves before adding any of them.
+	if err := prepareSequencedLeaves(tree, hasher, req.Leaves); err != nil {
+		return nil, err
+	}
 
 	ctx = trees.NewContext(ctx, tree)
-	leaves, err := t.registry.LogStorage.AddSequencedLeaves(ctx, tree, req.Leaves, t.timeSource.Now())
+	chunkSize := int(req.ChunkSize)
+	if chunkSize == 0 {
+		chunkSize = defaultBulkChunkSize
+	}
+	label := strconv.FormatInt(tree.TreeId, 10)
+	rsp := &trillian.BulkAddSequencedLeavesResponse{Results: make([]*trillian.QueuedLogLeaf, 0, len(req.Leaves))}
+	for start := 0; start < len(req.Leaves); start += chunkSize {
+		end := start + chunkSize
+		if end > len(req.Leaves) {
+			end = len(req.Leaves)
+		}
+		chunk := req.Leaves[start:end]
+		results, err := t.addSequencedLeaves(ctx, tree, chunk)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.findExistingLeaves(ctx, tree, chunk, results); err != nil {
+			return nil, err
+		}
+
+		var added, existing, rejected int64
+		highIndex := int64(-1)
+		for i, result := range results {
+			switch status.FromProto(result.GetStatus()).Code() {
+			case codes.OK:
+				added++
+			case codes.AlreadyExists:
+				existing++
+			default:
+				rejected++
+				continue
+			}
+			highIndex = chunk[i].LeafIndex
+		}
+		t.bulkAddedLeaves.Add(float64(added), label)
+		t.bulkExistingLeaves.Add(float64(existing), label)
+		t.bulkRejectedLeaves.Add(float64(rejected), label)
+		if highIndex >= 0 {
+			t.bulkHighIndex.Set(float64(highIndex), label)
+		}
+		rsp.Results = append(rsp.Results, results...)
+		rsp.Added += added
+		rsp.Existing += existing
+		rsp.Rejected += rejected
+	}
+	return rsp, nil
+}
+
+// prepareSequencedLeaves checks that the leaves can be added to the
+// pre-ordered log, and sets their hashes.
+func prepareSequencedLeaves(tree *trillian.Tree, hasher hashers.LogHasher, leaves []*trillian.LogLeaf) error {
+	if max := tree.MaxTreeSize; max > 0 {
+		for i, leaf := range leaves {
+			if leaf.LeafIndex >= max {
+				return status.Errorf(codes.FailedPrecondition, "leaves[%d].LeafIndex: %d, past max_tree_size %d", i, leaf.LeafIndex, max)
+			}
+		}
+	}
+
+	if k := tree.LeafOrderingKey; k != nil {
+		for i, leaf := range leaves {
+			if _, err := storage.LeafOrderingKey(k, leaf); err != nil {
+				return status.Errorf(codes.InvalidArgument, "leaves[%d]: %v", i, err)
+			}
+		}
+	}
+	if err := checkTombstones(tree, leaves, true); err != nil {
+		return err
+	}
+	return hashLeaves(tree, leaves, hasher)
+}
+
+// addSequencedLeaves adds the contiguous batch of prepared leaves to storage.
+func (t *TrillianLogRPCServer) addSequencedLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf) ([]*trillian.QueuedLogLeaf, error) {
+	if tree.OrderedLeafTimestamps {
+		if err := t.checkLeafTimestampOrder(ctx, tree, leaves); err != nil {
+			return nil, err
+		}
+	}
+	results, err := t.registry.LogStorage.AddSequencedLeaves(ctx, tree, leaves, t.timeSource.Now())
 	if err != nil {
 		return nil, err
 	}
-	if got, want := len(leaves), len(req.Leaves); got != want {
+	if got, want := len(results), len(leaves); got != want {
 		return nil, status.Errorf(codes.Internal, "AddSequencedLeaves returned %d leaves, want: %d", got, want)
 	}
+	return results, nil
+}
 
-	return &trillian.AddSequencedLeavesResponse{Results: leaves}, nil
+// findExistingLeaves replaces the FAILED_PRECONDITION results of leaves which
+// are already stored at their index, with the same hashes, by ALREADY_EXISTS
+// results holding the stored leaves.
+func (t *TrillianLogRPCServer) findExistingLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, results []*trillian.QueuedLogLeaf) error {
+	var hashes [][]byte
+	for i, result := range results {
+		if status.FromProto(result.GetStatus()).Code() == codes.FailedPrecondition {
+			hashes = append(hashes, leaves[i].MerkleLeafHash)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	tx, err := t.snapshotForTree(ctx, tree, "BulkAddSequencedLeaves")
+	if err != nil {
+		return err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "BulkAddSequencedLeaves")
+	stored, err := tx.GetLeavesByHash(ctx, hashes, false)
+	if err != nil {
+		return err
+	}
+	if err := t.commitAndLog(ctx, tree.TreeId, tx, "BulkAddSequencedLeaves"); err != nil {
+		return err
+	}
+
+	byIndex := make(map[int64]*trillian.LogLeaf)
+	for _, leaf := range stored {
+		byIndex[leaf.LeafIndex] = leaf
+	}
+	for i, leaf := range leaves {
+		if status.FromProto(results[i].GetStatus()).Code() != codes.FailedPrecondition {
+			continue
+		}
+		s, ok := byIndex[leaf.LeafIndex]
+		if !ok || !bytes.Equal(s.MerkleLeafHash, leaf.MerkleLeafHash) || !bytes.Equal(s.LeafIdentityHash, leaf.LeafIdentityHash) {
+			continue
+		}
+		results[i] = &trillian.QueuedLogLeaf{
+			Leaf:   s,
+			Status: status.Newf(codes.AlreadyExists, "leaf already exists at index %d", leaf.LeafIndex).Proto(),
+		}
+	}
+	return nil
+}
+
+// checkLeafTimestampOrder checks that the contiguous batch of leaves has
+// non-decreasing integrate timestamps, also with respect to the adjacent leaves
+// already in storage. Adjacent leaves that are added concurrently with this
+// batch are not taken into account.
+func (t *TrillianLogRPCServer) checkLeafTimestampOrder(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf) error {
+	// Check the batch itself before going to storage.
+	if err := validateLeafTimestampOrder(leaves, nil, nil); err != nil {
+		return err
+	}
+
+	first, last := leaves[0].LeafIndex, leaves[len(leaves)-1].LeafIndex
+	indices := []int64{last + 1}
+	if first > 0 {
+		indices = append(indices, first-1)
+	}
+	tx, err := t.snapshotForTree(ctx, tree, "AddSequencedLeaves")
+	if err != nil {
+		return err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "AddSequencedLeaves")
+	stored, err := tx.GetLeavesByIndex(ctx, indices)
+	if err != nil {
+		return err
+	}
+	if err := t.commitAndLog(ctx, tree.TreeId, tx, "AddSequencedLeaves"); err != nil {
+		return err
+	}
+
+	var prev, next *trillian.LogLeaf
+	for _, leaf := range stored {
+		switch leaf.LeafIndex {
+		case first - 1:
+			prev = leaf
+		case last + 1:
+			next = leaf
+		}
+	}
+	return validateLeafTimestampOrder(leaves, prev, next)
 }
 
 // GetInclusionProof obtains the proof of inclusion in the tree for a leaf that has been sequenced.
@@ -221,6 +794,10 @@ func (t *TrillianLogRPCServer) GetInclusionProof(ctx context.Context, req *trill
 		return nil, err
 	}
 	ctx = trees.NewContext(ctx, tree)
+	leafIndex, err := validateProofLeafIndex("GetInclusionProofRequest.LeafIndex", req.LeafIndex, tree.LeafIndexOffset, req.TreeSize)
+	if err != nil {
+		return nil, err
+	}
 
 	// Next we need to make sure the requested tree size corresponds to an STH, so that we
 	// have a usable tree revision
@@ -245,21 +822,83 @@ func (t *TrillianLogRPCServer) GetInclusionProof(ctx context.Context, req *trill
 		return r, nil
 	}
 
-	proof, err := getInclusionProofForLeafIndex(ctx, tx, hasher, req.TreeSize, req.LeafIndex, int64(root.TreeSize))
+	counter := t.newNodeReadCounter(tx, tree.TreeId)
+	proof, err := t.getInclusionProofForLeafIndex(ctx, counter, hasher, req.TreeSize, leafIndex, int64(root.TreeSize))
 	if err != nil {
 		return nil, err
 	}
-	t.recordIndexPercent(req.LeafIndex, root.TreeSize)
+	t.recordIndexPercent(leafIndex, root.TreeSize)
+	t.recordProofSize(tree.TreeId, proof, counter.reads)
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
 
+	proof.LeafIndex += tree.LeafIndexOffset
 	r.Proof = proof
 
 	return r, nil
 }
 
+// GetHistoricalInclusionProof obtains the proof of inclusion of a leaf as of
+// an earlier tree size, along with the signed log root retained for that size.
+func (t *TrillianLogRPCServer) GetHistoricalInclusionProof(ctx context.Context, req *trillian.GetHistoricalInclusionProofRequest) (*trillian.GetHistoricalInclusionProofResponse, error) {
+	ctx, spanEnd := spanFor(ctx, "GetHistoricalInclusionProof")
+	defer spanEnd()
+	if err := validateGetHistoricalInclusionProofRequest(req); err != nil {
+		return nil, err
+	}
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, req.LogId, optsLogRead)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	leafIndex, err := validateProofLeafIndex("GetHistoricalInclusionProofRequest.LeafIndex", req.LeafIndex, tree.LeafIndexOffset, req.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := t.snapshotForTree(ctx, tree, "GetHistoricalInclusionProof")
+	if err != nil {
+		return nil, err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "GetHistoricalInclusionProof")
+
+	historical, err := tx.SignedLogRootAtSize(ctx, req.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+	if historical == nil {
+		return nil, status.Errorf(codes.NotFound, "no signed log root of tree size %d is retained", req.TreeSize)
+	}
+
+	// The proof nodes are read at the current revision, which still holds
+	// every node needed to prove inclusion in an earlier tree.
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not read current log root: %v", err)
+	}
+
+	counter := t.newNodeReadCounter(tx, tree.TreeId)
+	proof, err := t.getInclusionProofForLeafIndex(ctx, counter, hasher, req.TreeSize, leafIndex, int64(root.TreeSize))
+	if err != nil {
+		return nil, err
+	}
+	t.recordProofSize(tree.TreeId, proof, counter.reads)
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "GetHistoricalInclusionProof"); err != nil {
+		return nil, err
+	}
+
+	proof.LeafIndex += tree.LeafIndexOffset
+	return &trillian.GetHistoricalInclusionProofResponse{Proof: proof, SignedLogRoot: historical}, nil
+}
+
 // GetInclusionProofByHash obtains proofs of inclusion by leaf hash. Because some logs can
 // contain duplicate hashes it is possible for multiple proofs to be returned.
 func (t *TrillianLogRPCServer) GetInclusionProofByHash(ctx context.Context, req *trillian.GetInclusionProofByHashRequest) (*trillian.GetInclusionProofByHashResponse, error) {
@@ -300,19 +939,33 @@ func (t *TrillianLogRPCServer) GetInclusionProofByHash(ctx context.Context, req
 		return nil, status.Errorf(codes.Internal, "Could not read current log root: %v", err)
 	}
 
-	// TODO(Martin2112): Need to define a limit on number of results or some form of paging etc.
-	proofs := make([]*trillian.Proof, 0, len(leaves))
+	// Don't include leaves that aren't in the requested TreeSize.
+	inTree := make([]*trillian.LogLeaf, 0, len(leaves))
 	for _, leaf := range leaves {
-		// Don't include leaves that aren't in the requested TreeSize.
-		if leaf.LeafIndex >= req.TreeSize {
-			continue
+		if leaf.LeafIndex < req.TreeSize {
+			inTree = append(inTree, leaf)
 		}
-		proof, err := getInclusionProofForLeafIndex(ctx, tx, hasher, req.TreeSize, leaf.LeafIndex, int64(root.TreeSize))
+	}
+	truncated := false
+	if req.AllIndices {
+		sort.Slice(inTree, func(i, j int) bool { return inTree[i].LeafIndex < inTree[j].LeafIndex })
+		if len(inTree) > maxProofsByHash {
+			inTree, truncated = inTree[:maxProofsByHash], true
+		}
+	}
+
+	// TODO(Martin2112): Need to define a limit on number of results or some form of paging etc.
+	proofs := make([]*trillian.Proof, 0, len(inTree))
+	for _, leaf := range inTree {
+		counter := t.newNodeReadCounter(tx, tree.TreeId)
+		proof, err := t.getInclusionProofForLeafIndex(ctx, counter, hasher, req.TreeSize, leaf.LeafIndex, int64(root.TreeSize))
 		if err != nil {
 			return nil, err
 		}
-		proofs = append(proofs, proof)
 		t.recordIndexPercent(leaf.LeafIndex, root.TreeSize)
+		t.recordProofSize(tree.TreeId, proof, counter.reads)
+		proof.LeafIndex += tree.LeafIndexOffset
+		proofs = append(proofs, proof)
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -324,10 +977,17 @@ func (t *TrillianLogRPCServer) GetInclusionProofByHash(ctx context.Context, req
 	}
 
 	// TODO(gbelvin): Rename "Proof" -> "Proofs"
-	return &trillian.GetInclusionProofByHashResponse{
+	resp := &trillian.GetInclusionProofByHashResponse{
 		SignedLogRoot: slr,
 		Proof:         proofs,
-	}, nil
+	}
+	if req.AllIndices {
+		for _, leaf := range inTree {
+			resp.LeafIndices = append(resp.LeafIndices, leaf.LeafIndex+tree.LeafIndexOffset)
+		}
+		resp.Truncated = truncated
+	}
+	return resp, nil
 }
 
 // GetConsistencyProof obtains a proof that two versions of the tree are consistent with each
@@ -367,10 +1027,12 @@ func (t *TrillianLogRPCServer) GetConsistencyProof(ctx context.Context, req *tri
 		return r, nil
 	}
 	// Try to get consistency proof
-	proof, err := tryGetConsistencyProof(ctx, req.FirstTreeSize, req.SecondTreeSize, int64(root.TreeSize), tx, hasher)
+	counter := t.newNodeReadCounter(tx, tree.TreeId)
+	proof, err := t.tryGetConsistencyProof(ctx, req.FirstTreeSize, req.SecondTreeSize, int64(root.TreeSize), counter, hasher)
 	if err != nil {
 		return nil, err
 	}
+	t.recordProofSize(tree.TreeId, proof, counter.reads)
 
 	if err := tx.Commit(ctx); err != nil {
 		return nil, err
@@ -381,6 +1043,194 @@ func (t *TrillianLogRPCServer) GetConsistencyProof(ctx context.Context, req *tri
 	return r, nil
 }
 
+// GetProofAtRevision obtains an inclusion or consistency proof with the nodes
+// read at a given storage revision, rather than that of the latest root. It's
+// for troubleshooting storage, so it's only served if RevisionProofs is set,
+// and to RevisionProofCallers if those are set.
+func (t *TrillianLogRPCServer) GetProofAtRevision(ctx context.Context, req *trillian.GetProofAtRevisionRequest) (*trillian.GetProofAtRevisionResponse, error) {
+	ctx, spanEnd := spanFor(ctx, "GetProofAtRevision")
+	defer spanEnd()
+	if !t.RevisionProofs {
+		return nil, status.Error(codes.PermissionDenied, "GetProofAtRevision isn't enabled on this server")
+	}
+	if err := t.checkRevisionProofCaller(ctx); err != nil {
+		return nil, err
+	}
+	if err := validateGetProofAtRevisionRequest(req); err != nil {
+		return nil, err
+	}
+	if err := t.checkProofTreeSizes(req.TreeSize); err != nil {
+		return nil, err
+	}
+
+	tree, hasher, err := t.getTreeAndHasher(ctx, req.LogId, optsLogRead)
+	if err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+	var leafIndex int64
+	if req.FirstTreeSize == 0 {
+		if leafIndex, err = validateProofLeafIndex("GetProofAtRevisionRequest.LeafIndex", req.LeafIndex, tree.LeafIndexOffset, req.TreeSize); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := t.snapshotForTree(ctx, tree, "GetProofAtRevision")
+	if err != nil {
+		return nil, err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "GetProofAtRevision")
+
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Later revisions aren't rejected by storage, which reads the latest nodes
+	// at or below the requested revision, but could only be written by a
+	// sequencer which hasn't committed yet.
+	latest, err := tx.ReadRevision(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Revision > latest {
+		return nil, status.Errorf(codes.InvalidArgument, "GetProofAtRevisionRequest.Revision: %v, want <= latest revision %v", req.Revision, latest)
+	}
+	// Storage compaction may have removed the nodes read at earlier revisions.
+	if r, ok := tx.(storage.CompactedRevisionReader); ok {
+		compacted, err := r.CompactedRevision(ctx)
+		if err != nil && status.Code(err) != codes.Unimplemented {
+			return nil, err
+		}
+		if req.Revision < compacted {
+			return nil, status.Errorf(codes.FailedPrecondition, "GetProofAtRevisionRequest.Revision: %v was removed by storage compaction, want >= %v", req.Revision, compacted)
+		}
+	}
+
+	var fetches []merkle.NodeFetch
+	if req.FirstTreeSize > 0 {
+		fetches, err = consistencyProofFetches(req.FirstTreeSize, req.TreeSize, req.TreeSize)
+	} else {
+		fetches, err = inclusionProofFetches(req.TreeSize, leafIndex, req.TreeSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+	counter := t.newNodeReadCounter(tx, tree.TreeId)
+	proof, err := fetchNodesAndBuildProof(ctx, counter, hasher, req.Revision, leafIndex, fetches, t.ProofReadConcurrency)
+	if err != nil {
+		return nil, err
+	}
+	t.recordProofSize(tree.TreeId, proof, counter.reads)
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	if req.FirstTreeSize == 0 {
+		proof.LeafIndex += tree.LeafIndexOffset
+	}
+	return &trillian.GetProofAtRevisionResponse{Proof: proof, SignedLogRoot: slr}, nil
+}
+
+// checkRevisionProofCaller returns an error if RevisionProofCallers is set
+// and the caller in ctx isn't one of them.
+func (t *TrillianLogRPCServer) checkRevisionProofCaller(ctx context.Context) error {
+	if len(t.RevisionProofCallers) == 0 {
+		return nil
+	}
+	caller, ok := namespace.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.PermissionDenied, "GetProofAtRevision requires a caller namespace")
+	}
+	for _, c := range t.RevisionProofCallers {
+		if c == caller {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "GetProofAtRevision isn't enabled for namespace %q", caller)
+}
+
+// PredictRoot computes the root hash of a log with the given leaf hashes
+// appended to it at its latest signed root, without modifying it.
+func (t *TrillianLogRPCServer) PredictRoot(ctx context.Context, req *trillian.PredictRootRequest) (*trillian.PredictRootResponse, error) {
+	ctx, spanEnd := spanFor(ctx, "PredictRoot")
+	defer spanEnd()
+	tree, hasher, err := t.getTreeAndHasher(ctx, req.LogId, optsLogRead)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePredictRootRequest(req, hasher); err != nil {
+		return nil, err
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	tx, err := t.snapshotForTree(ctx, tree, "PredictRoot")
+	if err != nil {
+		return nil, err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "PredictRoot")
+
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not read current log root: %v", err)
+	}
+	cr, err := fetchCompactRange(ctx, tx, hasher, &root)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.commitAndLog(ctx, req.LogId, tx, "PredictRoot"); err != nil {
+		return nil, err
+	}
+
+	for _, hash := range req.LeafHashes {
+		if err := cr.Append(hash, nil); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to append leaf hash: %v", err)
+		}
+	}
+	hash, err := cr.GetRootHash(nil)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to compute root hash: %v", err)
+	}
+	if cr.End() == 0 {
+		hash = hasher.EmptyRoot()
+	}
+	return &trillian.PredictRootResponse{
+		SignedLogRoot: slr,
+		TreeSize:      int64(cr.End()),
+		RootHash:      hash,
+	}, nil
+}
+
+// VerifyInclusion verifies a client-supplied inclusion proof with the hasher
+// of the log, using the same merkle.LogVerifier as clients. Invalid proofs
+// are reported in the response rather than as errors.
+func (t *TrillianLogRPCServer) VerifyInclusion(ctx context.Context, req *trillian.VerifyInclusionRequest) (*trillian.VerifyInclusionResponse, error) {
+	ctx, spanEnd := spanFor(ctx, "VerifyInclusion")
+	defer spanEnd()
+	tree, hasher, err := t.getTreeAndHasher(ctx, req.LogId, optsLogRead)
+	if err != nil {
+		return nil, err
+	}
+	// Proofs are computed for the 0-based index of the leaf in the tree.
+	leafIndex, err := validateProofLeafIndex("VerifyInclusionRequest.LeafIndex", req.LeafIndex, tree.LeafIndexOffset, req.TreeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier := merkle.NewLogVerifier(hasher)
+	switch err := verifier.VerifyInclusionProof(leafIndex, req.TreeSize, req.Proof, req.RootHash, req.LeafHash).(type) {
+	case nil:
+		return &trillian.VerifyInclusionResponse{Valid: true, ComputedRootHash: req.RootHash}, nil
+	case merkle.RootMismatchError:
+		return &trillian.VerifyInclusionResponse{ComputedRootHash: err.CalculatedRoot, Reason: "computed root hash doesn't match root_hash"}, nil
+	default:
+		return &trillian.VerifyInclusionResponse{Reason: err.Error()}, nil
+	}
+}
+
 // GetLatestSignedLogRoot obtains the latest published tree root for the Merkle Tree that
 // underlies the log.
 func (t *TrillianLogRPCServer) GetLatestSignedLogRoot(ctx context.Context, req *trillian.GetLatestSignedLogRootRequest) (*trillian.GetLatestSignedLogRootResponse, error) {
@@ -392,6 +1242,11 @@ func (t *TrillianLogRPCServer) GetLatestSignedLogRoot(ctx context.Context, req *
 	}
 	ctx = trees.NewContext(ctx, tree)
 	tx, err := t.registry.LogStorage.SnapshotForTree(ctx, tree)
+	if err == storage.ErrTreeNeedsInit && tx != nil {
+		// The TX is left open for uninitialised logs, which are reported as
+		// such rather than as NotFound like missing or deleted logs above.
+		t.closeAndLog(ctx, tree.TreeId, tx, "GetLatestSignedLogRoot")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -426,7 +1281,7 @@ func (t *TrillianLogRPCServer) GetLatestSignedLogRoot(ctx context.Context, req *
 		return nil, err
 	}
 	// Try to get consistency proof
-	proof, err := tryGetConsistencyProof(ctx, reqProof.FirstTreeSize, reqProof.SecondTreeSize, int64(root.TreeSize), tx, hasher)
+	proof, err := t.tryGetConsistencyProof(ctx, reqProof.FirstTreeSize, reqProof.SecondTreeSize, int64(root.TreeSize), tx, hasher)
 	if err != nil {
 		return nil, err
 	}
@@ -438,8 +1293,15 @@ func (t *TrillianLogRPCServer) GetLatestSignedLogRoot(ctx context.Context, req *
 	return r, nil
 }
 
-func tryGetConsistencyProof(ctx context.Context, firstTreeSize, secondTreeSize, rootTreeSize int64, tx storage.ReadOnlyLogTreeTX, hasher hashers.LogHasher) (*trillian.Proof, error) {
-	nodeFetches, err := merkle.CalcConsistencyProofNodeAddresses(firstTreeSize, secondTreeSize, rootTreeSize)
+func (t *TrillianLogRPCServer) tryGetConsistencyProof(ctx context.Context, firstTreeSize, secondTreeSize, rootTreeSize int64, tx storage.ReadOnlyLogTreeTX, hasher hashers.LogHasher) (*trillian.Proof, error) {
+	if err := t.checkProofTreeSizes(secondTreeSize, rootTreeSize); err != nil {
+		return nil, err
+	}
+	if firstTreeSize == 0 {
+		// Every tree is consistent with the empty tree, so the proof is empty.
+		return &trillian.Proof{Hashes: [][]byte{}}, nil
+	}
+	nodeFetches, err := consistencyProofFetches(firstTreeSize, secondTreeSize, rootTreeSize)
 	if err != nil {
 		return nil, err
 	}
@@ -450,13 +1312,48 @@ func tryGetConsistencyProof(ctx context.Context, firstTreeSize, secondTreeSize,
 	if err != nil {
 		return nil, err
 	}
-	proof, err := fetchNodesAndBuildProof(ctx, tx, hasher, rev, 0, nodeFetches)
+	proof, err := fetchNodesAndBuildProof(ctx, tx, hasher, rev, 0, nodeFetches, t.ProofReadConcurrency)
 	if err != nil {
 		return nil, err
 	}
 	return proof, nil
 }
 
+// GetSignedLogRootHistory returns the most recent signed log roots stored for
+// a log, newest first.
+func (t *TrillianLogRPCServer) GetSignedLogRootHistory(ctx context.Context, req *trillian.GetSignedLogRootHistoryRequest) (*trillian.GetSignedLogRootHistoryResponse, error) {
+	ctx, spanEnd := spanFor(ctx, "GetSignedLogRootHistory")
+	defer spanEnd()
+	limit := int(req.Limit)
+	switch {
+	case limit < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "GetSignedLogRootHistoryRequest.Limit: %d, want >= 0", limit)
+	case limit == 0, limit > maxSignedLogRootHistory:
+		limit = maxSignedLogRootHistory
+	}
+
+	tree, ctx, err := t.getTreeAndContext(ctx, req.LogId, optsLogRead)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := t.snapshotForTree(ctx, tree, "GetSignedLogRootHistory")
+	if err != nil {
+		return nil, err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "GetSignedLogRootHistory")
+
+	roots, err := tx.SignedLogRootHistory(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "GetSignedLogRootHistory"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.GetSignedLogRootHistoryResponse{SignedLogRoots: roots}, nil
+}
+
 // GetSequencedLeafCount returns the number of leaves that have been integrated into the Merkle
 // Tree. This can be zero for a log containing no entries.
 func (t *TrillianLogRPCServer) GetSequencedLeafCount(ctx context.Context, req *trillian.GetSequencedLeafCountRequest) (*trillian.GetSequencedLeafCountResponse, error) {
@@ -499,14 +1396,24 @@ func (t *TrillianLogRPCServer) GetLeavesByIndex(ctx context.Context, req *trilli
 	if err != nil {
 		return nil, err
 	}
+	indices := req.LeafIndex
+	if offset := tree.LeafIndexOffset; offset != 0 {
+		indices = make([]int64, 0, len(req.LeafIndex))
+		for i, leafIndex := range req.LeafIndex {
+			if leafIndex < offset {
+				return nil, status.Errorf(codes.InvalidArgument, "GetLeavesByIndexRequest.LeafIndex[%v]: %v, want >= %v", i, leafIndex, offset)
+			}
+			indices = append(indices, leafIndex-offset)
+		}
+	}
 	tx, err := t.snapshotForTree(ctx, tree, "GetLeavesByIndex")
 	if err != nil {
 		return nil, err
 	}
 	defer t.closeAndLog(ctx, tree.TreeId, tx, "GetLeavesByIndex")
 
-	t.fetchedLeaves.Add(float64(len(req.LeafIndex)))
-	leaves, err := tx.GetLeavesByIndex(ctx, req.LeafIndex)
+	t.fetchedLeaves.Add(float64(len(indices)))
+	leaves, err := tx.GetLeavesByIndex(ctx, indices)
 	if err != nil {
 		return nil, err
 	}
@@ -524,7 +1431,23 @@ func (t *TrillianLogRPCServer) GetLeavesByIndex(ctx context.Context, req *trilli
 		return nil, status.Errorf(codes.Internal, "Could not read current log root: %v", err)
 	}
 
-	return &trillian.GetLeavesByIndexResponse{Leaves: leaves, SignedLogRoot: slr}, nil
+	return &trillian.GetLeavesByIndexResponse{Leaves: withIndexOffset(leaves, tree.LeafIndexOffset), SignedLogRoot: slr}, nil
+}
+
+// withIndexOffset returns leaves with offset added to their LeafIndex. The
+// leaves are copied if offset isn't zero, so that those shared with the
+// LeafCache aren't modified.
+func withIndexOffset(leaves []*trillian.LogLeaf, offset int64) []*trillian.LogLeaf {
+	if offset == 0 {
+		return leaves
+	}
+	res := make([]*trillian.LogLeaf, 0, len(leaves))
+	for _, leaf := range leaves {
+		leaf = proto.Clone(leaf).(*trillian.LogLeaf)
+		leaf.LeafIndex += offset
+		res = append(res, leaf)
+	}
+	return res
 }
 
 // GetLeavesByRange obtains leaves based on a range of sequence numbers within the tree.
@@ -541,6 +1464,10 @@ func (t *TrillianLogRPCServer) GetLeavesByRange(ctx context.Context, req *trilli
 	if err != nil {
 		return nil, err
 	}
+	offset := firstLeafIndex(tree, req.IndexBase)
+	if req.StartIndex < offset {
+		return nil, status.Errorf(codes.InvalidArgument, "GetLeavesByRangeRequest.StartIndex: %v, want >= %v", req.StartIndex, offset)
+	}
 	tx, err := t.snapshotForTree(ctx, tree, "GetLeavesByRange")
 	if err != nil {
 		return nil, err
@@ -558,16 +1485,236 @@ func (t *TrillianLogRPCServer) GetLeavesByRange(ctx context.Context, req *trilli
 
 	r := &trillian.GetLeavesByRangeResponse{SignedLogRoot: slr}
 
-	if req.StartIndex < int64(root.TreeSize) {
+	if start := req.StartIndex - offset; start < int64(root.TreeSize) {
 		t.fetchedLeaves.Add(float64(req.Count))
-		leaves, err := tx.GetLeavesByRange(ctx, req.StartIndex, req.Count)
+		leaves, ok := t.LeafCache.get(req.LogId, start, req.Count, int64(root.TreeSize))
+		if !ok {
+			if leaves, err = tx.GetLeavesByRange(ctx, start, req.Count); err != nil {
+				return nil, err
+			}
+			t.LeafCache.put(req.LogId, leaves, int64(root.TreeSize))
+		}
+		r.Leaves = withIndexOffset(leaves, offset)
+	}
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "GetLeavesByRange"); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// GetLeavesByKeyRange obtains leaves of a log with a leaf_ordering_key in the
+// order of their ordering keys, from the requested key and index.
+func (t *TrillianLogRPCServer) GetLeavesByKeyRange(ctx context.Context, req *trillian.GetLeavesByKeyRangeRequest) (*trillian.GetLeavesByKeyRangeResponse, error) {
+	ctx, spanEnd := spanFor(ctx, "GetLeavesByKeyRange")
+	defer spanEnd()
+	if err := validateGetLeavesByKeyRangeRequest(req); err != nil {
+		return nil, err
+	}
+
+	tree, ctx, err := t.getTreeAndContext(ctx, req.LogId, optsLogRead)
+	if err != nil {
+		return nil, err
+	}
+	if tree.LeafOrderingKey == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "log %d has no leaf_ordering_key", req.LogId)
+	}
+	tx, err := t.snapshotForTree(ctx, tree, "GetLeavesByKeyRange")
+	if err != nil {
+		return nil, err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "GetLeavesByKeyRange")
+	r, ok := tx.(storage.LeafKeyReader)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "storage doesn't index leaves by ordering key")
+	}
+
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Start indices below the first leaf start from the first leaf of the key.
+	start := req.StartIndex - tree.LeafIndexOffset
+	if start < 0 {
+		start = 0
+	}
+	leaves, keys, err := r.GetLeavesByKeyRange(ctx, req.StartKey, start, req.EndKey, int(req.Count))
+	if err != nil {
+		return nil, err
+	}
+	t.fetchedLeaves.Add(float64(len(leaves)))
+
+	if err := t.commitAndLog(ctx, req.LogId, tx, "GetLeavesByKeyRange"); err != nil {
+		return nil, err
+	}
+
+	return &trillian.GetLeavesByKeyRangeResponse{Leaves: withIndexOffset(leaves, tree.LeafIndexOffset), OrderingKeys: keys, SignedLogRoot: slr}, nil
+}
+
+// GetEffectiveLeaves obtains the leaves of a log with leaf_tombstones in the
+// requested range which are neither tombstones nor deleted by one.
+func (t *TrillianLogRPCServer) GetEffectiveLeaves(ctx context.Context, req *trillian.GetEffectiveLeavesRequest) (*trillian.GetEffectiveLeavesResponse, error) {
+	ctx, spanEnd := spanFor(ctx, "GetEffectiveLeaves")
+	defer spanEnd()
+	if err := validateGetEffectiveLeavesRequest(req); err != nil {
+		return nil, err
+	}
+
+	tree, ctx, err := t.getTreeAndContext(ctx, req.LogId, optsLogRead)
+	if err != nil {
+		return nil, err
+	}
+	if !tree.LeafTombstones {
+		return nil, status.Errorf(codes.FailedPrecondition, "log %d has no leaf_tombstones", req.LogId)
+	}
+	tx, err := t.snapshotForTree(ctx, tree, "GetEffectiveLeaves")
+	if err != nil {
+		return nil, err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "GetEffectiveLeaves")
+	r, ok := tx.(storage.TombstoneReader)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "storage doesn't index tombstones")
+	}
+
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not read current log root: %v", err)
+	}
+
+	rsp := &trillian.GetEffectiveLeavesResponse{NextIndex: req.StartIndex, SignedLogRoot: slr}
+	if req.StartIndex < int64(root.TreeSize) {
+		count := req.Count
+		if left := int64(root.TreeSize) - req.StartIndex; count > left {
+			count = left
+		}
+		t.fetchedLeaves.Add(float64(count))
+		leaves, err := tx.GetLeavesByRange(ctx, req.StartIndex, count)
 		if err != nil {
 			return nil, err
 		}
-		r.Leaves = leaves
+		if len(leaves) > 0 {
+			rsp.NextIndex = leaves[len(leaves)-1].LeafIndex + 1
+			deleted, err := r.GetTombstonedIndices(ctx, req.StartIndex, rsp.NextIndex-req.StartIndex)
+			if err != nil {
+				return nil, err
+			}
+			if rsp.Leaves, err = effectiveLeaves(leaves, deleted); err != nil {
+				return nil, err
+			}
+		}
 	}
 
-	if err := t.commitAndLog(ctx, req.LogId, tx, "GetLeavesByRange"); err != nil {
+	if err := t.commitAndLog(ctx, req.LogId, tx, "GetEffectiveLeaves"); err != nil {
+		return nil, err
+	}
+
+	return rsp, nil
+}
+
+// effectiveLeaves returns the leaves which are neither tombstones nor at one of
+// the deleted indices, which must be in increasing order like the leaves.
+func effectiveLeaves(leaves []*trillian.LogLeaf, deleted []int64) ([]*trillian.LogLeaf, error) {
+	var ret []*trillian.LogLeaf
+	for _, leaf := range leaves {
+		for len(deleted) > 0 && deleted[0] < leaf.LeafIndex {
+			deleted = deleted[1:]
+		}
+		if len(deleted) > 0 && deleted[0] == leaf.LeafIndex {
+			continue
+		}
+		if _, ok, err := types.ParseTombstone(leaf.LeafValue); err != nil {
+			return nil, status.Errorf(codes.Internal, "leaf %d: %v", leaf.LeafIndex, err)
+		} else if ok {
+			continue
+		}
+		ret = append(ret, leaf)
+	}
+	return ret, nil
+}
+
+// TailLeaves streams the leaves of a log from the requested index, including
+// those integrated while the stream is open, until the client cancels it.
+func (t *TrillianLogRPCServer) TailLeaves(req *trillian.TailLeavesRequest, stream trillian.TrillianLog_TailLeavesServer) error {
+	ctx, spanEnd := spanFor(stream.Context(), "TailLeaves")
+	defer spanEnd()
+	if req.StartIndex < 0 {
+		return status.Errorf(codes.InvalidArgument, "TailLeavesRequest.StartIndex: %v, want >= 0", req.StartIndex)
+	}
+
+	tree, ctx, err := t.getTreeAndContext(ctx, req.LogId, optsLogRead)
+	if err != nil {
+		return err
+	}
+	if req.StartIndex < tree.LeafIndexOffset {
+		return status.Errorf(codes.InvalidArgument, "TailLeavesRequest.StartIndex: %v, want >= %v", req.StartIndex, tree.LeafIndexOffset)
+	}
+	interval := t.TailPollInterval
+	if interval <= 0 {
+		interval = defaultTailPollInterval
+	}
+
+	next := req.StartIndex - tree.LeafIndexOffset
+	for {
+		resp, err := t.nextTailLeaves(ctx, tree, next)
+		if err != nil {
+			return err
+		}
+		if len(resp.Leaves) == 0 {
+			// Wait for the sequencer to integrate more leaves.
+			if err := clock.SleepSource(ctx, interval, t.timeSource); err != nil {
+				return status.FromContextError(err).Err()
+			}
+			continue
+		}
+		// Send blocks while the client isn't keeping up, so leaves are read
+		// no faster than they are received.
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+		next += int64(len(resp.Leaves))
+	}
+}
+
+// nextTailLeaves returns the integrated leaves of the tree from index start,
+// if any, up to tailLeavesBatch of them, along with the current signed root.
+func (t *TrillianLogRPCServer) nextTailLeaves(ctx context.Context, tree *trillian.Tree, start int64) (*trillian.TailLeavesResponse, error) {
+	tx, err := t.snapshotForTree(ctx, tree, "TailLeaves")
+	if err != nil {
+		return nil, err
+	}
+	defer t.closeAndLog(ctx, tree.TreeId, tx, "TailLeaves")
+
+	slr, err := tx.LatestSignedLogRoot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+		return nil, status.Errorf(codes.Internal, "Could not read current log root: %v", err)
+	}
+
+	r := &trillian.TailLeavesResponse{SignedLogRoot: slr}
+
+	if size := int64(root.TreeSize); start < size {
+		count := size - start
+		if count > tailLeavesBatch {
+			count = tailLeavesBatch
+		}
+		leaves, err := tx.GetLeavesByRange(ctx, start, count)
+		if err != nil {
+			return nil, err
+		}
+		t.fetchedLeaves.Add(float64(len(leaves)))
+		r.Leaves = withIndexOffset(leaves, tree.LeafIndexOffset)
+	}
+
+	if err := t.commitAndLog(ctx, tree.TreeId, tx, "TailLeaves"); err != nil {
 		return nil, err
 	}
 
@@ -610,17 +1757,33 @@ func (t *TrillianLogRPCServer) GetLeavesByHash(ctx context.Context, req *trillia
 	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
 		return nil, status.Errorf(codes.Internal, "Could not read current log root: %v", err)
 	}
+	if tree.TreeType == trillian.TreeType_LOG && tree.SigningInterval != nil {
+		// Leaves integrated since the latest signed root aren't served yet.
+		leaves = signedLeaves(leaves, root.TreeSize)
+	}
 
 	if err := t.commitAndLog(ctx, req.LogId, tx, "GetLeavesByHash"); err != nil {
 		return nil, err
 	}
 
 	return &trillian.GetLeavesByHashResponse{
-		Leaves:        leaves,
+		Leaves:        withIndexOffset(leaves, tree.LeafIndexOffset),
 		SignedLogRoot: slr,
 	}, nil
 }
 
+// signedLeaves returns the leaves with an index within a tree of the given
+// signed size.
+func signedLeaves(leaves []*trillian.LogLeaf, treeSize uint64) []*trillian.LogLeaf {
+	signed := leaves[:0]
+	for _, leaf := range leaves {
+		if leaf.LeafIndex < int64(treeSize) {
+			signed = append(signed, leaf)
+		}
+	}
+	return signed
+}
+
 // GetEntryAndProof returns both a Merkle Leaf entry and an inclusion proof for a given index
 // and tree size.
 func (t *TrillianLogRPCServer) GetEntryAndProof(ctx context.Context, req *trillian.GetEntryAndProofRequest) (*trillian.GetEntryAndProofResponse, error) {
@@ -636,6 +1799,11 @@ func (t *TrillianLogRPCServer) GetEntryAndProof(ctx context.Context, req *trilli
 		return nil, err
 	}
 	ctx = trees.NewContext(ctx, tree)
+	offset := firstLeafIndex(tree, req.IndexBase)
+	leafIndex, err := validateProofLeafIndex("GetEntryAndProofRequest.LeafIndex", req.LeafIndex, offset, req.TreeSize)
+	if err != nil {
+		return nil, err
+	}
 
 	// Next we need to make sure the requested tree size corresponds to an STH, so that we
 	// have a usable tree revision
@@ -656,19 +1824,19 @@ func (t *TrillianLogRPCServer) GetEntryAndProof(ctx context.Context, req *trilli
 
 	r := &trillian.GetEntryAndProofResponse{SignedLogRoot: slr}
 
-	if req.TreeSize > int64(root.TreeSize) && req.LeafIndex < int64(root.TreeSize) {
+	if req.TreeSize > int64(root.TreeSize) && leafIndex < int64(root.TreeSize) {
 		// return latest proof we can manage
 		req.TreeSize = int64(root.TreeSize)
 	}
 
 	if req.TreeSize <= int64(root.TreeSize) {
-		proof, err := getInclusionProofForLeafIndex(ctx, tx, hasher, req.TreeSize, req.LeafIndex, int64(root.TreeSize))
+		proof, err := t.getInclusionProofForLeafIndex(ctx, tx, hasher, req.TreeSize, leafIndex, int64(root.TreeSize))
 		if err != nil {
 			return nil, err
 		}
 
 		// We also need the leaf entry
-		leaves, err := tx.GetLeavesByIndex(ctx, []int64{req.LeafIndex})
+		leaves, err := tx.GetLeavesByIndex(ctx, []int64{leafIndex})
 		if err != nil {
 			return nil, err
 		}
@@ -677,11 +1845,12 @@ func (t *TrillianLogRPCServer) GetEntryAndProof(ctx context.Context, req *trilli
 			return nil, status.Errorf(codes.Internal, "expected one leaf from storage but got: %d", len(leaves))
 		}
 
-		t.recordIndexPercent(req.LeafIndex, root.TreeSize)
+		t.recordIndexPercent(leafIndex, root.TreeSize)
 
 		// Work is complete, we have everything we need for the response
+		proof.LeafIndex += offset
 		r.Proof = proof
-		r.Leaf = leaves[0]
+		r.Leaf = withIndexOffset(leaves, offset)[0]
 	}
 
 	if err := tx.Commit(ctx); err != nil {
@@ -694,7 +1863,7 @@ func (t *TrillianLogRPCServer) GetEntryAndProof(ctx context.Context, req *trilli
 func (t *TrillianLogRPCServer) commitAndLog(ctx context.Context, logID int64, tx storage.ReadOnlyLogTreeTX, op string) error {
 	err := tx.Commit(ctx)
 	if err != nil {
-		glog.Warningf("%v: Commit failed for %v: %v", logID, op, err)
+		glog.Warningf("%v: %sCommit failed for %v: %v", logID, requestid.LogPrefix(ctx), op, err)
 	}
 	return err
 }
@@ -702,16 +1871,19 @@ func (t *TrillianLogRPCServer) commitAndLog(ctx context.Context, logID int64, tx
 func (t *TrillianLogRPCServer) closeAndLog(ctx context.Context, logID int64, tx storage.ReadOnlyLogTreeTX, op string) {
 	err := tx.Close()
 	if err != nil {
-		glog.Warningf("%v: Close failed for %v: %v", logID, op, err)
+		glog.Warningf("%v: %sClose failed for %v: %v", logID, requestid.LogPrefix(ctx), op, err)
 	}
 }
 
 // getInclusionProofForLeafIndex is used by multiple handlers. It does the storage fetching
 // and makes additional checks on the returned proof. Returns a Proof suitable for inclusion in
 // an RPC response
-func getInclusionProofForLeafIndex(ctx context.Context, tx storage.ReadOnlyLogTreeTX, hasher hashers.LogHasher, snapshot, leafIndex, treeSize int64) (*trillian.Proof, error) {
+func (t *TrillianLogRPCServer) getInclusionProofForLeafIndex(ctx context.Context, tx storage.ReadOnlyLogTreeTX, hasher hashers.LogHasher, snapshot, leafIndex, treeSize int64) (*trillian.Proof, error) {
+	if err := t.checkProofTreeSizes(snapshot, treeSize); err != nil {
+		return nil, err
+	}
 	// We have the tree size and leaf index so we know the nodes that we need to serve the proof
-	proofNodeIDs, err := merkle.CalcInclusionProofNodeAddresses(snapshot, leafIndex, treeSize)
+	proofNodeIDs, err := inclusionProofFetches(snapshot, leafIndex, treeSize)
 	if err != nil {
 		return nil, err
 	}
@@ -720,7 +1892,24 @@ func getInclusionProofForLeafIndex(ctx context.Context, tx storage.ReadOnlyLogTr
 	if err != nil {
 		return nil, err
 	}
-	return fetchNodesAndBuildProof(ctx, tx, hasher, rev, leafIndex, proofNodeIDs)
+	return fetchNodesAndBuildProof(ctx, tx, hasher, rev, leafIndex, proofNodeIDs, t.ProofReadConcurrency)
+}
+
+// checkProofTreeSizes returns an InvalidArgument error if any of the tree
+// sizes that a proof is computed from exceeds the maximum tree size which
+// proofs are served for, so that corrupted sizes can't lead to absurdly deep
+// proofs.
+func (t *TrillianLogRPCServer) checkProofTreeSizes(sizes ...int64) error {
+	max := t.MaxProofTreeSize
+	if max <= 0 {
+		max = defaultMaxProofTreeSize
+	}
+	for _, size := range sizes {
+		if size > max {
+			return status.Errorf(codes.InvalidArgument, "tree size %d exceeds the maximum of %d which proofs are served for", size, max)
+		}
+	}
+	return nil
 }
 
 func (t *TrillianLogRPCServer) getTreeAndHasher(ctx context.Context, treeID int64, opts trees.GetOpts) (*trillian.Tree, hashers.LogHasher, error) {
@@ -728,7 +1917,7 @@ func (t *TrillianLogRPCServer) getTreeAndHasher(ctx context.Context, treeID int6
 	if err != nil {
 		return nil, nil, err
 	}
-	hasher, err := hashers.NewLogHasher(tree.HashStrategy)
+	hasher, err := trees.LogHasher(tree)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -775,7 +1964,7 @@ func (t *TrillianLogRPCServer) InitLog(ctx context.Context, req *trillian.InitLo
 
 		root, err := signer.SignLogRoot(&types.LogRootV1{
 			RootHash:       hasher.EmptyRoot(),
-			TimestampNanos: uint64(t.timeSource.Now().UnixNano()),
+			TimestampNanos: trees.RootTimestamp(tree, t.timeSource.Now()),
 		})
 		if err != nil {
 			return err
@@ -805,6 +1994,37 @@ func (t *TrillianLogRPCServer) recordIndexPercent(leafIndex int64, treeSize uint
 	}
 }
 
+// newNodeReadCounter wraps tx to count the Merkle nodes read through it, and
+// to retry the reads which fail transiently if ProofReadRetries is set.
+func (t *TrillianLogRPCServer) newNodeReadCounter(tx storage.ReadOnlyLogTreeTX, treeID int64) *nodeReadCounter {
+	if t.ProofReadRetries > 0 {
+		backoff := t.ProofReadBackoff
+		if backoff <= 0 {
+			backoff = defaultProofReadBackoff
+		}
+		tx = &nodeReadRetrier{
+			ReadOnlyLogTreeTX: tx,
+			retries:           t.ProofReadRetries,
+			backoff:           backoff,
+			retried:           func() { t.proofReadRetries.Inc(strconv.FormatInt(treeID, 10)) },
+		}
+	}
+	return &nodeReadCounter{ReadOnlyLogTreeTX: tx}
+}
+
+// recordProofSize records the number of nodes and bytes in proof, and the number of Merkle
+// nodes read from storage to build it.
+func (t *TrillianLogRPCServer) recordProofSize(treeID int64, proof *trillian.Proof, nodeReads int64) {
+	label := strconv.FormatInt(treeID, 10)
+	var size int
+	for _, h := range proof.GetHashes() {
+		size += len(h)
+	}
+	t.proofNodes.Observe(float64(len(proof.GetHashes())), label)
+	t.proofNodeReads.Observe(float64(nodeReads), label)
+	t.proofBytes.Observe(float64(size), label)
+}
+
 func spanFor(ctx context.Context, name string) (context.Context, func()) {
 	return monitoring.StartSpan(ctx, fmt.Sprintf("%s.%s", traceSpanRoot, name))
 }
@@ -15,26 +15,38 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"crypto"
+	"crypto/sha256"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys/pem"
 	"github.com/google/trillian/crypto/sigpb"
 	"github.com/google/trillian/extension"
+	"github.com/google/trillian/merkle/compact"
+	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/server/queuewal"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/namespace"
 	"github.com/google/trillian/testonly"
 	"github.com/google/trillian/types"
 	"github.com/google/trillian/util/clock"
+	"github.com/google/trillian/validation"
 	"github.com/kylelemons/godebug/pretty"
 	"google.golang.org/genproto/googleapis/rpc/code"
 	"google.golang.org/grpc/codes"
@@ -116,6 +128,8 @@ var (
 	getConsistencyProofRequest7  = trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 4, SecondTreeSize: 7}
 	getConsistencyProofRequest44 = trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 4, SecondTreeSize: 4}
 	getConsistencyProofRequest48 = trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 4, SecondTreeSize: 8}
+	getConsistencyProofRequest07 = trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 0, SecondTreeSize: 7}
+	getConsistencyProofRequest00 = trillian.GetConsistencyProofRequest{LogId: logID1, FirstTreeSize: 0, SecondTreeSize: 0}
 
 	nodeIdsInclusionSize7Index2 = []tree.NodeID{
 		stestonly.MustCreateNodeIDForTreeCoords(0, 3, 64),
@@ -291,12 +305,14 @@ func TestGetLeavesByRange(t *testing.T) {
 
 	var tests = []struct {
 		start, count int64
+		base         trillian.IndexBase
 		skipTX       bool
 		adminErr     error
 		txErr        error
 		getErr       error
 		slrErr       error
 		root         *trillian.SignedLogRoot
+		stored       []*trillian.LogLeaf // Returned by storage, if not want.
 		want         []*trillian.LogLeaf
 		wantErr      string
 	}{
@@ -364,6 +380,30 @@ func TestGetLeavesByRange(t *testing.T) {
 			skipTX:  true,
 			wantErr: "want > 0",
 		},
+		{
+			start:  2,
+			count:  2,
+			base:   trillian.IndexBase_INDEX_BASE_ONE,
+			stored: []*trillian.LogLeaf{leaf1, leaf2},
+			want: []*trillian.LogLeaf{
+				newTestLeaf([]byte("value"), []byte("extra"), 2),
+				newTestLeaf([]byte("value2"), []byte("extra"), 3),
+			},
+		},
+		{
+			start:   0,
+			count:   1,
+			base:    trillian.IndexBase_INDEX_BASE_ONE,
+			skipTX:  true,
+			wantErr: "want >= 1",
+		},
+		{
+			start:   1,
+			count:   1,
+			base:    trillian.IndexBase(7),
+			skipTX:  true,
+			wantErr: "IndexBase",
+		},
 	}
 
 	for _, test := range tests {
@@ -386,10 +426,15 @@ func TestGetLeavesByRange(t *testing.T) {
 					mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(root, test.slrErr)
 
 					if test.root == nil {
+						start := test.start - indexOffsets[test.base]
+						stored := test.stored
+						if stored == nil {
+							stored = test.want
+						}
 						if test.getErr != nil {
-							mockTX.EXPECT().GetLeavesByRange(gomock.Any(), test.start, test.count).Return(nil, test.getErr)
+							mockTX.EXPECT().GetLeavesByRange(gomock.Any(), start, test.count).Return(nil, test.getErr)
 						} else {
-							mockTX.EXPECT().GetLeavesByRange(gomock.Any(), test.start, test.count).Return(test.want, nil)
+							mockTX.EXPECT().GetLeavesByRange(gomock.Any(), start, test.count).Return(stored, nil)
 							mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
 						}
 					}
@@ -408,6 +453,7 @@ func TestGetLeavesByRange(t *testing.T) {
 			LogId:      tree.TreeId,
 			StartIndex: test.start,
 			Count:      test.count,
+			IndexBase:  test.base,
 		}
 		rsp, err := server.GetLeavesByRange(ctx, &req)
 		if err != nil {
@@ -432,6 +478,52 @@ func TestGetLeavesByRange(t *testing.T) {
 	}
 }
 
+func TestGetLeavesByRangeCache(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	fakeStorage := storage.NewMockLogStorage(ctrl)
+	fakeAdmin := storage.NewMockAdminStorage(ctrl)
+	tree := &trillian.Tree{TreeId: 6962, TreeType: trillian.TreeType_LOG, TreeState: trillian.TreeState_ACTIVE}
+
+	registry := extension.Registry{LogStorage: fakeStorage, AdminStorage: fakeAdmin}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	server.LeafCache = NewLeafCache(8, 1, nil)
+
+	for _, test := range []struct {
+		start, count int64
+		read         bool
+		want         []*trillian.LogLeaf
+	}{
+		{start: 1, count: 3, read: true, want: []*trillian.LogLeaf{leaf1, leaf2, leaf3}},
+		{start: 2, count: 2, want: []*trillian.LogLeaf{leaf2, leaf3}},
+		{start: 3, count: 5, read: true, want: []*trillian.LogLeaf{leaf3}},
+	} {
+		mockAdminTX := storage.NewMockAdminTX(ctrl)
+		fakeAdmin.EXPECT().Snapshot(gomock.Any()).Return(mockAdminTX, nil)
+		mockAdminTX.EXPECT().GetTree(gomock.Any(), tree.TreeId).Return(tree, nil)
+		mockAdminTX.EXPECT().Commit().Return(nil)
+		mockAdminTX.EXPECT().Close().Return(nil)
+		mockTX := storage.NewMockLogTreeTX(ctrl)
+		fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree}).Return(mockTX, nil)
+		mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+		if test.read {
+			mockTX.EXPECT().GetLeavesByRange(gomock.Any(), test.start, test.count).Return(test.want, nil)
+		}
+		mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+		mockTX.EXPECT().Close().Return(nil)
+
+		req := &trillian.GetLeavesByRangeRequest{LogId: tree.TreeId, StartIndex: test.start, Count: test.count}
+		rsp, err := server.GetLeavesByRange(ctx, req)
+		if err != nil {
+			t.Fatalf("GetLeavesByRange(%d, %d)=nil,%v; want _,nil", test.start, test.count, err)
+		}
+		if got := rsp.Leaves; !cmp.Equal(got, test.want, cmp.Comparer(proto.Equal)) {
+			t.Errorf("GetLeavesByRange(%d, %d)=%+v; want %+v", test.start, test.count, got, test.want)
+		}
+	}
+}
+
 func TestQueueLeavesStorageError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -591,167 +683,1685 @@ func TestAddSequencedLeaves(t *testing.T) {
 	}
 }
 
-type latestRootTest struct {
-	desc        string
-	req         *trillian.GetLatestSignedLogRootRequest
-	wantRoot    *trillian.GetLatestSignedLogRootResponse
-	errStr      string
-	noSnap      bool
-	snapErr     error
-	noRoot      bool
-	storageRoot *trillian.SignedLogRoot
-	rootErr     error
-	noCommit    bool
-	commitErr   error
-	noClose     bool
+func TestBulkAddSequencedLeaves(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var leaves []*trillian.LogLeaf
+	for i := int64(0); i < 5; i++ {
+		leaves = append(leaves, newTestLeaf([]byte(fmt.Sprintf("value%d", i)), nil, i))
+	}
+	ok := status.New(codes.OK, "OK").Proto()
+	conflict := status.New(codes.FailedPrecondition, "conflicting LeafIdentityHash").Proto()
+	// Leaf 2 was added by an earlier attempt, and another leaf is stored at
+	// index 3.
+	stored2 := proto.Clone(leaves[2]).(*trillian.LogLeaf)
+	stored2.LeafIdentityHash = stored2.MerkleLeafHash
+
+	tree := addTreeID(stestonly.PreorderedLogTree, logID3)
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	mockTX := storage.NewMockLogTreeTX(ctrl)
+	gomock.InOrder(
+		mockStorage.EXPECT().AddSequencedLeaves(gomock.Any(), cmpMatcher{tree}, cmpMatcher{leaves[0:2]}, gomock.Any()).
+			Return([]*trillian.QueuedLogLeaf{{Status: ok}, {Status: ok}}, nil),
+		mockStorage.EXPECT().AddSequencedLeaves(gomock.Any(), cmpMatcher{tree}, cmpMatcher{leaves[2:4]}, gomock.Any()).
+			Return([]*trillian.QueuedLogLeaf{{Status: conflict}, {Status: conflict}}, nil),
+		mockStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree}).Return(mockTX, nil),
+		mockStorage.EXPECT().AddSequencedLeaves(gomock.Any(), cmpMatcher{tree}, cmpMatcher{leaves[4:]}, gomock.Any()).
+			Return([]*trillian.QueuedLogLeaf{{Status: ok}}, nil),
+	)
+	mockTX.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leaves[2].MerkleLeafHash, leaves[3].MerkleLeafHash}, false).
+		Return([]*trillian.LogLeaf{stored2}, nil)
+	mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+	mockTX.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: fakeAdminStorage(ctrl, storageParams{logID3, true, 1, nil, nil, false}),
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	rsp, err := server.BulkAddSequencedLeaves(ctx, &trillian.BulkAddSequencedLeavesRequest{LogId: logID3, Leaves: leaves, ChunkSize: 2})
+	if err != nil {
+		t.Fatalf("BulkAddSequencedLeaves(): %v", err)
+	}
+	var gotCodes []codes.Code
+	for _, result := range rsp.Results {
+		gotCodes = append(gotCodes, status.FromProto(result.Status).Code())
+	}
+	if got, want := fmt.Sprint(gotCodes), "[OK OK AlreadyExists FailedPrecondition OK]"; got != want {
+		t.Errorf("BulkAddSequencedLeaves().Results codes = %v, want %v", got, want)
+	}
+	if got := rsp.Results[2].Leaf; !proto.Equal(got, stored2) {
+		t.Errorf("BulkAddSequencedLeaves().Results[2].Leaf = %v, want %v", got, stored2)
+	}
+	if rsp.Added != 3 || rsp.Existing != 1 || rsp.Rejected != 1 {
+		t.Errorf("BulkAddSequencedLeaves() added/existing/rejected = %d/%d/%d, want 3/1/1", rsp.Added, rsp.Existing, rsp.Rejected)
+	}
+
+	label := strconv.FormatInt(logID3, 10)
+	for _, test := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{name: "bulk_added_leaves", got: server.bulkAddedLeaves.Value(label), want: 3},
+		{name: "bulk_existing_leaves", got: server.bulkExistingLeaves.Value(label), want: 1},
+		{name: "bulk_rejected_leaves", got: server.bulkRejectedLeaves.Value(label), want: 1},
+		{name: "bulk_high_index", got: server.bulkHighIndex.Value(label), want: 4},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
 }
 
-func TestGetLatestSignedLogRoot(t *testing.T) {
-	tests := []latestRootTest{
+func TestBulkAddSequencedLeaves_Invalid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := NewTrillianLogRPCServer(extension.Registry{}, fakeTimeSource)
+	for _, test := range []struct {
+		desc string
+		req  *trillian.BulkAddSequencedLeavesRequest
+	}{
+		{desc: "no leaves", req: &trillian.BulkAddSequencedLeavesRequest{LogId: logID3}},
+		{desc: "negative chunk size", req: &trillian.BulkAddSequencedLeavesRequest{LogId: logID3, Leaves: []*trillian.LogLeaf{leaf1}, ChunkSize: -1}},
+		{desc: "gap", req: &trillian.BulkAddSequencedLeavesRequest{LogId: logID3, Leaves: []*trillian.LogLeaf{leaf1, leaf3}}},
+		{desc: "descending", req: &trillian.BulkAddSequencedLeavesRequest{LogId: logID3, Leaves: []*trillian.LogLeaf{leaf2, leaf1}}},
+	} {
+		if _, err := server.BulkAddSequencedLeaves(context.Background(), test.req); err == nil {
+			t.Errorf("%s: BulkAddSequencedLeaves() returned err = nil, want non-nil", test.desc)
+		}
+	}
+}
+
+func TestAddSequencedLeaves_OrderedTimestamps(t *testing.T) {
+	ts := func(sec int64) *timestamp.Timestamp { return &timestamp.Timestamp{Seconds: sec} }
+	withTS := func(leaf *trillian.LogLeaf, sec int64) *trillian.LogLeaf {
+		leaf = proto.Clone(leaf).(*trillian.LogLeaf)
+		leaf.IntegrateTimestamp = ts(sec)
+		return leaf
+	}
+	stored0 := withTS(newTestLeaf([]byte("value0"), nil, 0), 10)
+	stored3 := withTS(leaf3, 30)
+
+	for _, test := range []struct {
+		desc     string
+		leaves   []*trillian.LogLeaf
+		stored   []*trillian.LogLeaf
+		noLookup bool
+		wantCode codes.Code
+	}{
 		{
-			desc: "snap_fail",
-			// Test error case when failing to get a snapshot from storage.
-			req:      &getLogRootRequest1,
-			snapErr:  errors.New("SnapshotForTree() error"),
-			errStr:   "SnapshotFor",
-			noRoot:   true,
-			noCommit: true,
-			noClose:  true,
+			desc:   "ordered",
+			leaves: []*trillian.LogLeaf{withTS(leaf1, 10), withTS(leaf2, 20)},
+			stored: []*trillian.LogLeaf{stored0, stored3},
 		},
 		{
-			desc: "storage_fail",
-			// Test error case when storage fails to provide a root.
-			req:      &getLogRootRequest1,
-			errStr:   "LatestSigned",
-			rootErr:  errors.New("LatestSignedLogRoot() error"),
-			noCommit: true,
+			desc:   "no neighbours",
+			leaves: []*trillian.LogLeaf{withTS(leaf1, 10), withTS(leaf2, 20)},
 		},
 		{
-			desc: "read_error",
-			// Test error case where the log root could not be read
-			req:      &getLogRootRequest1,
-			errStr:   "rpc error: code = Internal desc = Could not read current log root: logRootBytes too short",
-			noCommit: true,
+			desc:     "missing timestamp",
+			leaves:   []*trillian.LogLeaf{withTS(leaf1, 10), leaf2},
+			noLookup: true,
+			wantCode: codes.InvalidArgument,
 		},
 		{
-			desc: "ok",
-			// Test normal case where a root is returned correctly.
-			req:         &getLogRootRequest1,
-			wantRoot:    &trillian.GetLatestSignedLogRootResponse{SignedLogRoot: signedRoot1},
-			storageRoot: signedRoot1,
+			desc:     "unordered batch",
+			leaves:   []*trillian.LogLeaf{withTS(leaf1, 20), withTS(leaf2, 10)},
+			noLookup: true,
+			wantCode: codes.FailedPrecondition,
 		},
+		{
+			desc:     "before previous",
+			leaves:   []*trillian.LogLeaf{withTS(leaf1, 5), withTS(leaf2, 20)},
+			stored:   []*trillian.LogLeaf{stored0},
+			wantCode: codes.FailedPrecondition,
+		},
+		{
+			desc:     "after next",
+			leaves:   []*trillian.LogLeaf{withTS(leaf1, 10), withTS(leaf2, 40)},
+			stored:   []*trillian.LogLeaf{stored3},
+			wantCode: codes.FailedPrecondition,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.PreorderedLogTree, logID3)
+			tree.OrderedLeafTimestamps = true
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID3).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if !test.noLookup {
+				mockTX := storage.NewMockLogTreeTX(ctrl)
+				mockStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree}).Return(mockTX, nil)
+				mockTX.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{3, 0}).Return(test.stored, nil)
+				mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+				mockTX.EXPECT().Close().Return(nil)
+			}
+			if test.wantCode == codes.OK {
+				mockStorage.EXPECT().AddSequencedLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), gomock.Any()).
+					Return([]*trillian.QueuedLogLeaf{{}, {}}, nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.AddSequencedLeavesRequest{LogId: logID3, Leaves: test.leaves}
+			_, err := server.AddSequencedLeaves(ctx, req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("AddSequencedLeaves() = %v, want code %v", err, test.wantCode)
+			}
+		})
 	}
+}
 
-	for _, test := range tests {
+func TestAddSequencedLeaves_MaxTreeSize(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		leaves   []*trillian.LogLeaf
+		wantCode codes.Code
+	}{
+		{desc: "belowMax", leaves: []*trillian.LogLeaf{leaf1, leaf2}},
+		{desc: "pastMax", leaves: []*trillian.LogLeaf{leaf2, leaf3}, wantCode: codes.FailedPrecondition},
+	} {
 		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			fakeStorage := storage.NewMockLogStorage(ctrl)
-			mockTX := storage.NewMockLogTreeTX(ctrl)
-			if !test.noSnap {
-				fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(mockTX, test.snapErr)
+			tree := addTreeID(stestonly.PreorderedLogTree, logID3)
+			tree.MaxTreeSize = 3
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID3).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.wantCode == codes.OK {
+				mockStorage.EXPECT().AddSequencedLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), gomock.Any()).
+					Return([]*trillian.QueuedLogLeaf{{}, {}}, nil)
 			}
-			if !test.noRoot {
-				mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(test.storageRoot, test.rootErr)
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
 			}
-			if !test.noCommit {
-				mockTX.EXPECT().Commit(gomock.Any()).Return(test.commitErr)
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.AddSequencedLeavesRequest{LogId: logID3, Leaves: test.leaves}
+			_, err := server.AddSequencedLeaves(ctx, req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("AddSequencedLeaves() = %v, want code %v", err, test.wantCode)
 			}
-			if !test.noClose {
-				mockTX.EXPECT().Close().Return(nil)
+		})
+	}
+}
+
+func TestAddSequencedLeaves_OrderingKey(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		leaves   []*trillian.LogLeaf
+		wantCode codes.Code
+	}{
+		{desc: "keyed", leaves: []*trillian.LogLeaf{leaf1, leaf2}},
+		{desc: "noKey", leaves: []*trillian.LogLeaf{leaf1, newTestLeaf([]byte("value2"), []byte("ex"), 2)}, wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.PreorderedLogTree, logID3)
+			tree.LeafOrderingKey = &trillian.LeafOrderingKey{Source: trillian.LeafOrderingKey_EXTRA_DATA, Length: 5}
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID3).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.wantCode == codes.OK {
+				mockStorage.EXPECT().AddSequencedLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), gomock.Any()).
+					Return([]*trillian.QueuedLogLeaf{{}, {}}, nil)
 			}
 
 			registry := extension.Registry{
-				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: test.req.LogId, numSnapshots: 1}),
-				LogStorage:   fakeStorage,
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
 			}
-			s := NewTrillianLogRPCServer(registry, fakeTimeSource)
-			got, err := s.GetLatestSignedLogRoot(context.Background(), test.req)
-			if len(test.errStr) > 0 {
-				if err == nil || !strings.Contains(err.Error(), test.errStr) {
-					t.Errorf("GetLatestSignedLogRoot(%+v)=_,nil, want: _,err contains: %s but got: %v", test.req, test.errStr, err)
-				}
-			} else {
-				if err != nil {
-					t.Errorf("GetLatestSignedLogRoot(%+v)=_,%v, want: _,nil", test.req, err)
-					return
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.AddSequencedLeavesRequest{LogId: logID3, Leaves: test.leaves}
+			_, err := server.AddSequencedLeaves(ctx, req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("AddSequencedLeaves() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+// keyReaderTX is a LogTreeTX implementing storage.LeafKeyReader.
+type keyReaderTX struct {
+	*storage.MockLogTreeTX
+	leaves []*trillian.LogLeaf
+	keys   [][]byte
+	err    error
+
+	gotStartKey, gotEndKey []byte
+	gotStartIndex          int64
+	gotLimit               int
+}
+
+func (tx *keyReaderTX) GetLeavesByKeyRange(ctx context.Context, startKey []byte, startIndex int64, endKey []byte, limit int) ([]*trillian.LogLeaf, [][]byte, error) {
+	tx.gotStartKey, tx.gotStartIndex, tx.gotEndKey, tx.gotLimit = startKey, startIndex, endKey, limit
+	return tx.leaves, tx.keys, tx.err
+}
+
+func TestGetLeavesByKeyRange(t *testing.T) {
+	keyedTree := addTreeID(stestonly.PreorderedLogTree, logID3)
+	keyedTree.LeafOrderingKey = &trillian.LeafOrderingKey{Source: trillian.LeafOrderingKey_EXTRA_DATA}
+	req := &trillian.GetLeavesByKeyRangeRequest{LogId: logID3, StartKey: []byte("extra"), StartIndex: 2, EndKey: []byte("extra4"), Count: 10}
+
+	for _, test := range []struct {
+		desc       string
+		tree       *trillian.Tree
+		req        *trillian.GetLeavesByKeyRangeRequest
+		noReader   bool
+		getErr     error
+		wantLeaves []*trillian.LogLeaf
+		wantCode   codes.Code
+	}{
+		{desc: "ok", tree: keyedTree, req: req, wantLeaves: []*trillian.LogLeaf{leaf2, leaf3}},
+		{desc: "storageErr", tree: keyedTree, req: req, getErr: errors.New("STORAGE"), wantCode: codes.Unknown},
+		{desc: "noReader", tree: keyedTree, req: req, noReader: true, wantCode: codes.Unimplemented},
+		{desc: "noKey", tree: addTreeID(stestonly.PreorderedLogTree, logID3), req: req, wantCode: codes.FailedPrecondition},
+		{desc: "badCount", req: &trillian.GetLeavesByKeyRangeRequest{LogId: logID3}, wantCode: codes.InvalidArgument},
+		{desc: "badIndex", req: &trillian.GetLeavesByKeyRangeRequest{LogId: logID3, StartIndex: -1, Count: 1}, wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			mockTX := storage.NewMockLogTreeTX(ctrl)
+			tx := &keyReaderTX{MockLogTreeTX: mockTX, leaves: []*trillian.LogLeaf{leaf2, leaf3}, keys: [][]byte{[]byte("extra"), []byte("extra3")}, err: test.getErr}
+			if test.tree != nil {
+				adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+				adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+				adminTX.EXPECT().GetTree(gomock.Any(), logID3).Return(test.tree, nil)
+				adminTX.EXPECT().Close().AnyTimes().Return(nil)
+				adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+			}
+			if test.tree.GetLeafOrderingKey() != nil {
+				if test.noReader {
+					mockStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{test.tree}).Return(mockTX, nil)
+				} else {
+					mockStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{test.tree}).Return(tx, nil)
+					mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
 				}
-				// Ensure we got the expected root back.
-				if !proto.Equal(got.SignedLogRoot, test.wantRoot.SignedLogRoot) {
-					t.Errorf("GetConsistencyProof(%+v)=%v,nil, want: %v,nil", test.req, got, test.wantRoot)
+				if test.wantCode == codes.OK {
+					mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
 				}
+				mockTX.EXPECT().Close().Return(nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			rsp, err := server.GetLeavesByKeyRange(ctx, test.req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("GetLeavesByKeyRange() = %v, want code %v", err, test.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if got := rsp.Leaves; !cmp.Equal(got, test.wantLeaves, cmp.Comparer(proto.Equal)) {
+				t.Errorf("GetLeavesByKeyRange().Leaves = %+v, want %+v", got, test.wantLeaves)
+			}
+			if got, want := rsp.OrderingKeys, tx.keys; !cmp.Equal(got, want) {
+				t.Errorf("GetLeavesByKeyRange().OrderingKeys = %q, want %q", got, want)
+			}
+			if !proto.Equal(rsp.SignedLogRoot, signedRoot1) {
+				t.Errorf("GetLeavesByKeyRange().SignedLogRoot = %v, want %v", rsp.SignedLogRoot, signedRoot1)
+			}
+			if !bytes.Equal(tx.gotStartKey, req.StartKey) || tx.gotStartIndex != req.StartIndex || !bytes.Equal(tx.gotEndKey, req.EndKey) || tx.gotLimit != int(req.Count) {
+				t.Errorf("GetLeavesByKeyRange(%q, %d, %q, %d) called, want (%q, %d, %q, %d)", tx.gotStartKey, tx.gotStartIndex, tx.gotEndKey, tx.gotLimit, req.StartKey, req.StartIndex, req.EndKey, req.Count)
 			}
 		})
 	}
 }
 
-func TestGetLeavesByHash(t *testing.T) {
-	for _, tc := range []struct {
-		name         string
-		setupStorage func(*gomock.Controller, *storage.MockLogStorage)
-		snapErr      error
-		treeErr      error
-		req          *trillian.GetLeavesByHashRequest
-		errStr       string
-		wantResp     *trillian.GetLeavesByHashResponse
+func TestQueueLeaves_Tombstones(t *testing.T) {
+	malformed := append(types.NewTombstone(3), 0)
+
+	for _, test := range []struct {
+		desc       string
+		tombstones bool
+		value      []byte
+		wantCode   codes.Code
 	}{
-		{
-			name: "admin snapshot fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-			},
-			req:     &getByHashRequest1,
-			snapErr: errors.New("admin snap"),
-			errStr:  "admin snap",
-		},
-		{
-			name: "get tree fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-			},
-			req:     &getByHashRequest1,
-			treeErr: errors.New("tree error"),
-			errStr:  "tree error",
-		},
-		{
-			name: "begin fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(nil, errors.New("TX"))
-			},
-			req:    &getByHashRequest1,
-			errStr: "TX",
-		},
-		{
-			name: "not initialized",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, storage.ErrTreeNeedsInit)
-				tx.EXPECT().Close().Return(nil)
-			},
-			req:    &getByHashRequest1,
-			errStr: "tree needs init",
-		},
-		{
+		{desc: "tombstone", tombstones: true, value: types.NewTombstone(3)},
+		{desc: "value", tombstones: true, value: []byte("value")},
+		{desc: "malformed", tombstones: true, value: malformed, wantCode: codes.InvalidArgument},
+		{desc: "noTombstones", value: malformed},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.LogTree, logID1)
+			tree.LeafTombstones = test.tombstones
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.wantCode == codes.OK {
+				mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), fakeTime).
+					Return([]*trillian.QueuedLogLeaf{{}}, nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.QueueLeavesRequest{LogId: logID1, Leaves: []*trillian.LogLeaf{{LeafValue: test.value}}}
+			if _, err := server.QueueLeaves(ctx, req); status.Code(err) != test.wantCode {
+				t.Errorf("QueueLeaves() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestAddSequencedLeaves_Tombstones(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		leaf     *trillian.LogLeaf
+		wantCode codes.Code
+	}{
+		{desc: "earlier", leaf: &trillian.LogLeaf{LeafValue: types.NewTombstone(4), LeafIndex: 5}},
+		{desc: "self", leaf: &trillian.LogLeaf{LeafValue: types.NewTombstone(5), LeafIndex: 5}, wantCode: codes.InvalidArgument},
+		{desc: "later", leaf: &trillian.LogLeaf{LeafValue: types.NewTombstone(6), LeafIndex: 5}, wantCode: codes.InvalidArgument},
+		{desc: "malformed", leaf: &trillian.LogLeaf{LeafValue: []byte(types.TombstonePrefix), LeafIndex: 5}, wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.PreorderedLogTree, logID3)
+			tree.LeafTombstones = true
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID3).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.wantCode == codes.OK {
+				mockStorage.EXPECT().AddSequencedLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), gomock.Any()).
+					Return([]*trillian.QueuedLogLeaf{{}}, nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.AddSequencedLeavesRequest{LogId: logID3, Leaves: []*trillian.LogLeaf{test.leaf}}
+			if _, err := server.AddSequencedLeaves(ctx, req); status.Code(err) != test.wantCode {
+				t.Errorf("AddSequencedLeaves() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+// tombstoneReaderTX is a LogTreeTX implementing storage.TombstoneReader.
+type tombstoneReaderTX struct {
+	*storage.MockLogTreeTX
+	deleted []int64
+	err     error
+
+	gotStart, gotCount int64
+}
+
+func (tx *tombstoneReaderTX) GetTombstonedIndices(ctx context.Context, start, count int64) ([]int64, error) {
+	tx.gotStart, tx.gotCount = start, count
+	return tx.deleted, tx.err
+}
+
+func TestGetEffectiveLeaves(t *testing.T) {
+	tombstoneTree := addTreeID(stestonly.LogTree, logID1)
+	tombstoneTree.LeafTombstones = true
+	leaves := []*trillian.LogLeaf{
+		newTestLeaf([]byte("a"), nil, 0),
+		newTestLeaf([]byte("b"), nil, 1),
+		newTestLeaf(types.NewTombstone(0), nil, 2),
+		newTestLeaf([]byte("c"), nil, 3),
+		newTestLeaf(types.NewTombstone(1), nil, 4),
+	}
+	// The tombstone of leaf 1 isn't within the tree size yet, so only leaf 0
+	// is deleted.
+	deleted := []int64{0}
+	req := &trillian.GetEffectiveLeavesRequest{LogId: logID1, StartIndex: 0, Count: 10}
+
+	for _, test := range []struct {
+		desc          string
+		tree          *trillian.Tree
+		req           *trillian.GetEffectiveLeavesRequest
+		noReader      bool
+		getErr        error
+		wantGetLeaves bool
+		wantLeaves    []*trillian.LogLeaf
+		wantNext      int64
+		wantCode      codes.Code
+	}{
+		{desc: "ok", tree: tombstoneTree, req: req, wantGetLeaves: true, wantLeaves: []*trillian.LogLeaf{leaves[1], leaves[3]}, wantNext: 5},
+		{desc: "pastTreeSize", tree: tombstoneTree, req: &trillian.GetEffectiveLeavesRequest{LogId: logID1, StartIndex: 7, Count: 10}, wantNext: 7},
+		{desc: "storageErr", tree: tombstoneTree, req: req, wantGetLeaves: true, getErr: errors.New("STORAGE"), wantCode: codes.Unknown},
+		{desc: "noReader", tree: tombstoneTree, req: req, noReader: true, wantCode: codes.Unimplemented},
+		{desc: "noTombstones", tree: addTreeID(stestonly.LogTree, logID1), req: req, wantCode: codes.FailedPrecondition},
+		{desc: "badCount", req: &trillian.GetEffectiveLeavesRequest{LogId: logID1}, wantCode: codes.InvalidArgument},
+		{desc: "badIndex", req: &trillian.GetEffectiveLeavesRequest{LogId: logID1, StartIndex: -1, Count: 1}, wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			mockTX := storage.NewMockLogTreeTX(ctrl)
+			tx := &tombstoneReaderTX{MockLogTreeTX: mockTX, deleted: deleted, err: test.getErr}
+			if test.tree != nil {
+				adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+				adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+				adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(test.tree, nil)
+				adminTX.EXPECT().Close().AnyTimes().Return(nil)
+				adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+			}
+			if test.tree.GetLeafTombstones() {
+				if test.noReader {
+					mockStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{test.tree}).Return(mockTX, nil)
+				} else {
+					mockStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{test.tree}).Return(tx, nil)
+					mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				}
+				if test.wantGetLeaves {
+					// The count is capped by the tree size of root1.
+					mockTX.EXPECT().GetLeavesByRange(gomock.Any(), int64(0), int64(7)).Return(leaves, nil)
+				}
+				if test.wantCode == codes.OK {
+					mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+				}
+				mockTX.EXPECT().Close().Return(nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			rsp, err := server.GetEffectiveLeaves(ctx, test.req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("GetEffectiveLeaves() = %v, want code %v", err, test.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if got := rsp.Leaves; !cmp.Equal(got, test.wantLeaves, cmp.Comparer(proto.Equal)) {
+				t.Errorf("GetEffectiveLeaves().Leaves = %+v, want %+v", got, test.wantLeaves)
+			}
+			if got := rsp.NextIndex; got != test.wantNext {
+				t.Errorf("GetEffectiveLeaves().NextIndex = %d, want %d", got, test.wantNext)
+			}
+			if !proto.Equal(rsp.SignedLogRoot, signedRoot1) {
+				t.Errorf("GetEffectiveLeaves().SignedLogRoot = %v, want %v", rsp.SignedLogRoot, signedRoot1)
+			}
+			if test.wantGetLeaves && (tx.gotStart != 0 || tx.gotCount != 5) {
+				t.Errorf("GetTombstonedIndices(%d, %d) called, want (0, 5)", tx.gotStart, tx.gotCount)
+			}
+		})
+	}
+}
+
+func TestQueueLeaves_MaxTreeSize(t *testing.T) {
+	for _, test := range []struct {
+		desc        string
+		maxTreeSize int64
+		wantCode    codes.Code
+	}{
+		{desc: "notFull", maxTreeSize: int64(root1.TreeSize) + 1},
+		{desc: "full", maxTreeSize: int64(root1.TreeSize), wantCode: codes.FailedPrecondition},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.LogTree, logID1)
+			tree.MaxTreeSize = test.maxTreeSize
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			mockTX := storage.NewMockLogTreeTX(ctrl)
+			mockStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree}).Return(mockTX, nil)
+			mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+			mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+			mockTX.EXPECT().Close().Return(nil)
+			if test.wantCode == codes.OK {
+				mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), fakeTime).
+					Return([]*trillian.QueuedLogLeaf{okQueuedLeaf(leaf1)}, nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.QueueLeavesRequest{LogId: logID1, Leaves: []*trillian.LogLeaf{{LeafValue: []byte("value")}}}
+			if _, err := server.QueueLeaves(ctx, req); status.Code(err) != test.wantCode {
+				t.Errorf("QueueLeaves() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestQueueLeaves_WriteAhead(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := addTreeID(stestonly.LogTree, logID1)
+	tree.QueueWriteAhead = true
+	adminStorage := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	adminStorage.EXPECT().Snapshot(gomock.Any()).AnyTimes().Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), logID1).AnyTimes().Return(tree, nil)
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	// Storage is down when the leaf is queued, and back when it is drained.
+	mockStorage := storage.NewMockLogStorage(ctrl)
+	c1 := mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), fakeTime).
+		Return(nil, status.Error(codes.Unavailable, "connection refused"))
+	mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), fakeTime).After(c1).
+		Return([]*trillian.QueuedLogLeaf{okQueuedLeaf(leaf1)}, nil)
+
+	dir, err := ioutil.TempDir("", "queuewal")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	wal, err := queuewal.Open(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	registry := extension.Registry{
+		AdminStorage: adminStorage,
+		LogStorage:   mockStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	server.QueueWAL = wal
+	req := &trillian.QueueLeavesRequest{LogId: logID1, Leaves: []*trillian.LogLeaf{{LeafValue: []byte("value")}}}
+	rsp, err := server.QueueLeaves(ctx, req)
+	if err != nil {
+		t.Fatalf("QueueLeaves(): %v", err)
+	}
+	if got := len(rsp.QueuedLeaves); got != 1 || rsp.QueuedLeaves[0].Status != nil {
+		t.Errorf("QueueLeaves() = %v, want 1 new leaf", rsp.QueuedLeaves)
+	}
+	if got, want := wal.Pending(), []int64{logID1}; !cmp.Equal(got, want) {
+		t.Errorf("Pending() after QueueLeaves() = %v, want %v", got, want)
+	}
+
+	if err := server.drainQueueWAL(ctx, logID1); err != nil {
+		t.Fatalf("drainQueueWAL(): %v", err)
+	}
+	if got := wal.Pending(); len(got) != 0 {
+		t.Errorf("Pending() after drainQueueWAL() = %v, want none", got)
+	}
+}
+
+func TestQueueLeaves_Condition(t *testing.T) {
+	for _, test := range []struct {
+		desc         string
+		expectedSize int64
+		wantCode     codes.Code
+	}{
+		{desc: "match", expectedSize: int64(root1.TreeSize)},
+		{desc: "mismatch", expectedSize: int64(root1.TreeSize) + 1, wantCode: codes.FailedPrecondition},
+		{desc: "negative", expectedSize: -1, wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.LogTree, logID1)
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.wantCode != codes.InvalidArgument {
+				mockTX := storage.NewMockLogTreeTX(ctrl)
+				mockStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree}).Return(mockTX, nil)
+				mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+				mockTX.EXPECT().Close().Return(nil)
+			}
+			if test.wantCode == codes.OK {
+				mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, gomock.Any(), fakeTime).DoAndReturn(
+					func(ctx context.Context, _ *trillian.Tree, leaves []*trillian.LogLeaf, _ time.Time) ([]*trillian.QueuedLogLeaf, error) {
+						c := storage.QueueConditionFromContext(ctx)
+						if c == nil {
+							t.Fatal("QueueLeaves() called without a queue condition")
+						}
+						if got, want := c.TreeSize, test.expectedSize; got != want {
+							t.Errorf("queue condition has tree size %d, want %d", got, want)
+						}
+						if got, want := c.BatchSize, int64(len(leaves)); got != want {
+							t.Errorf("queue condition has batch size %d, want %d", got, want)
+						}
+						if len(c.BatchId) == 0 {
+							t.Error("queue condition has no batch ID")
+						}
+						return []*trillian.QueuedLogLeaf{okQueuedLeaf(leaf1), okQueuedLeaf(leaf3)}, nil
+					})
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.QueueLeavesRequest{
+				LogId:     logID1,
+				Leaves:    []*trillian.LogLeaf{{LeafValue: []byte("value1")}, {LeafValue: []byte("value3")}},
+				Condition: &trillian.QueueCondition{ExpectedTreeSize: test.expectedSize},
+			}
+			if _, err := server.QueueLeaves(ctx, req); status.Code(err) != test.wantCode {
+				t.Errorf("QueueLeaves() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestQueueLeaves_CallerLeafIdentityHash(t *testing.T) {
+	identity := sha256.Sum256([]byte("identity"))
+	withIdentity := func(hash []byte) *trillian.LogLeaf {
+		return &trillian.LogLeaf{LeafValue: []byte("value"), LeafIdentityHash: hash}
+	}
+	merkleHash := th.HashLeaf([]byte("value"))
+
+	for _, test := range []struct {
+		desc         string
+		strict       bool
+		leaf         *trillian.LogLeaf
+		wantIdentity []byte
+		wantCode     codes.Code
+	}{
+		{desc: "default", leaf: withIdentity(nil), wantIdentity: merkleHash},
+		{desc: "supplied", leaf: withIdentity(identity[:]), wantIdentity: identity[:]},
+		{desc: "supplied any size", leaf: withIdentity(identity[:20]), wantIdentity: identity[:20]},
+		{desc: "strict", strict: true, leaf: withIdentity(identity[:]), wantIdentity: identity[:]},
+		{desc: "strict default", strict: true, leaf: withIdentity(nil), wantIdentity: merkleHash},
+		{desc: "strict wrong size", strict: true, leaf: withIdentity(identity[:20]), wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.LogTree, logID1)
+			tree.CallerLeafIdentityHash = test.strict
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.wantCode == codes.OK {
+				want := &trillian.LogLeaf{LeafValue: []byte("value"), MerkleLeafHash: merkleHash, LeafIdentityHash: test.wantIdentity}
+				mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, cmpMatcher{[]*trillian.LogLeaf{want}}, fakeTime).
+					Return([]*trillian.QueuedLogLeaf{okQueuedLeaf(want)}, nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.QueueLeavesRequest{LogId: logID1, Leaves: []*trillian.LogLeaf{test.leaf}}
+			_, err := server.QueueLeaves(ctx, req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("QueueLeaves() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestQueueLeaves_HashExtraData(t *testing.T) {
+	value, extra := []byte("value"), []byte("extra")
+
+	for _, test := range []struct {
+		desc          string
+		hashExtraData bool
+		wantHash      []byte
+	}{
+		{desc: "value only", wantHash: th.HashLeaf(value)},
+		{desc: "extra data", hashExtraData: true, wantHash: th.HashLeaf(hashers.ExtraDataLeafInput(value, extra))},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.LogTree, logID1)
+			tree.HashExtraData = test.hashExtraData
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			want := &trillian.LogLeaf{LeafValue: value, ExtraData: extra, MerkleLeafHash: test.wantHash, LeafIdentityHash: test.wantHash}
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, cmpMatcher{[]*trillian.LogLeaf{want}}, fakeTime).
+				Return([]*trillian.QueuedLogLeaf{okQueuedLeaf(want)}, nil)
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.QueueLeavesRequest{LogId: logID1, Leaves: []*trillian.LogLeaf{{LeafValue: value, ExtraData: extra}}}
+			if _, err := server.QueueLeaves(ctx, req); err != nil {
+				t.Errorf("QueueLeaves() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestQueueLeaves_HashOnly(t *testing.T) {
+	hash := th.HashLeaf([]byte("private"))
+
+	for _, test := range []struct {
+		desc     string
+		hashOnly bool
+		leaf     *trillian.LogLeaf
+		wantCode codes.Code
+	}{
+		{desc: "hash", hashOnly: true, leaf: &trillian.LogLeaf{MerkleLeafHash: hash, ExtraData: []byte("extra")}},
+		{desc: "value", hashOnly: true, leaf: &trillian.LogLeaf{LeafValue: []byte("private"), MerkleLeafHash: hash}, wantCode: codes.InvalidArgument},
+		{desc: "wrong size", hashOnly: true, leaf: &trillian.LogLeaf{MerkleLeafHash: hash[:20]}, wantCode: codes.InvalidArgument},
+		{desc: "not hash-only", leaf: &trillian.LogLeaf{MerkleLeafHash: hash}, wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.LogTree, logID1)
+			tree.HashOnly = test.hashOnly
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if test.wantCode == codes.OK {
+				want := &trillian.LogLeaf{LeafValue: []byte{}, ExtraData: test.leaf.ExtraData, MerkleLeafHash: hash, LeafIdentityHash: hash}
+				mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, cmpMatcher{[]*trillian.LogLeaf{want}}, fakeTime).
+					Return([]*trillian.QueuedLogLeaf{okQueuedLeaf(want)}, nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: adminStorage,
+				LogStorage:   mockStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.QueueLeavesRequest{LogId: logID1, Leaves: []*trillian.LogLeaf{test.leaf}}
+			if _, err := server.QueueLeaves(ctx, req); status.Code(err) != test.wantCode {
+				t.Errorf("QueueLeaves() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestQueueLeaves_LeafValidator(t *testing.T) {
+	good, bad := []byte("good"), []byte("bad")
+	reject := validation.LeafValidatorFunc(func(_ context.Context, _ *trillian.Tree, leaf *trillian.LogLeaf) error {
+		if bytes.Equal(leaf.LeafValue, bad) {
+			return errors.New("bad leaf")
+		}
+		return nil
+	})
+
+	for _, test := range []struct {
+		desc      string
+		validator validation.LeafValidator
+		values    [][]byte
+		// stored are the values expected to be passed to storage.
+		stored    [][]byte
+		wantCodes []codes.Code
+		wantErr   bool
+	}{
+		{desc: "noop", validator: validation.Noop(), values: [][]byte{good, bad}, stored: [][]byte{good, bad}, wantCodes: []codes.Code{codes.OK, codes.OK}},
+		{desc: "some rejected", validator: reject, values: [][]byte{bad, good, bad}, stored: [][]byte{good}, wantCodes: []codes.Code{codes.InvalidArgument, codes.OK, codes.InvalidArgument}},
+		{desc: "all rejected", validator: reject, values: [][]byte{bad}, wantCodes: []codes.Code{codes.InvalidArgument}},
+		{desc: "all or nothing", validator: validation.AllOrNothing(reject), values: [][]byte{good, bad}, wantErr: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctx := context.Background()
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tree := addTreeID(stestonly.LogTree, logID1)
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(tree, nil)
+			adminTX.EXPECT().Close().AnyTimes().Return(nil)
+			adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+			mockStorage := storage.NewMockLogStorage(ctrl)
+			if len(test.stored) > 0 {
+				var want []*trillian.LogLeaf
+				var queued []*trillian.QueuedLogLeaf
+				for _, value := range test.stored {
+					hash := th.HashLeaf(value)
+					leaf := &trillian.LogLeaf{LeafValue: value, MerkleLeafHash: hash, LeafIdentityHash: hash}
+					want = append(want, leaf)
+					queued = append(queued, okQueuedLeaf(leaf))
+				}
+				mockStorage.EXPECT().QueueLeaves(gomock.Any(), cmpMatcher{tree}, cmpMatcher{want}, fakeTime).Return(queued, nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage:  adminStorage,
+				LogStorage:    mockStorage,
+				LeafValidator: test.validator,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			req := &trillian.QueueLeavesRequest{LogId: logID1}
+			for _, value := range test.values {
+				req.Leaves = append(req.Leaves, &trillian.LogLeaf{LeafValue: value})
+			}
+			rsp, err := server.QueueLeaves(ctx, req)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("QueueLeaves() = (_, %v), want error: %v", err, test.wantErr)
+			}
+			if err != nil {
+				if got, want := status.Code(err), codes.InvalidArgument; got != want {
+					t.Errorf("QueueLeaves() returned code %v, want %v", got, want)
+				}
+				return
+			}
+			if got, want := len(rsp.QueuedLeaves), len(test.wantCodes); got != want {
+				t.Fatalf("QueueLeaves() returned %d leaves, want %d", got, want)
+			}
+			for i, queued := range rsp.QueuedLeaves {
+				if got, want := codes.Code(queued.GetStatus().GetCode()), test.wantCodes[i]; got != want {
+					t.Errorf("QueuedLeaves[%d].Status.Code = %v, want %v", i, got, want)
+				}
+				if want := test.wantCodes[i] == codes.OK; (queued.Leaf != nil) != want {
+					t.Errorf("QueuedLeaves[%d].Leaf = %v, want set: %v", i, queued.Leaf, want)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueLeaf_LeafValidator(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := addTreeID(stestonly.LogTree, logID1)
+	adminStorage := storage.NewMockAdminStorage(ctrl)
+	adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+	adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+	adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(tree, nil)
+	adminTX.EXPECT().Close().AnyTimes().Return(nil)
+	adminTX.EXPECT().Commit().AnyTimes().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: adminStorage,
+		LogStorage:   storage.NewMockLogStorage(ctrl),
+		LeafValidator: validation.LeafValidatorFunc(func(context.Context, *trillian.Tree, *trillian.LogLeaf) error {
+			return errors.New("bad leaf")
+		}),
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	// The rejection fails the request, rather than being reported in the
+	// status of the queued leaf.
+	_, err := server.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: logID1, Leaf: &trillian.LogLeaf{LeafValue: []byte("bad")}})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("QueueLeaf() = %v, want code %v", err, want)
+	}
+}
+
+type latestRootTest struct {
+	desc        string
+	req         *trillian.GetLatestSignedLogRootRequest
+	wantRoot    *trillian.GetLatestSignedLogRootResponse
+	errStr      string
+	noSnap      bool
+	snapErr     error
+	noRoot      bool
+	storageRoot *trillian.SignedLogRoot
+	rootErr     error
+	noCommit    bool
+	commitErr   error
+	noClose     bool
+}
+
+func TestGetLatestSignedLogRoot(t *testing.T) {
+	tests := []latestRootTest{
+		{
+			desc: "snap_fail",
+			// Test error case when failing to get a snapshot from storage.
+			req:      &getLogRootRequest1,
+			snapErr:  errors.New("SnapshotForTree() error"),
+			errStr:   "SnapshotFor",
+			noRoot:   true,
+			noCommit: true,
+			noClose:  true,
+		},
+		{
+			desc: "needs_init",
+			// Test error case where the log exists but hasn't been initialised,
+			// making sure the snapshot is still closed.
+			req:      &getLogRootRequest1,
+			snapErr:  storage.ErrTreeNeedsInit,
+			errStr:   "tree needs initialising",
+			noRoot:   true,
+			noCommit: true,
+		},
+		{
+			desc: "storage_fail",
+			// Test error case when storage fails to provide a root.
+			req:      &getLogRootRequest1,
+			errStr:   "LatestSigned",
+			rootErr:  errors.New("LatestSignedLogRoot() error"),
+			noCommit: true,
+		},
+		{
+			desc: "read_error",
+			// Test error case where the log root could not be read
+			req:      &getLogRootRequest1,
+			errStr:   "rpc error: code = Internal desc = Could not read current log root: logRootBytes too short",
+			noCommit: true,
+		},
+		{
+			desc: "ok",
+			// Test normal case where a root is returned correctly.
+			req:         &getLogRootRequest1,
+			wantRoot:    &trillian.GetLatestSignedLogRootResponse{SignedLogRoot: signedRoot1},
+			storageRoot: signedRoot1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fakeStorage := storage.NewMockLogStorage(ctrl)
+			mockTX := storage.NewMockLogTreeTX(ctrl)
+			if !test.noSnap {
+				fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(mockTX, test.snapErr)
+			}
+			if !test.noRoot {
+				mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(test.storageRoot, test.rootErr)
+			}
+			if !test.noCommit {
+				mockTX.EXPECT().Commit(gomock.Any()).Return(test.commitErr)
+			}
+			if !test.noClose {
+				mockTX.EXPECT().Close().Return(nil)
+			}
+
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: test.req.LogId, numSnapshots: 1}),
+				LogStorage:   fakeStorage,
+			}
+			s := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			got, err := s.GetLatestSignedLogRoot(context.Background(), test.req)
+			if len(test.errStr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.errStr) {
+					t.Errorf("GetLatestSignedLogRoot(%+v)=_,nil, want: _,err contains: %s but got: %v", test.req, test.errStr, err)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("GetLatestSignedLogRoot(%+v)=_,%v, want: _,nil", test.req, err)
+					return
+				}
+				// Ensure we got the expected root back.
+				if !proto.Equal(got.SignedLogRoot, test.wantRoot.SignedLogRoot) {
+					t.Errorf("GetConsistencyProof(%+v)=%v,nil, want: %v,nil", test.req, got, test.wantRoot)
+				}
+			}
+		})
+	}
+}
+
+func TestGetLeavesByHash(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		setupStorage func(*gomock.Controller, *storage.MockLogStorage)
+		snapErr      error
+		treeErr      error
+		req          *trillian.GetLeavesByHashRequest
+		errStr       string
+		wantResp     *trillian.GetLeavesByHashResponse
+	}{
+		{
+			name: "admin snapshot fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+			},
+			req:     &getByHashRequest1,
+			snapErr: errors.New("admin snap"),
+			errStr:  "admin snap",
+		},
+		{
+			name: "get tree fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+			},
+			req:     &getByHashRequest1,
+			treeErr: errors.New("tree error"),
+			errStr:  "tree error",
+		},
+		{
+			name: "begin fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(nil, errors.New("TX"))
+			},
+			req:    &getByHashRequest1,
+			errStr: "TX",
+		},
+		{
+			name: "not initialized",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, storage.ErrTreeNeedsInit)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getByHashRequest1,
+			errStr: "tree needs init",
+		},
+		{
+			name: "storage error",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return(nil, errors.New("STORAGE"))
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getByHashRequest1,
+			errStr: "STORAGE",
+		},
+		{
+			name: "commit fails",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return(nil, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(errors.New("COMMIT"))
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getByHashRequest1,
+			errStr: "COMMIT",
+		},
+		{
+			name: "log root fails",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return(nil, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(&trillian.SignedLogRoot{}, errors.New("SLR"))
+				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().IsOpen().AnyTimes().Return(false)
+			},
+			req:    &getByHashRequest1,
+			errStr: "SLR",
+		},
+		{
+			name: "bad log root",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return(nil, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(corruptLogRoot, nil)
+				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().IsOpen().AnyTimes().Return(false)
+			},
+			req:    &getByHashRequest1,
+			errStr: "not read current log root",
+		},
+		{
+			name: "leaf hash too short",
+			req: &trillian.GetLeavesByHashRequest{
+				LeafHash: [][]byte{
+					[]byte("too-short-to-be-a-hash"),
+				},
+				LogId: logID1,
+			},
+			errStr: "GetLeavesByHashRequest.LeafHash[0]: 22 bytes, want 32",
+		},
+		{
+			name: "ok multiple",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return([]*trillian.LogLeaf{leaf1, leaf3}, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(nil)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req: &getByHashRequest1,
+			wantResp: &trillian.GetLeavesByHashResponse{
+				SignedLogRoot: signedRoot1,
+				Leaves:        []*trillian.LogLeaf{leaf1, leaf3},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			fakeStorage := storage.NewMockLogStorage(ctrl)
+			if tc.setupStorage != nil {
+				tc.setupStorage(ctrl, fakeStorage)
+			}
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: leaf0Request.LogId, numSnapshots: 1, snapErr: tc.snapErr, treeErr: tc.treeErr}),
+				LogStorage:   fakeStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			resp, err := server.GetLeavesByHash(context.Background(), tc.req)
+			if len(tc.errStr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.errStr) {
+					t.Errorf("GetLeavesByHash(%v)=(%v, %v), want (nil, err containing %q)", tc.req, resp, err, tc.errStr)
+				}
+				return
+			}
+
+			if err != nil || !proto.Equal(tc.wantResp, resp) {
+				t.Errorf("GetLeavesByHash(%v)=(%v, %v), want (%v, nil)", tc.req, resp, err, tc.wantResp)
+			}
+		})
+	}
+}
+
+func TestGetProofByHashErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		setupStorage func(*gomock.Controller, *storage.MockLogStorage)
+		snapErr      error
+		treeErr      error
+		req          *trillian.GetInclusionProofByHashRequest
+		errStr       string
+		wantResp     *trillian.GetInclusionProofByHashResponse
+	}{
+		{
+			name: "admin snapshot fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+			},
+			req:     &getInclusionProofByHashRequest25,
+			snapErr: errors.New("admin snap"),
+			errStr:  "admin snap",
+		},
+		{
+			name: "get tree fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+			},
+			req:     &getInclusionProofByHashRequest25,
+			treeErr: errors.New("tree error"),
+			errStr:  "tree error",
+		},
+		{
+			name: "begin fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(nil, errors.New("TX"))
+			},
+			req:    &getInclusionProofByHashRequest25,
+			errStr: "TX",
+		},
+		{
+			name: "not initialized",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, storage.ErrTreeNeedsInit)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByHashRequest25,
+			errStr: "tree needs init",
+		},
+		{
 			name: "storage error",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return(nil, errors.New("STORAGE"))
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash2}, false).Return(nil, errors.New("STORAGE"))
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByHashRequest25,
+			errStr: "STORAGE",
+		},
+		{
+			name: "get nodes fails",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return([]*trillian.LogLeaf{{LeafIndex: 2}}, nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{}, errors.New("STORAGE"))
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByHashRequest7,
+			errStr: "STORAGE",
+		},
+		{
+			name: "too few nodes",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return([]*trillian.LogLeaf{{LeafIndex: 2}}, nil)
+				// The server expects three nodes from storage but we return only two
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeRevision: 3}, {NodeRevision: 2}}, nil)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByHashRequest7,
+			errStr: "expected 3 nodes",
+		},
+		{
+			name: "wrong node",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return([]*trillian.LogLeaf{{LeafIndex: 2}}, nil)
+				// We set this up so one of the returned nodes has the wrong ID
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3}, {NodeID: stestonly.MustCreateNodeIDForTreeCoords(4, 5, 64), NodeRevision: 2}, {NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3}}, nil)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByHashRequest7,
+			errStr: "expected node ",
+		},
+		{
+			name: "commit fails",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(nil, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(errors.New("COMMIT"))
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByHashRequest7,
+			errStr: "COMMIT",
+		},
+		{
+			name: "log root fails",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(nil, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(&trillian.SignedLogRoot{}, errors.New("SLR"))
+				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().IsOpen().AnyTimes().Return(false)
+			},
+			req:    &getInclusionProofByHashRequest7,
+			errStr: "SLR",
+		},
+		{
+			name: "bad log root",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(nil, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(corruptLogRoot, nil)
+				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().IsOpen().AnyTimes().Return(false)
+			},
+			req:    &getInclusionProofByHashRequest7,
+			errStr: "not read current log root",
+		},
+		{
+			name: "leaf hash too short",
+			req: &trillian.GetInclusionProofByHashRequest{
+				LeafHash: []byte("too-short-to-be-a-hash"),
+				LogId:    logID1,
+				TreeSize: 7,
+			},
+			errStr: "GetInclusionProofByHashRequest.LeafHash: 22 bytes, want 32",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			fakeStorage := storage.NewMockLogStorage(ctrl)
+			if tc.setupStorage != nil {
+				tc.setupStorage(ctrl, fakeStorage)
+			}
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: leaf0Request.LogId, numSnapshots: 1, snapErr: tc.snapErr, treeErr: tc.treeErr}),
+				LogStorage:   fakeStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			resp, err := server.GetInclusionProofByHash(context.Background(), tc.req)
+			if len(tc.errStr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.errStr) {
+					t.Errorf("GetInclusionProofByHash(%v)=(%v, %v), want (nil, err containing %q)", tc.req, resp, err, tc.errStr)
+				}
+				return
+			}
+
+			if err != nil || !proto.Equal(tc.wantResp, resp) {
+				t.Errorf("GetInclusionProofByHash(%v)=(%v, %v), want (%v, nil)", tc.req, resp, err, tc.wantResp)
+			}
+		})
+	}
+}
+
+func TestGetProofByHash_AllIndices(t *testing.T) {
+	ctx := context.Background()
+	root := &types.LogRootV1{TimestampNanos: 987654321, RootHash: []byte("A NICE HASH"), TreeSize: 256, Revision: uint64(revision1)}
+	signedRoot, err := fixedSigner.SignLogRoot(root)
+	if err != nil {
+		t.Fatalf("SignLogRoot(): %v", err)
+	}
+	leavesAt := func(indices ...int64) []*trillian.LogLeaf {
+		leaves := make([]*trillian.LogLeaf, 0, len(indices))
+		for _, index := range indices {
+			leaves = append(leaves, &trillian.LogLeaf{LeafIndex: index})
+		}
+		return leaves
+	}
+	var many, firstMany []int64
+	for i := int64(maxProofsByHash + 10); i > 0; i-- {
+		many = append(many, i-1)
+	}
+	for i := int64(0); i < maxProofsByHash; i++ {
+		firstMany = append(firstMany, i)
+	}
+
+	for _, tc := range []struct {
+		desc          string
+		allIndices    bool
+		leaves        []*trillian.LogLeaf
+		wantProofs    []int64
+		wantIndices   []int64
+		wantTruncated bool
+	}{
+		{desc: "not requested", leaves: leavesAt(9, 2, 300), wantProofs: []int64{9, 2}},
+		{desc: "all", allIndices: true, leaves: leavesAt(9, 2, 300, 5), wantProofs: []int64{2, 5, 9}, wantIndices: []int64{2, 5, 9}},
+		{desc: "truncated", allIndices: true, leaves: leavesAt(many...), wantProofs: firstMany, wantIndices: firstMany, wantTruncated: true},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fakeStorage := storage.NewMockLogStorage(ctrl)
+			mockTX := storage.NewMockLogTreeTX(ctrl)
+			fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(mockTX, nil)
+			mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot, nil)
+			mockTX.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(tc.leaves, nil)
+			mockTX.EXPECT().ReadRevision(gomock.Any()).Return(revision1, nil).AnyTimes()
+			mockTX.EXPECT().GetMerkleNodes(gomock.Any(), revision1, gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ int64, ids []tree.NodeID) ([]tree.Node, error) {
+					nodes := make([]tree.Node, 0, len(ids))
+					for _, id := range ids {
+						nodes = append(nodes, tree.Node{NodeID: id, NodeRevision: revision1, Hash: []byte("nodehash")})
+					}
+					return nodes, nil
+				}).AnyTimes()
+			mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+			mockTX.EXPECT().Close().Return(nil)
+
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+				LogStorage:   fakeStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			resp, err := server.GetInclusionProofByHash(ctx, &trillian.GetInclusionProofByHashRequest{
+				LogId:      logID1,
+				TreeSize:   int64(root.TreeSize),
+				LeafHash:   leafHash1,
+				AllIndices: tc.allIndices,
+			})
+			if err != nil {
+				t.Fatalf("GetInclusionProofByHash(): %v", err)
+			}
+			var gotProofs []int64
+			for _, proof := range resp.Proof {
+				gotProofs = append(gotProofs, proof.LeafIndex)
+			}
+			if diff := cmp.Diff(gotProofs, tc.wantProofs); diff != "" {
+				t.Errorf("GetInclusionProofByHash() proof indices diff (-got +want):\n%s", diff)
+			}
+			if diff := cmp.Diff(resp.LeafIndices, tc.wantIndices); diff != "" {
+				t.Errorf("GetInclusionProofByHash().LeafIndices diff (-got +want):\n%s", diff)
+			}
+			if got, want := resp.Truncated, tc.wantTruncated; got != want {
+				t.Errorf("GetInclusionProofByHash().Truncated = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestGetProofByHash(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		desc            string
+		wantCode        codes.Code
+		leavesByHashVal []*trillian.LogLeaf
+	}{
+		{desc: "OK", leavesByHashVal: []*trillian.LogLeaf{{LeafIndex: 2}}},
+		{desc: "NotFoundTreeSize", wantCode: codes.NotFound, leavesByHashVal: []*trillian.LogLeaf{{LeafIndex: 7}}},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fakeStorage := storage.NewMockLogStorage(ctrl)
+			mockTX := storage.NewMockLogTreeTX(ctrl)
+			fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(mockTX, nil)
+
+			mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+			mockTX.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(tc.leavesByHashVal, nil)
+			mockTX.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil).AnyTimes()
+			mockTX.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+				{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+				{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+				{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil).AnyTimes()
+			mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+			mockTX.EXPECT().Close().Return(nil)
+
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{
+					treeID:       logID1,
+					numSnapshots: 1,
+				}),
+				LogStorage: fakeStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+			proofResponse, err := server.GetInclusionProofByHash(ctx,
+				&trillian.GetInclusionProofByHashRequest{
+					LogId:    logID1,
+					TreeSize: 7,
+					LeafHash: leafHash1,
+				})
+			if got, want := status.Code(err), tc.wantCode; got != want {
+				t.Fatalf("GetInclusionProofByHash(): %v, want %v", err, want)
+			}
+			if err != nil {
+				return
+			}
+
+			if proofResponse == nil {
+				t.Fatalf("server response was not successful: %v", proofResponse)
+			}
+
+			expectedProof := &trillian.Proof{
+				LeafIndex: 2,
+				Hashes: [][]byte{
+					[]byte("nodehash0"),
+					[]byte("nodehash1"),
+					[]byte("nodehash2"),
+				},
+			}
+
+			if !proto.Equal(proofResponse.Proof[0], expectedProof) {
+				t.Fatalf("expected proof: %v but got: %v", proto.CompactTextString(expectedProof), proto.CompactTextString(proofResponse.Proof[0]))
+			}
+		})
+	}
+}
+
+func TestGetProofByIndex(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		setupStorage func(*gomock.Controller, *storage.MockLogStorage)
+		snapErr      error
+		treeErr      error
+		req          *trillian.GetInclusionProofRequest
+		errStr       string
+		wantResp     *trillian.GetInclusionProofResponse
+	}{
+		{
+			name: "admin snapshot fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+			},
+			req:     &getInclusionProofByIndexRequest25,
+			snapErr: errors.New("admin snap"),
+			errStr:  "admin snap",
+		},
+		{
+			name: "get tree fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+			},
+			req:     &getInclusionProofByIndexRequest25,
+			treeErr: errors.New("tree error"),
+			errStr:  "tree error",
+		},
+		{
+			name: "begin fails",
+			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(nil, errors.New("TX"))
+			},
+			req:    &getInclusionProofByIndexRequest25,
+			errStr: "TX",
+		},
+		{
+			name: "not initialized",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, storage.ErrTreeNeedsInit)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByIndexRequest25,
+			errStr: "tree needs init",
+		},
+		{
+			name: "get nodes fails",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{}, errors.New("STORAGE"))
 				tx.EXPECT().Close().Return(nil)
 			},
-			req:    &getByHashRequest1,
+			req:    &getInclusionProofByIndexRequest7,
 			errStr: "STORAGE",
 		},
+		{
+			name: "too few nodes",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				// The server expects three nodes from storage but we return only two
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeRevision: 3}, {NodeRevision: 2}}, nil)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByIndexRequest7,
+			errStr: "expected 3 nodes",
+		},
+		{
+			name: "wrong node",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				// We set this up so one of the returned nodes has the wrong ID
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3}, {NodeID: stestonly.MustCreateNodeIDForTreeCoords(4, 5, 64), NodeRevision: 2}, {NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3}}, nil)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req:    &getInclusionProofByIndexRequest7,
+			errStr: "expected node ",
+		},
 		{
 			name: "commit fails",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return(nil, nil)
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3}, {NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2}, {NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3}}, nil)
 				tx.EXPECT().Commit(gomock.Any()).Return(errors.New("COMMIT"))
 				tx.EXPECT().Close().Return(nil)
 			},
-			req:    &getByHashRequest1,
+			req:    &getInclusionProofByIndexRequest7,
 			errStr: "COMMIT",
 		},
 		{
@@ -759,12 +2369,11 @@ func TestGetLeavesByHash(t *testing.T) {
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return(nil, nil)
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(&trillian.SignedLogRoot{}, errors.New("SLR"))
 				tx.EXPECT().Close().Return(nil)
 				tx.EXPECT().IsOpen().AnyTimes().Return(false)
 			},
-			req:    &getByHashRequest1,
+			req:    &getInclusionProofByIndexRequest7,
 			errStr: "SLR",
 		},
 		{
@@ -772,38 +2381,51 @@ func TestGetLeavesByHash(t *testing.T) {
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return(nil, nil)
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(corruptLogRoot, nil)
 				tx.EXPECT().Close().Return(nil)
 				tx.EXPECT().IsOpen().AnyTimes().Return(false)
 			},
-			req:    &getByHashRequest1,
+			req:    &getInclusionProofByIndexRequest7,
 			errStr: "not read current log root",
 		},
 		{
-			name: "leaf hash too short",
-			req: &trillian.GetLeavesByHashRequest{
-				LeafHash: [][]byte{
-					[]byte("too-short-to-be-a-hash"),
+			name: "ok",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(nil)
+				tx.EXPECT().Close().Return(nil)
+			},
+			req: &getInclusionProofByIndexRequest7,
+			wantResp: &trillian.GetInclusionProofResponse{
+				SignedLogRoot: signedRoot1,
+				Proof: &trillian.Proof{
+					LeafIndex: 2,
+					Hashes: [][]byte{
+						[]byte("nodehash0"),
+						[]byte("nodehash1"),
+						[]byte("nodehash2"),
+					},
 				},
-				LogId: logID1,
 			},
-			errStr: "GetLeavesByHashRequest.LeafHash[0]: 22 bytes, want 32",
 		},
 		{
-			name: "ok multiple",
+			name: "skew beyond sth",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1, leafHash3}, false).Return([]*trillian.LogLeaf{leaf1, leaf3}, nil)
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().Commit(gomock.Any()).Return(nil)
 				tx.EXPECT().Close().Return(nil)
 			},
-			req: &getByHashRequest1,
-			wantResp: &trillian.GetLeavesByHashResponse{
+			req: &getInclusionProofByIndexRequest25,
+			wantResp: &trillian.GetInclusionProofResponse{
 				SignedLogRoot: signedRoot1,
-				Leaves:        []*trillian.LogLeaf{leaf1, leaf3},
 			},
 		},
 	} {
@@ -811,44 +2433,42 @@ func TestGetLeavesByHash(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			fakeStorage := storage.NewMockLogStorage(ctrl)
-			if tc.setupStorage != nil {
-				tc.setupStorage(ctrl, fakeStorage)
-			}
+			tc.setupStorage(ctrl, fakeStorage)
 			registry := extension.Registry{
 				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: leaf0Request.LogId, numSnapshots: 1, snapErr: tc.snapErr, treeErr: tc.treeErr}),
 				LogStorage:   fakeStorage,
 			}
 			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
-			resp, err := server.GetLeavesByHash(context.Background(), tc.req)
+			resp, err := server.GetInclusionProof(context.Background(), tc.req)
 			if len(tc.errStr) > 0 {
 				if err == nil || !strings.Contains(err.Error(), tc.errStr) {
-					t.Errorf("GetLeavesByHash(%v)=(%v, %v), want (nil, err containing %q)", tc.req, resp, err, tc.errStr)
+					t.Errorf("GetInclusionProofByHash(%v)=%v, %v want nil, err containing: %s", tc.req, resp, err, tc.errStr)
 				}
 				return
 			}
 
 			if err != nil || !proto.Equal(tc.wantResp, resp) {
-				t.Errorf("GetLeavesByHash(%v)=(%v, %v), want (%v, nil)", tc.req, resp, err, tc.wantResp)
+				t.Errorf("GetInclusionProofByHash(%v)=%v, %v, want: %v, nil", tc.req, resp, err, tc.wantResp)
 			}
 		})
 	}
 }
 
-func TestGetProofByHashErrors(t *testing.T) {
+func TestGetEntryAndProof(t *testing.T) {
 	for _, tc := range []struct {
 		name         string
 		setupStorage func(*gomock.Controller, *storage.MockLogStorage)
 		snapErr      error
 		treeErr      error
-		req          *trillian.GetInclusionProofByHashRequest
+		req          *trillian.GetEntryAndProofRequest
 		errStr       string
-		wantResp     *trillian.GetInclusionProofByHashResponse
+		wantResp     *trillian.GetEntryAndProofResponse
 	}{
 		{
 			name: "admin snapshot fails",
 			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
 			},
-			req:     &getInclusionProofByHashRequest25,
+			req:     &getEntryAndProofRequest17,
 			snapErr: errors.New("admin snap"),
 			errStr:  "admin snap",
 		},
@@ -856,7 +2476,7 @@ func TestGetProofByHashErrors(t *testing.T) {
 			name: "get tree fails",
 			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
 			},
-			req:     &getInclusionProofByHashRequest25,
+			req:     &getEntryAndProofRequest17,
 			treeErr: errors.New("tree error"),
 			errStr:  "tree error",
 		},
@@ -865,7 +2485,7 @@ func TestGetProofByHashErrors(t *testing.T) {
 			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(nil, errors.New("TX"))
 			},
-			req:    &getInclusionProofByHashRequest25,
+			req:    &getEntryAndProofRequest17,
 			errStr: "TX",
 		},
 		{
@@ -875,7 +2495,7 @@ func TestGetProofByHashErrors(t *testing.T) {
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, storage.ErrTreeNeedsInit)
 				tx.EXPECT().Close().Return(nil)
 			},
-			req:    &getInclusionProofByHashRequest25,
+			req:    &getEntryAndProofRequest17,
 			errStr: "tree needs init",
 		},
 		{
@@ -883,703 +2503,1172 @@ func TestGetProofByHashErrors(t *testing.T) {
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash2}, false).Return(nil, errors.New("STORAGE"))
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return(nil, errors.New("STORAGE"))
 				tx.EXPECT().Close().Return(nil)
 			},
-			req:    &getInclusionProofByHashRequest25,
+			req:    &getEntryAndProofRequest7,
 			errStr: "STORAGE",
 		},
 		{
-			name: "get nodes fails",
+			name: "commit fails",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
 				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return([]*trillian.LogLeaf{{LeafIndex: 2}}, nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{}, errors.New("STORAGE"))
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{leaf1}, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(errors.New("COMMIT"))
 				tx.EXPECT().Close().Return(nil)
 			},
-			req:    &getInclusionProofByHashRequest7,
-			errStr: "STORAGE",
+			req:    &getEntryAndProofRequest7,
+			errStr: "COMMIT",
 		},
 		{
-			name: "too few nodes",
+			name: "log root fails",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return([]*trillian.LogLeaf{{LeafIndex: 2}}, nil)
-				// The server expects three nodes from storage but we return only two
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeRevision: 3}, {NodeRevision: 2}}, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, errors.New("SLR"))
 				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().IsOpen().AnyTimes().Return(false)
 			},
-			req:    &getInclusionProofByHashRequest7,
-			errStr: "expected 3 nodes",
+			req:    &getEntryAndProofRequest17,
+			errStr: "SLR",
 		},
 		{
-			name: "wrong node",
+			name: "bad log root",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(corruptLogRoot, nil)
+				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().IsOpen().AnyTimes().Return(false)
+			},
+			req:    &getEntryAndProofRequest17,
+			errStr: "not read current log root",
+		},
+		{
+			name: "multiple leaves incorrect",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
 				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return([]*trillian.LogLeaf{{LeafIndex: 2}}, nil)
-				// We set this up so one of the returned nodes has the wrong ID
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3}, {NodeID: stestonly.MustCreateNodeIDForTreeCoords(4, 5, 64), NodeRevision: 2}, {NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3}}, nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+				// Code passed one leaf index so expects one result, but we return more
+				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{leaf1, leaf3}, nil)
 				tx.EXPECT().Close().Return(nil)
 			},
-			req:    &getInclusionProofByHashRequest7,
-			errStr: "expected node ",
+			req:    &getEntryAndProofRequest7,
+			errStr: "expected one leaf",
 		},
 		{
-			name: "commit fails",
+			name: "ok",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(nil, nil)
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().Commit(gomock.Any()).Return(errors.New("COMMIT"))
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{leaf1}, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(nil)
 				tx.EXPECT().Close().Return(nil)
 			},
-			req:    &getInclusionProofByHashRequest7,
-			errStr: "COMMIT",
+			req: &getEntryAndProofRequest7,
+			wantResp: &trillian.GetEntryAndProofResponse{
+				SignedLogRoot: signedRoot1,
+				Proof: &trillian.Proof{
+					LeafIndex: 2,
+					Hashes: [][]byte{
+						[]byte("nodehash0"),
+						[]byte("nodehash1"),
+						[]byte("nodehash2"),
+					},
+				},
+				Leaf: leaf1,
+			},
 		},
 		{
-			name: "log root fails",
+			name: "one based",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(nil, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(&trillian.SignedLogRoot{}, errors.New("SLR"))
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{newTestLeaf([]byte("value2"), []byte("extra"), 2)}, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(nil)
 				tx.EXPECT().Close().Return(nil)
-				tx.EXPECT().IsOpen().AnyTimes().Return(false)
 			},
-			req:    &getInclusionProofByHashRequest7,
-			errStr: "SLR",
+			req: &trillian.GetEntryAndProofRequest{LogId: logID1, TreeSize: 7, LeafIndex: 3, IndexBase: trillian.IndexBase_INDEX_BASE_ONE},
+			wantResp: &trillian.GetEntryAndProofResponse{
+				SignedLogRoot: signedRoot1,
+				Proof: &trillian.Proof{
+					LeafIndex: 3,
+					Hashes: [][]byte{
+						[]byte("nodehash0"),
+						[]byte("nodehash1"),
+						[]byte("nodehash2"),
+					},
+				},
+				Leaf: newTestLeaf([]byte("value2"), []byte("extra"), 3),
+			},
 		},
 		{
-			name: "bad log root",
+			name: "skew no proof",
 			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
 				tx := storage.NewMockLogTreeTX(c)
 				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(nil, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(corruptLogRoot, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(nil)
 				tx.EXPECT().Close().Return(nil)
-				tx.EXPECT().IsOpen().AnyTimes().Return(false)
 			},
-			req:    &getInclusionProofByHashRequest7,
-			errStr: "not read current log root",
+			req: &getEntryAndProofRequest17_11,
+			wantResp: &trillian.GetEntryAndProofResponse{
+				SignedLogRoot: signedRoot1,
+			},
 		},
 		{
-			name: "leaf hash too short",
-			req: &trillian.GetInclusionProofByHashRequest{
-				LeafHash: []byte("too-short-to-be-a-hash"),
-				LogId:    logID1,
-				TreeSize: 7,
+			name: "skew smaller tree",
+			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
+				tx := storage.NewMockLogTreeTX(c)
+				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil) // nolint: megacheck
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{leaf1}, nil)
+				tx.EXPECT().Commit(gomock.Any()).Return(nil)
+				tx.EXPECT().Close().Return(nil)
 			},
-			errStr: "GetInclusionProofByHashRequest.LeafHash: 22 bytes, want 32",
+			req: &getEntryAndProofRequest17_2,
+			wantResp: &trillian.GetEntryAndProofResponse{
+				SignedLogRoot: signedRoot1,
+				Proof: &trillian.Proof{
+					LeafIndex: 2,
+					Hashes: [][]byte{
+						[]byte("nodehash0"),
+						[]byte("nodehash1"),
+						[]byte("nodehash2"),
+					},
+				},
+				Leaf: leaf1,
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			fakeStorage := storage.NewMockLogStorage(ctrl)
+			tc.setupStorage(ctrl, fakeStorage)
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: leaf0Request.LogId, numSnapshots: 1, snapErr: tc.snapErr, treeErr: tc.treeErr}),
+				LogStorage:   fakeStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			resp, err := server.GetEntryAndProof(context.Background(), tc.req)
+			if len(tc.errStr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tc.errStr) {
+					t.Errorf("GetEntryAndProof(%v)=%v, %v want nil, err containing: %s", tc.req, resp, err, tc.errStr)
+				}
+				return
+			}
+
+			if err != nil || !proto.Equal(tc.wantResp, resp) {
+				t.Errorf("GetEntryAndProof(%v)=%v, %v, want: %v, nil", tc.req, resp, err, tc.wantResp)
+			}
+		})
+	}
+}
+
+func TestGetSequencedLeafCountBeginTXFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	test := newParameterizedTest(ctrl, "GetSequencedLeafCount", readOnly, nopStorage,
+		func(t *storage.MockLogTreeTX) {
+			t.EXPECT().Close().Return(nil)
+		},
+		func(s *TrillianLogRPCServer) error {
+			_, err := s.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
+			return err
+		})
+
+	test.executeBeginFailsTest(t, logID1)
+}
+
+func TestGetSequencedLeafCountStorageFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	test := newParameterizedTest(ctrl, "GetSequencedLeafCount", readOnly, nopStorage,
+		func(t *storage.MockLogTreeTX) {
+			t.EXPECT().GetSequencedLeafCount(gomock.Any()).Return(int64(0), errors.New("STORAGE"))
+		},
+		func(s *TrillianLogRPCServer) error {
+			_, err := s.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
+			return err
+		})
+
+	test.executeStorageFailureTest(t, logID1)
+}
+
+func TestGetSequencedLeafCountCommitFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	test := newParameterizedTest(ctrl, "GetSequencedLeafCount", readOnly, nopStorage,
+		func(t *storage.MockLogTreeTX) {
+			t.EXPECT().GetSequencedLeafCount(gomock.Any()).Return(int64(27), nil)
 		},
+		func(s *TrillianLogRPCServer) error {
+			_, err := s.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
+			return err
+		})
+
+	test.executeCommitFailsTest(t, logID1)
+}
+
+func TestGetSequencedLeafCount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeStorage := storage.NewMockLogStorage(ctrl)
+	mockTX := storage.NewMockLogTreeTX(ctrl)
+	fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(mockTX, nil)
+
+	mockTX.EXPECT().GetSequencedLeafCount(gomock.Any()).Return(int64(268), nil)
+	mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+	mockTX.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+		LogStorage:   fakeStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+	response, err := server.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
+	if err != nil {
+		t.Fatalf("expected no error getting leaf count but got: %v", err)
+	}
+
+	if got, want := response.LeafCount, int64(268); got != want {
+		t.Fatalf("expected leaf count: %d but got: %d", want, got)
+	}
+}
+
+func TestGetSignedLogRootHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for _, test := range []struct {
+		desc      string
+		limit     int32
+		wantLimit int
+	}{
+		{desc: "default", limit: 0, wantLimit: maxSignedLogRootHistory},
+		{desc: "limit", limit: 2, wantLimit: 2},
+		{desc: "overMax", limit: maxSignedLogRootHistory + 1, wantLimit: maxSignedLogRootHistory},
 	} {
-		t.Run(tc.name, func(t *testing.T) {
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
+		t.Run(test.desc, func(t *testing.T) {
 			fakeStorage := storage.NewMockLogStorage(ctrl)
-			if tc.setupStorage != nil {
-				tc.setupStorage(ctrl, fakeStorage)
-			}
+			mockTX := storage.NewMockLogTreeTX(ctrl)
+			fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(mockTX, nil)
+			roots := []*trillian.SignedLogRoot{signedRoot1, signedRoot1}
+			mockTX.EXPECT().SignedLogRootHistory(gomock.Any(), test.wantLimit).Return(roots, nil)
+			mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
+			mockTX.EXPECT().Close().Return(nil)
+
 			registry := extension.Registry{
-				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: leaf0Request.LogId, numSnapshots: 1, snapErr: tc.snapErr, treeErr: tc.treeErr}),
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
 				LogStorage:   fakeStorage,
 			}
 			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
-			resp, err := server.GetInclusionProofByHash(context.Background(), tc.req)
-			if len(tc.errStr) > 0 {
-				if err == nil || !strings.Contains(err.Error(), tc.errStr) {
-					t.Errorf("GetInclusionProofByHash(%v)=(%v, %v), want (nil, err containing %q)", tc.req, resp, err, tc.errStr)
-				}
-				return
-			}
 
-			if err != nil || !proto.Equal(tc.wantResp, resp) {
-				t.Errorf("GetInclusionProofByHash(%v)=(%v, %v), want (%v, nil)", tc.req, resp, err, tc.wantResp)
+			resp, err := server.GetSignedLogRootHistory(context.Background(), &trillian.GetSignedLogRootHistoryRequest{LogId: logID1, Limit: test.limit})
+			if err != nil {
+				t.Fatalf("GetSignedLogRootHistory(): %v", err)
+			}
+			if want := (&trillian.GetSignedLogRootHistoryResponse{SignedLogRoots: roots}); !proto.Equal(resp, want) {
+				t.Errorf("GetSignedLogRootHistory() = %v, want %v", resp, want)
 			}
 		})
 	}
 }
 
-func TestGetProofByHash(t *testing.T) {
-	ctx := context.Background()
-	for _, tc := range []struct {
-		desc            string
-		wantCode        codes.Code
-		leavesByHashVal []*trillian.LogLeaf
+func TestGetSignedLogRootHistory_InvalidLimit(t *testing.T) {
+	server := NewTrillianLogRPCServer(extension.Registry{}, fakeTimeSource)
+	_, err := server.GetSignedLogRootHistory(context.Background(), &trillian.GetSignedLogRootHistoryRequest{LogId: logID1, Limit: -1})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("GetSignedLogRootHistory() returned err = %v, want code %s", err, want)
+	}
+}
+
+func TestPredictRoot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	hasher := rfc6962.DefaultHasher
+	fact := compact.RangeFactory{Hash: hasher.HashChildren}
+	leafHashes := func(begin, end int) [][]byte {
+		var hashes [][]byte
+		for i := begin; i < end; i++ {
+			hashes = append(hashes, hasher.HashLeaf([]byte(fmt.Sprintf("leaf-%d", i))))
+		}
+		return hashes
+	}
+	rootHash := func(hashes [][]byte) []byte {
+		if len(hashes) == 0 {
+			return hasher.EmptyRoot()
+		}
+		cr := fact.NewEmptyRange(0)
+		for _, hash := range hashes {
+			if err := cr.Append(hash, nil); err != nil {
+				t.Fatalf("Append(): %v", err)
+			}
+		}
+		hash, err := cr.GetRootHash(nil)
+		if err != nil {
+			t.Fatalf("GetRootHash(): %v", err)
+		}
+		return hash
+	}
+
+	for _, test := range []struct {
+		desc           string
+		size, appended int
 	}{
-		{desc: "OK", leavesByHashVal: []*trillian.LogLeaf{{LeafIndex: 2}}},
-		{desc: "NotFoundTreeSize", wantCode: codes.NotFound, leavesByHashVal: []*trillian.LogLeaf{{LeafIndex: 7}}},
+		{desc: "empty", size: 0, appended: 0},
+		{desc: "emptyLog", size: 0, appended: 3},
+		{desc: "nothingAppended", size: 5, appended: 0},
+		{desc: "append", size: 5, appended: 3},
+		{desc: "appendToPerfectTree", size: 8, appended: 9},
 	} {
-		t.Run(tc.desc, func(t *testing.T) {
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
+		t.Run(test.desc, func(t *testing.T) {
+			existing := leafHashes(0, test.size)
+			root := &types.LogRootV1{TreeSize: uint64(test.size), RootHash: rootHash(existing), Revision: 3}
+			logRoot, err := root.MarshalBinary()
+			if err != nil {
+				t.Fatalf("MarshalBinary(): %v", err)
+			}
+			slr := &trillian.SignedLogRoot{LogRoot: logRoot}
 
 			fakeStorage := storage.NewMockLogStorage(ctrl)
 			mockTX := storage.NewMockLogTreeTX(ctrl)
 			fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(mockTX, nil)
-
-			mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-			mockTX.EXPECT().GetLeavesByHash(gomock.Any(), [][]byte{leafHash1}, false).Return(tc.leavesByHashVal, nil)
-			mockTX.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil).AnyTimes()
-			mockTX.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
-				{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
-				{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
-				{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil).AnyTimes()
+			mockTX.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(slr, nil)
+			if test.size > 0 {
+				// The stored nodes are those of the compact range of the log.
+				cr := fact.NewEmptyRange(0)
+				for _, hash := range existing {
+					if err := cr.Append(hash, nil); err != nil {
+						t.Fatalf("Append(): %v", err)
+					}
+				}
+				var nodes []tree.Node
+				for i, id := range compact.RangeNodes(0, uint64(test.size)) {
+					nodeID, err := tree.NewNodeIDForTreeCoords(int64(id.Level), int64(id.Index), 64)
+					if err != nil {
+						t.Fatalf("NewNodeIDForTreeCoords(): %v", err)
+					}
+					nodes = append(nodes, tree.Node{NodeID: nodeID, Hash: cr.Hashes()[i]})
+				}
+				mockTX.EXPECT().GetMerkleNodes(gomock.Any(), int64(3), gomock.Any()).Return(nodes, nil)
+			}
 			mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
 			mockTX.EXPECT().Close().Return(nil)
 
 			registry := extension.Registry{
-				AdminStorage: fakeAdminStorage(ctrl, storageParams{
-					treeID:       logID1,
-					numSnapshots: 1,
-				}),
-				LogStorage: fakeStorage,
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+				LogStorage:   fakeStorage,
 			}
 			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
 
-			proofResponse, err := server.GetInclusionProofByHash(ctx,
-				&trillian.GetInclusionProofByHashRequest{
-					LogId:    logID1,
-					TreeSize: 7,
-					LeafHash: leafHash1,
-				})
-			if got, want := status.Code(err), tc.wantCode; got != want {
-				t.Fatalf("GetInclusionProofByHash(): %v, want %v", err, want)
-			}
+			appended := leafHashes(test.size, test.size+test.appended)
+			resp, err := server.PredictRoot(context.Background(), &trillian.PredictRootRequest{LogId: logID1, LeafHashes: appended})
 			if err != nil {
-				return
+				t.Fatalf("PredictRoot(): %v", err)
 			}
-
-			if proofResponse == nil {
-				t.Fatalf("server response was not successful: %v", proofResponse)
+			want := &trillian.PredictRootResponse{
+				SignedLogRoot: slr,
+				TreeSize:      int64(test.size + test.appended),
+				RootHash:      rootHash(append(existing, appended...)),
 			}
-
-			expectedProof := &trillian.Proof{
-				LeafIndex: 2,
-				Hashes: [][]byte{
-					[]byte("nodehash0"),
-					[]byte("nodehash1"),
-					[]byte("nodehash2"),
-				},
+			if !proto.Equal(resp, want) {
+				t.Errorf("PredictRoot() = %v, want %v", resp, want)
 			}
+		})
+	}
+}
 
-			if !proto.Equal(proofResponse.Proof[0], expectedProof) {
-				t.Fatalf("expected proof: %v but got: %v", proto.CompactTextString(expectedProof), proto.CompactTextString(proofResponse.Proof[0]))
+func TestPredictRoot_InvalidHashes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	for _, test := range []struct {
+		desc   string
+		hashes [][]byte
+	}{
+		{desc: "shortHash", hashes: [][]byte{[]byte("short")}},
+		{desc: "tooMany", hashes: make([][]byte, maxPredictRootLeaves+1)},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			_, err := server.PredictRoot(context.Background(), &trillian.PredictRootRequest{LogId: logID1, LeafHashes: test.hashes})
+			if got, want := status.Code(err), codes.InvalidArgument; got != want {
+				t.Errorf("PredictRoot() returned err = %v, want code %s", err, want)
 			}
 		})
 	}
 }
 
-func TestGetProofByIndex(t *testing.T) {
-	for _, tc := range []struct {
-		name         string
-		setupStorage func(*gomock.Controller, *storage.MockLogStorage)
-		snapErr      error
-		treeErr      error
-		req          *trillian.GetInclusionProofRequest
-		errStr       string
-		wantResp     *trillian.GetInclusionProofResponse
+func TestVerifyInclusion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	hasher := rfc6962.DefaultHasher
+	h0, h1, h2 := hasher.HashLeaf([]byte("leaf-0")), hasher.HashLeaf([]byte("leaf-1")), hasher.HashLeaf([]byte("leaf-2"))
+	root := hasher.HashChildren(hasher.HashChildren(h0, h1), h2)
+
+	for _, test := range []struct {
+		desc      string
+		leafHash  []byte
+		leafIndex int64
+		proof     [][]byte
+		rootHash  []byte
+		want      *trillian.VerifyInclusionResponse
 	}{
 		{
-			name: "admin snapshot fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-			},
-			req:     &getInclusionProofByIndexRequest25,
-			snapErr: errors.New("admin snap"),
-			errStr:  "admin snap",
+			desc:      "valid",
+			leafHash:  h1,
+			leafIndex: 1,
+			proof:     [][]byte{h0, h2},
+			rootHash:  root,
+			want:      &trillian.VerifyInclusionResponse{Valid: true, ComputedRootHash: root},
 		},
 		{
-			name: "get tree fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-			},
-			req:     &getInclusionProofByIndexRequest25,
-			treeErr: errors.New("tree error"),
-			errStr:  "tree error",
+			desc:      "wrongRoot",
+			leafHash:  h1,
+			leafIndex: 1,
+			proof:     [][]byte{h0, h2},
+			rootHash:  h2,
+			want:      &trillian.VerifyInclusionResponse{ComputedRootHash: root, Reason: "computed root hash doesn't match root_hash"},
 		},
 		{
-			name: "begin fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(nil, errors.New("TX"))
+			desc:      "wrongLeaf",
+			leafHash:  h2,
+			leafIndex: 1,
+			proof:     [][]byte{h0, h2},
+			rootHash:  root,
+			want: &trillian.VerifyInclusionResponse{
+				ComputedRootHash: hasher.HashChildren(hasher.HashChildren(h0, h2), h2),
+				Reason:           "computed root hash doesn't match root_hash",
 			},
-			req:    &getInclusionProofByIndexRequest25,
-			errStr: "TX",
 		},
 		{
-			name: "not initialized",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, storage.ErrTreeNeedsInit)
-				tx.EXPECT().Close().Return(nil)
-			},
-			req:    &getInclusionProofByIndexRequest25,
-			errStr: "tree needs init",
+			desc:      "wrongProofSize",
+			leafHash:  h1,
+			leafIndex: 1,
+			proof:     [][]byte{h0},
+			rootHash:  root,
+			want:      &trillian.VerifyInclusionResponse{Reason: "wrong proof size 1, want 2"},
 		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			got, err := server.VerifyInclusion(context.Background(), &trillian.VerifyInclusionRequest{
+				LogId:     logID1,
+				LeafHash:  test.leafHash,
+				LeafIndex: test.leafIndex,
+				TreeSize:  3,
+				Proof:     test.proof,
+				RootHash:  test.rootHash,
+			})
+			if err != nil {
+				t.Fatalf("VerifyInclusion(): %v", err)
+			}
+			if !proto.Equal(got, test.want) {
+				t.Errorf("VerifyInclusion() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifyInclusion_BeyondTreeSize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	registry := extension.Registry{
+		AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	_, err := server.VerifyInclusion(context.Background(), &trillian.VerifyInclusionRequest{LogId: logID1, LeafIndex: 3, TreeSize: 3})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("VerifyInclusion() returned err = %v, want code %s", err, want)
+	}
+}
+
+func TestTailLeaves(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	signedRoot := func(size uint64) *trillian.SignedLogRoot {
+		t.Helper()
+		slr, err := fixedSigner.SignLogRoot(&types.LogRootV1{TreeSize: size, RootHash: []byte("A NICE HASH")})
+		if err != nil {
+			t.Fatalf("SignLogRoot(): %v", err)
+		}
+		return slr
+	}
+	root2, root4 := signedRoot(2), signedRoot(4)
+
+	// The log grows from 2 to 4 leaves after the stream catches up, and then
+	// stays at that size.
+	states := []struct {
+		root   *trillian.SignedLogRoot
+		start  int64
+		leaves []*trillian.LogLeaf
+	}{
+		{root: root2, start: 1, leaves: []*trillian.LogLeaf{leaf1}},
+		{root: root2},
+		{root: root4, start: 2, leaves: []*trillian.LogLeaf{leaf2, leaf3}},
+		{root: root4},
+	}
+	fakeStorage := storage.NewMockLogStorage(ctrl)
+	snapshots := 0
+	fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).MinTimes(len(states)).DoAndReturn(func(context.Context, *trillian.Tree) (storage.ReadOnlyLogTreeTX, error) {
+		state := states[len(states)-1]
+		if snapshots < len(states) {
+			state = states[snapshots]
+		}
+		snapshots++
+		tx := storage.NewMockLogTreeTX(ctrl)
+		tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(state.root, nil)
+		if len(state.leaves) > 0 {
+			tx.EXPECT().GetLeavesByRange(gomock.Any(), state.start, int64(len(state.leaves))).Return(state.leaves, nil)
+		}
+		tx.EXPECT().Commit(gomock.Any()).Return(nil)
+		tx.EXPECT().Close().Return(nil)
+		return tx, nil
+	})
+
+	registry := extension.Registry{
+		AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+		LogStorage:   fakeStorage,
+	}
+	server := NewTrillianLogRPCServer(registry, clock.System)
+	server.TailPollInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &fakeTailLeavesServer{ctx: ctx, cancelAfter: 3, cancel: cancel}
+	err := server.TailLeaves(&trillian.TailLeavesRequest{LogId: logID1, StartIndex: 1}, stream)
+	if got, want := status.Code(err), codes.Canceled; got != want {
+		t.Errorf("TailLeaves() returned err = %v, want code %s", err, want)
+	}
+	want := []*trillian.TailLeavesResponse{
+		{Leaves: []*trillian.LogLeaf{leaf1}, SignedLogRoot: root2},
+		{Leaves: []*trillian.LogLeaf{leaf2, leaf3}, SignedLogRoot: root4},
+	}
+	if len(stream.sent) != len(want) {
+		t.Fatalf("TailLeaves() sent %d responses, want %d", len(stream.sent), len(want))
+	}
+	for i := range want {
+		if !proto.Equal(stream.sent[i], want[i]) {
+			t.Errorf("TailLeaves() response %d = %v, want %v", i, stream.sent[i], want[i])
+		}
+	}
+}
+
+func TestTailLeaves_InvalidStartIndex(t *testing.T) {
+	server := NewTrillianLogRPCServer(extension.Registry{}, fakeTimeSource)
+	stream := &fakeTailLeavesServer{ctx: context.Background()}
+	err := server.TailLeaves(&trillian.TailLeavesRequest{LogId: logID1, StartIndex: -1}, stream)
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("TailLeaves() returned err = %v, want code %s", err, want)
+	}
+}
+
+// fakeTailLeavesServer records the responses sent to it, and cancels the
+// stream once it has received cancelAfter leaves.
+type fakeTailLeavesServer struct {
+	trillian.TrillianLog_TailLeavesServer
+	ctx         context.Context
+	cancel      func()
+	cancelAfter int
+	leaves      int
+	sent        []*trillian.TailLeavesResponse
+}
+
+func (s *fakeTailLeavesServer) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeTailLeavesServer) Send(resp *trillian.TailLeavesResponse) error {
+	s.sent = append(s.sent, resp)
+	if s.leaves += len(resp.Leaves); s.leaves >= s.cancelAfter {
+		s.cancel()
+	}
+	return nil
+}
+
+func TestGetHistoricalInclusionProof(t *testing.T) {
+	req := &trillian.GetHistoricalInclusionProofRequest{LogId: logID1, TreeSize: 7, LeafIndex: 2}
+	for _, test := range []struct {
+		desc     string
+		req      *trillian.GetHistoricalInclusionProofRequest
+		noSnap   bool
+		root     *trillian.SignedLogRoot
+		wantCode codes.Code
+	}{
+		{desc: "ok", req: req, root: signedRoot1},
+		{desc: "notRetained", req: req, wantCode: codes.NotFound},
 		{
-			name: "get nodes fails",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{}, errors.New("STORAGE"))
+			desc:     "indexTooLarge",
+			req:      &trillian.GetHistoricalInclusionProofRequest{LogId: logID1, TreeSize: 7, LeafIndex: 7},
+			noSnap:   true,
+			wantCode: codes.InvalidArgument,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			fakeStorage := storage.NewMockLogStorage(ctrl)
+			if !test.noSnap {
+				tx := storage.NewMockLogTreeTX(ctrl)
+				fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				tx.EXPECT().SignedLogRootAtSize(gomock.Any(), test.req.TreeSize).Return(test.root, nil)
+				if test.root != nil {
+					tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+					tx.EXPECT().ReadRevision(gomock.Any()).Return(revision1, nil)
+					tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+						{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+						{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+						{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+					tx.EXPECT().Commit(gomock.Any()).Return(nil)
+				}
 				tx.EXPECT().Close().Return(nil)
-			},
-			req:    &getInclusionProofByIndexRequest7,
-			errStr: "STORAGE",
+			}
+
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+				LogStorage:   fakeStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+			resp, err := server.GetHistoricalInclusionProof(context.Background(), test.req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("GetHistoricalInclusionProof() returned err = %v, want code %s", err, test.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			want := &trillian.GetHistoricalInclusionProofResponse{
+				Proof: &trillian.Proof{
+					LeafIndex: 2,
+					Hashes:    [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")},
+				},
+				SignedLogRoot: test.root,
+			}
+			if !proto.Equal(resp, want) {
+				t.Errorf("GetHistoricalInclusionProof() = %v, want %v", resp, want)
+			}
+		})
+	}
+}
+
+func TestGetProofAtRevision(t *testing.T) {
+	inclusion := &trillian.GetProofAtRevisionRequest{LogId: logID1, Revision: 3, TreeSize: 7, LeafIndex: 2}
+	consistency := &trillian.GetProofAtRevisionRequest{LogId: logID1, Revision: 3, TreeSize: 7, FirstTreeSize: 4}
+	for _, test := range []struct {
+		desc      string
+		req       *trillian.GetProofAtRevisionRequest
+		disabled  bool
+		callers   []string
+		caller    string
+		noTree    bool
+		noSnap    bool
+		noFetch   bool
+		compacted int64
+		nodeIDs   []tree.NodeID
+		wantProof *trillian.Proof
+		wantCode  codes.Code
+	}{
+		{
+			desc:      "inclusion",
+			req:       inclusion,
+			nodeIDs:   nodeIdsInclusionSize7Index2,
+			wantProof: &trillian.Proof{LeafIndex: 2, Hashes: [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")}},
+		},
+		{
+			desc:      "compactedBefore",
+			req:       inclusion,
+			compacted: 3,
+			nodeIDs:   nodeIdsInclusionSize7Index2,
+			wantProof: &trillian.Proof{LeafIndex: 2, Hashes: [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")}},
+		},
+		{
+			desc:      "compacted",
+			req:       inclusion,
+			compacted: 4,
+			noFetch:   true,
+			wantCode:  codes.FailedPrecondition,
 		},
 		{
-			name: "too few nodes",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				// The server expects three nodes from storage but we return only two
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeRevision: 3}, {NodeRevision: 2}}, nil)
-				tx.EXPECT().Close().Return(nil)
-			},
-			req:    &getInclusionProofByIndexRequest7,
-			errStr: "expected 3 nodes",
+			desc:      "consistency",
+			req:       consistency,
+			nodeIDs:   nodeIdsConsistencySize4ToSize7,
+			wantProof: &trillian.Proof{Hashes: [][]byte{[]byte("nodehash0")}},
 		},
 		{
-			name: "wrong node",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				// We set this up so one of the returned nodes has the wrong ID
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3}, {NodeID: stestonly.MustCreateNodeIDForTreeCoords(4, 5, 64), NodeRevision: 2}, {NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3}}, nil)
-				tx.EXPECT().Close().Return(nil)
-			},
-			req:    &getInclusionProofByIndexRequest7,
-			errStr: "expected node ",
+			desc:     "disabled",
+			req:      inclusion,
+			disabled: true,
+			noTree:   true,
+			noSnap:   true,
+			wantCode: codes.PermissionDenied,
 		},
 		{
-			name: "commit fails",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3}, {NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2}, {NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3}}, nil)
-				tx.EXPECT().Commit(gomock.Any()).Return(errors.New("COMMIT"))
-				tx.EXPECT().Close().Return(nil)
-			},
-			req:    &getInclusionProofByIndexRequest7,
-			errStr: "COMMIT",
+			desc:      "allowedCaller",
+			req:       inclusion,
+			callers:   []string{"ops", "sre"},
+			caller:    "sre",
+			nodeIDs:   nodeIdsInclusionSize7Index2,
+			wantProof: &trillian.Proof{LeafIndex: 2, Hashes: [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")}},
 		},
 		{
-			name: "log root fails",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(&trillian.SignedLogRoot{}, errors.New("SLR"))
-				tx.EXPECT().Close().Return(nil)
-				tx.EXPECT().IsOpen().AnyTimes().Return(false)
-			},
-			req:    &getInclusionProofByIndexRequest7,
-			errStr: "SLR",
+			desc:     "deniedCaller",
+			req:      inclusion,
+			callers:  []string{"ops", "sre"},
+			caller:   "tenant",
+			noTree:   true,
+			noSnap:   true,
+			wantCode: codes.PermissionDenied,
 		},
 		{
-			name: "bad log root",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(corruptLogRoot, nil)
-				tx.EXPECT().Close().Return(nil)
-				tx.EXPECT().IsOpen().AnyTimes().Return(false)
-			},
-			req:    &getInclusionProofByIndexRequest7,
-			errStr: "not read current log root",
+			desc:     "noCaller",
+			req:      inclusion,
+			callers:  []string{"ops"},
+			noTree:   true,
+			noSnap:   true,
+			wantCode: codes.PermissionDenied,
 		},
 		{
-			name: "ok",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
-					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
-					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
-					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
-				tx.EXPECT().Commit(gomock.Any()).Return(nil)
-				tx.EXPECT().Close().Return(nil)
-			},
-			req: &getInclusionProofByIndexRequest7,
-			wantResp: &trillian.GetInclusionProofResponse{
-				SignedLogRoot: signedRoot1,
-				Proof: &trillian.Proof{
-					LeafIndex: 2,
-					Hashes: [][]byte{
-						[]byte("nodehash0"),
-						[]byte("nodehash1"),
-						[]byte("nodehash2"),
-					},
-				},
-			},
+			desc:     "laterRevision",
+			req:      &trillian.GetProofAtRevisionRequest{LogId: logID1, Revision: revision1 + 1, TreeSize: 7, LeafIndex: 2},
+			noFetch:  true,
+			wantCode: codes.InvalidArgument,
 		},
 		{
-			name: "skew beyond sth",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().Close().Return(nil)
-			},
-			req: &getInclusionProofByIndexRequest25,
-			wantResp: &trillian.GetInclusionProofResponse{
-				SignedLogRoot: signedRoot1,
-			},
+			desc:     "indexTooLarge",
+			req:      &trillian.GetProofAtRevisionRequest{LogId: logID1, Revision: 3, TreeSize: 7, LeafIndex: 7},
+			noSnap:   true,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc:     "firstSizeTooLarge",
+			req:      &trillian.GetProofAtRevisionRequest{LogId: logID1, Revision: 3, TreeSize: 7, FirstTreeSize: 8},
+			noTree:   true,
+			noSnap:   true,
+			wantCode: codes.InvalidArgument,
 		},
 	} {
-		t.Run(tc.name, func(t *testing.T) {
+		t.Run(test.desc, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
+
 			fakeStorage := storage.NewMockLogStorage(ctrl)
-			tc.setupStorage(ctrl, fakeStorage)
+			numSnapshots := 1
+			if test.noTree {
+				numSnapshots = 0
+			}
+			if !test.noSnap {
+				tx := storage.NewMockLogTreeTX(ctrl)
+				if test.compacted > 0 {
+					fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(compactedTX{tx, test.compacted}, nil)
+				} else {
+					fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+				}
+				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(revision1, nil)
+				if !test.noFetch {
+					var nodes []tree.Node
+					for i, id := range test.nodeIDs {
+						nodes = append(nodes, tree.Node{NodeID: id, NodeRevision: 3, Hash: []byte(fmt.Sprintf("nodehash%d", i))})
+					}
+					// The nodes are read at the requested revision, not that
+					// of the latest root.
+					tx.EXPECT().GetMerkleNodes(gomock.Any(), test.req.Revision, test.nodeIDs).Return(nodes, nil)
+					tx.EXPECT().Commit(gomock.Any()).Return(nil)
+				}
+				tx.EXPECT().Close().Return(nil)
+			}
+
 			registry := extension.Registry{
-				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: leaf0Request.LogId, numSnapshots: 1, snapErr: tc.snapErr, treeErr: tc.treeErr}),
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: numSnapshots}),
 				LogStorage:   fakeStorage,
 			}
 			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
-			resp, err := server.GetInclusionProof(context.Background(), tc.req)
-			if len(tc.errStr) > 0 {
-				if err == nil || !strings.Contains(err.Error(), tc.errStr) {
-					t.Errorf("GetInclusionProofByHash(%v)=%v, %v want nil, err containing: %s", tc.req, resp, err, tc.errStr)
-				}
+			server.RevisionProofs = !test.disabled
+			server.RevisionProofCallers = test.callers
+
+			ctx := context.Background()
+			if test.caller != "" {
+				ctx = namespace.NewContext(ctx, test.caller)
+			}
+			resp, err := server.GetProofAtRevision(ctx, test.req)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("GetProofAtRevision() returned err = %v, want code %s", err, test.wantCode)
+			}
+			if err != nil {
 				return
 			}
-
-			if err != nil || !proto.Equal(tc.wantResp, resp) {
-				t.Errorf("GetInclusionProofByHash(%v)=%v, %v, want: %v, nil", tc.req, resp, err, tc.wantResp)
+			want := &trillian.GetProofAtRevisionResponse{Proof: test.wantProof, SignedLogRoot: signedRoot1}
+			if !proto.Equal(resp, want) {
+				t.Errorf("GetProofAtRevision() = %v, want %v", resp, want)
 			}
 		})
 	}
 }
 
-func TestGetEntryAndProof(t *testing.T) {
-	for _, tc := range []struct {
-		name         string
-		setupStorage func(*gomock.Controller, *storage.MockLogStorage)
-		snapErr      error
-		treeErr      error
-		req          *trillian.GetEntryAndProofRequest
-		errStr       string
-		wantResp     *trillian.GetEntryAndProofResponse
+// compactedTX is a transaction whose storage was compacted below a revision.
+type compactedTX struct {
+	*storage.MockLogTreeTX
+	compacted int64
+}
+
+func (t compactedTX) CompactedRevision(ctx context.Context) (int64, error) {
+	return t.compacted, nil
+}
+
+type consistProofTest struct {
+	req         *trillian.GetConsistencyProofRequest
+	errStr      string
+	wantHashes  [][]byte
+	noSnap      bool
+	snapErr     error
+	root        *trillian.SignedLogRoot
+	noRoot      bool
+	rootErr     error
+	noRev       bool
+	nodeIDs     []tree.NodeID
+	nodes       []tree.Node
+	getNodesErr error
+	noCommit    bool
+	commitErr   error
+}
+
+func TestGetInclusionProof_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fakeStorage := storage.NewMockLogStorage(ctrl)
+	tx := storage.NewMockLogTreeTX(ctrl)
+	fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+	tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
+	tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
+	tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
+		{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+		{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+		{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
+	tx.EXPECT().Commit(gomock.Any()).Return(nil)
+	tx.EXPECT().Close().Return(nil)
+
+	registry := extension.Registry{
+		AdminStorage:  fakeAdminStorage(ctrl, storageParams{treeID: leaf0Request.LogId, numSnapshots: 1}),
+		LogStorage:    fakeStorage,
+		MetricFactory: monitoring.InertMetricFactory{},
+	}
+	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	if _, err := server.GetInclusionProof(context.Background(), &getInclusionProofByIndexRequest7); err != nil {
+		t.Fatalf("GetInclusionProof() = %v", err)
+	}
+
+	label := strconv.FormatInt(tree1.TreeId, 10)
+	for _, test := range []struct {
+		name    string
+		h       monitoring.Histogram
+		wantSum float64
+	}{
+		{name: "proofNodes", h: server.proofNodes, wantSum: 3},
+		{name: "proofNodeReads", h: server.proofNodeReads, wantSum: 3},
+		{name: "proofBytes", h: server.proofBytes, wantSum: 27},
+	} {
+		if count, sum := test.h.Info(label); count != 1 || sum != test.wantSum {
+			t.Errorf("%s.Info(%q) = (%v, %v), want (1, %v)", test.name, label, count, sum, test.wantSum)
+		}
+	}
+}
+
+func TestLeafIndexOffset(t *testing.T) {
+	const offset = 1000
+	offsetTree := addTreeID(stestonly.LogTree, logID1)
+	offsetTree.LeafIndexOffset = offset
+	// Leaves as stored, with 0-based indices.
+	stored1 := newTestLeaf([]byte("value"), []byte("extra"), 1)
+	stored2 := newTestLeaf([]byte("value2"), []byte("extra"), 2)
+	inclusionNodes := []tree.Node{
+		{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
+		{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
+		{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")},
+	}
+	// A proof for the leaf at 0-based index 1 of a tree of size 3.
+	vh0, vh1, vh2 := rfc6962.DefaultHasher.HashLeaf([]byte("leaf-0")), rfc6962.DefaultHasher.HashLeaf([]byte("leaf-1")), rfc6962.DefaultHasher.HashLeaf([]byte("leaf-2"))
+	vroot := rfc6962.DefaultHasher.HashChildren(rfc6962.DefaultHasher.HashChildren(vh0, vh1), vh2)
+	wantProof := &trillian.Proof{
+		LeafIndex: offset + 2,
+		Hashes:    [][]byte{[]byte("nodehash0"), []byte("nodehash1"), []byte("nodehash2")},
+	}
+
+	for _, test := range []struct {
+		desc string
+		// setup sets the expected storage calls, if the request is valid.
+		setup    func(tx *storage.MockLogTreeTX)
+		call     func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error)
+		want     proto.Message
+		wantCode codes.Code
 	}{
 		{
-			name: "admin snapshot fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-			},
-			req:     &getEntryAndProofRequest17,
-			snapErr: errors.New("admin snap"),
-			errStr:  "admin snap",
-		},
-		{
-			name: "get tree fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-			},
-			req:     &getEntryAndProofRequest17,
-			treeErr: errors.New("tree error"),
-			errStr:  "tree error",
-		},
-		{
-			name: "begin fails",
-			setupStorage: func(_ *gomock.Controller, s *storage.MockLogStorage) {
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(nil, errors.New("TX"))
-			},
-			req:    &getEntryAndProofRequest17,
-			errStr: "TX",
-		},
-		{
-			name: "not initialized",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, storage.ErrTreeNeedsInit)
-				tx.EXPECT().Close().Return(nil)
-			},
-			req:    &getEntryAndProofRequest17,
-			errStr: "tree needs init",
-		},
-		{
-			name: "storage error",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
-					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
-					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
-					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
-				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return(nil, errors.New("STORAGE"))
-				tx.EXPECT().Close().Return(nil)
-			},
-			req:    &getEntryAndProofRequest7,
-			errStr: "STORAGE",
-		},
-		{
-			name: "commit fails",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+			desc: "GetInclusionProof",
+			setup: func(tx *storage.MockLogTreeTX) {
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
-					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
-					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
-					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
-				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{leaf1}, nil)
-				tx.EXPECT().Commit(gomock.Any()).Return(errors.New("COMMIT"))
-				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(revision1, nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return(inclusionNodes, nil)
 			},
-			req:    &getEntryAndProofRequest7,
-			errStr: "COMMIT",
-		},
-		{
-			name: "log root fails",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, errors.New("SLR"))
-				tx.EXPECT().Close().Return(nil)
-				tx.EXPECT().IsOpen().AnyTimes().Return(false)
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.GetInclusionProof(ctx, &trillian.GetInclusionProofRequest{LogId: logID1, TreeSize: 7, LeafIndex: offset + 2})
 			},
-			req:    &getEntryAndProofRequest17,
-			errStr: "SLR",
+			want: &trillian.GetInclusionProofResponse{Proof: wantProof, SignedLogRoot: signedRoot1},
 		},
-		{
-			name: "bad log root",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(corruptLogRoot, nil)
-				tx.EXPECT().Close().Return(nil)
-				tx.EXPECT().IsOpen().AnyTimes().Return(false)
+		{
+			desc: "GetInclusionProofBelowOffset",
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.GetInclusionProof(ctx, &trillian.GetInclusionProofRequest{LogId: logID1, TreeSize: 7, LeafIndex: 2})
 			},
-			req:    &getEntryAndProofRequest17,
-			errStr: "not read current log root",
+			wantCode: codes.InvalidArgument,
 		},
 		{
-			name: "multiple leaves incorrect",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
-				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
-					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
-					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
-					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
-				// Code passed one leaf index so expects one result, but we return more
-				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{leaf1, leaf3}, nil)
-				tx.EXPECT().Close().Return(nil)
+			desc: "GetInclusionProofPastTreeSize",
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.GetInclusionProof(ctx, &trillian.GetInclusionProofRequest{LogId: logID1, TreeSize: 7, LeafIndex: offset + 7})
 			},
-			req:    &getEntryAndProofRequest7,
-			errStr: "expected one leaf",
+			wantCode: codes.InvalidArgument,
 		},
 		{
-			name: "ok",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+			desc: "GetEntryAndProof",
+			setup: func(tx *storage.MockLogTreeTX) {
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil)
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
-					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
-					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
-					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
-				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{leaf1}, nil)
-				tx.EXPECT().Commit(gomock.Any()).Return(nil)
-				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().ReadRevision(gomock.Any()).Return(revision1, nil)
+				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return(inclusionNodes, nil)
+				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{stored2}, nil)
 			},
-			req: &getEntryAndProofRequest7,
-			wantResp: &trillian.GetEntryAndProofResponse{
+			// The offset of the tree and the index base add up.
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.GetEntryAndProof(ctx, &trillian.GetEntryAndProofRequest{LogId: logID1, TreeSize: 7, LeafIndex: offset + 3, IndexBase: trillian.IndexBase_INDEX_BASE_ONE})
+			},
+			want: &trillian.GetEntryAndProofResponse{
+				Proof:         &trillian.Proof{LeafIndex: offset + 3, Hashes: wantProof.Hashes},
+				Leaf:          newTestLeaf([]byte("value2"), []byte("extra"), offset+3),
 				SignedLogRoot: signedRoot1,
-				Proof: &trillian.Proof{
-					LeafIndex: 2,
-					Hashes: [][]byte{
-						[]byte("nodehash0"),
-						[]byte("nodehash1"),
-						[]byte("nodehash2"),
-					},
-				},
-				Leaf: leaf1,
 			},
 		},
 		{
-			name: "skew no proof",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+			desc: "GetLeavesByIndex",
+			setup: func(tx *storage.MockLogTreeTX) {
+				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{stored2}, nil)
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().Commit(gomock.Any()).Return(nil)
-				tx.EXPECT().Close().Return(nil)
 			},
-			req: &getEntryAndProofRequest17_11,
-			wantResp: &trillian.GetEntryAndProofResponse{
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.GetLeavesByIndex(ctx, &trillian.GetLeavesByIndexRequest{LogId: logID1, LeafIndex: []int64{offset + 2}})
+			},
+			want: &trillian.GetLeavesByIndexResponse{
+				Leaves:        []*trillian.LogLeaf{newTestLeaf([]byte("value2"), []byte("extra"), offset+2)},
 				SignedLogRoot: signedRoot1,
 			},
 		},
 		{
-			name: "skew smaller tree",
-			setupStorage: func(c *gomock.Controller, s *storage.MockLogStorage) {
-				tx := storage.NewMockLogTreeTX(c)
-				s.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+			desc: "GetLeavesByIndexBelowOffset",
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.GetLeavesByIndex(ctx, &trillian.GetLeavesByIndexRequest{LogId: logID1, LeafIndex: []int64{offset + 2, 2}})
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "GetLeavesByRange",
+			setup: func(tx *storage.MockLogTreeTX) {
 				tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(signedRoot1, nil)
-				tx.EXPECT().ReadRevision(gomock.Any()).Return(int64(root1.Revision), nil) // nolint: megacheck
-				tx.EXPECT().GetMerkleNodes(gomock.Any(), revision1, nodeIdsInclusionSize7Index2).Return([]tree.Node{
-					{NodeID: nodeIdsInclusionSize7Index2[0], NodeRevision: 3, Hash: []byte("nodehash0")},
-					{NodeID: nodeIdsInclusionSize7Index2[1], NodeRevision: 2, Hash: []byte("nodehash1")},
-					{NodeID: nodeIdsInclusionSize7Index2[2], NodeRevision: 3, Hash: []byte("nodehash2")}}, nil)
-				tx.EXPECT().GetLeavesByIndex(gomock.Any(), []int64{2}).Return([]*trillian.LogLeaf{leaf1}, nil)
-				tx.EXPECT().Commit(gomock.Any()).Return(nil)
-				tx.EXPECT().Close().Return(nil)
+				tx.EXPECT().GetLeavesByRange(gomock.Any(), int64(1), int64(2)).Return([]*trillian.LogLeaf{stored1, stored2}, nil)
 			},
-			req: &getEntryAndProofRequest17_2,
-			wantResp: &trillian.GetEntryAndProofResponse{
-				SignedLogRoot: signedRoot1,
-				Proof: &trillian.Proof{
-					LeafIndex: 2,
-					Hashes: [][]byte{
-						[]byte("nodehash0"),
-						[]byte("nodehash1"),
-						[]byte("nodehash2"),
-					},
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{LogId: logID1, StartIndex: offset + 1, Count: 2})
+			},
+			want: &trillian.GetLeavesByRangeResponse{
+				Leaves: []*trillian.LogLeaf{
+					newTestLeaf([]byte("value"), []byte("extra"), offset+1),
+					newTestLeaf([]byte("value2"), []byte("extra"), offset+2),
 				},
-				Leaf: leaf1,
+				SignedLogRoot: signedRoot1,
 			},
 		},
+		{
+			desc: "VerifyInclusion",
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.VerifyInclusion(ctx, &trillian.VerifyInclusionRequest{LogId: logID1, LeafHash: vh1, LeafIndex: offset + 1, TreeSize: 3, Proof: [][]byte{vh0, vh2}, RootHash: vroot})
+			},
+			want: &trillian.VerifyInclusionResponse{Valid: true, ComputedRootHash: vroot},
+		},
+		{
+			desc: "VerifyInclusionBelowOffset",
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.VerifyInclusion(ctx, &trillian.VerifyInclusionRequest{LogId: logID1, LeafHash: vh1, LeafIndex: 1, TreeSize: 3, Proof: [][]byte{vh0, vh2}, RootHash: vroot})
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "VerifyInclusionPastTreeSize",
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.VerifyInclusion(ctx, &trillian.VerifyInclusionRequest{LogId: logID1, LeafHash: vh1, LeafIndex: offset + 3, TreeSize: 3, Proof: [][]byte{vh0, vh2}, RootHash: vroot})
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			desc: "GetLeavesByRangeBelowOffset",
+			call: func(ctx context.Context, s *TrillianLogRPCServer) (proto.Message, error) {
+				return s.GetLeavesByRange(ctx, &trillian.GetLeavesByRangeRequest{LogId: logID1, StartIndex: offset - 1, Count: 2})
+			},
+			wantCode: codes.InvalidArgument,
+		},
 	} {
-		t.Run(tc.name, func(t *testing.T) {
+		t.Run(test.desc, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
+
 			fakeStorage := storage.NewMockLogStorage(ctrl)
-			tc.setupStorage(ctrl, fakeStorage)
-			registry := extension.Registry{
-				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: leaf0Request.LogId, numSnapshots: 1, snapErr: tc.snapErr, treeErr: tc.treeErr}),
-				LogStorage:   fakeStorage,
+			if test.setup != nil {
+				tx := storage.NewMockLogTreeTX(ctrl)
+				fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{offsetTree}).Return(tx, nil)
+				test.setup(tx)
+				tx.EXPECT().Commit(gomock.Any()).Return(nil)
+				tx.EXPECT().Close().Return(nil)
 			}
+			adminStorage := storage.NewMockAdminStorage(ctrl)
+			adminTX := storage.NewMockReadOnlyAdminTX(ctrl)
+			adminStorage.EXPECT().Snapshot(gomock.Any()).Return(adminTX, nil)
+			adminTX.EXPECT().GetTree(gomock.Any(), logID1).Return(offsetTree, nil)
+			adminTX.EXPECT().Commit().Return(nil)
+			adminTX.EXPECT().Close().Return(nil)
+			registry := extension.Registry{AdminStorage: adminStorage, LogStorage: fakeStorage}
 			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
-			resp, err := server.GetEntryAndProof(context.Background(), tc.req)
-			if len(tc.errStr) > 0 {
-				if err == nil || !strings.Contains(err.Error(), tc.errStr) {
-					t.Errorf("GetEntryAndProof(%v)=%v, %v want nil, err containing: %s", tc.req, resp, err, tc.errStr)
-				}
-				return
-			}
 
-			if err != nil || !proto.Equal(tc.wantResp, resp) {
-				t.Errorf("GetEntryAndProof(%v)=%v, %v, want: %v, nil", tc.req, resp, err, tc.wantResp)
+			got, err := test.call(context.Background(), server)
+			if status.Code(err) != test.wantCode {
+				t.Fatalf("%s() returned err = %v, want code %s", test.desc, err, test.wantCode)
+			}
+			if err == nil && !proto.Equal(got, test.want) {
+				t.Errorf("%s() = %v, want %v", test.desc, got, test.want)
 			}
 		})
 	}
 }
 
-func TestGetSequencedLeafCountBeginTXFails(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
+func TestProofTreeSizeLimit(t *testing.T) {
+	// A root whose tree size has been corrupted, e.g. by a flipped bit.
+	corruptRoot := &types.LogRootV1{TimestampNanos: 987654321, RootHash: []byte("A NICE HASH"), TreeSize: 1<<62 + 7, Revision: uint64(revision1)}
+	signedCorruptRoot, err := fixedSigner.SignLogRoot(corruptRoot)
+	if err != nil {
+		t.Fatalf("SignLogRoot(): %v", err)
+	}
 
-	test := newParameterizedTest(ctrl, "GetSequencedLeafCount", readOnly, nopStorage,
-		func(t *storage.MockLogTreeTX) {
-			t.EXPECT().Close().Return(nil)
+	for _, test := range []struct {
+		desc    string
+		maxSize int64
+		slr     *trillian.SignedLogRoot
+		call    func(ctx context.Context, s *TrillianLogRPCServer) error
+	}{
+		{
+			desc: "inclusionCorruptRoot",
+			slr:  signedCorruptRoot,
+			call: func(ctx context.Context, s *TrillianLogRPCServer) error {
+				_, err := s.GetInclusionProof(ctx, &getInclusionProofByIndexRequest7)
+				return err
+			},
 		},
-		func(s *TrillianLogRPCServer) error {
-			_, err := s.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
-			return err
-		})
-
-	test.executeBeginFailsTest(t, logID1)
-}
-
-func TestGetSequencedLeafCountStorageFails(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	test := newParameterizedTest(ctrl, "GetSequencedLeafCount", readOnly, nopStorage,
-		func(t *storage.MockLogTreeTX) {
-			t.EXPECT().GetSequencedLeafCount(gomock.Any()).Return(int64(0), errors.New("STORAGE"))
+		{
+			desc: "consistencyCorruptRoot",
+			slr:  signedCorruptRoot,
+			call: func(ctx context.Context, s *TrillianLogRPCServer) error {
+				_, err := s.GetConsistencyProof(ctx, &getConsistencyProofRequest7)
+				return err
+			},
 		},
-		func(s *TrillianLogRPCServer) error {
-			_, err := s.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
-			return err
-		})
-
-	test.executeStorageFailureTest(t, logID1)
-}
-
-func TestGetSequencedLeafCountCommitFails(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	test := newParameterizedTest(ctrl, "GetSequencedLeafCount", readOnly, nopStorage,
-		func(t *storage.MockLogTreeTX) {
-			t.EXPECT().GetSequencedLeafCount(gomock.Any()).Return(int64(27), nil)
+		{
+			desc:    "inclusionConfiguredMax",
+			maxSize: 6,
+			slr:     signedRoot1,
+			call: func(ctx context.Context, s *TrillianLogRPCServer) error {
+				_, err := s.GetInclusionProof(ctx, &getInclusionProofByIndexRequest7)
+				return err
+			},
 		},
-		func(s *TrillianLogRPCServer) error {
-			_, err := s.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
-			return err
-		})
-
-	test.executeCommitFailsTest(t, logID1)
-}
-
-func TestGetSequencedLeafCount(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	fakeStorage := storage.NewMockLogStorage(ctrl)
-	mockTX := storage.NewMockLogTreeTX(ctrl)
-	fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(mockTX, nil)
-
-	mockTX.EXPECT().GetSequencedLeafCount(gomock.Any()).Return(int64(268), nil)
-	mockTX.EXPECT().Commit(gomock.Any()).Return(nil)
-	mockTX.EXPECT().Close().Return(nil)
-
-	registry := extension.Registry{
-		AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
-		LogStorage:   fakeStorage,
-	}
-	server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+		{
+			desc:    "consistencyConfiguredMax",
+			maxSize: 6,
+			slr:     signedRoot1,
+			call: func(ctx context.Context, s *TrillianLogRPCServer) error {
+				_, err := s.GetConsistencyProof(ctx, &getConsistencyProofRequest7)
+				return err
+			},
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
-	response, err := server.GetSequencedLeafCount(context.Background(), &trillian.GetSequencedLeafCountRequest{LogId: logID1})
-	if err != nil {
-		t.Fatalf("expected no error getting leaf count but got: %v", err)
-	}
+			fakeStorage := storage.NewMockLogStorage(ctrl)
+			tx := storage.NewMockLogTreeTX(ctrl)
+			fakeStorage.EXPECT().SnapshotForTree(gomock.Any(), cmpMatcher{tree1}).Return(tx, nil)
+			tx.EXPECT().LatestSignedLogRoot(gomock.Any()).Return(test.slr, nil)
+			tx.EXPECT().Close().Return(nil)
 
-	if got, want := response.LeafCount, int64(268); got != want {
-		t.Fatalf("expected leaf count: %d but got: %d", want, got)
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: logID1, numSnapshots: 1}),
+				LogStorage:   fakeStorage,
+			}
+			server := NewTrillianLogRPCServer(registry, fakeTimeSource)
+			server.MaxProofTreeSize = test.maxSize
+			err := test.call(context.Background(), server)
+			if got, want := status.Code(err), codes.InvalidArgument; got != want {
+				t.Errorf("proof request returned err = %v, want code %v", err, want)
+			}
+		})
 	}
 }
 
-type consistProofTest struct {
-	req         *trillian.GetConsistencyProofRequest
-	errStr      string
-	wantHashes  [][]byte
-	noSnap      bool
-	snapErr     error
-	root        *trillian.SignedLogRoot
-	noRoot      bool
-	rootErr     error
-	noRev       bool
-	nodeIDs     []tree.NodeID
-	nodes       []tree.Node
-	getNodesErr error
-	noCommit    bool
-	commitErr   error
-}
-
 func TestGetConsistencyProof(t *testing.T) {
 	tests := []consistProofTest{
 		{
@@ -1667,6 +3756,19 @@ func TestGetConsistencyProof(t *testing.T) {
 			nodeIDs:    []tree.NodeID{},
 			nodes:      []tree.Node{},
 		},
+		{
+			// Tests first==0 edge case, which should succeed with an empty proof without reading
+			// any nodes.
+			req:        &getConsistencyProofRequest07,
+			wantHashes: [][]byte{},
+			noRev:      true,
+		},
+		{
+			// Tests first==second==0 edge case, which should succeed with an empty proof.
+			req:        &getConsistencyProofRequest00,
+			wantHashes: [][]byte{},
+			noRev:      true,
+		},
 	}
 
 	ctrl := gomock.NewController(t)
@@ -1804,13 +3906,24 @@ func TestTrillianLogRPCServer_GetEntryAndProofErrors(t *testing.T) {
 		},
 	}
 
-	logServer := NewTrillianLogRPCServer(extension.Registry{}, fakeTimeSource)
 	ctx := context.Background()
 	for _, test := range tests {
-		_, err := logServer.GetEntryAndProof(ctx, test.req)
-		if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument {
-			t.Errorf("%v: GetEntryAndProof() returned err = %v, wantCode = %s", test.desc, err, codes.InvalidArgument)
-		}
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The leaf index is checked against the tree size once the tree
+			// is read.
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: test.req.LogId, numSnapshots: 1}),
+			}
+			logServer := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+			_, err := logServer.GetEntryAndProof(ctx, test.req)
+			if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument {
+				t.Errorf("%v: GetEntryAndProof() returned err = %v, wantCode = %s", test.desc, err, codes.InvalidArgument)
+			}
+		})
 	}
 }
 
@@ -1845,13 +3958,24 @@ func TestTrillianLogRPCServer_GetInclusionProofErrors(t *testing.T) {
 		},
 	}
 
-	logServer := NewTrillianLogRPCServer(extension.Registry{}, fakeTimeSource)
 	ctx := context.Background()
 	for _, test := range tests {
-		_, err := logServer.GetInclusionProof(ctx, test.req)
-		if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument {
-			t.Errorf("%v: GetInclusionProof() returned err = %v, wantCode = %s", test.desc, err, codes.InvalidArgument)
-		}
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The leaf index is checked against the tree size once the tree
+			// is read.
+			registry := extension.Registry{
+				AdminStorage: fakeAdminStorage(ctrl, storageParams{treeID: test.req.LogId, numSnapshots: 1}),
+			}
+			logServer := NewTrillianLogRPCServer(registry, fakeTimeSource)
+
+			_, err := logServer.GetInclusionProof(ctx, test.req)
+			if s, ok := status.FromError(err); !ok || s.Code() != codes.InvalidArgument {
+				t.Errorf("%v: GetInclusionProof() returned err = %v, wantCode = %s", test.desc, err, codes.InvalidArgument)
+			}
+		})
 	}
 }
 
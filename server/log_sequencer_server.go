@@ -0,0 +1,248 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/util/requestid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultMaxQuarantinedLeaves is the number of leaves returned by
+// ListQuarantinedLeaves if the request doesn't set a maximum.
+const defaultMaxQuarantinedLeaves = 1000
+
+// maxPendingLeaves is the number of leaves up to which GetSequencerStatus
+// counts the queue of a log, so that deep queues are cheap to count.
+const maxPendingLeaves = 10000
+
+// TrillianLogSequencerServer implements the TrillianLogSequencer service,
+// which provides maintenance operations on the logs sequenced by a log signer.
+type TrillianLogSequencerServer struct {
+	manager     *log.SequencerManager
+	info        *log.OperationInfo
+	guardWindow time.Duration
+	ops         *log.OperationManager
+
+	// AllowReintegratePending enables the ReintegratePending RPC, which is
+	// rejected otherwise.
+	AllowReintegratePending bool
+	// AllowResignMastership enables the ResignMastership RPC, which is
+	// rejected otherwise.
+	AllowResignMastership bool
+	// AllowSequencerStatus enables the GetSequencerStatus RPC, which is
+	// rejected otherwise.
+	AllowSequencerStatus bool
+	// AllowSignLogRoot enables the SignLogRoot RPC, which is rejected
+	// otherwise.
+	AllowSignLogRoot bool
+	// InstanceID identifies this signer in GetSequencerStatus responses.
+	InstanceID string
+}
+
+// NewTrillianLogSequencerServer creates a new TrillianLogSequencerServer,
+// which sequences logs with the given manager and operation info, like the
+// signer does. Leaves are never integrated before guardWindow has passed since
+// they were queued. The sequencing status of logs is reported from ops, which
+// runs the signer's sequencing passes.
+func NewTrillianLogSequencerServer(manager *log.SequencerManager, info *log.OperationInfo, guardWindow time.Duration, ops *log.OperationManager) *TrillianLogSequencerServer {
+	return &TrillianLogSequencerServer{manager: manager, info: info, guardWindow: guardWindow, ops: ops}
+}
+
+// ReintegratePending integrates the leaves of a log queued for at least the
+// requested minimum age. Only the signer which is master for the log does so,
+// so that it isn't integrated concurrently by two signers.
+func (s *TrillianLogSequencerServer) ReintegratePending(ctx context.Context, req *trillian.ReintegratePendingRequest) (*trillian.ReintegratePendingResponse, error) {
+	if !s.AllowReintegratePending {
+		return nil, status.Error(codes.PermissionDenied, "ReintegratePending is not enabled on this signer")
+	}
+	var minAge time.Duration
+	if req.MinAge != nil {
+		var err error
+		if minAge, err = ptypes.Duration(req.MinAge); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid min_age: %v", err)
+		}
+	}
+	if minAge < s.guardWindow {
+		return nil, status.Errorf(codes.InvalidArgument, "min_age %v is shorter than the sequencer guard window %v", minAge, s.guardWindow)
+	}
+	if s.ops == nil {
+		return nil, status.Error(codes.Unavailable, "sequencing is not running")
+	}
+	if !s.ops.SequencingStatus(req.LogId).Master {
+		return nil, status.Errorf(codes.FailedPrecondition, "this signer is not master for log %v", req.LogId)
+	}
+
+	n, slr, err := s.manager.ReintegratePending(ctx, req.LogId, s.info, minAge)
+	if err != nil {
+		glog.Warningf("%s%v: ReintegratePending failed after %d leaves: %v", requestid.LogPrefix(ctx), req.LogId, n, err)
+		return nil, err
+	}
+	glog.Infof("%s%v: ReintegratePending integrated %d leaves", requestid.LogPrefix(ctx), req.LogId, n)
+	return &trillian.ReintegratePendingResponse{LeavesIntegrated: int64(n), SignedLogRoot: slr}, nil
+}
+
+// ListQuarantinedLeaves returns the leaves of a log which the sequencer has
+// quarantined.
+func (s *TrillianLogSequencerServer) ListQuarantinedLeaves(ctx context.Context, req *trillian.ListQuarantinedLeavesRequest) (*trillian.ListQuarantinedLeavesResponse, error) {
+	limit := int(req.MaxLeaves)
+	switch {
+	case limit < 0:
+		return nil, status.Errorf(codes.InvalidArgument, "max_leaves %d must not be negative", limit)
+	case limit == 0:
+		limit = defaultMaxQuarantinedLeaves
+	}
+	leaves, err := s.manager.ListQuarantinedLeaves(ctx, req.LogId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.ListQuarantinedLeavesResponse{Leaves: leaves}, nil
+}
+
+// RequeueQuarantinedLeaves moves quarantined leaves of a log back to its
+// queue.
+func (s *TrillianLogSequencerServer) RequeueQuarantinedLeaves(ctx context.Context, req *trillian.RequeueQuarantinedLeavesRequest) (*trillian.RequeueQuarantinedLeavesResponse, error) {
+	if len(req.LeafIdentityHashes) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "no leaf_identity_hashes given")
+	}
+	n, err := s.manager.RequeueQuarantinedLeaves(ctx, req.LogId, req.LeafIdentityHashes)
+	if err != nil {
+		return nil, err
+	}
+	glog.Infof("%s%v: RequeueQuarantinedLeaves requeued %d of %d leaves", requestid.LogPrefix(ctx), req.LogId, n, len(req.LeafIdentityHashes))
+	return &trillian.RequeueQuarantinedLeavesResponse{LeavesRequeued: int64(n)}, nil
+}
+
+// GetSequencingStatus reports whether this signer is sequencing a log, and the
+// outcome of its latest runs for it.
+func (s *TrillianLogSequencerServer) GetSequencingStatus(ctx context.Context, req *trillian.GetSequencingStatusRequest) (*trillian.GetSequencingStatusResponse, error) {
+	if s.ops == nil {
+		return nil, status.Error(codes.Unavailable, "sequencing is not running")
+	}
+	st := s.ops.SequencingStatus(req.LogId)
+	rsp := &trillian.GetSequencingStatusResponse{Master: st.Master}
+	if !st.LastSuccess.IsZero() {
+		ts, err := ptypes.TimestampProto(st.LastSuccess)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid last integration time: %v", err)
+		}
+		rsp.LastIntegrationTimestamp = ts
+	}
+	if st.LastErr != nil {
+		rsp.LastError = st.LastErr.Error()
+	}
+	return rsp, nil
+}
+
+// GetSequencerStatus returns a snapshot of this signer's view of the
+// sequencing of a log.
+func (s *TrillianLogSequencerServer) GetSequencerStatus(ctx context.Context, req *trillian.GetSequencerStatusRequest) (*trillian.GetSequencerStatusResponse, error) {
+	if !s.AllowSequencerStatus {
+		return nil, status.Error(codes.PermissionDenied, "GetSequencerStatus is not enabled on this signer")
+	}
+	if s.ops == nil {
+		return nil, status.Error(codes.Unavailable, "sequencing is not running")
+	}
+	st := s.ops.SequencingStatus(req.LogId)
+	seq := s.manager.SequencerStatus(req.LogId, s.info)
+	pending, err := s.manager.CountPending(ctx, req.LogId, maxPendingLeaves)
+	if err != nil {
+		return nil, err
+	}
+	rsp := &trillian.GetSequencerStatusResponse{
+		InstanceId:    s.InstanceID,
+		Master:        st.Master,
+		BatchFull:     seq.BatchFull,
+		BatchSize:     int32(seq.BatchSize),
+		PendingLeaves: pending,
+	}
+	if root := seq.Root; root != nil {
+		rsp.TreeSize = int64(root.TreeSize)
+		ts, err := ptypes.TimestampProto(time.Unix(0, int64(root.TimestampNanos)))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid root timestamp: %v", err)
+		}
+		rsp.RootTimestamp = ts
+	}
+	for _, run := range st.Runs {
+		ts, err := ptypes.TimestampProto(run.Start)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "invalid run start time: %v", err)
+		}
+		r := &trillian.SequencingRun{
+			StartTimestamp:   ts,
+			Duration:         ptypes.DurationProto(run.Duration),
+			LeavesIntegrated: int64(run.Items),
+		}
+		if run.Err != nil {
+			r.Error = run.Err.Error()
+		}
+		rsp.Runs = append(rsp.Runs, r)
+	}
+	return rsp, nil
+}
+
+// ResignMastership makes this signer resign mastership for a log, or for all
+// the logs it is master for if the request has no log ID.
+func (s *TrillianLogSequencerServer) ResignMastership(ctx context.Context, req *trillian.ResignMastershipRequest) (*trillian.ResignMastershipResponse, error) {
+	if !s.AllowResignMastership {
+		return nil, status.Error(codes.PermissionDenied, "ResignMastership is not enabled on this signer")
+	}
+	if req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "no reason given")
+	}
+	if s.ops == nil {
+		return nil, status.Error(codes.Unavailable, "sequencing is not running")
+	}
+	ids, err := s.ops.ResignMastership(req.LogId, req.Reason)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "can't resign mastership: %v", err)
+	}
+	if req.LogId != 0 && len(ids) == 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "not master for log %d", req.LogId)
+	}
+	glog.Infof("%s%v: ResignMastership resigning for %d logs: %s", requestid.LogPrefix(ctx), req.LogId, len(ids), req.Reason)
+	return &trillian.ResignMastershipResponse{LogIds: ids}, nil
+}
+
+// SignLogRoot signs the integrated state of a log with a signing_interval
+// without waiting for the interval to pass. Only the signer which is master for
+// the log does so, so that it isn't signed concurrently by two signers.
+func (s *TrillianLogSequencerServer) SignLogRoot(ctx context.Context, req *trillian.SignLogRootRequest) (*trillian.SignLogRootResponse, error) {
+	if !s.AllowSignLogRoot {
+		return nil, status.Error(codes.PermissionDenied, "SignLogRoot is not enabled on this signer")
+	}
+	if s.ops == nil {
+		return nil, status.Error(codes.Unavailable, "sequencing is not running")
+	}
+	if !s.ops.SequencingStatus(req.LogId).Master {
+		return nil, status.Errorf(codes.FailedPrecondition, "this signer is not master for log %v", req.LogId)
+	}
+	slr, signed, err := s.manager.SignLogRoot(ctx, req.LogId, s.info)
+	if err != nil {
+		return nil, err
+	}
+	if signed {
+		glog.Infof("%s%v: SignLogRoot signed a new root", requestid.LogPrefix(ctx), req.LogId)
+	}
+	return &trillian.SignLogRootResponse{SignedLogRoot: slr, Signed: signed}, nil
+}
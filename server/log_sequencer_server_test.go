@@ -0,0 +1,367 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/log"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/quota"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	stestonly "github.com/google/trillian/storage/testonly"
+)
+
+func TestReintegratePending_InvalidMinAge(t *testing.T) {
+	s := NewTrillianLogSequencerServer(nil, nil, time.Minute, nil)
+	s.AllowReintegratePending = true
+	for _, test := range []struct {
+		desc   string
+		minAge *duration.Duration
+	}{
+		{desc: "unset"},
+		{desc: "belowGuardWindow", minAge: ptypes.DurationProto(time.Second)},
+		{desc: "invalid", minAge: &duration.Duration{Seconds: 1, Nanos: -1}},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			_, err := s.ReintegratePending(context.Background(), &trillian.ReintegratePendingRequest{LogId: 1, MinAge: test.minAge})
+			if got, want := status.Code(err), codes.InvalidArgument; got != want {
+				t.Errorf("ReintegratePending() = %v, want code %v", err, want)
+			}
+		})
+	}
+}
+
+func TestReintegratePending_Errors(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	registry := extension.Registry{
+		AdminStorage: memory.NewAdminStorage(ts),
+		LogStorage:   memory.NewLogStorage(ts, nil),
+	}
+	tree, err := storage.CreateTree(ctx, registry.AdminStorage, stestonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	info := log.OperationInfo{Registry: registry, NumWorkers: 1, TimeSource: fakeTimeSource}
+	notMaster := log.NewOperationManager(info, failingOperation{})
+	for _, test := range []struct {
+		desc     string
+		allow    bool
+		ops      *log.OperationManager
+		wantCode codes.Code
+	}{
+		{desc: "disabled", ops: notMaster, wantCode: codes.PermissionDenied},
+		{desc: "not-running", allow: true, wantCode: codes.Unavailable},
+		{desc: "not-master", allow: true, ops: notMaster, wantCode: codes.FailedPrecondition},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			s := NewTrillianLogSequencerServer(nil, &info, time.Minute, test.ops)
+			s.AllowReintegratePending = test.allow
+			req := &trillian.ReintegratePendingRequest{LogId: tree.TreeId, MinAge: ptypes.DurationProto(time.Hour)}
+			if _, err := s.ReintegratePending(ctx, req); status.Code(err) != test.wantCode {
+				t.Errorf("ReintegratePending() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestListQuarantinedLeaves_InvalidMaxLeaves(t *testing.T) {
+	s := NewTrillianLogSequencerServer(nil, nil, time.Minute, nil)
+	_, err := s.ListQuarantinedLeaves(context.Background(), &trillian.ListQuarantinedLeavesRequest{LogId: 1, MaxLeaves: -1})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("ListQuarantinedLeaves() = %v, want code %v", err, want)
+	}
+}
+
+func TestRequeueQuarantinedLeaves_NoHashes(t *testing.T) {
+	s := NewTrillianLogSequencerServer(nil, nil, time.Minute, nil)
+	_, err := s.RequeueQuarantinedLeaves(context.Background(), &trillian.RequeueQuarantinedLeavesRequest{LogId: 1})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("RequeueQuarantinedLeaves() = %v, want code %v", err, want)
+	}
+}
+
+// failingOperation is a log.Operation which always fails.
+type failingOperation struct{}
+
+func (failingOperation) ExecutePass(ctx context.Context, logID int64, info *log.OperationInfo) (int, error) {
+	return 0, errors.New("pass failed")
+}
+
+func TestGetSequencingStatus(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	registry := extension.Registry{
+		AdminStorage: memory.NewAdminStorage(ts),
+		LogStorage:   memory.NewLogStorage(ts, nil),
+	}
+	tree, err := storage.CreateTree(ctx, registry.AdminStorage, stestonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	info := log.OperationInfo{Registry: registry, NumWorkers: 1, TimeSource: fakeTimeSource}
+	ops := log.NewOperationManager(info, failingOperation{})
+	s := NewTrillianLogSequencerServer(nil, &info, time.Minute, ops)
+
+	getStatus := func() *trillian.GetSequencingStatusResponse {
+		t.Helper()
+		rsp, err := s.GetSequencingStatus(ctx, &trillian.GetSequencingStatusRequest{LogId: tree.TreeId})
+		if err != nil {
+			t.Fatalf("GetSequencingStatus(): %v", err)
+		}
+		return rsp
+	}
+	if rsp := getStatus(); rsp.Master || rsp.LastIntegrationTimestamp != nil || rsp.LastError != "" {
+		t.Errorf("GetSequencingStatus() before any pass = %v, want empty response", rsp)
+	}
+
+	ops.OperationSingle(ctx)
+	rsp := getStatus()
+	if !rsp.Master {
+		t.Error("GetSequencingStatus().Master = false, want true")
+	}
+	if rsp.LastIntegrationTimestamp != nil {
+		t.Errorf("GetSequencingStatus().LastIntegrationTimestamp = %v, want unset", rsp.LastIntegrationTimestamp)
+	}
+	if got, want := rsp.LastError, "pass failed"; got != want {
+		t.Errorf("GetSequencingStatus().LastError = %q, want %q", got, want)
+	}
+}
+
+func TestGetSequencingStatus_NotRunning(t *testing.T) {
+	s := NewTrillianLogSequencerServer(nil, nil, time.Minute, nil)
+	_, err := s.GetSequencingStatus(context.Background(), &trillian.GetSequencingStatusRequest{LogId: 1})
+	if got, want := status.Code(err), codes.Unavailable; got != want {
+		t.Errorf("GetSequencingStatus() = %v, want code %v", err, want)
+	}
+}
+
+func TestGetSequencerStatus(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	registry := extension.Registry{
+		AdminStorage: memory.NewAdminStorage(ts),
+		LogStorage:   memory.NewLogStorage(ts, nil),
+	}
+	tree, err := storage.CreateTree(ctx, registry.AdminStorage, stestonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	info := log.OperationInfo{Registry: registry, BatchSize: 100, NumWorkers: 1, TimeSource: fakeTimeSource}
+	ops := log.NewOperationManager(info, failingOperation{})
+	s := NewTrillianLogSequencerServer(log.NewSequencerManager(registry, time.Minute), &info, time.Minute, ops)
+	s.AllowSequencerStatus = true
+	s.InstanceID = "signer-1"
+
+	if _, err := NewTrillianLogRPCServer(registry, fakeTimeSource).InitLog(ctx, &trillian.InitLogRequest{LogId: tree.TreeId}); err != nil {
+		t.Fatalf("InitLog(): %v", err)
+	}
+	hashA, hashB := rfc6962.DefaultHasher.HashLeaf([]byte("a")), rfc6962.DefaultHasher.HashLeaf([]byte("b"))
+	if _, err := registry.LogStorage.QueueLeaves(ctx, tree, []*trillian.LogLeaf{
+		{LeafValue: []byte("a"), MerkleLeafHash: hashA, LeafIdentityHash: hashA},
+		{LeafValue: []byte("b"), MerkleLeafHash: hashB, LeafIdentityHash: hashB},
+	}, fakeTime); err != nil {
+		t.Fatalf("QueueLeaves(): %v", err)
+	}
+
+	ops.OperationSingle(ctx)
+	ops.OperationSingle(ctx)
+	rsp, err := s.GetSequencerStatus(ctx, &trillian.GetSequencerStatusRequest{LogId: tree.TreeId})
+	if err != nil {
+		t.Fatalf("GetSequencerStatus(): %v", err)
+	}
+	if got, want := rsp.InstanceId, "signer-1"; got != want {
+		t.Errorf("GetSequencerStatus().InstanceId = %q, want %q", got, want)
+	}
+	if !rsp.Master {
+		t.Error("GetSequencerStatus().Master = false, want true")
+	}
+	if rsp.TreeSize != 0 || rsp.RootTimestamp != nil {
+		t.Errorf("GetSequencerStatus() root = %d at %v, want none", rsp.TreeSize, rsp.RootTimestamp)
+	}
+	if got, want := rsp.BatchSize, int32(100); got != want {
+		t.Errorf("GetSequencerStatus().BatchSize = %d, want %d", got, want)
+	}
+	if got, want := rsp.PendingLeaves, int64(2); got != want {
+		t.Errorf("GetSequencerStatus().PendingLeaves = %d, want %d", got, want)
+	}
+	if got, want := len(rsp.Runs), 2; got != want {
+		t.Fatalf("GetSequencerStatus() has %d runs, want %d", got, want)
+	}
+	for _, run := range rsp.Runs {
+		if got, want := run.Error, "pass failed"; got != want {
+			t.Errorf("GetSequencerStatus().Runs[].Error = %q, want %q", got, want)
+		}
+		if run.StartTimestamp == nil || run.Duration == nil {
+			t.Errorf("GetSequencerStatus().Runs[] = %v, want start and duration set", run)
+		}
+	}
+}
+
+func TestGetSequencerStatus_Errors(t *testing.T) {
+	ops := log.NewOperationManager(log.OperationInfo{TimeSource: fakeTimeSource}, failingOperation{})
+	for _, test := range []struct {
+		desc     string
+		allow    bool
+		ops      *log.OperationManager
+		wantCode codes.Code
+	}{
+		{desc: "disabled", ops: ops, wantCode: codes.PermissionDenied},
+		{desc: "not-running", allow: true, wantCode: codes.Unavailable},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			s := NewTrillianLogSequencerServer(nil, nil, time.Minute, test.ops)
+			s.AllowSequencerStatus = test.allow
+			_, err := s.GetSequencerStatus(context.Background(), &trillian.GetSequencerStatusRequest{LogId: 1})
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("GetSequencerStatus() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestResignMastership(t *testing.T) {
+	noElections := log.NewOperationManager(log.OperationInfo{TimeSource: fakeTimeSource}, failingOperation{})
+	for _, test := range []struct {
+		desc     string
+		allow    bool
+		ops      *log.OperationManager
+		reason   string
+		wantCode codes.Code
+	}{
+		{desc: "disabled", ops: noElections, reason: "testing", wantCode: codes.PermissionDenied},
+		{desc: "no-reason", allow: true, ops: noElections, wantCode: codes.InvalidArgument},
+		{desc: "not-running", allow: true, reason: "testing", wantCode: codes.Unavailable},
+		{desc: "no-elections", allow: true, ops: noElections, reason: "testing", wantCode: codes.FailedPrecondition},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			s := NewTrillianLogSequencerServer(nil, nil, time.Minute, test.ops)
+			s.AllowResignMastership = test.allow
+			_, err := s.ResignMastership(context.Background(), &trillian.ResignMastershipRequest{LogId: 1, Reason: test.reason})
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("ResignMastership() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestSignLogRoot_Errors(t *testing.T) {
+	ctx := context.Background()
+	notMaster := log.NewOperationManager(log.OperationInfo{TimeSource: fakeTimeSource}, failingOperation{})
+	for _, test := range []struct {
+		desc     string
+		allow    bool
+		ops      *log.OperationManager
+		wantCode codes.Code
+	}{
+		{desc: "disabled", ops: notMaster, wantCode: codes.PermissionDenied},
+		{desc: "not-running", allow: true, wantCode: codes.Unavailable},
+		{desc: "not-master", allow: true, ops: notMaster, wantCode: codes.FailedPrecondition},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			s := NewTrillianLogSequencerServer(nil, nil, time.Minute, test.ops)
+			s.AllowSignLogRoot = test.allow
+			if _, err := s.SignLogRoot(ctx, &trillian.SignLogRootRequest{LogId: 1}); status.Code(err) != test.wantCode {
+				t.Errorf("SignLogRoot() = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
+
+func TestSignLogRoot(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	registry := extension.Registry{
+		AdminStorage: memory.NewAdminStorage(ts),
+		LogStorage:   memory.NewLogStorage(ts, nil),
+		QuotaManager: quota.Noop(),
+	}
+	logTree := proto.Clone(stestonly.LogTree).(*trillian.Tree)
+	logTree.SigningInterval = ptypes.DurationProto(time.Minute)
+	tree, err := storage.CreateTree(ctx, registry.AdminStorage, logTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	timeSource := clock.NewFake(fakeTime)
+	info := log.OperationInfo{Registry: registry, BatchSize: 10, NumWorkers: 1, TimeSource: timeSource}
+	manager := log.NewSequencerManager(registry, 0)
+	ops := log.NewOperationManager(info, failingOperation{})
+	s := NewTrillianLogSequencerServer(manager, &info, 0, ops)
+	s.AllowSignLogRoot = true
+	rpc := NewTrillianLogRPCServer(registry, timeSource)
+	// Without elections, the signer becomes master of all logs on its first
+	// pass.
+	ops.OperationSingle(ctx)
+
+	// Initialise the log, then integrate a leaf within the signing interval.
+	if _, err := rpc.InitLog(ctx, &trillian.InitLogRequest{LogId: tree.TreeId}); err != nil {
+		t.Fatalf("InitLog(): %v", err)
+	}
+	hash := rfc6962.DefaultHasher.HashLeaf([]byte("leaf"))
+	leaf := &trillian.LogLeaf{LeafValue: []byte("leaf"), LeafIdentityHash: hash, MerkleLeafHash: hash}
+	if _, err := registry.LogStorage.QueueLeaves(ctx, tree, []*trillian.LogLeaf{leaf}, fakeTime.Add(-time.Hour)); err != nil {
+		t.Fatalf("QueueLeaves(): %v", err)
+	}
+	timeSource.Set(fakeTime.Add(10 * time.Second))
+	if n, err := manager.ExecutePass(ctx, tree.TreeId, &info); err != nil || n != 1 {
+		t.Fatalf("ExecutePass() = (%d, %v), want (1, nil)", n, err)
+	}
+
+	// The integrated leaf isn't served until it's signed.
+	getLeaves := func() int {
+		t.Helper()
+		rsp, err := rpc.GetLeavesByHash(ctx, &trillian.GetLeavesByHashRequest{LogId: tree.TreeId, LeafHash: [][]byte{hash}})
+		if err != nil {
+			t.Fatalf("GetLeavesByHash(): %v", err)
+		}
+		return len(rsp.Leaves)
+	}
+	if got := getLeaves(); got != 0 {
+		t.Errorf("GetLeavesByHash() before signing returned %d leaves, want 0", got)
+	}
+
+	timeSource.Set(fakeTime.Add(20 * time.Second))
+	for _, want := range []bool{true, false} {
+		rsp, err := s.SignLogRoot(ctx, &trillian.SignLogRootRequest{LogId: tree.TreeId})
+		if err != nil {
+			t.Fatalf("SignLogRoot(): %v", err)
+		}
+		var root types.LogRootV1
+		if err := root.UnmarshalBinary(rsp.SignedLogRoot.GetLogRoot()); err != nil {
+			t.Fatalf("UnmarshalBinary(): %v", err)
+		}
+		if rsp.Signed != want || root.TreeSize != 1 {
+			t.Errorf("SignLogRoot() = signed %v at size %d, want signed %v at size 1", rsp.Signed, root.TreeSize, want)
+		}
+	}
+	if got := getLeaves(); got != 1 {
+		t.Errorf("GetLeavesByHash() after signing returned %d leaves, want 1", got)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/trees"
 	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/requestid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -250,7 +251,7 @@ func (t *TrillianMapServer) getLeavesByRevision(ctx context.Context, mapID int64
 		for _, l := range leaves {
 			leavesByIndex[string(l.Index)] = l
 		}
-		glog.V(1).Infof("%v: wanted %v leaves, found %v", mapID, len(indices), len(leaves))
+		glog.V(1).Infof("%v: %swanted %v leaves, found %v", mapID, requestid.LogPrefix(ctx), len(indices), len(leaves))
 
 		// Add empty leaf values for indices that were not returned.
 		for _, index := range indices {
@@ -365,7 +366,7 @@ func (t *TrillianMapServer) SetLeaves(ctx context.Context, req *trillian.SetMapL
 		if err != nil {
 			return err
 		}
-		glog.V(2).Infof("%v: Writing at revision %v", tree.TreeId, writeRev)
+		glog.V(2).Infof("%v: %sWriting at revision %v", tree.TreeId, requestid.LogPrefix(ctx), writeRev)
 
 		if err := t.writeLeaves(ctx, tx, req.Leaves); err != nil {
 			return err
@@ -457,7 +458,7 @@ func (t *TrillianMapServer) GetSignedMapRoot(ctx context.Context, req *trillian.
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		glog.Warningf("%v: Commit failed for GetSignedMapRoot: %v", req.MapId, err)
+		glog.Warningf("%v: %sCommit failed for GetSignedMapRoot: %v", req.MapId, requestid.LogPrefix(ctx), err)
 		return nil, err
 	}
 
@@ -488,7 +489,7 @@ func (t *TrillianMapServer) GetSignedMapRootByRevision(ctx context.Context, req
 	}
 
 	if err := tx.Commit(ctx); err != nil {
-		glog.Warningf("%v: Commit failed for GetSignedMapRootByRevision: %v", req.MapId, err)
+		glog.Warningf("%v: %sCommit failed for GetSignedMapRootByRevision: %v", req.MapId, requestid.LogPrefix(ctx), err)
 		return nil, err
 	}
 
@@ -578,7 +579,7 @@ func (t *TrillianMapServer) addRevision(ctx context.Context, treeID, rev int64,
 func (t *TrillianMapServer) closeAndLog(ctx context.Context, logID int64, tx storage.ReadOnlyMapTreeTX, op string) {
 	err := tx.Close()
 	if err != nil {
-		glog.Warningf("%v: Close failed for %v: %v", logID, op, err)
+		glog.Warningf("%v: %sClose failed for %v: %v", logID, requestid.LogPrefix(ctx), op, err)
 	}
 }
 
@@ -15,28 +15,73 @@
 package server
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/trillian"
+	"github.com/google/trillian/client/backoff"
 	"github.com/google/trillian/merkle"
+	"github.com/google/trillian/merkle/compact"
 	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/tree"
+	"github.com/google/trillian/types"
+	"golang.org/x/sync/errgroup"
 )
 
 // proofMaxBitLen is the max depth of a tree. Used for tree.NodeID creation.
 const proofMaxBitLen = 64
 
+// inclusionProofFetches returns the nodes to fetch for the inclusion proof of
+// the leaf at index in the tree of snapshot leaves, from storage at the
+// revision of treeSize. Proofs at that tree size consist of the nodes of
+// merkle.InclusionProofNodes; earlier ones need some nodes rehashed.
+func inclusionProofFetches(snapshot, index, treeSize int64) ([]merkle.NodeFetch, error) {
+	if snapshot != treeSize {
+		return merkle.CalcInclusionProofNodeAddresses(snapshot, index, treeSize)
+	}
+	ids, err := merkle.InclusionProofNodes(index, treeSize)
+	if err != nil {
+		return nil, err
+	}
+	return nodeFetches(ids), nil
+}
+
+// consistencyProofFetches returns the nodes to fetch for the consistency proof
+// between the trees of size1 and size2 leaves, from storage at the revision
+// of treeSize, see inclusionProofFetches.
+func consistencyProofFetches(size1, size2, treeSize int64) ([]merkle.NodeFetch, error) {
+	if size2 != treeSize {
+		return merkle.CalcConsistencyProofNodeAddresses(size1, size2, treeSize)
+	}
+	ids, err := merkle.ConsistencyProofNodes(size1, size2)
+	if err != nil {
+		return nil, err
+	}
+	return nodeFetches(ids), nil
+}
+
+// nodeFetches returns fetches of the nodes ids, which need no rehashing.
+func nodeFetches(ids []compact.NodeID) []merkle.NodeFetch {
+	fetches := make([]merkle.NodeFetch, len(ids))
+	for i, id := range ids {
+		fetches[i] = merkle.NodeFetch{ID: id}
+	}
+	return fetches
+}
+
 // fetchNodesAndBuildProof is used by both inclusion and consistency proofs. It fetches the nodes
 // from storage and converts them into the proof proto that will be returned to the client.
 // This includes rehashing where necessary to serve proofs for tree sizes between stored tree
 // revisions. This code only relies on the NodeReader interface so can be tested without
-// a complete storage implementation.
-func fetchNodesAndBuildProof(ctx context.Context, tx storage.NodeReader, th hashers.LogHasher, treeRevision, leafIndex int64, proofNodeFetches []merkle.NodeFetch) (*trillian.Proof, error) {
+// a complete storage implementation. See fetchNodes for the meaning of concurrency.
+func fetchNodesAndBuildProof(ctx context.Context, tx storage.NodeReader, th hashers.LogHasher, treeRevision, leafIndex int64, proofNodeFetches []merkle.NodeFetch, concurrency int) (*trillian.Proof, error) {
 	ctx, spanEnd := spanFor(ctx, "fetchNodesAndBuildProof")
 	defer spanEnd()
-	proofNodes, err := fetchNodes(ctx, tx, treeRevision, proofNodeFetches)
+	proofNodes, err := fetchNodes(ctx, tx, treeRevision, proofNodeFetches, concurrency)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +94,108 @@ func fetchNodesAndBuildProof(ctx context.Context, tx storage.NodeReader, th hash
 	return r.rehashedProof(leafIndex)
 }
 
+// fetchCompactRange fetches the compact range [0, root.TreeSize) of a log
+// from storage, as of the revision of root, and checks that it matches the
+// root hash.
+func fetchCompactRange(ctx context.Context, tx storage.NodeReader, th hashers.LogHasher, root *types.LogRootV1) (*compact.Range, error) {
+	fact := compact.RangeFactory{Hash: th.HashChildren}
+	if root.TreeSize == 0 {
+		return fact.NewEmptyRange(0), nil
+	}
+	ids := compact.RangeNodes(0, root.TreeSize)
+	storIDs := make([]tree.NodeID, len(ids))
+	for i, id := range ids {
+		nodeID, err := tree.NewNodeIDForTreeCoords(int64(id.Level), int64(id.Index), proofMaxBitLen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create nodeID: %v", err)
+		}
+		storIDs[i] = nodeID
+	}
+	nodes, err := tx.GetMerkleNodes(ctx, int64(root.Revision), storIDs)
+	if err != nil {
+		return nil, err
+	}
+	if got, want := len(nodes), len(storIDs); got != want {
+		return nil, fmt.Errorf("expected %d nodes at revision %d, but got %d", want, root.Revision, got)
+	}
+	hashes := make([][]byte, len(nodes))
+	for i, node := range nodes {
+		if !node.NodeID.Equivalent(storIDs[i]) {
+			return nil, fmt.Errorf("node ID mismatch at %d", i)
+		}
+		hashes[i] = node.Hash
+	}
+	cr, err := fact.NewRange(0, root.TreeSize, hashes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compact range: %v", err)
+	}
+	hash, err := cr.GetRootHash(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute root hash: %v", err)
+	}
+	if !bytes.Equal(hash, root.RootHash) {
+		return nil, fmt.Errorf("root hash mismatch: got %x, want %x", hash, root.RootHash)
+	}
+	return cr, nil
+}
+
+// nodeReadCounter wraps a ReadOnlyLogTreeTX and counts the Merkle nodes read through it.
+// It is safe for concurrent reads.
+type nodeReadCounter struct {
+	storage.ReadOnlyLogTreeTX
+	reads int64
+}
+
+// GetMerkleNodes implements storage.NodeReader.
+func (c *nodeReadCounter) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []tree.NodeID) ([]tree.Node, error) {
+	atomic.AddInt64(&c.reads, int64(len(ids)))
+	return c.ReadOnlyLogTreeTX.GetMerkleNodes(ctx, treeRevision, ids)
+}
+
+// nodeReadRetrier wraps a ReadOnlyLogTreeTX and retries the Merkle node reads
+// through it which fail with transient errors, as classified by
+// backoff.IsRetryable or by the transaction if it is a
+// storage.TransientErrorClassifier, up to retries times. Each retry reads the
+// same nodes at the same revision, so a proof built from them is consistent
+// with the rest of the transaction. It is safe for concurrent reads if the
+// wrapped transaction is.
+type nodeReadRetrier struct {
+	storage.ReadOnlyLogTreeTX
+	retries int
+	backoff time.Duration
+	retried func()
+}
+
+// GetMerkleNodes implements storage.NodeReader.
+func (r *nodeReadRetrier) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []tree.NodeID) ([]tree.Node, error) {
+	nodes, err := r.ReadOnlyLogTreeTX.GetMerkleNodes(ctx, treeRevision, ids)
+	if err == nil || !r.retryable(err) {
+		return nodes, err
+	}
+	// The backoff is only set up once a read has failed, so that reads which
+	// succeed first time cost nothing extra.
+	b := backoff.Backoff{Min: r.backoff, Max: 32 * r.backoff, Factor: 2, Jitter: true}
+	for i := 0; i < r.retries && r.retryable(err); i++ {
+		select {
+		case <-time.After(b.Duration()):
+		case <-ctx.Done():
+			return nil, err
+		}
+		r.retried()
+		nodes, err = r.ReadOnlyLogTreeTX.GetMerkleNodes(ctx, treeRevision, ids)
+	}
+	return nodes, err
+}
+
+// retryable returns whether err, as returned by a read, is transient.
+func (r *nodeReadRetrier) retryable(err error) bool {
+	if backoff.IsRetryable(err) {
+		return true
+	}
+	c, ok := r.ReadOnlyLogTreeTX.(storage.TransientErrorClassifier)
+	return ok && c.IsTransientError(err)
+}
+
 // rehasher bundles the rehashing logic into a simple state machine
 type rehasher struct {
 	th         hashers.LogHasher
@@ -106,7 +253,11 @@ func (r *rehasher) rehashedProof(leafIndex int64) (*trillian.Proof, error) {
 
 // fetchNodes extracts the NodeIDs from a list of NodeFetch structs and passes them
 // to storage, returning the result after some additional validation checks.
-func fetchNodes(ctx context.Context, tx storage.NodeReader, treeRevision int64, fetches []merkle.NodeFetch) ([]tree.Node, error) {
+//
+// If concurrency is greater than 1, the nodes are split into up to that many
+// batches which are read from tx in parallel, so tx must support concurrent
+// reads. The nodes are returned in the order of fetches either way.
+func fetchNodes(ctx context.Context, tx storage.NodeReader, treeRevision int64, fetches []merkle.NodeFetch, concurrency int) ([]tree.Node, error) {
 	ctx, spanEnd := spanFor(ctx, "fetchNodes")
 	defer spanEnd()
 	proofNodeIDs := make([]tree.NodeID, 0, len(fetches))
@@ -119,15 +270,11 @@ func fetchNodes(ctx context.Context, tx storage.NodeReader, treeRevision int64,
 		proofNodeIDs = append(proofNodeIDs, id)
 	}
 
-	proofNodes, err := tx.GetMerkleNodes(ctx, treeRevision, proofNodeIDs)
+	proofNodes, err := readNodes(ctx, tx, treeRevision, proofNodeIDs, concurrency)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(proofNodes) != len(proofNodeIDs) {
-		return nil, fmt.Errorf("expected %d nodes from storage but got %d", len(proofNodeIDs), len(proofNodes))
-	}
-
 	for i, node := range proofNodes {
 		// Additional check that the correct node was returned.
 		if !node.NodeID.Equivalent(proofNodeIDs[i]) {
@@ -137,3 +284,45 @@ func fetchNodes(ctx context.Context, tx storage.NodeReader, treeRevision int64,
 
 	return proofNodes, nil
 }
+
+// readNodes reads the nodes with the given IDs from tx, in up to concurrency
+// parallel batches, and checks that storage returned all of them.
+func readNodes(ctx context.Context, tx storage.NodeReader, treeRevision int64, ids []tree.NodeID, concurrency int) ([]tree.Node, error) {
+	if concurrency <= 1 || len(ids) <= 1 {
+		nodes, err := tx.GetMerkleNodes(ctx, treeRevision, ids)
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) != len(ids) {
+			return nil, fmt.Errorf("expected %d nodes from storage but got %d", len(ids), len(nodes))
+		}
+		return nodes, nil
+	}
+
+	batchSize := (len(ids) + concurrency - 1) / concurrency
+	nodes := make([]tree.Node, len(ids))
+	g, gCtx := errgroup.WithContext(ctx)
+	for begin := 0; begin < len(ids); begin += batchSize {
+		begin, end := begin, begin+batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		g.Go(func() error {
+			batch, err := tx.GetMerkleNodes(gCtx, treeRevision, ids[begin:end])
+			if err != nil {
+				return err
+			}
+			if len(batch) != end-begin {
+				return fmt.Errorf("expected %d nodes from storage but got %d", end-begin, len(batch))
+			}
+			// Each batch fills its own part of nodes, so the result doesn't depend
+			// on the order in which the reads complete.
+			copy(nodes[begin:], batch)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
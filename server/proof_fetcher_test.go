@@ -16,16 +16,23 @@ package server
 
 import (
 	"context"
+	"database/sql/driver"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
 	"github.com/google/trillian/merkle"
 	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/testonly"
 	"github.com/google/trillian/storage/tree"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // rehashTest encapsulates one test case for the rehasher in isolation. Input data like the storage
@@ -140,13 +147,13 @@ func TestTree813FetchAll(t *testing.T) {
 	})
 
 	for l := int64(271); l < ts; l++ {
-		fetches, err := merkle.CalcInclusionProofNodeAddresses(ts, l, ts)
+		fetches, err := inclusionProofFetches(ts, l, ts)
 
 		if err != nil {
 			t.Fatal(err)
 		}
 
-		proof, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, int64(l), fetches)
+		proof, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, int64(l), fetches, 1)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -180,12 +187,12 @@ func TestTree32InclusionProofFetchAll(t *testing.T) {
 
 		for s := int64(2); s <= int64(ts); s++ {
 			for l := int64(0); l < s; l++ {
-				fetches, err := merkle.CalcInclusionProofNodeAddresses(s, l, int64(ts))
+				fetches, err := inclusionProofFetches(s, l, int64(ts))
 				if err != nil {
 					t.Fatal(err)
 				}
 
-				proof, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, int64(l), fetches)
+				proof, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, int64(l), fetches, 1)
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -222,13 +229,13 @@ func TestTree32InclusionProofFetchMultiBatch(t *testing.T) {
 
 	for s := int64(2); s <= 32; s++ {
 		for l := int64(0); l < s; l++ {
-			fetches, err := merkle.CalcInclusionProofNodeAddresses(s, l, 32)
+			fetches, err := inclusionProofFetches(s, l, 32)
 			if err != nil {
 				t.Fatal(err)
 			}
 
 			// Use the highest tree revision that should be available from the node reader
-			proof, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision+3, l, fetches)
+			proof, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision+3, l, fetches, 1)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -260,12 +267,12 @@ func TestTree32ConsistencyProofFetchAll(t *testing.T) {
 
 		for s1 := int64(2); s1 < int64(ts); s1++ {
 			for s2 := int64(s1 + 1); s2 < int64(ts); s2++ {
-				fetches, err := merkle.CalcConsistencyProofNodeAddresses(s1, s2, int64(ts))
+				fetches, err := consistencyProofFetches(s1, s2, int64(ts))
 				if err != nil {
 					t.Fatal(err)
 				}
 
-				proof, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, int64(s1), fetches)
+				proof, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, int64(s1), fetches, 1)
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -286,6 +293,223 @@ func TestTree32ConsistencyProofFetchAll(t *testing.T) {
 	}
 }
 
+func TestTree32ConsistencyProofFetchConcurrent(t *testing.T) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const ts = 32
+	r := testonly.NewMultiFakeNodeReaderFromLeaves([]testonly.LeafBatch{
+		{TreeRevision: testTreeRevision, Leaves: expandLeaves(0, ts-1), ExpectedRoot: expectedRootAtSize(treeAtSize(ts))},
+	})
+
+	for s1 := int64(1); s1 < ts; s1++ {
+		for s2 := s1 + 1; s2 <= ts; s2++ {
+			fetches, err := consistencyProofFetches(s1, s2, ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, s1, fetches, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, concurrency := range []int{2, 3, 64} {
+				sr := &slowNodeReader{NodeReader: r}
+				got, err := fetchNodesAndBuildProof(ctx, sr, hasher, testTreeRevision, s1, fetches, concurrency)
+				if err != nil {
+					t.Fatalf("(%d, %d, concurrency=%d): %v", s1, s2, concurrency, err)
+				}
+				if !proto.Equal(got, want) {
+					t.Errorf("(%d, %d, concurrency=%d): got proof %v, want %v", s1, s2, concurrency, got, want)
+				}
+				if got := sr.maxActive; got > int32(concurrency) {
+					t.Errorf("(%d, %d, concurrency=%d): got %d concurrent reads", s1, s2, concurrency, got)
+				}
+			}
+		}
+	}
+}
+
+func TestFetchNodesConcurrentError(t *testing.T) {
+	ctx := context.Background()
+	fetches, err := consistencyProofFetches(1, 32, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The reader has no nodes, so every batch comes back short.
+	r := testonly.NewMultiFakeNodeReader(nil)
+	if _, err := fetchNodes(ctx, r, testTreeRevision, fetches, 2); err == nil {
+		t.Error("fetchNodes() with missing nodes: got nil error")
+	}
+}
+
+func TestNodeReadRetrier(t *testing.T) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const ts = 32
+	r := testonly.NewMultiFakeNodeReaderFromLeaves([]testonly.LeafBatch{
+		{TreeRevision: testTreeRevision, Leaves: expandLeaves(0, ts-1), ExpectedRoot: expectedRootAtSize(treeAtSize(ts))},
+	})
+	fetches, err := consistencyProofFetches(5, ts, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, 0, fetches, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unavailable := status.Error(codes.Unavailable, "blip")
+
+	for _, test := range []struct {
+		desc        string
+		errs        []error
+		classify    bool
+		wantErr     bool
+		wantReads   int
+		wantRetries int
+	}{
+		{desc: "ok", wantReads: 1},
+		{desc: "transient", errs: []error{unavailable, unavailable}, wantReads: 3, wantRetries: 2},
+		{desc: "exhausted", errs: []error{unavailable, unavailable, unavailable, unavailable}, wantErr: true, wantReads: 4, wantRetries: 3},
+		{desc: "permanent", errs: []error{errors.New("corrupt")}, wantErr: true, wantReads: 1},
+		{desc: "transient-then-permanent", errs: []error{unavailable, status.Error(codes.NotFound, "gone")}, wantErr: true, wantReads: 2, wantRetries: 1},
+		{desc: "driver", errs: []error{driver.ErrBadConn}, classify: true, wantReads: 2, wantRetries: 1},
+		{desc: "driver-unclassified", errs: []error{driver.ErrBadConn}, wantErr: true, wantReads: 1},
+		{desc: "driver-permanent", errs: []error{errors.New("syntax error")}, classify: true, wantErr: true, wantReads: 1},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			tx := &flakyNodeReader{NodeReader: r, errs: test.errs}
+			var reader storage.ReadOnlyLogTreeTX = tx
+			if test.classify {
+				reader = badConnClassifier{tx}
+			}
+			retries := 0
+			retrier := &nodeReadRetrier{ReadOnlyLogTreeTX: reader, retries: 3, backoff: time.Millisecond, retried: func() { retries++ }}
+			got, err := fetchNodesAndBuildProof(ctx, retrier, hasher, testTreeRevision, 0, fetches, 1)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("fetchNodesAndBuildProof()=_, %v, want err? %v", err, test.wantErr)
+			}
+			if !test.wantErr && !proto.Equal(got, want) {
+				t.Errorf("fetchNodesAndBuildProof()=%v, want %v", got, want)
+			}
+			if got := len(tx.revisions); got != test.wantReads {
+				t.Errorf("got %d reads, want %d", got, test.wantReads)
+			}
+			for _, rev := range tx.revisions {
+				if rev != testTreeRevision {
+					t.Errorf("read at revision %d, want %d", rev, testTreeRevision)
+				}
+			}
+			if retries != test.wantRetries {
+				t.Errorf("got %d retries, want %d", retries, test.wantRetries)
+			}
+		})
+	}
+}
+
+// flakyNodeReader fails reads with errs, in turn, before passing them to
+// NodeReader, and records the revisions that are read.
+type flakyNodeReader struct {
+	storage.ReadOnlyLogTreeTX
+	storage.NodeReader
+	errs      []error
+	revisions []int64
+}
+
+func (r *flakyNodeReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []tree.NodeID) ([]tree.Node, error) {
+	r.revisions = append(r.revisions, treeRevision)
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return nil, err
+	}
+	return r.NodeReader.GetMerkleNodes(ctx, treeRevision, ids)
+}
+
+// badConnClassifier classifies the driver.ErrBadConn errors of the wrapped
+// transaction as transient, like the SQL storage implementations do.
+type badConnClassifier struct {
+	*flakyNodeReader
+}
+
+func (badConnClassifier) IsTransientError(err error) bool {
+	return errors.Is(err, driver.ErrBadConn)
+}
+
+// txNodeReader lets a NodeReader stand in for the Merkle node reads of a
+// transaction.
+type txNodeReader struct {
+	storage.ReadOnlyLogTreeTX
+	storage.NodeReader
+}
+
+func (r txNodeReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []tree.NodeID) ([]tree.Node, error) {
+	return r.NodeReader.GetMerkleNodes(ctx, treeRevision, ids)
+}
+
+// slowNodeReader delays reads of lower nodes for longer, so that concurrent
+// batches of a proof, which is ordered from the bottom of the tree up,
+// complete in reverse order. It also tracks the number of concurrent reads.
+type slowNodeReader struct {
+	storage.NodeReader
+	active, maxActive int32
+}
+
+func (r *slowNodeReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []tree.NodeID) ([]tree.Node, error) {
+	active := atomic.AddInt32(&r.active, 1)
+	defer atomic.AddInt32(&r.active, -1)
+	for {
+		max := atomic.LoadInt32(&r.maxActive)
+		if active <= max || atomic.CompareAndSwapInt32(&r.maxActive, max, active) {
+			break
+		}
+	}
+	time.Sleep(time.Duration(ids[0].PrefixLenBits) * 10 * time.Microsecond)
+	return r.NodeReader.GetMerkleNodes(ctx, treeRevision, ids)
+}
+
+// latencyNodeReader returns a made-up hash for every node, after a delay for
+// each node read, as if each of them was in a different subtree.
+type latencyNodeReader struct {
+	latency time.Duration
+}
+
+func (r latencyNodeReader) GetMerkleNodes(ctx context.Context, treeRevision int64, ids []tree.NodeID) ([]tree.Node, error) {
+	time.Sleep(time.Duration(len(ids)) * r.latency)
+	nodes := make([]tree.Node, 0, len(ids))
+	for _, id := range ids {
+		nodes = append(nodes, tree.Node{NodeID: id, Hash: th.HashLeaf([]byte(id.String()))})
+	}
+	return nodes, nil
+}
+
+func BenchmarkConsistencyProof(b *testing.B) {
+	ctx := context.Background()
+	hasher := rfc6962.DefaultHasher
+	const size = 100000000
+	fetches, err := consistencyProofFetches(1, size, size)
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := latencyNodeReader{latency: 100 * time.Microsecond}
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := fetchNodesAndBuildProof(ctx, r, hasher, testTreeRevision, 0, fetches, concurrency); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+	// Reads which succeed should cost the same with retries enabled.
+	retrier := &nodeReadRetrier{ReadOnlyLogTreeTX: txNodeReader{NodeReader: r}, retries: 3, backoff: time.Millisecond}
+	b.Run("retries=3", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := fetchNodesAndBuildProof(ctx, retrier, hasher, testTreeRevision, 0, fetches, 1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func expandLeaves(n, m int) []string {
 	leaves := make([]string, 0, m-n+1)
 	for l := n; l <= m; l++ {
@@ -0,0 +1,494 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queuewal provides a write-ahead log (WAL) of leaves which a log
+// server accepted while storage was unavailable, see
+// trillian.Tree.QueueWriteAhead. Leaves in the WAL are later drained into
+// storage, in the order they were accepted.
+package queuewal
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/util/requestid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// fileSuffix is the suffix of the WAL file of each tree, which is named
+	// after the tree ID.
+	fileSuffix = ".wal"
+	// rejectedSuffix is appended to the name of the WAL file of a tree to name
+	// the file holding the records which couldn't be drained.
+	rejectedSuffix = ".rejected"
+	// DefaultMaxDrainAttempts is the default of WAL.MaxDrainAttempts.
+	DefaultMaxDrainAttempts = 600
+	// headerSize is the size of the frame preceding each record, holding the
+	// size of the record and its checksum.
+	headerSize = 8
+)
+
+// QueueFunc queues leaves in storage, like storage.LogStorage.QueueLeaves does
+// for a given tree.
+type QueueFunc func(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error)
+
+// WAL is a write-ahead log of leaves, held in a directory with a file for
+// each tree with leaves to drain. Each file is a sequence of records, each
+// holding a batch of leaves queued together, framed by its size and CRC32
+// checksum, which lets a record torn by a crash while it was being appended
+// be discarded; such a record was never acknowledged.
+type WAL struct {
+	// MaxDrainAttempts is the number of periodic drains in a row, see Drain,
+	// in which the first record of a tree may fail with an error which is
+	// neither storage being unavailable nor a rejection of the leaves, before
+	// it is set aside as rejected, so that a record which storage never
+	// accepts doesn't block those after it. Storage being unavailable doesn't
+	// count, however long it lasts. Zero means no limit.
+	MaxDrainAttempts int
+
+	dir       string
+	maxLeaves int
+	depth     monitoring.Gauge
+
+	mu      sync.Mutex
+	leaves  int           // The number of leaves in the WAL, across trees.
+	pending map[int64]int // The number of leaves of each tree in the WAL.
+	trees   map[int64]*treeWAL
+}
+
+// treeWAL holds the records of a single tree. Its mutex serializes appending
+// and draining them, so that leaves are drained in order.
+type treeWAL struct {
+	mu       sync.Mutex
+	records  []*trillian.QueueLeavesRequest
+	attempts int // The number of periodic drains in a row of records[0] which failed.
+}
+
+// Open opens the WAL in the given directory, creating it if needed, and loads
+// the leaves left to drain. The WAL accepts up to maxLeaves leaves across all
+// trees, beyond which storage errors are returned to callers as if there was
+// no WAL. The number of leaves of each tree in the WAL is exported as the
+// queue_wal_leaves metric. MaxDrainAttempts is set to DefaultMaxDrainAttempts.
+func Open(dir string, maxLeaves int, mf monitoring.MetricFactory) (*WAL, error) {
+	if maxLeaves <= 0 {
+		return nil, fmt.Errorf("maximum number of leaves %d must be positive", maxLeaves)
+	}
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	w := &WAL{
+		MaxDrainAttempts: DefaultMaxDrainAttempts,
+		dir:              dir,
+		maxLeaves:        maxLeaves,
+		depth:            mf.NewGauge("queue_wal_leaves", "Number of leaves accepted into the queue write-ahead log and not yet drained into storage", monitoring.TreeIDLabel),
+		pending:          make(map[int64]int),
+		trees:            make(map[int64]*treeWAL),
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range files {
+		name := fi.Name()
+		if fi.IsDir() || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		treeID, err := strconv.ParseInt(strings.TrimSuffix(name, fileSuffix), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected WAL file %s: %v", name, err)
+		}
+		records, err := readRecords(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WAL of tree %d: %v", treeID, err)
+		}
+		if len(records) == 0 {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		w.trees[treeID] = &treeWAL{records: records}
+		n := countLeaves(records)
+		w.setPending(treeID, n)
+		glog.Infof("%d: loaded %d leaves to drain from the queue WAL", treeID, n)
+	}
+	return w, nil
+}
+
+// Pending returns the IDs of the trees with leaves left to drain, in
+// ascending order.
+func (w *WAL) Pending() []int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var ids []int64
+	for id := range w.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Queue queues leaves of the given tree in storage with queue, unless leaves
+// accepted into the WAL earlier for the tree can't be drained first. If the
+// leaves can't be queued because storage is unavailable, see IsUnavailable,
+// they are accepted into the WAL instead, space permitting, and reported as
+// new leaves.
+func (w *WAL) Queue(ctx context.Context, treeID int64, leaves []*trillian.LogLeaf, queueTimestamp time.Time, queue QueueFunc) ([]*trillian.QueuedLogLeaf, error) {
+	tw := w.tree(treeID)
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	var err error
+	if len(tw.records) > 0 {
+		err = w.drain(ctx, treeID, tw, queue, false)
+	}
+	if err == nil {
+		var ret []*trillian.QueuedLogLeaf
+		if ret, err = queue(ctx, leaves, queueTimestamp); !IsUnavailable(err) {
+			return ret, err
+		}
+	}
+	if aerr := w.append(treeID, tw, leaves, queueTimestamp); aerr != nil {
+		glog.Warningf("%d: %sfailed to accept %d leaves into the queue WAL: %v", treeID, requestid.LogPrefix(ctx), len(leaves), aerr)
+		return nil, err
+	}
+	glog.Warningf("%d: %saccepted %d leaves into the queue WAL: %v", treeID, requestid.LogPrefix(ctx), len(leaves), err)
+	ret := make([]*trillian.QueuedLogLeaf, 0, len(leaves))
+	for _, leaf := range leaves {
+		ret = append(ret, &trillian.QueuedLogLeaf{Leaf: leaf})
+	}
+	return ret, nil
+}
+
+// Drain queues the leaves of the given tree in the WAL in storage with queue,
+// in the order they were accepted, until storage is unavailable. It is meant
+// to be called periodically, and counts towards MaxDrainAttempts.
+func (w *WAL) Drain(ctx context.Context, treeID int64, queue QueueFunc) error {
+	tw := w.tree(treeID)
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return w.drain(ctx, treeID, tw, queue, true)
+}
+
+// IsUnavailable returns whether err, returned by storage, may be due to a
+// transient outage, which leaves are accepted into the WAL for. These are
+// errors with code UNAVAILABLE or DEADLINE_EXCEEDED, and errors connecting to
+// the database. Other database errors, which storage returns as they are, may
+// be due to the leaves themselves, so aren't.
+func IsUnavailable(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.As(err, &netErr)
+}
+
+// isRejected returns whether err, returned by storage, rejects the leaves
+// themselves, e.g. as invalid, or because their tree no longer exists, so that
+// queueing them again would fail the same way.
+func isRejected(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange, codes.NotFound, codes.Unimplemented:
+		return true
+	}
+	return false
+}
+
+// tree returns the records of the given tree, creating them if needed.
+func (w *WAL) tree(treeID int64) *treeWAL {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	tw, ok := w.trees[treeID]
+	if !ok {
+		tw = &treeWAL{}
+		w.trees[treeID] = tw
+	}
+	return tw
+}
+
+// append durably adds a record with the given leaves to the WAL of a tree.
+// It must be called with tw.mu held.
+func (w *WAL) append(treeID int64, tw *treeWAL, leaves []*trillian.LogLeaf, queueTimestamp time.Time) error {
+	ts, err := ptypes.TimestampProto(queueTimestamp)
+	if err != nil {
+		return err
+	}
+	record := &trillian.QueueLeavesRequest{LogId: treeID}
+	for _, leaf := range leaves {
+		leaf = proto.Clone(leaf).(*trillian.LogLeaf)
+		leaf.QueueTimestamp = ts
+		record.Leaves = append(record.Leaves, leaf)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.leaves+len(leaves) > w.maxLeaves {
+		return fmt.Errorf("WAL holds %d leaves, the maximum is %d", w.leaves, w.maxLeaves)
+	}
+	if err := appendRecord(w.path(treeID), record); err != nil {
+		return err
+	}
+	tw.records = append(tw.records, record)
+	w.setPending(treeID, w.pending[treeID]+len(leaves))
+	return nil
+}
+
+// drain queues the records of a tree in storage, in order, and removes those
+// which were queued from the WAL. Records which storage rejects, see
+// isRejected, as retrying them wouldn't help, and records which failed to
+// drain with other errors in MaxDrainAttempts periodic drains in a row, are
+// moved to the rejected file of the tree for operators to inspect. Records
+// are kept for as long as storage is unavailable. Records are removed after
+// they are queued, so leaves may be queued again if the server crashes in
+// between, which storage detects as duplicates. It must be called with tw.mu
+// held.
+func (w *WAL) drain(ctx context.Context, treeID int64, tw *treeWAL, queue QueueFunc, periodic bool) error {
+	var drained int
+	var err error
+records:
+	for _, record := range tw.records {
+		var ts time.Time
+		if ts, err = ptypes.Timestamp(record.Leaves[0].QueueTimestamp); err != nil {
+			return err
+		}
+		_, err = queue(ctx, record.Leaves, ts)
+		switch {
+		case err == nil:
+		case IsUnavailable(err):
+			// However long an outage lasts, the leaves were acknowledged, so
+			// they are kept until storage is back.
+			tw.attempts = 0
+			break records
+		case isRejected(err):
+			glog.Errorf("%d: rejecting %d leaves from the queue WAL rejected by storage: %v", treeID, len(record.Leaves), err)
+		default:
+			if periodic {
+				tw.attempts++
+			}
+			if w.MaxDrainAttempts == 0 || tw.attempts < w.MaxDrainAttempts {
+				break records
+			}
+			glog.Errorf("%d: rejecting %d leaves from the queue WAL which failed to drain %d times: %v", treeID, len(record.Leaves), tw.attempts, err)
+		}
+		if err != nil {
+			if err = appendRecord(w.path(treeID)+rejectedSuffix, record); err != nil {
+				break
+			}
+		}
+		tw.attempts = 0
+		drained++
+	}
+	if drained == 0 {
+		return err
+	}
+
+	rest := tw.records[drained:]
+	if perr := w.persist(treeID, rest); perr != nil {
+		// The drained records are retried later, which is harmless.
+		return perr
+	}
+	n := countLeaves(tw.records[:drained])
+	tw.records = rest
+	glog.Infof("%d: drained %d leaves from the queue WAL, %d left", treeID, n, countLeaves(rest))
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.setPending(treeID, w.pending[treeID]-n)
+	return err
+}
+
+// setPending updates the number of leaves of a tree in the WAL. It must be
+// called with w.mu held.
+func (w *WAL) setPending(treeID int64, n int) {
+	w.leaves += n - w.pending[treeID]
+	if n == 0 {
+		delete(w.pending, treeID)
+	} else {
+		w.pending[treeID] = n
+	}
+	w.depth.Set(float64(n), strconv.FormatInt(treeID, 10))
+}
+
+// persist replaces the WAL file of a tree with the given records, or removes
+// it if there are none.
+func (w *WAL) persist(treeID int64, records []*trillian.QueueLeavesRequest) error {
+	path := w.path(treeID)
+	if len(records) == 0 {
+		return os.Remove(path)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	for _, record := range records {
+		if err := writeRecord(bw, record); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (w *WAL) path(treeID int64) string {
+	return filepath.Join(w.dir, strconv.FormatInt(treeID, 10)+fileSuffix)
+}
+
+// appendRecord durably appends a record to a WAL file. If it fails, the file
+// is truncated to its previous size, so that a partly written record doesn't
+// hide those appended later.
+func appendRecord(path string, record *trillian.QueueLeavesRequest) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	defer func() {
+		if err != nil {
+			if terr := f.Truncate(fi.Size()); terr != nil {
+				glog.Errorf("%s: failed to truncate partly written record: %v", path, terr)
+			}
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	if err := writeRecord(f, record); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// writeRecord writes a record, framed by its size and checksum.
+func writeRecord(wr io.Writer, record *trillian.QueueLeavesRequest) error {
+	data, err := proto.Marshal(record)
+	if err != nil {
+		return err
+	}
+	var header [headerSize]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(data))
+	if _, err := wr.Write(header[:]); err != nil {
+		return err
+	}
+	_, err = wr.Write(data)
+	return err
+}
+
+// readRecords reads the records of a WAL file, and truncates a torn record at
+// the end of the file, so that records appended later can be read.
+func readRecords(path string) ([]*trillian.QueueLeavesRequest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []*trillian.QueueLeavesRequest
+	var offset int
+	for offset < len(data) {
+		record, size, err := parseRecord(data[offset:])
+		if err != nil {
+			return nil, fmt.Errorf("record at offset %d: %v", offset, err)
+		}
+		if record == nil {
+			glog.Warningf("%s: truncating torn record at offset %d", path, offset)
+			if err := os.Truncate(path, int64(offset)); err != nil {
+				return nil, err
+			}
+			break
+		}
+		records = append(records, record)
+		offset += size
+	}
+	return records, nil
+}
+
+// parseRecord parses the record at the start of data, and returns it with its
+// framed size. It returns a nil record if the record is torn.
+func parseRecord(data []byte) (*trillian.QueueLeavesRequest, int, error) {
+	if len(data) < headerSize {
+		return nil, 0, nil
+	}
+	size := binary.BigEndian.Uint32(data[:4])
+	sum := binary.BigEndian.Uint32(data[4:headerSize])
+	data = data[headerSize:]
+	if uint64(len(data)) < uint64(size) || crc32.ChecksumIEEE(data[:size]) != sum {
+		return nil, 0, nil
+	}
+	var record trillian.QueueLeavesRequest
+	if err := proto.Unmarshal(data[:size], &record); err != nil {
+		return nil, 0, err
+	}
+	if len(record.Leaves) == 0 {
+		return nil, 0, errors.New("no leaves")
+	}
+	return &record, headerSize + int(size), nil
+}
+
+func countLeaves(records []*trillian.QueueLeavesRequest) int {
+	var n int
+	for _, record := range records {
+		n += len(record.Leaves)
+	}
+	return n
+}
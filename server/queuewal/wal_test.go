@@ -0,0 +1,297 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queuewal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const treeID = 12345
+
+// fakeStorage queues leaves in memory, or fails with err if set.
+type fakeStorage struct {
+	err    error
+	leaves []string
+	times  []time.Time
+}
+
+func (s *fakeStorage) queue(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	var ret []*trillian.QueuedLogLeaf
+	for _, leaf := range leaves {
+		s.leaves = append(s.leaves, string(leaf.LeafValue))
+		s.times = append(s.times, queueTimestamp)
+		ret = append(ret, &trillian.QueuedLogLeaf{Leaf: leaf})
+	}
+	return ret, nil
+}
+
+func tempDir(t *testing.T) (string, func()) {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "queuewal")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
+
+func newLeaves(values ...string) []*trillian.LogLeaf {
+	var leaves []*trillian.LogLeaf
+	for _, v := range values {
+		leaves = append(leaves, &trillian.LogLeaf{LeafValue: []byte(v)})
+	}
+	return leaves
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	mf := monitoring.InertMetricFactory{}
+	w, err := Open(dir, 10, mf)
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	s := &fakeStorage{}
+	queue := func(values ...string) {
+		t.Helper()
+		rsp, err := w.Queue(ctx, treeID, newLeaves(values...), time.Unix(int64(len(s.leaves)+len(values)), 0), s.queue)
+		if err != nil {
+			t.Fatalf("Queue(%v): %v", values, err)
+		}
+		if len(rsp) != len(values) {
+			t.Errorf("Queue(%v) returned %d leaves, want %d", values, len(rsp), len(values))
+		}
+	}
+
+	queue("a")
+	s.err = fmt.Errorf("dial tcp: %w", syscall.ECONNREFUSED)
+	queue("b", "c")
+	queue("d")
+	if got, want := w.Pending(), []int64{treeID}; !cmp.Equal(got, want) {
+		t.Errorf("Pending() = %v, want %v", got, want)
+	}
+	if got, want := w.depth.(*monitoring.InertFloat).Value(fmt.Sprint(treeID)), 3.0; got != want {
+		t.Errorf("queue_wal_leaves = %v, want %v", got, want)
+	}
+
+	// The WAL survives a restart.
+	if w, err = Open(dir, 10, mf); err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	// Leaves in the WAL are drained before new ones, in order.
+	s.err = nil
+	queue("e")
+	if got, want := s.leaves, []string{"a", "b", "c", "d", "e"}; !cmp.Equal(got, want) {
+		t.Errorf("queued leaves = %v, want %v", got, want)
+	}
+	// The leaves accepted into the WAL keep their queue timestamp.
+	if got, want := s.times[1], time.Unix(3, 0); !got.Equal(want) {
+		t.Errorf("queue timestamp of drained leaf = %v, want %v", got, want)
+	}
+	if got := w.Pending(); len(got) != 0 {
+		t.Errorf("Pending() after draining = %v, want none", got)
+	}
+	if _, err := os.Stat(w.path(treeID)); !os.IsNotExist(err) {
+		t.Errorf("WAL file after draining: %v, want not to exist", err)
+	}
+}
+
+func TestQueueErrors(t *testing.T) {
+	ctx := context.Background()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	w, err := Open(dir, 2, nil)
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+
+	// Errors which aren't due to storage being unavailable are returned.
+	s := &fakeStorage{err: status.Error(codes.FailedPrecondition, "frozen")}
+	if _, err := w.Queue(ctx, treeID, newLeaves("a"), time.Unix(1, 0), s.queue); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Queue() = %v, want code %v", err, codes.FailedPrecondition)
+	}
+
+	// Leaves beyond the maximum get the storage error.
+	s.err = status.Error(codes.Unavailable, "down")
+	if _, err := w.Queue(ctx, treeID, newLeaves("a", "b"), time.Unix(1, 0), s.queue); err != nil {
+		t.Fatalf("Queue(): %v", err)
+	}
+	if _, err := w.Queue(ctx, treeID+1, newLeaves("c"), time.Unix(1, 0), s.queue); status.Code(err) != codes.Unavailable {
+		t.Errorf("Queue() beyond the maximum = %v, want code %v", err, codes.Unavailable)
+	}
+
+	// Draining while storage is down keeps the leaves.
+	if err := w.Drain(ctx, treeID, s.queue); err == nil {
+		t.Error("Drain() while storage is down succeeded, want error")
+	}
+	if got, want := w.Pending(), []int64{treeID}; !cmp.Equal(got, want) {
+		t.Errorf("Pending() = %v, want %v", got, want)
+	}
+
+	// Leaves which storage rejects when draining are set aside.
+	s.err = status.Error(codes.InvalidArgument, "bad leaf")
+	if err := w.Drain(ctx, treeID, s.queue); err != nil {
+		t.Errorf("Drain(): %v", err)
+	}
+	if got := w.Pending(); len(got) != 0 {
+		t.Errorf("Pending() after draining = %v, want none", got)
+	}
+	if records, err := readRecords(w.path(treeID) + rejectedSuffix); err != nil || len(records) != 1 {
+		t.Errorf("readRecords() of rejected file = (%v, %v), want 1 record", records, err)
+	}
+}
+
+func TestQueueDatabaseError(t *testing.T) {
+	ctx := context.Background()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	w, err := Open(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+
+	// Plain database errors may be due to the leaves, so they are returned
+	// rather than accepted into the WAL.
+	dbErr := errors.New("Error 1406: Data too long for column 'LeafValue'")
+	s := &fakeStorage{err: dbErr}
+	if _, err := w.Queue(ctx, treeID, newLeaves("a"), time.Unix(1, 0), s.queue); err != dbErr {
+		t.Errorf("Queue() = %v, want %v", err, dbErr)
+	}
+	if got := w.Pending(); len(got) != 0 {
+		t.Errorf("Pending() = %v, want none", got)
+	}
+}
+
+func TestDrainPoisonRecord(t *testing.T) {
+	ctx := context.Background()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	w, err := Open(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	w.MaxDrainAttempts = 3
+
+	down := &fakeStorage{err: status.Error(codes.Unavailable, "down")}
+	for _, v := range []string{"poison", "b"} {
+		if _, err := w.Queue(ctx, treeID, newLeaves(v), time.Unix(1, 0), down.queue); err != nil {
+			t.Fatalf("Queue(%v): %v", v, err)
+		}
+	}
+
+	// However many drains fail with storage unavailable, no record is set
+	// aside.
+	for i := 0; i < 2*w.MaxDrainAttempts; i++ {
+		if err := w.Drain(ctx, treeID, down.queue); status.Code(err) != codes.Unavailable {
+			t.Fatalf("Drain() while down = %v, want code %v", err, codes.Unavailable)
+		}
+	}
+	if _, err := os.Stat(w.path(treeID) + rejectedSuffix); !os.IsNotExist(err) {
+		t.Fatalf("Stat() of rejected file while down = %v, want not exist", err)
+	}
+
+	// A record which always fails otherwise blocks the others until it has
+	// failed MaxDrainAttempts periodic drains in a row.
+	s := &fakeStorage{}
+	queue := func(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+		if string(leaves[0].LeafValue) == "poison" {
+			return nil, errors.New("data too long")
+		}
+		return s.queue(ctx, leaves, queueTimestamp)
+	}
+	for i := 1; i < w.MaxDrainAttempts; i++ {
+		if err := w.Drain(ctx, treeID, queue); err == nil || IsUnavailable(err) {
+			t.Fatalf("Drain() #%d = %v, want the poison error", i, err)
+		}
+	}
+	if len(s.leaves) != 0 {
+		t.Errorf("queued leaves = %v, want none", s.leaves)
+	}
+	if err := w.Drain(ctx, treeID, queue); err != nil {
+		t.Fatalf("Drain() #%d: %v", w.MaxDrainAttempts, err)
+	}
+	if got, want := s.leaves, []string{"b"}; !cmp.Equal(got, want) {
+		t.Errorf("queued leaves = %v, want %v", got, want)
+	}
+	if got := w.Pending(); len(got) != 0 {
+		t.Errorf("Pending() after draining = %v, want none", got)
+	}
+	records, err := readRecords(w.path(treeID) + rejectedSuffix)
+	if err != nil {
+		t.Fatalf("readRecords() of rejected file: %v", err)
+	}
+	if len(records) != 1 || string(records[0].Leaves[0].LeafValue) != "poison" {
+		t.Errorf("rejected records = %v, want the poison record", records)
+	}
+}
+
+func TestOpenTornRecord(t *testing.T) {
+	ctx := context.Background()
+	dir, cleanup := tempDir(t)
+	defer cleanup()
+	w, err := Open(dir, 10, nil)
+	if err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	s := &fakeStorage{err: status.Error(codes.Unavailable, "connection refused")}
+	if _, err := w.Queue(ctx, treeID, newLeaves("a"), time.Unix(1, 0), s.queue); err != nil {
+		t.Fatalf("Queue(): %v", err)
+	}
+
+	// A crash while appending the second record leaves part of it.
+	path := filepath.Join(dir, fmt.Sprintf("%d.wal", treeID))
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatalf("OpenFile(): %v", err)
+	}
+	if _, err := f.Write([]byte{0, 0, 0, 100, 1, 2}); err != nil {
+		t.Fatalf("Write(): %v", err)
+	}
+	f.Close()
+
+	if w, err = Open(dir, 10, nil); err != nil {
+		t.Fatalf("Open() with torn record: %v", err)
+	}
+	if _, err := w.Queue(ctx, treeID, newLeaves("b"), time.Unix(2, 0), s.queue); err != nil {
+		t.Fatalf("Queue(): %v", err)
+	}
+	if w, err = Open(dir, 10, nil); err != nil {
+		t.Fatalf("Open(): %v", err)
+	}
+	s.err = nil
+	if err := w.Drain(ctx, treeID, s.queue); err != nil {
+		t.Fatalf("Drain(): %v", err)
+	}
+	if got, want := s.leaves, []string{"a", "b"}; !cmp.Equal(got, want) {
+		t.Errorf("drained leaves = %v, want %v", got, want)
+	}
+}
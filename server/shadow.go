@@ -0,0 +1,155 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/trees"
+	"github.com/google/trillian/util/requestid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LeafTransform converts a leaf queued to a log into the leaf to queue to its
+// shadow log, e.g. to change its format. It may modify and return leaf, which
+// is a copy. An error is reported as the status of the leaf in the shadow log.
+type LeafTransform func(ctx context.Context, leaf *trillian.LogLeaf) (*trillian.LogLeaf, error)
+
+// Shadow configures the shadow log of a log, which the leaves queued to the
+// log are also queued to.
+type Shadow struct {
+	// LogID is the ID of the shadow log.
+	LogID int64
+	// Transform, if set, converts the leaves queued to the log. Otherwise
+	// they are queued unchanged. Their hashes are computed for the shadow log.
+	Transform LeafTransform
+}
+
+// ParseShadows parses a comma-separated list of log=shadow pairs of log IDs
+// into Shadows which queue leaves unchanged.
+func ParseShadows(s string) (map[int64]Shadow, error) {
+	shadows := make(map[int64]Shadow)
+	if s == "" {
+		return shadows, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid shadow log %q, want log=shadow", pair)
+		}
+		logID, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log ID in %q: %v", pair, err)
+		}
+		shadowID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shadow log ID in %q: %v", pair, err)
+		}
+		if logID == shadowID {
+			return nil, fmt.Errorf("log %d can't be its own shadow", logID)
+		}
+		if _, ok := shadows[logID]; ok {
+			return nil, fmt.Errorf("duplicate shadow for log %d", logID)
+		}
+		shadows[logID] = Shadow{LogID: shadowID}
+	}
+	return shadows, nil
+}
+
+// queueShadow queues copies of the leaves queued to the log, as they were
+// before being hashed for it, to its shadow log. Only the leaves which queued
+// reports as queued in the log are queued, and the others get an ABORTED
+// status. Shadow logs of the shadow log are ignored.
+func (t *TrillianLogRPCServer) queueShadow(ctx context.Context, shadow Shadow, leaves []*trillian.LogLeaf, queued []*trillian.QueuedLogLeaf) *trillian.ShadowQueueResult {
+	ctx, spanEnd := spanFor(ctx, "queueShadow")
+	defer spanEnd()
+	result := &trillian.ShadowQueueResult{LogId: shadow.LogID}
+	// The context holds the tree of the log, unless it's cleared.
+	ctx = trees.NewContext(ctx, nil)
+	tree, hasher, err := t.getTreeAndHasher(ctx, shadow.LogID, optsLogWrite)
+	if err != nil {
+		return shadowFailed(ctx, result, err)
+	}
+	ctx = trees.NewContext(ctx, tree)
+
+	ret := make([]*trillian.QueuedLogLeaf, len(leaves))
+	var toQueue []*trillian.LogLeaf
+	var indices []int
+	for i, leaf := range leaves {
+		if i >= len(queued) || !isQueued(queued[i]) {
+			ret[i] = &trillian.QueuedLogLeaf{Status: status.Newf(codes.Aborted, "not queued to log %d", tree.TreeId).Proto()}
+			continue
+		}
+		if shadow.Transform != nil {
+			if leaf, err = shadow.Transform(ctx, leaf); err != nil {
+				ret[i] = &trillian.QueuedLogLeaf{Status: status.Convert(err).Proto()}
+				continue
+			}
+		}
+		if err := validateLogLeaf(leaf, fmt.Sprintf("leaves[%d]", i)); err != nil {
+			ret[i] = &trillian.QueuedLogLeaf{Status: status.Convert(err).Proto()}
+			continue
+		}
+		toQueue = append(toQueue, leaf)
+		indices = append(indices, i)
+	}
+
+	if len(toQueue) > 0 {
+		if err := hashLeaves(tree, toQueue, hasher); err != nil {
+			return shadowFailed(ctx, result, err)
+		}
+		shadowQueued, err := t.queueValidLeaves(ctx, tree, toQueue, nil)
+		if err != nil {
+			return shadowFailed(ctx, result, err)
+		}
+		if got, want := len(shadowQueued), len(toQueue); got != want {
+			return shadowFailed(ctx, result, status.Errorf(codes.Internal, "QueueLeaves returned %d leaves, want: %d", got, want))
+		}
+		for j, i := range indices {
+			ret[i] = shadowQueued[j]
+		}
+	}
+	result.QueuedLeaves = ret
+	return result
+}
+
+// shadowFailed returns result with the status of err.
+func shadowFailed(ctx context.Context, result *trillian.ShadowQueueResult, err error) *trillian.ShadowQueueResult {
+	glog.Warningf("%d: %sfailed to queue leaves to shadow log: %v", result.LogId, requestid.LogPrefix(ctx), err)
+	result.Status = status.Convert(err).Proto()
+	return result
+}
+
+// isQueued returns whether l is queued in the log, either as a new leaf or as
+// an existing one.
+func isQueued(l *trillian.QueuedLogLeaf) bool {
+	return l.Status == nil || l.Status.Code == int32(codes.OK) || l.Status.Code == int32(codes.AlreadyExists)
+}
+
+// cloneLeaves returns deep copies of leaves.
+func cloneLeaves(leaves []*trillian.LogLeaf) []*trillian.LogLeaf {
+	ret := make([]*trillian.LogLeaf, len(leaves))
+	for i, leaf := range leaves {
+		ret[i] = proto.Clone(leaf).(*trillian.LogLeaf)
+	}
+	return ret
+}
@@ -0,0 +1,155 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian"
+	"github.com/google/trillian/extension"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	stestonly "github.com/google/trillian/storage/testonly"
+	"google.golang.org/grpc/codes"
+)
+
+func TestQueueLeaves_Shadow(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	registry := extension.Registry{
+		AdminStorage: memory.NewAdminStorage(ts),
+		LogStorage:   memory.NewLogStorage(ts, nil),
+	}
+	newLog := func() *trillian.Tree {
+		t.Helper()
+		tree, err := storage.CreateTree(ctx, registry.AdminStorage, proto.Clone(stestonly.LogTree).(*trillian.Tree))
+		if err != nil {
+			t.Fatalf("CreateTree(): %v", err)
+		}
+		return tree
+	}
+	logTree, shadowTree, unshadowedTree := newLog(), newLog(), newLog()
+
+	s := NewTrillianLogRPCServer(registry, fakeTimeSource)
+	for _, tree := range []*trillian.Tree{logTree, shadowTree, unshadowedTree} {
+		if _, err := s.InitLog(ctx, &trillian.InitLogRequest{LogId: tree.TreeId}); err != nil {
+			t.Fatalf("InitLog(): %v", err)
+		}
+	}
+	s.Shadows = map[int64]Shadow{
+		logTree.TreeId: {
+			LogID: shadowTree.TreeId,
+			Transform: func(ctx context.Context, leaf *trillian.LogLeaf) (*trillian.LogLeaf, error) {
+				if string(leaf.LeafValue) == "untransformable" {
+					return nil, errors.New("bad leaf")
+				}
+				leaf.LeafValue = append([]byte("v2:"), leaf.LeafValue...)
+				return leaf, nil
+			},
+		},
+		unshadowedTree.TreeId: {LogID: 12345},
+	}
+
+	queue := func(logID int64, values ...string) *trillian.QueueLeavesResponse {
+		t.Helper()
+		req := &trillian.QueueLeavesRequest{LogId: logID}
+		for _, v := range values {
+			req.Leaves = append(req.Leaves, &trillian.LogLeaf{LeafValue: []byte(v)})
+		}
+		rsp, err := s.QueueLeaves(ctx, req)
+		if err != nil {
+			t.Fatalf("QueueLeaves(): %v", err)
+		}
+		return rsp
+	}
+	code := func(l *trillian.QueuedLogLeaf) codes.Code {
+		return codes.Code(l.GetStatus().GetCode())
+	}
+
+	rsp := queue(logTree.TreeId, "a", "untransformable")
+	shadow := rsp.Shadow
+	if shadow == nil || shadow.LogId != shadowTree.TreeId || shadow.Status != nil {
+		t.Fatalf("QueueLeaves().Shadow = %v, want result for log %d", shadow, shadowTree.TreeId)
+	}
+	if got, want := len(shadow.QueuedLeaves), 2; got != want {
+		t.Fatalf("QueueLeaves().Shadow has %d leaves, want %d", got, want)
+	}
+	if got, want := shadow.QueuedLeaves[0].GetLeaf().GetLeafValue(), []byte("v2:a"); !bytes.Equal(got, want) {
+		t.Errorf("Shadow leaf value = %q, want %q", got, want)
+	}
+	if got, want := shadow.QueuedLeaves[0].GetLeaf().GetMerkleLeafHash(), rfc6962.DefaultHasher.HashLeaf([]byte("v2:a")); !bytes.Equal(got, want) {
+		t.Errorf("Shadow leaf hash = %x, want %x", got, want)
+	}
+	if got, want := code(shadow.QueuedLeaves[1]), codes.Unknown; got != want {
+		t.Errorf("Untransformable shadow leaf has status %v, want %v", got, want)
+	}
+	// The leaves queued to the log are unchanged.
+	if got, want := rsp.QueuedLeaves[0].GetLeaf().GetLeafValue(), []byte("a"); !bytes.Equal(got, want) {
+		t.Errorf("Leaf value = %q, want %q", got, want)
+	}
+
+	// Failing to queue to the shadow log doesn't fail the request.
+	rsp = queue(unshadowedTree.TreeId, "a")
+	if got, want := code(rsp.QueuedLeaves[0]), codes.OK; got != want {
+		t.Errorf("Leaf has status %v, want %v", got, want)
+	}
+	if rsp.Shadow == nil || rsp.Shadow.Status == nil || len(rsp.Shadow.QueuedLeaves) != 0 {
+		t.Errorf("QueueLeaves().Shadow = %v, want error status", rsp.Shadow)
+	}
+
+	// Logs without a shadow report none, and shadow logs aren't chained.
+	if rsp := queue(shadowTree.TreeId, "b"); rsp.Shadow != nil {
+		t.Errorf("QueueLeaves().Shadow = %v, want nil", rsp.Shadow)
+	}
+
+	leafRsp, err := s.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: logTree.TreeId, Leaf: &trillian.LogLeaf{LeafValue: []byte("c")}})
+	if err != nil {
+		t.Fatalf("QueueLeaf(): %v", err)
+	}
+	if got, want := leafRsp.GetShadow().GetQueuedLeaves()[0].GetLeaf().GetLeafValue(), []byte("v2:c"); !bytes.Equal(got, want) {
+		t.Errorf("QueueLeaf() shadow leaf value = %q, want %q", got, want)
+	}
+}
+
+func TestParseShadows(t *testing.T) {
+	for _, tc := range []struct {
+		s       string
+		want    map[int64]Shadow
+		wantErr bool
+	}{
+		{s: "", want: map[int64]Shadow{}},
+		{s: "1=2,3=2", want: map[int64]Shadow{1: {LogID: 2}, 3: {LogID: 2}}},
+		{s: "1", wantErr: true},
+		{s: "a=2", wantErr: true},
+		{s: "1=b", wantErr: true},
+		{s: "1=1", wantErr: true},
+		{s: "1=2,1=3", wantErr: true},
+	} {
+		got, err := ParseShadows(tc.s)
+		if gotErr := err != nil; gotErr != tc.wantErr {
+			t.Errorf("ParseShadows(%q): %v, wantErr %v", tc.s, err, tc.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(got, tc.want); diff != "" {
+			t.Errorf("ParseShadows(%q) diff (-got +want):\n%s", tc.s, diff)
+		}
+	}
+}
@@ -16,7 +16,9 @@ package server
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/merkle/hashers"
 	"google.golang.org/grpc/codes"
@@ -30,8 +32,15 @@ func validateGetInclusionProofRequest(req *trillian.GetInclusionProofRequest) er
 	if req.LeafIndex < 0 {
 		return status.Errorf(codes.InvalidArgument, "GetInclusionProofRequest.LeafIndex: %v, want >= 0", req.LeafIndex)
 	}
-	if req.LeafIndex >= req.TreeSize {
-		return status.Errorf(codes.InvalidArgument, "GetInclusionProofRequest.LeafIndex: %v >= TreeSize: %v, want < ", req.LeafIndex, req.TreeSize)
+	return nil
+}
+
+func validateGetHistoricalInclusionProofRequest(req *trillian.GetHistoricalInclusionProofRequest) error {
+	if req.TreeSize <= 0 {
+		return status.Errorf(codes.InvalidArgument, "GetHistoricalInclusionProofRequest.TreeSize: %v, want > 0", req.TreeSize)
+	}
+	if req.LeafIndex < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetHistoricalInclusionProofRequest.LeafIndex: %v, want >= 0", req.LeafIndex)
 	}
 	return nil
 }
@@ -71,8 +80,12 @@ func validateGetLeavesByIndexRequest(req *trillian.GetLeavesByIndexRequest) erro
 }
 
 func validateGetLeavesByRangeRequest(req *trillian.GetLeavesByRangeRequest) error {
-	if req.StartIndex < 0 {
-		return status.Errorf(codes.InvalidArgument, "GetLeavesByRangeRequest.StartIndex: %v, want >= 0", req.StartIndex)
+	offset, ok := indexOffsets[req.IndexBase]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "GetLeavesByRangeRequest.IndexBase: %v, want INDEX_BASE_ZERO or INDEX_BASE_ONE", req.IndexBase)
+	}
+	if req.StartIndex < offset {
+		return status.Errorf(codes.InvalidArgument, "GetLeavesByRangeRequest.StartIndex: %v, want >= %v", req.StartIndex, offset)
 	}
 	if req.Count <= 0 {
 		return status.Errorf(codes.InvalidArgument, "GetLeavesByRangeRequest.Count: %v, want > 0", req.Count)
@@ -80,12 +93,32 @@ func validateGetLeavesByRangeRequest(req *trillian.GetLeavesByRangeRequest) erro
 	return nil
 }
 
+func validateGetLeavesByKeyRangeRequest(req *trillian.GetLeavesByKeyRangeRequest) error {
+	if req.StartIndex < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetLeavesByKeyRangeRequest.StartIndex: %v, want >= 0", req.StartIndex)
+	}
+	if req.Count <= 0 {
+		return status.Errorf(codes.InvalidArgument, "GetLeavesByKeyRangeRequest.Count: %v, want > 0", req.Count)
+	}
+	return nil
+}
+
+func validateGetEffectiveLeavesRequest(req *trillian.GetEffectiveLeavesRequest) error {
+	if req.StartIndex < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetEffectiveLeavesRequest.StartIndex: %v, want >= 0", req.StartIndex)
+	}
+	if req.Count <= 0 {
+		return status.Errorf(codes.InvalidArgument, "GetEffectiveLeavesRequest.Count: %v, want > 0", req.Count)
+	}
+	return nil
+}
+
 func validateGetConsistencyProofRequest(req *trillian.GetConsistencyProofRequest) error {
-	if req.FirstTreeSize <= 0 {
-		return status.Errorf(codes.InvalidArgument, "GetConsistencyProofRequest.FirstTreeSize: %v, want > 0", req.FirstTreeSize)
+	if req.FirstTreeSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetConsistencyProofRequest.FirstTreeSize: %v, want >= 0", req.FirstTreeSize)
 	}
-	if req.SecondTreeSize <= 0 {
-		return status.Errorf(codes.InvalidArgument, "GetConsistencyProofRequest.SecondTreeSize: %v, want > 0", req.SecondTreeSize)
+	if req.SecondTreeSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetConsistencyProofRequest.SecondTreeSize: %v, want >= 0", req.SecondTreeSize)
 	}
 	if req.SecondTreeSize < req.FirstTreeSize {
 		return status.Errorf(codes.InvalidArgument, "GetConsistencyProofRequest.SecondTreeSize: %v < GetConsistencyProofRequest.FirstTreeSize: %v, want >= ", req.SecondTreeSize, req.FirstTreeSize)
@@ -93,36 +126,143 @@ func validateGetConsistencyProofRequest(req *trillian.GetConsistencyProofRequest
 	return nil
 }
 
+func validateGetProofAtRevisionRequest(req *trillian.GetProofAtRevisionRequest) error {
+	if req.Revision < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetProofAtRevisionRequest.Revision: %v, want >= 0", req.Revision)
+	}
+	if req.TreeSize <= 0 {
+		return status.Errorf(codes.InvalidArgument, "GetProofAtRevisionRequest.TreeSize: %v, want > 0", req.TreeSize)
+	}
+	if req.FirstTreeSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetProofAtRevisionRequest.FirstTreeSize: %v, want >= 0", req.FirstTreeSize)
+	}
+	if req.FirstTreeSize > req.TreeSize {
+		return status.Errorf(codes.InvalidArgument, "GetProofAtRevisionRequest.FirstTreeSize: %v > TreeSize: %v, want <= ", req.FirstTreeSize, req.TreeSize)
+	}
+	if req.FirstTreeSize == 0 && req.LeafIndex < 0 {
+		return status.Errorf(codes.InvalidArgument, "GetProofAtRevisionRequest.LeafIndex: %v, want >= 0", req.LeafIndex)
+	}
+	return nil
+}
+
+func validatePredictRootRequest(req *trillian.PredictRootRequest, hasher hashers.LogHasher) error {
+	if got, max := len(req.LeafHashes), maxPredictRootLeaves; got > max {
+		return status.Errorf(codes.InvalidArgument, "PredictRootRequest.LeafHashes: %d hashes, want <= %d", got, max)
+	}
+	for i, hash := range req.LeafHashes {
+		if err := validateLeafHash(hash, hasher); err != nil {
+			return status.Errorf(codes.InvalidArgument, "PredictRootRequest.LeafHashes[%v]: %v", i, err)
+		}
+	}
+	return nil
+}
+
 func validateGetEntryAndProofRequest(req *trillian.GetEntryAndProofRequest) error {
 	if req.TreeSize <= 0 {
 		return status.Errorf(codes.InvalidArgument, "GetEntryAndProofRequest.TreeSize: %v, want > 0", req.TreeSize)
 	}
-	if req.LeafIndex < 0 {
-		return status.Errorf(codes.InvalidArgument, "GetEntryAndProofRequest.LeafIndex: %v, want >= 0", req.LeafIndex)
+	offset, ok := indexOffsets[req.IndexBase]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "GetEntryAndProofRequest.IndexBase: %v, want INDEX_BASE_ZERO or INDEX_BASE_ONE", req.IndexBase)
 	}
-	if req.LeafIndex >= req.TreeSize {
-		return status.Errorf(codes.InvalidArgument, "GetEntryAndProofRequest.LeafIndex: %v >= TreeSize: %v, want < ", req.LeafIndex, req.TreeSize)
+	if req.LeafIndex < offset {
+		return status.Errorf(codes.InvalidArgument, "GetEntryAndProofRequest.LeafIndex: %v, want >= %v", req.LeafIndex, offset)
 	}
 	return nil
 }
 
+// validateProofLeafIndex checks that index, the leaf_index of a request for a
+// proof at treeSize, is in a tree whose first leaf has index first in the
+// request, and returns the 0-based index of the leaf. The index can only be
+// checked against the tree size once the leaf_index_offset of the tree is
+// known.
+func validateProofLeafIndex(field string, index, first, treeSize int64) (int64, error) {
+	if index < first {
+		return 0, status.Errorf(codes.InvalidArgument, "%s: %v, want >= %v", field, index, first)
+	}
+	if index-first >= treeSize {
+		return 0, status.Errorf(codes.InvalidArgument, "%s: %v >= TreeSize: %v, want < ", field, index-first, treeSize)
+	}
+	return index - first, nil
+}
+
 func validateAddSequencedLeavesRequest(req *trillian.AddSequencedLeavesRequest) error {
-	prefix := "AddSequencedLeavesRequest"
-	if err := validateLogLeaves(req.Leaves, prefix); err != nil {
+	return validateSequencedLeaves(req.Leaves, "AddSequencedLeavesRequest")
+}
+
+func validateBulkAddSequencedLeavesRequest(req *trillian.BulkAddSequencedLeavesRequest) error {
+	if req.ChunkSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "BulkAddSequencedLeavesRequest.ChunkSize: %v, want >= 0", req.ChunkSize)
+	}
+	return validateSequencedLeaves(req.Leaves, "BulkAddSequencedLeavesRequest")
+}
+
+// validateSequencedLeaves checks that leaves are valid, have contiguous
+// ascending indices, and valid timestamps if set.
+func validateSequencedLeaves(leaves []*trillian.LogLeaf, prefix string) error {
+	if err := validateLogLeaves(leaves, prefix); err != nil {
 		return err
 	}
 
 	// Note: Not empty, as verified by validateLogLeaves.
-	nextIndex := req.Leaves[0].LeafIndex
-	for i, leaf := range req.Leaves {
+	nextIndex := leaves[0].LeafIndex
+	for i, leaf := range leaves {
 		if leaf.LeafIndex != nextIndex {
 			return status.Errorf(codes.FailedPrecondition, "%v.Leaves[%v].LeafIndex=%v, want %v", prefix, i, leaf.LeafIndex, nextIndex)
 		}
+		if leaf.QueueTimestamp != nil {
+			if _, err := ptypes.Timestamp(leaf.QueueTimestamp); err != nil {
+				return status.Errorf(codes.InvalidArgument, "%v.Leaves[%v].QueueTimestamp invalid: %v", prefix, i, err)
+			}
+		}
+		if leaf.IntegrateTimestamp != nil {
+			if _, err := ptypes.Timestamp(leaf.IntegrateTimestamp); err != nil {
+				return status.Errorf(codes.InvalidArgument, "%v.Leaves[%v].IntegrateTimestamp invalid: %v", prefix, i, err)
+			}
+		}
 		nextIndex++
 	}
 	return nil
 }
 
+// validateLeafTimestampOrder checks that leaves have non-decreasing integrate
+// timestamps, also with respect to the adjacent stored leaves prev and next, if
+// not nil. All of the passed in leaves must have an integrate timestamp.
+func validateLeafTimestampOrder(leaves []*trillian.LogLeaf, prev, next *trillian.LogLeaf) error {
+	prefix := "AddSequencedLeavesRequest"
+	var last time.Time
+	if prev != nil && prev.IntegrateTimestamp != nil {
+		ts, err := ptypes.Timestamp(prev.IntegrateTimestamp)
+		if err != nil {
+			return status.Errorf(codes.Internal, "leaf %v has invalid integrate_timestamp: %v", prev.LeafIndex, err)
+		}
+		last = ts
+	}
+	for i, leaf := range leaves {
+		if leaf.IntegrateTimestamp == nil {
+			return status.Errorf(codes.InvalidArgument, "%v.Leaves[%v].IntegrateTimestamp empty, required by the tree", prefix, i)
+		}
+		ts, err := ptypes.Timestamp(leaf.IntegrateTimestamp)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "%v.Leaves[%v].IntegrateTimestamp invalid: %v", prefix, i, err)
+		}
+		if ts.Before(last) {
+			return status.Errorf(codes.FailedPrecondition, "%v.Leaves[%v].IntegrateTimestamp=%v, want >= %v", prefix, i, ts, last)
+		}
+		last = ts
+	}
+	if next != nil && next.IntegrateTimestamp != nil {
+		ts, err := ptypes.Timestamp(next.IntegrateTimestamp)
+		if err != nil {
+			return status.Errorf(codes.Internal, "leaf %v has invalid integrate_timestamp: %v", next.LeafIndex, err)
+		}
+		if ts.Before(last) {
+			return status.Errorf(codes.FailedPrecondition, "%v.Leaves[%v].IntegrateTimestamp=%v, want <= %v (leaf %v)", prefix, len(leaves)-1, last, ts, next.LeafIndex)
+		}
+	}
+	return nil
+}
+
 func validateLogLeaves(leaves []*trillian.LogLeaf, errPrefix string) error {
 	if len(leaves) == 0 {
 		return status.Errorf(codes.InvalidArgument, "%v.Leaves empty", errPrefix)
@@ -140,7 +280,8 @@ func validateLogLeaf(leaf *trillian.LogLeaf, errPrefix string) error {
 		return status.Errorf(codes.InvalidArgument, "%v empty", errPrefix)
 	}
 	switch {
-	case len(leaf.LeafValue) == 0:
+	case len(leaf.LeafValue) == 0 && len(leaf.MerkleLeafHash) == 0:
+		// Leaves of hash-only trees have a hash instead, see hashLeaves.
 		return status.Errorf(codes.InvalidArgument, "%v.LeafValue: empty", errPrefix)
 	case leaf.LeafIndex < 0:
 		return status.Errorf(codes.InvalidArgument, "%v.LeafIndex: %v, want >= 0", errPrefix, leaf.LeafIndex)
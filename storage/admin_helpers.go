@@ -125,6 +125,78 @@ func UndeleteTree(ctx context.Context, admin AdminStorage, treeID int64) (*trill
 	return tree, err
 }
 
+// GetTreeAttestation reads the attestation of a tree from storage using a snapshot transaction.
+// It's a convenience wrapper around RunInAdminSnapshot and AdminReader's GetTreeAttestation.
+func GetTreeAttestation(ctx context.Context, admin AdminStorage, treeID int64) (*trillian.TreeAttestation, error) {
+	ctx, spanEnd := spanFor(ctx, "GetTreeAttestation")
+	defer spanEnd()
+	var a *trillian.TreeAttestation
+	err := RunInAdminSnapshot(ctx, admin, func(tx ReadOnlyAdminTX) error {
+		var err error
+		a, err = tx.GetTreeAttestation(ctx, treeID)
+		return err
+	})
+	return a, err
+}
+
+// GetTreeTemplate reads a tree template from storage using a snapshot transaction.
+// It's a convenience wrapper around RunInAdminSnapshot and AdminReader's GetTreeTemplate.
+func GetTreeTemplate(ctx context.Context, admin AdminStorage, name string) (*trillian.TreeTemplate, error) {
+	ctx, spanEnd := spanFor(ctx, "GetTreeTemplate")
+	defer spanEnd()
+	var tmpl *trillian.TreeTemplate
+	err := RunInAdminSnapshot(ctx, admin, func(tx ReadOnlyAdminTX) error {
+		var err error
+		tmpl, err = tx.GetTreeTemplate(ctx, name)
+		return err
+	})
+	return tmpl, err
+}
+
+// ListTreeTemplates reads all tree templates from storage using a snapshot transaction.
+// It's a convenience wrapper around RunInAdminSnapshot and AdminReader's ListTreeTemplates.
+func ListTreeTemplates(ctx context.Context, admin AdminStorage) ([]*trillian.TreeTemplate, error) {
+	ctx, spanEnd := spanFor(ctx, "ListTreeTemplates")
+	defer spanEnd()
+	var resp []*trillian.TreeTemplate
+	err := RunInAdminSnapshot(ctx, admin, func(tx ReadOnlyAdminTX) error {
+		var err error
+		resp, err = tx.ListTreeTemplates(ctx)
+		return err
+	})
+	return resp, err
+}
+
+// CreateTreeTemplate creates a tree template in storage.
+// It's a convenience wrapper around ReadWriteTransaction and AdminWriter's CreateTreeTemplate.
+func CreateTreeTemplate(ctx context.Context, admin AdminStorage, tmpl *trillian.TreeTemplate) error {
+	ctx, spanEnd := spanFor(ctx, "CreateTreeTemplate")
+	defer spanEnd()
+	return admin.ReadWriteTransaction(ctx, func(ctx context.Context, tx AdminTX) error {
+		return tx.CreateTreeTemplate(ctx, tmpl)
+	})
+}
+
+// UpdateTreeTemplate replaces a tree template in storage.
+// It's a convenience wrapper around ReadWriteTransaction and AdminWriter's UpdateTreeTemplate.
+func UpdateTreeTemplate(ctx context.Context, admin AdminStorage, tmpl *trillian.TreeTemplate) error {
+	ctx, spanEnd := spanFor(ctx, "UpdateTreeTemplate")
+	defer spanEnd()
+	return admin.ReadWriteTransaction(ctx, func(ctx context.Context, tx AdminTX) error {
+		return tx.UpdateTreeTemplate(ctx, tmpl)
+	})
+}
+
+// DeleteTreeTemplate deletes a tree template from storage.
+// It's a convenience wrapper around ReadWriteTransaction and AdminWriter's DeleteTreeTemplate.
+func DeleteTreeTemplate(ctx context.Context, admin AdminStorage, name string) error {
+	ctx, spanEnd := spanFor(ctx, "DeleteTreeTemplate")
+	defer spanEnd()
+	return admin.ReadWriteTransaction(ctx, func(ctx context.Context, tx AdminTX) error {
+		return tx.DeleteTreeTemplate(ctx, name)
+	})
+}
+
 // RunInAdminSnapshot runs fn against a ReadOnlyAdminTX and commits if no error is returned.
 func RunInAdminSnapshot(ctx context.Context, admin AdminStorage, fn func(tx ReadOnlyAdminTX) error) error {
 	tx, err := admin.Snapshot(ctx)
@@ -88,14 +88,29 @@ type AdminReader interface {
 	// Note that there's no authorization restriction on the trees returned,
 	// so it should be used with caution in production code.
 	ListTrees(ctx context.Context, includeDeleted bool) ([]*trillian.Tree, error)
+
+	// GetTreeTemplate returns the tree template called name, or a NotFound
+	// error if there is none.
+	GetTreeTemplate(ctx context.Context, name string) (*trillian.TreeTemplate, error)
+
+	// ListTreeTemplates returns all tree templates in storage, in order of
+	// name.
+	ListTreeTemplates(ctx context.Context) ([]*trillian.TreeTemplate, error)
+
+	// GetTreeAttestation returns the attestation of the settings the tree
+	// was created with, or a NotFound error if there is none.
+	GetTreeAttestation(ctx context.Context, treeID int64) (*trillian.TreeAttestation, error)
 }
 
 // AdminWriter provides a write-only interface for tree data.
 type AdminWriter interface {
 	// CreateTree inserts the specified tree in storage, returning a tree
 	// with all storage-generated fields set.
-	// Note that treeID and timestamps will be automatically generated by
-	// the storage layer, thus may be ignored by the implementation.
+	// If tree.TreeId is set it's used as the ID of the new tree, and an
+	// AlreadyExists error is returned if the ID is already taken; otherwise
+	// a random ID is generated by the storage layer.
+	// Timestamps will be automatically generated by the storage layer, thus
+	// may be ignored by the implementation.
 	// Remaining fields must be set to valid values.
 	// Returns an error if the tree is invalid or creation fails.
 	CreateTree(ctx context.Context, tree *trillian.Tree) (*trillian.Tree, error)
@@ -125,4 +140,42 @@ type AdminWriter interface {
 	// The tree must exist and currently be soft deleted, as per SoftDeletedTree, otherwise an error
 	// is returned.
 	UndeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error)
+
+	// CreateTreeTemplate inserts the specified tree template in storage.
+	// An AlreadyExists error is returned if a template with the same name
+	// exists. The template must be valid, as per ValidateTreeTemplate.
+	CreateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error
+
+	// UpdateTreeTemplate replaces the tree template of the same name in
+	// storage. The template must exist, otherwise a NotFound error is
+	// returned, and be valid, as per ValidateTreeTemplate.
+	UpdateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error
+
+	// DeleteTreeTemplate removes the tree template called name from storage.
+	// The template must exist, otherwise a NotFound error is returned.
+	DeleteTreeTemplate(ctx context.Context, name string) error
+
+	// CreateTreeAttestation stores the attestation of the settings the tree
+	// was created with. The tree must exist, and attestations can't be
+	// replaced: an AlreadyExists error is returned if the tree has one.
+	CreateTreeAttestation(ctx context.Context, treeID int64, a *trillian.TreeAttestation) error
+}
+
+// DisplayNameLocker is implemented by AdminTXs which can lock the trees with
+// a display name. See CheckDisplayNameAvailable.
+type DisplayNameLocker interface {
+	// LockTreesByDisplayName returns the IDs of the non-deleted trees called
+	// displayName, and prevents other transactions from creating trees
+	// called displayName until this one ends. It returns an Unimplemented
+	// error if the storage can't do so.
+	LockTreesByDisplayName(ctx context.Context, displayName string) ([]int64, error)
+}
+
+// LeafIndexRangeLocker is implemented by AdminTXs which can serialize the
+// creation of logs with leaf index ranges. See CheckLeafIndexRangeAvailable.
+type LeafIndexRangeLocker interface {
+	// LockLeafIndexRanges prevents other transactions from passing
+	// LockLeafIndexRanges until this one ends. It returns an Unimplemented
+	// error if the storage can't do so.
+	LockLeafIndexRanges(ctx context.Context) error
 }
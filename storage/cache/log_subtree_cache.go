@@ -19,6 +19,7 @@ import (
 
 	"github.com/google/trillian/merkle/compact"
 	"github.com/google/trillian/merkle/hashers"
+	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/storage/tree"
@@ -37,6 +38,13 @@ func NewLogSubtreeCache(logStrata []int, hasher hashers.LogHasher) *SubtreeCache
 	return NewSubtreeCache(logStrata, populateLogSubtreeNodes(hasher), prepareLogSubtreeWrite())
 }
 
+// NewLogSubtreeCacheWithMetrics creates a SubtreeCache like NewLogSubtreeCache,
+// which exports its metrics with mf, labelled by treeID, see
+// NewSubtreeCacheWithMetrics.
+func NewLogSubtreeCacheWithMetrics(logStrata []int, treeID int64, hasher hashers.LogHasher, mf monitoring.MetricFactory) *SubtreeCache {
+	return NewSubtreeCacheWithMetrics(logStrata, treeID, populateLogSubtreeNodes(hasher), prepareLogSubtreeWrite(), mf)
+}
+
 // LogPopulateFunc obtains a log storage population function based on a supplied LogHasher.
 // This is intended for use by storage utilities.
 func LogPopulateFunc(hasher hashers.LogHasher) storage.PopulateSubtreeFunc {
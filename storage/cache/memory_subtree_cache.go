@@ -0,0 +1,95 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+type memoryCacheKey struct {
+	treeID   int64
+	revision int64
+	prefix   string
+}
+
+type memoryCacheEntry struct {
+	key     memoryCacheKey
+	subtree *storagepb.SubtreeProto
+}
+
+// MemorySubtreeCache is an in-memory storage.SubtreeCache, which holds up to
+// a fixed number of subtrees and evicts the least recently used ones.
+type MemorySubtreeCache struct {
+	mu      sync.Mutex
+	max     int
+	lru     *list.List // Of *memoryCacheEntry, most recently used first.
+	entries map[memoryCacheKey]*list.Element
+}
+
+// NewMemorySubtreeCache returns a MemorySubtreeCache holding up to
+// maxSubtrees subtrees.
+func NewMemorySubtreeCache(maxSubtrees int) *MemorySubtreeCache {
+	return &MemorySubtreeCache{
+		max:     maxSubtrees,
+		lru:     list.New(),
+		entries: make(map[memoryCacheKey]*list.Element),
+	}
+}
+
+// GetSubtree implements storage.SubtreeCache.
+func (c *MemorySubtreeCache) GetSubtree(treeID, revision int64, prefix []byte) *storagepb.SubtreeProto {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[memoryCacheKey{treeID: treeID, revision: revision, prefix: string(prefix)}]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return proto.Clone(e.Value.(*memoryCacheEntry).subtree).(*storagepb.SubtreeProto)
+}
+
+// SetSubtree implements storage.SubtreeCache.
+func (c *MemorySubtreeCache) SetSubtree(treeID, revision int64, subtree *storagepb.SubtreeProto) {
+	if c.max <= 0 {
+		return
+	}
+	key := memoryCacheKey{treeID: treeID, revision: revision, prefix: string(subtree.Prefix)}
+	subtree = proto.Clone(subtree).(*storagepb.SubtreeProto)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*memoryCacheEntry).subtree = subtree
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&memoryCacheEntry{key: key, subtree: subtree})
+	for c.lru.Len() > c.max {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Len returns the number of cached subtrees.
+func (c *MemorySubtreeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
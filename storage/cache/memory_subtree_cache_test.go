@@ -0,0 +1,92 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/storagepb"
+)
+
+var _ storage.SubtreeCache = &MemorySubtreeCache{}
+
+func TestMemorySubtreeCache(t *testing.T) {
+	subtree := func(prefix byte) *storagepb.SubtreeProto {
+		return &storagepb.SubtreeProto{
+			Prefix: []byte{prefix},
+			Depth:  8,
+			Leaves: map[string][]byte{"a": {prefix}},
+		}
+	}
+	c := NewMemorySubtreeCache(2)
+	c.SetSubtree(1, 10, subtree(1))
+	c.SetSubtree(1, 10, subtree(2))
+
+	for _, test := range []struct {
+		desc     string
+		treeID   int64
+		revision int64
+		prefix   byte
+		want     bool
+	}{
+		{desc: "hit", treeID: 1, revision: 10, prefix: 1, want: true},
+		{desc: "other-prefix", treeID: 1, revision: 10, prefix: 3},
+		{desc: "other-revision", treeID: 1, revision: 11, prefix: 1},
+		{desc: "other-tree", treeID: 2, revision: 10, prefix: 1},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			got := c.GetSubtree(test.treeID, test.revision, []byte{test.prefix})
+			if want := subtree(test.prefix); test.want && !proto.Equal(got, want) {
+				t.Errorf("GetSubtree() = %v, want %v", got, want)
+			} else if !test.want && got != nil {
+				t.Errorf("GetSubtree() = %v, want nil", got)
+			}
+		})
+	}
+
+	// Returned subtrees are copies.
+	c.GetSubtree(1, 10, []byte{1}).Leaves["a"] = []byte("modified")
+	if got, want := c.GetSubtree(1, 10, []byte{1}), subtree(1); !proto.Equal(got, want) {
+		t.Errorf("GetSubtree() after modification = %v, want %v", got, want)
+	}
+
+	// Prefix 1 was used more recently than 2, so 2 is evicted.
+	c.SetSubtree(1, 11, subtree(3))
+	if got, want := c.Len(), 2; got != want {
+		t.Errorf("Len() = %d, want %d", got, want)
+	}
+	if got := c.GetSubtree(1, 10, []byte{2}); got != nil {
+		t.Errorf("GetSubtree() of evicted subtree = %v, want nil", got)
+	}
+	for _, prefix := range []byte{1, 3} {
+		rev := int64(10)
+		if prefix == 3 {
+			rev = 11
+		}
+		if got := c.GetSubtree(1, rev, []byte{prefix}); got == nil {
+			t.Errorf("GetSubtree(%d) = nil, want subtree", prefix)
+		}
+	}
+}
+
+func TestMemorySubtreeCacheDisabled(t *testing.T) {
+	c := NewMemorySubtreeCache(0)
+	c.SetSubtree(1, 10, &storagepb.SubtreeProto{Prefix: []byte{1}})
+	if got := c.GetSubtree(1, 10, []byte{1}); got != nil {
+		t.Errorf("GetSubtree() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/google/trillian/monitoring"
+)
+
+// cacheMetrics are the metrics of the SubtreeCaches created with a
+// MetricFactory, labelled by tree ID.
+type cacheMetrics struct {
+	subtreeHits     monitoring.Counter
+	subtreeMisses   monitoring.Counter
+	nodeWrites      monitoring.Counter
+	subtreesFlushed monitoring.Histogram
+}
+
+var (
+	metricsMu sync.Mutex
+	// metricsByFactory holds the metrics created with each MetricFactory, as
+	// factories may only create each metric once.
+	metricsByFactory = make(map[monitoring.MetricFactory]*cacheMetrics)
+)
+
+// metricsFor returns the metrics created with mf, creating them on its first
+// use. A nil mf means inert metrics.
+func metricsFor(mf monitoring.MetricFactory) *cacheMetrics {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	if m, ok := metricsByFactory[mf]; ok {
+		return m
+	}
+	m := &cacheMetrics{
+		subtreeHits:   mf.NewCounter("subtree_cache_hits", "Number of subtrees read through the subtree cache which were already cached", monitoring.TreeIDLabel),
+		subtreeMisses: mf.NewCounter("subtree_cache_misses", "Number of subtrees read through the subtree cache which had to be read from storage", monitoring.TreeIDLabel),
+		nodeWrites:    mf.NewCounter("subtree_cache_node_writes", "Number of node hashes changed in the subtree cache", monitoring.TreeIDLabel),
+		subtreesFlushed: mf.NewHistogramWithBuckets(
+			"subtree_cache_flushed_subtrees",
+			"Number of subtrees written to storage by each flush of the subtree cache, e.g. per integration",
+			monitoring.ExpBuckets(1, 2, 16),
+			monitoring.TreeIDLabel,
+		),
+	}
+	metricsByFactory[mf] = m
+	return m
+}
+
+// treeLabel returns the monitoring label of treeID.
+func treeLabel(treeID int64) string {
+	return strconv.FormatInt(treeID, 10)
+}
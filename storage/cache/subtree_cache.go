@@ -24,6 +24,7 @@ import (
 
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/storage/tree"
@@ -58,6 +59,9 @@ const maxSupportedTreeDepth = 256
 //  2. Subtrees/nodes are rarely written, and mostly read.
 type SubtreeCache struct {
 	layout *tree.Layout
+	// metrics are labelled by label, the monitoring label of the tree.
+	metrics *cacheMetrics
+	label   string
 
 	// subtrees contains the Subtree data read from storage, and is updated by
 	// calls to SetNodeHash.
@@ -79,6 +83,14 @@ type SubtreeCache struct {
 // internal nodes given its leaves, and will be called for each subtree loaded
 // from storage.
 func NewSubtreeCache(strataDepths []int, populateSubtree storage.PopulateSubtreeFunc, prepareSubtreeWrite storage.PrepareSubtreeWriteFunc) *SubtreeCache {
+	return NewSubtreeCacheWithMetrics(strataDepths, 0, populateSubtree, prepareSubtreeWrite, nil)
+}
+
+// NewSubtreeCacheWithMetrics returns a newly intialised cache like
+// NewSubtreeCache, which exports its metrics with mf, labelled by treeID.
+// Caches created with the same mf share its metrics. A nil mf means the
+// metrics are inert.
+func NewSubtreeCacheWithMetrics(strataDepths []int, treeID int64, populateSubtree storage.PopulateSubtreeFunc, prepareSubtreeWrite storage.PrepareSubtreeWriteFunc, mf monitoring.MetricFactory) *SubtreeCache {
 	// TODO(al): pass this in
 	maxTreeDepth := maxSupportedTreeDepth
 	glog.V(1).Infof("Creating new subtree cache maxDepth=%d strataDepths=%v", maxTreeDepth, strataDepths)
@@ -96,6 +108,8 @@ func NewSubtreeCache(strataDepths []int, populateSubtree storage.PopulateSubtree
 
 	return &SubtreeCache{
 		layout:              layout,
+		metrics:             metricsFor(mf),
+		label:               treeLabel(treeID),
 		populate:            populateSubtree,
 		populateConcurrency: *populateConcurrency,
 		prepare:             prepareSubtreeWrite,
@@ -108,17 +122,22 @@ func NewSubtreeCache(strataDepths []int, populateSubtree storage.PopulateSubtree
 func (s *SubtreeCache) preload(ids []tree.NodeID, getSubtrees GetSubtreesFunc) error {
 	// Figure out the set of subtrees we need.
 	want := make(map[string]tree.TileID)
+	cached := make(map[string]bool)
 	for _, id := range ids {
 		subID := s.layout.GetTileID(id)
 		subKey := subID.AsKey()
-		if _, ok := want[subKey]; ok {
+		if _, ok := want[subKey]; ok || cached[subKey] {
 			// No need to check s.subtrees map twice.
 			continue
 		}
 		if _, ok := s.subtrees.Load(subKey); !ok {
 			want[subKey] = subID
+		} else {
+			cached[subKey] = true
 		}
 	}
+	s.metrics.subtreeHits.Add(float64(len(cached)), s.label)
+	s.metrics.subtreeMisses.Add(float64(len(want)), s.label)
 	// Note: At this point multiple parallel preload invocations can happen to
 	// getSubtrees with overlapping sets of IDs. It's okay because we collapse
 	// results further below.
@@ -213,8 +232,10 @@ func (s *SubtreeCache) GetNodes(ids []tree.NodeID, getSubtrees GetSubtreesFunc)
 
 	ret := make([]tree.Node, 0, len(ids))
 	for _, id := range ids {
-		h, err := s.getNodeHash(
+		// The subtrees read were counted by preload already.
+		h, err := s.nodeHash(
 			id,
+			false,
 			func(n tree.NodeID) (*storagepb.SubtreeProto, error) {
 				// This should never happen - we should've already read all the data we
 				// need above, in Preload()
@@ -265,6 +286,12 @@ func (s *SubtreeCache) prefixIsDirty(prefixKey string) bool {
 
 // getNodeHash returns a single node hash from the cache.
 func (s *SubtreeCache) getNodeHash(id tree.NodeID, getSubtree GetSubtreeFunc) ([]byte, error) {
+	return s.nodeHash(id, true, getSubtree)
+}
+
+// nodeHash returns a single node hash from the cache, and counts the read of
+// its subtree as a hit or miss if count is set.
+func (s *SubtreeCache) nodeHash(id tree.NodeID, count bool, getSubtree GetSubtreeFunc) ([]byte, error) {
 	if glog.V(3) {
 		glog.Infof("cache: getNodeHash(path=%x, prefixLen=%d) {", id.Path, id.PrefixLenBits)
 	}
@@ -275,6 +302,9 @@ func (s *SubtreeCache) getNodeHash(id tree.NodeID, getSubtree GetSubtreeFunc) ([
 	if c == nil {
 		glog.V(2).Infof("Cache miss for %x so we'll try to fetch from storage", subKey)
 		// Cache miss, so we'll try to fetch from storage.
+		if count {
+			s.metrics.subtreeMisses.Inc(s.label)
+		}
 		var err error
 		if c, err = getSubtree(subID.Root); err != nil {
 			return nil, err
@@ -291,6 +321,8 @@ func (s *SubtreeCache) getNodeHash(id tree.NodeID, getSubtree GetSubtreeFunc) ([
 		}
 
 		s.subtrees.Store(subKey, c)
+	} else if count {
+		s.metrics.subtreeHits.Inc(s.label)
 	}
 
 	// finally look for the particular node within the subtree so we can return
@@ -370,6 +402,7 @@ func (s *SubtreeCache) SetNodeHash(id tree.NodeID, h []byte, getSubtree GetSubtr
 		c.InternalNodes[sfxKey] = h
 	}
 	s.dirtyPrefixes.Store(subKey, nil)
+	s.metrics.nodeWrites.Inc(s.label)
 	if glog.V(3) {
 		b, err := base64.StdEncoding.DecodeString(sfxKey)
 		if err != nil {
@@ -424,6 +457,7 @@ func (s *SubtreeCache) Flush(ctx context.Context, setSubtrees SetSubtreesFunc) e
 	if len(treesToWrite) == 0 {
 		return nil
 	}
+	s.metrics.subtreesFlushed.Observe(float64(len(treesToWrite)), s.label)
 	err := setSubtrees(ctx, treesToWrite)
 	glog.V(1).Infof("cache: Flush done %v", err)
 	return err
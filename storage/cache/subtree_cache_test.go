@@ -24,6 +24,7 @@ import (
 	"github.com/google/trillian/merkle/compact"
 	"github.com/google/trillian/merkle/maphasher"
 	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/storage/tree"
 
@@ -380,3 +381,66 @@ func TestIdempotentWrites(t *testing.T) {
 		}
 	}
 }
+
+func TestCacheMetrics(t *testing.T) {
+	ctx := context.Background()
+	const metricsTreeID = int64(12345)
+	label := treeLabel(metricsTreeID)
+	mf := monitoring.InertMetricFactory{}
+	c := NewSubtreeCacheWithMetrics(defaultMapStrata, metricsTreeID, populateMapSubtreeNodes(metricsTreeID, maphasher.Default), prepareMapSubtreeWrite(), mf)
+	m := c.metrics
+	if got := metricsFor(mf); got != m {
+		t.Errorf("metricsFor() returned new metrics for the MetricFactory of a cache")
+	}
+
+	// The first two nodes are leaves of the same subtree.
+	var nodeIDs []tree.NodeID
+	for _, h := range []string{
+		"0123456789abcdef0123456789abcdef",
+		"0123556789abcdef0123456789abcdef",
+		"4567456789abcdef0123456789abcdef",
+	} {
+		id := tree.NewNodeIDFromHash([]byte(h))
+		id.PrefixLenBits = 40
+		nodeIDs = append(nodeIDs, id)
+	}
+	// Storage doesn't have any of the subtrees yet.
+	getSubtrees := func([]tree.NodeID) ([]*storagepb.SubtreeProto, error) {
+		return nil, nil
+	}
+
+	// The first read misses both subtrees, the second one hits them.
+	for i := 0; i < 2; i++ {
+		if _, err := c.GetNodes(nodeIDs, getSubtrees); err != nil {
+			t.Fatalf("GetNodes(): %v", err)
+		}
+	}
+	// Single reads count too.
+	if _, err := c.getNodeHash(nodeIDs[2], noFetch); err != nil {
+		t.Fatalf("getNodeHash(): %v", err)
+	}
+	if got, want := m.subtreeMisses.Value(label), 2.0; got != want {
+		t.Errorf("subtree_cache_misses = %v, want %v", got, want)
+	}
+	if got, want := m.subtreeHits.Value(label), 3.0; got != want {
+		t.Errorf("subtree_cache_hits = %v, want %v", got, want)
+	}
+
+	// Only changed values count as writes.
+	for i := 0; i < 2; i++ {
+		if err := c.SetNodeHash(nodeIDs[0], []byte("hash"), noFetch); err != nil {
+			t.Fatalf("SetNodeHash(): %v", err)
+		}
+	}
+	if got, want := m.nodeWrites.Value(label), 1.0; got != want {
+		t.Errorf("subtree_cache_node_writes = %v, want %v", got, want)
+	}
+
+	setSubtrees := func(context.Context, []*storagepb.SubtreeProto) error { return nil }
+	if err := c.Flush(ctx, setSubtrees); err != nil {
+		t.Fatalf("Flush(): %v", err)
+	}
+	if count, sum := m.subtreesFlushed.Info(label); count != 1 || sum != 1 {
+		t.Errorf("subtree_cache_flushed_subtrees = (%d, %v), want (1, 1)", count, sum)
+	}
+}
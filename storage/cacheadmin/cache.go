@@ -0,0 +1,341 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cacheadmin contains a caching storage.AdminStorage implementation.
+package cacheadmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// now is used in place of time.Now to allow tests to take control of time.
+var now = time.Now
+
+var (
+	metricsOnce sync.Once
+	hitCounter  monitoring.Counter
+	missCounter monitoring.Counter
+)
+
+func initMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	hitCounter = mf.NewCounter("admin_tree_cache_hits", "Number of tree lookups served from the admin tree cache")
+	missCounter = mf.NewCounter("admin_tree_cache_misses", "Number of tree lookups that missed the admin tree cache")
+}
+
+type entry struct {
+	tree    *trillian.Tree
+	expires time.Time
+}
+
+type adminStorage struct {
+	storage.AdminStorage
+	ttl time.Duration
+
+	// mu guards trees and gen.
+	mu    sync.Mutex
+	trees map[int64]entry
+	// gen is incremented on every invalidation, so that lookups that started
+	// before a write don't repopulate the cache with stale trees.
+	gen uint64
+}
+
+// NewCachedAdminStorage wraps s with an implementation that caches trees read
+// via snapshot GetTree calls for up to ttl.
+//
+// Trees modified through the returned AdminStorage are evicted from the cache
+// as soon as the modifying call is made, and again when its transaction ends,
+// so changes such as freezing or deleting a tree take effect immediately
+// within this process. Changes made by other processes may take up to ttl to
+// be observed.
+func NewCachedAdminStorage(s storage.AdminStorage, ttl time.Duration, mf monitoring.MetricFactory) (storage.AdminStorage, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("invalid ttl: %v", ttl)
+	}
+	metricsOnce.Do(func() { initMetrics(mf) })
+	return &adminStorage{
+		AdminStorage: s,
+		ttl:          ttl,
+		trees:        make(map[int64]entry),
+	}, nil
+}
+
+// Snapshot implements AdminStorage.Snapshot.
+// The underlying snapshot is only started once it's needed, so transactions
+// that are fully served from the cache don't reach the underlying storage.
+func (s *adminStorage) Snapshot(ctx context.Context) (storage.ReadOnlyAdminTX, error) {
+	return &snapshotTX{ctx: ctx, s: s}, nil
+}
+
+// ReadWriteTransaction implements AdminStorage.ReadWriteTransaction.
+func (s *adminStorage) ReadWriteTransaction(ctx context.Context, f storage.AdminTXFunc) error {
+	var written []int64
+	defer func() {
+		// Evict again once the transaction is over, as concurrent lookups may
+		// have read the tree before the change was committed.
+		s.invalidate(written...)
+	}()
+	return s.AdminStorage.ReadWriteTransaction(ctx, func(ctx context.Context, tx storage.AdminTX) error {
+		return f(ctx, &adminTX{AdminTX: tx, s: s, written: &written})
+	})
+}
+
+// get returns a copy of the cached tree, if present and not expired, plus the
+// current cache generation.
+func (s *adminStorage) get(treeID int64) (*trillian.Tree, uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.trees[treeID]
+	if !ok {
+		return nil, s.gen
+	}
+	if now().After(e.expires) {
+		delete(s.trees, treeID)
+		return nil, s.gen
+	}
+	return proto.Clone(e.tree).(*trillian.Tree), s.gen
+}
+
+// put caches a copy of tree, unless the cache was invalidated after gen.
+func (s *adminStorage) put(tree *trillian.Tree, gen uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gen != s.gen {
+		return
+	}
+	s.trees[tree.TreeId] = entry{tree: proto.Clone(tree).(*trillian.Tree), expires: now().Add(s.ttl)}
+}
+
+// invalidate evicts the specified trees from the cache.
+func (s *adminStorage) invalidate(treeIDs ...int64) {
+	if len(treeIDs) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gen++
+	for _, id := range treeIDs {
+		delete(s.trees, id)
+	}
+}
+
+// snapshotTX is a lazily-started snapshot transaction.
+type snapshotTX struct {
+	ctx context.Context
+	s   *adminStorage
+
+	// mu guards tx and closed.
+	mu     sync.Mutex
+	tx     storage.ReadOnlyAdminTX
+	closed bool
+}
+
+// begin returns the underlying transaction, starting it if necessary.
+func (t *snapshotTX) begin() (storage.ReadOnlyAdminTX, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, errors.New("transaction is closed")
+	}
+	if t.tx == nil {
+		tx, err := t.s.AdminStorage.Snapshot(t.ctx)
+		if err != nil {
+			return nil, err
+		}
+		t.tx = tx
+	}
+	return t.tx, nil
+}
+
+// end marks the transaction as closed and runs fn on the underlying
+// transaction, if one was started.
+func (t *snapshotTX) end(fn func(storage.ReadOnlyAdminTX) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	wasClosed := t.closed
+	t.closed = true
+	if t.tx == nil || wasClosed {
+		return nil
+	}
+	return fn(t.tx)
+}
+
+// GetTree implements AdminReader.GetTree.
+func (t *snapshotTX) GetTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	tree, gen := t.s.get(treeID)
+	if tree != nil {
+		hitCounter.Inc()
+		return tree, nil
+	}
+	missCounter.Inc()
+	tx, err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+	tree, err = tx.GetTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	t.s.put(tree, gen)
+	return tree, nil
+}
+
+// ListTreeIDs implements AdminReader.ListTreeIDs.
+func (t *snapshotTX) ListTreeIDs(ctx context.Context, includeDeleted bool) ([]int64, error) {
+	tx, err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.ListTreeIDs(ctx, includeDeleted)
+}
+
+// ListTrees implements AdminReader.ListTrees.
+func (t *snapshotTX) ListTrees(ctx context.Context, includeDeleted bool) ([]*trillian.Tree, error) {
+	tx, err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.ListTrees(ctx, includeDeleted)
+}
+
+// GetTreeTemplate implements AdminReader.GetTreeTemplate.
+// Templates are not cached.
+func (t *snapshotTX) GetTreeTemplate(ctx context.Context, name string) (*trillian.TreeTemplate, error) {
+	tx, err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.GetTreeTemplate(ctx, name)
+}
+
+// ListTreeTemplates implements AdminReader.ListTreeTemplates.
+func (t *snapshotTX) ListTreeTemplates(ctx context.Context) ([]*trillian.TreeTemplate, error) {
+	tx, err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.ListTreeTemplates(ctx)
+}
+
+// GetTreeAttestation implements AdminReader.GetTreeAttestation.
+// Attestations are not cached.
+func (t *snapshotTX) GetTreeAttestation(ctx context.Context, treeID int64) (*trillian.TreeAttestation, error) {
+	tx, err := t.begin()
+	if err != nil {
+		return nil, err
+	}
+	return tx.GetTreeAttestation(ctx, treeID)
+}
+
+// Commit implements ReadOnlyAdminTX.Commit.
+func (t *snapshotTX) Commit() error {
+	return t.end(storage.ReadOnlyAdminTX.Commit)
+}
+
+// Rollback implements ReadOnlyAdminTX.Rollback.
+func (t *snapshotTX) Rollback() error {
+	return t.end(storage.ReadOnlyAdminTX.Rollback)
+}
+
+// IsClosed implements ReadOnlyAdminTX.IsClosed.
+func (t *snapshotTX) IsClosed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.closed
+}
+
+// Close implements ReadOnlyAdminTX.Close.
+func (t *snapshotTX) Close() error {
+	return t.end(storage.ReadOnlyAdminTX.Close)
+}
+
+// adminTX wraps an AdminTX, evicting modified trees from the cache.
+// Reads within a read-write transaction always go to the underlying storage.
+type adminTX struct {
+	storage.AdminTX
+	s       *adminStorage
+	written *[]int64
+}
+
+func (t *adminTX) invalidate(treeID int64) {
+	*t.written = append(*t.written, treeID)
+	t.s.invalidate(treeID)
+}
+
+// CreateTree implements AdminWriter.CreateTree.
+func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillian.Tree, error) {
+	created, err := t.AdminTX.CreateTree(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	t.invalidate(created.TreeId)
+	return created, nil
+}
+
+// UpdateTree implements AdminWriter.UpdateTree.
+func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(*trillian.Tree)) (*trillian.Tree, error) {
+	t.invalidate(treeID)
+	return t.AdminTX.UpdateTree(ctx, treeID, updateFunc)
+}
+
+// SoftDeleteTree implements AdminWriter.SoftDeleteTree.
+func (t *adminTX) SoftDeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	t.invalidate(treeID)
+	return t.AdminTX.SoftDeleteTree(ctx, treeID)
+}
+
+// HardDeleteTree implements AdminWriter.HardDeleteTree.
+func (t *adminTX) HardDeleteTree(ctx context.Context, treeID int64) error {
+	t.invalidate(treeID)
+	return t.AdminTX.HardDeleteTree(ctx, treeID)
+}
+
+// UndeleteTree implements AdminWriter.UndeleteTree.
+func (t *adminTX) UndeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	t.invalidate(treeID)
+	return t.AdminTX.UndeleteTree(ctx, treeID)
+}
+
+// LockTreesByDisplayName implements storage.DisplayNameLocker, if the wrapped
+// transaction does.
+func (t *adminTX) LockTreesByDisplayName(ctx context.Context, displayName string) ([]int64, error) {
+	l, ok := t.AdminTX.(storage.DisplayNameLocker)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "storage can't lock trees by display name")
+	}
+	return l.LockTreesByDisplayName(ctx, displayName)
+}
+
+// LockLeafIndexRanges implements storage.LeafIndexRangeLocker, if the wrapped
+// transaction does.
+func (t *adminTX) LockLeafIndexRanges(ctx context.Context) error {
+	l, ok := t.AdminTX.(storage.LeafIndexRangeLocker)
+	if !ok {
+		return status.Error(codes.Unimplemented, "storage can't lock leaf index ranges")
+	}
+	return l.LockLeafIndexRanges(ctx)
+}
@@ -0,0 +1,219 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cacheadmin
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"github.com/google/trillian/storage"
+)
+
+const (
+	treeID = 12345
+	ttl    = 10 * time.Second
+)
+
+var tree = &trillian.Tree{
+	TreeId:      treeID,
+	TreeState:   trillian.TreeState_ACTIVE,
+	TreeType:    trillian.TreeType_LOG,
+	DisplayName: "Llamas Log",
+}
+
+// expectSnapshotGetTree sets up s to return a snapshot that reads tree once.
+func expectSnapshotGetTree(ctrl *gomock.Controller, s *storage.MockAdminStorage, tree *trillian.Tree) *gomock.Call {
+	tx := storage.NewMockReadOnlyAdminTX(ctrl)
+	s.EXPECT().Snapshot(gomock.Any()).Return(tx, nil)
+	tx.EXPECT().Commit().AnyTimes().Return(nil)
+	tx.EXPECT().Close().AnyTimes().Return(nil)
+	return tx.EXPECT().GetTree(gomock.Any(), tree.TreeId).Return(proto.Clone(tree).(*trillian.Tree), nil)
+}
+
+func mustGetTree(ctx context.Context, t *testing.T, s storage.AdminStorage, treeID int64) *trillian.Tree {
+	t.Helper()
+	got, err := storage.GetTree(ctx, s, treeID)
+	if err != nil {
+		t.Fatalf("GetTree() = (_, %v), want = (_, nil)", err)
+	}
+	return got
+}
+
+func newStorage(t *testing.T, s storage.AdminStorage) storage.AdminStorage {
+	t.Helper()
+	cs, err := NewCachedAdminStorage(s, ttl, monitoring.InertMetricFactory{})
+	if err != nil {
+		t.Fatalf("NewCachedAdminStorage() = (_, %v), want = (_, nil)", err)
+	}
+	return cs
+}
+
+func fakeNow(t time.Time) func() {
+	oldNow := now
+	now = func() time.Time { return t }
+	return func() { now = oldNow }
+}
+
+func TestNewCachedAdminStorage_Errors(t *testing.T) {
+	for _, ttl := range []time.Duration{0, -1 * time.Second} {
+		if _, err := NewCachedAdminStorage(nil, ttl, nil); err == nil {
+			t.Errorf("NewCachedAdminStorage(ttl=%v) = (_, nil), want = (_, err)", ttl)
+		}
+	}
+}
+
+func TestGetTree_Cached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	mockStorage := storage.NewMockAdminStorage(ctrl)
+	// The underlying storage is queried only once.
+	expectSnapshotGetTree(ctrl, mockStorage, tree)
+	s := newStorage(t, mockStorage)
+
+	hits, misses := hitCounter.Value(), missCounter.Value()
+	for i := 0; i < 3; i++ {
+		got := mustGetTree(ctx, t, s, treeID)
+		if !proto.Equal(got, tree) {
+			t.Errorf("%v: GetTree() = %v, want = %v", i, got, tree)
+		}
+		// Callers are free to modify the returned tree.
+		got.DisplayName = "Modified"
+	}
+	if got, want := hitCounter.Value()-hits, 2.0; got != want {
+		t.Errorf("hits = %v, want = %v", got, want)
+	}
+	if got, want := missCounter.Value()-misses, 1.0; got != want {
+		t.Errorf("misses = %v, want = %v", got, want)
+	}
+}
+
+func TestGetTree_Expired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	start := time.Now()
+	defer fakeNow(start)()
+
+	mockStorage := storage.NewMockAdminStorage(ctrl)
+	s := newStorage(t, mockStorage)
+
+	expectSnapshotGetTree(ctrl, mockStorage, tree)
+	mustGetTree(ctx, t, s, treeID)
+
+	// Still fresh, no storage calls expected.
+	fakeNow(start.Add(ttl))
+	mustGetTree(ctx, t, s, treeID)
+
+	// Expired, storage must be queried again.
+	fakeNow(start.Add(ttl + time.Millisecond))
+	frozen := proto.Clone(tree).(*trillian.Tree)
+	frozen.TreeState = trillian.TreeState_FROZEN
+	expectSnapshotGetTree(ctrl, mockStorage, frozen)
+	if got := mustGetTree(ctx, t, s, treeID); !proto.Equal(got, frozen) {
+		t.Errorf("GetTree() = %v, want = %v", got, frozen)
+	}
+}
+
+func TestWritesInvalidate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc  string
+		write func(context.Context, storage.AdminTX) error
+	}{
+		{
+			desc: "update",
+			write: func(ctx context.Context, tx storage.AdminTX) error {
+				_, err := tx.UpdateTree(ctx, treeID, func(*trillian.Tree) {})
+				return err
+			},
+		},
+		{
+			desc: "softDelete",
+			write: func(ctx context.Context, tx storage.AdminTX) error {
+				_, err := tx.SoftDeleteTree(ctx, treeID)
+				return err
+			},
+		},
+		{
+			desc: "hardDelete",
+			write: func(ctx context.Context, tx storage.AdminTX) error {
+				return tx.HardDeleteTree(ctx, treeID)
+			},
+		},
+		{
+			desc: "undelete",
+			write: func(ctx context.Context, tx storage.AdminTX) error {
+				_, err := tx.UndeleteTree(ctx, treeID)
+				return err
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStorage := storage.NewMockAdminStorage(ctrl)
+			s := newStorage(t, mockStorage)
+
+			expectSnapshotGetTree(ctrl, mockStorage, tree)
+			mustGetTree(ctx, t, s, treeID)
+
+			tx := storage.NewMockAdminTX(ctrl)
+			tx.EXPECT().UpdateTree(gomock.Any(), int64(treeID), gomock.Any()).AnyTimes().Return(tree, nil)
+			tx.EXPECT().SoftDeleteTree(gomock.Any(), int64(treeID)).AnyTimes().Return(tree, nil)
+			tx.EXPECT().HardDeleteTree(gomock.Any(), int64(treeID)).AnyTimes().Return(nil)
+			tx.EXPECT().UndeleteTree(gomock.Any(), int64(treeID)).AnyTimes().Return(tree, nil)
+			mockStorage.EXPECT().ReadWriteTransaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, f storage.AdminTXFunc) error {
+				return f(ctx, tx)
+			})
+			if err := s.ReadWriteTransaction(ctx, test.write); err != nil {
+				t.Fatalf("ReadWriteTransaction() = %v", err)
+			}
+
+			// The tree must be read from storage again.
+			expectSnapshotGetTree(ctrl, mockStorage, tree)
+			mustGetTree(ctx, t, s, treeID)
+		})
+	}
+}
+
+func TestGetTree_ConcurrentWriteNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	mockStorage := storage.NewMockAdminStorage(ctrl)
+	cs := newStorage(t, mockStorage)
+
+	// Simulate a write that happens while the tree is being read.
+	expectSnapshotGetTree(ctrl, mockStorage, tree).Do(func(context.Context, int64) {
+		cs.(*adminStorage).invalidate(treeID)
+	})
+	mustGetTree(ctx, t, cs, treeID)
+
+	// The possibly stale read must not have been cached.
+	expectSnapshotGetTree(ctrl, mockStorage, tree)
+	mustGetTree(ctx, t, cs, treeID)
+}
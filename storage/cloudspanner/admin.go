@@ -135,7 +135,7 @@ func reverseSignatureAlgMap(m map[sigpb.DigitallySigned_SignatureAlgorithm]spann
 type adminTX struct {
 	client *spanner.Client
 
-	// tx is either spanner.ReadOnlyTransaction or spanner.ReadWriteTransaction,
+	// tx is either readOnlyTX or spanner.ReadWriteTransaction,
 	// according to the role adminTX is meant to fill.
 	// If tx is a snapshot transaction it'll be set to nil when adminTX is
 	// closed to avoid reuse.
@@ -153,11 +153,24 @@ type adminTX struct {
 // adminStorage implements storage.AdminStorage.
 type adminStorage struct {
 	client *spanner.Client
+	opts   AdminStorageOptions
+}
+
+// AdminStorageOptions is used to configure the spanner admin storage layer.
+type AdminStorageOptions struct {
+	TimeoutOptions
 }
 
 // NewAdminStorage returns a Spanner-based storage.AdminStorage implementation.
 func NewAdminStorage(client *spanner.Client) storage.AdminStorage {
-	return &adminStorage{client}
+	return NewAdminStorageWithOpts(client, AdminStorageOptions{})
+}
+
+// NewAdminStorageWithOpts returns a Spanner-based storage.AdminStorage
+// implementation using options.
+func NewAdminStorageWithOpts(client *spanner.Client, opts AdminStorageOptions) storage.AdminStorage {
+	ensureMetrics(nil)
+	return &adminStorage{client: client, opts: opts}
 }
 
 // CheckDatabaseAccessible implements AdminStorage.CheckDatabaseAccessible.
@@ -167,7 +180,7 @@ func (s *adminStorage) CheckDatabaseAccessible(ctx context.Context) error {
 
 // Snapshot implements AdminStorage.Snapshot.
 func (s *adminStorage) Snapshot(ctx context.Context) (storage.ReadOnlyAdminTX, error) {
-	tx := s.client.ReadOnlyTransaction()
+	tx := newReadOnlyTX(s.client.ReadOnlyTransaction(), s.opts.TimeoutOptions)
 	return &adminTX{client: s.client, tx: tx}, nil
 }
 
@@ -178,11 +191,10 @@ func (s *adminStorage) Begin(ctx context.Context) (storage.AdminTX, error) {
 
 // ReadWriteTransaction implements AdminStorage.ReadWriteTransaction.
 func (s *adminStorage) ReadWriteTransaction(ctx context.Context, f storage.AdminTXFunc) error {
-	_, err := s.client.ReadWriteTransaction(ctx, func(ctx context.Context, stx *spanner.ReadWriteTransaction) error {
+	return readWriteTransaction(ctx, s.client, s.opts.TimeoutOptions, func(ctx context.Context, stx *spanner.ReadWriteTransaction) error {
 		tx := &adminTX{client: s.client, tx: stx}
 		return f(ctx, tx)
 	})
-	return err
 }
 
 // Commit implements ReadOnlyAdminTX.Commit.
@@ -213,7 +225,7 @@ func (t *adminTX) Close() error {
 		return nil
 	}
 	// tx will be committed by ReadWriteTransaction(), so only close readonly tx here
-	if stx, ok := t.tx.(*spanner.ReadOnlyTransaction); ok {
+	if stx, ok := t.tx.(*readOnlyTX); ok {
 		glog.V(1).Infof("Closed admin %p", stx)
 		stx.Close()
 	}
@@ -351,8 +363,11 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 	if err := storage.ValidateTreeForCreation(ctx, tree); err != nil {
 		return nil, err
 	}
+	if err := checkTreeFieldsSupported(tree); err != nil {
+		return nil, err
+	}
 
-	id, err := storage.NewTreeID()
+	id, err := storage.AllocateTreeID(ctx, t, tree.TreeId)
 	if err != nil {
 		return nil, err
 	}
@@ -464,6 +479,49 @@ func newTreeInfo(tree *trillian.Tree, treeID int64, now time.Time) (*spannerpb.T
 	return info, nil
 }
 
+// checkTreeFieldsSupported returns an Unimplemented error if tree sets a field
+// which TreeInfo can't store, rather than letting it be silently dropped.
+func checkTreeFieldsSupported(tree *trillian.Tree) error {
+	var field string
+	switch {
+	case tree.CallerLeafIdentityHash:
+		field = "caller_leaf_identity_hash"
+	case tree.HashExtraData:
+		field = "hash_extra_data"
+	case tree.Namespace != "":
+		field = "namespace"
+	case tree.LogRootEncoding != trillian.LogRootEncoding_TLS:
+		field = "log_root_encoding"
+	case tree.TimestampGranularity != trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_NANOSECOND:
+		field = "timestamp_granularity"
+	case tree.HashOnly:
+		field = "hash_only"
+	case tree.LeafCompression != trillian.LeafCompression_LEAF_COMPRESSION_NONE:
+		field = "leaf_compression"
+	case tree.MaxTreeSize != 0:
+		field = "max_tree_size"
+	case tree.QueueWriteAhead:
+		field = "queue_write_ahead"
+	case tree.LeafEncryption != nil:
+		field = "leaf_encryption"
+	case tree.SortByQueueTimestamp:
+		field = "sort_by_queue_timestamp"
+	case tree.LeafOrderingKey != nil:
+		field = "leaf_ordering_key"
+	case tree.LeafTombstones:
+		field = "leaf_tombstones"
+	case len(tree.EmptyRootHash) != 0:
+		field = "empty_root_hash"
+	case tree.LeafIndexOffset != 0:
+		field = "leaf_index_offset"
+	case tree.SigningInterval != nil:
+		field = "signing_interval"
+	default:
+		return nil
+	}
+	return status.Errorf(codes.Unimplemented, "%s not supported by CloudSpanner storage", field)
+}
+
 func logConfigOrDefault(tree *trillian.Tree) (*spannerpb.LogStorageConfig, error) {
 	settings, err := unmarshalSettings(tree)
 	if err != nil {
@@ -516,6 +574,9 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 	if !proto.Equal(beforeTree.StorageSettings, tree.StorageSettings) {
 		return nil, status.New(codes.InvalidArgument, "readonly field changed: storage_settings").Err()
 	}
+	if err := checkTreeFieldsSupported(tree); err != nil {
+		return nil, err
+	}
 
 	ts, ok := treeStateMap[tree.TreeState]
 	if !ok {
@@ -642,6 +703,43 @@ func (t *adminTX) UndeleteTree(ctx context.Context, treeID int64) (*trillian.Tre
 	return toTrillianTree(info)
 }
 
+// GetTreeTemplate implements AdminReader.GetTreeTemplate.
+// Tree templates are not supported by Cloud Spanner storage.
+func (t *adminTX) GetTreeTemplate(ctx context.Context, name string) (*trillian.TreeTemplate, error) {
+	return nil, status.Error(codes.Unimplemented, "tree templates not supported")
+}
+
+// ListTreeTemplates implements AdminReader.ListTreeTemplates.
+func (t *adminTX) ListTreeTemplates(ctx context.Context) ([]*trillian.TreeTemplate, error) {
+	return nil, status.Error(codes.Unimplemented, "tree templates not supported")
+}
+
+// CreateTreeTemplate implements AdminWriter.CreateTreeTemplate.
+func (t *adminTX) CreateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	return status.Error(codes.Unimplemented, "tree templates not supported")
+}
+
+// UpdateTreeTemplate implements AdminWriter.UpdateTreeTemplate.
+func (t *adminTX) UpdateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	return status.Error(codes.Unimplemented, "tree templates not supported")
+}
+
+// DeleteTreeTemplate implements AdminWriter.DeleteTreeTemplate.
+func (t *adminTX) DeleteTreeTemplate(ctx context.Context, name string) error {
+	return status.Error(codes.Unimplemented, "tree templates not supported")
+}
+
+// GetTreeAttestation implements AdminReader.GetTreeAttestation.
+// Tree attestations are not supported by Cloud Spanner storage.
+func (t *adminTX) GetTreeAttestation(ctx context.Context, treeID int64) (*trillian.TreeAttestation, error) {
+	return nil, status.Error(codes.Unimplemented, "tree attestations not supported")
+}
+
+// CreateTreeAttestation implements AdminWriter.CreateTreeAttestation.
+func (t *adminTX) CreateTreeAttestation(ctx context.Context, treeID int64, a *trillian.TreeAttestation) error {
+	return status.Error(codes.Unimplemented, "tree attestations not supported")
+}
+
 func toTrillianTree(info *spannerpb.TreeInfo) (*trillian.Tree, error) {
 	createdPB, err := ptypes.TimestampProto(time.Unix(0, info.CreateTimeNanos))
 	if err != nil {
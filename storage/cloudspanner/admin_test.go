@@ -0,0 +1,67 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudspanner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage/testonly"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckTreeFieldsSupported(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		modify   func(*trillian.Tree)
+		wantCode codes.Code
+	}{
+		{desc: "defaults", modify: func(*trillian.Tree) {}},
+		{desc: "caller_leaf_identity_hash", modify: func(tree *trillian.Tree) { tree.CallerLeafIdentityHash = true }, wantCode: codes.Unimplemented},
+		{desc: "hash_extra_data", modify: func(tree *trillian.Tree) { tree.HashExtraData = true }, wantCode: codes.Unimplemented},
+		{desc: "namespace", modify: func(tree *trillian.Tree) { tree.Namespace = "tenant" }, wantCode: codes.Unimplemented},
+		{desc: "log_root_encoding", modify: func(tree *trillian.Tree) { tree.LogRootEncoding = trillian.LogRootEncoding_CBOR }, wantCode: codes.Unimplemented},
+		{
+			desc: "timestamp_granularity",
+			modify: func(tree *trillian.Tree) {
+				tree.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND
+			},
+			wantCode: codes.Unimplemented,
+		},
+		{desc: "hash_only", modify: func(tree *trillian.Tree) { tree.HashOnly = true }, wantCode: codes.Unimplemented},
+		{desc: "leaf_compression", modify: func(tree *trillian.Tree) { tree.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP }, wantCode: codes.Unimplemented},
+		{desc: "max_tree_size", modify: func(tree *trillian.Tree) { tree.MaxTreeSize = 1000 }, wantCode: codes.Unimplemented},
+		{desc: "queue_write_ahead", modify: func(tree *trillian.Tree) { tree.QueueWriteAhead = true }, wantCode: codes.Unimplemented},
+		{desc: "leaf_encryption", modify: func(tree *trillian.Tree) { tree.LeafEncryption = &trillian.LeafEncryption{} }, wantCode: codes.Unimplemented},
+		{desc: "sort_by_queue_timestamp", modify: func(tree *trillian.Tree) { tree.SortByQueueTimestamp = true }, wantCode: codes.Unimplemented},
+		{desc: "leaf_ordering_key", modify: func(tree *trillian.Tree) { tree.LeafOrderingKey = &trillian.LeafOrderingKey{} }, wantCode: codes.Unimplemented},
+		{desc: "leaf_tombstones", modify: func(tree *trillian.Tree) { tree.LeafTombstones = true }, wantCode: codes.Unimplemented},
+		{desc: "empty_root_hash", modify: func(tree *trillian.Tree) { tree.EmptyRootHash = make([]byte, 32) }, wantCode: codes.Unimplemented},
+		{desc: "leaf_index_offset", modify: func(tree *trillian.Tree) { tree.LeafIndexOffset = 1000 }, wantCode: codes.Unimplemented},
+		{desc: "signing_interval", modify: func(tree *trillian.Tree) { tree.SigningInterval = ptypes.DurationProto(time.Minute) }, wantCode: codes.Unimplemented},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+			test.modify(tree)
+			if got := status.Code(checkTreeFieldsSupported(tree)); got != test.wantCode {
+				t.Errorf("checkTreeFieldsSupported() returned code %v, want %v", got, test.wantCode)
+			}
+		})
+	}
+}
@@ -111,6 +111,13 @@ func (ls *logStorage) CheckDatabaseAccessible(ctx context.Context) error {
 	return checkDatabaseAccessible(ctx, ls.ts.client)
 }
 
+// CompactTreeStorage implements storage.TreeStorageCompactor. Cloud Spanner
+// manages the layout of its storage itself, so there is nothing to optimize,
+// and no data is removed.
+func (ls *logStorage) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	return 0, nil
+}
+
 func (ls *logStorage) Snapshot(ctx context.Context) (storage.ReadOnlyLogTX, error) {
 	var staleness spanner.TimestampBound
 	if ls.opts.ReadOnlyStaleness > 0 {
@@ -121,7 +128,7 @@ func (ls *logStorage) Snapshot(ctx context.Context) (storage.ReadOnlyLogTX, erro
 
 	snapshotTX := &snapshotTX{
 		client: ls.ts.client,
-		stx:    ls.ts.client.ReadOnlyTransaction().WithTimestampBound(staleness),
+		stx:    newReadOnlyTX(ls.ts.client.ReadOnlyTransaction().WithTimestampBound(staleness), ls.opts.TimeoutOptions),
 		ls:     ls,
 	}
 	return &readOnlyLogTX{snapshotTX}, nil
@@ -150,6 +157,7 @@ func (ls *logStorage) begin(ctx context.Context, tree *trillian.Tree, readonly b
 		ls:       ls,
 		dequeued: make(map[string]*QueuedEntry),
 		treeTX:   tx,
+		encoding: tree.LogRootEncoding,
 	}, nil
 }
 
@@ -158,7 +166,7 @@ func (ls *logStorage) BeginForTree(ctx context.Context, treeID int64) (storage.L
 }
 
 func (ls *logStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.Tree, f storage.LogTXFunc) error {
-	_, err := ls.ts.client.ReadWriteTransaction(ctx, func(ctx context.Context, stx *spanner.ReadWriteTransaction) error {
+	return readWriteTransaction(ctx, ls.ts.client, ls.opts.TimeoutOptions, func(ctx context.Context, stx *spanner.ReadWriteTransaction) error {
 		tx, err := ls.begin(ctx, tree, false /* readonly */, stx)
 		if err != nil {
 			return err
@@ -168,14 +176,16 @@ func (ls *logStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.T
 		}
 		return tx.flushSubtrees(ctx)
 	})
-	return err
 }
 
 func (ls *logStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (storage.ReadOnlyLogTreeTX, error) {
-	return ls.begin(ctx, tree, true /* readonly */, ls.ts.client.ReadOnlyTransaction())
+	return ls.begin(ctx, tree, true /* readonly */, newReadOnlyTX(ls.ts.client.ReadOnlyTransaction(), ls.opts.TimeoutOptions))
 }
 
 func (ls *logStorage) QueueLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, qTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	if storage.QueueConditionFromContext(ctx) != nil {
+		return nil, status.Error(codes.Unimplemented, "conditional QueueLeaves not supported")
+	}
 	_, treeConfig, err := ls.ts.getTreeAndConfig(ctx, tree)
 	if err != nil {
 		return nil, err
@@ -302,6 +312,9 @@ type logTX struct {
 	// This is required to recover the primary key for the unsequenced entry in
 	// UpdateSequencedLeaves.
 	dequeued map[string]*QueuedEntry
+
+	// encoding is the serialization of the log roots of the tree.
+	encoding trillian.LogRootEncoding
 }
 
 func (tx *logTX) getLogStorageConfig() *spannerpb.LogStorageConfig {
@@ -331,7 +344,7 @@ func (tx *logTX) LatestSignedLogRoot(ctx context.Context) (*trillian.SignedLogRo
 		TreeSize:       uint64(currentSTH.TreeSize),
 		Revision:       uint64(currentSTH.TreeRevision),
 		Metadata:       currentSTH.Metadata,
-	}).MarshalBinary()
+	}).Marshal(tx.encoding)
 	if err != nil {
 		return nil, err
 	}
@@ -423,6 +436,28 @@ func (tx *logTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.LogL
 	return nil, ErrNotImplemented
 }
 
+// QuarantineLeaves is not supported, so a leaf which can't be integrated
+// blocks the sequencing of the log.
+func (tx *logTX) QuarantineLeaves(ctx context.Context, leaves []*trillian.QuarantinedLeaf) error {
+	return status.Error(codes.Unimplemented, "leaf quarantine not supported")
+}
+
+func (tx *logTX) SignedLogRootHistory(ctx context.Context, limit int) ([]*trillian.SignedLogRoot, error) {
+	return nil, status.Error(codes.Unimplemented, "signed log root history not supported")
+}
+
+func (tx *logTX) SignedLogRootAtSize(ctx context.Context, treeSize int64) (*trillian.SignedLogRoot, error) {
+	return nil, status.Error(codes.Unimplemented, "signed log root lookup by size not supported")
+}
+
+func (tx *logTX) ListQuarantinedLeaves(ctx context.Context, limit int) ([]*trillian.QuarantinedLeaf, error) {
+	return nil, status.Error(codes.Unimplemented, "leaf quarantine not supported")
+}
+
+func (tx *logTX) RequeueQuarantinedLeaves(ctx context.Context, leafIdentityHashes [][]byte) (int, error) {
+	return 0, status.Error(codes.Unimplemented, "leaf quarantine not supported")
+}
+
 // DequeueLeaves removes [0, limit) leaves from the to-be-sequenced queue.
 // The leaves returned are not guaranteed to be in any particular order.
 // The caller should assign sequence numbers and pass the updated leaves as
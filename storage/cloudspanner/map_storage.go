@@ -77,6 +77,13 @@ func (ms *mapStorage) CheckDatabaseAccessible(ctx context.Context) error {
 	return checkDatabaseAccessible(ctx, ms.ts.client)
 }
 
+// CompactTreeStorage implements storage.TreeStorageCompactor. Cloud Spanner
+// manages the layout of its storage itself, so there is nothing to optimize,
+// and no data is removed.
+func (ms *mapStorage) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	return 0, nil
+}
+
 func newMapCache(tree *trillian.Tree) (*cache.SubtreeCache, error) {
 	hasher, err := hashers.NewMapHasher(tree.HashStrategy)
 	if err != nil {
@@ -109,7 +116,7 @@ func (ms *mapStorage) createMapTX(tx *treeTX) (*mapTX, error) {
 }
 
 func (ms *mapStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (storage.ReadOnlyMapTreeTX, error) {
-	return ms.begin(ctx, tree, true, ms.ts.client.ReadOnlyTransaction())
+	return ms.begin(ctx, tree, true, newReadOnlyTX(ms.ts.client.ReadOnlyTransaction(), ms.opts.TimeoutOptions))
 }
 
 // Layout is not implemented.
@@ -118,7 +125,7 @@ func (ms *mapStorage) Layout(tree *trillian.Tree) (*tree.Layout, error) {
 }
 
 func (ms *mapStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.Tree, f storage.MapTXFunc) error {
-	_, err := ms.ts.client.ReadWriteTransaction(ctx, func(ctx context.Context, stx *spanner.ReadWriteTransaction) error {
+	return readWriteTransaction(ctx, ms.ts.client, ms.opts.TimeoutOptions, func(ctx context.Context, stx *spanner.ReadWriteTransaction) error {
 		tx, err := ms.begin(ctx, tree, false /* readonly */, stx)
 		if err != nil {
 			glog.Errorf("failed to mapStorage.begin(treeID=%d): %v", tree.TreeId, err)
@@ -133,7 +140,6 @@ func (ms *mapStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.T
 		}
 		return nil
 	})
-	return err
 }
 
 // mapTX is a concrete implementation of the Trillian storage.MapStorage
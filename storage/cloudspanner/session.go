@@ -0,0 +1,155 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudspanner
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"github.com/google/trillian/monitoring"
+)
+
+var (
+	metricsOnce        sync.Once
+	sessionsInUse      monitoring.Gauge
+	sessionUtilization monitoring.Gauge
+
+	// inUse is the number of transactions currently holding a session.
+	inUse int64
+	// poolSize is the maximum number of sessions the client may open, or zero
+	// if unknown.
+	poolSize uint64
+)
+
+func initMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	sessionsInUse = mf.NewGauge("cloudspanner_sessions_in_use", "Number of Spanner transactions currently open")
+	sessionUtilization = mf.NewGauge("cloudspanner_session_pool_utilization", "Fraction of the maximum Spanner session pool size used by open transactions")
+}
+
+// ensureMetrics initializes metrics with mf, unless already done.
+func ensureMetrics(mf monitoring.MetricFactory) {
+	metricsOnce.Do(func() { initMetrics(mf) })
+}
+
+// setSessionPoolSize records the maximum session pool size used to report
+// utilization.
+func setSessionPoolSize(n uint64) {
+	atomic.StoreUint64(&poolSize, n)
+}
+
+func updateSessionsInUse(delta int64) {
+	n := atomic.AddInt64(&inUse, delta)
+	sessionsInUse.Set(float64(n))
+	if max := atomic.LoadUint64(&poolSize); max > 0 {
+		sessionUtilization.Set(float64(n) / float64(max))
+	}
+}
+
+// TimeoutOptions bounds how long individual Spanner operations may take, on
+// top of any deadline already set on the caller's context.
+// A zero value means no additional timeout is applied.
+type TimeoutOptions struct {
+	// ReadOnlyTimeout bounds each read made through a read-only snapshot.
+	ReadOnlyTimeout time.Duration
+	// ReadWriteTimeout bounds each read-write transaction, including any
+	// retries made by the Spanner client after the transaction was aborted.
+	ReadWriteTimeout time.Duration
+}
+
+// withTimeout returns a copy of ctx which expires after timeout, or ctx
+// itself if timeout is not positive.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// readWriteTransaction runs f in a read-write transaction bounded by
+// opts.ReadWriteTimeout.
+func readWriteTransaction(ctx context.Context, client *spanner.Client, opts TimeoutOptions, f func(context.Context, *spanner.ReadWriteTransaction) error) error {
+	ctx, cancel := withTimeout(ctx, opts.ReadWriteTimeout)
+	defer cancel()
+	updateSessionsInUse(1)
+	defer updateSessionsInUse(-1)
+	_, err := client.ReadWriteTransaction(ctx, f)
+	return err
+}
+
+// readOnlyTX wraps a spanner.ReadOnlyTransaction, applying a timeout to each
+// read and keeping track of open transactions.
+type readOnlyTX struct {
+	*spanner.ReadOnlyTransaction
+	timeout   time.Duration
+	closeOnce sync.Once
+}
+
+func newReadOnlyTX(stx *spanner.ReadOnlyTransaction, opts TimeoutOptions) *readOnlyTX {
+	updateSessionsInUse(1)
+	return &readOnlyTX{ReadOnlyTransaction: stx, timeout: opts.ReadOnlyTimeout}
+}
+
+// iterContext returns the context to use for a read returning a RowIterator.
+func (t *readOnlyTX) iterContext(ctx context.Context) context.Context {
+	if t.timeout <= 0 {
+		return ctx
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	// The iterator outlives this call, so the context can't be cancelled on
+	// return. Release it once its deadline has passed instead.
+	time.AfterFunc(t.timeout, cancel)
+	return ctx
+}
+
+// Query implements spanRead.Query.
+func (t *readOnlyTX) Query(ctx context.Context, stmt spanner.Statement) *spanner.RowIterator {
+	return t.ReadOnlyTransaction.Query(t.iterContext(ctx), stmt)
+}
+
+// Read implements spanRead.Read.
+func (t *readOnlyTX) Read(ctx context.Context, table string, keys spanner.KeySet, columns []string) *spanner.RowIterator {
+	return t.ReadOnlyTransaction.Read(t.iterContext(ctx), table, keys, columns)
+}
+
+// ReadUsingIndex implements spanRead.ReadUsingIndex.
+func (t *readOnlyTX) ReadUsingIndex(ctx context.Context, table, index string, keys spanner.KeySet, columns []string) *spanner.RowIterator {
+	return t.ReadOnlyTransaction.ReadUsingIndex(t.iterContext(ctx), table, index, keys, columns)
+}
+
+// ReadWithOptions implements spanRead.ReadWithOptions.
+func (t *readOnlyTX) ReadWithOptions(ctx context.Context, table string, keys spanner.KeySet, columns []string, opts *spanner.ReadOptions) *spanner.RowIterator {
+	return t.ReadOnlyTransaction.ReadWithOptions(t.iterContext(ctx), table, keys, columns, opts)
+}
+
+// ReadRow implements spanRead.ReadRow.
+func (t *readOnlyTX) ReadRow(ctx context.Context, table string, key spanner.Key, columns []string) (*spanner.Row, error) {
+	ctx, cancel := withTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.ReadOnlyTransaction.ReadRow(ctx, table, key, columns)
+}
+
+// Close closes the underlying transaction. It's safe to call more than once.
+func (t *readOnlyTX) Close() {
+	t.closeOnce.Do(func() {
+		t.ReadOnlyTransaction.Close()
+		updateSessionsInUse(-1)
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudspanner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+)
+
+func TestSessionPoolSize(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		cfg  spanner.ClientConfig
+		want uint64
+	}{
+		{desc: "defaults", want: 400},
+		{desc: "channels", cfg: spanner.ClientConfig{NumChannels: 2}, want: 200},
+		{desc: "maxOpened", cfg: spanner.ClientConfig{NumChannels: 2, SessionPoolConfig: spanner.SessionPoolConfig{MaxOpened: 50}}, want: 50},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := sessionPoolSize(test.cfg); got != test.want {
+				t.Errorf("sessionPoolSize() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUpdateSessionsInUse(t *testing.T) {
+	ensureMetrics(nil)
+	defer setSessionPoolSize(0)
+	setSessionPoolSize(10)
+
+	base := sessionsInUse.Value()
+	updateSessionsInUse(2)
+	if got, want := sessionsInUse.Value(), base+2; got != want {
+		t.Errorf("sessionsInUse = %v, want %v", got, want)
+	}
+	if got, want := sessionUtilization.Value(), (base+2)/10; got != want {
+		t.Errorf("sessionUtilization = %v, want %v", got, want)
+	}
+	updateSessionsInUse(-2)
+	if got, want := sessionsInUse.Value(), base; got != want {
+		t.Errorf("sessionsInUse = %v, want %v", got, want)
+	}
+}
+
+func TestReadOnlyTXContext(t *testing.T) {
+	ctx := context.Background()
+
+	tx := &readOnlyTX{}
+	if got := tx.iterContext(ctx); got != ctx {
+		t.Errorf("iterContext() without timeout returned a new context")
+	}
+
+	tx.timeout = time.Minute
+	for _, c := range []context.Context{
+		tx.iterContext(ctx),
+		func() context.Context {
+			c, cancel := withTimeout(ctx, tx.timeout)
+			defer cancel()
+			return c
+		}(),
+	} {
+		deadline, ok := c.Deadline()
+		if !ok {
+			t.Fatalf("Deadline() = (_, false), want (_, true)")
+		}
+		if d := time.Until(deadline); d <= 0 || d > time.Minute {
+			t.Errorf("Deadline() in %v, want within %v", d, time.Minute)
+		}
+	}
+}
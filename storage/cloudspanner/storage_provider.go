@@ -32,14 +32,16 @@ import (
 
 var (
 	csURI                                = flag.String("cloudspanner_uri", "", "Connection URI for CloudSpanner database")
-	csNumChannels                        = flag.Int("cloudspanner_num_channels", 0, "Number of gRPC channels to use to talk to CloudSpanner.")
-	csSessionMaxOpened                   = flag.Uint64("cloudspanner_max_open_sessions", 0, "Max open sessions.")
+	csNumChannels                        = flag.Int("cloudspanner_num_channels", 0, "Number of gRPC channels to use to talk to CloudSpanner. If zero, the client library default (4) is used.")
+	csSessionMaxOpened                   = flag.Uint64("cloudspanner_max_open_sessions", 0, "Max open sessions. If zero, 100 sessions per gRPC channel are allowed.")
 	csSessionMinOpened                   = flag.Uint64("cloudspanner_min_open_sessions", 0, "Min open sessions.")
 	csSessionMaxIdle                     = flag.Uint64("cloudspanner_max_idle_sessions", 0, "Max idle sessions.")
-	csSessionMaxBurst                    = flag.Uint64("cloudspanner_max_burst_sessions", 0, "Max concurrent create session requests.")
+	csSessionMaxBurst                    = flag.Uint64("cloudspanner_max_burst_sessions", 0, "Max concurrent create session requests. If zero, the client library default (10) is used.")
 	csSessionWriteSessions               = flag.Float64("cloudspanner_write_sessions", 0, "Fraction of write capable sessions to maintain.")
-	csSessionHCWorkers                   = flag.Int("cloudspanner_num_healthcheckers", 0, "Number of health check workers for Spanner session pool.")
-	csSessionHCInterval                  = flag.Duration("cloudspanner_healthcheck_interval", 0, "Interval betweek pinging sessions.")
+	csSessionHCWorkers                   = flag.Int("cloudspanner_num_healthcheckers", 0, "Number of health check workers for Spanner session pool. If zero, the client library default (10) is used.")
+	csSessionHCInterval                  = flag.Duration("cloudspanner_healthcheck_interval", 0, "Interval between pinging sessions. If zero, the client library default (5m) is used.")
+	csReadOnlyTimeout                    = flag.Duration("cloudspanner_read_only_timeout", 0, "Timeout for each read made by a read-only transaction, or zero for none. If set, it should comfortably exceed the latency of the largest reads, e.g. 30s.")
+	csReadWriteTimeout                   = flag.Duration("cloudspanner_read_write_timeout", 0, "Timeout for each read-write transaction, including retries, or zero for none. If set, it should allow for retries of the largest transactions, e.g. 1m.")
 	csDequeueAcrossMerkleBucketsFraction = flag.Float64("cloudspanner_dequeue_bucket_fraction", 0.75, "Fraction of merkle keyspace to dequeue from, set to zero to disable.")
 	csReadOnlyStaleness                  = flag.Duration("cloudspanner_readonly_staleness", time.Minute, "How far in the past to perform readonly operations. Within limits, raising this should help to increase performance/reduce latency.")
 
@@ -87,7 +89,27 @@ func configFromFlags() spanner.ClientConfig {
 	return r
 }
 
-func newCloudSpannerStorageProvider(_ monitoring.MetricFactory) (storage.Provider, error) {
+// sessionPoolSize returns the maximum number of sessions a client created with
+// cfg may open, mirroring the defaults applied by the Spanner client library.
+func sessionPoolSize(cfg spanner.ClientConfig) uint64 {
+	if cfg.SessionPoolConfig.MaxOpened > 0 {
+		return cfg.SessionPoolConfig.MaxOpened
+	}
+	numChannels := cfg.NumChannels
+	if numChannels == 0 {
+		numChannels = 4
+	}
+	return uint64(numChannels * 100)
+}
+
+func timeoutOptionsFromFlags() TimeoutOptions {
+	return TimeoutOptions{
+		ReadOnlyTimeout:  *csReadOnlyTimeout,
+		ReadWriteTimeout: *csReadWriteTimeout,
+	}
+}
+
+func newCloudSpannerStorageProvider(mf monitoring.MetricFactory) (storage.Provider, error) {
 	csMu.Lock()
 	defer csMu.Unlock()
 
@@ -95,10 +117,13 @@ func newCloudSpannerStorageProvider(_ monitoring.MetricFactory) (storage.Provide
 		return csStorageInstance, nil
 	}
 
-	client, err := spanner.NewClientWithConfig(context.TODO(), *csURI, configFromFlags())
+	ensureMetrics(mf)
+	cfg := configFromFlags()
+	client, err := spanner.NewClientWithConfig(context.TODO(), *csURI, cfg)
 	if err != nil {
 		return nil, err
 	}
+	setSessionPoolSize(sessionPoolSize(cfg))
 	csStorageInstance = &cloudSpannerProvider{
 		client: client,
 	}
@@ -109,6 +134,7 @@ func newCloudSpannerStorageProvider(_ monitoring.MetricFactory) (storage.Provide
 func (s *cloudSpannerProvider) LogStorage() storage.LogStorage {
 	warn()
 	opts := LogStorageOptions{}
+	opts.TimeoutOptions = timeoutOptionsFromFlags()
 	frac := *csDequeueAcrossMerkleBucketsFraction
 	if frac > 1.0 {
 		frac = 1.0
@@ -127,6 +153,7 @@ func (s *cloudSpannerProvider) LogStorage() storage.LogStorage {
 func (s *cloudSpannerProvider) MapStorage() storage.MapStorage {
 	warn()
 	opts := MapStorageOptions{}
+	opts.TimeoutOptions = timeoutOptionsFromFlags()
 	if *csReadOnlyStaleness > 0 {
 		opts.ReadOnlyStaleness = *csReadOnlyStaleness
 	}
@@ -136,7 +163,7 @@ func (s *cloudSpannerProvider) MapStorage() storage.MapStorage {
 // AdminStorage builds and returns a new storage.AdminStorage using CloudSpanner.
 func (s *cloudSpannerProvider) AdminStorage() storage.AdminStorage {
 	warn()
-	return NewAdminStorage(s.client)
+	return NewAdminStorageWithOpts(s.client, AdminStorageOptions{TimeoutOptions: timeoutOptionsFromFlags()})
 }
 
 // Close shuts down this provider. Calls to the other methods will fail
@@ -81,9 +81,12 @@ type TreeStorageOptions struct {
 	// to help with performance.
 	// See https://cloud.google.com/spanner/docs/timestamp-bounds for more details.
 	ReadOnlyStaleness time.Duration
+
+	TimeoutOptions
 }
 
 func newTreeStorageWithOpts(client *spanner.Client, opts TreeStorageOptions) *treeStorage {
+	ensureMetrics(nil)
 	return &treeStorage{client: client, admin: nil, opts: opts}
 }
 
@@ -262,7 +265,7 @@ func (t *treeTX) Commit(ctx context.Context) error {
 		return ErrTransactionClosed
 	}
 	switch stx := t.stx.(type) {
-	case *spanner.ReadOnlyTransaction:
+	case *readOnlyTX:
 		glog.V(1).Infof("Closed readonly tx %p", stx)
 		stx.Close()
 		return nil
@@ -286,9 +289,17 @@ func (t *treeTX) Rollback() error {
 	if t.stx == nil {
 		return ErrTransactionClosed
 	}
+	// A ReadWriteTransaction is rolled back by returning an error from its
+	// function, but a readonly one holds a session until it's closed.
+	if stx, ok := t.stx.(*readOnlyTX); ok {
+		glog.V(1).Infof("Closed readonly tx %p", stx)
+		stx.Close()
+	}
 	return nil
 }
 
+// Close rolls back the transaction if it's still open, which closes its
+// readonly Spanner transaction if it has one.
 func (t *treeTX) Close() error {
 	if t.IsOpen() {
 		if err := t.Rollback(); err != nil && err != ErrTransactionClosed {
@@ -511,7 +522,7 @@ func (t *snapshotTX) Close() error {
 	if t.stx == nil {
 		return ErrTransactionClosed
 	}
-	if stx, ok := t.stx.(*spanner.ReadOnlyTransaction); ok {
+	if stx, ok := t.stx.(*readOnlyTX); ok {
 		glog.V(1).Infof("Closed log snapshot %p", stx)
 		stx.Close()
 	}
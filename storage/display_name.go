@@ -0,0 +1,69 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CheckDisplayNameAvailable returns an AlreadyExists error naming the
+// conflicting tree if tx has a non-deleted tree called displayName. Trees
+// without a display name never conflict. If tx is a DisplayNameLocker, the
+// trees are locked, so that no concurrent transaction can create a tree of
+// the same name before tx ends; otherwise concurrent creations are only
+// prevented by the isolation of tx.
+func CheckDisplayNameAvailable(ctx context.Context, tx AdminReader, displayName string) error {
+	return CheckDisplayNameAvailableFor(ctx, tx, 0, displayName)
+}
+
+// CheckDisplayNameAvailableFor is like CheckDisplayNameAvailable, for the
+// tree treeID being renamed to displayName, which doesn't conflict with
+// itself.
+func CheckDisplayNameAvailableFor(ctx context.Context, tx AdminReader, treeID int64, displayName string) error {
+	if displayName == "" {
+		return nil
+	}
+	var ids []int64
+	l, ok := tx.(DisplayNameLocker)
+	if ok {
+		var err error
+		ids, err = l.LockTreesByDisplayName(ctx, displayName)
+		if status.Code(err) == codes.Unimplemented {
+			ok = false
+		} else if err != nil {
+			return err
+		}
+	}
+	if !ok {
+		trees, err := tx.ListTrees(ctx, false /* includeDeleted */)
+		if err != nil {
+			return err
+		}
+		for _, tree := range trees {
+			if tree.DisplayName == displayName {
+				ids = append(ids, tree.TreeId)
+			}
+		}
+	}
+	for _, id := range ids {
+		if id != treeID {
+			return status.Errorf(codes.AlreadyExists, "tree %v already has display name %q", id, displayName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// lockingAdminTX is an AdminTX which implements DisplayNameLocker.
+type lockingAdminTX struct {
+	*MockAdminTX
+	ids []int64
+	err error
+}
+
+func (t *lockingAdminTX) LockTreesByDisplayName(ctx context.Context, displayName string) ([]int64, error) {
+	return t.ids, t.err
+}
+
+func TestCheckDisplayNameAvailable(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	trees := []*trillian.Tree{{TreeId: 1, DisplayName: "a"}, {TreeId: 2, DisplayName: "b"}}
+	for _, test := range []struct {
+		desc        string
+		treeID      int64
+		displayName string
+		locker      bool
+		lockIDs     []int64
+		lockErr     error
+		list        bool
+		wantCode    codes.Code
+	}{
+		{desc: "empty", displayName: ""},
+		{desc: "listAvailable", displayName: "c", list: true},
+		{desc: "listTaken", displayName: "b", list: true, wantCode: codes.AlreadyExists},
+		{desc: "lockAvailable", displayName: "b", locker: true},
+		{desc: "lockTaken", displayName: "c", locker: true, lockIDs: []int64{3}, wantCode: codes.AlreadyExists},
+		{desc: "lockErr", displayName: "c", locker: true, lockErr: status.Error(codes.Aborted, "deadlock"), wantCode: codes.Aborted},
+		{desc: "lockUnimplemented", displayName: "a", locker: true, lockErr: status.Error(codes.Unimplemented, "no"), list: true, wantCode: codes.AlreadyExists},
+		{desc: "listSameTree", treeID: 2, displayName: "b", list: true},
+		{desc: "lockSameTree", treeID: 3, displayName: "c", locker: true, lockIDs: []int64{3}},
+		{desc: "lockOtherTree", treeID: 3, displayName: "c", locker: true, lockIDs: []int64{3, 4}, wantCode: codes.AlreadyExists},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			mockTX := NewMockAdminTX(ctrl)
+			if test.list {
+				mockTX.EXPECT().ListTrees(gomock.Any(), false).Return(trees, nil)
+			}
+			var tx AdminReader = mockTX
+			if test.locker {
+				tx = &lockingAdminTX{MockAdminTX: mockTX, ids: test.lockIDs, err: test.lockErr}
+			}
+			err := CheckDisplayNameAvailableFor(ctx, tx, test.treeID, test.displayName)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("CheckDisplayNameAvailableFor(%v, %q) returned err = %v, wantCode = %s", test.treeID, test.displayName, err, test.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,441 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encryption contains a storage implementation that encrypts the
+// values of leaves at rest, for trees with trillian.Tree.LeafEncryption set.
+//
+// The leaf_value and extra_data of each leaf are encrypted with AES-256-GCM
+// under the data key of its tree, and bound to the tree and the leaf identity
+// hash, so that stored values can't be moved between leaves unnoticed. The
+// data key is stored in the tree wrapped by a key encryption key (KEK), see
+// KeyWrapper, so rotating the KEK only requires rewrapping the data key of
+// each tree, not re-encrypting its leaves. Leaf hashes are computed by
+// callers on the plaintext, and stored as is.
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/storagepb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Versions of stored ciphertext, identified by its first byte. Values must
+// never be reused, as they are stored.
+const leafCipherV1 byte = 1
+
+// Fields of a leaf which are encrypted, bound to their ciphertext so they
+// can't be swapped.
+const (
+	fieldLeafValue byte = 1
+	fieldExtraData byte = 2
+)
+
+// NewLogStorage wraps s with an implementation that encrypts the leaf_value
+// and extra_data of the leaves of trees with leaf_encryption set, using data
+// keys unwrapped by w. Leaves of other trees are passed through unchanged.
+// Operations on trees with leaf_encryption fail with FailedPrecondition if w
+// is nil.
+//
+// Leaves are encrypted by QueueLeaves and AddSequencedLeaves, and decrypted
+// when read by GetLeavesByIndex, GetLeavesByRange and GetLeavesByHash, and
+// when returned as duplicates. DequeueLeaves returns leaves as stored, since
+// the sequencer only needs their hashes, and UpdateSequencedLeaves and
+// QuarantineLeaves expect them that way. Hence, the log signer doesn't need
+// access to the data keys. ListQuarantinedLeaves returns leaves as stored too,
+// as it is served by the signer, so the values of quarantined leaves are
+// returned encrypted.
+func NewLogStorage(s storage.LogStorage, w KeyWrapper) storage.LogStorage {
+	return &logStorage{LogStorage: s, wrapper: w, keys: make(map[int64]*treeKey)}
+}
+
+type logStorage struct {
+	storage.LogStorage
+	wrapper KeyWrapper
+
+	mu   sync.Mutex
+	keys map[int64]*treeKey // By tree ID.
+}
+
+// treeKey is an unwrapped data key, cached along with the wrapped key it was
+// obtained from.
+type treeKey struct {
+	wrapped []byte
+	cipher  *leafCipher
+}
+
+// leafCipher returns the cipher for the leaves of tree, or nil if they aren't
+// encrypted.
+func (s *logStorage) leafCipher(ctx context.Context, tree *trillian.Tree) (*leafCipher, error) {
+	e := tree.GetLeafEncryption()
+	if e == nil {
+		return nil, nil
+	}
+	if s.wrapper == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "tree %v has leaf_encryption, but no key wrapper is configured", tree.TreeId)
+	}
+
+	s.mu.Lock()
+	k, ok := s.keys[tree.TreeId]
+	s.mu.Unlock()
+	if ok && bytes.Equal(k.wrapped, e.WrappedDataKey) {
+		return k.cipher, nil
+	}
+
+	key, err := s.wrapper.Unwrap(ctx, e.WrappedDataKey, e.KekId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key of tree %v: %v", tree.TreeId, err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key of tree %v: %v", tree.TreeId, err)
+	}
+	c := &leafCipher{treeID: tree.TreeId, aead: aead}
+	s.mu.Lock()
+	s.keys[tree.TreeId] = &treeKey{wrapped: e.WrappedDataKey, cipher: c}
+	s.mu.Unlock()
+	return c, nil
+}
+
+// SnapshotForTree implements ReadOnlyLogStorage.SnapshotForTree.
+func (s *logStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (storage.ReadOnlyLogTreeTX, error) {
+	c, err := s.leafCipher(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.LogStorage.SnapshotForTree(ctx, tree)
+	if err != nil || c == nil {
+		return tx, err
+	}
+	return &readOnlyLogTreeTX{ReadOnlyLogTreeTX: tx, cipher: c}, nil
+}
+
+// ReadWriteTransaction implements LogStorage.ReadWriteTransaction.
+func (s *logStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.Tree, f storage.LogTXFunc) error {
+	c, err := s.leafCipher(ctx, tree)
+	if err != nil {
+		return err
+	}
+	if c == nil {
+		return s.LogStorage.ReadWriteTransaction(ctx, tree, f)
+	}
+	return s.LogStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		return f(ctx, &logTreeTX{
+			LogTreeTX:         tx,
+			readOnlyLogTreeTX: readOnlyLogTreeTX{ReadOnlyLogTreeTX: tx, cipher: c},
+		})
+	})
+}
+
+// QueueLeaves implements LogStorage.QueueLeaves.
+func (s *logStorage) QueueLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	c, err := s.leafCipher(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return s.LogStorage.QueueLeaves(ctx, tree, leaves, queueTimestamp)
+	}
+	encrypted, err := c.encryptLeaves(leaves)
+	if err != nil {
+		return nil, err
+	}
+	queued, err := s.LogStorage.QueueLeaves(ctx, tree, encrypted, queueTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	return c.decryptQueuedLeaves(queued)
+}
+
+// AddSequencedLeaves implements LogStorage.AddSequencedLeaves.
+func (s *logStorage) AddSequencedLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, timestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	c, err := s.leafCipher(ctx, tree)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return s.LogStorage.AddSequencedLeaves(ctx, tree, leaves, timestamp)
+	}
+	encrypted, err := c.encryptLeaves(leaves)
+	if err != nil {
+		return nil, err
+	}
+	added, err := s.LogStorage.AddSequencedLeaves(ctx, tree, encrypted, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return c.decryptQueuedLeaves(added)
+}
+
+// CompactTreeStorage implements storage.TreeStorageCompactor, if the wrapped
+// storage does. Compaction doesn't read leaves, so needs no data key.
+func (s *logStorage) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	c, ok := s.LogStorage.(storage.TreeStorageCompactor)
+	if !ok {
+		return 0, status.Error(codes.Unimplemented, "storage doesn't support compaction")
+	}
+	return c.CompactTreeStorage(ctx, tree, optimize)
+}
+
+type readOnlyLogTreeTX struct {
+	storage.ReadOnlyLogTreeTX
+	cipher *leafCipher
+}
+
+// GetLeavesByIndex implements ReadOnlyLogTreeTX.GetLeavesByIndex.
+func (t *readOnlyLogTreeTX) GetLeavesByIndex(ctx context.Context, leaves []int64) ([]*trillian.LogLeaf, error) {
+	ret, err := t.ReadOnlyLogTreeTX.GetLeavesByIndex(ctx, leaves)
+	if err != nil {
+		return nil, err
+	}
+	return t.cipher.decryptLeaves(ret)
+}
+
+// GetLeavesByRange implements ReadOnlyLogTreeTX.GetLeavesByRange.
+func (t *readOnlyLogTreeTX) GetLeavesByRange(ctx context.Context, start, count int64) ([]*trillian.LogLeaf, error) {
+	ret, err := t.ReadOnlyLogTreeTX.GetLeavesByRange(ctx, start, count)
+	if err != nil {
+		return nil, err
+	}
+	return t.cipher.decryptLeaves(ret)
+}
+
+// GetLeavesByHash implements ReadOnlyLogTreeTX.GetLeavesByHash.
+func (t *readOnlyLogTreeTX) GetLeavesByHash(ctx context.Context, leafHashes [][]byte, orderBySequence bool) ([]*trillian.LogLeaf, error) {
+	ret, err := t.ReadOnlyLogTreeTX.GetLeavesByHash(ctx, leafHashes, orderBySequence)
+	if err != nil {
+		return nil, err
+	}
+	return t.cipher.decryptLeaves(ret)
+}
+
+// CountUnsequenced implements storage.UnsequencedCounter, if the wrapped
+// transaction does.
+func (t *readOnlyLogTreeTX) CountUnsequenced(ctx context.Context, limit int64) (int64, error) {
+	c, ok := t.ReadOnlyLogTreeTX.(storage.UnsequencedCounter)
+	if !ok {
+		return 0, status.Error(codes.Unimplemented, "storage doesn't count unsequenced leaves")
+	}
+	return c.CountUnsequenced(ctx, limit)
+}
+
+// IsTransientError implements storage.TransientErrorClassifier, if the
+// wrapped transaction does.
+func (t *readOnlyLogTreeTX) IsTransientError(err error) bool {
+	c, ok := t.ReadOnlyLogTreeTX.(storage.TransientErrorClassifier)
+	return ok && c.IsTransientError(err)
+}
+
+// CompactedRevision implements storage.CompactedRevisionReader, if the
+// wrapped transaction does.
+func (t *readOnlyLogTreeTX) CompactedRevision(ctx context.Context) (int64, error) {
+	r, ok := t.ReadOnlyLogTreeTX.(storage.CompactedRevisionReader)
+	if !ok {
+		return 0, status.Error(codes.Unimplemented, "storage doesn't report compacted revisions")
+	}
+	return r.CompactedRevision(ctx)
+}
+
+// logTreeTX overrides the read methods of the embedded LogTreeTX with those of
+// readOnlyLogTreeTX.
+type logTreeTX struct {
+	storage.LogTreeTX
+	readOnlyLogTreeTX
+}
+
+// GetLeavesByIndex implements ReadOnlyLogTreeTX.GetLeavesByIndex.
+func (t *logTreeTX) GetLeavesByIndex(ctx context.Context, leaves []int64) ([]*trillian.LogLeaf, error) {
+	return t.readOnlyLogTreeTX.GetLeavesByIndex(ctx, leaves)
+}
+
+// GetLeavesByRange implements ReadOnlyLogTreeTX.GetLeavesByRange.
+func (t *logTreeTX) GetLeavesByRange(ctx context.Context, start, count int64) ([]*trillian.LogLeaf, error) {
+	return t.readOnlyLogTreeTX.GetLeavesByRange(ctx, start, count)
+}
+
+// GetLeavesByHash implements ReadOnlyLogTreeTX.GetLeavesByHash.
+func (t *logTreeTX) GetLeavesByHash(ctx context.Context, leafHashes [][]byte, orderBySequence bool) ([]*trillian.LogLeaf, error) {
+	return t.readOnlyLogTreeTX.GetLeavesByHash(ctx, leafHashes, orderBySequence)
+}
+
+// ListQuarantinedLeaves implements ReadOnlyLogTreeTX.ListQuarantinedLeaves.
+func (t *logTreeTX) ListQuarantinedLeaves(ctx context.Context, limit int) ([]*trillian.QuarantinedLeaf, error) {
+	return t.readOnlyLogTreeTX.ListQuarantinedLeaves(ctx, limit)
+}
+
+// QueueLeaves implements LogTreeTX.QueueLeaves.
+func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.LogLeaf, error) {
+	encrypted, err := t.cipher.encryptLeaves(leaves)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := t.LogTreeTX.QueueLeaves(ctx, encrypted, queueTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	return t.cipher.decryptLeaves(existing)
+}
+
+// AddSequencedLeaves implements LogTreeTX.AddSequencedLeaves.
+func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.LogLeaf, timestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	encrypted, err := t.cipher.encryptLeaves(leaves)
+	if err != nil {
+		return nil, err
+	}
+	added, err := t.LogTreeTX.AddSequencedLeaves(ctx, encrypted, timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return t.cipher.decryptQueuedLeaves(added)
+}
+
+// QueueRequestID implements storage.QueueRequestIDReader, if the wrapped
+// transaction does.
+func (t *logTreeTX) QueueRequestID(leafIdentityHash []byte) string {
+	if r, ok := t.LogTreeTX.(storage.QueueRequestIDReader); ok {
+		return r.QueueRequestID(leafIdentityHash)
+	}
+	return ""
+}
+
+// QueueCondition implements storage.QueueConditionReader, if the wrapped
+// transaction does.
+func (t *logTreeTX) QueueCondition(leafIdentityHash []byte) *storagepb.QueueCondition {
+	if r, ok := t.LogTreeTX.(storage.QueueConditionReader); ok {
+		return r.QueueCondition(leafIdentityHash)
+	}
+	return nil
+}
+
+// leafCipher encrypts and decrypts the values of the leaves of a tree.
+type leafCipher struct {
+	treeID int64
+	aead   cipher.AEAD
+}
+
+// additionalData returns the data bound to the ciphertext of a field of the
+// leaf with the given identity hash.
+func (c *leafCipher) additionalData(field byte, leafIdentityHash []byte) []byte {
+	ad := make([]byte, 9, 9+len(leafIdentityHash))
+	binary.BigEndian.PutUint64(ad, uint64(c.treeID))
+	ad[8] = field
+	return append(ad, leafIdentityHash...)
+}
+
+// encrypt returns the ciphertext to store for a field of a leaf. Empty values
+// are stored as is.
+func (c *leafCipher) encrypt(field byte, leafIdentityHash, plaintext []byte) ([]byte, error) {
+	if len(plaintext) == 0 {
+		return plaintext, nil
+	}
+	ciphertext, err := seal(c.aead, plaintext, c.additionalData(field, leafIdentityHash))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{leafCipherV1}, ciphertext...), nil
+}
+
+// decrypt reverses encrypt.
+func (c *leafCipher) decrypt(field byte, leafIdentityHash, stored []byte) ([]byte, error) {
+	if len(stored) == 0 {
+		return stored, nil
+	}
+	if stored[0] != leafCipherV1 {
+		return nil, fmt.Errorf("unknown leaf ciphertext version %d", stored[0])
+	}
+	return open(c.aead, stored[1:], c.additionalData(field, leafIdentityHash))
+}
+
+// encryptLeaf returns a copy of leaf with its values encrypted.
+func (c *leafCipher) encryptLeaf(leaf *trillian.LogLeaf) (*trillian.LogLeaf, error) {
+	if leaf == nil {
+		return nil, nil
+	}
+	value, err := c.encrypt(fieldLeafValue, leaf.LeafIdentityHash, leaf.LeafValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt leaf value of leaf %x: %v", leaf.LeafIdentityHash, err)
+	}
+	extra, err := c.encrypt(fieldExtraData, leaf.LeafIdentityHash, leaf.ExtraData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt extra data of leaf %x: %v", leaf.LeafIdentityHash, err)
+	}
+	leaf = proto.Clone(leaf).(*trillian.LogLeaf)
+	leaf.LeafValue, leaf.ExtraData = value, extra
+	return leaf, nil
+}
+
+// decryptLeaf returns a copy of leaf with its values decrypted.
+func (c *leafCipher) decryptLeaf(leaf *trillian.LogLeaf) (*trillian.LogLeaf, error) {
+	if leaf == nil {
+		return nil, nil
+	}
+	value, err := c.decrypt(fieldLeafValue, leaf.LeafIdentityHash, leaf.LeafValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt leaf value of leaf %x: %v", leaf.LeafIdentityHash, err)
+	}
+	extra, err := c.decrypt(fieldExtraData, leaf.LeafIdentityHash, leaf.ExtraData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt extra data of leaf %x: %v", leaf.LeafIdentityHash, err)
+	}
+	leaf = proto.Clone(leaf).(*trillian.LogLeaf)
+	leaf.LeafValue, leaf.ExtraData = value, extra
+	return leaf, nil
+}
+
+func (c *leafCipher) encryptLeaves(leaves []*trillian.LogLeaf) ([]*trillian.LogLeaf, error) {
+	ret := make([]*trillian.LogLeaf, len(leaves))
+	for i, leaf := range leaves {
+		var err error
+		if ret[i], err = c.encryptLeaf(leaf); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (c *leafCipher) decryptLeaves(leaves []*trillian.LogLeaf) ([]*trillian.LogLeaf, error) {
+	if leaves == nil {
+		return nil, nil
+	}
+	ret := make([]*trillian.LogLeaf, len(leaves))
+	for i, leaf := range leaves {
+		var err error
+		if ret[i], err = c.decryptLeaf(leaf); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func (c *leafCipher) decryptQueuedLeaves(queued []*trillian.QueuedLogLeaf) ([]*trillian.QueuedLogLeaf, error) {
+	ret := make([]*trillian.QueuedLogLeaf, len(queued))
+	for i, q := range queued {
+		leaf, err := c.decryptLeaf(q.GetLeaf())
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = &trillian.QueuedLogLeaf{Leaf: leaf, Status: q.GetStatus()}
+	}
+	return ret, nil
+}
@@ -0,0 +1,316 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/memory"
+	"github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newEncryptedTree creates a log tree in ts whose data key is wrapped by w.
+func newEncryptedTree(ctx context.Context, t *testing.T, ts *memory.TreeStorage, w KeyWrapper) *trillian.Tree {
+	t.Helper()
+	key, err := NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey(): %v", err)
+	}
+	wrapped, kekID, err := w.Wrap(ctx, key)
+	if err != nil {
+		t.Fatalf("Wrap(): %v", err)
+	}
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.LeafEncryption = &trillian.LeafEncryption{WrappedDataKey: wrapped, KekId: kekID}
+	return newTree(ctx, t, ts, tree)
+}
+
+// newTree creates tree in ts, with an empty root.
+func newTree(ctx context.Context, t *testing.T, ts *memory.TreeStorage, tree *trillian.Tree) *trillian.Tree {
+	t.Helper()
+	tree, err := storage.CreateTree(ctx, memory.NewAdminStorage(ts), tree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	logRoot, err := (&types.LogRootV1{RootHash: rfc6962.DefaultHasher.EmptyRoot()}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary(): %v", err)
+	}
+	if err := memory.NewLogStorage(ts, nil).ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		return tx.StoreSignedLogRoot(ctx, &trillian.SignedLogRoot{LogRoot: logRoot})
+	}); err != nil {
+		t.Fatalf("StoreSignedLogRoot(): %v", err)
+	}
+	return tree
+}
+
+func newLeaves(n int) []*trillian.LogLeaf {
+	var leaves []*trillian.LogLeaf
+	for i := 0; i < n; i++ {
+		value := []byte(fmt.Sprintf("value-%d", i))
+		hash := rfc6962.DefaultHasher.HashLeaf(value)
+		leaves = append(leaves, &trillian.LogLeaf{
+			LeafIdentityHash: hash,
+			MerkleLeafHash:   hash,
+			LeafValue:        value,
+			ExtraData:        []byte(fmt.Sprintf("extra-%d", i)),
+		})
+	}
+	return leaves
+}
+
+// sequence dequeues the leaves of tree from s, and assigns them indices in
+// the order they are dequeued.
+func sequence(ctx context.Context, t *testing.T, s storage.LogStorage, tree *trillian.Tree) []*trillian.LogLeaf {
+	t.Helper()
+	var dequeued []*trillian.LogLeaf
+	if err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		var err error
+		if dequeued, err = tx.DequeueLeaves(ctx, 10, time.Now()); err != nil {
+			return err
+		}
+		for i, leaf := range dequeued {
+			leaf.LeafIndex = int64(i)
+		}
+		return tx.UpdateSequencedLeaves(ctx, dequeued)
+	}); err != nil {
+		t.Fatalf("ReadWriteTransaction(): %v", err)
+	}
+	return dequeued
+}
+
+func getLeaves(ctx context.Context, t *testing.T, s storage.LogStorage, tree *trillian.Tree, count int64) []*trillian.LogLeaf {
+	t.Helper()
+	tx, err := s.SnapshotForTree(ctx, tree)
+	if err != nil {
+		t.Fatalf("SnapshotForTree(): %v", err)
+	}
+	defer tx.Close()
+	leaves, err := tx.GetLeavesByRange(ctx, 0, count)
+	if err != nil {
+		t.Fatalf("GetLeavesByRange(): %v", err)
+	}
+	return leaves
+}
+
+func TestLogStorage(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	raw := memory.NewLogStorage(ts, nil)
+	keyring, err := NewKeyring("k1", map[string][]byte{"k1": kek(1)})
+	if err != nil {
+		t.Fatalf("NewKeyring(): %v", err)
+	}
+	s := NewLogStorage(raw, keyring)
+	tree := newEncryptedTree(ctx, t, ts, keyring)
+
+	leaves := newLeaves(3)
+	leaves[2].ExtraData = nil
+	queued, err := s.QueueLeaves(ctx, tree, leaves, time.Now())
+	if err != nil {
+		t.Fatalf("QueueLeaves(): %v", err)
+	}
+	// Leaves are returned decrypted, as duplicates would be.
+	for i, q := range queued {
+		if !bytes.Equal(q.Leaf.LeafValue, leaves[i].LeafValue) || !bytes.Equal(q.Leaf.ExtraData, leaves[i].ExtraData) {
+			t.Errorf("QueueLeaves()[%d] = %q, %q; want %q, %q", i, q.Leaf.LeafValue, q.Leaf.ExtraData, leaves[i].LeafValue, leaves[i].ExtraData)
+		}
+	}
+
+	// The sequencer handles the values as stored.
+	for _, leaf := range sequence(ctx, t, s, tree) {
+		if bytes.Contains(leaf.LeafValue, []byte("value")) {
+			t.Errorf("DequeueLeaves() returned plaintext value %q", leaf.LeafValue)
+		}
+	}
+
+	stored := getLeaves(ctx, t, raw, tree, 3)
+	got := getLeaves(ctx, t, s, tree, 3)
+	if len(stored) != 3 || len(got) != 3 {
+		t.Fatalf("GetLeavesByRange() returned %d stored and %d decrypted leaves, want 3", len(stored), len(got))
+	}
+	byHash := make(map[string]*trillian.LogLeaf)
+	for _, leaf := range leaves {
+		byHash[string(leaf.LeafIdentityHash)] = leaf
+	}
+	for i := range got {
+		want := byHash[string(got[i].LeafIdentityHash)]
+		if !bytes.Equal(got[i].LeafValue, want.LeafValue) || !bytes.Equal(got[i].ExtraData, want.ExtraData) {
+			t.Errorf("GetLeavesByRange()[%d] = %q, %q; want %q, %q", i, got[i].LeafValue, got[i].ExtraData, want.LeafValue, want.ExtraData)
+		}
+		if len(want.ExtraData) == 0 && len(stored[i].ExtraData) != 0 {
+			t.Errorf("stored leaf %d has extra data %x, want empty", i, stored[i].ExtraData)
+		}
+		if bytes.Contains(stored[i].LeafValue, want.LeafValue) || (len(want.ExtraData) > 0 && bytes.Contains(stored[i].ExtraData, want.ExtraData)) {
+			t.Errorf("stored leaf %d has plaintext values", i)
+		}
+		if !bytes.Equal(stored[i].MerkleLeafHash, want.MerkleLeafHash) {
+			t.Errorf("stored leaf %d has Merkle leaf hash %x, want %x", i, stored[i].MerkleLeafHash, want.MerkleLeafHash)
+		}
+	}
+
+	// Leaves can still be read after the data key is rewrapped by another KEK.
+	rotated, err := NewKeyring("k2", map[string][]byte{"k1": kek(1), "k2": kek(2)})
+	if err != nil {
+		t.Fatalf("NewKeyring(): %v", err)
+	}
+	key, err := rotated.Unwrap(ctx, tree.LeafEncryption.WrappedDataKey, tree.LeafEncryption.KekId)
+	if err != nil {
+		t.Fatalf("Unwrap(): %v", err)
+	}
+	rewrapped := proto.Clone(tree).(*trillian.Tree)
+	if rewrapped.LeafEncryption.WrappedDataKey, rewrapped.LeafEncryption.KekId, err = rotated.Wrap(ctx, key); err != nil {
+		t.Fatalf("Wrap(): %v", err)
+	}
+	onlyNew, err := NewKeyring("k2", map[string][]byte{"k2": kek(2)})
+	if err != nil {
+		t.Fatalf("NewKeyring(): %v", err)
+	}
+	if after := getLeaves(ctx, t, NewLogStorage(raw, onlyNew), rewrapped, 3); !proto.Equal(after[0], got[0]) {
+		t.Errorf("GetLeavesByRange() after rewrapping = %v, want %v", after[0], got[0])
+	}
+}
+
+func TestLogStorageQuarantine(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	keyring, err := NewKeyring("k1", map[string][]byte{"k1": kek(1)})
+	if err != nil {
+		t.Fatalf("NewKeyring(): %v", err)
+	}
+	s := NewLogStorage(memory.NewLogStorage(ts, nil), keyring)
+	tree := newEncryptedTree(ctx, t, ts, keyring)
+
+	leaves := newLeaves(1)
+	if err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		_, err := tx.QueueLeaves(ctx, leaves, time.Now())
+		return err
+	}); err != nil {
+		t.Fatalf("QueueLeaves(): %v", err)
+	}
+	if err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		dequeued, err := tx.DequeueLeaves(ctx, 10, time.Now())
+		if err != nil {
+			return err
+		}
+		return tx.QuarantineLeaves(ctx, []*trillian.QuarantinedLeaf{{Leaf: dequeued[0], Error: "bad leaf"}})
+	}); err != nil {
+		t.Fatalf("QuarantineLeaves(): %v", err)
+	}
+
+	tx, err := s.SnapshotForTree(ctx, tree)
+	if err != nil {
+		t.Fatalf("SnapshotForTree(): %v", err)
+	}
+	defer tx.Close()
+	quarantined, err := tx.ListQuarantinedLeaves(ctx, 10)
+	if err != nil {
+		t.Fatalf("ListQuarantinedLeaves(): %v", err)
+	}
+	if len(quarantined) != 1 {
+		t.Fatalf("ListQuarantinedLeaves() returned %d leaves, want 1", len(quarantined))
+	}
+	// Quarantined leaves are listed as stored, as the signer which lists them
+	// has no data keys.
+	if got, want := quarantined[0].Leaf.LeafValue, leaves[0].LeafValue; bytes.Equal(got, want) {
+		t.Errorf("ListQuarantinedLeaves() returned plaintext value %q, want it encrypted", got)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit(): %v", err)
+	}
+
+	// Requeued leaves are still decrypted once integrated.
+	if err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		_, err := tx.RequeueQuarantinedLeaves(ctx, [][]byte{leaves[0].LeafIdentityHash})
+		return err
+	}); err != nil {
+		t.Fatalf("RequeueQuarantinedLeaves(): %v", err)
+	}
+	sequence(ctx, t, s, tree)
+	got := getLeaves(ctx, t, s, tree, 1)
+	if len(got) != 1 || !bytes.Equal(got[0].LeafValue, leaves[0].LeafValue) {
+		t.Errorf("GetLeavesByRange() after requeue = %v, want value %q", got, leaves[0].LeafValue)
+	}
+}
+
+func TestLogStoragePassThrough(t *testing.T) {
+	ctx := context.Background()
+	ts := memory.NewTreeStorage()
+	raw := memory.NewLogStorage(ts, nil)
+	s := NewLogStorage(raw, nil)
+	tree := newTree(ctx, t, ts, testonly.LogTree)
+
+	leaves := newLeaves(1)
+	if _, err := s.QueueLeaves(ctx, tree, leaves, time.Now()); err != nil {
+		t.Fatalf("QueueLeaves(): %v", err)
+	}
+	sequence(ctx, t, s, tree)
+	if got := getLeaves(ctx, t, raw, tree, 1); len(got) != 1 || !bytes.Equal(got[0].LeafValue, leaves[0].LeafValue) {
+		t.Errorf("GetLeavesByRange() = %v, want value %q", got, leaves[0].LeafValue)
+	}
+
+	// Encrypted trees can't be used without a key wrapper.
+	keyring, err := NewKeyring("k1", map[string][]byte{"k1": kek(1)})
+	if err != nil {
+		t.Fatalf("NewKeyring(): %v", err)
+	}
+	encrypted := newEncryptedTree(ctx, t, ts, keyring)
+	if _, err := s.QueueLeaves(ctx, encrypted, leaves, time.Now()); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("QueueLeaves() without key wrapper = %v, want code %v", err, codes.FailedPrecondition)
+	}
+}
+
+func TestLeafCipherBinding(t *testing.T) {
+	aead, err := newAEAD(kek(1))
+	if err != nil {
+		t.Fatalf("newAEAD(): %v", err)
+	}
+	c := &leafCipher{treeID: 1, aead: aead}
+	leaf, err := c.encryptLeaf(newLeaves(1)[0])
+	if err != nil {
+		t.Fatalf("encryptLeaf(): %v", err)
+	}
+	if _, err := c.decryptLeaf(leaf); err != nil {
+		t.Fatalf("decryptLeaf(): %v", err)
+	}
+
+	for _, test := range []struct {
+		desc   string
+		cipher *leafCipher
+		modify func(*trillian.LogLeaf)
+	}{
+		{desc: "otherTree", cipher: &leafCipher{treeID: 2, aead: aead}, modify: func(*trillian.LogLeaf) {}},
+		{desc: "otherLeaf", cipher: c, modify: func(l *trillian.LogLeaf) { l.LeafIdentityHash = newLeaves(2)[1].LeafIdentityHash }},
+		{desc: "swappedFields", cipher: c, modify: func(l *trillian.LogLeaf) { l.LeafValue, l.ExtraData = l.ExtraData, l.LeafValue }},
+		{desc: "unknownVersion", cipher: c, modify: func(l *trillian.LogLeaf) { l.LeafValue[0] = 0 }},
+	} {
+		l := proto.Clone(leaf).(*trillian.LogLeaf)
+		test.modify(l)
+		if _, err := test.cipher.decryptLeaf(l); err == nil {
+			t.Errorf("%v: decryptLeaf() succeeded, want error", test.desc)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// DataKeySize is the size in bytes of tree data keys, and of the KEKs held by
+// a Keyring, which are AES-256 keys.
+const DataKeySize = 32
+
+// KeyWrapper encrypts and decrypts the data keys of trees with a key
+// encryption key (KEK). Implementations are typically backed by a KMS, which
+// never reveals the KEKs.
+type KeyWrapper interface {
+	// Wrap encrypts key with the current KEK, and returns it along with the
+	// ID of the KEK.
+	Wrap(ctx context.Context, key []byte) (wrapped []byte, kekID string, err error)
+	// Unwrap decrypts a key which was wrapped by the KEK with the given ID.
+	// KEKs which have been rotated out must still be able to unwrap keys,
+	// until all trees have been rewrapped with the current KEK.
+	Unwrap(ctx context.Context, wrapped []byte, kekID string) ([]byte, error)
+}
+
+// NewDataKey returns a new random data key for a tree.
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, DataKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %v", err)
+	}
+	return key, nil
+}
+
+// Keyring is a KeyWrapper which holds its KEKs in memory, and wraps keys with
+// AES-256-GCM. The ID of a KEK is used as additional data, so keys can only
+// be unwrapped by the KEK ID they were wrapped by.
+type Keyring struct {
+	current string
+	keks    map[string]cipher.AEAD
+}
+
+// NewKeyring returns a Keyring holding the given KEKs by ID, which must be
+// DataKeySize bytes long. Keys are wrapped by the KEK with ID current.
+func NewKeyring(current string, keks map[string][]byte) (*Keyring, error) {
+	if _, ok := keks[current]; !ok {
+		return nil, fmt.Errorf("current KEK %q not found", current)
+	}
+	r := &Keyring{current: current, keks: make(map[string]cipher.AEAD, len(keks))}
+	for id, kek := range keks {
+		if id == "" {
+			return nil, fmt.Errorf("KEK ID is empty")
+		}
+		aead, err := newAEAD(kek)
+		if err != nil {
+			return nil, fmt.Errorf("KEK %q: %v", id, err)
+		}
+		r.keks[id] = aead
+	}
+	return r, nil
+}
+
+// LoadKeyring returns a Keyring holding the KEKs listed in spec, as a
+// comma-separated list of id=path pairs, where each file holds a raw KEK of
+// DataKeySize bytes. Keys are wrapped by the first KEK in the list.
+func LoadKeyring(spec string) (*Keyring, error) {
+	var current string
+	keks := make(map[string][]byte)
+	for _, pair := range strings.Split(spec, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid KEK %q, want id=path", pair)
+		}
+		id, path := parts[0], parts[1]
+		if _, ok := keks[id]; ok {
+			return nil, fmt.Errorf("duplicate KEK ID %q", id)
+		}
+		kek, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read KEK %q: %v", id, err)
+		}
+		keks[id] = kek
+		if current == "" {
+			current = id
+		}
+	}
+	return NewKeyring(current, keks)
+}
+
+// Wrap implements KeyWrapper.Wrap.
+func (r *Keyring) Wrap(ctx context.Context, key []byte) ([]byte, string, error) {
+	wrapped, err := seal(r.keks[r.current], key, []byte(r.current))
+	if err != nil {
+		return nil, "", err
+	}
+	return wrapped, r.current, nil
+}
+
+// Unwrap implements KeyWrapper.Unwrap.
+func (r *Keyring) Unwrap(ctx context.Context, wrapped []byte, kekID string) ([]byte, error) {
+	aead, ok := r.keks[kekID]
+	if !ok {
+		return nil, fmt.Errorf("unknown KEK %q", kekID)
+	}
+	key, err := open(aead, wrapped, []byte(kekID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key with KEK %q: %v", kekID, err)
+	}
+	return key, nil
+}
+
+// newAEAD returns an AES-256-GCM cipher with the given key.
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if got, want := len(key), DataKeySize; got != want {
+		return nil, fmt.Errorf("key has %d bytes, want %d", got, want)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts plaintext with a random nonce, which is prepended to the
+// returned ciphertext.
+func seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// open decrypts ciphertext produced by seal.
+func open(aead cipher.AEAD, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
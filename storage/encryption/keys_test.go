@@ -0,0 +1,135 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encryption
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// kek returns a test KEK of the right size, filled with b.
+func kek(b byte) []byte {
+	return bytes.Repeat([]byte{b}, DataKeySize)
+}
+
+func TestKeyringRotation(t *testing.T) {
+	ctx := context.Background()
+	old, err := NewKeyring("k1", map[string][]byte{"k1": kek(1)})
+	if err != nil {
+		t.Fatalf("NewKeyring(): %v", err)
+	}
+	key, err := NewDataKey()
+	if err != nil {
+		t.Fatalf("NewDataKey(): %v", err)
+	}
+	wrapped, kekID, err := old.Wrap(ctx, key)
+	if err != nil {
+		t.Fatalf("Wrap(): %v", err)
+	}
+	if kekID != "k1" {
+		t.Errorf("Wrap() returned KEK ID %q, want %q", kekID, "k1")
+	}
+	if bytes.Contains(wrapped, key) {
+		t.Error("Wrap() returned the key in the clear")
+	}
+
+	// After rotation, keys wrapped by the old KEK can still be unwrapped, and
+	// are rewrapped by the new one.
+	rotated, err := NewKeyring("k2", map[string][]byte{"k1": kek(1), "k2": kek(2)})
+	if err != nil {
+		t.Fatalf("NewKeyring(): %v", err)
+	}
+	got, err := rotated.Unwrap(ctx, wrapped, kekID)
+	if err != nil {
+		t.Fatalf("Unwrap(): %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("Unwrap() = %x, want %x", got, key)
+	}
+	rewrapped, kekID, err := rotated.Wrap(ctx, key)
+	if err != nil {
+		t.Fatalf("Wrap(): %v", err)
+	}
+	if kekID != "k2" {
+		t.Errorf("Wrap() after rotation returned KEK ID %q, want %q", kekID, "k2")
+	}
+	if got, err := rotated.Unwrap(ctx, rewrapped, kekID); err != nil || !bytes.Equal(got, key) {
+		t.Errorf("Unwrap() of rewrapped key = %x, %v; want %x, nil", got, err, key)
+	}
+
+	// The KEK ID is bound to the wrapped key.
+	if _, err := rotated.Unwrap(ctx, wrapped, "k2"); err == nil {
+		t.Error("Unwrap() with the wrong KEK ID succeeded")
+	}
+	if _, err := rotated.Unwrap(ctx, wrapped, "unknown"); err == nil {
+		t.Error("Unwrap() with an unknown KEK ID succeeded")
+	}
+}
+
+func TestNewKeyringErrors(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		current string
+		keks    map[string][]byte
+	}{
+		{desc: "noCurrent", current: "k2", keks: map[string][]byte{"k1": kek(1)}},
+		{desc: "emptyID", current: "k1", keks: map[string][]byte{"k1": kek(1), "": kek(2)}},
+		{desc: "shortKEK", current: "k1", keks: map[string][]byte{"k1": []byte("short")}},
+	} {
+		if _, err := NewKeyring(test.current, test.keks); err == nil {
+			t.Errorf("%v: NewKeyring() succeeded, want error", test.desc)
+		}
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyring")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	var paths []string
+	for i := byte(1); i <= 2; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("k%d", i))
+		if err := ioutil.WriteFile(path, kek(i), 0600); err != nil {
+			t.Fatalf("WriteFile(): %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	r, err := LoadKeyring(fmt.Sprintf("k2=%s,k1=%s", paths[1], paths[0]))
+	if err != nil {
+		t.Fatalf("LoadKeyring(): %v", err)
+	}
+	if _, kekID, err := r.Wrap(context.Background(), kek(3)); err != nil || kekID != "k2" {
+		t.Errorf("Wrap() = _, %q, %v; want _, %q, nil", kekID, err, "k2")
+	}
+
+	for _, spec := range []string{
+		"",
+		paths[0],
+		"k1=" + filepath.Join(dir, "missing"),
+		fmt.Sprintf("k1=%s,k1=%s", paths[0], paths[1]),
+	} {
+		if _, err := LoadKeyring(spec); err == nil {
+			t.Errorf("LoadKeyring(%q) succeeded, want error", spec)
+		}
+	}
+}
@@ -0,0 +1,367 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Values of the "type" label of the transaction duration metric.
+const (
+	// txSnapshot is the time from starting a read-only transaction until it
+	// is committed, rolled back or closed.
+	txSnapshot = "snapshot"
+	// txReadWrite is the duration of a ReadWriteTransaction call, including
+	// the function run in it and the commit.
+	txReadWrite = "read-write"
+	// txCommit is the duration of committing a transaction. For read-write
+	// transactions, it's the time between the function run in the transaction
+	// returning successfully and ReadWriteTransaction returning.
+	txCommit = "commit"
+)
+
+var (
+	txMetricsOnce sync.Once
+	txDuration    monitoring.Histogram
+)
+
+func initTXMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	txDuration = mf.NewHistogram("storage_tx_duration", "Duration of storage transactions in seconds, by storage backend and operation type", "backend", "type")
+}
+
+// instrumentedProvider wraps a Provider so that the duration of the
+// transactions of its storage is exported by the transaction duration metric.
+type instrumentedProvider struct {
+	Provider
+	backend string
+}
+
+// newInstrumentedProvider returns p, instrumented with metrics created by mf,
+// which are labelled with the backend name.
+func newInstrumentedProvider(p Provider, backend string, mf monitoring.MetricFactory) Provider {
+	txMetricsOnce.Do(func() { initTXMetrics(mf) })
+	return &instrumentedProvider{Provider: p, backend: backend}
+}
+
+// LogStorage implements Provider.LogStorage.
+func (p *instrumentedProvider) LogStorage() LogStorage {
+	s := p.Provider.LogStorage()
+	if s == nil {
+		return nil
+	}
+	return &instrumentedLogStorage{LogStorage: s, backend: p.backend}
+}
+
+// CountsUnsequenced implements UnsequencedCounterProvider, as the wrapped
+// Provider does.
+func (p *instrumentedProvider) CountsUnsequenced() bool {
+	return CountsUnsequenced(p.Provider)
+}
+
+// MapStorage implements Provider.MapStorage.
+func (p *instrumentedProvider) MapStorage() MapStorage {
+	s := p.Provider.MapStorage()
+	if s == nil {
+		return nil
+	}
+	return &instrumentedMapStorage{MapStorage: s, backend: p.backend}
+}
+
+// AdminStorage implements Provider.AdminStorage.
+func (p *instrumentedProvider) AdminStorage() AdminStorage {
+	s := p.Provider.AdminStorage()
+	if s == nil {
+		return nil
+	}
+	return &instrumentedAdminStorage{AdminStorage: s, backend: p.backend}
+}
+
+// observeSince records the time since start as the duration of a transaction
+// operation of the given type.
+func observeSince(backend, opType string, start time.Time) {
+	txDuration.Observe(time.Since(start).Seconds(), backend, opType)
+}
+
+// timeReadWrite runs a ReadWriteTransaction by calling rw with a function
+// that wraps f, and records its duration and that of its commit.
+func timeReadWrite(backend string, rw func(wrap func(f func() error) error) error) error {
+	start := time.Now()
+	var committing time.Time
+	err := rw(func(f func() error) error {
+		err := f()
+		committing = time.Now()
+		return err
+	})
+	if err == nil && !committing.IsZero() {
+		observeSince(backend, txCommit, committing)
+	}
+	observeSince(backend, txReadWrite, start)
+	return err
+}
+
+// snapshotTimer records the duration of a read-only transaction when it is
+// finished, and of its commit.
+type snapshotTimer struct {
+	backend string
+	start   time.Time
+	once    sync.Once
+}
+
+// newSnapshotTimer starts timing a read-only transaction. Some storage
+// implementations return a usable transaction along with an error, e.g.
+// ErrTreeNeedsInit, so transactions are wrapped whenever they are non-nil, and
+// errors are passed through.
+func newSnapshotTimer(backend string) *snapshotTimer {
+	return &snapshotTimer{backend: backend, start: time.Now()}
+}
+
+// commit calls commitFn, records its duration, and finishes the transaction.
+func (t *snapshotTimer) commit(commitFn func() error) error {
+	start := time.Now()
+	err := commitFn()
+	observeSince(t.backend, txCommit, start)
+	t.finish()
+	return err
+}
+
+// finish records the duration of the transaction, unless it was already
+// finished.
+func (t *snapshotTimer) finish() {
+	t.once.Do(func() { observeSince(t.backend, txSnapshot, t.start) })
+}
+
+type instrumentedLogStorage struct {
+	LogStorage
+	backend string
+}
+
+// Snapshot implements LogStorage.Snapshot.
+func (s *instrumentedLogStorage) Snapshot(ctx context.Context) (ReadOnlyLogTX, error) {
+	timer := newSnapshotTimer(s.backend)
+	tx, err := s.LogStorage.Snapshot(ctx)
+	if tx == nil {
+		timer.finish()
+		return nil, err
+	}
+	return &instrumentedLogTX{ReadOnlyLogTX: tx, timer: timer}, err
+}
+
+// SnapshotForTree implements LogStorage.SnapshotForTree.
+func (s *instrumentedLogStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (ReadOnlyLogTreeTX, error) {
+	timer := newSnapshotTimer(s.backend)
+	tx, err := s.LogStorage.SnapshotForTree(ctx, tree)
+	if tx == nil {
+		timer.finish()
+		return nil, err
+	}
+	return &instrumentedLogTreeTX{ReadOnlyLogTreeTX: tx, timer: timer}, err
+}
+
+// ReadWriteTransaction implements LogStorage.ReadWriteTransaction.
+func (s *instrumentedLogStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.Tree, f LogTXFunc) error {
+	return timeReadWrite(s.backend, func(wrap func(func() error) error) error {
+		return s.LogStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx LogTreeTX) error {
+			return wrap(func() error { return f(ctx, tx) })
+		})
+	})
+}
+
+type instrumentedLogTX struct {
+	ReadOnlyLogTX
+	timer *snapshotTimer
+}
+
+func (t *instrumentedLogTX) Commit(ctx context.Context) error {
+	return t.timer.commit(func() error { return t.ReadOnlyLogTX.Commit(ctx) })
+}
+
+func (t *instrumentedLogTX) Rollback() error {
+	defer t.timer.finish()
+	return t.ReadOnlyLogTX.Rollback()
+}
+
+func (t *instrumentedLogTX) Close() error {
+	defer t.timer.finish()
+	return t.ReadOnlyLogTX.Close()
+}
+
+type instrumentedLogTreeTX struct {
+	ReadOnlyLogTreeTX
+	timer *snapshotTimer
+}
+
+func (t *instrumentedLogTreeTX) Commit(ctx context.Context) error {
+	return t.timer.commit(func() error { return t.ReadOnlyLogTreeTX.Commit(ctx) })
+}
+
+func (t *instrumentedLogTreeTX) Rollback() error {
+	defer t.timer.finish()
+	return t.ReadOnlyLogTreeTX.Rollback()
+}
+
+func (t *instrumentedLogTreeTX) Close() error {
+	defer t.timer.finish()
+	return t.ReadOnlyLogTreeTX.Close()
+}
+
+// GetLeavesByKeyRange implements LeafKeyReader, if the wrapped transaction
+// does.
+func (t *instrumentedLogTreeTX) GetLeavesByKeyRange(ctx context.Context, startKey []byte, startIndex int64, endKey []byte, limit int) ([]*trillian.LogLeaf, [][]byte, error) {
+	r, ok := t.ReadOnlyLogTreeTX.(LeafKeyReader)
+	if !ok {
+		return nil, nil, status.Error(codes.Unimplemented, "storage doesn't index leaves by ordering key")
+	}
+	return r.GetLeavesByKeyRange(ctx, startKey, startIndex, endKey, limit)
+}
+
+// GetTombstonedIndices implements TombstoneReader, if the wrapped transaction
+// does.
+func (t *instrumentedLogTreeTX) GetTombstonedIndices(ctx context.Context, start, count int64) ([]int64, error) {
+	r, ok := t.ReadOnlyLogTreeTX.(TombstoneReader)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "storage doesn't index tombstones")
+	}
+	return r.GetTombstonedIndices(ctx, start, count)
+}
+
+// CountUnsequenced implements UnsequencedCounter, if the wrapped transaction
+// does.
+func (t *instrumentedLogTreeTX) CountUnsequenced(ctx context.Context, limit int64) (int64, error) {
+	c, ok := t.ReadOnlyLogTreeTX.(UnsequencedCounter)
+	if !ok {
+		return 0, status.Error(codes.Unimplemented, "storage doesn't count unsequenced leaves")
+	}
+	return c.CountUnsequenced(ctx, limit)
+}
+
+// CompactTreeStorage implements TreeStorageCompactor, if the wrapped storage
+// does.
+func (s *instrumentedLogStorage) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	return compactTreeStorage(ctx, s.LogStorage, tree, optimize)
+}
+
+// compactTreeStorage calls CompactTreeStorage on s if it's a
+// TreeStorageCompactor, and fails with Unimplemented otherwise.
+func compactTreeStorage(ctx context.Context, s interface{}, tree *trillian.Tree, optimize bool) (int64, error) {
+	c, ok := s.(TreeStorageCompactor)
+	if !ok {
+		return 0, status.Error(codes.Unimplemented, "storage doesn't support compaction")
+	}
+	return c.CompactTreeStorage(ctx, tree, optimize)
+}
+
+type instrumentedMapStorage struct {
+	MapStorage
+	backend string
+}
+
+// SnapshotForTree implements MapStorage.SnapshotForTree.
+func (s *instrumentedMapStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (ReadOnlyMapTreeTX, error) {
+	timer := newSnapshotTimer(s.backend)
+	tx, err := s.MapStorage.SnapshotForTree(ctx, tree)
+	if tx == nil {
+		timer.finish()
+		return nil, err
+	}
+	return &instrumentedMapTreeTX{ReadOnlyMapTreeTX: tx, timer: timer}, err
+}
+
+// ReadWriteTransaction implements MapStorage.ReadWriteTransaction.
+func (s *instrumentedMapStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.Tree, f MapTXFunc) error {
+	return timeReadWrite(s.backend, func(wrap func(func() error) error) error {
+		return s.MapStorage.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx MapTreeTX) error {
+			return wrap(func() error { return f(ctx, tx) })
+		})
+	})
+}
+
+// CompactTreeStorage implements TreeStorageCompactor, if the wrapped storage
+// does.
+func (s *instrumentedMapStorage) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	return compactTreeStorage(ctx, s.MapStorage, tree, optimize)
+}
+
+type instrumentedMapTreeTX struct {
+	ReadOnlyMapTreeTX
+	timer *snapshotTimer
+}
+
+func (t *instrumentedMapTreeTX) Commit(ctx context.Context) error {
+	return t.timer.commit(func() error { return t.ReadOnlyMapTreeTX.Commit(ctx) })
+}
+
+func (t *instrumentedMapTreeTX) Rollback() error {
+	defer t.timer.finish()
+	return t.ReadOnlyMapTreeTX.Rollback()
+}
+
+func (t *instrumentedMapTreeTX) Close() error {
+	defer t.timer.finish()
+	return t.ReadOnlyMapTreeTX.Close()
+}
+
+type instrumentedAdminStorage struct {
+	AdminStorage
+	backend string
+}
+
+// Snapshot implements AdminStorage.Snapshot.
+func (s *instrumentedAdminStorage) Snapshot(ctx context.Context) (ReadOnlyAdminTX, error) {
+	timer := newSnapshotTimer(s.backend)
+	tx, err := s.AdminStorage.Snapshot(ctx)
+	if tx == nil {
+		timer.finish()
+		return nil, err
+	}
+	return &instrumentedAdminTX{ReadOnlyAdminTX: tx, timer: timer}, err
+}
+
+// ReadWriteTransaction implements AdminStorage.ReadWriteTransaction.
+func (s *instrumentedAdminStorage) ReadWriteTransaction(ctx context.Context, f AdminTXFunc) error {
+	return timeReadWrite(s.backend, func(wrap func(func() error) error) error {
+		return s.AdminStorage.ReadWriteTransaction(ctx, func(ctx context.Context, tx AdminTX) error {
+			return wrap(func() error { return f(ctx, tx) })
+		})
+	})
+}
+
+type instrumentedAdminTX struct {
+	ReadOnlyAdminTX
+	timer *snapshotTimer
+}
+
+func (t *instrumentedAdminTX) Commit() error {
+	return t.timer.commit(t.ReadOnlyAdminTX.Commit)
+}
+
+func (t *instrumentedAdminTX) Rollback() error {
+	defer t.timer.finish()
+	return t.ReadOnlyAdminTX.Rollback()
+}
+
+func (t *instrumentedAdminTX) Close() error {
+	defer t.timer.finish()
+	return t.ReadOnlyAdminTX.Close()
+}
@@ -0,0 +1,124 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"github.com/google/trillian/monitoring"
+)
+
+type mockProvider struct {
+	provider
+	ls LogStorage
+	as AdminStorage
+}
+
+func (p *mockProvider) LogStorage() LogStorage     { return p.ls }
+func (p *mockProvider) AdminStorage() AdminStorage { return p.as }
+
+// txCount returns the number of observations of the given type by the
+// transaction duration metric.
+func txCount(backend, opType string) uint64 {
+	count, _ := txDuration.Info(backend, opType)
+	return count
+}
+
+func TestInstrumentedProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+	tree := &trillian.Tree{TreeId: 1}
+
+	ls := NewMockLogStorage(ctrl)
+	as := NewMockAdminStorage(ctrl)
+	const backend = "instrumented"
+	RegisterProvider(backend, func(_ monitoring.MetricFactory) (Provider, error) {
+		return &mockProvider{ls: ls, as: as}, nil
+	})
+	p, err := NewProvider(backend, monitoring.InertMetricFactory{})
+	if err != nil {
+		t.Fatalf("NewProvider(): %v", err)
+	}
+	if got := p.MapStorage(); got != nil {
+		t.Errorf("MapStorage() = %v, want nil when not provided", got)
+	}
+
+	// A snapshot which is committed and then closed counts once.
+	tx := NewMockReadOnlyLogTreeTX(ctrl)
+	ls.EXPECT().SnapshotForTree(gomock.Any(), tree).Return(tx, nil)
+	tx.EXPECT().Commit(gomock.Any()).Return(nil)
+	tx.EXPECT().Close().Return(nil)
+	stx, err := p.LogStorage().SnapshotForTree(ctx, tree)
+	if err != nil {
+		t.Fatalf("SnapshotForTree(): %v", err)
+	}
+	if err := stx.Commit(ctx); err != nil {
+		t.Errorf("Commit(): %v", err)
+	}
+	stx.Close()
+	if got, want := txCount(backend, txSnapshot), uint64(1); got != want {
+		t.Errorf("got %d snapshot observations, want %d", got, want)
+	}
+	if got, want := txCount(backend, txCommit), uint64(1); got != want {
+		t.Errorf("got %d commit observations, want %d", got, want)
+	}
+
+	// Uninitialised trees come with a usable transaction.
+	tx = NewMockReadOnlyLogTreeTX(ctrl)
+	ls.EXPECT().SnapshotForTree(gomock.Any(), tree).Return(tx, ErrTreeNeedsInit)
+	tx.EXPECT().Close().Return(nil)
+	stx, err = p.LogStorage().SnapshotForTree(ctx, tree)
+	if err != ErrTreeNeedsInit || stx == nil {
+		t.Fatalf("SnapshotForTree() = (%v, %v), want (tx, %v)", stx, err, ErrTreeNeedsInit)
+	}
+	stx.Close()
+	if got, want := txCount(backend, txSnapshot), uint64(2); got != want {
+		t.Errorf("got %d snapshot observations, want %d", got, want)
+	}
+
+	// Successful read-write transactions have a commit, failed ones don't.
+	ls.EXPECT().ReadWriteTransaction(gomock.Any(), tree, gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ *trillian.Tree, f LogTXFunc) error { return f(ctx, nil) }).Times(2)
+	if err := p.LogStorage().ReadWriteTransaction(ctx, tree, func(context.Context, LogTreeTX) error { return nil }); err != nil {
+		t.Errorf("ReadWriteTransaction(): %v", err)
+	}
+	if err := p.LogStorage().ReadWriteTransaction(ctx, tree, func(context.Context, LogTreeTX) error { return errors.New("bang") }); err == nil {
+		t.Error("ReadWriteTransaction() = nil, want error")
+	}
+	if got, want := txCount(backend, txReadWrite), uint64(2); got != want {
+		t.Errorf("got %d read-write observations, want %d", got, want)
+	}
+	if got, want := txCount(backend, txCommit), uint64(2); got != want {
+		t.Errorf("got %d commit observations, want %d", got, want)
+	}
+
+	// Admin snapshots are counted under the same backend.
+	atx := NewMockReadOnlyAdminTX(ctrl)
+	as.EXPECT().Snapshot(gomock.Any()).Return(atx, nil)
+	atx.EXPECT().Rollback().Return(nil)
+	sa, err := p.AdminStorage().Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("Snapshot(): %v", err)
+	}
+	sa.Rollback()
+	if got, want := txCount(backend, txSnapshot), uint64(3); got != want {
+		t.Errorf("got %d snapshot observations, want %d", got, want)
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+
+	"github.com/google/trillian"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codecs of stored leaf data, identified by its first byte in trees which use
+// compression. Values must never be reused, as they are stored.
+const (
+	leafCodecRaw  byte = 0
+	leafCodecGzip byte = 1
+	// leafCodecZstdDict is followed by the uvarint version of the
+	// dictionary, or 0 if none, then the zstd frame.
+	leafCodecZstdDict byte = 2
+)
+
+// MaxLeafCompressionDictionarySize is the maximum size of a leaf compression
+// dictionary. All the dictionaries of a tree are read along with it.
+const MaxLeafCompressionDictionarySize = 1 << 20
+
+// Pools of gzip writers and readers, which are expensive to allocate.
+var (
+	gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(nil) }}
+	gzipReaders sync.Pool
+)
+
+// zstdCodecs holds a *zstdCodec for each dictionary in use, keyed by its
+// SHA-256 hash, as zstd encoders and decoders are expensive to allocate and
+// safe for concurrent use.
+var zstdCodecs sync.Map
+
+// zstdCodec compresses and decompresses leaf data with one dictionary.
+type zstdCodec struct {
+	once sync.Once
+	enc  *zstd.Encoder
+	dec  *zstd.Decoder
+	err  error
+}
+
+// zstdCodecFor returns the zstdCodec for dict, which may be empty, or an error
+// if dict isn't a valid zstd dictionary.
+func zstdCodecFor(dict []byte) (*zstdCodec, error) {
+	key := sha256.Sum256(dict)
+	v, _ := zstdCodecs.LoadOrStore(key, &zstdCodec{})
+	c := v.(*zstdCodec)
+	c.once.Do(func() {
+		// The default level hardly uses dictionaries for small leaves, and
+		// databases already checksum what they store.
+		eopts := []zstd.EOption{zstd.WithEncoderLevel(zstd.SpeedBetterCompression), zstd.WithEncoderCRC(false)}
+		var dopts []zstd.DOption
+		if len(dict) > 0 {
+			eopts = append(eopts, zstd.WithEncoderDict(dict))
+			dopts = append(dopts, zstd.WithDecoderDicts(dict))
+		}
+		if c.enc, c.err = zstd.NewWriter(nil, eopts...); c.err != nil {
+			return
+		}
+		c.dec, c.err = zstd.NewReader(nil, dopts...)
+	})
+	if c.err != nil {
+		// Don't keep invalid dictionaries around.
+		zstdCodecs.Delete(key)
+	}
+	return c, c.err
+}
+
+// UnknownDictionaryError is returned by LeafCompressor when leaf data was
+// compressed with a dictionary it doesn't have, e.g. because the tree it was
+// created with was read before the dictionary was appended.
+type UnknownDictionaryError struct {
+	Version int32
+}
+
+func (e *UnknownDictionaryError) Error() string {
+	return fmt.Sprintf("leaf data was compressed with unknown dictionary version %d", e.Version)
+}
+
+// LeafCompressor compresses and decompresses the leaf data of a tree with its
+// leaf_compression and leaf_compression_dictionaries.
+type LeafCompressor struct {
+	compression  trillian.LeafCompression
+	dictionaries map[int32][]byte
+	// latest is the version of the dictionary new leaf data is compressed
+	// with, or 0 if the tree has none.
+	latest int32
+}
+
+// NewLeafCompressor returns a LeafCompressor for the leaf data of tree.
+func NewLeafCompressor(tree *trillian.Tree) *LeafCompressor {
+	c := &LeafCompressor{compression: tree.GetLeafCompression()}
+	if ds := tree.GetLeafCompressionDictionaries(); len(ds) > 0 {
+		c.dictionaries = make(map[int32][]byte, len(ds))
+		for _, d := range ds {
+			c.dictionaries[d.Version] = d.Dictionary
+		}
+		c.latest = ds[len(ds)-1].Version
+	}
+	return c
+}
+
+// WithDictionaries returns a LeafCompressor for the same tree as c, with the
+// leaf compression dictionaries ds, as re-read after an
+// UnknownDictionaryError.
+func (c *LeafCompressor) WithDictionaries(ds []*trillian.LeafCompressionDictionary) *LeafCompressor {
+	return NewLeafCompressor(&trillian.Tree{LeafCompression: c.compression, LeafCompressionDictionaries: ds})
+}
+
+// Compresses returns whether the tree compresses its leaf data.
+func (c *LeafCompressor) Compresses() bool {
+	return c.compression != trillian.LeafCompression_LEAF_COMPRESSION_NONE
+}
+
+// CompressData returns the leaf value or extra data to store. Unless the
+// tree uses LEAF_COMPRESSION_NONE, the result starts with a byte identifying
+// the codec, and is only compressed if that makes it smaller, so
+// DecompressData always gets back the original data. Empty data is returned
+// as is.
+func (c *LeafCompressor) CompressData(data []byte) ([]byte, error) {
+	if c.compression == trillian.LeafCompression_LEAF_COMPRESSION_NONE || len(data) == 0 {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	switch c.compression {
+	case trillian.LeafCompression_LEAF_COMPRESSION_GZIP:
+		buf.WriteByte(leafCodecGzip)
+		w := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(w)
+		w.Reset(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case trillian.LeafCompression_LEAF_COMPRESSION_ZSTD_DICTIONARY:
+		zc, err := zstdCodecFor(c.dictionaries[c.latest])
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaf compression dictionary version %d: %v", c.latest, err)
+		}
+		buf.WriteByte(leafCodecZstdDict)
+		var version [binary.MaxVarintLen32]byte
+		buf.Write(version[:binary.PutUvarint(version[:], uint64(c.latest))])
+		buf.Write(zc.enc.EncodeAll(data, nil))
+	default:
+		return nil, fmt.Errorf("unknown leaf compression: %v", c.compression)
+	}
+	if buf.Len() > len(data) {
+		return append([]byte{leafCodecRaw}, data...), nil
+	}
+	return buf.Bytes(), nil
+}
+
+// DecompressData returns the leaf value or extra data stored as data by
+// CompressData. It returns an *UnknownDictionaryError if data was compressed
+// with a dictionary c doesn't have.
+func (c *LeafCompressor) DecompressData(data []byte) ([]byte, error) {
+	if c.compression == trillian.LeafCompression_LEAF_COMPRESSION_NONE || len(data) == 0 {
+		return data, nil
+	}
+	switch data[0] {
+	case leafCodecRaw:
+		return data[1:], nil
+	case leafCodecGzip:
+		r, ok := gzipReaders.Get().(*gzip.Reader)
+		if !ok {
+			r = new(gzip.Reader)
+		}
+		defer gzipReaders.Put(r)
+		if err := r.Reset(bytes.NewReader(data[1:])); err != nil {
+			return nil, fmt.Errorf("failed to decompress leaf data: %v", err)
+		}
+		return readLeafData(r)
+	case leafCodecZstdDict:
+		version, n := binary.Uvarint(data[1:])
+		if n <= 0 || version > 1<<31-1 {
+			return nil, errors.New("leaf data has invalid dictionary version")
+		}
+		dict, ok := c.dictionaries[int32(version)]
+		if !ok && version != 0 {
+			return nil, &UnknownDictionaryError{Version: int32(version)}
+		}
+		zc, err := zstdCodecFor(dict)
+		if err != nil {
+			return nil, fmt.Errorf("invalid leaf compression dictionary version %d: %v", version, err)
+		}
+		plain, err := zc.dec.DecodeAll(data[1+n:], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress leaf data: %v", err)
+		}
+		return plain, nil
+	}
+	return nil, fmt.Errorf("unknown leaf data codec: %d", data[0])
+}
+
+// checkLeafCompressionDictionary returns an error if dict isn't a zstd
+// dictionary, as written by zstd --train.
+func checkLeafCompressionDictionary(dict []byte) error {
+	_, err := zstdCodecFor(dict)
+	return err
+}
+
+// CompressLeaf returns the leaf value and extra data of leaf to store,
+// leaving leaf unchanged.
+func (c *LeafCompressor) CompressLeaf(leaf *trillian.LogLeaf) (value, extraData []byte, err error) {
+	if value, err = c.CompressData(leaf.LeafValue); err != nil {
+		return nil, nil, err
+	}
+	if extraData, err = c.CompressData(leaf.ExtraData); err != nil {
+		return nil, nil, err
+	}
+	return value, extraData, nil
+}
+
+// DecompressLeaf replaces the leaf value and extra data of leaf, as read from
+// storage, with their original contents.
+func (c *LeafCompressor) DecompressLeaf(leaf *trillian.LogLeaf) error {
+	value, err := c.DecompressData(leaf.LeafValue)
+	if err != nil {
+		return err
+	}
+	extraData, err := c.DecompressData(leaf.ExtraData)
+	if err != nil {
+		return err
+	}
+	leaf.LeafValue, leaf.ExtraData = value, extraData
+	return nil
+}
+
+func readLeafData(r io.Reader) ([]byte, error) {
+	plain, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress leaf data: %v", err)
+	}
+	return plain, nil
+}
+
+// CompressLeafData returns the leaf value or extra data to store for a tree
+// using compression c, without dictionaries. See LeafCompressor.CompressData.
+func CompressLeafData(c trillian.LeafCompression, data []byte) ([]byte, error) {
+	return NewLeafCompressor(&trillian.Tree{LeafCompression: c}).CompressData(data)
+}
+
+// DecompressLeafData returns the leaf value or extra data stored as data by
+// CompressLeafData for a tree using compression c.
+func DecompressLeafData(c trillian.LeafCompression, data []byte) ([]byte, error) {
+	return NewLeafCompressor(&trillian.Tree{LeafCompression: c}).DecompressData(data)
+}
+
+// CompressLeaf returns the leaf value and extra data of leaf to store for a
+// tree using compression c, without dictionaries, leaving leaf unchanged.
+func CompressLeaf(c trillian.LeafCompression, leaf *trillian.LogLeaf) (value, extraData []byte, err error) {
+	return NewLeafCompressor(&trillian.Tree{LeafCompression: c}).CompressLeaf(leaf)
+}
+
+// DecompressLeaf replaces the leaf value and extra data of leaf, as read from
+// storage for a tree using compression c, with their original contents.
+func DecompressLeaf(c trillian.LeafCompression, leaf *trillian.LogLeaf) error {
+	return NewLeafCompressor(&trillian.Tree{LeafCompression: c}).DecompressLeaf(leaf)
+}
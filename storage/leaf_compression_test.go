@@ -0,0 +1,231 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/trillian"
+)
+
+// jsonLeaf returns a JSON blob of about n bytes, as typical leaf data.
+func jsonLeaf(n int) []byte {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i := 0; b.Len() < n; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id": %d, "name": "entry-%d", "issuer": "CN=Example Issuing CA", "valid": true}`, i, i*7919%1000)
+	}
+	b.WriteString("]")
+	return b.Bytes()
+}
+
+// testDictionary returns a zstd dictionary trained on leaves like jsonLeaf,
+// with content appended to it, which makes another valid dictionary.
+func testDictionary(t testing.TB, content string) []byte {
+	t.Helper()
+	dict, err := ioutil.ReadFile("testdata/leaf_compression.zdict")
+	if err != nil {
+		t.Fatalf("Failed to read dictionary: %v", err)
+	}
+	return append(dict, content...)
+}
+
+func TestLeafDataCompression(t *testing.T) {
+	const (
+		none = trillian.LeafCompression_LEAF_COMPRESSION_NONE
+		gzip = trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+	)
+	for _, test := range []struct {
+		desc        string
+		c           trillian.LeafCompression
+		data        []byte
+		wantCodec   int // -1 if the data is stored as is.
+		wantSmaller bool
+	}{
+		{desc: "none", c: none, data: jsonLeaf(1000), wantCodec: -1},
+		{desc: "empty", c: gzip, data: []byte{}, wantCodec: -1},
+		{desc: "nil", c: gzip, data: nil, wantCodec: -1},
+		{desc: "incompressible", c: gzip, data: []byte("abc"), wantCodec: int(leafCodecRaw)},
+		{desc: "json", c: gzip, data: jsonLeaf(1000), wantCodec: int(leafCodecGzip), wantSmaller: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			stored, err := CompressLeafData(test.c, test.data)
+			if err != nil {
+				t.Fatalf("CompressLeafData(): %v", err)
+			}
+			switch {
+			case test.wantCodec < 0:
+				if !bytes.Equal(stored, test.data) {
+					t.Errorf("CompressLeafData() = %x, want data as is", stored)
+				}
+			case len(stored) == 0 || int(stored[0]) != test.wantCodec:
+				t.Errorf("CompressLeafData() = %x, want codec %d", stored, test.wantCodec)
+			}
+			if test.wantSmaller && len(stored) >= len(test.data) {
+				t.Errorf("CompressLeafData() returned %d bytes, want less than %d", len(stored), len(test.data))
+			}
+
+			got, err := DecompressLeafData(test.c, stored)
+			if err != nil {
+				t.Fatalf("DecompressLeafData(): %v", err)
+			}
+			if !bytes.Equal(got, test.data) {
+				t.Errorf("DecompressLeafData() = %x, want %x", got, test.data)
+			}
+		})
+	}
+}
+
+func TestDecompressLeafData_Errors(t *testing.T) {
+	for _, data := range [][]byte{
+		{0xff, 1, 2, 3},
+		{leafCodecGzip, 1, 2, 3},
+	} {
+		if got, err := DecompressLeafData(trillian.LeafCompression_LEAF_COMPRESSION_GZIP, data); err == nil {
+			t.Errorf("DecompressLeafData(%x) = %x, want error", data, got)
+		}
+	}
+}
+
+func TestCompressLeaf(t *testing.T) {
+	c := trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+	leaf := &trillian.LogLeaf{LeafValue: jsonLeaf(1000), ExtraData: jsonLeaf(500)}
+	value, extraData, err := CompressLeaf(c, leaf)
+	if err != nil {
+		t.Fatalf("CompressLeaf(): %v", err)
+	}
+	if bytes.Equal(value, leaf.LeafValue) || bytes.Equal(extraData, leaf.ExtraData) {
+		t.Fatal("CompressLeaf() returned uncompressed data")
+	}
+
+	stored := &trillian.LogLeaf{LeafValue: value, ExtraData: extraData}
+	if err := DecompressLeaf(c, stored); err != nil {
+		t.Fatalf("DecompressLeaf(): %v", err)
+	}
+	if !bytes.Equal(stored.LeafValue, leaf.LeafValue) || !bytes.Equal(stored.ExtraData, leaf.ExtraData) {
+		t.Error("DecompressLeaf() didn't restore the original leaf data")
+	}
+}
+
+func TestLeafCompressorDictionaries(t *testing.T) {
+	tree := &trillian.Tree{
+		LeafCompression: trillian.LeafCompression_LEAF_COMPRESSION_ZSTD_DICTIONARY,
+		LeafCompressionDictionaries: []*trillian.LeafCompressionDictionary{
+			{Version: 1, Dictionary: testDictionary(t, "")},
+		},
+	}
+	data := jsonLeaf(100)
+	v1 := NewLeafCompressor(tree)
+	stored1, err := v1.CompressData(data)
+	if err != nil {
+		t.Fatalf("CompressData(): %v", err)
+	}
+	if got, want := stored1[:2], []byte{leafCodecZstdDict, 1}; !bytes.Equal(got, want) {
+		t.Errorf("CompressData() starts with %x, want %x", got, want)
+	}
+	gzipped, err := CompressLeafData(trillian.LeafCompression_LEAF_COMPRESSION_GZIP, data)
+	if err != nil {
+		t.Fatalf("CompressLeafData(): %v", err)
+	}
+	if len(stored1) >= len(gzipped) {
+		t.Errorf("CompressData() returned %d bytes, want less than the %d bytes of gzip", len(stored1), len(gzipped))
+	}
+
+	// Leaf data compressed with older dictionaries can still be read after a
+	// new one is appended.
+	tree.LeafCompressionDictionaries = append(tree.LeafCompressionDictionaries,
+		&trillian.LeafCompressionDictionary{Version: 2, Dictionary: testDictionary(t, `"issuer": "CN=Example Issuing CA"`)})
+	v2 := NewLeafCompressor(tree)
+	stored2, err := v2.CompressData(data)
+	if err != nil {
+		t.Fatalf("CompressData(): %v", err)
+	}
+	if got, want := stored2[:2], []byte{leafCodecZstdDict, 2}; !bytes.Equal(got, want) {
+		t.Errorf("CompressData() starts with %x, want %x", got, want)
+	}
+	for _, stored := range [][]byte{stored1, stored2} {
+		got, err := v2.DecompressData(stored)
+		if err != nil {
+			t.Fatalf("DecompressData(): %v", err)
+		}
+		if !bytes.Equal(got, data) {
+			t.Errorf("DecompressData() = %x, want %x", got, data)
+		}
+	}
+
+	// A compressor without the newer dictionary can't read its leaf data
+	// until it gets the dictionaries again.
+	var unknown *UnknownDictionaryError
+	if got, err := v1.DecompressData(stored2); !errors.As(err, &unknown) || unknown.Version != 2 {
+		t.Errorf("DecompressData() = (%x, %v), want unknown dictionary version 2", got, err)
+	}
+	if got, err := v1.WithDictionaries(tree.LeafCompressionDictionaries).DecompressData(stored2); err != nil {
+		t.Errorf("DecompressData(): %v", err)
+	} else if !bytes.Equal(got, data) {
+		t.Errorf("DecompressData() = %x, want %x", got, data)
+	}
+
+	// Trees can compress leaf data before they have any dictionary.
+	none := NewLeafCompressor(&trillian.Tree{LeafCompression: tree.LeafCompression})
+	stored0, err := none.CompressData(data)
+	if err != nil {
+		t.Fatalf("CompressData(): %v", err)
+	}
+	if got, err := v2.DecompressData(stored0); err != nil {
+		t.Errorf("DecompressData(): %v", err)
+	} else if !bytes.Equal(got, data) {
+		t.Errorf("DecompressData() = %x, want %x", got, data)
+	}
+}
+
+// BenchmarkLeafDataCompression reports the CPU cost of compressing and
+// decompressing typical JSON leaves, and the ratio of their stored size to
+// their original size.
+func BenchmarkLeafDataCompression(b *testing.B) {
+	c := trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+	for _, size := range []int{256, 4096, 65536} {
+		data := jsonLeaf(size)
+		stored, err := CompressLeafData(c, data)
+		if err != nil {
+			b.Fatalf("CompressLeafData(): %v", err)
+		}
+		ratio := float64(len(stored)) / float64(len(data))
+
+		b.Run(fmt.Sprintf("compress/%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			b.ReportMetric(ratio, "stored/orig")
+			for i := 0; i < b.N; i++ {
+				if _, err := CompressLeafData(c, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("decompress/%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				if _, err := DecompressLeafData(c, stored); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
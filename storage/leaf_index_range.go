@@ -0,0 +1,75 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"math"
+
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// leafIndexRange returns the range [start, end) of the leaf indices of a log,
+// as defined by its leaf_index_offset and max_tree_size, and whether it has
+// one. Logs numbered from 0 without a max_tree_size have no range, so that
+// the logs which don't use offsets never conflict.
+func leafIndexRange(tree *trillian.Tree) (start, end int64, ok bool) {
+	if tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG {
+		return 0, 0, false
+	}
+	if tree.LeafIndexOffset == 0 && tree.MaxTreeSize == 0 {
+		return 0, 0, false
+	}
+	end = math.MaxInt64
+	if tree.MaxTreeSize > 0 {
+		end = tree.LeafIndexOffset + tree.MaxTreeSize
+	}
+	return tree.LeafIndexOffset, end, true
+}
+
+// CheckLeafIndexRangeAvailable returns an AlreadyExists error naming the
+// conflicting tree if the leaf index range of tree overlaps that of a
+// non-deleted log of tx, where either of them has a leaf_index_offset. Trees
+// without an offset only conflict with those which have one. Only the trees
+// listed by tx are checked, so that logs of different namespaces never
+// conflict. If tx is a LeafIndexRangeLocker, the check is serialized with
+// those of concurrent transactions; otherwise concurrent creations are only
+// prevented by the isolation of tx.
+func CheckLeafIndexRangeAvailable(ctx context.Context, tx AdminReader, tree *trillian.Tree) error {
+	start, end, ok := leafIndexRange(tree)
+	if !ok {
+		return nil
+	}
+	if l, ok := tx.(LeafIndexRangeLocker); ok {
+		if err := l.LockLeafIndexRanges(ctx); err != nil && status.Code(err) != codes.Unimplemented {
+			return err
+		}
+	}
+	trees, err := tx.ListTrees(ctx, false /* includeDeleted */)
+	if err != nil {
+		return err
+	}
+	for _, other := range trees {
+		if other.TreeId == tree.TreeId || (tree.LeafIndexOffset == 0 && other.LeafIndexOffset == 0) {
+			continue
+		}
+		if otherStart, otherEnd, ok := leafIndexRange(other); ok && start < otherEnd && otherStart < end {
+			return status.Errorf(codes.AlreadyExists, "leaf indices [%d, %d) overlap those of tree %v, [%d, %d)", start, end, other.TreeId, otherStart, otherEnd)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCheckLeafIndexRangeAvailable(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log := trillian.TreeType_LOG
+	trees := []*trillian.Tree{
+		// Logs numbered from 0 without a maximum have no range.
+		{TreeId: 1, TreeType: log},
+		// A predecessor log, rotated at 1000 leaves.
+		{TreeId: 2, TreeType: log, MaxTreeSize: 1000},
+		// Its successor, which continues the numbering.
+		{TreeId: 3, TreeType: log, LeafIndexOffset: 1000, MaxTreeSize: 1000},
+		{TreeId: 4, TreeType: trillian.TreeType_MAP},
+	}
+	for _, test := range []struct {
+		desc     string
+		tree     *trillian.Tree
+		list     bool
+		wantCode codes.Code
+	}{
+		{desc: "noRange", tree: &trillian.Tree{TreeType: log}},
+		{desc: "noOffset", tree: &trillian.Tree{TreeType: log, MaxTreeSize: 10}, list: true},
+		{desc: "map", tree: &trillian.Tree{TreeType: trillian.TreeType_MAP}},
+		{desc: "next", tree: &trillian.Tree{TreeType: log, LeafIndexOffset: 2000}, list: true},
+		{desc: "overlapsPredecessor", tree: &trillian.Tree{TreeType: log, LeafIndexOffset: 999, MaxTreeSize: 1}, list: true, wantCode: codes.AlreadyExists},
+		{desc: "overlapsSuccessor", tree: &trillian.Tree{TreeType: log, LeafIndexOffset: 1999}, list: true, wantCode: codes.AlreadyExists},
+		{desc: "noOffsetOverlaps", tree: &trillian.Tree{TreeType: log, MaxTreeSize: 1001}, list: true, wantCode: codes.AlreadyExists},
+		{desc: "self", tree: &trillian.Tree{TreeId: 3, TreeType: log, LeafIndexOffset: 1000, MaxTreeSize: 1000}, list: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			mockTX := NewMockAdminTX(ctrl)
+			if test.list {
+				mockTX.EXPECT().ListTrees(gomock.Any(), false).Return(trees, nil)
+			}
+			err := CheckLeafIndexRangeAvailable(ctx, mockTX, test.tree)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("CheckLeafIndexRangeAvailable(%+v) returned err = %v, wantCode = %s", test.tree, err, test.wantCode)
+			}
+		})
+	}
+}
+
+// rangeLockingAdminTX is an AdminTX which implements LeafIndexRangeLocker.
+type rangeLockingAdminTX struct {
+	*MockAdminTX
+	locked bool
+	err    error
+}
+
+func (t *rangeLockingAdminTX) LockLeafIndexRanges(ctx context.Context) error {
+	t.locked = true
+	return t.err
+}
+
+func TestCheckLeafIndexRangeAvailable_Locker(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tree := &trillian.Tree{TreeType: trillian.TreeType_LOG, LeafIndexOffset: 1000}
+	for _, test := range []struct {
+		desc     string
+		lockErr  error
+		list     bool
+		wantCode codes.Code
+	}{
+		{desc: "locked", list: true},
+		{desc: "lockErr", lockErr: status.Error(codes.Aborted, "deadlock"), wantCode: codes.Aborted},
+		{desc: "lockUnimplemented", lockErr: status.Error(codes.Unimplemented, "no"), list: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			mockTX := NewMockAdminTX(ctrl)
+			if test.list {
+				mockTX.EXPECT().ListTrees(gomock.Any(), false).Return(nil, nil)
+			}
+			tx := &rangeLockingAdminTX{MockAdminTX: mockTX, err: test.lockErr}
+			err := CheckLeafIndexRangeAvailable(ctx, tx, tree)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("CheckLeafIndexRangeAvailable() returned err = %v, wantCode = %s", err, test.wantCode)
+			}
+			if !tx.locked {
+				t.Error("CheckLeafIndexRangeAvailable() didn't lock the leaf index ranges")
+			}
+		})
+	}
+}
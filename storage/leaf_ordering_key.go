@@ -0,0 +1,52 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/google/trillian"
+)
+
+// MaxLeafOrderingKeySize is the maximum size in bytes of the ordering key of a
+// leaf, see trillian.LeafOrderingKey.
+const MaxLeafOrderingKeySize = 255
+
+// LeafOrderingKey returns the ordering key of leaf, extracted as specified by
+// k. It returns an error if the key is empty, or longer than
+// MaxLeafOrderingKeySize.
+func LeafOrderingKey(k *trillian.LeafOrderingKey, leaf *trillian.LogLeaf) ([]byte, error) {
+	var src []byte
+	switch k.Source {
+	case trillian.LeafOrderingKey_LEAF_VALUE:
+		src = leaf.LeafValue
+	case trillian.LeafOrderingKey_EXTRA_DATA:
+		src = leaf.ExtraData
+	default:
+		return nil, fmt.Errorf("unknown ordering key source: %v", k.Source)
+	}
+
+	start, end := int(k.Offset), len(src)
+	if k.Length > 0 {
+		end = start + int(k.Length)
+	}
+	if start < 0 || start >= end || end > len(src) {
+		return nil, fmt.Errorf("%v has %d bytes, too few for an ordering key at offset %d with length %d", k.Source, len(src), k.Offset, k.Length)
+	}
+	if size := end - start; size > MaxLeafOrderingKeySize {
+		return nil, fmt.Errorf("ordering key has %d bytes, want <= %d", size, MaxLeafOrderingKeySize)
+	}
+	return src[start:end], nil
+}
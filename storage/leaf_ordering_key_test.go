@@ -0,0 +1,57 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/trillian"
+)
+
+func TestLeafOrderingKey(t *testing.T) {
+	const (
+		value = trillian.LeafOrderingKey_LEAF_VALUE
+		extra = trillian.LeafOrderingKey_EXTRA_DATA
+	)
+	leaf := &trillian.LogLeaf{LeafValue: []byte("serial:0042;cert"), ExtraData: []byte("0042")}
+	for _, test := range []struct {
+		desc    string
+		k       *trillian.LeafOrderingKey
+		leaf    *trillian.LogLeaf
+		want    []byte
+		wantErr bool
+	}{
+		{desc: "prefix", k: &trillian.LeafOrderingKey{Source: value, Length: 6}, leaf: leaf, want: []byte("serial")},
+		{desc: "offset", k: &trillian.LeafOrderingKey{Source: value, Offset: 7, Length: 4}, leaf: leaf, want: []byte("0042")},
+		{desc: "toEnd", k: &trillian.LeafOrderingKey{Source: value, Offset: 12}, leaf: leaf, want: []byte("cert")},
+		{desc: "extraData", k: &trillian.LeafOrderingKey{Source: extra}, leaf: leaf, want: []byte("0042")},
+		{desc: "tooShort", k: &trillian.LeafOrderingKey{Source: extra, Length: 5}, leaf: leaf, wantErr: true},
+		{desc: "offsetAtEnd", k: &trillian.LeafOrderingKey{Source: extra, Offset: 4}, leaf: leaf, wantErr: true},
+		{desc: "empty", k: &trillian.LeafOrderingKey{Source: extra}, leaf: &trillian.LogLeaf{}, wantErr: true},
+		{desc: "tooLong", k: &trillian.LeafOrderingKey{Source: value}, leaf: &trillian.LogLeaf{LeafValue: make([]byte, MaxLeafOrderingKeySize+1)}, wantErr: true},
+		{desc: "unknownSource", k: &trillian.LeafOrderingKey{}, leaf: leaf, wantErr: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			got, err := LeafOrderingKey(test.k, test.leaf)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("LeafOrderingKey(): %v, wantErr %v", err, test.wantErr)
+			}
+			if !bytes.Equal(got, test.want) {
+				t.Errorf("LeafOrderingKey() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"time"
 
 	"github.com/google/trillian"
+	"github.com/google/trillian/storage/storagepb"
+	"github.com/google/trillian/types"
 )
 
 // ReadOnlyLogTX provides a read-only view into log data.
@@ -62,6 +64,15 @@ type ReadOnlyLogTreeTX interface {
 	GetLeavesByHash(ctx context.Context, leafHashes [][]byte, orderBySequence bool) ([]*trillian.LogLeaf, error)
 	// LatestSignedLogRoot returns the most recent SignedLogRoot, if any.
 	LatestSignedLogRoot(ctx context.Context) (*trillian.SignedLogRoot, error)
+	// SignedLogRootHistory returns up to limit of the most recent stored
+	// SignedLogRoots, newest first.
+	SignedLogRootHistory(ctx context.Context, limit int) ([]*trillian.SignedLogRoot, error)
+	// SignedLogRootAtSize returns the most recent stored SignedLogRoot with
+	// the given tree size, or nil if there is none.
+	SignedLogRootAtSize(ctx context.Context, treeSize int64) (*trillian.SignedLogRoot, error)
+	// ListQuarantinedLeaves returns up to limit leaves quarantined by
+	// LogTreeTX.QuarantineLeaves, ordered by the time they were quarantined.
+	ListQuarantinedLeaves(ctx context.Context, limit int) ([]*trillian.QuarantinedLeaf, error)
 }
 
 // LogTreeTX is the transactional interface for reading/updating a Log.
@@ -108,8 +119,22 @@ type LogTreeTX interface {
 	// but it *must* include MerkleLeafHash, QueueTimestamp, and LeafIndex (for
 	// PREORDERED_LOG trees). Storage implementations might apply optimizations
 	// employing this property. Consult the call sites of this method to be sure.
+	//
+	// Queued leaves are returned even if they are malformed, e.g. their hashes
+	// have the wrong size, so that the caller can quarantine them.
 	DequeueLeaves(ctx context.Context, limit int, cutoff time.Time) ([]*trillian.LogLeaf, error)
 
+	// QuarantineLeaves takes leaves of a LOG tree dequeued by this transaction
+	// out of the queue, and keeps them, along with the reason why they can't be
+	// integrated, until they are requeued by RequeueQuarantinedLeaves.
+	QuarantineLeaves(ctx context.Context, leaves []*trillian.QuarantinedLeaf) error
+
+	// RequeueQuarantinedLeaves moves the quarantined leaves with the given
+	// identity hashes back to the queue, with their original queue timestamps,
+	// and returns the number of leaves requeued. Hashes of leaves which aren't
+	// quarantined are ignored.
+	RequeueQuarantinedLeaves(ctx context.Context, leafIdentityHashes [][]byte) (int, error)
+
 	// AddSequencedLeaves stores the passed in leaves at the log positions
 	// specified in the `LeafIndex` field. The indices must be contiguous.
 	//
@@ -121,6 +146,94 @@ type LogTreeTX interface {
 	UpdateSequencedLeaves(ctx context.Context, leaves []*trillian.LogLeaf) error
 }
 
+// QueueRequestIDReader is optionally implemented by LogTreeTX implementations
+// which store the ID of the request that queued each leaf, as found in the
+// context passed to QueueLeaves (see package util/requestid).
+type QueueRequestIDReader interface {
+	// QueueRequestID returns the ID of the request that queued the leaf with
+	// the given identity hash, if it was dequeued by this transaction and was
+	// queued with a request ID. It returns the empty string otherwise.
+	QueueRequestID(leafIdentityHash []byte) string
+}
+
+// QueueConditionReader is optionally implemented by LogTreeTX implementations
+// which store the condition each leaf was queued with, as found in the context
+// passed to QueueLeaves (see NewQueueConditionContext). Implementations which
+// don't support conditions must fail QueueLeaves if the context has one.
+type QueueConditionReader interface {
+	// QueueCondition returns the condition of the leaf with the given identity
+	// hash, if it was dequeued by this transaction and was queued with one. It
+	// returns nil otherwise.
+	QueueCondition(leafIdentityHash []byte) *storagepb.QueueCondition
+}
+
+// LeafKeyReader is optionally implemented by ReadOnlyLogTreeTX implementations
+// which index the leaves of trees with a leaf_ordering_key by their ordering
+// key (see LeafOrderingKey).
+type LeafKeyReader interface {
+	// GetLeavesByKeyRange returns up to limit leaves within the tree size of
+	// the latest SignedLogRoot, along with their ordering keys, ordered by key
+	// and then by LeafIndex. The first leaf returned is the first one whose
+	// (key, LeafIndex) pair is greater than or equal to (startKey, startIndex).
+	// If endKey is not empty, only leaves whose key is less than endKey are
+	// returned.
+	GetLeavesByKeyRange(ctx context.Context, startKey []byte, startIndex int64, endKey []byte, limit int) ([]*trillian.LogLeaf, [][]byte, error)
+}
+
+// TombstoneReader is optionally implemented by ReadOnlyLogTreeTX
+// implementations which index the tombstones among the leaves of trees with
+// leaf_tombstones (see types.ParseTombstone).
+type TombstoneReader interface {
+	// GetTombstonedIndices returns, in increasing order, the indices in
+	// [start, start+count) of the leaves deleted by a tombstone which is
+	// sequenced after them, within the tree size of the latest SignedLogRoot.
+	GetTombstonedIndices(ctx context.Context, start, count int64) ([]int64, error)
+}
+
+// UnsequencedCounter is optionally implemented by ReadOnlyLogTreeTX
+// implementations which can count the leaves queued in a tree.
+type UnsequencedCounter interface {
+	// CountUnsequenced returns the number of leaves queued in the tree which
+	// haven't been sequenced yet. If limit is positive, it counts at most limit
+	// leaves, so that deep queues are cheap to count.
+	CountUnsequenced(ctx context.Context, limit int64) (int64, error)
+}
+
+// TransientErrorClassifier is optionally implemented by ReadOnlyLogTreeTX
+// implementations whose errors may be transient without carrying a gRPC
+// status code, e.g. those of database drivers.
+type TransientErrorClassifier interface {
+	// IsTransientError returns whether err, as returned by a read of the
+	// transaction, may not recur if the read is retried, e.g. a lost
+	// connection, a deadlock or a network timeout.
+	IsTransientError(err error) bool
+}
+
+// CompactedRevisionReader is optionally implemented by ReadOnlyLogTreeTX
+// implementations whose storage compaction may remove the Merkle nodes read
+// at revisions before the latest root.
+type CompactedRevisionReader interface {
+	// CompactedRevision returns the revision below which reads of Merkle
+	// nodes may miss nodes removed by compaction, or 0 if none may.
+	CompactedRevision(ctx context.Context) (int64, error)
+}
+
+// IntegratedRootStore is optionally implemented by LogTreeTX implementations
+// which can keep the state of a log after an integration without publishing
+// it as a SignedLogRoot, for logs with a signing_interval.
+type IntegratedRootStore interface {
+	// LatestIntegratedRoot returns the root of the tree as of its latest
+	// integration: the root last stored by StoreIntegratedRoot, if it is newer
+	// than the latest SignedLogRoot, or that of the latter otherwise.
+	LatestIntegratedRoot(ctx context.Context) (*types.LogRootV1, error)
+	// StoreIntegratedRoot stores the root of the tree after an integration at
+	// the write revision of the transaction, without publishing it. Reads
+	// bounded by the latest SignedLogRoot are unaffected, but the write
+	// revisions of later transactions follow it. It is published by storing a
+	// SignedLogRoot of the same revision.
+	StoreIntegratedRoot(ctx context.Context, root *types.LogRootV1) error
+}
+
 // ReadOnlyLogStorage represents a narrowed read-only view into a LogStorage.
 type ReadOnlyLogStorage interface {
 	DatabaseChecker
@@ -17,6 +17,7 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -25,6 +26,8 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // NewAdminStorage returns a storage.AdminStorage implementation backed by
@@ -144,12 +147,23 @@ func (t *adminTX) CreateTree(ctx context.Context, tr *trillian.Tree) (*trillian.
 	if err := validateStorageSettings(tr); err != nil {
 		return nil, err
 	}
-
-	id, err := storage.NewTreeID()
-	if err != nil {
+	if err := checkTreeFieldsSupported(tr); err != nil {
 		return nil, err
 	}
 
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+
+	id := tr.TreeId
+	var err error
+	if id == 0 {
+		if id, err = storage.NewTreeID(); err != nil {
+			return nil, err
+		}
+	} else if _, ok := t.ms.trees[id]; ok {
+		return nil, status.Errorf(codes.AlreadyExists, "tree %v already exists", id)
+	}
+
 	now := time.Now()
 
 	meta := proto.Clone(tr).(*trillian.Tree)
@@ -162,9 +176,6 @@ func (t *adminTX) CreateTree(ctx context.Context, tr *trillian.Tree) (*trillian.
 	if err != nil {
 		return nil, err
 	}
-
-	t.ms.mu.Lock()
-	defer t.ms.mu.Unlock()
 	t.ms.trees[id] = newTree(meta)
 
 	glog.V(1).Infof("trees: %v", t.ms.trees)
@@ -186,6 +197,9 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 	if err := validateStorageSettings(tree); err != nil {
 		return nil, err
 	}
+	if err := checkTreeFieldsSupported(tree); err != nil {
+		return nil, err
+	}
 
 	var err error
 	tree.UpdateTime, err = ptypes.TimestampProto(time.Now())
@@ -207,9 +221,111 @@ func (t *adminTX) UndeleteTree(ctx context.Context, treeID int64) (*trillian.Tre
 	return nil, fmt.Errorf("method not supported: UndeleteTree")
 }
 
+func (t *adminTX) GetTreeTemplate(ctx context.Context, name string) (*trillian.TreeTemplate, error) {
+	t.ms.mu.RLock()
+	defer t.ms.mu.RUnlock()
+
+	tmpl, ok := t.ms.templates[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "tree template %q not found", name)
+	}
+	return proto.Clone(tmpl).(*trillian.TreeTemplate), nil
+}
+
+func (t *adminTX) ListTreeTemplates(ctx context.Context) ([]*trillian.TreeTemplate, error) {
+	t.ms.mu.RLock()
+	defer t.ms.mu.RUnlock()
+
+	ret := make([]*trillian.TreeTemplate, 0, len(t.ms.templates))
+	for _, tmpl := range t.ms.templates {
+		ret = append(ret, proto.Clone(tmpl).(*trillian.TreeTemplate))
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret, nil
+}
+
+func (t *adminTX) CreateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	if err := storage.ValidateTreeTemplate(tmpl); err != nil {
+		return err
+	}
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+
+	if _, ok := t.ms.templates[tmpl.Name]; ok {
+		return status.Errorf(codes.AlreadyExists, "tree template %q already exists", tmpl.Name)
+	}
+	t.ms.templates[tmpl.Name] = proto.Clone(tmpl).(*trillian.TreeTemplate)
+	return nil
+}
+
+func (t *adminTX) UpdateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	if err := storage.ValidateTreeTemplate(tmpl); err != nil {
+		return err
+	}
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+
+	if _, ok := t.ms.templates[tmpl.Name]; !ok {
+		return status.Errorf(codes.NotFound, "tree template %q not found", tmpl.Name)
+	}
+	t.ms.templates[tmpl.Name] = proto.Clone(tmpl).(*trillian.TreeTemplate)
+	return nil
+}
+
+func (t *adminTX) DeleteTreeTemplate(ctx context.Context, name string) error {
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+
+	if _, ok := t.ms.templates[name]; !ok {
+		return status.Errorf(codes.NotFound, "tree template %q not found", name)
+	}
+	delete(t.ms.templates, name)
+	return nil
+}
+
+func (t *adminTX) GetTreeAttestation(ctx context.Context, treeID int64) (*trillian.TreeAttestation, error) {
+	t.ms.mu.RLock()
+	defer t.ms.mu.RUnlock()
+
+	a, ok := t.ms.attestations[treeID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "attestation of tree %v not found", treeID)
+	}
+	return proto.Clone(a).(*trillian.TreeAttestation), nil
+}
+
+func (t *adminTX) CreateTreeAttestation(ctx context.Context, treeID int64, a *trillian.TreeAttestation) error {
+	if t.ms.getTree(treeID) == nil {
+		return status.Errorf(codes.NotFound, "tree %v not found", treeID)
+	}
+	t.ms.mu.Lock()
+	defer t.ms.mu.Unlock()
+
+	if _, ok := t.ms.attestations[treeID]; ok {
+		return status.Errorf(codes.AlreadyExists, "tree %v already has an attestation", treeID)
+	}
+	t.ms.attestations[treeID] = proto.Clone(a).(*trillian.TreeAttestation)
+	return nil
+}
+
 func validateStorageSettings(tree *trillian.Tree) error {
 	if tree.StorageSettings != nil {
 		return fmt.Errorf("storage_settings not supported, but got %v", tree.StorageSettings)
 	}
 	return nil
 }
+
+// checkTreeFieldsSupported returns an Unimplemented error if tree has a field
+// set which the memory storage would otherwise silently ignore.
+func checkTreeFieldsSupported(tree *trillian.Tree) error {
+	var field string
+	switch {
+	case tree.LeafOrderingKey != nil:
+		field = "leaf_ordering_key"
+	case tree.LeafTombstones:
+		field = "leaf_tombstones"
+	default:
+		return nil
+	}
+	return status.Errorf(codes.Unimplemented, "%s not supported by memory storage", field)
+}
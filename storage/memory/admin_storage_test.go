@@ -0,0 +1,71 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/testonly"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTreeTemplates(t *testing.T) {
+	// The memory storage doesn't support deleting trees, so only the template
+	// tests of the AdminStorage suite apply.
+	tester := &testonly.AdminStorageTester{NewAdminStorage: func() storage.AdminStorage {
+		return NewAdminStorage(NewTreeStorage())
+	}}
+	tester.TestTreeTemplates(t)
+}
+
+func TestTreeAttestations(t *testing.T) {
+	tester := &testonly.AdminStorageTester{NewAdminStorage: func() storage.AdminStorage {
+		return NewAdminStorage(NewTreeStorage())
+	}}
+	tester.TestTreeAttestations(t)
+}
+
+func TestCreateTree_UnsupportedFields(t *testing.T) {
+	ctx := context.Background()
+	for _, test := range []struct {
+		desc     string
+		modify   func(*trillian.Tree)
+		wantCode codes.Code
+	}{
+		{desc: "defaults", modify: func(*trillian.Tree) {}},
+		{
+			desc: "leaf_ordering_key",
+			modify: func(tree *trillian.Tree) {
+				tree.LeafOrderingKey = &trillian.LeafOrderingKey{Source: trillian.LeafOrderingKey_EXTRA_DATA, Length: 8}
+			},
+			wantCode: codes.Unimplemented,
+		},
+		{desc: "leaf_tombstones", modify: func(tree *trillian.Tree) { tree.LeafTombstones = true }, wantCode: codes.Unimplemented},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			tree := proto.Clone(testonly.PreorderedLogTree).(*trillian.Tree)
+			test.modify(tree)
+			_, err := storage.CreateTree(ctx, NewAdminStorage(NewTreeStorage()), tree)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("CreateTree() returned err = %v, want code %v", err, test.wantCode)
+			}
+		})
+	}
+}
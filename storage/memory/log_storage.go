@@ -20,17 +20,23 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/google/btree"
 	"github.com/google/trillian"
 	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/requestid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -73,12 +79,26 @@ func hashToSeqKey(treeID int64) btree.Item {
 	return &kv{k: fmt.Sprintf("/%d/h2s", treeID)}
 }
 
+// quarantineKey formats a key for use in a tree's BTree store.
+// The associated Item value will be the quarantined leaf with the given leaf
+// identity hash.
+func quarantineKey(treeID int64, leafIdentityHash []byte) btree.Item {
+	return &kv{k: fmt.Sprintf("/%d/quarantine/%x", treeID, leafIdentityHash)}
+}
+
 // sthKey formats a key for use in a tree's BTree store.
 // The associated Item value will be the STH with the given timestamp.
 func sthKey(treeID int64, timestamp uint64) btree.Item {
 	return &kv{k: fmt.Sprintf("/%d/sth/%020d", treeID, timestamp)}
 }
 
+// integratedRootKey formats a key for use in a tree's BTree store.
+// The associated Item value will be the root last stored by
+// StoreIntegratedRoot.
+func integratedRootKey(treeID int64) btree.Item {
+	return &kv{k: fmt.Sprintf("/%d/integrated", treeID)}
+}
+
 // getActiveLogIDs returns the IDs of all logs that are currently in a state
 // that requires sequencing (e.g. ACTIVE, DRAINING).
 func getActiveLogIDs(trees map[int64]*tree) []int64 {
@@ -156,7 +176,7 @@ func (m *memoryLogStorage) beginInternal(ctx context.Context, tree *trillian.Tre
 		return nil, err
 	}
 
-	stCache := cache.NewLogSubtreeCache(defaultLogStrata, hasher)
+	stCache := cache.NewLogSubtreeCacheWithMetrics(defaultLogStrata, tree.TreeId, hasher, m.metricFactory)
 	ttx, err := m.TreeStorage.beginTreeTX(ctx, tree.TreeId, hasher.Size(), stCache, readonly)
 	if err != nil {
 		return nil, err
@@ -180,7 +200,12 @@ func (m *memoryLogStorage) beginInternal(ctx context.Context, tree *trillian.Tre
 		return nil, err
 	}
 
-	ltx.treeTX.writeRevision = int64(ltx.root.Revision) + 1
+	if r := ltx.tx.Get(integratedRootKey(tree.TreeId)); r != nil {
+		if root := r.(*kv).v.(*types.LogRootV1); root.Revision > ltx.root.Revision {
+			ltx.integrated = root
+		}
+	}
+	ltx.treeTX.writeRevision = int64(ltx.integratedRoot().Revision) + 1
 
 	return ltx, nil
 }
@@ -248,6 +273,22 @@ type logTreeTX struct {
 	ls   *memoryLogStorage
 	root types.LogRootV1
 	slr  *trillian.SignedLogRoot
+	// integrated is the root last stored by StoreIntegratedRoot, if it is
+	// newer than root.
+	integrated *types.LogRootV1
+	// requestIDs holds the request IDs of the leaves dequeued by this
+	// transaction, keyed by leaf identity hash.
+	requestIDs map[string]string
+	// conditions holds the queue conditions of the leaves dequeued by this
+	// transaction, keyed by leaf identity hash.
+	conditions map[string]*storagepb.QueueCondition
+}
+
+// queuedLeaf is an entry of the queue of a log.
+type queuedLeaf struct {
+	leaf      *trillian.LogLeaf
+	requestID string
+	condition *storagepb.QueueCondition
 }
 
 func (t *logTreeTX) ReadRevision(ctx context.Context) (int64, error) {
@@ -266,10 +307,24 @@ func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime tim
 
 	q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
 	e := q.Front()
-	for i := 0; i < limit && e != nil; i++ {
-		// TODO(al): consider cutoffTime
-		leaves = append(leaves, e.Value.(*trillian.LogLeaf))
-		e = e.Next()
+	for ; len(leaves) < limit && e != nil; e = e.Next() {
+		ql := e.Value.(*queuedLeaf)
+		if queued, err := ptypes.Timestamp(ql.leaf.QueueTimestamp); err == nil && queued.After(cutoffTime) {
+			continue
+		}
+		leaves = append(leaves, ql.leaf)
+		if ql.requestID != "" {
+			if t.requestIDs == nil {
+				t.requestIDs = make(map[string]string)
+			}
+			t.requestIDs[string(ql.leaf.LeafIdentityHash)] = ql.requestID
+		}
+		if ql.condition != nil {
+			if t.conditions == nil {
+				t.conditions = make(map[string]*storagepb.QueueCondition)
+			}
+			t.conditions[string(ql.leaf.LeafIdentityHash)] = ql.condition
+		}
 	}
 
 	dequeuedCounter.Add(float64(len(leaves)), labelForTX(t))
@@ -282,17 +337,68 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 		if len(leaf.LeafIdentityHash) != t.hashSizeBytes {
 			return nil, fmt.Errorf("queued leaf must have a leaf ID hash of length %d", t.hashSizeBytes)
 		}
+		var err error
+		leaf.QueueTimestamp, err = ptypes.TimestampProto(queueTimestamp)
+		if err != nil {
+			return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
+		}
 	}
-	queuedCounter.Add(float64(len(leaves)), labelForTX(t))
-	// No deduping in this storage!
+	// No deduping in this storage, except for conditional batches which must
+	// be rejected as a whole if any leaf is a duplicate, as in MySQL storage.
 	k := unseqKey(t.treeID)
 	q := t.tx.Get(k).(*kv).v.(*list.List)
+	requestID := requestid.FromContext(ctx)
+	condition := storage.QueueConditionFromContext(ctx)
+	if condition != nil {
+		known := t.knownLeaves(q)
+		for _, leaf := range leaves {
+			if known[string(leaf.LeafIdentityHash)] || t.tx.Get(quarantineKey(t.treeID, leaf.LeafIdentityHash)) != nil {
+				return nil, status.Errorf(codes.FailedPrecondition, "conditional batch has leaf %x which is already queued or in the log", leaf.LeafIdentityHash)
+			}
+			known[string(leaf.LeafIdentityHash)] = true
+		}
+	}
+	queuedCounter.Add(float64(len(leaves)), labelForTX(t))
 	for _, l := range leaves {
-		q.PushBack(l)
+		q.PushBack(&queuedLeaf{leaf: l, requestID: requestID, condition: condition})
 	}
 	return make([]*trillian.LogLeaf, len(leaves)), nil
 }
 
+// knownLeaves returns the identity hashes of all leaves which are queued or
+// sequenced in the tree.
+func (t *logTreeTX) knownLeaves(q *list.List) map[string]bool {
+	known := make(map[string]bool)
+	for e := q.Front(); e != nil; e = e.Next() {
+		known[string(e.Value.(*queuedLeaf).leaf.LeafIdentityHash)] = true
+	}
+	t.tx.AscendRange(seqLeafKey(t.treeID, 0), seqLeafKey(t.treeID, math.MaxInt64), func(i btree.Item) bool {
+		known[string(i.(*kv).v.(*trillian.LogLeaf).LeafIdentityHash)] = true
+		return true
+	})
+	return known
+}
+
+// QueueRequestID implements storage.QueueRequestIDReader.
+func (t *logTreeTX) QueueRequestID(leafIdentityHash []byte) string {
+	return t.requestIDs[string(leafIdentityHash)]
+}
+
+// QueueCondition implements storage.QueueConditionReader.
+func (t *logTreeTX) QueueCondition(leafIdentityHash []byte) *storagepb.QueueCondition {
+	return t.conditions[string(leafIdentityHash)]
+}
+
+// CountUnsequenced implements storage.UnsequencedCounter.
+func (t *logTreeTX) CountUnsequenced(ctx context.Context, limit int64) (int64, error) {
+	q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
+	count := int64(q.Len())
+	if limit > 0 && count > limit {
+		count = limit
+	}
+	return count, nil
+}
+
 func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.LogLeaf, timestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
 	return nil, status.Errorf(codes.Unimplemented, "AddSequencedLeaves is not implemented")
 }
@@ -333,7 +439,7 @@ func (t *logTreeTX) GetLeavesByHash(ctx context.Context, leafHashes [][]byte, or
 	m := t.tx.Get(hashToSeqKey(t.treeID)).(*kv).v.(map[string][]int64)
 
 	ret := make([]*trillian.LogLeaf, 0, len(leafHashes))
-	for hash := range leafHashes {
+	for _, hash := range leafHashes {
 		seq, ok := m[string(hash)]
 		if !ok {
 			continue
@@ -362,6 +468,42 @@ func (t *logTreeTX) fetchLatestRoot(ctx context.Context) (*trillian.SignedLogRoo
 	return r.(*kv).v.(*trillian.SignedLogRoot), nil
 }
 
+func (t *logTreeTX) SignedLogRootHistory(ctx context.Context, limit int) ([]*trillian.SignedLogRoot, error) {
+	var ret []*trillian.SignedLogRoot
+	prefix := fmt.Sprintf("/%d/sth/", t.treeID)
+	t.tx.DescendLessOrEqual(sthKey(t.treeID, math.MaxUint64), func(i btree.Item) bool {
+		if len(ret) >= limit || !strings.HasPrefix(i.(*kv).k, prefix) {
+			return false
+		}
+		ret = append(ret, i.(*kv).v.(*trillian.SignedLogRoot))
+		return true
+	})
+	return ret, nil
+}
+
+func (t *logTreeTX) SignedLogRootAtSize(ctx context.Context, treeSize int64) (*trillian.SignedLogRoot, error) {
+	var ret *trillian.SignedLogRoot
+	var err error
+	prefix := fmt.Sprintf("/%d/sth/", t.treeID)
+	t.tx.DescendLessOrEqual(sthKey(t.treeID, math.MaxUint64), func(i btree.Item) bool {
+		if !strings.HasPrefix(i.(*kv).k, prefix) {
+			return false
+		}
+		slr := i.(*kv).v.(*trillian.SignedLogRoot)
+		var root types.LogRootV1
+		if err = root.UnmarshalBinary(slr.LogRoot); err != nil {
+			return false
+		}
+		if root.TreeSize == uint64(treeSize) {
+			ret = slr
+			return false
+		}
+		// Older roots are no larger, so stop once they are too small.
+		return root.TreeSize > uint64(treeSize)
+	})
+	return ret, err
+}
+
 func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, slr *trillian.SignedLogRoot) error {
 	var root types.LogRootV1
 	if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
@@ -378,6 +520,35 @@ func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, slr *trillian.Signed
 	return nil
 }
 
+// integratedRoot returns the root of the tree as of its latest integration.
+func (t *logTreeTX) integratedRoot() *types.LogRootV1 {
+	if t.integrated != nil {
+		return t.integrated
+	}
+	return &t.root
+}
+
+// LatestIntegratedRoot implements storage.IntegratedRootStore.
+func (t *logTreeTX) LatestIntegratedRoot(ctx context.Context) (*types.LogRootV1, error) {
+	if t.slr == nil {
+		return nil, storage.ErrTreeNeedsInit
+	}
+	root := *t.integratedRoot()
+	return &root, nil
+}
+
+// StoreIntegratedRoot implements storage.IntegratedRootStore.
+func (t *logTreeTX) StoreIntegratedRoot(ctx context.Context, root *types.LogRootV1) error {
+	if got, want := int64(root.Revision), t.treeTX.writeRevision; got != want {
+		return fmt.Errorf("integrated root has revision %d, want write revision %d", got, want)
+	}
+	stored := *root
+	k := integratedRootKey(t.treeID)
+	k.(*kv).v = &stored
+	t.tx.ReplaceOrInsert(k)
+	return nil
+}
+
 func (t *logTreeTX) UpdateSequencedLeaves(ctx context.Context, leaves []*trillian.LogLeaf) error {
 	countByMerkleHash := make(map[string]int)
 	for _, leaf := range leaves {
@@ -401,7 +572,7 @@ func (t *logTreeTX) UpdateSequencedLeaves(ctx context.Context, leaves []*trillia
 	q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
 	toRemove := make([]*list.Element, 0, q.Len())
 	for e := q.Front(); e != nil && len(countByMerkleHash) > 0; e = e.Next() {
-		h := e.Value.(*trillian.LogLeaf).MerkleLeafHash
+		h := e.Value.(*queuedLeaf).leaf.MerkleLeafHash
 		mh := string(h)
 		if countByMerkleHash[mh] > 0 {
 			countByMerkleHash[mh]--
@@ -422,6 +593,65 @@ func (t *logTreeTX) UpdateSequencedLeaves(ctx context.Context, leaves []*trillia
 	return nil
 }
 
+func (t *logTreeTX) QuarantineLeaves(ctx context.Context, leaves []*trillian.QuarantinedLeaf) error {
+	byHash := make(map[string]*trillian.QuarantinedLeaf, len(leaves))
+	for _, ql := range leaves {
+		byHash[string(ql.Leaf.LeafIdentityHash)] = ql
+	}
+	q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
+	for e := q.Front(); e != nil && len(byHash) > 0; {
+		next := e.Next()
+		h := string(e.Value.(*queuedLeaf).leaf.LeafIdentityHash)
+		if ql, ok := byHash[h]; ok {
+			q.Remove(e)
+			delete(byHash, h)
+			k := quarantineKey(t.treeID, ql.Leaf.LeafIdentityHash)
+			k.(*kv).v = proto.Clone(ql).(*trillian.QuarantinedLeaf)
+			t.tx.ReplaceOrInsert(k)
+		}
+		e = next
+	}
+	if unknown := len(byHash); unknown != 0 {
+		return fmt.Errorf("attempted to quarantine %d unknown leaves", unknown)
+	}
+	return nil
+}
+
+func (t *logTreeTX) ListQuarantinedLeaves(ctx context.Context, limit int) ([]*trillian.QuarantinedLeaf, error) {
+	var ret []*trillian.QuarantinedLeaf
+	prefix := fmt.Sprintf("/%d/quarantine/", t.treeID)
+	t.tx.AscendGreaterOrEqual(&kv{k: prefix}, func(i btree.Item) bool {
+		if !strings.HasPrefix(i.(*kv).k, prefix) {
+			return false
+		}
+		ret = append(ret, proto.Clone(i.(*kv).v.(*trillian.QuarantinedLeaf)).(*trillian.QuarantinedLeaf))
+		return true
+	})
+	sort.SliceStable(ret, func(i, j int) bool {
+		ti, tj := ret[i].QuarantineTimestamp, ret[j].QuarantineTimestamp
+		return ti.GetSeconds() < tj.GetSeconds() || ti.GetSeconds() == tj.GetSeconds() && ti.GetNanos() < tj.GetNanos()
+	})
+	if len(ret) > limit {
+		ret = ret[:limit]
+	}
+	return ret, nil
+}
+
+func (t *logTreeTX) RequeueQuarantinedLeaves(ctx context.Context, leafIdentityHashes [][]byte) (int, error) {
+	q := t.tx.Get(unseqKey(t.treeID)).(*kv).v.(*list.List)
+	requestID := requestid.FromContext(ctx)
+	requeued := 0
+	for _, hash := range leafIdentityHashes {
+		item := t.tx.Delete(quarantineKey(t.treeID, hash))
+		if item == nil {
+			continue
+		}
+		q.PushBack(&queuedLeaf{leaf: item.(*kv).v.(*trillian.QuarantinedLeaf).Leaf, requestID: requestID})
+		requeued++
+	}
+	return requeued, nil
+}
+
 func (t *logTreeTX) GetActiveLogIDs(ctx context.Context) ([]int64, error) {
 	return getActiveLogIDs(t.ts.trees), nil
 }
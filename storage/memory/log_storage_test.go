@@ -0,0 +1,348 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/google/trillian"
+	"github.com/google/trillian/merkle/rfc6962"
+	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/storagepb"
+	"github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestQuarantineLeaves(t *testing.T) {
+	ctx := context.Background()
+	ts := NewTreeStorage()
+	tree, err := storage.CreateTree(ctx, NewAdminStorage(ts), testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	s := NewLogStorage(ts, nil)
+
+	queuedAt := time.Unix(1000, 0)
+	var leaves []*trillian.LogLeaf
+	for i := 0; i < 3; i++ {
+		hash := rfc6962.DefaultHasher.HashLeaf([]byte(fmt.Sprintf("leaf-%d", i)))
+		leaves = append(leaves, &trillian.LogLeaf{LeafIdentityHash: hash, MerkleLeafHash: hash})
+	}
+	runTX := func(f storage.LogTXFunc) {
+		t.Helper()
+		if err := s.ReadWriteTransaction(ctx, tree, f); err != nil {
+			t.Fatalf("ReadWriteTransaction(): %v", err)
+		}
+	}
+	dequeue := func(tx storage.LogTreeTX) []*trillian.LogLeaf {
+		t.Helper()
+		dequeued, err := tx.DequeueLeaves(ctx, 10, queuedAt)
+		if err != nil {
+			t.Fatalf("DequeueLeaves(): %v", err)
+		}
+		return dequeued
+	}
+	list := func(tx storage.LogTreeTX) []*trillian.QuarantinedLeaf {
+		t.Helper()
+		quarantined, err := tx.ListQuarantinedLeaves(ctx, 10)
+		if err != nil {
+			t.Fatalf("ListQuarantinedLeaves(): %v", err)
+		}
+		return quarantined
+	}
+
+	runTX(func(ctx context.Context, tx storage.LogTreeTX) error {
+		_, err := tx.QueueLeaves(ctx, leaves, queuedAt)
+		return err
+	})
+
+	// Quarantine the first and last leaves, one after the other.
+	for _, i := range []int{2, 0} {
+		runTX(func(ctx context.Context, tx storage.LogTreeTX) error {
+			var ql *trillian.QuarantinedLeaf
+			for _, leaf := range dequeue(tx) {
+				if bytes.Equal(leaf.LeafIdentityHash, leaves[i].LeafIdentityHash) {
+					quarantinedAt, _ := ptypes.TimestampProto(time.Unix(int64(2000-i), 0))
+					ql = &trillian.QuarantinedLeaf{Leaf: leaf, Error: fmt.Sprintf("bad leaf %d", i), QuarantineTimestamp: quarantinedAt}
+				}
+			}
+			return tx.QuarantineLeaves(ctx, []*trillian.QuarantinedLeaf{ql})
+		})
+	}
+
+	runTX(func(ctx context.Context, tx storage.LogTreeTX) error {
+		if got, want := len(dequeue(tx)), 1; got != want {
+			t.Errorf("DequeueLeaves() returned %d leaves, want %d", got, want)
+		}
+		quarantined := list(tx)
+		if got, want := len(quarantined), 2; got != want {
+			t.Fatalf("ListQuarantinedLeaves() returned %d leaves, want %d", got, want)
+		}
+		// Leaf 2 was quarantined at the earlier time.
+		for j, i := range []int{2, 0} {
+			if got, want := quarantined[j].Leaf.LeafIdentityHash, leaves[i].LeafIdentityHash; !bytes.Equal(got, want) {
+				t.Errorf("ListQuarantinedLeaves()[%d] has identity hash %x, want %x", j, got, want)
+			}
+			if got, want := quarantined[j].Error, fmt.Sprintf("bad leaf %d", i); got != want {
+				t.Errorf("ListQuarantinedLeaves()[%d] has error %q, want %q", j, got, want)
+			}
+		}
+		if first, err := tx.ListQuarantinedLeaves(ctx, 1); err != nil || len(first) != 1 {
+			t.Errorf("ListQuarantinedLeaves() with limit 1 = %d leaves, %v; want 1 leaf", len(first), err)
+		}
+		return nil
+	})
+
+	runTX(func(ctx context.Context, tx storage.LogTreeTX) error {
+		n, err := tx.RequeueQuarantinedLeaves(ctx, [][]byte{leaves[0].LeafIdentityHash, []byte("unknown")})
+		if err != nil {
+			t.Fatalf("RequeueQuarantinedLeaves(): %v", err)
+		}
+		if got, want := n, 1; got != want {
+			t.Errorf("RequeueQuarantinedLeaves() = %d, want %d", got, want)
+		}
+		return nil
+	})
+
+	runTX(func(ctx context.Context, tx storage.LogTreeTX) error {
+		if got, want := len(dequeue(tx)), 2; got != want {
+			t.Errorf("DequeueLeaves() after requeue returned %d leaves, want %d", got, want)
+		}
+		if got, want := len(list(tx)), 1; got != want {
+			t.Errorf("ListQuarantinedLeaves() after requeue returned %d leaves, want %d", got, want)
+		}
+		return nil
+	})
+}
+
+func TestSignedLogRootHistory(t *testing.T) {
+	ctx := context.Background()
+	ts := NewTreeStorage()
+	tree, err := storage.CreateTree(ctx, NewAdminStorage(ts), testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	// Another tree, whose roots must not be returned.
+	other, err := storage.CreateTree(ctx, NewAdminStorage(ts), testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	s := NewLogStorage(ts, nil)
+
+	var roots []*trillian.SignedLogRoot
+	for i := 0; i < 3; i++ {
+		logRoot, err := (&types.LogRootV1{TimestampNanos: uint64(1000 * (i + 1)), TreeSize: uint64(i), Revision: uint64(i)}).MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(): %v", err)
+		}
+		root := &trillian.SignedLogRoot{LogRoot: logRoot, LogRootSignature: []byte(fmt.Sprintf("sig-%d", i))}
+		roots = append(roots, root)
+		for _, tr := range []*trillian.Tree{tree, other} {
+			if err := s.ReadWriteTransaction(ctx, tr, func(ctx context.Context, tx storage.LogTreeTX) error {
+				return tx.StoreSignedLogRoot(ctx, root)
+			}); err != nil {
+				t.Fatalf("StoreSignedLogRoot(): %v", err)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		limit int
+		want  []*trillian.SignedLogRoot
+	}{
+		{limit: 2, want: []*trillian.SignedLogRoot{roots[2], roots[1]}},
+		{limit: 10, want: []*trillian.SignedLogRoot{roots[2], roots[1], roots[0]}},
+	} {
+		tx, err := s.SnapshotForTree(ctx, tree)
+		if err != nil {
+			t.Fatalf("SnapshotForTree(): %v", err)
+		}
+		got, err := tx.SignedLogRootHistory(ctx, test.limit)
+		if err != nil {
+			t.Fatalf("SignedLogRootHistory(%d): %v", test.limit, err)
+		}
+		if len(got) != len(test.want) {
+			t.Fatalf("SignedLogRootHistory(%d) returned %d roots, want %d", test.limit, len(got), len(test.want))
+		}
+		for i := range got {
+			if !proto.Equal(got[i], test.want[i]) {
+				t.Errorf("SignedLogRootHistory(%d)[%d] = %v, want %v", test.limit, i, got[i], test.want[i])
+			}
+		}
+		tx.Close()
+	}
+}
+
+func TestSignedLogRootAtSize(t *testing.T) {
+	ctx := context.Background()
+	ts := NewTreeStorage()
+	tree, err := storage.CreateTree(ctx, NewAdminStorage(ts), testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	s := NewLogStorage(ts, nil)
+
+	// The root of size 1 is signed twice, and the later one is returned.
+	var roots []*trillian.SignedLogRoot
+	for i, size := range []uint64{0, 1, 1, 3} {
+		logRoot, err := (&types.LogRootV1{TimestampNanos: uint64(1000 * (i + 1)), TreeSize: size, Revision: uint64(i)}).MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary(): %v", err)
+		}
+		root := &trillian.SignedLogRoot{LogRoot: logRoot, LogRootSignature: []byte(fmt.Sprintf("sig-%d", i))}
+		roots = append(roots, root)
+		if err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+			return tx.StoreSignedLogRoot(ctx, root)
+		}); err != nil {
+			t.Fatalf("StoreSignedLogRoot(): %v", err)
+		}
+	}
+
+	tx, err := s.SnapshotForTree(ctx, tree)
+	if err != nil {
+		t.Fatalf("SnapshotForTree(): %v", err)
+	}
+	defer tx.Close()
+	for _, test := range []struct {
+		size int64
+		want *trillian.SignedLogRoot
+	}{
+		{size: 0, want: roots[0]},
+		{size: 1, want: roots[2]},
+		{size: 2},
+		{size: 3, want: roots[3]},
+		{size: 4},
+	} {
+		got, err := tx.SignedLogRootAtSize(ctx, test.size)
+		if err != nil {
+			t.Fatalf("SignedLogRootAtSize(%d): %v", test.size, err)
+		}
+		if !proto.Equal(got, test.want) {
+			t.Errorf("SignedLogRootAtSize(%d) = %v, want %v", test.size, got, test.want)
+		}
+	}
+}
+
+func TestQueueCondition(t *testing.T) {
+	ctx := context.Background()
+	ts := NewTreeStorage()
+	tree, err := storage.CreateTree(ctx, NewAdminStorage(ts), testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	s := NewLogStorage(ts, nil)
+
+	queuedAt := time.Unix(1000, 0)
+	conditional := rfc6962.DefaultHasher.HashLeaf([]byte("conditional"))
+	plain := rfc6962.DefaultHasher.HashLeaf([]byte("plain"))
+	cond := &storagepb.QueueCondition{BatchId: []byte("batch"), TreeSize: 5, BatchSize: 1}
+	for _, q := range []struct {
+		ctx  context.Context
+		hash []byte
+	}{
+		{ctx: storage.NewQueueConditionContext(ctx, cond), hash: conditional},
+		{ctx: ctx, hash: plain},
+	} {
+		if err := s.ReadWriteTransaction(q.ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+			_, err := tx.QueueLeaves(ctx, []*trillian.LogLeaf{{LeafIdentityHash: q.hash, MerkleLeafHash: q.hash}}, queuedAt)
+			return err
+		}); err != nil {
+			t.Fatalf("QueueLeaves(): %v", err)
+		}
+	}
+
+	if err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		if _, err := tx.DequeueLeaves(ctx, 10, queuedAt); err != nil {
+			t.Fatalf("DequeueLeaves(): %v", err)
+		}
+		r := tx.(storage.QueueConditionReader)
+		if got := r.QueueCondition(conditional); !proto.Equal(got, cond) {
+			t.Errorf("QueueCondition(conditional) = %v, want %v", got, cond)
+		}
+		if got := r.QueueCondition(plain); got != nil {
+			t.Errorf("QueueCondition(plain) = %v, want nil", got)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("ReadWriteTransaction(): %v", err)
+	}
+}
+
+func TestQueueCondition_Duplicates(t *testing.T) {
+	ctx := context.Background()
+	ts := NewTreeStorage()
+	tree, err := storage.CreateTree(ctx, NewAdminStorage(ts), testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree(): %v", err)
+	}
+	s := NewLogStorage(ts, nil)
+
+	queuedAt := time.Unix(1000, 0)
+	leafFor := func(data string) *trillian.LogLeaf {
+		h := rfc6962.DefaultHasher.HashLeaf([]byte(data))
+		return &trillian.LogLeaf{LeafIdentityHash: h, MerkleLeafHash: h}
+	}
+	queue := func(ctx context.Context, leaves ...*trillian.LogLeaf) error {
+		return s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+			_, err := tx.QueueLeaves(ctx, leaves, queuedAt)
+			return err
+		})
+	}
+
+	// Sequence one leaf and leave another one queued.
+	if err := queue(ctx, leafFor("sequenced")); err != nil {
+		t.Fatalf("QueueLeaves(sequenced): %v", err)
+	}
+	if err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		leaves, err := tx.DequeueLeaves(ctx, 10, queuedAt)
+		if err != nil {
+			return err
+		}
+		leaves[0].LeafIndex = 0
+		return tx.UpdateSequencedLeaves(ctx, leaves)
+	}); err != nil {
+		t.Fatalf("sequencing failed: %v", err)
+	}
+	if err := queue(ctx, leafFor("queued")); err != nil {
+		t.Fatalf("QueueLeaves(queued): %v", err)
+	}
+
+	condCtx := storage.NewQueueConditionContext(ctx, &storagepb.QueueCondition{BatchId: []byte("batch"), TreeSize: 1, BatchSize: 2})
+	for _, tc := range []struct {
+		desc   string
+		ctx    context.Context
+		leaves []*trillian.LogLeaf
+		want   codes.Code
+	}{
+		{desc: "queued", ctx: condCtx, leaves: []*trillian.LogLeaf{leafFor("new"), leafFor("queued")}, want: codes.FailedPrecondition},
+		{desc: "sequenced", ctx: condCtx, leaves: []*trillian.LogLeaf{leafFor("new"), leafFor("sequenced")}, want: codes.FailedPrecondition},
+		{desc: "within-batch", ctx: condCtx, leaves: []*trillian.LogLeaf{leafFor("new"), leafFor("new")}, want: codes.FailedPrecondition},
+		{desc: "unconditional", ctx: ctx, leaves: []*trillian.LogLeaf{leafFor("new"), leafFor("queued")}, want: codes.OK},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := status.Code(queue(tc.ctx, tc.leaves...)); got != tc.want {
+				t.Errorf("QueueLeaves() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -42,6 +42,11 @@ func (s *memProvider) LogStorage() storage.LogStorage {
 	return NewLogStorage(s.ts, s.mf)
 }
 
+// CountsUnsequenced implements storage.UnsequencedCounterProvider.
+func (s *memProvider) CountsUnsequenced() bool {
+	return true
+}
+
 func (s *memProvider) MapStorage() storage.MapStorage {
 	return nil
 }
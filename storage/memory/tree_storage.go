@@ -80,15 +80,19 @@ func (t *tree) RUnlock() {
 // TreeStorage is shared between the memoryLog and (forthcoming) memoryMap-
 // Storage implementations, and contains functionality which is common to both,
 type TreeStorage struct {
-	// mu only protects access to the trees map.
-	mu    sync.RWMutex
-	trees map[int64]*tree
+	// mu only protects access to the trees, templates and attestations maps.
+	mu           sync.RWMutex
+	trees        map[int64]*tree
+	templates    map[string]*trillian.TreeTemplate
+	attestations map[int64]*trillian.TreeAttestation
 }
 
 // NewTreeStorage returns a new instance of the in-memory tree storage database.
 func NewTreeStorage() *TreeStorage {
 	return &TreeStorage{
-		trees: make(map[int64]*tree),
+		trees:        make(map[int64]*tree),
+		templates:    make(map[string]*trillian.TreeTemplate),
+		attestations: make(map[int64]*trillian.TreeAttestation),
 	}
 }
 
@@ -146,6 +146,48 @@ func (mr *MockAdminTXMockRecorder) CreateTree(arg0, arg1 interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTree", reflect.TypeOf((*MockAdminTX)(nil).CreateTree), arg0, arg1)
 }
 
+// CreateTreeAttestation mocks base method
+func (m *MockAdminTX) CreateTreeAttestation(arg0 context.Context, arg1 int64, arg2 *trillian.TreeAttestation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTreeAttestation", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTreeAttestation indicates an expected call of CreateTreeAttestation
+func (mr *MockAdminTXMockRecorder) CreateTreeAttestation(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTreeAttestation", reflect.TypeOf((*MockAdminTX)(nil).CreateTreeAttestation), arg0, arg1, arg2)
+}
+
+// CreateTreeTemplate mocks base method
+func (m *MockAdminTX) CreateTreeTemplate(arg0 context.Context, arg1 *trillian.TreeTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateTreeTemplate indicates an expected call of CreateTreeTemplate
+func (mr *MockAdminTXMockRecorder) CreateTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTreeTemplate", reflect.TypeOf((*MockAdminTX)(nil).CreateTreeTemplate), arg0, arg1)
+}
+
+// DeleteTreeTemplate mocks base method
+func (m *MockAdminTX) DeleteTreeTemplate(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTreeTemplate indicates an expected call of DeleteTreeTemplate
+func (mr *MockAdminTXMockRecorder) DeleteTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTreeTemplate", reflect.TypeOf((*MockAdminTX)(nil).DeleteTreeTemplate), arg0, arg1)
+}
+
 // GetTree mocks base method
 func (m *MockAdminTX) GetTree(arg0 context.Context, arg1 int64) (*trillian.Tree, error) {
 	m.ctrl.T.Helper()
@@ -161,6 +203,36 @@ func (mr *MockAdminTXMockRecorder) GetTree(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTree", reflect.TypeOf((*MockAdminTX)(nil).GetTree), arg0, arg1)
 }
 
+// GetTreeAttestation mocks base method
+func (m *MockAdminTX) GetTreeAttestation(arg0 context.Context, arg1 int64) (*trillian.TreeAttestation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTreeAttestation", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.TreeAttestation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeAttestation indicates an expected call of GetTreeAttestation
+func (mr *MockAdminTXMockRecorder) GetTreeAttestation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeAttestation", reflect.TypeOf((*MockAdminTX)(nil).GetTreeAttestation), arg0, arg1)
+}
+
+// GetTreeTemplate mocks base method
+func (m *MockAdminTX) GetTreeTemplate(arg0 context.Context, arg1 string) (*trillian.TreeTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.TreeTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeTemplate indicates an expected call of GetTreeTemplate
+func (mr *MockAdminTXMockRecorder) GetTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeTemplate", reflect.TypeOf((*MockAdminTX)(nil).GetTreeTemplate), arg0, arg1)
+}
+
 // HardDeleteTree mocks base method
 func (m *MockAdminTX) HardDeleteTree(arg0 context.Context, arg1 int64) error {
 	m.ctrl.T.Helper()
@@ -204,6 +276,21 @@ func (mr *MockAdminTXMockRecorder) ListTreeIDs(arg0, arg1 interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTreeIDs", reflect.TypeOf((*MockAdminTX)(nil).ListTreeIDs), arg0, arg1)
 }
 
+// ListTreeTemplates mocks base method
+func (m *MockAdminTX) ListTreeTemplates(arg0 context.Context) ([]*trillian.TreeTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTreeTemplates", arg0)
+	ret0, _ := ret[0].([]*trillian.TreeTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTreeTemplates indicates an expected call of ListTreeTemplates
+func (mr *MockAdminTXMockRecorder) ListTreeTemplates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTreeTemplates", reflect.TypeOf((*MockAdminTX)(nil).ListTreeTemplates), arg0)
+}
+
 // ListTrees mocks base method
 func (m *MockAdminTX) ListTrees(arg0 context.Context, arg1 bool) ([]*trillian.Tree, error) {
 	m.ctrl.T.Helper()
@@ -278,6 +365,20 @@ func (mr *MockAdminTXMockRecorder) UpdateTree(arg0, arg1, arg2 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTree", reflect.TypeOf((*MockAdminTX)(nil).UpdateTree), arg0, arg1, arg2)
 }
 
+// UpdateTreeTemplate mocks base method
+func (m *MockAdminTX) UpdateTreeTemplate(arg0 context.Context, arg1 *trillian.TreeTemplate) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTreeTemplate indicates an expected call of UpdateTreeTemplate
+func (mr *MockAdminTXMockRecorder) UpdateTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTreeTemplate", reflect.TypeOf((*MockAdminTX)(nil).UpdateTreeTemplate), arg0, arg1)
+}
+
 // MockLogStorage is a mock of LogStorage interface
 type MockLogStorage struct {
 	ctrl     *gomock.Controller
@@ -574,6 +675,35 @@ func (mr *MockLogTreeTXMockRecorder) LatestSignedLogRoot(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestSignedLogRoot", reflect.TypeOf((*MockLogTreeTX)(nil).LatestSignedLogRoot), arg0)
 }
 
+// ListQuarantinedLeaves mocks base method
+func (m *MockLogTreeTX) ListQuarantinedLeaves(arg0 context.Context, arg1 int) ([]*trillian.QuarantinedLeaf, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQuarantinedLeaves", arg0, arg1)
+	ret0, _ := ret[0].([]*trillian.QuarantinedLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQuarantinedLeaves indicates an expected call of ListQuarantinedLeaves
+func (mr *MockLogTreeTXMockRecorder) ListQuarantinedLeaves(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQuarantinedLeaves", reflect.TypeOf((*MockLogTreeTX)(nil).ListQuarantinedLeaves), arg0, arg1)
+}
+
+// QuarantineLeaves mocks base method
+func (m *MockLogTreeTX) QuarantineLeaves(arg0 context.Context, arg1 []*trillian.QuarantinedLeaf) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuarantineLeaves", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// QuarantineLeaves indicates an expected call of QuarantineLeaves
+func (mr *MockLogTreeTXMockRecorder) QuarantineLeaves(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuarantineLeaves", reflect.TypeOf((*MockLogTreeTX)(nil).QuarantineLeaves), arg0, arg1)
+}
+
 // QueueLeaves mocks base method
 func (m *MockLogTreeTX) QueueLeaves(arg0 context.Context, arg1 []*trillian.LogLeaf, arg2 time.Time) ([]*trillian.LogLeaf, error) {
 	m.ctrl.T.Helper()
@@ -604,6 +734,21 @@ func (mr *MockLogTreeTXMockRecorder) ReadRevision(arg0 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRevision", reflect.TypeOf((*MockLogTreeTX)(nil).ReadRevision), arg0)
 }
 
+// RequeueQuarantinedLeaves mocks base method
+func (m *MockLogTreeTX) RequeueQuarantinedLeaves(arg0 context.Context, arg1 [][]byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueQuarantinedLeaves", arg0, arg1)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueQuarantinedLeaves indicates an expected call of RequeueQuarantinedLeaves
+func (mr *MockLogTreeTXMockRecorder) RequeueQuarantinedLeaves(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueQuarantinedLeaves", reflect.TypeOf((*MockLogTreeTX)(nil).RequeueQuarantinedLeaves), arg0, arg1)
+}
+
 // Rollback mocks base method
 func (m *MockLogTreeTX) Rollback() error {
 	m.ctrl.T.Helper()
@@ -632,6 +777,36 @@ func (mr *MockLogTreeTXMockRecorder) SetMerkleNodes(arg0, arg1 interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMerkleNodes", reflect.TypeOf((*MockLogTreeTX)(nil).SetMerkleNodes), arg0, arg1)
 }
 
+// SignedLogRootAtSize mocks base method
+func (m *MockLogTreeTX) SignedLogRootAtSize(arg0 context.Context, arg1 int64) (*trillian.SignedLogRoot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedLogRootAtSize", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRootAtSize indicates an expected call of SignedLogRootAtSize
+func (mr *MockLogTreeTXMockRecorder) SignedLogRootAtSize(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedLogRootAtSize", reflect.TypeOf((*MockLogTreeTX)(nil).SignedLogRootAtSize), arg0, arg1)
+}
+
+// SignedLogRootHistory mocks base method
+func (m *MockLogTreeTX) SignedLogRootHistory(arg0 context.Context, arg1 int) ([]*trillian.SignedLogRoot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedLogRootHistory", arg0, arg1)
+	ret0, _ := ret[0].([]*trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRootHistory indicates an expected call of SignedLogRootHistory
+func (mr *MockLogTreeTXMockRecorder) SignedLogRootHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedLogRootHistory", reflect.TypeOf((*MockLogTreeTX)(nil).SignedLogRootHistory), arg0, arg1)
+}
+
 // StoreSignedLogRoot mocks base method
 func (m *MockLogTreeTX) StoreSignedLogRoot(arg0 context.Context, arg1 *trillian.SignedLogRoot) error {
 	m.ctrl.T.Helper()
@@ -1062,6 +1237,36 @@ func (mr *MockReadOnlyAdminTXMockRecorder) GetTree(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTree", reflect.TypeOf((*MockReadOnlyAdminTX)(nil).GetTree), arg0, arg1)
 }
 
+// GetTreeAttestation mocks base method
+func (m *MockReadOnlyAdminTX) GetTreeAttestation(arg0 context.Context, arg1 int64) (*trillian.TreeAttestation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTreeAttestation", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.TreeAttestation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeAttestation indicates an expected call of GetTreeAttestation
+func (mr *MockReadOnlyAdminTXMockRecorder) GetTreeAttestation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeAttestation", reflect.TypeOf((*MockReadOnlyAdminTX)(nil).GetTreeAttestation), arg0, arg1)
+}
+
+// GetTreeTemplate mocks base method
+func (m *MockReadOnlyAdminTX) GetTreeTemplate(arg0 context.Context, arg1 string) (*trillian.TreeTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.TreeTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeTemplate indicates an expected call of GetTreeTemplate
+func (mr *MockReadOnlyAdminTXMockRecorder) GetTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeTemplate", reflect.TypeOf((*MockReadOnlyAdminTX)(nil).GetTreeTemplate), arg0, arg1)
+}
+
 // IsClosed mocks base method
 func (m *MockReadOnlyAdminTX) IsClosed() bool {
 	m.ctrl.T.Helper()
@@ -1091,6 +1296,21 @@ func (mr *MockReadOnlyAdminTXMockRecorder) ListTreeIDs(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTreeIDs", reflect.TypeOf((*MockReadOnlyAdminTX)(nil).ListTreeIDs), arg0, arg1)
 }
 
+// ListTreeTemplates mocks base method
+func (m *MockReadOnlyAdminTX) ListTreeTemplates(arg0 context.Context) ([]*trillian.TreeTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTreeTemplates", arg0)
+	ret0, _ := ret[0].([]*trillian.TreeTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTreeTemplates indicates an expected call of ListTreeTemplates
+func (mr *MockReadOnlyAdminTXMockRecorder) ListTreeTemplates(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTreeTemplates", reflect.TypeOf((*MockReadOnlyAdminTX)(nil).ListTreeTemplates), arg0)
+}
+
 // ListTrees mocks base method
 func (m *MockReadOnlyAdminTX) ListTrees(arg0 context.Context, arg1 bool) ([]*trillian.Tree, error) {
 	m.ctrl.T.Helper()
@@ -1355,6 +1575,21 @@ func (mr *MockReadOnlyLogTreeTXMockRecorder) LatestSignedLogRoot(arg0 interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestSignedLogRoot", reflect.TypeOf((*MockReadOnlyLogTreeTX)(nil).LatestSignedLogRoot), arg0)
 }
 
+// ListQuarantinedLeaves mocks base method
+func (m *MockReadOnlyLogTreeTX) ListQuarantinedLeaves(arg0 context.Context, arg1 int) ([]*trillian.QuarantinedLeaf, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListQuarantinedLeaves", arg0, arg1)
+	ret0, _ := ret[0].([]*trillian.QuarantinedLeaf)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListQuarantinedLeaves indicates an expected call of ListQuarantinedLeaves
+func (mr *MockReadOnlyLogTreeTXMockRecorder) ListQuarantinedLeaves(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListQuarantinedLeaves", reflect.TypeOf((*MockReadOnlyLogTreeTX)(nil).ListQuarantinedLeaves), arg0, arg1)
+}
+
 // ReadRevision mocks base method
 func (m *MockReadOnlyLogTreeTX) ReadRevision(arg0 context.Context) (int64, error) {
 	m.ctrl.T.Helper()
@@ -1384,6 +1619,36 @@ func (mr *MockReadOnlyLogTreeTXMockRecorder) Rollback() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockReadOnlyLogTreeTX)(nil).Rollback))
 }
 
+// SignedLogRootAtSize mocks base method
+func (m *MockReadOnlyLogTreeTX) SignedLogRootAtSize(arg0 context.Context, arg1 int64) (*trillian.SignedLogRoot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedLogRootAtSize", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRootAtSize indicates an expected call of SignedLogRootAtSize
+func (mr *MockReadOnlyLogTreeTXMockRecorder) SignedLogRootAtSize(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedLogRootAtSize", reflect.TypeOf((*MockReadOnlyLogTreeTX)(nil).SignedLogRootAtSize), arg0, arg1)
+}
+
+// SignedLogRootHistory mocks base method
+func (m *MockReadOnlyLogTreeTX) SignedLogRootHistory(arg0 context.Context, arg1 int) ([]*trillian.SignedLogRoot, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedLogRootHistory", arg0, arg1)
+	ret0, _ := ret[0].([]*trillian.SignedLogRoot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedLogRootHistory indicates an expected call of SignedLogRootHistory
+func (mr *MockReadOnlyLogTreeTXMockRecorder) SignedLogRootHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedLogRootHistory", reflect.TypeOf((*MockReadOnlyLogTreeTX)(nil).SignedLogRootHistory), arg0, arg1)
+}
+
 // MockReadOnlyMapTreeTX is a mock of ReadOnlyMapTreeTX interface
 type MockReadOnlyMapTreeTX struct {
 	ctrl     *gomock.Controller
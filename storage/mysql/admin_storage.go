@@ -38,6 +38,10 @@ const (
 	selectTreeIDs           = "SELECT TreeId FROM Trees"
 	selectNonDeletedTreeIDs = selectTreeIDs + nonDeletedWhere
 
+	// There is no index on DisplayName, so the locking read locks all the
+	// rows of Trees and the gaps among them, which blocks concurrent inserts.
+	lockTreeIDsByDisplayName = selectNonDeletedTreeIDs + " AND DisplayName = ? ORDER BY TreeId FOR UPDATE"
+
 	selectTrees = `
 		SELECT
 			TreeId,
@@ -54,14 +58,43 @@ const (
 			PublicKey,
 			MaxRootDurationMillis,
 			Deleted,
-			DeleteTimeMillis
+			DeleteTimeMillis,
+			OrderedLeafTimestamps,
+			CallerLeafIdentityHash,
+			HashExtraData,
+			Namespace,
+			LogRootEncoding,
+			TimestampGranularity,
+			HashOnly,
+			LeafCompression,
+			MaxTreeSize,
+			QueueWriteAhead,
+			LeafEncryption,
+			SortByQueueTimestamp,
+			LeafOrderingKey,
+			LeafTombstones,
+			EmptyRootHash,
+			LeafCompressionDictionaries,
+			LeafIndexOffset,
+			SigningIntervalMillis,
+			MaxQueueAgeMillis
 		FROM Trees`
 	selectNonDeletedTrees = selectTrees + nonDeletedWhere
 	selectTreeByID        = selectTrees + " WHERE TreeId = ?"
 
 	updateTreeSQL = `UPDATE Trees
-		SET TreeState = ?, TreeType = ?, DisplayName = ?, Description = ?, UpdateTimeMillis = ?, MaxRootDurationMillis = ?, PrivateKey = ?
+		SET TreeState = ?, TreeType = ?, DisplayName = ?, Description = ?, UpdateTimeMillis = ?, MaxRootDurationMillis = ?, PrivateKey = ?, LeafEncryption = ?, LeafCompressionDictionaries = ?,
+			SigningIntervalMillis = ?, MaxQueueAgeMillis = ?
 		WHERE TreeId = ?`
+
+	selectTreeTemplateSQL  = "SELECT Template FROM TreeTemplates WHERE Name = ?"
+	selectTreeTemplatesSQL = "SELECT Template FROM TreeTemplates ORDER BY Name"
+	insertTreeTemplateSQL  = "INSERT INTO TreeTemplates(Name, Template) VALUES(?, ?)"
+	updateTreeTemplateSQL  = "UPDATE TreeTemplates SET Template = ? WHERE Name = ?"
+	deleteTreeTemplateSQL  = "DELETE FROM TreeTemplates WHERE Name = ?"
+
+	selectTreeAttestationSQL = "SELECT Tree, Signature FROM TreeAttestations WHERE TreeId = ?"
+	insertTreeAttestationSQL = "INSERT INTO TreeAttestations(TreeId, Tree, Signature) VALUES(?, ?, ?)"
 )
 
 // NewAdminStorage returns a MySQL storage.AdminStorage implementation backed by DB.
@@ -200,6 +233,24 @@ func (t *adminTX) ListTreeIDs(ctx context.Context, includeDeleted bool) ([]int64
 	return treeIDs, nil
 }
 
+// LockTreesByDisplayName implements storage.DisplayNameLocker.
+func (t *adminTX) LockTreesByDisplayName(ctx context.Context, displayName string) ([]int64, error) {
+	rows, err := t.tx.QueryContext(ctx, lockTreeIDsByDisplayName, displayName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var treeIDs []int64
+	for rows.Next() {
+		var treeID int64
+		if err := rows.Scan(&treeID); err != nil {
+			return nil, err
+		}
+		treeIDs = append(treeIDs, treeID)
+	}
+	return treeIDs, rows.Err()
+}
+
 func (t *adminTX) ListTrees(ctx context.Context, includeDeleted bool) ([]*trillian.Tree, error) {
 	var query string
 	if includeDeleted {
@@ -237,7 +288,7 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 		return nil, err
 	}
 
-	id, err := storage.NewTreeID()
+	id, err := storage.AllocateTreeID(ctx, t, tree.TreeId)
 	if err != nil {
 		return nil, err
 	}
@@ -260,6 +311,14 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 	if err != nil {
 		return nil, fmt.Errorf("could not parse MaxRootDuration: %v", err)
 	}
+	signingInterval, err := storage.OptionalDurationMillis(newTree.SigningInterval)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse SigningInterval: %v", err)
+	}
+	maxQueueAge, err := storage.OptionalDurationMillis(newTree.MaxQueueAge)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse MaxQueueAge: %v", err)
+	}
 
 	insertTreeStmt, err := t.tx.PrepareContext(
 		ctx,
@@ -276,8 +335,27 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 			UpdateTimeMillis,
 			PrivateKey,
 			PublicKey,
-			MaxRootDurationMillis)
-		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+			MaxRootDurationMillis,
+			OrderedLeafTimestamps,
+			CallerLeafIdentityHash,
+			HashExtraData,
+			Namespace,
+			LogRootEncoding,
+			TimestampGranularity,
+			HashOnly,
+			LeafCompression,
+			MaxTreeSize,
+			QueueWriteAhead,
+			LeafEncryption,
+			SortByQueueTimestamp,
+			LeafOrderingKey,
+			LeafTombstones,
+			EmptyRootHash,
+			LeafCompressionDictionaries,
+			LeafIndexOffset,
+			SigningIntervalMillis,
+			MaxQueueAgeMillis)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return nil, err
 	}
@@ -287,6 +365,18 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal PrivateKey: %v", err)
 	}
+	leafEncryption, err := storage.MarshalLeafEncryption(newTree.LeafEncryption)
+	if err != nil {
+		return nil, err
+	}
+	leafOrderingKey, err := storage.MarshalLeafOrderingKey(newTree.LeafOrderingKey)
+	if err != nil {
+		return nil, err
+	}
+	leafCompressionDictionaries, err := storage.MarshalLeafCompressionDictionaries(newTree.LeafCompressionDictionaries)
+	if err != nil {
+		return nil, err
+	}
 
 	_, err = insertTreeStmt.ExecContext(
 		ctx,
@@ -303,7 +393,31 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 		privateKey,
 		newTree.PublicKey.GetDer(),
 		rootDuration/time.Millisecond,
+		newTree.OrderedLeafTimestamps,
+		newTree.CallerLeafIdentityHash,
+		newTree.HashExtraData,
+		newTree.Namespace,
+		newTree.LogRootEncoding.String(),
+		newTree.TimestampGranularity.String(),
+		newTree.HashOnly,
+		newTree.LeafCompression.String(),
+		newTree.MaxTreeSize,
+		newTree.QueueWriteAhead,
+		leafEncryption,
+		newTree.SortByQueueTimestamp,
+		leafOrderingKey,
+		newTree.LeafTombstones,
+		newTree.EmptyRootHash,
+		leafCompressionDictionaries,
+		newTree.LeafIndexOffset,
+		signingInterval,
+		maxQueueAge,
 	)
+	if isDuplicateErr(err) {
+		// Another transaction created a tree with the requested ID after
+		// AllocateTreeID checked it.
+		return nil, status.Errorf(codes.AlreadyExists, "tree %v already exists", newTree.TreeId)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -372,11 +486,27 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 	if err != nil {
 		return nil, fmt.Errorf("could not parse MaxRootDuration: %v", err)
 	}
+	signingInterval, err := storage.OptionalDurationMillis(tree.SigningInterval)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse SigningInterval: %v", err)
+	}
+	maxQueueAge, err := storage.OptionalDurationMillis(tree.MaxQueueAge)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse MaxQueueAge: %v", err)
+	}
 
 	privateKey, err := proto.Marshal(tree.PrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal PrivateKey: %v", err)
 	}
+	leafEncryption, err := storage.MarshalLeafEncryption(tree.LeafEncryption)
+	if err != nil {
+		return nil, err
+	}
+	leafCompressionDictionaries, err := storage.MarshalLeafCompressionDictionaries(tree.LeafCompressionDictionaries)
+	if err != nil {
+		return nil, err
+	}
 
 	stmt, err := t.tx.PrepareContext(ctx, updateTreeSQL)
 	if err != nil {
@@ -393,6 +523,10 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 		nowMillis,
 		rootDuration/time.Millisecond,
 		privateKey,
+		leafEncryption,
+		leafCompressionDictionaries,
+		signingInterval,
+		maxQueueAge,
 		tree.TreeId); err != nil {
 		return nil, err
 	}
@@ -454,6 +588,110 @@ func validateDeleted(ctx context.Context, tx *sql.Tx, treeID int64, wantDeleted
 	return nil
 }
 
+func (t *adminTX) GetTreeTemplate(ctx context.Context, name string) (*trillian.TreeTemplate, error) {
+	var b []byte
+	switch err := t.tx.QueryRowContext(ctx, selectTreeTemplateSQL, name).Scan(&b); {
+	case err == sql.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "tree template %q not found", name)
+	case err != nil:
+		return nil, fmt.Errorf("error reading tree template %q: %v", name, err)
+	}
+	return storage.UnmarshalTreeTemplate(b)
+}
+
+func (t *adminTX) ListTreeTemplates(ctx context.Context) ([]*trillian.TreeTemplate, error) {
+	rows, err := t.tx.QueryContext(ctx, selectTreeTemplatesSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tmpls := []*trillian.TreeTemplate{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		tmpl, err := storage.UnmarshalTreeTemplate(b)
+		if err != nil {
+			return nil, err
+		}
+		tmpls = append(tmpls, tmpl)
+	}
+	return tmpls, rows.Err()
+}
+
+func (t *adminTX) CreateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	if err := storage.ValidateTreeTemplate(tmpl); err != nil {
+		return err
+	}
+	b, err := proto.Marshal(tmpl)
+	if err != nil {
+		return err
+	}
+	switch _, err := t.GetTreeTemplate(ctx, tmpl.Name); {
+	case err == nil:
+		return status.Errorf(codes.AlreadyExists, "tree template %q already exists", tmpl.Name)
+	case status.Code(err) != codes.NotFound:
+		return err
+	}
+	_, err = t.tx.ExecContext(ctx, insertTreeTemplateSQL, tmpl.Name, b)
+	if isDuplicateErr(err) {
+		// Another transaction created the template after it was checked.
+		return status.Errorf(codes.AlreadyExists, "tree template %q already exists", tmpl.Name)
+	}
+	return err
+}
+
+func (t *adminTX) UpdateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	if err := storage.ValidateTreeTemplate(tmpl); err != nil {
+		return err
+	}
+	b, err := proto.Marshal(tmpl)
+	if err != nil {
+		return err
+	}
+	if _, err := t.GetTreeTemplate(ctx, tmpl.Name); err != nil {
+		return err
+	}
+	_, err = t.tx.ExecContext(ctx, updateTreeTemplateSQL, b, tmpl.Name)
+	return err
+}
+
+func (t *adminTX) DeleteTreeTemplate(ctx context.Context, name string) error {
+	res, err := t.tx.ExecContext(ctx, deleteTreeTemplateSQL, name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return status.Errorf(codes.NotFound, "tree template %q not found", name)
+	}
+	return nil
+}
+
+func (t *adminTX) GetTreeAttestation(ctx context.Context, treeID int64) (*trillian.TreeAttestation, error) {
+	var a trillian.TreeAttestation
+	switch err := t.tx.QueryRowContext(ctx, selectTreeAttestationSQL, treeID).Scan(&a.Tree, &a.Signature); {
+	case err == sql.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "attestation of tree %v not found", treeID)
+	case err != nil:
+		return nil, fmt.Errorf("error reading attestation of tree %v: %v", treeID, err)
+	}
+	return &a, nil
+}
+
+func (t *adminTX) CreateTreeAttestation(ctx context.Context, treeID int64, a *trillian.TreeAttestation) error {
+	switch _, err := t.GetTreeAttestation(ctx, treeID); {
+	case err == nil:
+		return status.Errorf(codes.AlreadyExists, "tree %v already has an attestation", treeID)
+	case status.Code(err) != codes.NotFound:
+		return err
+	}
+	_, err := t.tx.ExecContext(ctx, insertTreeAttestationSQL, treeID, a.Tree, a.Signature)
+	return err
+}
+
 func validateStorageSettings(tree *trillian.Tree) error {
 	if tree.StorageSettings != nil {
 		return fmt.Errorf("storage_settings not supported, but got %v", tree.StorageSettings)
@@ -18,6 +18,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
@@ -26,6 +28,8 @@ import (
 	"github.com/google/trillian/crypto/keyspb"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/testonly"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const selectTreeControlByID = "SELECT SigningEnabled, SequencingEnabled, SequenceIntervalSeconds FROM TreeControl WHERE TreeId = ?"
@@ -61,6 +65,45 @@ func TestAdminTX_CreateTree_InitializesStorageStructures(t *testing.T) {
 	}
 }
 
+func TestAdminTX_CreateTree_ConcurrentRequestedID(t *testing.T) {
+	cleanTestDB(DB)
+	s := NewAdminStorage(DB).(*mysqlAdminStorage)
+	ctx := context.Background()
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.TreeId = 12345
+
+	tx1, err := s.beginInternal(ctx)
+	if err != nil {
+		t.Fatalf("beginInternal() failed: %v", err)
+	}
+	defer tx1.Close()
+	tx2, err := s.beginInternal(ctx)
+	if err != nil {
+		t.Fatalf("beginInternal() failed: %v", err)
+	}
+	defer tx2.Close()
+
+	// Both transactions find the requested ID free before either inserts it.
+	if _, err := tx2.GetTree(ctx, tree.TreeId); status.Code(err) != codes.NotFound {
+		t.Fatalf("GetTree() returned err = %v, want NotFound", err)
+	}
+	if _, err := tx1.CreateTree(ctx, tree); err != nil {
+		t.Fatalf("CreateTree() failed: %v", err)
+	}
+	// The insert of tx2 waits for tx1 to commit.
+	errc := make(chan error, 1)
+	go func() {
+		_, err := tx2.CreateTree(ctx, tree)
+		errc <- err
+	}()
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+	if err := <-errc; status.Code(err) != codes.AlreadyExists {
+		t.Errorf("concurrent CreateTree() returned err = %v, want AlreadyExists", err)
+	}
+}
+
 func TestCreateTreeInvalidStates(t *testing.T) {
 	cleanTestDB(DB)
 	s := NewAdminStorage(DB)
@@ -218,6 +261,43 @@ func TestAdminTX_HardDeleteTree(t *testing.T) {
 	}
 }
 
+func TestAdminTX_LockTreesByDisplayName(t *testing.T) {
+	cleanTestDB(DB)
+	s := NewAdminStorage(DB)
+	ctx := context.Background()
+
+	var wantIDs []int64
+	for _, name := range []string{"dup", "dup", "other", "dup"} {
+		tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+		tree.DisplayName = name
+		created, err := storage.CreateTree(ctx, s, tree)
+		if err != nil {
+			t.Fatalf("CreateTree() returned err = %v", err)
+		}
+		if name == "dup" {
+			wantIDs = append(wantIDs, created.TreeId)
+		}
+	}
+	if _, err := storage.SoftDeleteTree(ctx, s, wantIDs[0]); err != nil {
+		t.Fatalf("SoftDeleteTree() returned err = %v", err)
+	}
+	wantIDs = wantIDs[1:]
+	sort.Slice(wantIDs, func(i, j int) bool { return wantIDs[i] < wantIDs[j] })
+
+	if err := s.ReadWriteTransaction(ctx, func(ctx context.Context, tx storage.AdminTX) error {
+		got, err := tx.(storage.DisplayNameLocker).LockTreesByDisplayName(ctx, "dup")
+		if err != nil {
+			return err
+		}
+		if !reflect.DeepEqual(got, wantIDs) {
+			t.Errorf("LockTreesByDisplayName() = %v, want %v", got, wantIDs)
+		}
+		return nil
+	}); err != nil {
+		t.Fatalf("ReadWriteTransaction() returned err = %v", err)
+	}
+}
+
 func TestCheckDatabaseAccessible_Fails(t *testing.T) {
 	ctx := context.Background()
 
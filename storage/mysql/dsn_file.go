@@ -0,0 +1,174 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/golang/glog"
+)
+
+// OpenDBFromFile opens a MySQL database with the DSN held in the file at path,
+// rather than in a flag, so that its credentials don't show up in process
+// listings. If interval is positive, the file is re-read that often, and new
+// connections use the DSN it holds, so that credentials can be rotated without
+// restarting. Connections opened with an older DSN are closed once they are
+// returned to the pool, so in-flight transactions complete with the
+// credentials they started with. The file keeps being watched for the
+// lifetime of the process.
+func OpenDBFromFile(path string, interval time.Duration) (*sql.DB, error) {
+	c, err := newDSNConnector(path)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(c)
+	if _, err := db.ExecContext(context.TODO(), "SET sql_mode = 'STRICT_ALL_TABLES'"); err != nil {
+		glog.Warningf("Failed to set strict mode on mysql db: %s", err)
+		db.Close()
+		return nil, err
+	}
+	if interval > 0 {
+		go c.watch(interval)
+	}
+	return db, nil
+}
+
+// dsnConnector is a driver.Connector which opens MySQL connections with the
+// DSN last read from a file.
+type dsnConnector struct {
+	path string
+
+	mu        sync.RWMutex
+	dsn       string
+	gen       uint64 // Incremented whenever the DSN changes.
+	connector driver.Connector
+}
+
+func newDSNConnector(path string) (*dsnConnector, error) {
+	c := &dsnConnector{path: path}
+	if _, err := c.reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// reload re-reads the DSN from the file, and returns whether it changed. The
+// current DSN is kept if the file can't be read or holds an invalid DSN.
+func (c *dsnConnector) reload() (bool, error) {
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read MySQL DSN file: %v", err)
+	}
+	dsn := strings.TrimSpace(string(data))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connector != nil && dsn == c.dsn {
+		return false, nil
+	}
+	// Don't include the DSN in errors, as it holds credentials.
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return false, fmt.Errorf("MySQL DSN file %s holds an invalid DSN", c.path)
+	}
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		return false, fmt.Errorf("MySQL DSN file %s holds an unusable DSN", c.path)
+	}
+	c.dsn, c.connector = dsn, connector
+	c.gen++
+	return true, nil
+}
+
+// watch reloads the DSN every interval.
+func (c *dsnConnector) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		changed, err := c.reload()
+		if err != nil {
+			glog.Errorf("Keeping the current MySQL DSN: %v", err)
+		} else if changed {
+			glog.Infof("MySQL DSN file %s changed, new connections use the new DSN", c.path)
+		}
+	}
+}
+
+// current returns whether gen is the generation of the current DSN.
+func (c *dsnConnector) current(gen uint64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return gen == c.gen
+}
+
+// Connect implements driver.Connector.
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.RLock()
+	connector, gen := c.connector, c.gen
+	c.mu.RUnlock()
+
+	conn, err := connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fc, ok := conn.(fullConn)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("MySQL connection of type %T lacks required methods", conn)
+	}
+	return &rotatingConn{fullConn: fc, gen: gen, c: c}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *dsnConnector) Driver() driver.Driver {
+	return mysql.MySQLDriver{}
+}
+
+// fullConn is the set of driver interfaces implemented by MySQL connections,
+// which rotatingConn passes through, so that database/sql doesn't fall back to
+// their context-less versions.
+type fullConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.ExecerContext
+	driver.QueryerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.NamedValueChecker
+}
+
+// rotatingConn is a MySQL connection which is discarded by the pool, rather
+// than reused, if it was opened with a DSN which has since changed.
+type rotatingConn struct {
+	fullConn
+	gen uint64
+	c   *dsnConnector
+}
+
+// ResetSession implements driver.SessionResetter. It's called before the pool
+// reuses the connection.
+func (r *rotatingConn) ResetSession(ctx context.Context) error {
+	if !r.c.current(r.gen) {
+		return driver.ErrBadConn
+	}
+	return r.fullConn.ResetSession(ctx)
+}
@@ -0,0 +1,113 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/trillian/testonly/flagsaver"
+)
+
+// resetConn is a fullConn which only implements ResetSession.
+type resetConn struct {
+	fullConn
+	resets int
+}
+
+func (c *resetConn) ResetSession(ctx context.Context) error {
+	c.resets++
+	return nil
+}
+
+func TestDSNConnectorRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dsn")
+	if err != nil {
+		t.Fatalf("TempDir(): %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "dsn")
+	writeDSN := func(dsn string) {
+		t.Helper()
+		if err := ioutil.WriteFile(path, []byte(dsn), 0600); err != nil {
+			t.Fatalf("WriteFile(): %v", err)
+		}
+	}
+
+	if _, err := newDSNConnector(path); err == nil {
+		t.Error("newDSNConnector() with a missing file succeeded")
+	}
+	writeDSN("user:old@tcp(127.0.0.1:3306)/db\n")
+	c, err := newDSNConnector(path)
+	if err != nil {
+		t.Fatalf("newDSNConnector(): %v", err)
+	}
+	conn := &resetConn{}
+	old := &rotatingConn{fullConn: conn, gen: c.gen, c: c}
+
+	// Connections opened with the current DSN are reused.
+	if changed, err := c.reload(); err != nil || changed {
+		t.Errorf("reload() of an unchanged file = %v, %v; want false, nil", changed, err)
+	}
+	if err := old.ResetSession(context.Background()); err != nil {
+		t.Errorf("ResetSession() with the current DSN = %v, want nil", err)
+	}
+	if conn.resets != 1 {
+		t.Errorf("ResetSession() called the connection's %d times, want 1", conn.resets)
+	}
+
+	// An invalid DSN is ignored.
+	const bogus = "secret:pw@tcp(127.0.0.1:3306)"
+	writeDSN(bogus)
+	if _, err := c.reload(); err == nil {
+		t.Error("reload() of an invalid DSN succeeded")
+	} else if strings.Contains(err.Error(), "secret") {
+		t.Errorf("reload() error %q reveals the DSN", err)
+	}
+	if err := old.ResetSession(context.Background()); err != nil {
+		t.Errorf("ResetSession() after an invalid DSN = %v, want nil", err)
+	}
+
+	// Once the DSN is rotated, connections opened with the old one are
+	// discarded by the pool instead of being reused.
+	writeDSN("user:new@tcp(127.0.0.1:3306)/db")
+	if changed, err := c.reload(); err != nil || !changed {
+		t.Errorf("reload() of a rotated DSN = %v, %v; want true, nil", changed, err)
+	}
+	if err := old.ResetSession(context.Background()); err != driver.ErrBadConn {
+		t.Errorf("ResetSession() after rotation = %v, want %v", err, driver.ErrBadConn)
+	}
+	if got, want := c.dsn, "user:new@tcp(127.0.0.1:3306)/db"; got != want {
+		t.Errorf("DSN = %q, want %q", got, want)
+	}
+}
+
+func TestOpenDBFromEnv(t *testing.T) {
+	defer flagsaver.Save().MustRestore()
+	const env = "TRILLIAN_TEST_MYSQL_URI"
+	os.Unsetenv(env)
+	if err := flag.Set("mysql_uri_env", env); err != nil {
+		t.Fatalf("Failed to set flag: %v", err)
+	}
+	if _, err := openDB(); err == nil {
+		t.Error("openDB() with an unset environment variable succeeded")
+	}
+}
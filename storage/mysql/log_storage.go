@@ -18,8 +18,10 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"sync"
@@ -27,13 +29,16 @@ import (
 
 	"github.com/go-sql-driver/mysql"
 	"github.com/golang/glog"
+	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/requestid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -43,6 +48,8 @@ const (
 
 	insertLeafDataSQL      = "INSERT INTO LeafData(TreeId,LeafIdentityHash,LeafValue,ExtraData,QueueTimestampNanos) VALUES" + valuesPlaceholder5
 	insertSequencedLeafSQL = "INSERT INTO SequencedLeafData(TreeId,LeafIdentityHash,MerkleLeafHash,SequenceNumber,IntegrateTimestampNanos) VALUES"
+	insertLeafKeySQL       = "INSERT INTO LeafKey(TreeId,LeafKey,SequenceNumber) VALUES(?,?,?)"
+	insertTombstoneSQL     = "INSERT INTO Tombstone(TreeId,LeafIdentityHash,LeafIndex) VALUES(?,?,?)"
 
 	selectNonDeletedTreeIDByTypeAndStateSQL = `
 		SELECT TreeId FROM Trees
@@ -54,12 +61,47 @@ const (
 	selectLatestSignedLogRootSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature
 			FROM TreeHead WHERE TreeId=?
 			ORDER BY TreeHeadTimestamp DESC LIMIT 1`
+	selectSignedLogRootAtSizeSQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature
+			FROM TreeHead WHERE TreeId=? AND TreeSize=?
+			ORDER BY TreeHeadTimestamp DESC LIMIT 1`
+	selectSignedLogRootHistorySQL = `SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature
+			FROM TreeHead WHERE TreeId=?
+			ORDER BY TreeHeadTimestamp DESC LIMIT ?`
+	selectIntegratedRootSQL = "SELECT TreeHeadTimestamp,TreeSize,RootHash,TreeRevision FROM IntegratedRoot WHERE TreeId=?"
+	// Only replaces older roots, so that a concurrent integration at the same
+	// revision updates no row, and then fails to insert one.
+	updateIntegratedRootSQL = `UPDATE IntegratedRoot SET TreeHeadTimestamp=?,TreeSize=?,RootHash=?,TreeRevision=?
+			WHERE TreeId=? AND TreeRevision<?`
+	insertIntegratedRootSQL = "INSERT INTO IntegratedRoot(TreeId,TreeHeadTimestamp,TreeSize,RootHash,TreeRevision) VALUES(?,?,?,?,?)"
 
 	selectLeavesByRangeSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData,l.QueueTimestampNanos,s.IntegrateTimestampNanos
 			FROM LeafData l,SequencedLeafData s
 			WHERE l.LeafIdentityHash = s.LeafIdentityHash
 			AND s.SequenceNumber >= ? AND s.SequenceNumber < ? AND l.TreeId = ? AND s.TreeId = l.TreeId` + orderBySequenceNumberSQL
 
+	// Leaves with (LeafKey, SequenceNumber) >= (?, ?) and SequenceNumber < ?,
+	// optionally followed by leafKeyEndSQL, then by orderByLeafKeySQL.
+	selectLeavesByKeyRangeSQL = `SELECT k.LeafKey,s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData,l.QueueTimestampNanos,s.IntegrateTimestampNanos
+			FROM LeafKey k,SequencedLeafData s,LeafData l
+			WHERE k.TreeId = ? AND s.TreeId = k.TreeId AND s.SequenceNumber = k.SequenceNumber
+			AND l.TreeId = s.TreeId AND l.LeafIdentityHash = s.LeafIdentityHash
+			AND (k.LeafKey > ? OR (k.LeafKey = ? AND k.SequenceNumber >= ?)) AND k.SequenceNumber < ?`
+	leafKeyEndSQL     = " AND k.LeafKey < ?"
+	orderByLeafKeySQL = " ORDER BY k.LeafKey, k.SequenceNumber LIMIT ?"
+
+	// Leaf indices in [?, ?) deleted by tombstones sequenced after them and
+	// before ?.
+	selectTombstonedIndicesSQL = `SELECT DISTINCT t.LeafIndex
+			FROM Tombstone t,SequencedLeafData s
+			WHERE t.TreeId = ? AND s.TreeId = t.TreeId AND s.LeafIdentityHash = t.LeafIdentityHash
+			AND t.LeafIndex >= ? AND t.LeafIndex < ? AND s.SequenceNumber > t.LeafIndex AND s.SequenceNumber < ?
+			ORDER BY t.LeafIndex`
+
+	selectUnsequencedCountSQL        = "SELECT COUNT(*) FROM Unsequenced WHERE TreeId = ?"
+	selectLimitedUnsequencedCountSQL = "SELECT COUNT(*) FROM (SELECT 1 FROM Unsequenced WHERE TreeId = ? LIMIT ?) AS Pending"
+
+	selectLeafCompressionDictionariesSQL = "SELECT LeafCompressionDictionaries FROM Trees WHERE TreeId = ?"
+
 	// These statements need to be expanded to provide the correct number of parameter placeholders.
 	selectLeavesByIndexSQL = `SELECT s.MerkleLeafHash,l.LeafIdentityHash,l.LeafValue,s.SequenceNumber,l.ExtraData,l.QueueTimestampNanos,s.IntegrateTimestampNanos
 			FROM LeafData l,SequencedLeafData s
@@ -78,12 +120,25 @@ const (
 			FROM LeafData l LEFT JOIN SequencedLeafData s ON (l.LeafIdentityHash = s.LeafIdentityHash AND l.TreeID = s.TreeID)
 			WHERE l.LeafIdentityHash IN (` + placeholderSQL + `) AND l.TreeId = ?`
 
+	replaceQuarantinedLeafSQL = `REPLACE INTO QuarantinedLeaves(TreeId,LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,QuarantineTimestampNanos,Error)
+			VALUES(?,?,?,?,?,?)`
+	selectQuarantinedLeavesSQL = `SELECT q.LeafIdentityHash,q.MerkleLeafHash,q.QueueTimestampNanos,q.QuarantineTimestampNanos,q.Error,l.LeafValue,l.ExtraData
+			FROM QuarantinedLeaves q LEFT JOIN LeafData l ON (q.LeafIdentityHash = l.LeafIdentityHash AND q.TreeId = l.TreeId)
+			WHERE q.TreeId=?
+			ORDER BY q.QuarantineTimestampNanos,q.LeafIdentityHash LIMIT ?`
+	selectQuarantinedLeafSQL = "SELECT MerkleLeafHash,QueueTimestampNanos FROM QuarantinedLeaves WHERE TreeId=? AND LeafIdentityHash=?"
+	deleteQuarantinedLeafSQL = "DELETE FROM QuarantinedLeaves WHERE TreeId=? AND LeafIdentityHash=?"
+
 	// Same as above except with leaves ordered by sequence so we only incur this cost when necessary
 	orderBySequenceNumberSQL                     = " ORDER BY s.SequenceNumber"
 	selectLeavesByMerkleHashOrderedBySequenceSQL = selectLeavesByMerkleHashSQL + orderBySequenceNumberSQL
 
 	// Error code returned by driver when inserting a duplicate row
 	errNumDuplicate = 1062
+	// Error codes returned by driver when a lock wait times out, or a
+	// transaction is rolled back to break a deadlock
+	errNumLockWaitTimeout = 1205
+	errNumDeadlock        = 1213
 
 	logIDLabel = "logid"
 )
@@ -104,6 +159,8 @@ var (
 	dequeueLatency          monitoring.Histogram
 	dequeueSelectLatency    monitoring.Histogram
 	dequeueRemoveLatency    monitoring.Histogram
+
+	leafCompressionRatio monitoring.Histogram
 )
 
 func createMetrics(mf monitoring.MetricFactory) {
@@ -120,6 +177,8 @@ func createMetrics(mf monitoring.MetricFactory) {
 	dequeueLatency = mf.NewHistogram("mysql_dequeue_leaves_latency", "Latency of dequeue leaves operation in seconds", logIDLabel)
 	dequeueSelectLatency = mf.NewHistogram("mysql_dequeue_leaves_latency_select", "Latency of selection part of dequeue leaves operation in seconds", logIDLabel)
 	dequeueRemoveLatency = mf.NewHistogram("mysql_dequeue_leaves_latency_remove", "Latency of removal part of dequeue leaves operation in seconds", logIDLabel)
+
+	leafCompressionRatio = mf.NewHistogramWithBuckets("mysql_leaf_compression_ratio", "Stored size of the value and extra data of leaves of trees with leaf compression, as a percentage of their original size", monitoring.PercentileBuckets(5), logIDLabel)
 }
 
 func labelForTX(t *logTreeTX) string {
@@ -134,11 +193,34 @@ type mySQLLogStorage struct {
 	*mySQLTreeStorage
 	admin         storage.AdminStorage
 	metricFactory monitoring.MetricFactory
+	opts          LogStorageOptions
+}
+
+// LogStorageOptions are tuning, experimental and workaround options for the
+// MySQL log storage.
+type LogStorageOptions struct {
+	// SubtreeWriteBatch, if positive, makes integrations which write more
+	// subtrees than this write them in separately committed transactions of
+	// at most this many subtrees each, before the transaction storing the new
+	// root. This keeps transactions, and so binlog events, small for large
+	// integrations, at the cost of some atomicity: an interrupted integration
+	// leaves subtrees behind, which the next one deletes.
+	SubtreeWriteBatch int
+	// CompactionRevisionHorizon is the number of revisions before the latest
+	// root of a log whose nodes CompactTreeStorage keeps, so that
+	// GetProofAtRevision can still read them. Zero keeps only the nodes read
+	// at the latest root.
+	CompactionRevisionHorizon int64
 }
 
 // NewLogStorage creates a storage.LogStorage instance for the specified MySQL URL.
 // It assumes storage.AdminStorage is backed by the same MySQL database as well.
 func NewLogStorage(db *sql.DB, mf monitoring.MetricFactory) storage.LogStorage {
+	return NewLogStorageWithOpts(db, mf, LogStorageOptions{})
+}
+
+// NewLogStorageWithOpts is like NewLogStorage, but allows passing in options.
+func NewLogStorageWithOpts(db *sql.DB, mf monitoring.MetricFactory, opts LogStorageOptions) storage.LogStorage {
 	if mf == nil {
 		mf = monitoring.InertMetricFactory{}
 	}
@@ -146,6 +228,7 @@ func NewLogStorage(db *sql.DB, mf monitoring.MetricFactory) storage.LogStorage {
 		admin:            NewAdminStorage(db),
 		mySQLTreeStorage: newTreeStorage(db),
 		metricFactory:    mf,
+		opts:             opts,
 	}
 }
 
@@ -243,15 +326,19 @@ func (m *mySQLLogStorage) beginInternal(ctx context.Context, tree *trillian.Tree
 		return nil, err
 	}
 
-	stCache := cache.NewLogSubtreeCache(defaultLogStrata, hasher)
+	stCache := cache.NewLogSubtreeCacheWithMetrics(defaultLogStrata, tree.TreeId, hasher, m.metricFactory)
 	ttx, err := m.beginTreeTx(ctx, tree, hasher.Size(), stCache)
 	if err != nil && err != storage.ErrTreeNeedsInit {
 		return nil, err
 	}
 
 	ltx := &logTreeTX{
-		treeTX: ttx,
-		ls:     m,
+		treeTX:      ttx,
+		ls:          m,
+		encoding:    tree.LogRootEncoding,
+		compressor:  storage.NewLeafCompressor(tree),
+		orderingKey: tree.LeafOrderingKey,
+		tombstones:  tree.LeafTombstones,
 	}
 	ltx.slr, err = ltx.fetchLatestRoot(ctx)
 	if err == storage.ErrTreeNeedsInit {
@@ -266,7 +353,14 @@ func (m *mySQLLogStorage) beginInternal(ctx context.Context, tree *trillian.Tree
 		return nil, err
 	}
 
-	ltx.treeTX.writeRevision = int64(ltx.root.Revision) + 1
+	if ltx.integrated, err = ltx.fetchIntegratedRoot(ctx); err != nil {
+		ttx.Rollback()
+		return nil, err
+	}
+
+	ltx.treeTX.writeRevision = int64(ltx.integratedRoot().Revision) + 1
+	ltx.treeTX.sharedCache = storage.SubtreeCacheFromContext(ctx)
+	ltx.treeTX.subtreeWriteBatch = m.opts.SubtreeWriteBatch
 	return ltx, nil
 }
 
@@ -345,11 +439,66 @@ func (m *mySQLLogStorage) QueueLeaves(ctx context.Context, tree *trillian.Tree,
 	return ret, nil
 }
 
+// compressLeaf returns the leaf value and extra data of leaf to store, and
+// records how well they compressed.
+func (t *logTreeTX) compressLeaf(leaf *trillian.LogLeaf) (value, extraData []byte, err error) {
+	value, extraData, err = t.compressor.CompressLeaf(leaf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if size := len(leaf.LeafValue) + len(leaf.ExtraData); t.compressor.Compresses() && size > 0 {
+		leafCompressionRatio.Observe(100*float64(len(value)+len(extraData))/float64(size), labelForTX(t))
+	}
+	return value, extraData, nil
+}
+
+// decompressLeaf replaces the stored leaf data of leaf with its original
+// contents. If the leaf data was compressed with a dictionary appended after
+// the tree was read, e.g. from a cache, the dictionaries of the tree are read
+// again and decompression is retried. They are read outside the transaction,
+// whose connection may be busy with the rows of leaf.
+func (t *logTreeTX) decompressLeaf(ctx context.Context, leaf *trillian.LogLeaf) error {
+	err := t.compressor.DecompressLeaf(leaf)
+	var unknown *storage.UnknownDictionaryError
+	if !errors.As(err, &unknown) {
+		return err
+	}
+	var b []byte
+	if err := t.ls.db.QueryRowContext(ctx, selectLeafCompressionDictionariesSQL, t.treeID).Scan(&b); err != nil {
+		return fmt.Errorf("failed to read leaf compression dictionaries: %v", err)
+	}
+	ds, err := storage.UnmarshalLeafCompressionDictionaries(b)
+	if err != nil {
+		return err
+	}
+	t.compressor = t.compressor.WithDictionaries(ds)
+	return t.compressor.DecompressLeaf(leaf)
+}
+
 type logTreeTX struct {
 	treeTX
 	ls   *mySQLLogStorage
 	root types.LogRootV1
 	slr  *trillian.SignedLogRoot
+	// integrated is the root stored by StoreIntegratedRoot, if it is newer
+	// than root.
+	integrated *types.LogRootV1
+	// encoding is the serialization of the log roots of the tree.
+	encoding trillian.LogRootEncoding
+	// compressor compresses the leaf data of the tree.
+	compressor *storage.LeafCompressor
+	// orderingKey specifies the ordering key leaves of the tree are indexed
+	// by, if any.
+	orderingKey *trillian.LeafOrderingKey
+	// tombstones is set if the tombstones among the leaves of the tree are
+	// indexed.
+	tombstones bool
+	// requestIDs holds the request IDs of the leaves dequeued by this
+	// transaction, keyed by leaf identity hash.
+	requestIDs map[string]string
+	// conditions holds the queue conditions of the leaves dequeued by this
+	// transaction, keyed by leaf identity hash.
+	conditions map[string]*storagepb.QueueCondition
 }
 
 func (t *logTreeTX) ReadRevision(ctx context.Context) (int64, error) {
@@ -376,7 +525,7 @@ func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime tim
 	if t.treeType == trillian.TreeType_PREORDERED_LOG {
 		// TODO(pavelkalinnikov): Optimize this by fetching only the required
 		// fields of LogLeaf. We can avoid joining with LeafData table here.
-		return t.getLeavesByRangeInternal(ctx, int64(t.root.TreeSize), int64(limit))
+		return t.getLeavesByRangeInternal(ctx, int64(t.integratedRoot().TreeSize), int64(limit))
 	}
 
 	start := time.Now()
@@ -403,10 +552,6 @@ func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime tim
 			return nil, err
 		}
 
-		if len(leaf.LeafIdentityHash) != t.hashSizeBytes {
-			return nil, errors.New("dequeued a leaf with incorrect hash size")
-		}
-
 		leaves = append(leaves, leaf)
 		dq = append(dq, dqInfo)
 	}
@@ -437,6 +582,139 @@ func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime tim
 	return leaves, nil
 }
 
+// recordRequestID remembers the ID of the request that queued a dequeued leaf,
+// if it has one.
+func (t *logTreeTX) recordRequestID(leafIdentityHash []byte, requestID string) {
+	if requestID == "" {
+		return
+	}
+	if t.requestIDs == nil {
+		t.requestIDs = make(map[string]string)
+	}
+	t.requestIDs[string(leafIdentityHash)] = requestID
+}
+
+// QueueRequestID implements storage.QueueRequestIDReader.
+func (t *logTreeTX) QueueRequestID(leafIdentityHash []byte) string {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+	return t.requestIDs[string(leafIdentityHash)]
+}
+
+// recordQueueCondition remembers the condition a dequeued leaf was queued
+// with, as stored in the Unsequenced table, if it has one.
+func (t *logTreeTX) recordQueueCondition(leafIdentityHash, condition []byte) error {
+	if len(condition) == 0 {
+		return nil
+	}
+	var c storagepb.QueueCondition
+	if err := proto.Unmarshal(condition, &c); err != nil {
+		return fmt.Errorf("failed to unmarshal queue condition of leaf %x: %v", leafIdentityHash, err)
+	}
+	if t.conditions == nil {
+		t.conditions = make(map[string]*storagepb.QueueCondition)
+	}
+	t.conditions[string(leafIdentityHash)] = &c
+	return nil
+}
+
+// QueueCondition implements storage.QueueConditionReader.
+func (t *logTreeTX) QueueCondition(leafIdentityHash []byte) *storagepb.QueueCondition {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+	return t.conditions[string(leafIdentityHash)]
+}
+
+func (t *logTreeTX) QuarantineLeaves(ctx context.Context, leaves []*trillian.QuarantinedLeaf) error {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	for _, ql := range leaves {
+		// The leaves were removed from Unsequenced when they were dequeued.
+		queueTimestamp, err := ptypes.Timestamp(ql.Leaf.QueueTimestamp)
+		if err != nil {
+			// Keep the leaf anyway, as a bad timestamp is a reason to quarantine it.
+			queueTimestamp = time.Unix(0, 0)
+		}
+		quarantineTimestamp, err := ptypes.Timestamp(ql.QuarantineTimestamp)
+		if err != nil {
+			return fmt.Errorf("got invalid quarantine timestamp: %v", err)
+		}
+		if _, err := t.tx.ExecContext(ctx, replaceQuarantinedLeafSQL, t.treeID, ql.Leaf.LeafIdentityHash, ql.Leaf.MerkleLeafHash,
+			queueTimestamp.UnixNano(), quarantineTimestamp.UnixNano(), ql.Error); err != nil {
+			glog.Warningf("Failed to quarantine leaf: %s", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *logTreeTX) ListQuarantinedLeaves(ctx context.Context, limit int) ([]*trillian.QuarantinedLeaf, error) {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	rows, err := t.tx.QueryContext(ctx, selectQuarantinedLeavesSQL, t.treeID, limit)
+	if err != nil {
+		glog.Warningf("Failed to select quarantined leaves: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []*trillian.QuarantinedLeaf
+	for rows.Next() {
+		leaf := &trillian.LogLeaf{}
+		ql := &trillian.QuarantinedLeaf{Leaf: leaf}
+		var queueTS, quarantineTS int64
+		if err := rows.Scan(&leaf.LeafIdentityHash, &leaf.MerkleLeafHash, &queueTS, &quarantineTS, &ql.Error, &leaf.LeafValue, &leaf.ExtraData); err != nil {
+			return nil, err
+		}
+		// The leaf data is NULL if it's no longer stored.
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, err
+		}
+		if leaf.QueueTimestamp, err = ptypes.TimestampProto(time.Unix(0, queueTS)); err != nil {
+			return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
+		}
+		if ql.QuarantineTimestamp, err = ptypes.TimestampProto(time.Unix(0, quarantineTS)); err != nil {
+			return nil, fmt.Errorf("got invalid quarantine timestamp: %v", err)
+		}
+		ret = append(ret, ql)
+	}
+	return ret, rows.Err()
+}
+
+func (t *logTreeTX) RequeueQuarantinedLeaves(ctx context.Context, leafIdentityHashes [][]byte) (int, error) {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	requeued := 0
+	for _, hash := range leafIdentityHashes {
+		var merkleHash []byte
+		var queueTS int64
+		err := t.tx.QueryRowContext(ctx, selectQuarantinedLeafSQL, t.treeID, hash).Scan(&merkleHash, &queueTS)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return requeued, err
+		}
+
+		args := []interface{}{t.treeID, hash, merkleHash}
+		args = append(args, queueArgs(t.treeID, hash, time.Unix(0, queueTS))...)
+		// Requeued leaves are no longer part of a conditional batch.
+		args = append(args, requestid.FromContext(ctx), nil)
+		if _, err := t.tx.ExecContext(ctx, insertUnsequencedEntrySQL, args...); err != nil {
+			glog.Warningf("Error requeueing quarantined leaf: %s", err)
+			return requeued, err
+		}
+		res, err := t.tx.ExecContext(ctx, deleteQuarantinedLeafSQL, t.treeID, hash)
+		if err := checkResultOkAndRowCountIs(res, err, 1); err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
 // sortLeavesForInsert returns a slice containing the passed in leaves sorted
 // by LeafIdentityHash, and paired with their original positions.
 // QueueLeaves and AddSequencedLeaves use this to make the order that LeafData
@@ -465,6 +743,13 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 			return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
 		}
 	}
+	var condition []byte
+	if c := storage.QueueConditionFromContext(ctx); c != nil {
+		var err error
+		if condition, err = proto.Marshal(c); err != nil {
+			return nil, fmt.Errorf("failed to marshal queue condition: %v", err)
+		}
+	}
 	start := time.Now()
 	label := labelForTX(t)
 
@@ -480,9 +765,21 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 		if err != nil {
 			return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
 		}
-		_, err = t.tx.ExecContext(ctx, insertLeafDataSQL, t.treeID, leaf.LeafIdentityHash, leaf.LeafValue, leaf.ExtraData, qTimestamp.UnixNano())
+		deleted, isTombstone, err := t.parseTombstone(leaf)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "leaves[%d]: %v", i, err)
+		}
+		value, extraData, err := t.compressLeaf(leaf)
+		if err != nil {
+			return nil, err
+		}
+		_, err = t.tx.ExecContext(ctx, insertLeafDataSQL, t.treeID, leaf.LeafIdentityHash, value, extraData, qTimestamp.UnixNano())
 		insertDuration := time.Since(leafStart)
 		observe(queueInsertLeafLatency, insertDuration, label)
+		if isDuplicateErr(err) && condition != nil {
+			// The batch can't be appended as a whole.
+			return nil, status.Errorf(codes.FailedPrecondition, "conditional batch has leaf %x which is already queued or in the log", leaf.LeafIdentityHash)
+		}
 		if isDuplicateErr(err) {
 			// Remember the duplicate leaf, using the requested leaf for now.
 			existingLeaves[i] = leaf
@@ -494,6 +791,12 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 			glog.Warningf("Error inserting %d into LeafData: %s", i, err)
 			return nil, err
 		}
+		if isTombstone {
+			if _, err := t.tx.ExecContext(ctx, insertTombstoneSQL, t.treeID, leaf.LeafIdentityHash, deleted); err != nil {
+				glog.Warningf("Error inserting %d into Tombstone: %s", i, err)
+				return nil, err
+			}
+		}
 
 		// Create the work queue entry
 		args := []interface{}{
@@ -506,6 +809,7 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 			return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
 		}
 		args = append(args, queueArgs(t.treeID, leaf.LeafIdentityHash, queueTimestamp)...)
+		args = append(args, requestid.FromContext(ctx), condition)
 		_, err = t.tx.ExecContext(
 			ctx,
 			insertUnsequencedEntrySQL,
@@ -596,6 +900,20 @@ func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.L
 		if got, want := len(leaf.LeafIdentityHash), t.hashSizeBytes; got != want {
 			return nil, status.Errorf(codes.FailedPrecondition, "leaves[%d] has incorrect hash size %d, want %d", i, got, want)
 		}
+		queueNanos, integrateNanos, err := storage.SequencedLeafTimestamps(leaf, timestamp)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "leaves[%d]: %v", i, err)
+		}
+		var key []byte
+		if t.orderingKey != nil {
+			if key, err = storage.LeafOrderingKey(t.orderingKey, leaf); err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "leaves[%d]: %v", i, err)
+			}
+		}
+		deleted, isTombstone, err := t.parseTombstone(leaf)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "leaves[%d]: %v", i, err)
+		}
 
 		if _, err := t.tx.ExecContext(ctx, savepoint); err != nil {
 			glog.Errorf("Error updating savepoint: %s", err)
@@ -604,9 +922,13 @@ func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.L
 
 		res[i] = &trillian.QueuedLogLeaf{Status: ok}
 
+		value, extraData, err := t.compressLeaf(leaf)
+		if err != nil {
+			return nil, err
+		}
 		// TODO(pavelkalinnikov): Measure latencies.
-		_, err := t.tx.ExecContext(ctx, insertLeafDataSQL,
-			t.treeID, leaf.LeafIdentityHash, leaf.LeafValue, leaf.ExtraData, timestamp.UnixNano())
+		_, err = t.tx.ExecContext(ctx, insertLeafDataSQL,
+			t.treeID, leaf.LeafIdentityHash, value, extraData, queueNanos)
 		// TODO(pavelkalinnikov): Detach PREORDERED_LOG integration latency metric.
 
 		// TODO(pavelkalinnikov): Support opting out from duplicates detection.
@@ -620,7 +942,7 @@ func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.L
 		}
 
 		_, err = t.tx.ExecContext(ctx, insertSequencedLeafSQL+valuesPlaceholder5,
-			t.treeID, leaf.LeafIdentityHash, leaf.MerkleLeafHash, leaf.LeafIndex, 0)
+			t.treeID, leaf.LeafIdentityHash, leaf.MerkleLeafHash, leaf.LeafIndex, integrateNanos)
 		// TODO(pavelkalinnikov): Update IntegrateTimestamp on integrating the leaf.
 
 		if isDuplicateErr(err) {
@@ -629,11 +951,25 @@ func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.L
 				glog.Errorf("Error rolling back to savepoint: %s", err)
 				return nil, err
 			}
+			continue
 		} else if err != nil {
 			glog.Errorf("Error inserting leaves[%d] into SequencedLeafData: %s", i, err)
 			return nil, err
 		}
 
+		if key != nil {
+			if _, err := t.tx.ExecContext(ctx, insertLeafKeySQL, t.treeID, key, leaf.LeafIndex); err != nil {
+				glog.Errorf("Error inserting leaves[%d] into LeafKey: %s", i, err)
+				return nil, err
+			}
+		}
+		if isTombstone {
+			if _, err := t.tx.ExecContext(ctx, insertTombstoneSQL, t.treeID, leaf.LeafIdentityHash, deleted); err != nil {
+				glog.Errorf("Error inserting leaves[%d] into Tombstone: %s", i, err)
+				return nil, err
+			}
+		}
+
 		// TODO(pavelkalinnikov): Load LeafData for conflicting entries.
 	}
 
@@ -645,6 +981,15 @@ func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.L
 	return res, nil
 }
 
+// parseTombstone returns the index of the leaf deleted by leaf, and whether it
+// is a tombstone which must be indexed.
+func (t *logTreeTX) parseTombstone(leaf *trillian.LogLeaf) (int64, bool, error) {
+	if !t.tombstones {
+		return 0, false, nil
+	}
+	return types.ParseTombstone(leaf.LeafValue)
+}
+
 func (t *logTreeTX) GetSequencedLeafCount(ctx context.Context) (int64, error) {
 	t.treeTX.mu.Lock()
 	defer t.treeTX.mu.Unlock()
@@ -705,6 +1050,9 @@ func (t *logTreeTX) GetLeavesByIndex(ctx context.Context, leaves []int64) ([]*tr
 			glog.Warningf("Failed to scan merkle leaves: %s", err)
 			return nil, err
 		}
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, err
+		}
 		var err error
 		leaf.QueueTimestamp, err = ptypes.TimestampProto(time.Unix(0, qTimestamp))
 		if err != nil {
@@ -778,6 +1126,9 @@ func (t *logTreeTX) getLeavesByRangeInternal(ctx context.Context, start, count i
 			glog.Warningf("Failed to scan merkle leaves: %s", err)
 			return nil, err
 		}
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, err
+		}
 		if leaf.LeafIndex != wantIndex {
 			if wantIndex < int64(t.root.TreeSize) {
 				return nil, fmt.Errorf("got unexpected index %d, want %d", leaf.LeafIndex, wantIndex)
@@ -803,6 +1154,127 @@ func (t *logTreeTX) getLeavesByRangeInternal(ctx context.Context, start, count i
 	return ret, nil
 }
 
+// GetLeavesByKeyRange implements storage.LeafKeyReader.
+func (t *logTreeTX) GetLeavesByKeyRange(ctx context.Context, startKey []byte, startIndex int64, endKey []byte, limit int) ([]*trillian.LogLeaf, [][]byte, error) {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	if t.orderingKey == nil {
+		return nil, nil, status.Error(codes.FailedPrecondition, "tree has no leaf_ordering_key")
+	}
+	if limit <= 0 {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "invalid limit %d, want > 0", limit)
+	}
+	if startIndex < 0 {
+		startIndex = 0
+	}
+
+	query := selectLeavesByKeyRangeSQL
+	args := []interface{}{t.treeID, startKey, startKey, startIndex, int64(t.root.TreeSize)}
+	if len(endKey) > 0 {
+		query += leafKeyEndSQL
+		args = append(args, endKey)
+	}
+	query += orderByLeafKeySQL
+	args = append(args, limit)
+	rows, err := t.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		glog.Warningf("Failed to get leaves by key range: %s", err)
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var leaves []*trillian.LogLeaf
+	var keys [][]byte
+	for rows.Next() {
+		leaf := &trillian.LogLeaf{}
+		var key []byte
+		var qTimestamp, iTimestamp int64
+		if err := rows.Scan(
+			&key,
+			&leaf.MerkleLeafHash,
+			&leaf.LeafIdentityHash,
+			&leaf.LeafValue,
+			&leaf.LeafIndex,
+			&leaf.ExtraData,
+			&qTimestamp,
+			&iTimestamp); err != nil {
+			glog.Warningf("Failed to scan merkle leaves: %s", err)
+			return nil, nil, err
+		}
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, nil, err
+		}
+		leaf.QueueTimestamp, err = ptypes.TimestampProto(time.Unix(0, qTimestamp))
+		if err != nil {
+			return nil, nil, fmt.Errorf("got invalid queue timestamp: %v", err)
+		}
+		leaf.IntegrateTimestamp, err = ptypes.TimestampProto(time.Unix(0, iTimestamp))
+		if err != nil {
+			return nil, nil, fmt.Errorf("got invalid integrate timestamp: %v", err)
+		}
+		leaves = append(leaves, leaf)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		glog.Warningf("Failed to read returned leaves: %s", err)
+		return nil, nil, err
+	}
+	return leaves, keys, nil
+}
+
+// CountUnsequenced implements storage.UnsequencedCounter.
+func (t *logTreeTX) CountUnsequenced(ctx context.Context, limit int64) (int64, error) {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	query, args := selectUnsequencedCountSQL, []interface{}{t.treeID}
+	if limit > 0 {
+		query, args = selectLimitedUnsequencedCountSQL, append(args, limit)
+	}
+	var count int64
+	if err := t.tx.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		glog.Warningf("Failed to count unsequenced leaves: %s", err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetTombstonedIndices implements storage.TombstoneReader.
+func (t *logTreeTX) GetTombstonedIndices(ctx context.Context, start, count int64) ([]int64, error) {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	if !t.tombstones {
+		return nil, status.Error(codes.FailedPrecondition, "tree has no leaf_tombstones")
+	}
+	if start < 0 || count <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid range start=%d, count=%d", start, count)
+	}
+
+	rows, err := t.tx.QueryContext(ctx, selectTombstonedIndicesSQL, t.treeID, start, start+count, int64(t.root.TreeSize))
+	if err != nil {
+		glog.Warningf("Failed to get tombstoned indices: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indices []int64
+	for rows.Next() {
+		var index int64
+		if err := rows.Scan(&index); err != nil {
+			glog.Warningf("Failed to scan tombstoned index: %s", err)
+			return nil, err
+		}
+		indices = append(indices, index)
+	}
+	if err := rows.Err(); err != nil {
+		glog.Warningf("Failed to read tombstoned indices: %s", err)
+		return nil, err
+	}
+	return indices, nil
+}
+
 func (t *logTreeTX) GetLeavesByHash(ctx context.Context, leafHashes [][]byte, orderBySequence bool) ([]*trillian.LogLeaf, error) {
 	t.treeTX.mu.Lock()
 	defer t.treeTX.mu.Unlock()
@@ -848,14 +1320,64 @@ func (t *logTreeTX) fetchLatestRoot(ctx context.Context) (*trillian.SignedLogRoo
 		// It's possible there are no roots for this tree yet
 		return nil, storage.ErrTreeNeedsInit
 	}
+	return t.signedLogRoot(timestamp, treeSize, treeRevision, rootHash, rootSignatureBytes)
+}
 
-	// Put logRoot back together. Fortunately LogRoot has a deterministic serialization.
+func (t *logTreeTX) SignedLogRootHistory(ctx context.Context, limit int) ([]*trillian.SignedLogRoot, error) {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	rows, err := t.tx.QueryContext(ctx, selectSignedLogRootHistorySQL, t.treeID, limit)
+	if err != nil {
+		glog.Warningf("Failed to select signed root history: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []*trillian.SignedLogRoot
+	for rows.Next() {
+		var timestamp, treeSize, treeRevision int64
+		var rootHash, rootSignatureBytes []byte
+		if err := rows.Scan(&timestamp, &treeSize, &rootHash, &treeRevision, &rootSignatureBytes); err != nil {
+			return nil, err
+		}
+		root, err := t.signedLogRoot(timestamp, treeSize, treeRevision, rootHash, rootSignatureBytes)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, root)
+	}
+	return ret, rows.Err()
+}
+
+func (t *logTreeTX) SignedLogRootAtSize(ctx context.Context, treeSize int64) (*trillian.SignedLogRoot, error) {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	var timestamp, size, treeRevision int64
+	var rootHash, rootSignatureBytes []byte
+	err := t.tx.QueryRowContext(ctx, selectSignedLogRootAtSizeSQL, t.treeID, treeSize).Scan(
+		&timestamp, &size, &rootHash, &treeRevision, &rootSignatureBytes)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		glog.Warningf("Failed to select signed root at size %d: %s", treeSize, err)
+		return nil, err
+	}
+	return t.signedLogRoot(timestamp, size, treeRevision, rootHash, rootSignatureBytes)
+}
+
+// signedLogRoot puts a SignedLogRoot back together from the columns of a
+// TreeHead row.
+func (t *logTreeTX) signedLogRoot(timestamp, treeSize, treeRevision int64, rootHash, rootSignatureBytes []byte) (*trillian.SignedLogRoot, error) {
+	// Fortunately LogRoot has a deterministic serialization.
 	logRoot, err := (&types.LogRootV1{
 		RootHash:       rootHash,
 		TimestampNanos: uint64(timestamp),
 		Revision:       uint64(treeRevision),
 		TreeSize:       uint64(treeSize),
-	}).MarshalBinary()
+	}).Marshal(t.encoding)
 	if err != nil {
 		return nil, err
 	}
@@ -892,7 +1414,81 @@ func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, root *trillian.Signe
 	if err != nil {
 		glog.Warningf("Failed to store signed root: %s", err)
 	}
+	if int64(logRoot.Revision) == t.treeTX.writeRevision {
+		t.treeTX.writesRoot = true
+	}
+
+	return checkResultOkAndRowCountIs(res, err, 1)
+}
+
+// fetchIntegratedRoot reads the root stored by StoreIntegratedRoot from the
+// DB, and returns it if it is newer than the latest SignedLogRoot.
+func (t *logTreeTX) fetchIntegratedRoot(ctx context.Context) (*types.LogRootV1, error) {
+	var timestamp, treeSize, treeRevision int64
+	var rootHash []byte
+	err := t.tx.QueryRowContext(ctx, selectIntegratedRootSQL, t.treeID).Scan(&timestamp, &treeSize, &rootHash, &treeRevision)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		glog.Warningf("Failed to select integrated root: %s", err)
+		return nil, err
+	case uint64(treeRevision) <= t.root.Revision:
+		return nil, nil
+	}
+	return &types.LogRootV1{
+		RootHash:       rootHash,
+		TimestampNanos: uint64(timestamp),
+		Revision:       uint64(treeRevision),
+		TreeSize:       uint64(treeSize),
+	}, nil
+}
+
+// integratedRoot returns the root of the tree as of its latest integration.
+func (t *logTreeTX) integratedRoot() *types.LogRootV1 {
+	if t.integrated != nil {
+		return t.integrated
+	}
+	return &t.root
+}
 
+// LatestIntegratedRoot implements storage.IntegratedRootStore.
+func (t *logTreeTX) LatestIntegratedRoot(ctx context.Context) (*types.LogRootV1, error) {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	if t.slr == nil {
+		return nil, storage.ErrTreeNeedsInit
+	}
+	root := *t.integratedRoot()
+	return &root, nil
+}
+
+// StoreIntegratedRoot implements storage.IntegratedRootStore.
+func (t *logTreeTX) StoreIntegratedRoot(ctx context.Context, root *types.LogRootV1) error {
+	t.treeTX.mu.Lock()
+	defer t.treeTX.mu.Unlock()
+
+	if got, want := int64(root.Revision), t.treeTX.writeRevision; got != want {
+		return fmt.Errorf("integrated root has revision %d, want write revision %d", got, want)
+	}
+	t.treeTX.writesRoot = true
+	res, err := t.tx.ExecContext(ctx, updateIntegratedRootSQL,
+		root.TimestampNanos, root.TreeSize, root.RootHash, root.Revision, t.treeID, root.Revision)
+	if err != nil {
+		glog.Warningf("Failed to update integrated root: %s", err)
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n > 0 {
+		return nil
+	}
+	res, err = t.tx.ExecContext(ctx, insertIntegratedRootSQL,
+		t.treeID, root.TimestampNanos, root.TreeSize, root.RootHash, root.Revision)
+	if err != nil {
+		glog.Warningf("Failed to store integrated root: %s", err)
+	}
 	return checkResultOkAndRowCountIs(res, err, 1)
 }
 
@@ -928,6 +1524,9 @@ func (t *logTreeTX) getLeavesByHashInternal(ctx context.Context, leafHashes [][]
 			glog.Warningf("LogID: %d Scan() %s = %s", t.treeID, desc, err)
 			return nil, err
 		}
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, err
+		}
 		var err error
 		leaf.QueueTimestamp, err = ptypes.TimestampProto(time.Unix(0, queueTS))
 		if err != nil {
@@ -982,3 +1581,26 @@ func isDuplicateErr(err error) bool {
 		return false
 	}
 }
+
+// isTransientErr returns whether err may not recur if the statement which
+// failed with it is retried: a broken connection, a lock wait timeout, a
+// deadlock or a network timeout.
+func isTransientErr(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn):
+		return true
+	case errors.As(err, &mysqlErr):
+		return mysqlErr.Number == errNumLockWaitTimeout || mysqlErr.Number == errNumDeadlock
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	default:
+		return false
+	}
+}
+
+// IsTransientError implements storage.TransientErrorClassifier.
+func (t *logTreeTX) IsTransientError(err error) bool {
+	return isTransientErr(err)
+}
@@ -20,7 +20,11 @@ import (
 	"crypto"
 	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"reflect"
 	"sort"
 	"testing"
@@ -28,10 +32,12 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/google/trillian"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/testonly"
 	"github.com/google/trillian/types"
+	"github.com/google/trillian/util/requestid"
 	"github.com/kylelemons/godebug/pretty"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -39,10 +45,10 @@ import (
 	tcrypto "github.com/google/trillian/crypto"
 	ttestonly "github.com/google/trillian/testonly"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 )
 
-var allTables = []string{"Unsequenced", "TreeHead", "SequencedLeafData", "LeafData", "Subtree", "TreeControl", "Trees", "MapLeaf", "MapHead"}
+var allTables = []string{"Unsequenced", "QuarantinedLeaves", "PendingSubtrees", "TreeHead", "IntegratedRoot", "LeafKey", "Tombstone", "SequencedLeafData", "LeafData", "Subtree", "TreeControl", "TreeAttestations", "Trees", "TreeTemplates", "MapLeaf", "MapHead"}
 
 // Must be 32 bytes to match sha256 length if it was a real hash
 var dummyHash = []byte("hashxxxxhashxxxxhashxxxxhashxxxx")
@@ -371,6 +377,94 @@ func TestQueueLeaves(t *testing.T) {
 	}
 }
 
+func TestQueueLeavesCompressed(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	create := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	create.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+	tree := mustCreateTree(ctx, t, as, create)
+	s := NewLogStorage(DB, nil)
+
+	leaves := createTestLeaves(2, 20)
+	leaves[0].LeafValue = bytes.Repeat([]byte(`{"key": "value"}`), 100)
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		if _, err := tx.QueueLeaves(ctx, leaves, fakeQueueTime); err != nil {
+			t.Fatalf("Failed to queue leaves: %v", err)
+		}
+		return nil
+	})
+
+	// The compressible leaf value takes less space in the database.
+	var stored []byte
+	if err := DB.QueryRowContext(ctx, "SELECT LeafValue FROM LeafData WHERE TreeId=? AND LeafIdentityHash=?", tree.TreeId, leaves[0].LeafIdentityHash).Scan(&stored); err != nil {
+		t.Fatalf("Could not query leaf value: %v", err)
+	}
+	if got, want := len(stored), len(leaves[0].LeafValue); got >= want {
+		t.Errorf("Stored leaf value has %d bytes, want less than %d", got, want)
+	}
+
+	// Leaves are read back as queued, whether or not they were compressed.
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		got, err := tx.(*logTreeTX).getLeafDataByIdentityHash(ctx, [][]byte{leaves[0].LeafIdentityHash, leaves[1].LeafIdentityHash})
+		if err != nil {
+			t.Fatalf("getLeafDataByIdentityHash(): %v", err)
+		}
+		for _, leaf := range leaves {
+			leaf.LeafIndex = -1
+			leaf.MerkleLeafHash = []byte(dummyMerkleLeafHash)
+		}
+		leavesEquivalent(t, got, leaves)
+		return nil
+	})
+}
+
+func TestReadLeavesCompressedWithNewDictionary(t *testing.T) {
+	ctx := context.Background()
+
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	dict, err := ioutil.ReadFile("../testdata/leaf_compression.zdict")
+	if err != nil {
+		t.Fatalf("Failed to read dictionary: %v", err)
+	}
+	create := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	create.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_ZSTD_DICTIONARY
+	create.LeafCompressionDictionaries = []*trillian.LeafCompressionDictionary{{Version: 1, Dictionary: dict}}
+	staleTree := mustCreateTree(ctx, t, as, create)
+	tree, err := storage.UpdateTree(ctx, as, staleTree.TreeId, func(tree *trillian.Tree) {
+		tree.LeafCompressionDictionaries = append(tree.LeafCompressionDictionaries,
+			&trillian.LeafCompressionDictionary{Version: 2, Dictionary: append(dict, `{"key": "value"}`...)})
+	})
+	if err != nil {
+		t.Fatalf("UpdateTree(): %v", err)
+	}
+	s := NewLogStorage(DB, nil)
+
+	leaves := createTestLeaves(1, 20)
+	leaves[0].LeafValue = bytes.Repeat([]byte(`{"key": "value"}`), 100)
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		if _, err := tx.QueueLeaves(ctx, leaves, fakeQueueTime); err != nil {
+			t.Fatalf("Failed to queue leaves: %v", err)
+		}
+		return nil
+	})
+
+	// A server which read the tree before the new dictionary was appended can
+	// still read the leaves compressed with it.
+	runLogTX(s, staleTree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		got, err := tx.(*logTreeTX).getLeafDataByIdentityHash(ctx, [][]byte{leaves[0].LeafIdentityHash})
+		if err != nil {
+			t.Fatalf("getLeafDataByIdentityHash(): %v", err)
+		}
+		leaves[0].LeafIndex = -1
+		leaves[0].MerkleLeafHash = []byte(dummyMerkleLeafHash)
+		leavesEquivalent(t, got, leaves)
+		return nil
+	})
+}
+
 func TestQueueLeavesDuplicateBigBatch(t *testing.T) {
 	t.Skip("Known Issue: https://github.com/google/trillian/issues/1845")
 	ctx := context.Background()
@@ -498,6 +592,287 @@ func TestAddSequencedLeavesWithDuplicates(t *testing.T) {
 	aslt.verifySequencedLeaves(6, 4, dupLeaves)
 }
 
+func TestAddSequencedLeavesPreservesTimestamps(t *testing.T) {
+	ctx := context.Background()
+	leaves := createTestLeaves(2, 0)
+	queueTS, integrateTS := fakeQueueTime.Add(-time.Hour), fakeQueueTime.Add(-time.Minute)
+	leaves[0].QueueTimestamp = mustTimestampProto(t, queueTS)
+	leaves[0].IntegrateTimestamp = mustTimestampProto(t, integrateTS)
+
+	aslt := initAddSequencedLeavesTest(ctx, t)
+	aslt.addSequencedLeaves(leaves)
+
+	var stored []*trillian.LogLeaf
+	runLogTX(aslt.s, aslt.tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		var err error
+		stored, err = tx.GetLeavesByRange(ctx, 0, 2)
+		return err
+	})
+	if got, want := len(stored), 2; got != want {
+		t.Fatalf("GetLeavesByRange() returned %d leaves, want %d", got, want)
+	}
+	for _, tc := range []struct {
+		desc             string
+		leaf             *trillian.LogLeaf
+		queue, integrate time.Time
+	}{
+		{desc: "supplied", leaf: stored[0], queue: queueTS, integrate: integrateTS},
+		{desc: "default", leaf: stored[1], queue: fakeQueueTime, integrate: time.Unix(0, 0)},
+	} {
+		if got, want := tc.leaf.QueueTimestamp, mustTimestampProto(t, tc.queue); !proto.Equal(got, want) {
+			t.Errorf("%s: QueueTimestamp=%v, want %v", tc.desc, got, want)
+		}
+		if got, want := tc.leaf.IntegrateTimestamp, mustTimestampProto(t, tc.integrate); !proto.Equal(got, want) {
+			t.Errorf("%s: IntegrateTimestamp=%v, want %v", tc.desc, got, want)
+		}
+	}
+}
+
+func TestGetLeavesByKeyRange(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	keyed := proto.Clone(testonly.PreorderedLogTree).(*trillian.Tree)
+	keyed.LeafOrderingKey = &trillian.LeafOrderingKey{Source: trillian.LeafOrderingKey_EXTRA_DATA}
+	tree := mustCreateTree(ctx, t, as, keyed)
+	s := NewLogStorage(DB, nil)
+
+	// Keys are deliberately out of index order, and leaves 1 and 3 share one.
+	leaves := createTestLeaves(5, 0)
+	for i, key := range []string{"d", "b", "a", "b", "c"} {
+		leaves[i].ExtraData = []byte(key)
+	}
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		_, err := tx.AddSequencedLeaves(ctx, leaves, fakeQueueTime)
+		return err
+	})
+	// Leaf 4 is stored but not yet integrated.
+	mustSignAndStoreLogRoot(ctx, t, s, tree, 4)
+
+	for _, test := range []struct {
+		desc       string
+		startKey   string
+		startIndex int64
+		endKey     string
+		limit      int
+		want       []int64
+	}{
+		{desc: "all", limit: 10, want: []int64{2, 1, 3, 0}},
+		{desc: "limit", limit: 2, want: []int64{2, 1}},
+		{desc: "startKey", startKey: "b", limit: 10, want: []int64{1, 3, 0}},
+		{desc: "startIndex", startKey: "b", startIndex: 2, limit: 10, want: []int64{3, 0}},
+		{desc: "endKey", endKey: "d", limit: 10, want: []int64{2, 1, 3}},
+		{desc: "empty", startKey: "e", limit: 10},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+				r, ok := tx.(storage.LeafKeyReader)
+				if !ok {
+					t.Fatal("LogTreeTX does not implement LeafKeyReader")
+				}
+				got, keys, err := r.GetLeavesByKeyRange(ctx, []byte(test.startKey), test.startIndex, []byte(test.endKey), test.limit)
+				if err != nil {
+					t.Fatalf("GetLeavesByKeyRange(): %v", err)
+				}
+				if len(got) != len(test.want) || len(keys) != len(test.want) {
+					t.Fatalf("GetLeavesByKeyRange() returned %d leaves and %d keys, want %d", len(got), len(keys), len(test.want))
+				}
+				for i, leaf := range got {
+					if leaf.LeafIndex != test.want[i] {
+						t.Errorf("leaves[%d].LeafIndex=%d, want %d", i, leaf.LeafIndex, test.want[i])
+					}
+					if !bytes.Equal(keys[i], leaf.ExtraData) {
+						t.Errorf("ordering_keys[%d]=%q, want %q", i, keys[i], leaf.ExtraData)
+					}
+				}
+				return nil
+			})
+		})
+	}
+}
+
+func TestGetTombstonedIndices(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tombstones := proto.Clone(testonly.PreorderedLogTree).(*trillian.Tree)
+	tombstones.LeafTombstones = true
+	tree := mustCreateTree(ctx, t, as, tombstones)
+	s := NewLogStorage(DB, nil)
+
+	leaves := createTestLeaves(6, 0)
+	leaves[2].LeafValue = types.NewTombstone(0)
+	// Tombstones only delete earlier leaves.
+	leaves[3].LeafValue = types.NewTombstone(4)
+	leaves[5].LeafValue = types.NewTombstone(1)
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		_, err := tx.AddSequencedLeaves(ctx, leaves, fakeQueueTime)
+		return err
+	})
+	// Leaf 5 is stored but not yet integrated.
+	mustSignAndStoreLogRoot(ctx, t, s, tree, 5)
+
+	for _, test := range []struct {
+		desc         string
+		start, count int64
+		want         []int64
+	}{
+		{desc: "all", start: 0, count: 6, want: []int64{0}},
+		{desc: "after", start: 1, count: 5},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+				r, ok := tx.(storage.TombstoneReader)
+				if !ok {
+					t.Fatal("LogTreeTX does not implement TombstoneReader")
+				}
+				got, err := r.GetTombstonedIndices(ctx, test.start, test.count)
+				if err != nil {
+					t.Fatalf("GetTombstonedIndices(): %v", err)
+				}
+				if len(got) != len(test.want) || (len(got) > 0 && !reflect.DeepEqual(got, test.want)) {
+					t.Errorf("GetTombstonedIndices(%d, %d) = %v, want %v", test.start, test.count, got, test.want)
+				}
+				return nil
+			})
+		})
+	}
+
+	// Malformed tombstones are rejected.
+	malformed := createTestLeaves(1, 6)
+	malformed[0].LeafValue = []byte(types.TombstonePrefix)
+	err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		_, err := tx.AddSequencedLeaves(ctx, malformed, fakeQueueTime)
+		return err
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("AddSequencedLeaves(malformed tombstone) = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestCountUnsequenced(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tree := mustCreateTree(ctx, t, as, testonly.LogTree)
+	other := mustCreateTree(ctx, t, as, testonly.LogTree)
+	s := NewLogStorage(DB, nil)
+
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		_, err := tx.QueueLeaves(ctx, createTestLeaves(leavesToInsert, 20), fakeQueueTime)
+		return err
+	})
+	runLogTX(s, other, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		_, err := tx.QueueLeaves(ctx, createTestLeaves(1, 40), fakeQueueTime)
+		return err
+	})
+
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		c, ok := tx.(storage.UnsequencedCounter)
+		if !ok {
+			t.Fatal("LogTreeTX does not implement UnsequencedCounter")
+		}
+		for _, limit := range []int64{0, 1, leavesToInsert, leavesToInsert + 1} {
+			got, err := c.CountUnsequenced(ctx, limit)
+			if err != nil {
+				t.Fatalf("CountUnsequenced(%d): %v", limit, err)
+			}
+			want := int64(leavesToInsert)
+			if limit > 0 && limit < want {
+				want = limit
+			}
+			if got != want {
+				t.Errorf("CountUnsequenced(%d) = %d, want %d", limit, got, want)
+			}
+		}
+		return nil
+	})
+}
+
+func TestIntegratedRoot(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tree := mustCreateTree(ctx, t, as, testonly.LogTree)
+	s := NewLogStorage(DB, nil)
+	signer := tcrypto.NewSigner(0, ttestonly.NewSignerWithFixedSig(nil, []byte("notnil")), crypto.SHA256)
+
+	storeSigned := func(root *types.LogRootV1) {
+		runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+			slr, err := signer.SignLogRoot(root)
+			if err != nil {
+				return err
+			}
+			return tx.StoreSignedLogRoot(ctx, slr)
+		})
+	}
+	storeIntegrated := func(root *types.LogRootV1) error {
+		return s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+			return tx.(storage.IntegratedRootStore).StoreIntegratedRoot(ctx, root)
+		})
+	}
+	check := func(desc string, wantSize uint64, wantIntegrated *types.LogRootV1) {
+		t.Helper()
+		runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+			slr, err := tx.LatestSignedLogRoot(ctx)
+			if err != nil {
+				t.Fatalf("%s: LatestSignedLogRoot(): %v", desc, err)
+			}
+			var root types.LogRootV1
+			if err := root.UnmarshalBinary(slr.LogRoot); err != nil {
+				t.Fatalf("%s: UnmarshalBinary(): %v", desc, err)
+			}
+			if root.TreeSize != wantSize {
+				t.Errorf("%s: LatestSignedLogRoot() has size %d, want %d", desc, root.TreeSize, wantSize)
+			}
+			got, err := tx.(storage.IntegratedRootStore).LatestIntegratedRoot(ctx)
+			if err != nil {
+				t.Fatalf("%s: LatestIntegratedRoot(): %v", desc, err)
+			}
+			if !reflect.DeepEqual(got, wantIntegrated) {
+				t.Errorf("%s: LatestIntegratedRoot() = %+v, want %+v", desc, got, wantIntegrated)
+			}
+			if rev, err := tx.WriteRevision(ctx); err != nil || rev != int64(wantIntegrated.Revision)+1 {
+				t.Errorf("%s: WriteRevision() = %d, %v, want %d", desc, rev, err, wantIntegrated.Revision+1)
+			}
+			return nil
+		})
+	}
+
+	signed := &types.LogRootV1{TimestampNanos: 1, RootHash: []byte{0}}
+	storeSigned(signed)
+	check("initial", 0, signed)
+
+	integrated := &types.LogRootV1{TimestampNanos: 2, TreeSize: 10, RootHash: []byte{1}, Revision: 1}
+	if err := storeIntegrated(&types.LogRootV1{TimestampNanos: 2, Revision: 2}); err == nil {
+		t.Error("StoreIntegratedRoot() at a revision other than the write revision returned err = nil")
+	}
+	if err := storeIntegrated(integrated); err != nil {
+		t.Fatalf("StoreIntegratedRoot(): %v", err)
+	}
+	check("integrated", 0, integrated)
+
+	integrated = &types.LogRootV1{TimestampNanos: 3, TreeSize: 20, RootHash: []byte{2}, Revision: 2}
+	if err := storeIntegrated(integrated); err != nil {
+		t.Fatalf("StoreIntegratedRoot(): %v", err)
+	}
+	check("integrated again", 0, integrated)
+
+	// Publishing the integrated root makes it the latest root.
+	signed = &types.LogRootV1{TimestampNanos: 4, TreeSize: 20, RootHash: []byte{2}, Revision: 2}
+	storeSigned(signed)
+	check("published", 20, signed)
+}
+
+func mustTimestampProto(t *testing.T, ts time.Time) *timestamp.Timestamp {
+	t.Helper()
+	pb, err := ptypes.TimestampProto(ts)
+	if err != nil {
+		t.Fatalf("TimestampProto(%v): %v", ts, err)
+	}
+	return pb
+}
+
 // -----------------------------------------------------------------------------
 
 func TestDequeueLeavesNoneQueued(t *testing.T) {
@@ -599,6 +974,39 @@ func TestDequeueLeavesHaveQueueTimestamp(t *testing.T) {
 	}
 }
 
+func TestDequeueLeavesHaveQueueRequestID(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tree := mustCreateTree(ctx, t, as, testonly.LogTree)
+	s := NewLogStorage(DB, nil)
+
+	leaves := createTestLeaves(2, 20)
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		// Only the first leaf is queued with a request ID.
+		if _, err := tx.QueueLeaves(requestid.NewContext(ctx, "req-1"), leaves[:1], fakeDequeueCutoffTime); err != nil {
+			t.Fatalf("Failed to queue leaves: %v", err)
+		}
+		if _, err := tx.QueueLeaves(ctx, leaves[1:], fakeDequeueCutoffTime); err != nil {
+			t.Fatalf("Failed to queue leaves: %v", err)
+		}
+		return nil
+	})
+
+	runLogTX(s, tree, t, func(ctx context.Context, tx2 storage.LogTreeTX) error {
+		if _, err := tx2.DequeueLeaves(ctx, 99, fakeDequeueCutoffTime); err != nil {
+			t.Fatalf("Failed to dequeue leaves: %v", err)
+		}
+		r := tx2.(storage.QueueRequestIDReader)
+		for i, want := range []string{"req-1", ""} {
+			if got := r.QueueRequestID(leaves[i].LeafIdentityHash); got != want {
+				t.Errorf("QueueRequestID(leaves[%d]) = %q, want %q", i, got, want)
+			}
+		}
+		return nil
+	})
+}
+
 func TestDequeueLeavesTwoBatches(t *testing.T) {
 	ctx := context.Background()
 	cleanTestDB(DB)
@@ -1178,6 +1586,65 @@ func TestLatestSignedLogRoot(t *testing.T) {
 	}
 }
 
+func TestSignedLogRootHistory(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tree := mustCreateTree(ctx, t, as, testonly.LogTree)
+	s := NewLogStorage(DB, nil)
+
+	signer := tcrypto.NewSigner(tree.TreeId, ttestonly.NewSignerWithFixedSig(nil, []byte("notempty")), crypto.SHA256)
+	var roots []*trillian.SignedLogRoot
+	for i := 0; i < 3; i++ {
+		root, err := signer.SignLogRoot(&types.LogRootV1{
+			TimestampNanos: uint64(98765 + i),
+			TreeSize:       uint64(16 + i),
+			Revision:       uint64(5 + i),
+			RootHash:       []byte(dummyHash),
+		})
+		if err != nil {
+			t.Fatalf("SignLogRoot(): %v", err)
+		}
+		runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+			if err := tx.StoreSignedLogRoot(ctx, root); err != nil {
+				t.Fatalf("Failed to store signed root: %v", err)
+			}
+			return nil
+		})
+		roots = append(roots, root)
+	}
+
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		got, err := tx.SignedLogRootHistory(ctx, 2)
+		if err != nil {
+			t.Fatalf("SignedLogRootHistory(): %v", err)
+		}
+		if want := []*trillian.SignedLogRoot{roots[2], roots[1]}; len(got) != len(want) || !proto.Equal(got[0], want[0]) || !proto.Equal(got[1], want[1]) {
+			t.Fatalf("SignedLogRootHistory() = %v, want %v", got, want)
+		}
+		return nil
+	})
+
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		for _, test := range []struct {
+			size int64
+			want *trillian.SignedLogRoot
+		}{
+			{size: 17, want: roots[1]},
+			{size: 20},
+		} {
+			got, err := tx.SignedLogRootAtSize(ctx, test.size)
+			if err != nil {
+				t.Fatalf("SignedLogRootAtSize(%d): %v", test.size, err)
+			}
+			if !proto.Equal(got, test.want) {
+				t.Errorf("SignedLogRootAtSize(%d) = %v, want %v", test.size, got, test.want)
+			}
+		}
+		return nil
+	})
+}
+
 func TestDuplicateSignedLogRoot(t *testing.T) {
 	ctx := context.Background()
 	cleanTestDB(DB)
@@ -1536,3 +2003,31 @@ func logTree(logID int64) *trillian.Tree {
 		HashStrategy: trillian.HashStrategy_RFC6962_SHA256,
 	}
 }
+
+func TestIsTransientErr(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		err  error
+		want bool
+	}{
+		{desc: "badConn", err: driver.ErrBadConn, want: true},
+		{desc: "invalidConn", err: mysql.ErrInvalidConn, want: true},
+		{desc: "lockWaitTimeout", err: &mysql.MySQLError{Number: errNumLockWaitTimeout}, want: true},
+		{desc: "deadlock", err: &mysql.MySQLError{Number: errNumDeadlock}, want: true},
+		{desc: "duplicate", err: &mysql.MySQLError{Number: errNumDuplicate}},
+		{desc: "ioTimeout", err: &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}, want: true},
+		{desc: "noRows", err: sql.ErrNoRows},
+		{desc: "other", err: errors.New("other")},
+	} {
+		if got := isTransientErr(test.err); got != test.want {
+			t.Errorf("%s: isTransientErr(%v) = %v, want %v", test.desc, test.err, got, test.want)
+		}
+	}
+}
+
+// timeoutError is a net.Error which timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
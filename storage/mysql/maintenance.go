@@ -0,0 +1,294 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/golang/glog"
+	"github.com/google/trillian"
+)
+
+const (
+	selectLatestTreeRevisionSQL = "SELECT MAX(TreeRevision) FROM TreeHead WHERE TreeId=?"
+	selectMapRevisionsSQL       = "SELECT MapRevision FROM MapHead WHERE TreeId=? ORDER BY MapRevision"
+	selectSubtreeRevisionsSQL   = `SELECT SubtreeId, SubtreeRevision, LENGTH(Nodes) FROM Subtree
+		 WHERE TreeId=? ORDER BY SubtreeId, SubtreeRevision`
+	deleteSubtreeRevisionSQL   = "DELETE FROM Subtree WHERE TreeId=? AND SubtreeId=? AND SubtreeRevision=?"
+	updateCompactedRevisionSQL = "UPDATE TreeControl SET CompactedRevision=GREATEST(CompactedRevision, ?) WHERE TreeId=?"
+	selectCompactedRevisionSQL = "SELECT CompactedRevision FROM TreeControl WHERE TreeId=?"
+
+	// compactionBatchSize is the number of subtree revisions deleted per
+	// transaction by compaction.
+	compactionBatchSize = 1000
+)
+
+var (
+	logTables = []string{"Subtree", "TreeHead", "IntegratedRoot", "LeafData", "SequencedLeafData", "Unsequenced"}
+	mapTables = []string{"Subtree", "MapHead", "MapLeaf"}
+)
+
+// CompactTreeStorage implements storage.TreeStorageCompactor.
+//
+// Log nodes are normally read at the revision of the latest root, as the
+// nodes needed by earlier roots never change, so only the latest revision of
+// each subtree is kept, along with those read at the CompactionRevisionHorizon
+// revisions before it by GetProofAtRevision. The revision below which nodes
+// may be missing is recorded in TreeControl, and reported by CompactedRevision.
+func (m *mySQLLogStorage) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	var latest sql.NullInt64
+	if err := m.db.QueryRowContext(ctx, selectLatestTreeRevisionSQL, tree.TreeId).Scan(&latest); err != nil {
+		return 0, err
+	}
+	var roots []int64
+	keepFrom := int64(math.MaxInt64)
+	if latest.Valid {
+		roots = []int64{latest.Int64}
+		keepFrom = latest.Int64
+		if h := m.opts.CompactionRevisionHorizon; h > 0 {
+			keepFrom -= h
+		}
+		// Readers are told before any node they could read is deleted.
+		if _, err := m.db.ExecContext(ctx, updateCompactedRevisionSQL, keepFrom, tree.TreeId); err != nil {
+			return 0, err
+		}
+	}
+	return m.compactTree(ctx, tree.TreeId, roots, keepFrom, optimize, logTables)
+}
+
+// CompactedRevision implements storage.CompactedRevisionReader.
+func (t *logTreeTX) CompactedRevision(ctx context.Context) (int64, error) {
+	var rev int64
+	if err := t.tx.QueryRowContext(ctx, selectCompactedRevisionSQL, t.treeID).Scan(&rev); err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	return rev, nil
+}
+
+// CompactTreeStorage implements storage.TreeStorageCompactor.
+//
+// Map leaves and nodes can be read at the revision of any root, so the
+// revisions of each subtree read by any of them are kept.
+func (m *mySQLMapStorage) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	rows, err := m.db.QueryContext(ctx, selectMapRevisionsSQL, tree.TreeId)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	var roots []int64
+	for rows.Next() {
+		var rev int64
+		if err := rows.Scan(&rev); err != nil {
+			return 0, err
+		}
+		roots = append(roots, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return m.compactTree(ctx, tree.TreeId, roots, math.MaxInt64, optimize, mapTables)
+}
+
+// subtreeRevision is a stored revision of a subtree.
+type subtreeRevision struct {
+	rev  int64
+	size int64
+}
+
+// compactTree deletes the subtree revisions of a tree that aren't read by the
+// roots at the given revisions, which must be in increasing order, nor at any
+// revision from keepFrom up to the latest root, and then optimizes tables if
+// optimize is set. It returns the total size of the nodes deleted.
+//
+// Subtrees are deleted in separate transactions while the tree is written. A
+// revision once superseded before all roots stays that way, as later roots
+// and subtree revisions only have higher revisions, and revisions above the
+// latest root are never deleted, so this is safe.
+func (m *mySQLTreeStorage) compactTree(ctx context.Context, treeID int64, roots []int64, keepFrom int64, optimize bool, tables []string) (int64, error) {
+	var reclaimed int64
+	if len(roots) > 0 {
+		var err error
+		if reclaimed, err = m.compactSubtrees(ctx, treeID, roots, keepFrom); err != nil {
+			return reclaimed, err
+		}
+	}
+	if optimize {
+		if err := m.optimizeTables(ctx, tables); err != nil {
+			return reclaimed, err
+		}
+	}
+	return reclaimed, nil
+}
+
+func (m *mySQLTreeStorage) compactSubtrees(ctx context.Context, treeID int64, roots []int64, keepFrom int64) (int64, error) {
+	rows, err := m.db.QueryContext(ctx, selectSubtreeRevisionsSQL, treeID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var reclaimed int64
+	var batch []deletedSubtree
+	flush := func() error {
+		n, err := m.deleteSubtrees(ctx, treeID, batch)
+		reclaimed += n
+		batch = batch[:0]
+		return err
+	}
+	var id []byte
+	var revs []subtreeRevision
+	collect := func() error {
+		for _, i := range unneededRevisions(revs, roots, keepFrom) {
+			batch = append(batch, deletedSubtree{id: id, subtreeRevision: revs[i]})
+		}
+		if len(batch) >= compactionBatchSize {
+			return flush()
+		}
+		return nil
+	}
+	for rows.Next() {
+		var next []byte
+		var r subtreeRevision
+		if err := rows.Scan(&next, &r.rev, &r.size); err != nil {
+			return reclaimed, err
+		}
+		if id != nil && string(next) != string(id) {
+			if err := collect(); err != nil {
+				return reclaimed, err
+			}
+			revs = nil
+		}
+		id = next
+		revs = append(revs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return reclaimed, err
+	}
+	if err := collect(); err != nil {
+		return reclaimed, err
+	}
+	if err := flush(); err != nil {
+		return reclaimed, err
+	}
+	return reclaimed, nil
+}
+
+// unneededRevisions returns the indices of the revisions of a subtree, given
+// in increasing order, which aren't read by any of the roots at the given
+// revisions, also in increasing order, nor at any revision from keepFrom up to
+// the latest root. A read at a revision gets the highest revision of each
+// subtree that isn't higher. Revisions higher than the latest root may belong
+// to an integration in progress, so are kept.
+func unneededRevisions(revs []subtreeRevision, roots []int64, keepFrom int64) []int {
+	if len(roots) == 0 {
+		return nil
+	}
+	var unneeded []int
+	latest := roots[len(roots)-1]
+	for i, r := range revs {
+		if r.rev > latest || i == len(revs)-1 {
+			break
+		}
+		next := revs[i+1].rev
+		// Reads from keepFrom on get r if the first of them at or above r
+		// is below the next revision.
+		first := keepFrom
+		if r.rev > first {
+			first = r.rev
+		}
+		if first < next && first <= latest {
+			continue
+		}
+		// The first root at or above r, which exists as r isn't above the
+		// latest root, reads r if it's below the next revision.
+		if j := sort.Search(len(roots), func(j int) bool { return roots[j] >= r.rev }); roots[j] < next {
+			continue
+		}
+		unneeded = append(unneeded, i)
+	}
+	return unneeded
+}
+
+// deletedSubtree is a subtree revision to be deleted.
+type deletedSubtree struct {
+	id []byte
+	subtreeRevision
+}
+
+// deleteSubtrees deletes the given subtree revisions in a single transaction,
+// and returns their total size.
+func (m *mySQLTreeStorage) deleteSubtrees(ctx context.Context, treeID int64, subtrees []deletedSubtree) (int64, error) {
+	if len(subtrees) == 0 {
+		return 0, nil
+	}
+	tx, err := m.db.BeginTx(ctx, nil /* opts */)
+	if err != nil {
+		return 0, err
+	}
+	size, err := deleteSubtreesTX(ctx, tx, treeID, subtrees)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func deleteSubtreesTX(ctx context.Context, tx *sql.Tx, treeID int64, subtrees []deletedSubtree) (int64, error) {
+	stmt, err := tx.PrepareContext(ctx, deleteSubtreeRevisionSQL)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	var size int64
+	for _, s := range subtrees {
+		res, err := stmt.ExecContext(ctx, treeID, s.id, s.rev)
+		if err != nil {
+			return 0, err
+		}
+		// Subtrees may be deleted concurrently, e.g. along with their tree.
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			size += s.size
+		}
+	}
+	return size, nil
+}
+
+// optimizeTables runs OPTIMIZE TABLE on tables, which reports failures in
+// its results rather than as errors.
+func (m *mySQLTreeStorage) optimizeTables(ctx context.Context, tables []string) error {
+	rows, err := m.db.QueryContext(ctx, "OPTIMIZE TABLE "+strings.Join(tables, ","))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, op, msgType, msgText string
+		if err := rows.Scan(&table, &op, &msgType, &msgText); err != nil {
+			return err
+		}
+		if msgType == "error" {
+			return fmt.Errorf("failed to optimize %s: %s", table, msgText)
+		}
+		glog.V(1).Infof("Optimized %s: %s: %s", table, msgType, msgText)
+	}
+	return rows.Err()
+}
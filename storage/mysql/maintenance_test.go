@@ -0,0 +1,168 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package mysql
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	stree "github.com/google/trillian/storage/tree"
+	"github.com/google/trillian/testonly"
+	"github.com/google/trillian/types"
+
+	tcrypto "github.com/google/trillian/crypto"
+	storageto "github.com/google/trillian/storage/testonly"
+)
+
+func TestUnneededRevisions(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		revs     []int64
+		roots    []int64
+		keepFrom int64 // Zero means none are kept.
+		want     []int
+	}{
+		{desc: "noRoots", revs: []int64{1, 2}},
+		{desc: "single", revs: []int64{1}, roots: []int64{1}},
+		{desc: "latestOnly", revs: []int64{1, 2, 3}, roots: []int64{3}, want: []int{0, 1}},
+		{desc: "everyRoot", revs: []int64{1, 2, 3}, roots: []int64{1, 2, 3}},
+		{desc: "betweenRoots", revs: []int64{1, 2, 3, 5}, roots: []int64{2, 4, 6}, want: []int{0}},
+		{desc: "aboveLatestRoot", revs: []int64{1, 2, 3}, roots: []int64{1}, want: nil},
+		{desc: "pendingIntegration", revs: []int64{1, 2, 3}, roots: []int64{2}, want: []int{0}},
+		{desc: "horizon", revs: []int64{1, 2, 3, 5}, roots: []int64{6}, keepFrom: 3, want: []int{0, 1}},
+		{desc: "horizonBetweenRevisions", revs: []int64{1, 3, 5}, roots: []int64{6}, keepFrom: 2, want: nil},
+		{desc: "horizonBeforeAll", revs: []int64{1, 2, 3}, roots: []int64{3}, keepFrom: -10, want: nil},
+		{desc: "horizonPendingIntegration", revs: []int64{1, 2, 3}, roots: []int64{2}, keepFrom: 1, want: nil},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			revs := make([]subtreeRevision, len(tc.revs))
+			for i, r := range tc.revs {
+				revs[i].rev = r
+			}
+			keepFrom := tc.keepFrom
+			if keepFrom == 0 {
+				keepFrom = math.MaxInt64
+			}
+			if got := unneededRevisions(revs, tc.roots, keepFrom); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unneededRevisions(%v, %v, %d) = %v, want %v", tc.revs, tc.roots, keepFrom, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLogCompactTreeStorage(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tree := mustCreateTree(ctx, t, as, storageto.LogTree)
+	s := NewLogStorage(DB, nil)
+	mustSignAndStoreLogRoot(ctx, t, s, tree, 0)
+
+	nodes := storeSubtreeRevisions(ctx, t, s, tree, 3)
+	ids := []stree.NodeID{nodes[0].NodeID}
+
+	reclaimed, err := s.(storage.TreeStorageCompactor).CompactTreeStorage(ctx, tree, true /* optimize */)
+	if err != nil {
+		t.Fatalf("CompactTreeStorage(): %v", err)
+	}
+	if reclaimed <= 0 {
+		t.Errorf("CompactTreeStorage() = %d, want > 0", reclaimed)
+	}
+	var count int
+	if err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM Subtree WHERE TreeId=?", tree.TreeId).Scan(&count); err != nil {
+		t.Fatalf("Failed to count subtrees: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Subtree has %d revisions after compaction, want 1", count)
+	}
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		got, err := tx.GetMerkleNodes(ctx, 3, ids)
+		if err != nil {
+			t.Fatalf("Failed to retrieve nodes: %s", err)
+		}
+		if err := nodesAreEqual(got, nodes); err != nil {
+			t.Fatalf("Read back different nodes from the ones stored: %s", err)
+		}
+		return nil
+	})
+
+	// Nothing is left to reclaim.
+	if reclaimed, err := s.(storage.TreeStorageCompactor).CompactTreeStorage(ctx, tree, false /* optimize */); err != nil || reclaimed != 0 {
+		t.Errorf("CompactTreeStorage() = %d, %v; want 0, nil", reclaimed, err)
+	}
+}
+
+func TestLogCompactTreeStorage_Horizon(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tree := mustCreateTree(ctx, t, as, storageto.LogTree)
+	s := NewLogStorageWithOpts(DB, nil, LogStorageOptions{CompactionRevisionHorizon: 1})
+	mustSignAndStoreLogRoot(ctx, t, s, tree, 0)
+	storeSubtreeRevisions(ctx, t, s, tree, 3)
+
+	if _, err := s.(storage.TreeStorageCompactor).CompactTreeStorage(ctx, tree, false /* optimize */); err != nil {
+		t.Fatalf("CompactTreeStorage(): %v", err)
+	}
+	// The revisions read at the latest root and the one before it are kept.
+	var count int
+	if err := DB.QueryRowContext(ctx, "SELECT COUNT(*) FROM Subtree WHERE TreeId=?", tree.TreeId).Scan(&count); err != nil {
+		t.Fatalf("Failed to count subtrees: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Subtree has %d revisions after compaction, want 2", count)
+	}
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		if got, err := tx.(storage.CompactedRevisionReader).CompactedRevision(ctx); err != nil || got != 2 {
+			t.Errorf("CompactedRevision() = %d, %v; want 2, nil", got, err)
+		}
+		return nil
+	})
+}
+
+// storeSubtreeRevisions stores n roots of tree, at revisions 1 to n, and with
+// each a new revision of the same subtree, whose nodes it returns as of the
+// last one.
+func storeSubtreeRevisions(ctx context.Context, t *testing.T, s storage.LogStorage, tree *trillian.Tree, n int64) []stree.Node {
+	t.Helper()
+	nodes := createSomeNodes(1)
+	ids := []stree.NodeID{nodes[0].NodeID}
+	signer := tcrypto.NewSigner(0, testonly.NewSignerWithFixedSig(nil, []byte("notnil")), crypto.SHA256)
+	for rev := int64(1); rev <= n; rev++ {
+		err := s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+			if _, err := tx.GetMerkleNodes(ctx, rev-1, ids); err != nil {
+				return fmt.Errorf("failed to read nodes: %v", err)
+			}
+			nodes[0].Hash = []byte{byte(rev)}
+			if err := tx.SetMerkleNodes(ctx, nodes); err != nil {
+				return fmt.Errorf("failed to store nodes: %v", err)
+			}
+			root, err := signer.SignLogRoot(&types.LogRootV1{TreeSize: uint64(rev), RootHash: []byte{0}, Revision: uint64(rev)})
+			if err != nil {
+				return fmt.Errorf("error creating new SignedLogRoot: %v", err)
+			}
+			return tx.StoreSignedLogRoot(ctx, root)
+		})
+		if err != nil {
+			t.Fatalf("ReadWriteTransaction() = %v", err)
+		}
+	}
+	return nodes
+}
@@ -17,7 +17,10 @@ package mysql
 import (
 	"database/sql"
 	"flag"
+	"fmt"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/google/trillian/monitoring"
@@ -29,9 +32,18 @@ import (
 
 var (
 	mySQLURI = flag.String("mysql_uri", "test:zaphod@tcp(127.0.0.1:3306)/test", "Connection URI for MySQL database")
+	// Credentials in --mysql_uri show up in process listings, so they can be
+	// read from a file or environment variable instead.
+	mySQLURIFile         = flag.String("mysql_uri_file", "", "If set, the path of a file holding the connection URI for MySQL database, which overrides --mysql_uri. The file is re-read every --mysql_uri_file_poll_interval, so that credentials can be rotated without restarting")
+	mySQLURIFileInterval = flag.Duration("mysql_uri_file_poll_interval", 30*time.Second, "How often the file named by --mysql_uri_file is re-read. Zero means it's only read at startup")
+	mySQLURIEnv          = flag.String("mysql_uri_env", "", "If set, the name of an environment variable holding the connection URI for MySQL database, which overrides --mysql_uri. It's only read at startup")
+
 	maxConns = flag.Int("mysql_max_conns", 0, "Maximum connections to the database")
 	maxIdle  = flag.Int("mysql_max_idle_conns", -1, "Maximum idle database connections in the connection pool")
 
+	subtreeWriteBatch         = flag.Int("mysql_subtree_write_batch", 0, "If positive, integrations writing more subtrees than this write them in separate transactions of at most this many subtrees each, to keep transactions small")
+	compactionRevisionHorizon = flag.Int64("mysql_compaction_revision_horizon", 0, "Number of revisions before the latest root of a log whose Merkle nodes storage compaction keeps, so that GetProofAtRevision can read them")
+
 	mysqlMu              sync.Mutex
 	mysqlErr             error
 	mysqlDB              *sql.DB
@@ -81,7 +93,7 @@ func getMySQLDatabaseLocked() (*sql.DB, error) {
 	if mysqlDB != nil || mysqlErr != nil {
 		return mysqlDB, mysqlErr
 	}
-	db, err := OpenDB(*mySQLURI)
+	db, err := openDB()
 	if err != nil {
 		mysqlErr = err
 		return nil, err
@@ -96,8 +108,31 @@ func getMySQLDatabaseLocked() (*sql.DB, error) {
 	return db, nil
 }
 
+// openDB opens the MySQL database with the connection URI from the flags.
+func openDB() (*sql.DB, error) {
+	switch {
+	case *mySQLURIFile != "":
+		return OpenDBFromFile(*mySQLURIFile, *mySQLURIFileInterval)
+	case *mySQLURIEnv != "":
+		uri := os.Getenv(*mySQLURIEnv)
+		if uri == "" {
+			return nil, fmt.Errorf("environment variable %s named by --mysql_uri_env is empty", *mySQLURIEnv)
+		}
+		return OpenDB(uri)
+	}
+	return OpenDB(*mySQLURI)
+}
+
 func (s *mysqlProvider) LogStorage() storage.LogStorage {
-	return NewLogStorage(s.db, s.mf)
+	return NewLogStorageWithOpts(s.db, s.mf, LogStorageOptions{
+		SubtreeWriteBatch:         *subtreeWriteBatch,
+		CompactionRevisionHorizon: *compactionRevisionHorizon,
+	})
+}
+
+// CountsUnsequenced implements storage.UnsequencedCounterProvider.
+func (s *mysqlProvider) CountsUnsequenced() bool {
+	return true
 }
 
 func (s *mysqlProvider) MapStorage() storage.MapStorage {
@@ -30,14 +30,14 @@ import (
 
 const (
 	// If this statement ORDER BY clause is changed refer to the comment in removeSequencedLeaves
-	selectQueuedLeavesSQL = `SELECT LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos
+	selectQueuedLeavesSQL = `SELECT LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,RequestID,QueueCondition
 			FROM Unsequenced
 			WHERE TreeID=?
 			AND Bucket=0
 			AND QueueTimestampNanos<=?
 			ORDER BY QueueTimestampNanos,LeafIdentityHash ASC LIMIT ?`
-	insertUnsequencedEntrySQL = `INSERT INTO Unsequenced(TreeId,Bucket,LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos)
-			VALUES(?,0,?,?,?)`
+	insertUnsequencedEntrySQL = `INSERT INTO Unsequenced(TreeId,Bucket,LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,RequestID,QueueCondition)
+			VALUES(?,0,?,?,?,?,?)`
 	deleteUnsequencedSQL = "DELETE FROM Unsequenced WHERE TreeId=? AND Bucket=0 AND QueueTimestampNanos=? AND LeafIdentityHash=?"
 )
 
@@ -54,12 +54,18 @@ func (t *logTreeTX) dequeueLeaf(rows *sql.Rows) (*trillian.LogLeaf, dequeuedLeaf
 	var leafIDHash []byte
 	var merkleHash []byte
 	var queueTimestamp int64
+	var requestID string
+	var condition []byte
 
-	err := rows.Scan(&leafIDHash, &merkleHash, &queueTimestamp)
+	err := rows.Scan(&leafIDHash, &merkleHash, &queueTimestamp, &requestID, &condition)
 	if err != nil {
 		glog.Warningf("Error scanning work rows: %s", err)
 		return nil, dequeuedLeaf{}, err
 	}
+	t.recordRequestID(leafIDHash, requestID)
+	if err := t.recordQueueCondition(leafIDHash, condition); err != nil {
+		return nil, dequeuedLeaf{}, err
+	}
 
 	// Note: the LeafData and ExtraData being nil here is OK as this is only used by the
 	// sequencer. The sequencer only writes to the SequencedLeafData table and the client
@@ -32,13 +32,13 @@ import (
 
 const (
 	// If this statement ORDER BY clause is changed refer to the comment in removeSequencedLeaves
-	selectQueuedLeavesSQL = `SELECT LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,QueueID
+	selectQueuedLeavesSQL = `SELECT LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,QueueID,RequestID,QueueCondition
 			FROM Unsequenced
 			WHERE TreeID=?
 			AND Bucket=0
 			AND QueueTimestampNanos<=?
 			ORDER BY QueueTimestampNanos,LeafIdentityHash ASC LIMIT ?`
-	insertUnsequencedEntrySQL = `INSERT INTO Unsequenced(TreeId,Bucket,LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,QueueID) VALUES(?,0,?,?,?,?)`
+	insertUnsequencedEntrySQL = `INSERT INTO Unsequenced(TreeId,Bucket,LeafIdentityHash,MerkleLeafHash,QueueTimestampNanos,QueueID,RequestID,QueueCondition) VALUES(?,0,?,?,?,?,?,?)`
 	deleteUnsequencedSQL      = "DELETE FROM Unsequenced WHERE QueueID IN (<placeholder>)"
 )
 
@@ -53,12 +53,18 @@ func (t *logTreeTX) dequeueLeaf(rows *sql.Rows) (*trillian.LogLeaf, dequeuedLeaf
 	var merkleHash []byte
 	var queueTimestamp int64
 	var queueID []byte
+	var requestID string
+	var condition []byte
 
-	err := rows.Scan(&leafIDHash, &merkleHash, &queueTimestamp, &queueID)
+	err := rows.Scan(&leafIDHash, &merkleHash, &queueTimestamp, &queueID, &requestID, &condition)
 	if err != nil {
 		glog.Warningf("Error scanning work rows: %s", err)
 		return nil, nil, err
 	}
+	t.recordRequestID(leafIDHash, requestID)
+	if err := t.recordQueueCondition(leafIDHash, condition); err != nil {
+		return nil, nil, err
+	}
 
 	queueTimestampProto, err := ptypes.TimestampProto(time.Unix(0, queueTimestamp))
 	if err != nil {
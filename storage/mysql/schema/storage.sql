@@ -22,6 +22,25 @@ CREATE TABLE IF NOT EXISTS Trees(
   PublicKey             MEDIUMBLOB NOT NULL,
   Deleted               BOOLEAN,
   DeleteTimeMillis      BIGINT,
+  OrderedLeafTimestamps BOOLEAN NOT NULL DEFAULT FALSE,
+  CallerLeafIdentityHash BOOLEAN NOT NULL DEFAULT FALSE,
+  HashExtraData         BOOLEAN NOT NULL DEFAULT FALSE,
+  Namespace             VARCHAR(255) NOT NULL DEFAULT '',
+  LogRootEncoding       ENUM('TLS', 'CBOR') NOT NULL DEFAULT 'TLS',
+  TimestampGranularity  ENUM('TIMESTAMP_GRANULARITY_NANOSECOND', 'TIMESTAMP_GRANULARITY_MILLISECOND', 'TIMESTAMP_GRANULARITY_SECOND') NOT NULL DEFAULT 'TIMESTAMP_GRANULARITY_NANOSECOND',
+  HashOnly              BOOLEAN NOT NULL DEFAULT FALSE,
+  LeafCompression       ENUM('LEAF_COMPRESSION_NONE', 'LEAF_COMPRESSION_GZIP', 'LEAF_COMPRESSION_ZSTD_DICTIONARY') NOT NULL DEFAULT 'LEAF_COMPRESSION_NONE',
+  MaxTreeSize           BIGINT NOT NULL DEFAULT 0,
+  QueueWriteAhead       BOOLEAN NOT NULL DEFAULT FALSE,
+  LeafEncryption        BLOB,
+  SortByQueueTimestamp  BOOLEAN NOT NULL DEFAULT FALSE,
+  LeafOrderingKey       BLOB,
+  LeafTombstones        BOOLEAN NOT NULL DEFAULT FALSE,
+  EmptyRootHash         VARBINARY(64),
+  LeafCompressionDictionaries MEDIUMBLOB,
+  LeafIndexOffset       BIGINT NOT NULL DEFAULT 0,
+  SigningIntervalMillis BIGINT NOT NULL DEFAULT 0,
+  MaxQueueAgeMillis     BIGINT NOT NULL DEFAULT 0,
   PRIMARY KEY(TreeId)
 );
 
@@ -32,6 +51,26 @@ CREATE TABLE IF NOT EXISTS TreeControl(
   SigningEnabled          BOOLEAN NOT NULL,
   SequencingEnabled       BOOLEAN NOT NULL,
   SequenceIntervalSeconds INTEGER NOT NULL,
+  -- Revision below which compaction may have removed Merkle nodes.
+  CompactedRevision       BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY(TreeId),
+  FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
+);
+
+-- Named sets of tree parameters that trees can be created from. Template holds
+-- a serialized trillian.TreeTemplate proto.
+CREATE TABLE IF NOT EXISTS TreeTemplates(
+  Name                  VARCHAR(63) NOT NULL,
+  Template              MEDIUMBLOB NOT NULL,
+  PRIMARY KEY(Name)
+);
+
+-- Signed records of the settings trees were created with, see
+-- trillian.TreeAttestation. Written once when the tree is created.
+CREATE TABLE IF NOT EXISTS TreeAttestations(
+  TreeId                BIGINT NOT NULL,
+  Tree                  MEDIUMBLOB NOT NULL,
+  Signature             BLOB NOT NULL,
   PRIMARY KEY(TreeId),
   FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
 );
@@ -63,6 +102,20 @@ CREATE TABLE IF NOT EXISTS TreeHead(
 CREATE UNIQUE INDEX TreeHeadRevisionIdx
   ON TreeHead(TreeId, TreeRevision);
 
+-- The root of a log after its latest integration, for logs with a
+-- signing_interval, whose integrations don't all store a TreeHead. It is the
+-- state of the tree the next integration builds on while it is ahead of the
+-- latest TreeHead, and is published by storing a TreeHead of its revision.
+CREATE TABLE IF NOT EXISTS IntegratedRoot(
+  TreeId               BIGINT NOT NULL,
+  TreeHeadTimestamp    BIGINT,
+  TreeSize             BIGINT,
+  RootHash             VARBINARY(255) NOT NULL,
+  TreeRevision         BIGINT,
+  PRIMARY KEY(TreeId),
+  FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
+);
+
 -- ---------------------------------------------
 -- Log specific stuff here
 -- ---------------------------------------------
@@ -117,6 +170,31 @@ CREATE TABLE IF NOT EXISTS SequencedLeafData(
 CREATE INDEX SequencedLeafMerkleIdx
   ON SequencedLeafData(TreeId, MerkleLeafHash);
 
+-- The ordering keys of the sequenced leaves of trees with a leaf_ordering_key,
+-- so that they can be scanned in key order. Each leaf costs a row here, holding
+-- the key, besides its rows in LeafData and SequencedLeafData.
+CREATE TABLE IF NOT EXISTS LeafKey(
+  TreeId               BIGINT NOT NULL,
+  LeafKey              VARBINARY(255) NOT NULL,
+  SequenceNumber       BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY(TreeId, LeafKey, SequenceNumber),
+  FOREIGN KEY(TreeId, SequenceNumber) REFERENCES SequencedLeafData(TreeId, SequenceNumber) ON DELETE CASCADE
+);
+
+-- The tombstones queued or added to trees with leaf_tombstones, keyed by their
+-- leaf identity hash, with the index of the leaf each one deletes. Tombstones
+-- take effect once they are sequenced after the leaf they delete.
+CREATE TABLE IF NOT EXISTS Tombstone(
+  TreeId               BIGINT NOT NULL,
+  LeafIdentityHash     VARBINARY(255) NOT NULL,
+  LeafIndex            BIGINT UNSIGNED NOT NULL,
+  PRIMARY KEY(TreeId, LeafIdentityHash),
+  FOREIGN KEY(TreeId, LeafIdentityHash) REFERENCES LeafData(TreeId, LeafIdentityHash) ON DELETE CASCADE
+);
+
+CREATE INDEX TombstoneLeafIndexIdx
+  ON Tombstone(TreeId, LeafIndex);
+
 CREATE TABLE IF NOT EXISTS Unsequenced(
   TreeId               BIGINT NOT NULL,
   -- The bucket field is to allow the use of time based ring bucketed schemes if desired. If
@@ -134,9 +212,40 @@ CREATE TABLE IF NOT EXISTS Unsequenced(
   -- for batched deletes from the table when trillian_log_server and trillian_log_signer are
   -- built with the batched_queue tag.
   QueueID VARBINARY(32) DEFAULT NULL UNIQUE,
+  -- The ID of the request that queued the leaf, if any, so the log signer can
+  -- log it when the leaf is integrated.
+  RequestID VARCHAR(128) NOT NULL DEFAULT '',
+  -- The serialized storagepb.QueueCondition of the leaf, if it was queued by a
+  -- conditional QueueLeaves request.
+  QueueCondition VARBINARY(255) DEFAULT NULL,
   PRIMARY KEY (TreeId, Bucket, QueueTimestampNanos, LeafIdentityHash)
 );
 
+-- Leaves which the sequencer could not integrate are moved here from the
+-- Unsequenced table, along with the reason, so that they don't block the rest
+-- of the queue. They stay here until they are requeued.
+CREATE TABLE IF NOT EXISTS QuarantinedLeaves(
+  TreeId                   BIGINT NOT NULL,
+  LeafIdentityHash         VARBINARY(255) NOT NULL,
+  MerkleLeafHash           VARBINARY(255) NOT NULL,
+  QueueTimestampNanos      BIGINT NOT NULL,
+  QuarantineTimestampNanos BIGINT NOT NULL,
+  Error                    TEXT NOT NULL,
+  PRIMARY KEY(TreeId, LeafIdentityHash),
+  FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
+);
+
+-- Subtrees written in their own transactions by an integration which hasn't
+-- stored its root yet (see --mysql_subtree_write_batch). If the integration is
+-- interrupted, the next one deletes them before writing its own.
+CREATE TABLE IF NOT EXISTS PendingSubtrees(
+  TreeId          BIGINT NOT NULL,
+  SubtreeId       VARBINARY(255) NOT NULL,
+  SubtreeRevision INTEGER NOT NULL,
+  PRIMARY KEY(TreeId, SubtreeId),
+  FOREIGN KEY(TreeId) REFERENCES Trees(TreeId) ON DELETE CASCADE
+);
+
 
 -- ---------------------------------------------
 -- Map specific stuff here
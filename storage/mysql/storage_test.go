@@ -31,6 +31,8 @@ import (
 	"github.com/google/trillian/merkle/compact"
 	"github.com/google/trillian/merkle/rfc6962"
 	"github.com/google/trillian/storage"
+	"github.com/google/trillian/storage/cache"
+	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/storage/testdb"
 	stree "github.com/google/trillian/storage/tree"
 	"github.com/google/trillian/testonly"
@@ -154,6 +156,167 @@ func TestLogNodeRoundTripMultiSubtree(t *testing.T) {
 	}
 }
 
+// countingSubtreeCache is a storage.SubtreeCache which counts cache hits.
+type countingSubtreeCache struct {
+	*cache.MemorySubtreeCache
+	hits int
+}
+
+func (c *countingSubtreeCache) GetSubtree(treeID, revision int64, prefix []byte) *storagepb.SubtreeProto {
+	s := c.MemorySubtreeCache.GetSubtree(treeID, revision, prefix)
+	if s != nil {
+		c.hits++
+	}
+	return s
+}
+
+func TestLogNodeRoundTripSharedCache(t *testing.T) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tree := mustCreateTree(ctx, t, as, storageto.LogTree)
+	s := NewLogStorage(DB, nil)
+	mustSignAndStoreLogRoot(ctx, t, s, tree, 0)
+
+	sc := &countingSubtreeCache{MemorySubtreeCache: cache.NewMemorySubtreeCache(100)}
+	ctx = storage.NewSubtreeCacheContext(ctx, sc)
+
+	const writeRevision = int64(1)
+	nodesToStore, err := createLogNodesForTreeAtSize(t, 871, writeRevision)
+	if err != nil {
+		t.Fatalf("failed to create test tree: %v", err)
+	}
+	nodeIDsToRead := make([]stree.NodeID, len(nodesToStore))
+	for i := range nodesToStore {
+		nodeIDsToRead[i] = nodesToStore[i].NodeID
+	}
+
+	signer := tcrypto.NewSigner(0, testonly.NewSignerWithFixedSig(nil, []byte("notnil")), crypto.SHA256)
+	err = s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		if _, err := tx.GetMerkleNodes(ctx, writeRevision-1, nodeIDsToRead); err != nil {
+			return fmt.Errorf("failed to read nodes: %v", err)
+		}
+		if err := tx.SetMerkleNodes(ctx, nodesToStore); err != nil {
+			return fmt.Errorf("failed to store nodes: %v", err)
+		}
+		root, err := signer.SignLogRoot(&types.LogRootV1{TreeSize: 871, RootHash: []byte{0}, Revision: uint64(writeRevision)})
+		if err != nil {
+			return fmt.Errorf("error creating new SignedLogRoot: %v", err)
+		}
+		return tx.StoreSignedLogRoot(ctx, root)
+	})
+	if err != nil {
+		t.Fatalf("ReadWriteTransaction() = %v", err)
+	}
+	if sc.Len() == 0 {
+		t.Fatal("No subtrees cached after commit")
+	}
+
+	err = s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		readNodes, err := tx.GetMerkleNodes(ctx, writeRevision, nodeIDsToRead)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve nodes: %v", err)
+		}
+		if err := nodesAreEqual(readNodes, nodesToStore); err != nil {
+			return fmt.Errorf("read back different nodes from the ones stored: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReadWriteTransaction() = %v", err)
+	}
+	if sc.hits == 0 {
+		t.Error("GetMerkleNodes() didn't read any subtrees from the shared cache")
+	}
+}
+
+func TestLogNodeRoundTripSubtreeWriteBatch(t *testing.T) {
+	// The integration following an interrupted one deletes the subtrees it
+	// left behind, whether or not it stores its own subtrees separately.
+	for _, batch := range []int{2, 0} {
+		t.Run(fmt.Sprintf("batch%d", batch), func(t *testing.T) {
+			testLogNodeRoundTripSubtreeWriteBatch(t, batch)
+		})
+	}
+}
+
+func testLogNodeRoundTripSubtreeWriteBatch(t *testing.T, batch int) {
+	ctx := context.Background()
+	cleanTestDB(DB)
+	as := NewAdminStorage(DB)
+	tree := mustCreateTree(ctx, t, as, storageto.LogTree)
+	s := NewLogStorageWithOpts(DB, nil, LogStorageOptions{SubtreeWriteBatch: 2})
+	mustSignAndStoreLogRoot(ctx, t, s, tree, 0)
+
+	const writeRevision = int64(1)
+	nodesToStore, err := createLogNodesForTreeAtSize(t, 871, writeRevision)
+	if err != nil {
+		t.Fatalf("failed to create test tree: %v", err)
+	}
+	nodeIDsToRead := make([]stree.NodeID, len(nodesToStore))
+	for i := range nodesToStore {
+		nodeIDsToRead[i] = nodesToStore[i].NodeID
+	}
+
+	// An integration at the same revision is interrupted after writing a
+	// subtree which the next one doesn't overwrite.
+	orphan := &storagepb.SubtreeProto{Prefix: []byte{0xff}, Depth: 8, Leaves: map[string][]byte{}}
+	err = s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		if err := tx.(*logTreeTX).storeSubtreesSeparately(ctx, []*storagepb.SubtreeProto{orphan}); err != nil {
+			t.Fatalf("storeSubtreesSeparately(): %v", err)
+		}
+		return errors.New("interrupted")
+	})
+	if err == nil {
+		t.Fatal("ReadWriteTransaction() succeeded, want interrupted")
+	}
+
+	s = NewLogStorageWithOpts(DB, nil, LogStorageOptions{SubtreeWriteBatch: batch})
+	signer := tcrypto.NewSigner(0, testonly.NewSignerWithFixedSig(nil, []byte("notnil")), crypto.SHA256)
+	err = s.ReadWriteTransaction(ctx, tree, func(ctx context.Context, tx storage.LogTreeTX) error {
+		if _, err := tx.GetMerkleNodes(ctx, writeRevision-1, nodeIDsToRead); err != nil {
+			return fmt.Errorf("failed to read nodes: %v", err)
+		}
+		if err := tx.SetMerkleNodes(ctx, nodesToStore); err != nil {
+			return fmt.Errorf("failed to store nodes: %v", err)
+		}
+		root, err := signer.SignLogRoot(&types.LogRootV1{TreeSize: 871, RootHash: []byte{0}, Revision: uint64(writeRevision)})
+		if err != nil {
+			return fmt.Errorf("error creating new SignedLogRoot: %v", err)
+		}
+		return tx.StoreSignedLogRoot(ctx, root)
+	})
+	if err != nil {
+		t.Fatalf("ReadWriteTransaction() = %v", err)
+	}
+
+	runLogTX(s, tree, t, func(ctx context.Context, tx storage.LogTreeTX) error {
+		readNodes, err := tx.GetMerkleNodes(ctx, writeRevision, nodeIDsToRead)
+		if err != nil {
+			t.Fatalf("Failed to retrieve nodes: %s", err)
+		}
+		if err := nodesAreEqual(readNodes, nodesToStore); err != nil {
+			t.Fatalf("Read back different nodes from the ones stored: %s", err)
+		}
+		return nil
+	})
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{query: "SELECT COUNT(*) FROM Subtree WHERE TreeId=? AND SubtreeId=?", args: []interface{}{tree.TreeId, orphan.Prefix}},
+		{query: "SELECT COUNT(*) FROM PendingSubtrees WHERE TreeId=?", args: []interface{}{tree.TreeId}},
+	} {
+		var count int
+		if err := DB.QueryRowContext(ctx, q.query, q.args...).Scan(&count); err != nil {
+			t.Fatalf("%s: %v", q.query, err)
+		}
+		if count != 0 {
+			t.Errorf("%s = %d, want 0", q.query, count)
+		}
+	}
+}
+
 func forceWriteRevision(rev int64, tx storage.TreeTX) {
 	mtx, ok := tx.(*logTreeTX)
 	if !ok {
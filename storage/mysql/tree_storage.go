@@ -27,6 +27,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
 	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/cache"
 	"github.com/google/trillian/storage/storagepb"
 	"github.com/google/trillian/storage/tree"
@@ -37,6 +38,17 @@ const (
 	insertSubtreeMultiSQL = `INSERT INTO Subtree(TreeId, SubtreeId, Nodes, SubtreeRevision) ` + placeholderSQL
 	insertTreeHeadSQL     = `INSERT INTO TreeHead(TreeId,TreeHeadTimestamp,TreeSize,RootHash,TreeRevision,RootSignature)
 		 VALUES(?,?,?,?,?,?)`
+	insertPendingSubtreeMultiSQL = `INSERT INTO PendingSubtrees(TreeId, SubtreeId, SubtreeRevision) ` + placeholderSQL
+	countRootsFromRevisionSQL    = `SELECT
+		(SELECT COUNT(*) FROM TreeHead WHERE TreeId=? AND TreeRevision>=?) +
+		(SELECT COUNT(*) FROM IntegratedRoot WHERE TreeId=? AND TreeRevision>=?)`
+	deletePendingSubtreesSQL = "DELETE FROM PendingSubtrees WHERE TreeId=?"
+	// Deletes the subtrees left behind by an interrupted integration.
+	deleteOrphanSubtreesSQL = `DELETE Subtree FROM Subtree INNER JOIN PendingSubtrees
+		ON Subtree.TreeId = PendingSubtrees.TreeId
+		AND Subtree.SubtreeId = PendingSubtrees.SubtreeId
+		AND Subtree.SubtreeRevision = PendingSubtrees.SubtreeRevision
+		WHERE PendingSubtrees.TreeId = ?`
 
 	selectSubtreeSQL = `
  SELECT x.SubtreeId, x.MaxRevision, Subtree.Nodes
@@ -169,8 +181,16 @@ type treeTX struct {
 	treeType      trillian.TreeType
 	hashSizeBytes int
 	subtreeCache  *cache.SubtreeCache
+	// sharedCache, if set, caches committed subtrees across transactions.
+	// It's only used by log transactions.
+	sharedCache   storage.SubtreeCache
 	dirty         []*storagepb.SubtreeProto
 	writeRevision int64
+	// subtreeWriteBatch, if positive, is the maximum number of subtrees
+	// written by the transaction itself; see storeSubtreesSeparately.
+	subtreeWriteBatch int
+	// writesRoot is set once the transaction stores a root at writeRevision.
+	writesRoot bool
 }
 
 func (t *treeTX) getSubtree(ctx context.Context, treeRevision int64, nodeID tree.NodeID) (*storagepb.SubtreeProto, error) {
@@ -195,6 +215,45 @@ func (t *treeTX) getSubtreesWithLock(ctx context.Context, rev int64, ids []tree.
 }
 
 func (t *treeTX) getSubtrees(ctx context.Context, treeRevision int64, nodeIDs []tree.NodeID) ([]*storagepb.SubtreeProto, error) {
+	if t.sharedCache == nil || t.writeRevision <= 0 {
+		return t.readSubtrees(ctx, treeRevision, nodeIDs, nil)
+	}
+
+	// Reads at or after the revision being written see the latest committed
+	// revision, unless a concurrent transaction commits the same revision, in
+	// which case this one will fail.
+	cacheRev := treeRevision
+	if latest := t.writeRevision - 1; cacheRev > latest {
+		cacheRev = latest
+	}
+	var ret []*storagepb.SubtreeProto
+	var missing []tree.NodeID
+	for _, id := range nodeIDs {
+		key, err := subtreeKey(id)
+		if err != nil {
+			return nil, err
+		}
+		if s := t.sharedCache.GetSubtree(t.treeID, cacheRev, key); s != nil {
+			ret = append(ret, s)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	read, err := t.readSubtrees(ctx, treeRevision, missing, func(s *storagepb.SubtreeProto, rev int64) {
+		if rev <= cacheRev {
+			t.sharedCache.SetSubtree(t.treeID, cacheRev, s)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(ret, read...), nil
+}
+
+// readSubtrees reads the subtrees with the given IDs from the database, as of
+// treeRevision. If set, onRead is called with each subtree read and the
+// revision it was written at.
+func (t *treeTX) readSubtrees(ctx context.Context, treeRevision int64, nodeIDs []tree.NodeID, onRead func(*storagepb.SubtreeProto, int64)) ([]*storagepb.SubtreeProto, error) {
 	glog.V(2).Infof("getSubtrees(len(nodeIDs)=%d)", len(nodeIDs))
 	glog.V(4).Infof("getSubtrees(")
 	if len(nodeIDs) == 0 {
@@ -255,6 +314,9 @@ func (t *treeTX) getSubtrees(ctx context.Context, treeRevision int64, nodeIDs []
 		if subtree.Prefix == nil {
 			subtree.Prefix = []byte{}
 		}
+		if onRead != nil {
+			onRead(&subtree, subtreeRev)
+		}
 		ret = append(ret, &subtree)
 
 		if glog.V(4) {
@@ -301,8 +363,12 @@ func (t *treeTX) storeSubtrees(ctx context.Context, subtrees []*storagepb.Subtre
 		return nil
 	}
 
-	// TODO(al): probably need to be able to batch this in the case where we have
-	// a really large number of subtrees to store.
+	return t.insertSubtrees(ctx, t.tx, subtrees)
+}
+
+// insertSubtrees writes subtrees at the write revision within tx, which is
+// either this transaction or one started by storeSubtreesSeparately.
+func (t *treeTX) insertSubtrees(ctx context.Context, tx *sql.Tx, subtrees []*storagepb.SubtreeProto) error {
 	args := make([]interface{}, 0, len(subtrees))
 
 	for _, s := range subtrees {
@@ -324,7 +390,7 @@ func (t *treeTX) storeSubtrees(ctx context.Context, subtrees []*storagepb.Subtre
 	if err != nil {
 		return err
 	}
-	stx := t.tx.StmtContext(ctx, tmpl)
+	stx := tx.StmtContext(ctx, tmpl)
 	defer stx.Close()
 
 	r, err := stx.ExecContext(ctx, args...)
@@ -336,6 +402,89 @@ func (t *treeTX) storeSubtrees(ctx context.Context, subtrees []*storagepb.Subtre
 	return nil
 }
 
+// storeSubtreesSeparately writes subtrees in their own transactions of at most
+// subtreeWriteBatch subtrees each, which are committed before this one. This
+// keeps the transactions of large integrations small, at the cost of
+// atomicity. Subtrees at the write revision are only read once a root at that
+// revision is stored, so the root stored by this transaction remains the
+// commit point. Until then, the subtrees are listed in the PendingSubtrees
+// table, so that those left behind by an interrupted integration are deleted
+// by the next one, which starts afresh at the same revision.
+func (t *treeTX) storeSubtreesSeparately(ctx context.Context, subtrees []*storagepb.SubtreeProto) error {
+	if err := t.separateTX(ctx, func(tx *sql.Tx) error {
+		var count int64
+		if err := tx.QueryRowContext(ctx, countRootsFromRevisionSQL, t.treeID, t.writeRevision, t.treeID, t.writeRevision).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("tree %d already has a root at revision %d", t.treeID, t.writeRevision)
+		}
+		return t.deleteOrphanSubtrees(ctx, tx)
+	}); err != nil {
+		return fmt.Errorf("failed to delete orphan subtrees: %v", err)
+	}
+
+	for len(subtrees) > 0 {
+		batch := subtrees
+		if len(batch) > t.subtreeWriteBatch {
+			batch = batch[:t.subtreeWriteBatch]
+		}
+		subtrees = subtrees[len(batch):]
+		if err := t.separateTX(ctx, func(tx *sql.Tx) error {
+			if err := t.insertPendingSubtrees(ctx, tx, batch); err != nil {
+				return err
+			}
+			return t.insertSubtrees(ctx, tx, batch)
+		}); err != nil {
+			return err
+		}
+	}
+
+	// The subtrees are no longer pending once this transaction commits.
+	_, err := t.tx.ExecContext(ctx, deletePendingSubtreesSQL, t.treeID)
+	return err
+}
+
+// deleteOrphanSubtrees deletes the subtrees left behind by an interrupted
+// storeSubtreesSeparately within tx, along with the PendingSubtrees listing
+// them.
+func (t *treeTX) deleteOrphanSubtrees(ctx context.Context, tx *sql.Tx) error {
+	if _, err := tx.ExecContext(ctx, deleteOrphanSubtreesSQL, t.treeID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, deletePendingSubtreesSQL, t.treeID)
+	return err
+}
+
+// insertPendingSubtrees lists subtrees in the PendingSubtrees table within tx.
+func (t *treeTX) insertPendingSubtrees(ctx context.Context, tx *sql.Tx, subtrees []*storagepb.SubtreeProto) error {
+	args := make([]interface{}, 0, 3*len(subtrees))
+	for _, s := range subtrees {
+		args = append(args, t.treeID, s.Prefix, t.writeRevision)
+	}
+	tmpl, err := t.ts.getStmt(ctx, insertPendingSubtreeMultiSQL, len(subtrees), "VALUES(?, ?, ?)", "(?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	stx := tx.StmtContext(ctx, tmpl)
+	defer stx.Close()
+	_, err = stx.ExecContext(ctx, args...)
+	return err
+}
+
+// separateTX runs f in a new transaction, which is committed if f succeeds.
+func (t *treeTX) separateTX(ctx context.Context, f func(*sql.Tx) error) error {
+	tx, err := t.ts.db.BeginTx(ctx, nil /* opts */)
+	if err != nil {
+		return err
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
 func checkResultOkAndRowCountIs(res sql.Result, err error, count int64) error {
 	// The Exec() might have just failed
 	if err != nil {
@@ -392,14 +541,31 @@ func (t *treeTX) Commit(ctx context.Context) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	var written []*storagepb.SubtreeProto
 	if t.writeRevision > -1 {
 		if err := t.subtreeCache.Flush(ctx, func(ctx context.Context, st []*storagepb.SubtreeProto) error {
-			return t.storeSubtrees(ctx, st)
+			written = append(written, st...)
+			return nil
 		}); err != nil {
 			glog.Warningf("TX commit flush error: %v", err)
 			return err
 		}
-		if err := t.storeSubtrees(ctx, t.dirty); err != nil {
+		written = append(written, t.dirty...)
+		store := t.storeSubtrees
+		if t.subtreeWriteBatch > 0 && len(written) > t.subtreeWriteBatch {
+			store = t.storeSubtreesSeparately
+		} else if len(written) > 0 || t.writesRoot {
+			// An interrupted integration which stored its subtrees separately
+			// may have left some behind at this revision, which this one
+			// wouldn't overwrite, so delete them whatever its size. Other
+			// transactions leave them alone, as they may belong to an
+			// integration which is still in progress.
+			if err := t.deleteOrphanSubtrees(ctx, t.tx); err != nil {
+				glog.Warningf("TX commit flush error: %v", err)
+				return fmt.Errorf("failed to delete orphan subtrees: %v", err)
+			}
+		}
+		if err := store(ctx, written); err != nil {
 			glog.Warningf("TX commit flush error: %v", err)
 			return err
 		}
@@ -409,6 +575,11 @@ func (t *treeTX) Commit(ctx context.Context) error {
 		glog.Warningf("TX commit error: %s, stack:\n%s", err, string(debug.Stack()))
 		return err
 	}
+	if t.sharedCache != nil && t.writeRevision > 0 {
+		for _, s := range written {
+			t.sharedCache.SetSubtree(t.treeID, t.writeRevision, s)
+		}
+	}
 	return nil
 }
 
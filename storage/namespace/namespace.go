@@ -0,0 +1,367 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package namespace contains storage implementations that isolate trees
+// belonging to different namespaces (i.e. tenants) from each other.
+//
+// The namespace of the caller is carried in the context, see NewContext.
+// Requests whose context has a namespace can only see trees of that
+// namespace; any other tree is reported as NotFound, exactly as if it didn't
+// exist. Requests without a namespace in their context, such as those made
+// internally by the log signer or the tree garbage collector, are not
+// restricted.
+package namespace
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type namespaceKey struct{}
+
+// NewContext returns a ctx carrying the namespace of the caller.
+func NewContext(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// FromContext returns the namespace within ctx if present, together with an
+// indication of whether a namespace was present.
+func FromContext(ctx context.Context) (string, bool) {
+	namespace, ok := ctx.Value(namespaceKey{}).(string)
+	return namespace, ok
+}
+
+// visible returns whether tree may be accessed by the caller in ctx.
+func visible(ctx context.Context, tree *trillian.Tree) bool {
+	namespace, ok := FromContext(ctx)
+	return !ok || tree.GetNamespace() == namespace
+}
+
+// notFound returns the error reported by the SQL storage implementations for
+// trees that don't exist, so callers can't tell the two cases apart.
+func notFound(treeID int64) error {
+	return status.Errorf(codes.NotFound, "tree %v not found", treeID)
+}
+
+// NewAdminStorage wraps s with an implementation that enforces the namespace
+// in the context of each call.
+//
+// Trees of other namespaces can't be read, updated or deleted, and are
+// omitted from listings. Created trees are assigned the namespace of the
+// caller; creating a tree for a different namespace fails with
+// PermissionDenied. Tree templates can be read by all callers, but only
+// modified by callers without a namespace.
+func NewAdminStorage(s storage.AdminStorage) storage.AdminStorage {
+	return &adminStorage{AdminStorage: s}
+}
+
+type adminStorage struct {
+	storage.AdminStorage
+}
+
+// Snapshot implements AdminStorage.Snapshot.
+func (s *adminStorage) Snapshot(ctx context.Context) (storage.ReadOnlyAdminTX, error) {
+	tx, err := s.AdminStorage.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshotTX{ReadOnlyAdminTX: tx}, nil
+}
+
+// ReadWriteTransaction implements AdminStorage.ReadWriteTransaction.
+func (s *adminStorage) ReadWriteTransaction(ctx context.Context, f storage.AdminTXFunc) error {
+	return s.AdminStorage.ReadWriteTransaction(ctx, func(ctx context.Context, tx storage.AdminTX) error {
+		return f(ctx, &adminTX{AdminTX: tx})
+	})
+}
+
+type snapshotTX struct {
+	storage.ReadOnlyAdminTX
+}
+
+// GetTree implements AdminReader.GetTree.
+func (t *snapshotTX) GetTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	return getTree(ctx, t.ReadOnlyAdminTX, treeID)
+}
+
+// ListTreeIDs implements AdminReader.ListTreeIDs.
+func (t *snapshotTX) ListTreeIDs(ctx context.Context, includeDeleted bool) ([]int64, error) {
+	return listTreeIDs(ctx, t.ReadOnlyAdminTX, includeDeleted)
+}
+
+// ListTrees implements AdminReader.ListTrees.
+func (t *snapshotTX) ListTrees(ctx context.Context, includeDeleted bool) ([]*trillian.Tree, error) {
+	return listTrees(ctx, t.ReadOnlyAdminTX, includeDeleted)
+}
+
+// GetTreeAttestation implements AdminReader.GetTreeAttestation.
+func (t *snapshotTX) GetTreeAttestation(ctx context.Context, treeID int64) (*trillian.TreeAttestation, error) {
+	return getTreeAttestation(ctx, t.ReadOnlyAdminTX, treeID)
+}
+
+type adminTX struct {
+	storage.AdminTX
+}
+
+// GetTree implements AdminReader.GetTree.
+func (t *adminTX) GetTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	return getTree(ctx, t.AdminTX, treeID)
+}
+
+// ListTreeIDs implements AdminReader.ListTreeIDs.
+func (t *adminTX) ListTreeIDs(ctx context.Context, includeDeleted bool) ([]int64, error) {
+	return listTreeIDs(ctx, t.AdminTX, includeDeleted)
+}
+
+// ListTrees implements AdminReader.ListTrees.
+func (t *adminTX) ListTrees(ctx context.Context, includeDeleted bool) ([]*trillian.Tree, error) {
+	return listTrees(ctx, t.AdminTX, includeDeleted)
+}
+
+// GetTreeAttestation implements AdminReader.GetTreeAttestation.
+func (t *adminTX) GetTreeAttestation(ctx context.Context, treeID int64) (*trillian.TreeAttestation, error) {
+	return getTreeAttestation(ctx, t.AdminTX, treeID)
+}
+
+// CreateTree implements AdminWriter.CreateTree.
+func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillian.Tree, error) {
+	namespace, ok := FromContext(ctx)
+	if !ok {
+		return t.AdminTX.CreateTree(ctx, tree)
+	}
+	switch tree.GetNamespace() {
+	case "", namespace:
+	default:
+		return nil, status.Errorf(codes.PermissionDenied, "cannot create tree in namespace %q", tree.Namespace)
+	}
+	tree = proto.Clone(tree).(*trillian.Tree)
+	tree.Namespace = namespace
+	return t.AdminTX.CreateTree(ctx, tree)
+}
+
+// UpdateTree implements AdminWriter.UpdateTree.
+func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(*trillian.Tree)) (*trillian.Tree, error) {
+	if _, err := t.GetTree(ctx, treeID); err != nil {
+		return nil, err
+	}
+	return t.AdminTX.UpdateTree(ctx, treeID, updateFunc)
+}
+
+// SoftDeleteTree implements AdminWriter.SoftDeleteTree.
+func (t *adminTX) SoftDeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	if _, err := t.GetTree(ctx, treeID); err != nil {
+		return nil, err
+	}
+	return t.AdminTX.SoftDeleteTree(ctx, treeID)
+}
+
+// HardDeleteTree implements AdminWriter.HardDeleteTree.
+func (t *adminTX) HardDeleteTree(ctx context.Context, treeID int64) error {
+	if _, err := t.GetTree(ctx, treeID); err != nil {
+		return err
+	}
+	return t.AdminTX.HardDeleteTree(ctx, treeID)
+}
+
+// UndeleteTree implements AdminWriter.UndeleteTree.
+func (t *adminTX) UndeleteTree(ctx context.Context, treeID int64) (*trillian.Tree, error) {
+	if _, err := t.GetTree(ctx, treeID); err != nil {
+		return nil, err
+	}
+	return t.AdminTX.UndeleteTree(ctx, treeID)
+}
+
+// CreateTreeTemplate implements AdminWriter.CreateTreeTemplate.
+func (t *adminTX) CreateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	if err := checkTemplateWrite(ctx); err != nil {
+		return err
+	}
+	return t.AdminTX.CreateTreeTemplate(ctx, tmpl)
+}
+
+// UpdateTreeTemplate implements AdminWriter.UpdateTreeTemplate.
+func (t *adminTX) UpdateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	if err := checkTemplateWrite(ctx); err != nil {
+		return err
+	}
+	return t.AdminTX.UpdateTreeTemplate(ctx, tmpl)
+}
+
+// DeleteTreeTemplate implements AdminWriter.DeleteTreeTemplate.
+func (t *adminTX) DeleteTreeTemplate(ctx context.Context, name string) error {
+	if err := checkTemplateWrite(ctx); err != nil {
+		return err
+	}
+	return t.AdminTX.DeleteTreeTemplate(ctx, name)
+}
+
+// LockTreesByDisplayName implements storage.DisplayNameLocker, if the wrapped
+// transaction does, omitting the trees of other namespaces.
+func (t *adminTX) LockTreesByDisplayName(ctx context.Context, displayName string) ([]int64, error) {
+	l, ok := t.AdminTX.(storage.DisplayNameLocker)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "storage can't lock trees by display name")
+	}
+	ids, err := l.LockTreesByDisplayName(ctx, displayName)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := FromContext(ctx); !ok {
+		return ids, nil
+	}
+	visibleIDs := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		switch _, err := t.GetTree(ctx, id); {
+		case err == nil:
+			visibleIDs = append(visibleIDs, id)
+		case status.Code(err) != codes.NotFound:
+			return nil, err
+		}
+	}
+	return visibleIDs, nil
+}
+
+// LockLeafIndexRanges implements storage.LeafIndexRangeLocker, if the wrapped
+// transaction does.
+func (t *adminTX) LockLeafIndexRanges(ctx context.Context) error {
+	l, ok := t.AdminTX.(storage.LeafIndexRangeLocker)
+	if !ok {
+		return status.Error(codes.Unimplemented, "storage can't lock leaf index ranges")
+	}
+	return l.LockLeafIndexRanges(ctx)
+}
+
+// checkTemplateWrite returns an error if the caller in ctx has a namespace, as
+// tree templates are shared by all namespaces.
+func checkTemplateWrite(ctx context.Context) error {
+	if namespace, ok := FromContext(ctx); ok {
+		return status.Errorf(codes.PermissionDenied, "cannot modify tree templates from namespace %q", namespace)
+	}
+	return nil
+}
+
+func getTree(ctx context.Context, r storage.AdminReader, treeID int64) (*trillian.Tree, error) {
+	tree, err := r.GetTree(ctx, treeID)
+	if err != nil {
+		return nil, err
+	}
+	if !visible(ctx, tree) {
+		return nil, notFound(treeID)
+	}
+	return tree, nil
+}
+
+func getTreeAttestation(ctx context.Context, r storage.AdminReader, treeID int64) (*trillian.TreeAttestation, error) {
+	if _, err := getTree(ctx, r, treeID); err != nil {
+		return nil, err
+	}
+	return r.GetTreeAttestation(ctx, treeID)
+}
+
+func listTreeIDs(ctx context.Context, r storage.AdminReader, includeDeleted bool) ([]int64, error) {
+	if _, ok := FromContext(ctx); !ok {
+		return r.ListTreeIDs(ctx, includeDeleted)
+	}
+	trees, err := listTrees(ctx, r, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int64, 0, len(trees))
+	for _, tree := range trees {
+		ids = append(ids, tree.TreeId)
+	}
+	return ids, nil
+}
+
+func listTrees(ctx context.Context, r storage.AdminReader, includeDeleted bool) ([]*trillian.Tree, error) {
+	trees, err := r.ListTrees(ctx, includeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := FromContext(ctx); !ok {
+		return trees, nil
+	}
+	visibleTrees := make([]*trillian.Tree, 0, len(trees))
+	for _, tree := range trees {
+		if visible(ctx, tree) {
+			visibleTrees = append(visibleTrees, tree)
+		}
+	}
+	return visibleTrees, nil
+}
+
+// NewLogStorage wraps s with an implementation that enforces the namespace in
+// the context of each call, failing with NotFound for trees of other
+// namespaces.
+//
+// Snapshot isn't restricted, as the active log IDs it exposes are only meant
+// for internal use by the log signer.
+func NewLogStorage(s storage.LogStorage) storage.LogStorage {
+	return &logStorage{LogStorage: s}
+}
+
+type logStorage struct {
+	storage.LogStorage
+}
+
+// SnapshotForTree implements ReadOnlyLogStorage.SnapshotForTree.
+func (s *logStorage) SnapshotForTree(ctx context.Context, tree *trillian.Tree) (storage.ReadOnlyLogTreeTX, error) {
+	if !visible(ctx, tree) {
+		return nil, notFound(tree.TreeId)
+	}
+	return s.LogStorage.SnapshotForTree(ctx, tree)
+}
+
+// ReadWriteTransaction implements LogStorage.ReadWriteTransaction.
+func (s *logStorage) ReadWriteTransaction(ctx context.Context, tree *trillian.Tree, f storage.LogTXFunc) error {
+	if !visible(ctx, tree) {
+		return notFound(tree.TreeId)
+	}
+	return s.LogStorage.ReadWriteTransaction(ctx, tree, f)
+}
+
+// QueueLeaves implements LogStorage.QueueLeaves.
+func (s *logStorage) QueueLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	if !visible(ctx, tree) {
+		return nil, notFound(tree.TreeId)
+	}
+	return s.LogStorage.QueueLeaves(ctx, tree, leaves, queueTimestamp)
+}
+
+// AddSequencedLeaves implements LogStorage.AddSequencedLeaves.
+func (s *logStorage) AddSequencedLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, timestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	if !visible(ctx, tree) {
+		return nil, notFound(tree.TreeId)
+	}
+	return s.LogStorage.AddSequencedLeaves(ctx, tree, leaves, timestamp)
+}
+
+// CompactTreeStorage implements storage.TreeStorageCompactor, if the wrapped
+// storage does.
+func (s *logStorage) CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error) {
+	if !visible(ctx, tree) {
+		return 0, notFound(tree.TreeId)
+	}
+	c, ok := s.LogStorage.(storage.TreeStorageCompactor)
+	if !ok {
+		return 0, status.Error(codes.Unimplemented, "storage doesn't support compaction")
+	}
+	return c.CompactTreeStorage(ctx, tree, optimize)
+}
@@ -0,0 +1,393 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package namespace
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/trillian"
+	"github.com/google/trillian/storage"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	treeA = &trillian.Tree{TreeId: 1, TreeType: trillian.TreeType_LOG, Namespace: "a"}
+	treeB = &trillian.Tree{TreeId: 2, TreeType: trillian.TreeType_LOG, Namespace: "b"}
+)
+
+func expectSnapshot(ctrl *gomock.Controller, s *storage.MockAdminStorage) *storage.MockReadOnlyAdminTX {
+	tx := storage.NewMockReadOnlyAdminTX(ctrl)
+	s.EXPECT().Snapshot(gomock.Any()).Return(tx, nil)
+	tx.EXPECT().Commit().AnyTimes().Return(nil)
+	tx.EXPECT().Close().AnyTimes().Return(nil)
+	return tx
+}
+
+func expectReadWrite(ctrl *gomock.Controller, s *storage.MockAdminStorage) *storage.MockAdminTX {
+	tx := storage.NewMockAdminTX(ctrl)
+	s.EXPECT().ReadWriteTransaction(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, f storage.AdminTXFunc) error {
+			return f(ctx, tx)
+		})
+	return tx
+}
+
+func TestNewContext(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := FromContext(ctx); ok {
+		t.Errorf("FromContext(Background()) = (_, true), want (_, false)")
+	}
+	if got, ok := FromContext(NewContext(ctx, "a")); !ok || got != "a" {
+		t.Errorf("FromContext() = (%q, %v), want (%q, true)", got, ok, "a")
+	}
+}
+
+func TestAdminStorage_GetTree(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		ctx      context.Context
+		tree     *trillian.Tree
+		wantCode codes.Code
+	}{
+		{desc: "noNamespace", ctx: context.Background(), tree: treeB},
+		{desc: "sameNamespace", ctx: NewContext(context.Background(), "a"), tree: treeA},
+		{desc: "otherNamespace", ctx: NewContext(context.Background(), "a"), tree: treeB, wantCode: codes.NotFound},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storage.NewMockAdminStorage(ctrl)
+			tx := expectSnapshot(ctrl, s)
+			tx.EXPECT().GetTree(gomock.Any(), test.tree.TreeId).Return(test.tree, nil)
+
+			tree, err := storage.GetTree(test.ctx, NewAdminStorage(s), test.tree.TreeId)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("GetTree() = (_, %v), want code %v", err, test.wantCode)
+			}
+			if err == nil && tree != test.tree {
+				t.Errorf("GetTree() = %v, want %v", tree, test.tree)
+			}
+		})
+	}
+}
+
+func TestAdminStorage_GetTreeAttestation(t *testing.T) {
+	for _, test := range []struct {
+		desc     string
+		ctx      context.Context
+		tree     *trillian.Tree
+		wantCode codes.Code
+	}{
+		{desc: "noNamespace", ctx: context.Background(), tree: treeB},
+		{desc: "sameNamespace", ctx: NewContext(context.Background(), "a"), tree: treeA},
+		{desc: "otherNamespace", ctx: NewContext(context.Background(), "a"), tree: treeB, wantCode: codes.NotFound},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storage.NewMockAdminStorage(ctrl)
+			tx := expectSnapshot(ctrl, s)
+			tx.EXPECT().GetTree(gomock.Any(), test.tree.TreeId).Return(test.tree, nil)
+			want := &trillian.TreeAttestation{Signature: []byte("sig")}
+			if test.wantCode == codes.OK {
+				tx.EXPECT().GetTreeAttestation(gomock.Any(), test.tree.TreeId).Return(want, nil)
+			}
+
+			a, err := storage.GetTreeAttestation(test.ctx, NewAdminStorage(s), test.tree.TreeId)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("GetTreeAttestation() = (_, %v), want code %v", err, test.wantCode)
+			}
+			if err == nil && a != want {
+				t.Errorf("GetTreeAttestation() = %v, want %v", a, want)
+			}
+		})
+	}
+}
+
+func TestAdminStorage_ListTrees(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		ctx     context.Context
+		want    []*trillian.Tree
+		wantIDs []int64
+	}{
+		{desc: "noNamespace", ctx: context.Background(), want: []*trillian.Tree{treeA, treeB}, wantIDs: []int64{1, 2}},
+		{desc: "namespace", ctx: NewContext(context.Background(), "b"), want: []*trillian.Tree{treeB}, wantIDs: []int64{2}},
+		{desc: "emptyNamespace", ctx: NewContext(context.Background(), "c"), want: []*trillian.Tree{}, wantIDs: []int64{}},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storage.NewMockAdminStorage(ctrl)
+			tx := expectSnapshot(ctrl, s)
+			if _, ok := FromContext(test.ctx); ok {
+				// IDs are filtered by listing the trees themselves.
+				tx.EXPECT().ListTrees(gomock.Any(), true).Return([]*trillian.Tree{treeA, treeB}, nil).Times(2)
+			} else {
+				tx.EXPECT().ListTrees(gomock.Any(), true).Return([]*trillian.Tree{treeA, treeB}, nil)
+				tx.EXPECT().ListTreeIDs(gomock.Any(), true).Return([]int64{1, 2}, nil)
+			}
+
+			ns := NewAdminStorage(s)
+			tx2, err := ns.Snapshot(test.ctx)
+			if err != nil {
+				t.Fatalf("Snapshot() = (_, %v)", err)
+			}
+			defer tx2.Close()
+			trees, err := tx2.ListTrees(test.ctx, true)
+			if err != nil {
+				t.Fatalf("ListTrees() = (_, %v)", err)
+			}
+			if diff := cmp.Diff(trees, test.want, cmp.Comparer(func(a, b *trillian.Tree) bool { return a == b })); diff != "" {
+				t.Errorf("ListTrees() diff (-got +want):\n%s", diff)
+			}
+			ids, err := tx2.ListTreeIDs(test.ctx, true)
+			if err != nil {
+				t.Fatalf("ListTreeIDs() = (_, %v)", err)
+			}
+			if diff := cmp.Diff(ids, test.wantIDs); diff != "" {
+				t.Errorf("ListTreeIDs() diff (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAdminStorage_CreateTree(t *testing.T) {
+	for _, test := range []struct {
+		desc          string
+		ctx           context.Context
+		namespace     string
+		wantNamespace string
+		wantCode      codes.Code
+	}{
+		{desc: "noNamespace", ctx: context.Background(), namespace: "b", wantNamespace: "b"},
+		{desc: "assigned", ctx: NewContext(context.Background(), "a"), wantNamespace: "a"},
+		{desc: "sameNamespace", ctx: NewContext(context.Background(), "a"), namespace: "a", wantNamespace: "a"},
+		{desc: "otherNamespace", ctx: NewContext(context.Background(), "a"), namespace: "b", wantCode: codes.PermissionDenied},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storage.NewMockAdminStorage(ctrl)
+			tx := expectReadWrite(ctrl, s)
+			if test.wantCode == codes.OK {
+				tx.EXPECT().CreateTree(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, tree *trillian.Tree) (*trillian.Tree, error) {
+						if got := tree.Namespace; got != test.wantNamespace {
+							t.Errorf("CreateTree() called with namespace %q, want %q", got, test.wantNamespace)
+						}
+						return tree, nil
+					})
+			}
+
+			tree := &trillian.Tree{TreeType: trillian.TreeType_LOG, Namespace: test.namespace}
+			_, err := storage.CreateTree(test.ctx, NewAdminStorage(s), tree)
+			if got := status.Code(err); got != test.wantCode {
+				t.Errorf("CreateTree() = (_, %v), want code %v", err, test.wantCode)
+			}
+			if tree.Namespace != test.namespace {
+				t.Errorf("CreateTree() modified the namespace of its argument to %q", tree.Namespace)
+			}
+		})
+	}
+}
+
+func TestAdminStorage_Writes(t *testing.T) {
+	ctx := NewContext(context.Background(), "a")
+	for _, test := range []struct {
+		desc   string
+		expect func(tx *storage.MockAdminTX, treeID int64)
+		write  func(s storage.AdminStorage, treeID int64) error
+	}{
+		{
+			desc: "UpdateTree",
+			expect: func(tx *storage.MockAdminTX, treeID int64) {
+				tx.EXPECT().UpdateTree(gomock.Any(), treeID, gomock.Any()).Return(treeA, nil)
+			},
+			write: func(s storage.AdminStorage, treeID int64) error {
+				_, err := storage.UpdateTree(ctx, s, treeID, func(*trillian.Tree) {})
+				return err
+			},
+		},
+		{
+			desc: "SoftDeleteTree",
+			expect: func(tx *storage.MockAdminTX, treeID int64) {
+				tx.EXPECT().SoftDeleteTree(gomock.Any(), treeID).Return(treeA, nil)
+			},
+			write: func(s storage.AdminStorage, treeID int64) error {
+				_, err := storage.SoftDeleteTree(ctx, s, treeID)
+				return err
+			},
+		},
+		{
+			desc: "HardDeleteTree",
+			expect: func(tx *storage.MockAdminTX, treeID int64) {
+				tx.EXPECT().HardDeleteTree(gomock.Any(), treeID).Return(nil)
+			},
+			write: func(s storage.AdminStorage, treeID int64) error {
+				return storage.HardDeleteTree(ctx, s, treeID)
+			},
+		},
+		{
+			desc: "UndeleteTree",
+			expect: func(tx *storage.MockAdminTX, treeID int64) {
+				tx.EXPECT().UndeleteTree(gomock.Any(), treeID).Return(treeA, nil)
+			},
+			write: func(s storage.AdminStorage, treeID int64) error {
+				_, err := storage.UndeleteTree(ctx, s, treeID)
+				return err
+			},
+		},
+	} {
+		for _, tree := range []*trillian.Tree{treeA, treeB} {
+			t.Run(test.desc+"/"+tree.Namespace, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				s := storage.NewMockAdminStorage(ctrl)
+				tx := expectReadWrite(ctrl, s)
+				tx.EXPECT().GetTree(gomock.Any(), tree.TreeId).Return(tree, nil)
+				wantCode := codes.NotFound
+				if tree == treeA {
+					wantCode = codes.OK
+					test.expect(tx, tree.TreeId)
+				}
+
+				err := test.write(NewAdminStorage(s), tree.TreeId)
+				if got := status.Code(err); got != wantCode {
+					t.Errorf("%v() = %v, want code %v", test.desc, err, wantCode)
+				}
+			})
+		}
+	}
+}
+
+func TestAdminStorage_TreeTemplates(t *testing.T) {
+	tmpl := &trillian.TreeTemplate{Name: "ct", Tree: &trillian.Tree{TreeType: trillian.TreeType_LOG}}
+	for _, test := range []struct {
+		desc     string
+		ctx      context.Context
+		wantCode codes.Code
+	}{
+		{desc: "noNamespace", ctx: context.Background(), wantCode: codes.OK},
+		{desc: "namespace", ctx: NewContext(context.Background(), "a"), wantCode: codes.PermissionDenied},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			s := storage.NewMockAdminStorage(ctrl)
+			expectSnapshot(ctrl, s).EXPECT().GetTreeTemplate(gomock.Any(), tmpl.Name).Return(tmpl, nil)
+			if _, err := storage.GetTreeTemplate(test.ctx, NewAdminStorage(s), tmpl.Name); err != nil {
+				t.Errorf("GetTreeTemplate() = %v, want nil", err)
+			}
+
+			writes := []func(storage.AdminStorage) error{
+				func(s storage.AdminStorage) error { return storage.CreateTreeTemplate(test.ctx, s, tmpl) },
+				func(s storage.AdminStorage) error { return storage.UpdateTreeTemplate(test.ctx, s, tmpl) },
+				func(s storage.AdminStorage) error { return storage.DeleteTreeTemplate(test.ctx, s, tmpl.Name) },
+			}
+			for _, write := range writes {
+				tx := expectReadWrite(ctrl, s)
+				if test.wantCode == codes.OK {
+					tx.EXPECT().CreateTreeTemplate(gomock.Any(), tmpl).AnyTimes().Return(nil)
+					tx.EXPECT().UpdateTreeTemplate(gomock.Any(), tmpl).AnyTimes().Return(nil)
+					tx.EXPECT().DeleteTreeTemplate(gomock.Any(), tmpl.Name).AnyTimes().Return(nil)
+				}
+				if got := status.Code(write(NewAdminStorage(s))); got != test.wantCode {
+					t.Errorf("write returned code %v, want %v", got, test.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestLogStorage(t *testing.T) {
+	ctx := NewContext(context.Background(), "a")
+	now := time.Now()
+	for _, test := range []struct {
+		desc   string
+		expect func(s *storage.MockLogStorage, tree *trillian.Tree)
+		call   func(s storage.LogStorage, tree *trillian.Tree) error
+	}{
+		{
+			desc: "SnapshotForTree",
+			expect: func(s *storage.MockLogStorage, tree *trillian.Tree) {
+				s.EXPECT().SnapshotForTree(gomock.Any(), tree).Return(nil, nil)
+			},
+			call: func(s storage.LogStorage, tree *trillian.Tree) error {
+				_, err := s.SnapshotForTree(ctx, tree)
+				return err
+			},
+		},
+		{
+			desc: "ReadWriteTransaction",
+			expect: func(s *storage.MockLogStorage, tree *trillian.Tree) {
+				s.EXPECT().ReadWriteTransaction(gomock.Any(), tree, gomock.Any()).Return(nil)
+			},
+			call: func(s storage.LogStorage, tree *trillian.Tree) error {
+				return s.ReadWriteTransaction(ctx, tree, func(context.Context, storage.LogTreeTX) error { return nil })
+			},
+		},
+		{
+			desc: "QueueLeaves",
+			expect: func(s *storage.MockLogStorage, tree *trillian.Tree) {
+				s.EXPECT().QueueLeaves(gomock.Any(), tree, nil, now).Return(nil, nil)
+			},
+			call: func(s storage.LogStorage, tree *trillian.Tree) error {
+				_, err := s.QueueLeaves(ctx, tree, nil, now)
+				return err
+			},
+		},
+		{
+			desc: "AddSequencedLeaves",
+			expect: func(s *storage.MockLogStorage, tree *trillian.Tree) {
+				s.EXPECT().AddSequencedLeaves(gomock.Any(), tree, nil, now).Return(nil, nil)
+			},
+			call: func(s storage.LogStorage, tree *trillian.Tree) error {
+				_, err := s.AddSequencedLeaves(ctx, tree, nil, now)
+				return err
+			},
+		},
+	} {
+		for _, tree := range []*trillian.Tree{treeA, treeB} {
+			t.Run(test.desc+"/"+tree.Namespace, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				s := storage.NewMockLogStorage(ctrl)
+				wantCode := codes.NotFound
+				if tree == treeA {
+					wantCode = codes.OK
+					test.expect(s, tree)
+				}
+
+				err := test.call(NewLogStorage(s), tree)
+				if got := status.Code(err); got != wantCode {
+					t.Errorf("%v() = %v, want code %v", test.desc, err, wantCode)
+				}
+			})
+		}
+	}
+}
@@ -17,7 +17,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"net"
 	"sync"
 	"time"
 
@@ -26,6 +29,7 @@ import (
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/storage"
+	"github.com/lib/pq"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -33,6 +37,15 @@ import (
 const (
 	defaultSequenceIntervalSeconds = 60
 
+	// errCodeUniqueViolation is the SQLSTATE of unique constraint violations.
+	errCodeUniqueViolation = "23505"
+	// errCodeDeadlockDetected and errCodeLockNotAvailable are the SQLSTATEs of
+	// transactions rolled back to break a deadlock, and of lock timeouts.
+	errCodeDeadlockDetected = "40P01"
+	errCodeLockNotAvailable = "55P03"
+	// errClassConnectionException is the SQLSTATE class of lost connections.
+	errClassConnectionException = "08"
+
 	selectTrees = `
 	SELECT
 		tree_id,
@@ -49,7 +62,26 @@ const (
 		public_key,
 		max_root_duration_millis,
 		deleted,
-		delete_time_millis
+		delete_time_millis,
+		ordered_leaf_timestamps,
+		caller_leaf_identity_hash,
+		hash_extra_data,
+		namespace,
+		log_root_encoding,
+		timestamp_granularity,
+		hash_only,
+		leaf_compression,
+		max_tree_size,
+		queue_write_ahead,
+		leaf_encryption,
+		sort_by_queue_timestamp,
+		leaf_ordering_key,
+		leaf_tombstones,
+		empty_root_hash,
+		leaf_compression_dictionaries,
+		leaf_index_offset,
+		signing_interval_millis,
+		max_queue_age_millis
 	FROM trees`
 
 	nonDeletedWhere       = " WHERE deleted = false"
@@ -73,8 +105,27 @@ const (
 		update_time_millis,
 		private_key,
 		public_key,
-		max_root_duration_millis)
-	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`
+		max_root_duration_millis,
+		ordered_leaf_timestamps,
+		caller_leaf_identity_hash,
+		hash_extra_data,
+		namespace,
+		log_root_encoding,
+		timestamp_granularity,
+		hash_only,
+		leaf_compression,
+		max_tree_size,
+		queue_write_ahead,
+		leaf_encryption,
+		sort_by_queue_timestamp,
+		leaf_ordering_key,
+		leaf_tombstones,
+		empty_root_hash,
+		leaf_compression_dictionaries,
+		leaf_index_offset,
+		signing_interval_millis,
+		max_queue_age_millis)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32)`
 
 	insertTreeControlSQL = `INSERT INTO tree_control(
 		tree_id,
@@ -84,8 +135,10 @@ const (
 	VALUES($1, $2, $3, $4)`
 
 	updateTreeSQL = `UPDATE trees SET tree_state = $1, tree_type = $2, display_name = $3, 
-		description = $4, update_time_millis = $5, max_root_duration_millis = $6, private_key = $7
-		WHERE tree_id = $8`
+		description = $4, update_time_millis = $5, max_root_duration_millis = $6, private_key = $7,
+		leaf_encryption = $8, leaf_compression_dictionaries = $9, signing_interval_millis = $10,
+		max_queue_age_millis = $11
+		WHERE tree_id = $12`
 
 	softDeleteSQL = "UPDATE trees SET deleted = $1, delete_time_millis = $2 WHERE tree_id = $3"
 
@@ -93,7 +146,31 @@ const (
 
 	deleteFromTreeControlSQL = "DELETE FROM tree_control WHERE tree_id = $1"
 
+	deleteFromTreeAttestationsSQL = "DELETE FROM tree_attestations WHERE tree_id = $1"
+
 	deleteFromTreesSQL = "DELETE FROM trees WHERE tree_id = $1"
+
+	selectTreeTemplateSQL  = "SELECT template FROM tree_templates WHERE name = $1"
+	selectTreeTemplatesSQL = "SELECT template FROM tree_templates ORDER BY name"
+	insertTreeTemplateSQL  = "INSERT INTO tree_templates(name, template) VALUES($1, $2)"
+	updateTreeTemplateSQL  = "UPDATE tree_templates SET template = $1 WHERE name = $2"
+	deleteTreeTemplateSQL  = "DELETE FROM tree_templates WHERE name = $1"
+
+	// lockSQL takes a transaction-level advisory lock. Its first argument
+	// is one of the lock classes below, and its second one a key within the
+	// class; keys whose hashes collide share a lock.
+	lockSQL = "SELECT pg_advisory_xact_lock($1, hashtext($2))"
+
+	selectNonDeletedTreeIDsByDisplayName = selectNonDeletedTreeIDs + " AND display_name = $1 ORDER BY tree_id"
+
+	selectTreeAttestationSQL = "SELECT tree, signature FROM tree_attestations WHERE tree_id = $1"
+	insertTreeAttestationSQL = "INSERT INTO tree_attestations(tree_id, tree, signature) VALUES($1, $2, $3)"
+)
+
+// Classes of the advisory locks taken with lockSQL.
+const (
+	lockClassLeafIndexRanges = 1
+	lockClassDisplayNames    = 2
 )
 
 // NewAdminStorage returns a storage.AdminStorage implementation
@@ -275,8 +352,11 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 	if err := validateStorageSettings(tree); err != nil {
 		return nil, err
 	}
+	if err := checkTreeFieldsSupported(tree); err != nil {
+		return nil, err
+	}
 
-	id, err := storage.NewTreeID()
+	id, err := storage.AllocateTreeID(ctx, t, tree.TreeId)
 	if err != nil {
 		return nil, err
 	}
@@ -299,6 +379,14 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 	if err != nil {
 		return nil, fmt.Errorf("could not parse MaxRootDuration: %v", err)
 	}
+	signingInterval, err := storage.OptionalDurationMillis(newTree.SigningInterval)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse SigningInterval: %v", err)
+	}
+	maxQueueAge, err := storage.OptionalDurationMillis(newTree.MaxQueueAge)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse MaxQueueAge: %v", err)
+	}
 
 	insertTreeStmt, err := t.tx.PrepareContext(ctx, insertSQL)
 	if err != nil {
@@ -310,6 +398,18 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal PrivateKey: %v", err)
 	}
+	leafEncryption, err := storage.MarshalLeafEncryption(newTree.LeafEncryption)
+	if err != nil {
+		return nil, err
+	}
+	leafOrderingKey, err := storage.MarshalLeafOrderingKey(newTree.LeafOrderingKey)
+	if err != nil {
+		return nil, err
+	}
+	leafCompressionDictionaries, err := storage.MarshalLeafCompressionDictionaries(newTree.LeafCompressionDictionaries)
+	if err != nil {
+		return nil, err
+	}
 
 	_, err = insertTreeStmt.ExecContext(
 		ctx,
@@ -326,7 +426,31 @@ func (t *adminTX) CreateTree(ctx context.Context, tree *trillian.Tree) (*trillia
 		privateKey,
 		newTree.PublicKey.GetDer(),
 		rootDuration/time.Millisecond,
+		newTree.OrderedLeafTimestamps,
+		newTree.CallerLeafIdentityHash,
+		newTree.HashExtraData,
+		newTree.Namespace,
+		newTree.LogRootEncoding.String(),
+		newTree.TimestampGranularity.String(),
+		newTree.HashOnly,
+		newTree.LeafCompression.String(),
+		newTree.MaxTreeSize,
+		newTree.QueueWriteAhead,
+		leafEncryption,
+		newTree.SortByQueueTimestamp,
+		leafOrderingKey,
+		newTree.LeafTombstones,
+		newTree.EmptyRootHash,
+		leafCompressionDictionaries,
+		newTree.LeafIndexOffset,
+		signingInterval,
+		maxQueueAge,
 	)
+	if isDuplicateErr(err) {
+		// Another transaction created a tree with the requested ID after
+		// AllocateTreeID checked it.
+		return nil, status.Errorf(codes.AlreadyExists, "tree %v already exists", newTree.TreeId)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -364,6 +488,9 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 	if err := validateStorageSettings(tree); err != nil {
 		return nil, err
 	}
+	if err := checkTreeFieldsSupported(tree); err != nil {
+		return nil, err
+	}
 
 	// Use the time truncated-to-millis throughout, as that's what's stored.
 	nowMillis := storage.ToMillisSinceEpoch(time.Now())
@@ -376,11 +503,27 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 	if err != nil {
 		return nil, fmt.Errorf("could not parse MaxRootDuration: %v", err)
 	}
+	signingInterval, err := storage.OptionalDurationMillis(tree.SigningInterval)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse SigningInterval: %v", err)
+	}
+	maxQueueAge, err := storage.OptionalDurationMillis(tree.MaxQueueAge)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse MaxQueueAge: %v", err)
+	}
 
 	privateKey, err := proto.Marshal(tree.PrivateKey)
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal PrivateKey: %v", err)
 	}
+	leafEncryption, err := storage.MarshalLeafEncryption(tree.LeafEncryption)
+	if err != nil {
+		return nil, err
+	}
+	leafCompressionDictionaries, err := storage.MarshalLeafCompressionDictionaries(tree.LeafCompressionDictionaries)
+	if err != nil {
+		return nil, err
+	}
 
 	stmt, err := t.tx.PrepareContext(ctx, updateTreeSQL)
 	if err != nil {
@@ -397,6 +540,10 @@ func (t *adminTX) UpdateTree(ctx context.Context, treeID int64, updateFunc func(
 		nowMillis,
 		rootDuration/time.Millisecond,
 		privateKey,
+		leafEncryption,
+		leafCompressionDictionaries,
+		signingInterval,
+		maxQueueAge,
 		tree.TreeId); err != nil {
 		return nil, err
 	}
@@ -420,6 +567,9 @@ func (t *adminTX) HardDeleteTree(ctx context.Context, treeID int64) error {
 	if _, err := t.tx.ExecContext(ctx, deleteFromTreeControlSQL, treeID); err != nil {
 		return err
 	}
+	if _, err := t.tx.ExecContext(ctx, deleteFromTreeAttestationsSQL, treeID); err != nil {
+		return err
+	}
 	_, err := t.tx.ExecContext(ctx, deleteFromTreesSQL, treeID)
 	return err
 }
@@ -459,9 +609,183 @@ func validateDeleted(ctx context.Context, tx *sql.Tx, treeID int64, wantDeleted
 	return nil
 }
 
+// LockLeafIndexRanges implements storage.LeafIndexRangeLocker. Reads of
+// the default READ COMMITTED transactions see the trees created by those
+// which held the lock before.
+func (t *adminTX) LockLeafIndexRanges(ctx context.Context) error {
+	_, err := t.tx.ExecContext(ctx, lockSQL, lockClassLeafIndexRanges, "")
+	return err
+}
+
+// LockTreesByDisplayName implements storage.DisplayNameLocker. The lock is
+// taken on the name rather than on rows, so that it also covers trees which
+// don't exist yet, and reads of the default READ COMMITTED transactions see
+// the trees created by those which held it before.
+func (t *adminTX) LockTreesByDisplayName(ctx context.Context, displayName string) ([]int64, error) {
+	if _, err := t.tx.ExecContext(ctx, lockSQL, lockClassDisplayNames, displayName); err != nil {
+		return nil, err
+	}
+	rows, err := t.tx.QueryContext(ctx, selectNonDeletedTreeIDsByDisplayName, displayName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var treeIDs []int64
+	for rows.Next() {
+		var treeID int64
+		if err := rows.Scan(&treeID); err != nil {
+			return nil, err
+		}
+		treeIDs = append(treeIDs, treeID)
+	}
+	return treeIDs, rows.Err()
+}
+
+func (t *adminTX) GetTreeTemplate(ctx context.Context, name string) (*trillian.TreeTemplate, error) {
+	var b []byte
+	switch err := t.tx.QueryRowContext(ctx, selectTreeTemplateSQL, name).Scan(&b); {
+	case err == sql.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "tree template %q not found", name)
+	case err != nil:
+		return nil, fmt.Errorf("error reading tree template %q: %v", name, err)
+	}
+	return storage.UnmarshalTreeTemplate(b)
+}
+
+func (t *adminTX) ListTreeTemplates(ctx context.Context) ([]*trillian.TreeTemplate, error) {
+	rows, err := t.tx.QueryContext(ctx, selectTreeTemplatesSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tmpls := []*trillian.TreeTemplate{}
+	for rows.Next() {
+		var b []byte
+		if err := rows.Scan(&b); err != nil {
+			return nil, err
+		}
+		tmpl, err := storage.UnmarshalTreeTemplate(b)
+		if err != nil {
+			return nil, err
+		}
+		tmpls = append(tmpls, tmpl)
+	}
+	return tmpls, rows.Err()
+}
+
+func (t *adminTX) CreateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	if err := storage.ValidateTreeTemplate(tmpl); err != nil {
+		return err
+	}
+	b, err := proto.Marshal(tmpl)
+	if err != nil {
+		return err
+	}
+	switch _, err := t.GetTreeTemplate(ctx, tmpl.Name); {
+	case err == nil:
+		return status.Errorf(codes.AlreadyExists, "tree template %q already exists", tmpl.Name)
+	case status.Code(err) != codes.NotFound:
+		return err
+	}
+	_, err = t.tx.ExecContext(ctx, insertTreeTemplateSQL, tmpl.Name, b)
+	if isDuplicateErr(err) {
+		// Another transaction created the template after it was checked.
+		return status.Errorf(codes.AlreadyExists, "tree template %q already exists", tmpl.Name)
+	}
+	return err
+}
+
+func (t *adminTX) UpdateTreeTemplate(ctx context.Context, tmpl *trillian.TreeTemplate) error {
+	if err := storage.ValidateTreeTemplate(tmpl); err != nil {
+		return err
+	}
+	b, err := proto.Marshal(tmpl)
+	if err != nil {
+		return err
+	}
+	if _, err := t.GetTreeTemplate(ctx, tmpl.Name); err != nil {
+		return err
+	}
+	_, err = t.tx.ExecContext(ctx, updateTreeTemplateSQL, b, tmpl.Name)
+	return err
+}
+
+func (t *adminTX) DeleteTreeTemplate(ctx context.Context, name string) error {
+	res, err := t.tx.ExecContext(ctx, deleteTreeTemplateSQL, name)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return status.Errorf(codes.NotFound, "tree template %q not found", name)
+	}
+	return nil
+}
+
+func (t *adminTX) GetTreeAttestation(ctx context.Context, treeID int64) (*trillian.TreeAttestation, error) {
+	var a trillian.TreeAttestation
+	switch err := t.tx.QueryRowContext(ctx, selectTreeAttestationSQL, treeID).Scan(&a.Tree, &a.Signature); {
+	case err == sql.ErrNoRows:
+		return nil, status.Errorf(codes.NotFound, "attestation of tree %v not found", treeID)
+	case err != nil:
+		return nil, fmt.Errorf("error reading attestation of tree %v: %v", treeID, err)
+	}
+	return &a, nil
+}
+
+func (t *adminTX) CreateTreeAttestation(ctx context.Context, treeID int64, a *trillian.TreeAttestation) error {
+	switch _, err := t.GetTreeAttestation(ctx, treeID); {
+	case err == nil:
+		return status.Errorf(codes.AlreadyExists, "tree %v already has an attestation", treeID)
+	case status.Code(err) != codes.NotFound:
+		return err
+	}
+	_, err := t.tx.ExecContext(ctx, insertTreeAttestationSQL, treeID, a.Tree, a.Signature)
+	return err
+}
+
 func validateStorageSettings(tree *trillian.Tree) error {
 	if tree.StorageSettings != nil {
 		return fmt.Errorf("storage_settings not supported, but got %v", tree.StorageSettings)
 	}
 	return nil
 }
+
+// checkTreeFieldsSupported returns an Unimplemented error if tree sets a field
+// which this storage stores but can't act on.
+func checkTreeFieldsSupported(tree *trillian.Tree) error {
+	// The log transactions don't implement storage.IntegratedRootStore, so
+	// the sequencer couldn't integrate a log with a signing_interval.
+	if tree.SigningInterval != nil {
+		return status.Error(codes.Unimplemented, "signing_interval not supported by PostgreSQL storage")
+	}
+	return nil
+}
+
+func isDuplicateErr(err error) bool {
+	switch err := err.(type) {
+	case *pq.Error:
+		return err.Code == errCodeUniqueViolation
+	default:
+		return false
+	}
+}
+
+// isTransientErr returns whether err may not recur if the statement which
+// failed with it is retried: a broken connection, a deadlock, a lock timeout
+// or a network timeout.
+func isTransientErr(err error) bool {
+	var pqErr *pq.Error
+	var netErr net.Error
+	switch {
+	case errors.Is(err, driver.ErrBadConn):
+		return true
+	case errors.As(err, &pqErr):
+		return pqErr.Code == errCodeDeadlockDetected || pqErr.Code == errCodeLockNotAvailable || pqErr.Code.Class() == errClassConnectionException
+	case errors.As(err, &netErr):
+		return netErr.Timeout()
+	default:
+		return false
+	}
+}
@@ -18,7 +18,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
@@ -26,9 +28,11 @@ import (
 	"github.com/google/trillian"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/testonly"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-var allTables = []string{"unsequenced", "tree_head", "sequenced_leaf_data", "leaf_data", "subtree", "tree_control", "trees"}
+var allTables = []string{"unsequenced", "quarantined_leaves", "tree_head", "sequenced_leaf_data", "leaf_data", "subtree", "tree_control", "tree_attestations", "trees", "tree_templates"}
 var db *sql.DB
 
 const selectTreeControlByID = "SELECT signing_enabled, sequencing_enabled, sequence_interval_seconds FROM tree_control WHERE tree_id = $1"
@@ -64,6 +68,92 @@ func TestAdminTX_CreateTree_InitializesStorageStructures(t *testing.T) {
 	}
 }
 
+func TestAdminTX_CreateTree_ConcurrentRequestedID(t *testing.T) {
+	cleanTestDB(db, t)
+	s := NewAdminStorage(db).(*pgAdminStorage)
+	ctx := context.Background()
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.TreeId = 12345
+
+	tx1, err := s.beginInternal(ctx)
+	if err != nil {
+		t.Fatalf("beginInternal() failed: %v", err)
+	}
+	defer tx1.Close()
+	tx2, err := s.beginInternal(ctx)
+	if err != nil {
+		t.Fatalf("beginInternal() failed: %v", err)
+	}
+	defer tx2.Close()
+
+	// Both transactions find the requested ID free before either inserts it.
+	if _, err := tx2.GetTree(ctx, tree.TreeId); status.Code(err) != codes.NotFound {
+		t.Fatalf("GetTree() returned err = %v, want NotFound", err)
+	}
+	if _, err := tx1.CreateTree(ctx, tree); err != nil {
+		t.Fatalf("CreateTree() failed: %v", err)
+	}
+	// The insert of tx2 waits for tx1 to commit.
+	errc := make(chan error, 1)
+	go func() {
+		_, err := tx2.CreateTree(ctx, tree)
+		errc <- err
+	}()
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+	if err := <-errc; status.Code(err) != codes.AlreadyExists {
+		t.Errorf("concurrent CreateTree() returned err = %v, want AlreadyExists", err)
+	}
+}
+
+func TestAdminTX_LockTreesByDisplayName(t *testing.T) {
+	cleanTestDB(db, t)
+	s := NewAdminStorage(db).(*pgAdminStorage)
+	ctx := context.Background()
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.DisplayName = "dup"
+
+	tx1, err := s.beginInternal(ctx)
+	if err != nil {
+		t.Fatalf("beginInternal() failed: %v", err)
+	}
+	defer tx1.Close()
+	tx2, err := s.beginInternal(ctx)
+	if err != nil {
+		t.Fatalf("beginInternal() failed: %v", err)
+	}
+	defer tx2.Close()
+
+	if ids, err := tx1.(storage.DisplayNameLocker).LockTreesByDisplayName(ctx, "dup"); err != nil || len(ids) != 0 {
+		t.Fatalf("LockTreesByDisplayName() = %v, %v, want no trees", ids, err)
+	}
+	created, err := tx1.CreateTree(ctx, tree)
+	if err != nil {
+		t.Fatalf("CreateTree() failed: %v", err)
+	}
+	// The lock of tx2 waits for tx1 to commit, and then sees its tree.
+	type result struct {
+		ids []int64
+		err error
+	}
+	resc := make(chan result, 1)
+	go func() {
+		ids, err := tx2.(storage.DisplayNameLocker).LockTreesByDisplayName(ctx, "dup")
+		resc <- result{ids, err}
+	}()
+	if err := tx1.Commit(); err != nil {
+		t.Fatalf("Commit() failed: %v", err)
+	}
+	res := <-resc
+	if res.err != nil {
+		t.Fatalf("concurrent LockTreesByDisplayName() failed: %v", res.err)
+	}
+	if want := []int64{created.TreeId}; !reflect.DeepEqual(res.ids, want) {
+		t.Errorf("concurrent LockTreesByDisplayName() = %v, want %v", res.ids, want)
+	}
+}
+
 func TestCreateTreeInvalidStates(t *testing.T) {
 	cleanTestDB(db, t)
 	s := NewAdminStorage(db)
@@ -193,6 +283,27 @@ func TestAdminTX_StorageSettingsNotSupported(t *testing.T) {
 	}
 }
 
+func TestAdminTX_SigningIntervalNotSupported(t *testing.T) {
+	cleanTestDB(db, t)
+	s := NewAdminStorage(db)
+	ctx := context.Background()
+
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.SigningInterval = ptypes.DurationProto(time.Minute)
+	if _, err := storage.CreateTree(ctx, s, tree); status.Code(err) != codes.Unimplemented {
+		t.Errorf("CreateTree() = %v, want code %v", err, codes.Unimplemented)
+	}
+
+	created, err := storage.CreateTree(ctx, s, testonly.LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree() failed with err = %v", err)
+	}
+	_, err = storage.UpdateTree(ctx, s, created.TreeId, func(tree *trillian.Tree) { tree.SigningInterval = ptypes.DurationProto(time.Minute) })
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("UpdateTree() = %v, want code %v", err, codes.Unimplemented)
+	}
+}
+
 func cleanTestDB(db *sql.DB, t *testing.T) {
 	t.Helper()
 	for _, table := range allTables {
@@ -48,9 +48,17 @@ const (
 
 	selectSequencedLeafCountSQL   = "SELECT COUNT(*) FROM sequenced_leaf_data WHERE tree_id=$1"
 	selectUnsequencedLeafCountSQL = "SELECT tree_id, COUNT(1) FROM unsequenced GROUP BY tree_id"
+
+	selectLeafCompressionDictionariesSQL = "SELECT leaf_compression_dictionaries FROM trees WHERE tree_id = $1"
 	//selectLatestSignedLogRootSQL  = `SELECT tree_head_timestamp,tree_size,root_hash,tree_revision,root_signature
 	//              FROM tree_head WHERE tree_id=$1
 	//              ORDER BY tree_head_timestamp DESC LIMIT 1`
+	selectSignedLogRootHistorySQL = `SELECT tree_head_timestamp,tree_size,root_hash,tree_revision,root_signature
+                FROM tree_head WHERE tree_id=$1
+                ORDER BY tree_head_timestamp DESC LIMIT $2`
+	selectSignedLogRootAtSizeSQL = `SELECT tree_head_timestamp,tree_size,root_hash,tree_revision,root_signature
+                FROM tree_head WHERE tree_id=$1 AND tree_size=$2
+                ORDER BY tree_head_timestamp DESC LIMIT 1`
 
 	selectLeavesByRangeSQL = `SELECT s.merkle_leaf_hash,l.leaf_identity_hash,l.leaf_value,s.sequence_number,l.extra_data,l.queue_timestamp_nanos,s.integrate_timestamp_nanos
                         FROM leaf_data l,sequenced_leaf_data s
@@ -75,6 +83,17 @@ const (
                         FROM leaf_data l LEFT JOIN sequenced_leaf_data s ON (l.leaf_identity_hash = s.leaf_identity_hash AND l.tree_id = s.tree_id)
                         WHERE l.leaf_identity_hash IN (` + placeholderSQL + `) AND l.tree_id = <param>`
 
+	upsertQuarantinedLeafSQL = `INSERT INTO quarantined_leaves(tree_id,leaf_identity_hash,merkle_leaf_hash,queue_timestamp_nanos,quarantine_timestamp_nanos,error)
+                        VALUES($1,$2,$3,$4,$5,$6)
+                        ON CONFLICT (tree_id,leaf_identity_hash) DO UPDATE
+                        SET merkle_leaf_hash=$3,queue_timestamp_nanos=$4,quarantine_timestamp_nanos=$5,error=$6`
+	selectQuarantinedLeavesSQL = `SELECT q.leaf_identity_hash,q.merkle_leaf_hash,q.queue_timestamp_nanos,q.quarantine_timestamp_nanos,q.error,l.leaf_value,l.extra_data
+                        FROM quarantined_leaves q LEFT JOIN leaf_data l ON (q.leaf_identity_hash = l.leaf_identity_hash AND q.tree_id = l.tree_id)
+                        WHERE q.tree_id=$1
+                        ORDER BY q.quarantine_timestamp_nanos,q.leaf_identity_hash LIMIT $2`
+	selectQuarantinedLeafSQL = "SELECT merkle_leaf_hash,queue_timestamp_nanos FROM quarantined_leaves WHERE tree_id=$1 AND leaf_identity_hash=$2"
+	deleteQuarantinedLeafSQL = "DELETE FROM quarantined_leaves WHERE tree_id=$1 AND leaf_identity_hash=$2"
+
 	// Same as above except with leaves ordered by sequence so we only incur this cost when necessary
 	orderBySequenceNumberSQL                     = " ORDER BY s.sequence_number"
 	selectLeavesByMerkleHashOrderedBySequenceSQL = selectLeavesByMerkleHashSQL + orderBySequenceNumberSQL
@@ -100,6 +119,8 @@ var (
 	dequeueLatency          monitoring.Histogram
 	dequeueSelectLatency    monitoring.Histogram
 	dequeueRemoveLatency    monitoring.Histogram
+
+	leafCompressionRatio monitoring.Histogram
 )
 
 func createMetrics(mf monitoring.MetricFactory) {
@@ -116,6 +137,8 @@ func createMetrics(mf monitoring.MetricFactory) {
 	dequeueLatency = mf.NewHistogram("postgres_dequeue_leaves_latency", "Latency of dequeue leaves operation in seconds", logIDLabel)
 	dequeueSelectLatency = mf.NewHistogram("postgres_dequeue_leaves_latency_select", "Latency of selection part of dequeue leaves operation in seconds", logIDLabel)
 	dequeueRemoveLatency = mf.NewHistogram("postgres_dequeue_leaves_latency_remove", "Latency of removal part of dequeue leaves operation in seconds", logIDLabel)
+
+	leafCompressionRatio = mf.NewHistogramWithBuckets("postgres_leaf_compression_ratio", "Stored size of the value and extra data of leaves of trees with leaf compression, as a percentage of their original size", monitoring.PercentileBuckets(5), logIDLabel)
 }
 
 func labelForTX(t *logTreeTX) string {
@@ -263,15 +286,17 @@ func (m *postgresLogStorage) beginInternal(ctx context.Context, tree *trillian.T
 		return nil, err
 	}
 
-	stCache := cache.NewLogSubtreeCache(defaultLogStrata, hasher)
+	stCache := cache.NewLogSubtreeCacheWithMetrics(defaultLogStrata, tree.TreeId, hasher, m.metricFactory)
 	ttx, err := m.beginTreeTx(ctx, tree, hasher.Size(), stCache)
 	if err != nil && err != storage.ErrTreeNeedsInit {
 		return nil, err
 	}
 
 	ltx := &logTreeTX{
-		treeTX: ttx,
-		ls:     m,
+		treeTX:     ttx,
+		ls:         m,
+		encoding:   tree.LogRootEncoding,
+		compressor: storage.NewLeafCompressor(tree),
 	}
 	ltx.slr, err = ltx.fetchLatestRoot(ctx)
 	if err == storage.ErrTreeNeedsInit {
@@ -302,6 +327,14 @@ func (m *postgresLogStorage) ReadWriteTransaction(ctx context.Context, tree *tri
 }
 
 func (m *postgresLogStorage) AddSequencedLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, timestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	if tree.LeafOrderingKey != nil {
+		// Leaves would be stored without being indexed by their ordering key.
+		return nil, status.Error(codes.Unimplemented, "leaf_ordering_key is not supported")
+	}
+	if tree.LeafTombstones {
+		// Tombstones would be stored without being indexed.
+		return nil, status.Error(codes.Unimplemented, "leaf_tombstones is not supported")
+	}
 	tx, err := m.beginInternal(ctx, tree)
 	if err != nil {
 		return nil, err
@@ -325,6 +358,10 @@ func (m *postgresLogStorage) SnapshotForTree(ctx context.Context, tree *trillian
 }
 
 func (m *postgresLogStorage) QueueLeaves(ctx context.Context, tree *trillian.Tree, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.QueuedLogLeaf, error) {
+	if tree.LeafTombstones {
+		// Tombstones would be stored without being indexed.
+		return nil, status.Error(codes.Unimplemented, "leaf_tombstones is not supported")
+	}
 	tx, err := m.beginInternal(ctx, tree)
 	if err != nil {
 		return nil, err
@@ -352,11 +389,56 @@ func (m *postgresLogStorage) QueueLeaves(ctx context.Context, tree *trillian.Tre
 	return ret, nil
 }
 
+// compressLeaf returns the leaf value and extra data of leaf to store, and
+// records how well they compressed.
+func (t *logTreeTX) compressLeaf(leaf *trillian.LogLeaf) (value, extraData []byte, err error) {
+	value, extraData, err = t.compressor.CompressLeaf(leaf)
+	if err != nil {
+		return nil, nil, err
+	}
+	if size := len(leaf.LeafValue) + len(leaf.ExtraData); t.compressor.Compresses() && size > 0 {
+		leafCompressionRatio.Observe(100*float64(len(value)+len(extraData))/float64(size), labelForTX(t))
+	}
+	return value, extraData, nil
+}
+
+// decompressLeaf replaces the stored leaf data of leaf with its original
+// contents. If the leaf data was compressed with a dictionary appended after
+// the tree was read, e.g. from a cache, the dictionaries of the tree are read
+// again and decompression is retried. They are read outside the transaction,
+// whose connection may be busy with the rows of leaf.
+func (t *logTreeTX) decompressLeaf(ctx context.Context, leaf *trillian.LogLeaf) error {
+	err := t.compressor.DecompressLeaf(leaf)
+	var unknown *storage.UnknownDictionaryError
+	if !errors.As(err, &unknown) {
+		return err
+	}
+	var b []byte
+	if err := t.ls.db.QueryRowContext(ctx, selectLeafCompressionDictionariesSQL, t.treeID).Scan(&b); err != nil {
+		return fmt.Errorf("failed to read leaf compression dictionaries: %v", err)
+	}
+	ds, err := storage.UnmarshalLeafCompressionDictionaries(b)
+	if err != nil {
+		return err
+	}
+	t.compressor = t.compressor.WithDictionaries(ds)
+	return t.compressor.DecompressLeaf(leaf)
+}
+
+// IsTransientError implements storage.TransientErrorClassifier.
+func (t *logTreeTX) IsTransientError(err error) bool {
+	return isTransientErr(err)
+}
+
 type logTreeTX struct {
 	treeTX
 	ls   *postgresLogStorage
 	root types.LogRootV1
 	slr  *trillian.SignedLogRoot
+	// encoding is the serialization of the log roots of the tree.
+	encoding trillian.LogRootEncoding
+	// compressor compresses the leaf data of the tree.
+	compressor *storage.LeafCompressor
 }
 
 func (t *logTreeTX) ReadRevision(ctx context.Context) (int64, error) {
@@ -401,10 +483,6 @@ func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime tim
 			return nil, err
 		}
 
-		if len(leaf.LeafIdentityHash) != t.hashSizeBytes {
-			return nil, errors.New("dequeued a leaf with incorrect hash size")
-		}
-
 		leaves = append(leaves, leaf)
 		dq = append(dq, dqInfo)
 	}
@@ -435,6 +513,85 @@ func (t *logTreeTX) DequeueLeaves(ctx context.Context, limit int, cutoffTime tim
 	return leaves, nil
 }
 
+func (t *logTreeTX) QuarantineLeaves(ctx context.Context, leaves []*trillian.QuarantinedLeaf) error {
+	for _, ql := range leaves {
+		// The leaves were removed from unsequenced when they were dequeued.
+		queueTimestamp, err := ptypes.Timestamp(ql.Leaf.QueueTimestamp)
+		if err != nil {
+			// Keep the leaf anyway, as a bad timestamp is a reason to quarantine it.
+			queueTimestamp = time.Unix(0, 0)
+		}
+		quarantineTimestamp, err := ptypes.Timestamp(ql.QuarantineTimestamp)
+		if err != nil {
+			return fmt.Errorf("got invalid quarantine timestamp: %v", err)
+		}
+		if _, err := t.tx.ExecContext(ctx, upsertQuarantinedLeafSQL, t.treeID, ql.Leaf.LeafIdentityHash, ql.Leaf.MerkleLeafHash,
+			queueTimestamp.UnixNano(), quarantineTimestamp.UnixNano(), ql.Error); err != nil {
+			glog.Warningf("Failed to quarantine leaf: %s", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *logTreeTX) ListQuarantinedLeaves(ctx context.Context, limit int) ([]*trillian.QuarantinedLeaf, error) {
+	rows, err := t.tx.QueryContext(ctx, selectQuarantinedLeavesSQL, t.treeID, limit)
+	if err != nil {
+		glog.Warningf("Failed to select quarantined leaves: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []*trillian.QuarantinedLeaf
+	for rows.Next() {
+		leaf := &trillian.LogLeaf{}
+		ql := &trillian.QuarantinedLeaf{Leaf: leaf}
+		var queueTS, quarantineTS int64
+		if err := rows.Scan(&leaf.LeafIdentityHash, &leaf.MerkleLeafHash, &queueTS, &quarantineTS, &ql.Error, &leaf.LeafValue, &leaf.ExtraData); err != nil {
+			return nil, err
+		}
+		// The leaf data is NULL if it's no longer stored.
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, err
+		}
+		if leaf.QueueTimestamp, err = ptypes.TimestampProto(time.Unix(0, queueTS)); err != nil {
+			return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
+		}
+		if ql.QuarantineTimestamp, err = ptypes.TimestampProto(time.Unix(0, quarantineTS)); err != nil {
+			return nil, fmt.Errorf("got invalid quarantine timestamp: %v", err)
+		}
+		ret = append(ret, ql)
+	}
+	return ret, rows.Err()
+}
+
+func (t *logTreeTX) RequeueQuarantinedLeaves(ctx context.Context, leafIdentityHashes [][]byte) (int, error) {
+	requeued := 0
+	for _, hash := range leafIdentityHashes {
+		var merkleHash []byte
+		var queueTS int64
+		err := t.tx.QueryRowContext(ctx, selectQuarantinedLeafSQL, t.treeID, hash).Scan(&merkleHash, &queueTS)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return requeued, err
+		}
+
+		args := []interface{}{t.treeID, hash, merkleHash}
+		args = append(args, queueArgs(t.treeID, hash, time.Unix(0, queueTS))...)
+		if _, err := t.tx.ExecContext(ctx, insertUnsequencedEntrySQL, args...); err != nil {
+			glog.Warningf("Error requeueing quarantined leaf: %s", err)
+			return requeued, err
+		}
+		res, err := t.tx.ExecContext(ctx, deleteQuarantinedLeafSQL, t.treeID, hash)
+		if err := checkResultOkAndRowCountIs(res, err, 1); err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
 // sortLeavesForInsert returns a slice containing the passed in leaves sorted
 // by LeafIdentityHash, and paired with their original positions.
 // QueueLeaves and AddSequencedLeaves use this to make the order that LeafData
@@ -449,6 +606,9 @@ func sortLeavesForInsert(leaves []*trillian.LogLeaf) []leafAndPosition {
 }
 
 func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf, queueTimestamp time.Time) ([]*trillian.LogLeaf, error) {
+	if storage.QueueConditionFromContext(ctx) != nil {
+		return nil, status.Error(codes.Unimplemented, "conditional QueueLeaves not supported")
+	}
 	// Don't accept batches if any of the leaves are invalid.
 	for _, leaf := range leaves {
 		if len(leaf.LeafIdentityHash) != t.hashSizeBytes {
@@ -475,7 +635,11 @@ func (t *logTreeTX) QueueLeaves(ctx context.Context, leaves []*trillian.LogLeaf,
 		if err != nil {
 			return nil, fmt.Errorf("got invalid queue timestamp: %v", err)
 		}
-		dupCheckRow, err := t.tx.QueryContext(ctx, insertLeafDataSQL, t.treeID, leaf.LeafIdentityHash, leaf.LeafValue, leaf.ExtraData, qTimestamp.UnixNano())
+		value, extraData, err := t.compressLeaf(leaf)
+		if err != nil {
+			return nil, err
+		}
+		dupCheckRow, err := t.tx.QueryContext(ctx, insertLeafDataSQL, t.treeID, leaf.LeafIdentityHash, value, extraData, qTimestamp.UnixNano())
 		if err != nil {
 			return nil, fmt.Errorf("dupecheck failed: %v", err)
 		}
@@ -599,6 +763,10 @@ func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.L
 		if got, want := len(leaf.LeafIdentityHash), t.hashSizeBytes; got != want {
 			return nil, status.Errorf(codes.FailedPrecondition, "leaves[%d] has incorrect hash size %d, want %d", i, got, want)
 		}
+		queueNanos, integrateNanos, err := storage.SequencedLeafTimestamps(leaf, timestamp)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "leaves[%d]: %v", i, err)
+		}
 
 		if _, err := t.tx.ExecContext(ctx, savepoint); err != nil {
 			glog.Errorf("Error updating savepoint: %s", err)
@@ -607,9 +775,13 @@ func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.L
 
 		res[i] = &trillian.QueuedLogLeaf{Status: ok}
 
+		value, extraData, err := t.compressLeaf(leaf)
+		if err != nil {
+			return nil, err
+		}
 		// TODO(pavelkalinnikov): Measure latencies.
-		_, err := t.tx.ExecContext(ctx, insertLeafDataSQL,
-			t.treeID, leaf.LeafIdentityHash, leaf.LeafValue, leaf.ExtraData, timestamp.UnixNano())
+		_, err = t.tx.ExecContext(ctx, insertLeafDataSQL,
+			t.treeID, leaf.LeafIdentityHash, value, extraData, queueNanos)
 		// TODO(pavelkalinnikov): Detach PREORDERED_LOG integration latency metric.
 		if err != nil {
 			glog.Errorf("Error inserting leaves[%d] into LeafData: %s", i, err)
@@ -617,7 +789,7 @@ func (t *logTreeTX) AddSequencedLeaves(ctx context.Context, leaves []*trillian.L
 		}
 
 		dupCheckRow, err := t.tx.QueryContext(ctx, insertSequencedLeafSQL,
-			t.treeID, leaf.LeafIndex, leaf.LeafIdentityHash, leaf.MerkleLeafHash, 0)
+			t.treeID, leaf.LeafIndex, leaf.LeafIdentityHash, leaf.MerkleLeafHash, integrateNanos)
 		// TODO(pavelkalinnikov): Update IntegrateTimestamp on integrating the leaf.
 		resultData := true
 		for dupCheckRow.Next() {
@@ -706,6 +878,9 @@ func (t *logTreeTX) GetLeavesByIndex(ctx context.Context, leaves []int64) ([]*tr
 			glog.Warningf("Failed to scan merkle leaves: %s", err)
 			return nil, err
 		}
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, err
+		}
 		var err error
 		leaf.QueueTimestamp, err = ptypes.TimestampProto(time.Unix(0, qTimestamp))
 		if err != nil {
@@ -769,6 +944,9 @@ func (t *logTreeTX) GetLeavesByRange(ctx context.Context, start, count int64) ([
 			glog.Warningf("Failed to scan merkle leaves: %s", err)
 			return nil, err
 		}
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, err
+		}
 		if leaf.LeafIndex != wantIndex {
 			if wantIndex < int64(t.root.TreeSize) {
 				return nil, fmt.Errorf("got unexpected index %d, want %d", leaf.LeafIndex, wantIndex)
@@ -829,7 +1007,7 @@ func (t *logTreeTX) fetchLatestRoot(ctx context.Context) (*trillian.SignedLogRoo
 	}
 	var logRoot types.LogRootV1
 	json.Unmarshal(jsonObj, &logRoot)
-	newRoot, _ := logRoot.MarshalBinary()
+	newRoot, _ := logRoot.Marshal(t.encoding)
 	return &trillian.SignedLogRoot{
 		KeyHint:          types.SerializeKeyHint(t.treeID),
 		LogRoot:          newRoot,
@@ -837,6 +1015,64 @@ func (t *logTreeTX) fetchLatestRoot(ctx context.Context) (*trillian.SignedLogRoo
 	}, nil
 }
 
+func (t *logTreeTX) SignedLogRootHistory(ctx context.Context, limit int) ([]*trillian.SignedLogRoot, error) {
+	rows, err := t.tx.QueryContext(ctx, selectSignedLogRootHistorySQL, t.treeID, limit)
+	if err != nil {
+		glog.Warningf("Failed to select signed root history: %s", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ret []*trillian.SignedLogRoot
+	for rows.Next() {
+		var timestamp, treeSize, treeRevision int64
+		var rootHash, rootSignatureBytes []byte
+		if err := rows.Scan(&timestamp, &treeSize, &rootHash, &treeRevision, &rootSignatureBytes); err != nil {
+			return nil, err
+		}
+		root, err := t.signedLogRoot(timestamp, treeSize, treeRevision, rootHash, rootSignatureBytes)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, root)
+	}
+	return ret, rows.Err()
+}
+
+func (t *logTreeTX) SignedLogRootAtSize(ctx context.Context, treeSize int64) (*trillian.SignedLogRoot, error) {
+	var timestamp, size, treeRevision int64
+	var rootHash, rootSignatureBytes []byte
+	err := t.tx.QueryRowContext(ctx, selectSignedLogRootAtSizeSQL, t.treeID, treeSize).Scan(
+		&timestamp, &size, &rootHash, &treeRevision, &rootSignatureBytes)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		glog.Warningf("Failed to select signed root at size %d: %s", treeSize, err)
+		return nil, err
+	}
+	return t.signedLogRoot(timestamp, size, treeRevision, rootHash, rootSignatureBytes)
+}
+
+// signedLogRoot puts a SignedLogRoot back together from the columns of a
+// tree_head row.
+func (t *logTreeTX) signedLogRoot(timestamp, treeSize, treeRevision int64, rootHash, rootSignatureBytes []byte) (*trillian.SignedLogRoot, error) {
+	logRoot, err := (&types.LogRootV1{
+		RootHash:       rootHash,
+		TimestampNanos: uint64(timestamp),
+		Revision:       uint64(treeRevision),
+		TreeSize:       uint64(treeSize),
+	}).Marshal(t.encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &trillian.SignedLogRoot{
+		KeyHint:          types.SerializeKeyHint(t.treeID),
+		LogRoot:          logRoot,
+		LogRootSignature: rootSignatureBytes,
+	}, nil
+}
+
 func (t *logTreeTX) StoreSignedLogRoot(ctx context.Context, root *trillian.SignedLogRoot) error {
 	var logRoot types.LogRootV1
 	if err := logRoot.UnmarshalBinary(root.LogRoot); err != nil {
@@ -903,6 +1139,9 @@ func (t *logTreeTX) getLeavesByHashInternal(ctx context.Context, leafHashes [][]
 			glog.Warningf("LogID: %d Scan() %s = %s", t.treeID, desc, err)
 			return nil, err
 		}
+		if err := t.decompressLeaf(ctx, leaf); err != nil {
+			return nil, err
+		}
 		var err error
 		leaf.QueueTimestamp, err = ptypes.TimestampProto(time.Unix(0, queueTS))
 		if err != nil {
@@ -20,7 +20,10 @@ import (
 	"crypto"
 	"crypto/sha256"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"net"
 	"reflect"
 	"sort"
 	"testing"
@@ -39,7 +42,7 @@ import (
 	tcrypto "github.com/google/trillian/crypto"
 	ttestonly "github.com/google/trillian/testonly"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Must be 32 bytes to match sha256 length if it was a real hash
@@ -1362,3 +1365,31 @@ func logTree(logID int64) *trillian.Tree {
 		HashStrategy: trillian.HashStrategy_RFC6962_SHA256,
 	}
 }
+
+func TestIsTransientErr(t *testing.T) {
+	for _, test := range []struct {
+		desc string
+		err  error
+		want bool
+	}{
+		{desc: "badConn", err: driver.ErrBadConn, want: true},
+		{desc: "connectionFailure", err: &pq.Error{Code: "08006"}, want: true},
+		{desc: "deadlock", err: &pq.Error{Code: errCodeDeadlockDetected}, want: true},
+		{desc: "lockNotAvailable", err: &pq.Error{Code: errCodeLockNotAvailable}, want: true},
+		{desc: "uniqueViolation", err: &pq.Error{Code: errCodeUniqueViolation}},
+		{desc: "ioTimeout", err: &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}, want: true},
+		{desc: "noRows", err: sql.ErrNoRows},
+		{desc: "other", err: errors.New("other")},
+	} {
+		if got := isTransientErr(test.err); got != test.want {
+			t.Errorf("%s: isTransientErr(%v) = %v, want %v", test.desc, test.err, got, test.want)
+		}
+	}
+}
+
+// timeoutError is a net.Error which timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
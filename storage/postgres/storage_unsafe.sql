@@ -9,6 +9,9 @@ CREATE TYPE E_TREE_TYPE AS ENUM('LOG', 'MAP', 'PREORDERED_LOG');
 CREATE TYPE E_HASH_STRATEGY AS ENUM('RFC6962_SHA256', 'TEST_MAP_HASHER', 'OBJECT_RFC6962_SHA256', 'CONIKS_SHA512_256', 'CONIKS_SHA256');
 CREATE TYPE E_HASH_ALGORITHM AS ENUM('SHA256');
 CREATE TYPE E_SIGNATURE_ALGORITHM AS ENUM('ECDSA', 'RSA');
+CREATE TYPE E_LOG_ROOT_ENCODING AS ENUM('TLS', 'CBOR');
+CREATE TYPE E_TIMESTAMP_GRANULARITY AS ENUM('TIMESTAMP_GRANULARITY_NANOSECOND', 'TIMESTAMP_GRANULARITY_MILLISECOND', 'TIMESTAMP_GRANULARITY_SECOND');
+CREATE TYPE E_LEAF_COMPRESSION AS ENUM('LEAF_COMPRESSION_NONE', 'LEAF_COMPRESSION_GZIP', 'LEAF_COMPRESSION_ZSTD_DICTIONARY');
 
 -- Tree parameters should not be changed after creation. Doing so can
 -- render the data in the tree unusable or inconsistent.
@@ -28,6 +31,25 @@ CREATE TABLE IF NOT EXISTS trees (
   public_key               BYTEA NOT NULL,
   deleted                  BOOLEAN NOT NULL DEFAULT FALSE,
   delete_time_millis       BIGINT,
+  ordered_leaf_timestamps  BOOLEAN NOT NULL DEFAULT FALSE,
+  caller_leaf_identity_hash BOOLEAN NOT NULL DEFAULT FALSE,
+  hash_extra_data          BOOLEAN NOT NULL DEFAULT FALSE,
+  namespace                VARCHAR(255) NOT NULL DEFAULT '',
+  log_root_encoding        E_LOG_ROOT_ENCODING NOT NULL DEFAULT 'TLS',
+  timestamp_granularity    E_TIMESTAMP_GRANULARITY NOT NULL DEFAULT 'TIMESTAMP_GRANULARITY_NANOSECOND',
+  hash_only                BOOLEAN NOT NULL DEFAULT FALSE,
+  leaf_compression         E_LEAF_COMPRESSION NOT NULL DEFAULT 'LEAF_COMPRESSION_NONE',
+  max_tree_size            BIGINT NOT NULL DEFAULT 0,
+  queue_write_ahead        BOOLEAN NOT NULL DEFAULT FALSE,
+  leaf_encryption          BYTEA,
+  sort_by_queue_timestamp  BOOLEAN NOT NULL DEFAULT FALSE,
+  leaf_ordering_key        BYTEA,
+  leaf_tombstones          BOOLEAN NOT NULL DEFAULT FALSE,
+  empty_root_hash          BYTEA,
+  leaf_compression_dictionaries BYTEA,
+  leaf_index_offset        BIGINT NOT NULL DEFAULT 0,
+  signing_interval_millis  BIGINT NOT NULL DEFAULT 0,
+  max_queue_age_millis     BIGINT NOT NULL DEFAULT 0,
   current_tree_data        json,
   root_signature	   BYTEA,
   PRIMARY KEY(tree_id)
@@ -43,6 +65,23 @@ CREATE TABLE IF NOT EXISTS tree_control(
   PRIMARY KEY(tree_id)
 );
 
+-- Named sets of tree parameters that trees can be created from. template holds
+-- a serialized trillian.TreeTemplate proto.
+CREATE TABLE IF NOT EXISTS tree_templates(
+  name                  VARCHAR(63) NOT NULL,
+  template              BYTEA NOT NULL,
+  PRIMARY KEY(name)
+);
+
+-- Signed records of the settings trees were created with, see
+-- trillian.TreeAttestation. Written once when the tree is created.
+CREATE TABLE IF NOT EXISTS tree_attestations(
+  tree_id               BIGINT NOT NULL,
+  tree                  BYTEA NOT NULL,
+  signature             BYTEA NOT NULL,
+  PRIMARY KEY(tree_id)
+);
+
 CREATE TABLE IF NOT EXISTS subtree(
   tree_id               BIGINT NOT NULL,
   subtree_id            BYTEA NOT NULL,
@@ -136,6 +175,19 @@ CREATE TABLE IF NOT EXISTS unsequenced(
   PRIMARY KEY (queue_timestamp_nanos, leaf_identity_hash)
 );
 
+-- Leaves which the sequencer could not integrate are moved here from the
+-- unsequenced table, along with the reason, so that they don't block the rest
+-- of the queue. They stay here until they are requeued.
+CREATE TABLE IF NOT EXISTS quarantined_leaves(
+  tree_id                    BIGINT NOT NULL,
+  leaf_identity_hash         BYTEA NOT NULL,
+  merkle_leaf_hash           BYTEA NOT NULL,
+  queue_timestamp_nanos      BIGINT NOT NULL,
+  quarantine_timestamp_nanos BIGINT NOT NULL,
+  error                      TEXT NOT NULL,
+  PRIMARY KEY(tree_id, leaf_identity_hash)
+);
+
 CREATE OR REPLACE FUNCTION public.insert_leaf_data_ignore_duplicates(tree_id bigint, leaf_identity_hash bytea, leaf_value bytea, extra_data bytea, queue_timestamp_nanos bigint)
  RETURNS boolean
  LANGUAGE plpgsql
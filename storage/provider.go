@@ -54,6 +54,8 @@ func NewProviderFromFlags(mf monitoring.MetricFactory) (Provider, error) {
 }
 
 // NewProvider returns a new Provider instance of the type specified by name.
+// The durations of the transactions of its storage are exported with the
+// "storage_tx_duration" histogram of mf, labelled by name.
 func NewProvider(name string, mf monitoring.MetricFactory) (Provider, error) {
 	spMu.RLock()
 	defer spMu.RUnlock()
@@ -63,7 +65,11 @@ func NewProvider(name string, mf monitoring.MetricFactory) (Provider, error) {
 		return nil, fmt.Errorf("no such storage provider %v", name)
 	}
 
-	return sp(mf)
+	p, err := sp(mf)
+	if err != nil {
+		return nil, err
+	}
+	return newInstrumentedProvider(p, name, mf), nil
 }
 
 // providers returns a slice of all registered storage provider names.
@@ -92,3 +98,19 @@ type Provider interface {
 	// Close closes the underlying storage.
 	Close() error
 }
+
+// UnsequencedCounterProvider is optionally implemented by Providers whose log
+// storage transactions implement UnsequencedCounter.
+type UnsequencedCounterProvider interface {
+	// CountsUnsequenced returns whether the transactions of the LogStorage
+	// of the Provider implement UnsequencedCounter.
+	CountsUnsequenced() bool
+}
+
+// CountsUnsequenced returns whether the log storage transactions of p
+// implement UnsequencedCounter, as reported by an UnsequencedCounterProvider.
+// Features relying on it can thus be rejected on startup.
+func CountsUnsequenced(p Provider) bool {
+	c, ok := p.(UnsequencedCounterProvider)
+	return ok && c.CountsUnsequenced()
+}
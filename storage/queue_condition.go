@@ -0,0 +1,37 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/google/trillian/storage/storagepb"
+)
+
+type queueConditionKey struct{}
+
+// NewQueueConditionContext returns a ctx carrying the condition c, which
+// storage implementations store with the leaves queued by QueueLeaves in that
+// context (see QueueConditionReader).
+func NewQueueConditionContext(ctx context.Context, c *storagepb.QueueCondition) context.Context {
+	return context.WithValue(ctx, queueConditionKey{}, c)
+}
+
+// QueueConditionFromContext returns the queue condition within ctx, or nil if
+// there is none.
+func QueueConditionFromContext(ctx context.Context) *storagepb.QueueCondition {
+	c, _ := ctx.Value(queueConditionKey{}).(*storagepb.QueueCondition)
+	return c
+}
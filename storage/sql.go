@@ -22,6 +22,7 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
 	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keyspb"
 	spb "github.com/google/trillian/crypto/sigpb"
@@ -54,10 +55,10 @@ func ReadTree(row Row) (*trillian.Tree, error) {
 	tree := &trillian.Tree{}
 
 	// Enums and Datetimes need an extra conversion step
-	var treeState, treeType, hashStrategy, hashAlgorithm, signatureAlgorithm string
-	var createMillis, updateMillis, maxRootDurationMillis int64
+	var treeState, treeType, hashStrategy, hashAlgorithm, signatureAlgorithm, logRootEncoding, timestampGranularity, leafCompression string
+	var createMillis, updateMillis, maxRootDurationMillis, signingIntervalMillis, maxQueueAgeMillis int64
 	var displayName, description sql.NullString
-	var privateKey, publicKey []byte
+	var privateKey, publicKey, leafEncryption, leafOrderingKey, leafCompressionDictionaries []byte
 	var deleted sql.NullBool
 	var deleteMillis sql.NullInt64
 	err := row.Scan(
@@ -76,6 +77,25 @@ func ReadTree(row Row) (*trillian.Tree, error) {
 		&maxRootDurationMillis,
 		&deleted,
 		&deleteMillis,
+		&tree.OrderedLeafTimestamps,
+		&tree.CallerLeafIdentityHash,
+		&tree.HashExtraData,
+		&tree.Namespace,
+		&logRootEncoding,
+		&timestampGranularity,
+		&tree.HashOnly,
+		&leafCompression,
+		&tree.MaxTreeSize,
+		&tree.QueueWriteAhead,
+		&leafEncryption,
+		&tree.SortByQueueTimestamp,
+		&leafOrderingKey,
+		&tree.LeafTombstones,
+		&tree.EmptyRootHash,
+		&leafCompressionDictionaries,
+		&tree.LeafIndexOffset,
+		&signingIntervalMillis,
+		&maxQueueAgeMillis,
 	)
 	if err != nil {
 		return nil, err
@@ -110,18 +130,36 @@ func ReadTree(row Row) (*trillian.Tree, error) {
 	} else {
 		return nil, fmt.Errorf("unknown SignatureAlgorithm: %v", signatureAlgorithm)
 	}
+	if le, ok := trillian.LogRootEncoding_value[logRootEncoding]; ok {
+		tree.LogRootEncoding = trillian.LogRootEncoding(le)
+	} else {
+		return nil, fmt.Errorf("unknown LogRootEncoding: %v", logRootEncoding)
+	}
+	if tg, ok := trillian.TimestampGranularity_value[timestampGranularity]; ok {
+		tree.TimestampGranularity = trillian.TimestampGranularity(tg)
+	} else {
+		return nil, fmt.Errorf("unknown TimestampGranularity: %v", timestampGranularity)
+	}
+	if lc, ok := trillian.LeafCompression_value[leafCompression]; ok {
+		tree.LeafCompression = trillian.LeafCompression(lc)
+	} else {
+		return nil, fmt.Errorf("unknown LeafCompression: %v", leafCompression)
+	}
 
 	// Let's make sure we didn't mismatch any of the casts above
 	ok := tree.TreeState.String() == treeState &&
 		tree.TreeType.String() == treeType &&
 		tree.HashStrategy.String() == hashStrategy &&
 		tree.HashAlgorithm.String() == hashAlgorithm &&
-		tree.SignatureAlgorithm.String() == signatureAlgorithm
+		tree.SignatureAlgorithm.String() == signatureAlgorithm &&
+		tree.LogRootEncoding.String() == logRootEncoding &&
+		tree.TimestampGranularity.String() == timestampGranularity &&
+		tree.LeafCompression.String() == leafCompression
 	if !ok {
 		return nil, fmt.Errorf(
-			"mismatched enum: tree = %v, enums = [%v, %v, %v, %v, %v]",
+			"mismatched enum: tree = %v, enums = [%v, %v, %v, %v, %v, %v, %v, %v]",
 			tree,
-			treeState, treeType, hashStrategy, hashAlgorithm, signatureAlgorithm)
+			treeState, treeType, hashStrategy, hashAlgorithm, signatureAlgorithm, logRootEncoding, timestampGranularity, leafCompression)
 	}
 
 	tree.CreateTime, err = ptypes.TimestampProto(FromMillisSinceEpoch(createMillis))
@@ -133,12 +171,30 @@ func ReadTree(row Row) (*trillian.Tree, error) {
 		return nil, fmt.Errorf("failed to parse update time: %v", err)
 	}
 	tree.MaxRootDuration = ptypes.DurationProto(time.Duration(maxRootDurationMillis * int64(time.Millisecond)))
+	tree.SigningInterval = optionalDuration(signingIntervalMillis)
+	tree.MaxQueueAge = optionalDuration(maxQueueAgeMillis)
 
 	tree.PrivateKey = &any.Any{}
 	if err := proto.Unmarshal(privateKey, tree.PrivateKey); err != nil {
 		return nil, fmt.Errorf("could not unmarshal PrivateKey: %v", err)
 	}
 	tree.PublicKey = &keyspb.PublicKey{Der: publicKey}
+	if len(leafEncryption) > 0 {
+		tree.LeafEncryption = &trillian.LeafEncryption{}
+		if err := proto.Unmarshal(leafEncryption, tree.LeafEncryption); err != nil {
+			return nil, fmt.Errorf("could not unmarshal LeafEncryption: %v", err)
+		}
+	}
+	if len(leafOrderingKey) > 0 {
+		tree.LeafOrderingKey = &trillian.LeafOrderingKey{}
+		if err := proto.Unmarshal(leafOrderingKey, tree.LeafOrderingKey); err != nil {
+			return nil, fmt.Errorf("could not unmarshal LeafOrderingKey: %v", err)
+		}
+	}
+
+	if tree.LeafCompressionDictionaries, err = UnmarshalLeafCompressionDictionaries(leafCompressionDictionaries); err != nil {
+		return nil, err
+	}
 
 	tree.Deleted = deleted.Valid && deleted.Bool
 	if tree.Deleted && deleteMillis.Valid {
@@ -150,3 +206,114 @@ func ReadTree(row Row) (*trillian.Tree, error) {
 
 	return tree, nil
 }
+
+// MarshalLeafEncryption serializes e for storage in a nullable column, as
+// read by ReadTree. It returns nil, i.e. NULL, if e is nil.
+func MarshalLeafEncryption(e *trillian.LeafEncryption) ([]byte, error) {
+	if e == nil {
+		return nil, nil
+	}
+	b, err := proto.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal LeafEncryption: %v", err)
+	}
+	return b, nil
+}
+
+// OptionalDurationMillis returns d in milliseconds, for storage of an
+// optional duration in a column defaulting to 0, as read by ReadTree. It
+// returns 0 if d is unset.
+func OptionalDurationMillis(d *duration.Duration) (int64, error) {
+	if d == nil {
+		return 0, nil
+	}
+	dur, err := ptypes.Duration(d)
+	if err != nil {
+		return 0, err
+	}
+	return int64(dur / time.Millisecond), nil
+}
+
+// optionalDuration returns the optional duration stored as ms milliseconds
+// by OptionalDurationMillis.
+func optionalDuration(ms int64) *duration.Duration {
+	if ms == 0 {
+		return nil
+	}
+	return ptypes.DurationProto(time.Duration(ms) * time.Millisecond)
+}
+
+// MarshalLeafCompressionDictionaries serializes ds for storage in a nullable
+// column, as read by ReadTree. It returns nil, i.e. NULL, if ds is empty. The
+// dictionaries are stored as a Tree holding only them, which avoids a message
+// of their own.
+func MarshalLeafCompressionDictionaries(ds []*trillian.LeafCompressionDictionary) ([]byte, error) {
+	if len(ds) == 0 {
+		return nil, nil
+	}
+	b, err := proto.Marshal(&trillian.Tree{LeafCompressionDictionaries: ds})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal LeafCompressionDictionaries: %v", err)
+	}
+	return b, nil
+}
+
+// UnmarshalLeafCompressionDictionaries parses the dictionaries stored as b by
+// MarshalLeafCompressionDictionaries.
+func UnmarshalLeafCompressionDictionaries(b []byte) ([]*trillian.LeafCompressionDictionary, error) {
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var dicts trillian.Tree
+	if err := proto.Unmarshal(b, &dicts); err != nil {
+		return nil, fmt.Errorf("could not unmarshal LeafCompressionDictionaries: %v", err)
+	}
+	return dicts.LeafCompressionDictionaries, nil
+}
+
+// MarshalLeafOrderingKey serializes k for storage in a nullable column, as
+// read by ReadTree. It returns nil, i.e. NULL, if k is nil.
+func MarshalLeafOrderingKey(k *trillian.LeafOrderingKey) ([]byte, error) {
+	if k == nil {
+		return nil, nil
+	}
+	b, err := proto.Marshal(k)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal LeafOrderingKey: %v", err)
+	}
+	return b, nil
+}
+
+// UnmarshalTreeTemplate parses a tree template stored as a serialized proto.
+func UnmarshalTreeTemplate(b []byte) (*trillian.TreeTemplate, error) {
+	var tmpl trillian.TreeTemplate
+	if err := proto.Unmarshal(b, &tmpl); err != nil {
+		return nil, fmt.Errorf("failed to parse tree template: %v", err)
+	}
+	return &tmpl, nil
+}
+
+// SequencedLeafTimestamps returns the queue and integrate timestamps to store
+// for a leaf added via AddSequencedLeaves, in nanos since epoch.
+// Timestamps supplied by the caller are preserved verbatim. Otherwise, the
+// queue timestamp defaults to queueTimestamp and the integrate timestamp to
+// zero.
+func SequencedLeafTimestamps(leaf *trillian.LogLeaf, queueTimestamp time.Time) (int64, int64, error) {
+	queueNanos := queueTimestamp.UnixNano()
+	if leaf.QueueTimestamp != nil {
+		ts, err := ptypes.Timestamp(leaf.QueueTimestamp)
+		if err != nil {
+			return 0, 0, fmt.Errorf("got invalid queue timestamp: %v", err)
+		}
+		queueNanos = ts.UnixNano()
+	}
+	var integrateNanos int64
+	if leaf.IntegrateTimestamp != nil {
+		ts, err := ptypes.Timestamp(leaf.IntegrateTimestamp)
+		if err != nil {
+			return 0, 0, fmt.Errorf("got invalid integrate timestamp: %v", err)
+		}
+		integrateNanos = ts.UnixNano()
+	}
+	return queueNanos, integrateNanos, nil
+}
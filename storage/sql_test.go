@@ -0,0 +1,83 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	tspb "github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/google/trillian"
+)
+
+func TestSequencedLeafTimestamps(t *testing.T) {
+	now := time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC)
+	queued := now.Add(-time.Hour)
+	integrated := now.Add(-time.Minute)
+
+	for _, test := range []struct {
+		desc                     string
+		leaf                     *trillian.LogLeaf
+		wantQueue, wantIntegrate int64
+		wantErr                  bool
+	}{
+		{
+			desc:      "defaults",
+			leaf:      &trillian.LogLeaf{},
+			wantQueue: now.UnixNano(),
+		},
+		{
+			desc: "supplied",
+			leaf: &trillian.LogLeaf{
+				QueueTimestamp:     mustTimestampProto(t, queued),
+				IntegrateTimestamp: mustTimestampProto(t, integrated),
+			},
+			wantQueue:     queued.UnixNano(),
+			wantIntegrate: integrated.UnixNano(),
+		},
+		{
+			desc:    "invalid queue timestamp",
+			leaf:    &trillian.LogLeaf{QueueTimestamp: &tspb.Timestamp{Nanos: -1}},
+			wantErr: true,
+		},
+		{
+			desc:    "invalid integrate timestamp",
+			leaf:    &trillian.LogLeaf{IntegrateTimestamp: &tspb.Timestamp{Nanos: -1}},
+			wantErr: true,
+		},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			queue, integrate, err := SequencedLeafTimestamps(test.leaf, now)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("SequencedLeafTimestamps() = (_, _, %v), wantErr %v", err, test.wantErr)
+			} else if gotErr {
+				return
+			}
+			if queue != test.wantQueue || integrate != test.wantIntegrate {
+				t.Errorf("SequencedLeafTimestamps() = (%v, %v, nil), want (%v, %v, nil)", queue, integrate, test.wantQueue, test.wantIntegrate)
+			}
+		})
+	}
+}
+
+func mustTimestampProto(t *testing.T, ts time.Time) *tspb.Timestamp {
+	t.Helper()
+	pb, err := ptypes.TimestampProto(ts)
+	if err != nil {
+		t.Fatalf("TimestampProto(%v): %v", ts, err)
+	}
+	return pb
+}
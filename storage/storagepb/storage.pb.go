@@ -1,5 +1,5 @@
 // Code generated by protoc-gen-go. DO NOT EDIT.
-// source: storage.proto
+// source: storage/storagepb/storage.proto
 
 package storagepb
 
@@ -34,7 +34,7 @@ func (m *NodeIDProto) Reset()         { *m = NodeIDProto{} }
 func (m *NodeIDProto) String() string { return proto.CompactTextString(m) }
 func (*NodeIDProto) ProtoMessage()    {}
 func (*NodeIDProto) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0d2c4ccf1453ffdb, []int{0}
+	return fileDescriptor_22a67192205f4493, []int{0}
 }
 
 func (m *NodeIDProto) XXX_Unmarshal(b []byte) error {
@@ -95,7 +95,7 @@ func (m *SubtreeProto) Reset()         { *m = SubtreeProto{} }
 func (m *SubtreeProto) String() string { return proto.CompactTextString(m) }
 func (*SubtreeProto) ProtoMessage()    {}
 func (*SubtreeProto) Descriptor() ([]byte, []int) {
-	return fileDescriptor_0d2c4ccf1453ffdb, []int{1}
+	return fileDescriptor_22a67192205f4493, []int{1}
 }
 
 func (m *SubtreeProto) XXX_Unmarshal(b []byte) error {
@@ -158,35 +158,101 @@ func (m *SubtreeProto) GetInternalNodeCount() uint32 {
 	return 0
 }
 
+// QueueCondition is stored with each leaf queued by a conditional QueueLeaves
+// request, so that the log signer only integrates the whole batch at the
+// expected tree size.
+type QueueCondition struct {
+	// Identifies the batch of leaves queued by the request.
+	BatchId []byte `protobuf:"bytes,1,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	// The tree size the log must have when the batch is integrated.
+	TreeSize int64 `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	// The number of leaves in the batch.
+	BatchSize            int64    `protobuf:"varint,3,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QueueCondition) Reset()         { *m = QueueCondition{} }
+func (m *QueueCondition) String() string { return proto.CompactTextString(m) }
+func (*QueueCondition) ProtoMessage()    {}
+func (*QueueCondition) Descriptor() ([]byte, []int) {
+	return fileDescriptor_22a67192205f4493, []int{2}
+}
+
+func (m *QueueCondition) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueueCondition.Unmarshal(m, b)
+}
+func (m *QueueCondition) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueueCondition.Marshal(b, m, deterministic)
+}
+func (m *QueueCondition) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueueCondition.Merge(m, src)
+}
+func (m *QueueCondition) XXX_Size() int {
+	return xxx_messageInfo_QueueCondition.Size(m)
+}
+func (m *QueueCondition) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueueCondition.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueueCondition proto.InternalMessageInfo
+
+func (m *QueueCondition) GetBatchId() []byte {
+	if m != nil {
+		return m.BatchId
+	}
+	return nil
+}
+
+func (m *QueueCondition) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *QueueCondition) GetBatchSize() int64 {
+	if m != nil {
+		return m.BatchSize
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*NodeIDProto)(nil), "storagepb.NodeIDProto")
 	proto.RegisterType((*SubtreeProto)(nil), "storagepb.SubtreeProto")
 	proto.RegisterMapType((map[string][]byte)(nil), "storagepb.SubtreeProto.InternalNodesEntry")
 	proto.RegisterMapType((map[string][]byte)(nil), "storagepb.SubtreeProto.LeavesEntry")
+	proto.RegisterType((*QueueCondition)(nil), "storagepb.QueueCondition")
 }
 
-func init() { proto.RegisterFile("storage.proto", fileDescriptor_0d2c4ccf1453ffdb) }
-
-var fileDescriptor_0d2c4ccf1453ffdb = []byte{
-	// 308 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x91, 0x4d, 0x4b, 0xc3, 0x40,
-	0x10, 0x86, 0x49, 0xd3, 0x06, 0x3b, 0x6d, 0xfc, 0x58, 0x45, 0x96, 0x7a, 0x09, 0x15, 0x24, 0x78,
-	0xc8, 0x41, 0x2f, 0x7e, 0x5c, 0xc4, 0x0f, 0xb0, 0x50, 0x44, 0xd7, 0x1f, 0x10, 0x36, 0x66, 0x34,
-	0xc1, 0xb0, 0x1b, 0x76, 0x37, 0xc5, 0xfe, 0x11, 0x7f, 0xaf, 0x64, 0xb3, 0x48, 0x44, 0x3c, 0x78,
-	0x9b, 0x77, 0xe6, 0x9d, 0x27, 0x93, 0x77, 0x21, 0xd4, 0x46, 0x2a, 0xfe, 0x86, 0x49, 0xad, 0xa4,
-	0x91, 0x64, 0xec, 0x64, 0x9d, 0xcd, 0x17, 0x30, 0x79, 0x90, 0x39, 0x2e, 0x6e, 0x1f, 0xed, 0x84,
-	0xc0, 0xb0, 0xe6, 0xa6, 0xa0, 0x5e, 0xe4, 0xc5, 0x53, 0x66, 0x6b, 0x72, 0x04, 0x5b, 0xb5, 0xc2,
-	0xd7, 0xf2, 0x23, 0xad, 0x50, 0xa4, 0x59, 0x69, 0x34, 0x1d, 0x44, 0x5e, 0x3c, 0x62, 0x61, 0xd7,
-	0x5e, 0xa2, 0xb8, 0x2e, 0x8d, 0x9e, 0x7f, 0xfa, 0x30, 0x7d, 0x6e, 0x32, 0xa3, 0x10, 0x3b, 0xd8,
-	0x3e, 0x04, 0x9d, 0xc3, 0xe1, 0x9c, 0x22, 0x7b, 0x30, 0xca, 0xb1, 0x36, 0x85, 0xc3, 0x74, 0x82,
-	0x1c, 0xc0, 0x58, 0x49, 0x69, 0xd2, 0x82, 0xeb, 0x82, 0xfa, 0x76, 0x61, 0xa3, 0x6d, 0xdc, 0x73,
-	0x5d, 0x90, 0x4b, 0x08, 0x2a, 0xe4, 0x2b, 0xd4, 0x74, 0x18, 0xf9, 0xf1, 0xe4, 0xe4, 0x30, 0xf9,
-	0xfe, 0x85, 0xa4, 0xff, 0xcd, 0x64, 0x69, 0x5d, 0x77, 0xc2, 0xa8, 0x35, 0x73, 0x2b, 0xe4, 0x09,
-	0x36, 0x4b, 0x61, 0x50, 0x09, 0x5e, 0xa5, 0x42, 0xe6, 0xa8, 0xe9, 0xc8, 0x42, 0x8e, 0xff, 0x82,
-	0x2c, 0x9c, 0xbb, 0x4d, 0xc6, 0xb1, 0xc2, 0xb2, 0xdf, 0x23, 0x09, 0xec, 0xfe, 0x40, 0xa6, 0x2f,
-	0xb2, 0x11, 0x86, 0x06, 0x91, 0x17, 0x87, 0x6c, 0xa7, 0xef, 0xbd, 0x69, 0x07, 0xb3, 0x73, 0x98,
-	0xf4, 0x2e, 0x23, 0xdb, 0xe0, 0xbf, 0xe3, 0xda, 0xc6, 0x32, 0x66, 0x6d, 0xd9, 0x66, 0xb2, 0xe2,
-	0x55, 0x83, 0x36, 0x93, 0x29, 0xeb, 0xc4, 0xc5, 0xe0, 0xcc, 0x9b, 0x5d, 0x01, 0xf9, 0x7d, 0xcf,
-	0x7f, 0x08, 0x59, 0x60, 0x5f, 0xfd, 0xf4, 0x2b, 0x00, 0x00, 0xff, 0xff, 0xf4, 0xae, 0x38, 0x20,
-	0x06, 0x02, 0x00, 0x00,
+func init() { proto.RegisterFile("storage/storagepb/storage.proto", fileDescriptor_22a67192205f4493) }
+
+var fileDescriptor_22a67192205f4493 = []byte{
+	// 373 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x92, 0xdf, 0x8a, 0xd3, 0x40,
+	0x14, 0xc6, 0x49, 0xd3, 0xc6, 0xe6, 0xf4, 0x8f, 0x3a, 0x8a, 0xc4, 0x8a, 0x18, 0x2a, 0x48, 0xf0,
+	0x22, 0x82, 0xde, 0xf8, 0xe7, 0x46, 0xac, 0x82, 0x81, 0x22, 0x36, 0x7d, 0x80, 0x30, 0x69, 0xce,
+	0x36, 0xc3, 0x86, 0x99, 0x90, 0x99, 0x94, 0x6d, 0x1f, 0x64, 0x9f, 0x77, 0x99, 0xc9, 0x6c, 0xc9,
+	0xb2, 0xec, 0xc5, 0x5e, 0xe5, 0x9c, 0xef, 0x3b, 0xe7, 0x97, 0xc9, 0x37, 0x81, 0x77, 0x52, 0x89,
+	0x86, 0xee, 0xf1, 0x93, 0x7d, 0xd6, 0xf9, 0x6d, 0x15, 0xd7, 0x8d, 0x50, 0x82, 0xf8, 0x67, 0x63,
+	0x99, 0xc0, 0xe4, 0x9f, 0x28, 0x30, 0xf9, 0xfd, 0xdf, 0x38, 0x04, 0x86, 0x35, 0x55, 0x65, 0xe0,
+	0x84, 0x4e, 0x34, 0x4d, 0x4d, 0x4d, 0x3e, 0xc0, 0xd3, 0xba, 0xc1, 0x0b, 0x76, 0x95, 0x55, 0xc8,
+	0xb3, 0x9c, 0x29, 0x19, 0x0c, 0x42, 0x27, 0x1a, 0xa5, 0xb3, 0x4e, 0x5e, 0x23, 0xff, 0xc5, 0x94,
+	0x5c, 0x5e, 0xbb, 0x30, 0xdd, 0xb6, 0xb9, 0x6a, 0x10, 0x3b, 0xd8, 0x2b, 0xf0, 0xba, 0x09, 0x8b,
+	0xb3, 0x1d, 0x79, 0x09, 0xa3, 0x02, 0x6b, 0x55, 0x5a, 0x4c, 0xd7, 0x90, 0x37, 0xe0, 0x37, 0x42,
+	0xa8, 0xac, 0xa4, 0xb2, 0x0c, 0x5c, 0xb3, 0x30, 0xd6, 0xc2, 0x5f, 0x2a, 0x4b, 0xf2, 0x03, 0xbc,
+	0x0a, 0xe9, 0x01, 0x65, 0x30, 0x0c, 0xdd, 0x68, 0xf2, 0xf9, 0x7d, 0x7c, 0xfe, 0x84, 0xb8, 0xff,
+	0xce, 0x78, 0x6d, 0xa6, 0xfe, 0x70, 0xd5, 0x1c, 0x53, 0xbb, 0x42, 0x36, 0x30, 0x67, 0x5c, 0x61,
+	0xc3, 0x69, 0x95, 0x71, 0x51, 0xa0, 0x0c, 0x46, 0x06, 0xf2, 0xf1, 0x21, 0x48, 0x62, 0xa7, 0x75,
+	0x32, 0x96, 0x35, 0x63, 0x7d, 0x8d, 0xc4, 0xf0, 0xe2, 0x0e, 0x32, 0xdb, 0x89, 0x96, 0xab, 0xc0,
+	0x0b, 0x9d, 0x68, 0x96, 0x3e, 0xef, 0xcf, 0xae, 0xb4, 0xb1, 0xf8, 0x06, 0x93, 0xde, 0xc9, 0xc8,
+	0x33, 0x70, 0x2f, 0xf1, 0x68, 0x62, 0xf1, 0x53, 0x5d, 0xea, 0x4c, 0x0e, 0xb4, 0x6a, 0xd1, 0x64,
+	0x32, 0x4d, 0xbb, 0xe6, 0xfb, 0xe0, 0xab, 0xb3, 0xf8, 0x09, 0xe4, 0xfe, 0x79, 0x1e, 0x43, 0x58,
+	0xee, 0x61, 0xbe, 0x69, 0xb1, 0xc5, 0x95, 0xe0, 0x05, 0x53, 0x4c, 0x70, 0xf2, 0x1a, 0xc6, 0x39,
+	0x55, 0xbb, 0x32, 0x63, 0x85, 0xbd, 0x9b, 0x27, 0xa6, 0x4f, 0x0a, 0x7d, 0x0d, 0x3a, 0x88, 0x4c,
+	0xb2, 0x53, 0x87, 0x72, 0xd3, 0xb1, 0x16, 0xb6, 0xec, 0x84, 0xe4, 0x2d, 0x40, 0xb7, 0x67, 0x5c,
+	0xd7, 0xb8, 0xbe, 0x51, 0xb4, 0x9d, 0x7b, 0xe6, 0xf7, 0xfa, 0x72, 0x33, 0x00, 0x78, 0xb6, 0x99,
+	0xf3, 0x81, 0x02, 0x00, 0x00,
 }
@@ -0,0 +1,55 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/google/trillian/storage/storagepb"
+)
+
+type subtreeCacheKey struct{}
+
+// SubtreeCache caches log subtrees read from storage, so that they can be
+// shared between transactions, e.g. across sequencer runs, goroutines or,
+// with an external implementation, processes.
+//
+// Entries are keyed by tree ID, tree revision and subtree prefix, and hold
+// the most recent version of the subtree at or before that revision. As
+// revisions are immutable once committed, entries never need invalidating.
+// Storage implementations only add committed subtrees to the cache.
+//
+// Implementations must be safe for concurrent use, and must not share
+// subtrees with callers, which may modify them.
+type SubtreeCache interface {
+	// GetSubtree returns the subtree of the tree with the given prefix, as of
+	// the given revision, or nil if it isn't cached.
+	GetSubtree(treeID, revision int64, prefix []byte) *storagepb.SubtreeProto
+	// SetSubtree caches the subtree of the tree as of the given revision.
+	SetSubtree(treeID, revision int64, subtree *storagepb.SubtreeProto)
+}
+
+// NewSubtreeCacheContext returns a context carrying the subtree cache to be
+// used by storage transactions started with it.
+func NewSubtreeCacheContext(ctx context.Context, c SubtreeCache) context.Context {
+	return context.WithValue(ctx, subtreeCacheKey{}, c)
+}
+
+// SubtreeCacheFromContext returns the subtree cache carried by ctx, or nil if
+// there is none.
+func SubtreeCacheFromContext(ctx context.Context) SubtreeCache {
+	c, _ := ctx.Value(subtreeCacheKey{}).(SubtreeCache)
+	return c
+}
@@ -139,6 +139,7 @@ type AdminStorageTester struct {
 // RunAllTests runs all AdminStorage tests.
 func (tester *AdminStorageTester) RunAllTests(t *testing.T) {
 	t.Run("TestCreateTree", tester.TestCreateTree)
+	t.Run("TestCreateTreeWithID", tester.TestCreateTreeWithID)
 	t.Run("TestUpdateTree", tester.TestUpdateTree)
 	t.Run("TestListTrees", tester.TestListTrees)
 	t.Run("TestSoftDeleteTree", tester.TestSoftDeleteTree)
@@ -148,6 +149,8 @@ func (tester *AdminStorageTester) RunAllTests(t *testing.T) {
 	t.Run("TestUndeleteTree", tester.TestUndeleteTree)
 	t.Run("TestUndeleteTreeErrors", tester.TestUndeleteTreeErrors)
 	t.Run("TestAdminTXReadWriteTransaction", tester.TestAdminTXReadWriteTransaction)
+	t.Run("TestTreeTemplates", tester.TestTreeTemplates)
+	t.Run("TestTreeAttestations", tester.TestTreeAttestations)
 }
 
 // TestCreateTree tests AdminStorage Tree creation.
@@ -160,6 +163,36 @@ func (tester *AdminStorageTester) TestCreateTree(t *testing.T) {
 	validTree1 := proto.Clone(LogTree).(*trillian.Tree)
 	validTree2 := proto.Clone(MapTree).(*trillian.Tree)
 	validTree3 := proto.Clone(PreorderedLogTree).(*trillian.Tree)
+	validTree4 := proto.Clone(PreorderedLogTree).(*trillian.Tree)
+	validTree4.OrderedLeafTimestamps = true
+	validTree5 := proto.Clone(LogTree).(*trillian.Tree)
+	validTree5.CallerLeafIdentityHash = true
+	validTree5.HashExtraData = true
+	validTree5.Namespace = "tenant"
+	validTree5.LogRootEncoding = trillian.LogRootEncoding_CBOR
+	validTree5.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND
+	validTree6 := proto.Clone(PreorderedLogTree).(*trillian.Tree)
+	validTree6.HashOnly = true
+	validTree6.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+	validTree6.MaxTreeSize = 1000
+
+	validTree7 := proto.Clone(LogTree).(*trillian.Tree)
+	validTree7.QueueWriteAhead = true
+
+	validTree8 := proto.Clone(LogTree).(*trillian.Tree)
+	validTree8.LeafEncryption = &trillian.LeafEncryption{WrappedDataKey: []byte("wrapped"), KekId: "kek"}
+
+	validTree9 := proto.Clone(LogTree).(*trillian.Tree)
+	validTree9.SortByQueueTimestamp = true
+
+	validTree10 := proto.Clone(LogTree).(*trillian.Tree)
+	validTree10.LeafTombstones = true
+
+	validTree11 := proto.Clone(LogTree).(*trillian.Tree)
+	validTree11.EmptyRootHash = make([]byte, 32)
+
+	validTree12 := proto.Clone(LogTree).(*trillian.Tree)
+	validTree12.LeafIndexOffset = 1000
 
 	validTreeWithoutOptionals := proto.Clone(LogTree).(*trillian.Tree)
 	validTreeWithoutOptionals.DisplayName = ""
@@ -187,6 +220,42 @@ func (tester *AdminStorageTester) TestCreateTree(t *testing.T) {
 			desc: "validTree3",
 			tree: validTree3,
 		},
+		{
+			desc: "validTree4",
+			tree: validTree4,
+		},
+		{
+			desc: "validTree5",
+			tree: validTree5,
+		},
+		{
+			desc: "validTree6",
+			tree: validTree6,
+		},
+		{
+			desc: "validTree7",
+			tree: validTree7,
+		},
+		{
+			desc: "validTree8",
+			tree: validTree8,
+		},
+		{
+			desc: "validTree9",
+			tree: validTree9,
+		},
+		{
+			desc: "validTree10",
+			tree: validTree10,
+		},
+		{
+			desc: "validTree11",
+			tree: validTree11,
+		},
+		{
+			desc: "validTree12",
+			tree: validTree12,
+		},
 		{
 			desc: "validTreeWithoutOptionals",
 			tree: validTreeWithoutOptionals,
@@ -242,6 +311,32 @@ func (tester *AdminStorageTester) TestCreateTree(t *testing.T) {
 	}
 }
 
+// TestCreateTreeWithID tests AdminStorage Tree creation with a caller-specified
+// tree ID.
+func (tester *AdminStorageTester) TestCreateTreeWithID(t *testing.T) {
+	ctx := context.Background()
+	s := tester.NewAdminStorage()
+
+	tree := proto.Clone(LogTree).(*trillian.Tree)
+	tree.TreeId = 424242
+
+	newTree, err := storage.CreateTree(ctx, s, tree)
+	if err != nil {
+		t.Fatalf("CreateTree() = (_, %v), want = (_, nil)", err)
+	}
+	if got, want := newTree.TreeId, tree.TreeId; got != want {
+		t.Errorf("CreateTree().TreeId = %v, want = %v", got, want)
+	}
+	if err := assertStoredTree(ctx, s, newTree); err != nil {
+		t.Error(err)
+	}
+
+	// A second tree with the same ID must be rejected.
+	if _, err := storage.CreateTree(ctx, s, tree); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateTree() with duplicate ID returned err = %v, wantCode = %s", err, codes.AlreadyExists)
+	}
+}
+
 // TestUpdateTree tests AdminStorage Tree updates.
 func (tester *AdminStorageTester) TestUpdateTree(t *testing.T) {
 	ctx := context.Background()
@@ -301,6 +396,16 @@ func (tester *AdminStorageTester) TestUpdateTree(t *testing.T) {
 		})
 	}
 
+	encryptedLog := tweakedCopy(LogTree, func(tree *trillian.Tree) {
+		tree.LeafEncryption = &trillian.LeafEncryption{WrappedDataKey: []byte("wrapped"), KekId: "kek"}
+	})
+	rewrappedLog := tweakedCopy(encryptedLog, func(tree *trillian.Tree) {
+		tree.LeafEncryption = &trillian.LeafEncryption{WrappedDataKey: []byte("rewrapped"), KekId: "kek2"}
+	})
+	rewrapFunc := func(tree *trillian.Tree) {
+		tree.LeafEncryption = rewrappedLog.LeafEncryption
+	}
+
 	// Test for an unknown tree outside the loop: it makes the test logic simpler
 	if _, err := storage.UpdateTree(ctx, s, -1, func(tree *trillian.Tree) {}); err == nil {
 		t.Error("UpdateTree() for treeID -1 returned nil err")
@@ -354,6 +459,12 @@ func (tester *AdminStorageTester) TestUpdateTree(t *testing.T) {
 			updateFunc: privateKeyChangedAndKeyMaterialDifferentFunc,
 			wantErr:    true,
 		},
+		{
+			desc:       "leafDataKeyRewrapped",
+			create:     encryptedLog,
+			updateFunc: rewrapFunc,
+			want:       rewrappedLog,
+		},
 	}
 	for _, test := range tests {
 		createdTree, err := storage.CreateTree(ctx, s, test.create)
@@ -650,6 +761,118 @@ func (tester *AdminStorageTester) TestUndeleteTreeErrors(t *testing.T) {
 	}
 }
 
+// TestTreeTemplates tests the creation, reading, update and deletion of tree
+// templates.
+func (tester *AdminStorageTester) TestTreeTemplates(t *testing.T) {
+	ctx := context.Background()
+	s := tester.NewAdminStorage()
+
+	ct := &trillian.TreeTemplate{
+		Name:        "ct",
+		Description: "Certificate Transparency log",
+		Tree: &trillian.Tree{
+			TreeType:           trillian.TreeType_LOG,
+			HashStrategy:       trillian.HashStrategy_RFC6962_SHA256,
+			HashAlgorithm:      spb.DigitallySigned_SHA256,
+			SignatureAlgorithm: spb.DigitallySigned_ECDSA,
+			MaxRootDuration:    ptypes.DurationProto(time.Hour),
+		},
+		KeySpec: &keyspb.Specification{
+			Params: &keyspb.Specification_EcdsaParams{EcdsaParams: &keyspb.Specification_ECDSA{Curve: keyspb.Specification_ECDSA_P256}},
+		},
+	}
+	other := &trillian.TreeTemplate{Name: "a-map", Tree: &trillian.Tree{TreeType: trillian.TreeType_MAP}}
+
+	if _, err := storage.GetTreeTemplate(ctx, s, ct.Name); status.Code(err) != codes.NotFound {
+		t.Errorf("GetTreeTemplate() of missing template returned err = %v, wantCode = %s", err, codes.NotFound)
+	}
+	for _, tmpl := range []*trillian.TreeTemplate{ct, other} {
+		if err := storage.CreateTreeTemplate(ctx, s, tmpl); err != nil {
+			t.Fatalf("CreateTreeTemplate(%q) returned err = %v", tmpl.Name, err)
+		}
+	}
+	if err := storage.CreateTreeTemplate(ctx, s, ct); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateTreeTemplate() of existing template returned err = %v, wantCode = %s", err, codes.AlreadyExists)
+	}
+	if err := storage.CreateTreeTemplate(ctx, s, &trillian.TreeTemplate{Name: "INVALID", Tree: ct.Tree}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateTreeTemplate() of invalid template returned err = %v, wantCode = %s", err, codes.InvalidArgument)
+	}
+
+	got, err := storage.GetTreeTemplate(ctx, s, ct.Name)
+	if err != nil {
+		t.Fatalf("GetTreeTemplate() returned err = %v", err)
+	}
+	if !proto.Equal(got, ct) {
+		t.Errorf("GetTreeTemplate() diff:\n%v", pretty.Compare(got, ct))
+	}
+
+	updated := proto.Clone(ct).(*trillian.TreeTemplate)
+	updated.Description = "CT log, 1 day MMD"
+	updated.Tree.MaxRootDuration = ptypes.DurationProto(24 * time.Hour)
+	if err := storage.UpdateTreeTemplate(ctx, s, updated); err != nil {
+		t.Fatalf("UpdateTreeTemplate() returned err = %v", err)
+	}
+	if err := storage.UpdateTreeTemplate(ctx, s, &trillian.TreeTemplate{Name: "missing", Tree: ct.Tree}); status.Code(err) != codes.NotFound {
+		t.Errorf("UpdateTreeTemplate() of missing template returned err = %v, wantCode = %s", err, codes.NotFound)
+	}
+
+	list, err := storage.ListTreeTemplates(ctx, s)
+	if err != nil {
+		t.Fatalf("ListTreeTemplates() returned err = %v", err)
+	}
+	if want := []*trillian.TreeTemplate{other, updated}; len(list) != len(want) || !proto.Equal(list[0], want[0]) || !proto.Equal(list[1], want[1]) {
+		t.Errorf("ListTreeTemplates() diff:\n%v", pretty.Compare(list, want))
+	}
+
+	if err := storage.DeleteTreeTemplate(ctx, s, ct.Name); err != nil {
+		t.Fatalf("DeleteTreeTemplate() returned err = %v", err)
+	}
+	if err := storage.DeleteTreeTemplate(ctx, s, ct.Name); status.Code(err) != codes.NotFound {
+		t.Errorf("DeleteTreeTemplate() of missing template returned err = %v, wantCode = %s", err, codes.NotFound)
+	}
+	if _, err := storage.GetTreeTemplate(ctx, s, ct.Name); status.Code(err) != codes.NotFound {
+		t.Errorf("GetTreeTemplate() of deleted template returned err = %v, wantCode = %s", err, codes.NotFound)
+	}
+}
+
+// TestTreeAttestations tests the creation and reading of tree attestations.
+func (tester *AdminStorageTester) TestTreeAttestations(t *testing.T) {
+	ctx := context.Background()
+	s := tester.NewAdminStorage()
+
+	tree, err := storage.CreateTree(ctx, s, LogTree)
+	if err != nil {
+		t.Fatalf("CreateTree() returned err = %v", err)
+	}
+	if _, err := storage.GetTreeAttestation(ctx, s, tree.TreeId); status.Code(err) != codes.NotFound {
+		t.Errorf("GetTreeAttestation() of tree without attestation returned err = %v, wantCode = %s", err, codes.NotFound)
+	}
+
+	want := &trillian.TreeAttestation{Tree: []byte("settings"), Signature: []byte("signature")}
+	createAttestation := func(treeID int64, a *trillian.TreeAttestation) error {
+		return s.ReadWriteTransaction(ctx, func(ctx context.Context, tx storage.AdminTX) error {
+			return tx.CreateTreeAttestation(ctx, treeID, a)
+		})
+	}
+	if err := createAttestation(tree.TreeId, want); err != nil {
+		t.Fatalf("CreateTreeAttestation() returned err = %v", err)
+	}
+	if err := createAttestation(tree.TreeId, want); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateTreeAttestation() of attested tree returned err = %v, wantCode = %s", err, codes.AlreadyExists)
+	}
+	if err := createAttestation(tree.TreeId+1, want); err == nil {
+		t.Error("CreateTreeAttestation() of missing tree returned err = nil")
+	}
+
+	got, err := storage.GetTreeAttestation(ctx, s, tree.TreeId)
+	if err != nil {
+		t.Fatalf("GetTreeAttestation() returned err = %v", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("GetTreeAttestation() diff:\n%v", pretty.Compare(got, want))
+	}
+}
+
 // TestAdminTXReadWriteTransaction tests the ReadWriteTransaction method on AdminStorage.
 func (tester *AdminStorageTester) TestAdminTXReadWriteTransaction(t *testing.T) {
 	tests := []struct {
@@ -15,16 +15,47 @@
 package storage
 
 import (
+	"context"
 	"crypto/rand"
+	"io"
 	"math"
 	"math/big"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // NewTreeID generates a random, positive, non-zero tree ID.
 func NewTreeID() (int64, error) {
-	id, err := rand.Int(rand.Reader, big.NewInt(math.MaxInt64))
+	return NewTreeIDFrom(rand.Reader)
+}
+
+// NewTreeIDFrom generates a positive, non-zero tree ID using randomness read
+// from r.
+func NewTreeIDFrom(r io.Reader) (int64, error) {
+	id, err := rand.Int(r, big.NewInt(math.MaxInt64))
 	if err != nil {
 		return 0, err
 	}
 	return id.Int64() + 1, nil
 }
+
+// AllocateTreeID returns the ID to be assigned to a new tree.
+// If requestedID is zero a random ID is generated via NewTreeID, otherwise
+// requestedID is returned, provided that r doesn't already have a tree with
+// that ID. An AlreadyExists error is returned in case of collision.
+// Concurrent creations of the same ID can both pass this check, so storage
+// implementations must also report the failure to insert a duplicate ID as
+// AlreadyExists.
+func AllocateTreeID(ctx context.Context, r AdminReader, requestedID int64) (int64, error) {
+	if requestedID == 0 {
+		return NewTreeID()
+	}
+	switch _, err := r.GetTree(ctx, requestedID); {
+	case err == nil:
+		return 0, status.Errorf(codes.AlreadyExists, "tree %v already exists", requestedID)
+	case status.Code(err) != codes.NotFound:
+		return 0, err
+	}
+	return requestedID, nil
+}
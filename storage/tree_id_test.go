@@ -15,7 +15,14 @@
 package storage
 
 import (
+	"context"
+	"errors"
 	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewTreeID(t *testing.T) {
@@ -35,3 +42,43 @@ func TestNewTreeID(t *testing.T) {
 		}
 	}
 }
+
+func TestAllocateTreeID(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	const treeID = 12345
+	tests := []struct {
+		desc     string
+		getErr   error
+		wantCode codes.Code
+	}{
+		{desc: "free", getErr: status.Error(codes.NotFound, "not found")},
+		{desc: "taken", wantCode: codes.AlreadyExists},
+		{desc: "storageErr", getErr: errors.New("boom"), wantCode: codes.Unknown},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tx := NewMockReadOnlyAdminTX(ctrl)
+			var tree *trillian.Tree
+			if test.getErr == nil {
+				tree = &trillian.Tree{TreeId: treeID}
+			}
+			tx.EXPECT().GetTree(gomock.Any(), int64(treeID)).Return(tree, test.getErr)
+
+			id, err := AllocateTreeID(ctx, tx, treeID)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("AllocateTreeID() = (_, %v), wantCode = %s", err, test.wantCode)
+			}
+			if err == nil && id != treeID {
+				t.Errorf("AllocateTreeID() = %v, want = %v", id, treeID)
+			}
+		})
+	}
+
+	// No GetTree calls are expected when generating random IDs.
+	if id, err := AllocateTreeID(ctx, NewMockReadOnlyAdminTX(ctrl), 0); err != nil || id <= 0 {
+		t.Errorf("AllocateTreeID(0) = (%v, %v), want = (>0, nil)", id, err)
+	}
+}
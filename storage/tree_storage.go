@@ -17,13 +17,33 @@ package storage
 import (
 	"context"
 
+	"github.com/google/trillian"
 	"github.com/google/trillian/storage/tree"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// TreeNeedsInitViolation is the type of the errdetails.PreconditionFailure
+// violation attached to ErrTreeNeedsInit, which allows clients to tell trees
+// that exist but haven't been initialised apart from other failures.
+const TreeNeedsInitViolation = "TREE_NEEDS_INIT"
+
 // ErrTreeNeedsInit is returned when calling methods on an uninitialised tree.
-var ErrTreeNeedsInit = status.Error(codes.FailedPrecondition, "tree needs initialising")
+var ErrTreeNeedsInit = newTreeNeedsInitError()
+
+func newTreeNeedsInitError() error {
+	s, err := status.New(codes.FailedPrecondition, "tree needs initialising").WithDetails(&errdetails.PreconditionFailure{
+		Violations: []*errdetails.PreconditionFailure_Violation{{
+			Type:        TreeNeedsInitViolation,
+			Description: "the tree exists but has no root yet",
+		}},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return s.Err()
+}
 
 // ReadOnlyTreeTX represents a read-only transaction on a TreeStorage.
 // A ReadOnlyTreeTX can only modify the tree specified in its creation.
@@ -75,6 +95,18 @@ type DatabaseChecker interface {
 	CheckDatabaseAccessible(context.Context) error
 }
 
+// TreeStorageCompactor is optionally implemented by LogStorage and MapStorage
+// implementations which can reclaim the space taken by data of a tree that is
+// no longer needed to serve it.
+type TreeStorageCompactor interface {
+	// CompactTreeStorage removes the data of tree which isn't needed to serve
+	// any of its roots, and returns the number of bytes removed. It must be
+	// safe to call while the tree is being read and written. If optimize is
+	// true, the backend-specific optimization of the underlying storage is run
+	// afterwards.
+	CompactTreeStorage(ctx context.Context, tree *trillian.Tree, optimize bool) (int64, error)
+}
+
 // NodeReader provides read-only access to the stored tree nodes, as an interface to allow easier
 // testing of node manipulation.
 type NodeReader interface {
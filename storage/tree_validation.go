@@ -17,13 +17,17 @@ package storage
 import (
 	"bytes"
 	"context"
+	"math"
+	"regexp"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/keys/der"
 	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/merkle/hashers"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -36,6 +40,8 @@ func ValidateTreeForCreation(ctx context.Context, tree *trillian.Tree) error {
 	switch {
 	case tree == nil:
 		return status.Error(codes.InvalidArgument, "a tree is required")
+	case tree.TreeId < 0:
+		return status.Errorf(codes.InvalidArgument, "invalid tree_id: %v", tree.TreeId)
 	case tree.TreeState != trillian.TreeState_ACTIVE:
 		return status.Errorf(codes.InvalidArgument, "invalid tree_state: %s", tree.TreeState)
 	case tree.TreeType == trillian.TreeType_UNKNOWN_TREE_TYPE:
@@ -54,6 +60,78 @@ func ValidateTreeForCreation(ctx context.Context, tree *trillian.Tree) error {
 		return status.Errorf(codes.InvalidArgument, "invalid deleted: %v", tree.Deleted)
 	case tree.DeleteTime != nil:
 		return status.Errorf(codes.InvalidArgument, "invalid delete_time: %+v (must be nil)", tree.DeleteTime)
+	case tree.OrderedLeafTimestamps && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "ordered_leaf_timestamps not supported for tree_type: %s", tree.TreeType)
+	case tree.CallerLeafIdentityHash && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "caller_leaf_identity_hash not supported for tree_type: %s", tree.TreeType)
+	case tree.HashExtraData && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "hash_extra_data not supported for tree_type: %s", tree.TreeType)
+	case trillian.LogRootEncoding_name[int32(tree.LogRootEncoding)] == "":
+		return status.Errorf(codes.InvalidArgument, "invalid log_root_encoding: %s", tree.LogRootEncoding)
+	case tree.LogRootEncoding != trillian.LogRootEncoding_TLS && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "log_root_encoding %s not supported for tree_type: %s", tree.LogRootEncoding, tree.TreeType)
+	case trillian.TimestampGranularity_name[int32(tree.TimestampGranularity)] == "":
+		return status.Errorf(codes.InvalidArgument, "invalid timestamp_granularity: %s", tree.TimestampGranularity)
+	case tree.TimestampGranularity != trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_NANOSECOND && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "timestamp_granularity %s not supported for tree_type: %s", tree.TimestampGranularity, tree.TreeType)
+	case tree.HashOnly && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "hash_only not supported for tree_type: %s", tree.TreeType)
+	case tree.HashOnly && tree.HashExtraData:
+		return status.Error(codes.InvalidArgument, "hash_only and hash_extra_data are mutually exclusive")
+	case trillian.LeafCompression_name[int32(tree.LeafCompression)] == "":
+		return status.Errorf(codes.InvalidArgument, "invalid leaf_compression: %s", tree.LeafCompression)
+	case tree.LeafCompression != trillian.LeafCompression_LEAF_COMPRESSION_NONE && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "leaf_compression %s not supported for tree_type: %s", tree.LeafCompression, tree.TreeType)
+	case tree.MaxTreeSize < 0:
+		return status.Errorf(codes.InvalidArgument, "max_tree_size: %d, want >= 0", tree.MaxTreeSize)
+	case tree.MaxTreeSize != 0 && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "max_tree_size not supported for tree_type: %s", tree.TreeType)
+	case tree.QueueWriteAhead && tree.TreeType != trillian.TreeType_LOG:
+		return status.Errorf(codes.InvalidArgument, "queue_write_ahead not supported for tree_type: %s", tree.TreeType)
+	case tree.LeafEncryption != nil && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "leaf_encryption not supported for tree_type: %s", tree.TreeType)
+	case tree.LeafEncryption != nil && tree.LeafCompression != trillian.LeafCompression_LEAF_COMPRESSION_NONE:
+		return status.Error(codes.InvalidArgument, "leaf_encryption and leaf_compression are mutually exclusive")
+	case tree.LeafEncryption != nil && tree.QueueWriteAhead:
+		return status.Error(codes.InvalidArgument, "leaf_encryption and queue_write_ahead are mutually exclusive")
+	case tree.SortByQueueTimestamp && tree.TreeType != trillian.TreeType_LOG:
+		return status.Errorf(codes.InvalidArgument, "sort_by_queue_timestamp not supported for tree_type: %s", tree.TreeType)
+	case tree.LeafOrderingKey != nil && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "leaf_ordering_key not supported for tree_type: %s", tree.TreeType)
+	case tree.LeafOrderingKey != nil && tree.LeafEncryption != nil:
+		return status.Error(codes.InvalidArgument, "leaf_ordering_key and leaf_encryption are mutually exclusive")
+	case tree.LeafOrderingKey.GetSource() == trillian.LeafOrderingKey_LEAF_VALUE && tree.HashOnly:
+		return status.Error(codes.InvalidArgument, "leaf_ordering_key can't be taken from the leaf_value of a hash_only tree")
+	case tree.LeafTombstones && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "leaf_tombstones not supported for tree_type: %s", tree.TreeType)
+	case tree.LeafTombstones && tree.HashOnly:
+		return status.Error(codes.InvalidArgument, "leaf_tombstones and hash_only are mutually exclusive")
+	case tree.LeafTombstones && tree.LeafEncryption != nil:
+		return status.Error(codes.InvalidArgument, "leaf_tombstones and leaf_encryption are mutually exclusive")
+	case len(tree.EmptyRootHash) != 0 && tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG:
+		return status.Errorf(codes.InvalidArgument, "empty_root_hash not supported for tree_type: %s", tree.TreeType)
+	case tree.LeafIndexOffset < 0:
+		return status.Errorf(codes.InvalidArgument, "leaf_index_offset: %d, want >= 0", tree.LeafIndexOffset)
+	case tree.LeafIndexOffset != 0 && tree.TreeType != trillian.TreeType_LOG:
+		return status.Errorf(codes.InvalidArgument, "leaf_index_offset not supported for tree_type: %s", tree.TreeType)
+	case tree.LeafIndexOffset != 0 && tree.LeafTombstones:
+		return status.Error(codes.InvalidArgument, "leaf_index_offset and leaf_tombstones are mutually exclusive")
+	case tree.MaxTreeSize > math.MaxInt64-tree.LeafIndexOffset:
+		return status.Errorf(codes.InvalidArgument, "leaf_index_offset %d plus max_tree_size %d overflows", tree.LeafIndexOffset, tree.MaxTreeSize)
+	}
+	if k := tree.LeafOrderingKey; k != nil {
+		if err := validateLeafOrderingKey(k); err != nil {
+			return err
+		}
+	}
+	if len(tree.EmptyRootHash) != 0 {
+		h, err := hashers.NewLogHasher(tree.HashStrategy)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid hash_strategy: %v", err)
+		}
+		if got, want := len(tree.EmptyRootHash), h.Size(); got != want {
+			return status.Errorf(codes.InvalidArgument, "empty_root_hash is %d bytes long, want %d", got, want)
+		}
 	}
 
 	return validateMutableTreeFields(ctx, tree)
@@ -111,19 +189,103 @@ func ValidateTreeForUpdate(ctx context.Context, storedTree, newTree *trillian.Tr
 		return status.Error(codes.InvalidArgument, "readonly field changed: deleted")
 	case !proto.Equal(storedTree.DeleteTime, newTree.DeleteTime):
 		return status.Error(codes.InvalidArgument, "readonly field changed: delete_time")
+	case storedTree.OrderedLeafTimestamps != newTree.OrderedLeafTimestamps:
+		return status.Error(codes.InvalidArgument, "readonly field changed: ordered_leaf_timestamps")
+	case storedTree.CallerLeafIdentityHash != newTree.CallerLeafIdentityHash:
+		return status.Error(codes.InvalidArgument, "readonly field changed: caller_leaf_identity_hash")
+	case storedTree.HashExtraData != newTree.HashExtraData:
+		return status.Error(codes.InvalidArgument, "readonly field changed: hash_extra_data")
+	case storedTree.Namespace != newTree.Namespace:
+		return status.Error(codes.InvalidArgument, "readonly field changed: namespace")
+	case storedTree.LogRootEncoding != newTree.LogRootEncoding:
+		return status.Error(codes.InvalidArgument, "readonly field changed: log_root_encoding")
+	case storedTree.TimestampGranularity != newTree.TimestampGranularity:
+		return status.Error(codes.InvalidArgument, "readonly field changed: timestamp_granularity")
+	case storedTree.HashOnly != newTree.HashOnly:
+		return status.Error(codes.InvalidArgument, "readonly field changed: hash_only")
+	case storedTree.LeafCompression != newTree.LeafCompression:
+		return status.Error(codes.InvalidArgument, "readonly field changed: leaf_compression")
+	case storedTree.MaxTreeSize != newTree.MaxTreeSize:
+		return status.Error(codes.InvalidArgument, "readonly field changed: max_tree_size")
+	case storedTree.QueueWriteAhead != newTree.QueueWriteAhead:
+		return status.Error(codes.InvalidArgument, "readonly field changed: queue_write_ahead")
+	case (storedTree.LeafEncryption == nil) != (newTree.LeafEncryption == nil):
+		return status.Error(codes.InvalidArgument, "readonly field changed: leaf_encryption")
+	case storedTree.SortByQueueTimestamp != newTree.SortByQueueTimestamp:
+		return status.Error(codes.InvalidArgument, "readonly field changed: sort_by_queue_timestamp")
+	case !proto.Equal(storedTree.LeafOrderingKey, newTree.LeafOrderingKey):
+		return status.Error(codes.InvalidArgument, "readonly field changed: leaf_ordering_key")
+	case storedTree.LeafTombstones != newTree.LeafTombstones:
+		return status.Error(codes.InvalidArgument, "readonly field changed: leaf_tombstones")
+	case !bytes.Equal(storedTree.EmptyRootHash, newTree.EmptyRootHash):
+		return status.Error(codes.InvalidArgument, "readonly field changed: empty_root_hash")
+	case storedTree.LeafIndexOffset != newTree.LeafIndexOffset:
+		return status.Error(codes.InvalidArgument, "readonly field changed: leaf_index_offset")
+	}
+	// Stored leaf data refers to dictionaries by version, so they can only be
+	// appended.
+	for i, d := range storedTree.LeafCompressionDictionaries {
+		if i >= len(newTree.LeafCompressionDictionaries) || !proto.Equal(d, newTree.LeafCompressionDictionaries[i]) {
+			return status.Errorf(codes.InvalidArgument, "leaf_compression_dictionaries can only be appended to, but version %d changed", d.Version)
+		}
 	}
 	return validateMutableTreeFields(ctx, newTree)
 }
 
+// validateLeafOrderingKey checks that the ordering key of leaves can be
+// extracted as specified by k.
+func validateLeafOrderingKey(k *trillian.LeafOrderingKey) error {
+	switch {
+	case k.Source == trillian.LeafOrderingKey_SOURCE_UNKNOWN || trillian.LeafOrderingKey_Source_name[int32(k.Source)] == "":
+		return status.Errorf(codes.InvalidArgument, "invalid leaf_ordering_key.source: %s", k.Source)
+	case k.Offset < 0:
+		return status.Errorf(codes.InvalidArgument, "leaf_ordering_key.offset: %d, want >= 0", k.Offset)
+	case k.Length < 0 || k.Length > MaxLeafOrderingKeySize:
+		return status.Errorf(codes.InvalidArgument, "leaf_ordering_key.length: %d, want in [0, %d]", k.Length, MaxLeafOrderingKeySize)
+	}
+	return nil
+}
+
+// validateLeafCompressionDictionaries checks that the leaf compression
+// dictionaries of tree are only set if it uses them, are numbered from 1, and
+// are valid zstd dictionaries.
+func validateLeafCompressionDictionaries(tree *trillian.Tree) error {
+	ds := tree.LeafCompressionDictionaries
+	if len(ds) > 0 && tree.LeafCompression != trillian.LeafCompression_LEAF_COMPRESSION_ZSTD_DICTIONARY {
+		return status.Errorf(codes.InvalidArgument, "leaf_compression_dictionaries not supported for leaf_compression: %s", tree.LeafCompression)
+	}
+	for i, d := range ds {
+		switch {
+		case d.GetVersion() != int32(i+1):
+			return status.Errorf(codes.InvalidArgument, "leaf_compression_dictionaries[%d].version: %d, want %d", i, d.GetVersion(), i+1)
+		case len(d.Dictionary) == 0 || len(d.Dictionary) > MaxLeafCompressionDictionarySize:
+			return status.Errorf(codes.InvalidArgument, "leaf_compression_dictionaries[%d] is %d bytes long, want in [1, %d]", i, len(d.Dictionary), MaxLeafCompressionDictionarySize)
+		}
+		if err := checkLeafCompressionDictionary(d.Dictionary); err != nil {
+			return status.Errorf(codes.InvalidArgument, "leaf_compression_dictionaries[%d] is not a zstd dictionary: %v", i, err)
+		}
+	}
+	return nil
+}
+
 func validateMutableTreeFields(ctx context.Context, tree *trillian.Tree) error {
 	if tree.TreeState == trillian.TreeState_UNKNOWN_TREE_STATE {
 		return status.Errorf(codes.InvalidArgument, "invalid tree_state: %v", tree.TreeState)
 	}
+	if e := tree.LeafEncryption; e != nil && (len(e.WrappedDataKey) == 0 || e.KekId == "") {
+		return status.Error(codes.InvalidArgument, "leaf_encryption requires a wrapped_data_key and kek_id")
+	}
+	if err := validateLeafCompressionDictionaries(tree); err != nil {
+		return err
+	}
 	if duration, err := ptypes.Duration(tree.MaxRootDuration); err != nil {
 		return status.Errorf(codes.InvalidArgument, "max_root_duration malformed: %v", tree.MaxRootDuration)
 	} else if duration < 0 {
 		return status.Errorf(codes.InvalidArgument, "max_root_duration negative: %v", tree.MaxRootDuration)
 	}
+	if err := validateSigningCadence(tree); err != nil {
+		return err
+	}
 
 	// Implementations may vary, so let's assume storage_settings is mutable.
 	// Other than checking that it's a valid Any there isn't much to do at this layer, though.
@@ -154,3 +316,58 @@ func validateMutableTreeFields(ctx context.Context, tree *trillian.Tree) error {
 
 	return nil
 }
+
+// validateSigningCadence checks the signing_interval and max_queue_age of a
+// tree, which only logs have.
+func validateSigningCadence(tree *trillian.Tree) error {
+	for _, f := range []struct {
+		name string
+		d    *duration.Duration
+	}{
+		{"signing_interval", tree.SigningInterval},
+		{"max_queue_age", tree.MaxQueueAge},
+	} {
+		if f.d == nil {
+			continue
+		}
+		if tree.TreeType != trillian.TreeType_LOG && tree.TreeType != trillian.TreeType_PREORDERED_LOG {
+			return status.Errorf(codes.InvalidArgument, "%s not supported for tree_type %v", f.name, tree.TreeType)
+		}
+		if d, err := ptypes.Duration(f.d); err != nil {
+			return status.Errorf(codes.InvalidArgument, "%s malformed: %v", f.name, f.d)
+		} else if d < 0 {
+			return status.Errorf(codes.InvalidArgument, "%s negative: %v", f.name, f.d)
+		}
+	}
+	return nil
+}
+
+// treeTemplateName matches valid names of tree templates.
+var treeTemplateName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// ValidateTreeTemplate returns nil if tmpl is valid for storage, error
+// otherwise. The tree of a template may be partial, as it's completed when a
+// tree is created from it, but mustn't set per-tree fields.
+func ValidateTreeTemplate(tmpl *trillian.TreeTemplate) error {
+	switch {
+	case tmpl == nil:
+		return status.Error(codes.InvalidArgument, "a template is required")
+	case !treeTemplateName.MatchString(tmpl.Name):
+		return status.Errorf(codes.InvalidArgument, "invalid template name: %q", tmpl.Name)
+	case tmpl.Tree == nil:
+		return status.Error(codes.InvalidArgument, "a template tree is required")
+	case tmpl.Tree.TreeId != 0:
+		return status.Errorf(codes.InvalidArgument, "invalid tree_id: %v (must be unset)", tmpl.Tree.TreeId)
+	case tmpl.Tree.PrivateKey != nil:
+		return status.Error(codes.InvalidArgument, "invalid private_key (must be unset)")
+	case tmpl.Tree.PublicKey != nil:
+		return status.Error(codes.InvalidArgument, "invalid public_key (must be unset)")
+	case tmpl.Tree.CreateTime != nil || tmpl.Tree.UpdateTime != nil || tmpl.Tree.DeleteTime != nil:
+		return status.Error(codes.InvalidArgument, "invalid create_time, update_time or delete_time (must be unset)")
+	case tmpl.Tree.Deleted:
+		return status.Errorf(codes.InvalidArgument, "invalid deleted: %v", tmpl.Tree.Deleted)
+	case len(tmpl.Tree.GetLeafEncryption().GetWrappedDataKey()) != 0 || tmpl.Tree.GetLeafEncryption().GetKekId() != "":
+		return status.Error(codes.InvalidArgument, "invalid leaf_encryption (must be empty)")
+	}
+	return nil
+}
@@ -16,6 +16,8 @@ package storage
 
 import (
 	"context"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
@@ -34,6 +36,7 @@ import (
 
 	_ "github.com/google/trillian/crypto/keys/der/proto"
 	_ "github.com/google/trillian/crypto/keys/pem/proto"
+	_ "github.com/google/trillian/merkle/rfc6962" // Register the RFC6962_SHA256 hasher
 )
 
 const (
@@ -63,6 +66,12 @@ func TestValidateTreeForCreation(t *testing.T) {
 	valid2 := newTree()
 	valid2.TreeType = trillian.TreeType_MAP
 
+	validID := newTree()
+	validID.TreeId = 12345
+
+	invalidID := newTree()
+	invalidID.TreeId = -1
+
 	invalidState1 := newTree()
 	invalidState1.TreeState = trillian.TreeState_UNKNOWN_TREE_STATE
 	invalidState2 := newTree()
@@ -118,6 +127,208 @@ func TestValidateTreeForCreation(t *testing.T) {
 	deleteTimeTree := newTree()
 	deleteTimeTree.DeleteTime = ptypes.TimestampNow()
 
+	orderedTimestamps := newTree()
+	orderedTimestamps.TreeType = trillian.TreeType_PREORDERED_LOG
+	orderedTimestamps.OrderedLeafTimestamps = true
+
+	invalidOrderedTimestamps := newTree()
+	invalidOrderedTimestamps.OrderedLeafTimestamps = true
+
+	callerIdentityHash := newTree()
+	callerIdentityHash.CallerLeafIdentityHash = true
+
+	invalidCallerIdentityHash := newTree()
+	invalidCallerIdentityHash.TreeType = trillian.TreeType_MAP
+	invalidCallerIdentityHash.CallerLeafIdentityHash = true
+
+	hashExtraData := newTree()
+	hashExtraData.HashExtraData = true
+
+	invalidHashExtraData := newTree()
+	invalidHashExtraData.TreeType = trillian.TreeType_MAP
+	invalidHashExtraData.HashExtraData = true
+
+	cborLogRoots := newTree()
+	cborLogRoots.LogRootEncoding = trillian.LogRootEncoding_CBOR
+
+	unknownLogRootEncoding := newTree()
+	unknownLogRootEncoding.LogRootEncoding = trillian.LogRootEncoding(-1)
+
+	invalidCBORLogRoots := newTree()
+	invalidCBORLogRoots.TreeType = trillian.TreeType_MAP
+	invalidCBORLogRoots.LogRootEncoding = trillian.LogRootEncoding_CBOR
+
+	coarseTimestamps := newTree()
+	coarseTimestamps.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND
+
+	unknownTimestampGranularity := newTree()
+	unknownTimestampGranularity.TimestampGranularity = trillian.TimestampGranularity(-1)
+
+	invalidCoarseTimestamps := newTree()
+	invalidCoarseTimestamps.TreeType = trillian.TreeType_MAP
+	invalidCoarseTimestamps.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_MILLISECOND
+
+	hashOnly := newTree()
+	hashOnly.HashOnly = true
+
+	invalidHashOnly := newTree()
+	invalidHashOnly.TreeType = trillian.TreeType_MAP
+	invalidHashOnly.HashOnly = true
+
+	hashOnlyWithExtraData := newTree()
+	hashOnlyWithExtraData.HashOnly = true
+	hashOnlyWithExtraData.HashExtraData = true
+
+	compressedLeaves := newTree()
+	compressedLeaves.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+
+	unknownLeafCompression := newTree()
+	unknownLeafCompression.LeafCompression = trillian.LeafCompression(-1)
+
+	invalidCompressedLeaves := newTree()
+	invalidCompressedLeaves.TreeType = trillian.TreeType_MAP
+	invalidCompressedLeaves.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+
+	dictionaryLeaves := newTree()
+	dictionaryLeaves.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_ZSTD_DICTIONARY
+	dictionaryLeaves.LeafCompressionDictionaries = []*trillian.LeafCompressionDictionary{
+		{Version: 1, Dictionary: testDictionary(t, `{"name":`)},
+		{Version: 2, Dictionary: testDictionary(t, `{"name":"","value":`)},
+	}
+
+	gzipDictionaryLeaves := proto.Clone(dictionaryLeaves).(*trillian.Tree)
+	gzipDictionaryLeaves.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+
+	misnumberedDictionaryLeaves := proto.Clone(dictionaryLeaves).(*trillian.Tree)
+	misnumberedDictionaryLeaves.LeafCompressionDictionaries[1].Version = 3
+
+	emptyDictionaryLeaves := proto.Clone(dictionaryLeaves).(*trillian.Tree)
+	emptyDictionaryLeaves.LeafCompressionDictionaries[0].Dictionary = nil
+
+	longDictionaryLeaves := proto.Clone(dictionaryLeaves).(*trillian.Tree)
+	longDictionaryLeaves.LeafCompressionDictionaries[0].Dictionary = make([]byte, MaxLeafCompressionDictionarySize+1)
+
+	rawDictionaryLeaves := proto.Clone(dictionaryLeaves).(*trillian.Tree)
+	rawDictionaryLeaves.LeafCompressionDictionaries[0].Dictionary = []byte(`{"name":`)
+
+	cappedTree := newTree()
+	cappedTree.MaxTreeSize = 1000
+
+	negativeMaxTreeSize := newTree()
+	negativeMaxTreeSize.MaxTreeSize = -1
+
+	invalidCappedTree := newTree()
+	invalidCappedTree.TreeType = trillian.TreeType_MAP
+	invalidCappedTree.MaxTreeSize = 1000
+
+	writeAheadTree := newTree()
+	writeAheadTree.QueueWriteAhead = true
+
+	invalidWriteAheadTree := newTree()
+	invalidWriteAheadTree.TreeType = trillian.TreeType_PREORDERED_LOG
+	invalidWriteAheadTree.QueueWriteAhead = true
+
+	encryptedTree := newTree()
+	encryptedTree.LeafEncryption = &trillian.LeafEncryption{WrappedDataKey: []byte("wrapped"), KekId: "kek"}
+
+	unwrappedEncryptedTree := newTree()
+	unwrappedEncryptedTree.LeafEncryption = &trillian.LeafEncryption{}
+
+	encryptedMapTree := newTree()
+	encryptedMapTree.TreeType = trillian.TreeType_MAP
+	encryptedMapTree.LeafEncryption = encryptedTree.LeafEncryption
+
+	encryptedCompressedTree := proto.Clone(encryptedTree).(*trillian.Tree)
+	encryptedCompressedTree.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+
+	encryptedWriteAheadTree := proto.Clone(encryptedTree).(*trillian.Tree)
+	encryptedWriteAheadTree.QueueWriteAhead = true
+
+	sortedTree := newTree()
+	sortedTree.SortByQueueTimestamp = true
+
+	invalidSortedTree := newTree()
+	invalidSortedTree.TreeType = trillian.TreeType_PREORDERED_LOG
+	invalidSortedTree.SortByQueueTimestamp = true
+
+	keyedTree := newTree()
+	keyedTree.TreeType = trillian.TreeType_PREORDERED_LOG
+	keyedTree.LeafOrderingKey = &trillian.LeafOrderingKey{Source: trillian.LeafOrderingKey_EXTRA_DATA, Length: 16}
+
+	keyedLogTree := proto.Clone(keyedTree).(*trillian.Tree)
+	keyedLogTree.TreeType = trillian.TreeType_LOG
+
+	keyedEncryptedTree := proto.Clone(keyedTree).(*trillian.Tree)
+	keyedEncryptedTree.LeafEncryption = encryptedTree.LeafEncryption
+
+	keyedHashOnlyTree := proto.Clone(keyedTree).(*trillian.Tree)
+	keyedHashOnlyTree.HashOnly = true
+	keyedHashOnlyTree.LeafOrderingKey.Source = trillian.LeafOrderingKey_LEAF_VALUE
+
+	unknownKeySourceTree := proto.Clone(keyedTree).(*trillian.Tree)
+	unknownKeySourceTree.LeafOrderingKey.Source = trillian.LeafOrderingKey_SOURCE_UNKNOWN
+
+	negativeKeyOffsetTree := proto.Clone(keyedTree).(*trillian.Tree)
+	negativeKeyOffsetTree.LeafOrderingKey.Offset = -1
+
+	longKeyTree := proto.Clone(keyedTree).(*trillian.Tree)
+	longKeyTree.LeafOrderingKey.Length = MaxLeafOrderingKeySize + 1
+
+	tombstoneTree := newTree()
+	tombstoneTree.LeafTombstones = true
+
+	tombstoneMapTree := proto.Clone(tombstoneTree).(*trillian.Tree)
+	tombstoneMapTree.TreeType = trillian.TreeType_MAP
+
+	tombstoneHashOnlyTree := proto.Clone(tombstoneTree).(*trillian.Tree)
+	tombstoneHashOnlyTree.HashOnly = true
+
+	tombstoneEncryptedTree := proto.Clone(tombstoneTree).(*trillian.Tree)
+	tombstoneEncryptedTree.LeafEncryption = encryptedTree.LeafEncryption
+
+	emptyRootTree := newTree()
+	emptyRootTree.EmptyRootHash = make([]byte, 32)
+
+	emptyRootMapTree := proto.Clone(emptyRootTree).(*trillian.Tree)
+	emptyRootMapTree.TreeType = trillian.TreeType_MAP
+
+	shortEmptyRootTree := proto.Clone(emptyRootTree).(*trillian.Tree)
+	shortEmptyRootTree.EmptyRootHash = make([]byte, 31)
+
+	offsetTree := newTree()
+	offsetTree.LeafIndexOffset = 1000
+	offsetTree.MaxTreeSize = 1000
+
+	negativeOffsetTree := newTree()
+	negativeOffsetTree.LeafIndexOffset = -1
+
+	offsetPreorderedTree := proto.Clone(offsetTree).(*trillian.Tree)
+	offsetPreorderedTree.TreeType = trillian.TreeType_PREORDERED_LOG
+
+	offsetTombstoneTree := proto.Clone(offsetTree).(*trillian.Tree)
+	offsetTombstoneTree.LeafTombstones = true
+
+	offsetOverflowTree := proto.Clone(offsetTree).(*trillian.Tree)
+	offsetOverflowTree.MaxTreeSize = math.MaxInt64 - offsetTree.LeafIndexOffset + 1
+
+	signingIntervalTree := newTree()
+	signingIntervalTree.SigningInterval = ptypes.DurationProto(time.Minute)
+
+	signingIntervalPreorderedTree := proto.Clone(signingIntervalTree).(*trillian.Tree)
+	signingIntervalPreorderedTree.TreeType = trillian.TreeType_PREORDERED_LOG
+
+	negativeSigningIntervalTree := newTree()
+	negativeSigningIntervalTree.SigningInterval = ptypes.DurationProto(-time.Minute)
+
+	maxQueueAgeTree := proto.Clone(signingIntervalTree).(*trillian.Tree)
+	maxQueueAgeTree.MaxQueueAge = ptypes.DurationProto(10 * time.Second)
+
+	negativeMaxQueueAgeTree := proto.Clone(signingIntervalTree).(*trillian.Tree)
+	negativeMaxQueueAgeTree.MaxQueueAge = ptypes.DurationProto(-time.Second)
+
+	maxQueueAgeNoSigningIntervalTree := newTree()
+	maxQueueAgeNoSigningIntervalTree.MaxQueueAge = ptypes.DurationProto(10 * time.Second)
+
 	tests := []struct {
 		desc    string
 		tree    *trillian.Tree
@@ -131,6 +342,15 @@ func TestValidateTreeForCreation(t *testing.T) {
 			desc: "valid2",
 			tree: valid2,
 		},
+		{
+			desc: "validID",
+			tree: validID,
+		},
+		{
+			desc:    "invalidID",
+			tree:    invalidID,
+			wantErr: true,
+		},
 		{
 			desc:    "nilTree",
 			tree:    nil,
@@ -220,6 +440,291 @@ func TestValidateTreeForCreation(t *testing.T) {
 			tree:    deleteTimeTree,
 			wantErr: true,
 		},
+		{
+			desc: "orderedTimestamps",
+			tree: orderedTimestamps,
+		},
+		{
+			desc:    "invalidOrderedTimestamps",
+			tree:    invalidOrderedTimestamps,
+			wantErr: true,
+		},
+		{
+			desc: "callerIdentityHash",
+			tree: callerIdentityHash,
+		},
+		{
+			desc:    "invalidCallerIdentityHash",
+			tree:    invalidCallerIdentityHash,
+			wantErr: true,
+		},
+		{
+			desc: "hashExtraData",
+			tree: hashExtraData,
+		},
+		{
+			desc:    "invalidHashExtraData",
+			tree:    invalidHashExtraData,
+			wantErr: true,
+		},
+		{
+			desc: "cborLogRoots",
+			tree: cborLogRoots,
+		},
+		{
+			desc:    "unknownLogRootEncoding",
+			tree:    unknownLogRootEncoding,
+			wantErr: true,
+		},
+		{
+			desc:    "invalidCBORLogRoots",
+			tree:    invalidCBORLogRoots,
+			wantErr: true,
+		},
+		{
+			desc: "coarseTimestamps",
+			tree: coarseTimestamps,
+		},
+		{
+			desc:    "unknownTimestampGranularity",
+			tree:    unknownTimestampGranularity,
+			wantErr: true,
+		},
+		{
+			desc:    "invalidCoarseTimestamps",
+			tree:    invalidCoarseTimestamps,
+			wantErr: true,
+		},
+		{
+			desc: "hashOnly",
+			tree: hashOnly,
+		},
+		{
+			desc:    "invalidHashOnly",
+			tree:    invalidHashOnly,
+			wantErr: true,
+		},
+		{
+			desc:    "hashOnlyWithExtraData",
+			tree:    hashOnlyWithExtraData,
+			wantErr: true,
+		},
+		{
+			desc: "compressedLeaves",
+			tree: compressedLeaves,
+		},
+		{
+			desc:    "unknownLeafCompression",
+			tree:    unknownLeafCompression,
+			wantErr: true,
+		},
+		{
+			desc:    "invalidCompressedLeaves",
+			tree:    invalidCompressedLeaves,
+			wantErr: true,
+		},
+		{
+			desc: "dictionaryLeaves",
+			tree: dictionaryLeaves,
+		},
+		{
+			desc:    "gzipDictionaryLeaves",
+			tree:    gzipDictionaryLeaves,
+			wantErr: true,
+		},
+		{
+			desc:    "misnumberedDictionaryLeaves",
+			tree:    misnumberedDictionaryLeaves,
+			wantErr: true,
+		},
+		{
+			desc:    "emptyDictionaryLeaves",
+			tree:    emptyDictionaryLeaves,
+			wantErr: true,
+		},
+		{
+			desc:    "longDictionaryLeaves",
+			tree:    longDictionaryLeaves,
+			wantErr: true,
+		},
+		{
+			desc:    "rawDictionaryLeaves",
+			tree:    rawDictionaryLeaves,
+			wantErr: true,
+		},
+		{
+			desc: "cappedTree",
+			tree: cappedTree,
+		},
+		{
+			desc:    "negativeMaxTreeSize",
+			tree:    negativeMaxTreeSize,
+			wantErr: true,
+		},
+		{
+			desc:    "invalidCappedTree",
+			tree:    invalidCappedTree,
+			wantErr: true,
+		},
+		{
+			desc: "writeAheadTree",
+			tree: writeAheadTree,
+		},
+		{
+			desc:    "invalidWriteAheadTree",
+			tree:    invalidWriteAheadTree,
+			wantErr: true,
+		},
+		{
+			desc: "encryptedTree",
+			tree: encryptedTree,
+		},
+		{
+			desc:    "unwrappedEncryptedTree",
+			tree:    unwrappedEncryptedTree,
+			wantErr: true,
+		},
+		{
+			desc:    "encryptedMapTree",
+			tree:    encryptedMapTree,
+			wantErr: true,
+		},
+		{
+			desc:    "encryptedCompressedTree",
+			tree:    encryptedCompressedTree,
+			wantErr: true,
+		},
+		{
+			desc:    "encryptedWriteAheadTree",
+			tree:    encryptedWriteAheadTree,
+			wantErr: true,
+		},
+		{
+			desc: "sortedTree",
+			tree: sortedTree,
+		},
+		{
+			desc:    "invalidSortedTree",
+			tree:    invalidSortedTree,
+			wantErr: true,
+		},
+		{
+			desc: "keyedTree",
+			tree: keyedTree,
+		},
+		{
+			desc:    "keyedLogTree",
+			tree:    keyedLogTree,
+			wantErr: true,
+		},
+		{
+			desc:    "keyedEncryptedTree",
+			tree:    keyedEncryptedTree,
+			wantErr: true,
+		},
+		{
+			desc:    "keyedHashOnlyTree",
+			tree:    keyedHashOnlyTree,
+			wantErr: true,
+		},
+		{
+			desc:    "unknownKeySourceTree",
+			tree:    unknownKeySourceTree,
+			wantErr: true,
+		},
+		{
+			desc:    "negativeKeyOffsetTree",
+			tree:    negativeKeyOffsetTree,
+			wantErr: true,
+		},
+		{
+			desc:    "longKeyTree",
+			tree:    longKeyTree,
+			wantErr: true,
+		},
+		{
+			desc: "tombstoneTree",
+			tree: tombstoneTree,
+		},
+		{
+			desc:    "tombstoneMapTree",
+			tree:    tombstoneMapTree,
+			wantErr: true,
+		},
+		{
+			desc:    "tombstoneHashOnlyTree",
+			tree:    tombstoneHashOnlyTree,
+			wantErr: true,
+		},
+		{
+			desc:    "tombstoneEncryptedTree",
+			tree:    tombstoneEncryptedTree,
+			wantErr: true,
+		},
+		{
+			desc: "emptyRootTree",
+			tree: emptyRootTree,
+		},
+		{
+			desc:    "emptyRootMapTree",
+			tree:    emptyRootMapTree,
+			wantErr: true,
+		},
+		{
+			desc:    "shortEmptyRootTree",
+			tree:    shortEmptyRootTree,
+			wantErr: true,
+		},
+		{
+			desc: "offsetTree",
+			tree: offsetTree,
+		},
+		{
+			desc:    "negativeOffsetTree",
+			tree:    negativeOffsetTree,
+			wantErr: true,
+		},
+		{
+			desc:    "offsetPreorderedTree",
+			tree:    offsetPreorderedTree,
+			wantErr: true,
+		},
+		{
+			desc:    "offsetTombstoneTree",
+			tree:    offsetTombstoneTree,
+			wantErr: true,
+		},
+		{
+			desc:    "offsetOverflowTree",
+			tree:    offsetOverflowTree,
+			wantErr: true,
+		},
+		{
+			desc: "signingIntervalTree",
+			tree: signingIntervalTree,
+		},
+		{
+			desc: "signingIntervalPreorderedTree",
+			tree: signingIntervalPreorderedTree,
+		},
+		{
+			desc:    "negativeSigningIntervalTree",
+			tree:    negativeSigningIntervalTree,
+			wantErr: true,
+		},
+		{
+			desc: "maxQueueAgeTree",
+			tree: maxQueueAgeTree,
+		},
+		{
+			desc:    "negativeMaxQueueAgeTree",
+			tree:    negativeMaxQueueAgeTree,
+			wantErr: true,
+		},
+		{
+			desc: "maxQueueAgeNoSigningIntervalTree",
+			tree: maxQueueAgeNoSigningIntervalTree,
+		},
 	}
 	for _, test := range tests {
 		err := ValidateTreeForCreation(ctx, test.tree)
@@ -285,6 +790,40 @@ func TestValidateTreeForUpdate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			desc: "validSigningInterval",
+			updatefn: func(tree *trillian.Tree) {
+				tree.SigningInterval = ptypes.DurationProto(time.Minute)
+			},
+		},
+		{
+			desc: "invalidSigningInterval",
+			updatefn: func(tree *trillian.Tree) {
+				tree.SigningInterval = ptypes.DurationProto(-time.Minute)
+			},
+			wantErr: true,
+		},
+		{
+			desc: "validMaxQueueAge",
+			updatefn: func(tree *trillian.Tree) {
+				tree.SigningInterval = ptypes.DurationProto(time.Minute)
+				tree.MaxQueueAge = ptypes.DurationProto(10 * time.Second)
+			},
+		},
+		{
+			desc: "invalidMaxQueueAge",
+			updatefn: func(tree *trillian.Tree) {
+				tree.SigningInterval = ptypes.DurationProto(time.Minute)
+				tree.MaxQueueAge = ptypes.DurationProto(-10 * time.Second)
+			},
+			wantErr: true,
+		},
+		{
+			desc: "maxQueueAgeWithoutSigningInterval",
+			updatefn: func(tree *trillian.Tree) {
+				tree.MaxQueueAge = ptypes.DurationProto(10 * time.Second)
+			},
+		},
 		{
 			desc: "differentPrivateKeyProtoButSameKeyMaterial",
 			updatefn: func(tree *trillian.Tree) {
@@ -414,6 +953,102 @@ func TestValidateTreeForUpdate(t *testing.T) {
 			updatefn: func(tree *trillian.Tree) { tree.DeleteTime = ptypes.TimestampNow() },
 			wantErr:  true,
 		},
+		{
+			desc:     "OrderedLeafTimestamps",
+			treeType: trillian.TreeType_PREORDERED_LOG,
+			updatefn: func(tree *trillian.Tree) { tree.OrderedLeafTimestamps = !tree.OrderedLeafTimestamps },
+			wantErr:  true,
+		},
+		{
+			desc:     "CallerLeafIdentityHash",
+			updatefn: func(tree *trillian.Tree) { tree.CallerLeafIdentityHash = !tree.CallerLeafIdentityHash },
+			wantErr:  true,
+		},
+		{
+			desc:     "HashExtraData",
+			updatefn: func(tree *trillian.Tree) { tree.HashExtraData = !tree.HashExtraData },
+			wantErr:  true,
+		},
+		{
+			desc:     "LogRootEncoding",
+			updatefn: func(tree *trillian.Tree) { tree.LogRootEncoding = trillian.LogRootEncoding_CBOR },
+			wantErr:  true,
+		},
+		{
+			desc: "TimestampGranularity",
+			updatefn: func(tree *trillian.Tree) {
+				tree.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND
+			},
+			wantErr: true,
+		},
+		{
+			desc:     "HashOnly",
+			updatefn: func(tree *trillian.Tree) { tree.HashOnly = !tree.HashOnly },
+			wantErr:  true,
+		},
+		{
+			desc: "LeafCompression",
+			updatefn: func(tree *trillian.Tree) {
+				tree.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_GZIP
+			},
+			wantErr: true,
+		},
+		{
+			desc: "LeafCompressionDictionaries",
+			updatefn: func(tree *trillian.Tree) {
+				tree.LeafCompressionDictionaries = []*trillian.LeafCompressionDictionary{{Version: 1, Dictionary: testDictionary(t, "dict")}}
+			},
+			wantErr: true,
+		},
+		{
+			desc:     "MaxTreeSize",
+			updatefn: func(tree *trillian.Tree) { tree.MaxTreeSize = 1000 },
+			wantErr:  true,
+		},
+		{
+			desc:     "QueueWriteAhead",
+			updatefn: func(tree *trillian.Tree) { tree.QueueWriteAhead = true },
+			wantErr:  true,
+		},
+		{
+			desc: "LeafEncryption",
+			updatefn: func(tree *trillian.Tree) {
+				tree.LeafEncryption = &trillian.LeafEncryption{WrappedDataKey: []byte("wrapped"), KekId: "kek"}
+			},
+			wantErr: true,
+		},
+		{
+			desc:     "SortByQueueTimestamp",
+			updatefn: func(tree *trillian.Tree) { tree.SortByQueueTimestamp = true },
+			wantErr:  true,
+		},
+		{
+			desc: "LeafOrderingKey",
+			updatefn: func(tree *trillian.Tree) {
+				tree.LeafOrderingKey = &trillian.LeafOrderingKey{Source: trillian.LeafOrderingKey_LEAF_VALUE}
+			},
+			wantErr: true,
+		},
+		{
+			desc:     "LeafTombstones",
+			updatefn: func(tree *trillian.Tree) { tree.LeafTombstones = true },
+			wantErr:  true,
+		},
+		{
+			desc:     "EmptyRootHash",
+			updatefn: func(tree *trillian.Tree) { tree.EmptyRootHash = make([]byte, 32) },
+			wantErr:  true,
+		},
+		{
+			desc:     "LeafIndexOffset",
+			updatefn: func(tree *trillian.Tree) { tree.LeafIndexOffset = 1000 },
+			wantErr:  true,
+		},
+		{
+			desc:     "Namespace",
+			updatefn: func(tree *trillian.Tree) { tree.Namespace = "other" },
+			wantErr:  true,
+		},
 	}
 	for _, test := range tests {
 		tree := newTree()
@@ -437,6 +1072,114 @@ func TestValidateTreeForUpdate(t *testing.T) {
 	}
 }
 
+func TestValidateTreeForUpdateLeafCompressionDictionaries(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		desc     string
+		updatefn func(*trillian.Tree)
+		wantErr  bool
+	}{
+		{
+			desc: "append",
+			updatefn: func(tree *trillian.Tree) {
+				tree.LeafCompressionDictionaries = append(tree.LeafCompressionDictionaries,
+					&trillian.LeafCompressionDictionary{Version: 2, Dictionary: testDictionary(t, "dict2")})
+			},
+		},
+		{
+			desc: "appendTwo",
+			updatefn: func(tree *trillian.Tree) {
+				tree.LeafCompressionDictionaries = append(tree.LeafCompressionDictionaries,
+					&trillian.LeafCompressionDictionary{Version: 2, Dictionary: testDictionary(t, "dict2")},
+					&trillian.LeafCompressionDictionary{Version: 3, Dictionary: testDictionary(t, "dict3")})
+			},
+		},
+		{
+			desc: "misnumbered",
+			updatefn: func(tree *trillian.Tree) {
+				tree.LeafCompressionDictionaries = append(tree.LeafCompressionDictionaries,
+					&trillian.LeafCompressionDictionary{Version: 3, Dictionary: testDictionary(t, "dict3")})
+			},
+			wantErr: true,
+		},
+		{
+			desc:     "change",
+			updatefn: func(tree *trillian.Tree) { tree.LeafCompressionDictionaries[0].Dictionary = testDictionary(t, "other") },
+			wantErr:  true,
+		},
+		{
+			desc:     "remove",
+			updatefn: func(tree *trillian.Tree) { tree.LeafCompressionDictionaries = nil },
+			wantErr:  true,
+		},
+	}
+	for _, test := range tests {
+		tree := newTree()
+		tree.LeafCompression = trillian.LeafCompression_LEAF_COMPRESSION_ZSTD_DICTIONARY
+		tree.LeafCompressionDictionaries = []*trillian.LeafCompressionDictionary{{Version: 1, Dictionary: testDictionary(t, "dict1")}}
+
+		baseTree := proto.Clone(tree).(*trillian.Tree)
+		test.updatefn(tree)
+
+		err := ValidateTreeForUpdate(ctx, baseTree, tree)
+		switch hasErr := err != nil; {
+		case hasErr != test.wantErr:
+			t.Errorf("%v: ValidateTreeForUpdate() = %v, wantErr = %v", test.desc, err, test.wantErr)
+		case hasErr && status.Code(err) != codes.InvalidArgument:
+			t.Errorf("%v: ValidateTreeForUpdate() = %v, wantCode = %d", test.desc, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestValidateTreeTemplate(t *testing.T) {
+	valid := func() *trillian.TreeTemplate {
+		return &trillian.TreeTemplate{
+			Name: "ct",
+			Tree: &trillian.Tree{
+				TreeType:     trillian.TreeType_LOG,
+				HashStrategy: trillian.HashStrategy_RFC6962_SHA256,
+			},
+		}
+	}
+	tests := []struct {
+		desc    string
+		modify  func(*trillian.TreeTemplate)
+		wantErr bool
+	}{
+		{desc: "valid", modify: func(*trillian.TreeTemplate) {}},
+		{desc: "validName", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Name = "ct-2020_a" }},
+		{desc: "emptyTree", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Tree = &trillian.Tree{} }},
+		{desc: "noName", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Name = "" }, wantErr: true},
+		{desc: "upperCaseName", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Name = "CT" }, wantErr: true},
+		{desc: "leadingHyphen", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Name = "-ct" }, wantErr: true},
+		{desc: "longName", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Name = strings.Repeat("a", 64) }, wantErr: true},
+		{desc: "nilTree", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Tree = nil }, wantErr: true},
+		{desc: "treeID", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Tree.TreeId = 12345 }, wantErr: true},
+		{desc: "privateKey", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Tree.PrivateKey = newTree().PrivateKey }, wantErr: true},
+		{desc: "publicKey", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Tree.PublicKey = newTree().PublicKey }, wantErr: true},
+		{desc: "createTime", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Tree.CreateTime = ptypes.TimestampNow() }, wantErr: true},
+		{desc: "deleted", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Tree.Deleted = true }, wantErr: true},
+		{desc: "leafEncryption", modify: func(tmpl *trillian.TreeTemplate) { tmpl.Tree.LeafEncryption = &trillian.LeafEncryption{} }},
+		{desc: "wrappedDataKey", modify: func(tmpl *trillian.TreeTemplate) {
+			tmpl.Tree.LeafEncryption = &trillian.LeafEncryption{WrappedDataKey: []byte("wrapped"), KekId: "kek"}
+		}, wantErr: true},
+	}
+	for _, test := range tests {
+		tmpl := valid()
+		test.modify(tmpl)
+		err := ValidateTreeTemplate(tmpl)
+		if hasErr := err != nil; hasErr != test.wantErr {
+			t.Errorf("%v: ValidateTreeTemplate() = %v, wantErr = %v", test.desc, err, test.wantErr)
+		} else if hasErr && status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%v: ValidateTreeTemplate() = %v, wantCode = %d", test.desc, err, codes.InvalidArgument)
+		}
+	}
+	if err := ValidateTreeTemplate(nil); err == nil {
+		t.Error("ValidateTreeTemplate(nil) = nil, want error")
+	}
+}
+
 // newTree returns a valid log tree for tests.
 func newTree() *trillian.Tree {
 	privateKey, err := ptypes.MarshalAny(&keyspb.PEMKeyFile{
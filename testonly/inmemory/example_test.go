@@ -0,0 +1,65 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inmemory_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/trillian"
+	"github.com/google/trillian/testonly/inmemory"
+	"github.com/google/trillian/types"
+)
+
+func ExampleLogEnv() {
+	ctx := context.Background()
+	env, err := inmemory.NewLogEnv(ctx, 1 /* seed */)
+	if err != nil {
+		panic(err)
+	}
+	defer env.Close()
+
+	tree, err := env.CreateLog(ctx, nil)
+	if err != nil {
+		panic(err)
+	}
+	for _, data := range []string{"one", "two", "three"} {
+		leaf := &trillian.LogLeaf{LeafValue: []byte(data)}
+		if _, err := env.Log.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: tree.TreeId, Leaf: leaf}); err != nil {
+			panic(err)
+		}
+	}
+
+	// Nothing is integrated until the sequencer is run.
+	n, err := env.Advance(ctx, time.Minute)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("integrated %d leaves\n", n)
+
+	resp, err := env.Log.GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{LogId: tree.TreeId})
+	if err != nil {
+		panic(err)
+	}
+	var root types.LogRootV1
+	if err := root.UnmarshalBinary(resp.SignedLogRoot.LogRoot); err != nil {
+		panic(err)
+	}
+	fmt.Printf("tree size %d at %v\n", root.TreeSize, time.Unix(0, int64(root.TimestampNanos)).UTC())
+	// Output:
+	// integrated 3 leaves
+	// tree size 3 at 2020-01-01 00:01:00 +0000 UTC
+}
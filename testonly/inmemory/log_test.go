@@ -0,0 +1,327 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/trillian"
+	"github.com/google/trillian/client"
+	"github.com/google/trillian/storage/testonly"
+	"github.com/google/trillian/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLogEnv_TreeIDsAreReproducible(t *testing.T) {
+	ctx := context.Background()
+	var ids [2][]int64
+	for i := range ids {
+		env, err := NewLogEnv(ctx, 42)
+		if err != nil {
+			t.Fatalf("NewLogEnv(): %v", err)
+		}
+		for j := 0; j < 3; j++ {
+			tree, err := env.CreateLog(ctx, nil)
+			if err != nil {
+				t.Fatalf("CreateLog(): %v", err)
+			}
+			ids[i] = append(ids[i], tree.TreeId)
+		}
+		env.Close()
+	}
+	for j := range ids[0] {
+		if ids[0][j] != ids[1][j] {
+			t.Errorf("tree %d: got IDs %d and %d with the same seed, want equal", j, ids[0][j], ids[1][j])
+		}
+	}
+}
+
+func TestLogEnv_Advance(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewLogEnv(ctx, 1)
+	if err != nil {
+		t.Fatalf("NewLogEnv(): %v", err)
+	}
+	defer env.Close()
+	tree, err := env.CreateLog(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateLog(): %v", err)
+	}
+
+	if n, err := env.Advance(ctx, time.Second); err != nil || n != 0 {
+		t.Fatalf("Advance() with no leaves = (%d, %v), want (0, nil)", n, err)
+	}
+	leaf := &trillian.LogLeaf{LeafValue: []byte("leaf")}
+	if _, err := env.Log.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: tree.TreeId, Leaf: leaf}); err != nil {
+		t.Fatalf("QueueLeaf(): %v", err)
+	}
+	resp, err := env.Log.GetLeavesByIndex(ctx, &trillian.GetLeavesByIndexRequest{LogId: tree.TreeId, LeafIndex: []int64{0}})
+	if err == nil && len(resp.Leaves) > 0 {
+		t.Fatalf("GetLeavesByIndex() before Advance() = %v, want no leaves", resp.Leaves)
+	}
+	if n, err := env.Advance(ctx, time.Second); err != nil || n != 1 {
+		t.Fatalf("Advance() = (%d, %v), want (1, nil)", n, err)
+	}
+	resp, err = env.Log.GetLeavesByIndex(ctx, &trillian.GetLeavesByIndexRequest{LogId: tree.TreeId, LeafIndex: []int64{0}})
+	if err != nil {
+		t.Fatalf("GetLeavesByIndex(): %v", err)
+	}
+	if got, want := resp.Leaves[0].QueueTimestamp.GetSeconds(), StartTime.Add(time.Second).Unix(); got != want {
+		t.Errorf("QueueTimestamp = %ds, want %ds from the fake clock", got, want)
+	}
+}
+
+func TestLogEnv_CoarseTimestampGranularity(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewLogEnv(ctx, 1)
+	if err != nil {
+		t.Fatalf("NewLogEnv(): %v", err)
+	}
+	defer env.Close()
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.TimestampGranularity = trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND
+	tree, err = env.CreateLog(ctx, tree)
+	if err != nil {
+		t.Fatalf("CreateLog(): %v", err)
+	}
+
+	// Queue and sequence a leaf ten times a second, for a few seconds.
+	const passes = 35
+	var prev types.LogRootV1
+	integrated, roots := 0, 0
+	for i := 0; i < passes; i++ {
+		leaf := &trillian.LogLeaf{LeafValue: []byte{byte(i)}}
+		if _, err := env.Log.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: tree.TreeId, Leaf: leaf}); err != nil {
+			t.Fatalf("QueueLeaf(): %v", err)
+		}
+		n, err := env.Advance(ctx, 100*time.Millisecond)
+		if err != nil {
+			t.Fatalf("Advance(): %v", err)
+		}
+		integrated += n
+
+		resp, err := env.Log.GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{LogId: tree.TreeId})
+		if err != nil {
+			t.Fatalf("GetLatestSignedLogRoot(): %v", err)
+		}
+		var root types.LogRootV1
+		if err := root.UnmarshalBinary(resp.SignedLogRoot.LogRoot); err != nil {
+			t.Fatalf("UnmarshalBinary(): %v", err)
+		}
+		if got := root.TimestampNanos % uint64(time.Second); got != 0 {
+			t.Errorf("pass %d: root timestamp %d is not a whole second", i, root.TimestampNanos)
+		}
+		if i > 0 && root.Revision != prev.Revision {
+			roots++
+			if root.TimestampNanos <= prev.TimestampNanos {
+				t.Errorf("pass %d: root timestamp %d not after previous root's %d", i, root.TimestampNanos, prev.TimestampNanos)
+			}
+		} else if n != 0 {
+			t.Errorf("pass %d: integrated %d leaves without a new root", i, n)
+		}
+		prev = root
+	}
+
+	// The clock only crossed three second boundaries.
+	if got, want := roots, 3; got != want {
+		t.Errorf("got %d new roots, want %d", got, want)
+	}
+	if got, want := integrated, 30; got != want {
+		t.Errorf("integrated %d leaves, want %d", got, want)
+	}
+}
+
+func TestLogEnv_HashOnly(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewLogEnv(ctx, 1)
+	if err != nil {
+		t.Fatalf("NewLogEnv(): %v", err)
+	}
+	defer env.Close()
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.HashOnly = true
+	tree, err = env.CreateLog(ctx, tree)
+	if err != nil {
+		t.Fatalf("CreateLog(): %v", err)
+	}
+	c, err := client.NewFromTree(env.Log, tree, types.LogRootV1{})
+	if err != nil {
+		t.Fatalf("NewFromTree(): %v", err)
+	}
+
+	// Only the hashes of the values are sent to the log.
+	var hashes [][]byte
+	for i := 0; i < 3; i++ {
+		hash := c.Hasher.HashLeaf([]byte(fmt.Sprintf("private-%d", i)))
+		if err := c.QueueLeafHash(ctx, hash, []byte("extra")); err != nil {
+			t.Fatalf("QueueLeafHash(): %v", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	if _, err := env.Advance(ctx, time.Second); err != nil {
+		t.Fatalf("Advance(): %v", err)
+	}
+
+	wctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for _, hash := range hashes {
+		if err := c.WaitForInclusionByHash(wctx, hash); err != nil {
+			t.Fatalf("WaitForInclusionByHash(%x): %v", hash, err)
+		}
+	}
+	root := c.GetRoot()
+	if got, want := root.TreeSize, uint64(len(hashes)); got != want {
+		t.Fatalf("root has tree size %d, want %d", got, want)
+	}
+
+	entry, err := env.Log.GetEntryAndProof(ctx, &trillian.GetEntryAndProofRequest{LogId: tree.TreeId, LeafIndex: 1, TreeSize: int64(root.TreeSize)})
+	if err != nil {
+		t.Fatalf("GetEntryAndProof(): %v", err)
+	}
+	if got := entry.Leaf.LeafValue; len(got) != 0 {
+		t.Errorf("GetEntryAndProof() returned leaf value %q, want none", got)
+	}
+	if got, want := entry.Leaf.MerkleLeafHash, hashes[entry.Leaf.LeafIndex]; !bytes.Equal(got, want) {
+		t.Errorf("GetEntryAndProof() returned leaf hash %x, want %x", got, want)
+	}
+	if err := c.VerifyInclusionByHash(root, entry.Leaf.MerkleLeafHash, entry.Proof); err != nil {
+		t.Errorf("VerifyInclusionByHash(): %v", err)
+	}
+}
+
+func TestLogEnv_TrivialConsistencyProofs(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewLogEnv(ctx, 1)
+	if err != nil {
+		t.Fatalf("NewLogEnv(): %v", err)
+	}
+	defer env.Close()
+	tree, err := env.CreateLog(ctx, nil)
+	if err != nil {
+		t.Fatalf("CreateLog(): %v", err)
+	}
+	v, err := client.NewLogVerifierFromTree(tree)
+	if err != nil {
+		t.Fatalf("NewLogVerifierFromTree(): %v", err)
+	}
+
+	getRoot := func() types.LogRootV1 {
+		t.Helper()
+		resp, err := env.Log.GetLatestSignedLogRoot(ctx, &trillian.GetLatestSignedLogRootRequest{LogId: tree.TreeId})
+		if err != nil {
+			t.Fatalf("GetLatestSignedLogRoot(): %v", err)
+		}
+		var root types.LogRootV1
+		if err := root.UnmarshalBinary(resp.SignedLogRoot.LogRoot); err != nil {
+			t.Fatalf("UnmarshalBinary(): %v", err)
+		}
+		return root
+	}
+	getProof := func(first, second int64) [][]byte {
+		t.Helper()
+		resp, err := env.Log.GetConsistencyProof(ctx, &trillian.GetConsistencyProofRequest{LogId: tree.TreeId, FirstTreeSize: first, SecondTreeSize: second})
+		if err != nil {
+			t.Fatalf("GetConsistencyProof(%d, %d): %v", first, second, err)
+		}
+		if resp.Proof == nil || len(resp.Proof.Hashes) != 0 {
+			t.Fatalf("GetConsistencyProof(%d, %d) returned proof %v, want an empty proof", first, second, resp.Proof)
+		}
+		return resp.Proof.Hashes
+	}
+
+	empty := getRoot()
+	for i := 0; i < 5; i++ {
+		leaf := &trillian.LogLeaf{LeafValue: []byte{byte(i)}}
+		if _, err := env.Log.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: tree.TreeId, Leaf: leaf}); err != nil {
+			t.Fatalf("QueueLeaf(): %v", err)
+		}
+	}
+	if _, err := env.Advance(ctx, time.Second); err != nil {
+		t.Fatalf("Advance(): %v", err)
+	}
+	root := getRoot()
+
+	// The empty proofs returned by the log are accepted by the client.
+	checkpoints := []types.LogRootV1{empty, empty, root, root}
+	proofs := [][][]byte{getProof(0, 0), getProof(0, 5), getProof(5, 5)}
+	if err := v.VerifyConsistencyChain(checkpoints, proofs); err != nil {
+		t.Errorf("VerifyConsistencyChain(): %v", err)
+	}
+
+	_, err = env.Log.GetConsistencyProof(ctx, &trillian.GetConsistencyProofRequest{LogId: tree.TreeId, FirstTreeSize: 5, SecondTreeSize: 3})
+	if got, want := status.Code(err), codes.InvalidArgument; got != want {
+		t.Errorf("GetConsistencyProof(5, 3) = %v, want code %v", err, want)
+	}
+}
+
+func TestLogEnv_MaxTreeSize(t *testing.T) {
+	ctx := context.Background()
+	env, err := NewLogEnv(ctx, 1)
+	if err != nil {
+		t.Fatalf("NewLogEnv(): %v", err)
+	}
+	defer env.Close()
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+	tree.MaxTreeSize = 3
+	tree, err = env.CreateLog(ctx, tree)
+	if err != nil {
+		t.Fatalf("CreateLog(): %v", err)
+	}
+	c, err := client.NewFromTree(env.Log, tree, types.LogRootV1{})
+	if err != nil {
+		t.Fatalf("NewFromTree(): %v", err)
+	}
+
+	// More leaves than fit are queued before the log fills up.
+	for i := 0; i < 5; i++ {
+		leaf := &trillian.LogLeaf{LeafValue: []byte(fmt.Sprintf("leaf-%d", i))}
+		if _, err := env.Log.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: tree.TreeId, Leaf: leaf}); err != nil {
+			t.Fatalf("QueueLeaf(%d): %v", i, err)
+		}
+	}
+	for i, want := range []int{3, 0} {
+		if n, err := env.Advance(ctx, time.Second); err != nil || n != want {
+			t.Fatalf("Advance() pass %d = (%d, %v), want (%d, nil)", i, n, err, want)
+		}
+	}
+	root, err := c.UpdateRoot(ctx)
+	if err != nil {
+		t.Fatalf("UpdateRoot(): %v", err)
+	}
+	if got, want := root.TreeSize, uint64(tree.MaxTreeSize); got != want {
+		t.Fatalf("root has tree size %d, want %d", got, want)
+	}
+
+	leaf := &trillian.LogLeaf{LeafValue: []byte("too late")}
+	_, err = env.Log.QueueLeaf(ctx, &trillian.QueueLeafRequest{LogId: tree.TreeId, Leaf: leaf})
+	if got, want := status.Code(err), codes.FailedPrecondition; got != want {
+		t.Errorf("QueueLeaf() on full log = %v, want code %v", err, want)
+	}
+
+	// The leaves of the full log can still be proven.
+	entry, err := env.Log.GetEntryAndProof(ctx, &trillian.GetEntryAndProofRequest{LogId: tree.TreeId, LeafIndex: 2, TreeSize: int64(root.TreeSize)})
+	if err != nil {
+		t.Fatalf("GetEntryAndProof(): %v", err)
+	}
+	if err := c.VerifyInclusionByHash(root, entry.Leaf.MerkleLeafHash, entry.Proof); err != nil {
+		t.Errorf("VerifyInclusionByHash(): %v", err)
+	}
+}
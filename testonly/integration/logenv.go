@@ -127,7 +127,7 @@ func NewLogEnvWithRegistryAndGRPCOptions(ctx context.Context, numSequencers int,
 	grpcServer := grpc.NewServer(serverOpts...)
 
 	// Setup the Admin Server.
-	adminServer := admin.New(registry, nil)
+	adminServer := admin.New(registry, nil /* allowedTreeTypes */, 0 /* deleteThreshold */)
 	trillian.RegisterTrillianAdminServer(grpcServer, adminServer)
 
 	// Setup the Log Server.
@@ -123,7 +123,7 @@ func NewMapEnvWithRegistry(registry extension.Registry, singleTX bool) (*MapEnv,
 	writeServer := server.NewTrillianMapWriteServer(registry, mapServer)
 	trillian.RegisterTrillianMapServer(grpcServer, mapServer)
 	trillian.RegisterTrillianMapWriteServer(grpcServer, writeServer)
-	trillian.RegisterTrillianAdminServer(grpcServer, admin.New(registry, nil /* allowedTreeTypes */))
+	trillian.RegisterTrillianAdminServer(grpcServer, admin.New(registry, nil /* allowedTreeTypes */, 0 /* deleteThreshold */))
 	go grpcServer.Serve(lis)
 
 	// Connect to the server.
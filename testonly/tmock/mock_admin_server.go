@@ -7,6 +7,7 @@ package tmock
 import (
 	context "context"
 	gomock "github.com/golang/mock/gomock"
+	empty "github.com/golang/protobuf/ptypes/empty"
 	trillian "github.com/google/trillian"
 	reflect "reflect"
 )
@@ -34,6 +35,36 @@ func (m *MockTrillianAdminServer) EXPECT() *MockTrillianAdminServerMockRecorder
 	return m.recorder
 }
 
+// BatchUpdateTrees mocks base method
+func (m *MockTrillianAdminServer) BatchUpdateTrees(arg0 context.Context, arg1 *trillian.BatchUpdateTreesRequest) (*trillian.BatchUpdateTreesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchUpdateTrees", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.BatchUpdateTreesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BatchUpdateTrees indicates an expected call of BatchUpdateTrees
+func (mr *MockTrillianAdminServerMockRecorder) BatchUpdateTrees(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchUpdateTrees", reflect.TypeOf((*MockTrillianAdminServer)(nil).BatchUpdateTrees), arg0, arg1)
+}
+
+// CompactTreeStorage mocks base method
+func (m *MockTrillianAdminServer) CompactTreeStorage(arg0 context.Context, arg1 *trillian.CompactTreeStorageRequest) (*trillian.CompactTreeStorageResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompactTreeStorage", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.CompactTreeStorageResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompactTreeStorage indicates an expected call of CompactTreeStorage
+func (mr *MockTrillianAdminServerMockRecorder) CompactTreeStorage(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompactTreeStorage", reflect.TypeOf((*MockTrillianAdminServer)(nil).CompactTreeStorage), arg0, arg1)
+}
+
 // CreateTree mocks base method
 func (m *MockTrillianAdminServer) CreateTree(arg0 context.Context, arg1 *trillian.CreateTreeRequest) (*trillian.Tree, error) {
 	m.ctrl.T.Helper()
@@ -49,6 +80,21 @@ func (mr *MockTrillianAdminServerMockRecorder) CreateTree(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTree", reflect.TypeOf((*MockTrillianAdminServer)(nil).CreateTree), arg0, arg1)
 }
 
+// CreateTreeTemplate mocks base method
+func (m *MockTrillianAdminServer) CreateTreeTemplate(arg0 context.Context, arg1 *trillian.CreateTreeTemplateRequest) (*trillian.TreeTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.TreeTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTreeTemplate indicates an expected call of CreateTreeTemplate
+func (mr *MockTrillianAdminServerMockRecorder) CreateTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTreeTemplate", reflect.TypeOf((*MockTrillianAdminServer)(nil).CreateTreeTemplate), arg0, arg1)
+}
+
 // DeleteTree mocks base method
 func (m *MockTrillianAdminServer) DeleteTree(arg0 context.Context, arg1 *trillian.DeleteTreeRequest) (*trillian.Tree, error) {
 	m.ctrl.T.Helper()
@@ -64,6 +110,36 @@ func (mr *MockTrillianAdminServerMockRecorder) DeleteTree(arg0, arg1 interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTree", reflect.TypeOf((*MockTrillianAdminServer)(nil).DeleteTree), arg0, arg1)
 }
 
+// DeleteTreeTemplate mocks base method
+func (m *MockTrillianAdminServer) DeleteTreeTemplate(arg0 context.Context, arg1 *trillian.DeleteTreeTemplateRequest) (*empty.Empty, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*empty.Empty)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteTreeTemplate indicates an expected call of DeleteTreeTemplate
+func (mr *MockTrillianAdminServerMockRecorder) DeleteTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTreeTemplate", reflect.TypeOf((*MockTrillianAdminServer)(nil).DeleteTreeTemplate), arg0, arg1)
+}
+
+// GetRootAges mocks base method
+func (m *MockTrillianAdminServer) GetRootAges(arg0 context.Context, arg1 *trillian.GetRootAgesRequest) (*trillian.GetRootAgesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRootAges", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.GetRootAgesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRootAges indicates an expected call of GetRootAges
+func (mr *MockTrillianAdminServerMockRecorder) GetRootAges(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRootAges", reflect.TypeOf((*MockTrillianAdminServer)(nil).GetRootAges), arg0, arg1)
+}
+
 // GetTree mocks base method
 func (m *MockTrillianAdminServer) GetTree(arg0 context.Context, arg1 *trillian.GetTreeRequest) (*trillian.Tree, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +155,66 @@ func (mr *MockTrillianAdminServerMockRecorder) GetTree(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTree", reflect.TypeOf((*MockTrillianAdminServer)(nil).GetTree), arg0, arg1)
 }
 
+// GetTreeAttestation mocks base method
+func (m *MockTrillianAdminServer) GetTreeAttestation(arg0 context.Context, arg1 *trillian.GetTreeAttestationRequest) (*trillian.TreeAttestation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTreeAttestation", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.TreeAttestation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeAttestation indicates an expected call of GetTreeAttestation
+func (mr *MockTrillianAdminServerMockRecorder) GetTreeAttestation(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeAttestation", reflect.TypeOf((*MockTrillianAdminServer)(nil).GetTreeAttestation), arg0, arg1)
+}
+
+// GetTreeTemplate mocks base method
+func (m *MockTrillianAdminServer) GetTreeTemplate(arg0 context.Context, arg1 *trillian.GetTreeTemplateRequest) (*trillian.TreeTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.TreeTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTreeTemplate indicates an expected call of GetTreeTemplate
+func (mr *MockTrillianAdminServerMockRecorder) GetTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTreeTemplate", reflect.TypeOf((*MockTrillianAdminServer)(nil).GetTreeTemplate), arg0, arg1)
+}
+
+// ListSoftDeletedTrees mocks base method
+func (m *MockTrillianAdminServer) ListSoftDeletedTrees(arg0 context.Context, arg1 *trillian.ListSoftDeletedTreesRequest) (*trillian.ListSoftDeletedTreesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListSoftDeletedTrees", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.ListSoftDeletedTreesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListSoftDeletedTrees indicates an expected call of ListSoftDeletedTrees
+func (mr *MockTrillianAdminServerMockRecorder) ListSoftDeletedTrees(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSoftDeletedTrees", reflect.TypeOf((*MockTrillianAdminServer)(nil).ListSoftDeletedTrees), arg0, arg1)
+}
+
+// ListTreeTemplates mocks base method
+func (m *MockTrillianAdminServer) ListTreeTemplates(arg0 context.Context, arg1 *trillian.ListTreeTemplatesRequest) (*trillian.ListTreeTemplatesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTreeTemplates", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.ListTreeTemplatesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTreeTemplates indicates an expected call of ListTreeTemplates
+func (mr *MockTrillianAdminServerMockRecorder) ListTreeTemplates(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTreeTemplates", reflect.TypeOf((*MockTrillianAdminServer)(nil).ListTreeTemplates), arg0, arg1)
+}
+
 // ListTrees mocks base method
 func (m *MockTrillianAdminServer) ListTrees(arg0 context.Context, arg1 *trillian.ListTreesRequest) (*trillian.ListTreesResponse, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +230,21 @@ func (mr *MockTrillianAdminServerMockRecorder) ListTrees(arg0, arg1 interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTrees", reflect.TypeOf((*MockTrillianAdminServer)(nil).ListTrees), arg0, arg1)
 }
 
+// RewrapLeafDataKey mocks base method
+func (m *MockTrillianAdminServer) RewrapLeafDataKey(arg0 context.Context, arg1 *trillian.RewrapLeafDataKeyRequest) (*trillian.Tree, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RewrapLeafDataKey", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.Tree)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RewrapLeafDataKey indicates an expected call of RewrapLeafDataKey
+func (mr *MockTrillianAdminServerMockRecorder) RewrapLeafDataKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RewrapLeafDataKey", reflect.TypeOf((*MockTrillianAdminServer)(nil).RewrapLeafDataKey), arg0, arg1)
+}
+
 // UndeleteTree mocks base method
 func (m *MockTrillianAdminServer) UndeleteTree(arg0 context.Context, arg1 *trillian.UndeleteTreeRequest) (*trillian.Tree, error) {
 	m.ctrl.T.Helper()
@@ -123,3 +274,18 @@ func (mr *MockTrillianAdminServerMockRecorder) UpdateTree(arg0, arg1 interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTree", reflect.TypeOf((*MockTrillianAdminServer)(nil).UpdateTree), arg0, arg1)
 }
+
+// UpdateTreeTemplate mocks base method
+func (m *MockTrillianAdminServer) UpdateTreeTemplate(arg0 context.Context, arg1 *trillian.UpdateTreeTemplateRequest) (*trillian.TreeTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTreeTemplate", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.TreeTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateTreeTemplate indicates an expected call of UpdateTreeTemplate
+func (mr *MockTrillianAdminServerMockRecorder) UpdateTreeTemplate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTreeTemplate", reflect.TypeOf((*MockTrillianAdminServer)(nil).UpdateTreeTemplate), arg0, arg1)
+}
@@ -64,6 +64,21 @@ func (mr *MockTrillianLogServerMockRecorder) AddSequencedLeaves(arg0, arg1 inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSequencedLeaves", reflect.TypeOf((*MockTrillianLogServer)(nil).AddSequencedLeaves), arg0, arg1)
 }
 
+// BulkAddSequencedLeaves mocks base method
+func (m *MockTrillianLogServer) BulkAddSequencedLeaves(arg0 context.Context, arg1 *trillian.BulkAddSequencedLeavesRequest) (*trillian.BulkAddSequencedLeavesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BulkAddSequencedLeaves", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.BulkAddSequencedLeavesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BulkAddSequencedLeaves indicates an expected call of BulkAddSequencedLeaves
+func (mr *MockTrillianLogServerMockRecorder) BulkAddSequencedLeaves(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BulkAddSequencedLeaves", reflect.TypeOf((*MockTrillianLogServer)(nil).BulkAddSequencedLeaves), arg0, arg1)
+}
+
 // GetConsistencyProof mocks base method
 func (m *MockTrillianLogServer) GetConsistencyProof(arg0 context.Context, arg1 *trillian.GetConsistencyProofRequest) (*trillian.GetConsistencyProofResponse, error) {
 	m.ctrl.T.Helper()
@@ -79,6 +94,21 @@ func (mr *MockTrillianLogServerMockRecorder) GetConsistencyProof(arg0, arg1 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConsistencyProof", reflect.TypeOf((*MockTrillianLogServer)(nil).GetConsistencyProof), arg0, arg1)
 }
 
+// GetEffectiveLeaves mocks base method
+func (m *MockTrillianLogServer) GetEffectiveLeaves(arg0 context.Context, arg1 *trillian.GetEffectiveLeavesRequest) (*trillian.GetEffectiveLeavesResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEffectiveLeaves", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.GetEffectiveLeavesResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEffectiveLeaves indicates an expected call of GetEffectiveLeaves
+func (mr *MockTrillianLogServerMockRecorder) GetEffectiveLeaves(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEffectiveLeaves", reflect.TypeOf((*MockTrillianLogServer)(nil).GetEffectiveLeaves), arg0, arg1)
+}
+
 // GetEntryAndProof mocks base method
 func (m *MockTrillianLogServer) GetEntryAndProof(arg0 context.Context, arg1 *trillian.GetEntryAndProofRequest) (*trillian.GetEntryAndProofResponse, error) {
 	m.ctrl.T.Helper()
@@ -94,6 +124,21 @@ func (mr *MockTrillianLogServerMockRecorder) GetEntryAndProof(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntryAndProof", reflect.TypeOf((*MockTrillianLogServer)(nil).GetEntryAndProof), arg0, arg1)
 }
 
+// GetHistoricalInclusionProof mocks base method
+func (m *MockTrillianLogServer) GetHistoricalInclusionProof(arg0 context.Context, arg1 *trillian.GetHistoricalInclusionProofRequest) (*trillian.GetHistoricalInclusionProofResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistoricalInclusionProof", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.GetHistoricalInclusionProofResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistoricalInclusionProof indicates an expected call of GetHistoricalInclusionProof
+func (mr *MockTrillianLogServerMockRecorder) GetHistoricalInclusionProof(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistoricalInclusionProof", reflect.TypeOf((*MockTrillianLogServer)(nil).GetHistoricalInclusionProof), arg0, arg1)
+}
+
 // GetInclusionProof mocks base method
 func (m *MockTrillianLogServer) GetInclusionProof(arg0 context.Context, arg1 *trillian.GetInclusionProofRequest) (*trillian.GetInclusionProofResponse, error) {
 	m.ctrl.T.Helper()
@@ -169,6 +214,21 @@ func (mr *MockTrillianLogServerMockRecorder) GetLeavesByIndex(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeavesByIndex", reflect.TypeOf((*MockTrillianLogServer)(nil).GetLeavesByIndex), arg0, arg1)
 }
 
+// GetLeavesByKeyRange mocks base method
+func (m *MockTrillianLogServer) GetLeavesByKeyRange(arg0 context.Context, arg1 *trillian.GetLeavesByKeyRangeRequest) (*trillian.GetLeavesByKeyRangeResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLeavesByKeyRange", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.GetLeavesByKeyRangeResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLeavesByKeyRange indicates an expected call of GetLeavesByKeyRange
+func (mr *MockTrillianLogServerMockRecorder) GetLeavesByKeyRange(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeavesByKeyRange", reflect.TypeOf((*MockTrillianLogServer)(nil).GetLeavesByKeyRange), arg0, arg1)
+}
+
 // GetLeavesByRange mocks base method
 func (m *MockTrillianLogServer) GetLeavesByRange(arg0 context.Context, arg1 *trillian.GetLeavesByRangeRequest) (*trillian.GetLeavesByRangeResponse, error) {
 	m.ctrl.T.Helper()
@@ -184,6 +244,21 @@ func (mr *MockTrillianLogServerMockRecorder) GetLeavesByRange(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLeavesByRange", reflect.TypeOf((*MockTrillianLogServer)(nil).GetLeavesByRange), arg0, arg1)
 }
 
+// GetProofAtRevision mocks base method
+func (m *MockTrillianLogServer) GetProofAtRevision(arg0 context.Context, arg1 *trillian.GetProofAtRevisionRequest) (*trillian.GetProofAtRevisionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProofAtRevision", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.GetProofAtRevisionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProofAtRevision indicates an expected call of GetProofAtRevision
+func (mr *MockTrillianLogServerMockRecorder) GetProofAtRevision(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProofAtRevision", reflect.TypeOf((*MockTrillianLogServer)(nil).GetProofAtRevision), arg0, arg1)
+}
+
 // GetSequencedLeafCount mocks base method
 func (m *MockTrillianLogServer) GetSequencedLeafCount(arg0 context.Context, arg1 *trillian.GetSequencedLeafCountRequest) (*trillian.GetSequencedLeafCountResponse, error) {
 	m.ctrl.T.Helper()
@@ -199,6 +274,21 @@ func (mr *MockTrillianLogServerMockRecorder) GetSequencedLeafCount(arg0, arg1 in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSequencedLeafCount", reflect.TypeOf((*MockTrillianLogServer)(nil).GetSequencedLeafCount), arg0, arg1)
 }
 
+// GetSignedLogRootHistory mocks base method
+func (m *MockTrillianLogServer) GetSignedLogRootHistory(arg0 context.Context, arg1 *trillian.GetSignedLogRootHistoryRequest) (*trillian.GetSignedLogRootHistoryResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSignedLogRootHistory", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.GetSignedLogRootHistoryResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSignedLogRootHistory indicates an expected call of GetSignedLogRootHistory
+func (mr *MockTrillianLogServerMockRecorder) GetSignedLogRootHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSignedLogRootHistory", reflect.TypeOf((*MockTrillianLogServer)(nil).GetSignedLogRootHistory), arg0, arg1)
+}
+
 // InitLog mocks base method
 func (m *MockTrillianLogServer) InitLog(arg0 context.Context, arg1 *trillian.InitLogRequest) (*trillian.InitLogResponse, error) {
 	m.ctrl.T.Helper()
@@ -214,6 +304,21 @@ func (mr *MockTrillianLogServerMockRecorder) InitLog(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitLog", reflect.TypeOf((*MockTrillianLogServer)(nil).InitLog), arg0, arg1)
 }
 
+// PredictRoot mocks base method
+func (m *MockTrillianLogServer) PredictRoot(arg0 context.Context, arg1 *trillian.PredictRootRequest) (*trillian.PredictRootResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PredictRoot", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.PredictRootResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PredictRoot indicates an expected call of PredictRoot
+func (mr *MockTrillianLogServerMockRecorder) PredictRoot(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PredictRoot", reflect.TypeOf((*MockTrillianLogServer)(nil).PredictRoot), arg0, arg1)
+}
+
 // QueueLeaf mocks base method
 func (m *MockTrillianLogServer) QueueLeaf(arg0 context.Context, arg1 *trillian.QueueLeafRequest) (*trillian.QueueLeafResponse, error) {
 	m.ctrl.T.Helper()
@@ -243,3 +348,32 @@ func (mr *MockTrillianLogServerMockRecorder) QueueLeaves(arg0, arg1 interface{})
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueLeaves", reflect.TypeOf((*MockTrillianLogServer)(nil).QueueLeaves), arg0, arg1)
 }
+
+// TailLeaves mocks base method
+func (m *MockTrillianLogServer) TailLeaves(arg0 *trillian.TailLeavesRequest, arg1 trillian.TrillianLog_TailLeavesServer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TailLeaves", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TailLeaves indicates an expected call of TailLeaves
+func (mr *MockTrillianLogServerMockRecorder) TailLeaves(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TailLeaves", reflect.TypeOf((*MockTrillianLogServer)(nil).TailLeaves), arg0, arg1)
+}
+
+// VerifyInclusion mocks base method
+func (m *MockTrillianLogServer) VerifyInclusion(arg0 context.Context, arg1 *trillian.VerifyInclusionRequest) (*trillian.VerifyInclusionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyInclusion", arg0, arg1)
+	ret0, _ := ret[0].(*trillian.VerifyInclusionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyInclusion indicates an expected call of VerifyInclusion
+func (mr *MockTrillianLogServerMockRecorder) VerifyInclusion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyInclusion", reflect.TypeOf((*MockTrillianLogServer)(nil).VerifyInclusion), arg0, arg1)
+}
@@ -20,11 +20,13 @@ import (
 	"context"
 	"crypto"
 	"fmt"
+	"time"
 
 	"github.com/golang/protobuf/ptypes"
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/merkle/hashers"
 	"github.com/google/trillian/monitoring"
 	"github.com/google/trillian/storage"
 	"google.golang.org/grpc/codes"
@@ -84,7 +86,7 @@ var rules = map[OpType]accessRule{
 		},
 		rejectCodes: map[trillian.TreeState]codes.Code{
 			trillian.TreeState_DRAINING: codes.PermissionDenied,
-			trillian.TreeState_FROZEN:   codes.PermissionDenied,
+			trillian.TreeState_FROZEN:   codes.FailedPrecondition,
 		},
 		okTypes: map[trillian.TreeType]bool{
 			trillian.TreeType_LOG:            true,
@@ -101,19 +103,29 @@ var rules = map[OpType]accessRule{
 			trillian.TreeType_PREORDERED_LOG: true,
 		},
 		rejectCodes: map[trillian.TreeState]codes.Code{
-			trillian.TreeState_FROZEN: codes.PermissionDenied,
+			trillian.TreeState_FROZEN: codes.FailedPrecondition,
 		},
 	},
 	UpdateMap: {
 		okStates: map[trillian.TreeState]bool{
 			trillian.TreeState_ACTIVE: true,
 		},
+		rejectCodes: map[trillian.TreeState]codes.Code{
+			trillian.TreeState_FROZEN: codes.FailedPrecondition,
+		},
 		okTypes: map[trillian.TreeType]bool{
 			trillian.TreeType_MAP: true,
 		},
 	},
 }
 
+// stateReasons are the reasons reported in the details of errors rejecting
+// operations due to the state of a tree.
+var stateReasons = map[trillian.TreeState]trillian.TreeUnavailableDetails_Reason{
+	trillian.TreeState_FROZEN:   trillian.TreeUnavailableDetails_TREE_FROZEN,
+	trillian.TreeState_DRAINING: trillian.TreeUnavailableDetails_TREE_DRAINING,
+}
+
 // NewContext returns a ctx with the given tree.
 func NewContext(ctx context.Context, tree *trillian.Tree) context.Context {
 	return context.WithValue(ctx, treeKey{}, tree)
@@ -146,7 +158,11 @@ func validate(o GetOpts, tree *trillian.Tree) error {
 		if !ok {
 			code = codes.InvalidArgument
 		}
-		return status.Errorf(code, "operation: %v not allowed for tree type: %v state: %v", o.Operation, tree.TreeType, tree.TreeState)
+		msg := fmt.Sprintf("operation: %v not allowed for tree type: %v state: %v", o.Operation, tree.TreeType, tree.TreeState)
+		if reason, ok := stateReasons[tree.TreeState]; ok && rule.okTypes[tree.TreeType] {
+			return unavailable(code, tree.TreeId, reason, msg)
+		}
+		return status.Error(code, msg)
 	}
 
 	return nil
@@ -162,6 +178,9 @@ func GetTree(ctx context.Context, s storage.AdminStorage, treeID int64, opts Get
 	if !ok {
 		var err error
 		tree, err = storage.GetTree(ctx, s, treeID)
+		if status.Code(err) == codes.NotFound && UnavailableReason(err) == trillian.TreeUnavailableDetails_REASON_UNSPECIFIED {
+			return nil, unavailable(codes.NotFound, treeID, trillian.TreeUnavailableDetails_TREE_NOT_FOUND, status.Convert(err).Message())
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -173,16 +192,39 @@ func GetTree(ctx context.Context, s storage.AdminStorage, treeID int64, opts Get
 		return nil, status.Errorf(codes.Internal, "got tree %v, want %v", tree.TreeId, treeID)
 	}
 
+	// Deleted trees are reported as such regardless of their state.
+	if tree.Deleted {
+		return nil, unavailable(codes.NotFound, tree.TreeId, trillian.TreeUnavailableDetails_TREE_DELETED, fmt.Sprintf("tree %v not found", tree.TreeId))
+	}
 	if err := validate(opts, tree); err != nil {
 		return nil, err
 	}
-	if tree.Deleted {
-		return nil, status.Errorf(codes.NotFound, "tree %v not found", tree.TreeId)
-	}
 
 	return tree, nil
 }
 
+// unavailable returns an error with the given code and message, and
+// trillian.TreeUnavailableDetails with the given tree ID and reason.
+func unavailable(code codes.Code, treeID int64, reason trillian.TreeUnavailableDetails_Reason, msg string) error {
+	s, err := status.New(code, msg).WithDetails(&trillian.TreeUnavailableDetails{TreeId: treeID, Reason: reason})
+	if err != nil {
+		return status.Error(code, msg)
+	}
+	return s.Err()
+}
+
+// UnavailableReason returns the reason in the trillian.TreeUnavailableDetails
+// of err, i.e. why a request was rejected due to the existence or state of
+// its tree, or REASON_UNSPECIFIED if err has no such details.
+func UnavailableReason(err error) trillian.TreeUnavailableDetails_Reason {
+	for _, d := range status.Convert(err).Details() {
+		if details, ok := d.(*trillian.TreeUnavailableDetails); ok {
+			return details.Reason
+		}
+	}
+	return trillian.TreeUnavailableDetails_REASON_UNSPECIFIED
+}
+
 // Hash returns the crypto.Hash configured by the tree.
 func Hash(tree *trillian.Tree) (crypto.Hash, error) {
 	switch tree.HashAlgorithm {
@@ -193,6 +235,31 @@ func Hash(tree *trillian.Tree) (crypto.Hash, error) {
 	return crypto.SHA256, fmt.Errorf("unexpected hash algorithm: %s", tree.HashAlgorithm)
 }
 
+// LogHasher returns the hashers.LogHasher configured by the log tree: the
+// hasher of its hash strategy, with the empty root overridden by its
+// empty_root_hash if set.
+func LogHasher(tree *trillian.Tree) (hashers.LogHasher, error) {
+	h, err := hashers.NewLogHasher(tree.HashStrategy)
+	if err != nil {
+		return nil, err
+	}
+	return hashers.WithEmptyRoot(h, tree.EmptyRootHash), nil
+}
+
+// RootTimestamp returns t in nanoseconds since the epoch, truncated to the
+// timestamp granularity configured by the tree.
+func RootTimestamp(tree *trillian.Tree, t time.Time) uint64 {
+	unit := int64(1)
+	switch tree.GetTimestampGranularity() {
+	case trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_MILLISECOND:
+		unit = int64(time.Millisecond)
+	case trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND:
+		unit = int64(time.Second)
+	}
+	n := t.UnixNano()
+	return uint64(n - n%unit)
+}
+
 // Signer returns a Trillian crypto.Signer configured by the tree.
 func Signer(ctx context.Context, tree *trillian.Tree) (*tcrypto.Signer, error) {
 	if tree.SignatureAlgorithm == sigpb.DigitallySigned_ANONYMOUS {
@@ -218,7 +285,9 @@ func Signer(ctx context.Context, tree *trillian.Tree) (*tcrypto.Signer, error) {
 		return nil, fmt.Errorf("%s signature not supported by signer of type %T", tree.SignatureAlgorithm, signer)
 	}
 
-	return tcrypto.NewSigner(tree.GetTreeId(), signer, hash), nil
+	s := tcrypto.NewSigner(tree.GetTreeId(), signer, hash)
+	s.LogRootEncoding = tree.LogRootEncoding
+	return s, nil
 }
 
 func spanFor(ctx context.Context, name string) (context.Context, func()) {
@@ -15,6 +15,7 @@
 package trees
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"crypto/ecdsa"
@@ -24,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/golang/protobuf/proto"
@@ -31,6 +33,7 @@ import (
 	"github.com/google/trillian"
 	"github.com/google/trillian/crypto/keys"
 	"github.com/google/trillian/crypto/sigpb"
+	"github.com/google/trillian/merkle/rfc6962"
 	"github.com/google/trillian/storage"
 	"github.com/google/trillian/storage/testonly"
 	"github.com/kylelemons/godebug/pretty"
@@ -82,6 +85,12 @@ func TestGetTree(t *testing.T) {
 	softDeletedTree.Deleted = true
 	softDeletedTree.DeleteTime = ptypes.TimestampNow()
 
+	deletedFrozenTree := proto.Clone(softDeletedTree).(*trillian.Tree)
+	deletedFrozenTree.TreeState = trillian.TreeState_FROZEN
+
+	frozenMapTree := proto.Clone(mapTree).(*trillian.Tree)
+	frozenMapTree.TreeState = trillian.TreeState_FROZEN
+
 	tests := []struct {
 		desc                           string
 		treeID                         int64
@@ -90,6 +99,7 @@ func TestGetTree(t *testing.T) {
 		beginErr, getErr, commitErr    error
 		wantErr                        bool
 		code                           codes.Code
+		reason                         trillian.TreeUnavailableDetails_Reason
 	}{
 		{
 			desc:        "anyTree",
@@ -213,7 +223,8 @@ func TestGetTree(t *testing.T) {
 			storageTree: frozenTree,
 			wantTree:    frozenTree,
 			wantErr:     true,
-			code:        codes.PermissionDenied,
+			code:        codes.FailedPrecondition,
+			reason:      trillian.TreeUnavailableDetails_TREE_FROZEN,
 		},
 		{
 			desc:        "queueFrozen",
@@ -222,7 +233,17 @@ func TestGetTree(t *testing.T) {
 			storageTree: frozenTree,
 			wantTree:    frozenTree,
 			wantErr:     true,
-			code:        codes.PermissionDenied,
+			code:        codes.FailedPrecondition,
+			reason:      trillian.TreeUnavailableDetails_TREE_FROZEN,
+		},
+		{
+			desc:        "updateFrozenMap",
+			treeID:      frozenMapTree.TreeId,
+			opts:        NewGetOpts(UpdateMap, trillian.TreeType_MAP),
+			storageTree: frozenMapTree,
+			wantErr:     true,
+			code:        codes.FailedPrecondition,
+			reason:      trillian.TreeUnavailableDetails_TREE_FROZEN,
 		},
 		{
 			desc:        "queryDraining",
@@ -246,6 +267,7 @@ func TestGetTree(t *testing.T) {
 			wantTree:    drainingTree,
 			wantErr:     true,
 			code:        codes.PermissionDenied,
+			reason:      trillian.TreeUnavailableDetails_TREE_DRAINING,
 		},
 		{
 			desc:        "softDeleted",
@@ -254,6 +276,25 @@ func TestGetTree(t *testing.T) {
 			storageTree: softDeletedTree,
 			wantErr:     true, // Deleted = true makes the tree "invisible" for most RPCs
 			code:        codes.NotFound,
+			reason:      trillian.TreeUnavailableDetails_TREE_DELETED,
+		},
+		{
+			desc:        "softDeletedFrozen",
+			treeID:      deletedFrozenTree.TreeId,
+			opts:        NewGetOpts(QueueLog, trillian.TreeType_LOG),
+			storageTree: deletedFrozenTree,
+			wantErr:     true,
+			code:        codes.NotFound,
+			reason:      trillian.TreeUnavailableDetails_TREE_DELETED,
+		},
+		{
+			desc:    "notFound",
+			treeID:  logTree.TreeId,
+			opts:    NewGetOpts(QueueLog, trillian.TreeType_LOG),
+			getErr:  status.Errorf(codes.NotFound, "tree %v not found", logTree.TreeId),
+			wantErr: true,
+			code:    codes.NotFound,
+			reason:  trillian.TreeUnavailableDetails_TREE_NOT_FOUND,
 		},
 		{
 			desc:     "treeInCtx",
@@ -319,6 +360,9 @@ func TestGetTree(t *testing.T) {
 			if status.Code(err) != test.code {
 				t.Errorf("%v: GetTree() = (_, %q), got ErrorCode: %v, want: %v", test.desc, err, status.Code(err), test.code)
 			}
+			if got := UnavailableReason(err); got != test.reason {
+				t.Errorf("%v: GetTree() = (_, %q), got reason: %v, want: %v", test.desc, err, got, test.reason)
+			}
 			continue
 		}
 
@@ -357,6 +401,59 @@ func TestHash(t *testing.T) {
 	}
 }
 
+func TestRootTimestamp(t *testing.T) {
+	ts := time.Unix(1577836800, 123456789)
+	tests := []struct {
+		granularity trillian.TimestampGranularity
+		want        uint64
+	}{
+		{granularity: trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_NANOSECOND, want: 1577836800123456789},
+		{granularity: trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_MILLISECOND, want: 1577836800123000000},
+		{granularity: trillian.TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND, want: 1577836800000000000},
+	}
+
+	for _, test := range tests {
+		tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+		tree.TimestampGranularity = test.granularity
+
+		if got := RootTimestamp(tree, ts); got != test.want {
+			t.Errorf("RootTimestamp(%s) = %d, want %d", test.granularity, got, test.want)
+		}
+	}
+}
+
+func TestLogHasher(t *testing.T) {
+	emptyRoot := bytes.Repeat([]byte{1}, 32)
+	tree := proto.Clone(testonly.LogTree).(*trillian.Tree)
+
+	h, err := LogHasher(tree)
+	if err != nil {
+		t.Fatalf("LogHasher() returned err = %v", err)
+	}
+	if got, want := h.EmptyRoot(), rfc6962.DefaultHasher.EmptyRoot(); !bytes.Equal(got, want) {
+		t.Errorf("LogHasher().EmptyRoot() = %x, want %x", got, want)
+	}
+
+	tree.EmptyRootHash = emptyRoot
+	h, err = LogHasher(tree)
+	if err != nil {
+		t.Fatalf("LogHasher() returned err = %v", err)
+	}
+	if got := h.EmptyRoot(); !bytes.Equal(got, emptyRoot) {
+		t.Errorf("LogHasher().EmptyRoot() = %x, want %x", got, emptyRoot)
+	}
+	// Only the empty root is overridden.
+	leaf := []byte("leaf")
+	if got, want := h.HashLeaf(leaf), rfc6962.DefaultHasher.HashLeaf(leaf); !bytes.Equal(got, want) {
+		t.Errorf("LogHasher().HashLeaf() = %x, want %x", got, want)
+	}
+
+	tree.HashStrategy = trillian.HashStrategy_UNKNOWN_HASH_STRATEGY
+	if _, err := LogHasher(tree); err == nil {
+		t.Error("LogHasher() of unknown hash strategy returned err = nil, want non-nil")
+	}
+}
+
 func TestSigner(t *testing.T) {
 	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
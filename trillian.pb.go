@@ -52,6 +52,103 @@ func (LogRootFormat) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_364603a4e17a2a56, []int{0}
 }
 
+// LogRootEncoding specifies how the log roots of a tree are serialized in
+// SignedLogRoot.log_root, and therefore which bytes are signed.
+type LogRootEncoding int32
+
+const (
+	// TLS-style serialization, as described in SignedLogRoot.log_root.
+	LogRootEncoding_TLS LogRootEncoding = 0
+	// Canonical CBOR serialization, as described in SignedLogRoot.log_root.
+	LogRootEncoding_CBOR LogRootEncoding = 1
+)
+
+var LogRootEncoding_name = map[int32]string{
+	0: "TLS",
+	1: "CBOR",
+}
+
+var LogRootEncoding_value = map[string]int32{
+	"TLS":  0,
+	"CBOR": 1,
+}
+
+func (x LogRootEncoding) String() string {
+	return proto.EnumName(LogRootEncoding_name, int32(x))
+}
+
+func (LogRootEncoding) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{1}
+}
+
+// TimestampGranularity specifies the resolution of the timestamps in the log
+// roots signed for a tree.
+type TimestampGranularity int32
+
+const (
+	// Timestamps have nanosecond resolution.
+	TimestampGranularity_TIMESTAMP_GRANULARITY_NANOSECOND TimestampGranularity = 0
+	// Timestamps are truncated to whole milliseconds.
+	TimestampGranularity_TIMESTAMP_GRANULARITY_MILLISECOND TimestampGranularity = 1
+	// Timestamps are truncated to whole seconds.
+	TimestampGranularity_TIMESTAMP_GRANULARITY_SECOND TimestampGranularity = 2
+)
+
+var TimestampGranularity_name = map[int32]string{
+	0: "TIMESTAMP_GRANULARITY_NANOSECOND",
+	1: "TIMESTAMP_GRANULARITY_MILLISECOND",
+	2: "TIMESTAMP_GRANULARITY_SECOND",
+}
+
+var TimestampGranularity_value = map[string]int32{
+	"TIMESTAMP_GRANULARITY_NANOSECOND":  0,
+	"TIMESTAMP_GRANULARITY_MILLISECOND": 1,
+	"TIMESTAMP_GRANULARITY_SECOND":      2,
+}
+
+func (x TimestampGranularity) String() string {
+	return proto.EnumName(TimestampGranularity_name, int32(x))
+}
+
+func (TimestampGranularity) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{2}
+}
+
+// LeafCompression specifies how the leaf_value and extra_data of the leaves of
+// a tree are compressed by storage.
+type LeafCompression int32
+
+const (
+	// Leaf data is stored as is.
+	LeafCompression_LEAF_COMPRESSION_NONE LeafCompression = 0
+	// Leaf data is compressed with gzip, unless that doesn't make it smaller.
+	LeafCompression_LEAF_COMPRESSION_GZIP LeafCompression = 1
+	// Leaf data is compressed with zstd, using the latest of the
+	// leaf_compression_dictionaries of the tree, unless that doesn't make it
+	// smaller.
+	LeafCompression_LEAF_COMPRESSION_ZSTD_DICTIONARY LeafCompression = 2
+)
+
+var LeafCompression_name = map[int32]string{
+	0: "LEAF_COMPRESSION_NONE",
+	1: "LEAF_COMPRESSION_GZIP",
+	2: "LEAF_COMPRESSION_ZSTD_DICTIONARY",
+}
+
+var LeafCompression_value = map[string]int32{
+	"LEAF_COMPRESSION_NONE":            0,
+	"LEAF_COMPRESSION_GZIP":            1,
+	"LEAF_COMPRESSION_ZSTD_DICTIONARY": 2,
+}
+
+func (x LeafCompression) String() string {
+	return proto.EnumName(LeafCompression_name, int32(x))
+}
+
+func (LeafCompression) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{3}
+}
+
 // MapRootFormat specifies the fields that are covered by the
 // SignedMapRoot signature, as well as their ordering and formats.
 type MapRootFormat int32
@@ -76,7 +173,7 @@ func (x MapRootFormat) String() string {
 }
 
 func (MapRootFormat) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_364603a4e17a2a56, []int{1}
+	return fileDescriptor_364603a4e17a2a56, []int{4}
 }
 
 // Defines the way empty / node / leaf hashes are constructed incorporating
@@ -126,7 +223,7 @@ func (x HashStrategy) String() string {
 }
 
 func (HashStrategy) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_364603a4e17a2a56, []int{2}
+	return fileDescriptor_364603a4e17a2a56, []int{5}
 }
 
 // State of the tree.
@@ -175,7 +272,7 @@ func (x TreeState) String() string {
 }
 
 func (TreeState) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_364603a4e17a2a56, []int{3}
+	return fileDescriptor_364603a4e17a2a56, []int{6}
 }
 
 // Type of the tree.
@@ -213,7 +310,79 @@ func (x TreeType) String() string {
 }
 
 func (TreeType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_364603a4e17a2a56, []int{4}
+	return fileDescriptor_364603a4e17a2a56, []int{7}
+}
+
+// Source is the field of a leaf which holds its ordering key.
+type LeafOrderingKey_Source int32
+
+const (
+	LeafOrderingKey_SOURCE_UNKNOWN LeafOrderingKey_Source = 0
+	// The key is taken from the leaf_value.
+	LeafOrderingKey_LEAF_VALUE LeafOrderingKey_Source = 1
+	// The key is taken from the extra_data.
+	LeafOrderingKey_EXTRA_DATA LeafOrderingKey_Source = 2
+)
+
+var LeafOrderingKey_Source_name = map[int32]string{
+	0: "SOURCE_UNKNOWN",
+	1: "LEAF_VALUE",
+	2: "EXTRA_DATA",
+}
+
+var LeafOrderingKey_Source_value = map[string]int32{
+	"SOURCE_UNKNOWN": 0,
+	"LEAF_VALUE":     1,
+	"EXTRA_DATA":     2,
+}
+
+func (x LeafOrderingKey_Source) String() string {
+	return proto.EnumName(LeafOrderingKey_Source_name, int32(x))
+}
+
+func (LeafOrderingKey_Source) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{1, 0}
+}
+
+// Reason why the tree is unavailable.
+type TreeUnavailableDetails_Reason int32
+
+const (
+	TreeUnavailableDetails_REASON_UNSPECIFIED TreeUnavailableDetails_Reason = 0
+	// The tree doesn't exist. Returned with NOT_FOUND.
+	TreeUnavailableDetails_TREE_NOT_FOUND TreeUnavailableDetails_Reason = 1
+	// The tree is soft-deleted. Returned with NOT_FOUND.
+	TreeUnavailableDetails_TREE_DELETED TreeUnavailableDetails_Reason = 2
+	// The tree is FROZEN, so doesn't accept writes. Returned with
+	// FAILED_PRECONDITION.
+	TreeUnavailableDetails_TREE_FROZEN TreeUnavailableDetails_Reason = 3
+	// The tree is DRAINING, so doesn't accept new leaves. Returned with
+	// PERMISSION_DENIED.
+	TreeUnavailableDetails_TREE_DRAINING TreeUnavailableDetails_Reason = 4
+)
+
+var TreeUnavailableDetails_Reason_name = map[int32]string{
+	0: "REASON_UNSPECIFIED",
+	1: "TREE_NOT_FOUND",
+	2: "TREE_DELETED",
+	3: "TREE_FROZEN",
+	4: "TREE_DRAINING",
+}
+
+var TreeUnavailableDetails_Reason_value = map[string]int32{
+	"REASON_UNSPECIFIED": 0,
+	"TREE_NOT_FOUND":     1,
+	"TREE_DELETED":       2,
+	"TREE_FROZEN":        3,
+	"TREE_DRAINING":      4,
+}
+
+func (x TreeUnavailableDetails_Reason) String() string {
+	return proto.EnumName(TreeUnavailableDetails_Reason_name, int32(x))
+}
+
+func (TreeUnavailableDetails_Reason) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{9, 0}
 }
 
 // Represents a tree, which may be either a verifiable log or map.
@@ -225,7 +394,9 @@ func (TreeType) EnumDescriptor() ([]byte, []int) {
 // not created dynamically.
 type Tree struct {
 	// ID of the tree.
-	// Readonly.
+	// May be specified on creation, in which case it must not collide with an
+	// existing tree; if unset a random ID is assigned.
+	// Readonly after Tree creation.
 	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId,proto3" json:"tree_id,omitempty"`
 	// State of the tree.
 	// Trees are ACTIVE after creation. At any point the tree may transition
@@ -281,10 +452,201 @@ type Tree struct {
 	Deleted bool `protobuf:"varint,19,opt,name=deleted,proto3" json:"deleted,omitempty"`
 	// Time of tree deletion, if any.
 	// Readonly.
-	DeleteTime           *timestamp.Timestamp `protobuf:"bytes,20,opt,name=delete_time,json=deleteTime,proto3" json:"delete_time,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
-	XXX_unrecognized     []byte               `json:"-"`
-	XXX_sizecache        int32                `json:"-"`
+	DeleteTime *timestamp.Timestamp `protobuf:"bytes,20,opt,name=delete_time,json=deleteTime,proto3" json:"delete_time,omitempty"`
+	// If true, leaves added to the tree must have non-decreasing
+	// integrate_timestamp values in leaf index order.
+	// Only valid for PREORDERED_LOG trees, which must then supply
+	// integrate_timestamp on all leaves passed to AddSequencedLeaves.
+	// Readonly after Tree creation.
+	OrderedLeafTimestamps bool `protobuf:"varint,21,opt,name=ordered_leaf_timestamps,json=orderedLeafTimestamps,proto3" json:"ordered_leaf_timestamps,omitempty"`
+	// If true, QueueLeaves and AddSequencedLeaves reject leaves with a
+	// leaf_identity_hash which isn't the size of the output of the tree's
+	// hasher. That size check is all it does: on every tree, a supplied
+	// leaf_identity_hash is stored as is and deduplicates leaves, and a missing
+	// one defaults to the Merkle leaf hash, whether or not this is set.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	CallerLeafIdentityHash bool `protobuf:"varint,22,opt,name=caller_leaf_identity_hash,json=callerLeafIdentityHash,proto3" json:"caller_leaf_identity_hash,omitempty"`
+	// If true, the Merkle leaf hash of each leaf commits to its extra_data as
+	// well as its leaf_value, making extra data tamper-evident. The hash is then
+	// computed over the leaf value and extra data, each prefixed with its length
+	// as a 4-byte big-endian integer:
+	//   uint32(len(leaf_value)) || leaf_value || uint32(len(extra_data)) || extra_data
+	// Otherwise, only the leaf value is hashed.
+	// Clients verifying inclusion of leaves must hash them the same way.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	HashExtraData bool `protobuf:"varint,23,opt,name=hash_extra_data,json=hashExtraData,proto3" json:"hash_extra_data,omitempty"`
+	// Namespace (i.e. tenant) that owns the tree.
+	// Servers that enforce namespaces only expose the tree to callers claiming
+	// the same namespace; other callers get NOT_FOUND, as if the tree didn't
+	// exist. Trees created through such servers are assigned the namespace of
+	// the caller, and a random tree_id: callers claiming a namespace can't
+	// choose tree_id, as collisions would reveal the IDs of other namespaces.
+	// Empty means the tree has no namespace.
+	// Readonly after Tree creation.
+	Namespace string `protobuf:"bytes,24,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Serialization of the log roots signed for the tree.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	LogRootEncoding LogRootEncoding `protobuf:"varint,25,opt,name=log_root_encoding,json=logRootEncoding,proto3,enum=trillian.LogRootEncoding" json:"log_root_encoding,omitempty"`
+	// Resolution of the timestamp_nanos of the log roots signed for the tree,
+	// e.g. for verifiers which expect whole seconds. Roots are still signed
+	// with strictly increasing timestamps, so with a coarse granularity at most
+	// one root is signed per unit of time.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	TimestampGranularity TimestampGranularity `protobuf:"varint,26,opt,name=timestamp_granularity,json=timestampGranularity,proto3,enum=trillian.TimestampGranularity" json:"timestamp_granularity,omitempty"`
+	// If true, clients submit the merkle_leaf_hash of each leaf instead of its
+	// leaf_value, which must be empty, so that leaf contents never reach the
+	// log. The supplied hashes are stored as is, and used for deduplication
+	// unless a leaf_identity_hash is supplied too. Leaves returned by the log,
+	// e.g. by GetEntryAndProof, have no leaf_value.
+	// Cannot be combined with hash_extra_data, as the log hashes nothing.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	HashOnly bool `protobuf:"varint,27,opt,name=hash_only,json=hashOnly,proto3" json:"hash_only,omitempty"`
+	// Compression of the leaf_value and extra_data of leaves in storage. It is
+	// transparent to clients: leaves are hashed and returned uncompressed.
+	// Only honored by the MySQL and Postgres storage.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	LeafCompression LeafCompression `protobuf:"varint,28,opt,name=leaf_compression,json=leafCompression,proto3,enum=trillian.LeafCompression" json:"leaf_compression,omitempty"`
+	// If non-zero, the maximum number of leaves of the tree. Once the tree has
+	// that many leaves, QueueLeaf and QueueLeaves fail with FAILED_PRECONDITION
+	// and the tree accepts no more leaves, while the leaves it has can still be
+	// read and proven, e.g. so that applications can rotate to a new tree.
+	// AddSequencedLeaves rejects leaf indices past the maximum the same way.
+	// The maximum is enforced when sequencing, so leaves queued concurrently
+	// with the tree filling up are never integrated.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	MaxTreeSize int64 `protobuf:"varint,29,opt,name=max_tree_size,json=maxTreeSize,proto3" json:"max_tree_size,omitempty"`
+	// If true, log servers configured with a write-ahead log (WAL) accept leaves
+	// into it when QueueLeaf and QueueLeaves fail because storage is
+	// unavailable, acknowledge them, and queue them in storage once it recovers.
+	// This weakens durability: acknowledged leaves are only as durable as the
+	// local disk of the server which accepted them until they are drained, and
+	// are lost if that server never comes back. Leaves accepted into the WAL are
+	// reported as new even if they duplicate queued leaves; duplicates are
+	// detected when they are drained, and only integrated once. Leaves of a tree
+	// are drained in the order they were accepted by each server, but not
+	// across servers. Conditional appends are never accepted into the WAL.
+	// Only valid for LOG trees.
+	// Readonly after Tree creation.
+	QueueWriteAhead bool `protobuf:"varint,30,opt,name=queue_write_ahead,json=queueWriteAhead,proto3" json:"queue_write_ahead,omitempty"`
+	// If set, the leaf_value and extra_data of leaves are encrypted by log
+	// servers before they are written to storage and decrypted when read, with a
+	// data key specific to the tree. Leaves are hashed before encryption, so
+	// proofs are unaffected. Set it to an empty message on CreateTree to opt in;
+	// the data key is generated by the server. See RewrapLeafDataKey for key
+	// rotation.
+	// Can't be combined with leaf_compression or queue_write_ahead.
+	// Only honored by the MySQL, Postgres and in-memory storage.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation, apart from the wrapped data key.
+	LeafEncryption *LeafEncryption `protobuf:"bytes,31,opt,name=leaf_encryption,json=leafEncryption,proto3" json:"leaf_encryption,omitempty"`
+	// If true, the signer sorts each batch of leaves it dequeues by
+	// queue_timestamp, breaking ties by leaf_identity_hash, before assigning
+	// their indices. Otherwise leaves are sequenced in the order storage
+	// dequeues them, which isn't deterministic relative to their queue
+	// timestamps under concurrent queueing.
+	// Leaves are only sorted within a batch: a leaf dequeued after an earlier
+	// batch was integrated, e.g. because it was still inside the guard window,
+	// comes after that batch even if it was queued before some of its leaves.
+	// Sorting costs O(n log n) comparisons per batch of n leaves in the
+	// sequencing transaction, which is small next to the storage writes.
+	// Only valid for LOG trees.
+	// Readonly after Tree creation.
+	SortByQueueTimestamp bool `protobuf:"varint,32,opt,name=sort_by_queue_timestamp,json=sortByQueueTimestamp,proto3" json:"sort_by_queue_timestamp,omitempty"`
+	// If set, leaves are also indexed by an ordering key extracted from each
+	// leaf when it is added with AddSequencedLeaves, so that GetLeavesByKeyRange
+	// can scan them in key order, e.g. to migrate datasets keyed by certificate
+	// serial number. The Merkle tree stays ordered by leaf index. Leaves whose
+	// key can't be extracted are rejected.
+	// The index costs one extra row per leaf in storage, holding the key, the
+	// tree ID and the leaf index, i.e. about 16 bytes plus the key length before
+	// storage engine overhead, and one extra insert per leaf in
+	// AddSequencedLeaves. The key is stored unencrypted, so this can't be
+	// combined with leaf_encryption.
+	// Only honored by the MySQL storage.
+	// Only valid for PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	LeafOrderingKey *LeafOrderingKey `protobuf:"bytes,33,opt,name=leaf_ordering_key,json=leafOrderingKey,proto3" json:"leaf_ordering_key,omitempty"`
+	// If true, leaves whose leaf_value is a tombstone, i.e. the 19 bytes
+	// "trillian:tombstone:" followed by the 8-byte big-endian index of an earlier
+	// leaf (see package types), mark that leaf as deleted. Tombstones are
+	// ordinary leaves, so the Merkle tree stays append-only and verifiable;
+	// storage indexes them so that GetEffectiveLeaves can skip both the
+	// tombstones and the leaves they delete. A tombstone for a leaf at or after
+	// its own index has no effect, and tombstones can't be undone. Leaf values
+	// starting with the tombstone prefix which aren't valid tombstones are
+	// rejected.
+	// The index costs one extra row per tombstone in storage, holding the
+	// tree ID, the tombstone's leaf identity hash and the deleted leaf index.
+	// Can't be combined with hash_only or leaf_encryption, as the server must
+	// read the leaf values.
+	// Only honored by the MySQL storage.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	LeafTombstones bool `protobuf:"varint,34,opt,name=leaf_tombstones,json=leafTombstones,proto3" json:"leaf_tombstones,omitempty"`
+	// If set, the root hash of the tree when it has no leaves, overriding the
+	// empty root of the hash strategy (for RFC6962_SHA256, the SHA-256 hash of
+	// the empty string), e.g. for verifiers which define it differently. It's
+	// used for the size-0 roots signed by InitLog and the log signer, and by
+	// clients verifying them. It must be as long as the output of the hasher.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	// Readonly after Tree creation.
+	EmptyRootHash []byte `protobuf:"bytes,35,opt,name=empty_root_hash,json=emptyRootHash,proto3" json:"empty_root_hash,omitempty"`
+	// The dictionaries which leaf data is compressed with if leaf_compression
+	// is LEAF_COMPRESSION_ZSTD_DICTIONARY, in increasing order of version.
+	// New leaf data is compressed with the last one; stored leaf data records
+	// the version it was compressed with, so the earlier ones are kept to read
+	// it. Versions start at 1 and increase by one.
+	// Only valid for trees with LEAF_COMPRESSION_ZSTD_DICTIONARY.
+	// Dictionaries can only be appended after Tree creation.
+	LeafCompressionDictionaries []*LeafCompressionDictionary `protobuf:"bytes,36,rep,name=leaf_compression_dictionaries,json=leafCompressionDictionaries,proto3" json:"leaf_compression_dictionaries,omitempty"`
+	// The index of the first leaf of the log, e.g. to continue the numbering of
+	// a predecessor log after rotation. Leaves are sequenced and stored with
+	// 0-based indices, so the Merkle tree is unaffected; the log server adds the
+	// offset to the leaf indices of the leaves and proofs it returns, and
+	// subtracts it from those of requests, rejecting indices below it. Tree
+	// sizes still count leaves, so the last leaf of a tree of size n has index
+	// leaf_index_offset + n - 1, and verifiers must subtract the offset from
+	// the leaf_index of inclusion proofs. CreateTree rejects logs whose index range, i.e.
+	// [leaf_index_offset, leaf_index_offset + max_tree_size), unbounded if
+	// max_tree_size is zero, overlaps that of another log if either of them
+	// has a leaf_index_offset. Logs numbered from 0 without a max_tree_size
+	// have no range.
+	// Can't be combined with leaf_tombstones, whose values hold 0-based indices.
+	// Only valid for LOG trees.
+	// Readonly after Tree creation.
+	LeafIndexOffset int64 `protobuf:"varint,37,opt,name=leaf_index_offset,json=leafIndexOffset,proto3" json:"leaf_index_offset,omitempty"`
+	// The minimum time between the signed roots of the log. If set, the
+	// sequencer integrates leaves as often as usual, but only signs a root
+	// covering them once signing_interval has passed since the previous signed
+	// root, or when requested by TrillianLogSequencer.SignLogRoot, so clients
+	// and witnesses see fewer roots, each covering several batches. In between,
+	// the state of the tree is kept in storage without being published:
+	// GetLatestSignedLogRoot, proofs and leaf reads are all bounded by the
+	// latest signed root. max_root_duration still applies. Requires storage
+	// which can keep unpublished roots (MySQL or memory).
+	// Only valid for LOG and PREORDERED_LOG trees.
+	SigningInterval *duration.Duration `protobuf:"bytes,38,opt,name=signing_interval,json=signingInterval,proto3" json:"signing_interval,omitempty"`
+	// Bounds the time leaves spend in the queue before being published. Leaves
+	// queued for longer than max_queue_age are integrated by the next pass of
+	// the sequencer even if they are within its guard window, and in as many
+	// batches as needed rather than one. If the log has a signing_interval, the
+	// integrated state is also signed before the interval has passed when a
+	// leaf would otherwise be published later than max_queue_age after it was
+	// queued. So, as long as the log has a master signer, a leaf is published
+	// at most max_queue_age and the sequencer pass interval, plus the duration
+	// of the pass, after it was queued.
+	// Only valid for LOG and PREORDERED_LOG trees.
+	MaxQueueAge          *duration.Duration `protobuf:"bytes,39,opt,name=max_queue_age,json=maxQueueAge,proto3" json:"max_queue_age,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
 }
 
 func (m *Tree) Reset()         { *m = Tree{} }
@@ -424,6 +786,301 @@ func (m *Tree) GetDeleteTime() *timestamp.Timestamp {
 	return nil
 }
 
+func (m *Tree) GetOrderedLeafTimestamps() bool {
+	if m != nil {
+		return m.OrderedLeafTimestamps
+	}
+	return false
+}
+
+func (m *Tree) GetCallerLeafIdentityHash() bool {
+	if m != nil {
+		return m.CallerLeafIdentityHash
+	}
+	return false
+}
+
+func (m *Tree) GetHashExtraData() bool {
+	if m != nil {
+		return m.HashExtraData
+	}
+	return false
+}
+
+func (m *Tree) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *Tree) GetLogRootEncoding() LogRootEncoding {
+	if m != nil {
+		return m.LogRootEncoding
+	}
+	return LogRootEncoding_TLS
+}
+
+func (m *Tree) GetTimestampGranularity() TimestampGranularity {
+	if m != nil {
+		return m.TimestampGranularity
+	}
+	return TimestampGranularity_TIMESTAMP_GRANULARITY_NANOSECOND
+}
+
+func (m *Tree) GetHashOnly() bool {
+	if m != nil {
+		return m.HashOnly
+	}
+	return false
+}
+
+func (m *Tree) GetLeafCompression() LeafCompression {
+	if m != nil {
+		return m.LeafCompression
+	}
+	return LeafCompression_LEAF_COMPRESSION_NONE
+}
+
+func (m *Tree) GetMaxTreeSize() int64 {
+	if m != nil {
+		return m.MaxTreeSize
+	}
+	return 0
+}
+
+func (m *Tree) GetQueueWriteAhead() bool {
+	if m != nil {
+		return m.QueueWriteAhead
+	}
+	return false
+}
+
+func (m *Tree) GetLeafEncryption() *LeafEncryption {
+	if m != nil {
+		return m.LeafEncryption
+	}
+	return nil
+}
+
+func (m *Tree) GetSortByQueueTimestamp() bool {
+	if m != nil {
+		return m.SortByQueueTimestamp
+	}
+	return false
+}
+
+func (m *Tree) GetLeafOrderingKey() *LeafOrderingKey {
+	if m != nil {
+		return m.LeafOrderingKey
+	}
+	return nil
+}
+
+func (m *Tree) GetLeafTombstones() bool {
+	if m != nil {
+		return m.LeafTombstones
+	}
+	return false
+}
+
+func (m *Tree) GetEmptyRootHash() []byte {
+	if m != nil {
+		return m.EmptyRootHash
+	}
+	return nil
+}
+
+func (m *Tree) GetLeafCompressionDictionaries() []*LeafCompressionDictionary {
+	if m != nil {
+		return m.LeafCompressionDictionaries
+	}
+	return nil
+}
+
+func (m *Tree) GetLeafIndexOffset() int64 {
+	if m != nil {
+		return m.LeafIndexOffset
+	}
+	return 0
+}
+
+func (m *Tree) GetSigningInterval() *duration.Duration {
+	if m != nil {
+		return m.SigningInterval
+	}
+	return nil
+}
+
+func (m *Tree) GetMaxQueueAge() *duration.Duration {
+	if m != nil {
+		return m.MaxQueueAge
+	}
+	return nil
+}
+
+// LeafOrderingKey specifies how the ordering key of a leaf is extracted from
+// it: the key is the bytes [offset, offset+length) of the source field of the
+// leaf, or all bytes from offset if length is zero. Keys are compared
+// bytewise and must be between 1 and 255 bytes long.
+type LeafOrderingKey struct {
+	Source               LeafOrderingKey_Source `protobuf:"varint,1,opt,name=source,proto3,enum=trillian.LeafOrderingKey_Source" json:"source,omitempty"`
+	Offset               int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Length               int32                  `protobuf:"varint,3,opt,name=length,proto3" json:"length,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *LeafOrderingKey) Reset()         { *m = LeafOrderingKey{} }
+func (m *LeafOrderingKey) String() string { return proto.CompactTextString(m) }
+func (*LeafOrderingKey) ProtoMessage()    {}
+func (*LeafOrderingKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{1}
+}
+
+func (m *LeafOrderingKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LeafOrderingKey.Unmarshal(m, b)
+}
+func (m *LeafOrderingKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LeafOrderingKey.Marshal(b, m, deterministic)
+}
+func (m *LeafOrderingKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeafOrderingKey.Merge(m, src)
+}
+func (m *LeafOrderingKey) XXX_Size() int {
+	return xxx_messageInfo_LeafOrderingKey.Size(m)
+}
+func (m *LeafOrderingKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeafOrderingKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeafOrderingKey proto.InternalMessageInfo
+
+func (m *LeafOrderingKey) GetSource() LeafOrderingKey_Source {
+	if m != nil {
+		return m.Source
+	}
+	return LeafOrderingKey_SOURCE_UNKNOWN
+}
+
+func (m *LeafOrderingKey) GetOffset() int32 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *LeafOrderingKey) GetLength() int32 {
+	if m != nil {
+		return m.Length
+	}
+	return 0
+}
+
+// LeafEncryption holds the data key used to encrypt the leaves of a tree at
+// rest, wrapped by a key encryption key (KEK), e.g. held in a KMS.
+type LeafEncryption struct {
+	// The data key, encrypted with the KEK.
+	WrappedDataKey []byte `protobuf:"bytes,1,opt,name=wrapped_data_key,json=wrappedDataKey,proto3" json:"wrapped_data_key,omitempty"`
+	// The ID of the KEK which wrapped_data_key is encrypted with.
+	KekId                string   `protobuf:"bytes,2,opt,name=kek_id,json=kekId,proto3" json:"kek_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LeafEncryption) Reset()         { *m = LeafEncryption{} }
+func (m *LeafEncryption) String() string { return proto.CompactTextString(m) }
+func (*LeafEncryption) ProtoMessage()    {}
+func (*LeafEncryption) Descriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{2}
+}
+
+func (m *LeafEncryption) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LeafEncryption.Unmarshal(m, b)
+}
+func (m *LeafEncryption) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LeafEncryption.Marshal(b, m, deterministic)
+}
+func (m *LeafEncryption) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeafEncryption.Merge(m, src)
+}
+func (m *LeafEncryption) XXX_Size() int {
+	return xxx_messageInfo_LeafEncryption.Size(m)
+}
+func (m *LeafEncryption) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeafEncryption.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeafEncryption proto.InternalMessageInfo
+
+func (m *LeafEncryption) GetWrappedDataKey() []byte {
+	if m != nil {
+		return m.WrappedDataKey
+	}
+	return nil
+}
+
+func (m *LeafEncryption) GetKekId() string {
+	if m != nil {
+		return m.KekId
+	}
+	return ""
+}
+
+// LeafCompressionDictionary is a preset dictionary for the compression of the
+// leaf data of a tree, e.g. made of content typical of its leaves, such as
+// common prefixes.
+type LeafCompressionDictionary struct {
+	// The version of the dictionary within the tree.
+	Version int32 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	// The zstd dictionary, as written by zstd --train, of at most 1MiB.
+	Dictionary           []byte   `protobuf:"bytes,2,opt,name=dictionary,proto3" json:"dictionary,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *LeafCompressionDictionary) Reset()         { *m = LeafCompressionDictionary{} }
+func (m *LeafCompressionDictionary) String() string { return proto.CompactTextString(m) }
+func (*LeafCompressionDictionary) ProtoMessage()    {}
+func (*LeafCompressionDictionary) Descriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{3}
+}
+
+func (m *LeafCompressionDictionary) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_LeafCompressionDictionary.Unmarshal(m, b)
+}
+func (m *LeafCompressionDictionary) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_LeafCompressionDictionary.Marshal(b, m, deterministic)
+}
+func (m *LeafCompressionDictionary) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_LeafCompressionDictionary.Merge(m, src)
+}
+func (m *LeafCompressionDictionary) XXX_Size() int {
+	return xxx_messageInfo_LeafCompressionDictionary.Size(m)
+}
+func (m *LeafCompressionDictionary) XXX_DiscardUnknown() {
+	xxx_messageInfo_LeafCompressionDictionary.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_LeafCompressionDictionary proto.InternalMessageInfo
+
+func (m *LeafCompressionDictionary) GetVersion() int32 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *LeafCompressionDictionary) GetDictionary() []byte {
+	if m != nil {
+		return m.Dictionary
+	}
+	return nil
+}
+
 type SignedEntryTimestamp struct {
 	TimestampNanos       int64                  `protobuf:"varint,1,opt,name=timestamp_nanos,json=timestampNanos,proto3" json:"timestamp_nanos,omitempty"`
 	LogId                int64                  `protobuf:"varint,2,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
@@ -437,7 +1094,7 @@ func (m *SignedEntryTimestamp) Reset()         { *m = SignedEntryTimestamp{} }
 func (m *SignedEntryTimestamp) String() string { return proto.CompactTextString(m) }
 func (*SignedEntryTimestamp) ProtoMessage()    {}
 func (*SignedEntryTimestamp) Descriptor() ([]byte, []int) {
-	return fileDescriptor_364603a4e17a2a56, []int{1}
+	return fileDescriptor_364603a4e17a2a56, []int{4}
 }
 
 func (m *SignedEntryTimestamp) XXX_Unmarshal(b []byte) error {
@@ -523,6 +1180,23 @@ type SignedLogRoot struct {
 	// +---+---+---+---+---+-....---+
 	//
 	// (with all integers encoded big-endian).
+	//
+	// For trees with the CBOR log_root_encoding, log_root instead holds the
+	// canonical CBOR (RFC 8949) encoding of a map with the following unsigned
+	// integer keys and values:
+	//   0: version (unsigned integer, 1)
+	//   1: tree_size (unsigned integer)
+	//   2: root_hash (byte string of at most 128 bytes)
+	//   3: timestamp_nanos (unsigned integer)
+	//   4: revision (unsigned integer)
+	//   5: metadata (byte string of at most 65535 bytes)
+	// The encoding is canonical in that:
+	//  - all six entries are present, even if zero or empty, and no others;
+	//  - entries are in ascending order of key;
+	//  - integers and lengths use the shortest possible encoding;
+	//  - lengths are definite, and there are no tags.
+	// Its first byte is therefore always 0xa6 (a map of six entries), whereas
+	// the first byte of the TLS serialization is always 0x00.
 	LogRoot []byte `protobuf:"bytes,8,opt,name=log_root,json=logRoot,proto3" json:"log_root,omitempty"`
 	// log_root_signature is the raw signature over log_root.
 	LogRootSignature     []byte   `protobuf:"bytes,9,opt,name=log_root_signature,json=logRootSignature,proto3" json:"log_root_signature,omitempty"`
@@ -535,7 +1209,7 @@ func (m *SignedLogRoot) Reset()         { *m = SignedLogRoot{} }
 func (m *SignedLogRoot) String() string { return proto.CompactTextString(m) }
 func (*SignedLogRoot) ProtoMessage()    {}
 func (*SignedLogRoot) Descriptor() ([]byte, []int) {
-	return fileDescriptor_364603a4e17a2a56, []int{2}
+	return fileDescriptor_364603a4e17a2a56, []int{5}
 }
 
 func (m *SignedLogRoot) XXX_Unmarshal(b []byte) error {
@@ -607,7 +1281,7 @@ func (m *SignedMapRoot) Reset()         { *m = SignedMapRoot{} }
 func (m *SignedMapRoot) String() string { return proto.CompactTextString(m) }
 func (*SignedMapRoot) ProtoMessage()    {}
 func (*SignedMapRoot) Descriptor() ([]byte, []int) {
-	return fileDescriptor_364603a4e17a2a56, []int{3}
+	return fileDescriptor_364603a4e17a2a56, []int{6}
 }
 
 func (m *SignedMapRoot) XXX_Unmarshal(b []byte) error {
@@ -659,7 +1333,7 @@ func (m *Proof) Reset()         { *m = Proof{} }
 func (m *Proof) String() string { return proto.CompactTextString(m) }
 func (*Proof) ProtoMessage()    {}
 func (*Proof) Descriptor() ([]byte, []int) {
-	return fileDescriptor_364603a4e17a2a56, []int{4}
+	return fileDescriptor_364603a4e17a2a56, []int{7}
 }
 
 func (m *Proof) XXX_Unmarshal(b []byte) error {
@@ -694,90 +1368,270 @@ func (m *Proof) GetHashes() [][]byte {
 	return nil
 }
 
+// QuotaExhaustedDetails is attached to the status of RESOURCE_EXHAUSTED
+// errors returned when a request is denied due to insufficient quota.
+type QuotaExhaustedDetails struct {
+	// Name of the exhausted quota spec, e.g. "trees/10/write".
+	Spec string `protobuf:"bytes,1,opt,name=spec,proto3" json:"spec,omitempty"`
+	// Number of tokens available in the spec when the request was denied.
+	TokensAvailable int64 `protobuf:"varint,2,opt,name=tokens_available,json=tokensAvailable,proto3" json:"tokens_available,omitempty"`
+	// Estimated time until enough tokens are available for the request to
+	// succeed. Unset if the quota implementation can't provide an estimate.
+	RefillDelay          *duration.Duration `protobuf:"bytes,3,opt,name=refill_delay,json=refillDelay,proto3" json:"refill_delay,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *QuotaExhaustedDetails) Reset()         { *m = QuotaExhaustedDetails{} }
+func (m *QuotaExhaustedDetails) String() string { return proto.CompactTextString(m) }
+func (*QuotaExhaustedDetails) ProtoMessage()    {}
+func (*QuotaExhaustedDetails) Descriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{8}
+}
+
+func (m *QuotaExhaustedDetails) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QuotaExhaustedDetails.Unmarshal(m, b)
+}
+func (m *QuotaExhaustedDetails) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QuotaExhaustedDetails.Marshal(b, m, deterministic)
+}
+func (m *QuotaExhaustedDetails) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuotaExhaustedDetails.Merge(m, src)
+}
+func (m *QuotaExhaustedDetails) XXX_Size() int {
+	return xxx_messageInfo_QuotaExhaustedDetails.Size(m)
+}
+func (m *QuotaExhaustedDetails) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuotaExhaustedDetails.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuotaExhaustedDetails proto.InternalMessageInfo
+
+func (m *QuotaExhaustedDetails) GetSpec() string {
+	if m != nil {
+		return m.Spec
+	}
+	return ""
+}
+
+func (m *QuotaExhaustedDetails) GetTokensAvailable() int64 {
+	if m != nil {
+		return m.TokensAvailable
+	}
+	return 0
+}
+
+func (m *QuotaExhaustedDetails) GetRefillDelay() *duration.Duration {
+	if m != nil {
+		return m.RefillDelay
+	}
+	return nil
+}
+
+// TreeUnavailableDetails is attached to the status of errors returned when a
+// request is rejected because its tree doesn't exist, or its state doesn't
+// allow the request.
+type TreeUnavailableDetails struct {
+	// ID of the requested tree.
+	TreeId               int64                         `protobuf:"varint,1,opt,name=tree_id,json=treeId,proto3" json:"tree_id,omitempty"`
+	Reason               TreeUnavailableDetails_Reason `protobuf:"varint,2,opt,name=reason,proto3,enum=trillian.TreeUnavailableDetails_Reason" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                      `json:"-"`
+	XXX_unrecognized     []byte                        `json:"-"`
+	XXX_sizecache        int32                         `json:"-"`
+}
+
+func (m *TreeUnavailableDetails) Reset()         { *m = TreeUnavailableDetails{} }
+func (m *TreeUnavailableDetails) String() string { return proto.CompactTextString(m) }
+func (*TreeUnavailableDetails) ProtoMessage()    {}
+func (*TreeUnavailableDetails) Descriptor() ([]byte, []int) {
+	return fileDescriptor_364603a4e17a2a56, []int{9}
+}
+
+func (m *TreeUnavailableDetails) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TreeUnavailableDetails.Unmarshal(m, b)
+}
+func (m *TreeUnavailableDetails) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TreeUnavailableDetails.Marshal(b, m, deterministic)
+}
+func (m *TreeUnavailableDetails) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TreeUnavailableDetails.Merge(m, src)
+}
+func (m *TreeUnavailableDetails) XXX_Size() int {
+	return xxx_messageInfo_TreeUnavailableDetails.Size(m)
+}
+func (m *TreeUnavailableDetails) XXX_DiscardUnknown() {
+	xxx_messageInfo_TreeUnavailableDetails.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TreeUnavailableDetails proto.InternalMessageInfo
+
+func (m *TreeUnavailableDetails) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *TreeUnavailableDetails) GetReason() TreeUnavailableDetails_Reason {
+	if m != nil {
+		return m.Reason
+	}
+	return TreeUnavailableDetails_REASON_UNSPECIFIED
+}
+
 func init() {
 	proto.RegisterEnum("trillian.LogRootFormat", LogRootFormat_name, LogRootFormat_value)
+	proto.RegisterEnum("trillian.LogRootEncoding", LogRootEncoding_name, LogRootEncoding_value)
+	proto.RegisterEnum("trillian.TimestampGranularity", TimestampGranularity_name, TimestampGranularity_value)
+	proto.RegisterEnum("trillian.LeafCompression", LeafCompression_name, LeafCompression_value)
 	proto.RegisterEnum("trillian.MapRootFormat", MapRootFormat_name, MapRootFormat_value)
 	proto.RegisterEnum("trillian.HashStrategy", HashStrategy_name, HashStrategy_value)
 	proto.RegisterEnum("trillian.TreeState", TreeState_name, TreeState_value)
 	proto.RegisterEnum("trillian.TreeType", TreeType_name, TreeType_value)
+	proto.RegisterEnum("trillian.LeafOrderingKey_Source", LeafOrderingKey_Source_name, LeafOrderingKey_Source_value)
+	proto.RegisterEnum("trillian.TreeUnavailableDetails_Reason", TreeUnavailableDetails_Reason_name, TreeUnavailableDetails_Reason_value)
 	proto.RegisterType((*Tree)(nil), "trillian.Tree")
+	proto.RegisterType((*LeafOrderingKey)(nil), "trillian.LeafOrderingKey")
+	proto.RegisterType((*LeafEncryption)(nil), "trillian.LeafEncryption")
+	proto.RegisterType((*LeafCompressionDictionary)(nil), "trillian.LeafCompressionDictionary")
 	proto.RegisterType((*SignedEntryTimestamp)(nil), "trillian.SignedEntryTimestamp")
 	proto.RegisterType((*SignedLogRoot)(nil), "trillian.SignedLogRoot")
 	proto.RegisterType((*SignedMapRoot)(nil), "trillian.SignedMapRoot")
 	proto.RegisterType((*Proof)(nil), "trillian.Proof")
+	proto.RegisterType((*QuotaExhaustedDetails)(nil), "trillian.QuotaExhaustedDetails")
+	proto.RegisterType((*TreeUnavailableDetails)(nil), "trillian.TreeUnavailableDetails")
 }
 
 func init() { proto.RegisterFile("trillian.proto", fileDescriptor_364603a4e17a2a56) }
 
 var fileDescriptor_364603a4e17a2a56 = []byte{
-	// 1095 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x56, 0xdd, 0x6e, 0xdb, 0x36,
-	0x14, 0xae, 0x6c, 0xd9, 0x96, 0x8f, 0x9d, 0x84, 0x61, 0xda, 0x54, 0xf1, 0x7e, 0xea, 0x05, 0x03,
-	0xe6, 0x15, 0x83, 0xb3, 0x7a, 0x6b, 0x81, 0xa1, 0x17, 0x83, 0x1a, 0x2b, 0xb1, 0x9d, 0xc4, 0x36,
-	0x68, 0xad, 0x43, 0x7b, 0x43, 0x28, 0x31, 0x2b, 0x0b, 0x91, 0x25, 0x41, 0x62, 0x86, 0xea, 0x11,
-	0x86, 0xed, 0xbe, 0xaf, 0x3b, 0x90, 0xa2, 0xe4, 0x34, 0x69, 0xd7, 0x9b, 0x84, 0xe7, 0xfb, 0x3b,
-	0xa4, 0x48, 0x4a, 0x86, 0x6d, 0x9e, 0xf8, 0x41, 0xe0, 0xbb, 0x61, 0x3f, 0x4e, 0x22, 0x1e, 0x61,
-	0xa3, 0xa8, 0x3b, 0x9d, 0xab, 0x24, 0x8b, 0x79, 0x74, 0x74, 0xcd, 0xb2, 0x34, 0xbe, 0x54, 0xff,
-	0x72, 0x55, 0xc7, 0x54, 0x5c, 0xea, 0x7b, 0xf1, 0x65, 0xfe, 0x57, 0x31, 0x07, 0x5e, 0x14, 0x79,
-	0x01, 0x3b, 0x92, 0xd5, 0xe5, 0xcd, 0xbb, 0x23, 0x37, 0xcc, 0x14, 0xf5, 0xed, 0x5d, 0x6a, 0x79,
-	0x93, 0xb8, 0xdc, 0x8f, 0x54, 0xeb, 0xce, 0x93, 0xbb, 0x3c, 0xf7, 0xd7, 0x2c, 0xe5, 0xee, 0x3a,
-	0xce, 0x05, 0x87, 0x7f, 0x37, 0x40, 0x77, 0x12, 0xc6, 0xf0, 0x63, 0x68, 0xf0, 0x84, 0x31, 0xea,
-	0x2f, 0x4d, 0xad, 0xab, 0xf5, 0xaa, 0xa4, 0x2e, 0xca, 0xf1, 0x12, 0x0f, 0x00, 0x24, 0x91, 0x72,
-	0x97, 0x33, 0xb3, 0xd2, 0xd5, 0x7a, 0xdb, 0x83, 0xbd, 0x7e, 0xb9, 0x44, 0x61, 0x5e, 0x08, 0x8a,
-	0x34, 0x79, 0x31, 0xc4, 0x47, 0x20, 0x0b, 0xca, 0xb3, 0x98, 0x99, 0x55, 0x69, 0xc1, 0x1f, 0x5b,
-	0x9c, 0x2c, 0x66, 0xc4, 0xe0, 0x6a, 0x84, 0x5f, 0xc2, 0xd6, 0xca, 0x4d, 0x57, 0x34, 0xe5, 0x89,
-	0xcb, 0x99, 0x97, 0x99, 0xba, 0x34, 0xed, 0x6f, 0x4c, 0x23, 0x37, 0x5d, 0x2d, 0x14, 0x4b, 0xda,
-	0xab, 0x5b, 0x15, 0x3e, 0x83, 0x6d, 0x69, 0x76, 0x03, 0x2f, 0x4a, 0x7c, 0xbe, 0x5a, 0x9b, 0x35,
-	0xe9, 0xfe, 0xbe, 0x9f, 0x3f, 0xc5, 0xa1, 0xef, 0xf9, 0xdc, 0x0d, 0x82, 0x6c, 0xe1, 0x7b, 0x21,
-	0x5b, 0xca, 0x28, 0xab, 0xd0, 0x12, 0xd9, 0xb8, 0x2c, 0xf1, 0x5b, 0xd8, 0x4b, 0x7d, 0x2f, 0x74,
-	0xf9, 0x4d, 0xc2, 0x6e, 0x25, 0xd6, 0x65, 0xe2, 0x8f, 0x9f, 0x49, 0x5c, 0x14, 0x8e, 0x4d, 0x2c,
-	0x4e, 0xef, 0x61, 0xf8, 0x3b, 0x68, 0x2f, 0xfd, 0x34, 0x0e, 0xdc, 0x8c, 0x86, 0xee, 0x9a, 0x99,
-	0x46, 0x57, 0xeb, 0x35, 0x49, 0x4b, 0x61, 0x53, 0x77, 0xcd, 0x70, 0x17, 0x5a, 0x4b, 0x96, 0x5e,
-	0x25, 0x7e, 0x2c, 0x76, 0xd1, 0x6c, 0x2a, 0xc5, 0x06, 0xc2, 0xcf, 0xa1, 0x15, 0x27, 0xfe, 0x5f,
-	0x2e, 0x67, 0xf4, 0x9a, 0x65, 0x66, 0xbb, 0xab, 0xf5, 0x5a, 0x83, 0x87, 0xfd, 0x7c, 0xa3, 0xfb,
-	0xc5, 0x46, 0xf7, 0xad, 0x30, 0x23, 0xa0, 0x84, 0x67, 0x2c, 0xc3, 0xbf, 0x03, 0x4a, 0x79, 0x94,
-	0xb8, 0x1e, 0xa3, 0x29, 0xe3, 0xdc, 0x0f, 0xbd, 0xd4, 0xdc, 0xfa, 0x1f, 0xef, 0x8e, 0x52, 0x2f,
-	0x94, 0x18, 0xff, 0x0c, 0x10, 0xdf, 0x5c, 0x06, 0xfe, 0x95, 0x6c, 0xbb, 0x2d, 0xad, 0xbb, 0x7d,
-	0x75, 0x84, 0xe7, 0x92, 0x39, 0x63, 0x19, 0x69, 0xc6, 0xc5, 0x10, 0xdb, 0xb0, 0xbb, 0x76, 0xdf,
-	0xd3, 0x24, 0x8a, 0x38, 0x2d, 0xce, 0xa5, 0xb9, 0x23, 0x8d, 0x07, 0xf7, 0x7a, 0x0e, 0x95, 0x80,
-	0xec, 0xac, 0xdd, 0xf7, 0x24, 0x8a, 0x78, 0x01, 0xe0, 0x97, 0xd0, 0xba, 0x4a, 0x98, 0x58, 0xaf,
-	0x38, 0xbc, 0x26, 0x92, 0x01, 0x9d, 0x7b, 0x01, 0x4e, 0x71, 0xb2, 0x09, 0xe4, 0x72, 0x01, 0x08,
-	0xf3, 0x4d, 0xbc, 0x2c, 0xcd, 0xbb, 0x5f, 0x36, 0xe7, 0x72, 0x69, 0x36, 0xa1, 0xb1, 0x64, 0x01,
-	0xe3, 0x6c, 0x69, 0xee, 0x75, 0xb5, 0x9e, 0x41, 0x8a, 0x52, 0xc4, 0xe6, 0xc3, 0x3c, 0xf6, 0xe1,
-	0x97, 0x63, 0x73, 0xb9, 0x00, 0x26, 0xba, 0x81, 0xd1, 0xde, 0x44, 0x37, 0x1a, 0xc8, 0x98, 0xe8,
-	0x06, 0xa0, 0xd6, 0x44, 0x37, 0x5a, 0xa8, 0x7d, 0xf8, 0xaf, 0x06, 0x0f, 0xf3, 0x03, 0x65, 0x87,
-	0x3c, 0xc9, 0x4a, 0x33, 0xfe, 0x01, 0x76, 0xca, 0x7b, 0x4b, 0x43, 0x37, 0x8c, 0x52, 0x75, 0x47,
-	0xb7, 0x4b, 0x78, 0x2a, 0x50, 0xfc, 0x08, 0xea, 0x41, 0xe4, 0x89, 0x3b, 0x5c, 0x91, 0x7c, 0x2d,
-	0x88, 0xbc, 0xf1, 0x12, 0xff, 0x0a, 0xcd, 0xf2, 0x34, 0xca, 0xeb, 0xd8, 0x1a, 0xec, 0x7f, 0xfa,
-	0x24, 0x93, 0x8d, 0xf0, 0xf0, 0x83, 0x06, 0x5b, 0x39, 0x7a, 0x1e, 0x79, 0x62, 0x47, 0xf0, 0x01,
-	0x18, 0xd7, 0x2c, 0xa3, 0x2b, 0x3f, 0xe4, 0x66, 0xa3, 0xab, 0xf5, 0xda, 0xa4, 0x71, 0xcd, 0xb2,
-	0x91, 0x1f, 0x4a, 0x4a, 0x74, 0x16, 0x7b, 0x2d, 0x8f, 0x75, 0x9b, 0x34, 0x02, 0xe5, 0xfa, 0x09,
-	0x70, 0x41, 0xd1, 0xcd, 0x34, 0x9a, 0x52, 0x84, 0x94, 0xa8, 0xbc, 0x40, 0x13, 0xdd, 0xd0, 0x50,
-	0x65, 0xa2, 0x1b, 0x15, 0x54, 0x9d, 0xe8, 0x46, 0x15, 0xe9, 0x13, 0xdd, 0xd0, 0x51, 0x6d, 0xa2,
-	0x1b, 0x35, 0x54, 0x9f, 0xe8, 0x46, 0x1d, 0x35, 0x0e, 0x93, 0x62, 0x62, 0x17, 0x6e, 0x5c, 0x4c,
-	0x6c, 0xed, 0xc6, 0x79, 0xf7, 0x3c, 0xb8, 0xb1, 0x56, 0xd4, 0xd7, 0xb7, 0xd7, 0xae, 0x4b, 0x6e,
-	0x03, 0x7c, 0xb2, 0x5b, 0xd9, 0xa7, 0xdc, 0x22, 0x03, 0x35, 0x0f, 0x87, 0x50, 0x9b, 0x27, 0x51,
-	0xf4, 0x0e, 0x7f, 0x03, 0x10, 0x30, 0xf7, 0x1d, 0xf5, 0xc3, 0x25, 0x7b, 0xaf, 0xf6, 0xa1, 0x29,
-	0x90, 0xb1, 0x00, 0xf0, 0x3e, 0xd4, 0xc5, 0x0b, 0x85, 0xa5, 0x66, 0xb5, 0x5b, 0xed, 0xb5, 0x89,
-	0xaa, 0xf2, 0x1e, 0x4f, 0x87, 0xb0, 0xa5, 0x1e, 0xe6, 0x49, 0x94, 0xac, 0x5d, 0x8e, 0xbf, 0x82,
-	0xc7, 0xe7, 0xb3, 0x53, 0x4a, 0x66, 0x33, 0x87, 0x9e, 0xcc, 0xc8, 0x85, 0xe5, 0xd0, 0x3f, 0xa6,
-	0x67, 0xd3, 0xd9, 0x9f, 0x53, 0xf4, 0x00, 0xef, 0x03, 0xbe, 0x4b, 0xbe, 0x7e, 0x86, 0x34, 0x91,
-	0xa2, 0x56, 0xbe, 0x49, 0xb9, 0xb0, 0xe6, 0x9f, 0x4f, 0xb9, 0x4b, 0xca, 0x94, 0x0f, 0x1a, 0xb4,
-	0x6f, 0xbf, 0x55, 0xf1, 0x01, 0x3c, 0x52, 0x2e, 0x3a, 0xb2, 0x16, 0x23, 0xba, 0x70, 0x88, 0xe5,
-	0xd8, 0xa7, 0x6f, 0xd0, 0x03, 0x8c, 0x61, 0x9b, 0x9c, 0x1c, 0xbf, 0xf8, 0xed, 0xc5, 0x80, 0x2e,
-	0x46, 0xd6, 0xe0, 0xf9, 0x0b, 0xa4, 0xe1, 0x3d, 0xd8, 0x71, 0xec, 0x85, 0x43, 0x45, 0xb8, 0xd0,
-	0xdb, 0x04, 0x55, 0x44, 0xc6, 0xec, 0xd5, 0xc4, 0x3e, 0x76, 0xe8, 0x1d, 0x7d, 0x15, 0x3f, 0x82,
-	0xdd, 0xe3, 0xd9, 0x74, 0x7c, 0xb6, 0x10, 0xd0, 0xf3, 0x67, 0x03, 0x2a, 0x60, 0x1d, 0xef, 0xc2,
-	0xd6, 0x06, 0x16, 0x50, 0xed, 0xe9, 0x3f, 0x1a, 0x34, 0xcb, 0xef, 0x8a, 0x98, 0x7f, 0x31, 0x2d,
-	0x87, 0xd8, 0x36, 0x5d, 0x38, 0x96, 0x63, 0xa3, 0x07, 0x18, 0xa0, 0x6e, 0x1d, 0x3b, 0xe3, 0xd7,
-	0x36, 0xd2, 0xc4, 0xf8, 0x84, 0xcc, 0xde, 0xda, 0x53, 0x54, 0xc1, 0x4f, 0xe0, 0xf1, 0xd0, 0x9e,
-	0x13, 0xfb, 0xd8, 0x72, 0xec, 0x21, 0x5d, 0xcc, 0x4e, 0x1c, 0x3a, 0xb4, 0xcf, 0x6d, 0xc7, 0x1e,
-	0xa2, 0x6a, 0xa7, 0x62, 0x68, 0x77, 0x04, 0x23, 0x8b, 0x0c, 0x4b, 0x81, 0x2e, 0x05, 0x6d, 0x30,
-	0x86, 0xc4, 0x1a, 0x4f, 0xc7, 0xd3, 0x53, 0x54, 0x7b, 0x7a, 0x0a, 0x46, 0xf1, 0xc5, 0x12, 0x6b,
-	0xf8, 0x68, 0x2e, 0xce, 0x9b, 0xb9, 0x98, 0x4a, 0x03, 0xaa, 0xe7, 0xb3, 0x53, 0xa4, 0x89, 0xc1,
-	0x85, 0x35, 0x47, 0x15, 0xf1, 0xc0, 0xe6, 0xc4, 0x9e, 0x91, 0xa1, 0x4d, 0xec, 0x21, 0x15, 0x64,
-	0xf5, 0xd5, 0x08, 0x0e, 0xae, 0xa2, 0x75, 0xf1, 0x92, 0xf8, 0xf8, 0x47, 0xc2, 0xab, 0x2d, 0x47,
-	0xd5, 0x73, 0x51, 0xce, 0xb5, 0xb7, 0x1d, 0xcf, 0xe7, 0xab, 0x9b, 0xcb, 0xfe, 0x55, 0xb4, 0x3e,
-	0x52, 0x5f, 0xf1, 0xc2, 0x72, 0x59, 0x97, 0x9e, 0x5f, 0xfe, 0x0b, 0x00, 0x00, 0xff, 0xff, 0xe3,
-	0xfa, 0x69, 0xdf, 0x6a, 0x08, 0x00, 0x00,
+	// 2023 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x58, 0x5d, 0x73, 0xdb, 0xc6,
+	0xd5, 0x36, 0x48, 0x8a, 0x02, 0x8f, 0x28, 0x11, 0x5a, 0xeb, 0x03, 0x92, 0xbf, 0x68, 0xc6, 0x89,
+	0x15, 0xcd, 0x3b, 0xf2, 0x1b, 0xb5, 0xf6, 0x34, 0x93, 0x74, 0x32, 0x30, 0x09, 0x49, 0xa4, 0x29,
+	0x82, 0x5e, 0x40, 0x4e, 0xed, 0x1b, 0xcc, 0x8a, 0x58, 0x91, 0xa8, 0x40, 0x80, 0x05, 0x96, 0x8e,
+	0x90, 0xfb, 0x5e, 0xb5, 0x97, 0x9d, 0xc9, 0x6f, 0xe9, 0x45, 0xff, 0x4a, 0x7f, 0x4b, 0x67, 0x17,
+	0x0b, 0x52, 0xd4, 0x47, 0x7c, 0x23, 0x61, 0x9f, 0xf3, 0x9c, 0xb3, 0x67, 0xf7, 0x3c, 0x7b, 0xb0,
+	0x20, 0xac, 0xb1, 0xd8, 0x0f, 0x02, 0x9f, 0x84, 0x07, 0x93, 0x38, 0x62, 0x11, 0x52, 0xf3, 0xf1,
+	0xee, 0xee, 0x20, 0x4e, 0x27, 0x2c, 0x7a, 0x75, 0x49, 0xd3, 0x64, 0x72, 0x2e, 0xff, 0x65, 0xac,
+	0x5d, 0x5d, 0xda, 0x12, 0x7f, 0x38, 0x39, 0xcf, 0xfe, 0x4a, 0xcb, 0xce, 0x30, 0x8a, 0x86, 0x01,
+	0x7d, 0x25, 0x46, 0xe7, 0xd3, 0x8b, 0x57, 0x24, 0x4c, 0xa5, 0xe9, 0xe9, 0x4d, 0x93, 0x37, 0x8d,
+	0x09, 0xf3, 0x23, 0x39, 0xf5, 0xee, 0xb3, 0x9b, 0x76, 0xe6, 0x8f, 0x69, 0xc2, 0xc8, 0x78, 0x92,
+	0x11, 0x1a, 0xff, 0xa9, 0x41, 0xc9, 0x89, 0x29, 0x45, 0xdb, 0xb0, 0xcc, 0x62, 0x4a, 0x5d, 0xdf,
+	0xd3, 0x95, 0xba, 0xb2, 0x57, 0xc4, 0x65, 0x3e, 0x6c, 0x7b, 0xe8, 0x10, 0x40, 0x18, 0x12, 0x46,
+	0x18, 0xd5, 0x0b, 0x75, 0x65, 0x6f, 0xed, 0xf0, 0xe1, 0xc1, 0x6c, 0x89, 0xdc, 0xd9, 0xe6, 0x26,
+	0x5c, 0x61, 0xf9, 0x23, 0x7a, 0x05, 0x62, 0xe0, 0xb2, 0x74, 0x42, 0xf5, 0xa2, 0x70, 0x41, 0x8b,
+	0x2e, 0x4e, 0x3a, 0xa1, 0x58, 0x65, 0xf2, 0x09, 0xfd, 0x00, 0xab, 0x23, 0x92, 0x8c, 0xdc, 0x84,
+	0xc5, 0x84, 0xd1, 0x61, 0xaa, 0x97, 0x84, 0xd3, 0xd6, 0xdc, 0xe9, 0x84, 0x24, 0x23, 0x5b, 0x5a,
+	0x71, 0x75, 0x74, 0x6d, 0x84, 0xde, 0xc1, 0x9a, 0x70, 0x26, 0xc1, 0x30, 0x8a, 0x7d, 0x36, 0x1a,
+	0xeb, 0x4b, 0xc2, 0xfb, 0xc5, 0x41, 0xb6, 0x8b, 0x2d, 0x7f, 0xe8, 0x33, 0x12, 0x04, 0xa9, 0xed,
+	0x0f, 0x43, 0xea, 0x89, 0x50, 0x46, 0xce, 0xc5, 0xab, 0xa3, 0xeb, 0x43, 0xf4, 0x09, 0x1e, 0x26,
+	0xfe, 0x30, 0x24, 0x6c, 0x1a, 0xd3, 0x6b, 0x11, 0xcb, 0x22, 0xe2, 0xb7, 0xf7, 0x44, 0xb4, 0x73,
+	0x8f, 0x79, 0x58, 0x94, 0xdc, 0xc2, 0xd0, 0x73, 0xa8, 0x7a, 0x7e, 0x32, 0x09, 0x48, 0xea, 0x86,
+	0x64, 0x4c, 0x75, 0xb5, 0xae, 0xec, 0x55, 0xf0, 0x8a, 0xc4, 0x7a, 0x64, 0x4c, 0x51, 0x1d, 0x56,
+	0x3c, 0x9a, 0x0c, 0x62, 0x7f, 0xc2, 0xab, 0xa8, 0x57, 0x24, 0x63, 0x0e, 0xa1, 0xd7, 0xb0, 0x32,
+	0x89, 0xfd, 0xcf, 0x84, 0x51, 0xf7, 0x92, 0xa6, 0x7a, 0xb5, 0xae, 0xec, 0xad, 0x1c, 0x6e, 0x1c,
+	0x64, 0x85, 0x3e, 0xc8, 0x0b, 0x7d, 0x60, 0x84, 0x29, 0x06, 0x49, 0x7c, 0x47, 0x53, 0xf4, 0x13,
+	0x68, 0x09, 0x8b, 0x62, 0x32, 0xa4, 0x6e, 0x42, 0x19, 0xf3, 0xc3, 0x61, 0xa2, 0xaf, 0xfe, 0x8e,
+	0x6f, 0x4d, 0xb2, 0x6d, 0x49, 0x46, 0xff, 0x0f, 0x30, 0x99, 0x9e, 0x07, 0xfe, 0x40, 0x4c, 0xbb,
+	0x26, 0x5c, 0xd7, 0x0f, 0xa4, 0x84, 0xfb, 0xc2, 0xf2, 0x8e, 0xa6, 0xb8, 0x32, 0xc9, 0x1f, 0x91,
+	0x09, 0xeb, 0x63, 0x72, 0xe5, 0xc6, 0x51, 0xc4, 0xdc, 0x5c, 0x97, 0x7a, 0x4d, 0x38, 0xee, 0xdc,
+	0x9a, 0xb3, 0x25, 0x09, 0xb8, 0x36, 0x26, 0x57, 0x38, 0x8a, 0x58, 0x0e, 0xa0, 0x1f, 0x60, 0x65,
+	0x10, 0x53, 0xbe, 0x5e, 0x2e, 0x5e, 0x5d, 0x13, 0x01, 0x76, 0x6f, 0x05, 0x70, 0x72, 0x65, 0x63,
+	0xc8, 0xe8, 0x1c, 0xe0, 0xce, 0xd3, 0x89, 0x37, 0x73, 0x5e, 0xff, 0xb2, 0x73, 0x46, 0x17, 0xce,
+	0x3a, 0x2c, 0x7b, 0x34, 0xa0, 0x8c, 0x7a, 0xfa, 0xc3, 0xba, 0xb2, 0xa7, 0xe2, 0x7c, 0xc8, 0xc3,
+	0x66, 0x8f, 0x59, 0xd8, 0x8d, 0x2f, 0x87, 0xcd, 0xe8, 0x22, 0xec, 0x1b, 0xd8, 0x8e, 0x62, 0x8f,
+	0xc6, 0xd4, 0x73, 0x03, 0x4a, 0x2e, 0xdc, 0xd9, 0x99, 0x4c, 0xf4, 0x4d, 0x31, 0xcd, 0xa6, 0x34,
+	0x77, 0x29, 0xb9, 0x98, 0x85, 0x48, 0xd0, 0xf7, 0xb0, 0x33, 0x20, 0x41, 0x40, 0xe3, 0xcc, 0xcd,
+	0xf7, 0x68, 0xc8, 0x7c, 0x96, 0xba, 0x5c, 0xc0, 0xfa, 0x96, 0xf0, 0xdc, 0xca, 0x08, 0xdc, 0xb1,
+	0x2d, 0xcd, 0x5c, 0xed, 0xe8, 0x1b, 0xa8, 0x89, 0x23, 0x42, 0xaf, 0x58, 0x4c, 0x5c, 0x8f, 0x30,
+	0xa2, 0x6f, 0x0b, 0x07, 0xa1, 0x7e, 0x93, 0xa3, 0x2d, 0xc2, 0x08, 0x7a, 0x0c, 0x15, 0xae, 0xcc,
+	0x64, 0x42, 0x06, 0x54, 0xd7, 0x85, 0xf8, 0xe6, 0x00, 0x2f, 0x68, 0x10, 0x0d, 0xb3, 0x82, 0xd2,
+	0x70, 0x10, 0x79, 0x7e, 0x38, 0xd4, 0x77, 0xc4, 0xc9, 0xd8, 0x99, 0x9f, 0xd4, 0x6e, 0x34, 0xe4,
+	0xf5, 0x33, 0x25, 0x01, 0xd7, 0x82, 0x45, 0x00, 0xd9, 0xb0, 0x39, 0x5b, 0xb2, 0x3b, 0x8c, 0x49,
+	0x38, 0x0d, 0x48, 0xec, 0xb3, 0x54, 0xdf, 0x15, 0xa1, 0x9e, 0x5e, 0xeb, 0x14, 0x39, 0xed, 0x78,
+	0xce, 0xc2, 0x1b, 0xec, 0x0e, 0x14, 0x3d, 0x82, 0x8a, 0x58, 0x61, 0x14, 0x06, 0xa9, 0xfe, 0x48,
+	0xac, 0x4d, 0xe5, 0x80, 0x15, 0x06, 0x29, 0x6a, 0x81, 0x26, 0xb6, 0x6c, 0x10, 0x8d, 0x27, 0x31,
+	0x4d, 0x12, 0x2e, 0xc4, 0xc7, 0xb7, 0xf2, 0xa6, 0xe4, 0xa2, 0x39, 0x27, 0xe0, 0x5a, 0xb0, 0x08,
+	0xa0, 0x06, 0xac, 0x72, 0x3d, 0x67, 0xdd, 0xd0, 0xff, 0x95, 0xea, 0x4f, 0x44, 0xa3, 0x5c, 0x19,
+	0x93, 0x2b, 0xd1, 0x05, 0xfd, 0x5f, 0x29, 0xda, 0x87, 0xf5, 0xbf, 0x4d, 0xe9, 0x94, 0xba, 0xbf,
+	0xc4, 0x3e, 0xa3, 0x2e, 0x19, 0x51, 0xe2, 0xe9, 0x4f, 0x45, 0x3a, 0x35, 0x61, 0xf8, 0x99, 0xe3,
+	0x06, 0x87, 0x91, 0x01, 0x62, 0x0a, 0xbe, 0x95, 0xbc, 0xf5, 0xf3, 0xa4, 0x9e, 0x09, 0x21, 0xe9,
+	0x8b, 0x49, 0x99, 0x33, 0x3b, 0x5e, 0x0b, 0x16, 0xc6, 0xe8, 0x35, 0x6c, 0x27, 0x51, 0xcc, 0xdc,
+	0xf3, 0xd4, 0xcd, 0xa6, 0x9d, 0xed, 0x8d, 0x5e, 0x17, 0x93, 0x6e, 0x70, 0xf3, 0xdb, 0xf4, 0x3d,
+	0x37, 0xce, 0x76, 0x53, 0x14, 0x92, 0xcf, 0x2c, 0x74, 0xe6, 0x87, 0x43, 0x71, 0xa4, 0x9f, 0xcb,
+	0x93, 0xb9, 0x30, 0xb7, 0x25, 0x19, 0xfc, 0x68, 0xd7, 0x82, 0x45, 0x00, 0xbd, 0x94, 0x0b, 0x60,
+	0xd1, 0xf8, 0x3c, 0x61, 0x51, 0x48, 0x13, 0xbd, 0x21, 0x66, 0x15, 0x69, 0x3a, 0x33, 0x94, 0xcb,
+	0x8f, 0x8e, 0x27, 0x2c, 0xcd, 0xa4, 0x23, 0xf4, 0xfa, 0x55, 0x5d, 0xd9, 0xab, 0xe2, 0x55, 0x01,
+	0x73, 0x75, 0x08, 0x99, 0x0e, 0xe1, 0xc9, 0xcd, 0x3a, 0xb9, 0x9e, 0x3f, 0xe0, 0x4b, 0x25, 0xb1,
+	0x4f, 0x13, 0xfd, 0x45, 0xbd, 0xb8, 0xb7, 0x72, 0xf8, 0xd5, 0xbd, 0x45, 0x6b, 0xe5, 0xe4, 0x14,
+	0x3f, 0x0a, 0xee, 0x31, 0xf9, 0x34, 0xe1, 0x65, 0xca, 0xce, 0x50, 0xe8, 0xd1, 0x2b, 0x37, 0xba,
+	0xb8, 0x48, 0x28, 0xd3, 0xbf, 0x16, 0xe5, 0x14, 0x4b, 0x6a, 0x73, 0xdc, 0x12, 0x30, 0x17, 0x0f,
+	0xef, 0xe5, 0x7c, 0x9b, 0xfc, 0x90, 0xd1, 0xf8, 0x33, 0x09, 0xf4, 0x6f, 0xbe, 0xd8, 0xc5, 0xa4,
+	0x4b, 0x5b, 0x7a, 0xa0, 0x3f, 0x67, 0xe2, 0xc9, 0xaa, 0x44, 0x86, 0x54, 0x7f, 0xf9, 0xa5, 0x10,
+	0x5c, 0x57, 0xa2, 0x6e, 0xc6, 0x90, 0x76, 0x4a, 0x2a, 0xd2, 0x1e, 0x76, 0x4a, 0xea, 0xb2, 0xa6,
+	0x76, 0x4a, 0x2a, 0x68, 0x2b, 0x9d, 0x92, 0xba, 0xa2, 0x55, 0x1b, 0xff, 0x56, 0xa0, 0x76, 0xa3,
+	0x4e, 0xe8, 0x4f, 0x50, 0x4e, 0xa2, 0x69, 0x3c, 0xa0, 0xe2, 0x4d, 0xbe, 0x76, 0x58, 0xbf, 0xb7,
+	0xa4, 0x07, 0xb6, 0xe0, 0x61, 0xc9, 0x47, 0x5b, 0x50, 0x96, 0x7b, 0xc1, 0xdf, 0xf3, 0x4b, 0x58,
+	0x8e, 0x38, 0x1e, 0xd0, 0x70, 0xc8, 0x46, 0xe2, 0x65, 0xbe, 0x84, 0xe5, 0xa8, 0xf1, 0x23, 0x94,
+	0xb3, 0x08, 0x08, 0xc1, 0x9a, 0x6d, 0x9d, 0xe1, 0xa6, 0xe9, 0x9e, 0xf5, 0xde, 0xf5, 0xac, 0x9f,
+	0x7b, 0xda, 0x03, 0xb4, 0x06, 0xd0, 0x35, 0x8d, 0x23, 0xf7, 0x83, 0xd1, 0x3d, 0x33, 0x35, 0x85,
+	0x8f, 0xcd, 0xbf, 0x38, 0xd8, 0x70, 0x5b, 0x86, 0x63, 0x68, 0x85, 0xc6, 0x7b, 0x58, 0x5b, 0x94,
+	0x37, 0xda, 0x03, 0xed, 0x97, 0x98, 0x4c, 0x26, 0xd4, 0x13, 0x3d, 0x4a, 0xc8, 0x52, 0x11, 0x42,
+	0x59, 0x93, 0x38, 0xef, 0x52, 0x7c, 0x8d, 0x9b, 0x50, 0xbe, 0xa4, 0x97, 0xfc, 0xb6, 0x52, 0x10,
+	0x5d, 0x6a, 0xe9, 0x92, 0x5e, 0xb6, 0xbd, 0xc6, 0x19, 0xec, 0xdc, 0xab, 0x08, 0xde, 0xce, 0x3f,
+	0xd3, 0x58, 0x1c, 0x7e, 0x45, 0x2c, 0x23, 0x1f, 0xa2, 0xa7, 0x00, 0x33, 0x99, 0xa5, 0x22, 0x62,
+	0x15, 0x5f, 0x43, 0x1a, 0xff, 0x54, 0x60, 0x23, 0x7b, 0xd5, 0x9b, 0x21, 0x8b, 0xd3, 0xf9, 0x41,
+	0x7a, 0x09, 0xb5, 0x79, 0x2b, 0x0b, 0x49, 0x18, 0x25, 0xf2, 0xf6, 0xb4, 0x36, 0x83, 0x7b, 0x1c,
+	0xe5, 0xf9, 0xf2, 0xd6, 0x29, 0xf3, 0x2d, 0xe2, 0xa5, 0x20, 0x1a, 0xb6, 0x3d, 0xf4, 0x47, 0xa8,
+	0xcc, 0xee, 0x09, 0x62, 0x6f, 0x57, 0x0e, 0xb7, 0xee, 0xbe, 0x63, 0xe0, 0x39, 0xb1, 0xf1, 0x9b,
+	0x02, 0xab, 0x19, 0x2a, 0x7b, 0x2d, 0xda, 0x01, 0xf5, 0x92, 0xa6, 0xee, 0xc8, 0x0f, 0x99, 0xbe,
+	0x2c, 0xd2, 0x5f, 0xbe, 0xa4, 0xe9, 0x89, 0x1f, 0x0a, 0x53, 0xde, 0xb4, 0xc5, 0x85, 0xa3, 0x8a,
+	0x97, 0x65, 0x43, 0x46, 0xff, 0x07, 0x28, 0x37, 0xb9, 0xf3, 0x34, 0x2a, 0x82, 0xa4, 0x49, 0xd2,
+	0xec, 0x6a, 0xd3, 0x29, 0xa9, 0x8a, 0x56, 0xe8, 0x94, 0xd4, 0x82, 0x56, 0xec, 0x94, 0xd4, 0xa2,
+	0x56, 0xea, 0x94, 0xd4, 0x92, 0xb6, 0xd4, 0x29, 0xa9, 0x4b, 0x5a, 0xb9, 0x53, 0x52, 0xcb, 0xda,
+	0x72, 0x23, 0xce, 0x13, 0x3b, 0x25, 0x93, 0x3c, 0xb1, 0x31, 0x99, 0x64, 0xb3, 0x67, 0x81, 0x97,
+	0xc7, 0xd2, 0xf4, 0xf8, 0xfa, 0xda, 0x4b, 0xc2, 0x56, 0x49, 0x7e, 0x77, 0xb6, 0xd9, 0x3c, 0xb3,
+	0x83, 0xa0, 0x6a, 0x95, 0x46, 0x0b, 0x96, 0xfa, 0x71, 0x14, 0x5d, 0xa0, 0x27, 0x00, 0xf3, 0x43,
+	0x2d, 0xeb, 0x50, 0x99, 0x9d, 0x66, 0x2e, 0x62, 0xde, 0x79, 0x68, 0xa2, 0x17, 0xeb, 0xc5, 0xbd,
+	0x2a, 0x96, 0xa3, 0x6c, 0x8e, 0xc6, 0xbf, 0x14, 0xd8, 0x7c, 0x3f, 0x8d, 0x18, 0x31, 0xaf, 0x46,
+	0x64, 0x9a, 0x30, 0xea, 0xb5, 0x28, 0x23, 0x7e, 0x90, 0x20, 0x04, 0xa5, 0x64, 0x42, 0x07, 0x22,
+	0x60, 0x05, 0x8b, 0x67, 0xf4, 0x2d, 0x68, 0x2c, 0xba, 0xa4, 0x61, 0xe2, 0x92, 0xcf, 0xc4, 0x0f,
+	0xc8, 0x79, 0x40, 0x65, 0x61, 0x6b, 0x19, 0x6e, 0xe4, 0x30, 0xfa, 0x11, 0xaa, 0x31, 0xbd, 0xf0,
+	0x83, 0xc0, 0xf5, 0x68, 0x40, 0x52, 0xbd, 0xf8, 0xc5, 0x73, 0x9f, 0xd1, 0x5b, 0x9c, 0xdd, 0xf8,
+	0xaf, 0x02, 0x5b, 0xfc, 0xe5, 0x72, 0x16, 0xce, 0x26, 0xca, 0xf3, 0xba, 0xf7, 0xc6, 0xfe, 0x13,
+	0x94, 0x63, 0x4a, 0x92, 0x28, 0x94, 0xb7, 0xf5, 0x97, 0x8b, 0x57, 0xef, 0xdb, 0xa1, 0x0e, 0xb0,
+	0xa0, 0x63, 0xe9, 0xd6, 0xf8, 0x2b, 0x94, 0x33, 0x04, 0x6d, 0x01, 0xc2, 0xa6, 0x61, 0x5b, 0x3d,
+	0xf7, 0xac, 0x67, 0xf7, 0xcd, 0x66, 0xfb, 0xa8, 0x6d, 0xb6, 0xb4, 0x07, 0xfc, 0xb8, 0x3b, 0xd8,
+	0x34, 0xdd, 0x9e, 0xe5, 0xb8, 0x47, 0xd6, 0x59, 0xaf, 0xa5, 0x29, 0x48, 0x83, 0xaa, 0xc0, 0x5a,
+	0x66, 0xd7, 0x74, 0xcc, 0x96, 0x56, 0x40, 0x35, 0x58, 0x11, 0xc8, 0x11, 0xb6, 0x3e, 0x99, 0x3d,
+	0xad, 0x88, 0xd6, 0x61, 0x35, 0xa3, 0x60, 0xa3, 0xdd, 0x6b, 0xf7, 0x8e, 0xb5, 0xd2, 0x7e, 0x0b,
+	0x56, 0xa5, 0x88, 0x8f, 0xa2, 0x78, 0x4c, 0x18, 0x7a, 0x04, 0xdb, 0x5d, 0xeb, 0xd8, 0xc5, 0x96,
+	0x08, 0x8d, 0x4f, 0x0d, 0xe7, 0x5a, 0x4b, 0xd9, 0x02, 0x74, 0xd3, 0xf8, 0xe1, 0x3b, 0x4d, 0xd9,
+	0x7f, 0x01, 0xb5, 0x1b, 0xd7, 0x0e, 0xb4, 0x0c, 0x45, 0xa7, 0x6b, 0x6b, 0x0f, 0x90, 0x0a, 0xa5,
+	0xe6, 0x5b, 0x0b, 0x6b, 0xca, 0xfe, 0xdf, 0x15, 0xd8, 0xb8, 0xeb, 0x4a, 0x81, 0x5e, 0x40, 0xdd,
+	0x69, 0x9f, 0x9a, 0xb6, 0x63, 0x9c, 0xf6, 0xdd, 0x63, 0x6c, 0xf4, 0xce, 0xba, 0x06, 0x6e, 0x3b,
+	0x1f, 0xdd, 0x9e, 0xd1, 0xb3, 0x6c, 0xb3, 0x69, 0xf5, 0xf8, 0xa2, 0xbf, 0x86, 0xe7, 0x77, 0xb3,
+	0x4e, 0xdb, 0xdd, 0x6e, 0x5b, 0xd2, 0x14, 0x54, 0x87, 0xc7, 0x77, 0xd3, 0x24, 0xa3, 0xb0, 0x3f,
+	0x86, 0xda, 0x8d, 0x2e, 0x85, 0x76, 0x60, 0x53, 0xf4, 0xca, 0xa6, 0x75, 0xda, 0xc7, 0xa6, 0x6d,
+	0xb7, 0xad, 0x9e, 0xdb, 0xb3, 0x7a, 0xa6, 0xf6, 0xe0, 0x4e, 0xd3, 0xf1, 0xa7, 0x76, 0x5f, 0x53,
+	0x78, 0xde, 0xb7, 0x4c, 0x9f, 0x6c, 0xa7, 0xe5, 0xb6, 0xda, 0x4d, 0xa7, 0x6d, 0xf5, 0x0c, 0xfc,
+	0x51, 0x2b, 0xf0, 0x2d, 0x96, 0xc7, 0x71, 0xbe, 0xc5, 0xa7, 0x46, 0xff, 0xfe, 0x2d, 0xbe, 0x69,
+	0x14, 0x5b, 0xfc, 0x9b, 0x02, 0xd5, 0xeb, 0x1f, 0x61, 0x3c, 0x2f, 0xe9, 0xe5, 0x9e, 0x18, 0xf6,
+	0x89, 0x6b, 0x3b, 0xd8, 0x70, 0xcc, 0xe3, 0x8f, 0x99, 0x3c, 0xf0, 0x51, 0xf3, 0xcd, 0xf7, 0x6f,
+	0x0e, 0x5d, 0xfb, 0xc4, 0x38, 0x7c, 0xfd, 0x46, 0x53, 0xd0, 0x43, 0xa8, 0x39, 0xa6, 0xed, 0xb8,
+	0x3c, 0x38, 0xe7, 0x9b, 0x58, 0x2b, 0xf0, 0x18, 0xd6, 0xdb, 0x8e, 0xd9, 0x74, 0xdc, 0x1b, 0xfc,
+	0x22, 0xda, 0x84, 0xf5, 0xa6, 0xd5, 0x6b, 0xbf, 0xb3, 0x39, 0xf4, 0xfa, 0xbb, 0x43, 0x97, 0xc3,
+	0x25, 0x2e, 0xa1, 0x39, 0xcc, 0xa1, 0xa5, 0xfd, 0x7f, 0x28, 0x50, 0x99, 0x7d, 0x86, 0xf2, 0xfc,
+	0xf3, 0xb4, 0x84, 0xd6, 0x6c, 0xc7, 0x70, 0xf8, 0x36, 0x02, 0x94, 0x8d, 0xa6, 0xd3, 0xfe, 0xc0,
+	0xdf, 0x44, 0x00, 0x65, 0xa9, 0xc9, 0x02, 0x7a, 0x06, 0xdb, 0x2d, 0xb3, 0x8f, 0xcd, 0xa6, 0xe1,
+	0x98, 0x2d, 0xd7, 0xb6, 0x8e, 0x9c, 0x99, 0x82, 0x8b, 0xbb, 0x05, 0x55, 0xb9, 0x41, 0x38, 0x31,
+	0x70, 0x6b, 0x46, 0x28, 0x09, 0x42, 0x15, 0xd4, 0x99, 0xa0, 0x97, 0xf6, 0x8f, 0x41, 0xcd, 0x3f,
+	0x70, 0xf9, 0x1a, 0x16, 0x72, 0x71, 0x3e, 0xf6, 0x79, 0x2a, 0xcb, 0x50, 0xec, 0x5a, 0xc7, 0x9a,
+	0xc2, 0x1f, 0x4e, 0x8d, 0xbe, 0x56, 0xe0, 0x1b, 0xd6, 0xc7, 0xa6, 0x85, 0x5b, 0x26, 0x36, 0x5b,
+	0x2e, 0x37, 0x16, 0xdf, 0x9e, 0xc0, 0xce, 0x20, 0x1a, 0xe7, 0x7d, 0x62, 0xf1, 0x37, 0x85, 0xb7,
+	0xab, 0x8e, 0x1c, 0xf7, 0xf9, 0xb0, 0xaf, 0x7c, 0xda, 0x1d, 0xfa, 0x6c, 0x34, 0x3d, 0x3f, 0x18,
+	0x44, 0xe3, 0x57, 0xf2, 0xa3, 0x3f, 0x77, 0x39, 0x2f, 0x0b, 0x9f, 0x3f, 0xfc, 0x6f, 0x00, 0xff,
+	0xcb, 0xbb, 0x9c, 0x99, 0x10, 0x00, 0x00,
 }
@@ -7,6 +7,8 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
+	empty "github.com/golang/protobuf/ptypes/empty"
 	keyspb "github.com/google/trillian/crypto/keyspb"
 	_ "google.golang.org/genproto/googleapis/api/annotations"
 	field_mask "google.golang.org/genproto/protobuf/field_mask"
@@ -112,6 +114,131 @@ func (m *ListTreesResponse) GetTree() []*Tree {
 	return nil
 }
 
+// ListSoftDeletedTrees request.
+type ListSoftDeletedTreesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListSoftDeletedTreesRequest) Reset()         { *m = ListSoftDeletedTreesRequest{} }
+func (m *ListSoftDeletedTreesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListSoftDeletedTreesRequest) ProtoMessage()    {}
+func (*ListSoftDeletedTreesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{2}
+}
+
+func (m *ListSoftDeletedTreesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSoftDeletedTreesRequest.Unmarshal(m, b)
+}
+func (m *ListSoftDeletedTreesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSoftDeletedTreesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListSoftDeletedTreesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSoftDeletedTreesRequest.Merge(m, src)
+}
+func (m *ListSoftDeletedTreesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListSoftDeletedTreesRequest.Size(m)
+}
+func (m *ListSoftDeletedTreesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSoftDeletedTreesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSoftDeletedTreesRequest proto.InternalMessageInfo
+
+// A tree that has been soft-deleted but not yet hard-deleted.
+type SoftDeletedTree struct {
+	// The soft-deleted tree.
+	Tree *Tree `protobuf:"bytes,1,opt,name=tree,proto3" json:"tree,omitempty"`
+	// Time left until the tree becomes eligible for hard-deletion, after which
+	// it can no longer be undeleted. Zero if the tree is already eligible.
+	// Unset if the server doesn't hard-delete trees.
+	TimeUntilHardDelete  *duration.Duration `protobuf:"bytes,2,opt,name=time_until_hard_delete,json=timeUntilHardDelete,proto3" json:"time_until_hard_delete,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *SoftDeletedTree) Reset()         { *m = SoftDeletedTree{} }
+func (m *SoftDeletedTree) String() string { return proto.CompactTextString(m) }
+func (*SoftDeletedTree) ProtoMessage()    {}
+func (*SoftDeletedTree) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{3}
+}
+
+func (m *SoftDeletedTree) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SoftDeletedTree.Unmarshal(m, b)
+}
+func (m *SoftDeletedTree) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SoftDeletedTree.Marshal(b, m, deterministic)
+}
+func (m *SoftDeletedTree) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SoftDeletedTree.Merge(m, src)
+}
+func (m *SoftDeletedTree) XXX_Size() int {
+	return xxx_messageInfo_SoftDeletedTree.Size(m)
+}
+func (m *SoftDeletedTree) XXX_DiscardUnknown() {
+	xxx_messageInfo_SoftDeletedTree.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SoftDeletedTree proto.InternalMessageInfo
+
+func (m *SoftDeletedTree) GetTree() *Tree {
+	if m != nil {
+		return m.Tree
+	}
+	return nil
+}
+
+func (m *SoftDeletedTree) GetTimeUntilHardDelete() *duration.Duration {
+	if m != nil {
+		return m.TimeUntilHardDelete
+	}
+	return nil
+}
+
+// ListSoftDeletedTrees response.
+type ListSoftDeletedTreesResponse struct {
+	// Soft-deleted trees the requester has access to.
+	Trees                []*SoftDeletedTree `protobuf:"bytes,1,rep,name=trees,proto3" json:"trees,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ListSoftDeletedTreesResponse) Reset()         { *m = ListSoftDeletedTreesResponse{} }
+func (m *ListSoftDeletedTreesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListSoftDeletedTreesResponse) ProtoMessage()    {}
+func (*ListSoftDeletedTreesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{4}
+}
+
+func (m *ListSoftDeletedTreesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListSoftDeletedTreesResponse.Unmarshal(m, b)
+}
+func (m *ListSoftDeletedTreesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListSoftDeletedTreesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListSoftDeletedTreesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListSoftDeletedTreesResponse.Merge(m, src)
+}
+func (m *ListSoftDeletedTreesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListSoftDeletedTreesResponse.Size(m)
+}
+func (m *ListSoftDeletedTreesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListSoftDeletedTreesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListSoftDeletedTreesResponse proto.InternalMessageInfo
+
+func (m *ListSoftDeletedTreesResponse) GetTrees() []*SoftDeletedTree {
+	if m != nil {
+		return m.Trees
+	}
+	return nil
+}
+
 // GetTree request.
 type GetTreeRequest struct {
 	// ID of the tree to retrieve.
@@ -125,7 +252,7 @@ func (m *GetTreeRequest) Reset()         { *m = GetTreeRequest{} }
 func (m *GetTreeRequest) String() string { return proto.CompactTextString(m) }
 func (*GetTreeRequest) ProtoMessage()    {}
 func (*GetTreeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_aac35e28a5dd9ee3, []int{2}
+	return fileDescriptor_aac35e28a5dd9ee3, []int{5}
 }
 
 func (m *GetTreeRequest) XXX_Unmarshal(b []byte) error {
@@ -169,7 +296,7 @@ func (m *CreateTreeRequest) Reset()         { *m = CreateTreeRequest{} }
 func (m *CreateTreeRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateTreeRequest) ProtoMessage()    {}
 func (*CreateTreeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_aac35e28a5dd9ee3, []int{3}
+	return fileDescriptor_aac35e28a5dd9ee3, []int{6}
 }
 
 func (m *CreateTreeRequest) XXX_Unmarshal(b []byte) error {
@@ -220,7 +347,7 @@ func (m *UpdateTreeRequest) Reset()         { *m = UpdateTreeRequest{} }
 func (m *UpdateTreeRequest) String() string { return proto.CompactTextString(m) }
 func (*UpdateTreeRequest) ProtoMessage()    {}
 func (*UpdateTreeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_aac35e28a5dd9ee3, []int{4}
+	return fileDescriptor_aac35e28a5dd9ee3, []int{7}
 }
 
 func (m *UpdateTreeRequest) XXX_Unmarshal(b []byte) error {
@@ -255,6 +382,90 @@ func (m *UpdateTreeRequest) GetUpdateMask() *field_mask.FieldMask {
 	return nil
 }
 
+// BatchUpdateTrees request.
+type BatchUpdateTreesRequest struct {
+	// Updates to apply, at most one per tree. Only the mutable metadata of trees
+	// may be updated in a batch, so the masks may only contain "display_name",
+	// "description" and "max_root_duration".
+	Requests             []*UpdateTreeRequest `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *BatchUpdateTreesRequest) Reset()         { *m = BatchUpdateTreesRequest{} }
+func (m *BatchUpdateTreesRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchUpdateTreesRequest) ProtoMessage()    {}
+func (*BatchUpdateTreesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{8}
+}
+
+func (m *BatchUpdateTreesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchUpdateTreesRequest.Unmarshal(m, b)
+}
+func (m *BatchUpdateTreesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchUpdateTreesRequest.Marshal(b, m, deterministic)
+}
+func (m *BatchUpdateTreesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchUpdateTreesRequest.Merge(m, src)
+}
+func (m *BatchUpdateTreesRequest) XXX_Size() int {
+	return xxx_messageInfo_BatchUpdateTreesRequest.Size(m)
+}
+func (m *BatchUpdateTreesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchUpdateTreesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchUpdateTreesRequest proto.InternalMessageInfo
+
+func (m *BatchUpdateTreesRequest) GetRequests() []*UpdateTreeRequest {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+// BatchUpdateTrees response.
+type BatchUpdateTreesResponse struct {
+	// Updated trees, in the order of the requests.
+	Trees                []*Tree  `protobuf:"bytes,1,rep,name=trees,proto3" json:"trees,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BatchUpdateTreesResponse) Reset()         { *m = BatchUpdateTreesResponse{} }
+func (m *BatchUpdateTreesResponse) String() string { return proto.CompactTextString(m) }
+func (*BatchUpdateTreesResponse) ProtoMessage()    {}
+func (*BatchUpdateTreesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{9}
+}
+
+func (m *BatchUpdateTreesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchUpdateTreesResponse.Unmarshal(m, b)
+}
+func (m *BatchUpdateTreesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchUpdateTreesResponse.Marshal(b, m, deterministic)
+}
+func (m *BatchUpdateTreesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchUpdateTreesResponse.Merge(m, src)
+}
+func (m *BatchUpdateTreesResponse) XXX_Size() int {
+	return xxx_messageInfo_BatchUpdateTreesResponse.Size(m)
+}
+func (m *BatchUpdateTreesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchUpdateTreesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchUpdateTreesResponse proto.InternalMessageInfo
+
+func (m *BatchUpdateTreesResponse) GetTrees() []*Tree {
+	if m != nil {
+		return m.Trees
+	}
+	return nil
+}
+
 // DeleteTree request.
 type DeleteTreeRequest struct {
 	// ID of the tree to delete.
@@ -268,7 +479,7 @@ func (m *DeleteTreeRequest) Reset()         { *m = DeleteTreeRequest{} }
 func (m *DeleteTreeRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteTreeRequest) ProtoMessage()    {}
 func (*DeleteTreeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_aac35e28a5dd9ee3, []int{5}
+	return fileDescriptor_aac35e28a5dd9ee3, []int{10}
 }
 
 func (m *DeleteTreeRequest) XXX_Unmarshal(b []byte) error {
@@ -309,7 +520,7 @@ func (m *UndeleteTreeRequest) Reset()         { *m = UndeleteTreeRequest{} }
 func (m *UndeleteTreeRequest) String() string { return proto.CompactTextString(m) }
 func (*UndeleteTreeRequest) ProtoMessage()    {}
 func (*UndeleteTreeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_aac35e28a5dd9ee3, []int{6}
+	return fileDescriptor_aac35e28a5dd9ee3, []int{11}
 }
 
 func (m *UndeleteTreeRequest) XXX_Unmarshal(b []byte) error {
@@ -324,68 +535,867 @@ func (m *UndeleteTreeRequest) XXX_Merge(src proto.Message) {
 func (m *UndeleteTreeRequest) XXX_Size() int {
 	return xxx_messageInfo_UndeleteTreeRequest.Size(m)
 }
-func (m *UndeleteTreeRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_UndeleteTreeRequest.DiscardUnknown(m)
+func (m *UndeleteTreeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UndeleteTreeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UndeleteTreeRequest proto.InternalMessageInfo
+
+func (m *UndeleteTreeRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+// A named set of tree settings, from which trees with the same configuration
+// can be created, e.g. by createtree --template.
+type TreeTemplate struct {
+	// Name of the template, which identifies it. Must be 1 to 63 lowercase
+	// letters, digits, hyphens or underscores, starting with a letter or digit.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Human-readable description of the template.
+	Description string `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// Settings of trees created from the template. System-generated fields,
+	// tree_id and keys must not be set.
+	Tree *Tree `protobuf:"bytes,3,opt,name=tree,proto3" json:"tree,omitempty"`
+	// Describes how the private keys of trees created from the template should
+	// be generated.
+	KeySpec              *keyspb.Specification `protobuf:"bytes,4,opt,name=key_spec,json=keySpec,proto3" json:"key_spec,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *TreeTemplate) Reset()         { *m = TreeTemplate{} }
+func (m *TreeTemplate) String() string { return proto.CompactTextString(m) }
+func (*TreeTemplate) ProtoMessage()    {}
+func (*TreeTemplate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{12}
+}
+
+func (m *TreeTemplate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TreeTemplate.Unmarshal(m, b)
+}
+func (m *TreeTemplate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TreeTemplate.Marshal(b, m, deterministic)
+}
+func (m *TreeTemplate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TreeTemplate.Merge(m, src)
+}
+func (m *TreeTemplate) XXX_Size() int {
+	return xxx_messageInfo_TreeTemplate.Size(m)
+}
+func (m *TreeTemplate) XXX_DiscardUnknown() {
+	xxx_messageInfo_TreeTemplate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TreeTemplate proto.InternalMessageInfo
+
+func (m *TreeTemplate) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TreeTemplate) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *TreeTemplate) GetTree() *Tree {
+	if m != nil {
+		return m.Tree
+	}
+	return nil
+}
+
+func (m *TreeTemplate) GetKeySpec() *keyspb.Specification {
+	if m != nil {
+		return m.KeySpec
+	}
+	return nil
+}
+
+// CreateTreeTemplate request.
+type CreateTreeTemplateRequest struct {
+	// Template to be created.
+	Template             *TreeTemplate `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *CreateTreeTemplateRequest) Reset()         { *m = CreateTreeTemplateRequest{} }
+func (m *CreateTreeTemplateRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateTreeTemplateRequest) ProtoMessage()    {}
+func (*CreateTreeTemplateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{13}
+}
+
+func (m *CreateTreeTemplateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreateTreeTemplateRequest.Unmarshal(m, b)
+}
+func (m *CreateTreeTemplateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreateTreeTemplateRequest.Marshal(b, m, deterministic)
+}
+func (m *CreateTreeTemplateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreateTreeTemplateRequest.Merge(m, src)
+}
+func (m *CreateTreeTemplateRequest) XXX_Size() int {
+	return xxx_messageInfo_CreateTreeTemplateRequest.Size(m)
+}
+func (m *CreateTreeTemplateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreateTreeTemplateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreateTreeTemplateRequest proto.InternalMessageInfo
+
+func (m *CreateTreeTemplateRequest) GetTemplate() *TreeTemplate {
+	if m != nil {
+		return m.Template
+	}
+	return nil
+}
+
+// GetTreeTemplate request.
+type GetTreeTemplateRequest struct {
+	// Name of the template to retrieve.
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTreeTemplateRequest) Reset()         { *m = GetTreeTemplateRequest{} }
+func (m *GetTreeTemplateRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTreeTemplateRequest) ProtoMessage()    {}
+func (*GetTreeTemplateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{14}
+}
+
+func (m *GetTreeTemplateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTreeTemplateRequest.Unmarshal(m, b)
+}
+func (m *GetTreeTemplateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTreeTemplateRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTreeTemplateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTreeTemplateRequest.Merge(m, src)
+}
+func (m *GetTreeTemplateRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTreeTemplateRequest.Size(m)
+}
+func (m *GetTreeTemplateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTreeTemplateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTreeTemplateRequest proto.InternalMessageInfo
+
+func (m *GetTreeTemplateRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// ListTreeTemplates request.
+type ListTreeTemplatesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListTreeTemplatesRequest) Reset()         { *m = ListTreeTemplatesRequest{} }
+func (m *ListTreeTemplatesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTreeTemplatesRequest) ProtoMessage()    {}
+func (*ListTreeTemplatesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{15}
+}
+
+func (m *ListTreeTemplatesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTreeTemplatesRequest.Unmarshal(m, b)
+}
+func (m *ListTreeTemplatesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTreeTemplatesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListTreeTemplatesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTreeTemplatesRequest.Merge(m, src)
+}
+func (m *ListTreeTemplatesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListTreeTemplatesRequest.Size(m)
+}
+func (m *ListTreeTemplatesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTreeTemplatesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTreeTemplatesRequest proto.InternalMessageInfo
+
+// ListTreeTemplates response.
+type ListTreeTemplatesResponse struct {
+	// All templates, in order of name.
+	Templates            []*TreeTemplate `protobuf:"bytes,1,rep,name=templates,proto3" json:"templates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *ListTreeTemplatesResponse) Reset()         { *m = ListTreeTemplatesResponse{} }
+func (m *ListTreeTemplatesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListTreeTemplatesResponse) ProtoMessage()    {}
+func (*ListTreeTemplatesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{16}
+}
+
+func (m *ListTreeTemplatesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTreeTemplatesResponse.Unmarshal(m, b)
+}
+func (m *ListTreeTemplatesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTreeTemplatesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListTreeTemplatesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTreeTemplatesResponse.Merge(m, src)
+}
+func (m *ListTreeTemplatesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListTreeTemplatesResponse.Size(m)
+}
+func (m *ListTreeTemplatesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTreeTemplatesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTreeTemplatesResponse proto.InternalMessageInfo
+
+func (m *ListTreeTemplatesResponse) GetTemplates() []*TreeTemplate {
+	if m != nil {
+		return m.Templates
+	}
+	return nil
+}
+
+// UpdateTreeTemplate request.
+type UpdateTreeTemplateRequest struct {
+	// Template to be updated. Replaces the existing template of the same name.
+	Template             *TreeTemplate `protobuf:"bytes,1,opt,name=template,proto3" json:"template,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *UpdateTreeTemplateRequest) Reset()         { *m = UpdateTreeTemplateRequest{} }
+func (m *UpdateTreeTemplateRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateTreeTemplateRequest) ProtoMessage()    {}
+func (*UpdateTreeTemplateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{17}
+}
+
+func (m *UpdateTreeTemplateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateTreeTemplateRequest.Unmarshal(m, b)
+}
+func (m *UpdateTreeTemplateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateTreeTemplateRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdateTreeTemplateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateTreeTemplateRequest.Merge(m, src)
+}
+func (m *UpdateTreeTemplateRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateTreeTemplateRequest.Size(m)
+}
+func (m *UpdateTreeTemplateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateTreeTemplateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateTreeTemplateRequest proto.InternalMessageInfo
+
+func (m *UpdateTreeTemplateRequest) GetTemplate() *TreeTemplate {
+	if m != nil {
+		return m.Template
+	}
+	return nil
+}
+
+// DeleteTreeTemplate request.
+type DeleteTreeTemplateRequest struct {
+	// Name of the template to delete.
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteTreeTemplateRequest) Reset()         { *m = DeleteTreeTemplateRequest{} }
+func (m *DeleteTreeTemplateRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteTreeTemplateRequest) ProtoMessage()    {}
+func (*DeleteTreeTemplateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{18}
+}
+
+func (m *DeleteTreeTemplateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteTreeTemplateRequest.Unmarshal(m, b)
+}
+func (m *DeleteTreeTemplateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteTreeTemplateRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteTreeTemplateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteTreeTemplateRequest.Merge(m, src)
+}
+func (m *DeleteTreeTemplateRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteTreeTemplateRequest.Size(m)
+}
+func (m *DeleteTreeTemplateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteTreeTemplateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteTreeTemplateRequest proto.InternalMessageInfo
+
+func (m *DeleteTreeTemplateRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+// A signed record of the settings a tree was created with, which lets clients
+// check that the tree they audit wasn't later reconfigured.
+type TreeAttestation struct {
+	// Serialized Tree holding the settings of the tree at creation: its
+	// tree_id, create_time, public_key and the fields which are readonly after
+	// creation. Mutable fields, such as tree_state and display_name, and the
+	// private_key are not set.
+	Tree []byte `protobuf:"bytes,1,opt,name=tree,proto3" json:"tree,omitempty"`
+	// Signature over tree by the tree's private key, using the tree's
+	// hash_algorithm unless the key is Ed25519.
+	Signature            []byte   `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TreeAttestation) Reset()         { *m = TreeAttestation{} }
+func (m *TreeAttestation) String() string { return proto.CompactTextString(m) }
+func (*TreeAttestation) ProtoMessage()    {}
+func (*TreeAttestation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{19}
+}
+
+func (m *TreeAttestation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TreeAttestation.Unmarshal(m, b)
+}
+func (m *TreeAttestation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TreeAttestation.Marshal(b, m, deterministic)
+}
+func (m *TreeAttestation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TreeAttestation.Merge(m, src)
+}
+func (m *TreeAttestation) XXX_Size() int {
+	return xxx_messageInfo_TreeAttestation.Size(m)
+}
+func (m *TreeAttestation) XXX_DiscardUnknown() {
+	xxx_messageInfo_TreeAttestation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TreeAttestation proto.InternalMessageInfo
+
+func (m *TreeAttestation) GetTree() []byte {
+	if m != nil {
+		return m.Tree
+	}
+	return nil
+}
+
+func (m *TreeAttestation) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+// GetTreeAttestation request.
+type GetTreeAttestationRequest struct {
+	// ID of the tree whose attestation to retrieve.
+	TreeId               int64    `protobuf:"varint,1,opt,name=tree_id,json=treeId,proto3" json:"tree_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetTreeAttestationRequest) Reset()         { *m = GetTreeAttestationRequest{} }
+func (m *GetTreeAttestationRequest) String() string { return proto.CompactTextString(m) }
+func (*GetTreeAttestationRequest) ProtoMessage()    {}
+func (*GetTreeAttestationRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{20}
+}
+
+func (m *GetTreeAttestationRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetTreeAttestationRequest.Unmarshal(m, b)
+}
+func (m *GetTreeAttestationRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetTreeAttestationRequest.Marshal(b, m, deterministic)
+}
+func (m *GetTreeAttestationRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetTreeAttestationRequest.Merge(m, src)
+}
+func (m *GetTreeAttestationRequest) XXX_Size() int {
+	return xxx_messageInfo_GetTreeAttestationRequest.Size(m)
+}
+func (m *GetTreeAttestationRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetTreeAttestationRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetTreeAttestationRequest proto.InternalMessageInfo
+
+func (m *GetTreeAttestationRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+// RewrapLeafDataKey request.
+type RewrapLeafDataKeyRequest struct {
+	// ID of the tree whose data key to rewrap.
+	TreeId               int64    `protobuf:"varint,1,opt,name=tree_id,json=treeId,proto3" json:"tree_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RewrapLeafDataKeyRequest) Reset()         { *m = RewrapLeafDataKeyRequest{} }
+func (m *RewrapLeafDataKeyRequest) String() string { return proto.CompactTextString(m) }
+func (*RewrapLeafDataKeyRequest) ProtoMessage()    {}
+func (*RewrapLeafDataKeyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{21}
+}
+
+func (m *RewrapLeafDataKeyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RewrapLeafDataKeyRequest.Unmarshal(m, b)
+}
+func (m *RewrapLeafDataKeyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RewrapLeafDataKeyRequest.Marshal(b, m, deterministic)
+}
+func (m *RewrapLeafDataKeyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RewrapLeafDataKeyRequest.Merge(m, src)
+}
+func (m *RewrapLeafDataKeyRequest) XXX_Size() int {
+	return xxx_messageInfo_RewrapLeafDataKeyRequest.Size(m)
+}
+func (m *RewrapLeafDataKeyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RewrapLeafDataKeyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RewrapLeafDataKeyRequest proto.InternalMessageInfo
+
+func (m *RewrapLeafDataKeyRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+// CompactTreeStorage request.
+type CompactTreeStorageRequest struct {
+	// ID of the tree whose storage to compact.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId,proto3" json:"tree_id,omitempty"`
+	// Whether to also run the backend-specific optimization of the tables that
+	// hold the tree once its data is removed, e.g. OPTIMIZE TABLE for MySQL.
+	// Optimization covers the data of all trees in those tables, and may take
+	// long for large deployments.
+	Optimize             bool     `protobuf:"varint,2,opt,name=optimize,proto3" json:"optimize,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CompactTreeStorageRequest) Reset()         { *m = CompactTreeStorageRequest{} }
+func (m *CompactTreeStorageRequest) String() string { return proto.CompactTextString(m) }
+func (*CompactTreeStorageRequest) ProtoMessage()    {}
+func (*CompactTreeStorageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{22}
+}
+
+func (m *CompactTreeStorageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompactTreeStorageRequest.Unmarshal(m, b)
+}
+func (m *CompactTreeStorageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompactTreeStorageRequest.Marshal(b, m, deterministic)
+}
+func (m *CompactTreeStorageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompactTreeStorageRequest.Merge(m, src)
+}
+func (m *CompactTreeStorageRequest) XXX_Size() int {
+	return xxx_messageInfo_CompactTreeStorageRequest.Size(m)
+}
+func (m *CompactTreeStorageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompactTreeStorageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompactTreeStorageRequest proto.InternalMessageInfo
+
+func (m *CompactTreeStorageRequest) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *CompactTreeStorageRequest) GetOptimize() bool {
+	if m != nil {
+		return m.Optimize
+	}
+	return false
+}
+
+// CompactTreeStorage response.
+type CompactTreeStorageResponse struct {
+	// Number of bytes of data removed from storage. Space returned to the
+	// operating system by optimization isn't included.
+	BytesReclaimed       int64    `protobuf:"varint,1,opt,name=bytes_reclaimed,json=bytesReclaimed,proto3" json:"bytes_reclaimed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CompactTreeStorageResponse) Reset()         { *m = CompactTreeStorageResponse{} }
+func (m *CompactTreeStorageResponse) String() string { return proto.CompactTextString(m) }
+func (*CompactTreeStorageResponse) ProtoMessage()    {}
+func (*CompactTreeStorageResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{23}
+}
+
+func (m *CompactTreeStorageResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompactTreeStorageResponse.Unmarshal(m, b)
+}
+func (m *CompactTreeStorageResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompactTreeStorageResponse.Marshal(b, m, deterministic)
+}
+func (m *CompactTreeStorageResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompactTreeStorageResponse.Merge(m, src)
+}
+func (m *CompactTreeStorageResponse) XXX_Size() int {
+	return xxx_messageInfo_CompactTreeStorageResponse.Size(m)
+}
+func (m *CompactTreeStorageResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompactTreeStorageResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompactTreeStorageResponse proto.InternalMessageInfo
+
+func (m *CompactTreeStorageResponse) GetBytesReclaimed() int64 {
+	if m != nil {
+		return m.BytesReclaimed
+	}
+	return 0
+}
+
+// GetRootAges request.
+type GetRootAgesRequest struct {
+	// Thresholds to count the trees whose latest signed root is older than.
+	// Each must be positive.
+	Thresholds           []*duration.Duration `protobuf:"bytes,1,rep,name=thresholds,proto3" json:"thresholds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *GetRootAgesRequest) Reset()         { *m = GetRootAgesRequest{} }
+func (m *GetRootAgesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRootAgesRequest) ProtoMessage()    {}
+func (*GetRootAgesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{24}
+}
+
+func (m *GetRootAgesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRootAgesRequest.Unmarshal(m, b)
+}
+func (m *GetRootAgesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRootAgesRequest.Marshal(b, m, deterministic)
+}
+func (m *GetRootAgesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRootAgesRequest.Merge(m, src)
+}
+func (m *GetRootAgesRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRootAgesRequest.Size(m)
+}
+func (m *GetRootAgesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRootAgesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRootAgesRequest proto.InternalMessageInfo
+
+func (m *GetRootAgesRequest) GetThresholds() []*duration.Duration {
+	if m != nil {
+		return m.Thresholds
+	}
+	return nil
+}
+
+// The age of the latest signed root of a log.
+type TreeRootAge struct {
+	// ID of the log.
+	TreeId int64 `protobuf:"varint,1,opt,name=tree_id,json=treeId,proto3" json:"tree_id,omitempty"`
+	// Time elapsed since the timestamp of the latest signed root of the log.
+	// Unset if the log doesn't have a signed root yet.
+	Age                  *duration.Duration `protobuf:"bytes,2,opt,name=age,proto3" json:"age,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *TreeRootAge) Reset()         { *m = TreeRootAge{} }
+func (m *TreeRootAge) String() string { return proto.CompactTextString(m) }
+func (*TreeRootAge) ProtoMessage()    {}
+func (*TreeRootAge) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{25}
+}
+
+func (m *TreeRootAge) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TreeRootAge.Unmarshal(m, b)
+}
+func (m *TreeRootAge) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TreeRootAge.Marshal(b, m, deterministic)
+}
+func (m *TreeRootAge) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TreeRootAge.Merge(m, src)
+}
+func (m *TreeRootAge) XXX_Size() int {
+	return xxx_messageInfo_TreeRootAge.Size(m)
+}
+func (m *TreeRootAge) XXX_DiscardUnknown() {
+	xxx_messageInfo_TreeRootAge.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TreeRootAge proto.InternalMessageInfo
+
+func (m *TreeRootAge) GetTreeId() int64 {
+	if m != nil {
+		return m.TreeId
+	}
+	return 0
+}
+
+func (m *TreeRootAge) GetAge() *duration.Duration {
+	if m != nil {
+		return m.Age
+	}
+	return nil
+}
+
+// The number of logs whose latest signed root is older than a threshold.
+type RootAgeCount struct {
+	// Threshold the age of roots was compared with.
+	Threshold *duration.Duration `protobuf:"bytes,1,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	// Number of logs whose latest signed root is older than threshold, including
+	// those without a signed root.
+	TreeCount            int64    `protobuf:"varint,2,opt,name=tree_count,json=treeCount,proto3" json:"tree_count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RootAgeCount) Reset()         { *m = RootAgeCount{} }
+func (m *RootAgeCount) String() string { return proto.CompactTextString(m) }
+func (*RootAgeCount) ProtoMessage()    {}
+func (*RootAgeCount) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{26}
+}
+
+func (m *RootAgeCount) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RootAgeCount.Unmarshal(m, b)
+}
+func (m *RootAgeCount) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RootAgeCount.Marshal(b, m, deterministic)
+}
+func (m *RootAgeCount) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RootAgeCount.Merge(m, src)
+}
+func (m *RootAgeCount) XXX_Size() int {
+	return xxx_messageInfo_RootAgeCount.Size(m)
+}
+func (m *RootAgeCount) XXX_DiscardUnknown() {
+	xxx_messageInfo_RootAgeCount.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RootAgeCount proto.InternalMessageInfo
+
+func (m *RootAgeCount) GetThreshold() *duration.Duration {
+	if m != nil {
+		return m.Threshold
+	}
+	return nil
+}
+
+func (m *RootAgeCount) GetTreeCount() int64 {
+	if m != nil {
+		return m.TreeCount
+	}
+	return 0
+}
+
+// GetRootAges response.
+type GetRootAgesResponse struct {
+	// Ages of the latest signed roots of all active and draining logs, ordered
+	// by tree_id.
+	Trees []*TreeRootAge `protobuf:"bytes,1,rep,name=trees,proto3" json:"trees,omitempty"`
+	// Number of logs older than each requested threshold, in request order.
+	OlderThan            []*RootAgeCount `protobuf:"bytes,2,rep,name=older_than,json=olderThan,proto3" json:"older_than,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *GetRootAgesResponse) Reset()         { *m = GetRootAgesResponse{} }
+func (m *GetRootAgesResponse) String() string { return proto.CompactTextString(m) }
+func (*GetRootAgesResponse) ProtoMessage()    {}
+func (*GetRootAgesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_aac35e28a5dd9ee3, []int{27}
+}
+
+func (m *GetRootAgesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRootAgesResponse.Unmarshal(m, b)
+}
+func (m *GetRootAgesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRootAgesResponse.Marshal(b, m, deterministic)
+}
+func (m *GetRootAgesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRootAgesResponse.Merge(m, src)
+}
+func (m *GetRootAgesResponse) XXX_Size() int {
+	return xxx_messageInfo_GetRootAgesResponse.Size(m)
+}
+func (m *GetRootAgesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRootAgesResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_UndeleteTreeRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetRootAgesResponse proto.InternalMessageInfo
 
-func (m *UndeleteTreeRequest) GetTreeId() int64 {
+func (m *GetRootAgesResponse) GetTrees() []*TreeRootAge {
 	if m != nil {
-		return m.TreeId
+		return m.Trees
 	}
-	return 0
+	return nil
+}
+
+func (m *GetRootAgesResponse) GetOlderThan() []*RootAgeCount {
+	if m != nil {
+		return m.OlderThan
+	}
+	return nil
 }
 
 func init() {
 	proto.RegisterType((*ListTreesRequest)(nil), "trillian.ListTreesRequest")
 	proto.RegisterType((*ListTreesResponse)(nil), "trillian.ListTreesResponse")
+	proto.RegisterType((*ListSoftDeletedTreesRequest)(nil), "trillian.ListSoftDeletedTreesRequest")
+	proto.RegisterType((*SoftDeletedTree)(nil), "trillian.SoftDeletedTree")
+	proto.RegisterType((*ListSoftDeletedTreesResponse)(nil), "trillian.ListSoftDeletedTreesResponse")
 	proto.RegisterType((*GetTreeRequest)(nil), "trillian.GetTreeRequest")
 	proto.RegisterType((*CreateTreeRequest)(nil), "trillian.CreateTreeRequest")
 	proto.RegisterType((*UpdateTreeRequest)(nil), "trillian.UpdateTreeRequest")
+	proto.RegisterType((*BatchUpdateTreesRequest)(nil), "trillian.BatchUpdateTreesRequest")
+	proto.RegisterType((*BatchUpdateTreesResponse)(nil), "trillian.BatchUpdateTreesResponse")
 	proto.RegisterType((*DeleteTreeRequest)(nil), "trillian.DeleteTreeRequest")
 	proto.RegisterType((*UndeleteTreeRequest)(nil), "trillian.UndeleteTreeRequest")
+	proto.RegisterType((*TreeTemplate)(nil), "trillian.TreeTemplate")
+	proto.RegisterType((*CreateTreeTemplateRequest)(nil), "trillian.CreateTreeTemplateRequest")
+	proto.RegisterType((*GetTreeTemplateRequest)(nil), "trillian.GetTreeTemplateRequest")
+	proto.RegisterType((*ListTreeTemplatesRequest)(nil), "trillian.ListTreeTemplatesRequest")
+	proto.RegisterType((*ListTreeTemplatesResponse)(nil), "trillian.ListTreeTemplatesResponse")
+	proto.RegisterType((*UpdateTreeTemplateRequest)(nil), "trillian.UpdateTreeTemplateRequest")
+	proto.RegisterType((*DeleteTreeTemplateRequest)(nil), "trillian.DeleteTreeTemplateRequest")
+	proto.RegisterType((*TreeAttestation)(nil), "trillian.TreeAttestation")
+	proto.RegisterType((*GetTreeAttestationRequest)(nil), "trillian.GetTreeAttestationRequest")
+	proto.RegisterType((*RewrapLeafDataKeyRequest)(nil), "trillian.RewrapLeafDataKeyRequest")
+	proto.RegisterType((*CompactTreeStorageRequest)(nil), "trillian.CompactTreeStorageRequest")
+	proto.RegisterType((*CompactTreeStorageResponse)(nil), "trillian.CompactTreeStorageResponse")
+	proto.RegisterType((*GetRootAgesRequest)(nil), "trillian.GetRootAgesRequest")
+	proto.RegisterType((*TreeRootAge)(nil), "trillian.TreeRootAge")
+	proto.RegisterType((*RootAgeCount)(nil), "trillian.RootAgeCount")
+	proto.RegisterType((*GetRootAgesResponse)(nil), "trillian.GetRootAgesResponse")
 }
 
 func init() { proto.RegisterFile("trillian_admin_api.proto", fileDescriptor_aac35e28a5dd9ee3) }
 
 var fileDescriptor_aac35e28a5dd9ee3 = []byte{
-	// 548 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xe1, 0x6a, 0x13, 0x4d,
-	0x14, 0xfd, 0xb6, 0x2d, 0x6d, 0xbe, 0x9b, 0x1a, 0xcc, 0x94, 0x62, 0xba, 0x56, 0x8c, 0xa3, 0x42,
-	0x8d, 0xb2, 0x6b, 0x23, 0x22, 0x54, 0xfc, 0xd1, 0x2a, 0x15, 0x41, 0x21, 0xac, 0x29, 0x82, 0x20,
-	0xcb, 0x66, 0xf7, 0x26, 0x1d, 0x93, 0xec, 0xac, 0x3b, 0x13, 0x25, 0x88, 0x7f, 0x7c, 0x05, 0x5f,
-	0xc6, 0xf7, 0xf0, 0x15, 0x7c, 0x10, 0x99, 0xd9, 0xd9, 0xee, 0xa6, 0xdb, 0x48, 0xf1, 0xd7, 0xee,
-	0xdc, 0x73, 0xef, 0x3d, 0x77, 0xce, 0xb9, 0x0c, 0xb4, 0x64, 0xca, 0x26, 0x13, 0x16, 0xc4, 0x7e,
-	0x10, 0x4d, 0x59, 0xec, 0x07, 0x09, 0x73, 0x92, 0x94, 0x4b, 0x4e, 0x6a, 0x39, 0x62, 0x37, 0xf2,
-	0xbf, 0x0c, 0xb1, 0xed, 0x30, 0x9d, 0x27, 0x92, 0xbb, 0x63, 0x9c, 0x8b, 0x64, 0x60, 0x3e, 0x06,
-	0xdb, 0x1d, 0x71, 0x3e, 0x9a, 0xa0, 0x1b, 0x24, 0xcc, 0x0d, 0xe2, 0x98, 0xcb, 0x40, 0x32, 0x1e,
-	0x0b, 0x83, 0xb6, 0x0d, 0xaa, 0x4f, 0x83, 0xd9, 0xd0, 0x1d, 0x32, 0x9c, 0x44, 0xfe, 0x34, 0x10,
-	0xe3, 0x2c, 0x83, 0x3e, 0x86, 0xab, 0xaf, 0x99, 0x90, 0xfd, 0x14, 0x51, 0x78, 0xf8, 0x69, 0x86,
-	0x42, 0x92, 0x5b, 0xb0, 0x29, 0x4e, 0xf9, 0x17, 0x3f, 0xc2, 0x09, 0x4a, 0x8c, 0x5a, 0x56, 0xdb,
-	0xda, 0xab, 0x79, 0x75, 0x15, 0x7b, 0x91, 0x85, 0xe8, 0x13, 0x68, 0x96, 0xca, 0x44, 0xc2, 0x63,
-	0x81, 0x84, 0xc2, 0x9a, 0x4c, 0x11, 0x5b, 0x56, 0x7b, 0x75, 0xaf, 0xde, 0x6d, 0x38, 0x67, 0xd7,
-	0x50, 0x69, 0x9e, 0xc6, 0xe8, 0x3d, 0x68, 0xbc, 0x44, 0x5d, 0x97, 0xb3, 0x5d, 0x83, 0x0d, 0x85,
-	0xf8, 0x2c, 0x23, 0x5a, 0xf5, 0xd6, 0xd5, 0xf1, 0x55, 0x44, 0x19, 0x34, 0x9f, 0xa7, 0x18, 0x48,
-	0x2c, 0x67, 0x17, 0x1c, 0xd6, 0x32, 0x0e, 0xf2, 0x10, 0x6a, 0x63, 0x9c, 0xfb, 0x22, 0xc1, 0xb0,
-	0xb5, 0xa2, 0xf3, 0xb6, 0x1d, 0x23, 0xda, 0xdb, 0x04, 0x43, 0x36, 0x64, 0xa1, 0x56, 0xc9, 0xdb,
-	0x18, 0xe3, 0x5c, 0x45, 0xa8, 0x84, 0xe6, 0x49, 0x12, 0xfd, 0x03, 0xd5, 0x53, 0xa8, 0xcf, 0x74,
-	0xa1, 0xd6, 0xd4, 0xb0, 0xd9, 0x4e, 0x26, 0xbb, 0x93, 0xcb, 0xee, 0x1c, 0x2b, 0xd9, 0xdf, 0x04,
-	0x62, 0xec, 0x41, 0x96, 0xae, 0xfe, 0xe9, 0x03, 0x68, 0x66, 0x7a, 0x5e, 0x4a, 0x0e, 0x07, 0xb6,
-	0x4e, 0xe2, 0xe8, 0xd2, 0xf9, 0xdd, 0x9f, 0x6b, 0x70, 0xa5, 0x6f, 0x46, 0x3e, 0x54, 0xbb, 0x46,
-	0x8e, 0xe1, 0xff, 0x33, 0xd3, 0x88, 0x5d, 0xdc, 0xe7, 0xfc, 0x02, 0xd8, 0xd7, 0x2f, 0xc4, 0x32,
-	0x97, 0xe9, 0x7f, 0xe4, 0x1d, 0x6c, 0x18, 0x0f, 0x49, 0xab, 0xc8, 0x5c, 0xb4, 0xd5, 0x3e, 0xa7,
-	0x17, 0xa5, 0xdf, 0x7f, 0xfd, 0xfe, 0xb1, 0xb2, 0x4b, 0x6c, 0xf7, 0xf3, 0xfe, 0x00, 0x65, 0xb0,
-	0xef, 0xaa, 0x39, 0x85, 0xfb, 0xd5, 0x4c, 0xff, 0xac, 0xf3, 0x8d, 0xf4, 0x01, 0x0a, 0xc7, 0x49,
-	0x69, 0x8a, 0xca, 0x1e, 0x54, 0xda, 0xef, 0xe8, 0xf6, 0x5b, 0xb4, 0xb1, 0xd8, 0xfe, 0xc0, 0xea,
-	0x10, 0x04, 0x28, 0xcc, 0x2d, 0x77, 0xad, 0x58, 0x5e, 0xe9, 0xda, 0xd1, 0x5d, 0xef, 0x74, 0x6f,
-	0x5e, 0x34, 0xb4, 0x53, 0x4c, 0xae, 0x68, 0x3e, 0x00, 0x14, 0x6e, 0x96, 0x69, 0x2a, 0x1e, 0x2f,
-	0xd3, 0xa6, 0xf3, 0x37, 0x6d, 0x3e, 0xc2, 0x66, 0xd9, 0x7e, 0x72, 0xa3, 0x74, 0x8f, 0xea, 0x5a,
-	0x54, 0x28, 0xee, 0x6b, 0x8a, 0xbb, 0x9d, 0xdb, 0xcb, 0x29, 0x0e, 0x66, 0xa6, 0xcf, 0x51, 0x0f,
-	0x76, 0x42, 0x3e, 0xcd, 0xb7, 0x78, 0xf1, 0x35, 0x3a, 0xda, 0x5e, 0x58, 0xaa, 0xc3, 0x84, 0xf5,
-	0x54, 0xb8, 0x67, 0xbd, 0xb7, 0x47, 0x4c, 0x9e, 0xce, 0x06, 0x4e, 0xc8, 0xa7, 0xae, 0x79, 0x77,
-	0xf2, 0xd2, 0xc1, 0xba, 0xae, 0x7d, 0xf4, 0x27, 0x00, 0x00, 0xff, 0xff, 0x58, 0xc1, 0xa5, 0x85,
-	0xff, 0x04, 0x00, 0x00,
+	// 1225 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xeb, 0x6e, 0x1b, 0xc5,
+	0x17, 0x8f, 0x9b, 0xfe, 0x1b, 0xfb, 0xd8, 0xff, 0xa4, 0x99, 0x90, 0xd4, 0xde, 0x24, 0x90, 0x4e,
+	0x52, 0x28, 0x49, 0x65, 0xd3, 0xb4, 0x55, 0x45, 0x11, 0x12, 0xb9, 0xb4, 0xa5, 0x22, 0x25, 0x61,
+	0x9d, 0x80, 0x40, 0x02, 0x6b, 0xbc, 0x3b, 0xb6, 0x87, 0x78, 0x2f, 0xec, 0x8e, 0xa9, 0x0c, 0xe2,
+	0x0b, 0x12, 0x4f, 0xc0, 0x37, 0x5e, 0x8b, 0x57, 0xe0, 0x39, 0x10, 0x9a, 0xd9, 0xd9, 0xbb, 0xd7,
+	0xb6, 0x10, 0x9f, 0xbc, 0x9e, 0xf3, 0x9b, 0x73, 0xdf, 0xdf, 0x39, 0x0b, 0x75, 0xee, 0xb1, 0xe1,
+	0x90, 0x11, 0xbb, 0x43, 0x4c, 0x8b, 0xd9, 0x1d, 0xe2, 0xb2, 0xa6, 0xeb, 0x39, 0xdc, 0x41, 0xe5,
+	0x50, 0xa2, 0x2d, 0x87, 0x4f, 0x81, 0x44, 0xd3, 0x0c, 0x6f, 0xec, 0x72, 0xa7, 0x75, 0x4d, 0xc7,
+	0xbe, 0xdb, 0x55, 0x3f, 0x4a, 0xb6, 0xd5, 0x77, 0x9c, 0xfe, 0x90, 0xb6, 0x88, 0xcb, 0x5a, 0xc4,
+	0xb6, 0x1d, 0x4e, 0x38, 0x73, 0x6c, 0x5f, 0x49, 0xdf, 0x56, 0x52, 0xf9, 0xaf, 0x3b, 0xea, 0xb5,
+	0xcc, 0x91, 0x27, 0x01, 0x4a, 0xbe, 0x99, 0x95, 0x53, 0xcb, 0xe5, 0x63, 0x25, 0xdc, 0xc9, 0x0a,
+	0x7b, 0x8c, 0x0e, 0xcd, 0x8e, 0x45, 0xfc, 0xeb, 0x00, 0x81, 0x9f, 0xc0, 0xed, 0x33, 0xe6, 0xf3,
+	0x4b, 0x8f, 0x52, 0x5f, 0xa7, 0x3f, 0x8c, 0xa8, 0xcf, 0xd1, 0x5d, 0xa8, 0xf9, 0x03, 0xe7, 0x4d,
+	0xc7, 0xa4, 0x43, 0xca, 0xa9, 0x59, 0x2f, 0xed, 0x94, 0xee, 0x97, 0xf5, 0xaa, 0x38, 0x3b, 0x0d,
+	0x8e, 0xf0, 0x53, 0x58, 0x4d, 0x5c, 0xf3, 0x5d, 0xc7, 0xf6, 0x29, 0xc2, 0x70, 0x93, 0x7b, 0x94,
+	0xd6, 0x4b, 0x3b, 0x8b, 0xf7, 0xab, 0x87, 0xcb, 0xcd, 0x28, 0x07, 0x02, 0xa6, 0x4b, 0x19, 0xde,
+	0x86, 0x4d, 0x71, 0xb1, 0xed, 0xf4, 0xb8, 0xd2, 0x95, 0x34, 0x8d, 0x7f, 0x2b, 0xc1, 0x4a, 0x46,
+	0x96, 0x50, 0x5b, 0x2a, 0x52, 0x8b, 0x3e, 0x87, 0x0d, 0xce, 0x2c, 0xda, 0x19, 0xd9, 0x9c, 0x0d,
+	0x3b, 0x03, 0xe2, 0x99, 0xca, 0xfb, 0xfa, 0x0d, 0x79, 0xab, 0xd1, 0x0c, 0x32, 0xd1, 0x0c, 0x33,
+	0xd1, 0x3c, 0x55, 0x69, 0xd4, 0xd7, 0xc4, 0xc5, 0x2b, 0x71, 0xef, 0x53, 0xe2, 0x99, 0x81, 0x61,
+	0x7c, 0x0e, 0x5b, 0x93, 0xdd, 0x54, 0xa1, 0xb6, 0xe0, 0x7f, 0xc2, 0xae, 0xaf, 0x62, 0x6d, 0xc4,
+	0x4e, 0x65, 0xae, 0xe8, 0x01, 0x0e, 0xbf, 0x0f, 0xcb, 0x2f, 0xa9, 0xcc, 0x57, 0x98, 0xe5, 0x3b,
+	0xb0, 0x24, 0x44, 0x1d, 0x16, 0x24, 0x78, 0x51, 0xbf, 0x25, 0xfe, 0xbe, 0x32, 0x31, 0x83, 0xd5,
+	0x13, 0x8f, 0x12, 0x4e, 0x93, 0xe8, 0x79, 0x92, 0xf0, 0x01, 0x94, 0xaf, 0xe9, 0xb8, 0xe3, 0xbb,
+	0xd4, 0x50, 0x61, 0xaf, 0x37, 0x55, 0xa7, 0xb5, 0x5d, 0x6a, 0xb0, 0x1e, 0x33, 0x82, 0x90, 0x97,
+	0xae, 0xe9, 0x58, 0x9c, 0x60, 0x0e, 0xab, 0x57, 0xae, 0xf9, 0x2f, 0x4c, 0x7d, 0x04, 0xd5, 0x91,
+	0xbc, 0x28, 0x7b, 0x49, 0x59, 0xd3, 0x72, 0x49, 0x7e, 0x21, 0xda, 0xed, 0x35, 0xf1, 0xaf, 0x75,
+	0x08, 0xe0, 0xe2, 0x19, 0xeb, 0x70, 0xe7, 0x98, 0x70, 0x63, 0x10, 0x9b, 0x8e, 0x5a, 0xef, 0x29,
+	0x94, 0xbd, 0xe0, 0x31, 0x4c, 0xed, 0x66, 0x6c, 0x3f, 0xe7, 0xaa, 0x1e, 0x81, 0xf1, 0x27, 0x50,
+	0xcf, 0xeb, 0x54, 0xc5, 0xda, 0x4b, 0x17, 0x2b, 0x1b, 0x91, 0xaa, 0xd0, 0x03, 0x58, 0x0d, 0xea,
+	0x36, 0x57, 0x91, 0x9a, 0xb0, 0x76, 0x65, 0x9b, 0xf3, 0xe3, 0xff, 0x28, 0x41, 0x4d, 0x00, 0x2f,
+	0xa9, 0xe5, 0x0e, 0x09, 0xa7, 0x08, 0xc1, 0x4d, 0x9b, 0x58, 0x41, 0x96, 0x2b, 0xba, 0x7c, 0x46,
+	0x3b, 0x50, 0x35, 0xa9, 0x6f, 0x78, 0xcc, 0x15, 0x65, 0x92, 0x59, 0xad, 0xe8, 0xc9, 0xa3, 0xa8,
+	0x36, 0x8b, 0x73, 0xb6, 0xc1, 0xcd, 0xb9, 0xda, 0xe0, 0x1c, 0x1a, 0x71, 0xc7, 0x85, 0x1e, 0x86,
+	0x21, 0x1d, 0x42, 0x99, 0xab, 0x23, 0xd5, 0x12, 0x1b, 0x69, 0xb3, 0xd1, 0x85, 0x08, 0x87, 0x1f,
+	0xc0, 0x86, 0xea, 0xf6, 0xac, 0xb6, 0x09, 0x61, 0x63, 0x0d, 0xea, 0x21, 0x99, 0x84, 0xf0, 0x88,
+	0x10, 0xbe, 0x80, 0xc6, 0x04, 0x99, 0x2a, 0xec, 0x63, 0xa8, 0x84, 0x26, 0xc3, 0xe2, 0x16, 0xf9,
+	0x16, 0x03, 0x45, 0xb4, 0x71, 0x97, 0xfc, 0x17, 0xd1, 0xb6, 0xa0, 0x11, 0x77, 0xce, 0x3c, 0x01,
+	0x9f, 0xc0, 0x8a, 0x80, 0x1e, 0x71, 0x4e, 0xfd, 0x80, 0xed, 0x05, 0x2c, 0x7a, 0xe9, 0x6a, 0xaa,
+	0x90, 0x5b, 0x50, 0xf1, 0x59, 0xdf, 0x26, 0x7c, 0xe4, 0x05, 0x3c, 0x56, 0xd3, 0xe3, 0x03, 0xfc,
+	0x18, 0x1a, 0x2a, 0xc7, 0x09, 0x3d, 0x33, 0xfb, 0xf0, 0x11, 0xd4, 0x75, 0xfa, 0xc6, 0x23, 0xee,
+	0x19, 0x25, 0xbd, 0x53, 0xc2, 0xc9, 0x67, 0x74, 0x3c, 0xf3, 0xd2, 0x05, 0x34, 0x4e, 0x1c, 0xcb,
+	0x25, 0x86, 0x34, 0xd7, 0xe6, 0x8e, 0x47, 0xfa, 0x33, 0x5b, 0x1e, 0x69, 0x50, 0x76, 0x5c, 0xce,
+	0x2c, 0xf6, 0x53, 0xe0, 0x7d, 0x59, 0x8f, 0xfe, 0xe3, 0xe7, 0xa0, 0x4d, 0xd2, 0xa8, 0xea, 0xfa,
+	0x1e, 0xac, 0x74, 0xc7, 0x9c, 0xfa, 0x1d, 0x8f, 0x1a, 0x43, 0xc2, 0x2c, 0x1a, 0xaa, 0x5e, 0x96,
+	0xc7, 0x7a, 0x78, 0x8a, 0xcf, 0x01, 0xbd, 0xa4, 0x5c, 0x77, 0x1c, 0x7e, 0xd4, 0x8f, 0x49, 0xe4,
+	0x43, 0x00, 0x3e, 0xf0, 0xa8, 0x3f, 0x70, 0x86, 0x66, 0xcc, 0xd0, 0x85, 0x03, 0x20, 0x01, 0xc6,
+	0x6d, 0xa8, 0xca, 0x37, 0x29, 0xd0, 0x58, 0x1c, 0xdb, 0x01, 0x2c, 0x92, 0xfe, 0x1c, 0xc3, 0x45,
+	0xa0, 0x70, 0x0f, 0x6a, 0x4a, 0xe1, 0x89, 0x33, 0xb2, 0x05, 0xc9, 0x55, 0x22, 0x93, 0xf5, 0xd2,
+	0x2c, 0x15, 0x31, 0x16, 0x6d, 0x03, 0x48, 0x77, 0x0c, 0xa1, 0x46, 0x1a, 0x5f, 0xd4, 0x2b, 0xe2,
+	0x44, 0xea, 0xc5, 0x63, 0x58, 0x4b, 0x65, 0x43, 0x65, 0xf3, 0x20, 0x4d, 0x7f, 0xeb, 0x19, 0xd2,
+	0x08, 0xe0, 0x8a, 0x05, 0xd1, 0x13, 0x00, 0x67, 0x68, 0x52, 0xaf, 0xc3, 0x07, 0x44, 0x30, 0x50,
+	0xe6, 0x9d, 0x4a, 0xc6, 0xa1, 0x57, 0x24, 0xf2, 0x72, 0x40, 0xec, 0xc3, 0xbf, 0xab, 0xf0, 0xff,
+	0x4b, 0x05, 0x3a, 0x12, 0x5b, 0x11, 0x7a, 0x01, 0x95, 0x68, 0x43, 0x40, 0x5a, 0xac, 0x21, 0xbb,
+	0x6d, 0x68, 0x9b, 0x13, 0x65, 0x81, 0xef, 0x78, 0x01, 0x7d, 0x05, 0x4b, 0xaa, 0xcd, 0x51, 0x3d,
+	0x46, 0xa6, 0x67, 0xa9, 0x96, 0x21, 0x42, 0x8c, 0x7f, 0xfd, 0xf3, 0xaf, 0xdf, 0x6f, 0x6c, 0x21,
+	0xad, 0xf5, 0xe3, 0xc3, 0x2e, 0xe5, 0xe4, 0x61, 0x4b, 0x46, 0xd7, 0xfa, 0x59, 0x55, 0xf3, 0xe3,
+	0xfd, 0x5f, 0xd0, 0x25, 0x40, 0x4c, 0x7a, 0x28, 0xe1, 0x45, 0x6e, 0xf8, 0xe6, 0xd4, 0x37, 0xa4,
+	0xfa, 0x35, 0xbc, 0x9c, 0x56, 0xff, 0xac, 0xb4, 0x8f, 0x28, 0x40, 0x4c, 0x2e, 0x68, 0xda, 0xf0,
+	0xca, 0x69, 0xdd, 0x97, 0x5a, 0xf7, 0x0e, 0xdf, 0x99, 0xe4, 0x74, 0x33, 0xf6, 0x5c, 0x98, 0xf9,
+	0x1a, 0x6e, 0x67, 0xc7, 0x1d, 0xba, 0x1b, 0xeb, 0x2b, 0x18, 0xaf, 0x1a, 0x9e, 0x06, 0x51, 0xed,
+	0xf2, 0x2d, 0x40, 0xcc, 0x66, 0xc9, 0x08, 0x72, 0xd3, 0xb1, 0x28, 0xed, 0xfb, 0xd3, 0xd2, 0xfe,
+	0x3d, 0xd4, 0x92, 0x83, 0x13, 0x6d, 0x27, 0x52, 0x64, 0x9b, 0x33, 0x4d, 0x1c, 0x48, 0x13, 0xf7,
+	0xf6, 0x77, 0x8b, 0x4d, 0x3c, 0x1b, 0x29, 0x3d, 0xa8, 0x0f, 0x6f, 0x4d, 0xda, 0xe2, 0xd0, 0xbd,
+	0x74, 0xcb, 0x15, 0x2c, 0xa3, 0xda, 0xbb, 0xb3, 0x60, 0x51, 0x93, 0x7e, 0x29, 0x79, 0x28, 0xcb,
+	0xe9, 0xbb, 0xb9, 0x7e, 0xcd, 0x33, 0xb5, 0xd6, 0x48, 0x07, 0x98, 0x40, 0xe0, 0x05, 0xf4, 0x0a,
+	0x56, 0x73, 0x6c, 0x8d, 0x12, 0x45, 0x2c, 0xa2, 0xf2, 0x5c, 0xda, 0x16, 0x10, 0x01, 0x94, 0x67,
+	0xdc, 0xa4, 0x8b, 0x85, 0x0c, 0xaf, 0xed, 0x4d, 0x07, 0x45, 0x59, 0x38, 0x83, 0x6a, 0x82, 0x7f,
+	0xd0, 0x56, 0x2a, 0xfc, 0x0c, 0x49, 0x6b, 0xdb, 0x05, 0xd2, 0x48, 0x5b, 0x1b, 0x50, 0x7e, 0x29,
+	0x49, 0x39, 0x5c, 0xb4, 0xb2, 0x68, 0x05, 0x23, 0x1b, 0x2f, 0xa0, 0xd7, 0xb0, 0x92, 0x59, 0x4c,
+	0xd0, 0x4e, 0xae, 0x4a, 0xf3, 0xab, 0xfb, 0x2e, 0xfe, 0x0c, 0x0a, 0x4f, 0xfd, 0x64, 0x7d, 0x8a,
+	0xd6, 0x1a, 0x6d, 0x77, 0x2a, 0x26, 0x99, 0x83, 0xfc, 0xaa, 0x92, 0xcc, 0x41, 0xe1, 0x22, 0x33,
+	0xc5, 0xe9, 0x36, 0xa0, 0xfc, 0xba, 0x92, 0x54, 0x5a, 0xb8, 0xcc, 0x68, 0x1b, 0xb9, 0x31, 0xf5,
+	0x5c, 0x7c, 0x6d, 0xe2, 0x85, 0xe3, 0x0b, 0x68, 0x18, 0x8e, 0x15, 0x8a, 0xd3, 0x5f, 0xbf, 0xc7,
+	0xeb, 0xa9, 0xd1, 0x70, 0xe4, 0xb2, 0x0b, 0x71, 0x7c, 0x51, 0xfa, 0x46, 0xeb, 0x33, 0x3e, 0x18,
+	0x75, 0x9b, 0x86, 0x63, 0xb5, 0xd4, 0xa7, 0x6a, 0x78, 0xb5, 0x7b, 0x4b, 0xde, 0x7d, 0xf4, 0xcf,
+	0x00, 0x10, 0xd5, 0x9e, 0xb4, 0x6f, 0x0f, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -406,12 +1416,18 @@ type TrillianAdminClient interface {
 	GetTree(ctx context.Context, in *GetTreeRequest, opts ...grpc.CallOption) (*Tree, error)
 	// Creates a new tree.
 	// System-generated fields are not required and will be ignored if present,
-	// e.g.: tree_id, create_time and update_time.
+	// e.g.: create_time and update_time.
+	// If tree_id is set it's used as the ID of the new tree, otherwise a random
+	// ID is assigned. Returns ALREADY_EXISTS if the requested ID is taken.
 	// Returns the created tree, with all system-generated fields assigned.
 	CreateTree(ctx context.Context, in *CreateTreeRequest, opts ...grpc.CallOption) (*Tree, error)
 	// Updates a tree.
 	// See Tree for details. Readonly fields cannot be updated.
 	UpdateTree(ctx context.Context, in *UpdateTreeRequest, opts ...grpc.CallOption) (*Tree, error)
+	// Updates the mutable metadata of many trees at once, e.g. to fix display
+	// names in bulk. The updates are applied atomically: if any of them is
+	// invalid, none are applied.
+	BatchUpdateTrees(ctx context.Context, in *BatchUpdateTreesRequest, opts ...grpc.CallOption) (*BatchUpdateTreesResponse, error)
 	// Soft-deletes a tree.
 	// A soft-deleted tree may be undeleted for a certain period, after which
 	// it'll be permanently deleted.
@@ -419,7 +1435,47 @@ type TrillianAdminClient interface {
 	// Undeletes a soft-deleted a tree.
 	// A soft-deleted tree may be undeleted for a certain period, after which
 	// it'll be permanently deleted.
+	// Returns FAILED_PRECONDITION if the tree is already eligible for
+	// hard-deletion.
 	UndeleteTree(ctx context.Context, in *UndeleteTreeRequest, opts ...grpc.CallOption) (*Tree, error)
+	// Lists all soft-deleted trees the requester has access to, along with the
+	// time left to undelete them.
+	ListSoftDeletedTrees(ctx context.Context, in *ListSoftDeletedTreesRequest, opts ...grpc.CallOption) (*ListSoftDeletedTreesResponse, error)
+	// Retrieves the signed attestation of the settings a tree was created with.
+	// The attestation is made when the tree is created, so it doesn't reflect
+	// later updates. Returns NOT_FOUND for trees created without one, e.g.
+	// before attestations were introduced.
+	GetTreeAttestation(ctx context.Context, in *GetTreeAttestationRequest, opts ...grpc.CallOption) (*TreeAttestation, error)
+	// Rewraps the data key of a tree with leaf_encryption by the current key
+	// encryption key (KEK) of the server, e.g. after the KEK was rotated. The
+	// data key itself doesn't change, so leaves don't need to be re-encrypted.
+	// Returns FAILED_PRECONDITION if the tree doesn't have leaf_encryption, or
+	// the server has no KEK.
+	RewrapLeafDataKey(ctx context.Context, in *RewrapLeafDataKeyRequest, opts ...grpc.CallOption) (*Tree, error)
+	// Removes the data of a tree which is no longer needed to serve it, e.g.
+	// revisions of Merkle tree nodes superseded before any of the roots of the
+	// tree, while the tree stays online. Returns UNIMPLEMENTED if the storage
+	// of the tree doesn't support compaction.
+	CompactTreeStorage(ctx context.Context, in *CompactTreeStorageRequest, opts ...grpc.CallOption) (*CompactTreeStorageResponse, error)
+	// Returns the age of the latest signed root of every active and draining
+	// log, and how many of them are older than the requested thresholds. Frozen
+	// logs are left out, as their roots aren't refreshed, and so are logs whose
+	// root can't be read, which the server logs. Returns FAILED_PRECONDITION if
+	// the server doesn't serve logs.
+	GetRootAges(ctx context.Context, in *GetRootAgesRequest, opts ...grpc.CallOption) (*GetRootAgesResponse, error)
+	// Creates a tree template.
+	// Returns ALREADY_EXISTS if a template with the same name exists.
+	CreateTreeTemplate(ctx context.Context, in *CreateTreeTemplateRequest, opts ...grpc.CallOption) (*TreeTemplate, error)
+	// Retrieves a tree template by name.
+	GetTreeTemplate(ctx context.Context, in *GetTreeTemplateRequest, opts ...grpc.CallOption) (*TreeTemplate, error)
+	// Lists all tree templates.
+	ListTreeTemplates(ctx context.Context, in *ListTreeTemplatesRequest, opts ...grpc.CallOption) (*ListTreeTemplatesResponse, error)
+	// Replaces an existing tree template.
+	// Trees already created from the template are not affected.
+	UpdateTreeTemplate(ctx context.Context, in *UpdateTreeTemplateRequest, opts ...grpc.CallOption) (*TreeTemplate, error)
+	// Deletes a tree template.
+	// Trees already created from the template are not affected.
+	DeleteTreeTemplate(ctx context.Context, in *DeleteTreeTemplateRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 }
 
 type trillianAdminClient struct {
@@ -466,6 +1522,15 @@ func (c *trillianAdminClient) UpdateTree(ctx context.Context, in *UpdateTreeRequ
 	return out, nil
 }
 
+func (c *trillianAdminClient) BatchUpdateTrees(ctx context.Context, in *BatchUpdateTreesRequest, opts ...grpc.CallOption) (*BatchUpdateTreesResponse, error) {
+	out := new(BatchUpdateTreesResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/BatchUpdateTrees", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trillianAdminClient) DeleteTree(ctx context.Context, in *DeleteTreeRequest, opts ...grpc.CallOption) (*Tree, error) {
 	out := new(Tree)
 	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/DeleteTree", in, out, opts...)
@@ -484,6 +1549,96 @@ func (c *trillianAdminClient) UndeleteTree(ctx context.Context, in *UndeleteTree
 	return out, nil
 }
 
+func (c *trillianAdminClient) ListSoftDeletedTrees(ctx context.Context, in *ListSoftDeletedTreesRequest, opts ...grpc.CallOption) (*ListSoftDeletedTreesResponse, error) {
+	out := new(ListSoftDeletedTreesResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/ListSoftDeletedTrees", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) GetTreeAttestation(ctx context.Context, in *GetTreeAttestationRequest, opts ...grpc.CallOption) (*TreeAttestation, error) {
+	out := new(TreeAttestation)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/GetTreeAttestation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) RewrapLeafDataKey(ctx context.Context, in *RewrapLeafDataKeyRequest, opts ...grpc.CallOption) (*Tree, error) {
+	out := new(Tree)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/RewrapLeafDataKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) CompactTreeStorage(ctx context.Context, in *CompactTreeStorageRequest, opts ...grpc.CallOption) (*CompactTreeStorageResponse, error) {
+	out := new(CompactTreeStorageResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/CompactTreeStorage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) GetRootAges(ctx context.Context, in *GetRootAgesRequest, opts ...grpc.CallOption) (*GetRootAgesResponse, error) {
+	out := new(GetRootAgesResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/GetRootAges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) CreateTreeTemplate(ctx context.Context, in *CreateTreeTemplateRequest, opts ...grpc.CallOption) (*TreeTemplate, error) {
+	out := new(TreeTemplate)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/CreateTreeTemplate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) GetTreeTemplate(ctx context.Context, in *GetTreeTemplateRequest, opts ...grpc.CallOption) (*TreeTemplate, error) {
+	out := new(TreeTemplate)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/GetTreeTemplate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) ListTreeTemplates(ctx context.Context, in *ListTreeTemplatesRequest, opts ...grpc.CallOption) (*ListTreeTemplatesResponse, error) {
+	out := new(ListTreeTemplatesResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/ListTreeTemplates", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) UpdateTreeTemplate(ctx context.Context, in *UpdateTreeTemplateRequest, opts ...grpc.CallOption) (*TreeTemplate, error) {
+	out := new(TreeTemplate)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/UpdateTreeTemplate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianAdminClient) DeleteTreeTemplate(ctx context.Context, in *DeleteTreeTemplateRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianAdmin/DeleteTreeTemplate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TrillianAdminServer is the server API for TrillianAdmin service.
 type TrillianAdminServer interface {
 	// Lists all trees the requester has access to.
@@ -492,12 +1647,18 @@ type TrillianAdminServer interface {
 	GetTree(context.Context, *GetTreeRequest) (*Tree, error)
 	// Creates a new tree.
 	// System-generated fields are not required and will be ignored if present,
-	// e.g.: tree_id, create_time and update_time.
+	// e.g.: create_time and update_time.
+	// If tree_id is set it's used as the ID of the new tree, otherwise a random
+	// ID is assigned. Returns ALREADY_EXISTS if the requested ID is taken.
 	// Returns the created tree, with all system-generated fields assigned.
 	CreateTree(context.Context, *CreateTreeRequest) (*Tree, error)
 	// Updates a tree.
 	// See Tree for details. Readonly fields cannot be updated.
 	UpdateTree(context.Context, *UpdateTreeRequest) (*Tree, error)
+	// Updates the mutable metadata of many trees at once, e.g. to fix display
+	// names in bulk. The updates are applied atomically: if any of them is
+	// invalid, none are applied.
+	BatchUpdateTrees(context.Context, *BatchUpdateTreesRequest) (*BatchUpdateTreesResponse, error)
 	// Soft-deletes a tree.
 	// A soft-deleted tree may be undeleted for a certain period, after which
 	// it'll be permanently deleted.
@@ -505,7 +1666,47 @@ type TrillianAdminServer interface {
 	// Undeletes a soft-deleted a tree.
 	// A soft-deleted tree may be undeleted for a certain period, after which
 	// it'll be permanently deleted.
+	// Returns FAILED_PRECONDITION if the tree is already eligible for
+	// hard-deletion.
 	UndeleteTree(context.Context, *UndeleteTreeRequest) (*Tree, error)
+	// Lists all soft-deleted trees the requester has access to, along with the
+	// time left to undelete them.
+	ListSoftDeletedTrees(context.Context, *ListSoftDeletedTreesRequest) (*ListSoftDeletedTreesResponse, error)
+	// Retrieves the signed attestation of the settings a tree was created with.
+	// The attestation is made when the tree is created, so it doesn't reflect
+	// later updates. Returns NOT_FOUND for trees created without one, e.g.
+	// before attestations were introduced.
+	GetTreeAttestation(context.Context, *GetTreeAttestationRequest) (*TreeAttestation, error)
+	// Rewraps the data key of a tree with leaf_encryption by the current key
+	// encryption key (KEK) of the server, e.g. after the KEK was rotated. The
+	// data key itself doesn't change, so leaves don't need to be re-encrypted.
+	// Returns FAILED_PRECONDITION if the tree doesn't have leaf_encryption, or
+	// the server has no KEK.
+	RewrapLeafDataKey(context.Context, *RewrapLeafDataKeyRequest) (*Tree, error)
+	// Removes the data of a tree which is no longer needed to serve it, e.g.
+	// revisions of Merkle tree nodes superseded before any of the roots of the
+	// tree, while the tree stays online. Returns UNIMPLEMENTED if the storage
+	// of the tree doesn't support compaction.
+	CompactTreeStorage(context.Context, *CompactTreeStorageRequest) (*CompactTreeStorageResponse, error)
+	// Returns the age of the latest signed root of every active and draining
+	// log, and how many of them are older than the requested thresholds. Frozen
+	// logs are left out, as their roots aren't refreshed, and so are logs whose
+	// root can't be read, which the server logs. Returns FAILED_PRECONDITION if
+	// the server doesn't serve logs.
+	GetRootAges(context.Context, *GetRootAgesRequest) (*GetRootAgesResponse, error)
+	// Creates a tree template.
+	// Returns ALREADY_EXISTS if a template with the same name exists.
+	CreateTreeTemplate(context.Context, *CreateTreeTemplateRequest) (*TreeTemplate, error)
+	// Retrieves a tree template by name.
+	GetTreeTemplate(context.Context, *GetTreeTemplateRequest) (*TreeTemplate, error)
+	// Lists all tree templates.
+	ListTreeTemplates(context.Context, *ListTreeTemplatesRequest) (*ListTreeTemplatesResponse, error)
+	// Replaces an existing tree template.
+	// Trees already created from the template are not affected.
+	UpdateTreeTemplate(context.Context, *UpdateTreeTemplateRequest) (*TreeTemplate, error)
+	// Deletes a tree template.
+	// Trees already created from the template are not affected.
+	DeleteTreeTemplate(context.Context, *DeleteTreeTemplateRequest) (*empty.Empty, error)
 }
 
 // UnimplementedTrillianAdminServer can be embedded to have forward compatible implementations.
@@ -524,12 +1725,45 @@ func (*UnimplementedTrillianAdminServer) CreateTree(ctx context.Context, req *Cr
 func (*UnimplementedTrillianAdminServer) UpdateTree(ctx context.Context, req *UpdateTreeRequest) (*Tree, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateTree not implemented")
 }
+func (*UnimplementedTrillianAdminServer) BatchUpdateTrees(ctx context.Context, req *BatchUpdateTreesRequest) (*BatchUpdateTreesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchUpdateTrees not implemented")
+}
 func (*UnimplementedTrillianAdminServer) DeleteTree(ctx context.Context, req *DeleteTreeRequest) (*Tree, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteTree not implemented")
 }
 func (*UnimplementedTrillianAdminServer) UndeleteTree(ctx context.Context, req *UndeleteTreeRequest) (*Tree, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UndeleteTree not implemented")
 }
+func (*UnimplementedTrillianAdminServer) ListSoftDeletedTrees(ctx context.Context, req *ListSoftDeletedTreesRequest) (*ListSoftDeletedTreesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSoftDeletedTrees not implemented")
+}
+func (*UnimplementedTrillianAdminServer) GetTreeAttestation(ctx context.Context, req *GetTreeAttestationRequest) (*TreeAttestation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTreeAttestation not implemented")
+}
+func (*UnimplementedTrillianAdminServer) RewrapLeafDataKey(ctx context.Context, req *RewrapLeafDataKeyRequest) (*Tree, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RewrapLeafDataKey not implemented")
+}
+func (*UnimplementedTrillianAdminServer) CompactTreeStorage(ctx context.Context, req *CompactTreeStorageRequest) (*CompactTreeStorageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompactTreeStorage not implemented")
+}
+func (*UnimplementedTrillianAdminServer) GetRootAges(ctx context.Context, req *GetRootAgesRequest) (*GetRootAgesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRootAges not implemented")
+}
+func (*UnimplementedTrillianAdminServer) CreateTreeTemplate(ctx context.Context, req *CreateTreeTemplateRequest) (*TreeTemplate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTreeTemplate not implemented")
+}
+func (*UnimplementedTrillianAdminServer) GetTreeTemplate(ctx context.Context, req *GetTreeTemplateRequest) (*TreeTemplate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTreeTemplate not implemented")
+}
+func (*UnimplementedTrillianAdminServer) ListTreeTemplates(ctx context.Context, req *ListTreeTemplatesRequest) (*ListTreeTemplatesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTreeTemplates not implemented")
+}
+func (*UnimplementedTrillianAdminServer) UpdateTreeTemplate(ctx context.Context, req *UpdateTreeTemplateRequest) (*TreeTemplate, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateTreeTemplate not implemented")
+}
+func (*UnimplementedTrillianAdminServer) DeleteTreeTemplate(ctx context.Context, req *DeleteTreeTemplateRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTreeTemplate not implemented")
+}
 
 func RegisterTrillianAdminServer(s *grpc.Server, srv TrillianAdminServer) {
 	s.RegisterService(&_TrillianAdmin_serviceDesc, srv)
@@ -607,6 +1841,24 @@ func _TrillianAdmin_UpdateTree_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianAdmin_BatchUpdateTrees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchUpdateTreesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).BatchUpdateTrees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/BatchUpdateTrees",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).BatchUpdateTrees(ctx, req.(*BatchUpdateTreesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianAdmin_DeleteTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteTreeRequest)
 	if err := dec(in); err != nil {
@@ -643,6 +1895,186 @@ func _TrillianAdmin_UndeleteTree_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianAdmin_ListSoftDeletedTrees_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSoftDeletedTreesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).ListSoftDeletedTrees(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/ListSoftDeletedTrees",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).ListSoftDeletedTrees(ctx, req.(*ListSoftDeletedTreesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_GetTreeAttestation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTreeAttestationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).GetTreeAttestation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/GetTreeAttestation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).GetTreeAttestation(ctx, req.(*GetTreeAttestationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_RewrapLeafDataKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RewrapLeafDataKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).RewrapLeafDataKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/RewrapLeafDataKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).RewrapLeafDataKey(ctx, req.(*RewrapLeafDataKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_CompactTreeStorage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactTreeStorageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).CompactTreeStorage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/CompactTreeStorage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).CompactTreeStorage(ctx, req.(*CompactTreeStorageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_GetRootAges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRootAgesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).GetRootAges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/GetRootAges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).GetRootAges(ctx, req.(*GetRootAgesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_CreateTreeTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTreeTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).CreateTreeTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/CreateTreeTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).CreateTreeTemplate(ctx, req.(*CreateTreeTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_GetTreeTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTreeTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).GetTreeTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/GetTreeTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).GetTreeTemplate(ctx, req.(*GetTreeTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_ListTreeTemplates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTreeTemplatesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).ListTreeTemplates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/ListTreeTemplates",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).ListTreeTemplates(ctx, req.(*ListTreeTemplatesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_UpdateTreeTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateTreeTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).UpdateTreeTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/UpdateTreeTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).UpdateTreeTemplate(ctx, req.(*UpdateTreeTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianAdmin_DeleteTreeTemplate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteTreeTemplateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianAdminServer).DeleteTreeTemplate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianAdmin/DeleteTreeTemplate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianAdminServer).DeleteTreeTemplate(ctx, req.(*DeleteTreeTemplateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _TrillianAdmin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "trillian.TrillianAdmin",
 	HandlerType: (*TrillianAdminServer)(nil),
@@ -663,6 +2095,10 @@ var _TrillianAdmin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateTree",
 			Handler:    _TrillianAdmin_UpdateTree_Handler,
 		},
+		{
+			MethodName: "BatchUpdateTrees",
+			Handler:    _TrillianAdmin_BatchUpdateTrees_Handler,
+		},
 		{
 			MethodName: "DeleteTree",
 			Handler:    _TrillianAdmin_DeleteTree_Handler,
@@ -671,6 +2107,46 @@ var _TrillianAdmin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "UndeleteTree",
 			Handler:    _TrillianAdmin_UndeleteTree_Handler,
 		},
+		{
+			MethodName: "ListSoftDeletedTrees",
+			Handler:    _TrillianAdmin_ListSoftDeletedTrees_Handler,
+		},
+		{
+			MethodName: "GetTreeAttestation",
+			Handler:    _TrillianAdmin_GetTreeAttestation_Handler,
+		},
+		{
+			MethodName: "RewrapLeafDataKey",
+			Handler:    _TrillianAdmin_RewrapLeafDataKey_Handler,
+		},
+		{
+			MethodName: "CompactTreeStorage",
+			Handler:    _TrillianAdmin_CompactTreeStorage_Handler,
+		},
+		{
+			MethodName: "GetRootAges",
+			Handler:    _TrillianAdmin_GetRootAges_Handler,
+		},
+		{
+			MethodName: "CreateTreeTemplate",
+			Handler:    _TrillianAdmin_CreateTreeTemplate_Handler,
+		},
+		{
+			MethodName: "GetTreeTemplate",
+			Handler:    _TrillianAdmin_GetTreeTemplate_Handler,
+		},
+		{
+			MethodName: "ListTreeTemplates",
+			Handler:    _TrillianAdmin_ListTreeTemplates_Handler,
+		},
+		{
+			MethodName: "UpdateTreeTemplate",
+			Handler:    _TrillianAdmin_UpdateTreeTemplate_Handler,
+		},
+		{
+			MethodName: "DeleteTreeTemplate",
+			Handler:    _TrillianAdmin_DeleteTreeTemplate_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "trillian_admin_api.proto",
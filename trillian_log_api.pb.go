@@ -27,6 +27,37 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// IndexBase specifies the number of the first leaf of a log in the leaf
+// indices of a request and its response, for interoperability with systems
+// which number leaves from 1. Tree sizes and counts are unaffected.
+type IndexBase int32
+
+const (
+	// Leaf indices start at 0, as elsewhere in the API.
+	IndexBase_INDEX_BASE_ZERO IndexBase = 0
+	// Leaf indices start at 1, so index 0 is invalid. The server translates
+	// them to and from its 0-based indices.
+	IndexBase_INDEX_BASE_ONE IndexBase = 1
+)
+
+var IndexBase_name = map[int32]string{
+	0: "INDEX_BASE_ZERO",
+	1: "INDEX_BASE_ONE",
+}
+
+var IndexBase_value = map[string]int32{
+	"INDEX_BASE_ZERO": 0,
+	"INDEX_BASE_ONE":  1,
+}
+
+func (x IndexBase) String() string {
+	return proto.EnumName(IndexBase_name, int32(x))
+}
+
+func (IndexBase) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{0}
+}
+
 // ChargeTo describes the user(s) associated with the request whose quota should
 // be checked and charged.
 type ChargeTo struct {
@@ -142,10 +173,12 @@ type QueueLeafResponse struct {
 	// Log.  If the submitted leaf was already present in the Log (as indicated by
 	// its leaf identity hash), then the returned leaf will be the pre-existing
 	// leaf entry rather than the submitted leaf.
-	QueuedLeaf           *QueuedLogLeaf `protobuf:"bytes,2,opt,name=queued_leaf,json=queuedLeaf,proto3" json:"queued_leaf,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
-	XXX_unrecognized     []byte         `json:"-"`
-	XXX_sizecache        int32          `json:"-"`
+	QueuedLeaf *QueuedLogLeaf `protobuf:"bytes,2,opt,name=queued_leaf,json=queuedLeaf,proto3" json:"queued_leaf,omitempty"`
+	// The result of queuing the leaf to the shadow log, if the log has one.
+	Shadow               *ShadowQueueResult `protobuf:"bytes,3,opt,name=shadow,proto3" json:"shadow,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
 }
 
 func (m *QueueLeafResponse) Reset()         { *m = QueueLeafResponse{} }
@@ -180,6 +213,13 @@ func (m *QueueLeafResponse) GetQueuedLeaf() *QueuedLogLeaf {
 	return nil
 }
 
+func (m *QueueLeafResponse) GetShadow() *ShadowQueueResult {
+	if m != nil {
+		return m.Shadow
+	}
+	return nil
+}
+
 type AddSequencedLeafRequest struct {
 	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
 	Leaf                 *LogLeaf  `protobuf:"bytes,2,opt,name=leaf,proto3" json:"leaf,omitempty"`
@@ -390,24 +430,143 @@ func (m *GetInclusionProofResponse) GetSignedLogRoot() *SignedLogRoot {
 	return nil
 }
 
-type GetInclusionProofByHashRequest struct {
-	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
-	// The leaf hash field provides the Merkle tree hash of the leaf entry
-	// to be retrieved.
-	LeafHash             []byte    `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+type GetHistoricalInclusionProofRequest struct {
+	LogId     int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	LeafIndex int64 `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	// The size of the tree to prove inclusion in, which must be the size of a
+	// retained signed log root.
 	TreeSize             int64     `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
-	OrderBySequence      bool      `protobuf:"varint,4,opt,name=order_by_sequence,json=orderBySequence,proto3" json:"order_by_sequence,omitempty"`
-	ChargeTo             *ChargeTo `protobuf:"bytes,5,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,4,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
 }
 
+func (m *GetHistoricalInclusionProofRequest) Reset()         { *m = GetHistoricalInclusionProofRequest{} }
+func (m *GetHistoricalInclusionProofRequest) String() string { return proto.CompactTextString(m) }
+func (*GetHistoricalInclusionProofRequest) ProtoMessage()    {}
+func (*GetHistoricalInclusionProofRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{7}
+}
+
+func (m *GetHistoricalInclusionProofRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetHistoricalInclusionProofRequest.Unmarshal(m, b)
+}
+func (m *GetHistoricalInclusionProofRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetHistoricalInclusionProofRequest.Marshal(b, m, deterministic)
+}
+func (m *GetHistoricalInclusionProofRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetHistoricalInclusionProofRequest.Merge(m, src)
+}
+func (m *GetHistoricalInclusionProofRequest) XXX_Size() int {
+	return xxx_messageInfo_GetHistoricalInclusionProofRequest.Size(m)
+}
+func (m *GetHistoricalInclusionProofRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetHistoricalInclusionProofRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetHistoricalInclusionProofRequest proto.InternalMessageInfo
+
+func (m *GetHistoricalInclusionProofRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetHistoricalInclusionProofRequest) GetLeafIndex() int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return 0
+}
+
+func (m *GetHistoricalInclusionProofRequest) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *GetHistoricalInclusionProofRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
+}
+
+type GetHistoricalInclusionProofResponse struct {
+	Proof *Proof `protobuf:"bytes,1,opt,name=proof,proto3" json:"proof,omitempty"`
+	// The retained signed log root of size tree_size, whose root hash the proof
+	// leads to.
+	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *GetHistoricalInclusionProofResponse) Reset()         { *m = GetHistoricalInclusionProofResponse{} }
+func (m *GetHistoricalInclusionProofResponse) String() string { return proto.CompactTextString(m) }
+func (*GetHistoricalInclusionProofResponse) ProtoMessage()    {}
+func (*GetHistoricalInclusionProofResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{8}
+}
+
+func (m *GetHistoricalInclusionProofResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetHistoricalInclusionProofResponse.Unmarshal(m, b)
+}
+func (m *GetHistoricalInclusionProofResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetHistoricalInclusionProofResponse.Marshal(b, m, deterministic)
+}
+func (m *GetHistoricalInclusionProofResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetHistoricalInclusionProofResponse.Merge(m, src)
+}
+func (m *GetHistoricalInclusionProofResponse) XXX_Size() int {
+	return xxx_messageInfo_GetHistoricalInclusionProofResponse.Size(m)
+}
+func (m *GetHistoricalInclusionProofResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetHistoricalInclusionProofResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetHistoricalInclusionProofResponse proto.InternalMessageInfo
+
+func (m *GetHistoricalInclusionProofResponse) GetProof() *Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *GetHistoricalInclusionProofResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+type GetInclusionProofByHashRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// The leaf hash field provides the Merkle tree hash of the leaf entry
+	// to be retrieved.
+	LeafHash        []byte    `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+	TreeSize        int64     `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	OrderBySequence bool      `protobuf:"varint,4,opt,name=order_by_sequence,json=orderBySequence,proto3" json:"order_by_sequence,omitempty"`
+	ChargeTo        *ChargeTo `protobuf:"bytes,5,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	// If all_indices is set, the response lists every index at which the leaf
+	// hash appears in the tree of size tree_size, in ascending order, with a
+	// proof for each, up to a limit set by the server. The order_by_sequence
+	// field is ignored in this case.
+	AllIndices           bool     `protobuf:"varint,6,opt,name=all_indices,json=allIndices,proto3" json:"all_indices,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
 func (m *GetInclusionProofByHashRequest) Reset()         { *m = GetInclusionProofByHashRequest{} }
 func (m *GetInclusionProofByHashRequest) String() string { return proto.CompactTextString(m) }
 func (*GetInclusionProofByHashRequest) ProtoMessage()    {}
 func (*GetInclusionProofByHashRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{7}
+	return fileDescriptor_5ad20a6a54aa5af3, []int{9}
 }
 
 func (m *GetInclusionProofByHashRequest) XXX_Unmarshal(b []byte) error {
@@ -463,23 +622,36 @@ func (m *GetInclusionProofByHashRequest) GetChargeTo() *ChargeTo {
 	return nil
 }
 
+func (m *GetInclusionProofByHashRequest) GetAllIndices() bool {
+	if m != nil {
+		return m.AllIndices
+	}
+	return false
+}
+
 type GetInclusionProofByHashResponse struct {
 	// Logs can potentially contain leaves with duplicate hashes so it's possible
 	// for this to return multiple proofs.  If the leaf index for a particular
 	// instance of the requested Merkle leaf hash is beyond the requested tree
 	// size, the corresponding proof entry will be missing.
-	Proof                []*Proof       `protobuf:"bytes,2,rep,name=proof,proto3" json:"proof,omitempty"`
-	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,3,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
-	XXX_unrecognized     []byte         `json:"-"`
-	XXX_sizecache        int32          `json:"-"`
+	Proof         []*Proof       `protobuf:"bytes,2,rep,name=proof,proto3" json:"proof,omitempty"`
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,3,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	// Only set if all_indices was requested: the indices of the leaves with the
+	// requested hash, each matching the proof at the same position.
+	LeafIndices []int64 `protobuf:"varint,4,rep,packed,name=leaf_indices,json=leafIndices,proto3" json:"leaf_indices,omitempty"`
+	// Only set if all_indices was requested: whether the leaf hash appears at
+	// more indices than were returned.
+	Truncated            bool     `protobuf:"varint,5,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *GetInclusionProofByHashResponse) Reset()         { *m = GetInclusionProofByHashResponse{} }
 func (m *GetInclusionProofByHashResponse) String() string { return proto.CompactTextString(m) }
 func (*GetInclusionProofByHashResponse) ProtoMessage()    {}
 func (*GetInclusionProofByHashResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{8}
+	return fileDescriptor_5ad20a6a54aa5af3, []int{10}
 }
 
 func (m *GetInclusionProofByHashResponse) XXX_Unmarshal(b []byte) error {
@@ -514,6 +686,20 @@ func (m *GetInclusionProofByHashResponse) GetSignedLogRoot() *SignedLogRoot {
 	return nil
 }
 
+func (m *GetInclusionProofByHashResponse) GetLeafIndices() []int64 {
+	if m != nil {
+		return m.LeafIndices
+	}
+	return nil
+}
+
+func (m *GetInclusionProofByHashResponse) GetTruncated() bool {
+	if m != nil {
+		return m.Truncated
+	}
+	return false
+}
+
 type GetConsistencyProofRequest struct {
 	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
 	FirstTreeSize        int64     `protobuf:"varint,2,opt,name=first_tree_size,json=firstTreeSize,proto3" json:"first_tree_size,omitempty"`
@@ -528,7 +714,7 @@ func (m *GetConsistencyProofRequest) Reset()         { *m = GetConsistencyProofR
 func (m *GetConsistencyProofRequest) String() string { return proto.CompactTextString(m) }
 func (*GetConsistencyProofRequest) ProtoMessage()    {}
 func (*GetConsistencyProofRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{9}
+	return fileDescriptor_5ad20a6a54aa5af3, []int{11}
 }
 
 func (m *GetConsistencyProofRequest) XXX_Unmarshal(b []byte) error {
@@ -595,7 +781,7 @@ func (m *GetConsistencyProofResponse) Reset()         { *m = GetConsistencyProof
 func (m *GetConsistencyProofResponse) String() string { return proto.CompactTextString(m) }
 func (*GetConsistencyProofResponse) ProtoMessage()    {}
 func (*GetConsistencyProofResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{10}
+	return fileDescriptor_5ad20a6a54aa5af3, []int{12}
 }
 
 func (m *GetConsistencyProofResponse) XXX_Unmarshal(b []byte) error {
@@ -630,808 +816,1973 @@ func (m *GetConsistencyProofResponse) GetSignedLogRoot() *SignedLogRoot {
 	return nil
 }
 
-type GetLatestSignedLogRootRequest struct {
-	LogId    int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
-	ChargeTo *ChargeTo `protobuf:"bytes,2,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
-	// If first_tree_size is non-zero, the response will include a consistency
-	// proof between first_tree_size and the new tree size (if not smaller).
-	FirstTreeSize        int64    `protobuf:"varint,3,opt,name=first_tree_size,json=firstTreeSize,proto3" json:"first_tree_size,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+type GetProofAtRevisionRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// The storage revision of the tree to read the proof nodes at.
+	Revision int64 `protobuf:"varint,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	// The size of the tree at revision, which the proof is computed for.
+	TreeSize int64 `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	// The index of the leaf to prove the inclusion of, unless first_tree_size is
+	// set.
+	LeafIndex int64 `protobuf:"varint,4,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	// If positive, a proof of consistency between first_tree_size and tree_size
+	// is returned instead of an inclusion proof.
+	FirstTreeSize        int64     `protobuf:"varint,5,opt,name=first_tree_size,json=firstTreeSize,proto3" json:"first_tree_size,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,6,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *GetLatestSignedLogRootRequest) Reset()         { *m = GetLatestSignedLogRootRequest{} }
-func (m *GetLatestSignedLogRootRequest) String() string { return proto.CompactTextString(m) }
-func (*GetLatestSignedLogRootRequest) ProtoMessage()    {}
-func (*GetLatestSignedLogRootRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{11}
+func (m *GetProofAtRevisionRequest) Reset()         { *m = GetProofAtRevisionRequest{} }
+func (m *GetProofAtRevisionRequest) String() string { return proto.CompactTextString(m) }
+func (*GetProofAtRevisionRequest) ProtoMessage()    {}
+func (*GetProofAtRevisionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{13}
 }
 
-func (m *GetLatestSignedLogRootRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetLatestSignedLogRootRequest.Unmarshal(m, b)
+func (m *GetProofAtRevisionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetProofAtRevisionRequest.Unmarshal(m, b)
 }
-func (m *GetLatestSignedLogRootRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetLatestSignedLogRootRequest.Marshal(b, m, deterministic)
+func (m *GetProofAtRevisionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetProofAtRevisionRequest.Marshal(b, m, deterministic)
 }
-func (m *GetLatestSignedLogRootRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetLatestSignedLogRootRequest.Merge(m, src)
+func (m *GetProofAtRevisionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetProofAtRevisionRequest.Merge(m, src)
 }
-func (m *GetLatestSignedLogRootRequest) XXX_Size() int {
-	return xxx_messageInfo_GetLatestSignedLogRootRequest.Size(m)
+func (m *GetProofAtRevisionRequest) XXX_Size() int {
+	return xxx_messageInfo_GetProofAtRevisionRequest.Size(m)
 }
-func (m *GetLatestSignedLogRootRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetLatestSignedLogRootRequest.DiscardUnknown(m)
+func (m *GetProofAtRevisionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetProofAtRevisionRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetLatestSignedLogRootRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetProofAtRevisionRequest proto.InternalMessageInfo
 
-func (m *GetLatestSignedLogRootRequest) GetLogId() int64 {
+func (m *GetProofAtRevisionRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *GetLatestSignedLogRootRequest) GetChargeTo() *ChargeTo {
+func (m *GetProofAtRevisionRequest) GetRevision() int64 {
 	if m != nil {
-		return m.ChargeTo
+		return m.Revision
 	}
-	return nil
+	return 0
 }
 
-func (m *GetLatestSignedLogRootRequest) GetFirstTreeSize() int64 {
+func (m *GetProofAtRevisionRequest) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *GetProofAtRevisionRequest) GetLeafIndex() int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return 0
+}
+
+func (m *GetProofAtRevisionRequest) GetFirstTreeSize() int64 {
 	if m != nil {
 		return m.FirstTreeSize
 	}
 	return 0
 }
 
-type GetLatestSignedLogRootResponse struct {
-	SignedLogRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
-	// proof is filled in with a consistency proof if first_tree_size in
-	// GetLatestSignedLogRootRequest is non-zero (and within the tree size
-	// available at the server).
-	Proof                *Proof   `protobuf:"bytes,3,opt,name=proof,proto3" json:"proof,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *GetProofAtRevisionRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
 }
 
-func (m *GetLatestSignedLogRootResponse) Reset()         { *m = GetLatestSignedLogRootResponse{} }
-func (m *GetLatestSignedLogRootResponse) String() string { return proto.CompactTextString(m) }
-func (*GetLatestSignedLogRootResponse) ProtoMessage()    {}
-func (*GetLatestSignedLogRootResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{12}
+type GetProofAtRevisionResponse struct {
+	Proof *Proof `protobuf:"bytes,1,opt,name=proof,proto3" json:"proof,omitempty"`
+	// The latest signed log root, whose revision is the latest the proof can be
+	// read at, for comparison with the requested revision and size.
+	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
-func (m *GetLatestSignedLogRootResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetLatestSignedLogRootResponse.Unmarshal(m, b)
+func (m *GetProofAtRevisionResponse) Reset()         { *m = GetProofAtRevisionResponse{} }
+func (m *GetProofAtRevisionResponse) String() string { return proto.CompactTextString(m) }
+func (*GetProofAtRevisionResponse) ProtoMessage()    {}
+func (*GetProofAtRevisionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{14}
 }
-func (m *GetLatestSignedLogRootResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetLatestSignedLogRootResponse.Marshal(b, m, deterministic)
+
+func (m *GetProofAtRevisionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetProofAtRevisionResponse.Unmarshal(m, b)
 }
-func (m *GetLatestSignedLogRootResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetLatestSignedLogRootResponse.Merge(m, src)
+func (m *GetProofAtRevisionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetProofAtRevisionResponse.Marshal(b, m, deterministic)
 }
-func (m *GetLatestSignedLogRootResponse) XXX_Size() int {
-	return xxx_messageInfo_GetLatestSignedLogRootResponse.Size(m)
+func (m *GetProofAtRevisionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetProofAtRevisionResponse.Merge(m, src)
 }
-func (m *GetLatestSignedLogRootResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetLatestSignedLogRootResponse.DiscardUnknown(m)
+func (m *GetProofAtRevisionResponse) XXX_Size() int {
+	return xxx_messageInfo_GetProofAtRevisionResponse.Size(m)
+}
+func (m *GetProofAtRevisionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetProofAtRevisionResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetLatestSignedLogRootResponse proto.InternalMessageInfo
+var xxx_messageInfo_GetProofAtRevisionResponse proto.InternalMessageInfo
 
-func (m *GetLatestSignedLogRootResponse) GetSignedLogRoot() *SignedLogRoot {
+func (m *GetProofAtRevisionResponse) GetProof() *Proof {
 	if m != nil {
-		return m.SignedLogRoot
+		return m.Proof
 	}
 	return nil
 }
 
-func (m *GetLatestSignedLogRootResponse) GetProof() *Proof {
+func (m *GetProofAtRevisionResponse) GetSignedLogRoot() *SignedLogRoot {
 	if m != nil {
-		return m.Proof
+		return m.SignedLogRoot
 	}
 	return nil
 }
 
-// DO NOT USE - FOR DEBUGGING/TEST ONLY
-//
-// (Use GetLatestSignedLogRoot then de-serialize the Log Root and use
-// use the tree size field within.)
-type GetSequencedLeafCountRequest struct {
-	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
-	ChargeTo             *ChargeTo `protobuf:"bytes,2,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+type PredictRootRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// The Merkle leaf hashes of the hypothetical leaves, in the order they would
+	// be appended. At most 1000 hashes are allowed.
+	LeafHashes           [][]byte  `protobuf:"bytes,2,rep,name=leaf_hashes,json=leafHashes,proto3" json:"leaf_hashes,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,3,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *GetSequencedLeafCountRequest) Reset()         { *m = GetSequencedLeafCountRequest{} }
-func (m *GetSequencedLeafCountRequest) String() string { return proto.CompactTextString(m) }
-func (*GetSequencedLeafCountRequest) ProtoMessage()    {}
-func (*GetSequencedLeafCountRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{13}
+func (m *PredictRootRequest) Reset()         { *m = PredictRootRequest{} }
+func (m *PredictRootRequest) String() string { return proto.CompactTextString(m) }
+func (*PredictRootRequest) ProtoMessage()    {}
+func (*PredictRootRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{15}
 }
 
-func (m *GetSequencedLeafCountRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetSequencedLeafCountRequest.Unmarshal(m, b)
+func (m *PredictRootRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PredictRootRequest.Unmarshal(m, b)
 }
-func (m *GetSequencedLeafCountRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetSequencedLeafCountRequest.Marshal(b, m, deterministic)
+func (m *PredictRootRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PredictRootRequest.Marshal(b, m, deterministic)
 }
-func (m *GetSequencedLeafCountRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetSequencedLeafCountRequest.Merge(m, src)
+func (m *PredictRootRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PredictRootRequest.Merge(m, src)
 }
-func (m *GetSequencedLeafCountRequest) XXX_Size() int {
-	return xxx_messageInfo_GetSequencedLeafCountRequest.Size(m)
+func (m *PredictRootRequest) XXX_Size() int {
+	return xxx_messageInfo_PredictRootRequest.Size(m)
 }
-func (m *GetSequencedLeafCountRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetSequencedLeafCountRequest.DiscardUnknown(m)
+func (m *PredictRootRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PredictRootRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetSequencedLeafCountRequest proto.InternalMessageInfo
+var xxx_messageInfo_PredictRootRequest proto.InternalMessageInfo
 
-func (m *GetSequencedLeafCountRequest) GetLogId() int64 {
+func (m *PredictRootRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *GetSequencedLeafCountRequest) GetChargeTo() *ChargeTo {
+func (m *PredictRootRequest) GetLeafHashes() [][]byte {
+	if m != nil {
+		return m.LeafHashes
+	}
+	return nil
+}
+
+func (m *PredictRootRequest) GetChargeTo() *ChargeTo {
 	if m != nil {
 		return m.ChargeTo
 	}
 	return nil
 }
 
-type GetSequencedLeafCountResponse struct {
-	LeafCount            int64    `protobuf:"varint,2,opt,name=leaf_count,json=leafCount,proto3" json:"leaf_count,omitempty"`
+type PredictRootResponse struct {
+	// The signed log root the leaves would be appended to.
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,1,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	// The size of the log after appending the leaves.
+	TreeSize int64 `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	// The root hash of the log after appending the leaves.
+	RootHash             []byte   `protobuf:"bytes,3,opt,name=root_hash,json=rootHash,proto3" json:"root_hash,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetSequencedLeafCountResponse) Reset()         { *m = GetSequencedLeafCountResponse{} }
-func (m *GetSequencedLeafCountResponse) String() string { return proto.CompactTextString(m) }
-func (*GetSequencedLeafCountResponse) ProtoMessage()    {}
-func (*GetSequencedLeafCountResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{14}
+func (m *PredictRootResponse) Reset()         { *m = PredictRootResponse{} }
+func (m *PredictRootResponse) String() string { return proto.CompactTextString(m) }
+func (*PredictRootResponse) ProtoMessage()    {}
+func (*PredictRootResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{16}
+}
+
+func (m *PredictRootResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PredictRootResponse.Unmarshal(m, b)
+}
+func (m *PredictRootResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PredictRootResponse.Marshal(b, m, deterministic)
+}
+func (m *PredictRootResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PredictRootResponse.Merge(m, src)
+}
+func (m *PredictRootResponse) XXX_Size() int {
+	return xxx_messageInfo_PredictRootResponse.Size(m)
+}
+func (m *PredictRootResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PredictRootResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PredictRootResponse proto.InternalMessageInfo
+
+func (m *PredictRootResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+func (m *PredictRootResponse) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *PredictRootResponse) GetRootHash() []byte {
+	if m != nil {
+		return m.RootHash
+	}
+	return nil
+}
+
+type VerifyInclusionRequest struct {
+	// The log whose hasher is used.
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// The Merkle leaf hash of the leaf.
+	LeafHash  []byte `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+	LeafIndex int64  `protobuf:"varint,3,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	TreeSize  int64  `protobuf:"varint,4,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	// The hashes of the inclusion proof, as in Proof.hashes.
+	Proof [][]byte `protobuf:"bytes,5,rep,name=proof,proto3" json:"proof,omitempty"`
+	// The root hash of the log at tree_size.
+	RootHash             []byte    `protobuf:"bytes,6,opt,name=root_hash,json=rootHash,proto3" json:"root_hash,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,7,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *VerifyInclusionRequest) Reset()         { *m = VerifyInclusionRequest{} }
+func (m *VerifyInclusionRequest) String() string { return proto.CompactTextString(m) }
+func (*VerifyInclusionRequest) ProtoMessage()    {}
+func (*VerifyInclusionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{17}
+}
+
+func (m *VerifyInclusionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyInclusionRequest.Unmarshal(m, b)
+}
+func (m *VerifyInclusionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyInclusionRequest.Marshal(b, m, deterministic)
+}
+func (m *VerifyInclusionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyInclusionRequest.Merge(m, src)
+}
+func (m *VerifyInclusionRequest) XXX_Size() int {
+	return xxx_messageInfo_VerifyInclusionRequest.Size(m)
+}
+func (m *VerifyInclusionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyInclusionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyInclusionRequest proto.InternalMessageInfo
+
+func (m *VerifyInclusionRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *VerifyInclusionRequest) GetLeafHash() []byte {
+	if m != nil {
+		return m.LeafHash
+	}
+	return nil
+}
+
+func (m *VerifyInclusionRequest) GetLeafIndex() int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return 0
+}
+
+func (m *VerifyInclusionRequest) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *VerifyInclusionRequest) GetProof() [][]byte {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *VerifyInclusionRequest) GetRootHash() []byte {
+	if m != nil {
+		return m.RootHash
+	}
+	return nil
+}
+
+func (m *VerifyInclusionRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
+}
+
+type VerifyInclusionResponse struct {
+	// Whether the proof shows that the leaf is included in the tree of
+	// tree_size with root_hash.
+	Valid bool `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	// The root hash computed from the leaf hash and the proof. It's empty if
+	// the proof is malformed, e.g. has the wrong number of hashes.
+	ComputedRootHash []byte `protobuf:"bytes,2,opt,name=computed_root_hash,json=computedRootHash,proto3" json:"computed_root_hash,omitempty"`
+	// Why the proof is invalid, if it is.
+	Reason               string   `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *VerifyInclusionResponse) Reset()         { *m = VerifyInclusionResponse{} }
+func (m *VerifyInclusionResponse) String() string { return proto.CompactTextString(m) }
+func (*VerifyInclusionResponse) ProtoMessage()    {}
+func (*VerifyInclusionResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{18}
+}
+
+func (m *VerifyInclusionResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_VerifyInclusionResponse.Unmarshal(m, b)
+}
+func (m *VerifyInclusionResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_VerifyInclusionResponse.Marshal(b, m, deterministic)
+}
+func (m *VerifyInclusionResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_VerifyInclusionResponse.Merge(m, src)
+}
+func (m *VerifyInclusionResponse) XXX_Size() int {
+	return xxx_messageInfo_VerifyInclusionResponse.Size(m)
+}
+func (m *VerifyInclusionResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_VerifyInclusionResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_VerifyInclusionResponse proto.InternalMessageInfo
+
+func (m *VerifyInclusionResponse) GetValid() bool {
+	if m != nil {
+		return m.Valid
+	}
+	return false
+}
+
+func (m *VerifyInclusionResponse) GetComputedRootHash() []byte {
+	if m != nil {
+		return m.ComputedRootHash
+	}
+	return nil
+}
+
+func (m *VerifyInclusionResponse) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type GetLatestSignedLogRootRequest struct {
+	LogId    int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	ChargeTo *ChargeTo `protobuf:"bytes,2,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	// If first_tree_size is non-zero, the response will include a consistency
+	// proof between first_tree_size and the new tree size (if not smaller).
+	FirstTreeSize        int64    `protobuf:"varint,3,opt,name=first_tree_size,json=firstTreeSize,proto3" json:"first_tree_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLatestSignedLogRootRequest) Reset()         { *m = GetLatestSignedLogRootRequest{} }
+func (m *GetLatestSignedLogRootRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLatestSignedLogRootRequest) ProtoMessage()    {}
+func (*GetLatestSignedLogRootRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{19}
+}
+
+func (m *GetLatestSignedLogRootRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLatestSignedLogRootRequest.Unmarshal(m, b)
+}
+func (m *GetLatestSignedLogRootRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLatestSignedLogRootRequest.Marshal(b, m, deterministic)
+}
+func (m *GetLatestSignedLogRootRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLatestSignedLogRootRequest.Merge(m, src)
+}
+func (m *GetLatestSignedLogRootRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLatestSignedLogRootRequest.Size(m)
+}
+func (m *GetLatestSignedLogRootRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLatestSignedLogRootRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLatestSignedLogRootRequest proto.InternalMessageInfo
+
+func (m *GetLatestSignedLogRootRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetLatestSignedLogRootRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
+}
+
+func (m *GetLatestSignedLogRootRequest) GetFirstTreeSize() int64 {
+	if m != nil {
+		return m.FirstTreeSize
+	}
+	return 0
+}
+
+type GetLatestSignedLogRootResponse struct {
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	// proof is filled in with a consistency proof if first_tree_size in
+	// GetLatestSignedLogRootRequest is non-zero (and within the tree size
+	// available at the server).
+	Proof                *Proof   `protobuf:"bytes,3,opt,name=proof,proto3" json:"proof,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetLatestSignedLogRootResponse) Reset()         { *m = GetLatestSignedLogRootResponse{} }
+func (m *GetLatestSignedLogRootResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLatestSignedLogRootResponse) ProtoMessage()    {}
+func (*GetLatestSignedLogRootResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{20}
+}
+
+func (m *GetLatestSignedLogRootResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLatestSignedLogRootResponse.Unmarshal(m, b)
+}
+func (m *GetLatestSignedLogRootResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLatestSignedLogRootResponse.Marshal(b, m, deterministic)
+}
+func (m *GetLatestSignedLogRootResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLatestSignedLogRootResponse.Merge(m, src)
+}
+func (m *GetLatestSignedLogRootResponse) XXX_Size() int {
+	return xxx_messageInfo_GetLatestSignedLogRootResponse.Size(m)
+}
+func (m *GetLatestSignedLogRootResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLatestSignedLogRootResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetLatestSignedLogRootResponse proto.InternalMessageInfo
+
+func (m *GetLatestSignedLogRootResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+func (m *GetLatestSignedLogRootResponse) GetProof() *Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+type GetSignedLogRootHistoryRequest struct {
+	LogId    int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	ChargeTo *ChargeTo `protobuf:"bytes,2,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	// The maximum number of roots to return. If zero, or larger than the
+	// server-chosen maximum, the latter is used.
+	Limit                int32    `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSignedLogRootHistoryRequest) Reset()         { *m = GetSignedLogRootHistoryRequest{} }
+func (m *GetSignedLogRootHistoryRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSignedLogRootHistoryRequest) ProtoMessage()    {}
+func (*GetSignedLogRootHistoryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{21}
+}
+
+func (m *GetSignedLogRootHistoryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSignedLogRootHistoryRequest.Unmarshal(m, b)
+}
+func (m *GetSignedLogRootHistoryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSignedLogRootHistoryRequest.Marshal(b, m, deterministic)
+}
+func (m *GetSignedLogRootHistoryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSignedLogRootHistoryRequest.Merge(m, src)
+}
+func (m *GetSignedLogRootHistoryRequest) XXX_Size() int {
+	return xxx_messageInfo_GetSignedLogRootHistoryRequest.Size(m)
+}
+func (m *GetSignedLogRootHistoryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSignedLogRootHistoryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSignedLogRootHistoryRequest proto.InternalMessageInfo
+
+func (m *GetSignedLogRootHistoryRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetSignedLogRootHistoryRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
+}
+
+func (m *GetSignedLogRootHistoryRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type GetSignedLogRootHistoryResponse struct {
+	// The signed log roots, newest first. Their log_root fields hold the tree
+	// size and timestamp of each root.
+	SignedLogRoots       []*SignedLogRoot `protobuf:"bytes,1,rep,name=signed_log_roots,json=signedLogRoots,proto3" json:"signed_log_roots,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *GetSignedLogRootHistoryResponse) Reset()         { *m = GetSignedLogRootHistoryResponse{} }
+func (m *GetSignedLogRootHistoryResponse) String() string { return proto.CompactTextString(m) }
+func (*GetSignedLogRootHistoryResponse) ProtoMessage()    {}
+func (*GetSignedLogRootHistoryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{22}
+}
+
+func (m *GetSignedLogRootHistoryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSignedLogRootHistoryResponse.Unmarshal(m, b)
+}
+func (m *GetSignedLogRootHistoryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSignedLogRootHistoryResponse.Marshal(b, m, deterministic)
+}
+func (m *GetSignedLogRootHistoryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSignedLogRootHistoryResponse.Merge(m, src)
+}
+func (m *GetSignedLogRootHistoryResponse) XXX_Size() int {
+	return xxx_messageInfo_GetSignedLogRootHistoryResponse.Size(m)
+}
+func (m *GetSignedLogRootHistoryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSignedLogRootHistoryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSignedLogRootHistoryResponse proto.InternalMessageInfo
+
+func (m *GetSignedLogRootHistoryResponse) GetSignedLogRoots() []*SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoots
+	}
+	return nil
+}
+
+// DO NOT USE - FOR DEBUGGING/TEST ONLY
+//
+// (Use GetLatestSignedLogRoot then de-serialize the Log Root and use
+// use the tree size field within.)
+type GetSequencedLeafCountRequest struct {
+	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,2,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *GetSequencedLeafCountRequest) Reset()         { *m = GetSequencedLeafCountRequest{} }
+func (m *GetSequencedLeafCountRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSequencedLeafCountRequest) ProtoMessage()    {}
+func (*GetSequencedLeafCountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{23}
+}
+
+func (m *GetSequencedLeafCountRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSequencedLeafCountRequest.Unmarshal(m, b)
+}
+func (m *GetSequencedLeafCountRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSequencedLeafCountRequest.Marshal(b, m, deterministic)
+}
+func (m *GetSequencedLeafCountRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSequencedLeafCountRequest.Merge(m, src)
+}
+func (m *GetSequencedLeafCountRequest) XXX_Size() int {
+	return xxx_messageInfo_GetSequencedLeafCountRequest.Size(m)
+}
+func (m *GetSequencedLeafCountRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSequencedLeafCountRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSequencedLeafCountRequest proto.InternalMessageInfo
+
+func (m *GetSequencedLeafCountRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetSequencedLeafCountRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
+}
+
+type GetSequencedLeafCountResponse struct {
+	LeafCount            int64    `protobuf:"varint,2,opt,name=leaf_count,json=leafCount,proto3" json:"leaf_count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSequencedLeafCountResponse) Reset()         { *m = GetSequencedLeafCountResponse{} }
+func (m *GetSequencedLeafCountResponse) String() string { return proto.CompactTextString(m) }
+func (*GetSequencedLeafCountResponse) ProtoMessage()    {}
+func (*GetSequencedLeafCountResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{24}
+}
+
+func (m *GetSequencedLeafCountResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSequencedLeafCountResponse.Unmarshal(m, b)
+}
+func (m *GetSequencedLeafCountResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSequencedLeafCountResponse.Marshal(b, m, deterministic)
+}
+func (m *GetSequencedLeafCountResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSequencedLeafCountResponse.Merge(m, src)
+}
+func (m *GetSequencedLeafCountResponse) XXX_Size() int {
+	return xxx_messageInfo_GetSequencedLeafCountResponse.Size(m)
+}
+func (m *GetSequencedLeafCountResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSequencedLeafCountResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSequencedLeafCountResponse proto.InternalMessageInfo
+
+func (m *GetSequencedLeafCountResponse) GetLeafCount() int64 {
+	if m != nil {
+		return m.LeafCount
+	}
+	return 0
+}
+
+type GetEntryAndProofRequest struct {
+	LogId     int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	LeafIndex int64     `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	TreeSize  int64     `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	ChargeTo  *ChargeTo `protobuf:"bytes,4,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	// The base of leaf_index, and of the leaf and proof indices in the response.
+	IndexBase            IndexBase `protobuf:"varint,5,opt,name=index_base,json=indexBase,proto3,enum=trillian.IndexBase" json:"index_base,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *GetEntryAndProofRequest) Reset()         { *m = GetEntryAndProofRequest{} }
+func (m *GetEntryAndProofRequest) String() string { return proto.CompactTextString(m) }
+func (*GetEntryAndProofRequest) ProtoMessage()    {}
+func (*GetEntryAndProofRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{25}
+}
+
+func (m *GetEntryAndProofRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetEntryAndProofRequest.Unmarshal(m, b)
+}
+func (m *GetEntryAndProofRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetEntryAndProofRequest.Marshal(b, m, deterministic)
+}
+func (m *GetEntryAndProofRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetEntryAndProofRequest.Merge(m, src)
+}
+func (m *GetEntryAndProofRequest) XXX_Size() int {
+	return xxx_messageInfo_GetEntryAndProofRequest.Size(m)
+}
+func (m *GetEntryAndProofRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetEntryAndProofRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetEntryAndProofRequest proto.InternalMessageInfo
+
+func (m *GetEntryAndProofRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *GetEntryAndProofRequest) GetLeafIndex() int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return 0
+}
+
+func (m *GetEntryAndProofRequest) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *GetEntryAndProofRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
+}
+
+func (m *GetEntryAndProofRequest) GetIndexBase() IndexBase {
+	if m != nil {
+		return m.IndexBase
+	}
+	return IndexBase_INDEX_BASE_ZERO
+}
+
+type GetEntryAndProofResponse struct {
+	Proof                *Proof         `protobuf:"bytes,2,opt,name=proof,proto3" json:"proof,omitempty"`
+	Leaf                 *LogLeaf       `protobuf:"bytes,3,opt,name=leaf,proto3" json:"leaf,omitempty"`
+	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,4,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *GetEntryAndProofResponse) Reset()         { *m = GetEntryAndProofResponse{} }
+func (m *GetEntryAndProofResponse) String() string { return proto.CompactTextString(m) }
+func (*GetEntryAndProofResponse) ProtoMessage()    {}
+func (*GetEntryAndProofResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{26}
+}
+
+func (m *GetEntryAndProofResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetEntryAndProofResponse.Unmarshal(m, b)
+}
+func (m *GetEntryAndProofResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetEntryAndProofResponse.Marshal(b, m, deterministic)
+}
+func (m *GetEntryAndProofResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetEntryAndProofResponse.Merge(m, src)
+}
+func (m *GetEntryAndProofResponse) XXX_Size() int {
+	return xxx_messageInfo_GetEntryAndProofResponse.Size(m)
+}
+func (m *GetEntryAndProofResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetEntryAndProofResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetEntryAndProofResponse proto.InternalMessageInfo
+
+func (m *GetEntryAndProofResponse) GetProof() *Proof {
+	if m != nil {
+		return m.Proof
+	}
+	return nil
+}
+
+func (m *GetEntryAndProofResponse) GetLeaf() *LogLeaf {
+	if m != nil {
+		return m.Leaf
+	}
+	return nil
+}
+
+func (m *GetEntryAndProofResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+type InitLogRequest struct {
+	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,2,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *InitLogRequest) Reset()         { *m = InitLogRequest{} }
+func (m *InitLogRequest) String() string { return proto.CompactTextString(m) }
+func (*InitLogRequest) ProtoMessage()    {}
+func (*InitLogRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{27}
+}
+
+func (m *InitLogRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InitLogRequest.Unmarshal(m, b)
+}
+func (m *InitLogRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InitLogRequest.Marshal(b, m, deterministic)
+}
+func (m *InitLogRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InitLogRequest.Merge(m, src)
+}
+func (m *InitLogRequest) XXX_Size() int {
+	return xxx_messageInfo_InitLogRequest.Size(m)
+}
+func (m *InitLogRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_InitLogRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InitLogRequest proto.InternalMessageInfo
+
+func (m *InitLogRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *InitLogRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
+}
+
+type InitLogResponse struct {
+	Created              *SignedLogRoot `protobuf:"bytes,1,opt,name=created,proto3" json:"created,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *InitLogResponse) Reset()         { *m = InitLogResponse{} }
+func (m *InitLogResponse) String() string { return proto.CompactTextString(m) }
+func (*InitLogResponse) ProtoMessage()    {}
+func (*InitLogResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{28}
+}
+
+func (m *InitLogResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_InitLogResponse.Unmarshal(m, b)
+}
+func (m *InitLogResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_InitLogResponse.Marshal(b, m, deterministic)
+}
+func (m *InitLogResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_InitLogResponse.Merge(m, src)
+}
+func (m *InitLogResponse) XXX_Size() int {
+	return xxx_messageInfo_InitLogResponse.Size(m)
+}
+func (m *InitLogResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_InitLogResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_InitLogResponse proto.InternalMessageInfo
+
+func (m *InitLogResponse) GetCreated() *SignedLogRoot {
+	if m != nil {
+		return m.Created
+	}
+	return nil
+}
+
+type QueueLeavesRequest struct {
+	LogId    int64      `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	Leaves   []*LogLeaf `protobuf:"bytes,2,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	ChargeTo *ChargeTo  `protobuf:"bytes,3,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	// If set, the leaves are only appended to the log if it satisfies the
+	// condition, which allows compare-and-append on the log.
+	Condition            *QueueCondition `protobuf:"bytes,4,opt,name=condition,proto3" json:"condition,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *QueueLeavesRequest) Reset()         { *m = QueueLeavesRequest{} }
+func (m *QueueLeavesRequest) String() string { return proto.CompactTextString(m) }
+func (*QueueLeavesRequest) ProtoMessage()    {}
+func (*QueueLeavesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{29}
+}
+
+func (m *QueueLeavesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueueLeavesRequest.Unmarshal(m, b)
+}
+func (m *QueueLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueueLeavesRequest.Marshal(b, m, deterministic)
+}
+func (m *QueueLeavesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueueLeavesRequest.Merge(m, src)
+}
+func (m *QueueLeavesRequest) XXX_Size() int {
+	return xxx_messageInfo_QueueLeavesRequest.Size(m)
+}
+func (m *QueueLeavesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueueLeavesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueueLeavesRequest proto.InternalMessageInfo
+
+func (m *QueueLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *QueueLeavesRequest) GetLeaves() []*LogLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+func (m *QueueLeavesRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
+}
+
+func (m *QueueLeavesRequest) GetCondition() *QueueCondition {
+	if m != nil {
+		return m.Condition
+	}
+	return nil
+}
+
+// QueueCondition makes a QueueLeaves request append its leaves only if the log
+// has the expected size, i.e. at the indices following it and next to each
+// other.
+//
+// The server checks the condition against the latest signed log root when the
+// leaves are queued, and returns FAILED_PRECONDITION if it doesn't hold, or if
+// any of the leaves is already in the log. As leaves are integrated
+// asynchronously, this is only a best-effort guard: the log signer checks the
+// condition again when it integrates the batch, e.g. against leaves queued
+// earlier by other requests. Batches which fail this check are not integrated,
+// and their leaves are quarantined with an error explaining why, where clients
+// can find them with ListQuarantinedLeaves.
+//
+// Leaves rejected by the server's leaf validation are not part of the batch.
+// Storage implementations which can't enforce the condition at integration
+// time return UNIMPLEMENTED.
+type QueueCondition struct {
+	// The size the log must have for the leaves to be appended. The first leaf
+	// of the batch gets this index.
+	ExpectedTreeSize     int64    `protobuf:"varint,1,opt,name=expected_tree_size,json=expectedTreeSize,proto3" json:"expected_tree_size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QueueCondition) Reset()         { *m = QueueCondition{} }
+func (m *QueueCondition) String() string { return proto.CompactTextString(m) }
+func (*QueueCondition) ProtoMessage()    {}
+func (*QueueCondition) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{30}
+}
+
+func (m *QueueCondition) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueueCondition.Unmarshal(m, b)
+}
+func (m *QueueCondition) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueueCondition.Marshal(b, m, deterministic)
+}
+func (m *QueueCondition) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueueCondition.Merge(m, src)
+}
+func (m *QueueCondition) XXX_Size() int {
+	return xxx_messageInfo_QueueCondition.Size(m)
+}
+func (m *QueueCondition) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueueCondition.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueueCondition proto.InternalMessageInfo
+
+func (m *QueueCondition) GetExpectedTreeSize() int64 {
+	if m != nil {
+		return m.ExpectedTreeSize
+	}
+	return 0
+}
+
+type QueueLeavesResponse struct {
+	// Same number and order as in the corresponding request.
+	QueuedLeaves []*QueuedLogLeaf `protobuf:"bytes,2,rep,name=queued_leaves,json=queuedLeaves,proto3" json:"queued_leaves,omitempty"`
+	// The result of queuing the leaves to the shadow log, if the log has one.
+	Shadow               *ShadowQueueResult `protobuf:"bytes,3,opt,name=shadow,proto3" json:"shadow,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *QueueLeavesResponse) Reset()         { *m = QueueLeavesResponse{} }
+func (m *QueueLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*QueueLeavesResponse) ProtoMessage()    {}
+func (*QueueLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{31}
+}
+
+func (m *QueueLeavesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueueLeavesResponse.Unmarshal(m, b)
+}
+func (m *QueueLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueueLeavesResponse.Marshal(b, m, deterministic)
+}
+func (m *QueueLeavesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueueLeavesResponse.Merge(m, src)
+}
+func (m *QueueLeavesResponse) XXX_Size() int {
+	return xxx_messageInfo_QueueLeavesResponse.Size(m)
+}
+func (m *QueueLeavesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueueLeavesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueueLeavesResponse proto.InternalMessageInfo
+
+func (m *QueueLeavesResponse) GetQueuedLeaves() []*QueuedLogLeaf {
+	if m != nil {
+		return m.QueuedLeaves
+	}
+	return nil
+}
+
+func (m *QueueLeavesResponse) GetShadow() *ShadowQueueResult {
+	if m != nil {
+		return m.Shadow
+	}
+	return nil
+}
+
+// ShadowQueueResult is the result of queuing leaves to the shadow log of a
+// log, which servers can be configured to dual-write to, e.g. while migrating
+// to a new leaf format. The leaves queued to the log are transformed and
+// queued to its shadow log after they are queued to the log. The two logs are
+// sequenced independently, so the leaves generally get different indices in
+// them, and failing to queue to the shadow log doesn't fail the request.
+type ShadowQueueResult struct {
+	// The ID of the shadow log.
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// Same number and order as in the corresponding request. Leaves which
+	// weren't queued to the log aren't queued to the shadow log either, and
+	// have an ABORTED status.
+	QueuedLeaves []*QueuedLogLeaf `protobuf:"bytes,2,rep,name=queued_leaves,json=queuedLeaves,proto3" json:"queued_leaves,omitempty"`
+	// Set if no leaves could be queued to the shadow log, in which case
+	// queued_leaves is empty.
+	Status               *status.Status `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *ShadowQueueResult) Reset()         { *m = ShadowQueueResult{} }
+func (m *ShadowQueueResult) String() string { return proto.CompactTextString(m) }
+func (*ShadowQueueResult) ProtoMessage()    {}
+func (*ShadowQueueResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{32}
+}
+
+func (m *ShadowQueueResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ShadowQueueResult.Unmarshal(m, b)
+}
+func (m *ShadowQueueResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ShadowQueueResult.Marshal(b, m, deterministic)
+}
+func (m *ShadowQueueResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ShadowQueueResult.Merge(m, src)
+}
+func (m *ShadowQueueResult) XXX_Size() int {
+	return xxx_messageInfo_ShadowQueueResult.Size(m)
+}
+func (m *ShadowQueueResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_ShadowQueueResult.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ShadowQueueResult proto.InternalMessageInfo
+
+func (m *ShadowQueueResult) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *ShadowQueueResult) GetQueuedLeaves() []*QueuedLogLeaf {
+	if m != nil {
+		return m.QueuedLeaves
+	}
+	return nil
+}
+
+func (m *ShadowQueueResult) GetStatus() *status.Status {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type AddSequencedLeavesRequest struct {
+	LogId                int64      `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	Leaves               []*LogLeaf `protobuf:"bytes,2,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	ChargeTo             *ChargeTo  `protobuf:"bytes,4,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *AddSequencedLeavesRequest) Reset()         { *m = AddSequencedLeavesRequest{} }
+func (m *AddSequencedLeavesRequest) String() string { return proto.CompactTextString(m) }
+func (*AddSequencedLeavesRequest) ProtoMessage()    {}
+func (*AddSequencedLeavesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{33}
+}
+
+func (m *AddSequencedLeavesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddSequencedLeavesRequest.Unmarshal(m, b)
+}
+func (m *AddSequencedLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddSequencedLeavesRequest.Marshal(b, m, deterministic)
+}
+func (m *AddSequencedLeavesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddSequencedLeavesRequest.Merge(m, src)
+}
+func (m *AddSequencedLeavesRequest) XXX_Size() int {
+	return xxx_messageInfo_AddSequencedLeavesRequest.Size(m)
+}
+func (m *AddSequencedLeavesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddSequencedLeavesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddSequencedLeavesRequest proto.InternalMessageInfo
+
+func (m *AddSequencedLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *AddSequencedLeavesRequest) GetLeaves() []*LogLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
 }
 
-func (m *GetSequencedLeafCountResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetSequencedLeafCountResponse.Unmarshal(m, b)
+func (m *AddSequencedLeavesRequest) GetChargeTo() *ChargeTo {
+	if m != nil {
+		return m.ChargeTo
+	}
+	return nil
 }
-func (m *GetSequencedLeafCountResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetSequencedLeafCountResponse.Marshal(b, m, deterministic)
+
+type AddSequencedLeavesResponse struct {
+	// Same number and order as in the corresponding request.
+	Results              []*QueuedLogLeaf `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
 }
-func (m *GetSequencedLeafCountResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetSequencedLeafCountResponse.Merge(m, src)
+
+func (m *AddSequencedLeavesResponse) Reset()         { *m = AddSequencedLeavesResponse{} }
+func (m *AddSequencedLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*AddSequencedLeavesResponse) ProtoMessage()    {}
+func (*AddSequencedLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{34}
 }
-func (m *GetSequencedLeafCountResponse) XXX_Size() int {
-	return xxx_messageInfo_GetSequencedLeafCountResponse.Size(m)
+
+func (m *AddSequencedLeavesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddSequencedLeavesResponse.Unmarshal(m, b)
 }
-func (m *GetSequencedLeafCountResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetSequencedLeafCountResponse.DiscardUnknown(m)
+func (m *AddSequencedLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddSequencedLeavesResponse.Marshal(b, m, deterministic)
+}
+func (m *AddSequencedLeavesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddSequencedLeavesResponse.Merge(m, src)
+}
+func (m *AddSequencedLeavesResponse) XXX_Size() int {
+	return xxx_messageInfo_AddSequencedLeavesResponse.Size(m)
+}
+func (m *AddSequencedLeavesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddSequencedLeavesResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetSequencedLeafCountResponse proto.InternalMessageInfo
+var xxx_messageInfo_AddSequencedLeavesResponse proto.InternalMessageInfo
 
-func (m *GetSequencedLeafCountResponse) GetLeafCount() int64 {
+func (m *AddSequencedLeavesResponse) GetResults() []*QueuedLogLeaf {
 	if m != nil {
-		return m.LeafCount
+		return m.Results
 	}
-	return 0
+	return nil
 }
 
-type GetEntryAndProofRequest struct {
-	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
-	LeafIndex            int64     `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
-	TreeSize             int64     `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+type BulkAddSequencedLeavesRequest struct {
+	LogId  int64      `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	Leaves []*LogLeaf `protobuf:"bytes,2,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	// The maximum number of leaves added in a single transaction. Zero means
+	// the server's default.
+	ChunkSize            int32     `protobuf:"varint,3,opt,name=chunk_size,json=chunkSize,proto3" json:"chunk_size,omitempty"`
 	ChargeTo             *ChargeTo `protobuf:"bytes,4,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *GetEntryAndProofRequest) Reset()         { *m = GetEntryAndProofRequest{} }
-func (m *GetEntryAndProofRequest) String() string { return proto.CompactTextString(m) }
-func (*GetEntryAndProofRequest) ProtoMessage()    {}
-func (*GetEntryAndProofRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{15}
+func (m *BulkAddSequencedLeavesRequest) Reset()         { *m = BulkAddSequencedLeavesRequest{} }
+func (m *BulkAddSequencedLeavesRequest) String() string { return proto.CompactTextString(m) }
+func (*BulkAddSequencedLeavesRequest) ProtoMessage()    {}
+func (*BulkAddSequencedLeavesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{35}
 }
 
-func (m *GetEntryAndProofRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetEntryAndProofRequest.Unmarshal(m, b)
+func (m *BulkAddSequencedLeavesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BulkAddSequencedLeavesRequest.Unmarshal(m, b)
 }
-func (m *GetEntryAndProofRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetEntryAndProofRequest.Marshal(b, m, deterministic)
+func (m *BulkAddSequencedLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BulkAddSequencedLeavesRequest.Marshal(b, m, deterministic)
 }
-func (m *GetEntryAndProofRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetEntryAndProofRequest.Merge(m, src)
+func (m *BulkAddSequencedLeavesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BulkAddSequencedLeavesRequest.Merge(m, src)
 }
-func (m *GetEntryAndProofRequest) XXX_Size() int {
-	return xxx_messageInfo_GetEntryAndProofRequest.Size(m)
+func (m *BulkAddSequencedLeavesRequest) XXX_Size() int {
+	return xxx_messageInfo_BulkAddSequencedLeavesRequest.Size(m)
 }
-func (m *GetEntryAndProofRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetEntryAndProofRequest.DiscardUnknown(m)
+func (m *BulkAddSequencedLeavesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BulkAddSequencedLeavesRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetEntryAndProofRequest proto.InternalMessageInfo
+var xxx_messageInfo_BulkAddSequencedLeavesRequest proto.InternalMessageInfo
 
-func (m *GetEntryAndProofRequest) GetLogId() int64 {
+func (m *BulkAddSequencedLeavesRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *GetEntryAndProofRequest) GetLeafIndex() int64 {
+func (m *BulkAddSequencedLeavesRequest) GetLeaves() []*LogLeaf {
 	if m != nil {
-		return m.LeafIndex
+		return m.Leaves
 	}
-	return 0
+	return nil
 }
 
-func (m *GetEntryAndProofRequest) GetTreeSize() int64 {
+func (m *BulkAddSequencedLeavesRequest) GetChunkSize() int32 {
 	if m != nil {
-		return m.TreeSize
+		return m.ChunkSize
 	}
 	return 0
 }
 
-func (m *GetEntryAndProofRequest) GetChargeTo() *ChargeTo {
+func (m *BulkAddSequencedLeavesRequest) GetChargeTo() *ChargeTo {
 	if m != nil {
 		return m.ChargeTo
 	}
 	return nil
 }
 
-type GetEntryAndProofResponse struct {
-	Proof                *Proof         `protobuf:"bytes,2,opt,name=proof,proto3" json:"proof,omitempty"`
-	Leaf                 *LogLeaf       `protobuf:"bytes,3,opt,name=leaf,proto3" json:"leaf,omitempty"`
-	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,4,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
-	XXX_unrecognized     []byte         `json:"-"`
-	XXX_sizecache        int32          `json:"-"`
+type BulkAddSequencedLeavesResponse struct {
+	// Same number and order as in the corresponding request.
+	Results []*QueuedLogLeaf `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	// The number of leaves added by the request.
+	Added int64 `protobuf:"varint,2,opt,name=added,proto3" json:"added,omitempty"`
+	// The number of leaves which were already stored at their index, e.g. by an
+	// earlier attempt at the same request, with an ALREADY_EXISTS status.
+	Existing int64 `protobuf:"varint,3,opt,name=existing,proto3" json:"existing,omitempty"`
+	// The number of leaves rejected, e.g. because another leaf is stored at
+	// their index, with a FAILED_PRECONDITION status.
+	Rejected             int64    `protobuf:"varint,4,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetEntryAndProofResponse) Reset()         { *m = GetEntryAndProofResponse{} }
-func (m *GetEntryAndProofResponse) String() string { return proto.CompactTextString(m) }
-func (*GetEntryAndProofResponse) ProtoMessage()    {}
-func (*GetEntryAndProofResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{16}
+func (m *BulkAddSequencedLeavesResponse) Reset()         { *m = BulkAddSequencedLeavesResponse{} }
+func (m *BulkAddSequencedLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*BulkAddSequencedLeavesResponse) ProtoMessage()    {}
+func (*BulkAddSequencedLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{36}
 }
 
-func (m *GetEntryAndProofResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetEntryAndProofResponse.Unmarshal(m, b)
+func (m *BulkAddSequencedLeavesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BulkAddSequencedLeavesResponse.Unmarshal(m, b)
 }
-func (m *GetEntryAndProofResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetEntryAndProofResponse.Marshal(b, m, deterministic)
+func (m *BulkAddSequencedLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BulkAddSequencedLeavesResponse.Marshal(b, m, deterministic)
 }
-func (m *GetEntryAndProofResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetEntryAndProofResponse.Merge(m, src)
+func (m *BulkAddSequencedLeavesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BulkAddSequencedLeavesResponse.Merge(m, src)
 }
-func (m *GetEntryAndProofResponse) XXX_Size() int {
-	return xxx_messageInfo_GetEntryAndProofResponse.Size(m)
+func (m *BulkAddSequencedLeavesResponse) XXX_Size() int {
+	return xxx_messageInfo_BulkAddSequencedLeavesResponse.Size(m)
 }
-func (m *GetEntryAndProofResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetEntryAndProofResponse.DiscardUnknown(m)
+func (m *BulkAddSequencedLeavesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_BulkAddSequencedLeavesResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetEntryAndProofResponse proto.InternalMessageInfo
+var xxx_messageInfo_BulkAddSequencedLeavesResponse proto.InternalMessageInfo
 
-func (m *GetEntryAndProofResponse) GetProof() *Proof {
+func (m *BulkAddSequencedLeavesResponse) GetResults() []*QueuedLogLeaf {
 	if m != nil {
-		return m.Proof
+		return m.Results
 	}
 	return nil
 }
 
-func (m *GetEntryAndProofResponse) GetLeaf() *LogLeaf {
+func (m *BulkAddSequencedLeavesResponse) GetAdded() int64 {
 	if m != nil {
-		return m.Leaf
+		return m.Added
 	}
-	return nil
+	return 0
 }
 
-func (m *GetEntryAndProofResponse) GetSignedLogRoot() *SignedLogRoot {
+func (m *BulkAddSequencedLeavesResponse) GetExisting() int64 {
 	if m != nil {
-		return m.SignedLogRoot
+		return m.Existing
 	}
-	return nil
+	return 0
 }
 
-type InitLogRequest struct {
+func (m *BulkAddSequencedLeavesResponse) GetRejected() int64 {
+	if m != nil {
+		return m.Rejected
+	}
+	return 0
+}
+
+type GetLeavesByIndexRequest struct {
 	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
-	ChargeTo             *ChargeTo `protobuf:"bytes,2,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	LeafIndex            []int64   `protobuf:"varint,2,rep,packed,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,5,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *InitLogRequest) Reset()         { *m = InitLogRequest{} }
-func (m *InitLogRequest) String() string { return proto.CompactTextString(m) }
-func (*InitLogRequest) ProtoMessage()    {}
-func (*InitLogRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{17}
+func (m *GetLeavesByIndexRequest) Reset()         { *m = GetLeavesByIndexRequest{} }
+func (m *GetLeavesByIndexRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLeavesByIndexRequest) ProtoMessage()    {}
+func (*GetLeavesByIndexRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{37}
 }
 
-func (m *InitLogRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_InitLogRequest.Unmarshal(m, b)
+func (m *GetLeavesByIndexRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLeavesByIndexRequest.Unmarshal(m, b)
 }
-func (m *InitLogRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_InitLogRequest.Marshal(b, m, deterministic)
+func (m *GetLeavesByIndexRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLeavesByIndexRequest.Marshal(b, m, deterministic)
 }
-func (m *InitLogRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_InitLogRequest.Merge(m, src)
+func (m *GetLeavesByIndexRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLeavesByIndexRequest.Merge(m, src)
 }
-func (m *InitLogRequest) XXX_Size() int {
-	return xxx_messageInfo_InitLogRequest.Size(m)
+func (m *GetLeavesByIndexRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLeavesByIndexRequest.Size(m)
 }
-func (m *InitLogRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_InitLogRequest.DiscardUnknown(m)
+func (m *GetLeavesByIndexRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLeavesByIndexRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_InitLogRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetLeavesByIndexRequest proto.InternalMessageInfo
 
-func (m *InitLogRequest) GetLogId() int64 {
+func (m *GetLeavesByIndexRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *InitLogRequest) GetChargeTo() *ChargeTo {
+func (m *GetLeavesByIndexRequest) GetLeafIndex() []int64 {
+	if m != nil {
+		return m.LeafIndex
+	}
+	return nil
+}
+
+func (m *GetLeavesByIndexRequest) GetChargeTo() *ChargeTo {
 	if m != nil {
 		return m.ChargeTo
 	}
 	return nil
 }
 
-type InitLogResponse struct {
-	Created              *SignedLogRoot `protobuf:"bytes,1,opt,name=created,proto3" json:"created,omitempty"`
+type GetLeavesByIndexResponse struct {
+	// TODO(gbelvin): Response syntax does not allow for some requested leaves to be available, and some not (but using QueuedLogLeaf might)
+	Leaves               []*LogLeaf     `protobuf:"bytes,2,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,3,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
 	XXX_unrecognized     []byte         `json:"-"`
 	XXX_sizecache        int32          `json:"-"`
 }
 
-func (m *InitLogResponse) Reset()         { *m = InitLogResponse{} }
-func (m *InitLogResponse) String() string { return proto.CompactTextString(m) }
-func (*InitLogResponse) ProtoMessage()    {}
-func (*InitLogResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{18}
+func (m *GetLeavesByIndexResponse) Reset()         { *m = GetLeavesByIndexResponse{} }
+func (m *GetLeavesByIndexResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLeavesByIndexResponse) ProtoMessage()    {}
+func (*GetLeavesByIndexResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{38}
 }
 
-func (m *InitLogResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_InitLogResponse.Unmarshal(m, b)
+func (m *GetLeavesByIndexResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLeavesByIndexResponse.Unmarshal(m, b)
 }
-func (m *InitLogResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_InitLogResponse.Marshal(b, m, deterministic)
+func (m *GetLeavesByIndexResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLeavesByIndexResponse.Marshal(b, m, deterministic)
 }
-func (m *InitLogResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_InitLogResponse.Merge(m, src)
+func (m *GetLeavesByIndexResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLeavesByIndexResponse.Merge(m, src)
 }
-func (m *InitLogResponse) XXX_Size() int {
-	return xxx_messageInfo_InitLogResponse.Size(m)
+func (m *GetLeavesByIndexResponse) XXX_Size() int {
+	return xxx_messageInfo_GetLeavesByIndexResponse.Size(m)
 }
-func (m *InitLogResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_InitLogResponse.DiscardUnknown(m)
+func (m *GetLeavesByIndexResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLeavesByIndexResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_InitLogResponse proto.InternalMessageInfo
+var xxx_messageInfo_GetLeavesByIndexResponse proto.InternalMessageInfo
 
-func (m *InitLogResponse) GetCreated() *SignedLogRoot {
+func (m *GetLeavesByIndexResponse) GetLeaves() []*LogLeaf {
 	if m != nil {
-		return m.Created
+		return m.Leaves
 	}
 	return nil
 }
 
-type QueueLeavesRequest struct {
-	LogId                int64      `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
-	Leaves               []*LogLeaf `protobuf:"bytes,2,rep,name=leaves,proto3" json:"leaves,omitempty"`
-	ChargeTo             *ChargeTo  `protobuf:"bytes,3,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
-	XXX_unrecognized     []byte     `json:"-"`
-	XXX_sizecache        int32      `json:"-"`
+func (m *GetLeavesByIndexResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
 }
 
-func (m *QueueLeavesRequest) Reset()         { *m = QueueLeavesRequest{} }
-func (m *QueueLeavesRequest) String() string { return proto.CompactTextString(m) }
-func (*QueueLeavesRequest) ProtoMessage()    {}
-func (*QueueLeavesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{19}
+type GetLeavesByRangeRequest struct {
+	LogId      int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	StartIndex int64     `protobuf:"varint,2,opt,name=start_index,json=startIndex,proto3" json:"start_index,omitempty"`
+	Count      int64     `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	ChargeTo   *ChargeTo `protobuf:"bytes,4,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	// The base of start_index, and of the leaf indices in the response.
+	IndexBase            IndexBase `protobuf:"varint,5,opt,name=index_base,json=indexBase,proto3,enum=trillian.IndexBase" json:"index_base,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *QueueLeavesRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_QueueLeavesRequest.Unmarshal(m, b)
+func (m *GetLeavesByRangeRequest) Reset()         { *m = GetLeavesByRangeRequest{} }
+func (m *GetLeavesByRangeRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLeavesByRangeRequest) ProtoMessage()    {}
+func (*GetLeavesByRangeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{39}
 }
-func (m *QueueLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_QueueLeavesRequest.Marshal(b, m, deterministic)
+
+func (m *GetLeavesByRangeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLeavesByRangeRequest.Unmarshal(m, b)
 }
-func (m *QueueLeavesRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_QueueLeavesRequest.Merge(m, src)
+func (m *GetLeavesByRangeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLeavesByRangeRequest.Marshal(b, m, deterministic)
 }
-func (m *QueueLeavesRequest) XXX_Size() int {
-	return xxx_messageInfo_QueueLeavesRequest.Size(m)
+func (m *GetLeavesByRangeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLeavesByRangeRequest.Merge(m, src)
 }
-func (m *QueueLeavesRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_QueueLeavesRequest.DiscardUnknown(m)
+func (m *GetLeavesByRangeRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLeavesByRangeRequest.Size(m)
+}
+func (m *GetLeavesByRangeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLeavesByRangeRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_QueueLeavesRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetLeavesByRangeRequest proto.InternalMessageInfo
 
-func (m *QueueLeavesRequest) GetLogId() int64 {
+func (m *GetLeavesByRangeRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *QueueLeavesRequest) GetLeaves() []*LogLeaf {
+func (m *GetLeavesByRangeRequest) GetStartIndex() int64 {
 	if m != nil {
-		return m.Leaves
+		return m.StartIndex
 	}
-	return nil
+	return 0
 }
 
-func (m *QueueLeavesRequest) GetChargeTo() *ChargeTo {
+func (m *GetLeavesByRangeRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *GetLeavesByRangeRequest) GetChargeTo() *ChargeTo {
 	if m != nil {
 		return m.ChargeTo
 	}
 	return nil
 }
 
-type QueueLeavesResponse struct {
-	// Same number and order as in the corresponding request.
-	QueuedLeaves         []*QueuedLogLeaf `protobuf:"bytes,2,rep,name=queued_leaves,json=queuedLeaves,proto3" json:"queued_leaves,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+func (m *GetLeavesByRangeRequest) GetIndexBase() IndexBase {
+	if m != nil {
+		return m.IndexBase
+	}
+	return IndexBase_INDEX_BASE_ZERO
 }
 
-func (m *QueueLeavesResponse) Reset()         { *m = QueueLeavesResponse{} }
-func (m *QueueLeavesResponse) String() string { return proto.CompactTextString(m) }
-func (*QueueLeavesResponse) ProtoMessage()    {}
-func (*QueueLeavesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{20}
+type GetLeavesByRangeResponse struct {
+	// Returned log leaves starting from the `start_index` of the request, in
+	// order. There may be fewer than `request.count` leaves returned, if the
+	// requested range extended beyond the size of the tree or if the server opted
+	// to return fewer leaves than requested.
+	Leaves               []*LogLeaf     `protobuf:"bytes,1,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
-func (m *QueueLeavesResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_QueueLeavesResponse.Unmarshal(m, b)
+func (m *GetLeavesByRangeResponse) Reset()         { *m = GetLeavesByRangeResponse{} }
+func (m *GetLeavesByRangeResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLeavesByRangeResponse) ProtoMessage()    {}
+func (*GetLeavesByRangeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{40}
 }
-func (m *QueueLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_QueueLeavesResponse.Marshal(b, m, deterministic)
+
+func (m *GetLeavesByRangeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLeavesByRangeResponse.Unmarshal(m, b)
 }
-func (m *QueueLeavesResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_QueueLeavesResponse.Merge(m, src)
+func (m *GetLeavesByRangeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLeavesByRangeResponse.Marshal(b, m, deterministic)
 }
-func (m *QueueLeavesResponse) XXX_Size() int {
-	return xxx_messageInfo_QueueLeavesResponse.Size(m)
+func (m *GetLeavesByRangeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLeavesByRangeResponse.Merge(m, src)
 }
-func (m *QueueLeavesResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_QueueLeavesResponse.DiscardUnknown(m)
+func (m *GetLeavesByRangeResponse) XXX_Size() int {
+	return xxx_messageInfo_GetLeavesByRangeResponse.Size(m)
+}
+func (m *GetLeavesByRangeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLeavesByRangeResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_QueueLeavesResponse proto.InternalMessageInfo
+var xxx_messageInfo_GetLeavesByRangeResponse proto.InternalMessageInfo
 
-func (m *QueueLeavesResponse) GetQueuedLeaves() []*QueuedLogLeaf {
+func (m *GetLeavesByRangeResponse) GetLeaves() []*LogLeaf {
 	if m != nil {
-		return m.QueuedLeaves
+		return m.Leaves
 	}
 	return nil
 }
 
-type AddSequencedLeavesRequest struct {
-	LogId                int64      `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
-	Leaves               []*LogLeaf `protobuf:"bytes,2,rep,name=leaves,proto3" json:"leaves,omitempty"`
-	ChargeTo             *ChargeTo  `protobuf:"bytes,4,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
-	XXX_unrecognized     []byte     `json:"-"`
-	XXX_sizecache        int32      `json:"-"`
+func (m *GetLeavesByRangeResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
 }
 
-func (m *AddSequencedLeavesRequest) Reset()         { *m = AddSequencedLeavesRequest{} }
-func (m *AddSequencedLeavesRequest) String() string { return proto.CompactTextString(m) }
-func (*AddSequencedLeavesRequest) ProtoMessage()    {}
-func (*AddSequencedLeavesRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{21}
+type GetLeavesByKeyRangeRequest struct {
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// The leaves returned start from the first one whose (ordering key, leaf
+	// index) pair is greater than or equal to (start_key, start_index). To
+	// continue after a response, pass the key and index of its last leaf, with
+	// the index incremented by one.
+	StartKey   []byte `protobuf:"bytes,2,opt,name=start_key,json=startKey,proto3" json:"start_key,omitempty"`
+	StartIndex int64  `protobuf:"varint,3,opt,name=start_index,json=startIndex,proto3" json:"start_index,omitempty"`
+	// If set, only leaves whose ordering key is less than end_key are returned.
+	EndKey               []byte    `protobuf:"bytes,4,opt,name=end_key,json=endKey,proto3" json:"end_key,omitempty"`
+	Count                int64     `protobuf:"varint,5,opt,name=count,proto3" json:"count,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,6,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *AddSequencedLeavesRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_AddSequencedLeavesRequest.Unmarshal(m, b)
+func (m *GetLeavesByKeyRangeRequest) Reset()         { *m = GetLeavesByKeyRangeRequest{} }
+func (m *GetLeavesByKeyRangeRequest) String() string { return proto.CompactTextString(m) }
+func (*GetLeavesByKeyRangeRequest) ProtoMessage()    {}
+func (*GetLeavesByKeyRangeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{41}
 }
-func (m *AddSequencedLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_AddSequencedLeavesRequest.Marshal(b, m, deterministic)
+
+func (m *GetLeavesByKeyRangeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLeavesByKeyRangeRequest.Unmarshal(m, b)
 }
-func (m *AddSequencedLeavesRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_AddSequencedLeavesRequest.Merge(m, src)
+func (m *GetLeavesByKeyRangeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLeavesByKeyRangeRequest.Marshal(b, m, deterministic)
 }
-func (m *AddSequencedLeavesRequest) XXX_Size() int {
-	return xxx_messageInfo_AddSequencedLeavesRequest.Size(m)
+func (m *GetLeavesByKeyRangeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLeavesByKeyRangeRequest.Merge(m, src)
 }
-func (m *AddSequencedLeavesRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_AddSequencedLeavesRequest.DiscardUnknown(m)
+func (m *GetLeavesByKeyRangeRequest) XXX_Size() int {
+	return xxx_messageInfo_GetLeavesByKeyRangeRequest.Size(m)
+}
+func (m *GetLeavesByKeyRangeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLeavesByKeyRangeRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_AddSequencedLeavesRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetLeavesByKeyRangeRequest proto.InternalMessageInfo
 
-func (m *AddSequencedLeavesRequest) GetLogId() int64 {
+func (m *GetLeavesByKeyRangeRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *AddSequencedLeavesRequest) GetLeaves() []*LogLeaf {
+func (m *GetLeavesByKeyRangeRequest) GetStartKey() []byte {
 	if m != nil {
-		return m.Leaves
+		return m.StartKey
 	}
 	return nil
 }
 
-func (m *AddSequencedLeavesRequest) GetChargeTo() *ChargeTo {
+func (m *GetLeavesByKeyRangeRequest) GetStartIndex() int64 {
+	if m != nil {
+		return m.StartIndex
+	}
+	return 0
+}
+
+func (m *GetLeavesByKeyRangeRequest) GetEndKey() []byte {
+	if m != nil {
+		return m.EndKey
+	}
+	return nil
+}
+
+func (m *GetLeavesByKeyRangeRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *GetLeavesByKeyRangeRequest) GetChargeTo() *ChargeTo {
 	if m != nil {
 		return m.ChargeTo
 	}
 	return nil
 }
 
-type AddSequencedLeavesResponse struct {
-	// Same number and order as in the corresponding request.
-	Results              []*QueuedLogLeaf `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
-	XXX_unrecognized     []byte           `json:"-"`
-	XXX_sizecache        int32            `json:"-"`
+type GetLeavesByKeyRangeResponse struct {
+	// Returned log leaves, in order. There may be fewer than `request.count`
+	// leaves returned, if there are no more leaves in the range within the size
+	// of the tree, or if the server opted to return fewer leaves than requested.
+	Leaves []*LogLeaf `protobuf:"bytes,1,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	// The ordering key of each of the leaves.
+	OrderingKeys         [][]byte       `protobuf:"bytes,2,rep,name=ordering_keys,json=orderingKeys,proto3" json:"ordering_keys,omitempty"`
+	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,3,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
-func (m *AddSequencedLeavesResponse) Reset()         { *m = AddSequencedLeavesResponse{} }
-func (m *AddSequencedLeavesResponse) String() string { return proto.CompactTextString(m) }
-func (*AddSequencedLeavesResponse) ProtoMessage()    {}
-func (*AddSequencedLeavesResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{22}
+func (m *GetLeavesByKeyRangeResponse) Reset()         { *m = GetLeavesByKeyRangeResponse{} }
+func (m *GetLeavesByKeyRangeResponse) String() string { return proto.CompactTextString(m) }
+func (*GetLeavesByKeyRangeResponse) ProtoMessage()    {}
+func (*GetLeavesByKeyRangeResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{42}
 }
 
-func (m *AddSequencedLeavesResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_AddSequencedLeavesResponse.Unmarshal(m, b)
+func (m *GetLeavesByKeyRangeResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetLeavesByKeyRangeResponse.Unmarshal(m, b)
 }
-func (m *AddSequencedLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_AddSequencedLeavesResponse.Marshal(b, m, deterministic)
+func (m *GetLeavesByKeyRangeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetLeavesByKeyRangeResponse.Marshal(b, m, deterministic)
 }
-func (m *AddSequencedLeavesResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_AddSequencedLeavesResponse.Merge(m, src)
+func (m *GetLeavesByKeyRangeResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetLeavesByKeyRangeResponse.Merge(m, src)
 }
-func (m *AddSequencedLeavesResponse) XXX_Size() int {
-	return xxx_messageInfo_AddSequencedLeavesResponse.Size(m)
+func (m *GetLeavesByKeyRangeResponse) XXX_Size() int {
+	return xxx_messageInfo_GetLeavesByKeyRangeResponse.Size(m)
 }
-func (m *AddSequencedLeavesResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_AddSequencedLeavesResponse.DiscardUnknown(m)
+func (m *GetLeavesByKeyRangeResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetLeavesByKeyRangeResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_AddSequencedLeavesResponse proto.InternalMessageInfo
+var xxx_messageInfo_GetLeavesByKeyRangeResponse proto.InternalMessageInfo
 
-func (m *AddSequencedLeavesResponse) GetResults() []*QueuedLogLeaf {
+func (m *GetLeavesByKeyRangeResponse) GetLeaves() []*LogLeaf {
 	if m != nil {
-		return m.Results
+		return m.Leaves
 	}
 	return nil
 }
 
-type GetLeavesByIndexRequest struct {
+func (m *GetLeavesByKeyRangeResponse) GetOrderingKeys() [][]byte {
+	if m != nil {
+		return m.OrderingKeys
+	}
+	return nil
+}
+
+func (m *GetLeavesByKeyRangeResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+type GetEffectiveLeavesRequest struct {
 	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
-	LeafIndex            []int64   `protobuf:"varint,2,rep,packed,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
-	ChargeTo             *ChargeTo `protobuf:"bytes,5,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	StartIndex           int64     `protobuf:"varint,2,opt,name=start_index,json=startIndex,proto3" json:"start_index,omitempty"`
+	Count                int64     `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,4,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *GetLeavesByIndexRequest) Reset()         { *m = GetLeavesByIndexRequest{} }
-func (m *GetLeavesByIndexRequest) String() string { return proto.CompactTextString(m) }
-func (*GetLeavesByIndexRequest) ProtoMessage()    {}
-func (*GetLeavesByIndexRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{23}
+func (m *GetEffectiveLeavesRequest) Reset()         { *m = GetEffectiveLeavesRequest{} }
+func (m *GetEffectiveLeavesRequest) String() string { return proto.CompactTextString(m) }
+func (*GetEffectiveLeavesRequest) ProtoMessage()    {}
+func (*GetEffectiveLeavesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{43}
 }
 
-func (m *GetLeavesByIndexRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetLeavesByIndexRequest.Unmarshal(m, b)
+func (m *GetEffectiveLeavesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetEffectiveLeavesRequest.Unmarshal(m, b)
 }
-func (m *GetLeavesByIndexRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetLeavesByIndexRequest.Marshal(b, m, deterministic)
+func (m *GetEffectiveLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetEffectiveLeavesRequest.Marshal(b, m, deterministic)
 }
-func (m *GetLeavesByIndexRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetLeavesByIndexRequest.Merge(m, src)
+func (m *GetEffectiveLeavesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetEffectiveLeavesRequest.Merge(m, src)
 }
-func (m *GetLeavesByIndexRequest) XXX_Size() int {
-	return xxx_messageInfo_GetLeavesByIndexRequest.Size(m)
+func (m *GetEffectiveLeavesRequest) XXX_Size() int {
+	return xxx_messageInfo_GetEffectiveLeavesRequest.Size(m)
 }
-func (m *GetLeavesByIndexRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetLeavesByIndexRequest.DiscardUnknown(m)
+func (m *GetEffectiveLeavesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetEffectiveLeavesRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetLeavesByIndexRequest proto.InternalMessageInfo
+var xxx_messageInfo_GetEffectiveLeavesRequest proto.InternalMessageInfo
 
-func (m *GetLeavesByIndexRequest) GetLogId() int64 {
+func (m *GetEffectiveLeavesRequest) GetLogId() int64 {
 	if m != nil {
 		return m.LogId
 	}
 	return 0
 }
 
-func (m *GetLeavesByIndexRequest) GetLeafIndex() []int64 {
+func (m *GetEffectiveLeavesRequest) GetStartIndex() int64 {
 	if m != nil {
-		return m.LeafIndex
+		return m.StartIndex
 	}
-	return nil
+	return 0
 }
 
-func (m *GetLeavesByIndexRequest) GetChargeTo() *ChargeTo {
+func (m *GetEffectiveLeavesRequest) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *GetEffectiveLeavesRequest) GetChargeTo() *ChargeTo {
 	if m != nil {
 		return m.ChargeTo
 	}
 	return nil
 }
 
-type GetLeavesByIndexResponse struct {
-	// TODO(gbelvin): Response syntax does not allow for some requested leaves to be available, and some not (but using QueuedLogLeaf might)
-	Leaves               []*LogLeaf     `protobuf:"bytes,2,rep,name=leaves,proto3" json:"leaves,omitempty"`
+type GetEffectiveLeavesResponse struct {
+	// The leaves in the range which are neither tombstones nor deleted by a
+	// tombstone within the size of the tree, in order.
+	Leaves []*LogLeaf `protobuf:"bytes,1,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	// The index following the last leaf considered. Pass it as the start_index
+	// of the next request to continue. It may be less than start_index + count,
+	// if the range extends past the size of the tree, or if the server opted to
+	// consider fewer leaves than requested.
+	NextIndex            int64          `protobuf:"varint,2,opt,name=next_index,json=nextIndex,proto3" json:"next_index,omitempty"`
 	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,3,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
 	XXX_unrecognized     []byte         `json:"-"`
 	XXX_sizecache        int32          `json:"-"`
 }
 
-func (m *GetLeavesByIndexResponse) Reset()         { *m = GetLeavesByIndexResponse{} }
-func (m *GetLeavesByIndexResponse) String() string { return proto.CompactTextString(m) }
-func (*GetLeavesByIndexResponse) ProtoMessage()    {}
-func (*GetLeavesByIndexResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{24}
+func (m *GetEffectiveLeavesResponse) Reset()         { *m = GetEffectiveLeavesResponse{} }
+func (m *GetEffectiveLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*GetEffectiveLeavesResponse) ProtoMessage()    {}
+func (*GetEffectiveLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{44}
 }
 
-func (m *GetLeavesByIndexResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetLeavesByIndexResponse.Unmarshal(m, b)
+func (m *GetEffectiveLeavesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetEffectiveLeavesResponse.Unmarshal(m, b)
 }
-func (m *GetLeavesByIndexResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetLeavesByIndexResponse.Marshal(b, m, deterministic)
+func (m *GetEffectiveLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetEffectiveLeavesResponse.Marshal(b, m, deterministic)
 }
-func (m *GetLeavesByIndexResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetLeavesByIndexResponse.Merge(m, src)
+func (m *GetEffectiveLeavesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetEffectiveLeavesResponse.Merge(m, src)
 }
-func (m *GetLeavesByIndexResponse) XXX_Size() int {
-	return xxx_messageInfo_GetLeavesByIndexResponse.Size(m)
+func (m *GetEffectiveLeavesResponse) XXX_Size() int {
+	return xxx_messageInfo_GetEffectiveLeavesResponse.Size(m)
 }
-func (m *GetLeavesByIndexResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetLeavesByIndexResponse.DiscardUnknown(m)
+func (m *GetEffectiveLeavesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetEffectiveLeavesResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetLeavesByIndexResponse proto.InternalMessageInfo
+var xxx_messageInfo_GetEffectiveLeavesResponse proto.InternalMessageInfo
 
-func (m *GetLeavesByIndexResponse) GetLeaves() []*LogLeaf {
+func (m *GetEffectiveLeavesResponse) GetLeaves() []*LogLeaf {
 	if m != nil {
 		return m.Leaves
 	}
 	return nil
 }
 
-func (m *GetLeavesByIndexResponse) GetSignedLogRoot() *SignedLogRoot {
+func (m *GetEffectiveLeavesResponse) GetNextIndex() int64 {
+	if m != nil {
+		return m.NextIndex
+	}
+	return 0
+}
+
+func (m *GetEffectiveLeavesResponse) GetSignedLogRoot() *SignedLogRoot {
 	if m != nil {
 		return m.SignedLogRoot
 	}
 	return nil
 }
 
-type GetLeavesByRangeRequest struct {
+type TailLeavesRequest struct {
 	LogId                int64     `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
 	StartIndex           int64     `protobuf:"varint,2,opt,name=start_index,json=startIndex,proto3" json:"start_index,omitempty"`
-	Count                int64     `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
-	ChargeTo             *ChargeTo `protobuf:"bytes,4,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
+	ChargeTo             *ChargeTo `protobuf:"bytes,3,opt,name=charge_to,json=chargeTo,proto3" json:"charge_to,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
 	XXX_unrecognized     []byte    `json:"-"`
 	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *GetLeavesByRangeRequest) Reset()         { *m = GetLeavesByRangeRequest{} }
-func (m *GetLeavesByRangeRequest) String() string { return proto.CompactTextString(m) }
-func (*GetLeavesByRangeRequest) ProtoMessage()    {}
-func (*GetLeavesByRangeRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{25}
+func (m *TailLeavesRequest) Reset()         { *m = TailLeavesRequest{} }
+func (m *TailLeavesRequest) String() string { return proto.CompactTextString(m) }
+func (*TailLeavesRequest) ProtoMessage()    {}
+func (*TailLeavesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{45}
 }
 
-func (m *GetLeavesByRangeRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetLeavesByRangeRequest.Unmarshal(m, b)
-}
-func (m *GetLeavesByRangeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetLeavesByRangeRequest.Marshal(b, m, deterministic)
+func (m *TailLeavesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TailLeavesRequest.Unmarshal(m, b)
 }
-func (m *GetLeavesByRangeRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetLeavesByRangeRequest.Merge(m, src)
+func (m *TailLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TailLeavesRequest.Marshal(b, m, deterministic)
 }
-func (m *GetLeavesByRangeRequest) XXX_Size() int {
-	return xxx_messageInfo_GetLeavesByRangeRequest.Size(m)
+func (m *TailLeavesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TailLeavesRequest.Merge(m, src)
 }
-func (m *GetLeavesByRangeRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetLeavesByRangeRequest.DiscardUnknown(m)
+func (m *TailLeavesRequest) XXX_Size() int {
+	return xxx_messageInfo_TailLeavesRequest.Size(m)
 }
-
-var xxx_messageInfo_GetLeavesByRangeRequest proto.InternalMessageInfo
-
-func (m *GetLeavesByRangeRequest) GetLogId() int64 {
-	if m != nil {
-		return m.LogId
-	}
-	return 0
+func (m *TailLeavesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TailLeavesRequest.DiscardUnknown(m)
 }
 
-func (m *GetLeavesByRangeRequest) GetStartIndex() int64 {
+var xxx_messageInfo_TailLeavesRequest proto.InternalMessageInfo
+
+func (m *TailLeavesRequest) GetLogId() int64 {
 	if m != nil {
-		return m.StartIndex
+		return m.LogId
 	}
 	return 0
 }
 
-func (m *GetLeavesByRangeRequest) GetCount() int64 {
+func (m *TailLeavesRequest) GetStartIndex() int64 {
 	if m != nil {
-		return m.Count
+		return m.StartIndex
 	}
 	return 0
 }
 
-func (m *GetLeavesByRangeRequest) GetChargeTo() *ChargeTo {
+func (m *TailLeavesRequest) GetChargeTo() *ChargeTo {
 	if m != nil {
 		return m.ChargeTo
 	}
 	return nil
 }
 
-type GetLeavesByRangeResponse struct {
-	// Returned log leaves starting from the `start_index` of the request, in
-	// order. There may be fewer than `request.count` leaves returned, if the
-	// requested range extended beyond the size of the tree or if the server opted
-	// to return fewer leaves than requested.
-	Leaves               []*LogLeaf     `protobuf:"bytes,1,rep,name=leaves,proto3" json:"leaves,omitempty"`
+type TailLeavesResponse struct {
+	// The next leaves of the log, in order, following on from those of the
+	// previous response, or from the start_index of the request.
+	Leaves []*LogLeaf `protobuf:"bytes,1,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	// A signed log root whose tree covers the leaves.
 	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
 	XXX_unrecognized     []byte         `json:"-"`
 	XXX_sizecache        int32          `json:"-"`
 }
 
-func (m *GetLeavesByRangeResponse) Reset()         { *m = GetLeavesByRangeResponse{} }
-func (m *GetLeavesByRangeResponse) String() string { return proto.CompactTextString(m) }
-func (*GetLeavesByRangeResponse) ProtoMessage()    {}
-func (*GetLeavesByRangeResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{26}
+func (m *TailLeavesResponse) Reset()         { *m = TailLeavesResponse{} }
+func (m *TailLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*TailLeavesResponse) ProtoMessage()    {}
+func (*TailLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_5ad20a6a54aa5af3, []int{46}
 }
 
-func (m *GetLeavesByRangeResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetLeavesByRangeResponse.Unmarshal(m, b)
+func (m *TailLeavesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TailLeavesResponse.Unmarshal(m, b)
 }
-func (m *GetLeavesByRangeResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetLeavesByRangeResponse.Marshal(b, m, deterministic)
+func (m *TailLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TailLeavesResponse.Marshal(b, m, deterministic)
 }
-func (m *GetLeavesByRangeResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetLeavesByRangeResponse.Merge(m, src)
+func (m *TailLeavesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TailLeavesResponse.Merge(m, src)
 }
-func (m *GetLeavesByRangeResponse) XXX_Size() int {
-	return xxx_messageInfo_GetLeavesByRangeResponse.Size(m)
+func (m *TailLeavesResponse) XXX_Size() int {
+	return xxx_messageInfo_TailLeavesResponse.Size(m)
 }
-func (m *GetLeavesByRangeResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetLeavesByRangeResponse.DiscardUnknown(m)
+func (m *TailLeavesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TailLeavesResponse.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetLeavesByRangeResponse proto.InternalMessageInfo
+var xxx_messageInfo_TailLeavesResponse proto.InternalMessageInfo
 
-func (m *GetLeavesByRangeResponse) GetLeaves() []*LogLeaf {
+func (m *TailLeavesResponse) GetLeaves() []*LogLeaf {
 	if m != nil {
 		return m.Leaves
 	}
 	return nil
 }
 
-func (m *GetLeavesByRangeResponse) GetSignedLogRoot() *SignedLogRoot {
+func (m *TailLeavesResponse) GetSignedLogRoot() *SignedLogRoot {
 	if m != nil {
 		return m.SignedLogRoot
 	}
@@ -1455,7 +2806,7 @@ func (m *GetLeavesByHashRequest) Reset()         { *m = GetLeavesByHashRequest{}
 func (m *GetLeavesByHashRequest) String() string { return proto.CompactTextString(m) }
 func (*GetLeavesByHashRequest) ProtoMessage()    {}
 func (*GetLeavesByHashRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{27}
+	return fileDescriptor_5ad20a6a54aa5af3, []int{47}
 }
 
 func (m *GetLeavesByHashRequest) XXX_Unmarshal(b []byte) error {
@@ -1516,7 +2867,7 @@ func (m *GetLeavesByHashResponse) Reset()         { *m = GetLeavesByHashResponse
 func (m *GetLeavesByHashResponse) String() string { return proto.CompactTextString(m) }
 func (*GetLeavesByHashResponse) ProtoMessage()    {}
 func (*GetLeavesByHashResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{28}
+	return fileDescriptor_5ad20a6a54aa5af3, []int{48}
 }
 
 func (m *GetLeavesByHashResponse) XXX_Unmarshal(b []byte) error {
@@ -1566,6 +2917,9 @@ type QueuedLogLeaf struct {
 	//    mode, or `leaf_index` in the `PREORDERED_LOG`.
 	//  - `google.rpc.FAILED_PRECONDITION`: A conflicting entry is already
 	//    present in the log, e.g., same `leaf_index` but different `leaf_data`.
+	//  - Any other code, typically `google.rpc.INVALID_ARGUMENT`: the leaf was
+	//    rejected by the leaf validator of the log, and was not added. QueueLeaf
+	//    fails with this status instead of returning it.
 	Status               *status.Status `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
 	XXX_unrecognized     []byte         `json:"-"`
@@ -1576,7 +2930,7 @@ func (m *QueuedLogLeaf) Reset()         { *m = QueuedLogLeaf{} }
 func (m *QueuedLogLeaf) String() string { return proto.CompactTextString(m) }
 func (*QueuedLogLeaf) ProtoMessage()    {}
 func (*QueuedLogLeaf) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{29}
+	return fileDescriptor_5ad20a6a54aa5af3, []int{49}
 }
 
 func (m *QueuedLogLeaf) XXX_Unmarshal(b []byte) error {
@@ -1671,10 +3025,14 @@ type LogLeaf struct {
 	LeafIdentityHash []byte `protobuf:"bytes,5,opt,name=leaf_identity_hash,json=leafIdentityHash,proto3" json:"leaf_identity_hash,omitempty"`
 	// queue_timestamp holds the time at which this leaf was queued for
 	// inclusion in the Log, or zero if the entry was submitted without
-	// queuing. Clients should not set this field on submissions.
+	// queuing. Clients should not set this field on submissions, except for
+	// AddSequencedLeaves, which stores it verbatim if set (e.g. when migrating
+	// an existing log).
 	QueueTimestamp *timestamp.Timestamp `protobuf:"bytes,6,opt,name=queue_timestamp,json=queueTimestamp,proto3" json:"queue_timestamp,omitempty"`
 	// integrate_timestamp holds the time at which this leaf was integrated into
-	// the tree.  Clients should not set this field on submissions.
+	// the tree.  Clients should not set this field on submissions, except for
+	// AddSequencedLeaves, which stores it verbatim if set (e.g. when migrating
+	// an existing log).
 	IntegrateTimestamp   *timestamp.Timestamp `protobuf:"bytes,7,opt,name=integrate_timestamp,json=integrateTimestamp,proto3" json:"integrate_timestamp,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
 	XXX_unrecognized     []byte               `json:"-"`
@@ -1685,7 +3043,7 @@ func (m *LogLeaf) Reset()         { *m = LogLeaf{} }
 func (m *LogLeaf) String() string { return proto.CompactTextString(m) }
 func (*LogLeaf) ProtoMessage()    {}
 func (*LogLeaf) Descriptor() ([]byte, []int) {
-	return fileDescriptor_5ad20a6a54aa5af3, []int{30}
+	return fileDescriptor_5ad20a6a54aa5af3, []int{50}
 }
 
 func (m *LogLeaf) XXX_Unmarshal(b []byte) error {
@@ -1756,6 +3114,7 @@ func (m *LogLeaf) GetIntegrateTimestamp() *timestamp.Timestamp {
 }
 
 func init() {
+	proto.RegisterEnum("trillian.IndexBase", IndexBase_name, IndexBase_value)
 	proto.RegisterType((*ChargeTo)(nil), "trillian.ChargeTo")
 	proto.RegisterType((*QueueLeafRequest)(nil), "trillian.QueueLeafRequest")
 	proto.RegisterType((*QueueLeafResponse)(nil), "trillian.QueueLeafResponse")
@@ -1763,12 +3122,22 @@ func init() {
 	proto.RegisterType((*AddSequencedLeafResponse)(nil), "trillian.AddSequencedLeafResponse")
 	proto.RegisterType((*GetInclusionProofRequest)(nil), "trillian.GetInclusionProofRequest")
 	proto.RegisterType((*GetInclusionProofResponse)(nil), "trillian.GetInclusionProofResponse")
+	proto.RegisterType((*GetHistoricalInclusionProofRequest)(nil), "trillian.GetHistoricalInclusionProofRequest")
+	proto.RegisterType((*GetHistoricalInclusionProofResponse)(nil), "trillian.GetHistoricalInclusionProofResponse")
 	proto.RegisterType((*GetInclusionProofByHashRequest)(nil), "trillian.GetInclusionProofByHashRequest")
 	proto.RegisterType((*GetInclusionProofByHashResponse)(nil), "trillian.GetInclusionProofByHashResponse")
 	proto.RegisterType((*GetConsistencyProofRequest)(nil), "trillian.GetConsistencyProofRequest")
 	proto.RegisterType((*GetConsistencyProofResponse)(nil), "trillian.GetConsistencyProofResponse")
+	proto.RegisterType((*GetProofAtRevisionRequest)(nil), "trillian.GetProofAtRevisionRequest")
+	proto.RegisterType((*GetProofAtRevisionResponse)(nil), "trillian.GetProofAtRevisionResponse")
+	proto.RegisterType((*PredictRootRequest)(nil), "trillian.PredictRootRequest")
+	proto.RegisterType((*PredictRootResponse)(nil), "trillian.PredictRootResponse")
+	proto.RegisterType((*VerifyInclusionRequest)(nil), "trillian.VerifyInclusionRequest")
+	proto.RegisterType((*VerifyInclusionResponse)(nil), "trillian.VerifyInclusionResponse")
 	proto.RegisterType((*GetLatestSignedLogRootRequest)(nil), "trillian.GetLatestSignedLogRootRequest")
 	proto.RegisterType((*GetLatestSignedLogRootResponse)(nil), "trillian.GetLatestSignedLogRootResponse")
+	proto.RegisterType((*GetSignedLogRootHistoryRequest)(nil), "trillian.GetSignedLogRootHistoryRequest")
+	proto.RegisterType((*GetSignedLogRootHistoryResponse)(nil), "trillian.GetSignedLogRootHistoryResponse")
 	proto.RegisterType((*GetSequencedLeafCountRequest)(nil), "trillian.GetSequencedLeafCountRequest")
 	proto.RegisterType((*GetSequencedLeafCountResponse)(nil), "trillian.GetSequencedLeafCountResponse")
 	proto.RegisterType((*GetEntryAndProofRequest)(nil), "trillian.GetEntryAndProofRequest")
@@ -1776,13 +3145,23 @@ func init() {
 	proto.RegisterType((*InitLogRequest)(nil), "trillian.InitLogRequest")
 	proto.RegisterType((*InitLogResponse)(nil), "trillian.InitLogResponse")
 	proto.RegisterType((*QueueLeavesRequest)(nil), "trillian.QueueLeavesRequest")
+	proto.RegisterType((*QueueCondition)(nil), "trillian.QueueCondition")
 	proto.RegisterType((*QueueLeavesResponse)(nil), "trillian.QueueLeavesResponse")
+	proto.RegisterType((*ShadowQueueResult)(nil), "trillian.ShadowQueueResult")
 	proto.RegisterType((*AddSequencedLeavesRequest)(nil), "trillian.AddSequencedLeavesRequest")
 	proto.RegisterType((*AddSequencedLeavesResponse)(nil), "trillian.AddSequencedLeavesResponse")
+	proto.RegisterType((*BulkAddSequencedLeavesRequest)(nil), "trillian.BulkAddSequencedLeavesRequest")
+	proto.RegisterType((*BulkAddSequencedLeavesResponse)(nil), "trillian.BulkAddSequencedLeavesResponse")
 	proto.RegisterType((*GetLeavesByIndexRequest)(nil), "trillian.GetLeavesByIndexRequest")
 	proto.RegisterType((*GetLeavesByIndexResponse)(nil), "trillian.GetLeavesByIndexResponse")
 	proto.RegisterType((*GetLeavesByRangeRequest)(nil), "trillian.GetLeavesByRangeRequest")
 	proto.RegisterType((*GetLeavesByRangeResponse)(nil), "trillian.GetLeavesByRangeResponse")
+	proto.RegisterType((*GetLeavesByKeyRangeRequest)(nil), "trillian.GetLeavesByKeyRangeRequest")
+	proto.RegisterType((*GetLeavesByKeyRangeResponse)(nil), "trillian.GetLeavesByKeyRangeResponse")
+	proto.RegisterType((*GetEffectiveLeavesRequest)(nil), "trillian.GetEffectiveLeavesRequest")
+	proto.RegisterType((*GetEffectiveLeavesResponse)(nil), "trillian.GetEffectiveLeavesResponse")
+	proto.RegisterType((*TailLeavesRequest)(nil), "trillian.TailLeavesRequest")
+	proto.RegisterType((*TailLeavesResponse)(nil), "trillian.TailLeavesResponse")
 	proto.RegisterType((*GetLeavesByHashRequest)(nil), "trillian.GetLeavesByHashRequest")
 	proto.RegisterType((*GetLeavesByHashResponse)(nil), "trillian.GetLeavesByHashResponse")
 	proto.RegisterType((*QueuedLogLeaf)(nil), "trillian.QueuedLogLeaf")
@@ -1792,102 +3171,156 @@ func init() {
 func init() { proto.RegisterFile("trillian_log_api.proto", fileDescriptor_5ad20a6a54aa5af3) }
 
 var fileDescriptor_5ad20a6a54aa5af3 = []byte{
-	// 1512 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x58, 0x5b, 0x6f, 0xdc, 0xc4,
-	0x17, 0xaf, 0xb3, 0xb9, 0x9e, 0x34, 0xd9, 0x64, 0xf2, 0x6f, 0xb3, 0x71, 0x9a, 0x36, 0x75, 0x9a,
-	0x76, 0x9b, 0x7f, 0x89, 0x49, 0x11, 0x02, 0x45, 0x15, 0xa8, 0x49, 0x51, 0x88, 0xba, 0x40, 0x71,
-	0x22, 0x54, 0xc1, 0x83, 0xe5, 0xf5, 0x4e, 0x1c, 0x0b, 0xc7, 0xb3, 0xb5, 0x67, 0xa3, 0x6e, 0xab,
-	0x4a, 0x5c, 0x54, 0x2e, 0x0f, 0xc0, 0x03, 0x3c, 0xf4, 0x85, 0xcb, 0x1b, 0xe2, 0x0b, 0xf0, 0x31,
-	0x10, 0x12, 0x5f, 0x81, 0x07, 0x3e, 0x06, 0xf2, 0xcc, 0x78, 0x7d, 0x59, 0xdb, 0xbb, 0x5b, 0xd2,
-	0xc2, 0xdb, 0xfa, 0xcc, 0x99, 0x73, 0x7e, 0xe7, 0x37, 0x73, 0xce, 0x9c, 0xb3, 0x70, 0x96, 0x7a,
-	0xb6, 0xe3, 0xd8, 0x86, 0xab, 0x3b, 0xc4, 0xd2, 0x8d, 0xa6, 0xbd, 0xde, 0xf4, 0x08, 0x25, 0x68,
-	0x3c, 0x94, 0xcb, 0xe7, 0x2c, 0x42, 0x2c, 0x07, 0xab, 0x46, 0xd3, 0x56, 0x0d, 0xd7, 0x25, 0xd4,
-	0xa0, 0x36, 0x71, 0x7d, 0xae, 0x27, 0x5f, 0x10, 0xab, 0xec, 0xab, 0xde, 0x3a, 0x50, 0xa9, 0x7d,
-	0x84, 0x7d, 0x6a, 0x1c, 0x35, 0x85, 0xc2, 0xbc, 0x50, 0xf0, 0x9a, 0xa6, 0xea, 0x53, 0x83, 0xb6,
-	0xc2, 0x9d, 0xd3, 0xa1, 0x07, 0xfe, 0xad, 0x9c, 0x87, 0xf1, 0xed, 0x43, 0xc3, 0xb3, 0xf0, 0x3e,
-	0x41, 0x08, 0x86, 0x5b, 0x3e, 0xf6, 0x2a, 0xd2, 0x72, 0xa9, 0x3a, 0xa1, 0xb1, 0xdf, 0xca, 0xc7,
-	0x12, 0xcc, 0xbc, 0xdb, 0xc2, 0x2d, 0x5c, 0xc3, 0xc6, 0x81, 0x86, 0xef, 0xb5, 0xb0, 0x4f, 0xd1,
-	0x19, 0x18, 0x0d, 0x70, 0xdb, 0x8d, 0x8a, 0xb4, 0x2c, 0x55, 0x4b, 0xda, 0x88, 0x43, 0xac, 0xdd,
-	0x06, 0x5a, 0x85, 0x61, 0x07, 0x1b, 0x07, 0x95, 0xa1, 0x65, 0xa9, 0x3a, 0x79, 0x7d, 0x76, 0xbd,
-	0xe3, 0xaa, 0x46, 0x2c, 0xb6, 0x9d, 0x2d, 0x23, 0x15, 0x26, 0x4c, 0xe6, 0x52, 0xa7, 0xa4, 0x52,
-	0x62, 0xba, 0x28, 0xd2, 0x0d, 0xd1, 0x68, 0xe3, 0xa6, 0xf8, 0xa5, 0xbc, 0x05, 0xb3, 0x31, 0x08,
-	0x7e, 0x93, 0xb8, 0x3e, 0x46, 0xaf, 0xc2, 0xe4, 0xbd, 0x40, 0xd8, 0xd0, 0x63, 0x3e, 0xe7, 0x23,
-	0x3b, 0x6c, 0x47, 0x23, 0xf4, 0x0c, 0x5c, 0x37, 0xf8, 0xad, 0x7c, 0x21, 0xc1, 0xfc, 0xcd, 0x46,
-	0x63, 0x2f, 0x08, 0xc6, 0x35, 0xb9, 0xf0, 0x5f, 0x8a, 0xec, 0x36, 0x54, 0xba, 0x91, 0x88, 0x00,
-	0x55, 0x18, 0xf5, 0xb0, 0xdf, 0x72, 0x68, 0xaf, 0xd8, 0x84, 0x9a, 0xf2, 0x83, 0x04, 0x95, 0x1d,
-	0x4c, 0x77, 0x5d, 0xd3, 0x69, 0xf9, 0x36, 0x71, 0xef, 0x78, 0x84, 0xf4, 0x0a, 0x6c, 0x09, 0x20,
-	0x40, 0xae, 0xdb, 0x6e, 0x03, 0xdf, 0x67, 0x8e, 0x4a, 0xda, 0x44, 0x20, 0xd9, 0x0d, 0x04, 0x68,
-	0x11, 0x26, 0xa8, 0x87, 0xb1, 0xee, 0xdb, 0x0f, 0x30, 0x0b, 0xa8, 0xa4, 0x8d, 0x07, 0x82, 0x3d,
-	0xfb, 0x01, 0x4e, 0x46, 0x3b, 0xdc, 0x47, 0xb4, 0x9f, 0x4a, 0xb0, 0x90, 0x01, 0x50, 0xc4, 0xbb,
-	0x0a, 0x23, 0xcd, 0x40, 0x20, 0xc2, 0x2d, 0x47, 0xa6, 0xb8, 0x1e, 0x5f, 0x45, 0xaf, 0x43, 0xd9,
-	0xb7, 0x2d, 0x37, 0x38, 0x77, 0x62, 0xe9, 0x1e, 0x21, 0x54, 0x30, 0x1d, 0xe3, 0x67, 0x8f, 0x29,
-	0xd4, 0x88, 0xa5, 0x11, 0x42, 0xb5, 0x29, 0x3f, 0xfe, 0xa9, 0xfc, 0x26, 0xc1, 0xf9, 0x2e, 0x14,
-	0x5b, 0xed, 0x37, 0x0d, 0xff, 0xb0, 0x07, 0x59, 0x8b, 0xc0, 0xa8, 0xd1, 0x0f, 0x0d, 0xff, 0x90,
-	0xa1, 0x3c, 0xad, 0x8d, 0x07, 0x82, 0x60, 0x6b, 0x31, 0x55, 0x6b, 0x30, 0x4b, 0xbc, 0x06, 0xf6,
-	0xf4, 0x7a, 0x5b, 0xf7, 0xc5, 0x69, 0x33, 0xca, 0xc6, 0xb5, 0x32, 0x5b, 0xd8, 0x6a, 0x87, 0x97,
-	0x20, 0x49, 0xeb, 0x48, 0x1f, 0xb4, 0x7e, 0x29, 0xc1, 0x85, 0xdc, 0x80, 0xba, 0xc9, 0x2d, 0x3d,
-	0x4b, 0x72, 0x7f, 0x95, 0x40, 0xde, 0xc1, 0x74, 0x9b, 0xb8, 0xbe, 0xed, 0x53, 0xec, 0x9a, 0xed,
-	0x7e, 0x6e, 0xe1, 0x65, 0x28, 0x1f, 0xd8, 0x9e, 0x4f, 0xf5, 0x88, 0x41, 0x7e, 0x15, 0xa7, 0x98,
-	0x78, 0x3f, 0xa4, 0xb1, 0x0a, 0x33, 0x3e, 0x36, 0x89, 0xdb, 0xd0, 0xd3, 0x54, 0x4f, 0x73, 0xf9,
-	0xfe, 0x53, 0xdf, 0xcd, 0xc7, 0x12, 0x2c, 0x66, 0x02, 0x7f, 0xce, 0xb7, 0xf3, 0x1b, 0x09, 0x96,
-	0x76, 0x30, 0xad, 0x19, 0x14, 0xfb, 0x34, 0xa9, 0x59, 0xcc, 0x61, 0x22, 0xe2, 0xa1, 0xde, 0x11,
-	0x67, 0x91, 0x5e, 0xca, 0x20, 0x3d, 0x28, 0x97, 0xe7, 0xf3, 0x10, 0x09, 0x72, 0x32, 0xa2, 0x1e,
-	0x1a, 0x24, 0xea, 0x88, 0xdd, 0x52, 0x11, 0xbb, 0xca, 0x01, 0x9c, 0xdb, 0xc1, 0x34, 0x51, 0x2e,
-	0xb7, 0x49, 0xcb, 0x3d, 0x69, 0x6a, 0x94, 0xd7, 0xd8, 0x19, 0x64, 0xf9, 0x11, 0x01, 0x87, 0x65,
-	0xd3, 0x0c, 0xa4, 0xf1, 0xb2, 0xc9, 0xd4, 0x94, 0xef, 0x25, 0x98, 0xdf, 0xc1, 0xf4, 0x0d, 0x97,
-	0x7a, 0xed, 0x9b, 0x6e, 0xe3, 0x3f, 0x57, 0x88, 0x7f, 0xe1, 0x2f, 0x45, 0x0a, 0xdf, 0x60, 0x37,
-	0x3d, 0x7c, 0x12, 0x4b, 0xc5, 0x4f, 0x62, 0xc6, 0xd5, 0x18, 0x1e, 0x28, 0x21, 0xee, 0xc2, 0xf4,
-	0xae, 0x6b, 0xd3, 0xe0, 0xf3, 0x84, 0x4f, 0xf9, 0x16, 0x94, 0x3b, 0x96, 0x45, 0xec, 0x1b, 0x30,
-	0x66, 0x7a, 0xd8, 0xa0, 0x98, 0xdb, 0x2e, 0x40, 0x19, 0xea, 0x29, 0x9f, 0x4b, 0x80, 0xc2, 0xee,
-	0xe4, 0x18, 0xfb, 0x3d, 0x40, 0x5e, 0x85, 0x51, 0x87, 0xe9, 0x89, 0x42, 0x9c, 0xc1, 0x9b, 0x50,
-	0x18, 0xbc, 0x99, 0xd8, 0x83, 0xb9, 0x04, 0x10, 0x11, 0xd3, 0x0d, 0x98, 0x8a, 0x1a, 0xa5, 0xc8,
-	0x73, 0x6e, 0x3b, 0x71, 0xba, 0xd3, 0x2a, 0x1d, 0x63, 0x5f, 0xf9, 0x5a, 0x82, 0x85, 0x54, 0x8b,
-	0xf2, 0xec, 0xa2, 0xec, 0xe7, 0xee, 0xbe, 0x03, 0x72, 0x16, 0x9e, 0xe8, 0x00, 0x79, 0x37, 0xd4,
-	0x33, 0xcc, 0x50, 0x4f, 0xf9, 0x88, 0x27, 0x2b, 0x37, 0xb4, 0xd5, 0x66, 0xf9, 0x36, 0x60, 0xb2,
-	0x96, 0x92, 0xc9, 0x3a, 0xf0, 0x0b, 0xfe, 0x19, 0xcf, 0xc7, 0x14, 0x04, 0x11, 0xd2, 0x00, 0x64,
-	0xfe, 0xe3, 0xd7, 0xe7, 0x49, 0x92, 0x0b, 0xcd, 0x70, 0x2d, 0xdc, 0x83, 0x8b, 0x0b, 0x30, 0xe9,
-	0x53, 0xc3, 0xa3, 0x89, 0xca, 0x05, 0x4c, 0xc4, 0xd9, 0xf8, 0x1f, 0x8c, 0xf0, 0x32, 0xc9, 0xcb,
-	0x16, 0xff, 0x18, 0xfc, 0xdc, 0x53, 0x1c, 0x09, 0x68, 0x5d, 0x1c, 0x49, 0x4f, 0xc1, 0xd1, 0x40,
-	0x6f, 0x55, 0x50, 0x3c, 0xcf, 0xc6, 0x80, 0x0c, 0xde, 0x37, 0x96, 0x12, 0x7d, 0x63, 0x66, 0x6b,
-	0x58, 0x3a, 0xa1, 0xd6, 0xf0, 0x71, 0xf2, 0x3c, 0x13, 0x2d, 0xe1, 0xf3, 0xbc, 0x57, 0x75, 0x98,
-	0x4a, 0x64, 0x5f, 0xe7, 0xf5, 0x90, 0x8a, 0x5f, 0x8f, 0x35, 0x18, 0xe5, 0xd3, 0x6b, 0xa7, 0xa0,
-	0xf3, 0xb9, 0x76, 0xdd, 0x6b, 0x9a, 0xeb, 0x7b, 0x6c, 0x45, 0x13, 0x1a, 0xca, 0xef, 0x43, 0x30,
-	0x16, 0x9a, 0xaf, 0xc2, 0xcc, 0x11, 0xf6, 0x3e, 0x74, 0xb0, 0x1e, 0x11, 0x2f, 0xb1, 0x86, 0x7d,
-	0x9a, 0xcb, 0x6b, 0x21, 0xfd, 0x61, 0x2a, 0x1f, 0x1b, 0x4e, 0x0b, 0x8b, 0xa6, 0x9e, 0x9d, 0xd6,
-	0x7b, 0x81, 0x20, 0x58, 0xc6, 0xf7, 0xa9, 0x67, 0xe8, 0x0d, 0x83, 0x1a, 0x2c, 0xe8, 0xd3, 0xda,
-	0x04, 0x93, 0xdc, 0x32, 0xa8, 0x91, 0x2a, 0x04, 0xc3, 0xe9, 0x57, 0xfb, 0x1a, 0x20, 0xbe, 0xdc,
-	0xc0, 0x2e, 0xb5, 0x69, 0x9b, 0x03, 0x19, 0x61, 0x56, 0x66, 0x98, 0x9a, 0x58, 0x60, 0x50, 0xb6,
-	0xa1, 0xcc, 0x4a, 0xaf, 0xde, 0x19, 0xe6, 0x2b, 0xa3, 0x2c, 0x6a, 0x39, 0x8c, 0x3a, 0x1c, 0xf7,
-	0xd7, 0xf7, 0x43, 0x0d, 0x6d, 0x9a, 0x6d, 0xe9, 0x7c, 0xa3, 0xdb, 0x30, 0x67, 0xbb, 0x14, 0x5b,
-	0x9e, 0x41, 0xe3, 0x86, 0xc6, 0x7a, 0x1a, 0x42, 0x9d, 0x6d, 0x1d, 0xd9, 0xf5, 0xbf, 0xa6, 0x60,
-	0x72, 0x5f, 0x9c, 0x4c, 0x8d, 0x58, 0xc8, 0x85, 0x89, 0xce, 0x20, 0x8e, 0xe4, 0x54, 0x65, 0x8d,
-	0x8d, 0xd1, 0xf2, 0x62, 0xe6, 0x1a, 0xbf, 0x78, 0x4a, 0xf5, 0x93, 0x3f, 0xfe, 0xfc, 0x76, 0x48,
-	0x51, 0x96, 0xd4, 0xe3, 0x8d, 0x3a, 0xa6, 0xc6, 0x86, 0xea, 0x10, 0xcb, 0x57, 0x1f, 0xf2, 0xd4,
-	0x79, 0xa4, 0xf2, 0x4b, 0xb7, 0x29, 0xad, 0xa1, 0xaf, 0x24, 0x98, 0x49, 0xcf, 0xc7, 0xe8, 0x62,
-	0x64, 0x3b, 0x67, 0x8a, 0x97, 0x95, 0x22, 0x15, 0x81, 0xe2, 0x3a, 0x43, 0x71, 0x4d, 0xb9, 0x52,
-	0x8c, 0x22, 0x4c, 0xc9, 0x46, 0x80, 0xe7, 0x27, 0x09, 0x66, 0xbb, 0x26, 0x2d, 0x14, 0xf3, 0x96,
-	0x37, 0x7e, 0xcb, 0x2b, 0x85, 0x3a, 0x02, 0xd2, 0x16, 0x83, 0x74, 0x03, 0x6d, 0x16, 0x42, 0x52,
-	0x1f, 0x46, 0x57, 0xee, 0xd1, 0xa6, 0x1d, 0x9a, 0xd2, 0x79, 0x5b, 0xf6, 0x33, 0xcf, 0xf8, 0xac,
-	0x61, 0x10, 0x55, 0x0b, 0x40, 0x24, 0x0a, 0x99, 0x7c, 0xb5, 0x0f, 0x4d, 0x01, 0xfa, 0x15, 0x06,
-	0x7a, 0x03, 0xa9, 0xc5, 0x3c, 0x46, 0x38, 0xeb, 0x3c, 0x0d, 0xd0, 0x77, 0x12, 0xcc, 0x65, 0x4c,
-	0x5c, 0xe8, 0x52, 0xc2, 0x77, 0xce, 0x24, 0x29, 0xaf, 0xf6, 0xd0, 0x12, 0xe8, 0x5e, 0x64, 0xe8,
-	0xd6, 0x50, 0x35, 0x1b, 0xdd, 0xa6, 0x19, 0x6d, 0x14, 0x04, 0x3e, 0x11, 0xe5, 0xbd, 0x7b, 0xdc,
-	0x41, 0x57, 0x12, 0x3e, 0xf3, 0x47, 0x34, 0xb9, 0xda, 0x5b, 0x51, 0xe0, 0xfb, 0x3f, 0xc3, 0xb7,
-	0x8a, 0x56, 0x72, 0xd8, 0x0b, 0x6a, 0xad, 0xbf, 0xe9, 0x30, 0x0b, 0xe8, 0x47, 0x09, 0xce, 0x64,
-	0xce, 0x25, 0xe8, 0x72, 0xc2, 0x61, 0xee, 0x80, 0x24, 0x5f, 0xe9, 0xa9, 0x27, 0x70, 0xbd, 0xcc,
-	0x70, 0xa9, 0xe8, 0x85, 0x3e, 0xb3, 0x83, 0x4f, 0x42, 0x2c, 0x61, 0xd3, 0x83, 0x45, 0x3c, 0x61,
-	0x73, 0x86, 0x22, 0x59, 0x29, 0x52, 0x49, 0x26, 0x2c, 0x5a, 0xeb, 0x3f, 0x3b, 0x90, 0x09, 0x63,
-	0xa2, 0xc5, 0x47, 0x95, 0xc8, 0x45, 0x72, 0x9e, 0x90, 0x17, 0x32, 0x56, 0x84, 0xcf, 0x15, 0xe6,
-	0x73, 0x49, 0x59, 0xcc, 0xb9, 0x3e, 0xb6, 0x6b, 0x53, 0x54, 0x83, 0xc9, 0x58, 0xdf, 0x8d, 0xce,
-	0x75, 0xd7, 0xbe, 0xa8, 0x63, 0x96, 0x97, 0x72, 0x56, 0x85, 0xc3, 0x53, 0xc8, 0x00, 0xd4, 0xdd,
-	0xdf, 0xa2, 0x95, 0xdc, 0x8a, 0x16, 0xb3, 0x7d, 0xa9, 0x58, 0xa9, 0xe3, 0xe2, 0x03, 0x76, 0x48,
-	0x89, 0x6e, 0x33, 0x75, 0x48, 0x59, 0xcd, 0x70, 0xea, 0x90, 0x32, 0x9b, 0xd5, 0x2e, 0xe3, 0xac,
-	0x4d, 0xcb, 0x31, 0x1e, 0xef, 0x2e, 0x73, 0x8c, 0x27, 0xba, 0x3c, 0xe5, 0x14, 0xba, 0x0b, 0xe5,
-	0x54, 0x3b, 0x83, 0x96, 0x33, 0x37, 0xc6, 0x8b, 0xd9, 0xc5, 0x02, 0x8d, 0xd0, 0xf2, 0xd6, 0xdb,
-	0xb0, 0x60, 0x92, 0xa3, 0xf0, 0x7d, 0x4c, 0xfe, 0x49, 0xbe, 0x35, 0x17, 0x7b, 0x04, 0x6f, 0x36,
-	0xed, 0x3b, 0x81, 0xf0, 0x8e, 0xf4, 0xbe, 0x6c, 0xd9, 0xf4, 0xb0, 0x55, 0x5f, 0x37, 0xc9, 0x91,
-	0x2a, 0xfe, 0x6f, 0x0f, 0x37, 0xd6, 0x47, 0xd9, 0xce, 0x97, 0xfe, 0x0e, 0x00, 0x00, 0xff, 0xff,
-	0x2a, 0x12, 0x9d, 0x60, 0xea, 0x17, 0x00, 0x00,
+	// 2377 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xcc, 0x5a, 0x4b, 0x6f, 0x1c, 0xc7,
+	0xf1, 0x77, 0x73, 0xb9, 0x4b, 0x6e, 0x51, 0xe2, 0xa3, 0x29, 0x8b, 0xab, 0xa1, 0x56, 0x8f, 0x91,
+	0x68, 0xad, 0xf8, 0x97, 0xb9, 0x16, 0xfd, 0xcf, 0x03, 0x84, 0xe1, 0x80, 0xa4, 0x08, 0x9a, 0x20,
+	0x21, 0x29, 0x43, 0xc2, 0x10, 0x9c, 0xc3, 0x60, 0x38, 0xd3, 0x5c, 0x4e, 0x38, 0x9c, 0x59, 0xcf,
+	0xf4, 0x32, 0x5c, 0xdb, 0x0a, 0x1c, 0x27, 0x0e, 0x7c, 0x71, 0x12, 0x20, 0x3e, 0x04, 0x39, 0xe4,
+	0x71, 0x72, 0x7c, 0x09, 0x90, 0x4b, 0xae, 0xb9, 0x07, 0x39, 0xc4, 0xc8, 0x47, 0x88, 0x8f, 0x01,
+	0xf2, 0x15, 0x82, 0xe9, 0xee, 0x79, 0xee, 0xcc, 0xec, 0xae, 0x44, 0xd1, 0xbe, 0xed, 0x54, 0x57,
+	0x77, 0x57, 0xfd, 0xaa, 0xba, 0xaa, 0xab, 0x7a, 0xe1, 0x32, 0x75, 0x4d, 0xcb, 0x32, 0x35, 0x5b,
+	0xb5, 0x9c, 0x96, 0xaa, 0xb5, 0xcd, 0xa5, 0xb6, 0xeb, 0x50, 0x07, 0x8f, 0x07, 0x74, 0xe9, 0x6a,
+	0xcb, 0x71, 0x5a, 0x16, 0x69, 0x6a, 0x6d, 0xb3, 0xa9, 0xd9, 0xb6, 0x43, 0x35, 0x6a, 0x3a, 0xb6,
+	0xc7, 0xf9, 0xa4, 0xeb, 0x62, 0x94, 0x7d, 0xed, 0x77, 0x0e, 0x9a, 0xd4, 0x3c, 0x26, 0x1e, 0xd5,
+	0x8e, 0xdb, 0x82, 0x61, 0x4e, 0x30, 0xb8, 0x6d, 0xbd, 0xe9, 0x51, 0x8d, 0x76, 0x82, 0x99, 0x93,
+	0xc1, 0x0e, 0xfc, 0x5b, 0xbe, 0x06, 0xe3, 0xeb, 0x87, 0x9a, 0xdb, 0x22, 0x7b, 0x0e, 0xc6, 0x30,
+	0xda, 0xf1, 0x88, 0x5b, 0x43, 0x37, 0x4a, 0x8d, 0xaa, 0xc2, 0x7e, 0xcb, 0x3f, 0x41, 0x30, 0xfd,
+	0xfd, 0x0e, 0xe9, 0x90, 0x1d, 0xa2, 0x1d, 0x28, 0xe4, 0xdd, 0x0e, 0xf1, 0x28, 0x7e, 0x19, 0x2a,
+	0xbe, 0xdc, 0xa6, 0x51, 0x43, 0x37, 0x50, 0xa3, 0xa4, 0x94, 0x2d, 0xa7, 0xb5, 0x65, 0xe0, 0x05,
+	0x18, 0xb5, 0x88, 0x76, 0x50, 0x1b, 0xb9, 0x81, 0x1a, 0x13, 0xcb, 0x33, 0x4b, 0xe1, 0x56, 0x3b,
+	0x4e, 0x8b, 0x4d, 0x67, 0xc3, 0xb8, 0x09, 0x55, 0x9d, 0x6d, 0xa9, 0x52, 0xa7, 0x56, 0x62, 0xbc,
+	0x38, 0xe2, 0x0d, 0xa4, 0x51, 0xc6, 0x75, 0xf1, 0x4b, 0xfe, 0x08, 0xc1, 0x4c, 0x4c, 0x06, 0xaf,
+	0xed, 0xd8, 0x1e, 0xc1, 0xdf, 0x85, 0x89, 0x77, 0x7d, 0xa2, 0xa1, 0xc6, 0x36, 0x9d, 0x8b, 0x16,
+	0x62, 0x33, 0x8c, 0x60, 0x6b, 0xe0, 0xbc, 0xfe, 0x6f, 0xfc, 0x3a, 0x54, 0xbc, 0x43, 0xcd, 0x70,
+	0x7e, 0x24, 0x76, 0x9f, 0x8f, 0x26, 0xed, 0x32, 0x3a, 0x9b, 0xaa, 0x10, 0xaf, 0x63, 0x51, 0x45,
+	0xb0, 0xca, 0x9f, 0x20, 0x98, 0x5b, 0x35, 0x8c, 0x5d, 0x1f, 0x02, 0x5b, 0x27, 0xc6, 0xd7, 0x88,
+	0xc7, 0x36, 0xd4, 0x7a, 0x25, 0x11, 0xa8, 0x34, 0xa1, 0xe2, 0x32, 0xc1, 0xfb, 0x01, 0x22, 0xd8,
+	0xe4, 0xdf, 0x21, 0xa8, 0x6d, 0x12, 0xba, 0x65, 0xeb, 0x56, 0xc7, 0x33, 0x1d, 0xfb, 0xb1, 0xeb,
+	0x38, 0xfd, 0x14, 0xab, 0x03, 0xf8, 0x92, 0xab, 0xa6, 0x6d, 0x90, 0x53, 0xb6, 0x51, 0x49, 0xa9,
+	0xfa, 0x94, 0x2d, 0x9f, 0x80, 0xe7, 0xa1, 0x4a, 0x5d, 0x42, 0x54, 0xcf, 0x7c, 0x8f, 0x30, 0x85,
+	0x4a, 0xca, 0xb8, 0x4f, 0xd8, 0x35, 0xdf, 0x23, 0x49, 0x6d, 0x47, 0x07, 0xd0, 0xf6, 0xa7, 0x08,
+	0xae, 0x64, 0x08, 0x28, 0xf4, 0x5d, 0x80, 0x72, 0xdb, 0x27, 0x08, 0x75, 0xa7, 0xa2, 0xa5, 0x38,
+	0x1f, 0x1f, 0xc5, 0xdf, 0x83, 0x29, 0xcf, 0x6c, 0xd9, 0xbe, 0xb3, 0x38, 0x2d, 0xd5, 0x75, 0x1c,
+	0x5a, 0x2b, 0xa5, 0xf1, 0xd9, 0x65, 0x0c, 0x3b, 0x4e, 0x4b, 0x71, 0x1c, 0xaa, 0x5c, 0xf4, 0xe2,
+	0x9f, 0xf2, 0x9f, 0x10, 0xc8, 0x9b, 0x84, 0xbe, 0x65, 0x7a, 0xd4, 0x71, 0x4d, 0x5d, 0xb3, 0xbe,
+	0xb9, 0x80, 0x7d, 0x8a, 0xe0, 0x56, 0xa1, 0xa8, 0x69, 0xe8, 0xd0, 0xb0, 0xd0, 0x8d, 0x0c, 0x05,
+	0xdd, 0x7f, 0x11, 0x5c, 0xeb, 0x31, 0xe0, 0x5a, 0xf7, 0x2d, 0xcd, 0x3b, 0xec, 0x03, 0xdb, 0x3c,
+	0x30, 0x90, 0xd4, 0x43, 0xcd, 0x3b, 0x64, 0x9b, 0x5e, 0x50, 0xc6, 0x7d, 0x82, 0x3f, 0xb5, 0x18,
+	0xb4, 0x45, 0x98, 0x71, 0x5c, 0x83, 0xb8, 0xea, 0x7e, 0x57, 0xf5, 0xc4, 0x41, 0x61, 0xe0, 0x8d,
+	0x2b, 0x53, 0x6c, 0x60, 0xad, 0x1b, 0x9c, 0x9f, 0x24, 0xc0, 0xe5, 0xfe, 0x00, 0xe3, 0xeb, 0x30,
+	0xa1, 0x59, 0x96, 0x6f, 0x4c, 0x53, 0x27, 0x5e, 0xad, 0xc2, 0x96, 0x05, 0xcd, 0xb2, 0xb6, 0x38,
+	0x45, 0xfe, 0x3b, 0x82, 0xeb, 0xb9, 0x1a, 0xf7, 0x3a, 0x6e, 0xe9, 0x05, 0x3a, 0x2e, 0xbe, 0x09,
+	0x17, 0x02, 0xd7, 0x63, 0xd2, 0x8e, 0xde, 0x28, 0x35, 0x4a, 0xca, 0x84, 0x70, 0x3e, 0x9f, 0x84,
+	0xaf, 0xfa, 0x48, 0x76, 0x6c, 0x5d, 0xa3, 0xc4, 0x60, 0x00, 0x8c, 0x2b, 0x11, 0x41, 0xfe, 0x2b,
+	0x02, 0x69, 0x93, 0xd0, 0x75, 0xc7, 0xf6, 0x4c, 0x8f, 0x12, 0x5b, 0xef, 0x0e, 0xe2, 0xf1, 0xaf,
+	0xc0, 0xd4, 0x81, 0xe9, 0x7a, 0x54, 0x8d, 0x6c, 0xc4, 0xdd, 0xfe, 0x22, 0x23, 0xef, 0x05, 0x86,
+	0x6a, 0xc0, 0xb4, 0x47, 0x74, 0xc7, 0x36, 0xd4, 0xb4, 0x31, 0x27, 0x39, 0x7d, 0xef, 0x99, 0xcf,
+	0xc1, 0xc7, 0x08, 0xe6, 0x33, 0x05, 0x3f, 0xe7, 0xd0, 0xf1, 0x6f, 0x1e, 0xc0, 0xd8, 0xa2, 0xab,
+	0x54, 0x21, 0x27, 0xa6, 0xef, 0x13, 0x7d, 0xf0, 0x93, 0x60, 0xdc, 0x15, 0x9c, 0x02, 0xb8, 0xf0,
+	0xbb, 0xd8, 0xf3, 0x93, 0xa1, 0x66, 0x34, 0x1d, 0x6a, 0x32, 0xec, 0x52, 0xce, 0xb2, 0x4b, 0x02,
+	0xed, 0xca, 0x00, 0x68, 0xff, 0x8c, 0xbb, 0x49, 0x8f, 0x96, 0xe7, 0x1c, 0x6c, 0x9e, 0x02, 0x7e,
+	0xec, 0x12, 0xc3, 0xd4, 0x29, 0x1b, 0x2d, 0x06, 0xf9, 0x3a, 0x4c, 0x84, 0xf1, 0x85, 0x78, 0xec,
+	0x24, 0x5e, 0x50, 0x20, 0x88, 0x30, 0xc4, 0x1b, 0x3e, 0x35, 0xff, 0x0a, 0xc1, 0x6c, 0x62, 0x7f,
+	0xa1, 0x7e, 0x86, 0x5e, 0x68, 0xa8, 0x63, 0x9c, 0xb0, 0xf9, 0x48, 0xca, 0xe6, 0xf3, 0x50, 0xf5,
+	0x97, 0xe4, 0x71, 0xb2, 0xc4, 0xe3, 0xa4, 0x4f, 0xf0, 0xb5, 0x90, 0xff, 0x83, 0xe0, 0xf2, 0xdb,
+	0xc4, 0x35, 0x0f, 0xba, 0x61, 0x3c, 0x7a, 0x9e, 0xb0, 0x9b, 0xf4, 0xaf, 0x52, 0x61, 0x2a, 0x1b,
+	0x4d, 0xc9, 0x79, 0x29, 0x70, 0x82, 0x32, 0x43, 0x5a, 0xd8, 0x3c, 0x21, 0x7d, 0x25, 0x29, 0x7d,
+	0xd2, 0x02, 0x63, 0x03, 0x58, 0xa0, 0x03, 0x73, 0x3d, 0xda, 0x0a, 0x23, 0x5c, 0x82, 0xf2, 0x89,
+	0x66, 0x09, 0x6d, 0xc7, 0x15, 0xfe, 0x81, 0xef, 0x01, 0xd6, 0x9d, 0xe3, 0x76, 0x87, 0x12, 0x43,
+	0x8d, 0xe4, 0xe0, 0x6a, 0x4f, 0x07, 0x23, 0x4a, 0x20, 0xcf, 0x65, 0xff, 0x7e, 0xa5, 0x79, 0x8e,
+	0xcd, 0x54, 0xaf, 0x2a, 0xe2, 0x4b, 0xfe, 0x25, 0x82, 0xfa, 0x26, 0xa1, 0x3b, 0x1a, 0x25, 0x1e,
+	0x4d, 0x5a, 0xb2, 0x18, 0xec, 0x84, 0x82, 0x23, 0x03, 0x64, 0x9f, 0x8c, 0x13, 0x5c, 0xca, 0x38,
+	0xc1, 0xf2, 0x27, 0x3c, 0xed, 0x66, 0x4a, 0x94, 0xef, 0x95, 0x43, 0x9d, 0xb6, 0xe8, 0x54, 0x97,
+	0x8a, 0x4e, 0xb5, 0xfc, 0x63, 0x26, 0x49, 0x62, 0x25, 0x7e, 0x3b, 0xe9, 0x9e, 0x35, 0x38, 0x97,
+	0xa0, 0x6c, 0x99, 0xc7, 0x26, 0x0f, 0xd1, 0x65, 0x85, 0x7f, 0xc8, 0x06, 0x5c, 0xcf, 0xdd, 0x5f,
+	0x40, 0xb1, 0x0a, 0xd3, 0x29, 0x28, 0x3c, 0x56, 0x07, 0x15, 0x60, 0x31, 0x99, 0xc0, 0xc2, 0x93,
+	0x0f, 0xe0, 0xaa, 0xbf, 0x4b, 0xfc, 0x5a, 0xbe, 0xee, 0x74, 0xec, 0xb3, 0x76, 0x00, 0xf9, 0x4d,
+	0xa8, 0xe7, 0xec, 0x23, 0x74, 0x09, 0x8e, 0xa8, 0xee, 0x53, 0xe3, 0xb7, 0x4d, 0xc6, 0x26, 0x7f,
+	0x89, 0x60, 0x6e, 0x93, 0xd0, 0x0d, 0x9b, 0xba, 0xdd, 0x55, 0xdb, 0xf8, 0xa6, 0xdd, 0x5f, 0xf1,
+	0x32, 0x00, 0xdb, 0x47, 0xdd, 0xd7, 0x3c, 0x9e, 0x9d, 0x26, 0x97, 0x67, 0xa3, 0x19, 0x6c, 0xcb,
+	0x35, 0xcd, 0x23, 0x4a, 0xd5, 0x0c, 0x7e, 0xca, 0x5f, 0xf0, 0x2a, 0x26, 0xa5, 0xd3, 0x70, 0x89,
+	0x3e, 0x28, 0xd7, 0x4a, 0xc5, 0xe5, 0x5a, 0xc6, 0xa1, 0x19, 0x1d, 0x2a, 0x45, 0x3d, 0x81, 0xc9,
+	0x2d, 0xdb, 0xa4, 0xfe, 0xe7, 0x19, 0x7b, 0xc6, 0x03, 0x98, 0x0a, 0x57, 0x16, 0xba, 0xdf, 0x87,
+	0x31, 0xdd, 0x25, 0xec, 0x66, 0xd7, 0x27, 0xe1, 0x04, 0x7c, 0xf2, 0xdf, 0x10, 0xe0, 0xa0, 0xdc,
+	0x3e, 0x21, 0x5e, 0x1f, 0x21, 0xef, 0x42, 0xc5, 0x62, 0x7c, 0xe2, 0x22, 0x9b, 0x81, 0x9b, 0x60,
+	0x18, 0x3a, 0x9b, 0xe2, 0x6f, 0x43, 0xd5, 0xbf, 0x02, 0x9a, 0xd4, 0xbf, 0x05, 0x71, 0x90, 0x6b,
+	0xa9, 0x7a, 0x76, 0x3d, 0x18, 0x57, 0x22, 0x56, 0xf9, 0x4d, 0x98, 0x4c, 0x0e, 0xfa, 0x41, 0x9e,
+	0x9c, 0xb6, 0x89, 0xee, 0x07, 0xf9, 0xc8, 0x55, 0xb9, 0x22, 0xd3, 0xc1, 0x48, 0x3c, 0x74, 0xce,
+	0x26, 0x10, 0x10, 0x60, 0xbe, 0x01, 0x17, 0xa3, 0x96, 0x43, 0xa4, 0x72, 0x6e, 0x8d, 0x7d, 0x21,
+	0x6c, 0x3a, 0xf8, 0xea, 0x3f, 0x53, 0xdb, 0xe1, 0x33, 0x04, 0x33, 0x3d, 0xa3, 0x79, 0xb6, 0x78,
+	0x3e, 0xf9, 0x16, 0xa1, 0xc2, 0x5b, 0x45, 0xa1, 0x6d, 0x78, 0x13, 0x69, 0xc9, 0x6d, 0xeb, 0x4b,
+	0xbb, 0x6c, 0x44, 0x11, 0x1c, 0xf2, 0x2f, 0x10, 0x5c, 0x49, 0xf5, 0x20, 0x5e, 0x9c, 0xab, 0x0c,
+	0x72, 0xd9, 0x7f, 0x04, 0x52, 0x96, 0x3c, 0xd1, 0x29, 0xe0, 0xed, 0x8e, 0xbe, 0x90, 0x04, 0x7c,
+	0xf2, 0x5f, 0x10, 0xd4, 0xd7, 0x3a, 0xd6, 0xd1, 0x8b, 0xd4, 0xb2, 0x0e, 0xa0, 0x1f, 0x76, 0xec,
+	0xa3, 0x28, 0x70, 0x96, 0x95, 0x2a, 0xa3, 0x3c, 0x5b, 0xc5, 0xf3, 0x47, 0x04, 0xd7, 0xf2, 0x64,
+	0xee, 0x45, 0x02, 0x0d, 0x86, 0x84, 0x9f, 0x53, 0x35, 0xc3, 0x20, 0x86, 0x88, 0xfb, 0xfc, 0xc3,
+	0x2f, 0x50, 0xc8, 0xa9, 0xe9, 0x51, 0xd3, 0x6e, 0x05, 0x21, 0x3f, 0xf8, 0xe6, 0xc5, 0xcb, 0x0f,
+	0xd9, 0x99, 0x0a, 0xee, 0x80, 0xc1, 0xb7, 0xfc, 0x21, 0xcf, 0x3e, 0x5c, 0xac, 0xb5, 0x2e, 0x8b,
+	0xe6, 0x43, 0x66, 0x9f, 0x52, 0x32, 0xfb, 0x0c, 0x5b, 0xbf, 0xcb, 0x3f, 0xe7, 0xc9, 0x22, 0x25,
+	0x82, 0x00, 0x68, 0x08, 0xf3, 0x3d, 0x77, 0x65, 0xf8, 0x8f, 0x24, 0x16, 0x8a, 0x66, 0xb7, 0x48,
+	0xff, 0x92, 0xc5, 0xa3, 0x9a, 0x4b, 0x13, 0xa9, 0x18, 0x18, 0x89, 0xa3, 0x71, 0x09, 0xca, 0x3c,
+	0xef, 0x73, 0xa3, 0xf0, 0x8f, 0xf3, 0x49, 0xc2, 0x29, 0x5c, 0x85, 0x3a, 0x3d, 0xb8, 0xa2, 0x67,
+	0xc0, 0x75, 0xb8, 0x22, 0xf0, 0x4b, 0x5e, 0x8b, 0x06, 0x82, 0x6c, 0x93, 0x81, 0xa0, 0x9d, 0x87,
+	0x2a, 0x87, 0xf6, 0x88, 0x74, 0x83, 0xb2, 0x87, 0x11, 0xb6, 0x49, 0x37, 0x8d, 0x7b, 0xa9, 0x07,
+	0xf7, 0x39, 0x18, 0x23, 0xb6, 0xc1, 0xe6, 0x8e, 0xb2, 0xb9, 0x15, 0x62, 0x1b, 0xfe, 0xcc, 0xd0,
+	0x20, 0xe5, 0x5c, 0x83, 0x0c, 0x52, 0x5f, 0xff, 0x99, 0x77, 0x33, 0x7a, 0x75, 0x1a, 0x1e, 0xdf,
+	0x5b, 0x70, 0x91, 0xf5, 0xc0, 0x4c, 0xbb, 0xe5, 0xcb, 0x1b, 0x14, 0xbe, 0x17, 0x02, 0xe2, 0x36,
+	0xe9, 0x9e, 0x81, 0x73, 0xff, 0x96, 0xb7, 0x3d, 0x36, 0x0e, 0x0e, 0x88, 0x4e, 0xcd, 0x93, 0xc1,
+	0x6e, 0x13, 0xe7, 0xe4, 0xde, 0xf2, 0xe7, 0xdc, 0x43, 0x7a, 0x84, 0x1b, 0x1e, 0xcc, 0x3a, 0x80,
+	0x4d, 0x4e, 0x93, 0x02, 0x57, 0x7d, 0x0a, 0x97, 0xf7, 0xb9, 0x61, 0xfc, 0x00, 0x66, 0xf6, 0x34,
+	0xd3, 0x3a, 0x1b, 0xf4, 0x86, 0xee, 0x67, 0x7c, 0x88, 0x00, 0xc7, 0xb7, 0xff, 0x1a, 0x0e, 0xf3,
+	0x17, 0x08, 0x2e, 0xc7, 0x1c, 0x7f, 0xf8, 0xb6, 0x71, 0x29, 0xd1, 0xbf, 0xc8, 0xec, 0x0c, 0x97,
+	0xce, 0xa6, 0x33, 0x2c, 0x7f, 0x9c, 0x0c, 0xe8, 0x89, 0x86, 0xef, 0x79, 0x26, 0x96, 0x7d, 0xb8,
+	0x98, 0x48, 0xe6, 0x61, 0x6d, 0x83, 0x8a, 0x6b, 0x9b, 0xe8, 0x0a, 0x38, 0xd2, 0xf7, 0x0a, 0xf8,
+	0xcf, 0x11, 0x18, 0x0b, 0x96, 0x6f, 0xc0, 0xf4, 0x31, 0x71, 0x8f, 0x2c, 0xa2, 0x46, 0xc0, 0x23,
+	0x16, 0x05, 0x27, 0x39, 0x7d, 0x27, 0xdd, 0x3e, 0x3a, 0xd1, 0xac, 0x0e, 0x11, 0x51, 0x96, 0x59,
+	0xeb, 0x6d, 0x9f, 0xe0, 0x0f, 0x93, 0x53, 0xea, 0x6a, 0xaa, 0xa1, 0x51, 0x4d, 0xb4, 0xb2, 0xaa,
+	0x8c, 0xf2, 0x40, 0xa3, 0x5a, 0xbf, 0xe6, 0xe6, 0x3d, 0xc0, 0x7c, 0xd8, 0x20, 0x36, 0x35, 0x69,
+	0x97, 0x0b, 0x52, 0xe6, 0xad, 0x1c, 0xc6, 0x26, 0x06, 0x98, 0x28, 0xeb, 0x30, 0xc5, 0xee, 0xbf,
+	0x6a, 0xf8, 0x78, 0x2a, 0x02, 0xb1, 0x14, 0x68, 0x1d, 0x3c, 0xaf, 0x2e, 0xed, 0x05, 0x1c, 0xca,
+	0x24, 0x9b, 0x12, 0x7e, 0xe3, 0x6d, 0x98, 0x35, 0x6d, 0x4a, 0x5a, 0xae, 0x46, 0xe3, 0x0b, 0x8d,
+	0xf5, 0x5d, 0x08, 0x87, 0xd3, 0x42, 0xda, 0xe2, 0xff, 0x43, 0x35, 0x4c, 0xac, 0x78, 0x16, 0xa6,
+	0xb6, 0x1e, 0x3e, 0xd8, 0x78, 0xa2, 0xae, 0xad, 0xee, 0x6e, 0xa8, 0xef, 0x6c, 0x28, 0x8f, 0xa6,
+	0x5f, 0xc2, 0x18, 0x26, 0x63, 0xc4, 0x47, 0x0f, 0x37, 0xa6, 0xd1, 0xf2, 0x57, 0x97, 0x60, 0x62,
+	0x4f, 0xd8, 0x73, 0xc7, 0x69, 0x61, 0x1b, 0xaa, 0xe1, 0x6b, 0x29, 0x96, 0x52, 0xd7, 0xbb, 0xd8,
+	0xb3, 0xa5, 0x34, 0x9f, 0x39, 0xc6, 0xdd, 0x55, 0x6e, 0x7c, 0xf4, 0xaf, 0xaf, 0x7e, 0x3d, 0x22,
+	0xcb, 0xf5, 0xe6, 0xc9, 0xfd, 0x7d, 0x42, 0xb5, 0xfb, 0x4d, 0xcb, 0x69, 0x79, 0xcd, 0xf7, 0xf9,
+	0x81, 0x7b, 0xda, 0xe4, 0xae, 0xba, 0x82, 0x16, 0xf1, 0xa7, 0x08, 0xa6, 0xd3, 0xef, 0x91, 0xf8,
+	0x66, 0xb4, 0x76, 0xce, 0xab, 0xa9, 0x24, 0x17, 0xb1, 0x08, 0x29, 0x96, 0x99, 0x14, 0xf7, 0xe4,
+	0x3b, 0xc5, 0x52, 0x04, 0x07, 0xd9, 0xf0, 0xe5, 0xf9, 0x03, 0x82, 0x99, 0x9e, 0xd7, 0x17, 0x1c,
+	0xdb, 0x2d, 0xef, 0xb9, 0x53, 0xba, 0x55, 0xc8, 0x23, 0x44, 0x5a, 0x63, 0x22, 0xbd, 0x81, 0x57,
+	0x0a, 0x45, 0x6a, 0xbe, 0x1f, 0x39, 0xea, 0xd3, 0x15, 0x33, 0x58, 0x4a, 0xe5, 0xad, 0x86, 0x0f,
+	0x58, 0x2e, 0xcf, 0x7b, 0xa1, 0xc3, 0xf7, 0x12, 0x72, 0xf4, 0x79, 0x73, 0x94, 0x5e, 0x1d, 0x90,
+	0x5b, 0xc8, 0xff, 0x12, 0xfe, 0x9c, 0x47, 0xa9, 0xac, 0xe7, 0x29, 0xdc, 0x28, 0x80, 0x20, 0x11,
+	0x7c, 0xa5, 0xbb, 0x03, 0x70, 0x8a, 0x2d, 0xbf, 0xc3, 0x20, 0xbb, 0x8f, 0x9b, 0xc5, 0x56, 0x8c,
+	0x50, 0xda, 0xe7, 0x47, 0x17, 0x7f, 0x86, 0x60, 0x36, 0xe3, 0x09, 0x07, 0xdf, 0x4e, 0xec, 0x9d,
+	0xf3, 0x34, 0x25, 0x2d, 0xf4, 0xe1, 0x12, 0xd2, 0xbd, 0xc6, 0xa4, 0x5b, 0xc4, 0x8d, 0x6c, 0xe9,
+	0x56, 0xf4, 0x68, 0xa2, 0x30, 0x9f, 0x0a, 0xb8, 0xf7, 0xa9, 0x03, 0x27, 0xbd, 0x27, 0xfb, 0xb9,
+	0x47, 0xba, 0x5d, 0xcc, 0x24, 0x72, 0xc5, 0x0e, 0x4c, 0xc4, 0x5e, 0x11, 0xf0, 0xd5, 0x78, 0xc7,
+	0x2a, 0xfd, 0xb8, 0x21, 0xd5, 0x73, 0x46, 0x43, 0x7b, 0x3f, 0x81, 0xa9, 0x54, 0x4b, 0x1c, 0xdf,
+	0x88, 0xe6, 0x64, 0xbf, 0x0d, 0x48, 0x37, 0x0b, 0x38, 0xc2, 0x95, 0x7f, 0x23, 0x72, 0x73, 0x6f,
+	0x8f, 0x19, 0xdf, 0x49, 0x28, 0x9a, 0xdf, 0x17, 0x97, 0x1a, 0xfd, 0x19, 0xc5, 0x7e, 0xff, 0xc7,
+	0x0c, 0xb5, 0x80, 0x6f, 0xe5, 0xb8, 0x11, 0xeb, 0xda, 0xae, 0x58, 0x6c, 0x05, 0xdc, 0x66, 0x3e,
+	0x9e, 0xd5, 0xf3, 0x4d, 0xf9, 0x78, 0x41, 0x5b, 0x5a, 0xba, 0x3b, 0x00, 0x67, 0x08, 0xc6, 0xef,
+	0x11, 0xbc, 0x9c, 0xd9, 0x98, 0xc5, 0xaf, 0x24, 0x97, 0xc9, 0xeb, 0x10, 0x4b, 0x77, 0xfa, 0xf2,
+	0x89, 0xcd, 0xbe, 0xc5, 0x90, 0x68, 0xe2, 0x57, 0x07, 0x0c, 0x8b, 0xbc, 0x15, 0xcc, 0x22, 0x75,
+	0xba, 0x4b, 0x1a, 0x8f, 0xd4, 0x39, 0x5d, 0x61, 0x49, 0x2e, 0x62, 0x49, 0x46, 0x6a, 0xbc, 0x38,
+	0x78, 0x58, 0xc4, 0x3a, 0x8c, 0x89, 0x7e, 0x25, 0xae, 0xc5, 0x6b, 0xcb, 0x78, 0x73, 0x54, 0xba,
+	0x92, 0x31, 0x22, 0xf6, 0xbc, 0xc5, 0xf6, 0xac, 0xcb, 0xf3, 0x39, 0x27, 0xd7, 0xb4, 0x4d, 0xea,
+	0x9f, 0xa5, 0x58, 0x2f, 0x2f, 0x7e, 0x96, 0x7a, 0x9b, 0x9c, 0x52, 0x3d, 0x67, 0x34, 0x34, 0xb2,
+	0x06, 0xb8, 0xb7, 0xbb, 0x12, 0x3f, 0xfa, 0xb9, 0xfd, 0x22, 0xe9, 0x76, 0x31, 0x53, 0xb8, 0xc5,
+	0x11, 0x5c, 0xce, 0x6e, 0xe2, 0xc4, 0xcf, 0x54, 0x61, 0x6b, 0x4a, 0x6a, 0xf4, 0x67, 0x14, 0x91,
+	0xe6, 0x07, 0xcc, 0x23, 0x12, 0xad, 0x90, 0x94, 0x47, 0x64, 0x75, 0x6a, 0x24, 0xb9, 0x88, 0x25,
+	0xd4, 0x24, 0xb9, 0x38, 0xab, 0x57, 0x73, 0x16, 0x8f, 0xd7, 0xe7, 0x92, 0x5c, 0xc4, 0x12, 0x8f,
+	0x6a, 0xa9, 0xab, 0x76, 0x3c, 0xaa, 0x65, 0x57, 0x0c, 0xd2, 0xcd, 0x02, 0x8e, 0x70, 0x65, 0x03,
+	0x66, 0x63, 0x83, 0x41, 0xa5, 0x9d, 0x4a, 0x3a, 0x39, 0xcd, 0x05, 0x69, 0xa1, 0x0f, 0x57, 0xdc,
+	0x93, 0x7a, 0x2b, 0xd0, 0x54, 0x12, 0xc9, 0x2e, 0x9e, 0xa5, 0xdb, 0xc5, 0x4c, 0xe1, 0x16, 0xdb,
+	0x00, 0x51, 0xf1, 0x86, 0x63, 0xd7, 0xbd, 0x9e, 0x8a, 0x52, 0xba, 0x9a, 0x3d, 0x18, 0x2c, 0xf5,
+	0x1a, 0x5a, 0x7b, 0x08, 0x57, 0x74, 0xe7, 0x38, 0xb8, 0xd1, 0x26, 0xff, 0x46, 0xb8, 0x36, 0x1b,
+	0xbb, 0x80, 0xae, 0xb6, 0xcd, 0xc7, 0x3e, 0xf1, 0x31, 0x7a, 0x47, 0x6a, 0x99, 0xf4, 0xb0, 0xb3,
+	0xbf, 0xa4, 0x3b, 0xc7, 0x4d, 0x3e, 0xb1, 0x19, 0x4c, 0xdc, 0xaf, 0xb0, 0x99, 0xaf, 0xff, 0x6f,
+	0x00, 0xf3, 0x5f, 0xd9, 0x8a, 0x0c, 0x29, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1914,6 +3347,15 @@ type TrillianLogClient interface {
 	// If the requested tree_size is larger than the server is aware of, the
 	// response will include the latest known log root and an empty proof.
 	GetInclusionProof(ctx context.Context, in *GetInclusionProofRequest, opts ...grpc.CallOption) (*GetInclusionProofResponse, error)
+	// GetHistoricalInclusionProof returns an inclusion proof for a leaf with a
+	// given index as of an earlier tree size, together with the signed log root
+	// that the server retained for that size, so that the proof can be tied to a
+	// checkpoint the caller already trusts.
+	//
+	// Returns NOT_FOUND if no signed log root of the requested tree size is
+	// stored, and UNIMPLEMENTED if the storage doesn't support looking roots up
+	// by size.
+	GetHistoricalInclusionProof(ctx context.Context, in *GetHistoricalInclusionProofRequest, opts ...grpc.CallOption) (*GetHistoricalInclusionProofResponse, error)
 	// GetInclusionProofByHash returns an inclusion proof for any leaves that have
 	// the given Merkle hash in a particular tree.
 	//
@@ -1925,14 +3367,63 @@ type TrillianLogClient interface {
 	//
 	// If the requested tree size is larger than the server is aware of,
 	// the response will include the latest known log root and an empty proof.
+	//
+	// Sizes must satisfy 0 <= first_tree_size <= second_tree_size, or an
+	// InvalidArgument error is returned. If first_tree_size is 0, or equal to
+	// second_tree_size, the proof is trivial: the response holds a proof with no
+	// hashes, which the client verifies by checking the root hashes instead.
 	GetConsistencyProof(ctx context.Context, in *GetConsistencyProofRequest, opts ...grpc.CallOption) (*GetConsistencyProofResponse, error)
+	// GetProofAtRevision returns an inclusion or consistency proof with the
+	// nodes read at the given storage revision of the tree, rather than at the
+	// revision of the latest signed log root. It's a low-level troubleshooting
+	// tool, e.g. for finding storage whose revisions don't match the tree sizes
+	// of their roots, and callers are responsible for pairing the revision with
+	// the right tree size: proofs of mismatched ones won't verify.
+	//
+	// Returns PERMISSION_DENIED unless enabled by the operator of the server,
+	// INVALID_ARGUMENT for revisions later than that of the latest root, and
+	// FAILED_PRECONDITION for revisions whose nodes storage compaction may have
+	// removed.
+	GetProofAtRevision(ctx context.Context, in *GetProofAtRevisionRequest, opts ...grpc.CallOption) (*GetProofAtRevisionResponse, error)
+	// PredictRoot returns the root hash the log would have if the given Merkle
+	// leaf hashes were appended to it, in order, at its latest signed root. It
+	// is read-only and doesn't queue the leaves.
+	//
+	// The prediction is advisory: leaves integrated concurrently, e.g. queued
+	// by other clients, change the position and root of the appended leaves,
+	// and leaves which duplicate existing ones aren't integrated again. Clients
+	// must verify the actual root once their leaves are integrated.
+	PredictRoot(ctx context.Context, in *PredictRootRequest, opts ...grpc.CallOption) (*PredictRootResponse, error)
+	// VerifyInclusion verifies an inclusion proof supplied by the client, e.g.
+	// one returned by GetInclusionProof, against a root hash, with the same
+	// logic and the hasher of the log as client-side verification. It is
+	// read-only, and doesn't check that the leaf or root are those of the log.
+	//
+	// It's a convenience for constrained clients which can't verify proofs
+	// themselves, and for debugging mismatches. Its result is only as
+	// trustworthy as the server: clients which don't trust the server must
+	// verify proofs themselves.
+	VerifyInclusion(ctx context.Context, in *VerifyInclusionRequest, opts ...grpc.CallOption) (*VerifyInclusionResponse, error)
 	// GetLatestSignedLogRoot returns the latest signed log root for a given tree,
 	// and optionally also includes a consistency proof from an earlier tree size
 	// to the new size of the tree.
 	//
 	// If the earlier tree size is larger than the server is aware of,
 	// an InvalidArgument error is returned.
+	//
+	// If the log exists but has no root yet, i.e. it hasn't been initialised
+	// with InitLog, a FailedPrecondition error is returned with a
+	// google.rpc.PreconditionFailure detail holding a violation of type
+	// "TREE_NEEDS_INIT". Logs that don't exist or are deleted result in a
+	// NotFound error instead.
 	GetLatestSignedLogRoot(ctx context.Context, in *GetLatestSignedLogRootRequest, opts ...grpc.CallOption) (*GetLatestSignedLogRootResponse, error)
+	// GetSignedLogRootHistory returns the most recent signed log roots stored
+	// for a given tree, newest first, e.g. so that monitors can chart the growth
+	// of the tree, or check that it never shrank, without polling
+	// GetLatestSignedLogRoot.
+	//
+	// Errors are as for GetLatestSignedLogRoot.
+	GetSignedLogRootHistory(ctx context.Context, in *GetSignedLogRootHistoryRequest, opts ...grpc.CallOption) (*GetSignedLogRootHistoryResponse, error)
 	// GetSequencedLeafCount returns the total number of leaves that have been
 	// integrated into the given tree.
 	//
@@ -1956,7 +3447,17 @@ type TrillianLogClient interface {
 	QueueLeaves(ctx context.Context, in *QueueLeavesRequest, opts ...grpc.CallOption) (*QueueLeavesResponse, error)
 	// AddSequencedLeaves adds a batch of leaves with assigned sequence numbers
 	// to a pre-ordered log.  The indices of the provided leaves must be contiguous.
+	// Leaves may carry queue and integrate timestamps, which are preserved
+	// verbatim; if unset, the time of the call and zero are stored respectively.
 	AddSequencedLeaves(ctx context.Context, in *AddSequencedLeavesRequest, opts ...grpc.CallOption) (*AddSequencedLeavesResponse, error)
+	// BulkAddSequencedLeaves adds a large batch of leaves with assigned sequence
+	// numbers to a pre-ordered log, e.g. when migrating a dataset into it. The
+	// indices of the provided leaves must be contiguous and ascending. The
+	// leaves are added in chunks, each in its own transaction, so a failed
+	// request may have added some of its chunks. Leaves already stored at their
+	// index are reported with an ALREADY_EXISTS status rather than as conflicts,
+	// so a failed request can be resubmitted as is.
+	BulkAddSequencedLeaves(ctx context.Context, in *BulkAddSequencedLeavesRequest, opts ...grpc.CallOption) (*BulkAddSequencedLeavesResponse, error)
 	// GetLeavesByIndex returns a batch of leaves whose leaf indices are provided
 	// in the request.
 	GetLeavesByIndex(ctx context.Context, in *GetLeavesByIndexRequest, opts ...grpc.CallOption) (*GetLeavesByIndexResponse, error)
@@ -1966,6 +3467,19 @@ type TrillianLogClient interface {
 	// GetLeavesByHash returns a batch of leaves which are identified by their
 	// Merkle leaf hash values.
 	GetLeavesByHash(ctx context.Context, in *GetLeavesByHashRequest, opts ...grpc.CallOption) (*GetLeavesByHashResponse, error)
+	// GetLeavesByKeyRange returns a batch of integrated leaves of a log with a
+	// leaf_ordering_key, ordered by their ordering key and then by leaf index.
+	GetLeavesByKeyRange(ctx context.Context, in *GetLeavesByKeyRangeRequest, opts ...grpc.CallOption) (*GetLeavesByKeyRangeResponse, error)
+	// GetEffectiveLeaves returns the integrated leaves of a log with
+	// leaf_tombstones in a range of leaf indices, omitting tombstones and the
+	// leaves they delete.
+	GetEffectiveLeaves(ctx context.Context, in *GetEffectiveLeavesRequest, opts ...grpc.CallOption) (*GetEffectiveLeavesResponse, error)
+	// TailLeaves streams the leaves of a log in order from start_index: first
+	// those already integrated, then new ones as the server observes them being
+	// integrated, until the client cancels the stream. The server only sends as
+	// fast as the client receives. To resume after a disconnection, call it
+	// again with the index following the last leaf received.
+	TailLeaves(ctx context.Context, in *TailLeavesRequest, opts ...grpc.CallOption) (TrillianLog_TailLeavesClient, error)
 }
 
 type trillianLogClient struct {
@@ -2003,6 +3517,15 @@ func (c *trillianLogClient) GetInclusionProof(ctx context.Context, in *GetInclus
 	return out, nil
 }
 
+func (c *trillianLogClient) GetHistoricalInclusionProof(ctx context.Context, in *GetHistoricalInclusionProofRequest, opts ...grpc.CallOption) (*GetHistoricalInclusionProofResponse, error) {
+	out := new(GetHistoricalInclusionProofResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetHistoricalInclusionProof", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trillianLogClient) GetInclusionProofByHash(ctx context.Context, in *GetInclusionProofByHashRequest, opts ...grpc.CallOption) (*GetInclusionProofByHashResponse, error) {
 	out := new(GetInclusionProofByHashResponse)
 	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetInclusionProofByHash", in, out, opts...)
@@ -2021,6 +3544,33 @@ func (c *trillianLogClient) GetConsistencyProof(ctx context.Context, in *GetCons
 	return out, nil
 }
 
+func (c *trillianLogClient) GetProofAtRevision(ctx context.Context, in *GetProofAtRevisionRequest, opts ...grpc.CallOption) (*GetProofAtRevisionResponse, error) {
+	out := new(GetProofAtRevisionResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetProofAtRevision", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) PredictRoot(ctx context.Context, in *PredictRootRequest, opts ...grpc.CallOption) (*PredictRootResponse, error) {
+	out := new(PredictRootResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/PredictRoot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) VerifyInclusion(ctx context.Context, in *VerifyInclusionRequest, opts ...grpc.CallOption) (*VerifyInclusionResponse, error) {
+	out := new(VerifyInclusionResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/VerifyInclusion", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trillianLogClient) GetLatestSignedLogRoot(ctx context.Context, in *GetLatestSignedLogRootRequest, opts ...grpc.CallOption) (*GetLatestSignedLogRootResponse, error) {
 	out := new(GetLatestSignedLogRootResponse)
 	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetLatestSignedLogRoot", in, out, opts...)
@@ -2030,6 +3580,15 @@ func (c *trillianLogClient) GetLatestSignedLogRoot(ctx context.Context, in *GetL
 	return out, nil
 }
 
+func (c *trillianLogClient) GetSignedLogRootHistory(ctx context.Context, in *GetSignedLogRootHistoryRequest, opts ...grpc.CallOption) (*GetSignedLogRootHistoryResponse, error) {
+	out := new(GetSignedLogRootHistoryResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetSignedLogRootHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trillianLogClient) GetSequencedLeafCount(ctx context.Context, in *GetSequencedLeafCountRequest, opts ...grpc.CallOption) (*GetSequencedLeafCountResponse, error) {
 	out := new(GetSequencedLeafCountResponse)
 	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetSequencedLeafCount", in, out, opts...)
@@ -2075,6 +3634,15 @@ func (c *trillianLogClient) AddSequencedLeaves(ctx context.Context, in *AddSeque
 	return out, nil
 }
 
+func (c *trillianLogClient) BulkAddSequencedLeaves(ctx context.Context, in *BulkAddSequencedLeavesRequest, opts ...grpc.CallOption) (*BulkAddSequencedLeavesResponse, error) {
+	out := new(BulkAddSequencedLeavesResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/BulkAddSequencedLeaves", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *trillianLogClient) GetLeavesByIndex(ctx context.Context, in *GetLeavesByIndexRequest, opts ...grpc.CallOption) (*GetLeavesByIndexResponse, error) {
 	out := new(GetLeavesByIndexResponse)
 	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetLeavesByIndex", in, out, opts...)
@@ -2102,6 +3670,56 @@ func (c *trillianLogClient) GetLeavesByHash(ctx context.Context, in *GetLeavesBy
 	return out, nil
 }
 
+func (c *trillianLogClient) GetLeavesByKeyRange(ctx context.Context, in *GetLeavesByKeyRangeRequest, opts ...grpc.CallOption) (*GetLeavesByKeyRangeResponse, error) {
+	out := new(GetLeavesByKeyRangeResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetLeavesByKeyRange", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) GetEffectiveLeaves(ctx context.Context, in *GetEffectiveLeavesRequest, opts ...grpc.CallOption) (*GetEffectiveLeavesResponse, error) {
+	out := new(GetEffectiveLeavesResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLog/GetEffectiveLeaves", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogClient) TailLeaves(ctx context.Context, in *TailLeavesRequest, opts ...grpc.CallOption) (TrillianLog_TailLeavesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_TrillianLog_serviceDesc.Streams[0], "/trillian.TrillianLog/TailLeaves", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &trillianLogTailLeavesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type TrillianLog_TailLeavesClient interface {
+	Recv() (*TailLeavesResponse, error)
+	grpc.ClientStream
+}
+
+type trillianLogTailLeavesClient struct {
+	grpc.ClientStream
+}
+
+func (x *trillianLogTailLeavesClient) Recv() (*TailLeavesResponse, error) {
+	m := new(TailLeavesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // TrillianLogServer is the server API for TrillianLog service.
 type TrillianLogServer interface {
 	// QueueLeaf adds a single leaf to the queue of pending leaves for a normal
@@ -2116,6 +3734,15 @@ type TrillianLogServer interface {
 	// If the requested tree_size is larger than the server is aware of, the
 	// response will include the latest known log root and an empty proof.
 	GetInclusionProof(context.Context, *GetInclusionProofRequest) (*GetInclusionProofResponse, error)
+	// GetHistoricalInclusionProof returns an inclusion proof for a leaf with a
+	// given index as of an earlier tree size, together with the signed log root
+	// that the server retained for that size, so that the proof can be tied to a
+	// checkpoint the caller already trusts.
+	//
+	// Returns NOT_FOUND if no signed log root of the requested tree size is
+	// stored, and UNIMPLEMENTED if the storage doesn't support looking roots up
+	// by size.
+	GetHistoricalInclusionProof(context.Context, *GetHistoricalInclusionProofRequest) (*GetHistoricalInclusionProofResponse, error)
 	// GetInclusionProofByHash returns an inclusion proof for any leaves that have
 	// the given Merkle hash in a particular tree.
 	//
@@ -2127,14 +3754,63 @@ type TrillianLogServer interface {
 	//
 	// If the requested tree size is larger than the server is aware of,
 	// the response will include the latest known log root and an empty proof.
+	//
+	// Sizes must satisfy 0 <= first_tree_size <= second_tree_size, or an
+	// InvalidArgument error is returned. If first_tree_size is 0, or equal to
+	// second_tree_size, the proof is trivial: the response holds a proof with no
+	// hashes, which the client verifies by checking the root hashes instead.
 	GetConsistencyProof(context.Context, *GetConsistencyProofRequest) (*GetConsistencyProofResponse, error)
+	// GetProofAtRevision returns an inclusion or consistency proof with the
+	// nodes read at the given storage revision of the tree, rather than at the
+	// revision of the latest signed log root. It's a low-level troubleshooting
+	// tool, e.g. for finding storage whose revisions don't match the tree sizes
+	// of their roots, and callers are responsible for pairing the revision with
+	// the right tree size: proofs of mismatched ones won't verify.
+	//
+	// Returns PERMISSION_DENIED unless enabled by the operator of the server,
+	// INVALID_ARGUMENT for revisions later than that of the latest root, and
+	// FAILED_PRECONDITION for revisions whose nodes storage compaction may have
+	// removed.
+	GetProofAtRevision(context.Context, *GetProofAtRevisionRequest) (*GetProofAtRevisionResponse, error)
+	// PredictRoot returns the root hash the log would have if the given Merkle
+	// leaf hashes were appended to it, in order, at its latest signed root. It
+	// is read-only and doesn't queue the leaves.
+	//
+	// The prediction is advisory: leaves integrated concurrently, e.g. queued
+	// by other clients, change the position and root of the appended leaves,
+	// and leaves which duplicate existing ones aren't integrated again. Clients
+	// must verify the actual root once their leaves are integrated.
+	PredictRoot(context.Context, *PredictRootRequest) (*PredictRootResponse, error)
+	// VerifyInclusion verifies an inclusion proof supplied by the client, e.g.
+	// one returned by GetInclusionProof, against a root hash, with the same
+	// logic and the hasher of the log as client-side verification. It is
+	// read-only, and doesn't check that the leaf or root are those of the log.
+	//
+	// It's a convenience for constrained clients which can't verify proofs
+	// themselves, and for debugging mismatches. Its result is only as
+	// trustworthy as the server: clients which don't trust the server must
+	// verify proofs themselves.
+	VerifyInclusion(context.Context, *VerifyInclusionRequest) (*VerifyInclusionResponse, error)
 	// GetLatestSignedLogRoot returns the latest signed log root for a given tree,
 	// and optionally also includes a consistency proof from an earlier tree size
 	// to the new size of the tree.
 	//
 	// If the earlier tree size is larger than the server is aware of,
 	// an InvalidArgument error is returned.
+	//
+	// If the log exists but has no root yet, i.e. it hasn't been initialised
+	// with InitLog, a FailedPrecondition error is returned with a
+	// google.rpc.PreconditionFailure detail holding a violation of type
+	// "TREE_NEEDS_INIT". Logs that don't exist or are deleted result in a
+	// NotFound error instead.
 	GetLatestSignedLogRoot(context.Context, *GetLatestSignedLogRootRequest) (*GetLatestSignedLogRootResponse, error)
+	// GetSignedLogRootHistory returns the most recent signed log roots stored
+	// for a given tree, newest first, e.g. so that monitors can chart the growth
+	// of the tree, or check that it never shrank, without polling
+	// GetLatestSignedLogRoot.
+	//
+	// Errors are as for GetLatestSignedLogRoot.
+	GetSignedLogRootHistory(context.Context, *GetSignedLogRootHistoryRequest) (*GetSignedLogRootHistoryResponse, error)
 	// GetSequencedLeafCount returns the total number of leaves that have been
 	// integrated into the given tree.
 	//
@@ -2158,7 +3834,17 @@ type TrillianLogServer interface {
 	QueueLeaves(context.Context, *QueueLeavesRequest) (*QueueLeavesResponse, error)
 	// AddSequencedLeaves adds a batch of leaves with assigned sequence numbers
 	// to a pre-ordered log.  The indices of the provided leaves must be contiguous.
+	// Leaves may carry queue and integrate timestamps, which are preserved
+	// verbatim; if unset, the time of the call and zero are stored respectively.
 	AddSequencedLeaves(context.Context, *AddSequencedLeavesRequest) (*AddSequencedLeavesResponse, error)
+	// BulkAddSequencedLeaves adds a large batch of leaves with assigned sequence
+	// numbers to a pre-ordered log, e.g. when migrating a dataset into it. The
+	// indices of the provided leaves must be contiguous and ascending. The
+	// leaves are added in chunks, each in its own transaction, so a failed
+	// request may have added some of its chunks. Leaves already stored at their
+	// index are reported with an ALREADY_EXISTS status rather than as conflicts,
+	// so a failed request can be resubmitted as is.
+	BulkAddSequencedLeaves(context.Context, *BulkAddSequencedLeavesRequest) (*BulkAddSequencedLeavesResponse, error)
 	// GetLeavesByIndex returns a batch of leaves whose leaf indices are provided
 	// in the request.
 	GetLeavesByIndex(context.Context, *GetLeavesByIndexRequest) (*GetLeavesByIndexResponse, error)
@@ -2168,6 +3854,19 @@ type TrillianLogServer interface {
 	// GetLeavesByHash returns a batch of leaves which are identified by their
 	// Merkle leaf hash values.
 	GetLeavesByHash(context.Context, *GetLeavesByHashRequest) (*GetLeavesByHashResponse, error)
+	// GetLeavesByKeyRange returns a batch of integrated leaves of a log with a
+	// leaf_ordering_key, ordered by their ordering key and then by leaf index.
+	GetLeavesByKeyRange(context.Context, *GetLeavesByKeyRangeRequest) (*GetLeavesByKeyRangeResponse, error)
+	// GetEffectiveLeaves returns the integrated leaves of a log with
+	// leaf_tombstones in a range of leaf indices, omitting tombstones and the
+	// leaves they delete.
+	GetEffectiveLeaves(context.Context, *GetEffectiveLeavesRequest) (*GetEffectiveLeavesResponse, error)
+	// TailLeaves streams the leaves of a log in order from start_index: first
+	// those already integrated, then new ones as the server observes them being
+	// integrated, until the client cancels the stream. The server only sends as
+	// fast as the client receives. To resume after a disconnection, call it
+	// again with the index following the last leaf received.
+	TailLeaves(*TailLeavesRequest, TrillianLog_TailLeavesServer) error
 }
 
 // UnimplementedTrillianLogServer can be embedded to have forward compatible implementations.
@@ -2183,15 +3882,30 @@ func (*UnimplementedTrillianLogServer) AddSequencedLeaf(ctx context.Context, req
 func (*UnimplementedTrillianLogServer) GetInclusionProof(ctx context.Context, req *GetInclusionProofRequest) (*GetInclusionProofResponse, error) {
 	return nil, status1.Errorf(codes.Unimplemented, "method GetInclusionProof not implemented")
 }
+func (*UnimplementedTrillianLogServer) GetHistoricalInclusionProof(ctx context.Context, req *GetHistoricalInclusionProofRequest) (*GetHistoricalInclusionProofResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method GetHistoricalInclusionProof not implemented")
+}
 func (*UnimplementedTrillianLogServer) GetInclusionProofByHash(ctx context.Context, req *GetInclusionProofByHashRequest) (*GetInclusionProofByHashResponse, error) {
 	return nil, status1.Errorf(codes.Unimplemented, "method GetInclusionProofByHash not implemented")
 }
 func (*UnimplementedTrillianLogServer) GetConsistencyProof(ctx context.Context, req *GetConsistencyProofRequest) (*GetConsistencyProofResponse, error) {
 	return nil, status1.Errorf(codes.Unimplemented, "method GetConsistencyProof not implemented")
 }
+func (*UnimplementedTrillianLogServer) GetProofAtRevision(ctx context.Context, req *GetProofAtRevisionRequest) (*GetProofAtRevisionResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method GetProofAtRevision not implemented")
+}
+func (*UnimplementedTrillianLogServer) PredictRoot(ctx context.Context, req *PredictRootRequest) (*PredictRootResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method PredictRoot not implemented")
+}
+func (*UnimplementedTrillianLogServer) VerifyInclusion(ctx context.Context, req *VerifyInclusionRequest) (*VerifyInclusionResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method VerifyInclusion not implemented")
+}
 func (*UnimplementedTrillianLogServer) GetLatestSignedLogRoot(ctx context.Context, req *GetLatestSignedLogRootRequest) (*GetLatestSignedLogRootResponse, error) {
 	return nil, status1.Errorf(codes.Unimplemented, "method GetLatestSignedLogRoot not implemented")
 }
+func (*UnimplementedTrillianLogServer) GetSignedLogRootHistory(ctx context.Context, req *GetSignedLogRootHistoryRequest) (*GetSignedLogRootHistoryResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method GetSignedLogRootHistory not implemented")
+}
 func (*UnimplementedTrillianLogServer) GetSequencedLeafCount(ctx context.Context, req *GetSequencedLeafCountRequest) (*GetSequencedLeafCountResponse, error) {
 	return nil, status1.Errorf(codes.Unimplemented, "method GetSequencedLeafCount not implemented")
 }
@@ -2207,6 +3921,9 @@ func (*UnimplementedTrillianLogServer) QueueLeaves(ctx context.Context, req *Que
 func (*UnimplementedTrillianLogServer) AddSequencedLeaves(ctx context.Context, req *AddSequencedLeavesRequest) (*AddSequencedLeavesResponse, error) {
 	return nil, status1.Errorf(codes.Unimplemented, "method AddSequencedLeaves not implemented")
 }
+func (*UnimplementedTrillianLogServer) BulkAddSequencedLeaves(ctx context.Context, req *BulkAddSequencedLeavesRequest) (*BulkAddSequencedLeavesResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method BulkAddSequencedLeaves not implemented")
+}
 func (*UnimplementedTrillianLogServer) GetLeavesByIndex(ctx context.Context, req *GetLeavesByIndexRequest) (*GetLeavesByIndexResponse, error) {
 	return nil, status1.Errorf(codes.Unimplemented, "method GetLeavesByIndex not implemented")
 }
@@ -2216,6 +3933,15 @@ func (*UnimplementedTrillianLogServer) GetLeavesByRange(ctx context.Context, req
 func (*UnimplementedTrillianLogServer) GetLeavesByHash(ctx context.Context, req *GetLeavesByHashRequest) (*GetLeavesByHashResponse, error) {
 	return nil, status1.Errorf(codes.Unimplemented, "method GetLeavesByHash not implemented")
 }
+func (*UnimplementedTrillianLogServer) GetLeavesByKeyRange(ctx context.Context, req *GetLeavesByKeyRangeRequest) (*GetLeavesByKeyRangeResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method GetLeavesByKeyRange not implemented")
+}
+func (*UnimplementedTrillianLogServer) GetEffectiveLeaves(ctx context.Context, req *GetEffectiveLeavesRequest) (*GetEffectiveLeavesResponse, error) {
+	return nil, status1.Errorf(codes.Unimplemented, "method GetEffectiveLeaves not implemented")
+}
+func (*UnimplementedTrillianLogServer) TailLeaves(req *TailLeavesRequest, srv TrillianLog_TailLeavesServer) error {
+	return status1.Errorf(codes.Unimplemented, "method TailLeaves not implemented")
+}
 
 func RegisterTrillianLogServer(s *grpc.Server, srv TrillianLogServer) {
 	s.RegisterService(&_TrillianLog_serviceDesc, srv)
@@ -2275,6 +4001,24 @@ func _TrillianLog_GetInclusionProof_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetHistoricalInclusionProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHistoricalInclusionProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetHistoricalInclusionProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetHistoricalInclusionProof",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetHistoricalInclusionProof(ctx, req.(*GetHistoricalInclusionProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianLog_GetInclusionProofByHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetInclusionProofByHashRequest)
 	if err := dec(in); err != nil {
@@ -2311,6 +4055,60 @@ func _TrillianLog_GetConsistencyProof_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetProofAtRevision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProofAtRevisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetProofAtRevision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetProofAtRevision",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetProofAtRevision(ctx, req.(*GetProofAtRevisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_PredictRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PredictRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).PredictRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/PredictRoot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).PredictRoot(ctx, req.(*PredictRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_VerifyInclusion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyInclusionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).VerifyInclusion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/VerifyInclusion",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).VerifyInclusion(ctx, req.(*VerifyInclusionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianLog_GetLatestSignedLogRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetLatestSignedLogRootRequest)
 	if err := dec(in); err != nil {
@@ -2329,6 +4127,24 @@ func _TrillianLog_GetLatestSignedLogRoot_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetSignedLogRootHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSignedLogRootHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetSignedLogRootHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetSignedLogRootHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetSignedLogRootHistory(ctx, req.(*GetSignedLogRootHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianLog_GetSequencedLeafCount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetSequencedLeafCountRequest)
 	if err := dec(in); err != nil {
@@ -2419,6 +4235,24 @@ func _TrillianLog_AddSequencedLeaves_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_BulkAddSequencedLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BulkAddSequencedLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).BulkAddSequencedLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/BulkAddSequencedLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).BulkAddSequencedLeaves(ctx, req.(*BulkAddSequencedLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _TrillianLog_GetLeavesByIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetLeavesByIndexRequest)
 	if err := dec(in); err != nil {
@@ -2473,6 +4307,63 @@ func _TrillianLog_GetLeavesByHash_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TrillianLog_GetLeavesByKeyRange_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetLeavesByKeyRangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetLeavesByKeyRange(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetLeavesByKeyRange",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetLeavesByKeyRange(ctx, req.(*GetLeavesByKeyRangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_GetEffectiveLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEffectiveLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogServer).GetEffectiveLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLog/GetEffectiveLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogServer).GetEffectiveLeaves(ctx, req.(*GetEffectiveLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLog_TailLeaves_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailLeavesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(TrillianLogServer).TailLeaves(m, &trillianLogTailLeavesServer{stream})
+}
+
+type TrillianLog_TailLeavesServer interface {
+	Send(*TailLeavesResponse) error
+	grpc.ServerStream
+}
+
+type trillianLogTailLeavesServer struct {
+	grpc.ServerStream
+}
+
+func (x *trillianLogTailLeavesServer) Send(m *TailLeavesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "trillian.TrillianLog",
 	HandlerType: (*TrillianLogServer)(nil),
@@ -2489,6 +4380,10 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetInclusionProof",
 			Handler:    _TrillianLog_GetInclusionProof_Handler,
 		},
+		{
+			MethodName: "GetHistoricalInclusionProof",
+			Handler:    _TrillianLog_GetHistoricalInclusionProof_Handler,
+		},
 		{
 			MethodName: "GetInclusionProofByHash",
 			Handler:    _TrillianLog_GetInclusionProofByHash_Handler,
@@ -2497,10 +4392,26 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetConsistencyProof",
 			Handler:    _TrillianLog_GetConsistencyProof_Handler,
 		},
+		{
+			MethodName: "GetProofAtRevision",
+			Handler:    _TrillianLog_GetProofAtRevision_Handler,
+		},
+		{
+			MethodName: "PredictRoot",
+			Handler:    _TrillianLog_PredictRoot_Handler,
+		},
+		{
+			MethodName: "VerifyInclusion",
+			Handler:    _TrillianLog_VerifyInclusion_Handler,
+		},
 		{
 			MethodName: "GetLatestSignedLogRoot",
 			Handler:    _TrillianLog_GetLatestSignedLogRoot_Handler,
 		},
+		{
+			MethodName: "GetSignedLogRootHistory",
+			Handler:    _TrillianLog_GetSignedLogRootHistory_Handler,
+		},
 		{
 			MethodName: "GetSequencedLeafCount",
 			Handler:    _TrillianLog_GetSequencedLeafCount_Handler,
@@ -2521,6 +4432,10 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "AddSequencedLeaves",
 			Handler:    _TrillianLog_AddSequencedLeaves_Handler,
 		},
+		{
+			MethodName: "BulkAddSequencedLeaves",
+			Handler:    _TrillianLog_BulkAddSequencedLeaves_Handler,
+		},
 		{
 			MethodName: "GetLeavesByIndex",
 			Handler:    _TrillianLog_GetLeavesByIndex_Handler,
@@ -2533,7 +4448,21 @@ var _TrillianLog_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetLeavesByHash",
 			Handler:    _TrillianLog_GetLeavesByHash_Handler,
 		},
+		{
+			MethodName: "GetLeavesByKeyRange",
+			Handler:    _TrillianLog_GetLeavesByKeyRange_Handler,
+		},
+		{
+			MethodName: "GetEffectiveLeaves",
+			Handler:    _TrillianLog_GetEffectiveLeaves_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TailLeaves",
+			Handler:       _TrillianLog_TailLeaves_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "trillian_log_api.proto",
 }
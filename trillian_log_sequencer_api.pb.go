@@ -7,7 +7,11 @@ import (
 	context "context"
 	fmt "fmt"
 	proto "github.com/golang/protobuf/proto"
+	duration "github.com/golang/protobuf/ptypes/duration"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
 	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	math "math"
 )
 
@@ -22,19 +26,950 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
+// ReintegratePendingRequest is the request for the ReintegratePending RPC.
+type ReintegratePendingRequest struct {
+	// The ID of the log.
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// The minimum time since leaves were queued for them to be integrated. It
+	// must be at least the sequencer guard window of the signer.
+	MinAge               *duration.Duration `protobuf:"bytes,2,opt,name=min_age,json=minAge,proto3" json:"min_age,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ReintegratePendingRequest) Reset()         { *m = ReintegratePendingRequest{} }
+func (m *ReintegratePendingRequest) String() string { return proto.CompactTextString(m) }
+func (*ReintegratePendingRequest) ProtoMessage()    {}
+func (*ReintegratePendingRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{0}
+}
+
+func (m *ReintegratePendingRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReintegratePendingRequest.Unmarshal(m, b)
+}
+func (m *ReintegratePendingRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReintegratePendingRequest.Marshal(b, m, deterministic)
+}
+func (m *ReintegratePendingRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReintegratePendingRequest.Merge(m, src)
+}
+func (m *ReintegratePendingRequest) XXX_Size() int {
+	return xxx_messageInfo_ReintegratePendingRequest.Size(m)
+}
+func (m *ReintegratePendingRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReintegratePendingRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReintegratePendingRequest proto.InternalMessageInfo
+
+func (m *ReintegratePendingRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *ReintegratePendingRequest) GetMinAge() *duration.Duration {
+	if m != nil {
+		return m.MinAge
+	}
+	return nil
+}
+
+// ReintegratePendingResponse is the response of the ReintegratePending RPC.
+type ReintegratePendingResponse struct {
+	// The number of leaves integrated.
+	LeavesIntegrated int64 `protobuf:"varint,1,opt,name=leaves_integrated,json=leavesIntegrated,proto3" json:"leaves_integrated,omitempty"`
+	// The latest root of the log, after all leaves are integrated.
+	SignedLogRoot        *SignedLogRoot `protobuf:"bytes,2,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
+}
+
+func (m *ReintegratePendingResponse) Reset()         { *m = ReintegratePendingResponse{} }
+func (m *ReintegratePendingResponse) String() string { return proto.CompactTextString(m) }
+func (*ReintegratePendingResponse) ProtoMessage()    {}
+func (*ReintegratePendingResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{1}
+}
+
+func (m *ReintegratePendingResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReintegratePendingResponse.Unmarshal(m, b)
+}
+func (m *ReintegratePendingResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReintegratePendingResponse.Marshal(b, m, deterministic)
+}
+func (m *ReintegratePendingResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReintegratePendingResponse.Merge(m, src)
+}
+func (m *ReintegratePendingResponse) XXX_Size() int {
+	return xxx_messageInfo_ReintegratePendingResponse.Size(m)
+}
+func (m *ReintegratePendingResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReintegratePendingResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReintegratePendingResponse proto.InternalMessageInfo
+
+func (m *ReintegratePendingResponse) GetLeavesIntegrated() int64 {
+	if m != nil {
+		return m.LeavesIntegrated
+	}
+	return 0
+}
+
+func (m *ReintegratePendingResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+// QuarantinedLeaf is a leaf which the sequencer failed to integrate.
+type QuarantinedLeaf struct {
+	// The leaf as it was queued. The leaf value and extra data are only set if
+	// they are still stored, and are encrypted for trees with leaf_encryption,
+	// since the signer serving them has no data keys.
+	Leaf *LogLeaf `protobuf:"bytes,1,opt,name=leaf,proto3" json:"leaf,omitempty"`
+	// The reason why the leaf couldn't be integrated.
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// The time at which the leaf was quarantined.
+	QuarantineTimestamp  *timestamp.Timestamp `protobuf:"bytes,3,opt,name=quarantine_timestamp,json=quarantineTimestamp,proto3" json:"quarantine_timestamp,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *QuarantinedLeaf) Reset()         { *m = QuarantinedLeaf{} }
+func (m *QuarantinedLeaf) String() string { return proto.CompactTextString(m) }
+func (*QuarantinedLeaf) ProtoMessage()    {}
+func (*QuarantinedLeaf) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{2}
+}
+
+func (m *QuarantinedLeaf) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QuarantinedLeaf.Unmarshal(m, b)
+}
+func (m *QuarantinedLeaf) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QuarantinedLeaf.Marshal(b, m, deterministic)
+}
+func (m *QuarantinedLeaf) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QuarantinedLeaf.Merge(m, src)
+}
+func (m *QuarantinedLeaf) XXX_Size() int {
+	return xxx_messageInfo_QuarantinedLeaf.Size(m)
+}
+func (m *QuarantinedLeaf) XXX_DiscardUnknown() {
+	xxx_messageInfo_QuarantinedLeaf.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QuarantinedLeaf proto.InternalMessageInfo
+
+func (m *QuarantinedLeaf) GetLeaf() *LogLeaf {
+	if m != nil {
+		return m.Leaf
+	}
+	return nil
+}
+
+func (m *QuarantinedLeaf) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+func (m *QuarantinedLeaf) GetQuarantineTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.QuarantineTimestamp
+	}
+	return nil
+}
+
+// ListQuarantinedLeavesRequest is the request for the ListQuarantinedLeaves
+// RPC.
+type ListQuarantinedLeavesRequest struct {
+	// The ID of the log.
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// The maximum number of leaves to return, oldest quarantined first. If zero,
+	// a server-chosen maximum is used.
+	MaxLeaves            int32    `protobuf:"varint,2,opt,name=max_leaves,json=maxLeaves,proto3" json:"max_leaves,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListQuarantinedLeavesRequest) Reset()         { *m = ListQuarantinedLeavesRequest{} }
+func (m *ListQuarantinedLeavesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListQuarantinedLeavesRequest) ProtoMessage()    {}
+func (*ListQuarantinedLeavesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{3}
+}
+
+func (m *ListQuarantinedLeavesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListQuarantinedLeavesRequest.Unmarshal(m, b)
+}
+func (m *ListQuarantinedLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListQuarantinedLeavesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListQuarantinedLeavesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListQuarantinedLeavesRequest.Merge(m, src)
+}
+func (m *ListQuarantinedLeavesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListQuarantinedLeavesRequest.Size(m)
+}
+func (m *ListQuarantinedLeavesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListQuarantinedLeavesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListQuarantinedLeavesRequest proto.InternalMessageInfo
+
+func (m *ListQuarantinedLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *ListQuarantinedLeavesRequest) GetMaxLeaves() int32 {
+	if m != nil {
+		return m.MaxLeaves
+	}
+	return 0
+}
+
+// ListQuarantinedLeavesResponse is the response of the ListQuarantinedLeaves
+// RPC.
+type ListQuarantinedLeavesResponse struct {
+	// The quarantined leaves, in the order they were quarantined.
+	Leaves               []*QuarantinedLeaf `protobuf:"bytes,1,rep,name=leaves,proto3" json:"leaves,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ListQuarantinedLeavesResponse) Reset()         { *m = ListQuarantinedLeavesResponse{} }
+func (m *ListQuarantinedLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListQuarantinedLeavesResponse) ProtoMessage()    {}
+func (*ListQuarantinedLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{4}
+}
+
+func (m *ListQuarantinedLeavesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListQuarantinedLeavesResponse.Unmarshal(m, b)
+}
+func (m *ListQuarantinedLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListQuarantinedLeavesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListQuarantinedLeavesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListQuarantinedLeavesResponse.Merge(m, src)
+}
+func (m *ListQuarantinedLeavesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListQuarantinedLeavesResponse.Size(m)
+}
+func (m *ListQuarantinedLeavesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListQuarantinedLeavesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListQuarantinedLeavesResponse proto.InternalMessageInfo
+
+func (m *ListQuarantinedLeavesResponse) GetLeaves() []*QuarantinedLeaf {
+	if m != nil {
+		return m.Leaves
+	}
+	return nil
+}
+
+// RequeueQuarantinedLeavesRequest is the request for the
+// RequeueQuarantinedLeaves RPC.
+type RequeueQuarantinedLeavesRequest struct {
+	// The ID of the log.
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// The identity hashes of the quarantined leaves to requeue. Hashes of leaves
+	// which aren't quarantined are ignored.
+	LeafIdentityHashes   [][]byte `protobuf:"bytes,2,rep,name=leaf_identity_hashes,json=leafIdentityHashes,proto3" json:"leaf_identity_hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequeueQuarantinedLeavesRequest) Reset()         { *m = RequeueQuarantinedLeavesRequest{} }
+func (m *RequeueQuarantinedLeavesRequest) String() string { return proto.CompactTextString(m) }
+func (*RequeueQuarantinedLeavesRequest) ProtoMessage()    {}
+func (*RequeueQuarantinedLeavesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{5}
+}
+
+func (m *RequeueQuarantinedLeavesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequeueQuarantinedLeavesRequest.Unmarshal(m, b)
+}
+func (m *RequeueQuarantinedLeavesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequeueQuarantinedLeavesRequest.Marshal(b, m, deterministic)
+}
+func (m *RequeueQuarantinedLeavesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequeueQuarantinedLeavesRequest.Merge(m, src)
+}
+func (m *RequeueQuarantinedLeavesRequest) XXX_Size() int {
+	return xxx_messageInfo_RequeueQuarantinedLeavesRequest.Size(m)
+}
+func (m *RequeueQuarantinedLeavesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequeueQuarantinedLeavesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequeueQuarantinedLeavesRequest proto.InternalMessageInfo
+
+func (m *RequeueQuarantinedLeavesRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *RequeueQuarantinedLeavesRequest) GetLeafIdentityHashes() [][]byte {
+	if m != nil {
+		return m.LeafIdentityHashes
+	}
+	return nil
+}
+
+// RequeueQuarantinedLeavesResponse is the response of the
+// RequeueQuarantinedLeaves RPC.
+type RequeueQuarantinedLeavesResponse struct {
+	// The number of leaves moved back to the queue.
+	LeavesRequeued       int64    `protobuf:"varint,1,opt,name=leaves_requeued,json=leavesRequeued,proto3" json:"leaves_requeued,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RequeueQuarantinedLeavesResponse) Reset()         { *m = RequeueQuarantinedLeavesResponse{} }
+func (m *RequeueQuarantinedLeavesResponse) String() string { return proto.CompactTextString(m) }
+func (*RequeueQuarantinedLeavesResponse) ProtoMessage()    {}
+func (*RequeueQuarantinedLeavesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{6}
+}
+
+func (m *RequeueQuarantinedLeavesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RequeueQuarantinedLeavesResponse.Unmarshal(m, b)
+}
+func (m *RequeueQuarantinedLeavesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RequeueQuarantinedLeavesResponse.Marshal(b, m, deterministic)
+}
+func (m *RequeueQuarantinedLeavesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RequeueQuarantinedLeavesResponse.Merge(m, src)
+}
+func (m *RequeueQuarantinedLeavesResponse) XXX_Size() int {
+	return xxx_messageInfo_RequeueQuarantinedLeavesResponse.Size(m)
+}
+func (m *RequeueQuarantinedLeavesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RequeueQuarantinedLeavesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RequeueQuarantinedLeavesResponse proto.InternalMessageInfo
+
+func (m *RequeueQuarantinedLeavesResponse) GetLeavesRequeued() int64 {
+	if m != nil {
+		return m.LeavesRequeued
+	}
+	return 0
+}
+
+// GetSequencingStatusRequest is the request for the GetSequencingStatus RPC.
+type GetSequencingStatusRequest struct {
+	// The ID of the log.
+	LogId                int64    `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSequencingStatusRequest) Reset()         { *m = GetSequencingStatusRequest{} }
+func (m *GetSequencingStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSequencingStatusRequest) ProtoMessage()    {}
+func (*GetSequencingStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{7}
+}
+
+func (m *GetSequencingStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSequencingStatusRequest.Unmarshal(m, b)
+}
+func (m *GetSequencingStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSequencingStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *GetSequencingStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSequencingStatusRequest.Merge(m, src)
+}
+func (m *GetSequencingStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_GetSequencingStatusRequest.Size(m)
+}
+func (m *GetSequencingStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSequencingStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSequencingStatusRequest proto.InternalMessageInfo
+
+func (m *GetSequencingStatusRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+// GetSequencingStatusResponse is the response of the GetSequencingStatus RPC.
+type GetSequencingStatusResponse struct {
+	// Whether the signer held mastership for the log at its latest sequencing
+	// pass.
+	Master bool `protobuf:"varint,1,opt,name=master,proto3" json:"master,omitempty"`
+	// When the signer last completed a run integrating a batch of the log
+	// successfully, unset if it hasn't since it started.
+	LastIntegrationTimestamp *timestamp.Timestamp `protobuf:"bytes,2,opt,name=last_integration_timestamp,json=lastIntegrationTimestamp,proto3" json:"last_integration_timestamp,omitempty"`
+	// The error of the latest run integrating a batch of the log, if it failed.
+	LastError            string   `protobuf:"bytes,3,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSequencingStatusResponse) Reset()         { *m = GetSequencingStatusResponse{} }
+func (m *GetSequencingStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*GetSequencingStatusResponse) ProtoMessage()    {}
+func (*GetSequencingStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{8}
+}
+
+func (m *GetSequencingStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSequencingStatusResponse.Unmarshal(m, b)
+}
+func (m *GetSequencingStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSequencingStatusResponse.Marshal(b, m, deterministic)
+}
+func (m *GetSequencingStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSequencingStatusResponse.Merge(m, src)
+}
+func (m *GetSequencingStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_GetSequencingStatusResponse.Size(m)
+}
+func (m *GetSequencingStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSequencingStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSequencingStatusResponse proto.InternalMessageInfo
+
+func (m *GetSequencingStatusResponse) GetMaster() bool {
+	if m != nil {
+		return m.Master
+	}
+	return false
+}
+
+func (m *GetSequencingStatusResponse) GetLastIntegrationTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.LastIntegrationTimestamp
+	}
+	return nil
+}
+
+func (m *GetSequencingStatusResponse) GetLastError() string {
+	if m != nil {
+		return m.LastError
+	}
+	return ""
+}
+
+// GetSequencerStatusRequest is the request for the GetSequencerStatus RPC.
+type GetSequencerStatusRequest struct {
+	// The ID of the log.
+	LogId                int64    `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSequencerStatusRequest) Reset()         { *m = GetSequencerStatusRequest{} }
+func (m *GetSequencerStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSequencerStatusRequest) ProtoMessage()    {}
+func (*GetSequencerStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{9}
+}
+
+func (m *GetSequencerStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSequencerStatusRequest.Unmarshal(m, b)
+}
+func (m *GetSequencerStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSequencerStatusRequest.Marshal(b, m, deterministic)
+}
+func (m *GetSequencerStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSequencerStatusRequest.Merge(m, src)
+}
+func (m *GetSequencerStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_GetSequencerStatusRequest.Size(m)
+}
+func (m *GetSequencerStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSequencerStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSequencerStatusRequest proto.InternalMessageInfo
+
+func (m *GetSequencerStatusRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+// SequencingRun is the outcome of a run of the signer integrating a batch of
+// a log.
+type SequencingRun struct {
+	// When the run started.
+	StartTimestamp *timestamp.Timestamp `protobuf:"bytes,1,opt,name=start_timestamp,json=startTimestamp,proto3" json:"start_timestamp,omitempty"`
+	// How long the run took.
+	Duration *duration.Duration `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	// The number of leaves integrated by the run.
+	LeavesIntegrated int64 `protobuf:"varint,3,opt,name=leaves_integrated,json=leavesIntegrated,proto3" json:"leaves_integrated,omitempty"`
+	// The error of the run, if it failed.
+	Error                string   `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SequencingRun) Reset()         { *m = SequencingRun{} }
+func (m *SequencingRun) String() string { return proto.CompactTextString(m) }
+func (*SequencingRun) ProtoMessage()    {}
+func (*SequencingRun) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{10}
+}
+
+func (m *SequencingRun) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SequencingRun.Unmarshal(m, b)
+}
+func (m *SequencingRun) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SequencingRun.Marshal(b, m, deterministic)
+}
+func (m *SequencingRun) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SequencingRun.Merge(m, src)
+}
+func (m *SequencingRun) XXX_Size() int {
+	return xxx_messageInfo_SequencingRun.Size(m)
+}
+func (m *SequencingRun) XXX_DiscardUnknown() {
+	xxx_messageInfo_SequencingRun.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SequencingRun proto.InternalMessageInfo
+
+func (m *SequencingRun) GetStartTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartTimestamp
+	}
+	return nil
+}
+
+func (m *SequencingRun) GetDuration() *duration.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return nil
+}
+
+func (m *SequencingRun) GetLeavesIntegrated() int64 {
+	if m != nil {
+		return m.LeavesIntegrated
+	}
+	return 0
+}
+
+func (m *SequencingRun) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// GetSequencerStatusResponse is the response of the GetSequencerStatus RPC.
+type GetSequencerStatusResponse struct {
+	// The ID of the signer instance which responded, as used in mastership
+	// elections. Elections don't tell signers which instance holds mastership,
+	// only whether they hold it themselves, so the holder is found by asking
+	// each of them.
+	InstanceId string `protobuf:"bytes,1,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
+	// Whether the signer held mastership for the log at its latest sequencing
+	// pass.
+	Master bool `protobuf:"varint,2,opt,name=master,proto3" json:"master,omitempty"`
+	// The size of the latest root of the log which the signer signed or read,
+	// as of its latest successful run, or zero if there was none.
+	TreeSize int64 `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	// The timestamp of that root, unset if there was none.
+	RootTimestamp *timestamp.Timestamp `protobuf:"bytes,4,opt,name=root_timestamp,json=rootTimestamp,proto3" json:"root_timestamp,omitempty"`
+	// Whether the latest successful run integrated a full batch, in which case
+	// more leaves are likely to be pending.
+	BatchFull bool `protobuf:"varint,5,opt,name=batch_full,json=batchFull,proto3" json:"batch_full,omitempty"`
+	// The maximum number of leaves integrated by the next run, which adapts to
+	// the load of the log if the signer has a --max_batch_size.
+	BatchSize int32 `protobuf:"varint,6,opt,name=batch_size,json=batchSize,proto3" json:"batch_size,omitempty"`
+	// The latest runs of the signer for the log, newest first. The first one is
+	// the latest run, and its error, if any, is why the log may be stuck.
+	Runs []*SequencingRun `protobuf:"bytes,7,rep,name=runs,proto3" json:"runs,omitempty"`
+	// The number of leaves queued in the log and not yet integrated, counted in
+	// storage when the request is served, up to 10000, or -1 if the storage
+	// can't count them.
+	PendingLeaves        int64    `protobuf:"varint,8,opt,name=pending_leaves,json=pendingLeaves,proto3" json:"pending_leaves,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSequencerStatusResponse) Reset()         { *m = GetSequencerStatusResponse{} }
+func (m *GetSequencerStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*GetSequencerStatusResponse) ProtoMessage()    {}
+func (*GetSequencerStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{11}
+}
+
+func (m *GetSequencerStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSequencerStatusResponse.Unmarshal(m, b)
+}
+func (m *GetSequencerStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSequencerStatusResponse.Marshal(b, m, deterministic)
+}
+func (m *GetSequencerStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSequencerStatusResponse.Merge(m, src)
+}
+func (m *GetSequencerStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_GetSequencerStatusResponse.Size(m)
+}
+func (m *GetSequencerStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSequencerStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSequencerStatusResponse proto.InternalMessageInfo
+
+func (m *GetSequencerStatusResponse) GetInstanceId() string {
+	if m != nil {
+		return m.InstanceId
+	}
+	return ""
+}
+
+func (m *GetSequencerStatusResponse) GetMaster() bool {
+	if m != nil {
+		return m.Master
+	}
+	return false
+}
+
+func (m *GetSequencerStatusResponse) GetTreeSize() int64 {
+	if m != nil {
+		return m.TreeSize
+	}
+	return 0
+}
+
+func (m *GetSequencerStatusResponse) GetRootTimestamp() *timestamp.Timestamp {
+	if m != nil {
+		return m.RootTimestamp
+	}
+	return nil
+}
+
+func (m *GetSequencerStatusResponse) GetBatchFull() bool {
+	if m != nil {
+		return m.BatchFull
+	}
+	return false
+}
+
+func (m *GetSequencerStatusResponse) GetBatchSize() int32 {
+	if m != nil {
+		return m.BatchSize
+	}
+	return 0
+}
+
+func (m *GetSequencerStatusResponse) GetRuns() []*SequencingRun {
+	if m != nil {
+		return m.Runs
+	}
+	return nil
+}
+
+func (m *GetSequencerStatusResponse) GetPendingLeaves() int64 {
+	if m != nil {
+		return m.PendingLeaves
+	}
+	return 0
+}
+
+// ResignMastershipRequest is the request for the ResignMastership RPC.
+type ResignMastershipRequest struct {
+	// The ID of the log, or zero for all logs the signer is master for.
+	LogId int64 `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	// Why mastership is resigned, which is logged by the signer. It must be set.
+	Reason               string   `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResignMastershipRequest) Reset()         { *m = ResignMastershipRequest{} }
+func (m *ResignMastershipRequest) String() string { return proto.CompactTextString(m) }
+func (*ResignMastershipRequest) ProtoMessage()    {}
+func (*ResignMastershipRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{12}
+}
+
+func (m *ResignMastershipRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResignMastershipRequest.Unmarshal(m, b)
+}
+func (m *ResignMastershipRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResignMastershipRequest.Marshal(b, m, deterministic)
+}
+func (m *ResignMastershipRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResignMastershipRequest.Merge(m, src)
+}
+func (m *ResignMastershipRequest) XXX_Size() int {
+	return xxx_messageInfo_ResignMastershipRequest.Size(m)
+}
+func (m *ResignMastershipRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResignMastershipRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResignMastershipRequest proto.InternalMessageInfo
+
+func (m *ResignMastershipRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+func (m *ResignMastershipRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+// ResignMastershipResponse is the response of the ResignMastership RPC.
+type ResignMastershipResponse struct {
+	// The IDs of the logs for which the signer is resigning mastership.
+	LogIds               []int64  `protobuf:"varint,1,rep,packed,name=log_ids,json=logIds,proto3" json:"log_ids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ResignMastershipResponse) Reset()         { *m = ResignMastershipResponse{} }
+func (m *ResignMastershipResponse) String() string { return proto.CompactTextString(m) }
+func (*ResignMastershipResponse) ProtoMessage()    {}
+func (*ResignMastershipResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{13}
+}
+
+func (m *ResignMastershipResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ResignMastershipResponse.Unmarshal(m, b)
+}
+func (m *ResignMastershipResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ResignMastershipResponse.Marshal(b, m, deterministic)
+}
+func (m *ResignMastershipResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ResignMastershipResponse.Merge(m, src)
+}
+func (m *ResignMastershipResponse) XXX_Size() int {
+	return xxx_messageInfo_ResignMastershipResponse.Size(m)
+}
+func (m *ResignMastershipResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ResignMastershipResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ResignMastershipResponse proto.InternalMessageInfo
+
+func (m *ResignMastershipResponse) GetLogIds() []int64 {
+	if m != nil {
+		return m.LogIds
+	}
+	return nil
+}
+
+// SignLogRootRequest is the request for the SignLogRoot RPC.
+type SignLogRootRequest struct {
+	// The ID of the log.
+	LogId                int64    `protobuf:"varint,1,opt,name=log_id,json=logId,proto3" json:"log_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SignLogRootRequest) Reset()         { *m = SignLogRootRequest{} }
+func (m *SignLogRootRequest) String() string { return proto.CompactTextString(m) }
+func (*SignLogRootRequest) ProtoMessage()    {}
+func (*SignLogRootRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{14}
+}
+
+func (m *SignLogRootRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignLogRootRequest.Unmarshal(m, b)
+}
+func (m *SignLogRootRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignLogRootRequest.Marshal(b, m, deterministic)
+}
+func (m *SignLogRootRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignLogRootRequest.Merge(m, src)
+}
+func (m *SignLogRootRequest) XXX_Size() int {
+	return xxx_messageInfo_SignLogRootRequest.Size(m)
+}
+func (m *SignLogRootRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignLogRootRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SignLogRootRequest proto.InternalMessageInfo
+
+func (m *SignLogRootRequest) GetLogId() int64 {
+	if m != nil {
+		return m.LogId
+	}
+	return 0
+}
+
+// SignLogRootResponse is the response of the SignLogRoot RPC.
+type SignLogRootResponse struct {
+	// The latest signed root of the log, which covers all its integrated
+	// leaves.
+	SignedLogRoot *SignedLogRoot `protobuf:"bytes,1,opt,name=signed_log_root,json=signedLogRoot,proto3" json:"signed_log_root,omitempty"`
+	// Whether the root was signed by this request, rather than already being
+	// the latest signed root.
+	Signed               bool     `protobuf:"varint,2,opt,name=signed,proto3" json:"signed,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SignLogRootResponse) Reset()         { *m = SignLogRootResponse{} }
+func (m *SignLogRootResponse) String() string { return proto.CompactTextString(m) }
+func (*SignLogRootResponse) ProtoMessage()    {}
+func (*SignLogRootResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f32c68ea33658ef4, []int{15}
+}
+
+func (m *SignLogRootResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SignLogRootResponse.Unmarshal(m, b)
+}
+func (m *SignLogRootResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SignLogRootResponse.Marshal(b, m, deterministic)
+}
+func (m *SignLogRootResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SignLogRootResponse.Merge(m, src)
+}
+func (m *SignLogRootResponse) XXX_Size() int {
+	return xxx_messageInfo_SignLogRootResponse.Size(m)
+}
+func (m *SignLogRootResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SignLogRootResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SignLogRootResponse proto.InternalMessageInfo
+
+func (m *SignLogRootResponse) GetSignedLogRoot() *SignedLogRoot {
+	if m != nil {
+		return m.SignedLogRoot
+	}
+	return nil
+}
+
+func (m *SignLogRootResponse) GetSigned() bool {
+	if m != nil {
+		return m.Signed
+	}
+	return false
+}
+
+func init() {
+	proto.RegisterType((*ReintegratePendingRequest)(nil), "trillian.ReintegratePendingRequest")
+	proto.RegisterType((*ReintegratePendingResponse)(nil), "trillian.ReintegratePendingResponse")
+	proto.RegisterType((*QuarantinedLeaf)(nil), "trillian.QuarantinedLeaf")
+	proto.RegisterType((*ListQuarantinedLeavesRequest)(nil), "trillian.ListQuarantinedLeavesRequest")
+	proto.RegisterType((*ListQuarantinedLeavesResponse)(nil), "trillian.ListQuarantinedLeavesResponse")
+	proto.RegisterType((*RequeueQuarantinedLeavesRequest)(nil), "trillian.RequeueQuarantinedLeavesRequest")
+	proto.RegisterType((*RequeueQuarantinedLeavesResponse)(nil), "trillian.RequeueQuarantinedLeavesResponse")
+	proto.RegisterType((*GetSequencingStatusRequest)(nil), "trillian.GetSequencingStatusRequest")
+	proto.RegisterType((*GetSequencingStatusResponse)(nil), "trillian.GetSequencingStatusResponse")
+	proto.RegisterType((*GetSequencerStatusRequest)(nil), "trillian.GetSequencerStatusRequest")
+	proto.RegisterType((*SequencingRun)(nil), "trillian.SequencingRun")
+	proto.RegisterType((*GetSequencerStatusResponse)(nil), "trillian.GetSequencerStatusResponse")
+	proto.RegisterType((*ResignMastershipRequest)(nil), "trillian.ResignMastershipRequest")
+	proto.RegisterType((*ResignMastershipResponse)(nil), "trillian.ResignMastershipResponse")
+	proto.RegisterType((*SignLogRootRequest)(nil), "trillian.SignLogRootRequest")
+	proto.RegisterType((*SignLogRootResponse)(nil), "trillian.SignLogRootResponse")
+}
+
 func init() { proto.RegisterFile("trillian_log_sequencer_api.proto", fileDescriptor_f32c68ea33658ef4) }
 
 var fileDescriptor_f32c68ea33658ef4 = []byte{
-	// 129 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x52, 0x28, 0x29, 0xca, 0xcc,
-	0xc9, 0xc9, 0x4c, 0xcc, 0x8b, 0xcf, 0xc9, 0x4f, 0x8f, 0x2f, 0x4e, 0x2d, 0x2c, 0x4d, 0xcd, 0x4b,
-	0x4e, 0x2d, 0x8a, 0x4f, 0x2c, 0xc8, 0xd4, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0x80, 0xa9,
-	0x30, 0x12, 0xe3, 0x12, 0x09, 0x81, 0xb2, 0x7d, 0xf2, 0xd3, 0x83, 0x61, 0x6a, 0x9d, 0xc2, 0xb9,
-	0x24, 0x93, 0xf3, 0x73, 0xf5, 0xd2, 0xf3, 0xf3, 0xd3, 0x73, 0x52, 0xf5, 0x60, 0xca, 0x21, 0xda,
-	0x9d, 0x64, 0xb0, 0x69, 0x71, 0x2c, 0xc8, 0x0c, 0x00, 0xc9, 0x06, 0x30, 0x46, 0x49, 0xa5, 0x67,
-	0x96, 0x64, 0x94, 0x26, 0xe9, 0x25, 0xe7, 0xe7, 0xea, 0x43, 0x4c, 0xd0, 0x87, 0x99, 0x90, 0xc4,
-	0x06, 0x36, 0xc2, 0x18, 0x10, 0x00, 0x00, 0xff, 0xff, 0x86, 0xa5, 0x47, 0x41, 0xa5, 0x00, 0x00,
-	0x00,
+	// 967 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xef, 0x6e, 0xdb, 0x36,
+	0x10, 0x9f, 0xe2, 0xd8, 0xb1, 0x2f, 0x8b, 0xd3, 0x32, 0x69, 0xe2, 0xa8, 0xc9, 0xe2, 0x69, 0xcd,
+	0x9a, 0x2d, 0x80, 0xb3, 0x39, 0xd8, 0xe7, 0x21, 0xdd, 0xbf, 0x7a, 0x4b, 0x81, 0x8c, 0x09, 0xb0,
+	0x61, 0xfb, 0x20, 0x30, 0x16, 0x2d, 0xb3, 0x90, 0x48, 0x57, 0xa4, 0x8a, 0xae, 0x8f, 0xb0, 0x77,
+	0xd8, 0x2b, 0xec, 0x35, 0x06, 0xec, 0xc3, 0x9e, 0x69, 0x10, 0x45, 0xfd, 0x71, 0x2c, 0x5b, 0xed,
+	0x47, 0xdd, 0xfd, 0xee, 0xee, 0xc7, 0xbb, 0xe3, 0x8f, 0x82, 0xbe, 0x8a, 0x58, 0x10, 0x30, 0xc2,
+	0xdd, 0x40, 0xf8, 0xae, 0xa4, 0xaf, 0x62, 0xca, 0xc7, 0x34, 0x72, 0xc9, 0x8c, 0x0d, 0x66, 0x91,
+	0x50, 0x02, 0xb5, 0x33, 0x84, 0xfd, 0x91, 0x2f, 0x84, 0x1f, 0xd0, 0x73, 0x6d, 0xbf, 0x8b, 0x27,
+	0xe7, 0x5e, 0x1c, 0x11, 0xc5, 0x04, 0x4f, 0x91, 0xf6, 0xf1, 0x7d, 0xbf, 0x62, 0x21, 0x95, 0x8a,
+	0x84, 0x33, 0x03, 0xe8, 0x66, 0xa9, 0xcc, 0xf7, 0xde, 0x5c, 0xf1, 0xbc, 0xa4, 0x33, 0x81, 0x03,
+	0x4c, 0x19, 0x57, 0xd4, 0x8f, 0x88, 0xa2, 0xd7, 0x94, 0x7b, 0x8c, 0xfb, 0x38, 0xe1, 0x26, 0x15,
+	0x7a, 0x04, 0xad, 0x04, 0xcd, 0xbc, 0x9e, 0xd5, 0xb7, 0x4e, 0x1b, 0xb8, 0x19, 0x08, 0x7f, 0xe4,
+	0xa1, 0x21, 0x6c, 0x84, 0x8c, 0xbb, 0xc4, 0xa7, 0xbd, 0xb5, 0xbe, 0x75, 0xba, 0x39, 0x3c, 0x18,
+	0xa4, 0x74, 0x06, 0x19, 0x9d, 0xc1, 0xb7, 0x86, 0x2e, 0x6e, 0x85, 0x8c, 0x5f, 0xfa, 0xd4, 0xf9,
+	0xd3, 0x02, 0xbb, 0xaa, 0x90, 0x9c, 0x09, 0x2e, 0x29, 0x3a, 0x83, 0x87, 0x01, 0x25, 0xaf, 0xa9,
+	0x74, 0x73, 0x48, 0x56, 0xf4, 0x41, 0xea, 0x18, 0xe5, 0x76, 0xf4, 0x35, 0x6c, 0x4b, 0xe6, 0x73,
+	0xea, 0xe9, 0xb3, 0x44, 0x42, 0x28, 0xc3, 0x63, 0x7f, 0x90, 0x9f, 0xfa, 0x46, 0x03, 0xae, 0x84,
+	0x8f, 0x85, 0x50, 0x78, 0x4b, 0x96, 0x3f, 0x9d, 0xbf, 0x2c, 0xd8, 0xfe, 0x39, 0x26, 0x11, 0xe1,
+	0x8a, 0x25, 0x66, 0x4a, 0x26, 0xe8, 0x04, 0xd6, 0x03, 0x4a, 0x26, 0xba, 0xe8, 0xe6, 0xf0, 0x61,
+	0x91, 0xe9, 0x4a, 0xf8, 0x09, 0x00, 0x6b, 0x37, 0xda, 0x85, 0x26, 0x8d, 0x22, 0x11, 0xe9, 0x8a,
+	0x1d, 0x9c, 0x7e, 0xa0, 0x17, 0xb0, 0xfb, 0x2a, 0xcf, 0xe7, 0xe6, 0xb3, 0xe8, 0x35, 0x74, 0x32,
+	0x7b, 0xa1, 0x3d, 0xb7, 0x19, 0x02, 0xef, 0x14, 0x71, 0xb9, 0xd1, 0xb9, 0x85, 0xc3, 0x2b, 0x26,
+	0xd5, 0x3c, 0xc5, 0xd7, 0x54, 0xd6, 0xcc, 0xe5, 0x08, 0x20, 0x24, 0x6f, 0xdc, 0xb4, 0x5f, 0x9a,
+	0x60, 0x13, 0x77, 0x42, 0xf2, 0x26, 0x0d, 0x76, 0x30, 0x1c, 0x2d, 0xc9, 0x6a, 0x86, 0xf0, 0x25,
+	0xb4, 0x4c, 0xac, 0xd5, 0x6f, 0xe8, 0xb1, 0xe6, 0x4d, 0xb8, 0xd7, 0x2d, 0x6c, 0x80, 0xce, 0x4b,
+	0x38, 0xd6, 0xa4, 0x62, 0xfa, 0xbe, 0x64, 0xbf, 0x80, 0xdd, 0xa4, 0xa1, 0x2e, 0xf3, 0x28, 0x57,
+	0x4c, 0xfd, 0xe1, 0x4e, 0x89, 0x9c, 0x6a, 0xda, 0x8d, 0xd3, 0x0f, 0x31, 0x4a, 0x7c, 0x23, 0xe3,
+	0x7a, 0xae, 0x3d, 0xce, 0x4f, 0xd0, 0x5f, 0x5e, 0xcb, 0x1c, 0xe1, 0x29, 0x6c, 0x9b, 0x3d, 0x8a,
+	0x52, 0x68, 0x56, 0xb5, 0x1b, 0x14, 0xa4, 0x62, 0xea, 0x39, 0x17, 0x60, 0xff, 0x40, 0xd5, 0x4d,
+	0x7a, 0x09, 0x19, 0xf7, 0x6f, 0x14, 0x51, 0x71, 0x0d, 0x67, 0xe7, 0x6f, 0x0b, 0x1e, 0x57, 0x46,
+	0x99, 0xea, 0x7b, 0xd0, 0x0a, 0x89, 0x54, 0x34, 0xd2, 0x61, 0x6d, 0x6c, 0xbe, 0xd0, 0xaf, 0x60,
+	0x07, 0x44, 0xaa, 0x7c, 0xb7, 0x99, 0xe0, 0xa5, 0x25, 0x59, 0xab, 0x5d, 0x92, 0x5e, 0x12, 0x3d,
+	0x2a, 0x82, 0x73, 0x4f, 0x32, 0x72, 0x9d, 0x39, 0xdd, 0xc9, 0x86, 0xde, 0xc9, 0x4e, 0x62, 0xf9,
+	0x2e, 0x31, 0x38, 0x43, 0x38, 0x28, 0xf8, 0xd2, 0xe8, 0x9d, 0x0e, 0xf9, 0x9f, 0x05, 0x5b, 0xc5,
+	0x09, 0x71, 0xcc, 0xd1, 0x37, 0xb0, 0x2d, 0x15, 0x89, 0x54, 0x89, 0xb3, 0x55, 0xcb, 0xb9, 0xab,
+	0x43, 0x0a, 0xa6, 0x5f, 0x41, 0x3b, 0xd3, 0xb0, 0x7a, 0xd5, 0xc8, 0xa1, 0xd5, 0xc2, 0xd0, 0x58,
+	0x22, 0x0c, 0xf9, 0xe5, 0x5c, 0x2f, 0x5d, 0x4e, 0xe7, 0x9f, 0x35, 0xb0, 0xab, 0xba, 0x60, 0x86,
+	0x76, 0x0c, 0x9b, 0x8c, 0x4b, 0x45, 0xf8, 0x98, 0x66, 0xbd, 0xe8, 0x60, 0xc8, 0x4c, 0x23, 0xaf,
+	0x34, 0xd5, 0xb5, 0xb9, 0xa9, 0x3e, 0x86, 0x8e, 0x8a, 0x28, 0x75, 0x25, 0x7b, 0x4b, 0x0d, 0xa5,
+	0x76, 0x62, 0xb8, 0x61, 0x6f, 0x29, 0xba, 0x84, 0x6e, 0x22, 0x4c, 0xa5, 0x96, 0xad, 0xd7, 0xb6,
+	0x6c, 0x2b, 0x89, 0x98, 0x9b, 0xed, 0x1d, 0x51, 0xe3, 0xa9, 0x3b, 0x89, 0x83, 0xa0, 0xd7, 0xd4,
+	0xb5, 0x3b, 0xda, 0xf2, 0x7d, 0x1c, 0x04, 0x85, 0x5b, 0xd7, 0x6f, 0xa5, 0xb7, 0x5d, 0x5b, 0x34,
+	0x81, 0x33, 0x58, 0x8f, 0x62, 0x2e, 0x7b, 0x1b, 0xfd, 0xc6, 0x3d, 0x65, 0x2c, 0xcf, 0x16, 0x6b,
+	0x10, 0x3a, 0x81, 0xee, 0x2c, 0x55, 0xe4, 0x4c, 0x3d, 0xda, 0xfa, 0x3c, 0x5b, 0xc6, 0x6a, 0x14,
+	0xe4, 0x39, 0xec, 0x63, 0x9a, 0x48, 0xe9, 0x0b, 0xdd, 0x01, 0x39, 0x65, 0xb3, 0x9a, 0x5b, 0xbe,
+	0x07, 0xad, 0x88, 0x12, 0x69, 0x66, 0xde, 0xc1, 0xe6, 0xcb, 0xb9, 0x80, 0xde, 0x62, 0x26, 0x33,
+	0x90, 0x7d, 0xd8, 0x48, 0x53, 0xa5, 0x3a, 0xd4, 0xc0, 0x2d, 0x9d, 0x4b, 0x3a, 0x67, 0x80, 0x12,
+	0x59, 0xcf, 0x44, 0x7d, 0xf5, 0x1a, 0x73, 0xd8, 0x99, 0x03, 0x9b, 0xe4, 0x15, 0x6f, 0x87, 0xf5,
+	0x3e, 0x6f, 0x47, 0x72, 0xa2, 0xd4, 0x90, 0x6d, 0x43, 0xfa, 0x35, 0xfc, 0xb7, 0x09, 0xbb, 0xb7,
+	0x26, 0xc3, 0x95, 0xf0, 0xf3, 0x6d, 0x43, 0x04, 0xd0, 0xe2, 0xc3, 0x87, 0x3e, 0x29, 0xca, 0x2d,
+	0x7d, 0x7f, 0xed, 0x27, 0xab, 0x41, 0xe9, 0x91, 0x9c, 0x0f, 0xd0, 0x4b, 0x78, 0x54, 0xa9, 0xec,
+	0xe8, 0xd3, 0xd2, 0x33, 0xb6, 0xe2, 0x41, 0xb1, 0x9f, 0xd6, 0xe2, 0xf2, 0x5a, 0x12, 0x7a, 0x46,
+	0x44, 0x17, 0xcb, 0x7d, 0x56, 0xe6, 0xbb, 0xf2, 0x55, 0xb0, 0x3f, 0x7f, 0x17, 0x68, 0x5e, 0xd4,
+	0x83, 0x9d, 0x0a, 0xdd, 0x45, 0xa5, 0xfe, 0x2c, 0x17, 0x73, 0xfb, 0xa4, 0x06, 0x95, 0x57, 0x71,
+	0x01, 0x2d, 0xea, 0x44, 0x79, 0x52, 0x4b, 0xb5, 0xd4, 0x7e, 0xb2, 0x1a, 0x64, 0x96, 0xef, 0x77,
+	0x78, 0x70, 0x7f, 0xeb, 0xd1, 0xc7, 0xe5, 0x46, 0x54, 0xde, 0x2d, 0xdb, 0x59, 0x05, 0xc9, 0xd9,
+	0xff, 0x08, 0x9b, 0xa5, 0x85, 0x47, 0x87, 0xf3, 0xfb, 0x3c, 0x7f, 0x69, 0xec, 0xa3, 0x25, 0xde,
+	0x34, 0xdb, 0xb3, 0x5f, 0xe0, 0x60, 0x2c, 0xc2, 0x4c, 0xaa, 0xe6, 0x7f, 0x25, 0x9f, 0x1d, 0x56,
+	0xad, 0xf9, 0xe5, 0x8c, 0x5d, 0x27, 0xde, 0x6b, 0xeb, 0x37, 0xdb, 0x67, 0x6a, 0x1a, 0xdf, 0x0d,
+	0xc6, 0x22, 0x3c, 0x37, 0xbf, 0xa9, 0x59, 0x86, 0xbb, 0x96, 0x4e, 0x71, 0xf1, 0xff, 0x00, 0x84,
+	0xf2, 0x6d, 0x12, 0x0c, 0x0b, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -49,6 +984,57 @@ const _ = grpc.SupportPackageIsVersion4
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type TrillianLogSequencerClient interface {
+	// ReintegratePending integrates all leaves of a log which have been queued
+	// for at least min_age, and checks every new root against one rebuilt from
+	// the leaf hashes in storage before it is stored or signed. It is a repair
+	// operation for leaves which were queued but never integrated, and is not
+	// needed for normal sequencing. It fails if leaves are queued but no new
+	// root can be signed yet, e.g. because of the timestamp_granularity of the
+	// log.
+	//
+	// Only the signer which is master for the log serves it, and only if
+	// enabled on that signer; other signers fail with FailedPrecondition. It
+	// may still conflict with sequencing of the same log, in which case it
+	// fails and should be retried.
+	ReintegratePending(ctx context.Context, in *ReintegratePendingRequest, opts ...grpc.CallOption) (*ReintegratePendingResponse, error)
+	// ListQuarantinedLeaves returns the quarantined leaves of a log. Leaves are
+	// quarantined when the sequencer finds that it can't integrate them, i.e.
+	// because their hashes have the wrong size or their queue timestamp is
+	// invalid, or because their conditional batch can't be integrated at its
+	// expected tree size, so that they don't block the rest of the queue. They
+	// are kept out of the log until requeued. Other failures, such as storage
+	// failing to read the queue or to write the batch, still fail the whole
+	// batch.
+	ListQuarantinedLeaves(ctx context.Context, in *ListQuarantinedLeavesRequest, opts ...grpc.CallOption) (*ListQuarantinedLeavesResponse, error)
+	// RequeueQuarantinedLeaves moves quarantined leaves of a log back to the
+	// queue, so that the sequencer tries to integrate them again. Leaves which
+	// still can't be integrated are quarantined again.
+	RequeueQuarantinedLeaves(ctx context.Context, in *RequeueQuarantinedLeavesRequest, opts ...grpc.CallOption) (*RequeueQuarantinedLeavesResponse, error)
+	// GetSequencingStatus reports whether the receiving signer is sequencing a
+	// log, i.e. holds mastership for it, and the outcome of its latest runs.
+	// It is a cheap diagnostic which doesn't trigger sequencing; combining the
+	// responses of all signers tells whether the log is being sequenced at all.
+	GetSequencingStatus(ctx context.Context, in *GetSequencingStatusRequest, opts ...grpc.CallOption) (*GetSequencingStatusResponse, error)
+	// GetSequencerStatus returns a snapshot of the receiving signer's view of
+	// the sequencing of a log, for debugging logs which are stuck: the latest
+	// root it saw, its batch size, the outcome of its latest runs, and the number
+	// of leaves pending in the queue. It doesn't trigger sequencing, and only
+	// reads storage to count the pending leaves. It exposes internal state, so it
+	// must be enabled on the signer.
+	GetSequencerStatus(ctx context.Context, in *GetSequencerStatusRequest, opts ...grpc.CallOption) (*GetSequencerStatusResponse, error)
+	// ResignMastership makes the receiving signer resign mastership for a log,
+	// or for all logs it is master for, so that they are re-elected. It is an
+	// operational lever for rebalancing logs across signers or recovering a log
+	// stuck on one, and must be enabled on the signer.
+	ResignMastership(ctx context.Context, in *ResignMastershipRequest, opts ...grpc.CallOption) (*ResignMastershipResponse, error)
+	// SignLogRoot signs a root of a log covering all its integrated leaves,
+	// if its latest signed root doesn't, regardless of the signing_interval of
+	// the log. It publishes the leaves integrated since the latest signed root
+	// on demand, e.g. before a witness is due to check the log.
+	//
+	// Only the signer which is master for the log serves it, and only if
+	// enabled on that signer; other signers fail with FailedPrecondition.
+	SignLogRoot(ctx context.Context, in *SignLogRootRequest, opts ...grpc.CallOption) (*SignLogRootResponse, error)
 }
 
 type trillianLogSequencerClient struct {
@@ -59,22 +1045,313 @@ func NewTrillianLogSequencerClient(cc *grpc.ClientConn) TrillianLogSequencerClie
 	return &trillianLogSequencerClient{cc}
 }
 
+func (c *trillianLogSequencerClient) ReintegratePending(ctx context.Context, in *ReintegratePendingRequest, opts ...grpc.CallOption) (*ReintegratePendingResponse, error) {
+	out := new(ReintegratePendingResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLogSequencer/ReintegratePending", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogSequencerClient) ListQuarantinedLeaves(ctx context.Context, in *ListQuarantinedLeavesRequest, opts ...grpc.CallOption) (*ListQuarantinedLeavesResponse, error) {
+	out := new(ListQuarantinedLeavesResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLogSequencer/ListQuarantinedLeaves", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogSequencerClient) RequeueQuarantinedLeaves(ctx context.Context, in *RequeueQuarantinedLeavesRequest, opts ...grpc.CallOption) (*RequeueQuarantinedLeavesResponse, error) {
+	out := new(RequeueQuarantinedLeavesResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLogSequencer/RequeueQuarantinedLeaves", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogSequencerClient) GetSequencingStatus(ctx context.Context, in *GetSequencingStatusRequest, opts ...grpc.CallOption) (*GetSequencingStatusResponse, error) {
+	out := new(GetSequencingStatusResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLogSequencer/GetSequencingStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogSequencerClient) GetSequencerStatus(ctx context.Context, in *GetSequencerStatusRequest, opts ...grpc.CallOption) (*GetSequencerStatusResponse, error) {
+	out := new(GetSequencerStatusResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLogSequencer/GetSequencerStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogSequencerClient) ResignMastership(ctx context.Context, in *ResignMastershipRequest, opts ...grpc.CallOption) (*ResignMastershipResponse, error) {
+	out := new(ResignMastershipResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLogSequencer/ResignMastership", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *trillianLogSequencerClient) SignLogRoot(ctx context.Context, in *SignLogRootRequest, opts ...grpc.CallOption) (*SignLogRootResponse, error) {
+	out := new(SignLogRootResponse)
+	err := c.cc.Invoke(ctx, "/trillian.TrillianLogSequencer/SignLogRoot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TrillianLogSequencerServer is the server API for TrillianLogSequencer service.
 type TrillianLogSequencerServer interface {
+	// ReintegratePending integrates all leaves of a log which have been queued
+	// for at least min_age, and checks every new root against one rebuilt from
+	// the leaf hashes in storage before it is stored or signed. It is a repair
+	// operation for leaves which were queued but never integrated, and is not
+	// needed for normal sequencing. It fails if leaves are queued but no new
+	// root can be signed yet, e.g. because of the timestamp_granularity of the
+	// log.
+	//
+	// Only the signer which is master for the log serves it, and only if
+	// enabled on that signer; other signers fail with FailedPrecondition. It
+	// may still conflict with sequencing of the same log, in which case it
+	// fails and should be retried.
+	ReintegratePending(context.Context, *ReintegratePendingRequest) (*ReintegratePendingResponse, error)
+	// ListQuarantinedLeaves returns the quarantined leaves of a log. Leaves are
+	// quarantined when the sequencer finds that it can't integrate them, i.e.
+	// because their hashes have the wrong size or their queue timestamp is
+	// invalid, or because their conditional batch can't be integrated at its
+	// expected tree size, so that they don't block the rest of the queue. They
+	// are kept out of the log until requeued. Other failures, such as storage
+	// failing to read the queue or to write the batch, still fail the whole
+	// batch.
+	ListQuarantinedLeaves(context.Context, *ListQuarantinedLeavesRequest) (*ListQuarantinedLeavesResponse, error)
+	// RequeueQuarantinedLeaves moves quarantined leaves of a log back to the
+	// queue, so that the sequencer tries to integrate them again. Leaves which
+	// still can't be integrated are quarantined again.
+	RequeueQuarantinedLeaves(context.Context, *RequeueQuarantinedLeavesRequest) (*RequeueQuarantinedLeavesResponse, error)
+	// GetSequencingStatus reports whether the receiving signer is sequencing a
+	// log, i.e. holds mastership for it, and the outcome of its latest runs.
+	// It is a cheap diagnostic which doesn't trigger sequencing; combining the
+	// responses of all signers tells whether the log is being sequenced at all.
+	GetSequencingStatus(context.Context, *GetSequencingStatusRequest) (*GetSequencingStatusResponse, error)
+	// GetSequencerStatus returns a snapshot of the receiving signer's view of
+	// the sequencing of a log, for debugging logs which are stuck: the latest
+	// root it saw, its batch size, the outcome of its latest runs, and the number
+	// of leaves pending in the queue. It doesn't trigger sequencing, and only
+	// reads storage to count the pending leaves. It exposes internal state, so it
+	// must be enabled on the signer.
+	GetSequencerStatus(context.Context, *GetSequencerStatusRequest) (*GetSequencerStatusResponse, error)
+	// ResignMastership makes the receiving signer resign mastership for a log,
+	// or for all logs it is master for, so that they are re-elected. It is an
+	// operational lever for rebalancing logs across signers or recovering a log
+	// stuck on one, and must be enabled on the signer.
+	ResignMastership(context.Context, *ResignMastershipRequest) (*ResignMastershipResponse, error)
+	// SignLogRoot signs a root of a log covering all its integrated leaves,
+	// if its latest signed root doesn't, regardless of the signing_interval of
+	// the log. It publishes the leaves integrated since the latest signed root
+	// on demand, e.g. before a witness is due to check the log.
+	//
+	// Only the signer which is master for the log serves it, and only if
+	// enabled on that signer; other signers fail with FailedPrecondition.
+	SignLogRoot(context.Context, *SignLogRootRequest) (*SignLogRootResponse, error)
 }
 
 // UnimplementedTrillianLogSequencerServer can be embedded to have forward compatible implementations.
 type UnimplementedTrillianLogSequencerServer struct {
 }
 
+func (*UnimplementedTrillianLogSequencerServer) ReintegratePending(ctx context.Context, req *ReintegratePendingRequest) (*ReintegratePendingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReintegratePending not implemented")
+}
+func (*UnimplementedTrillianLogSequencerServer) ListQuarantinedLeaves(ctx context.Context, req *ListQuarantinedLeavesRequest) (*ListQuarantinedLeavesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListQuarantinedLeaves not implemented")
+}
+func (*UnimplementedTrillianLogSequencerServer) RequeueQuarantinedLeaves(ctx context.Context, req *RequeueQuarantinedLeavesRequest) (*RequeueQuarantinedLeavesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequeueQuarantinedLeaves not implemented")
+}
+func (*UnimplementedTrillianLogSequencerServer) GetSequencingStatus(ctx context.Context, req *GetSequencingStatusRequest) (*GetSequencingStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSequencingStatus not implemented")
+}
+func (*UnimplementedTrillianLogSequencerServer) GetSequencerStatus(ctx context.Context, req *GetSequencerStatusRequest) (*GetSequencerStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSequencerStatus not implemented")
+}
+func (*UnimplementedTrillianLogSequencerServer) ResignMastership(ctx context.Context, req *ResignMastershipRequest) (*ResignMastershipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResignMastership not implemented")
+}
+func (*UnimplementedTrillianLogSequencerServer) SignLogRoot(ctx context.Context, req *SignLogRootRequest) (*SignLogRootResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SignLogRoot not implemented")
+}
+
 func RegisterTrillianLogSequencerServer(s *grpc.Server, srv TrillianLogSequencerServer) {
 	s.RegisterService(&_TrillianLogSequencer_serviceDesc, srv)
 }
 
+func _TrillianLogSequencer_ReintegratePending_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReintegratePendingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogSequencerServer).ReintegratePending(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLogSequencer/ReintegratePending",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogSequencerServer).ReintegratePending(ctx, req.(*ReintegratePendingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLogSequencer_ListQuarantinedLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListQuarantinedLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogSequencerServer).ListQuarantinedLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLogSequencer/ListQuarantinedLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogSequencerServer).ListQuarantinedLeaves(ctx, req.(*ListQuarantinedLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLogSequencer_RequeueQuarantinedLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequeueQuarantinedLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogSequencerServer).RequeueQuarantinedLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLogSequencer/RequeueQuarantinedLeaves",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogSequencerServer).RequeueQuarantinedLeaves(ctx, req.(*RequeueQuarantinedLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLogSequencer_GetSequencingStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSequencingStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogSequencerServer).GetSequencingStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLogSequencer/GetSequencingStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogSequencerServer).GetSequencingStatus(ctx, req.(*GetSequencingStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLogSequencer_GetSequencerStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSequencerStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogSequencerServer).GetSequencerStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLogSequencer/GetSequencerStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogSequencerServer).GetSequencerStatus(ctx, req.(*GetSequencerStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLogSequencer_ResignMastership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResignMastershipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogSequencerServer).ResignMastership(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLogSequencer/ResignMastership",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogSequencerServer).ResignMastership(ctx, req.(*ResignMastershipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TrillianLogSequencer_SignLogRoot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SignLogRootRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TrillianLogSequencerServer).SignLogRoot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/trillian.TrillianLogSequencer/SignLogRoot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TrillianLogSequencerServer).SignLogRoot(ctx, req.(*SignLogRootRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _TrillianLogSequencer_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "trillian.TrillianLogSequencer",
 	HandlerType: (*TrillianLogSequencerServer)(nil),
-	Methods:     []grpc.MethodDesc{},
-	Streams:     []grpc.StreamDesc{},
-	Metadata:    "trillian_log_sequencer_api.proto",
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReintegratePending",
+			Handler:    _TrillianLogSequencer_ReintegratePending_Handler,
+		},
+		{
+			MethodName: "ListQuarantinedLeaves",
+			Handler:    _TrillianLogSequencer_ListQuarantinedLeaves_Handler,
+		},
+		{
+			MethodName: "RequeueQuarantinedLeaves",
+			Handler:    _TrillianLogSequencer_RequeueQuarantinedLeaves_Handler,
+		},
+		{
+			MethodName: "GetSequencingStatus",
+			Handler:    _TrillianLogSequencer_GetSequencingStatus_Handler,
+		},
+		{
+			MethodName: "GetSequencerStatus",
+			Handler:    _TrillianLogSequencer_GetSequencerStatus_Handler,
+		},
+		{
+			MethodName: "ResignMastership",
+			Handler:    _TrillianLogSequencer_ResignMastership_Handler,
+		},
+		{
+			MethodName: "SignLogRoot",
+			Handler:    _TrillianLogSequencer_SignLogRoot_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "trillian_log_sequencer_api.proto",
 }
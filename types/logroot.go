@@ -58,7 +58,8 @@ type LogRoot struct {
 
 // UnmarshalBinary verifies that logRootBytes is a TLS serialized LogRoot, has
 // the LOG_ROOT_FORMAT_V1 tag, and populates the caller with the deserialized
-// *LogRootV1.
+// *LogRootV1. CBOR serialized log roots, which are told apart by their first
+// byte, are deserialized with UnmarshalCBOR.
 func (l *LogRootV1) UnmarshalBinary(logRootBytes []byte) error {
 	if len(logRootBytes) < 3 {
 		return fmt.Errorf("logRootBytes too short")
@@ -66,6 +67,9 @@ func (l *LogRootV1) UnmarshalBinary(logRootBytes []byte) error {
 	if l == nil {
 		return fmt.Errorf("nil log root")
 	}
+	if logRootBytes[0] == cborLogRootHeader {
+		return l.UnmarshalCBOR(logRootBytes)
+	}
 	version := binary.BigEndian.Uint16(logRootBytes)
 	if version != uint16(trillian.LogRootFormat_LOG_ROOT_FORMAT_V1) {
 		return fmt.Errorf("invalid LogRoot.Version: %v, want %v",
@@ -0,0 +1,224 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/google/trillian"
+)
+
+// CBOR major types used by the log root encoding.
+const (
+	cborUnsigned   = 0
+	cborByteString = 2
+	cborMap        = 5
+)
+
+// Keys of the entries of a CBOR-encoded log root, in encoding order.
+const (
+	cborKeyVersion = iota
+	cborKeyTreeSize
+	cborKeyRootHash
+	cborKeyTimestampNanos
+	cborKeyRevision
+	cborKeyMetadata
+	cborNumKeys
+)
+
+// Maximum lengths of the byte strings of a log root, as in the TLS encoding.
+const (
+	maxRootHashLen = 128
+	maxMetadataLen = 65535
+)
+
+// cborLogRootHeader is the first byte of every CBOR-encoded log root: the
+// header of a map of cborNumKeys entries.
+const cborLogRootHeader = cborMap<<5 | cborNumKeys
+
+// MarshalCBOR returns the canonical CBOR encoding of the log root, as
+// described in the documentation of SignedLogRoot.log_root: a map from keys 0
+// to 5 to the version, tree size, root hash, timestamp, revision and metadata,
+// with all entries present in order of key, and all integers and lengths in
+// their shortest form.
+func (l *LogRootV1) MarshalCBOR() ([]byte, error) {
+	if got := len(l.RootHash); got > maxRootHashLen {
+		return nil, fmt.Errorf("root hash has %d bytes, want at most %d", got, maxRootHashLen)
+	}
+	if got := len(l.Metadata); got > maxMetadataLen {
+		return nil, fmt.Errorf("metadata has %d bytes, want at most %d", got, maxMetadataLen)
+	}
+	b := make([]byte, 0, 64+len(l.RootHash)+len(l.Metadata))
+	b = appendCBORHead(b, cborMap, cborNumKeys)
+	b = appendCBORHead(b, cborUnsigned, cborKeyVersion)
+	b = appendCBORHead(b, cborUnsigned, uint64(trillian.LogRootFormat_LOG_ROOT_FORMAT_V1))
+	b = appendCBORHead(b, cborUnsigned, cborKeyTreeSize)
+	b = appendCBORHead(b, cborUnsigned, l.TreeSize)
+	b = appendCBORHead(b, cborUnsigned, cborKeyRootHash)
+	b = appendCBORHead(b, cborByteString, uint64(len(l.RootHash)))
+	b = append(b, l.RootHash...)
+	b = appendCBORHead(b, cborUnsigned, cborKeyTimestampNanos)
+	b = appendCBORHead(b, cborUnsigned, l.TimestampNanos)
+	b = appendCBORHead(b, cborUnsigned, cborKeyRevision)
+	b = appendCBORHead(b, cborUnsigned, l.Revision)
+	b = appendCBORHead(b, cborUnsigned, cborKeyMetadata)
+	b = appendCBORHead(b, cborByteString, uint64(len(l.Metadata)))
+	b = append(b, l.Metadata...)
+	return b, nil
+}
+
+// UnmarshalCBOR populates the log root from its canonical CBOR encoding, as
+// produced by MarshalCBOR. Encodings which are not canonical are rejected, so
+// that every log root has exactly one valid encoding.
+func (l *LogRootV1) UnmarshalCBOR(b []byte) error {
+	if l == nil {
+		return errors.New("nil log root")
+	}
+	d := cborDecoder{b: b}
+	if n := d.head(cborMap); d.err == nil && n != cborNumKeys {
+		return fmt.Errorf("log root map has %d entries, want %d", n, cborNumKeys)
+	}
+	var root LogRootV1
+	for key := uint64(0); key < cborNumKeys && d.err == nil; key++ {
+		if got := d.head(cborUnsigned); d.err == nil && got != key {
+			return fmt.Errorf("log root has key %d, want %d", got, key)
+		}
+		switch key {
+		case cborKeyVersion:
+			if v := d.head(cborUnsigned); d.err == nil && v != uint64(trillian.LogRootFormat_LOG_ROOT_FORMAT_V1) {
+				return fmt.Errorf("invalid LogRoot.Version: %v, want %v", v, trillian.LogRootFormat_LOG_ROOT_FORMAT_V1)
+			}
+		case cborKeyTreeSize:
+			root.TreeSize = d.head(cborUnsigned)
+		case cborKeyRootHash:
+			root.RootHash = d.bytes(maxRootHashLen)
+		case cborKeyTimestampNanos:
+			root.TimestampNanos = d.head(cborUnsigned)
+		case cborKeyRevision:
+			root.Revision = d.head(cborUnsigned)
+		case cborKeyMetadata:
+			root.Metadata = d.bytes(maxMetadataLen)
+		}
+	}
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.b) != 0 {
+		return fmt.Errorf("%d trailing bytes after log root", len(d.b))
+	}
+	*l = root
+	return nil
+}
+
+// Marshal returns the serialization of the log root in the given encoding.
+func (l *LogRootV1) Marshal(enc trillian.LogRootEncoding) ([]byte, error) {
+	switch enc {
+	case trillian.LogRootEncoding_TLS:
+		return l.MarshalBinary()
+	case trillian.LogRootEncoding_CBOR:
+		return l.MarshalCBOR()
+	}
+	return nil, fmt.Errorf("unknown log root encoding: %v", enc)
+}
+
+// appendCBORHead appends the head of a CBOR data item of the given major type
+// and argument to b, using the shortest form of the argument.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	var arg [8]byte
+	binary.BigEndian.PutUint64(arg[:], n)
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= 0xff:
+		return append(append(b, major|24), arg[7:]...)
+	case n <= 0xffff:
+		return append(append(b, major|25), arg[6:]...)
+	case n <= 0xffffffff:
+		return append(append(b, major|26), arg[4:]...)
+	}
+	return append(append(b, major|27), arg[:]...)
+}
+
+// cborDecoder reads canonical CBOR data items from b. Once an error has
+// occurred, it is kept in err and all further reads return zero values.
+type cborDecoder struct {
+	b   []byte
+	err error
+}
+
+// head reads the head of a data item of the given major type, and returns its
+// argument.
+func (d *cborDecoder) head(major byte) uint64 {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.b) == 0 {
+		d.err = errors.New("log root truncated")
+		return 0
+	}
+	if got := d.b[0] >> 5; got != major {
+		d.err = fmt.Errorf("got CBOR major type %d, want %d", got, major)
+		return 0
+	}
+	info := d.b[0] & 0x1f
+	d.b = d.b[1:]
+	var size int
+	switch {
+	case info < 24:
+		return uint64(info)
+	case info <= 27:
+		size = 1 << (info - 24)
+	default:
+		d.err = fmt.Errorf("unsupported CBOR additional information %d", info)
+		return 0
+	}
+	if len(d.b) < size {
+		d.err = errors.New("log root truncated")
+		return 0
+	}
+	var n uint64
+	for _, c := range d.b[:size] {
+		n = n<<8 | uint64(c)
+	}
+	d.b = d.b[size:]
+	if len(appendCBORHead(nil, major, n)) != 1+size {
+		d.err = fmt.Errorf("non-canonical CBOR encoding of %d", n)
+		return 0
+	}
+	return n
+}
+
+// bytes reads a byte string of at most max bytes.
+func (d *cborDecoder) bytes(max int) []byte {
+	n := d.head(cborByteString)
+	if d.err != nil {
+		return nil
+	}
+	if n > uint64(max) {
+		d.err = fmt.Errorf("byte string has %d bytes, want at most %d", n, max)
+		return nil
+	}
+	if uint64(len(d.b)) < n {
+		d.err = errors.New("log root truncated")
+		return nil
+	}
+	// Empty byte strings are non-nil, like with the TLS decoder.
+	ret := append([]byte{}, d.b[:n]...)
+	d.b = d.b[n:]
+	return ret
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/google/trillian"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("DecodeString(%q): %v", s, err)
+	}
+	return b
+}
+
+func TestLogRootCBOR(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		root    *LogRootV1
+		wantHex string
+	}{
+		{
+			desc:    "empty",
+			root:    &LogRootV1{RootHash: []byte{}, Metadata: []byte{}},
+			wantHex: "a6" + "0001" + "0100" + "0240" + "0300" + "0400" + "0540",
+		},
+		{
+			desc: "values",
+			root: &LogRootV1{
+				TreeSize:       24,
+				RootHash:       []byte("hash"),
+				TimestampNanos: 0x123456789,
+				Revision:       0xffff,
+				Metadata:       []byte{0xff},
+			},
+			wantHex: "a6" + "0001" + "011818" + "024468617368" + "031b0000000123456789" + "0419ffff" + "0541ff",
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			b, err := tc.root.MarshalCBOR()
+			if err != nil {
+				t.Fatalf("MarshalCBOR(): %v", err)
+			}
+			if want := mustDecodeHex(t, tc.wantHex); !bytes.Equal(b, want) {
+				t.Errorf("MarshalCBOR() = %x, want %x", b, want)
+			}
+			var got LogRootV1
+			if err := got.UnmarshalCBOR(b); err != nil {
+				t.Fatalf("UnmarshalCBOR(): %v", err)
+			}
+			if !reflect.DeepEqual(&got, tc.root) {
+				t.Errorf("UnmarshalCBOR() = %#v, want %#v", got, tc.root)
+			}
+			// UnmarshalBinary handles both encodings.
+			got = LogRootV1{}
+			if err := got.UnmarshalBinary(b); err != nil {
+				t.Fatalf("UnmarshalBinary(): %v", err)
+			}
+			if !reflect.DeepEqual(&got, tc.root) {
+				t.Errorf("UnmarshalBinary() = %#v, want %#v", got, tc.root)
+			}
+		})
+	}
+}
+
+func TestMarshalCBOR_TooLong(t *testing.T) {
+	for _, root := range []*LogRootV1{
+		{RootHash: make([]byte, 129)},
+		{Metadata: make([]byte, 65536)},
+	} {
+		if b, err := root.MarshalCBOR(); err == nil {
+			t.Errorf("MarshalCBOR() = %x, want error", b)
+		}
+	}
+}
+
+func TestUnmarshalCBOR_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		desc string
+		hex  string
+	}{
+		{desc: "empty", hex: ""},
+		{desc: "not a map", hex: "86" + "01" + "00" + "40" + "00" + "00" + "40"},
+		{desc: "too few entries", hex: "a5" + "0001" + "0100" + "0240" + "0300" + "0400"},
+		{desc: "too many entries", hex: "a7" + "0001" + "0100" + "0240" + "0300" + "0400" + "0540" + "0600"},
+		{desc: "unordered keys", hex: "a6" + "0001" + "0240" + "0100" + "0300" + "0400" + "0540"},
+		{desc: "wrong version", hex: "a6" + "0002" + "0100" + "0240" + "0300" + "0400" + "0540"},
+		{desc: "non-shortest integer", hex: "a6" + "0001" + "011801" + "0240" + "0300" + "0400" + "0540"},
+		{desc: "non-shortest length", hex: "a6" + "0001" + "0100" + "025800" + "0300" + "0400" + "0540"},
+		{desc: "indefinite length", hex: "a6" + "0001" + "0100" + "025fff" + "0300" + "0400" + "0540"},
+		{desc: "text string", hex: "a6" + "0001" + "0100" + "0260" + "0300" + "0400" + "0540"},
+		{desc: "tagged", hex: "a6" + "0001" + "01c200" + "0240" + "0300" + "0400" + "0540"},
+		{desc: "truncated", hex: "a6" + "0001" + "0100" + "0244686173"},
+		{desc: "trailing bytes", hex: "a6" + "0001" + "0100" + "0240" + "0300" + "0400" + "0540" + "00"},
+		{desc: "long root hash", hex: "a6" + "0001" + "0100" + "025881" + hex.EncodeToString(make([]byte, 129)) + "0300" + "0400" + "0540"},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var root LogRootV1
+			if err := root.UnmarshalCBOR(mustDecodeHex(t, tc.hex)); err == nil {
+				t.Errorf("UnmarshalCBOR() = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLogRootMarshal(t *testing.T) {
+	root := &LogRootV1{TreeSize: 3, RootHash: []byte("hash"), Metadata: []byte{}}
+	for _, enc := range []trillian.LogRootEncoding{trillian.LogRootEncoding_TLS, trillian.LogRootEncoding_CBOR} {
+		b, err := root.Marshal(enc)
+		if err != nil {
+			t.Fatalf("Marshal(%v): %v", enc, err)
+		}
+		var got LogRootV1
+		if err := got.UnmarshalBinary(b); err != nil {
+			t.Fatalf("UnmarshalBinary(Marshal(%v)): %v", enc, err)
+		}
+		if !reflect.DeepEqual(&got, root) {
+			t.Errorf("UnmarshalBinary(Marshal(%v)) = %#v, want %#v", enc, got, root)
+		}
+	}
+	if b, err := root.Marshal(trillian.LogRootEncoding(-1)); err == nil {
+		t.Errorf("Marshal(unknown) = %x, want error", b)
+	}
+}
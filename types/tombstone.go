@@ -0,0 +1,54 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// TombstonePrefix starts the leaf value of every tombstone leaf.
+const TombstonePrefix = "trillian:tombstone:"
+
+// tombstoneSize is the size of a tombstone leaf value: the prefix followed by
+// the 8-byte big-endian index of the leaf it deletes.
+const tombstoneSize = len(TombstonePrefix) + 8
+
+// NewTombstone returns the leaf value of a tombstone deleting the leaf at
+// index, for logs with leaf_tombstones.
+func NewTombstone(index int64) []byte {
+	value := make([]byte, tombstoneSize)
+	copy(value, TombstonePrefix)
+	binary.BigEndian.PutUint64(value[len(TombstonePrefix):], uint64(index))
+	return value
+}
+
+// ParseTombstone returns the index of the leaf deleted by the tombstone with
+// the given leaf value, and whether value is a tombstone at all. It returns an
+// error if value starts with TombstonePrefix but isn't a valid tombstone.
+func ParseTombstone(value []byte) (int64, bool, error) {
+	if !bytes.HasPrefix(value, []byte(TombstonePrefix)) {
+		return 0, false, nil
+	}
+	if got := len(value); got != tombstoneSize {
+		return 0, false, fmt.Errorf("tombstone has size %d, want %d", got, tombstoneSize)
+	}
+	index := int64(binary.BigEndian.Uint64(value[len(TombstonePrefix):]))
+	if index < 0 {
+		return 0, false, fmt.Errorf("tombstone has negative index %d", index)
+	}
+	return index, true, nil
+}
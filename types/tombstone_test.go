@@ -0,0 +1,52 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import "testing"
+
+func TestTombstone(t *testing.T) {
+	for _, index := range []int64{0, 1, 255, 1 << 40} {
+		got, ok, err := ParseTombstone(NewTombstone(index))
+		if err != nil || !ok || got != index {
+			t.Errorf("ParseTombstone(NewTombstone(%d)) = %d, %v, %v; want %d, true, nil", index, got, ok, err, index)
+		}
+	}
+}
+
+func TestParseTombstone(t *testing.T) {
+	for _, test := range []struct {
+		desc    string
+		value   []byte
+		wantOK  bool
+		wantErr bool
+	}{
+		{desc: "notTombstone", value: []byte("value")},
+		{desc: "empty", value: []byte{}},
+		{desc: "prefixOnly", value: []byte(TombstonePrefix), wantErr: true},
+		{desc: "long", value: append(NewTombstone(1), 0), wantErr: true},
+		{desc: "negative", value: append([]byte(TombstonePrefix), 0x80, 0, 0, 0, 0, 0, 0, 0), wantErr: true},
+		{desc: "valid", value: NewTombstone(7), wantOK: true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			_, ok, err := ParseTombstone(test.value)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Errorf("ParseTombstone(%q) = %v, want error: %v", test.value, err, test.wantErr)
+			}
+			if ok != test.wantOK {
+				t.Errorf("ParseTombstone(%q) = _, %v, want %v", test.value, ok, test.wantOK)
+			}
+		})
+	}
+}
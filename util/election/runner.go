@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -80,6 +81,11 @@ type Runner struct {
 	cfg      *RunnerConfig
 	tracker  *MasterTracker
 	election election2.Election
+
+	// resign is set while the runner is master, and receives the reasons of
+	// requests to resign early.
+	resignMu sync.Mutex
+	resign   chan string
 }
 
 // NewRunner builds a new election Runner instance with the given configuration.  On calling
@@ -138,6 +144,9 @@ func (er *Runner) beMaster(ctx context.Context, pending chan<- Resignation) erro
 
 	timer := er.cfg.TimeSource.NewTimer(er.cfg.ResignDelay())
 	defer timer.Stop()
+	resign := make(chan string, 1)
+	er.setResign(resign)
+	defer er.setResign(nil)
 
 	select {
 	case <-mctx.Done(): // Mastership context is canceled.
@@ -146,18 +155,42 @@ func (er *Runner) beMaster(ctx context.Context, pending chan<- Resignation) erro
 
 	case <-timer.Chan():
 		glog.Infof("%s: queue up resignation of mastership", er.id)
-		done := make(chan struct{})
-		r := Resignation{ID: er.id, er: er, done: done}
-		select {
-		case pending <- r:
-			<-done // Block until acted on.
-		default:
-			glog.Warning("Dropping resignation because operation manager seems to be exiting")
-		}
+	case reason := <-resign:
+		glog.Infof("%s: queue up requested resignation of mastership: %s", er.id, reason)
+	}
+	done := make(chan struct{})
+	r := Resignation{ID: er.id, er: er, done: done}
+	select {
+	case pending <- r:
+		<-done // Block until acted on.
+	default:
+		glog.Warning("Dropping resignation because operation manager seems to be exiting")
 	}
 	return nil
 }
 
+func (er *Runner) setResign(resign chan string) {
+	er.resignMu.Lock()
+	defer er.resignMu.Unlock()
+	er.resign = resign
+}
+
+// RequestResignation makes the runner resign mastership as soon as possible,
+// rather than once it has held it for its configured interval, so that
+// another election takes place. It returns false if the runner isn't master.
+func (er *Runner) RequestResignation(reason string) bool {
+	er.resignMu.Lock()
+	defer er.resignMu.Unlock()
+	if er.resign == nil {
+		return false
+	}
+	select {
+	case er.resign <- reason:
+	default: // A resignation is already requested.
+	}
+	return true
+}
+
 // Resignation indicates that a master should explicitly resign mastership, by invoking
 // the Execute() method at a point where no master-related activity is ongoing.
 type Resignation struct {
@@ -74,12 +74,16 @@ func TestElectionRunnerRun(t *testing.T) {
 		wantMaster bool
 		loseMaster bool
 		resign     bool
+		// requestResign makes the test request a resignation rather than
+		// waiting for the runner to resign.
+		requestResign bool
 	}{
 		// Basic cases.
 		{desc: "not-master"},
 		{desc: "is-master", isMaster: true, wantMaster: true},
 		{desc: "lose-master", isMaster: true, wantMaster: true, loseMaster: true},
 		{desc: "resign", isMaster: true, wantMaster: true, resign: true},
+		{desc: "request-resign", isMaster: true, wantMaster: true, requestResign: true},
 		// Error cases.
 		{desc: "err-await", errs: to.Errs{Await: errors.New("ErrAwait")}},
 		{desc: "err-mctx", errs: to.Errs{WithMastership: errors.New("ErrMastership")}},
@@ -128,6 +132,9 @@ func TestElectionRunnerRun(t *testing.T) {
 			ts.Set(start.Add(election.MinPreElectionPause))
 			time.Sleep(100 * time.Millisecond) // Now it *can* become the master.
 			checkMaster(t, tracker.Held(), tc.wantMaster)
+			if !tc.wantMaster && er.RequestResignation("test") {
+				t.Error("RequestResignation() = true, want false when not master")
+			}
 
 			if tc.loseMaster {
 				d.BlockAwait(true)
@@ -150,7 +157,20 @@ func TestElectionRunnerRun(t *testing.T) {
 				time.Sleep(100 * time.Millisecond)
 			}
 
-			checkMaster(t, tracker.Held(), tc.wantMaster && !tc.loseMaster && !tc.resign)
+			if tc.requestResign {
+				d.BlockAwait(true)
+				if !er.RequestResignation("test") {
+					t.Error("RequestResignation() = false, want true")
+				}
+				time.Sleep(100 * time.Millisecond)
+				for len(resignations) > 0 {
+					r := <-resignations
+					r.Execute(ctx)
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+
+			checkMaster(t, tracker.Held(), tc.wantMaster && !tc.loseMaster && !tc.resign && !tc.requestResign)
 			cancel()  // If Runner is still running, it should stop now.
 			wg.Wait() // Wait until it stops.
 			checkMaster(t, tracker.Held(), false)
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/namespace"
 )
 
 // NewClient returns an etcd Client connecting to the passed in servers'
@@ -47,3 +48,18 @@ func NewClientFromString(servers string) (*clientv3.Client, error) {
 	}
 	return NewClient(strings.Split(servers, ","), 5*time.Second)
 }
+
+// WithPrefix returns a client which uses the connection of client, but which
+// puts all keys, watches and leases under prefix, so that deployments using
+// different prefixes can share an etcd cluster. It returns client itself if
+// it is nil or prefix is empty. Closing the returned client closes client.
+func WithPrefix(client *clientv3.Client, prefix string) *clientv3.Client {
+	if client == nil || prefix == "" {
+		return client
+	}
+	c := clientv3.NewCtxClient(client.Ctx())
+	c.KV = namespace.NewKV(client.KV, prefix)
+	c.Watcher = namespace.NewWatcher(client.Watcher, prefix)
+	c.Lease = namespace.NewLease(client.Lease, prefix)
+	return c
+}
@@ -0,0 +1,82 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/coreos/etcd/clientv3"
+	etcdnaming "github.com/coreos/etcd/clientv3/naming"
+	"google.golang.org/grpc/naming"
+
+	etcdtest "github.com/google/trillian/testonly/integration/etcd"
+)
+
+func TestWithPrefix(t *testing.T) {
+	if got := WithPrefix(nil, "a/"); got != nil {
+		t.Errorf("WithPrefix(nil) = %v, want nil", got)
+	}
+	client := &clientv3.Client{}
+	if got := WithPrefix(client, ""); got != client {
+		t.Errorf("WithPrefix(client, \"\") = %v, want client", got)
+	}
+}
+
+func TestWithPrefix_Announcements(t *testing.T) {
+	_, client, cleanup, err := etcdtest.StartEtcd()
+	if err != nil {
+		t.Fatalf("StartEtcd(): %v", err)
+	}
+	defer cleanup()
+	ctx := context.Background()
+
+	announcer := &etcdnaming.GRPCResolver{Client: WithPrefix(client, "a/")}
+	update := naming.Update{Op: naming.Add, Addr: "host:1234"} // nolint: megacheck
+	if err := announcer.Update(ctx, "service", update); err != nil {
+		t.Fatalf("Update(): %v", err)
+	}
+
+	// The announcement is only visible under its prefix.
+	for _, tc := range []struct {
+		prefix string
+		want   int
+	}{
+		{prefix: "", want: 0},
+		{prefix: "b/", want: 0},
+		{prefix: "a/", want: 1},
+	} {
+		resp, err := WithPrefix(client, tc.prefix).Get(ctx, "service/", clientv3.WithPrefix())
+		if err != nil {
+			t.Fatalf("Get(%q): %v", tc.prefix+"service/", err)
+		}
+		if got := len(resp.Kvs); got != tc.want {
+			t.Errorf("Get(%q) returned %d keys, want %d", tc.prefix+"service/", got, tc.want)
+		}
+	}
+
+	watcher, err := (&etcdnaming.GRPCResolver{Client: WithPrefix(client, "a/")}).Resolve("service")
+	if err != nil {
+		t.Fatalf("Resolve(): %v", err)
+	}
+	defer watcher.Close()
+	updates, err := watcher.Next()
+	if err != nil {
+		t.Fatalf("Next(): %v", err)
+	}
+	if len(updates) != 1 || updates[0].Addr != update.Addr {
+		t.Errorf("Next() = %+v, want [%+v]", updates, update)
+	}
+}
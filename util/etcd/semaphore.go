@@ -0,0 +1,211 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/coreos/etcd/clientv3/concurrency"
+	"github.com/golang/glog"
+	"github.com/google/trillian/monitoring"
+)
+
+// releaseTimeout bounds the time taken to release a slot, which happens
+// independently of the context used to acquire it.
+const releaseTimeout = 10 * time.Second
+
+var (
+	semaphoreMetricsOnce sync.Once
+	semaphoreLimit       monitoring.Gauge
+	semaphoreInUse       monitoring.Gauge
+	semaphoreWaiting     monitoring.Gauge
+)
+
+func initSemaphoreMetrics(mf monitoring.MetricFactory) {
+	if mf == nil {
+		mf = monitoring.InertMetricFactory{}
+	}
+	semaphoreLimit = mf.NewGauge("etcd_semaphore_limit", "Maximum number of slots of a semaphore that can be held at once", "semaphore")
+	semaphoreInUse = mf.NewGauge("etcd_semaphore_in_use", "Number of slots of a semaphore held by all processes, as last observed", "semaphore")
+	semaphoreWaiting = mf.NewGauge("etcd_semaphore_waiting", "Number of requests for a slot of a semaphore waiting in all processes, as last observed", "semaphore")
+}
+
+// Semaphore is a counting semaphore shared by all processes using the same
+// etcd prefix, e.g. to bound the load that they collectively put on a shared
+// resource.
+//
+// Each request for a slot is an etcd key under the prefix, attached to the
+// lease of the process. Slots are granted in order of request, so at most
+// limit of the oldest keys hold a slot. Keys are removed when slots are
+// released or requests are abandoned, and when the lease expires, so the slots
+// of crashed processes are eventually freed. If the lease of a Semaphore
+// expires, e.g. because etcd was unreachable for longer than its TTL, its
+// pending requests fail, and it gets a new lease for later ones.
+type Semaphore struct {
+	client *clientv3.Client
+	prefix string
+	limit  int64
+	seq    uint64
+
+	// mu guards session and closed.
+	mu      sync.Mutex
+	session *concurrency.Session
+	closed  bool
+}
+
+// NewSemaphore returns a Semaphore with limit slots, shared with all other
+// Semaphores using prefix. All of them should use the same limit. The etcd
+// client should remain valid for the lifetime of the returned object.
+func NewSemaphore(client *clientv3.Client, prefix string, limit int, mf monitoring.MetricFactory) (*Semaphore, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("invalid limit: %d", limit)
+	}
+	semaphoreMetricsOnce.Do(func() { initSemaphoreMetrics(mf) })
+	session, err := concurrency.NewSession(client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create etcd session: %v", err)
+	}
+	prefix = strings.TrimRight(prefix, "/") + "/"
+	semaphoreLimit.Set(float64(limit), prefix)
+	return &Semaphore{client: client, session: session, prefix: prefix, limit: int64(limit)}, nil
+}
+
+// getSession returns the session of the Semaphore, replacing it if it has
+// expired.
+func (s *Semaphore) getSession() (*concurrency.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, fmt.Errorf("%s: semaphore closed", s.prefix)
+	}
+	select {
+	case <-s.session.Done():
+	default:
+		return s.session, nil
+	}
+	glog.Warningf("%s: etcd session %x expired, creating a new one", s.prefix, s.session.Lease())
+	session, err := concurrency.NewSession(s.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recreate etcd session: %v", err)
+	}
+	s.session = session
+	return session, nil
+}
+
+// Acquire blocks until a slot is held by the caller, or ctx is done. If it
+// returns no error, the returned function must be called to release the slot.
+func (s *Semaphore) Acquire(ctx context.Context) (func(), error) {
+	session, err := s.getSession()
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s%x-%d", s.prefix, session.Lease(), atomic.AddUint64(&s.seq, 1))
+	resp, err := s.client.Put(ctx, key, "", clientv3.WithLease(session.Lease()))
+	if err != nil {
+		return nil, err
+	}
+	rev := resp.Header.Revision
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+		defer cancel()
+		if _, err := s.client.Delete(ctx, key); err != nil {
+			// The key goes away with the lease anyway.
+			glog.Warningf("%s: failed to release semaphore slot: %v", key, err)
+		}
+		s.updateMetrics(ctx)
+	}
+
+	for {
+		// Check whether ours is among the oldest limit requests.
+		resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly(),
+			clientv3.WithSort(clientv3.SortByCreateRevision, clientv3.SortAscend), clientv3.WithLimit(s.limit))
+		if err != nil {
+			release()
+			return nil, err
+		}
+		// Our request is gone if the session expired.
+		select {
+		case <-session.Done():
+			return nil, fmt.Errorf("%s: etcd session %x expired", s.prefix, session.Lease())
+		default:
+		}
+		if n := len(resp.Kvs); n > 0 && resp.Kvs[n-1].CreateRevision >= rev {
+			s.updateMetrics(ctx)
+			return release, nil
+		}
+		if err := s.awaitDelete(ctx, session, resp.Header.Revision); err != nil {
+			release()
+			return nil, err
+		}
+	}
+}
+
+// awaitDelete blocks until a key under the prefix is deleted after revision
+// rev, or ctx is done. It fails if session expires, as the request waiting for
+// the deletion goes away with it.
+func (s *Semaphore) awaitDelete(ctx context.Context, session *concurrency.Session, rev int64) error {
+	wctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	wch := s.client.Watch(wctx, s.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1), clientv3.WithFilterPut())
+	for {
+		select {
+		case <-session.Done():
+			return fmt.Errorf("%s: etcd session %x expired", s.prefix, session.Lease())
+		case wresp, ok := <-wch:
+			if !ok {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				return fmt.Errorf("watch of %s closed", s.prefix)
+			}
+			if err := wresp.Err(); err != nil {
+				return err
+			}
+			if len(wresp.Events) > 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// updateMetrics exports the current usage of the semaphore.
+func (s *Semaphore) updateMetrics(ctx context.Context) {
+	resp, err := s.client.Get(ctx, s.prefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		glog.V(1).Infof("%s: failed to count semaphore requests: %v", s.prefix, err)
+		return
+	}
+	inUse := resp.Count
+	if inUse > s.limit {
+		inUse = s.limit
+	}
+	semaphoreInUse.Set(float64(inUse), s.prefix)
+	semaphoreWaiting.Set(float64(resp.Count-inUse), s.prefix)
+}
+
+// Close releases all slots held by this Semaphore. No other method should be
+// called after Close.
+func (s *Semaphore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return s.session.Close()
+}
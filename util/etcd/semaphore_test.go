@@ -0,0 +1,170 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/coreos/etcd/clientv3"
+	"github.com/google/trillian/monitoring"
+
+	etcdtest "github.com/google/trillian/testonly/integration/etcd"
+)
+
+func newSemaphore(t *testing.T, client *clientv3.Client, limit int) *Semaphore {
+	t.Helper()
+	s, err := NewSemaphore(client, "/semaphore", limit, monitoring.InertMetricFactory{})
+	if err != nil {
+		t.Fatalf("NewSemaphore(): %v", err)
+	}
+	return s
+}
+
+func mustAcquire(ctx context.Context, t *testing.T, s *Semaphore) func() {
+	t.Helper()
+	release, err := s.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire(): %v", err)
+	}
+	return release
+}
+
+// acquireAsync calls Acquire in a new goroutine, returning a channel that
+// receives its result.
+func acquireAsync(ctx context.Context, s *Semaphore) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		release, err := s.Acquire(ctx)
+		if err == nil {
+			release()
+		}
+		done <- err
+	}()
+	return done
+}
+
+func TestNewSemaphore_InvalidLimit(t *testing.T) {
+	if _, err := NewSemaphore(nil, "/semaphore", 0, nil); err == nil {
+		t.Error("NewSemaphore(limit=0) = (_, nil), want error")
+	}
+}
+
+func TestSemaphore(t *testing.T) {
+	_, client, cleanup, err := etcdtest.StartEtcd()
+	if err != nil {
+		t.Fatalf("StartEtcd(): %v", err)
+	}
+	defer cleanup()
+	ctx := context.Background()
+
+	// Two processes sharing two slots.
+	s1 := newSemaphore(t, client, 2)
+	defer s1.Close()
+	s2 := newSemaphore(t, client, 2)
+	defer s2.Close()
+
+	release1 := mustAcquire(ctx, t, s1)
+	release2 := mustAcquire(ctx, t, s2)
+
+	// A third request waits for either slot.
+	done := acquireAsync(ctx, s1)
+	select {
+	case err := <-done:
+		t.Fatalf("Acquire() = %v with all slots held, want blocked", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	release2()
+	if err := <-done; err != nil {
+		t.Fatalf("Acquire() after release: %v", err)
+	}
+
+	// Abandoned requests don't hold a slot.
+	release2 = mustAcquire(ctx, t, s2)
+	cctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if err := <-acquireAsync(cctx, s1); err == nil {
+		t.Fatal("Acquire() with all slots held and a deadline = nil, want error")
+	}
+	release1()
+	release2()
+	release1 = mustAcquire(ctx, t, s1)
+	release2 = mustAcquire(ctx, t, s2)
+	release1()
+	release2()
+}
+
+func TestSemaphore_Close(t *testing.T) {
+	_, client, cleanup, err := etcdtest.StartEtcd()
+	if err != nil {
+		t.Fatalf("StartEtcd(): %v", err)
+	}
+	defer cleanup()
+	ctx := context.Background()
+
+	s1 := newSemaphore(t, client, 1)
+	s2 := newSemaphore(t, client, 1)
+	defer s2.Close()
+
+	mustAcquire(ctx, t, s1)
+	done := acquireAsync(ctx, s2)
+	// Closing a process, e.g. as if it crashed, frees its slots.
+	if err := s1.Close(); err != nil {
+		t.Fatalf("Close(): %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Acquire() after Close(): %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Acquire() still blocked after Close()")
+	}
+}
+
+func TestSemaphore_SessionExpiry(t *testing.T) {
+	_, client, cleanup, err := etcdtest.StartEtcd()
+	if err != nil {
+		t.Fatalf("StartEtcd(): %v", err)
+	}
+	defer cleanup()
+	ctx := context.Background()
+
+	s1 := newSemaphore(t, client, 1)
+	defer s1.Close()
+	s2 := newSemaphore(t, client, 1)
+	defer s2.Close()
+
+	release1 := mustAcquire(ctx, t, s1)
+	done := acquireAsync(ctx, s2)
+	// Expiring the lease of a process, e.g. as if etcd was unreachable for
+	// longer than its TTL, fails its pending requests.
+	if _, err := client.Revoke(ctx, s2.session.Lease()); err != nil {
+		t.Fatalf("Revoke(): %v", err)
+	}
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("Acquire() after session expiry = nil, want error")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Acquire() still blocked after session expiry")
+	}
+
+	// Later requests use a new session.
+	release1()
+	mustAcquire(ctx, t, s2)()
+}
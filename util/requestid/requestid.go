@@ -0,0 +1,84 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package requestid carries the ID of the request being served in its
+// context, so that log messages and tracing spans from the servers, storage
+// and the log signer can be correlated.
+//
+// Callers can choose the ID of a request by setting the MetadataKey gRPC
+// metadata header; otherwise the server generates one. Either way, the ID is
+// sent back in the response header with the same key.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// MetadataKey is the gRPC metadata key carrying request IDs, i.e. the
+// X-Request-ID header.
+const MetadataKey = "x-request-id"
+
+// MaxLen is the maximum length of a request ID.
+const MaxLen = 128
+
+type requestIDKey struct{}
+
+// NewContext returns a ctx carrying the request ID id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request ID within ctx, or the empty string if there
+// is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// New returns a new random request ID.
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails if the OS has no source of randomness.
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Valid returns whether id is acceptable as a request ID: a non-empty string
+// of at most MaxLen printable ASCII characters, without spaces. This keeps
+// request IDs supplied by callers safe to include in log messages.
+func Valid(id string) bool {
+	if len(id) == 0 || len(id) > MaxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// LogPrefix returns a prefix for log messages about the request in ctx, which
+// is empty if it has no request ID.
+func LogPrefix(ctx context.Context) string {
+	id := FromContext(ctx)
+	if id == "" {
+		return ""
+	}
+	return "request " + id + ": "
+}
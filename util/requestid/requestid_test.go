@@ -0,0 +1,68 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != "" {
+		t.Errorf("FromContext(empty) = %q, want empty", got)
+	}
+	if got := LogPrefix(ctx); got != "" {
+		t.Errorf("LogPrefix(empty) = %q, want empty", got)
+	}
+	ctx = NewContext(ctx, "abc")
+	if got, want := FromContext(ctx), "abc"; got != want {
+		t.Errorf("FromContext() = %q, want %q", got, want)
+	}
+	if got, want := LogPrefix(ctx), "request abc: "; got != want {
+		t.Errorf("LogPrefix() = %q, want %q", got, want)
+	}
+}
+
+func TestNew(t *testing.T) {
+	id1, id2 := New(), New()
+	if !Valid(id1) {
+		t.Errorf("New() = %q, which is not Valid", id1)
+	}
+	if id1 == id2 {
+		t.Errorf("New() returned %q twice", id1)
+	}
+}
+
+func TestValid(t *testing.T) {
+	for _, tc := range []struct {
+		id   string
+		want bool
+	}{
+		{id: "0123456789abcdef", want: true},
+		{id: "req-1/2_3.4~", want: true},
+		{id: strings.Repeat("a", MaxLen), want: true},
+		{id: ""},
+		{id: strings.Repeat("a", MaxLen+1)},
+		{id: "with space"},
+		{id: "new\nline"},
+		{id: "café"},
+	} {
+		if got := Valid(tc.id); got != tc.want {
+			t.Errorf("Valid(%q) = %v, want %v", tc.id, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package validation provides the extension point through which applications
+// can reject malformed leaves before they are queued to a log.
+package validation
+
+import (
+	"context"
+
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LeafValidator checks the leaves queued to a log.
+type LeafValidator interface {
+	// ValidateLeaf returns an error if leaf must not be queued to tree. Errors
+	// created by the status package are reported to the caller as they are,
+	// others with code InvalidArgument. The hashes of the leaf are set when
+	// it's called, and the leaf must not be modified.
+	ValidateLeaf(ctx context.Context, tree *trillian.Tree, leaf *trillian.LogLeaf) error
+}
+
+// LeafValidatorFunc is a function that implements LeafValidator.
+type LeafValidatorFunc func(ctx context.Context, tree *trillian.Tree, leaf *trillian.LogLeaf) error
+
+// ValidateLeaf implements LeafValidator.
+func (f LeafValidatorFunc) ValidateLeaf(ctx context.Context, tree *trillian.Tree, leaf *trillian.LogLeaf) error {
+	return f(ctx, tree, leaf)
+}
+
+type noopValidator struct{}
+
+// Noop returns a LeafValidator that accepts all leaves.
+func Noop() LeafValidator {
+	return noopValidator{}
+}
+
+func (noopValidator) ValidateLeaf(context.Context, *trillian.Tree, *trillian.LogLeaf) error {
+	return nil
+}
+
+type allOrNothing struct {
+	LeafValidator
+}
+
+// AllOrNothing returns a LeafValidator that rejects the same leaves as v, but
+// for which a single rejected leaf causes its whole batch to be rejected,
+// rather than just that leaf.
+func AllOrNothing(v LeafValidator) LeafValidator {
+	return allOrNothing{v}
+}
+
+// ValidateLeaves validates each of the leaves with v. It returns the status of
+// each rejected leaf, indexed like leaves and nil for accepted leaves, or an
+// error if the whole batch is rejected. A nil v accepts all leaves.
+func ValidateLeaves(ctx context.Context, v LeafValidator, tree *trillian.Tree, leaves []*trillian.LogLeaf) ([]*status.Status, error) {
+	if v == nil {
+		return make([]*status.Status, len(leaves)), nil
+	}
+	_, failBatch := v.(allOrNothing)
+	rejected := make([]*status.Status, len(leaves))
+	for i, leaf := range leaves {
+		err := v.ValidateLeaf(ctx, tree, leaf)
+		if err == nil {
+			continue
+		}
+		s, ok := status.FromError(err)
+		if !ok {
+			s = status.New(codes.InvalidArgument, err.Error())
+		}
+		if failBatch {
+			return nil, status.Errorf(s.Code(), "leaves[%d] rejected: %s", i, s.Message())
+		}
+		rejected[i] = s
+	}
+	return rejected, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2020 Google LLC. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package validation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/trillian"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// jsonValidator accepts leaves with a JSON value, and rejects empty ones with
+// FailedPrecondition.
+var jsonValidator = LeafValidatorFunc(func(_ context.Context, _ *trillian.Tree, leaf *trillian.LogLeaf) error {
+	if len(leaf.LeafValue) == 0 {
+		return status.Error(codes.FailedPrecondition, "empty leaf")
+	}
+	if !json.Valid(leaf.LeafValue) {
+		return errors.New("not JSON")
+	}
+	return nil
+})
+
+func TestValidateLeaves(t *testing.T) {
+	leaves := []*trillian.LogLeaf{
+		{LeafValue: []byte(`{"a": 1}`)},
+		{LeafValue: []byte(`{"a": `)},
+		{},
+	}
+	for _, test := range []struct {
+		desc      string
+		v         LeafValidator
+		wantCodes []codes.Code
+		wantCode  codes.Code
+	}{
+		{desc: "nil", wantCodes: []codes.Code{codes.OK, codes.OK, codes.OK}},
+		{desc: "noop", v: Noop(), wantCodes: []codes.Code{codes.OK, codes.OK, codes.OK}},
+		{desc: "json", v: jsonValidator, wantCodes: []codes.Code{codes.OK, codes.InvalidArgument, codes.FailedPrecondition}},
+		{desc: "allOrNothing", v: AllOrNothing(jsonValidator), wantCode: codes.InvalidArgument},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			rejected, err := ValidateLeaves(context.Background(), test.v, &trillian.Tree{}, leaves)
+			if got := status.Code(err); got != test.wantCode {
+				t.Fatalf("ValidateLeaves() = (_, %v), want code %v", err, test.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if got, want := len(rejected), len(leaves); got != want {
+				t.Fatalf("ValidateLeaves() returned %d statuses, want %d", got, want)
+			}
+			for i, s := range rejected {
+				if got, want := s.Code(), test.wantCodes[i]; got != want {
+					t.Errorf("ValidateLeaves()[%d] = %v, want code %v", i, s, want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateLeaves_AllOrNothingAccepts(t *testing.T) {
+	leaves := []*trillian.LogLeaf{{LeafValue: []byte(`1`)}, {LeafValue: []byte(`"b"`)}}
+	rejected, err := ValidateLeaves(context.Background(), AllOrNothing(jsonValidator), &trillian.Tree{}, leaves)
+	if err != nil {
+		t.Fatalf("ValidateLeaves() = (_, %v), want nil", err)
+	}
+	for i, s := range rejected {
+		if s != nil {
+			t.Errorf("ValidateLeaves()[%d] = %v, want nil", i, s)
+		}
+	}
+}